@@ -30,7 +30,7 @@ func main() {
 	var (
 		configFile   = flag.String("config", "agent.yaml", "配置文件路径")
 		showVersion  = flag.Bool("version", false, "显示版本信息")
-		logLevel     = flag.String("log-level", "info", "日志级别 (debug, info, warn, error)")
+		logLevel     = flag.String("log-level", "", "日志级别 (debug, info, warn, error)，覆盖配置文件中的 logging.level")
 		agentID      = flag.String("agent-id", "", "Agent ID (覆盖配置文件中的设置)")
 		serverURL    = flag.String("server", "", "服务器地址 (覆盖配置文件中的设置)")
 	)
@@ -45,12 +45,22 @@ func main() {
 		os.Exit(0)
 	}
 
+	// 加载配置
+	cfg, err := loadConfig(*configFile, *agentID, *serverURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "加载配置失败: %v\n", err)
+		os.Exit(1)
+	}
+	if *logLevel != "" {
+		cfg.Logging.Level = *logLevel
+	}
+
 	// 初始化日志
-	log := logger.New(map[string]interface{}{
-		"level":  *logLevel,
-		"format": "text",
-		"output": "stdout",
-	})
+	log, err := logger.Setup(cfg.Logging)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "初始化日志失败: %v\n", err)
+		os.Exit(1)
+	}
 
 	log.WithFields(logrus.Fields{
 		"version":    version,
@@ -58,19 +68,13 @@ func main() {
 		"git_commit": gitCommit,
 	}).Info("Logstash Agent 启动中...")
 
-	// 加载配置
-	cfg, err := loadConfig(*configFile, *agentID, *serverURL)
-	if err != nil {
-		log.WithError(err).Fatal("加载配置失败")
-	}
-
 	// 验证配置
 	if err := cfg.Validate(); err != nil {
 		log.WithError(err).Fatal("配置验证失败")
 	}
 
 	// 创建Agent实例
-	agent, err := createAgent(cfg, log)
+	agent, outbox, err := createAgent(cfg, log)
 	if err != nil {
 		log.WithError(err).Fatal("创建Agent失败")
 	}
@@ -88,6 +92,9 @@ func main() {
 		log.WithError(err).Fatal("启动Agent失败")
 	}
 
+	// 补发平台不可达期间暂存的上报
+	go outbox.Start(ctx)
+
 	log.Info("Agent启动成功，等待信号...")
 
 	// 等待退出信号
@@ -151,34 +158,42 @@ func loadConfig(configFile, agentID, serverURL string) (*config.AgentConfig, err
 	return cfg, nil
 }
 
-// createAgent 创建完整的Agent实例
-func createAgent(cfg *config.AgentConfig, logger *logrus.Logger) (*core.Agent, error) {
+// createAgent 创建完整的Agent实例及其上报暂存队列，各组件使用 logging.modules 中对应模块的日志级别
+func createAgent(cfg *config.AgentConfig, log *logrus.Logger) (*core.Agent, *services.Outbox, error) {
 	// 创建基本Agent
-	agent, err := core.NewAgent(cfg, logger)
+	agent, err := core.NewAgent(cfg, log)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// 创建API客户端
-	apiClient, err := client.NewClient(cfg, logger)
+	apiClient, err := client.NewClient(cfg, logger.Module(log, "client"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 平台不可达时暂存配置应用结果、状态和指标，恢复连接后补发
+	outbox, err := services.NewOutbox(cfg.GetOutboxDir(), logger.Module(log, "outbox"))
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	apiClient.SetOutbox(outbox)
 
 	// 创建配置管理器
-	configMgr, err := config.NewManager(cfg, logger)
+	configMgr, err := config.NewManager(cfg, logger.Module(log, "config"))
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// 创建Logstash控制器
-	logstashCtrl := logstash.NewController(cfg, logger)
+	logstashCtrl := logstash.NewController(cfg, logger.Module(log, "logstash"))
 
 	// 创建心跳服务
-	heartbeat := services.NewHeartbeatService(cfg.AgentID, apiClient, logger)
+	heartbeat := services.NewHeartbeatService(cfg.AgentID, apiClient, logger.Module(log, "heartbeat"))
 
 	// 创建指标收集器
-	metrics := services.NewMetricsCollector(cfg.AgentID, apiClient, logstashCtrl, logger)
+	metrics := services.NewMetricsCollector(cfg.AgentID, apiClient, logstashCtrl, logger.Module(log, "metrics"))
+	metrics.SetLogstashAPIURL(cfg.LogstashAPIURL)
 
 	// 组装Agent
 	agent.
@@ -186,7 +201,8 @@ func createAgent(cfg *config.AgentConfig, logger *logrus.Logger) (*core.Agent, e
 		WithConfigManager(configMgr).
 		WithLogstashController(logstashCtrl).
 		WithHeartbeatService(heartbeat).
-		WithMetricsCollector(metrics)
+		WithMetricsCollector(metrics).
+		WithResourceSampler(services.SampleResources)
 
-	return agent, nil
+	return agent, outbox, nil
 }
\ No newline at end of file
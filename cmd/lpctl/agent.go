@@ -0,0 +1,134 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"os"
+
+	"logstash-platform/internal/platform/models"
+)
+
+// agentConfigList Agent运行的配置列表响应
+type agentConfigList struct {
+	AgentID string                    `json:"agent_id"`
+	Items   []models.AgentConfigUsage `json:"items"`
+	Total   int                       `json:"total"`
+}
+
+// agentStatus agent status 的输出：Agent信息及其运行的配置
+type agentStatus struct {
+	*models.Agent
+	Configs []models.AgentConfigUsage `json:"configs"`
+}
+
+// runAgent 查看Agent：list、status
+func runAgent(args []string) error {
+	if len(args) == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "list":
+		return runAgentList(args[1:])
+	case "status":
+		return runAgentStatus(args[1:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+	return nil
+}
+
+// runAgentList 列出全部Agent及其存活状态
+func runAgentList(args []string) error {
+	var common commonFlags
+	fs := flag.NewFlagSet("agent list", flag.ExitOnError)
+	common.register(fs)
+	parseArgs(fs, args)
+
+	client, err := common.client()
+	if err != nil {
+		return err
+	}
+	agents, err := listAgents(client)
+	if err != nil {
+		return err
+	}
+
+	return render(common.output, agents, func(w io.Writer) {
+		fmt.Fprintln(w, "AGENT_ID\tHOSTNAME\tIP\tLOGSTASH\tSTATUS\tLAST_HEARTBEAT\tCONFIGS")
+		for _, agent := range agents.Items {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%d\n", agent.AgentID, agent.Hostname, agent.IP,
+				agent.LogstashVersion, agent.Status, formatTime(agent.LastHeartbeat), len(agent.AppliedConfigs))
+		}
+		fmt.Fprintf(w, "\n共 %d 个Agent\n", agents.Total)
+	})
+}
+
+// runAgentStatus 查看单个Agent的状态和运行的配置版本
+func runAgentStatus(args []string) error {
+	var common commonFlags
+	fs := flag.NewFlagSet("agent status", flag.ExitOnError)
+	common.register(fs)
+	positional := parseArgs(fs, args)
+
+	if len(positional) != 1 {
+		return fmt.Errorf("必须指定一个Agent ID")
+	}
+	client, err := common.client()
+	if err != nil {
+		return err
+	}
+
+	// 存活状态由列表接口按最近心跳推导
+	agents, err := listAgents(client)
+	if err != nil {
+		return err
+	}
+	status := &agentStatus{}
+	for _, agent := range agents.Items {
+		if agent.AgentID == positional[0] {
+			status.Agent = agent
+			break
+		}
+	}
+	if status.Agent == nil {
+		return fmt.Errorf("Agent不存在: %s", positional[0])
+	}
+
+	var configs agentConfigList
+	if err := client.do(http.MethodGet, "/agents/"+neturl.PathEscape(positional[0])+"/configs", nil, nil, &configs); err != nil {
+		return err
+	}
+	status.Configs = configs.Items
+
+	return render(common.output, status, func(w io.Writer) {
+		fmt.Fprintf(w, "Agent: %s\n", status.AgentID)
+		fmt.Fprintf(w, "主机: %s (%s)\n", status.Hostname, status.IP)
+		fmt.Fprintf(w, "Logstash: %s\n", status.LogstashVersion)
+		fmt.Fprintf(w, "状态: %s\n", status.Status)
+		fmt.Fprintf(w, "最近心跳: %s\n", formatTime(status.LastHeartbeat))
+		if status.Group != "" {
+			fmt.Fprintf(w, "分组: %s\n", status.Group)
+		}
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "CONFIG_ID\tNAME\tVERSION\tCURRENT\tOUTDATED\tAPPLIED")
+		for _, config := range status.Configs {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%t\t%s\n", config.ConfigID, config.ConfigName, config.Version,
+				config.CurrentVersion, config.Outdated, formatTime(config.AppliedAt))
+		}
+	})
+}
+
+// listAgents 获取请求项目内的全部Agent
+func listAgents(client *apiClient) (*models.AgentListResponse, error) {
+	var resp models.AgentListResponse
+	if err := client.do(http.MethodGet, "/agents", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
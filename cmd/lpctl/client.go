@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"logstash-platform/internal/platform/api/middleware"
+)
+
+// 输出格式
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// commonFlags 资源子命令共用的平台地址、认证令牌和输出格式参数
+type commonFlags struct {
+	server string
+	token  string
+	output string
+}
+
+func (f *commonFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.server, "server", envOrDefault("LPCTL_SERVER", "http://localhost:8080"), "管理平台地址")
+	fs.StringVar(&f.token, "token", os.Getenv("LPCTL_TOKEN"), "认证令牌")
+	fs.StringVar(&f.output, "output", envOrDefault("LPCTL_OUTPUT", outputTable), "输出格式: json 或 table")
+}
+
+// client 校验输出格式并创建平台API客户端
+func (f *commonFlags) client() (*apiClient, error) {
+	if f.output != outputTable && f.output != outputJSON {
+		return nil, fmt.Errorf("不支持的输出格式: %s（可选 json、table）", f.output)
+	}
+	return &apiClient{
+		server: strings.TrimRight(f.server, "/"),
+		token:  f.token,
+		http:   &http.Client{Timeout: 60 * time.Second},
+	}, nil
+}
+
+// apiClient 管理平台REST API客户端
+type apiClient struct {
+	server string
+	token  string
+	http   *http.Client
+}
+
+// do 发送请求并把响应解析到out中，非2xx响应按平台的错误响应格式返回错误
+func (c *apiClient) do(method, path string, query neturl.Values, body, out interface{}) error {
+	url := c.server + "/api/v1" + path
+	if len(query) > 0 {
+		url += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求平台失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr middleware.ErrorResponse
+		if err := json.Unmarshal(data, &apiErr); err == nil && apiErr.Code != "" {
+			message := apiErr.Message
+			// 请求校验失败时逐字段列出原因
+			for _, field := range apiErr.Errors {
+				message += "\n  " + field.Message
+			}
+			return fmt.Errorf("平台返回错误: %s - %s: %s", resp.Status, apiErr.Code, message)
+		}
+		return fmt.Errorf("平台返回错误: %s - %s", resp.Status, string(data))
+	}
+
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+	return nil
+}
+
+// render 按输出格式打印结果，table格式由printTable写入对齐的列
+func render(output string, v interface{}, printTable func(w io.Writer)) error {
+	if output == outputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	printTable(w)
+	return w.Flush()
+}
+
+// parseArgs 解析参数，允许位置参数出现在选项之前或之后，返回位置参数
+func parseArgs(fs *flag.FlagSet, args []string) []string {
+	fs.Parse(args)
+	var positional []string
+	for fs.NArg() > 0 {
+		positional = append(positional, fs.Arg(0))
+		fs.Parse(fs.Args()[1:])
+	}
+	return positional
+}
+
+// splitList 解析逗号分隔的列表，忽略空项
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// formatTime 以本地时间输出，零值输出为-
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
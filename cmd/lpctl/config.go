@@ -0,0 +1,285 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"logstash-platform/internal/platform/models"
+)
+
+// runConfig 管理配置：list、get、create、update、rollback
+func runConfig(args []string) error {
+	if len(args) == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "list":
+		return runConfigList(args[1:])
+	case "get":
+		return runConfigGet(args[1:])
+	case "create":
+		return runConfigCreate(args[1:])
+	case "update":
+		return runConfigUpdate(args[1:])
+	case "rollback":
+		return runConfigRollback(args[1:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+	return nil
+}
+
+// runConfigList 按条件列出配置
+func runConfigList(args []string) error {
+	var common commonFlags
+	fs := flag.NewFlagSet("config list", flag.ExitOnError)
+	common.register(fs)
+	configType := fs.String("type", "", "配置类型: input、filter 或 output")
+	tags := fs.String("tags", "", "标签，逗号分隔")
+	enabled := fs.String("enabled", "", "只列出启用（true）或禁用（false）的配置")
+	page := fs.Int("page", 1, "页码")
+	size := fs.Int("size", 50, "每页数量")
+	parseArgs(fs, args)
+
+	client, err := common.client()
+	if err != nil {
+		return err
+	}
+
+	query := neturl.Values{}
+	query.Set("page", strconv.Itoa(*page))
+	query.Set("size", strconv.Itoa(*size))
+	if *configType != "" {
+		query.Set("type", *configType)
+	}
+	for _, tag := range splitList(*tags) {
+		query.Add("tags", tag)
+	}
+	if *enabled != "" {
+		query.Set("enabled", *enabled)
+	}
+
+	var resp models.ConfigListResponse
+	if err := client.do(http.MethodGet, "/configs", query, nil, &resp); err != nil {
+		return err
+	}
+
+	return render(common.output, &resp, func(w io.Writer) {
+		fmt.Fprintln(w, "ID\tNAME\tTYPE\tVERSION\tENABLED\tTEST\tUPDATED")
+		for _, config := range resp.Items {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%t\t%s\t%s\n", config.ID, config.Name, config.Type,
+				config.Version, config.Enabled, config.TestStatus, formatTime(config.UpdatedAt))
+		}
+		fmt.Fprintf(w, "\n共 %d 个配置，第 %d 页\n", resp.Total, resp.Page)
+	})
+}
+
+// runConfigGet 获取单个配置，可指定历史版本
+func runConfigGet(args []string) error {
+	var common commonFlags
+	fs := flag.NewFlagSet("config get", flag.ExitOnError)
+	common.register(fs)
+	version := fs.Int("version", 0, "历史版本号，默认当前版本")
+	positional := parseArgs(fs, args)
+
+	if len(positional) != 1 {
+		return fmt.Errorf("必须指定一个配置ID")
+	}
+	client, err := common.client()
+	if err != nil {
+		return err
+	}
+
+	query := neturl.Values{}
+	if *version > 0 {
+		query.Set("version", strconv.Itoa(*version))
+	}
+	var config models.Config
+	if err := client.do(http.MethodGet, "/configs/"+neturl.PathEscape(positional[0]), query, nil, &config); err != nil {
+		return err
+	}
+
+	if err := render(common.output, &config, func(w io.Writer) {
+		printConfig(w, &config)
+	}); err != nil {
+		return err
+	}
+	// 配置内容中可能有制表符，不经过列对齐直接输出
+	if common.output == outputTable {
+		fmt.Printf("\n%s\n", config.Content)
+	}
+	return nil
+}
+
+// runConfigCreate 从文件创建配置
+func runConfigCreate(args []string) error {
+	var common commonFlags
+	fs := flag.NewFlagSet("config create", flag.ExitOnError)
+	common.register(fs)
+	name := fs.String("name", "", "配置名称")
+	configType := fs.String("type", "", "配置类型: input、filter 或 output")
+	file := fs.String("f", "", "Logstash配置内容文件")
+	description := fs.String("description", "", "配置描述")
+	tags := fs.String("tags", "", "标签，逗号分隔")
+	team := fs.String("team", "", "负责团队")
+	parseArgs(fs, args)
+
+	if *name == "" || *configType == "" || *file == "" {
+		return fmt.Errorf("必须指定 --name、--type 和 -f")
+	}
+	content, err := os.ReadFile(*file)
+	if err != nil {
+		return fmt.Errorf("读取文件失败: %w", err)
+	}
+	client, err := common.client()
+	if err != nil {
+		return err
+	}
+
+	req := &models.CreateConfigRequest{
+		Name:        *name,
+		Description: *description,
+		Type:        models.ConfigType(*configType),
+		Content:     string(content),
+		Tags:        splitList(*tags),
+		Team:        *team,
+	}
+	var config models.Config
+	if err := client.do(http.MethodPost, "/configs", nil, req, &config); err != nil {
+		return err
+	}
+
+	return render(common.output, &config, func(w io.Writer) {
+		fmt.Fprintf(w, "已创建配置 %s（%s），版本 %d\n", config.ID, config.Name, config.Version)
+	})
+}
+
+// runConfigUpdate 更新配置，未指定的字段保持不变
+func runConfigUpdate(args []string) error {
+	var common commonFlags
+	fs := flag.NewFlagSet("config update", flag.ExitOnError)
+	common.register(fs)
+	name := fs.String("name", "", "配置名称")
+	file := fs.String("f", "", "Logstash配置内容文件")
+	description := fs.String("description", "", "配置描述")
+	tags := fs.String("tags", "", "标签，逗号分隔，替换原有标签")
+	enabled := fs.String("enabled", "", "启用（true）或禁用（false）配置")
+	positional := parseArgs(fs, args)
+
+	if len(positional) != 1 {
+		return fmt.Errorf("必须指定一个配置ID")
+	}
+	client, err := common.client()
+	if err != nil {
+		return err
+	}
+
+	// 更新接口需要完整的配置，先取当前版本再覆盖指定的字段
+	path := "/configs/" + neturl.PathEscape(positional[0])
+	var current models.Config
+	if err := client.do(http.MethodGet, path, nil, nil, &current); err != nil {
+		return err
+	}
+
+	req := &models.UpdateConfigRequest{
+		Name:             current.Name,
+		Description:      current.Description,
+		Type:             current.Type,
+		Content:          current.Content,
+		Tags:             current.Tags,
+		Destinations:     current.Destinations,
+		Team:             current.Team,
+		Reviewers:        current.Reviewers,
+		PipelineSettings: current.PipelineSettings,
+	}
+	flagSet := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { flagSet[f.Name] = true })
+	if flagSet["name"] {
+		req.Name = *name
+	}
+	if flagSet["description"] {
+		req.Description = *description
+	}
+	if flagSet["tags"] {
+		req.Tags = splitList(*tags)
+	}
+	if flagSet["f"] {
+		content, err := os.ReadFile(*file)
+		if err != nil {
+			return fmt.Errorf("读取文件失败: %w", err)
+		}
+		req.Content = string(content)
+	}
+	if flagSet["enabled"] {
+		value, err := strconv.ParseBool(*enabled)
+		if err != nil {
+			return fmt.Errorf("--enabled 必须为 true 或 false")
+		}
+		req.Enabled = &value
+	}
+
+	var config models.Config
+	if err := client.do(http.MethodPut, path, nil, req, &config); err != nil {
+		return err
+	}
+
+	return render(common.output, &config, func(w io.Writer) {
+		fmt.Fprintf(w, "已更新配置 %s（%s），版本 %d -> %d\n", config.ID, config.Name, current.Version, config.Version)
+	})
+}
+
+// runConfigRollback 把配置回滚到历史版本
+func runConfigRollback(args []string) error {
+	var common commonFlags
+	fs := flag.NewFlagSet("config rollback", flag.ExitOnError)
+	common.register(fs)
+	version := fs.Int("version", 0, "回滚到的版本号")
+	positional := parseArgs(fs, args)
+
+	if len(positional) != 1 {
+		return fmt.Errorf("必须指定一个配置ID")
+	}
+	if *version < 1 {
+		return fmt.Errorf("必须通过 --version 指定回滚到的版本")
+	}
+	client, err := common.client()
+	if err != nil {
+		return err
+	}
+
+	req := map[string]int{"version": *version}
+	var config models.Config
+	if err := client.do(http.MethodPost, "/configs/"+neturl.PathEscape(positional[0])+"/rollback", nil, req, &config); err != nil {
+		return err
+	}
+
+	return render(common.output, &config, func(w io.Writer) {
+		fmt.Fprintf(w, "已将配置 %s 回滚到版本 %d 的内容，当前版本 %d\n", config.ID, *version, config.Version)
+	})
+}
+
+// printConfig 以键值形式输出配置概要
+func printConfig(w io.Writer, config *models.Config) {
+	fmt.Fprintf(w, "ID: %s\n", config.ID)
+	fmt.Fprintf(w, "名称: %s\n", config.Name)
+	fmt.Fprintf(w, "类型: %s\n", config.Type)
+	fmt.Fprintf(w, "版本: %d\n", config.Version)
+	fmt.Fprintf(w, "启用: %t\n", config.Enabled)
+	fmt.Fprintf(w, "测试状态: %s\n", config.TestStatus)
+	if len(config.Tags) > 0 {
+		fmt.Fprintf(w, "标签: %s\n", strings.Join(config.Tags, ","))
+	}
+	if config.Description != "" {
+		fmt.Fprintf(w, "描述: %s\n", config.Description)
+	}
+	fmt.Fprintf(w, "更新: %s by %s\n", formatTime(config.UpdatedAt), config.UpdatedBy)
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"strings"
+	"time"
+
+	"logstash-platform/internal/platform/models"
+)
+
+// pollInterval 等待部署或测试结束时的轮询间隔
+const pollInterval = 2 * time.Second
+
+// runDeploy 创建部署，默认等待部署结束，部署失败或回滚时返回错误
+func runDeploy(args []string) error {
+	var common commonFlags
+	fs := flag.NewFlagSet("deploy", flag.ExitOnError)
+	common.register(fs)
+	configID := fs.String("config", "", "要部署的配置ID")
+	agents := fs.String("agents", "", "目标Agent ID，逗号分隔")
+	selector := fs.String("selector", "", "目标Agent的标签选择器，如 env=prod,region=cn")
+	strategy := fs.String("strategy", "", "部署策略: all（默认）或 canary")
+	canaryPercent := fs.Int("canary-percent", 0, "canary策略下金丝雀Agent的百分比")
+	batchSize := fs.Int("batch-size", 0, "分批下发时每批的Agent数")
+	batchPercent := fs.Int("batch-percent", 0, "分批下发时每批Agent占目标数的百分比")
+	waitHealthy := fs.Bool("wait-healthy", false, "分批下发时等待一批Agent恢复健康再下发下一批")
+	maxFailurePercent := fs.Int("max-failure-percent", 0, "分批下发时允许失败的Agent百分比，超过时中止")
+	skipTestGate := fs.Bool("skip-test-gate", false, "跳过测试门禁，需要平台配置的提升角色")
+	wait := fs.Bool("wait", true, "等待部署结束")
+	timeout := fs.Duration("timeout", 10*time.Minute, "等待部署结束的最长时间")
+	parseArgs(fs, args)
+
+	if *configID == "" {
+		return fmt.Errorf("必须通过 --config 指定配置ID")
+	}
+	client, err := common.client()
+	if err != nil {
+		return err
+	}
+
+	req := &models.CreateDeploymentRequest{
+		ConfigID:     *configID,
+		AgentIDs:     splitList(*agents),
+		Selector:     *selector,
+		Strategy:     *strategy,
+		SkipTestGate: *skipTestGate,
+	}
+	if *canaryPercent > 0 {
+		req.Canary = &models.CanaryOptions{Percent: *canaryPercent}
+	}
+	if *batchSize > 0 || *batchPercent > 0 {
+		req.Rollout = &models.RolloutOptions{
+			BatchSize:         *batchSize,
+			BatchPercent:      *batchPercent,
+			WaitForHealthy:    *waitHealthy,
+			MaxFailurePercent: *maxFailurePercent,
+		}
+	}
+	var deployment models.Deployment
+	if err := client.do(http.MethodPost, "/deployments", nil, req, &deployment); err != nil {
+		return err
+	}
+	if common.output == outputTable {
+		fmt.Printf("已创建部署 %s: %s 版本 %d -> %d 个Agent\n",
+			deployment.ID, deployment.ConfigName, deployment.ConfigVersion, len(deployment.AgentIDs))
+	}
+
+	if *wait {
+		deadline := time.Now().Add(*timeout)
+		for !deployment.IsFinished() {
+			if time.Now().After(deadline) {
+				return fmt.Errorf("等待部署 %s 超时，当前状态: %s", deployment.ID, deployment.Status)
+			}
+			time.Sleep(pollInterval)
+			if err := client.do(http.MethodGet, "/deployments/"+neturl.PathEscape(deployment.ID), nil, nil, &deployment); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := render(common.output, &deployment, func(w io.Writer) {
+		fmt.Fprintln(w, "AGENT_ID\tSTATUS\tMESSAGE")
+		for _, result := range deployment.Results {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", result.AgentID, result.Status, result.Message)
+		}
+		fmt.Fprintf(w, "\n部署状态: %s\n", deployment.Status)
+	}); err != nil {
+		return err
+	}
+
+	switch deployment.Status {
+	case models.DeploymentStatusFailed, models.DeploymentStatusRolledBack:
+		return fmt.Errorf("部署 %s 未成功: %s", deployment.ID, deployment.Status)
+	}
+	return nil
+}
+
+// runTest 管理样本测试：run
+func runTest(args []string) error {
+	if len(args) == 0 || args[0] != "run" {
+		usage()
+		os.Exit(2)
+	}
+	return runTestRun(args[1:])
+}
+
+// runTestRun 用样本文件测试配置并等待结果，测试失败或有样本未通过断言时返回错误
+func runTestRun(args []string) error {
+	var common commonFlags
+	fs := flag.NewFlagSet("test run", flag.ExitOnError)
+	common.register(fs)
+	configID := fs.String("config", "", "要测试的配置ID")
+	samplesFile := fs.String("f", "", "样本文件，每行一条样本")
+	assertionsFile := fs.String("assertions", "", "断言文件（JSON数组）")
+	timeout := fs.Duration("timeout", 5*time.Minute, "等待测试结束的最长时间")
+	parseArgs(fs, args)
+
+	if *configID == "" || *samplesFile == "" {
+		return fmt.Errorf("必须指定 --config 和 -f")
+	}
+	samples, err := readSamples(*samplesFile)
+	if err != nil {
+		return err
+	}
+	var assertions []models.TestAssertion
+	if *assertionsFile != "" {
+		data, err := os.ReadFile(*assertionsFile)
+		if err != nil {
+			return fmt.Errorf("读取断言文件失败: %w", err)
+		}
+		if err := json.Unmarshal(data, &assertions); err != nil {
+			return fmt.Errorf("解析断言文件失败: %w", err)
+		}
+	}
+	client, err := common.client()
+	if err != nil {
+		return err
+	}
+
+	req := &models.TestConfigRequest{
+		ConfigID: *configID,
+		TestData: models.TestData{Type: "sample", Samples: samples, Assertions: assertions},
+	}
+	var created struct {
+		TestID string `json:"test_id"`
+	}
+	if err := client.do(http.MethodPost, "/test", nil, req, &created); err != nil {
+		return err
+	}
+
+	var result models.TestResult
+	deadline := time.Now().Add(*timeout)
+	for {
+		if err := client.do(http.MethodGet, "/test/"+neturl.PathEscape(created.TestID)+"/result", nil, nil, &result); err != nil {
+			return err
+		}
+		if result.Status != "running" {
+			break
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("等待测试 %s 超时", created.TestID)
+		}
+		time.Sleep(pollInterval)
+	}
+
+	if err := render(common.output, &result, func(w io.Writer) {
+		fmt.Fprintln(w, "SAMPLE\tRESULT\tDETAIL")
+		for i, output := range result.Results {
+			fmt.Fprintf(w, "%d\t%s\t%s\n", i, sampleResult(output), sampleDetail(output))
+		}
+		fmt.Fprintf(w, "\n测试 %s: %s，%d 条样本，%d 条输出\n", result.TestID, result.Status, result.InputCount, result.OutputCount)
+		for _, message := range result.Errors {
+			fmt.Fprintf(w, "错误: %s\n", message)
+		}
+	}); err != nil {
+		return err
+	}
+
+	if result.Status != "completed" {
+		return fmt.Errorf("测试 %s 未通过: %s", result.TestID, result.Status)
+	}
+	return nil
+}
+
+// readSamples 读取样本文件，每个非空行为一条样本
+func readSamples(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取样本文件失败: %w", err)
+	}
+	defer file.Close()
+
+	var samples []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.TrimSpace(line) != "" {
+			samples = append(samples, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取样本文件失败: %w", err)
+	}
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("样本文件为空: %s", path)
+	}
+	return samples, nil
+}
+
+// sampleResult 单条样本的结果：处理失败、断言结果，或未设置断言时的ok
+func sampleResult(output models.TestOutput) string {
+	switch {
+	case output.Error != "":
+		return "error"
+	case output.Passed == nil:
+		return "ok"
+	case *output.Passed:
+		return "passed"
+	default:
+		return "failed"
+	}
+}
+
+// sampleDetail 处理失败的原因或未通过的断言
+func sampleDetail(output models.TestOutput) string {
+	if output.Error != "" {
+		return output.Error
+	}
+	var failed []string
+	for _, outcome := range output.Assertions {
+		if !outcome.Passed {
+			failed = append(failed, fmt.Sprintf("%s %s%s: %s", outcome.Assertion.Type,
+				outcome.Assertion.Field, outcome.Assertion.Tag, outcome.Message))
+		}
+	}
+	return strings.Join(failed, "; ")
+}
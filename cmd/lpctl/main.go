@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"time"
+
+	"logstash-platform/internal/platform/models"
+)
+
+// lpctl 管理平台命令行工具
+//
+//	lpctl apply -f fleet.yaml [--dry-run] [--force] [--server http://localhost:8080]
+//	lpctl protocol schema [-o agent-protocol.json]
+//	lpctl protocol conformance [--listen :18080] [--config pipeline.conf] [--timeout 30s]
+//	lpctl config list|get|create|update|rollback [--output json|table]
+//	lpctl agent list|status [--output json|table]
+//	lpctl deploy --config <id> --selector env=prod [--strategy canary]
+//	lpctl test run --config <id> -f samples.log [--assertions assertions.json]
+//
+// 资源子命令通过 --server 和 --token（或环境变量 LPCTL_SERVER、LPCTL_TOKEN）访问平台REST API
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "apply":
+		if err := runApply(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+	case "protocol":
+		if err := runProtocol(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+	case "config":
+		if err := runConfig(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+	case "agent":
+		if err := runAgent(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+	case "deploy":
+		if err := runDeploy(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+	case "test":
+		if err := runTest(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "用法: lpctl apply -f <file> [--dry-run] [--force] [--server <url>]")
+	fmt.Fprintln(os.Stderr, "      lpctl protocol schema [-o <file>]")
+	fmt.Fprintln(os.Stderr, "      lpctl protocol conformance [--listen <addr>] [--config <file>] [--timeout <duration>]")
+	fmt.Fprintln(os.Stderr, "      lpctl config list [--type <type>] [--tags <a,b>] [--enabled <bool>] [--page <n>] [--size <n>]")
+	fmt.Fprintln(os.Stderr, "      lpctl config get <id> [--version <n>]")
+	fmt.Fprintln(os.Stderr, "      lpctl config create --name <name> --type <type> -f <file> [--description <text>] [--tags <a,b>]")
+	fmt.Fprintln(os.Stderr, "      lpctl config update <id> [-f <file>] [--name <name>] [--description <text>] [--tags <a,b>] [--enabled <bool>]")
+	fmt.Fprintln(os.Stderr, "      lpctl config rollback <id> --version <n>")
+	fmt.Fprintln(os.Stderr, "      lpctl agent list")
+	fmt.Fprintln(os.Stderr, "      lpctl agent status <id>")
+	fmt.Fprintln(os.Stderr, "      lpctl deploy --config <id> (--agents <a,b> | --selector <expr>) [--strategy all|canary] [--skip-test-gate] [--wait=false]")
+	fmt.Fprintln(os.Stderr, "      lpctl test run --config <id> -f <samples> [--assertions <file>] [--timeout <duration>]")
+	fmt.Fprintln(os.Stderr, "资源命令通用参数: [--server <url>] [--token <token>] [--output json|table]")
+}
+
+// runApply 提交期望状态文件并输出计划
+func runApply(args []string) error {
+	fs := flag.NewFlagSet("apply", flag.ExitOnError)
+	file := fs.String("f", "", "期望状态文件（YAML或JSON）")
+	dryRun := fs.Bool("dry-run", false, "只显示计划，不执行变更")
+	force := fs.Bool("force", false, "prune时允许删除仍被Agent应用的配置")
+	server := fs.String("server", envOrDefault("LPCTL_SERVER", "http://localhost:8080"), "管理平台地址")
+	token := fs.String("token", os.Getenv("LPCTL_TOKEN"), "认证令牌")
+	fs.Parse(args)
+
+	if *file == "" {
+		return fmt.Errorf("必须通过 -f 指定期望状态文件")
+	}
+
+	body, err := os.ReadFile(*file)
+	if err != nil {
+		return fmt.Errorf("读取文件失败: %w", err)
+	}
+
+	query := neturl.Values{}
+	if *dryRun {
+		query.Set("dry_run", "true")
+	}
+	if *force {
+		query.Set("force", "true")
+	}
+	url := *server + "/api/v1/apply"
+	if len(query) > 0 {
+		url += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/yaml")
+	if *token != "" {
+		req.Header.Set("Authorization", "Bearer "+*token)
+	}
+
+	resp, err := (&http.Client{Timeout: 60 * time.Second}).Do(req)
+	if err != nil {
+		return fmt.Errorf("请求平台失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("平台返回错误: %s - %s", resp.Status, string(data))
+	}
+
+	var plan models.ApplyPlan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	printPlan(&plan)
+	if plan.Summary["failed"] > 0 {
+		return fmt.Errorf("%d 个资源应用失败", plan.Summary["failed"])
+	}
+	return nil
+}
+
+// printPlan 以类似 terraform plan 的格式输出计划
+func printPlan(plan *models.ApplyPlan) {
+	symbols := map[string]string{
+		models.PlanActionCreate: "+",
+		models.PlanActionUpdate: "~",
+		models.PlanActionDelete: "-",
+		models.PlanActionNoop:   " ",
+	}
+
+	for _, a := range plan.Actions {
+		line := fmt.Sprintf("%s %s/%s", symbols[a.Action], a.Kind, a.Name)
+		if len(a.Changes) > 0 {
+			line += fmt.Sprintf(" %v", a.Changes)
+		}
+		if a.Error != "" {
+			line += " 失败: " + a.Error
+		}
+		fmt.Println(line)
+	}
+
+	verb := "计划"
+	if plan.Applied {
+		verb = "已应用"
+	}
+	fmt.Printf("\n%s: %d 新建, %d 更新, %d 删除, %d 不变\n", verb,
+		plan.Summary[models.PlanActionCreate], plan.Summary[models.PlanActionUpdate],
+		plan.Summary[models.PlanActionDelete], plan.Summary[models.PlanActionNoop])
+}
+
+func envOrDefault(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
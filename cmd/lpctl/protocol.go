@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/protocol"
+)
+
+// runProtocol 导出Agent通信协议或对第三方Agent执行一致性测试
+func runProtocol(args []string) error {
+	if len(args) == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "schema":
+		return runProtocolSchema(args[1:])
+	case "conformance":
+		return runConformance(args[1:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+	return nil
+}
+
+// runProtocolSchema 输出当前版本的协议JSON Schema
+func runProtocolSchema(args []string) error {
+	fs := flag.NewFlagSet("protocol schema", flag.ExitOnError)
+	output := fs.String("o", "", "输出文件，默认输出到标准输出")
+	fs.Parse(args)
+
+	data, err := protocol.MarshalDocument()
+	if err != nil {
+		return fmt.Errorf("生成协议文档失败: %w", err)
+	}
+	if *output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(*output, data, 0644)
+}
+
+// runConformance 扮演管理平台，等待被测Agent连接后执行一致性检查
+func runConformance(args []string) error {
+	fs := flag.NewFlagSet("protocol conformance", flag.ExitOnError)
+	listen := fs.String("listen", ":18080", "监听地址，被测Agent的服务器地址需指向这里")
+	configFile := fs.String("config", "", "下发给Agent的Logstash配置文件，默认使用内置的最小配置")
+	timeout := fs.Duration("timeout", 30*time.Second, "每一步等待Agent响应的时间")
+	wait := fs.Duration("wait", 5*time.Minute, "整个测试（含等待Agent连接）的最长时间")
+	verbose := fs.Bool("v", false, "输出收到的每个请求")
+	fs.Parse(args)
+
+	content := "input { stdin {} }\noutput { stdout {} }\n"
+	if *configFile != "" {
+		data, err := os.ReadFile(*configFile)
+		if err != nil {
+			return fmt.Errorf("读取配置文件失败: %w", err)
+		}
+		content = string(data)
+	}
+
+	logger := logrus.New()
+	if *verbose {
+		logger.SetLevel(logrus.DebugLevel)
+	}
+
+	now := time.Now()
+	harness := protocol.NewHarness(&models.Config{
+		ID:        "conformance",
+		Name:      "conformance",
+		Content:   content,
+		Version:   1,
+		Enabled:   true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, *timeout, logger)
+
+	ln, err := net.Listen("tcp", *listen)
+	if err != nil {
+		return fmt.Errorf("监听失败: %w", err)
+	}
+	server := &http.Server{Handler: harness}
+	go server.Serve(ln)
+	defer server.Close()
+
+	fmt.Printf("协议版本 v%s，等待Agent连接 %s ...\n", protocol.Version, ln.Addr())
+
+	ctx, cancel := context.WithTimeout(context.Background(), *wait)
+	defer cancel()
+	report := harness.Run(ctx)
+
+	for _, c := range report.Checks {
+		mark := "PASS"
+		if !c.Passed {
+			mark = "FAIL"
+		}
+		line := fmt.Sprintf("%s %s", mark, c.Name)
+		if c.Detail != "" {
+			line += ": " + c.Detail
+		}
+		fmt.Println(line)
+	}
+	if !report.Passed() {
+		return fmt.Errorf("Agent %s 未通过协议一致性测试", report.AgentID)
+	}
+	fmt.Printf("\nAgent %s 通过协议 v%s 一致性测试\n", report.AgentID, protocol.Version)
+	return nil
+}
@@ -14,18 +14,43 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	"logstash-platform/internal/platform/api"
+	"logstash-platform/internal/platform/service"
+	"logstash-platform/internal/platform/tracing"
 	"logstash-platform/pkg/elasticsearch"
+	applog "logstash-platform/pkg/logger"
 )
 
 func main() {
-	// 初始化日志
-	logger := logrus.New()
-	logger.SetFormatter(&logrus.JSONFormatter{})
-	logger.SetLevel(logrus.InfoLevel)
-
 	// 加载配置
 	if err := loadConfig(); err != nil {
-		logger.Fatalf("加载配置失败: %v", err)
+		log.Fatalf("加载配置失败: %v", err)
+	}
+
+	// 初始化日志，logging.modules 可为 elasticsearch、api 单独设置级别
+	logger, err := applog.Setup(applog.ConfigFromViper("logging"))
+	if err != nil {
+		log.Fatalf("初始化日志失败: %v", err)
+	}
+
+	// 初始化链路追踪，须在创建ES客户端之前完成
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Enabled:     viper.GetBool("tracing.enabled"),
+		Endpoint:    viper.GetString("tracing.endpoint"),
+		URLPath:     viper.GetString("tracing.url_path"),
+		Insecure:    viper.GetBool("tracing.insecure"),
+		Headers:     viper.GetStringMapString("tracing.headers"),
+		ServiceName: viper.GetString("tracing.service_name"),
+		SampleRatio: viper.GetFloat64("tracing.sample_ratio"),
+	})
+	if err != nil {
+		logger.Fatalf("初始化链路追踪失败: %v", err)
+	}
+
+	// 启用认证时拒绝使用空的、占位的或过短的JWT签名密钥启动，否则任何人都能伪造令牌
+	if viper.GetBool("security.auth_enabled") {
+		if err := service.ValidateJWTSecret(viper.GetString("security.jwt_secret")); err != nil {
+			logger.Fatalf("认证配置无效: %v，请通过 LP_SECURITY_JWT_SECRET 环境变量设置至少%d字节的随机密钥", err, service.MinJWTSecretLength)
+		}
 	}
 
 	// 设置Gin模式
@@ -34,30 +59,50 @@ func main() {
 	}
 
 	// 初始化Elasticsearch客户端
-	esClient, err := elasticsearch.NewClient(logger)
+	esClient, err := elasticsearch.NewClient(applog.Module(logger, "elasticsearch"))
 	if err != nil {
 		logger.Fatalf("初始化Elasticsearch客户端失败: %v", err)
 	}
 
-	// 初始化索引
-	if err := esClient.InitializeIndices(context.Background()); err != nil {
-		logger.Errorf("初始化索引失败: %v", err)
-	}
+	// 执行索引迁移，关闭自动执行时只检查，由管理员通过 POST /api/v1/system/migrations 执行
+	migrateIndices(esClient, logger)
 
 	// 创建API服务器
-	apiServer := api.NewServer(logger, esClient)
+	apiServer := api.NewServer(applog.Module(logger, "api"), esClient)
 	router := apiServer.SetupRoutes()
 
+	// 创建初始管理员
+	if err := apiServer.Bootstrap(context.Background()); err != nil {
+		logger.Errorf("创建初始管理员失败: %v", err)
+	}
+
+	// 启动后台任务（CMDB同步等）
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	apiServer.StartBackgroundJobs(jobsCtx)
+
 	// 创建HTTP服务器
 	srv := &http.Server{
 		Addr:    fmt.Sprintf(":%s", viper.GetString("server.port")),
 		Handler: router,
 	}
 
+	// 启用TLS时使用HTTPS/WSS监听，启用Agent客户端证书时同时校验Agent出示的证书
+	tlsEnabled := viper.GetBool("server.tls.enabled")
+	if tlsEnabled {
+		srv.TLSConfig = apiServer.TLSConfig()
+	}
+
 	// 启动服务器
 	go func() {
-		logger.Infof("启动Logstash管理平台，监听端口: %s", srv.Addr)
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		logger.Infof("启动Logstash管理平台，监听端口: %s，TLS: %t", srv.Addr, tlsEnabled)
+		var err error
+		if tlsEnabled {
+			err = srv.ListenAndServeTLS(viper.GetString("server.tls.cert_file"), viper.GetString("server.tls.key_file"))
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			logger.Fatalf("启动服务器失败: %v", err)
 		}
 	}()
@@ -68,16 +113,51 @@ func main() {
 	<-quit
 	logger.Info("收到关闭信号，正在优雅关闭服务器...")
 
+	// 停止接受新的部署和测试，等待进行中的任务结束，超时后保存未结束部署的进度
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), viper.GetDuration("server.shutdown_timeout"))
+	if err := apiServer.Drain(drainCtx); err != nil {
+		logger.Warnf("部分任务未在关闭前结束: %v", err)
+	}
+	cancelDrain()
+	stopJobs()
+
 	// 优雅关闭
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := srv.Shutdown(ctx); err != nil {
 		logger.Errorf("服务器关闭错误: %v", err)
 	}
+	apiServer.Shutdown()
+	if err := shutdownTracing(ctx); err != nil {
+		logger.Warnf("导出剩余链路数据失败: %v", err)
+	}
 
 	logger.Info("服务器已关闭")
 }
 
+// migrateIndices 执行尚未执行的索引迁移，失败时记录错误并继续启动
+func migrateIndices(esClient elasticsearch.ClientInterface, logger *logrus.Logger) {
+	ctx := context.Background()
+	if !viper.GetBool("elasticsearch.migrations.auto_apply") {
+		status, err := esClient.MigrationStatus(ctx)
+		if err != nil {
+			logger.Errorf("获取索引迁移状态失败: %v", err)
+			return
+		}
+		if status.Pending() {
+			logger.Warnf("有尚未执行的索引迁移（当前版本 %d，最新版本 %d），需由管理员执行", status.Current, status.Latest)
+		}
+		return
+	}
+
+	status, err := esClient.Migrate(ctx)
+	if err != nil {
+		logger.Errorf("执行索引迁移失败: %v", err)
+		return
+	}
+	logger.Infof("索引迁移已是最新版本 %d", status.Current)
+}
+
 // loadConfig 加载配置文件
 func loadConfig() error {
 	viper.SetConfigName("config")
@@ -88,11 +168,22 @@ func loadConfig() error {
 	// 设置默认值
 	viper.SetDefault("server.port", "8080")
 	viper.SetDefault("server.mode", "debug")
+	viper.SetDefault("server.shutdown_timeout", 30*time.Second)
+	viper.SetDefault("server.body_limit.default", 1<<20)
+	viper.SetDefault("configs.max_content_size", 10<<20)
 	viper.SetDefault("elasticsearch.addresses", []string{"http://localhost:9200"})
+	viper.SetDefault("elasticsearch.migrations.auto_apply", true)
+	viper.SetDefault("logging.level", "info")
+	viper.SetDefault("logging.format", "json")
+	viper.SetDefault("logging.file.path", "./logs/platform.log")
 
 	// 环境变量覆盖
 	viper.AutomaticEnv()
 	viper.SetEnvPrefix("LOGSTASH_PLATFORM")
+	// JWT签名密钥不写入配置文件，从环境变量读取
+	if err := viper.BindEnv("security.jwt_secret", "LP_SECURITY_JWT_SECRET"); err != nil {
+		return err
+	}
 
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
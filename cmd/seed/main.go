@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"logstash-platform/pkg/elasticsearch"
+	"logstash-platform/tests/fixtures"
+)
+
+// 向开发环境的Elasticsearch写入示例数据，便于前端开发调试
+func main() {
+	configs := flag.Int("configs", 12, "生成的配置数量")
+	agents := flag.Int("agents", 8, "生成的Agent数量")
+	deployments := flag.Int("deployments", 10, "生成的部署记录数量")
+	flag.Parse()
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.InfoLevel)
+
+	if err := loadConfig(); err != nil {
+		logger.Fatalf("加载配置失败: %v", err)
+	}
+
+	esClient, err := elasticsearch.NewClient(logger)
+	if err != nil {
+		logger.Fatalf("初始化Elasticsearch客户端失败: %v", err)
+	}
+
+	ctx := context.Background()
+	if _, err := esClient.Migrate(ctx); err != nil {
+		logger.Fatalf("执行索引迁移失败: %v", err)
+	}
+
+	summary, err := fixtures.Seed(ctx, esClient, fixtures.SeedOptions{
+		Configs:     *configs,
+		Agents:      *agents,
+		Deployments: *deployments,
+	})
+	if err != nil {
+		logger.Fatalf("写入示例数据失败: %v", err)
+	}
+
+	logger.WithFields(logrus.Fields{
+		"configs":     summary.Configs,
+		"histories":   summary.Histories,
+		"agents":      summary.Agents,
+		"deployments": summary.Deployments,
+	}).Info("示例数据写入完成")
+}
+
+// loadConfig 加载配置文件，与平台服务使用同一份配置
+func loadConfig() error {
+	viper.SetConfigName("config")
+	viper.SetConfigType("yaml")
+	viper.AddConfigPath("./configs")
+	viper.AddConfigPath(".")
+
+	viper.SetDefault("elasticsearch.addresses", []string{"http://localhost:9200"})
+
+	viper.AutomaticEnv()
+	viper.SetEnvPrefix("LOGSTASH_PLATFORM")
+
+	if err := viper.ReadInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
+			log.Println("配置文件不存在，使用默认配置")
+			return nil
+		}
+		return err
+	}
+
+	return nil
+}
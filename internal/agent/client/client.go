@@ -2,12 +2,15 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"logstash-platform/internal/agent/config"
 	"logstash-platform/internal/agent/core"
+	"logstash-platform/internal/agent/services"
 	"logstash-platform/internal/platform/models"
 )
 
@@ -17,11 +20,15 @@ type Client struct {
 	logger      *logrus.Logger
 	httpClient  *HTTPClient
 	wsClient    *WebSocketClient
+	pollClient  *LongPollClient // 代理不允许WebSocket时经HTTP长轮询收发消息
 	
 	// WebSocket状态
 	wsConnected bool
 	wsMutex     sync.RWMutex
 	wsHandler   core.MessageHandler
+	
+	// 平台不可达时暂存上报，未设置时上报失败直接返回错误
+	outbox      *services.Outbox
 }
 
 // NewClient 创建统一的API客户端
@@ -34,42 +41,88 @@ func NewClient(cfg *config.AgentConfig, logger *logrus.Logger) (*Client, error)
 	
 	// 创建WebSocket客户端
 	wsClient := NewWebSocketClient(cfg, logger)
+	wsClient.SetTokenSource(httpClient.CurrentToken)
+	wsClient.SetEndpoints(httpClient.Endpoints())
 	
 	client := &Client{
 		config:     cfg,
 		logger:     logger,
 		httpClient: httpClient,
 		wsClient:   wsClient,
+		pollClient: NewLongPollClient(cfg, httpClient, logger),
 	}
 	
 	return client, nil
 }
 
+// SetOutbox 设置上报暂存队列，之后平台不可达时配置应用结果、状态和指标暂存到本地，
+// 恢复连接后（WebSocket重新连接或心跳成功）补发
+func (c *Client) SetOutbox(outbox *services.Outbox) {
+	c.outbox = outbox
+	outbox.SetSender(directReporter{client: c})
+}
+
 // Register 注册Agent
 func (c *Client) Register(ctx context.Context, agent *models.Agent) error {
 	return c.httpClient.Register(ctx, agent)
 }
 
+// NegotiatedIntervals 实现core.IntervalNegotiator，获取注册时平台协商的间隔
+func (c *Client) NegotiatedIntervals() *models.TelemetryIntervals {
+	return c.httpClient.NegotiatedIntervals()
+}
+
+// EnsureEnrolled 实现core.AgentEnroller，确保持有Agent专属令牌
+func (c *Client) EnsureEnrolled(ctx context.Context, agentID string) error {
+	return c.httpClient.EnsureEnrolled(ctx, agentID)
+}
+
+// RotateToken 实现core.TokenRotator，轮换Agent专属令牌
+func (c *Client) RotateToken(ctx context.Context, agentID string) error {
+	return c.httpClient.RotateToken(ctx, agentID)
+}
+
+// ReportPlugins 实现core.PluginReporter，上报已安装的Logstash插件清单
+func (c *Client) ReportPlugins(ctx context.Context, agentID string, report *models.PluginInventoryReport) error {
+	return c.httpClient.ReportPlugins(ctx, agentID, report)
+}
+
 // SendHeartbeat 发送心跳
+// heartbeat_transport 为 websocket 且WebSocket已连接时发送心跳消息，平台改经WebSocket推送心跳命令；
+// 其余情况经HTTP发送
 func (c *Client) SendHeartbeat(ctx context.Context, agentID string) error {
-	// 优先使用WebSocket发送心跳
-	if c.isWebSocketConnected() {
-		err := c.wsClient.Send(core.MsgTypeHeartbeat, map[string]interface{}{
-			"agent_id":  agentID,
-			"timestamp": ctx.Value("timestamp"), // 如果上下文中有时间戳
+	if c.config.HeartbeatTransport == config.HeartbeatTransportWebSocket && c.isWebSocketConnected() {
+		// 捎带经HTTP心跳收到的命令的确认，避免平台经WebSocket重发
+		acked := c.httpClient.takeAcks()
+		timestamp := time.Now().Unix()
+		err := c.wsClient.Send(core.MsgTypeHeartbeat, models.HeartbeatMessage{
+			AgentID:       agentID,
+			Timestamp:     &timestamp,
+			AckedCommands: acked,
 		})
 		if err == nil {
 			return nil
 		}
+		c.httpClient.restoreAcks(acked)
 		c.logger.WithError(err).Debug("WebSocket发送心跳失败，降级到HTTP")
 	}
 	
 	// 降级到HTTP
-	return c.httpClient.SendHeartbeat(ctx, agentID)
+	if err := c.httpClient.SendHeartbeat(ctx, agentID); err != nil {
+		return err
+	}
+	c.notifyOutbox()
+	return nil
 }
 
-// ReportStatus 上报状态
+// ReportStatus 上报状态，平台不可达时暂存
 func (c *Client) ReportStatus(ctx context.Context, agent *models.Agent) error {
+	err := c.reportStatus(ctx, agent)
+	return c.queueIfUnreachable(err, func() error { return c.outbox.EnqueueStatus(agent) })
+}
+
+// reportStatus 上报状态
+func (c *Client) reportStatus(ctx context.Context, agent *models.Agent) error {
 	// 优先使用WebSocket上报状态
 	if c.isWebSocketConnected() {
 		err := c.wsClient.Send(core.MsgTypeStatusReport, agent)
@@ -89,16 +142,54 @@ func (c *Client) GetConfig(ctx context.Context, configID string) (*models.Config
 	return c.httpClient.GetConfig(ctx, configID)
 }
 
-// ReportConfigApplied 上报配置应用结果
+// GetConfigVersion 实现core.ConfigVersionFetcher，获取配置的指定版本
+func (c *Client) GetConfigVersion(ctx context.Context, configID string, version int) (*models.Config, error) {
+	return c.httpClient.GetConfigVersion(ctx, configID, version)
+}
+
+// ReportConfigFailed 上报配置部署失败
+func (c *Client) ReportConfigFailed(ctx context.Context, agentID string, applied *models.AppliedConfig, reason string) error {
+	return c.httpClient.ReportConfigFailed(ctx, agentID, applied, reason)
+}
+
+// ReportConfigApplyFailed 上报新配置验证失败且已恢复之前的配置
+func (c *Client) ReportConfigApplyFailed(ctx context.Context, agentID string, applied *models.AppliedConfig, output string) error {
+	return c.httpClient.ReportConfigApplyFailed(ctx, agentID, applied, output)
+}
+
+// GetDesiredConfigs 实现core.DesiredConfigFetcher，获取平台推导的期望配置
+func (c *Client) GetDesiredConfigs(ctx context.Context, agentID string) (*models.AgentDesiredConfigs, error) {
+	return c.httpClient.GetDesiredConfigs(ctx, agentID)
+}
+
+// Deregister 实现core.Deregisterer，向平台注销Agent
+func (c *Client) Deregister(ctx context.Context, agentID, reason string) error {
+	return c.httpClient.Deregister(ctx, agentID, reason)
+}
+
+// ReportConfigRemoved 确认配置已删除
+func (c *Client) ReportConfigRemoved(ctx context.Context, agentID, configID string) error {
+	return c.httpClient.ReportConfigRemoved(ctx, agentID, configID)
+}
+
+// ReportConfigApplied 上报配置应用结果，平台不可达时暂存
 func (c *Client) ReportConfigApplied(ctx context.Context, agentID string, applied *models.AppliedConfig) error {
+	err := c.reportConfigApplied(ctx, agentID, applied)
+	return c.queueIfUnreachable(err, func() error { return c.outbox.EnqueueConfigApplied(agentID, applied) })
+}
+
+// reportConfigApplied 上报配置应用结果
+func (c *Client) reportConfigApplied(ctx context.Context, agentID string, applied *models.AppliedConfig) error {
 	// 优先使用WebSocket上报
 	if c.isWebSocketConnected() {
-		err := c.wsClient.Send(core.MsgTypeConfigApplied, map[string]interface{}{
+		err := c.wsClient.send(core.MsgTypeConfigApplied, map[string]interface{}{
 			"agent_id":    agentID,
 			"config_id":   applied.ConfigID,
 			"version":     applied.Version,
 			"applied_at":  applied.AppliedAt,
-		})
+			"deployment_id": applied.DeploymentID,
+			"hash":        applied.Hash,
+		}, core.TraceMetadata(ctx))
 		if err == nil {
 			return nil
 		}
@@ -110,8 +201,15 @@ func (c *Client) ReportConfigApplied(ctx context.Context, agentID string, applie
 }
 
 // ConnectWebSocket 建立WebSocket连接
+// message_transport 为 long_poll 时改用HTTP长轮询；为 auto 且握手被代理拒绝时降级到长轮询
 func (c *Client) ConnectWebSocket(ctx context.Context, agentID string, handler core.MessageHandler) error {
 	c.wsHandler = handler
+	// WebSocket不可用时，平台通过心跳响应捎带命令，同样交给该处理器
+	c.httpClient.SetCommandHandler(handler)
+	
+	if c.config.MessageTransport == config.MessageTransportLongPoll {
+		return c.pollClient.Run(ctx, agentID, &wsHandlerWrapper{handler: handler, client: c, longPoll: true})
+	}
 	
 	// 包装handler以更新连接状态
 	wrappedHandler := &wsHandlerWrapper{
@@ -123,14 +221,24 @@ func (c *Client) ConnectWebSocket(ctx context.Context, agentID string, handler c
 	err := c.wsClient.Connect(ctx, agentID, wrappedHandler)
 	if err != nil {
 		c.setWebSocketConnected(false)
+		if c.config.MessageTransport == config.MessageTransportAuto && errors.Is(err, ErrWebSocketRejected) {
+			c.logger.WithError(err).Warn("WebSocket握手被拒绝，改用HTTP长轮询")
+			return c.pollClient.Run(ctx, agentID, &wsHandlerWrapper{handler: handler, client: c, longPoll: true})
+		}
 		return err
 	}
 	
 	return nil
 }
 
-// ReportMetrics 上报指标
+// ReportMetrics 上报指标，平台不可达时暂存
 func (c *Client) ReportMetrics(ctx context.Context, agentID string, metrics *core.AgentMetrics) error {
+	err := c.reportMetrics(ctx, agentID, metrics)
+	return c.queueIfUnreachable(err, func() error { return c.outbox.EnqueueMetrics(agentID, metrics) })
+}
+
+// reportMetrics 上报指标
+func (c *Client) reportMetrics(ctx context.Context, agentID string, metrics *core.AgentMetrics) error {
 	// 优先使用WebSocket上报
 	if c.isWebSocketConnected() {
 		err := c.wsClient.Send(core.MsgTypeMetricsReport, map[string]interface{}{
@@ -147,13 +255,16 @@ func (c *Client) ReportMetrics(ctx context.Context, agentID string, metrics *cor
 	return c.httpClient.ReportMetrics(ctx, agentID, metrics)
 }
 
-// SendMessage 发送自定义消息（仅WebSocket）
+// SendMessage 发送自定义消息（WebSocket或长轮询通道）
 func (c *Client) SendMessage(msgType string, payload interface{}) error {
-	if !c.isWebSocketConnected() {
-		return fmt.Errorf("WebSocket未连接")
+	if c.isWebSocketConnected() {
+		return c.wsClient.Send(msgType, payload)
+	}
+	if c.pollClient.IsConnected() {
+		return c.pollClient.Send(msgType, payload)
 	}
 	
-	return c.wsClient.Send(msgType, payload)
+	return fmt.Errorf("WebSocket未连接")
 }
 
 // Close 关闭客户端
@@ -181,6 +292,57 @@ func (c *Client) Close() error {
 	return nil
 }
 
+// LastAlive 实现core.LivenessReporter，WebSocket写入卡住时返回写入开始的时间
+func (c *Client) LastAlive() time.Time {
+	if started := c.wsClient.WriteStartedAt(); !started.IsZero() {
+		return started
+	}
+	return time.Now()
+}
+
+// ResetWebSocket 实现core.ConnectionResetter，关闭当前连接以解除卡住的写入
+func (c *Client) ResetWebSocket() error {
+	c.setWebSocketConnected(false)
+	return c.wsClient.Close()
+}
+
+// queueIfUnreachable 上报因平台不可达失败时写入暂存队列，暂存成功后视为上报成功
+func (c *Client) queueIfUnreachable(err error, enqueue func() error) error {
+	if err == nil || c.outbox == nil || !errors.Is(err, ErrPlatformUnreachable) {
+		return err
+	}
+	if qerr := enqueue(); qerr != nil {
+		c.logger.WithError(qerr).Warn("暂存上报失败")
+		return err
+	}
+	c.logger.WithError(err).Info("平台不可达，上报已暂存，恢复连接后补发")
+	return nil
+}
+
+// notifyOutbox 通知暂存队列已恢复与平台的连接
+func (c *Client) notifyOutbox() {
+	if c.outbox != nil {
+		c.outbox.Notify()
+	}
+}
+
+// directReporter 补发暂存上报使用的客户端，发送失败时不再次暂存
+type directReporter struct {
+	client *Client
+}
+
+func (r directReporter) ReportConfigApplied(ctx context.Context, agentID string, applied *models.AppliedConfig) error {
+	return r.client.reportConfigApplied(ctx, agentID, applied)
+}
+
+func (r directReporter) ReportStatus(ctx context.Context, agent *models.Agent) error {
+	return r.client.reportStatus(ctx, agent)
+}
+
+func (r directReporter) ReportMetrics(ctx context.Context, agentID string, metrics *core.AgentMetrics) error {
+	return r.client.reportMetrics(ctx, agentID, metrics)
+}
+
 // isWebSocketConnected 检查WebSocket是否已连接
 func (c *Client) isWebSocketConnected() bool {
 	c.wsMutex.RLock()
@@ -197,8 +359,9 @@ func (c *Client) setWebSocketConnected(connected bool) {
 
 // wsHandlerWrapper WebSocket处理器包装器
 type wsHandlerWrapper struct {
-	handler core.MessageHandler
-	client  *Client
+	handler  core.MessageHandler
+	client   *Client
+	longPoll bool // 经长轮询通道收发，确认经长轮询发送，不更新WebSocket连接状态
 }
 
 // HandleMessage 处理消息
@@ -206,20 +369,67 @@ func (w *wsHandlerWrapper) HandleMessage(msgType string, payload []byte) error {
 	return w.handler.HandleMessage(msgType, payload)
 }
 
+// HandleMessageWithMetadata 处理携带链路上下文的消息
+func (w *wsHandlerWrapper) HandleMessageWithMetadata(msgType string, payload []byte, metadata map[string]string) error {
+	return handleWithMetadata(w.handler, msgType, payload, metadata)
+}
+
+// HandleMessageWithID 处理带ID的消息
+func (w *wsHandlerWrapper) HandleMessageWithID(id, msgType string, payload []byte, metadata map[string]string) error {
+	msg := &core.WebSocketMessage{ID: id, Type: msgType, Payload: payload, Metadata: metadata}
+	ack := w.client.wsClient.ack
+	if w.longPoll {
+		ack = w.client.pollClient.ack
+	}
+	return dispatchMessage(w.handler, msg, ack)
+}
+
+// dispatchMessage 把消息交给处理器
+// 带ID的消息优先交给支持确认的处理器，由处理器在处理完成后确认；其他处理器返回即视为处理完成，由ack确认
+func dispatchMessage(handler core.MessageHandler, msg *core.WebSocketMessage, ack func(id string)) error {
+	if msg.ID == "" {
+		return handleWithMetadata(handler, msg.Type, msg.Payload, msg.Metadata)
+	}
+	if h, ok := handler.(core.AckingMessageHandler); ok {
+		return h.HandleMessageWithID(msg.ID, msg.Type, msg.Payload, msg.Metadata)
+	}
+	err := handleWithMetadata(handler, msg.Type, msg.Payload, msg.Metadata)
+	ack(msg.ID)
+	return err
+}
+
+// handleWithMetadata 处理器支持时连同链路上下文交给处理器，否则只交给消息内容
+func handleWithMetadata(handler core.MessageHandler, msgType string, payload []byte, metadata map[string]string) error {
+	if h, ok := handler.(core.MetadataMessageHandler); ok && len(metadata) > 0 {
+		return h.HandleMessageWithMetadata(msgType, payload, metadata)
+	}
+	return handler.HandleMessage(msgType, payload)
+}
+
 // OnConnect 连接建立
 func (w *wsHandlerWrapper) OnConnect() error {
+	if w.longPoll {
+		w.client.logger.Info("长轮询连接已建立")
+		w.client.notifyOutbox()
+		return w.handler.OnConnect()
+	}
 	w.client.setWebSocketConnected(true)
 	w.client.logger.Info("WebSocket连接已建立")
+	w.client.notifyOutbox()
 	return w.handler.OnConnect()
 }
 
 // OnDisconnect 连接断开
 func (w *wsHandlerWrapper) OnDisconnect(err error) {
-	w.client.setWebSocketConnected(false)
+	transport := "长轮询"
+	if !w.longPoll {
+		w.client.setWebSocketConnected(false)
+		transport = "WebSocket"
+	}
 	if err != nil {
-		w.client.logger.WithError(err).Warn("WebSocket连接已断开")
+		w.client.logger.WithError(err).Warnf("%s连接已断开", transport)
 	} else {
-		w.client.logger.Info("WebSocket连接已正常关闭")
+		w.client.logger.Infof("%s连接已正常关闭", transport)
 	}
 	w.handler.OnDisconnect(err)
 }
\ No newline at end of file
@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -16,6 +17,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"logstash-platform/internal/agent/config"
 	"logstash-platform/internal/agent/core"
+	"logstash-platform/internal/agent/services"
 	"logstash-platform/internal/platform/models"
 )
 
@@ -152,6 +154,70 @@ func TestClient_SendHeartbeat(t *testing.T) {
 	}
 }
 
+func TestClient_WebSocketHeartbeat(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.WarnLevel)
+	
+	var httpHeartbeats atomic.Int32
+	received := make(chan models.WebSocketMessage, 1)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ws" {
+			httpHeartbeats.Add(1)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			var msg models.WebSocketMessage
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			received <- msg
+		}
+	}))
+	defer server.Close()
+	
+	client, err := NewClient(&config.AgentConfig{
+		ServerURL:             server.URL,
+		AgentID:               "test-agent",
+		EnableWebSocket:       true,
+		WebSocketPingInterval: 30 * time.Second,
+		HeartbeatTransport:    config.HeartbeatTransportWebSocket,
+	}, logger)
+	require.NoError(t, err)
+	defer client.Close()
+	
+	ctx := context.Background()
+	// 未连接时降级到HTTP
+	require.NoError(t, client.SendHeartbeat(ctx, "test-agent"))
+	assert.Equal(t, int32(1), httpHeartbeats.Load())
+	
+	go client.ConnectWebSocket(ctx, "test-agent", &mockMessageHandler{})
+	require.Eventually(t, client.isWebSocketConnected, time.Second, 10*time.Millisecond)
+	
+	// 已连接时经WebSocket发送，并捎带经HTTP心跳收到的命令的确认
+	client.httpClient.restoreAcks([]string{"cmd-1"})
+	require.NoError(t, client.SendHeartbeat(ctx, "test-agent"))
+	
+	select {
+	case msg := <-received:
+		assert.Equal(t, core.MsgTypeHeartbeat, msg.Type)
+		var heartbeat models.HeartbeatMessage
+		require.NoError(t, json.Unmarshal(msg.Payload, &heartbeat))
+		assert.Equal(t, "test-agent", heartbeat.AgentID)
+		assert.Equal(t, []string{"cmd-1"}, heartbeat.AckedCommands)
+	case <-time.After(time.Second):
+		t.Fatal("未收到WebSocket心跳")
+	}
+	assert.Equal(t, int32(1), httpHeartbeats.Load())
+	assert.Empty(t, client.httpClient.takeAcks())
+}
+
 func TestClient_ReportStatus(t *testing.T) {
 	logger := logrus.New()
 	logger.SetLevel(logrus.WarnLevel)
@@ -278,6 +344,7 @@ func TestClient_WebSocketConnection(t *testing.T) {
 		AgentID:               "test-agent",
 		EnableWebSocket:       true,
 		WebSocketPingInterval: 30 * time.Second,
+		HeartbeatTransport:    config.HeartbeatTransportWebSocket,
 	}
 
 	client, err := NewClient(cfg, logger)
@@ -370,6 +437,52 @@ func TestClient_ReportConfigApplied(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestClient_OutboxWhenUnreachable(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.WarnLevel)
+	
+	var available atomic.Bool
+	var applied []string
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !available.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.Path == "/api/v1/agents/test-agent/configs/applied" {
+			var report map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&report)
+			mu.Lock()
+			applied = append(applied, report["config_id"].(string))
+			mu.Unlock()
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	
+	client, err := NewClient(&config.AgentConfig{ServerURL: server.URL, AgentID: "test-agent"}, logger)
+	require.NoError(t, err)
+	outbox, err := services.NewOutbox(t.TempDir(), logger)
+	require.NoError(t, err)
+	client.SetOutbox(outbox)
+	
+	ctx := context.Background()
+	// 平台不可达时暂存并视为上报成功
+	require.NoError(t, client.ReportConfigApplied(ctx, "test-agent", &models.AppliedConfig{ConfigID: "config-123", Version: 1}))
+	require.NoError(t, client.ReportMetrics(ctx, "test-agent", &core.AgentMetrics{Timestamp: time.Now()}))
+	assert.Equal(t, 2, outbox.Len())
+	// 平台不可达之外的错误不暂存
+	assert.Error(t, client.ReportStatus(ctx, nil))
+	
+	available.Store(true)
+	require.NoError(t, client.SendHeartbeat(ctx, "test-agent"))
+	sent, err := outbox.Flush(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	assert.Equal(t, []string{"config-123"}, applied)
+	assert.Zero(t, outbox.Len())
+}
+
 func TestClient_Close(t *testing.T) {
 	logger := logrus.New()
 	logger.SetLevel(logrus.WarnLevel)
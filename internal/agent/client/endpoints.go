@@ -0,0 +1,105 @@
+package client
+
+import (
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// defaultFailoverCooldown 平台地址失败后默认跳过的时间
+const defaultFailoverCooldown = 30 * time.Second
+
+// EndpointSelector 在配置的多个平台地址之间选择当前使用的地址
+// 按配置顺序选择第一个可用的地址并一直使用（粘性），直到该地址连接失败；失败的地址在冷却时间内不会被选中，
+// 原地址恢复后也不会自动切回，避免在节点之间来回切换。全部地址都在冷却中时选择最早结束冷却的地址。
+// HTTP与WebSocket客户端共享同一个选择器，任一通道发现地址不可用时另一通道随之切换
+type EndpointSelector struct {
+	mu        sync.Mutex
+	endpoints []*url.URL
+	downUntil []time.Time // 各地址冷却结束的时间，零值表示可用
+	current   int
+	cooldown  time.Duration
+	logger    *logrus.Logger
+	now       func() time.Time
+}
+
+// NewEndpointSelector 创建平台地址选择器，cooldown<=0时使用默认值
+func NewEndpointSelector(rawURLs []string, cooldown time.Duration, logger *logrus.Logger) (*EndpointSelector, error) {
+	if len(rawURLs) == 0 {
+		return nil, fmt.Errorf("未配置平台地址")
+	}
+	endpoints := make([]*url.URL, 0, len(rawURLs))
+	for _, raw := range rawURLs {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("解析服务器URL %q 失败: %w", raw, err)
+		}
+		endpoints = append(endpoints, u)
+	}
+	if cooldown <= 0 {
+		cooldown = defaultFailoverCooldown
+	}
+	return &EndpointSelector{
+		endpoints: endpoints,
+		downUntil: make([]time.Time, len(endpoints)),
+		cooldown:  cooldown,
+		logger:    logger,
+		now:       time.Now,
+	}, nil
+}
+
+// Len 配置的平台地址数
+func (s *EndpointSelector) Len() int {
+	return len(s.endpoints)
+}
+
+// Current 当前使用的地址及其序号，返回的URL是副本，调用方可以修改
+func (s *EndpointSelector) Current() (int, *url.URL) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u := *s.endpoints[s.current]
+	return s.current, &u
+}
+
+// MarkFailed 记录地址不可用，该地址为当前地址时切换到下一个可用地址
+// 使用旧地址的并发请求先后失败时只切换一次
+func (s *EndpointSelector) MarkFailed(index int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.downUntil[index] = now.Add(s.cooldown)
+	if index != s.current || len(s.endpoints) == 1 {
+		return
+	}
+
+	next := -1
+	for i := range s.endpoints {
+		if i != index && !s.downUntil[i].After(now) {
+			next = i
+			break
+		}
+	}
+	if next < 0 {
+		// 全部地址都在冷却中，选择最早结束冷却的地址
+		for i := range s.endpoints {
+			if i != index && (next < 0 || s.downUntil[i].Before(s.downUntil[next])) {
+				next = i
+			}
+		}
+	}
+	s.current = next
+	s.logger.WithFields(logrus.Fields{
+		"from": s.endpoints[index].Host,
+		"to":   s.endpoints[next].Host,
+	}).Warn("平台地址不可用，切换到下一个地址")
+}
+
+// MarkHealthy 记录地址可用，清除其冷却时间
+func (s *EndpointSelector) MarkHealthy(index int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.downUntil[index] = time.Time{}
+}
@@ -0,0 +1,68 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpointSelector_StickyFailover(t *testing.T) {
+	selector, err := NewEndpointSelector([]string{"http://a:8080", "http://b:8080", "http://c:8080"}, time.Minute, logrus.New())
+	require.NoError(t, err)
+	now := time.Now()
+	selector.now = func() time.Time { return now }
+
+	index, u := selector.Current()
+	assert.Equal(t, 0, index)
+	assert.Equal(t, "a:8080", u.Host)
+
+	// 失败后切换到下一个可用地址
+	selector.MarkFailed(0)
+	index, u = selector.Current()
+	assert.Equal(t, 1, index)
+	assert.Equal(t, "b:8080", u.Host)
+
+	// 原地址恢复后不切回
+	now = now.Add(2 * time.Minute)
+	selector.MarkHealthy(0)
+	index, _ = selector.Current()
+	assert.Equal(t, 1, index)
+
+	// 旧地址上的请求再次失败不影响当前地址
+	selector.MarkFailed(0)
+	index, _ = selector.Current()
+	assert.Equal(t, 1, index)
+
+	// 当前地址失败时跳过冷却中的地址
+	selector.MarkFailed(1)
+	index, _ = selector.Current()
+	assert.Equal(t, 2, index)
+}
+
+func TestEndpointSelector_AllCoolingDown(t *testing.T) {
+	selector, err := NewEndpointSelector([]string{"http://a:8080", "http://b:8080", "http://c:8080"}, time.Minute, logrus.New())
+	require.NoError(t, err)
+	now := time.Now()
+	selector.now = func() time.Time { return now }
+
+	selector.MarkFailed(0)
+	now = now.Add(10 * time.Second)
+	selector.MarkFailed(1)
+	now = now.Add(10 * time.Second)
+	selector.MarkFailed(2)
+
+	// 全部在冷却中时选择最早结束冷却的地址
+	index, _ := selector.Current()
+	assert.Equal(t, 0, index)
+}
+
+func TestNewEndpointSelector_Invalid(t *testing.T) {
+	_, err := NewEndpointSelector(nil, 0, logrus.New())
+	assert.Error(t, err)
+
+	_, err = NewEndpointSelector([]string{"http://a:8080", "://invalid"}, 0, logrus.New())
+	assert.Error(t, err)
+}
@@ -6,36 +6,91 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"logstash-platform/internal/agent/config"
+	"logstash-platform/internal/agent/core"
+	"logstash-platform/internal/agent/services"
 	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/agentauth"
 )
 
+// ErrPlatformUnreachable 无法连接平台或平台暂时不可用，上报可以暂存后补发
+var ErrPlatformUnreachable = services.ErrPlatformUnreachable
+
+// StatusError 平台以非成功状态码响应，暂存队列据此区分可重试的失败和被拒绝的上报
+type StatusError = services.StatusError
+
 // HTTPClient HTTP客户端实现
 type HTTPClient struct {
 	config     *config.AgentConfig
 	logger     *logrus.Logger
 	httpClient *http.Client
-	baseURL    string
+	pollClient *http.Client      // 长轮询使用的客户端，不设置整体超时，由请求的上下文控制
+	endpoints  *EndpointSelector // 平台地址，连接失败时切换到下一个地址
+	
+	// 当前使用的认证令牌，注册后切换为Agent专属令牌
+	token      string
+	tokenMutex sync.RWMutex
+	
+	// 注册响应中平台协商的心跳/指标间隔下限
+	intervals      *models.TelemetryIntervals
+	intervalsMutex sync.RWMutex
+	
+	// 心跳响应中捎带命令的处理器
+	commandHandler core.MessageHandler
+	handlerMutex   sync.RWMutex
+	
+	// 捎带命令交给后台协程处理，不阻塞心跳；处理后的命令ID在下一次心跳中回传确认
+	commands  chan models.PendingCommand
+	ackMutex  sync.Mutex
+	acked     []string
+	seen      map[string]time.Time // 最近接收的命令ID及接收时间，避免重复处理重发的命令
+	done      chan struct{}
+	closeOnce sync.Once
+	
+	// 最近获取的各配置最新版本及其ETag，平台返回304时直接复用，省去重复下载配置内容
+	configCache      map[string]*cachedConfig
+	configCacheMutex sync.Mutex
 }
 
+// cachedConfig 带ETag的配置响应
+type cachedConfig struct {
+	etag   string
+	config models.Config
+}
+
+// commandBufferSize 等待处理的捎带命令缓冲数，缓冲满时不确认，由平台下次心跳重发
+const commandBufferSize = 64
+
+// seenRetention 已接收命令ID的去重保留时间
+const seenRetention = 10 * time.Minute
+
+// longPollKey 标记长轮询请求的上下文键，长轮询请求改用不限整体超时的客户端
+type longPollKey struct{}
+
 // NewHTTPClient 创建HTTP客户端
 func NewHTTPClient(cfg *config.AgentConfig, logger *logrus.Logger) (*HTTPClient, error) {
 	if cfg == nil {
 		return nil, fmt.Errorf("配置不能为空")
 	}
 	
-	// 解析基础URL
-	baseURL, err := url.Parse(cfg.ServerURL)
+	// 解析平台地址
+	endpoints, err := NewEndpointSelector(cfg.ServerURLs(), cfg.FailoverCooldown, logger)
 	if err != nil {
-		return nil, fmt.Errorf("解析服务器URL失败: %w", err)
+		return nil, err
 	}
 	
 	// 创建HTTP传输层
@@ -63,15 +118,28 @@ func NewHTTPClient(cfg *config.AgentConfig, logger *logrus.Logger) (*HTTPClient,
 	}
 	
 	client := &HTTPClient{
-		config:     cfg,
-		logger:     logger,
-		httpClient: httpClient,
-		baseURL:    baseURL.String(),
+		config:      cfg,
+		logger:      logger,
+		httpClient:  httpClient,
+		pollClient:  &http.Client{Transport: transport},
+		endpoints:   endpoints,
+		token:       cfg.Token,
+		commands:    make(chan models.PendingCommand, commandBufferSize),
+		seen:        make(map[string]time.Time),
+		done:        make(chan struct{}),
+		configCache: make(map[string]*cachedConfig),
 	}
 	
+	go client.commandLoop()
+	
 	return client, nil
 }
 
+// Endpoints 获取平台地址选择器，WebSocket客户端共享同一个选择器
+func (c *HTTPClient) Endpoints() *EndpointSelector {
+	return c.endpoints
+}
+
 // Register 注册Agent
 func (c *HTTPClient) Register(ctx context.Context, agent *models.Agent) error {
 	c.logger.Debug("发送注册请求")
@@ -82,6 +150,8 @@ func (c *HTTPClient) Register(ctx context.Context, agent *models.Agent) error {
 		"hostname":         agent.Hostname,
 		"ip":               agent.IP,
 		"logstash_version": agent.LogstashVersion,
+		"group":            agent.Group,
+		"labels":           agent.Labels,
 	}
 	
 	// 发送POST请求
@@ -97,6 +167,137 @@ func (c *HTTPClient) Register(ctx context.Context, agent *models.Agent) error {
 		return fmt.Errorf("注册失败: %s - %s", resp.Status, string(body))
 	}
 	
+	// 旧版本平台不返回协商间隔，解析失败时不影响注册
+	var registered models.RegisterResponse
+	if err := json.NewDecoder(resp.Body).Decode(&registered); err != nil {
+		c.logger.WithError(err).Debug("解析注册响应失败")
+	}
+	c.intervalsMutex.Lock()
+	c.intervals = registered.Telemetry
+	c.intervalsMutex.Unlock()
+	
+	return nil
+}
+
+// NegotiatedIntervals 获取最近一次注册时平台协商的间隔，平台未协商时返回nil
+func (c *HTTPClient) NegotiatedIntervals() *models.TelemetryIntervals {
+	c.intervalsMutex.RLock()
+	defer c.intervalsMutex.RUnlock()
+	return c.intervals
+}
+
+// EnsureEnrolled 确保持有Agent专属令牌
+// 优先使用令牌文件中保存的令牌，没有时用共享令牌向平台申请；平台不支持注册令牌时继续使用共享令牌
+func (c *HTTPClient) EnsureEnrolled(ctx context.Context, agentID string) error {
+	if agentauth.IsEnrollmentToken(c.CurrentToken()) {
+		return nil
+	}
+	
+	path := c.config.GetTokenFilePath()
+	data, err := ioutil.ReadFile(path)
+	switch {
+	case err == nil:
+		if token := strings.TrimSpace(string(data)); agentauth.IsEnrollmentToken(token) {
+			c.setToken(token)
+			return nil
+		}
+		c.logger.WithField("path", path).Warn("令牌文件内容无效，重新申请Agent令牌")
+	case !os.IsNotExist(err):
+		return fmt.Errorf("读取令牌文件失败: %w", err)
+	}
+	
+	resp, err := c.doRequest(ctx, "POST", "/api/v1/agents/enroll", models.EnrollAgentRequest{AgentID: agentID})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+	case http.StatusNotFound:
+		c.logger.Info("平台未提供Agent注册接口，继续使用共享令牌")
+		return nil
+	default:
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("申请Agent令牌失败: %s - %s", resp.Status, string(body))
+	}
+	
+	if err := c.acceptToken(resp.Body); err != nil {
+		return err
+	}
+	c.logger.WithField("path", path).Info("已获取Agent专属令牌")
+	return nil
+}
+
+// RotateToken 轮换Agent专属令牌，旧令牌在平台的宽限期后失效
+func (c *HTTPClient) RotateToken(ctx context.Context, agentID string) error {
+	if !agentauth.IsEnrollmentToken(c.CurrentToken()) {
+		return fmt.Errorf("未持有Agent专属令牌")
+	}
+	
+	path := fmt.Sprintf("/api/v1/agents/%s/token/rotate", agentID)
+	resp, err := c.doRequest(ctx, "POST", path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("轮换Agent令牌失败: %s - %s", resp.Status, string(body))
+	}
+	
+	if err := c.acceptToken(resp.Body); err != nil {
+		return err
+	}
+	c.logger.Info("Agent专属令牌已轮换")
+	return nil
+}
+
+// CurrentToken 获取当前使用的认证令牌
+func (c *HTTPClient) CurrentToken() string {
+	c.tokenMutex.RLock()
+	defer c.tokenMutex.RUnlock()
+	return c.token
+}
+
+// setToken 切换认证令牌
+func (c *HTTPClient) setToken(token string) {
+	c.tokenMutex.Lock()
+	defer c.tokenMutex.Unlock()
+	c.token = token
+}
+
+// acceptToken 解析平台签发的令牌，先写入令牌文件再切换，避免重启后丢失
+func (c *HTTPClient) acceptToken(body io.Reader) error {
+	var issued models.AgentTokenResponse
+	if err := json.NewDecoder(body).Decode(&issued); err != nil {
+		return fmt.Errorf("解析Agent令牌失败: %w", err)
+	}
+	if !agentauth.IsEnrollmentToken(issued.Token) {
+		return fmt.Errorf("平台返回的Agent令牌无效")
+	}
+	
+	if err := writeTokenFile(c.config.GetTokenFilePath(), issued.Token); err != nil {
+		return err
+	}
+	c.setToken(issued.Token)
+	return nil
+}
+
+// writeTokenFile 以仅属主可读写的权限保存令牌，先写临时文件再重命名
+func writeTokenFile(path, token string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("创建令牌目录失败: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, []byte(token+"\n"), 0600); err != nil {
+		return fmt.Errorf("写入令牌文件失败: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("写入令牌文件失败: %w", err)
+	}
 	return nil
 }
 
@@ -104,28 +305,149 @@ func (c *HTTPClient) Register(ctx context.Context, agent *models.Agent) error {
 func (c *HTTPClient) SendHeartbeat(ctx context.Context, agentID string) error {
 	c.logger.Debug("发送心跳")
 	
-	// 构建请求
-	req := map[string]interface{}{
-		"timestamp": time.Now().Unix(),
+	// 构建请求，捎带已处理命令的确认
+	acked := c.takeAcks()
+	req := models.HeartbeatRequest{
+		Timestamp:     time.Now().Unix(),
+		AckedCommands: acked,
 	}
 	
 	// 发送POST请求
 	path := fmt.Sprintf("/api/v1/agents/%s/heartbeat", agentID)
 	resp, err := c.doRequest(ctx, "POST", path, req)
 	if err != nil {
+		c.restoreAcks(acked)
 		return err
 	}
 	defer resp.Body.Close()
 	
 	// 检查响应
 	if resp.StatusCode != http.StatusOK {
+		c.restoreAcks(acked)
 		body, _ := ioutil.ReadAll(resp.Body)
 		return fmt.Errorf("心跳失败: %s - %s", resp.Status, string(body))
 	}
+	c.pruneSeen()
+	
+	// 解析心跳响应中捎带的命令，旧版本平台的响应体不含命令字段
+	var hbResp models.HeartbeatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&hbResp); err != nil {
+		c.logger.WithError(err).Debug("解析心跳响应失败，忽略捎带命令")
+		return nil
+	}
+	
+	c.dispatchCommands(hbResp.Commands)
 	
 	return nil
 }
 
+// SetCommandHandler 设置心跳捎带命令的处理器
+func (c *HTTPClient) SetCommandHandler(handler core.MessageHandler) {
+	c.handlerMutex.Lock()
+	defer c.handlerMutex.Unlock()
+	c.commandHandler = handler
+}
+
+// dispatchCommands 将心跳捎带的命令排入后台处理队列，跳过已接收的重发命令
+func (c *HTTPClient) dispatchCommands(commands []models.PendingCommand) {
+	for _, cmd := range commands {
+		if cmd.ID != "" {
+			c.ackMutex.Lock()
+			_, duplicate := c.seen[cmd.ID]
+			c.seen[cmd.ID] = time.Now()
+			c.ackMutex.Unlock()
+			if duplicate {
+				continue
+			}
+		}
+		
+		select {
+		case c.commands <- cmd:
+		default:
+			c.logger.WithField("type", cmd.Type).Warn("捎带命令缓冲已满，等待平台重发")
+			c.unmarkSeen(cmd.ID)
+		}
+	}
+}
+
+// commandLoop 后台处理捎带命令，处理成功的命令记录为待确认
+func (c *HTTPClient) commandLoop() {
+	for {
+		select {
+		case <-c.done:
+			return
+		case cmd := <-c.commands:
+			c.handleCommand(cmd)
+		}
+	}
+}
+
+// handleCommand 交给命令处理器，未设置处理器或处理失败时不确认，由平台重发
+func (c *HTTPClient) handleCommand(cmd models.PendingCommand) {
+	c.handlerMutex.RLock()
+	handler := c.commandHandler
+	c.handlerMutex.RUnlock()
+	
+	if handler == nil {
+		c.logger.WithField("type", cmd.Type).Warn("未设置命令处理器，暂不处理心跳捎带的命令")
+		c.unmarkSeen(cmd.ID)
+		return
+	}
+	
+	c.logger.WithField("type", cmd.Type).Info("收到心跳捎带命令")
+	if err := handleWithMetadata(handler, cmd.Type, cmd.Payload, cmd.Metadata); err != nil {
+		c.logger.WithError(err).WithField("type", cmd.Type).Error("处理心跳捎带命令失败")
+		c.unmarkSeen(cmd.ID)
+		return
+	}
+	
+	if cmd.ID != "" {
+		c.ackMutex.Lock()
+		c.acked = append(c.acked, cmd.ID)
+		c.ackMutex.Unlock()
+	}
+}
+
+// takeAcks 取出待确认的命令ID
+func (c *HTTPClient) takeAcks() []string {
+	c.ackMutex.Lock()
+	defer c.ackMutex.Unlock()
+	acked := c.acked
+	c.acked = nil
+	return acked
+}
+
+// restoreAcks 心跳失败时放回待确认的命令ID
+func (c *HTTPClient) restoreAcks(ids []string) {
+	if len(ids) == 0 {
+		return
+	}
+	c.ackMutex.Lock()
+	defer c.ackMutex.Unlock()
+	c.acked = append(ids, c.acked...)
+}
+
+// pruneSeen 清理超过保留时间的去重记录
+func (c *HTTPClient) pruneSeen() {
+	c.ackMutex.Lock()
+	defer c.ackMutex.Unlock()
+	for id, at := range c.seen {
+		if time.Since(at) > seenRetention {
+			delete(c.seen, id)
+		}
+	}
+}
+
+// unmarkSeen 命令未被处理，允许平台重发后再次处理
+func (c *HTTPClient) unmarkSeen(id string) {
+	if id == "" {
+		return
+	}
+	c.ackMutex.Lock()
+	defer c.ackMutex.Unlock()
+	delete(c.seen, id)
+}
+
 // ReportStatus 上报状态
 func (c *HTTPClient) ReportStatus(ctx context.Context, agent *models.Agent) error {
 	if agent == nil {
@@ -144,8 +466,7 @@ func (c *HTTPClient) ReportStatus(ctx context.Context, agent *models.Agent) erro
 	
 	// 检查响应
 	if resp.StatusCode != http.StatusOK {
-		body, _ := ioutil.ReadAll(resp.Body)
-		return fmt.Errorf("状态上报失败: %s - %s", resp.Status, string(body))
+		return fmt.Errorf("状态上报失败: %w", statusError(resp))
 	}
 	
 	return nil
@@ -153,20 +474,51 @@ func (c *HTTPClient) ReportStatus(ctx context.Context, agent *models.Agent) erro
 
 // GetConfig 获取配置
 func (c *HTTPClient) GetConfig(ctx context.Context, configID string) (*models.Config, error) {
+	return c.GetConfigVersion(ctx, configID, 0)
+}
+
+// GetConfigVersion 获取配置的指定版本，version<=0时获取最新版本
+func (c *HTTPClient) GetConfigVersion(ctx context.Context, configID string, version int) (*models.Config, error) {
 	if configID == "" {
 		return nil, fmt.Errorf("配置ID不能为空")
 	}
 	
-	c.logger.WithField("config_id", configID).Debug("获取配置")
+	c.logger.WithFields(logrus.Fields{
+		"config_id": configID,
+		"version":   version,
+	}).Debug("获取配置")
 	
 	// 发送GET请求
 	path := fmt.Sprintf("/api/v1/configs/%s", configID)
-	resp, err := c.doRequest(ctx, "GET", path, nil)
+	// 平台按环境替换配置中引用的下游集群
+	environment := c.config.Environment
+	if environment == "" {
+		environment = models.DefaultEnvironment
+	}
+	path += "?environment=" + url.QueryEscape(environment)
+	if version > 0 {
+		path += "&version=" + strconv.Itoa(version)
+	}
+	
+	// 只缓存最新版本，指定版本的请求（如回滚）很少重复
+	var header http.Header
+	cached := c.cachedConfig(path)
+	if version <= 0 && cached != nil {
+		header = http.Header{"If-None-Match": []string{cached.etag}}
+	}
+	resp, err := c.doRequestWithHeader(ctx, "GET", path, nil, header)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 	
+	// 内容未变化，复用上次下载的配置
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		c.logger.WithField("config_id", configID).Debug("配置未变化，使用缓存")
+		config := cached.config
+		return &config, nil
+	}
+	
 	// 检查响应
 	if resp.StatusCode != http.StatusOK {
 		body, _ := ioutil.ReadAll(resp.Body)
@@ -179,9 +531,49 @@ func (c *HTTPClient) GetConfig(ctx context.Context, configID string) (*models.Co
 		return nil, fmt.Errorf("解析配置响应失败: %w", err)
 	}
 	
+	if etag := resp.Header.Get("ETag"); etag != "" && version <= 0 {
+		c.configCacheMutex.Lock()
+		c.configCache[path] = &cachedConfig{etag: etag, config: config}
+		c.configCacheMutex.Unlock()
+	}
+	
 	return &config, nil
 }
 
+// cachedConfig 获取请求路径对应的缓存配置
+func (c *HTTPClient) cachedConfig(path string) *cachedConfig {
+	c.configCacheMutex.Lock()
+	defer c.configCacheMutex.Unlock()
+	return c.configCache[path]
+}
+
+// ResolveSecrets 获取配置引用的平台密钥值，平台只返回该配置引用的密钥
+func (c *HTTPClient) ResolveSecrets(ctx context.Context, agentID, configID string, names []string) (map[string]string, error) {
+	req := &models.ResolveSecretsRequest{
+		ConfigID: configID,
+		Names:    names,
+	}
+	
+	path := fmt.Sprintf("/api/v1/agents/%s/secrets/resolve", agentID)
+	resp, err := c.doRequest(ctx, "POST", path, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("获取密钥失败: %s - %s", resp.Status, string(body))
+	}
+	
+	var resolved models.ResolvedSecrets
+	if err := json.NewDecoder(resp.Body).Decode(&resolved); err != nil {
+		return nil, fmt.Errorf("解析密钥响应失败: %w", err)
+	}
+	
+	return resolved.Secrets, nil
+}
+
 // ReportConfigApplied 上报配置应用结果
 func (c *HTTPClient) ReportConfigApplied(ctx context.Context, agentID string, applied *models.AppliedConfig) error {
 	if applied == nil {
@@ -197,6 +589,15 @@ func (c *HTTPClient) ReportConfigApplied(ctx context.Context, agentID string, ap
 		"applied_at": applied.AppliedAt,
 		"status":     "success",
 	}
+	if applied.ReloadPending {
+		req["status"] = "reload_queued"
+	}
+	if applied.DeploymentID != "" {
+		req["deployment_id"] = applied.DeploymentID
+	}
+	if applied.Hash != "" {
+		req["hash"] = applied.Hash
+	}
 	
 	// 发送POST请求
 	path := fmt.Sprintf("/api/v1/agents/%s/configs/applied", agentID)
@@ -208,8 +609,186 @@ func (c *HTTPClient) ReportConfigApplied(ctx context.Context, agentID string, ap
 	
 	// 检查响应
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("上报配置应用结果失败: %w", statusError(resp))
+	}
+	
+	return nil
+}
+
+// ReportConfigFailed 上报配置部署失败，使平台的部署记录及时结束而不必等待超时
+func (c *HTTPClient) ReportConfigFailed(ctx context.Context, agentID string, applied *models.AppliedConfig, reason string) error {
+	return c.reportConfigFailure(ctx, agentID, applied, "failed", reason)
+}
+
+// ReportConfigApplyFailed 上报新配置验证失败，Agent已恢复之前的配置且未重载，output为验证输出
+func (c *HTTPClient) ReportConfigApplyFailed(ctx context.Context, agentID string, applied *models.AppliedConfig, output string) error {
+	return c.reportConfigFailure(ctx, agentID, applied, "apply_failed", output)
+}
+
+// reportConfigFailure 以指定状态上报配置部署失败
+func (c *HTTPClient) reportConfigFailure(ctx context.Context, agentID string, applied *models.AppliedConfig, status, reason string) error {
+	if applied == nil {
+		return fmt.Errorf("应用配置信息不能为空")
+	}
+	
+	req := map[string]interface{}{
+		"config_id":     applied.ConfigID,
+		"version":       applied.Version,
+		"status":        status,
+		"deployment_id": applied.DeploymentID,
+		"error":         reason,
+	}
+	
+	path := fmt.Sprintf("/api/v1/agents/%s/configs/applied", agentID)
+	resp, err := c.doRequest(ctx, "POST", path, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("上报配置部署失败结果失败: %s - %s", resp.Status, string(body))
+	}
+	
+	return nil
+}
+
+// ReportConfigRemoved 确认配置已从Agent上删除
+func (c *HTTPClient) ReportConfigRemoved(ctx context.Context, agentID, configID string) error {
+	req := map[string]interface{}{
+		"config_id":  configID,
+		"applied_at": time.Now(),
+		"status":     "removed",
+	}
+	
+	path := fmt.Sprintf("/api/v1/agents/%s/configs/applied", agentID)
+	resp, err := c.doRequest(ctx, "POST", path, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("上报配置删除结果失败: %s - %s", resp.Status, string(body))
+	}
+	
+	return nil
+}
+
+// GetDesiredConfigs 获取平台按部署记录推导的应运行的配置及版本
+func (c *HTTPClient) GetDesiredConfigs(ctx context.Context, agentID string) (*models.AgentDesiredConfigs, error) {
+	path := fmt.Sprintf("/api/v1/agents/%s/desired-configs", agentID)
+	resp, err := c.doRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode != http.StatusOK {
 		body, _ := ioutil.ReadAll(resp.Body)
-		return fmt.Errorf("上报配置应用结果失败: %s - %s", resp.Status, string(body))
+		return nil, fmt.Errorf("获取期望配置失败: %s - %s", resp.Status, string(body))
+	}
+	
+	var desired models.AgentDesiredConfigs
+	if err := json.NewDecoder(resp.Body).Decode(&desired); err != nil {
+		return nil, fmt.Errorf("解析期望配置失败: %w", err)
+	}
+	return &desired, nil
+}
+
+// Deregister 向平台注销Agent，平台归档后从Agent列表删除
+func (c *HTTPClient) Deregister(ctx context.Context, agentID, reason string) error {
+	path := fmt.Sprintf("/api/v1/agents/%s/deregister", agentID)
+	resp, err := c.doRequest(ctx, "POST", path, models.DeregisterRequest{Reason: reason})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("注销Agent失败: %s - %s", resp.Status, string(body))
+	}
+	
+	return nil
+}
+
+// PollMessages 长轮询接收平台消息，没有消息时平台等待最多wait时间后返回空的一批
+// received为上一次收到的批次序号，与平台记录不符时平台重发该批消息
+func (c *HTTPClient) PollMessages(ctx context.Context, agentID string, wait time.Duration, received uint64) (*models.PollResponse, error) {
+	// 平台最多等待wait时间，在此之上留出一个请求超时的余量
+	ctx, cancel := context.WithTimeout(context.WithValue(ctx, longPollKey{}, true), wait+c.config.RequestTimeout)
+	defer cancel()
+	
+	path := fmt.Sprintf("/api/v1/agents/%s/messages?wait=%s&received=%d", agentID, wait, received)
+	resp, err := c.doRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("长轮询接收消息失败: %s - %s", resp.Status, string(body))
+	}
+	
+	var poll models.PollResponse
+	if err := json.NewDecoder(resp.Body).Decode(&poll); err != nil {
+		return nil, fmt.Errorf("解析长轮询响应失败: %w", err)
+	}
+	return &poll, nil
+}
+
+// PostMessages 经长轮询通道上报消息
+func (c *HTTPClient) PostMessages(ctx context.Context, agentID string, messages []core.WebSocketMessage) error {
+	path := fmt.Sprintf("/api/v1/agents/%s/messages", agentID)
+	resp, err := c.doRequest(ctx, "POST", path, map[string]interface{}{
+		"messages": messages,
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode != http.StatusAccepted {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("经长轮询通道上报消息失败: %s - %s", resp.Status, string(body))
+	}
+	
+	return nil
+}
+
+// ReportEvent 上报重载失败、Logstash崩溃或重启事件到Agent事件时间线
+func (c *HTTPClient) ReportEvent(ctx context.Context, agentID string, event *models.AgentEventReport) error {
+	path := fmt.Sprintf("/api/v1/agents/%s/events", agentID)
+	resp, err := c.doRequest(ctx, "POST", path, event)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("上报Agent事件失败: %s - %s", resp.Status, string(body))
+	}
+	
+	return nil
+}
+
+// ReportPlugins 上报已安装的Logstash插件清单
+func (c *HTTPClient) ReportPlugins(ctx context.Context, agentID string, report *models.PluginInventoryReport) error {
+	path := fmt.Sprintf("/api/v1/agents/%s/plugins", agentID)
+	resp, err := c.doRequest(ctx, "POST", path, report)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("上报插件清单失败: %s - %s", resp.Status, string(body))
 	}
 	
 	return nil
@@ -234,25 +813,60 @@ func (c *HTTPClient) ReportMetrics(ctx context.Context, agentID string, metrics
 	
 	// 检查响应
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		body, _ := ioutil.ReadAll(resp.Body)
-		return fmt.Errorf("上报指标失败: %s - %s", resp.Status, string(body))
+		return fmt.Errorf("上报指标失败: %w", statusError(resp))
 	}
 	
 	return nil
 }
 
 // doRequest 执行HTTP请求
+// 配置了多个平台地址时，当前地址不可达（连接失败或502/503/504）则切换到下一个地址重试，每个地址最多尝试一次
 func (c *HTTPClient) doRequest(ctx context.Context, method, path string, body interface{}) (*http.Response, error) {
-	// 构建完整URL
-	fullURL := c.baseURL + path
-	
+	return c.doRequestWithHeader(ctx, method, path, body, nil)
+}
+
+// doRequestWithHeader 执行HTTP请求并附加额外的请求头
+func (c *HTTPClient) doRequestWithHeader(ctx context.Context, method, path string, body interface{}, header http.Header) (*http.Response, error) {
 	// 准备请求体
-	var reqBody io.Reader
+	var jsonBody []byte
 	if body != nil {
-		jsonBody, err := json.Marshal(body)
+		data, err := json.Marshal(body)
 		if err != nil {
 			return nil, fmt.Errorf("序列化请求体失败: %w", err)
 		}
+		jsonBody = data
+	}
+	
+	tried := make(map[int]bool, c.endpoints.Len())
+	for {
+		index, baseURL := c.endpoints.Current()
+		tried[index] = true
+		resp, err := c.sendRequest(ctx, baseURL.String()+path, method, jsonBody, header)
+		if err == nil || !errors.Is(err, ErrPlatformUnreachable) {
+			if err == nil {
+				c.endpoints.MarkHealthy(index)
+			}
+			return resp, err
+		}
+		
+		c.endpoints.MarkFailed(index)
+		if next, _ := c.endpoints.Current(); tried[next] || ctx.Err() != nil {
+			return nil, err
+		}
+		c.logger.WithError(err).WithField("path", path).Debug("平台地址不可达，使用下一个地址重试")
+	}
+}
+
+// statusError 读取非成功响应的状态和响应体
+func statusError(resp *http.Response) *StatusError {
+	body, _ := ioutil.ReadAll(resp.Body)
+	return &StatusError{StatusCode: resp.StatusCode, Status: resp.Status, Body: string(body)}
+}
+
+// sendRequest 向单个平台地址发送请求
+func (c *HTTPClient) sendRequest(ctx context.Context, fullURL, method string, jsonBody []byte, header http.Header) (*http.Response, error) {
+	var reqBody io.Reader
+	if jsonBody != nil {
 		reqBody = bytes.NewReader(jsonBody)
 	}
 	
@@ -267,10 +881,26 @@ func (c *HTTPClient) doRequest(ctx context.Context, method, path string, body in
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("User-Agent", fmt.Sprintf("LogstashAgent/%s", c.config.AgentID))
 	req.Header.Set("X-Agent-ID", c.config.AgentID)
+	// 处理平台消息时发出的请求携带消息的链路上下文
+	for key, value := range core.TraceMetadata(ctx) {
+		req.Header.Set(key, value)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
 	
 	// 设置认证
-	if c.config.Token != "" {
-		req.Header.Set("Authorization", "Bearer "+c.config.Token)
+	token := c.CurrentToken()
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	
+	// 使用Agent专属令牌时要求平台证明持有该令牌的记录，防止连到冒充的平台
+	proofKey, enrolled := agentauth.ProofKey(token)
+	nonce := ""
+	if enrolled {
+		nonce = agentauth.NewNonce()
+		req.Header.Set(agentauth.NonceHeader, nonce)
 	}
 	
 	// 记录请求
@@ -280,9 +910,20 @@ func (c *HTTPClient) doRequest(ctx context.Context, method, path string, body in
 	}).Debug("发送HTTP请求")
 	
 	// 执行请求
-	resp, err := c.httpClient.Do(req)
+	client := c.httpClient
+	if ctx.Value(longPollKey{}) != nil {
+		client = c.pollClient
+	}
+	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("执行请求失败: %w", err)
+		return nil, fmt.Errorf("执行请求失败: %w: %w", ErrPlatformUnreachable, err)
+	}
+	
+	// 平台重启或网关找不到后端时同样视为不可达
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %s", &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}, fullURL)
 	}
 	
 	// 记录响应
@@ -291,12 +932,21 @@ func (c *HTTPClient) doRequest(ctx context.Context, method, path string, body in
 		"url":    fullURL,
 	}).Debug("收到HTTP响应")
 	
+	if enrolled && resp.StatusCode >= 200 && resp.StatusCode < 300 &&
+		!agentauth.VerifyProof(proofKey, nonce, resp.Header.Get(agentauth.ProofHeader)) {
+		resp.Body.Close()
+		return nil, fmt.Errorf("平台身份校验失败: %s", fullURL)
+	}
+	
 	return resp, nil
 }
 
 // Close 关闭客户端
 func (c *HTTPClient) Close() error {
-	// HTTP客户端不需要特殊的关闭操作
+	// 停止捎带命令处理协程
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
 	return nil
 }
 
@@ -3,8 +3,12 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -12,7 +16,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"logstash-platform/internal/agent/config"
+	"logstash-platform/internal/agent/core"
 	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/agentauth"
 )
 
 func TestNewHTTPClient(t *testing.T) {
@@ -154,6 +160,30 @@ func TestHTTPClient_Register(t *testing.T) {
 	}
 }
 
+func TestHTTPClient_RegisterNegotiatedIntervals(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.WarnLevel)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(models.RegisterResponse{
+			Agent:     &models.Agent{AgentID: "test-agent"},
+			Telemetry: &models.TelemetryIntervals{HeartbeatInterval: 60, MetricsInterval: 120, FleetSize: 2000},
+		})
+	}))
+	defer server.Close()
+
+	client, err := NewHTTPClient(&config.AgentConfig{ServerURL: server.URL, AgentID: "test-agent"}, logger)
+	require.NoError(t, err)
+	assert.Nil(t, client.NegotiatedIntervals())
+
+	require.NoError(t, client.Register(context.Background(), &models.Agent{AgentID: "test-agent"}))
+	intervals := client.NegotiatedIntervals()
+	require.NotNil(t, intervals)
+	assert.Equal(t, 60, intervals.HeartbeatInterval)
+	assert.Equal(t, 120, intervals.MetricsInterval)
+}
+
 func TestHTTPClient_SendHeartbeat(t *testing.T) {
 	logger := logrus.New()
 	logger.SetLevel(logrus.WarnLevel)
@@ -377,6 +407,44 @@ func TestHTTPClient_GetConfig(t *testing.T) {
 	}
 }
 
+func TestHTTPClient_GetConfigNotModified(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	
+	var mu sync.Mutex
+	var conditional []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		conditional = append(conditional, r.Header.Get("If-None-Match"))
+		mu.Unlock()
+		if r.Header.Get("If-None-Match") == `W/"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `W/"v1"`)
+		json.NewEncoder(w).Encode(&models.Config{ID: "config-123", Content: "filter { }", Version: 1})
+	}))
+	defer server.Close()
+	
+	client, err := NewHTTPClient(&config.AgentConfig{ServerURL: server.URL, AgentID: "test-agent"}, logger)
+	require.NoError(t, err)
+	
+	// 第二次请求携带ETag，平台返回304时使用缓存的配置
+	first, err := client.GetConfig(context.Background(), "config-123")
+	require.NoError(t, err)
+	second, err := client.GetConfig(context.Background(), "config-123")
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+	
+	// 指定版本的请求不使用缓存
+	_, err = client.GetConfigVersion(context.Background(), "config-123", 1)
+	require.NoError(t, err)
+	
+	mu.Lock()
+	assert.Equal(t, []string{"", `W/"v1"`, ""}, conditional)
+	mu.Unlock()
+}
+
 func TestHTTPClient_ReportConfigApplied(t *testing.T) {
 	logger := logrus.New()
 	logger.SetLevel(logrus.WarnLevel)
@@ -464,6 +532,28 @@ func TestHTTPClient_ReportConfigApplied(t *testing.T) {
 	}
 }
 
+// TestHTTPClient_ReportStatusError 非成功响应返回带状态码的错误，暂存队列据此决定丢弃还是保留
+func TestHTTPClient_ReportStatusError(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.WarnLevel)
+
+	for _, code := range []int{http.StatusNotFound, http.StatusUnauthorized, http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusServiceUnavailable} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(code)
+		}))
+		client, err := NewHTTPClient(&config.AgentConfig{ServerURL: server.URL, AgentID: "test-agent"}, logger)
+		require.NoError(t, err)
+
+		err = client.ReportConfigApplied(context.Background(), "test-agent", &models.AppliedConfig{ConfigID: "config-123", Version: 1})
+		var statusErr *StatusError
+		require.True(t, errors.As(err, &statusErr), "%d: %v", code, err)
+		assert.Equal(t, code, statusErr.StatusCode)
+		assert.Equal(t, code == http.StatusNotFound, statusErr.Rejected())
+		assert.Equal(t, code == http.StatusServiceUnavailable, errors.Is(err, ErrPlatformUnreachable))
+		server.Close()
+	}
+}
+
 func TestHTTPClient_Timeout(t *testing.T) {
 	logger := logrus.New()
 	logger.SetLevel(logrus.WarnLevel)
@@ -521,6 +611,78 @@ func TestHTTPClient_Headers(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestHTTPClient_TraceHeaders(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.WarnLevel)
+	
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	var mu sync.Mutex
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received = append(received, r.Header.Get("traceparent"))
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	
+	client, err := NewHTTPClient(&config.AgentConfig{ServerURL: server.URL, AgentID: "test-agent"}, logger)
+	require.NoError(t, err)
+	
+	// 处理平台消息时的请求携带消息的链路上下文，其余请求不携带
+	ctx := core.WithTraceMetadata(context.Background(), map[string]string{"traceparent": traceparent})
+	require.NoError(t, client.SendHeartbeat(ctx, "test-agent"))
+	require.NoError(t, client.SendHeartbeat(context.Background(), "test-agent"))
+	
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{traceparent, ""}, received)
+}
+
+func TestHTTPClient_Failover(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	
+	var mu sync.Mutex
+	hits := map[string]int{}
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits["primary"]++
+		mu.Unlock()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits["secondary"]++
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	
+	client, err := NewHTTPClient(&config.AgentConfig{
+		ServerURL: unavailable.URL + ", " + healthy.URL,
+		AgentID:   "test-agent",
+	}, logger)
+	require.NoError(t, err)
+	
+	// 第一个地址不可用时在同一次请求中切换到第二个地址，之后保持使用第二个地址
+	require.NoError(t, client.SendHeartbeat(context.Background(), "test-agent"))
+	require.NoError(t, client.SendHeartbeat(context.Background(), "test-agent"))
+	mu.Lock()
+	assert.Equal(t, map[string]int{"primary": 1, "secondary": 2}, hits)
+	mu.Unlock()
+	index, _ := client.Endpoints().Current()
+	assert.Equal(t, 1, index)
+	
+	// 全部地址都不可达时返回ErrPlatformUnreachable，每个地址只尝试一次
+	healthy.Close()
+	err = client.SendHeartbeat(context.Background(), "test-agent")
+	assert.ErrorIs(t, err, ErrPlatformUnreachable)
+	mu.Lock()
+	assert.Equal(t, 2, hits["primary"])
+	mu.Unlock()
+}
+
 func TestHTTPClient_ContextCancellation(t *testing.T) {
 	logger := logrus.New()
 	logger.SetLevel(logrus.WarnLevel)
@@ -560,4 +722,176 @@ func TestHTTPClient_Close(t *testing.T) {
 
 	err = client.Close()
 	assert.NoError(t, err)
-}
\ No newline at end of file
+}
+func TestHTTPClient_SendHeartbeat_PiggybackedCommands(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.WarnLevel)
+
+	var mu sync.Mutex
+	var heartbeats []models.HeartbeatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req models.HeartbeatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		heartbeats = append(heartbeats, req)
+		mu.Unlock()
+
+		// 未确认前平台重复下发同一批命令
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"server_time":"2024-01-01T00:00:00Z","commands":[` +
+			`{"id":"cmd-1","type":"log_level","payload":{"level":"debug"}},` +
+			`{"id":"cmd-2","type":"maintenance","payload":{"enabled":true}}]}`))
+	}))
+	defer server.Close()
+
+	cfg := &config.AgentConfig{
+		ServerURL: server.URL,
+		AgentID:   "test-agent",
+	}
+	client, err := NewHTTPClient(cfg, logger)
+	require.NoError(t, err)
+	defer client.Close()
+
+	var received []string
+	client.SetCommandHandler(&mockMessageHandler{
+		handleFunc: func(msgType string, payload []byte) error {
+			mu.Lock()
+			received = append(received, msgType)
+			mu.Unlock()
+			return nil
+		},
+	})
+
+	err = client.SendHeartbeat(context.Background(), "test-agent")
+	assert.NoError(t, err)
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 2
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"log_level", "maintenance"}, received)
+
+	// 下一次心跳回传确认，重发的命令不再处理
+	err = client.SendHeartbeat(context.Background(), "test-agent")
+	assert.NoError(t, err)
+	time.Sleep(20 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, received, 2)
+	require.Len(t, heartbeats, 2)
+	assert.Empty(t, heartbeats[0].AckedCommands)
+	assert.Equal(t, []string{"cmd-1", "cmd-2"}, heartbeats[1].AckedCommands)
+}
+
+func TestHTTPClient_Enrollment(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	
+	// 模拟平台：用共享令牌签发专属令牌，专属令牌请求返回身份证明
+	issue := func(id string) string {
+		token, _, err := agentauth.Generate(id)
+		require.NoError(t, err)
+		return token
+	}
+	var mu sync.Mutex
+	current := issue("t1")
+	forge := false
+	enrollCalls := 0
+	
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		auth := r.Header.Get("Authorization")
+		switch r.URL.Path {
+		case "/api/v1/agents/enroll":
+			enrollCalls++
+			assert.Equal(t, "Bearer shared", auth)
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(models.AgentTokenResponse{AgentID: "agent-1", TokenID: "t1", Token: current})
+			return
+		}
+		
+		assert.Equal(t, "Bearer "+current, auth)
+		key, _ := agentauth.ProofKey(current)
+		if forge {
+			key = []byte("other")
+		}
+		w.Header().Set(agentauth.ProofHeader, agentauth.Proof(key, r.Header.Get(agentauth.NonceHeader)))
+		
+		if r.URL.Path == "/api/v1/agents/agent-1/token/rotate" {
+			current = issue("t2")
+			json.NewEncoder(w).Encode(models.AgentTokenResponse{AgentID: "agent-1", TokenID: "t2", Token: current})
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	
+	cfg := &config.AgentConfig{
+		ServerURL: server.URL,
+		AgentID:   "agent-1",
+		Token:     "shared",
+		DataDir:   t.TempDir(),
+	}
+	client, err := NewHTTPClient(cfg, logger)
+	require.NoError(t, err)
+	defer client.Close()
+	
+	ctx := context.Background()
+	require.NoError(t, client.EnsureEnrolled(ctx, "agent-1"))
+	assert.Equal(t, current, client.CurrentToken())
+	
+	// 令牌以0600权限保存
+	info, err := os.Stat(cfg.GetTokenFilePath())
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	
+	require.NoError(t, client.SendHeartbeat(ctx, "agent-1"))
+	
+	t.Run("重启后使用保存的令牌", func(t *testing.T) {
+		restarted, err := NewHTTPClient(cfg, logger)
+		require.NoError(t, err)
+		defer restarted.Close()
+		
+		require.NoError(t, restarted.EnsureEnrolled(ctx, "agent-1"))
+		assert.Equal(t, current, restarted.CurrentToken())
+		assert.Equal(t, 1, enrollCalls)
+	})
+	
+	t.Run("轮换", func(t *testing.T) {
+		require.NoError(t, client.RotateToken(ctx, "agent-1"))
+		assert.Equal(t, current, client.CurrentToken())
+		
+		saved, err := os.ReadFile(cfg.GetTokenFilePath())
+		require.NoError(t, err)
+		assert.Equal(t, current, strings.TrimSpace(string(saved)))
+		require.NoError(t, client.SendHeartbeat(ctx, "agent-1"))
+	})
+	
+	t.Run("平台身份证明错误", func(t *testing.T) {
+		mu.Lock()
+		forge = true
+		mu.Unlock()
+		
+		err := client.SendHeartbeat(ctx, "agent-1")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "平台身份校验失败")
+	})
+}
+
+func TestHTTPClient_EnrollmentUnsupported(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	
+	client, err := NewHTTPClient(&config.AgentConfig{ServerURL: server.URL, Token: "shared", DataDir: t.TempDir()}, logger)
+	require.NoError(t, err)
+	defer client.Close()
+	
+	// 旧版本平台没有注册接口时继续使用共享令牌
+	require.NoError(t, client.EnsureEnrolled(context.Background(), "agent-1"))
+	assert.Equal(t, "shared", client.CurrentToken())
+}
@@ -0,0 +1,198 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/agent/config"
+	"logstash-platform/internal/agent/core"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/wschunk"
+)
+
+// LongPollClient 经HTTP长轮询收发平台消息的客户端，供代理不允许WebSocket的网络使用
+// 平台推送的消息与WebSocket帧格式相同，上报的消息经POST发送，平台按WebSocket消息处理
+type LongPollClient struct {
+	config     *config.AgentConfig
+	logger     *logrus.Logger
+	httpClient *HTTPClient
+
+	// 分片消息重组
+	assembler *wschunk.Assembler
+
+	mu        sync.RWMutex
+	agentID   string
+	connected bool
+	received  uint64 // 已处理的最后一批消息的序号，下一次轮询回传给平台
+}
+
+// NewLongPollClient 创建长轮询客户端，请求经HTTP客户端发送，共享平台地址和认证令牌
+func NewLongPollClient(cfg *config.AgentConfig, httpClient *HTTPClient, logger *logrus.Logger) *LongPollClient {
+	return &LongPollClient{
+		config:     cfg,
+		logger:     logger,
+		httpClient: httpClient,
+		assembler:  wschunk.NewAssembler(cfg.WebSocketChunkTimeout, int(cfg.MaxConfigSize)),
+	}
+}
+
+// Run 持续长轮询直到ctx结束
+// 第一次轮询失败时返回错误；之后轮询失败视为连接断开，按重连间隔重试，恢复后视为重新连接
+func (p *LongPollClient) Run(ctx context.Context, agentID string, handler core.MessageHandler) error {
+	p.mu.Lock()
+	p.agentID = agentID
+	p.mu.Unlock()
+
+	resp, err := p.poll(ctx)
+	if err != nil {
+		return fmt.Errorf("长轮询连接失败: %w", err)
+	}
+
+	for {
+		if !p.IsConnected() {
+			p.setConnected(true)
+			if err := handler.OnConnect(); err != nil {
+				p.logger.WithError(err).Error("处理连接事件失败")
+			}
+		}
+		p.handleBatch(handler, resp)
+
+		for resp, err = p.poll(ctx); err != nil; resp, err = p.poll(ctx) {
+			if ctx.Err() != nil {
+				p.disconnect(handler, nil)
+				return nil
+			}
+			p.disconnect(handler, err)
+			select {
+			case <-time.After(p.config.ReconnectInterval):
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}
+}
+
+// poll 发出一次长轮询
+func (p *LongPollClient) poll(ctx context.Context) (*models.PollResponse, error) {
+	p.mu.RLock()
+	agentID, received := p.agentID, p.received
+	p.mu.RUnlock()
+	return p.httpClient.PollMessages(ctx, agentID, p.config.LongPollWait, received)
+}
+
+// handleBatch 按顺序处理一批消息，处理完后记录批次序号，平台据此不再重发该批消息
+func (p *LongPollClient) handleBatch(handler core.MessageHandler, resp *models.PollResponse) {
+	for _, frame := range resp.Messages {
+		p.handleMessage(handler, frame)
+	}
+
+	p.mu.Lock()
+	p.received = resp.Batch
+	p.mu.Unlock()
+}
+
+// handleMessage 处理一条消息，分片消息重组完整后按原始类型处理
+func (p *LongPollClient) handleMessage(handler core.MessageHandler, data []byte) {
+	var msg core.WebSocketMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		p.logger.WithError(err).Error("解析长轮询消息失败")
+		return
+	}
+
+	if msg.Type == wschunk.MsgType {
+		var envelope wschunk.Envelope
+		if err := json.Unmarshal(msg.Payload, &envelope); err != nil {
+			p.logger.WithError(err).Error("解析分片消息失败")
+			return
+		}
+		msgType, payload, done, err := p.assembler.Add(&envelope)
+		if err != nil {
+			p.logger.WithError(err).Warn("重组分片消息失败")
+			return
+		}
+		if !done {
+			return
+		}
+		msg.Type = msgType
+		msg.Payload = payload
+	}
+
+	p.logger.WithField("type", msg.Type).Debug("收到长轮询消息")
+
+	if err := dispatchMessage(handler, &msg, p.ack); err != nil {
+		p.logger.WithError(err).WithField("type", msg.Type).Error("处理消息失败")
+		p.Send(core.MsgTypeError, map[string]interface{}{
+			"error":    err.Error(),
+			"msg_type": msg.Type,
+		})
+	}
+}
+
+// Send 发送消息
+func (p *LongPollClient) Send(msgType string, payload interface{}) error {
+	return p.send(msgType, payload, nil)
+}
+
+// send 发送消息，metadata为随消息发送的链路上下文
+func (p *LongPollClient) send(msgType string, payload interface{}, metadata map[string]string) error {
+	p.mu.RLock()
+	agentID, connected := p.agentID, p.connected
+	p.mu.RUnlock()
+	if !connected {
+		return fmt.Errorf("长轮询未连接")
+	}
+
+	msg := core.WebSocketMessage{
+		Type:      msgType,
+		Timestamp: time.Now(),
+		Metadata:  metadata,
+	}
+	if payload != nil {
+		payloadBytes, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("序列化payload失败: %w", err)
+		}
+		msg.Payload = json.RawMessage(payloadBytes)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.config.RequestTimeout)
+	defer cancel()
+	return p.httpClient.PostMessages(ctx, agentID, []core.WebSocketMessage{msg})
+}
+
+// ack 确认已处理带ID的消息，发送失败时平台重新投递，由处理器去重
+func (p *LongPollClient) ack(id string) {
+	if err := p.Send(core.MsgTypeAck, models.MessageAck{IDs: []string{id}}); err != nil {
+		p.logger.WithError(err).WithField("id", id).Debug("确认消息失败")
+	}
+}
+
+// IsConnected 最近一次长轮询是否成功
+func (p *LongPollClient) IsConnected() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.connected
+}
+
+// setConnected 设置连接状态
+func (p *LongPollClient) setConnected(connected bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.connected = connected
+}
+
+// disconnect 已连接时标记断开并通知处理器
+func (p *LongPollClient) disconnect(handler core.MessageHandler, err error) {
+	p.mu.Lock()
+	wasConnected := p.connected
+	p.connected = false
+	p.mu.Unlock()
+
+	if wasConnected {
+		handler.OnDisconnect(err)
+	}
+}
@@ -4,18 +4,24 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
-	"net/url"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/sirupsen/logrus"
 	"logstash-platform/internal/agent/config"
 	"logstash-platform/internal/agent/core"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/wschunk"
 )
 
+// ErrWebSocketRejected 握手返回了非101响应，通常是代理不允许WebSocket升级；401（令牌无效）不属于此类
+var ErrWebSocketRejected = errors.New("WebSocket握手被拒绝")
+
 // WebSocketClient WebSocket客户端实现
 type WebSocketClient struct {
 	config    *config.AgentConfig
@@ -34,16 +40,53 @@ type WebSocketClient struct {
 	
 	// 重连管理
 	reconnectChan chan struct{}
+	
+	// 当前写入的开始时间（UnixNano），0表示没有进行中的写入
+	writeStartedAt int64
+	
+	// 分片消息重组
+	assembler *wschunk.Assembler
+	
+	// 连接时使用的认证令牌，未设置时使用配置中的令牌
+	tokenSource func() string
+	
+	// 平台地址，连接失败或断开时切换到下一个地址
+	endpoints     *EndpointSelector
+	endpointsErr  error // 解析配置的平台地址失败的原因
+	endpointIndex int   // 当前连接使用的地址序号
 }
 
 // NewWebSocketClient 创建WebSocket客户端
 func NewWebSocketClient(cfg *config.AgentConfig, logger *logrus.Logger) *WebSocketClient {
+	endpoints, err := NewEndpointSelector(cfg.ServerURLs(), cfg.FailoverCooldown, logger)
 	return &WebSocketClient{
 		config:        cfg,
 		logger:        logger,
 		closeChan:     make(chan struct{}),
 		reconnectChan: make(chan struct{}, 1),
+		assembler:     wschunk.NewAssembler(cfg.WebSocketChunkTimeout, int(cfg.MaxConfigSize)),
+		endpoints:     endpoints,
+		endpointsErr:  err,
+	}
+}
+
+// SetEndpoints 设置平台地址选择器，与HTTP客户端共享后两者使用同一个平台地址
+func (c *WebSocketClient) SetEndpoints(endpoints *EndpointSelector) {
+	c.endpoints = endpoints
+	c.endpointsErr = nil
+}
+
+// SetTokenSource 设置连接时获取认证令牌的函数，使重连使用轮换后的令牌
+func (c *WebSocketClient) SetTokenSource(source func() string) {
+	c.tokenSource = source
+}
+
+// currentToken 获取连接使用的认证令牌
+func (c *WebSocketClient) currentToken() string {
+	if c.tokenSource != nil {
+		return c.tokenSource()
 	}
+	return c.config.Token
 }
 
 // Connect 连接WebSocket
@@ -51,7 +94,7 @@ func (c *WebSocketClient) Connect(ctx context.Context, agentID string, handler c
 	c.handler = handler
 	
 	// 构建WebSocket URL
-	wsURL, err := c.buildWebSocketURL(agentID)
+	index, wsURL, err := c.buildWebSocketURL(agentID)
 	if err != nil {
 		return fmt.Errorf("构建WebSocket URL失败: %w", err)
 	}
@@ -63,25 +106,33 @@ func (c *WebSocketClient) Connect(ctx context.Context, agentID string, handler c
 	headers := http.Header{
 		"User-Agent": []string{fmt.Sprintf("LogstashAgent/%s", agentID)},
 	}
-	if c.config.Token != "" {
-		headers.Set("Authorization", "Bearer "+c.config.Token)
+	if token := c.currentToken(); token != "" {
+		headers.Set("Authorization", "Bearer "+token)
 	}
 	
 	// 连接WebSocket
 	c.logger.WithField("url", wsURL).Info("正在连接WebSocket...")
 	conn, resp, err := dialer.DialContext(ctx, wsURL, headers)
 	if err != nil {
+		// 下次连接使用下一个平台地址
+		c.endpoints.MarkFailed(index)
 		if resp != nil {
 			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusUnauthorized {
+				return fmt.Errorf("WebSocket连接失败 (HTTP %d): %w: %w", resp.StatusCode, ErrWebSocketRejected, err)
+			}
 			return fmt.Errorf("WebSocket连接失败 (HTTP %d): %w", resp.StatusCode, err)
 		}
 		return fmt.Errorf("WebSocket连接失败: %w", err)
 	}
 	
+	c.endpoints.MarkHealthy(index)
+	
 	// 保存连接
 	c.mu.Lock()
 	c.conn = conn
 	c.connected = true
+	c.endpointIndex = index
 	c.lastPong = time.Now()
 	c.mu.Unlock()
 	
@@ -117,6 +168,11 @@ func (c *WebSocketClient) Connect(ctx context.Context, agentID string, handler c
 
 // Send 发送消息
 func (c *WebSocketClient) Send(msgType string, payload interface{}) error {
+	return c.send(msgType, payload, nil)
+}
+
+// send 发送消息，metadata为随消息发送的链路上下文
+func (c *WebSocketClient) send(msgType string, payload interface{}, metadata map[string]string) error {
 	c.mu.RLock()
 	if !c.connected || c.conn == nil {
 		c.mu.RUnlock()
@@ -129,6 +185,7 @@ func (c *WebSocketClient) Send(msgType string, payload interface{}) error {
 	msg := core.WebSocketMessage{
 		Type:      msgType,
 		Timestamp: time.Now(),
+		Metadata:  metadata,
 	}
 	
 	// 序列化payload
@@ -146,25 +203,78 @@ func (c *WebSocketClient) Send(msgType string, payload interface{}) error {
 		return fmt.Errorf("序列化消息失败: %w", err)
 	}
 	
+	// 超过帧大小上限时分片发送
+	frames := [][]byte{msgBytes}
+	if limit := c.config.WebSocketMaxFrameSize; limit > 0 && len(msgBytes) > limit {
+		frames, err = c.buildChunkFrames(msgType, msg.Payload, metadata, limit)
+		if err != nil {
+			return err
+		}
+	}
+	
 	// 发送消息
 	c.logger.WithFields(logrus.Fields{
-		"type": msgType,
-		"size": len(msgBytes),
+		"type":   msgType,
+		"size":   len(msgBytes),
+		"frames": len(frames),
 	}).Debug("发送WebSocket消息")
 	
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	
-	// 设置写入超时
-	conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	atomic.StoreInt64(&c.writeStartedAt, time.Now().UnixNano())
+	defer atomic.StoreInt64(&c.writeStartedAt, 0)
 	
-	if err := conn.WriteMessage(websocket.TextMessage, msgBytes); err != nil {
-		return fmt.Errorf("发送消息失败: %w", err)
+	// 分片连续写入，每帧单独设置写入超时
+	for _, frame := range frames {
+		conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		if err := conn.WriteMessage(websocket.TextMessage, frame); err != nil {
+			return fmt.Errorf("发送消息失败: %w", err)
+		}
 	}
 	
 	return nil
 }
 
+// buildChunkFrames 将payload拆分为分片消息帧
+// 分片内容经base64编码后约膨胀1/3，按帧上限的一半切分以留出封包余量
+func (c *WebSocketClient) buildChunkFrames(msgType string, payload []byte, metadata map[string]string, limit int) ([][]byte, error) {
+	chunkSize := limit / 2
+	if chunkSize < 1 {
+		chunkSize = 1
+	}
+	
+	chunks := wschunk.Split(msgType, payload, chunkSize)
+	frames := make([][]byte, 0, len(chunks))
+	for i := range chunks {
+		envelope, err := json.Marshal(&chunks[i])
+		if err != nil {
+			return nil, fmt.Errorf("序列化分片失败: %w", err)
+		}
+		frame, err := json.Marshal(core.WebSocketMessage{
+			Type:      wschunk.MsgType,
+			Timestamp: time.Now(),
+			Payload:   envelope,
+			Metadata:  metadata,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("序列化分片失败: %w", err)
+		}
+		frames = append(frames, frame)
+	}
+	
+	return frames, nil
+}
+
+// WriteStartedAt 返回进行中的写入开始时间，没有写入时返回零值
+func (c *WebSocketClient) WriteStartedAt() time.Time {
+	started := atomic.LoadInt64(&c.writeStartedAt)
+	if started == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, started)
+}
+
 // Close 关闭连接
 func (c *WebSocketClient) Close() error {
 	c.mu.Lock()
@@ -294,6 +404,25 @@ func (c *WebSocketClient) handleMessage(data []byte) {
 		return
 	}
 	
+	// 分片消息先重组，完整后按原始类型处理
+	if msg.Type == wschunk.MsgType {
+		var envelope wschunk.Envelope
+		if err := json.Unmarshal(msg.Payload, &envelope); err != nil {
+			c.logger.WithError(err).Error("解析分片消息失败")
+			return
+		}
+		msgType, payload, done, err := c.assembler.Add(&envelope)
+		if err != nil {
+			c.logger.WithError(err).Warn("重组分片消息失败")
+			return
+		}
+		if !done {
+			return
+		}
+		msg.Type = msgType
+		msg.Payload = payload
+	}
+	
 	// 记录消息
 	c.logger.WithFields(logrus.Fields{
 		"type":      msg.Type,
@@ -302,7 +431,7 @@ func (c *WebSocketClient) handleMessage(data []byte) {
 	
 	// 处理消息
 	if c.handler != nil {
-		if err := c.handler.HandleMessage(msg.Type, msg.Payload); err != nil {
+		if err := dispatchMessage(c.handler, &msg, c.ack); err != nil {
 			c.logger.WithError(err).WithField("type", msg.Type).Error("处理消息失败")
 			
 			// 发送错误响应
@@ -314,6 +443,13 @@ func (c *WebSocketClient) handleMessage(data []byte) {
 	}
 }
 
+// ack 确认已处理带ID的消息，发送失败时平台在重新连接后重发，由处理器去重
+func (c *WebSocketClient) ack(id string) {
+	if err := c.Send(core.MsgTypeAck, models.MessageAck{IDs: []string{id}}); err != nil {
+		c.logger.WithError(err).WithField("id", id).Debug("确认消息失败")
+	}
+}
+
 // handleDisconnect 处理断开连接
 func (c *WebSocketClient) handleDisconnect(err error) {
 	c.mu.Lock()
@@ -324,6 +460,9 @@ func (c *WebSocketClient) handleDisconnect(err error) {
 	if wasConnected {
 		c.logger.WithError(err).Warn("WebSocket连接断开")
 		
+		// 平台节点断开连接时重连到下一个地址
+		c.endpoints.MarkFailed(c.endpointIndex)
+		
 		// 通知处理器
 		if c.handler != nil {
 			c.handler.OnDisconnect(err)
@@ -337,13 +476,12 @@ func (c *WebSocketClient) handleDisconnect(err error) {
 	}
 }
 
-// buildWebSocketURL 构建WebSocket URL
-func (c *WebSocketClient) buildWebSocketURL(agentID string) (string, error) {
-	// 解析服务器URL
-	u, err := url.Parse(c.config.ServerURL)
-	if err != nil {
-		return "", fmt.Errorf("解析服务器URL失败: %w", err)
+// buildWebSocketURL 按当前平台地址构建WebSocket URL，同时返回地址序号
+func (c *WebSocketClient) buildWebSocketURL(agentID string) (int, string, error) {
+	if c.endpointsErr != nil {
+		return 0, "", c.endpointsErr
 	}
+	index, u := c.endpoints.Current()
 	
 	// 转换协议
 	switch u.Scheme {
@@ -354,7 +492,7 @@ func (c *WebSocketClient) buildWebSocketURL(agentID string) (string, error) {
 	case "ws", "wss":
 		// 已经是WebSocket协议，保持不变
 	default:
-		return "", fmt.Errorf("不支持的协议: %s", u.Scheme)
+		return 0, "", fmt.Errorf("不支持的协议: %s", u.Scheme)
 	}
 	
 	// 设置路径
@@ -365,7 +503,7 @@ func (c *WebSocketClient) buildWebSocketURL(agentID string) (string, error) {
 	q.Set("agent_id", agentID)
 	u.RawQuery = q.Encode()
 	
-	return u.String(), nil
+	return index, u.String(), nil
 }
 
 // createDialer 创建WebSocket拨号器
@@ -16,6 +16,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"logstash-platform/internal/agent/config"
 	"logstash-platform/internal/agent/core"
+	"logstash-platform/pkg/wschunk"
 )
 
 var upgrader = websocket.Upgrader{
@@ -589,4 +590,85 @@ func (m *mockWSMessageHandler) OnDisconnect(err error) {
 	if m.disconnectFunc != nil {
 		m.disconnectFunc(err)
 	}
-}
\ No newline at end of file
+}
+func TestWebSocketClient_ChunkedMessages(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.WarnLevel)
+	
+	largeContent := strings.Repeat("filter { mutate { add_field => { \"k\" => \"v\" } } }\n", 200)
+	largePayload, _ := json.Marshal(map[string]string{"content": largeContent})
+	
+	received := make(chan []byte, 1)
+	handler := &mockWSMessageHandler{
+		handleFunc: func(msgType string, payload []byte) error {
+			if msgType == "config_deploy" {
+				received <- payload
+			}
+			return nil
+		},
+	}
+	
+	serverFrames := make(chan int, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		
+		// 平台侧分片下发
+		for _, chunk := range wschunk.Split("config_deploy", largePayload, 1024) {
+			envelope, _ := json.Marshal(chunk)
+			conn.WriteJSON(core.WebSocketMessage{Type: wschunk.MsgType, Timestamp: time.Now(), Payload: envelope})
+		}
+		
+		// 接收Agent分片上报并重组
+		assembler := wschunk.NewAssembler(time.Minute, 0)
+		frames := 0
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			frames++
+			var msg core.WebSocketMessage
+			json.Unmarshal(data, &msg)
+			var envelope wschunk.Envelope
+			json.Unmarshal(msg.Payload, &envelope)
+			if msgType, _, done, _ := assembler.Add(&envelope); done && msgType == "metrics_report" {
+				serverFrames <- frames
+				return
+			}
+		}
+	}))
+	defer server.Close()
+	
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+	cfg := &config.AgentConfig{
+		ServerURL:             wsURL,
+		AgentID:               "test-agent",
+		WebSocketPingInterval: 30 * time.Second,
+		WebSocketMaxFrameSize: 2048,
+		WebSocketChunkTimeout: time.Minute,
+	}
+	
+	client := NewWebSocketClient(cfg, logger)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go client.Connect(ctx, "test-agent", handler)
+	
+	select {
+	case payload := <-received:
+		assert.JSONEq(t, string(largePayload), string(payload))
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for chunked message")
+	}
+	
+	require.NoError(t, client.Send("metrics_report", map[string]string{"content": largeContent}))
+	select {
+	case frames := <-serverFrames:
+		assert.Greater(t, frames, 1)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for chunked upload")
+	}
+}
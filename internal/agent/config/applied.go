@@ -0,0 +1,142 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+)
+
+// appliedFileName 已应用配置集合的持久化文件，与元数据文件同在配置目录下
+const appliedFileName = ".applied.json"
+
+// SaveAppliedConfigs 持久化Agent当前的已应用配置集合，先写临时文件再改名，进程中途退出不会留下半个文件
+func (m *Manager) SaveAppliedConfigs(applied []models.AppliedConfig) error {
+	m.appliedMux.Lock()
+	defer m.appliedMux.Unlock()
+
+	if applied == nil {
+		applied = []models.AppliedConfig{}
+	}
+	data, err := json.MarshalIndent(applied, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化已应用配置失败: %w", err)
+	}
+
+	path := filepath.Join(m.config.ConfigDir, appliedFileName)
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("写入已应用配置失败: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("写入已应用配置失败: %w", err)
+	}
+	return nil
+}
+
+// LoadAppliedConfigs 加载上次运行时持久化的已应用配置集合，并与配置目录中的实际文件核对：
+// 文件已不存在的配置移除，哈希按文件当前内容重新计算；有元数据但不在集合中的配置
+// （升级前的Agent保存的配置）按元数据补入。Logstash启动时会加载全部配置，重载挂起标记一律清除
+func (m *Manager) LoadAppliedConfigs() ([]models.AppliedConfig, error) {
+	m.appliedMux.Lock()
+	defer m.appliedMux.Unlock()
+
+	var stored []models.AppliedConfig
+	data, err := ioutil.ReadFile(filepath.Join(m.config.ConfigDir, appliedFileName))
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &stored); err != nil {
+			m.logger.WithError(err).Warn("已应用配置文件损坏，按配置元数据重建")
+			stored = nil
+		}
+	case !os.IsNotExist(err):
+		return nil, fmt.Errorf("读取已应用配置失败: %w", err)
+	}
+
+	applied := make([]models.AppliedConfig, 0, len(stored))
+	seen := make(map[string]bool, len(stored))
+	for _, entry := range stored {
+		if entry.ConfigID == "" || seen[entry.ConfigID] {
+			continue
+		}
+		hash, ok := m.appliedFileHash(entry.ConfigID)
+		if !ok {
+			continue
+		}
+		if entry.Hash != "" && entry.Hash != hash {
+			m.logger.WithFields(logrus.Fields{
+				"config_id": entry.ConfigID,
+				"expected":  entry.Hash,
+				"actual":    hash,
+			}).Warn("配置文件在Agent停止期间被修改")
+		}
+		entry.Hash = hash
+		entry.ReloadPending = false
+		seen[entry.ConfigID] = true
+		applied = append(applied, entry)
+	}
+
+	restored, err := m.metadataAppliedConfigs(seen)
+	if err != nil {
+		return nil, err
+	}
+	return append(applied, restored...), nil
+}
+
+// metadataAppliedConfigs 按元数据目录中的配置元数据生成skip之外的已应用配置，按配置ID排序
+func (m *Manager) metadataAppliedConfigs(skip map[string]bool) ([]models.AppliedConfig, error) {
+	files, err := ioutil.ReadDir(filepath.Join(m.config.ConfigDir, ".metadata"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("读取元数据目录失败: %w", err)
+	}
+
+	var applied []models.AppliedConfig
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		configID := strings.TrimSuffix(file.Name(), ".json")
+		if skip[configID] {
+			continue
+		}
+		metadata, err := m.loadConfigMetadata(configID)
+		if err != nil {
+			m.logger.WithError(err).WithField("config_id", configID).Warn("加载配置元数据失败，跳过该配置")
+			continue
+		}
+		hash, ok := m.appliedFileHash(configID)
+		if !ok {
+			continue
+		}
+		applied = append(applied, models.AppliedConfig{
+			ConfigID:  configID,
+			Version:   metadata.Version,
+			AppliedAt: metadata.AppliedAt,
+			Hash:      hash,
+		})
+	}
+
+	sort.Slice(applied, func(i, j int) bool { return applied[i].ConfigID < applied[j].ConfigID })
+	return applied, nil
+}
+
+// appliedFileHash 计算配置文件当前内容的哈希，文件不存在时返回false
+func (m *Manager) appliedFileHash(configID string) (string, bool) {
+	content, err := ioutil.ReadFile(m.GetConfigPath(configID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			m.logger.WithField("config_id", configID).Warn("已应用的配置文件不存在，从已应用配置中移除")
+		} else {
+			m.logger.WithError(err).WithField("config_id", configID).Warn("读取已应用的配置文件失败，从已应用配置中移除")
+		}
+		return "", false
+	}
+	return m.calculateHash(string(content)), true
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+)
+
+func TestManager_AppliedConfigs(t *testing.T) {
+	manager, tempDir := createTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	// 尚未持久化过时为空
+	applied, err := manager.LoadAppliedConfigs()
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+
+	for _, id := range []string{"nginx", "kafka", "legacy"} {
+		require.NoError(t, manager.SaveConfig(&models.Config{ID: id, Version: 2, Content: "input { stdin {} } # " + id}))
+	}
+	appliedAt := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	require.NoError(t, manager.SaveAppliedConfigs([]models.AppliedConfig{
+		{ConfigID: "nginx", Version: 2, AppliedAt: appliedAt, DeploymentID: "dep-1", ReloadPending: true, Hash: models.ContentHash("input { stdin {} } # nginx")},
+		{ConfigID: "kafka", Version: 2, AppliedAt: appliedAt, Hash: models.ContentHash("input { stdin {} } # kafka")},
+		{ConfigID: "removed", Version: 1, AppliedAt: appliedAt},
+	}))
+
+	// Agent停止期间kafka被修改
+	require.NoError(t, ioutil.WriteFile(manager.GetConfigPath("kafka"), []byte("input { stdin {} } # edited"), 0644))
+
+	applied, err = manager.LoadAppliedConfigs()
+	require.NoError(t, err)
+	require.Len(t, applied, 3)
+
+	assert.Equal(t, "nginx", applied[0].ConfigID)
+	assert.Equal(t, "dep-1", applied[0].DeploymentID)
+	assert.True(t, applied[0].AppliedAt.Equal(appliedAt))
+	assert.False(t, applied[0].ReloadPending, "重启后Logstash会加载全部配置")
+
+	assert.Equal(t, "kafka", applied[1].ConfigID)
+	assert.Equal(t, models.ContentHash("input { stdin {} } # edited"), applied[1].Hash, "哈希按文件当前内容计算")
+
+	// 文件已不存在的配置被移除，未记录在集合中但有元数据的配置按元数据补入
+	assert.Equal(t, "legacy", applied[2].ConfigID)
+	assert.Equal(t, 2, applied[2].Version)
+	assert.Equal(t, models.ContentHash("input { stdin {} } # legacy"), applied[2].Hash)
+
+	// 持久化文件损坏时按元数据重建
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tempDir, appliedFileName), []byte("{"), 0644))
+	applied, err = manager.LoadAppliedConfigs()
+	require.NoError(t, err)
+	assert.Len(t, applied, 3)
+}
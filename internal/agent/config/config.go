@@ -5,17 +5,25 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
+	"logstash-platform/pkg/logger"
 )
 
 // AgentConfig Agent配置
 type AgentConfig struct {
 	// 基础配置
 	AgentID      string `yaml:"agent_id"`       // Agent唯一标识
-	ServerURL    string `yaml:"server_url"`     // 管理平台地址
-	Token        string `yaml:"token"`          // 认证令牌
+	ServerURL    string `yaml:"server_url"`     // 管理平台地址，多个平台节点以逗号分隔，按顺序故障转移
+	Token        string `yaml:"token"`          // 认证令牌（共享令牌，启用注册时作为引导令牌申请Agent专属令牌）
+	TokenFile    string `yaml:"token_file"`     // Agent专属令牌的保存路径，为空时保存在 data_dir/agent-token
+	TokenRotateInterval time.Duration `yaml:"token_rotate_interval"` // Agent专属令牌的轮换间隔，0表示不轮换
+	Group        string            `yaml:"group"`  // 所属分组（分组默认设置由平台下发）
+	Project      string            `yaml:"project"` // 所属项目，平台只将同一项目的配置部署到该Agent，为空时属于默认项目
+	Environment  string            `yaml:"environment"` // 所在环境，平台按环境渲染配置引用的下游集群
+	Labels       map[string]string `yaml:"labels"` // Agent标签
 	
 	// Logstash配置
 	LogstashPath    string `yaml:"logstash_path"`     // Logstash执行文件路径
@@ -24,17 +32,32 @@ type AgentConfig struct {
 	LogDir          string `yaml:"log_dir"`           // 日志目录
 	PipelineWorkers int    `yaml:"pipeline_workers"`  // Pipeline工作线程数
 	BatchSize       int    `yaml:"batch_size"`        // 批处理大小
+	LogstashAPIURL  string `yaml:"logstash_api_url"`  // Logstash监控API地址，用于采集管道、队列和JVM统计
+	PipelineMode    string `yaml:"pipeline_mode"`     // 管道组织方式：merged所有配置合并为一个管道，isolated每个配置独立为一个管道
+	LogstashSettingsDir  string `yaml:"logstash_settings_dir"`  // Logstash设置目录（keystore所在目录），为空时使用Logstash默认目录
+	LogstashKeystorePath string `yaml:"logstash_keystore_path"` // logstash-keystore工具路径，为空时取Logstash可执行文件同目录
+	SecretInjection      string `yaml:"secret_injection"`       // 配置引用的平台密钥注入方式：keystore写入keystore，file直接写入配置文件
 	
 	// 通信配置
 	HeartbeatInterval   time.Duration `yaml:"heartbeat_interval"`    // 心跳间隔
+	HeartbeatTransport  string        `yaml:"heartbeat_transport"`   // 心跳通道：http始终经HTTP发送，websocket在WebSocket已连接时经WebSocket发送
 	MetricsInterval     time.Duration `yaml:"metrics_interval"`      // 指标上报间隔
+	MinHeartbeatInterval time.Duration `yaml:"min_heartbeat_interval"` // 平台协商心跳间隔时允许的最小值
+	MaxHeartbeatInterval time.Duration `yaml:"max_heartbeat_interval"` // 平台协商心跳间隔时允许的最大值
+	MinMetricsInterval   time.Duration `yaml:"min_metrics_interval"`   // 平台协商指标上报间隔时允许的最小值
+	MaxMetricsInterval   time.Duration `yaml:"max_metrics_interval"`   // 平台协商指标上报间隔时允许的最大值
 	ReconnectInterval   time.Duration `yaml:"reconnect_interval"`    // 重连间隔
+	FailoverCooldown    time.Duration `yaml:"failover_cooldown"`     // 配置多个平台地址时，连接失败的地址在该时间内不再被选中
 	RequestTimeout      time.Duration `yaml:"request_timeout"`       // 请求超时
 	MaxReconnectAttempts int          `yaml:"max_reconnect_attempts"` // 最大重连次数
 	
 	// WebSocket配置
 	EnableWebSocket     bool          `yaml:"enable_websocket"`      // 是否启用WebSocket
 	WebSocketPingInterval time.Duration `yaml:"websocket_ping_interval"` // WebSocket Ping间隔
+	WebSocketMaxFrameSize int           `yaml:"websocket_max_frame_size"` // 单帧消息大小上限，超过时分片发送，0表示不分片
+	WebSocketChunkTimeout time.Duration `yaml:"websocket_chunk_timeout"`  // 分片消息重组超时
+	MessageTransport      string        `yaml:"message_transport"`        // 接收平台消息的通道：auto优先WebSocket、握手被代理拒绝时改用长轮询，websocket，long_poll
+	LongPollWait          time.Duration `yaml:"long_poll_wait"`           // 长轮询没有消息时的等待时间，超过平台上限时按平台上限
 	
 	// 安全配置
 	TLSEnabled     bool   `yaml:"tls_enabled"`      // 是否启用TLS
@@ -48,8 +71,55 @@ type AgentConfig struct {
 	ConfigBackupCount  int    `yaml:"config_backup_count"`   // 配置备份数量
 	EnableAutoReload   bool   `yaml:"enable_auto_reload"`    // 是否启用自动重载
 	ReloadDebounceTime time.Duration `yaml:"reload_debounce_time"` // 重载防抖时间
+	ReloadBudget       int           `yaml:"reload_budget"`        // 每个窗口内最多执行的重载次数，0表示不限制
+	ReloadBudgetWindow time.Duration `yaml:"reload_budget_window"` // 重载预算的统计窗口
+	ValidationCacheTTL  time.Duration `yaml:"validation_cache_ttl"`  // 配置验证结果缓存时间，0表示不缓存
+	ValidationCacheSize int           `yaml:"validation_cache_size"` // 配置验证结果缓存条数上限
+	WatchdogInterval    time.Duration `yaml:"watchdog_interval"`     // 看门狗检查间隔，0表示不启用
+	DriftCheckInterval  time.Duration `yaml:"drift_check_interval"`  // 配置漂移检查间隔，发现外部修改时重载，0表示不启用
+	CrashRestartDelay   time.Duration `yaml:"crash_restart_delay"`   // Logstash意外退出后自动重启前的等待时间，0表示不自动重启
+	ReconcileConfigs    bool          `yaml:"reconcile_configs"`     // 启动和WebSocket重新连接后按平台的期望配置补齐缺失的配置、删除多余的配置
+	DeregisterOnShutdown bool         `yaml:"deregister_on_shutdown"` // 关闭时向平台注销，平台归档后从Agent列表删除，适用于随主机销毁的Agent
+
+	// 资源保护：超过阈值时上报warning状态并拒绝部署新配置，恢复后自动解除
+	ResourceCheckInterval time.Duration `yaml:"resource_check_interval"` // 资源检查间隔，0表示不启用
+	DiskUsageThreshold    float64       `yaml:"disk_usage_threshold"`    // data_dir、log_dir所在磁盘的使用率上限（%），0表示不检查
+	MemoryUsageThreshold  float64       `yaml:"memory_usage_threshold"`  // 主机内存使用率上限（%），0表示不检查
+	PauseReloadOnPressure bool          `yaml:"pause_reload_on_pressure"` // 超过阈值时暂停自动重载和崩溃后的自动重启，恢复后补做
+
+	// 插件清单：启动时及之后每隔一段时间上报 logstash-plugin list --verbose 的结果，平台部署前据此检查配置引用的插件
+	PluginInventoryInterval time.Duration `yaml:"plugin_inventory_interval"` // 重新上报的间隔，0表示只在启动时上报
+	PluginBundleDir         string        `yaml:"plugin_bundle_dir"`         // 允许以file://安装的离线包所在目录，为空时只接受http(s)离线包
+
+	// 日志配置
+	Logging logger.Config `yaml:"logging"` // Agent自身的日志级别、格式和输出，modules可为client、logstash等模块单独设置级别
 }
 
+// 配置在Logstash中的管道组织方式
+const (
+	PipelineModeMerged   = "merged"   // 所有配置写入config_dir，由Logstash合并为一个管道
+	PipelineModeIsolated = "isolated" // Agent管理settings目录下的pipelines.yml，每个配置独立为一个管道，可单独设置参数和重载
+)
+
+// 平台密钥的注入方式
+const (
+	SecretInjectionKeystore = "keystore" // 写入Logstash keystore，配置文件中替换为 ${名称}
+	SecretInjectionFile     = "file"     // 直接替换为密钥值写入配置文件
+)
+
+// 心跳的发送通道
+const (
+	HeartbeatTransportHTTP      = "http"      // 经HTTP发送，响应捎带待处理命令
+	HeartbeatTransportWebSocket = "websocket" // WebSocket已连接时发送心跳消息，未连接时降级到HTTP，平台可在连接断开时立即判定离线
+)
+
+// 接收平台消息的通道
+const (
+	MessageTransportAuto      = "auto"      // 优先WebSocket，握手被代理拒绝（非101且非401响应）时改用长轮询
+	MessageTransportWebSocket = "websocket" // 只使用WebSocket
+	MessageTransportLongPoll  = "long_poll" // 只使用HTTP长轮询，适用于已知不允许WebSocket的网络
+)
+
 // DefaultConfig 返回默认配置
 func DefaultConfig() *AgentConfig {
 	return &AgentConfig{
@@ -63,15 +133,27 @@ func DefaultConfig() *AgentConfig {
 		LogDir:          "/var/log/logstash",
 		PipelineWorkers: 2,
 		BatchSize:       125,
+		LogstashAPIURL:  "http://localhost:9600",
+		PipelineMode:    PipelineModeMerged,
 		
 		HeartbeatInterval:    30 * time.Second,
+		HeartbeatTransport:   HeartbeatTransportHTTP,
 		MetricsInterval:      60 * time.Second,
+		MinHeartbeatInterval: 10 * time.Second,
+		MaxHeartbeatInterval: 5 * time.Minute,
+		MinMetricsInterval:   30 * time.Second,
+		MaxMetricsInterval:   time.Hour,
 		ReconnectInterval:    5 * time.Second,
+		FailoverCooldown:     30 * time.Second,
 		RequestTimeout:       30 * time.Second,
 		MaxReconnectAttempts: 10,
 		
 		EnableWebSocket:       true,
 		WebSocketPingInterval: 30 * time.Second,
+		WebSocketMaxFrameSize: 256 * 1024,
+		WebSocketChunkTimeout: 30 * time.Second,
+		MessageTransport:      MessageTransportAuto,
+		LongPollWait:          30 * time.Second,
 		
 		TLSEnabled:     false,
 		TLSCertFile:    "",
@@ -83,6 +165,32 @@ func DefaultConfig() *AgentConfig {
 		ConfigBackupCount:  3,
 		EnableAutoReload:   true,
 		ReloadDebounceTime: 5 * time.Second,
+		ReloadBudget:       10,
+		ReloadBudgetWindow: 5 * time.Minute,
+		ValidationCacheTTL:  24 * time.Hour,
+		ValidationCacheSize: 256,
+		WatchdogInterval:    30 * time.Second,
+		DriftCheckInterval:  0,
+		CrashRestartDelay:   10 * time.Second,
+		ReconcileConfigs:    true,
+		ResourceCheckInterval: 30 * time.Second,
+		DiskUsageThreshold:    90,
+		MemoryUsageThreshold:  95,
+		PluginInventoryInterval: 6 * time.Hour,
+		SecretInjection:     SecretInjectionKeystore,
+
+		Logging: logger.Config{
+			Level:  "info",
+			Format: logger.FormatText,
+			Output: logger.OutputStdout,
+			File: logger.FileConfig{
+				Path:       "/var/log/logstash-agent/agent.log",
+				MaxSize:    100,
+				MaxBackups: 5,
+				MaxAge:     30,
+				Compress:   true,
+			},
+		},
 	}
 }
 
@@ -123,6 +231,11 @@ func (c *AgentConfig) Validate() error {
 	if c.ServerURL == "" {
 		return fmt.Errorf("server_url 不能为空")
 	}
+	for _, serverURL := range strings.Split(c.ServerURL, ",") {
+		if strings.TrimSpace(serverURL) == "" {
+			return fmt.Errorf("server_url 中存在空地址")
+		}
+	}
 
 	if c.LogstashPath == "" {
 		return fmt.Errorf("logstash_path 不能为空")
@@ -155,6 +268,48 @@ func (c *AgentConfig) Validate() error {
 	if c.MetricsInterval < 30*time.Second {
 		return fmt.Errorf("metrics_interval 不能小于30秒")
 	}
+	
+	if c.HeartbeatTransport != "" && c.HeartbeatTransport != HeartbeatTransportHTTP && c.HeartbeatTransport != HeartbeatTransportWebSocket {
+		return fmt.Errorf("heartbeat_transport 只能为 http 或 websocket")
+	}
+	
+	switch c.MessageTransport {
+	case "", MessageTransportAuto, MessageTransportWebSocket, MessageTransportLongPoll:
+	default:
+		return fmt.Errorf("message_transport 只能为 auto、websocket 或 long_poll")
+	}
+	
+	if c.MaxHeartbeatInterval > 0 && c.MinHeartbeatInterval > c.MaxHeartbeatInterval {
+		return fmt.Errorf("min_heartbeat_interval 不能大于 max_heartbeat_interval")
+	}
+	
+	if c.MaxMetricsInterval > 0 && c.MinMetricsInterval > c.MaxMetricsInterval {
+		return fmt.Errorf("min_metrics_interval 不能大于 max_metrics_interval")
+	}
+	
+	if c.SecretInjection != "" && c.SecretInjection != SecretInjectionKeystore && c.SecretInjection != SecretInjectionFile {
+		return fmt.Errorf("secret_injection 只能为 keystore 或 file")
+	}
+
+	if c.PipelineMode != "" && c.PipelineMode != PipelineModeMerged && c.PipelineMode != PipelineModeIsolated {
+		return fmt.Errorf("pipeline_mode 只能为 merged 或 isolated")
+	}
+	
+	if c.PipelineMode == PipelineModeIsolated && c.LogstashSettingsDir == "" {
+		return fmt.Errorf("pipeline_mode 为 isolated 时必须设置 logstash_settings_dir")
+	}
+
+	if c.DiskUsageThreshold < 0 || c.DiskUsageThreshold > 100 {
+		return fmt.Errorf("disk_usage_threshold 必须在0到100之间")
+	}
+
+	if c.MemoryUsageThreshold < 0 || c.MemoryUsageThreshold > 100 {
+		return fmt.Errorf("memory_usage_threshold 必须在0到100之间")
+	}
+
+	if err := c.Logging.Validate(); err != nil {
+		return fmt.Errorf("logging 配置无效: %w", err)
+	}
 
 	// 验证TLS配置
 	if c.TLSEnabled {
@@ -181,6 +336,40 @@ func (c *AgentConfig) Validate() error {
 	return nil
 }
 
+// ServerURLs 获取配置的全部平台地址，按故障转移的顺序排列
+func (c *AgentConfig) ServerURLs() []string {
+	var urls []string
+	for _, serverURL := range strings.Split(c.ServerURL, ",") {
+		if serverURL = strings.TrimSpace(serverURL); serverURL != "" {
+			urls = append(urls, serverURL)
+		}
+	}
+	return urls
+}
+
+// GetTokenFilePath 获取Agent专属令牌的保存路径
+func (c *AgentConfig) GetTokenFilePath() string {
+	if c.TokenFile != "" {
+		return c.TokenFile
+	}
+	return filepath.Join(c.DataDir, "agent-token")
+}
+
+// GetOutboxDir 获取平台不可达时暂存上报的目录
+func (c *AgentConfig) GetOutboxDir() string {
+	return filepath.Join(c.DataDir, "outbox")
+}
+
+// IsolatedPipelines 是否每个配置独立为一个管道
+func (c *AgentConfig) IsolatedPipelines() bool {
+	return c.PipelineMode == PipelineModeIsolated
+}
+
+// GetPipelinesFilePath 获取Agent管理的pipelines.yml路径
+func (c *AgentConfig) GetPipelinesFilePath() string {
+	return filepath.Join(c.LogstashSettingsDir, "pipelines.yml")
+}
+
 // GetLogstashConfigPath 获取Logstash配置文件完整路径
 func (c *AgentConfig) GetLogstashConfigPath(configID string) string {
 	return fmt.Sprintf("%s/%s.conf", c.ConfigDir, configID)
@@ -8,6 +8,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"logstash-platform/pkg/logger"
 )
 
 func TestLoadFromFile(t *testing.T) {
@@ -43,6 +44,33 @@ tls_enabled: false
 				assert.False(t, cfg.TLSEnabled)
 			},
 		},
+		{
+			name: "load logging config",
+			setupFunc: func() (string, error) {
+				configPath := filepath.Join(t.TempDir(), "config.yaml")
+				content := `
+server_url: "http://localhost:8080"
+logging:
+  level: warn
+  format: json
+  output: file
+  file:
+    path: /tmp/agent.log
+  modules:
+    client: debug
+`
+				return configPath, os.WriteFile(configPath, []byte(content), 0644)
+			},
+			expectError: false,
+			validate: func(t *testing.T, cfg *AgentConfig) {
+				assert.Equal(t, "warn", cfg.Logging.Level)
+				assert.Equal(t, logger.FormatJSON, cfg.Logging.Format)
+				assert.Equal(t, "/tmp/agent.log", cfg.Logging.File.Path)
+				// 未设置的文件轮转参数沿用默认值
+				assert.Equal(t, 100, cfg.Logging.File.MaxSize)
+				assert.Equal(t, map[string]string{"client": "debug"}, cfg.Logging.Modules)
+			},
+		},
 		{
 			name: "load config with defaults",
 			setupFunc: func() (string, error) {
@@ -201,6 +229,58 @@ func TestValidate(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			name: "isolated pipelines without settings dir",
+			config: &AgentConfig{
+				ServerURL:         "http://localhost:8080",
+				LogstashPath:      logstashPath,
+				ConfigDir:         filepath.Join(tmpDir, "conf.d"),
+				HeartbeatInterval: 30 * time.Second,
+				MetricsInterval:   60 * time.Second,
+				PipelineMode:      PipelineModeIsolated,
+			},
+			expectError: true,
+			errorMsg:    "pipeline_mode 为 isolated 时必须设置 logstash_settings_dir",
+		},
+		{
+			name: "invalid module log level",
+			config: &AgentConfig{
+				ServerURL:         "http://localhost:8080",
+				LogstashPath:      logstashPath,
+				ConfigDir:         filepath.Join(tmpDir, "conf.d"),
+				HeartbeatInterval: 30 * time.Second,
+				MetricsInterval:   60 * time.Second,
+				Logging:           logger.Config{Modules: map[string]string{"client": "chatty"}},
+			},
+			expectError: true,
+			errorMsg:    "logging 配置无效",
+		},
+		{
+			name: "disk threshold out of range",
+			config: &AgentConfig{
+				ServerURL:          "http://localhost:8080",
+				LogstashPath:       logstashPath,
+				ConfigDir:          filepath.Join(tmpDir, "conf.d"),
+				HeartbeatInterval:  30 * time.Second,
+				MetricsInterval:    60 * time.Second,
+				DiskUsageThreshold: 120,
+			},
+			expectError: true,
+			errorMsg:    "disk_usage_threshold 必须在0到100之间",
+		},
+		{
+			name: "unknown heartbeat transport",
+			config: &AgentConfig{
+				ServerURL:          "http://localhost:8080",
+				LogstashPath:       logstashPath,
+				ConfigDir:          filepath.Join(tmpDir, "conf.d"),
+				HeartbeatInterval:  30 * time.Second,
+				MetricsInterval:    60 * time.Second,
+				HeartbeatTransport: "grpc",
+			},
+			expectError: true,
+			errorMsg:    "heartbeat_transport 只能为 http 或 websocket",
+		},
 		{
 			name: "missing server URL",
 			config: &AgentConfig{
@@ -213,6 +293,19 @@ func TestValidate(t *testing.T) {
 			expectError: true,
 			errorMsg:    "server_url 不能为空",
 		},
+		{
+			name: "empty server URL in list",
+			config: &AgentConfig{
+				ServerURL:         "http://platform-1:8080,,http://platform-2:8080",
+				AgentID:           "test-agent",
+				LogstashPath:      logstashPath,
+				ConfigDir:         filepath.Join(tmpDir, "conf.d"),
+				HeartbeatInterval: 30 * time.Second,
+				MetricsInterval:   60 * time.Second,
+			},
+			expectError: true,
+			errorMsg:    "server_url 中存在空地址",
+		},
 		{
 			name: "missing logstash path",
 			config: &AgentConfig{
@@ -326,6 +419,14 @@ func TestDefaultConfig(t *testing.T) {
 	assert.True(t, cfg.EnableAutoReload)
 }
 
+func TestServerURLs(t *testing.T) {
+	cfg := &AgentConfig{ServerURL: "http://platform-1:8080, http://platform-2:8080"}
+	assert.Equal(t, []string{"http://platform-1:8080", "http://platform-2:8080"}, cfg.ServerURLs())
+	
+	cfg.ServerURL = "http://localhost:8080"
+	assert.Equal(t, []string{"http://localhost:8080"}, cfg.ServerURLs())
+}
+
 func TestGetLogstashConfigPath(t *testing.T) {
 	cfg := &AgentConfig{
 		ConfigDir: "/etc/logstash/conf.d",
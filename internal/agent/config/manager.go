@@ -2,6 +2,7 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -15,6 +16,12 @@ import (
 	"logstash-platform/internal/platform/models"
 )
 
+// ErrConfigIntegrity 本地配置文件与保存时记录的哈希不一致，文件可能被篡改或截断
+var ErrConfigIntegrity = errors.New("配置文件完整性校验失败")
+
+// ErrConfigTooLarge 配置内容超过 max_config_size
+var ErrConfigTooLarge = errors.New("配置内容超过大小上限")
+
 // Manager 配置管理器实现
 type Manager struct {
 	config     *AgentConfig
@@ -26,6 +33,12 @@ type Manager struct {
 	
 	// 元数据文件路径
 	metadataFile string
+	
+	// 独立管道模式下串行重写pipelines.yml
+	pipelinesMux sync.Mutex
+	
+	// 串行读写已应用配置文件
+	appliedMux sync.Mutex
 }
 
 // ConfigMetadata 配置元数据
@@ -35,7 +48,8 @@ type ConfigMetadata struct {
 	FilePath    string    `json:"file_path"`
 	BackupPaths []string  `json:"backup_paths"`
 	AppliedAt   time.Time `json:"applied_at"`
-	Hash        string    `json:"hash"`
+	Hash        string    `json:"hash"` // 保存时配置内容的SHA-256，早期版本写入的元数据为空
+	PipelineSettings *models.PipelineSettings `json:"pipeline_settings,omitempty"` // 独立管道模式下写入pipelines.yml的管道参数
 }
 
 // NewManager 创建配置管理器
@@ -68,6 +82,13 @@ func NewManager(cfg *AgentConfig, logger *logrus.Logger) (*Manager, error) {
 		logger.WithError(err).Warn("加载配置元数据失败")
 	}
 	
+	// 按已保存的配置重新生成pipelines.yml，从合并模式切换过来时已有配置各自成为独立管道
+	if cfg.IsolatedPipelines() {
+		if err := manager.writePipelines(); err != nil {
+			return nil, err
+		}
+	}
+	
 	return manager, nil
 }
 
@@ -75,6 +96,13 @@ func NewManager(cfg *AgentConfig, logger *logrus.Logger) (*Manager, error) {
 func (m *Manager) SaveConfig(config *models.Config) error {
 	m.logger.WithField("config_id", config.ID).Info("保存配置")
 	
+	if err := m.checkConfigID(config.ID); err != nil {
+		return err
+	}
+	if limit := m.config.MaxConfigSize; limit > 0 && int64(len(config.Content)) > limit {
+		return fmt.Errorf("%w: %s 为 %d 字节，上限 %d 字节", ErrConfigTooLarge, config.ID, len(config.Content), limit)
+	}
+	
 	// 获取配置文件路径
 	configPath := m.GetConfigPath(config.ID)
 	
@@ -111,6 +139,7 @@ func (m *Manager) SaveConfig(config *models.Config) error {
 		FilePath:  configPath,
 		AppliedAt: time.Now(),
 		Hash:      m.calculateHash(config.Content),
+		PipelineSettings: config.PipelineSettings,
 	}
 	
 	// 保留现有的备份路径
@@ -122,6 +151,12 @@ func (m *Manager) SaveConfig(config *models.Config) error {
 		m.logger.WithError(err).Warn("保存配置元数据失败")
 	}
 	
+	if m.config.IsolatedPipelines() {
+		if err := m.writePipelines(); err != nil {
+			return err
+		}
+	}
+	
 	m.logger.WithFields(logrus.Fields{
 		"config_id": config.ID,
 		"version":   config.Version,
@@ -133,6 +168,10 @@ func (m *Manager) SaveConfig(config *models.Config) error {
 
 // LoadConfig 加载本地配置
 func (m *Manager) LoadConfig(configID string) (*models.Config, error) {
+	if err := m.checkConfigID(configID); err != nil {
+		return nil, err
+	}
+	
 	// 先从缓存查找
 	m.configsMux.RLock()
 	if config, ok := m.configs[configID]; ok {
@@ -159,11 +198,17 @@ func (m *Manager) LoadConfig(configID string) (*models.Config, error) {
 		}, nil
 	}
 	
+	// 校验文件内容与保存时一致
+	if err := m.checkHash(configID, metadata, m.calculateHash(string(content))); err != nil {
+		return nil, err
+	}
+	
 	// 创建配置对象
 	config := &models.Config{
 		ID:      configID,
 		Version: metadata.Version,
 		Content: string(content),
+		PipelineSettings: metadata.PipelineSettings,
 	}
 	
 	// 更新缓存
@@ -174,10 +219,36 @@ func (m *Manager) LoadConfig(configID string) (*models.Config, error) {
 	return config, nil
 }
 
+// VerifyConfig 校验本地配置文件与保存时记录的哈希一致，返回文件当前内容的哈希
+// 元数据中没有哈希（早期版本保存）时不做比较
+func (m *Manager) VerifyConfig(configID string) (string, error) {
+	if err := m.checkConfigID(configID); err != nil {
+		return "", err
+	}
+	content, err := ioutil.ReadFile(m.GetConfigPath(configID))
+	if err != nil {
+		return "", fmt.Errorf("读取配置文件失败: %w", err)
+	}
+	
+	hash := m.calculateHash(string(content))
+	metadata, err := m.loadConfigMetadata(configID)
+	if err != nil {
+		return "", fmt.Errorf("加载配置元数据失败: %w", err)
+	}
+	if err := m.checkHash(configID, metadata, hash); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
 // DeleteConfig 删除本地配置
 func (m *Manager) DeleteConfig(configID string) error {
 	m.logger.WithField("config_id", configID).Info("删除配置")
 	
+	if err := m.checkConfigID(configID); err != nil {
+		return err
+	}
+	
 	// 获取配置文件路径
 	configPath := m.GetConfigPath(configID)
 	
@@ -201,6 +272,12 @@ func (m *Manager) DeleteConfig(configID string) error {
 		m.logger.WithError(err).Warn("删除配置元数据失败")
 	}
 	
+	if m.config.IsolatedPipelines() {
+		if err := m.writePipelines(); err != nil {
+			return err
+		}
+	}
+	
 	return nil
 }
 
@@ -246,6 +323,9 @@ func (m *Manager) GetConfigPath(configID string) string {
 
 // BackupConfig 备份配置
 func (m *Manager) BackupConfig(configID string) error {
+	if err := m.checkConfigID(configID); err != nil {
+		return err
+	}
 	configPath := m.GetConfigPath(configID)
 	
 	// 检查配置文件是否存在
@@ -306,6 +386,10 @@ func (m *Manager) BackupConfig(configID string) error {
 
 // RestoreConfig 恢复配置
 func (m *Manager) RestoreConfig(configID string) error {
+	if err := m.checkConfigID(configID); err != nil {
+		return err
+	}
+	
 	// 加载元数据
 	metadata, err := m.loadConfigMetadata(configID)
 	if err != nil {
@@ -331,6 +415,9 @@ func (m *Manager) RestoreConfig(configID string) error {
 		return fmt.Errorf("恢复配置文件失败: %w", err)
 	}
 	
+	// 哈希随恢复的内容更新，否则恢复后的文件无法通过完整性校验
+	metadata.Hash = m.calculateHash(string(content))
+	
 	// 从备份路径提取版本号
 	// 格式: xxx.conf.backup.{version}
 	parts := strings.Split(backupPath, ".")
@@ -339,10 +426,10 @@ func (m *Manager) RestoreConfig(configID string) error {
 			if version, err := strconv.Atoi(versionStr); err == nil {
 				// 更新元数据中的版本号
 				metadata.Version = version
-				m.saveConfigMetadata(configID, metadata)
 			}
 		}
 	}
+	m.saveConfigMetadata(configID, metadata)
 	
 	// 清除缓存，强制重新加载
 	m.configsMux.Lock()
@@ -463,8 +550,45 @@ func (m *Manager) deleteConfigMetadata(configID string) error {
 
 // calculateHash 计算配置内容哈希
 func (m *Manager) calculateHash(content string) string {
-	// TODO: 实现哈希计算
-	return ""
+	return models.ContentHash(content)
+}
+
+// checkHash 比较配置文件当前的哈希与元数据中记录的哈希
+func (m *Manager) checkHash(configID string, metadata *ConfigMetadata, actual string) error {
+	if metadata.Hash == "" {
+		return nil
+	}
+	if actual != metadata.Hash {
+		m.logger.WithFields(logrus.Fields{
+			"config_id": configID,
+			"expected":  metadata.Hash,
+			"actual":    actual,
+		}).Error("配置文件哈希不匹配")
+		return fmt.Errorf("%w: %s 的哈希为 %s，保存时为 %s，文件可能被篡改或截断", ErrConfigIntegrity, configID, actual, metadata.Hash)
+	}
+	return nil
+}
+
+
+// checkConfigID 检查配置ID能安全地用作文件名
+// 配置ID来自平台消息，拼接出的配置、备份和元数据路径必须留在ConfigDir内，防止 ../ 等ID写到任意位置
+func (m *Manager) checkConfigID(configID string) error {
+	if err := models.ValidateConfigID(configID); err != nil {
+		return err
+	}
+	
+	dir := filepath.Clean(m.config.ConfigDir)
+	for _, path := range []string{
+		m.GetConfigPath(configID),
+		m.config.GetConfigBackupPath(configID, 0),
+		filepath.Join(dir, ".metadata", configID+".json"),
+	} {
+		rel, err := filepath.Rel(dir, filepath.Clean(path))
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("%w: %q，文件路径 %s 超出配置目录 %s", models.ErrInvalidConfigID, configID, path, dir)
+		}
+	}
+	return nil
 }
 
 // isConfigFile 检查是否为配置文件
@@ -175,6 +175,43 @@ func TestManager_LoadConfig(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestManager_VerifyConfig(t *testing.T) {
+	manager, tempDir := createTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	config := &models.Config{
+		ID:      "test-config",
+		Content: "input { stdin {} }",
+		Version: 1,
+	}
+	require.NoError(t, manager.SaveConfig(config))
+
+	hash, err := manager.VerifyConfig("test-config")
+	require.NoError(t, err)
+	assert.Len(t, hash, 64)
+	assert.Equal(t, models.ContentHash(config.Content), hash)
+
+	metadata, err := manager.loadConfigMetadata("test-config")
+	require.NoError(t, err)
+	assert.Equal(t, hash, metadata.Hash)
+
+	// 文件被截断后校验失败，重新从文件加载时同样拒绝
+	require.NoError(t, ioutil.WriteFile(manager.GetConfigPath("test-config"), []byte("input { std"), 0644))
+	_, err = manager.VerifyConfig("test-config")
+	assert.ErrorIs(t, err, ErrConfigIntegrity)
+
+	reopened, err := NewManager(manager.config, manager.logger)
+	require.NoError(t, err)
+	_, err = reopened.LoadConfig("test-config")
+	assert.ErrorIs(t, err, ErrConfigIntegrity)
+
+	// 元数据中没有哈希时不做比较
+	metadata.Hash = ""
+	require.NoError(t, manager.saveConfigMetadata("test-config", metadata))
+	_, err = manager.VerifyConfig("test-config")
+	assert.NoError(t, err)
+}
+
 func TestManager_DeleteConfig(t *testing.T) {
 	manager, tempDir := createTestManager(t)
 	defer os.RemoveAll(tempDir)
@@ -346,9 +383,32 @@ func TestManager_ValidateConfigSize(t *testing.T) {
 		Version: 1,
 	}
 
-	// 保存应该成功（因为当前没有大小限制检查）
+	// 超过 max_config_size 时拒绝保存，不写入配置文件
 	err := manager.SaveConfig(config)
-	assert.NoError(t, err)
+	assert.ErrorIs(t, err, ErrConfigTooLarge)
+	assert.NoFileExists(t, manager.GetConfigPath(config.ID))
+
+	config.Content = string(largeContent[:1024])
+	assert.NoError(t, manager.SaveConfig(config))
+}
+
+func TestManager_RejectsInvalidConfigID(t *testing.T) {
+	manager, tempDir := createTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	// 配置ID来自平台消息，路径穿越的ID不能写到配置目录之外
+	outside := filepath.Join(tempDir, "..", filepath.Base(tempDir)+"-escaped")
+	defer os.Remove(outside + ".conf")
+	id := "../" + filepath.Base(outside)
+
+	err := manager.SaveConfig(&models.Config{ID: id, Content: "input { stdin {} }", Version: 1})
+	assert.ErrorIs(t, err, models.ErrInvalidConfigID)
+	assert.NoFileExists(t, outside+".conf")
+
+	_, err = manager.LoadConfig(id)
+	assert.ErrorIs(t, err, models.ErrInvalidConfigID)
+	assert.ErrorIs(t, manager.DeleteConfig("../../etc/cron.d/x"), models.ErrInvalidConfigID)
+	assert.ErrorIs(t, manager.RestoreConfig(".metadata"), models.ErrInvalidConfigID)
 }
 
 func TestManager_BackupRotation(t *testing.T) {
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// pipelinesHeader 写在Agent生成的pipelines.yml开头，提示不要手动修改
+const pipelinesHeader = "# 由Logstash Agent根据已部署的配置生成，手动修改会在下一次部署时被覆盖\n"
+
+// pipelineEntry pipelines.yml中的一个管道
+type pipelineEntry struct {
+	ID            string `yaml:"pipeline.id"`
+	PathConfig    string `yaml:"path.config"`
+	Workers       int    `yaml:"pipeline.workers,omitempty"`
+	BatchSize     int    `yaml:"pipeline.batch.size,omitempty"`
+	QueueType     string `yaml:"queue.type,omitempty"`
+	QueueMaxBytes string `yaml:"queue.max_bytes,omitempty"`
+}
+
+// writePipelines 按本地已保存的配置重新生成pipelines.yml，每个配置一个管道，管道ID为配置ID
+// 未设置的管道参数不写入，沿用logstash.yml和命令行中的全局设置；先写临时文件再改名，
+// 开启自动重载时Logstash只会重新加载内容或参数发生变化的管道
+func (m *Manager) writePipelines() error {
+	m.pipelinesMux.Lock()
+	defer m.pipelinesMux.Unlock()
+
+	entries, err := m.pipelineEntries()
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("序列化pipelines.yml失败: %w", err)
+	}
+
+	path := m.config.GetPipelinesFilePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建Logstash设置目录失败: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, append([]byte(pipelinesHeader), data...), 0644); err != nil {
+		return fmt.Errorf("写入pipelines.yml失败: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("写入pipelines.yml失败: %w", err)
+	}
+
+	m.logger.WithField("pipelines", len(entries)).Debug("已更新pipelines.yml")
+	return nil
+}
+
+// pipelineEntries 从元数据目录收集已保存的配置，按配置ID排序保证生成的文件稳定
+func (m *Manager) pipelineEntries() ([]pipelineEntry, error) {
+	metadataDir := filepath.Join(m.config.ConfigDir, ".metadata")
+	files, err := ioutil.ReadDir(metadataDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("读取元数据目录失败: %w", err)
+	}
+
+	entries := []pipelineEntry{}
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		configID := strings.TrimSuffix(file.Name(), ".json")
+		metadata, err := m.loadConfigMetadata(configID)
+		if err != nil {
+			m.logger.WithError(err).WithField("config_id", configID).Warn("加载配置元数据失败，跳过该管道")
+			continue
+		}
+
+		entry := pipelineEntry{ID: configID, PathConfig: m.GetConfigPath(configID)}
+		if settings := metadata.PipelineSettings; settings != nil {
+			entry.Workers = settings.Workers
+			entry.BatchSize = settings.BatchSize
+			entry.QueueType = settings.QueueType
+			entry.QueueMaxBytes = settings.QueueMaxBytes
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+	return entries, nil
+}
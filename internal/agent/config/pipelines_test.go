@@ -0,0 +1,80 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+	"logstash-platform/internal/platform/models"
+)
+
+func TestManager_IsolatedPipelines(t *testing.T) {
+	cfg := &AgentConfig{
+		ConfigDir:           t.TempDir(),
+		LogstashSettingsDir: t.TempDir(),
+		PipelineMode:        PipelineModeIsolated,
+		ConfigBackupCount:   3,
+	}
+	manager, err := NewManager(cfg, logrus.New())
+	require.NoError(t, err)
+
+	readPipelines := func() []pipelineEntry {
+		data, err := ioutil.ReadFile(filepath.Join(cfg.LogstashSettingsDir, "pipelines.yml"))
+		require.NoError(t, err)
+		var entries []pipelineEntry
+		require.NoError(t, yaml.Unmarshal(data, &entries))
+		return entries
+	}
+	assert.Empty(t, readPipelines(), "启动时生成空的pipelines.yml")
+
+	require.NoError(t, manager.SaveConfig(&models.Config{
+		ID:      "nginx",
+		Version: 1,
+		Content: "input { beats { port => 5044 } }\noutput { stdout {} }",
+		PipelineSettings: &models.PipelineSettings{
+			Workers:       4,
+			QueueType:     models.QueueTypePersisted,
+			QueueMaxBytes: "1gb",
+		},
+	}))
+	require.NoError(t, manager.SaveConfig(&models.Config{
+		ID:      "audit",
+		Version: 2,
+		Content: "input { stdin {} }\noutput { stdout {} }",
+	}))
+
+	assert.Equal(t, []pipelineEntry{
+		{ID: "audit", PathConfig: filepath.Join(cfg.ConfigDir, "audit.conf")},
+		{ID: "nginx", PathConfig: filepath.Join(cfg.ConfigDir, "nginx.conf"), Workers: 4, QueueType: "persisted", QueueMaxBytes: "1gb"},
+	}, readPipelines())
+
+	// 管道参数随元数据保存，Agent重启后仍可读取
+	restarted, err := NewManager(cfg, logrus.New())
+	require.NoError(t, err)
+	loaded, err := restarted.LoadConfig("nginx")
+	require.NoError(t, err)
+	require.NotNil(t, loaded.PipelineSettings)
+	assert.Equal(t, 4, loaded.PipelineSettings.Workers)
+
+	require.NoError(t, restarted.DeleteConfig("nginx"))
+	entries := readPipelines()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "audit", entries[0].ID)
+}
+
+func TestManager_MergedPipelinesSkipsPipelinesFile(t *testing.T) {
+	cfg := &AgentConfig{
+		ConfigDir:           t.TempDir(),
+		LogstashSettingsDir: t.TempDir(),
+		PipelineMode:        PipelineModeMerged,
+		ConfigBackupCount:   3,
+	}
+	manager, err := NewManager(cfg, logrus.New())
+	require.NoError(t, err)
+	require.NoError(t, manager.SaveConfig(&models.Config{ID: "nginx", Version: 1, Content: "input { stdin {} }"}))
+	assert.NoFileExists(t, filepath.Join(cfg.LogstashSettingsDir, "pipelines.yml"))
+}
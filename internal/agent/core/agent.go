@@ -3,10 +3,13 @@ package core
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"os"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -40,6 +43,51 @@ type Agent struct {
 	
 	// 启动时间
 	startTime    time.Time
+	
+	// 维护模式下暂停配置变更和重载，退出时恢复进入前的状态
+	maintenance  bool
+	preMaintenanceStatus string
+	
+	// 看门狗：消息循环最近一次运转时间（UnixNano）及当前循环代数
+	watchdog     *Watchdog
+	loopAlive    int64
+	loopGen      int64
+	handleMu     sync.Mutex // 串行化消息处理，看门狗重启循环时保证不会并发处理
+	
+	// 重载协调器：所有来源的重载共享同一预算
+	reloads      *ReloadCoordinator
+	
+	// 配置漂移监测，未启用时为nil
+	drift        *DriftWatcher
+	
+	// 平台协商的心跳/指标间隔下限，0表示不限制
+	intervalFloor models.TelemetryIntervals
+	
+	// 经WebSocket推送实时日志和诊断结果，客户端不支持WebSocket发送时为nil
+	sender       MessageSender
+	logTails     *LogTailer
+	diagnostics  *Diagnostics
+	
+	// 资源保护，未启用或未设置采样函数时为nil；超过阈值期间推迟的重载来源和崩溃重启
+	sampleResources ResourceSampler
+	resources       *ResourceGuard
+	deferredReloads []string
+	deferredRestart string
+	
+	// 执行 logstash-plugin list --verbose，客户端支持上报插件清单时使用
+	listPlugins     PluginLister
+	// 最近处理过的平台消息ID，平台重发的消息据此去重
+	processed *processedMessages
+	
+	// 执行 logstash-plugin install/update，pluginInstalling保证同时只执行一个安装
+	runPlugin        PluginCommand
+	pluginInstalling sync.Mutex
+	
+	// 串行持久化已应用配置
+	appliedMux sync.Mutex
+	
+	// 请求按平台的期望配置核对本地配置，容量为1，核对期间的多次请求合并为一次
+	reconcileCh chan struct{}
 }
 
 // NewAgent 创建新的Agent实例
@@ -63,6 +111,8 @@ func NewAgent(cfg *config.AgentConfig, logger *logrus.Logger) (*Agent, error) {
 		config:    cfg,
 		logger:    logger,
 		msgChan:   make(chan *WebSocketMessage, 100),
+		processed: newProcessedMessages(processedMessageLimit),
+		reconcileCh: make(chan struct{}, 1),
 		startTime: time.Now(),
 		status: &models.Agent{
 			AgentID:         cfg.AgentID,
@@ -72,8 +122,28 @@ func NewAgent(cfg *config.AgentConfig, logger *logrus.Logger) (*Agent, error) {
 			Status:          "offline",
 			LastHeartbeat:   time.Now(),
 			AppliedConfigs:  []models.AppliedConfig{},
+			Group:           cfg.Group,
+			Labels:          cfg.Labels,
+			Project:         cfg.Project,
+			Environment:     cfg.Environment,
 		},
 	}
+	agent.reloads = NewReloadCoordinator(cfg.ReloadBudget, cfg.ReloadBudgetWindow, func(ctx context.Context) error {
+		err := agent.verifyAppliedConfigs()
+		if err == nil {
+			err = agent.logstashCtrl.Reload(ctx)
+		}
+		if err != nil {
+			agent.reportEvent(models.AgentEventReloadFailed, err.Error())
+		}
+		return err
+	}, logger)
+	agent.reloads.OnFlush(agent.onReloadFlushed)
+	// 短时间内连续下发或删除多个配置时只在静默期后重载一次
+	agent.reloads.SetDebounce(cfg.ReloadDebounceTime)
+	agent.diagnostics = NewDiagnostics(cfg)
+	agent.listPlugins = logstashPluginLister(cfg.LogstashPath)
+	agent.runPlugin = logstashPluginCommand(cfg.LogstashPath)
 	
 	return agent, nil
 }
@@ -81,6 +151,10 @@ func NewAgent(cfg *config.AgentConfig, logger *logrus.Logger) (*Agent, error) {
 // WithAPIClient 设置API客户端
 func (a *Agent) WithAPIClient(client APIClient) *Agent {
 	a.apiClient = client
+	if sender, ok := client.(MessageSender); ok {
+		a.sender = sender
+		a.logTails = NewLogTailer(a.config.LogDir, sender, a.logger)
+	}
 	return a
 }
 
@@ -108,6 +182,12 @@ func (a *Agent) WithMetricsCollector(collector MetricsCollector) *Agent {
 	return a
 }
 
+// WithResourceSampler 设置资源保护使用的采样函数
+func (a *Agent) WithResourceSampler(sample ResourceSampler) *Agent {
+	a.sampleResources = sample
+	return a
+}
+
 // Start 启动Agent
 func (a *Agent) Start(ctx context.Context) error {
 	a.logger.Info("正在启动Agent...")
@@ -120,12 +200,18 @@ func (a *Agent) Start(ctx context.Context) error {
 		return fmt.Errorf("组件验证失败: %w", err)
 	}
 	
+	// 恢复上次运行的已应用配置，注册时一并上报
+	a.restoreAppliedConfigs()
+	
 	// 注册到管理平台
 	if err := a.Register(a.ctx); err != nil {
 		return fmt.Errorf("注册到管理平台失败: %w", err)
 	}
 	
-	// 启动Logstash
+	// 启动Logstash，进程意外退出时上报并按配置自动重启
+	if notifier, ok := a.logstashCtrl.(ExitNotifier); ok {
+		notifier.OnUnexpectedExit(a.onLogstashExit)
+	}
 	if err := a.logstashCtrl.Start(a.ctx); err != nil {
 		a.logger.WithError(err).Error("启动Logstash失败")
 		// 不返回错误，允许Agent继续运行
@@ -138,6 +224,12 @@ func (a *Agent) Start(ctx context.Context) error {
 		})
 	}
 	
+	// 上报插件清单，logstash-plugin 启动JVM较慢，在后台执行
+	if reporter, ok := a.apiClient.(PluginReporter); ok && a.listPlugins != nil {
+		a.wg.Add(1)
+		go a.runPluginInventory(reporter)
+	}
+	
 	// 启动心跳服务
 	if err := a.heartbeat.Start(a.ctx); err != nil {
 		return fmt.Errorf("启动心跳服务失败: %w", err)
@@ -159,9 +251,50 @@ func (a *Agent) Start(ctx context.Context) error {
 	a.wg.Add(1)
 	go a.processMessages()
 	
-	// 更新状态为在线
+	// 启动看门狗
+	if a.config.WatchdogInterval > 0 {
+		a.startWatchdog()
+	}
+	
+	// 启动配置漂移监测
+	if a.config.DriftCheckInterval > 0 {
+		a.drift = NewDriftWatcher(a.config.ConfigDir, a.config.DriftCheckInterval, a.onConfigDrift, a.logger)
+		a.wg.Add(1)
+		go func() {
+			defer a.wg.Done()
+			a.drift.Run(a.ctx)
+		}()
+	}
+	
+	// 启动资源保护
+	if a.config.ResourceCheckInterval > 0 && a.sampleResources != nil {
+		a.resources = NewResourceGuard([]string{a.config.DataDir, a.config.LogDir}, ResourceLimits{
+			DiskPercent:   a.config.DiskUsageThreshold,
+			MemoryPercent: a.config.MemoryUsageThreshold,
+		}, a.config.ResourceCheckInterval, a.sampleResources, a.onResourcePressure, a.logger)
+		a.wg.Add(1)
+		go func() {
+			defer a.wg.Done()
+			a.resources.Run(a.ctx)
+		}()
+	}
+	
+	// 按平台的期望配置补齐本地配置，WebSocket重新连接时再次核对
+	if fetcher, ok := a.apiClient.(DesiredConfigFetcher); ok && a.config.ReconcileConfigs {
+		a.wg.Add(1)
+		go a.runReconcile(fetcher)
+		a.requestReconcile()
+	}
+	
+	// 启动令牌轮换
+	if rotator, ok := a.apiClient.(TokenRotator); ok && a.config.TokenRotateInterval > 0 {
+		a.wg.Add(1)
+		go a.rotateTokens(rotator)
+	}
+	
+	// 更新状态为在线，资源保护首次检查已超过阈值时为warning
 	a.updateStatus(func(s *models.Agent) {
-		s.Status = "online"
+		s.Status = healthyStatus(s)
 		s.LastHeartbeat = time.Now()
 	})
 	
@@ -178,11 +311,9 @@ func (a *Agent) Stop(ctx context.Context) error {
 		s.Status = "offline"
 	})
 	
-	// 发送最后的状态更新
+	// 发送最后的状态更新；启用关闭时注销则向平台注销，平台不再等待该Agent恢复
 	if a.apiClient != nil {
-		if err := a.apiClient.ReportStatus(ctx, a.GetStatus()); err != nil {
-			a.logger.WithError(err).Error("发送离线状态失败")
-		}
+		a.reportShutdown(ctx)
 	}
 	
 	// 取消上下文
@@ -190,6 +321,14 @@ func (a *Agent) Stop(ctx context.Context) error {
 		a.cancel()
 	}
 	
+	// 放弃排队中的重载
+	a.reloads.Stop()
+	
+	// 停止实时日志跟踪
+	if a.logTails != nil {
+		a.logTails.StopAll()
+	}
+	
 	// 停止心跳服务
 	if a.heartbeat != nil {
 		if err := a.heartbeat.Stop(); err != nil {
@@ -238,19 +377,69 @@ func (a *Agent) Stop(ctx context.Context) error {
 	}
 }
 
+// reportShutdown 关闭时通知平台：启用 deregister_on_shutdown 且客户端支持时注销，
+// 注销失败或未启用时上报离线状态
+func (a *Agent) reportShutdown(ctx context.Context) {
+	if deregisterer, ok := a.apiClient.(Deregisterer); ok && a.config.DeregisterOnShutdown {
+		err := deregisterer.Deregister(ctx, a.config.AgentID, "Agent关闭")
+		if err == nil {
+			a.logger.WithField("agent_id", a.config.AgentID).Info("已向平台注销")
+			return
+		}
+		a.logger.WithError(err).Error("向平台注销失败，改为上报离线状态")
+	}
+	
+	if err := a.apiClient.ReportStatus(ctx, a.GetStatus()); err != nil {
+		a.logger.WithError(err).Error("发送离线状态失败")
+	}
+}
+
 // Register 注册到管理平台
 func (a *Agent) Register(ctx context.Context) error {
 	a.logger.Info("正在注册到管理平台...")
 	
+	// 先获取Agent专属令牌，后续请求都使用该令牌
+	if enroller, ok := a.apiClient.(AgentEnroller); ok {
+		if err := enroller.EnsureEnrolled(ctx, a.config.AgentID); err != nil {
+			return fmt.Errorf("申请Agent令牌失败: %w", err)
+		}
+	}
+	
 	// 发送注册请求
 	if err := a.apiClient.Register(ctx, a.GetStatus()); err != nil {
 		return fmt.Errorf("注册请求失败: %w", err)
 	}
 	
 	a.logger.WithField("agent_id", a.config.AgentID).Info("注册成功")
+	
+	// 注册响应携带平台当前的间隔下限，不必等待心跳捎带
+	if negotiator, ok := a.apiClient.(IntervalNegotiator); ok {
+		if intervals := negotiator.NegotiatedIntervals(); intervals != nil {
+			a.setIntervalFloor(*intervals)
+		}
+	}
 	return nil
 }
 
+// rotateTokens 定期轮换Agent专属令牌，失败时等待下一个周期
+func (a *Agent) rotateTokens(rotator TokenRotator) {
+	defer a.wg.Done()
+	
+	ticker := time.NewTicker(a.config.TokenRotateInterval)
+	defer ticker.Stop()
+	
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := rotator.RotateToken(a.ctx, a.config.AgentID); err != nil {
+				a.logger.WithError(err).Warn("轮换Agent令牌失败")
+			}
+		}
+	}
+}
+
 // GetStatus 获取Agent状态
 func (a *Agent) GetStatus() *models.Agent {
 	a.statusMutex.RLock()
@@ -263,6 +452,9 @@ func (a *Agent) GetStatus() *models.Agent {
 	status.AppliedConfigs = make([]models.AppliedConfig, len(a.status.AppliedConfigs))
 	copy(status.AppliedConfigs, a.status.AppliedConfigs)
 	
+	// 附带重载预算状态
+	status.Reload = a.reloads.Status()
+	
 	return &status
 }
 
@@ -334,19 +526,37 @@ func (a *Agent) connectWebSocket() {
 }
 
 // processMessages 处理消息
+// 看门狗发现循环卡住时会启动新一代循环，旧循环处理完当前消息后自行退出
 func (a *Agent) processMessages() {
 	defer a.wg.Done()
 	
+	gen := atomic.AddInt64(&a.loopGen, 1)
+	ticker := time.NewTicker(a.loopTickInterval())
+	defer ticker.Stop()
+	
+	atomic.StoreInt64(&a.loopAlive, time.Now().UnixNano())
 	for {
+		if atomic.LoadInt64(&a.loopGen) != gen {
+			return
+		}
+		
 		select {
 		case msg, ok := <-a.msgChan:
 			if !ok {
 				return
 			}
 			
-			if err := a.handleMessage(msg); err != nil {
+			atomic.StoreInt64(&a.loopAlive, time.Now().UnixNano())
+			// 同一时刻只允许一个循环处理消息
+			a.handleMu.Lock()
+			if err := a.processMessage(msg); err != nil {
 				a.logger.WithError(err).WithField("msg_type", msg.Type).Error("处理消息失败")
 			}
+			a.handleMu.Unlock()
+			atomic.StoreInt64(&a.loopAlive, time.Now().UnixNano())
+			
+		case <-ticker.C:
+			atomic.StoreInt64(&a.loopAlive, time.Now().UnixNano())
 			
 		case <-a.ctx.Done():
 			return
@@ -354,6 +564,123 @@ func (a *Agent) processMessages() {
 	}
 }
 
+// loopTickInterval 消息循环的存活打点间隔
+func (a *Agent) loopTickInterval() time.Duration {
+	if a.config.WatchdogInterval > 0 {
+		return a.config.WatchdogInterval
+	}
+	return 30 * time.Second
+}
+
+// startWatchdog 启动看门狗，监测心跳、消息循环和WebSocket写入
+func (a *Agent) startWatchdog() {
+	interval := a.config.WatchdogInterval
+	a.watchdog = NewWatchdog(interval, a.logger, a.onWatchdogStateChange)
+	
+	a.watchdog.Watch("message_loop", 3*interval, func() time.Time {
+		return time.Unix(0, atomic.LoadInt64(&a.loopAlive))
+	}, a.restartMessageLoop)
+	
+	if reporter, ok := a.heartbeat.(LivenessReporter); ok {
+		// 心跳间隔可能被平台下发的设置修改，每次检查时重新计算阈值；单次心跳请求最长10秒，留出余量
+		a.watchdog.WatchFunc("heartbeat", func() time.Duration {
+			return 3*a.heartbeatInterval() + 10*time.Second
+		}, reporter.LastAlive, a.restartHeartbeat)
+	}
+	
+	if reporter, ok := a.apiClient.(LivenessReporter); ok {
+		var restart func() error
+		if resetter, ok := a.apiClient.(ConnectionResetter); ok {
+			restart = resetter.ResetWebSocket
+		}
+		// 写超时为10秒，超过30秒仍未返回视为卡死
+		a.watchdog.Watch("ws_writer", 30*time.Second, reporter.LastAlive, restart)
+	}
+	
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		a.watchdog.Run(a.ctx)
+	}()
+}
+
+// componentRestartTimeout 看门狗等待组件停止的最长时间
+const componentRestartTimeout = 10 * time.Second
+
+// heartbeatInterval 读取当前心跳间隔
+func (a *Agent) heartbeatInterval() time.Duration {
+	a.statusMutex.RLock()
+	defer a.statusMutex.RUnlock()
+	heartbeat, _ := a.effectiveIntervals()
+	return heartbeat
+}
+
+// restartMessageLoop 重启消息循环
+// 旧循环仍在处理消息时不启动新循环，避免两个循环同时处理消息；旧循环处理完后会继续运转
+func (a *Agent) restartMessageLoop() error {
+	if !a.handleMu.TryLock() {
+		return fmt.Errorf("消息循环仍在处理消息，暂不重启")
+	}
+	defer a.handleMu.Unlock()
+	
+	a.wg.Add(1)
+	go a.processMessages()
+	return nil
+}
+
+// restartHeartbeat 重启心跳服务
+// 卡住的心跳循环可能永远无法退出，停止超时后放弃旧循环直接启动新循环
+func (a *Agent) restartHeartbeat() error {
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- a.heartbeat.Stop()
+	}()
+	
+	select {
+	case err := <-stopped:
+		if err != nil {
+			return err
+		}
+	case <-time.After(componentRestartTimeout):
+		a.logger.Warn("停止心跳服务超时，放弃旧的心跳循环")
+	}
+	
+	return a.heartbeat.Start(a.ctx)
+}
+
+// onWatchdogStateChange 组件卡住时上报降级状态，全部恢复后恢复在线
+func (a *Agent) onWatchdogStateChange(degraded bool, stuck []string) {
+	a.updateStatus(func(s *models.Agent) {
+		switch {
+		case a.maintenance:
+			// 维护模式优先，记录最新状态供退出维护时恢复
+			if degraded {
+				a.preMaintenanceStatus = "degraded"
+			} else {
+				a.preMaintenanceStatus = healthyStatus(s)
+			}
+		case degraded:
+			s.Status = "degraded"
+		default:
+			s.Status = healthyStatus(s)
+		}
+	})
+	
+	if degraded {
+		a.logger.WithField("components", stuck).Error("Agent进入降级状态")
+	} else {
+		a.logger.Info("Agent组件全部恢复")
+	}
+	
+	go func() {
+		ctx, cancel := context.WithTimeout(a.ctx, 10*time.Second)
+		defer cancel()
+		if err := a.apiClient.ReportStatus(ctx, a.GetStatus()); err != nil {
+			a.logger.WithError(err).Warn("上报降级状态失败")
+		}
+	}()
+}
+
 // handleMessage 处理单个消息
 func (a *Agent) handleMessage(msg *WebSocketMessage) error {
 	a.logger.WithFields(logrus.Fields{
@@ -363,7 +690,7 @@ func (a *Agent) handleMessage(msg *WebSocketMessage) error {
 	
 	switch msg.Type {
 	case MsgTypeConfigDeploy:
-		return a.handleConfigDeploy(msg.Payload)
+		return a.handleConfigDeploy(WithTraceMetadata(a.ctx, msg.Metadata), msg.Payload)
 	case MsgTypeConfigDelete:
 		return a.handleConfigDelete(msg.Payload)
 	case MsgTypeReloadRequest:
@@ -372,6 +699,28 @@ func (a *Agent) handleMessage(msg *WebSocketMessage) error {
 		return a.handleStatusRequest()
 	case MsgTypeMetricsRequest:
 		return a.handleMetricsRequest()
+	case MsgTypeSettingsUpdate:
+		return a.handleSettingsUpdate(msg.Payload)
+	case MsgTypeSyncHint:
+		return a.handleSyncHint(msg.Payload)
+	case MsgTypeLogLevel:
+		return a.handleLogLevel(msg.Payload)
+	case MsgTypeMaintenance:
+		return a.handleMaintenance(msg.Payload)
+	case MsgTypeInvalidateValidationCache:
+		return a.handleInvalidateValidationCache()
+	case MsgTypeTelemetryIntervals:
+		return a.handleTelemetryIntervals(msg.Payload)
+	case MsgTypeLogTailStart:
+		return a.handleLogTailStart(msg.Payload)
+	case MsgTypeLogTailStop:
+		return a.handleLogTailStop(msg.Payload)
+	case MsgTypeDiagnosticRequest:
+		return a.handleDiagnosticRequest(msg.Payload)
+	case MsgTypeDLQRequest:
+		return a.handleDLQRequest(msg.Payload)
+	case MsgTypePluginInstall:
+		return a.handlePluginInstall(msg.Payload)
 	default:
 		return fmt.Errorf("未知消息类型: %s", msg.Type)
 	}
@@ -379,10 +728,16 @@ func (a *Agent) handleMessage(msg *WebSocketMessage) error {
 
 // HandleMessage 实现MessageHandler接口
 func (a *Agent) HandleMessage(msgType string, payload []byte) error {
+	return a.HandleMessageWithMetadata(msgType, payload, nil)
+}
+
+// HandleMessageWithMetadata 实现MetadataMessageHandler接口，部署过程中发往平台的请求携带消息的链路上下文
+func (a *Agent) HandleMessageWithMetadata(msgType string, payload []byte, metadata map[string]string) error {
 	msg := &WebSocketMessage{
 		Type:      msgType,
 		Timestamp: time.Now(),
 		Payload:   json.RawMessage(payload),
+		Metadata:  metadata,
 	}
 	
 	select {
@@ -399,10 +754,17 @@ func (a *Agent) OnConnect() error {
 	
 	// 更新状态
 	a.updateStatus(func(s *models.Agent) {
-		s.Status = "online"
+		if a.maintenance {
+			a.preMaintenanceStatus = healthyStatus(s)
+		} else {
+			s.Status = healthyStatus(s)
+		}
 		s.LastHeartbeat = time.Now()
 	})
 	
+	// 断开期间可能错过了部署或删除消息
+	a.requestReconcile()
+	
 	// 发送初始状态
 	return a.handleStatusRequest()
 }
@@ -414,49 +776,131 @@ func (a *Agent) OnDisconnect(err error) {
 	} else {
 		a.logger.Info("WebSocket连接正常关闭")
 	}
+	
+	// 平台侧的日志流随连接结束，重连后由浏览器重新打开
+	if a.logTails != nil {
+		a.logTails.StopAll()
+	}
 }
 
 // 消息处理方法
-func (a *Agent) handleConfigDeploy(payload json.RawMessage) error {
-	// 解析配置部署请求
-	var req struct {
-		ConfigID string `json:"config_id"`
-		Version  int    `json:"version"`
+// configDeployRequest config_deploy 消息中Agent使用的字段
+type configDeployRequest struct {
+	ConfigID     string `json:"config_id"`
+	Version      int    `json:"version"`
+	DeploymentID string `json:"deployment_id"`
+}
+
+// handleConfigDeploy 部署平台下发的配置，ctx携带部署消息的链路上下文
+func (a *Agent) handleConfigDeploy(ctx context.Context, payload json.RawMessage) error {
+	if a.inMaintenance() {
+		return fmt.Errorf("Agent处于维护模式，拒绝执行")
 	}
 	
+	// 解析配置部署请求
+	var req configDeployRequest
 	if err := json.Unmarshal(payload, &req); err != nil {
 		return fmt.Errorf("解析配置部署请求失败: %w", err)
 	}
 	
+	return a.deployConfig(ctx, req, false)
+}
+
+// deployConfig 获取、验证并落盘指定版本的配置后重载Logstash
+// deferReload为true时不单独重载，开启自动重载时配置标记为重载挂起，由调用方在全部变更后统一重载一次
+func (a *Agent) deployConfig(ctx context.Context, req configDeployRequest, deferReload bool) (err error) {
+	// 平台发起的部署失败时主动上报，避免平台等待超时；验证失败以apply_failed上报
+	defer func() {
+		if err != nil && req.DeploymentID != "" {
+			a.reportDeployFailure(ctx, req.ConfigID, req.Version, req.DeploymentID, err)
+		}
+	}()
+	
 	a.logger.WithFields(logrus.Fields{
 		"config_id": req.ConfigID,
 		"version":   req.Version,
 	}).Info("收到配置部署请求")
 	
-	// 获取配置内容
-	config, err := a.apiClient.GetConfig(a.ctx, req.ConfigID)
+	// 配置ID用作本地文件名，获取配置前拒绝无效的ID
+	if err := models.ValidateConfigID(req.ConfigID); err != nil {
+		return err
+	}
+	
+	// 磁盘或内存超过阈值时新配置可能进一步加重负载，恢复前拒绝部署
+	if a.resources != nil {
+		if reasons := a.resources.Reasons(); len(reasons) > 0 {
+			return fmt.Errorf("%w: %s", ErrResourcePressure, strings.Join(reasons, "；"))
+		}
+	}
+	
+	// 获取部署请求指定版本的内容，回滚时为旧版本
+	config, err := FetchConfigVersion(ctx, a.apiClient, req.ConfigID, req.Version)
 	if err != nil {
 		return fmt.Errorf("获取配置失败: %w", err)
 	}
+	if err := CheckContentHash(config); err != nil {
+		return err
+	}
+	
+	// 注入配置引用的平台密钥，之后验证和落盘的都是注入后的内容
+	if err := a.injectSecrets(config); err != nil {
+		return err
+	}
+	
+	// 先在临时文件上验证，避免无效配置落盘后被Logstash自动加载
+	// 相同内容的重复部署直接使用缓存的验证结果
+	validationCached, prevalidated, err := a.validateConfigContent(config.Content)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrConfigInvalid, err)
+	}
+	
+	// 记录保存前是否已有该配置，落盘后验证失败时据此恢复之前的版本或删除
+	_, statErr := os.Stat(a.configMgr.GetConfigPath(config.ID))
+	existed := statErr == nil
 	
 	// 保存配置
 	if err := a.configMgr.SaveConfig(config); err != nil {
 		return fmt.Errorf("保存配置失败: %w", err)
 	}
+	a.syncDrift()
+	
+	// 读回落盘的文件计算哈希，上报给平台确认实际部署的内容
+	hash, err := VerifyConfigFile(a.configMgr, config.ID)
+	if err != nil {
+		a.configMgr.RestoreConfig(config.ID)
+		return fmt.Errorf("配置落盘校验失败: %w", err)
+	}
+	
+	// 控制器不支持在临时文件上验证时验证落盘的文件，失败时恢复之前的配置并跳过重载
+	if !prevalidated {
+		if err := a.logstashCtrl.ValidateConfig(a.configMgr.GetConfigPath(config.ID)); err != nil {
+			a.rollbackConfig(config.ID, existed)
+			return fmt.Errorf("%w: %w", ErrConfigInvalid, err)
+		}
+	}
 	
 	// 重载Logstash
-	if a.config.EnableAutoReload && a.logstashCtrl.IsRunning() {
-		if err := a.logstashCtrl.Reload(a.ctx); err != nil {
+	reloadPending := false
+	if a.config.EnableAutoReload && a.logstashCtrl.IsRunning() && deferReload {
+		reloadPending = true
+	} else if a.config.EnableAutoReload && a.logstashCtrl.IsRunning() {
+		queued, err := a.requestReload(ReloadSourceDeploy)
+		if err != nil {
 			a.logger.WithError(err).Error("重载Logstash失败")
 			// 不返回错误，允许继续
 		}
+		reloadPending = queued
 	}
 	
 	// 更新已应用配置
 	applied := models.AppliedConfig{
-		ConfigID:  req.ConfigID,
-		Version:   req.Version,
-		AppliedAt: time.Now(),
+		ConfigID:         req.ConfigID,
+		Version:          req.Version,
+		AppliedAt:        time.Now(),
+		ValidationCached: validationCached,
+		DeploymentID:     req.DeploymentID,
+		ReloadPending:    reloadPending,
+		Hash:             hash,
 	}
 	
 	a.updateStatus(func(s *models.Agent) {
@@ -473,12 +917,82 @@ func (a *Agent) handleConfigDeploy(payload json.RawMessage) error {
 			s.AppliedConfigs = append(s.AppliedConfigs, applied)
 		}
 	})
+	a.persistAppliedConfigs()
 	
 	// 上报配置应用结果
-	return a.apiClient.ReportConfigApplied(a.ctx, a.config.AgentID, &applied)
+	return a.apiClient.ReportConfigApplied(ctx, a.config.AgentID, &applied)
+}
+
+// injectSecrets 获取配置中 ${secret:名称} 引用的平台密钥并注入
+// keystore方式将密钥写入Logstash keystore，引用替换为 ${名称}；file方式直接替换为密钥值
+func (a *Agent) injectSecrets(cfg *models.Config) error {
+	names := models.SecretReferences(cfg.Content)
+	if len(names) == 0 {
+		return nil
+	}
+	
+	fetcher, ok := a.apiClient.(SecretFetcher)
+	if !ok {
+		return fmt.Errorf("配置引用了平台密钥，客户端不支持获取密钥")
+	}
+	secrets, err := fetcher.ResolveSecrets(a.ctx, a.config.AgentID, cfg.ID, names)
+	if err != nil {
+		return fmt.Errorf("获取配置引用的密钥失败: %w", err)
+	}
+	for _, name := range names {
+		if _, ok := secrets[name]; !ok {
+			return fmt.Errorf("平台未返回密钥 %s", name)
+		}
+	}
+	
+	if a.config.SecretInjection == config.SecretInjectionFile {
+		cfg.Content = models.ReplaceSecretReferences(cfg.Content, func(name string) string {
+			return secrets[name]
+		})
+		return nil
+	}
+	
+	writer, ok := a.logstashCtrl.(KeystoreWriter)
+	if !ok {
+		return fmt.Errorf("Logstash控制器不支持写入keystore，可将 secret_injection 设为 file")
+	}
+	if err := writer.AddKeystoreSecrets(a.ctx, secrets); err != nil {
+		return fmt.Errorf("写入keystore失败: %w", err)
+	}
+	cfg.Content = models.ReplaceSecretReferences(cfg.Content, func(name string) string {
+		return "${" + name + "}"
+	})
+	return nil
+}
+
+// reportDeployFailure 向平台上报部署失败，客户端不支持时忽略
+func (a *Agent) reportDeployFailure(ctx context.Context, configID string, version int, deploymentID string, cause error) {
+	reporter, ok := a.apiClient.(DeployFailureReporter)
+	if !ok {
+		return
+	}
+	
+	applied := &models.AppliedConfig{
+		ConfigID:     configID,
+		Version:      version,
+		DeploymentID: deploymentID,
+	}
+	var err error
+	if applyReporter, ok := a.apiClient.(ApplyFailureReporter); ok && errors.Is(cause, ErrConfigInvalid) {
+		err = applyReporter.ReportConfigApplyFailed(ctx, a.config.AgentID, applied, cause.Error())
+	} else {
+		err = reporter.ReportConfigFailed(ctx, a.config.AgentID, applied, cause.Error())
+	}
+	if err != nil {
+		a.logger.WithError(err).WithField("deployment_id", deploymentID).Warn("上报部署失败结果失败")
+	}
 }
 
 func (a *Agent) handleConfigDelete(payload json.RawMessage) error {
+	if a.inMaintenance() {
+		return fmt.Errorf("Agent处于维护模式，拒绝执行")
+	}
+	
 	// 解析配置删除请求
 	var req struct {
 		ConfigID string `json:"config_id"`
@@ -490,34 +1004,52 @@ func (a *Agent) handleConfigDelete(payload json.RawMessage) error {
 	
 	a.logger.WithField("config_id", req.ConfigID).Info("收到配置删除请求")
 	
+	return a.removeConfig(req.ConfigID, false)
+}
+
+// removeConfig 删除本地配置并向平台确认，deferReload为true时不重载，由调用方统一重载
+func (a *Agent) removeConfig(configID string, deferReload bool) error {
 	// 删除配置
-	if err := a.configMgr.DeleteConfig(req.ConfigID); err != nil {
+	if err := a.configMgr.DeleteConfig(configID); err != nil {
 		return fmt.Errorf("删除配置失败: %w", err)
 	}
+	a.syncDrift()
 	
 	// 更新状态
 	a.updateStatus(func(s *models.Agent) {
 		// 从已应用配置中移除
 		newConfigs := make([]models.AppliedConfig, 0, len(s.AppliedConfigs))
 		for _, ac := range s.AppliedConfigs {
-			if ac.ConfigID != req.ConfigID {
+			if ac.ConfigID != configID {
 				newConfigs = append(newConfigs, ac)
 			}
 		}
 		s.AppliedConfigs = newConfigs
 	})
+	a.persistAppliedConfigs()
 	
 	// 重载Logstash
-	if a.config.EnableAutoReload && a.logstashCtrl.IsRunning() {
-		if err := a.logstashCtrl.Reload(a.ctx); err != nil {
+	if a.config.EnableAutoReload && a.logstashCtrl.IsRunning() && !deferReload {
+		if _, err := a.autoReload(ReloadSourceDelete); err != nil {
 			a.logger.WithError(err).Error("重载Logstash失败")
 		}
 	}
 	
+	// 向平台确认已删除，平台的强制删除等待该确认
+	if reporter, ok := a.apiClient.(ConfigRemovalReporter); ok {
+		if err := reporter.ReportConfigRemoved(a.ctx, a.config.AgentID, configID); err != nil {
+			a.logger.WithError(err).WithField("config_id", configID).Warn("上报配置删除结果失败")
+		}
+	}
+	
 	return nil
 }
 
 func (a *Agent) handleReloadRequest() error {
+	if a.inMaintenance() {
+		return fmt.Errorf("Agent处于维护模式，拒绝执行")
+	}
+	
 	a.logger.Info("收到重载请求")
 	
 	if !a.logstashCtrl.IsRunning() {
@@ -525,14 +1057,256 @@ func (a *Agent) handleReloadRequest() error {
 	}
 	
 	// 执行重载
-	if err := a.logstashCtrl.Reload(a.ctx); err != nil {
+	if _, err := a.requestReload(ReloadSourceRequest); err != nil {
 		return fmt.Errorf("重载失败: %w", err)
 	}
 	
-	a.logger.Info("Logstash重载成功")
 	return nil
 }
 
+// ReloadCoordinator 返回Agent的重载协调器，供其他重载来源共享预算
+func (a *Agent) ReloadCoordinator() *ReloadCoordinator {
+	return a.reloads
+}
+
+// requestReload 经由重载协调器重载Logstash，预算耗尽时请求排队并返回queued=true
+func (a *Agent) requestReload(source string) (bool, error) {
+	queued, err := a.reloads.Request(a.ctx, source)
+	if err != nil {
+		return false, err
+	}
+	if queued {
+		a.logger.WithField("source", source).Info("重载请求已排队，将在防抖静默期结束或预算释放后执行")
+		return true, nil
+	}
+	
+	a.logger.WithField("source", source).Info("Logstash重载成功")
+	return false, nil
+}
+
+// autoReload 配置变更后自动重载，资源超过阈值且配置了暂停时推迟到恢复后执行并返回queued=true
+func (a *Agent) autoReload(source string) (bool, error) {
+	a.statusMutex.Lock()
+	if a.reloadsPaused() {
+		a.deferredReloads = append(a.deferredReloads, source)
+		a.statusMutex.Unlock()
+		a.logger.WithField("source", source).Warn("资源使用率超过阈值，推迟自动重载")
+		return true, nil
+	}
+	a.statusMutex.Unlock()
+	return a.requestReload(source)
+}
+
+// deferRestart 资源超过阈值且配置了暂停时记录待恢复后执行的崩溃重启
+func (a *Agent) deferRestart(reason string) bool {
+	a.statusMutex.Lock()
+	defer a.statusMutex.Unlock()
+	if !a.reloadsPaused() {
+		return false
+	}
+	a.deferredRestart = reason
+	return true
+}
+
+// reloadsPaused 是否因资源超过阈值暂停自动重载和重启，调用方持有statusMutex
+func (a *Agent) reloadsPaused() bool {
+	return a.status.ResourcePressure != nil && a.status.ResourcePressure.ReloadsPaused
+}
+
+// onResourcePressure 资源超过阈值时上报warning状态，恢复后恢复在线并补做暂停期间推迟的重载和重启
+func (a *Agent) onResourcePressure(reasons []string) {
+	var reloads []string
+	var restart string
+	a.updateStatus(func(s *models.Agent) {
+		if len(reasons) > 0 {
+			s.ResourcePressure = &models.ResourcePressure{
+				Reasons:       reasons,
+				Since:         time.Now(),
+				ReloadsPaused: a.config.PauseReloadOnPressure,
+			}
+		} else {
+			s.ResourcePressure = nil
+			reloads, a.deferredReloads = a.deferredReloads, nil
+			restart, a.deferredRestart = a.deferredRestart, ""
+		}
+		// 维护和降级状态优先，记录最新状态供退出时恢复
+		switch {
+		case a.maintenance:
+			if a.preMaintenanceStatus != "degraded" {
+				a.preMaintenanceStatus = healthyStatus(s)
+			}
+		case s.Status == "online" || s.Status == models.AgentStatusWarning:
+			s.Status = healthyStatus(s)
+		}
+	})
+	
+	if len(reasons) > 0 {
+		a.reportEvent(models.AgentEventResourcePressure, strings.Join(reasons, "；"))
+	} else {
+		a.reportEvent(models.AgentEventResourceRecovered, "资源使用率已恢复到阈值以下")
+	}
+	
+	if a.ctx != nil {
+		go func() {
+			ctx, cancel := context.WithTimeout(a.ctx, 10*time.Second)
+			defer cancel()
+			if err := a.apiClient.ReportStatus(ctx, a.GetStatus()); err != nil {
+				a.logger.WithError(err).Warn("上报资源状态失败")
+			}
+		}()
+	}
+	
+	if restart != "" {
+		a.restartLogstash(restart)
+	} else if len(reloads) > 0 && a.logstashCtrl.IsRunning() {
+		if _, err := a.requestReload(ReloadSourceResume); err != nil {
+			a.logger.WithError(err).WithField("sources", reloads).Error("执行推迟的重载失败")
+		}
+	}
+}
+
+// healthyStatus 组件正常时的状态：资源超过阈值时为warning，否则为online
+func healthyStatus(s *models.Agent) string {
+	if s.ResourcePressure != nil {
+		return models.AgentStatusWarning
+	}
+	return "online"
+}
+
+// verifyAppliedConfigs 重载前校验平台下发的配置文件，拒绝让Logstash加载被篡改或截断的配置
+// 文件缺失等其他读取错误只记录日志，Logstash不会加载不存在的文件
+func (a *Agent) verifyAppliedConfigs() error {
+	a.statusMutex.RLock()
+	configIDs := make([]string, 0, len(a.status.AppliedConfigs))
+	for _, applied := range a.status.AppliedConfigs {
+		configIDs = append(configIDs, applied.ConfigID)
+	}
+	a.statusMutex.RUnlock()
+	
+	for _, configID := range configIDs {
+		_, err := VerifyConfigFile(a.configMgr, configID)
+		switch {
+		case err == nil:
+		case errors.Is(err, config.ErrConfigIntegrity):
+			return fmt.Errorf("重载前校验配置失败: %w", err)
+		default:
+			a.logger.WithError(err).WithField("config_id", configID).Warn("重载前读取配置文件失败")
+		}
+	}
+	return nil
+}
+
+// reportEvent 向平台上报Agent事件，客户端不支持时忽略
+func (a *Agent) reportEvent(eventType, reason string) {
+	reporter, ok := a.apiClient.(EventReporter)
+	if !ok || a.ctx == nil {
+		return
+	}
+	
+	event := &models.AgentEventReport{
+		Type:      eventType,
+		Reason:    reason,
+		Timestamp: time.Now(),
+	}
+	if err := reporter.ReportEvent(a.ctx, a.config.AgentID, event); err != nil {
+		a.logger.WithError(err).WithField("type", eventType).Warn("上报Agent事件失败")
+	}
+}
+
+// onLogstashExit Logstash进程意外退出时上报崩溃事件，并在等待后重新启动
+func (a *Agent) onLogstashExit(exitErr error) {
+	// Agent停止时上下文取消会终止进程，不属于崩溃
+	if a.ctx == nil || a.ctx.Err() != nil {
+		return
+	}
+	
+	reason := "进程退出"
+	if exitErr != nil {
+		reason = exitErr.Error()
+	}
+	a.logger.WithField("reason", reason).Error("Logstash进程意外退出")
+	a.reportEvent(models.AgentEventLogstashCrashed, reason)
+	
+	if a.config.CrashRestartDelay <= 0 {
+		return
+	}
+	go func() {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-time.After(a.config.CrashRestartDelay):
+		}
+		
+		// 资源超过阈值时重启可能再次崩溃，推迟到恢复后
+		if a.deferRestart(reason) {
+			a.logger.WithField("reason", reason).Warn("资源使用率超过阈值，推迟重启Logstash")
+			return
+		}
+		a.restartLogstash(reason)
+	}()
+}
+
+// restartLogstash 重新启动意外退出的Logstash
+func (a *Agent) restartLogstash(reason string) {
+	if err := a.logstashCtrl.Start(a.ctx); err != nil {
+		a.logger.WithError(err).Error("重新启动Logstash失败")
+		return
+	}
+	a.logger.Info("Logstash已重新启动")
+	a.reportEvent(models.AgentEventLogstashRestarted, fmt.Sprintf("意外退出后重启: %s", reason))
+}
+
+// onReloadFlushed 排队的重载执行后，向平台补报重载挂起期间下发的配置结果
+func (a *Agent) onReloadFlushed(sources []string, reloadErr error) {
+	var pending []models.AppliedConfig
+	a.updateStatus(func(s *models.Agent) {
+		for i := range s.AppliedConfigs {
+			if s.AppliedConfigs[i].ReloadPending {
+				s.AppliedConfigs[i].ReloadPending = false
+				pending = append(pending, s.AppliedConfigs[i])
+			}
+		}
+	})
+	if len(pending) > 0 {
+		a.persistAppliedConfigs()
+	}
+	if a.apiClient == nil {
+		return
+	}
+	
+	for i := range pending {
+		applied := pending[i]
+		if reloadErr != nil {
+			if applied.DeploymentID == "" {
+				continue
+			}
+			a.reportDeployFailure(a.ctx, applied.ConfigID, applied.Version, applied.DeploymentID, fmt.Errorf("排队的重载失败: %w", reloadErr))
+			continue
+		}
+		applied.AppliedAt = time.Now()
+		if err := a.apiClient.ReportConfigApplied(a.ctx, a.config.AgentID, &applied); err != nil {
+			a.logger.WithError(err).WithField("config_id", applied.ConfigID).Warn("补报配置应用结果失败")
+		}
+	}
+}
+
+// syncDrift Agent自身写入配置后刷新漂移基准
+func (a *Agent) syncDrift() {
+	if a.drift != nil {
+		a.drift.Sync()
+	}
+}
+
+// onConfigDrift 配置目录被外部修改时经由重载协调器重载，与平台下发共享预算
+func (a *Agent) onConfigDrift(changed []string) {
+	if a.inMaintenance() || !a.config.EnableAutoReload || !a.logstashCtrl.IsRunning() {
+		return
+	}
+	if _, err := a.autoReload(ReloadSourceDrift); err != nil {
+		a.logger.WithError(err).WithField("files", changed).Error("配置漂移后重载Logstash失败")
+	}
+}
+
 func (a *Agent) handleStatusRequest() error {
 	a.logger.Debug("收到状态请求")
 	
@@ -564,6 +1338,343 @@ func (a *Agent) handleMetricsRequest() error {
 	return nil
 }
 
+func (a *Agent) handleSettingsUpdate(payload json.RawMessage) error {
+	var settings models.AgentSettings
+	if err := json.Unmarshal(payload, &settings); err != nil {
+		return fmt.Errorf("解析设置下发请求失败: %w", err)
+	}
+	
+	a.logger.WithFields(logrus.Fields{
+		"heartbeat_interval": settings.HeartbeatInterval,
+		"metrics_interval":   settings.MetricsInterval,
+		"enable_auto_reload": settings.EnableAutoReload,
+	}).Info("收到设置下发请求")
+	
+	// 间隔在各服务内部做最小值保护，平台协商的下限仍然生效
+	if settings.HeartbeatInterval != nil || settings.MetricsInterval != nil {
+		a.statusMutex.Lock()
+		if settings.HeartbeatInterval != nil {
+			a.config.HeartbeatInterval = time.Duration(*settings.HeartbeatInterval) * time.Second
+		}
+		if settings.MetricsInterval != nil {
+			a.config.MetricsInterval = time.Duration(*settings.MetricsInterval) * time.Second
+		}
+		a.statusMutex.Unlock()
+		a.applyIntervals(settings.HeartbeatInterval != nil, settings.MetricsInterval != nil)
+	}
+	if settings.EnableAutoReload != nil {
+		a.config.EnableAutoReload = *settings.EnableAutoReload
+	}
+	
+	// 平台下发的标签与本地标签合并，本地agent.yaml中的标签优先
+	if len(settings.Labels) > 0 {
+		a.updateStatus(func(s *models.Agent) {
+			labels := make(map[string]string, len(settings.Labels)+len(a.config.Labels))
+			for k, v := range settings.Labels {
+				labels[k] = v
+			}
+			for k, v := range a.config.Labels {
+				labels[k] = v
+			}
+			s.Labels = labels
+		})
+	}
+	
+	return a.handleStatusRequest()
+}
+
+// handleLogTailStart 开始跟踪Logstash日志，跟踪只读取日志文件，维护模式下同样可用
+func (a *Agent) handleLogTailStart(payload json.RawMessage) error {
+	if a.logTails == nil {
+		return fmt.Errorf("客户端不支持WebSocket推送，无法跟踪日志")
+	}
+	var req models.LogTailStartPayload
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return fmt.Errorf("解析日志跟踪请求失败: %w", err)
+	}
+	return a.logTails.Start(a.ctx, req)
+}
+
+// handleLogTailStop 停止跟踪Logstash日志
+func (a *Agent) handleLogTailStop(payload json.RawMessage) error {
+	var req models.LogTailStopPayload
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return fmt.Errorf("解析停止日志跟踪请求失败: %w", err)
+	}
+	if a.logTails != nil {
+		a.logTails.Stop(req.StreamID)
+	}
+	return nil
+}
+
+// handleDiagnosticRequest 执行平台下发的诊断命令，结果经WebSocket回复
+// logstash --version需要启动JVM，在后台执行避免阻塞消息循环
+func (a *Agent) handleDiagnosticRequest(payload json.RawMessage) error {
+	if a.sender == nil {
+		return fmt.Errorf("客户端不支持WebSocket推送，无法回复诊断结果")
+	}
+	var req models.DiagnosticPayload
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return fmt.Errorf("解析诊断请求失败: %w", err)
+	}
+	if req.RequestID == "" {
+		return fmt.Errorf("诊断请求缺少request_id")
+	}
+	
+	a.logger.WithFields(logrus.Fields{
+		"request_id": req.RequestID,
+		"command":    req.Command,
+	}).Info("执行远程诊断")
+	
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		result := a.diagnostics.Run(a.ctx, req)
+		if err := a.sender.SendMessage(MsgTypeDiagnosticResult, result); err != nil {
+			a.logger.WithError(err).WithField("request_id", req.RequestID).Warn("回复诊断结果失败")
+		}
+	}()
+	return nil
+}
+
+// handleDLQRequest 读取管道死信队列中的一页事件，结果经WebSocket回复
+// 段文件可能很大，在后台读取避免阻塞消息循环
+func (a *Agent) handleDLQRequest(payload json.RawMessage) error {
+	if a.sender == nil {
+		return fmt.Errorf("客户端不支持WebSocket推送，无法回复死信队列")
+	}
+	var req models.DLQPayload
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return fmt.Errorf("解析死信队列请求失败: %w", err)
+	}
+	if req.RequestID == "" {
+		return fmt.Errorf("死信队列请求缺少request_id")
+	}
+	
+	a.logger.WithFields(logrus.Fields{
+		"request_id": req.RequestID,
+		"pipeline":   req.Pipeline,
+		"page":       req.Page,
+	}).Info("读取死信队列")
+	
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		result := readDLQ(a.config.DataDir, req)
+		if err := a.sender.SendMessage(MsgTypeDLQResult, result); err != nil {
+			a.logger.WithError(err).WithField("request_id", req.RequestID).Warn("回复死信队列失败")
+		}
+	}()
+	return nil
+}
+
+// handleTelemetryIntervals 处理平台协商的间隔下限
+func (a *Agent) handleTelemetryIntervals(payload json.RawMessage) error {
+	var intervals models.TelemetryIntervals
+	if err := json.Unmarshal(payload, &intervals); err != nil {
+		return fmt.Errorf("解析上报间隔失败: %w", err)
+	}
+	
+	a.logger.WithFields(logrus.Fields{
+		"heartbeat_interval": intervals.HeartbeatInterval,
+		"metrics_interval":   intervals.MetricsInterval,
+		"fleet_size":         intervals.FleetSize,
+		"pressure":           intervals.Pressure,
+	}).Info("收到平台协商的上报间隔")
+	
+	a.setIntervalFloor(intervals)
+	return nil
+}
+
+// setIntervalFloor 记录平台协商的间隔下限并应用到心跳和指标服务
+func (a *Agent) setIntervalFloor(intervals models.TelemetryIntervals) {
+	a.statusMutex.Lock()
+	a.intervalFloor = intervals
+	a.statusMutex.Unlock()
+	a.applyIntervals(true, true)
+}
+
+// applyIntervals 将实际间隔应用到心跳和指标服务
+func (a *Agent) applyIntervals(heartbeat, metrics bool) {
+	a.statusMutex.RLock()
+	heartbeatInterval, metricsInterval := a.effectiveIntervals()
+	a.statusMutex.RUnlock()
+	
+	if heartbeat && a.heartbeat != nil {
+		a.heartbeat.SetInterval(heartbeatInterval)
+	}
+	if metrics && a.metrics != nil {
+		a.metrics.SetInterval(metricsInterval)
+	}
+}
+
+// effectiveIntervals 实际间隔 = max(本地/分组设置, 平台下限)，并限制在agent.yaml配置的范围内，调用方需持有statusMutex
+func (a *Agent) effectiveIntervals() (heartbeat, metrics time.Duration) {
+	heartbeat = boundInterval(a.config.HeartbeatInterval, a.intervalFloor.HeartbeatInterval, a.config.MinHeartbeatInterval, a.config.MaxHeartbeatInterval)
+	metrics = boundInterval(a.config.MetricsInterval, a.intervalFloor.MetricsInterval, a.config.MinMetricsInterval, a.config.MaxMetricsInterval)
+	return heartbeat, metrics
+}
+
+// boundInterval floor为秒数，min/max为0时不限制对应方向
+func boundInterval(configured time.Duration, floor int, min, max time.Duration) time.Duration {
+	interval := configured
+	if f := time.Duration(floor) * time.Second; f > interval {
+		interval = f
+	}
+	if min > 0 && interval < min {
+		interval = min
+	}
+	if max > 0 && interval > max {
+		interval = max
+	}
+	return interval
+}
+
+func (a *Agent) handleSyncHint(payload json.RawMessage) error {
+	// 平台提示Agent同步的配置及目标版本
+	var req struct {
+		Configs []struct {
+			ConfigID string `json:"config_id"`
+			Version  int    `json:"version"`
+		} `json:"configs"`
+	}
+	
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return fmt.Errorf("解析同步提示失败: %w", err)
+	}
+	
+	applied := make(map[string]int)
+	for _, ac := range a.GetStatus().AppliedConfigs {
+		applied[ac.ConfigID] = ac.Version
+	}
+	
+	for _, cfg := range req.Configs {
+		// 已是目标版本的配置无需重复部署
+		if version, ok := applied[cfg.ConfigID]; ok && version == cfg.Version {
+			continue
+		}
+		
+		deploy, _ := json.Marshal(cfg)
+		if err := a.handleConfigDeploy(a.ctx, deploy); err != nil {
+			a.logger.WithError(err).WithField("config_id", cfg.ConfigID).Error("同步配置失败")
+		}
+	}
+	
+	return nil
+}
+
+// validateConfigContent 将配置内容写入临时文件后验证，返回结果是否命中缓存，以及控制器是否支持在临时文件上验证
+func (a *Agent) validateConfigContent(content string) (cached, validated bool, err error) {
+	validator, ok := a.logstashCtrl.(CachedConfigValidator)
+	if !ok {
+		return false, false, nil
+	}
+	
+	tmp, err := os.CreateTemp("", "logstash-validate-*.conf")
+	if err != nil {
+		return false, false, fmt.Errorf("创建临时配置文件失败: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	
+	if _, err := tmp.WriteString(content); err != nil {
+		tmp.Close()
+		return false, false, fmt.Errorf("写入临时配置文件失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return false, false, fmt.Errorf("写入临时配置文件失败: %w", err)
+	}
+	
+	cached, err = validator.ValidateConfigCached(tmp.Name())
+	return cached, true, err
+}
+
+// rollbackConfig 落盘的配置验证失败时恢复保存前的版本，保存前不存在该配置时删除
+func (a *Agent) rollbackConfig(configID string, existed bool) {
+	var err error
+	if existed {
+		err = a.configMgr.RestoreConfig(configID)
+	} else {
+		err = a.configMgr.DeleteConfig(configID)
+	}
+	if err != nil {
+		a.logger.WithError(err).WithField("config_id", configID).Error("恢复验证失败前的配置失败")
+		return
+	}
+	a.syncDrift()
+	a.logger.WithField("config_id", configID).Warn("新配置验证失败，已恢复之前的配置")
+}
+
+// handleInvalidateValidationCache 处理清空验证缓存命令，插件安装或升级后由平台下发
+func (a *Agent) handleInvalidateValidationCache() error {
+	validator, ok := a.logstashCtrl.(CachedConfigValidator)
+	if !ok {
+		a.logger.Debug("Logstash控制器不支持验证缓存，忽略清空请求")
+		return nil
+	}
+	
+	validator.InvalidateValidationCache()
+	return nil
+}
+
+func (a *Agent) handleLogLevel(payload json.RawMessage) error {
+	var req struct {
+		Level string `json:"level"`
+	}
+	
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return fmt.Errorf("解析日志级别请求失败: %w", err)
+	}
+	
+	level, err := logrus.ParseLevel(req.Level)
+	if err != nil {
+		return fmt.Errorf("无效的日志级别: %s", req.Level)
+	}
+	
+	a.logger.SetLevel(level)
+	a.logger.WithField("level", level.String()).Info("日志级别已变更")
+	return nil
+}
+
+func (a *Agent) handleMaintenance(payload json.RawMessage) error {
+	var req struct {
+		Enabled bool   `json:"enabled"`
+		Reason  string `json:"reason"`
+	}
+	
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return fmt.Errorf("解析维护模式请求失败: %w", err)
+	}
+	
+	a.updateStatus(func(s *models.Agent) {
+		switch {
+		case req.Enabled && !a.maintenance:
+			a.preMaintenanceStatus = s.Status
+			s.Status = "maintenance"
+		case !req.Enabled && a.maintenance:
+			s.Status = a.preMaintenanceStatus
+			if s.Status == "" || s.Status == "maintenance" {
+				s.Status = healthyStatus(s)
+			}
+			a.preMaintenanceStatus = ""
+		}
+		a.maintenance = req.Enabled
+	})
+	
+	a.logger.WithFields(logrus.Fields{
+		"enabled": req.Enabled,
+		"reason":  req.Reason,
+	}).Info("维护模式已变更")
+	
+	return a.handleStatusRequest()
+}
+
+// inMaintenance 是否处于维护模式
+func (a *Agent) inMaintenance() bool {
+	a.statusMutex.RLock()
+	defer a.statusMutex.RUnlock()
+	return a.maintenance
+}
+
 // updateStatus 更新Agent状态
 func (a *Agent) updateStatus(updater func(*models.Agent)) {
 	a.statusMutex.Lock()
@@ -4,12 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"os"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"logstash-platform/internal/agent/config"
 	"logstash-platform/internal/platform/models"
 )
@@ -310,6 +313,53 @@ func TestAgent_Stop(t *testing.T) {
 	mockMetrics.AssertCalled(t, "Stop")
 }
 
+type deregisteringAPIClient struct {
+	*MockAPIClient
+	err     error
+	reasons []string
+}
+
+func (m *deregisteringAPIClient) Deregister(ctx context.Context, agentID, reason string) error {
+	m.reasons = append(m.reasons, reason)
+	return m.err
+}
+
+func TestAgent_ReportShutdown(t *testing.T) {
+	t.Run("未启用时上报离线状态", func(t *testing.T) {
+		agent, mockAPI, _, _, _, _ := createTestAgent(t)
+		client := &deregisteringAPIClient{MockAPIClient: mockAPI}
+		agent.apiClient = client
+		mockAPI.On("ReportStatus", mock.Anything, mock.Anything).Return(nil).Once()
+
+		agent.reportShutdown(context.Background())
+		assert.Empty(t, client.reasons)
+		mockAPI.AssertExpectations(t)
+	})
+
+	t.Run("启用时注销，不再上报离线状态", func(t *testing.T) {
+		agent, mockAPI, _, _, _, _ := createTestAgent(t)
+		client := &deregisteringAPIClient{MockAPIClient: mockAPI}
+		agent.apiClient = client
+		agent.config.DeregisterOnShutdown = true
+
+		agent.reportShutdown(context.Background())
+		assert.Equal(t, []string{"Agent关闭"}, client.reasons)
+		mockAPI.AssertNotCalled(t, "ReportStatus", mock.Anything, mock.Anything)
+	})
+
+	t.Run("注销失败时改为上报离线状态", func(t *testing.T) {
+		agent, mockAPI, _, _, _, _ := createTestAgent(t)
+		client := &deregisteringAPIClient{MockAPIClient: mockAPI, err: errors.New("平台不可达")}
+		agent.apiClient = client
+		agent.config.DeregisterOnShutdown = true
+		mockAPI.On("ReportStatus", mock.Anything, mock.Anything).Return(nil).Once()
+
+		agent.reportShutdown(context.Background())
+		assert.Len(t, client.reasons, 1)
+		mockAPI.AssertExpectations(t)
+	})
+}
+
 func TestAgent_HandleConfigDeploy(t *testing.T) {
 	agent, mockAPI, mockConfigMgr, mockLogstash, _, _ := createTestAgent(t)
 
@@ -329,6 +379,8 @@ func TestAgent_HandleConfigDeploy(t *testing.T) {
 	// 设置mock期望
 	mockAPI.On("GetConfig", mock.Anything, "test-config").Return(config, nil)
 	mockConfigMgr.On("SaveConfig", config).Return(nil)
+	mockConfigMgr.On("GetConfigPath", "test-config").Return("/etc/logstash/conf.d/test-config.conf")
+	mockLogstash.On("ValidateConfig", "/etc/logstash/conf.d/test-config.conf").Return(nil)
 	mockLogstash.On("IsRunning").Return(true)
 	mockLogstash.On("Reload", mock.Anything).Return(nil)
 	mockAPI.On("ReportConfigApplied", mock.Anything, "test-agent", mock.Anything).Return(nil)
@@ -337,8 +389,9 @@ func TestAgent_HandleConfigDeploy(t *testing.T) {
 	agent.ctx, agent.cancel = context.WithCancel(context.Background())
 	defer agent.cancel()
 
-	err := agent.handleConfigDeploy(json.RawMessage(payload))
+	err := agent.handleConfigDeploy(agent.ctx, json.RawMessage(payload))
 	assert.NoError(t, err)
+	mockLogstash.AssertCalled(t, "ValidateConfig", "/etc/logstash/conf.d/test-config.conf")
 
 	// 验证配置已添加到状态
 	status := agent.GetStatus()
@@ -347,6 +400,204 @@ func TestAgent_HandleConfigDeploy(t *testing.T) {
 	assert.Equal(t, 1, status.AppliedConfigs[0].Version)
 }
 
+func TestAgent_ConfigIntegrity(t *testing.T) {
+	agent, mockAPI, _, mockLogstash, _, _ := createTestAgent(t)
+	configMgr, err := config.NewManager(&config.AgentConfig{ConfigDir: t.TempDir(), ConfigBackupCount: 3}, agent.logger)
+	require.NoError(t, err)
+	agent.configMgr = configMgr
+	
+	content := "input { stdin {} }"
+	mockAPI.On("GetConfig", mock.Anything, "test-config").Return(&models.Config{
+		ID: "test-config", Content: content, Version: 1, ContentHash: models.ContentHash(content),
+	}, nil).Once()
+	mockAPI.On("GetConfig", mock.Anything, "truncated").Return(&models.Config{
+		ID: "truncated", Content: "input { std", Version: 1, ContentHash: models.ContentHash(content),
+	}, nil).Once()
+	mockLogstash.On("ValidateConfig", mock.Anything).Return(nil)
+	mockLogstash.On("IsRunning").Return(true)
+	mockLogstash.On("Reload", mock.Anything).Return(nil).Once()
+	var reported *models.AppliedConfig
+	mockAPI.On("ReportConfigApplied", mock.Anything, "test-agent", mock.Anything).Run(func(args mock.Arguments) {
+		reported = args.Get(2).(*models.AppliedConfig)
+	}).Return(nil)
+	
+	agent.ctx, agent.cancel = context.WithCancel(context.Background())
+	defer agent.cancel()
+	
+	// 上报读回落盘文件得到的哈希
+	payload, _ := json.Marshal(map[string]interface{}{"config_id": "test-config", "version": 1})
+	require.NoError(t, agent.handleConfigDeploy(agent.ctx, json.RawMessage(payload)))
+	require.NotNil(t, reported)
+	assert.Equal(t, models.ContentHash(content), reported.Hash)
+	
+	// 下载的内容与平台给出的哈希不一致时不落盘
+	payload, _ = json.Marshal(map[string]interface{}{"config_id": "truncated", "version": 1})
+	err = agent.handleConfigDeploy(agent.ctx, json.RawMessage(payload))
+	assert.ErrorContains(t, err, "不一致")
+	_, statErr := os.Stat(configMgr.GetConfigPath("truncated"))
+	assert.True(t, os.IsNotExist(statErr))
+	
+	// 本地文件被篡改后拒绝重载
+	require.NoError(t, os.WriteFile(configMgr.GetConfigPath("test-config"), []byte("input { exec {} }"), 0644))
+	err = agent.handleReloadRequest()
+	assert.ErrorIs(t, err, config.ErrConfigIntegrity)
+	mockLogstash.AssertNumberOfCalls(t, "Reload", 1)
+}
+
+func TestAgent_HandleConfigDeploy_DebouncedReload(t *testing.T) {
+	agent, mockAPI, _, mockLogstash, _, _ := createTestAgent(t)
+	configMgr, err := config.NewManager(&config.AgentConfig{ConfigDir: t.TempDir(), ConfigBackupCount: 3}, agent.logger)
+	require.NoError(t, err)
+	agent.configMgr = configMgr
+	agent.reloads.SetDebounce(50 * time.Millisecond)
+	
+	ids := []string{"cfg-a", "cfg-b", "cfg-c"}
+	for _, id := range ids {
+		mockAPI.On("GetConfig", mock.Anything, id).Return(&models.Config{ID: id, Version: 1, Content: "input { stdin {} }"}, nil)
+	}
+	mockLogstash.On("ValidateConfig", mock.Anything).Return(nil)
+	mockLogstash.On("IsRunning").Return(true)
+	mockLogstash.On("Reload", mock.Anything).Return(nil)
+	var (
+		mu       sync.Mutex
+		reported []models.AppliedConfig
+	)
+	mockAPI.On("ReportConfigApplied", mock.Anything, "test-agent", mock.Anything).Run(func(args mock.Arguments) {
+		mu.Lock()
+		defer mu.Unlock()
+		reported = append(reported, *args.Get(2).(*models.AppliedConfig))
+	}).Return(nil)
+	
+	agent.ctx, agent.cancel = context.WithCancel(context.Background())
+	defer agent.cancel()
+	
+	// 连续下发的配置先以重载排队上报，静默期后只重载一次并补报全部结果
+	for _, id := range ids {
+		payload, _ := json.Marshal(map[string]interface{}{"config_id": id, "version": 1, "deployment_id": "dep-1"})
+		require.NoError(t, agent.handleConfigDeploy(agent.ctx, json.RawMessage(payload)))
+	}
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(reported) == 2*len(ids)
+	}, time.Second, 10*time.Millisecond)
+	mockLogstash.AssertNumberOfCalls(t, "Reload", 1)
+	
+	mu.Lock()
+	defer mu.Unlock()
+	for i, applied := range reported {
+		assert.Equal(t, i < len(ids), applied.ReloadPending, applied.ConfigID)
+	}
+	for _, applied := range agent.GetStatus().AppliedConfigs {
+		assert.False(t, applied.ReloadPending)
+	}
+}
+
+// applyFailureAPIClient 在Mock基础上实现DeployFailureReporter和ApplyFailureReporter
+type applyFailureAPIClient struct {
+	*MockAPIClient
+	failedStatus string
+	failedReason string
+}
+
+func (m *applyFailureAPIClient) ReportConfigFailed(ctx context.Context, agentID string, applied *models.AppliedConfig, reason string) error {
+	m.failedStatus, m.failedReason = "failed", reason
+	return nil
+}
+
+func (m *applyFailureAPIClient) ReportConfigApplyFailed(ctx context.Context, agentID string, applied *models.AppliedConfig, output string) error {
+	m.failedStatus, m.failedReason = "apply_failed", output
+	return nil
+}
+
+func TestAgent_HandleConfigDeploy_RollbackOnValidationFailure(t *testing.T) {
+	agent, mockAPI, _, mockLogstash, _, _ := createTestAgent(t)
+	configMgr, err := config.NewManager(&config.AgentConfig{ConfigDir: t.TempDir(), ConfigBackupCount: 3}, agent.logger)
+	require.NoError(t, err)
+	agent.configMgr = configMgr
+	apiClient := &applyFailureAPIClient{MockAPIClient: mockAPI}
+	agent.apiClient = apiClient
+	
+	good := "input { stdin {} }"
+	require.NoError(t, configMgr.SaveConfig(&models.Config{ID: "app", Version: 1, Content: good}))
+	mockAPI.On("GetConfig", mock.Anything, "app").Return(&models.Config{ID: "app", Version: 2, Content: "input { stdin { }"}, nil)
+	mockAPI.On("GetConfig", mock.Anything, "fresh").Return(&models.Config{ID: "fresh", Version: 1, Content: "output { std"}, nil)
+	mockLogstash.On("ValidateConfig", mock.Anything).Return(errors.New("配置验证失败: exit status 1\nExpected one of #"))
+	
+	agent.ctx, agent.cancel = context.WithCancel(context.Background())
+	defer agent.cancel()
+	
+	// 验证失败时恢复之前的版本，不重载，以apply_failed上报验证输出
+	payload, _ := json.Marshal(map[string]interface{}{"config_id": "app", "version": 2, "deployment_id": "dep-1"})
+	err = agent.handleConfigDeploy(agent.ctx, json.RawMessage(payload))
+	assert.ErrorIs(t, err, ErrConfigInvalid)
+	content, readErr := os.ReadFile(configMgr.GetConfigPath("app"))
+	require.NoError(t, readErr)
+	assert.Equal(t, good, string(content))
+	mockLogstash.AssertNotCalled(t, "Reload", mock.Anything)
+	assert.Equal(t, "apply_failed", apiClient.failedStatus)
+	assert.Contains(t, apiClient.failedReason, "Expected one of #")
+	
+	// 之前不存在的配置验证失败时删除
+	payload, _ = json.Marshal(map[string]interface{}{"config_id": "fresh", "version": 1, "deployment_id": "dep-2"})
+	assert.ErrorIs(t, agent.handleConfigDeploy(agent.ctx, json.RawMessage(payload)), ErrConfigInvalid)
+	_, statErr := os.Stat(configMgr.GetConfigPath("fresh"))
+	assert.True(t, os.IsNotExist(statErr))
+}
+
+// cachingLogstashController 在Mock基础上实现CachedConfigValidator
+type cachingLogstashController struct {
+	*MockLogstashController
+	validateErr   error
+	validatedPath string
+	invalidated   bool
+}
+
+func (m *cachingLogstashController) ValidateConfigCached(configPath string) (bool, error) {
+	m.validatedPath = configPath
+	return false, m.validateErr
+}
+
+func (m *cachingLogstashController) InvalidateValidationCache() {
+	m.invalidated = true
+}
+
+func TestAgent_HandleConfigDeploy_InvalidConfigNotSaved(t *testing.T) {
+	agent, mockAPI, mockConfigMgr, mockLogstash, _, _ := createTestAgent(t)
+	ctrl := &cachingLogstashController{
+		MockLogstashController: mockLogstash,
+		validateErr:            errors.New("Expected one of #"),
+	}
+	agent.logstashCtrl = ctrl
+	
+	config := &models.Config{
+		ID:      "bad-config",
+		Content: "input { stdin { }",
+		Version: 2,
+	}
+	payload, _ := json.Marshal(map[string]interface{}{
+		"config_id": "bad-config",
+		"version":   2,
+	})
+	mockAPI.On("GetConfig", mock.Anything, "bad-config").Return(config, nil)
+	
+	agent.ctx, agent.cancel = context.WithCancel(context.Background())
+	defer agent.cancel()
+	
+	err := agent.handleConfigDeploy(agent.ctx, json.RawMessage(payload))
+	assert.Error(t, err)
+	
+	// 验证失败的配置不应写入管道目录，临时文件已清理
+	mockConfigMgr.AssertNotCalled(t, "SaveConfig", mock.Anything)
+	assert.NotEmpty(t, ctrl.validatedPath)
+	_, statErr := os.Stat(ctrl.validatedPath)
+	assert.True(t, os.IsNotExist(statErr))
+	
+	// 平台下发清空验证缓存命令
+	assert.NoError(t, agent.handleMessage(&WebSocketMessage{Type: MsgTypeInvalidateValidationCache}))
+	assert.True(t, ctrl.invalidated)
+}
+
 func TestAgent_HandleConfigDelete(t *testing.T) {
 	agent, _, mockConfigMgr, mockLogstash, _, _ := createTestAgent(t)
 
@@ -511,4 +762,275 @@ func BenchmarkAgent_GetStatus(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		_ = agent.GetStatus()
 	}
-}
\ No newline at end of file
+}
+func TestAgent_HandleSettingsUpdate(t *testing.T) {
+	agent, mockAPI, _, _, mockHeartbeat, mockMetrics := createTestAgent(t)
+	agent.config.Labels = map[string]string{"dc": "local"}
+
+	payload, _ := json.Marshal(map[string]interface{}{
+		"heartbeat_interval": 20,
+		"metrics_interval":   120,
+		"enable_auto_reload": false,
+		"labels":             map[string]string{"env": "prod", "dc": "east"},
+	})
+
+	mockHeartbeat.On("SetInterval", 20*time.Second).Return()
+	mockMetrics.On("SetInterval", 120*time.Second).Return()
+	mockAPI.On("ReportStatus", mock.Anything, mock.Anything).Return(nil)
+
+	err := agent.handleSettingsUpdate(json.RawMessage(payload))
+	assert.NoError(t, err)
+
+	assert.False(t, agent.config.EnableAutoReload)
+	// 本地标签优先于平台下发的标签
+	assert.Equal(t, map[string]string{"env": "prod", "dc": "local"}, agent.GetStatus().Labels)
+	mockHeartbeat.AssertExpectations(t)
+	mockMetrics.AssertExpectations(t)
+}
+
+func TestAgent_HandleSyncHint(t *testing.T) {
+	agent, mockAPI, mockConfigMgr, mockLogstash, _, _ := createTestAgent(t)
+	agent.status.AppliedConfigs = []models.AppliedConfig{{ConfigID: "config-1", Version: 2}}
+
+	cfg := &models.Config{ID: "config-2", Version: 1}
+	mockAPI.On("GetConfig", mock.Anything, "config-2").Return(cfg, nil)
+	mockConfigMgr.On("SaveConfig", cfg).Return(nil)
+	mockConfigMgr.On("GetConfigPath", "config-2").Return("/etc/logstash/conf.d/config-2.conf")
+	mockLogstash.On("ValidateConfig", "/etc/logstash/conf.d/config-2.conf").Return(nil)
+	mockLogstash.On("IsRunning").Return(false)
+	mockAPI.On("ReportConfigApplied", mock.Anything, "test-agent", mock.Anything).Return(nil)
+
+	payload := json.RawMessage(`{"configs":[{"config_id":"config-1","version":2},{"config_id":"config-2","version":1}]}`)
+	err := agent.handleSyncHint(payload)
+
+	assert.NoError(t, err)
+	// 已是目标版本的config-1不应重新拉取
+	mockAPI.AssertNotCalled(t, "GetConfig", mock.Anything, "config-1")
+	mockAPI.AssertExpectations(t)
+}
+
+func TestAgent_HandleMaintenance(t *testing.T) {
+	agent, mockAPI, _, _, _, _ := createTestAgent(t)
+	mockAPI.On("ReportStatus", mock.Anything, mock.Anything).Return(nil)
+	agent.updateStatus(func(s *models.Agent) { s.Status = "online" })
+
+	err := agent.handleMaintenance(json.RawMessage(`{"enabled":true,"reason":"upgrade"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "maintenance", agent.GetStatus().Status)
+
+	// 维护模式下拒绝重载
+	assert.Error(t, agent.handleReloadRequest())
+
+	err = agent.handleMaintenance(json.RawMessage(`{"enabled":false}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "online", agent.GetStatus().Status)
+}
+
+func TestAgent_HandleMaintenanceRestoresStatus(t *testing.T) {
+	agent, mockAPI, _, _, _, _ := createTestAgent(t)
+	agent.ctx = context.Background()
+	mockAPI.On("ReportStatus", mock.Anything, mock.Anything).Return(nil)
+	agent.updateStatus(func(s *models.Agent) { s.Status = "degraded" })
+
+	assert.NoError(t, agent.handleMaintenance(json.RawMessage(`{"enabled":true}`)))
+	// 重复开启不覆盖进入维护前的状态
+	assert.NoError(t, agent.handleMaintenance(json.RawMessage(`{"enabled":true}`)))
+	assert.Equal(t, "maintenance", agent.GetStatus().Status)
+
+	// 退出维护后恢复为进入前的降级状态
+	assert.NoError(t, agent.handleMaintenance(json.RawMessage(`{"enabled":false}`)))
+	assert.Equal(t, "degraded", agent.GetStatus().Status)
+
+	// 维护期间组件恢复，退出后为在线
+	assert.NoError(t, agent.handleMaintenance(json.RawMessage(`{"enabled":true}`)))
+	agent.onWatchdogStateChange(false, nil)
+	assert.Equal(t, "maintenance", agent.GetStatus().Status)
+	assert.NoError(t, agent.handleMaintenance(json.RawMessage(`{"enabled":false}`)))
+	assert.Equal(t, "online", agent.GetStatus().Status)
+}
+
+func TestAgent_HandleLogLevel(t *testing.T) {
+	agent, _, _, _, _, _ := createTestAgent(t)
+
+	assert.NoError(t, agent.handleLogLevel(json.RawMessage(`{"level":"warn"}`)))
+	assert.Equal(t, logrus.WarnLevel, agent.logger.GetLevel())
+	assert.Error(t, agent.handleLogLevel(json.RawMessage(`{"level":"loud"}`)))
+}
+
+func TestAgent_HandleTelemetryIntervals(t *testing.T) {
+	agent, mockAPI, _, _, mockHeartbeat, mockMetrics := createTestAgent(t)
+	agent.config.MaxHeartbeatInterval = 90 * time.Second
+	mockAPI.On("ReportStatus", mock.Anything, mock.Anything).Return(nil)
+
+	// 平台下限超过agent.yaml允许的最大值时取最大值，没有下限的指标间隔保持本地设置
+	payload, _ := json.Marshal(models.TelemetryIntervals{HeartbeatInterval: 120, FleetSize: 5000, Pressure: 2})
+	mockHeartbeat.On("SetInterval", 90*time.Second).Return().Once()
+	mockMetrics.On("SetInterval", 60*time.Second).Return().Once()
+	assert.NoError(t, agent.handleMessage(&WebSocketMessage{Type: MsgTypeTelemetryIntervals, Payload: payload}))
+	assert.Equal(t, 90*time.Second, agent.heartbeatInterval())
+
+	// 分组设置缩短间隔时平台下限仍然生效
+	payload, _ = json.Marshal(models.TelemetryIntervals{HeartbeatInterval: 45})
+	mockHeartbeat.On("SetInterval", 45*time.Second).Return().Twice()
+	mockMetrics.On("SetInterval", 60*time.Second).Return().Once()
+	assert.NoError(t, agent.handleTelemetryIntervals(payload))
+	settings, _ := json.Marshal(map[string]interface{}{"heartbeat_interval": 20})
+	assert.NoError(t, agent.handleSettingsUpdate(settings))
+	assert.Equal(t, 20*time.Second, agent.config.HeartbeatInterval)
+
+	// 压力消失后恢复本地设置
+	mockHeartbeat.On("SetInterval", 20*time.Second).Return().Once()
+	mockMetrics.On("SetInterval", 60*time.Second).Return().Once()
+	assert.NoError(t, agent.handleTelemetryIntervals(json.RawMessage(`{"heartbeat_interval":0,"metrics_interval":0}`)))
+
+	mockHeartbeat.AssertExpectations(t)
+	mockMetrics.AssertExpectations(t)
+}
+
+type eventReportingAPIClient struct {
+	*MockAPIClient
+	mu     sync.Mutex
+	events []*models.AgentEventReport
+}
+
+func (m *eventReportingAPIClient) ReportEvent(ctx context.Context, agentID string, event *models.AgentEventReport) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, event)
+	return nil
+}
+
+func (m *eventReportingAPIClient) eventTypes() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	types := make([]string, 0, len(m.events))
+	for _, e := range m.events {
+		types = append(types, e.Type)
+	}
+	return types
+}
+
+func TestAgent_LogstashEvents(t *testing.T) {
+	agent, mockAPI, _, mockLogstash, _, _ := createTestAgent(t)
+	client := &eventReportingAPIClient{MockAPIClient: mockAPI}
+	agent.apiClient = client
+	agent.config.CrashRestartDelay = 10 * time.Millisecond
+	agent.ctx, agent.cancel = context.WithCancel(context.Background())
+	defer agent.cancel()
+	
+	// 重载失败
+	mockLogstash.On("IsRunning").Return(true)
+	mockLogstash.On("Reload", mock.Anything).Return(errors.New("SIGHUP失败")).Once()
+	assert.Error(t, agent.handleReloadRequest())
+	assert.Equal(t, []string{models.AgentEventReloadFailed}, client.eventTypes())
+	
+	// 意外退出后上报崩溃并重新启动
+	mockLogstash.On("Start", mock.Anything).Return(nil).Once()
+	agent.onLogstashExit(errors.New("exit status 137"))
+	assert.Eventually(t, func() bool { return len(client.eventTypes()) == 3 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{models.AgentEventReloadFailed, models.AgentEventLogstashCrashed, models.AgentEventLogstashRestarted}, client.eventTypes())
+	assert.Equal(t, "exit status 137", client.events[1].Reason)
+	
+	// Agent停止时进程随上下文退出，不视为崩溃
+	agent.cancel()
+	agent.onLogstashExit(errors.New("signal: killed"))
+	assert.Len(t, client.eventTypes(), 3)
+	mockLogstash.AssertNumberOfCalls(t, "Start", 1)
+}
+
+func TestAgent_ResourcePressure(t *testing.T) {
+	agent, mockAPI, _, mockLogstash, _, _ := createTestAgent(t)
+	client := &eventReportingAPIClient{MockAPIClient: mockAPI}
+	agent.apiClient = client
+	agent.config.PauseReloadOnPressure = true
+	agent.ctx, agent.cancel = context.WithCancel(context.Background())
+	defer agent.cancel()
+	agent.updateStatus(func(s *models.Agent) { s.Status = "online" })
+	mockAPI.On("ReportStatus", mock.Anything, mock.Anything).Return(nil)
+	mockLogstash.On("IsRunning").Return(true)
+
+	memory := 97.0
+	agent.resources = NewResourceGuard(nil, ResourceLimits{MemoryPercent: 95}, time.Second,
+		func(dirs []string) (*ResourceUsage, error) { return &ResourceUsage{Memory: memory}, nil },
+		agent.onResourcePressure, agent.logger)
+
+	// 超过阈值：上报warning并拒绝部署
+	agent.resources.Check()
+	status := agent.GetStatus()
+	assert.Equal(t, models.AgentStatusWarning, status.Status)
+	require.NotNil(t, status.ResourcePressure)
+	assert.True(t, status.ResourcePressure.ReloadsPaused)
+	assert.Equal(t, []string{models.AgentEventResourcePressure}, client.eventTypes())
+
+	payload, _ := json.Marshal(map[string]interface{}{"config_id": "test-config", "version": 2})
+	err := agent.handleConfigDeploy(agent.ctx, json.RawMessage(payload))
+	assert.ErrorIs(t, err, ErrResourcePressure)
+	assert.Contains(t, err.Error(), "主机内存")
+
+	// 暂停期间的自动重载推迟到恢复后
+	queued, err := agent.autoReload(ReloadSourceDrift)
+	assert.NoError(t, err)
+	assert.True(t, queued)
+	mockLogstash.AssertNotCalled(t, "Reload", mock.Anything)
+
+	// 恢复后回到在线并补做重载
+	mockLogstash.On("Reload", mock.Anything).Return(nil).Once()
+	memory = 60
+	agent.resources.Check()
+	status = agent.GetStatus()
+	assert.Equal(t, "online", status.Status)
+	assert.Nil(t, status.ResourcePressure)
+	assert.Equal(t, []string{models.AgentEventResourcePressure, models.AgentEventResourceRecovered}, client.eventTypes())
+	mockLogstash.AssertNumberOfCalls(t, "Reload", 1)
+}
+
+type secretAPIClient struct {
+	*MockAPIClient
+	secrets map[string]string
+}
+
+func (m *secretAPIClient) ResolveSecrets(ctx context.Context, agentID, configID string, names []string) (map[string]string, error) {
+	resolved := make(map[string]string)
+	for _, name := range names {
+		if value, ok := m.secrets[name]; ok {
+			resolved[name] = value
+		}
+	}
+	return resolved, nil
+}
+
+type keystoreLogstashController struct {
+	*MockLogstashController
+	keystore map[string]string
+}
+
+func (m *keystoreLogstashController) AddKeystoreSecrets(ctx context.Context, secrets map[string]string) error {
+	for name, value := range secrets {
+		m.keystore[name] = value
+	}
+	return nil
+}
+
+func TestAgent_InjectSecrets(t *testing.T) {
+	agent, mockAPI, _, mockLogstash, _, _ := createTestAgent(t)
+	agent.apiClient = &secretAPIClient{MockAPIClient: mockAPI, secrets: map[string]string{"es_password": "changeme"}}
+	ctrl := &keystoreLogstashController{MockLogstashController: mockLogstash, keystore: map[string]string{}}
+	agent.logstashCtrl = ctrl
+	agent.ctx = context.Background()
+	content := `output { elasticsearch { password => "${secret:es_password}" } }`
+	
+	// 默认写入keystore，配置文件中只保留keystore引用
+	cfg := &models.Config{ID: "cfg-1", Content: content}
+	require.NoError(t, agent.injectSecrets(cfg))
+	assert.Equal(t, `output { elasticsearch { password => "${es_password}" } }`, cfg.Content)
+	assert.Equal(t, map[string]string{"es_password": "changeme"}, ctrl.keystore)
+	
+	agent.config.SecretInjection = config.SecretInjectionFile
+	cfg = &models.Config{ID: "cfg-1", Content: content}
+	require.NoError(t, agent.injectSecrets(cfg))
+	assert.Equal(t, `output { elasticsearch { password => "changeme" } }`, cfg.Content)
+	
+	// 平台未返回引用的密钥时部署失败
+	cfg = &models.Config{ID: "cfg-1", Content: `password => "${secret:missing}"`}
+	assert.ErrorContains(t, agent.injectSecrets(cfg), "missing")
+}
@@ -0,0 +1,43 @@
+package core
+
+import (
+	"logstash-platform/internal/platform/models"
+)
+
+// restoreAppliedConfigs 启动时从配置管理器恢复上次运行的已应用配置，注册时随状态上报给平台
+// 配置管理器不支持持久化时保持为空，与升级前的行为一致
+func (a *Agent) restoreAppliedConfigs() {
+	store, ok := a.configMgr.(AppliedConfigStore)
+	if !ok {
+		return
+	}
+
+	applied, err := store.LoadAppliedConfigs()
+	if err != nil {
+		a.logger.WithError(err).Warn("恢复已应用配置失败")
+		return
+	}
+	a.updateStatus(func(s *models.Agent) {
+		s.AppliedConfigs = applied
+	})
+	a.logger.WithField("configs", len(applied)).Info("已恢复已应用配置")
+
+	// 核对时移除或补入的配置立即落盘
+	a.persistAppliedConfigs()
+}
+
+// persistAppliedConfigs 将当前的已应用配置集合写入配置管理器
+// 在appliedMux内读取快照再写入，并发的部署和删除按完成顺序落盘，后写入的总是最新的集合
+func (a *Agent) persistAppliedConfigs() {
+	store, ok := a.configMgr.(AppliedConfigStore)
+	if !ok {
+		return
+	}
+
+	a.appliedMux.Lock()
+	defer a.appliedMux.Unlock()
+
+	if err := store.SaveAppliedConfigs(a.GetStatus().AppliedConfigs); err != nil {
+		a.logger.WithError(err).Warn("持久化已应用配置失败")
+	}
+}
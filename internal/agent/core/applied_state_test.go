@@ -0,0 +1,59 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+)
+
+type appliedStoreConfigManager struct {
+	*MockConfigManager
+	stored []models.AppliedConfig
+	saves  int
+}
+
+func (m *appliedStoreConfigManager) SaveAppliedConfigs(applied []models.AppliedConfig) error {
+	m.stored = append([]models.AppliedConfig(nil), applied...)
+	m.saves++
+	return nil
+}
+
+func (m *appliedStoreConfigManager) LoadAppliedConfigs() ([]models.AppliedConfig, error) {
+	return append([]models.AppliedConfig(nil), m.stored...), nil
+}
+
+func TestAgent_RestoreAppliedConfigs(t *testing.T) {
+	agent, _, mockConfigMgr, _, _, _ := createTestAgent(t)
+	store := &appliedStoreConfigManager{
+		MockConfigManager: mockConfigMgr,
+		stored:            []models.AppliedConfig{{ConfigID: "nginx", Version: 3, AppliedAt: time.Now(), Hash: "abc"}},
+	}
+	agent.configMgr = store
+
+	agent.restoreAppliedConfigs()
+	applied := agent.GetStatus().AppliedConfigs
+	require.Len(t, applied, 1)
+	assert.Equal(t, "nginx", applied[0].ConfigID)
+	assert.Equal(t, 3, applied[0].Version)
+	assert.Equal(t, 1, store.saves, "核对后的集合立即落盘")
+
+	// 状态变化后持久化的是最新的集合
+	agent.updateStatus(func(s *models.Agent) {
+		s.AppliedConfigs = append(s.AppliedConfigs, models.AppliedConfig{ConfigID: "kafka", Version: 1})
+	})
+	agent.persistAppliedConfigs()
+	require.Len(t, store.stored, 2)
+	assert.Equal(t, "kafka", store.stored[1].ConfigID)
+}
+
+func TestAgent_RestoreAppliedConfigs_Unsupported(t *testing.T) {
+	agent, _, _, _, _, _ := createTestAgent(t)
+
+	// 配置管理器不支持持久化时保持为空
+	agent.restoreAppliedConfigs()
+	agent.persistAppliedConfigs()
+	assert.Empty(t, agent.GetStatus().AppliedConfigs)
+}
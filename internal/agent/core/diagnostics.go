@@ -0,0 +1,165 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"logstash-platform/internal/agent/config"
+	"logstash-platform/internal/platform/models"
+)
+
+// 远程诊断的限制
+const (
+	diagnosticTimeout      = 45 * time.Second // 单个诊断命令的最长执行时间
+	maxDiagnosticOutput    = 256 * 1024       // 输出超过该大小时截断
+	defaultDiagnosticLines = 100
+)
+
+// Diagnostics 执行平台下发的诊断命令
+// 只支持models.DiagnosticCommands中的固定操作，参数只用于选择日志文件和行数，不经过shell
+type Diagnostics struct {
+	logstashPath string
+	configDir    string
+	dataDir      string
+	logDir       string
+}
+
+// NewDiagnostics 按Agent配置创建诊断
+func NewDiagnostics(cfg *config.AgentConfig) *Diagnostics {
+	return &Diagnostics{
+		logstashPath: cfg.LogstashPath,
+		configDir:    cfg.ConfigDir,
+		dataDir:      cfg.DataDir,
+		logDir:       cfg.LogDir,
+	}
+}
+
+// Run 执行诊断命令，失败原因写入结果的Error
+func (d *Diagnostics) Run(ctx context.Context, req models.DiagnosticPayload) *models.DiagnosticResult {
+	start := time.Now()
+	result := &models.DiagnosticResult{RequestID: req.RequestID, Command: req.Command}
+
+	ctx, cancel := context.WithTimeout(ctx, diagnosticTimeout)
+	defer cancel()
+
+	var err error
+	switch req.Command {
+	case models.DiagnosticLogstashVersion:
+		err = d.logstashVersion(ctx, result)
+	case models.DiagnosticDataDirUsage:
+		err = d.dataDirUsage(result)
+	case models.DiagnosticListConfigs:
+		err = d.listConfigs(result)
+	case models.DiagnosticTailLog:
+		err = d.tailLog(req.DiagnosticRequest, result)
+	default:
+		err = fmt.Errorf("不支持的诊断命令: %s", req.Command)
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	if len(result.Output) > maxDiagnosticOutput {
+		result.Output = result.Output[len(result.Output)-maxDiagnosticOutput:]
+		result.Truncated = true
+	}
+	result.DurationMs = time.Since(start).Milliseconds()
+	return result
+}
+
+// logstashVersion 执行 logstash --version
+func (d *Diagnostics) logstashVersion(ctx context.Context, result *models.DiagnosticResult) error {
+	output, err := exec.CommandContext(ctx, d.logstashPath, "--version").CombinedOutput()
+	result.Output = strings.TrimSpace(string(output))
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("执行超时")
+	}
+	if err != nil {
+		return fmt.Errorf("执行 logstash --version 失败: %w", err)
+	}
+	return nil
+}
+
+// dataDirUsage 统计数据目录的总占用及各一级子目录（持久化队列、死信队列等）的占用
+func (d *Diagnostics) dataDirUsage(result *models.DiagnosticResult) error {
+	entries, err := ioutil.ReadDir(d.dataDir)
+	if err != nil {
+		return fmt.Errorf("读取数据目录失败: %w", err)
+	}
+	for _, entry := range entries {
+		size := entry.Size()
+		if entry.IsDir() {
+			size = dirSize(filepath.Join(d.dataDir, entry.Name()))
+		}
+		result.TotalBytes += size
+		result.Files = append(result.Files, models.DiagnosticFile{
+			Name:       entry.Name(),
+			SizeBytes:  size,
+			ModifiedAt: entry.ModTime().UTC().Format(time.RFC3339),
+		})
+	}
+	sort.Slice(result.Files, func(i, j int) bool { return result.Files[i].SizeBytes > result.Files[j].SizeBytes })
+	return nil
+}
+
+// dirSize 目录下全部文件的大小之和，无法访问的文件跳过
+func dirSize(dir string) int64 {
+	var total int64
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total
+}
+
+// listConfigs 列出配置目录中的.conf文件
+func (d *Diagnostics) listConfigs(result *models.DiagnosticResult) error {
+	entries, err := ioutil.ReadDir(d.configDir)
+	if err != nil {
+		return fmt.Errorf("读取配置目录失败: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".conf") {
+			continue
+		}
+		result.Files = append(result.Files, models.DiagnosticFile{
+			Name:       entry.Name(),
+			SizeBytes:  entry.Size(),
+			ModifiedAt: entry.ModTime().UTC().Format(time.RFC3339),
+		})
+		result.TotalBytes += entry.Size()
+	}
+	return nil
+}
+
+// tailLog 读取日志文件末尾的若干行
+func (d *Diagnostics) tailLog(req models.DiagnosticRequest, result *models.DiagnosticResult) error {
+	path, err := logFilePath(d.logDir, req.Source, req.Pipeline)
+	if err != nil {
+		return err
+	}
+	lines := req.Lines
+	if lines <= 0 {
+		lines = defaultDiagnosticLines
+	}
+	if lines > models.MaxDiagnosticTailLines {
+		lines = models.MaxDiagnosticTailLines
+	}
+
+	var out []string
+	file := &tailFile{path: path}
+	defer file.close()
+	if err := file.open(lines, func(line string) { out = append(out, line) }); err != nil {
+		return fmt.Errorf("读取日志文件失败: %w", err)
+	}
+	result.Output = strings.Join(out, "\n")
+	return nil
+}
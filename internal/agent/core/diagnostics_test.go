@@ -0,0 +1,99 @@
+package core
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/agent/config"
+	"logstash-platform/internal/platform/models"
+)
+
+func newTestDiagnostics(t *testing.T) (*Diagnostics, *config.AgentConfig) {
+	cfg := &config.AgentConfig{
+		LogstashPath: filepath.Join(t.TempDir(), "logstash"),
+		ConfigDir:    t.TempDir(),
+		DataDir:      t.TempDir(),
+		LogDir:       t.TempDir(),
+	}
+	require.NoError(t, ioutil.WriteFile(cfg.LogstashPath, []byte("#!/bin/sh\necho \"logstash 8.11.0\"\n"), 0755))
+	return NewDiagnostics(cfg), cfg
+}
+
+func runDiagnostic(d *Diagnostics, req models.DiagnosticRequest) *models.DiagnosticResult {
+	return d.Run(context.Background(), models.DiagnosticPayload{RequestID: "req-1", DiagnosticRequest: req})
+}
+
+func TestDiagnostics_Run(t *testing.T) {
+	d, cfg := newTestDiagnostics(t)
+
+	result := runDiagnostic(d, models.DiagnosticRequest{Command: models.DiagnosticLogstashVersion})
+	assert.Empty(t, result.Error)
+	assert.Equal(t, "logstash 8.11.0", result.Output)
+	assert.Equal(t, "req-1", result.RequestID)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(cfg.ConfigDir, "nginx.conf"), []byte("input {}"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(cfg.ConfigDir, "notes.txt"), []byte("x"), 0644))
+	result = runDiagnostic(d, models.DiagnosticRequest{Command: models.DiagnosticListConfigs})
+	require.Len(t, result.Files, 1)
+	assert.Equal(t, "nginx.conf", result.Files[0].Name)
+	assert.Equal(t, int64(8), result.Files[0].SizeBytes)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(cfg.DataDir, "queue", "main"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(cfg.DataDir, "queue", "main", "page.1"), make([]byte, 1000), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(cfg.DataDir, "uuid"), make([]byte, 36), 0644))
+	result = runDiagnostic(d, models.DiagnosticRequest{Command: models.DiagnosticDataDirUsage})
+	assert.Equal(t, int64(1036), result.TotalBytes)
+	require.Len(t, result.Files, 2)
+	assert.Equal(t, "queue", result.Files[0].Name, "按占用从大到小排序")
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(cfg.LogDir, "logstash-plain.log"), []byte("one\ntwo\nthree\n"), 0644))
+	result = runDiagnostic(d, models.DiagnosticRequest{Command: models.DiagnosticTailLog, Lines: 2})
+	assert.Empty(t, result.Error)
+	assert.Equal(t, "two\nthree", result.Output)
+
+	result = runDiagnostic(d, models.DiagnosticRequest{Command: models.DiagnosticTailLog, Source: models.LogSourcePipeline, Pipeline: "../../etc/passwd"})
+	assert.Contains(t, result.Error, "管道ID无效")
+
+	result = runDiagnostic(d, models.DiagnosticRequest{Command: "sh -c id"})
+	assert.Contains(t, result.Error, "不支持的诊断命令")
+}
+
+func TestAgent_HandleDiagnosticRequest(t *testing.T) {
+	d, cfg := newTestDiagnostics(t)
+	sender := &resultRecorder{results: make(chan *models.DiagnosticResult, 1)}
+	agent := &Agent{config: cfg, logger: logrus.New(), ctx: context.Background(), sender: sender, diagnostics: d}
+
+	require.NoError(t, agent.handleMessage(&WebSocketMessage{
+		Type:    MsgTypeDiagnosticRequest,
+		Payload: []byte(`{"request_id":"req-7","command":"logstash_version"}`),
+	}))
+	select {
+	case result := <-sender.results:
+		assert.Equal(t, "req-7", result.RequestID)
+		assert.Equal(t, "logstash 8.11.0", result.Output)
+	case <-time.After(5 * time.Second):
+		t.Fatal("未收到诊断结果")
+	}
+	agent.wg.Wait()
+
+	assert.Error(t, agent.handleMessage(&WebSocketMessage{Type: MsgTypeDiagnosticRequest, Payload: []byte(`{"command":"list_configs"}`)}))
+}
+
+// resultRecorder 记录回复的诊断结果
+type resultRecorder struct {
+	results chan *models.DiagnosticResult
+}
+
+func (r *resultRecorder) SendMessage(msgType string, payload interface{}) error {
+	if msgType == MsgTypeDiagnosticResult {
+		r.results <- payload.(*models.DiagnosticResult)
+	}
+	return nil
+}
@@ -0,0 +1,293 @@
+package core
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"logstash-platform/internal/platform/models"
+)
+
+// Logstash死信队列段文件的格式（org.logstash.common.io.RecordIOWriter）
+// 文件以版本字节开头，之后按32KB分块；事件序列化后按块切分为 complete 或 start/middle/end 记录，
+// 每条记录的头部为 类型(1) + 本段长度(4) + 事件总长度(4，仅start) + CRC32(4)
+const (
+	dlqVersion      = '1'
+	dlqBlockSize    = 32 * 1024
+	dlqHeaderSize   = 13
+	dlqRecordFull   = 'c'
+	dlqRecordStart  = 's'
+	dlqRecordMiddle = 'm'
+	dlqRecordEnd    = 'e'
+)
+
+// ErrDLQNotFound 管道的死信队列目录不存在，管道未启用死信队列或尚未写入过事件
+var ErrDLQNotFound = errors.New("死信队列不存在")
+
+// dlqDir 管道死信队列所在目录，dataDir为Logstash的 path.data
+func dlqDir(dataDir, pipeline string) (string, error) {
+	if pipeline == "" || pipeline != filepath.Base(pipeline) || strings.HasPrefix(pipeline, ".") {
+		return "", fmt.Errorf("管道ID无效: %q", pipeline)
+	}
+	return filepath.Join(dataDir, "dead_letter_queue", pipeline), nil
+}
+
+// readDLQ 读取死信队列，失败原因写入结果的Error
+func readDLQ(dataDir string, req models.DLQPayload) *models.DLQResult {
+	start := time.Now()
+	result := &models.DLQResult{Page: req.Page, PageSize: req.PageSize, Entries: []models.DLQEntry{}}
+	dir, err := dlqDir(dataDir, req.Pipeline)
+	if err == nil {
+		var page *models.DLQResult
+		if page, err = readDLQPage(dir, req.Page, req.PageSize); err == nil {
+			result = page
+		}
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	result.RequestID = req.RequestID
+	result.Pipeline = req.Pipeline
+	result.DurationMs = time.Since(start).Milliseconds()
+	return result
+}
+
+// readDLQPage 按段文件先后顺序读取死信队列中第page页的事件，写入中的段文件（.log.tmp）不读取
+// 事件内容转为JSON，超过 models.MaxDLQEventBytes 时截断
+func readDLQPage(dir string, page, pageSize int) (*models.DLQResult, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = models.DefaultDLQPageSize
+	}
+	if pageSize > models.MaxDLQPageSize {
+		pageSize = models.MaxDLQPageSize
+	}
+
+	segments, size, err := dlqSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+	result := &models.DLQResult{
+		Page:      page,
+		PageSize:  pageSize,
+		Segments:  len(segments),
+		SizeBytes: size,
+		Entries:   []models.DLQEntry{},
+	}
+
+	skip := (page - 1) * pageSize
+	for _, segment := range segments {
+		data, err := os.ReadFile(filepath.Join(dir, segment))
+		if err != nil {
+			return nil, fmt.Errorf("读取段文件 %s 失败: %w", segment, err)
+		}
+		err = scanDLQSegment(data, func(record []byte) bool {
+			if skip > 0 {
+				skip--
+				return true
+			}
+			if len(result.Entries) == pageSize {
+				result.HasMore = true
+				return false
+			}
+			result.Entries = append(result.Entries, parseDLQEntry(segment, record))
+			return true
+		})
+		if err != nil {
+			return nil, fmt.Errorf("解析段文件 %s 失败: %w", segment, err)
+		}
+		if result.HasMore {
+			break
+		}
+	}
+	return result, nil
+}
+
+// dlqSegments 按编号排序的已写完的段文件及其总大小
+func dlqSegments(dir string) ([]string, int64, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, 0, ErrDLQNotFound
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("读取死信队列目录失败: %w", err)
+	}
+
+	var segments []string
+	var size int64
+	number := make(map[string]int)
+	for _, entry := range entries {
+		n, err := strconv.Atoi(strings.TrimSuffix(entry.Name(), ".log"))
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".log") || err != nil {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			size += info.Size()
+		}
+		number[entry.Name()] = n
+		segments = append(segments, entry.Name())
+	}
+	sort.Slice(segments, func(i, j int) bool { return number[segments[i]] < number[segments[j]] })
+	return segments, size, nil
+}
+
+// scanDLQSegment 逐个拼接段文件中的事件并交给fn，fn返回false时停止
+// 块尾不足一个记录头时跳到下一块；校验和不符的记录连同所属事件一起丢弃
+func scanDLQSegment(data []byte, fn func(record []byte) bool) error {
+	if len(data) == 0 {
+		return nil
+	}
+	if data[0] != dlqVersion {
+		return fmt.Errorf("不支持的段文件版本: %q", data[0])
+	}
+	data = data[1:]
+
+	var pending []byte
+	assembling := false
+	for pos := 0; pos+dlqHeaderSize < len(data); {
+		blockEnd := (pos/dlqBlockSize + 1) * dlqBlockSize
+		if blockEnd-pos < dlqHeaderSize+1 {
+			pos = blockEnd
+			continue
+		}
+
+		recordType := data[pos]
+		length := int(binary.BigEndian.Uint32(data[pos+1:]))
+		checksum := binary.BigEndian.Uint32(data[pos+9:])
+		start := pos + dlqHeaderSize
+		if recordType != dlqRecordFull && recordType != dlqRecordStart && recordType != dlqRecordMiddle && recordType != dlqRecordEnd {
+			// 块尾的填充
+			pos = blockEnd
+			continue
+		}
+		if length < 0 || start+length > len(data) || start+length > blockEnd {
+			// 写到一半的记录
+			return nil
+		}
+		body := data[start : start+length]
+		pos = start + length
+
+		if crc32.ChecksumIEEE(body) != checksum {
+			pending, assembling = nil, false
+			continue
+		}
+		switch recordType {
+		case dlqRecordFull:
+			pending, assembling = nil, false
+			if !fn(body) {
+				return nil
+			}
+		case dlqRecordStart:
+			pending, assembling = append([]byte(nil), body...), true
+		case dlqRecordMiddle:
+			if assembling {
+				pending = append(pending, body...)
+			}
+		case dlqRecordEnd:
+			if !assembling {
+				continue
+			}
+			record := append(pending, body...)
+			pending, assembling = nil, false
+			if !fn(record) {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// parseDLQEntry 解析事件记录（org.logstash.DLQEntry）
+// 依次为 写入时间、CBOR序列化的事件、插件类型、插件ID、原因，每项以4字节长度开头
+func parseDLQEntry(segment string, record []byte) models.DLQEntry {
+	entry := models.DLQEntry{Segment: segment}
+	var fields [5][]byte
+	for i := range fields {
+		if len(record) < 4 {
+			entry.Error = "事件记录不完整"
+			return entry
+		}
+		n := int(binary.BigEndian.Uint32(record))
+		if n < 0 || 4+n > len(record) {
+			entry.Error = "事件记录不完整"
+			return entry
+		}
+		fields[i] = record[4 : 4+n]
+		record = record[4+n:]
+	}
+	entry.EntryTime = string(fields[0])
+	entry.PluginType = string(fields[2])
+	entry.PluginID = string(fields[3])
+	entry.Reason = string(fields[4])
+
+	event, err := decodeDLQEvent(fields[1])
+	if err != nil {
+		entry.Error = fmt.Sprintf("解析事件失败: %v", err)
+		return entry
+	}
+	raw, err := json.Marshal(event)
+	if err != nil {
+		entry.Error = fmt.Sprintf("转换事件失败: %v", err)
+		return entry
+	}
+	if len(raw) > models.MaxDLQEventBytes {
+		raw, _ = json.Marshal(string(raw[:models.MaxDLQEventBytes]))
+		entry.Truncated = true
+	}
+	entry.Event = raw
+	return entry
+}
+
+// decodeDLQEvent 解码事件，Logstash序列化为 {"DATA": 字段, "META": 元数据}，元数据放入 @metadata
+func decodeDLQEvent(data []byte) (map[string]interface{}, error) {
+	d := &cborDecoder{data: data}
+	value, err := d.decode()
+	if err != nil {
+		return nil, err
+	}
+	root, ok := unwrapJavaTypes(value).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("事件不是对象")
+	}
+	event, _ := root["DATA"].(map[string]interface{})
+	if event == nil {
+		event = make(map[string]interface{})
+	}
+	if meta, ok := root["META"].(map[string]interface{}); ok && len(meta) > 0 {
+		event["@metadata"] = meta
+	}
+	return event, nil
+}
+
+// unwrapJavaTypes 去掉Jackson写入的Java类型信息：["org.logstash.ConvertedMap", {...}] 取后者
+func unwrapJavaTypes(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = unwrapJavaTypes(item)
+		}
+		return v
+	case []interface{}:
+		if len(v) == 2 {
+			if class, ok := v[0].(string); ok && (strings.HasPrefix(class, "org.logstash.") || strings.HasPrefix(class, "java.")) {
+				return unwrapJavaTypes(v[1])
+			}
+		}
+		for i, item := range v {
+			v[i] = unwrapJavaTypes(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
@@ -0,0 +1,239 @@
+package core
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// cborMaxDepth 嵌套层数上限，防止损坏的数据导致无限递归
+const cborMaxDepth = 64
+
+// cborDecoder 解码死信事件用的最小CBOR实现（RFC 8949）
+// 支持Jackson生成的全部类型，包括不定长的字符串、数组和对象；标签被忽略，只保留其内容
+type cborDecoder struct {
+	data  []byte
+	pos   int
+	depth int
+}
+
+// cborBreak 不定长数据的结束标记
+type cborBreak struct{}
+
+func (d *cborDecoder) decode() (interface{}, error) {
+	d.depth++
+	defer func() { d.depth-- }()
+	if d.depth > cborMaxDepth {
+		return nil, fmt.Errorf("嵌套层数超过%d", cborMaxDepth)
+	}
+
+	if d.pos >= len(d.data) {
+		return nil, fmt.Errorf("数据不完整")
+	}
+	initial := d.data[d.pos]
+	d.pos++
+	major, info := initial>>5, initial&0x1f
+
+	if major == 7 {
+		return d.simple(info)
+	}
+	if info == 31 {
+		return d.indefinite(major)
+	}
+	arg, err := d.argument(info)
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case 0:
+		return arg, nil
+	case 1:
+		if arg > math.MaxInt64 {
+			return -float64(arg) - 1, nil
+		}
+		return -int64(arg) - 1, nil
+	case 2:
+		b, err := d.bytes(arg)
+		return b, err
+	case 3:
+		b, err := d.bytes(arg)
+		return string(b), err
+	case 4:
+		items := make([]interface{}, 0, min(arg, 1024))
+		for i := uint64(0); i < arg; i++ {
+			item, err := d.decode()
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	case 5:
+		object := make(map[string]interface{})
+		for i := uint64(0); i < arg; i++ {
+			if err := d.pair(object); err != nil {
+				return nil, err
+			}
+		}
+		return object, nil
+	default: // 6 标签
+		return d.decode()
+	}
+}
+
+// argument 读取数据项的长度或整数值
+func (d *cborDecoder) argument(info byte) (uint64, error) {
+	if info < 24 {
+		return uint64(info), nil
+	}
+	if info > 27 {
+		return 0, fmt.Errorf("无效的附加信息: %d", info)
+	}
+	n := 1 << (info - 24)
+	if d.pos+n > len(d.data) {
+		return 0, fmt.Errorf("数据不完整")
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	switch n {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	default:
+		return binary.BigEndian.Uint64(b), nil
+	}
+}
+
+func (d *cborDecoder) bytes(n uint64) ([]byte, error) {
+	if n > uint64(len(d.data)-d.pos) {
+		return nil, fmt.Errorf("数据不完整")
+	}
+	b := d.data[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+	return b, nil
+}
+
+// pair 读取对象的一个键值对，非字符串的键转为字符串
+func (d *cborDecoder) pair(object map[string]interface{}) error {
+	key, err := d.decode()
+	if err != nil {
+		return err
+	}
+	value, err := d.decode()
+	if err != nil {
+		return err
+	}
+	if _, ok := value.(cborBreak); ok {
+		return fmt.Errorf("对象缺少值")
+	}
+	object[fmt.Sprint(key)] = value
+	return nil
+}
+
+// indefinite 读取不定长的字符串、数组或对象，直到结束标记
+func (d *cborDecoder) indefinite(major byte) (interface{}, error) {
+	switch major {
+	case 2, 3:
+		var b []byte
+		for {
+			chunk, err := d.decode()
+			if err != nil {
+				return nil, err
+			}
+			switch c := chunk.(type) {
+			case cborBreak:
+				if major == 3 {
+					return string(b), nil
+				}
+				return b, nil
+			case []byte:
+				b = append(b, c...)
+			case string:
+				b = append(b, c...)
+			default:
+				return nil, fmt.Errorf("不定长字符串中的分段类型无效")
+			}
+		}
+	case 4:
+		items := []interface{}{}
+		for {
+			item, err := d.decode()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := item.(cborBreak); ok {
+				return items, nil
+			}
+			items = append(items, item)
+		}
+	case 5:
+		object := make(map[string]interface{})
+		for {
+			if d.pos < len(d.data) && d.data[d.pos] == 0xff {
+				d.pos++
+				return object, nil
+			}
+			if err := d.pair(object); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("类型 %d 不支持不定长", major)
+	}
+}
+
+// simple 读取布尔、空值、浮点数和结束标记
+func (d *cborDecoder) simple(info byte) (interface{}, error) {
+	switch info {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22, 23:
+		return nil, nil
+	case 25:
+		bits, err := d.argument(info)
+		return halfToFloat(uint16(bits)), err
+	case 26:
+		bits, err := d.argument(info)
+		return float64(math.Float32frombits(uint32(bits))), err
+	case 27:
+		bits, err := d.argument(info)
+		return math.Float64frombits(bits), err
+	case 31:
+		return cborBreak{}, nil
+	default:
+		if info == 24 {
+			_, err := d.argument(info)
+			return nil, err
+		}
+		return nil, nil
+	}
+}
+
+// halfToFloat 半精度浮点数
+func halfToFloat(h uint16) float64 {
+	exp := int(h>>10) & 0x1f
+	mant := float64(h & 0x3ff)
+	var value float64
+	switch exp {
+	case 0:
+		value = math.Ldexp(mant, -24)
+	case 31:
+		if mant == 0 {
+			value = math.Inf(1)
+		} else {
+			value = math.NaN()
+		}
+	default:
+		value = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		return -value
+	}
+	return value
+}
@@ -0,0 +1,238 @@
+package core
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/agent/config"
+	"logstash-platform/internal/platform/models"
+)
+
+// cborText CBOR定长字符串
+func cborText(s string) []byte {
+	return append(cborHead(3, len(s)), s...)
+}
+
+func cborHead(major byte, n int) []byte {
+	switch {
+	case n < 24:
+		return []byte{major<<5 | byte(n)}
+	case n < 256:
+		return []byte{major<<5 | 24, byte(n)}
+	default:
+		b := []byte{major<<5 | 26, 0, 0, 0, 0}
+		binary.BigEndian.PutUint32(b[1:], uint32(n))
+		return b
+	}
+}
+
+// testDLQEvent 按Jackson的方式序列化事件：不定长对象，字段带Java类型信息
+func testDLQEvent(message string) []byte {
+	var b []byte
+	b = append(b, 0xbf)
+	b = append(b, cborText("DATA")...)
+	b = append(b, 0x82)
+	b = append(b, cborText("org.logstash.ConvertedMap")...)
+	b = append(b, 0xbf)
+	b = append(b, cborText("message")...)
+	b = append(b, cborText(message)...)
+	b = append(b, cborText("status")...)
+	b = append(b, 0x19, 0x01, 0x90) // 400
+	b = append(b, cborText("@timestamp")...)
+	b = append(b, 0x82)
+	b = append(b, cborText("org.logstash.Timestamp")...)
+	b = append(b, cborText("2025-06-01T12:00:00.000Z")...)
+	b = append(b, 0xff)
+	b = append(b, cborText("META")...)
+	b = append(b, 0x82)
+	b = append(b, cborText("org.logstash.ConvertedMap")...)
+	b = append(b, 0xa1)
+	b = append(b, cborText("index")...)
+	b = append(b, cborText("logs")...)
+	b = append(b, 0xff)
+	return b
+}
+
+// testDLQRecord 按DLQEntry的格式拼接事件记录
+func testDLQRecord(event []byte, reason string) []byte {
+	var b []byte
+	for _, field := range [][]byte{[]byte("2025-06-01T12:00:01.000Z"), event, []byte("elasticsearch"), []byte("es_out"), []byte(reason)} {
+		b = binary.BigEndian.AppendUint32(b, uint32(len(field)))
+		b = append(b, field...)
+	}
+	return b
+}
+
+// writeTestDLQSegment 按RecordIOWriter的方式写段文件，事件跨块时切分为start/middle/end
+func writeTestDLQSegment(t *testing.T, path string, records ...[]byte) {
+	out := []byte{dlqVersion}
+	pos := 0
+	for _, record := range records {
+		first := true
+		for len(record) > 0 {
+			remaining := dlqBlockSize - pos%dlqBlockSize
+			if remaining < dlqHeaderSize+1 {
+				out = append(out, make([]byte, remaining)...)
+				pos += remaining
+				continue
+			}
+			n := min(remaining-dlqHeaderSize, len(record))
+			recordType := byte(dlqRecordMiddle)
+			switch {
+			case first && n == len(record):
+				recordType = dlqRecordFull
+			case first:
+				recordType = dlqRecordStart
+			case n == len(record):
+				recordType = dlqRecordEnd
+			}
+			header := []byte{recordType}
+			header = binary.BigEndian.AppendUint32(header, uint32(n))
+			header = binary.BigEndian.AppendUint32(header, 0xffffffff)
+			header = binary.BigEndian.AppendUint32(header, crc32.ChecksumIEEE(record[:n]))
+			out = append(out, header...)
+			out = append(out, record[:n]...)
+			pos += dlqHeaderSize + n
+			record = record[n:]
+			first = false
+		}
+	}
+	require.NoError(t, os.WriteFile(path, out, 0644))
+}
+
+func TestReadDLQ(t *testing.T) {
+	dataDir := t.TempDir()
+	dir, err := dlqDir(dataDir, "main")
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(dir, 0755))
+
+	// 1.log 中3个事件，第2个超过一块；10.log 排在 2.log 之后；写入中的段文件不读取
+	big := strings.Repeat("x", 40*1024)
+	writeTestDLQSegment(t, filepath.Join(dir, "1.log"),
+		testDLQRecord(testDLQEvent("e1"), "mapper_parsing_exception"),
+		testDLQRecord(testDLQEvent(big), "too large"),
+		testDLQRecord(testDLQEvent("e3"), "r3"))
+	writeTestDLQSegment(t, filepath.Join(dir, "10.log"), testDLQRecord(testDLQEvent("e5"), "r5"))
+	writeTestDLQSegment(t, filepath.Join(dir, "2.log"), testDLQRecord(testDLQEvent("e4"), "r4"))
+	writeTestDLQSegment(t, filepath.Join(dir, "11.log.tmp"), testDLQRecord(testDLQEvent("e6"), "r6"))
+
+	page, err := readDLQPage(dir, 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 3, page.Segments)
+	assert.True(t, page.HasMore)
+	require.Len(t, page.Entries, 2)
+
+	first := page.Entries[0]
+	assert.Equal(t, "1.log", first.Segment)
+	assert.Equal(t, "2025-06-01T12:00:01.000Z", first.EntryTime)
+	assert.Equal(t, "elasticsearch", first.PluginType)
+	assert.Equal(t, "es_out", first.PluginID)
+	assert.Equal(t, "mapper_parsing_exception", first.Reason)
+	var event map[string]interface{}
+	require.NoError(t, json.Unmarshal(first.Event, &event))
+	assert.Equal(t, "e1", event["message"])
+	assert.Equal(t, float64(400), event["status"])
+	assert.Equal(t, "2025-06-01T12:00:00.000Z", event["@timestamp"])
+	assert.Equal(t, map[string]interface{}{"index": "logs"}, event["@metadata"])
+
+	// 跨块的事件拼接后超过大小上限被截断
+	second := page.Entries[1]
+	assert.Empty(t, second.Error)
+	assert.True(t, second.Truncated)
+	var truncated string
+	require.NoError(t, json.Unmarshal(second.Event, &truncated))
+	assert.Len(t, truncated, models.MaxDLQEventBytes)
+
+	page, err = readDLQPage(dir, 2, 2)
+	require.NoError(t, err)
+	assert.True(t, page.HasMore)
+	require.Len(t, page.Entries, 2)
+	assert.Equal(t, []string{"r3", "r4"}, []string{page.Entries[0].Reason, page.Entries[1].Reason})
+
+	page, err = readDLQPage(dir, 3, 2)
+	require.NoError(t, err)
+	assert.False(t, page.HasMore)
+	require.Len(t, page.Entries, 1)
+	assert.Equal(t, "10.log", page.Entries[0].Segment)
+}
+
+func TestReadDLQ_Errors(t *testing.T) {
+	dataDir := t.TempDir()
+
+	_, err := dlqDir(dataDir, "../etc")
+	assert.Error(t, err)
+
+	dir, _ := dlqDir(dataDir, "missing")
+	_, err = readDLQPage(dir, 1, 10)
+	assert.ErrorIs(t, err, ErrDLQNotFound)
+
+	result := readDLQ(dataDir, models.DLQPayload{RequestID: "req-1", DLQRequest: models.DLQRequest{Pipeline: "missing", Page: 1, PageSize: 10}})
+	assert.Equal(t, "req-1", result.RequestID)
+	assert.Equal(t, "missing", result.Pipeline)
+	assert.Equal(t, ErrDLQNotFound.Error(), result.Error)
+	assert.NotNil(t, result.Entries)
+
+	// 校验和不符的记录被跳过，损坏的事件内容记入Error
+	dir, _ = dlqDir(dataDir, "main")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	path := filepath.Join(dir, "1.log")
+	writeTestDLQSegment(t, path, testDLQRecord(testDLQEvent("bad"), "r1"), testDLQRecord([]byte{0xbf, 0x63}, "r2"))
+	data, _ := os.ReadFile(path)
+	data[1+dlqHeaderSize+10] ^= 0xff
+	require.NoError(t, os.WriteFile(path, data, 0644))
+
+	page, err := readDLQPage(dir, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 1)
+	assert.Equal(t, "r2", page.Entries[0].Reason)
+	assert.Contains(t, page.Entries[0].Error, "解析事件失败")
+}
+
+func TestAgent_HandleDLQRequest(t *testing.T) {
+	cfg := &config.AgentConfig{DataDir: t.TempDir()}
+	dir, _ := dlqDir(cfg.DataDir, "main")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	writeTestDLQSegment(t, filepath.Join(dir, "1.log"), testDLQRecord(testDLQEvent("e1"), "r1"))
+
+	sender := &dlqRecorder{results: make(chan *models.DLQResult, 1)}
+	agent := &Agent{config: cfg, logger: logrus.New(), ctx: context.Background(), sender: sender}
+
+	require.NoError(t, agent.handleMessage(&WebSocketMessage{
+		Type:    MsgTypeDLQRequest,
+		Payload: []byte(`{"request_id":"req-3","pipeline":"main","page":1,"size":10}`),
+	}))
+	select {
+	case result := <-sender.results:
+		assert.Equal(t, "req-3", result.RequestID)
+		assert.Equal(t, "main", result.Pipeline)
+		require.Len(t, result.Entries, 1)
+		assert.Equal(t, "r1", result.Entries[0].Reason)
+	case <-time.After(5 * time.Second):
+		t.Fatal("未收到死信队列结果")
+	}
+	agent.wg.Wait()
+
+	assert.Error(t, agent.handleMessage(&WebSocketMessage{Type: MsgTypeDLQRequest, Payload: []byte(`{"pipeline":"main"}`)}))
+}
+
+// dlqRecorder 记录回复的死信队列结果
+type dlqRecorder struct {
+	results chan *models.DLQResult
+}
+
+func (r *dlqRecorder) SendMessage(msgType string, payload interface{}) error {
+	if msgType == MsgTypeDLQResult {
+		r.results <- payload.(*models.DLQResult)
+	}
+	return nil
+}
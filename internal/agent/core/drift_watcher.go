@@ -0,0 +1,116 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// fileStamp 配置文件快照，按修改时间和大小判断是否变化
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// DriftWatcher 配置漂移监测
+// 定期扫描配置目录，发现不是由Agent写入的变更（手工修改、外部工具下发）时回调onDrift
+type DriftWatcher struct {
+	dir      string
+	interval time.Duration
+	onDrift  func(changed []string)
+	logger   *logrus.Logger
+
+	mu       sync.Mutex
+	snapshot map[string]fileStamp
+}
+
+// NewDriftWatcher 创建配置漂移监测
+func NewDriftWatcher(dir string, interval time.Duration, onDrift func(changed []string), logger *logrus.Logger) *DriftWatcher {
+	return &DriftWatcher{
+		dir:      dir,
+		interval: interval,
+		onDrift:  onDrift,
+		logger:   logger,
+	}
+}
+
+// Run 按间隔扫描配置目录，直到ctx取消
+func (w *DriftWatcher) Run(ctx context.Context) {
+	w.Sync()
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if changed := w.Check(); len(changed) > 0 {
+				w.logger.WithField("files", changed).Warn("检测到配置文件被外部修改")
+				w.onDrift(changed)
+			}
+		}
+	}
+}
+
+// Sync 以当前目录内容为基准，Agent自身写入配置后调用，避免把自己的变更当作漂移
+func (w *DriftWatcher) Sync() {
+	snapshot := w.scan()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.snapshot = snapshot
+}
+
+// Check 与上次快照比较，返回新增、修改或删除的配置文件，并更新快照
+func (w *DriftWatcher) Check() []string {
+	snapshot := w.scan()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var changed []string
+	for path, stamp := range snapshot {
+		if old, ok := w.snapshot[path]; !ok || !old.modTime.Equal(stamp.modTime) || old.size != stamp.size {
+			changed = append(changed, path)
+		}
+	}
+	for path := range w.snapshot {
+		if _, ok := snapshot[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	w.snapshot = snapshot
+
+	sort.Strings(changed)
+	return changed
+}
+
+// scan 读取配置目录下的 .conf 文件，忽略元数据和备份目录
+func (w *DriftWatcher) scan() map[string]fileStamp {
+	snapshot := make(map[string]fileStamp)
+
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		w.logger.WithError(err).WithField("dir", w.dir).Debug("扫描配置目录失败")
+		return snapshot
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".conf") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		snapshot[filepath.Join(w.dir, entry.Name())] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+	}
+	return snapshot
+}
@@ -0,0 +1,37 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDriftWatcher_DetectsExternalChanges(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.conf")
+	require.NoError(t, os.WriteFile(path, []byte("input { stdin {} }"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".backup"), 0755))
+
+	w := NewDriftWatcher(dir, time.Minute, func([]string) {}, logrus.New())
+	w.Sync()
+	assert.Empty(t, w.Check())
+
+	// 外部修改内容
+	require.NoError(t, os.WriteFile(path, []byte("input { stdin {} } output { stdout {} }"), 0644))
+	assert.Equal(t, []string{path}, w.Check())
+
+	// Agent自身写入后同步基准，不视为漂移
+	require.NoError(t, os.WriteFile(path, []byte("input { beats {} }"), 0644))
+	w.Sync()
+	assert.Empty(t, w.Check())
+
+	// 非配置文件被忽略，删除配置视为漂移
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x"), 0644))
+	require.NoError(t, os.Remove(path))
+	assert.Equal(t, []string{path}, w.Check())
+}
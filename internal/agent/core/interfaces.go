@@ -3,6 +3,8 @@ package core
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
 	"logstash-platform/internal/platform/models"
@@ -50,6 +52,96 @@ type APIClient interface {
 	Close() error
 }
 
+// DeployFailureReporter 可选接口，支持向平台上报配置部署失败的客户端实现
+type DeployFailureReporter interface {
+	ReportConfigFailed(ctx context.Context, agentID string, applied *models.AppliedConfig, reason string) error
+}
+
+// ApplyFailureReporter 可选接口，支持上报配置验证失败（apply_failed）的客户端实现
+// 与DeployFailureReporter的区别是平台据此得知Agent已恢复之前的配置，Logstash仍在运行旧配置
+type ApplyFailureReporter interface {
+	ReportConfigApplyFailed(ctx context.Context, agentID string, applied *models.AppliedConfig, output string) error
+}
+
+// ConfigRemovalReporter 可选接口，支持向平台确认配置已删除的客户端实现
+// 平台强制删除仍在运行的配置时等待该确认
+type ConfigRemovalReporter interface {
+	ReportConfigRemoved(ctx context.Context, agentID, configID string) error
+}
+
+// SecretFetcher 可选接口，支持获取配置引用的平台密钥的客户端实现
+type SecretFetcher interface {
+	ResolveSecrets(ctx context.Context, agentID, configID string, names []string) (map[string]string, error)
+}
+
+// MessageSender 可选接口，支持经WebSocket发送消息的客户端实现，实时日志只经WebSocket推送
+type MessageSender interface {
+	SendMessage(msgType string, payload interface{}) error
+}
+
+// EventReporter 可选接口，支持向平台上报重载失败、Logstash崩溃等事件的客户端实现
+type EventReporter interface {
+	ReportEvent(ctx context.Context, agentID string, event *models.AgentEventReport) error
+}
+
+// Deregisterer 可选接口，支持关闭时向平台注销的客户端实现
+type Deregisterer interface {
+	Deregister(ctx context.Context, agentID, reason string) error
+}
+
+// DesiredConfigFetcher 可选接口，支持获取平台推导的期望配置的客户端实现，Agent启动或重新连接后据此自愈
+type DesiredConfigFetcher interface {
+	GetDesiredConfigs(ctx context.Context, agentID string) (*models.AgentDesiredConfigs, error)
+}
+
+// ConfigVersionFetcher 可选接口，支持按版本获取配置的客户端实现
+// 平台回滚金丝雀时下发旧版本号，需要取回该版本的内容而不是最新内容
+type ConfigVersionFetcher interface {
+	GetConfigVersion(ctx context.Context, configID string, version int) (*models.Config, error)
+}
+
+// FetchConfigVersion 获取配置的指定版本，客户端不支持按版本获取时获取最新版本
+func FetchConfigVersion(ctx context.Context, client APIClient, configID string, version int) (*models.Config, error) {
+	if fetcher, ok := client.(ConfigVersionFetcher); ok && version > 0 {
+		return fetcher.GetConfigVersion(ctx, configID, version)
+	}
+	return client.GetConfig(ctx, configID)
+}
+
+// ErrConfigInvalid Logstash验证新配置未通过，错误信息包含验证输出
+var ErrConfigInvalid = errors.New("配置验证失败")
+
+// CheckContentHash 校验下载的配置内容与平台给出的哈希一致，平台未提供哈希时跳过
+func CheckContentHash(cfg *models.Config) error {
+	if cfg.ContentHash == "" {
+		return nil
+	}
+	if actual := models.ContentHash(cfg.Content); actual != cfg.ContentHash {
+		return fmt.Errorf("配置内容哈希 %s 与平台提供的 %s 不一致，下载的内容可能不完整", actual, cfg.ContentHash)
+	}
+	return nil
+}
+
+// AgentEnroller 可选接口，支持申请Agent专属令牌的客户端实现
+type AgentEnroller interface {
+	EnsureEnrolled(ctx context.Context, agentID string) error
+}
+
+// TokenRotator 可选接口，支持轮换Agent专属令牌的客户端实现
+type TokenRotator interface {
+	RotateToken(ctx context.Context, agentID string) error
+}
+
+// PluginReporter 可选接口，支持向平台上报已安装Logstash插件清单的客户端实现
+type PluginReporter interface {
+	ReportPlugins(ctx context.Context, agentID string, report *models.PluginInventoryReport) error
+}
+
+// IntervalNegotiator 可选接口，支持在注册时获取平台协商间隔的客户端实现
+type IntervalNegotiator interface {
+	NegotiatedIntervals() *models.TelemetryIntervals
+}
+
 // ConfigManager 配置管理器接口
 type ConfigManager interface {
 	// SaveConfig 保存配置到本地
@@ -74,6 +166,30 @@ type ConfigManager interface {
 	RestoreConfig(configID string) error
 }
 
+// ConfigVerifier 可选接口，支持校验本地配置文件完整性的配置管理器实现
+type ConfigVerifier interface {
+	// VerifyConfig 校验配置文件与保存时记录的哈希一致，返回文件内容的哈希
+	VerifyConfig(configID string) (string, error)
+}
+
+// AppliedConfigStore 可选接口，支持持久化已应用配置集合的配置管理器实现，Agent重启后据此恢复
+type AppliedConfigStore interface {
+	// SaveAppliedConfigs 保存当前的已应用配置集合
+	SaveAppliedConfigs(applied []models.AppliedConfig) error
+	
+	// LoadAppliedConfigs 加载持久化的已应用配置集合，已与本地配置文件核对
+	LoadAppliedConfigs() ([]models.AppliedConfig, error)
+}
+
+// VerifyConfigFile 校验本地配置文件的完整性并返回其哈希，配置管理器不支持校验时返回空哈希
+func VerifyConfigFile(mgr ConfigManager, configID string) (string, error) {
+	verifier, ok := mgr.(ConfigVerifier)
+	if !ok {
+		return "", nil
+	}
+	return verifier.VerifyConfig(configID)
+}
+
 // LogstashController Logstash控制器接口
 type LogstashController interface {
 	// Start 启动Logstash
@@ -98,6 +214,27 @@ type LogstashController interface {
 	ValidateConfig(configPath string) error
 }
 
+// KeystoreWriter 可选接口，支持将密钥写入Logstash keystore的控制器实现
+type KeystoreWriter interface {
+	AddKeystoreSecrets(ctx context.Context, secrets map[string]string) error
+}
+
+// ExitNotifier 可选接口，支持通知Logstash进程意外退出的控制器实现
+type ExitNotifier interface {
+	// OnUnexpectedExit 设置进程未经Stop而退出时的回调
+	OnUnexpectedExit(fn func(err error))
+}
+
+// CachedConfigValidator 支持验证结果缓存的配置验证器
+// LogstashController的可选扩展，未实现时部署流程跳过验证
+type CachedConfigValidator interface {
+	// ValidateConfigCached 验证配置文件，返回结果是否命中缓存
+	ValidateConfigCached(configPath string) (cached bool, err error)
+	
+	// InvalidateValidationCache 清空验证结果缓存
+	InvalidateValidationCache()
+}
+
 // HeartbeatService 心跳服务接口
 type HeartbeatService interface {
 	// Start 启动心跳服务
@@ -137,6 +274,19 @@ type MessageHandler interface {
 	OnDisconnect(err error)
 }
 
+// MetadataMessageHandler 可接收消息链路上下文的处理器，客户端优先使用
+type MetadataMessageHandler interface {
+	// HandleMessageWithMetadata 处理接收到的消息，metadata为平台消息携带的链路上下文
+	HandleMessageWithMetadata(msgType string, payload []byte, metadata map[string]string) error
+}
+
+// AckingMessageHandler 可接收消息ID的处理器，客户端优先使用
+// 处理器在处理完带ID的消息后自行以ack确认；不支持的处理器视为同步处理，返回后由客户端确认
+type AckingMessageHandler interface {
+	// HandleMessageWithID 处理接收到的带ID的消息，重复收到已处理过的ID时不应再次处理
+	HandleMessageWithID(id, msgType string, payload []byte, metadata map[string]string) error
+}
+
 // LogstashStatus Logstash状态
 type LogstashStatus struct {
 	Running        bool      `json:"running"`
@@ -157,13 +307,19 @@ type AgentMetrics struct {
 	EventsSent     int64     `json:"events_sent"`      // 发送事件数
 	EventsFailed   int64     `json:"events_failed"`    // 失败事件数
 	Uptime         int64     `json:"uptime"`           // 运行时间 (秒)
+	CPUCores       int       `json:"cpu_cores,omitempty"`       // 主机CPU核数
+	MemoryTotalMB  float64   `json:"memory_total_mb,omitempty"` // 主机内存总量 (MB)
+	DiskTotalMB    float64   `json:"disk_total_mb,omitempty"`   // 数据盘总容量 (MB)
+	Logstash       *models.LogstashStats `json:"logstash,omitempty"` // Logstash监控API统计，API不可用时为空
 }
 
 // WebSocketMessage WebSocket消息
 type WebSocketMessage struct {
+	ID        string          `json:"id,omitempty"` // 需要确认的消息ID（config_deploy、config_delete），处理后以ack回传
 	Type      string          `json:"type"`      // 消息类型
 	Timestamp time.Time       `json:"timestamp"` // 时间戳
 	Payload   json.RawMessage `json:"payload"`   // 消息内容
+	Metadata  map[string]string `json:"metadata,omitempty"` // 链路上下文（traceparent、tracestate），处理消息时发出的请求原样携带
 }
 
 // 消息类型常量
@@ -174,6 +330,17 @@ const (
 	MsgTypeReloadRequest  = "reload_request"   // 重载请求
 	MsgTypeStatusRequest  = "status_request"   // 状态请求
 	MsgTypeMetricsRequest = "metrics_request"  // 指标请求
+	MsgTypeSettingsUpdate = "settings_update"  // 运行参数下发
+	MsgTypeSyncHint       = "sync_hint"        // 配置同步提示（心跳捎带）
+	MsgTypeLogLevel       = "log_level"        // 日志级别变更（心跳捎带）
+	MsgTypeMaintenance    = "maintenance"      // 维护模式开关（心跳捎带）
+	MsgTypeInvalidateValidationCache = "invalidate_validation_cache" // 清空配置验证缓存（心跳捎带）
+	MsgTypeTelemetryIntervals = "telemetry_intervals" // 平台协商的心跳/指标间隔下限（心跳捎带）
+	MsgTypeLogTailStart   = "log_tail_start"   // 开始跟踪Logstash日志（仅WebSocket）
+	MsgTypeLogTailStop    = "log_tail_stop"    // 停止跟踪Logstash日志
+	MsgTypeDiagnosticRequest = "diagnostic_request" // 执行白名单内的诊断命令（仅WebSocket）
+	MsgTypeDLQRequest     = "dlq_request"      // 读取管道死信队列（仅WebSocket）
+	MsgTypePluginInstall  = "plugin_install"   // 安装或更新Logstash插件（仅WebSocket）
 	
 	// Agent到服务器的消息类型
 	MsgTypeHeartbeat      = "heartbeat"        // 心跳
@@ -181,4 +348,10 @@ const (
 	MsgTypeMetricsReport  = "metrics_report"   // 指标上报
 	MsgTypeConfigApplied  = "config_applied"   // 配置已应用
 	MsgTypeError          = "error"            // 错误报告
+	MsgTypeAck            = "ack"              // 确认已处理带ID的消息
+	MsgTypeLogLines       = "log_lines"        // 跟踪到的日志行
+	MsgTypeDiagnosticResult = "diagnostic_result" // 诊断结果
+	MsgTypeDLQResult      = "dlq_result"       // 死信队列中的事件
+	MsgTypePluginInstallProgress = "plugin_install_progress" // 插件安装进度
+	MsgTypePluginInstallResult   = "plugin_install_result"   // 插件安装结果
 )
\ No newline at end of file
@@ -0,0 +1,409 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+)
+
+// 日志跟踪的默认参数
+const (
+	defaultTailPollInterval  = 250 * time.Millisecond
+	defaultTailFlushInterval = 500 * time.Millisecond
+	defaultTailMaxDuration   = time.Hour
+	maxTailStreams           = 4
+	maxTailBacklog           = 1000
+	maxTailLineBytes         = 16 * 1024 // 超长的行截断，避免单条消息超过平台的大小上限
+)
+
+// plainLogLine Logstash plain格式的日志行：[时间][级别][logger]消息
+var plainLogLine = regexp.MustCompile(`^\[([^\]]+)\]\[\s*([A-Za-z]+)\s*\]\[\s*([^\]]+?)\s*\]\s?(.*)$`)
+
+// LogTailer 按平台请求跟踪Logstash日志文件，经WebSocket分批推送log_lines
+// 每个流独立按最低级别过滤并执行每秒行数上限；推送失败、文件无法读取或达到最长跟踪时间时结束该流
+type LogTailer struct {
+	logDir string
+	sender MessageSender
+	logger *logrus.Logger
+
+	pollInterval  time.Duration
+	flushInterval time.Duration
+	maxDuration   time.Duration
+
+	mu      sync.Mutex
+	streams map[string]context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewLogTailer 创建日志跟踪，logDir为Logstash的日志目录
+func NewLogTailer(logDir string, sender MessageSender, logger *logrus.Logger) *LogTailer {
+	return &LogTailer{
+		logDir:        logDir,
+		sender:        sender,
+		logger:        logger,
+		pollInterval:  defaultTailPollInterval,
+		flushInterval: defaultTailFlushInterval,
+		maxDuration:   defaultTailMaxDuration,
+		streams:       make(map[string]context.CancelFunc),
+	}
+}
+
+// Start 开始跟踪，同一StreamID重复开始时先停止之前的跟踪
+// 请求无效时推送closed批次告知平台原因，浏览器不必等待
+func (t *LogTailer) Start(ctx context.Context, req models.LogTailStartPayload) error {
+	if req.StreamID == "" {
+		return fmt.Errorf("日志跟踪请求缺少stream_id")
+	}
+	if err := t.start(ctx, req); err != nil {
+		closed := &models.LogLinesMessage{StreamID: req.StreamID, Lines: []models.LogLine{}, Closed: true, Error: err.Error()}
+		if sendErr := t.sender.SendMessage(MsgTypeLogLines, closed); sendErr != nil {
+			t.logger.WithError(sendErr).WithField("stream_id", req.StreamID).Warn("推送日志跟踪失败原因失败")
+		}
+		return err
+	}
+	return nil
+}
+
+// start 校验请求并启动跟踪
+func (t *LogTailer) start(ctx context.Context, req models.LogTailStartPayload) error {
+	path, err := logFilePath(t.logDir, req.Source, req.Pipeline)
+	if err != nil {
+		return err
+	}
+	if req.MaxRate <= 0 {
+		return fmt.Errorf("日志跟踪速率必须大于0")
+	}
+	if req.Backlog > maxTailBacklog {
+		req.Backlog = maxTailBacklog
+	}
+
+	t.mu.Lock()
+	if cancel, ok := t.streams[req.StreamID]; ok {
+		cancel()
+		delete(t.streams, req.StreamID)
+	}
+	if len(t.streams) >= maxTailStreams {
+		t.mu.Unlock()
+		return fmt.Errorf("同时跟踪的日志流已达上限%d", maxTailStreams)
+	}
+
+	// 收到请求时即定位到文件末尾，之后写入的行都会推送
+	stream := &tailStream{req: req, minRank: models.LogLevelRank(req.MinLevel), lastPassed: true}
+	file := &tailFile{path: path}
+	if err := file.open(req.Backlog, stream.add); err != nil {
+		t.mu.Unlock()
+		return fmt.Errorf("打开日志文件失败: %w", err)
+	}
+	streamCtx, cancel := context.WithTimeout(ctx, t.maxDuration)
+	t.streams[req.StreamID] = cancel
+	t.mu.Unlock()
+
+	t.logger.WithFields(logrus.Fields{
+		"stream_id": req.StreamID,
+		"path":      path,
+		"min_level": req.MinLevel,
+	}).Info("开始跟踪Logstash日志")
+
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		defer t.finish(req.StreamID)
+		t.run(streamCtx, stream, file)
+	}()
+	return nil
+}
+
+// Stop 停止跟踪，流不存在时忽略
+func (t *LogTailer) Stop(streamID string) {
+	t.mu.Lock()
+	cancel, ok := t.streams[streamID]
+	delete(t.streams, streamID)
+	t.mu.Unlock()
+
+	if ok {
+		cancel()
+		t.logger.WithField("stream_id", streamID).Info("停止跟踪Logstash日志")
+	}
+}
+
+// StopAll 停止全部跟踪并等待退出，WebSocket断开时平台侧的流已随之结束
+func (t *LogTailer) StopAll() {
+	t.mu.Lock()
+	for id, cancel := range t.streams {
+		cancel()
+		delete(t.streams, id)
+	}
+	t.mu.Unlock()
+	t.wg.Wait()
+}
+
+// finish 流退出后移除记录
+func (t *LogTailer) finish(streamID string) {
+	t.mu.Lock()
+	if cancel, ok := t.streams[streamID]; ok {
+		cancel()
+		delete(t.streams, streamID)
+	}
+	t.mu.Unlock()
+}
+
+// logFilePath 日志来源对应的文件，管道日志需开启 pipeline.separate_logs
+func logFilePath(logDir, source, pipeline string) (string, error) {
+	switch source {
+	case models.LogSourceLogstash, "":
+		return filepath.Join(logDir, "logstash-plain.log"), nil
+	case models.LogSourcePipeline:
+		if pipeline == "" || pipeline != filepath.Base(pipeline) || strings.HasPrefix(pipeline, ".") {
+			return "", fmt.Errorf("管道ID无效: %q", pipeline)
+		}
+		return filepath.Join(logDir, "pipeline_"+pipeline+".log"), nil
+	default:
+		return "", fmt.Errorf("未知日志来源: %s", source)
+	}
+}
+
+// run 轮询日志文件并按间隔推送，退出前推送closed批次（平台主动停止时除外）
+func (t *LogTailer) run(ctx context.Context, stream *tailStream, file *tailFile) {
+	defer file.close()
+
+	fail := func(reason string) {
+		stream.pending.Closed = true
+		stream.pending.Error = reason
+		t.flush(stream)
+	}
+
+	poll := time.NewTicker(t.pollInterval)
+	defer poll.Stop()
+	flush := time.NewTicker(t.flushInterval)
+	defer flush.Stop()
+
+	if !t.flush(stream) {
+		return
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				fail("达到最长跟踪时间")
+			}
+			return
+		case <-poll.C:
+			if err := file.poll(stream.add); err != nil {
+				fail(fmt.Sprintf("读取日志文件失败: %v", err))
+				return
+			}
+		case <-flush.C:
+			if !t.flush(stream) {
+				return
+			}
+		}
+	}
+}
+
+// flush 推送待发送的行，返回是否应继续跟踪
+func (t *LogTailer) flush(stream *tailStream) bool {
+	batch := stream.take()
+	if batch == nil {
+		return true
+	}
+	if err := t.sender.SendMessage(MsgTypeLogLines, batch); err != nil {
+		t.logger.WithError(err).WithField("stream_id", batch.StreamID).Warn("推送日志行失败，停止跟踪")
+		return false
+	}
+	return !batch.Closed
+}
+
+// tailStream 一个流的过滤、限速和待发送批次
+type tailStream struct {
+	req     models.LogTailStartPayload
+	minRank int
+
+	pending     models.LogLinesMessage
+	lastPassed  bool // 上一条带级别的行是否通过过滤，续行（如堆栈）随之过滤
+	windowStart time.Time
+	sent        int
+}
+
+// add 处理读取到的一行
+func (s *tailStream) add(raw string) {
+	if len(raw) > maxTailLineBytes {
+		raw = raw[:maxTailLineBytes]
+	}
+	line := parseLogLine(raw)
+	if line.Level != "" {
+		s.lastPassed = models.LogLevelRank(line.Level) >= s.minRank
+	}
+	if !s.lastPassed {
+		return
+	}
+
+	now := time.Now()
+	if now.Sub(s.windowStart) >= time.Second {
+		s.windowStart = now
+		s.sent = 0
+	}
+	if s.sent >= s.req.MaxRate {
+		s.pending.Dropped++
+		return
+	}
+	s.sent++
+	line.Timestamp = now
+	s.pending.Lines = append(s.pending.Lines, line)
+}
+
+// take 取出待发送的批次，没有内容时返回nil
+func (s *tailStream) take() *models.LogLinesMessage {
+	if len(s.pending.Lines) == 0 && s.pending.Dropped == 0 && !s.pending.Closed {
+		return nil
+	}
+	batch := s.pending
+	batch.StreamID = s.req.StreamID
+	if batch.Lines == nil {
+		batch.Lines = []models.LogLine{}
+	}
+	s.pending = models.LogLinesMessage{}
+	return &batch
+}
+
+// parseLogLine 解析plain格式的行首，无法解析的行（堆栈、多行消息）整体作为消息
+func parseLogLine(raw string) models.LogLine {
+	m := plainLogLine.FindStringSubmatch(raw)
+	if m == nil || models.LogLevelRank(m[2]) < 0 {
+		return models.LogLine{Message: raw}
+	}
+	return models.LogLine{
+		Level:   strings.ToLower(m[2]),
+		Logger:  m[3],
+		Message: m[4],
+	}
+}
+
+// tailFile 跟踪中的日志文件，Logstash按大小滚动日志时重新打开新文件
+type tailFile struct {
+	path    string
+	file    *os.File
+	info    os.FileInfo
+	offset  int64
+	partial []byte // 尚未读到换行的行尾
+}
+
+// open 打开文件并定位到末尾，backlog大于0时先回放末尾的若干行
+func (f *tailFile) open(backlog int, emit func(string)) error {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file, f.info, f.offset = file, info, info.Size()
+
+	if backlog > 0 {
+		start := info.Size() - int64(backlog)*512
+		if start < 0 {
+			start = 0
+		}
+		data := make([]byte, info.Size()-start)
+		if _, err := file.ReadAt(data, start); err != nil && err != io.EOF {
+			return err
+		}
+		lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+		if start > 0 && len(lines) > 0 {
+			lines = lines[1:] // 第一行可能不完整
+		}
+		if len(lines) > backlog {
+			lines = lines[len(lines)-backlog:]
+		}
+		for _, line := range lines {
+			if line != "" {
+				emit(strings.TrimSuffix(line, "\r"))
+			}
+		}
+	}
+	return nil
+}
+
+// poll 读取新追加的行；文件被滚动（路径指向新文件）时读完旧文件后从新文件开头继续，被截断时从头读取
+func (f *tailFile) poll(emit func(string)) error {
+	if err := f.read(emit); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // 滚动过程中文件暂时不存在
+		}
+		return err
+	}
+	if !os.SameFile(info, f.info) {
+		file, err := os.Open(f.path)
+		if err != nil {
+			return err
+		}
+		f.close()
+		f.file, f.info, f.offset, f.partial = file, info, 0, nil
+		return f.read(emit)
+	}
+	if info.Size() < f.offset {
+		f.offset, f.partial = 0, nil
+	}
+	return nil
+}
+
+// read 从当前位置读到文件末尾
+func (f *tailFile) read(emit func(string)) error {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := f.file.ReadAt(buf, f.offset)
+		if n > 0 {
+			f.offset += int64(n)
+			f.consume(buf[:n], emit)
+		}
+		if err == io.EOF || n == 0 {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// consume 按换行拆分读取的数据
+func (f *tailFile) consume(data []byte, emit func(string)) {
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			f.partial = append(f.partial, data...)
+			if len(f.partial) > maxTailLineBytes {
+				emit(string(f.partial))
+				f.partial = nil
+			}
+			return
+		}
+		line := append(f.partial, data[:i]...)
+		f.partial = nil
+		emit(strings.TrimSuffix(string(line), "\r"))
+		data = data[i+1:]
+	}
+}
+
+// close 关闭当前打开的文件
+func (f *tailFile) close() {
+	if f.file != nil {
+		f.file.Close()
+		f.file = nil
+	}
+}
@@ -0,0 +1,171 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/agent/config"
+	"logstash-platform/internal/platform/models"
+)
+
+// batchRecorder 记录推送的日志批次
+type batchRecorder struct {
+	mu      sync.Mutex
+	batches []*models.LogLinesMessage
+}
+
+func (r *batchRecorder) SendMessage(msgType string, payload interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if msgType == MsgTypeLogLines {
+		r.batches = append(r.batches, payload.(*models.LogLinesMessage))
+	}
+	return nil
+}
+
+// lines 已推送的全部日志消息
+func (r *batchRecorder) lines() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []string
+	for _, b := range r.batches {
+		for _, line := range b.Lines {
+			out = append(out, line.Message)
+		}
+	}
+	return out
+}
+
+// last 最后一个批次
+func (r *batchRecorder) last() *models.LogLinesMessage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.batches) == 0 {
+		return nil
+	}
+	return r.batches[len(r.batches)-1]
+}
+
+func newTestLogTailer(dir string, sender MessageSender) *LogTailer {
+	tailer := NewLogTailer(dir, sender, logrus.New())
+	tailer.pollInterval = 10 * time.Millisecond
+	tailer.flushInterval = 10 * time.Millisecond
+	return tailer
+}
+
+func appendLog(t *testing.T, path, content string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString(content)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+}
+
+func TestParseLogLine(t *testing.T) {
+	line := parseLogLine("[2024-01-02T10:00:00,123][WARN ][logstash.outputs.elasticsearch][main] Connection refused")
+	assert.Equal(t, "warn", line.Level)
+	assert.Equal(t, "logstash.outputs.elasticsearch", line.Logger)
+	assert.Equal(t, "[main] Connection refused", line.Message)
+
+	line = parseLogLine("  at org.logstash.Pipeline.run(Pipeline.java:42)")
+	assert.Empty(t, line.Level)
+	assert.Equal(t, "  at org.logstash.Pipeline.run(Pipeline.java:42)", line.Message)
+}
+
+func TestLogTailer_FollowsFilteredLines(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "logstash-plain.log")
+	appendLog(t, path, "[2024-01-02T10:00:00,000][INFO ][logstash.runner] old info\n[2024-01-02T10:00:01,000][ERROR][logstash.runner] old error\n")
+
+	sender := &batchRecorder{}
+	tailer := newTestLogTailer(dir, sender)
+	require.NoError(t, tailer.Start(context.Background(), models.LogTailStartPayload{
+		StreamID: "s-1", Source: models.LogSourceLogstash, MinLevel: "warn", MaxRate: 100, Backlog: 5,
+	}))
+	defer tailer.StopAll()
+
+	// 回放末尾的行同样按级别过滤
+	require.Eventually(t, func() bool { return len(sender.lines()) == 1 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"old error"}, sender.lines())
+
+	appendLog(t, path, "[2024-01-02T10:00:02,000][DEBUG][logstash.runner] skipped\n  debug stack\n"+
+		"[2024-01-02T10:00:03,000][WARN ][logstash.runner] new warn\n  at Pipeline.run\n")
+	require.Eventually(t, func() bool { return len(sender.lines()) == 3 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"old error", "new warn", "  at Pipeline.run"}, sender.lines(), "续行随上一行过滤")
+
+	// 日志滚动后从新文件开头继续
+	require.NoError(t, os.Rename(path, path+".1"))
+	appendLog(t, path, "[2024-01-02T10:00:04,000][ERROR][logstash.runner] after rotate\n")
+	require.Eventually(t, func() bool { return len(sender.lines()) == 4 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "after rotate", sender.lines()[3])
+
+	tailer.Stop("s-1")
+	assert.False(t, sender.last().Closed, "平台主动停止时不推送closed")
+}
+
+func TestLogTailer_RateLimitAndErrors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pipeline_nginx.log")
+	appendLog(t, path, "")
+
+	sender := &batchRecorder{}
+	tailer := newTestLogTailer(dir, sender)
+	require.NoError(t, tailer.Start(context.Background(), models.LogTailStartPayload{
+		StreamID: "s-1", Source: models.LogSourcePipeline, Pipeline: "nginx", MaxRate: 2,
+	}))
+	appendLog(t, path, "one\ntwo\nthree\nfour\n")
+	require.Eventually(t, func() bool {
+		dropped := 0
+		sender.mu.Lock()
+		for _, b := range sender.batches {
+			dropped += b.Dropped
+		}
+		sender.mu.Unlock()
+		return len(sender.lines()) == 2 && dropped == 2
+	}, time.Second, 10*time.Millisecond, "超过速率的行丢弃并计入dropped")
+	tailer.StopAll()
+
+	// 无效的管道ID和不存在的文件推送closed批次告知原因
+	err := tailer.Start(context.Background(), models.LogTailStartPayload{StreamID: "s-2", Source: models.LogSourcePipeline, Pipeline: "../etc", MaxRate: 1})
+	assert.Error(t, err)
+	require.NotNil(t, sender.last())
+	assert.True(t, sender.last().Closed)
+	assert.Equal(t, "s-2", sender.last().StreamID)
+
+	assert.Error(t, tailer.Start(context.Background(), models.LogTailStartPayload{StreamID: "s-3", Source: models.LogSourcePipeline, Pipeline: "missing", MaxRate: 1}))
+	assert.Equal(t, "s-3", sender.last().StreamID)
+	assert.Contains(t, sender.last().Error, "打开日志文件失败")
+
+	// 达到最长跟踪时间后结束
+	tailer.maxDuration = 30 * time.Millisecond
+	require.NoError(t, tailer.Start(context.Background(), models.LogTailStartPayload{StreamID: "s-4", Source: models.LogSourcePipeline, Pipeline: "nginx", MaxRate: 1}))
+	require.Eventually(t, func() bool {
+		last := sender.last()
+		return last.StreamID == "s-4" && last.Closed
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "达到最长跟踪时间", sender.last().Error)
+}
+
+func TestAgent_HandleLogTail(t *testing.T) {
+	dir := t.TempDir()
+	appendLog(t, filepath.Join(dir, "logstash-plain.log"), "")
+
+	sender := &batchRecorder{}
+	agent := &Agent{config: &config.AgentConfig{LogDir: dir}, logger: logrus.New(), ctx: context.Background()}
+	agent.logTails = newTestLogTailer(dir, sender)
+
+	require.NoError(t, agent.handleMessage(&WebSocketMessage{Type: MsgTypeLogTailStart, Payload: []byte(`{"stream_id":"s-1","source":"logstash","max_rate":10}`)}))
+	appendLog(t, filepath.Join(dir, "logstash-plain.log"), "[2024-01-02T10:00:00,000][INFO ][logstash.runner] hello\n")
+	require.Eventually(t, func() bool { return len(sender.lines()) == 1 }, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, agent.handleMessage(&WebSocketMessage{Type: MsgTypeLogTailStop, Payload: []byte(`{"stream_id":"s-1"}`)}))
+	agent.OnDisconnect(nil)
+}
@@ -0,0 +1,99 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"logstash-platform/internal/platform/models"
+)
+
+// processedMessageLimit 记住的已处理消息ID数，平台只保留数量有限的未确认消息待重发
+const processedMessageLimit = 512
+
+// processedMessages 最近处理过的消息ID，平台重发的消息据此去重
+// 只保存在内存中，Agent重启后重发的部署同样会重新执行，重新下发同一版本是幂等的
+type processedMessages struct {
+	mu    sync.Mutex
+	ids   map[string]struct{}
+	order []string
+	limit int
+}
+
+// newProcessedMessages 创建已处理消息记录，超过limit时忘记最早的ID
+func newProcessedMessages(limit int) *processedMessages {
+	return &processedMessages{
+		ids:   make(map[string]struct{}, limit),
+		limit: limit,
+	}
+}
+
+// seen 是否处理过该ID
+func (p *processedMessages) seen(id string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.ids[id]
+	return ok
+}
+
+// add 记录处理过的ID
+func (p *processedMessages) add(id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.ids[id]; ok {
+		return
+	}
+	p.ids[id] = struct{}{}
+	p.order = append(p.order, id)
+	if len(p.order) > p.limit {
+		delete(p.ids, p.order[0])
+		p.order = p.order[1:]
+	}
+}
+
+// HandleMessageWithID 实现AckingMessageHandler接口，消息在消息循环中处理完成后确认
+func (a *Agent) HandleMessageWithID(id, msgType string, payload []byte, metadata map[string]string) error {
+	msg := &WebSocketMessage{
+		ID:        id,
+		Type:      msgType,
+		Timestamp: time.Now(),
+		Payload:   json.RawMessage(payload),
+		Metadata:  metadata,
+	}
+
+	select {
+	case a.msgChan <- msg:
+		return nil
+	case <-time.After(5 * time.Second):
+		return fmt.Errorf("消息队列已满")
+	}
+}
+
+// processMessage 处理消息循环中的一条消息，带ID的消息处理后确认，重复的ID只重新确认
+// 处理失败同样确认，失败已经上报，重发不会改变结果
+func (a *Agent) processMessage(msg *WebSocketMessage) error {
+	if msg.ID == "" {
+		return a.handleMessage(msg)
+	}
+	if a.processed.seen(msg.ID) {
+		a.logger.WithField("id", msg.ID).WithField("type", msg.Type).Info("忽略平台重发的已处理消息")
+		a.ackMessage(msg.ID)
+		return nil
+	}
+
+	err := a.handleMessage(msg)
+	a.processed.add(msg.ID)
+	a.ackMessage(msg.ID)
+	return err
+}
+
+// ackMessage 向平台确认消息，确认失败时平台在重新连接后重发，届时按ID去重
+func (a *Agent) ackMessage(id string) {
+	if a.sender == nil {
+		return
+	}
+	if err := a.sender.SendMessage(MsgTypeAck, models.MessageAck{IDs: []string{id}}); err != nil {
+		a.logger.WithError(err).WithField("id", id).Debug("确认消息失败")
+	}
+}
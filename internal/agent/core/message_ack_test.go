@@ -0,0 +1,60 @@
+package core
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+)
+
+// ackRecorder 记录发给平台的确认
+type ackRecorder struct {
+	mu   sync.Mutex
+	acks []string
+}
+
+func (r *ackRecorder) SendMessage(msgType string, payload interface{}) error {
+	if msgType == MsgTypeAck {
+		r.mu.Lock()
+		r.acks = append(r.acks, payload.(models.MessageAck).IDs...)
+		r.mu.Unlock()
+	}
+	return nil
+}
+
+func TestAgent_ProcessMessageDedup(t *testing.T) {
+	agent, _, _, _, _, _ := createTestAgent(t)
+	sender := &ackRecorder{}
+	agent.sender = sender
+	agent.logger.SetLevel(logrus.InfoLevel)
+
+	msg := &WebSocketMessage{ID: "msg-1", Type: MsgTypeLogLevel, Payload: []byte(`{"level":"debug"}`)}
+	require.NoError(t, agent.processMessage(msg))
+	assert.Equal(t, logrus.DebugLevel, agent.logger.GetLevel())
+
+	// 平台重发的同一消息不再处理，只重新确认
+	agent.logger.SetLevel(logrus.InfoLevel)
+	require.NoError(t, agent.processMessage(msg))
+	assert.Equal(t, logrus.InfoLevel, agent.logger.GetLevel())
+
+	// 处理失败同样确认
+	assert.Error(t, agent.processMessage(&WebSocketMessage{ID: "msg-2", Type: MsgTypeLogLevel, Payload: []byte(`{"level":"loud"}`)}))
+	// 不带ID的消息不确认
+	require.NoError(t, agent.processMessage(&WebSocketMessage{Type: MsgTypeLogLevel, Payload: []byte(`{"level":"warn"}`)}))
+	assert.Equal(t, []string{"msg-1", "msg-1", "msg-2"}, sender.acks)
+}
+
+func TestProcessedMessages_Limit(t *testing.T) {
+	processed := newProcessedMessages(2)
+	processed.add("a")
+	processed.add("b")
+	processed.add("a")
+	processed.add("c")
+
+	assert.False(t, processed.seen("a"), "超过上限时忘记最早的ID")
+	assert.True(t, processed.seen("b"))
+	assert.True(t, processed.seen("c"))
+}
@@ -0,0 +1,267 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+)
+
+const (
+	// pluginInstallTimeout 单次安装（含下载离线包、执行logstash-plugin和重新采集清单）的时长上限
+	pluginInstallTimeout = 20 * time.Minute
+	// maxPluginBundleSize 离线包大小上限
+	maxPluginBundleSize = 1 << 30
+)
+
+// PluginCommand 执行 logstash-plugin 的子命令，返回合并的标准输出和标准错误
+type PluginCommand func(ctx context.Context, args ...string) (string, error)
+
+// logstashPluginCommand 执行与logstash同目录的logstash-plugin
+func logstashPluginCommand(logstashPath string) PluginCommand {
+	pluginPath := filepath.Join(filepath.Dir(logstashPath), "logstash-plugin")
+	return func(ctx context.Context, args ...string) (string, error) {
+		output, err := exec.CommandContext(ctx, pluginPath, args...).CombinedOutput()
+		if ctx.Err() == context.DeadlineExceeded {
+			return string(output), fmt.Errorf("执行 logstash-plugin %s 超时", args[0])
+		}
+		if err != nil {
+			return string(output), fmt.Errorf("执行 logstash-plugin %s 失败: %w", args[0], err)
+		}
+		return string(output), nil
+	}
+}
+
+// handlePluginInstall 安装或更新插件，进度和结果经WebSocket报告
+// 安装需要数分钟，在后台执行避免阻塞消息循环；同时只执行一个安装
+func (a *Agent) handlePluginInstall(payload json.RawMessage) error {
+	if a.sender == nil {
+		return fmt.Errorf("客户端不支持WebSocket推送，无法报告插件安装结果")
+	}
+	var req models.PluginInstallPayload
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return fmt.Errorf("解析插件安装请求失败: %w", err)
+	}
+	if req.InstallID == "" {
+		return fmt.Errorf("插件安装请求缺少install_id")
+	}
+	if !models.ValidPluginInstallID(req.InstallID) {
+		return fmt.Errorf("插件安装请求的install_id无效: %q", req.InstallID)
+	}
+
+	a.logger.WithFields(logrus.Fields{
+		"install_id": req.InstallID,
+		"plugin":     req.Name,
+		"version":    req.Version,
+		"offline":    req.BundleURL != "",
+	}).Info("安装Logstash插件")
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		var result *models.PluginInstallResult
+		if !a.pluginInstalling.TryLock() {
+			result = &models.PluginInstallResult{InstallID: req.InstallID, Error: "已有插件安装正在进行"}
+		} else {
+			result = a.installPlugin(req)
+			a.pluginInstalling.Unlock()
+		}
+		if err := a.sender.SendMessage(MsgTypePluginInstallResult, result); err != nil {
+			a.logger.WithError(err).WithField("install_id", req.InstallID).Warn("报告插件安装结果失败")
+		}
+	}()
+	return nil
+}
+
+// installPlugin 执行安装并重新采集插件清单，restart为true时安装成功后重启Logstash
+// 未指定版本且插件已安装时执行update，否则执行install；离线包先下载到数据目录再以file://安装
+func (a *Agent) installPlugin(req models.PluginInstallPayload) *models.PluginInstallResult {
+	start := time.Now()
+	result := &models.PluginInstallResult{InstallID: req.InstallID}
+	defer func() { result.DurationMs = time.Since(start).Milliseconds() }()
+
+	// 参数会成为命令行参数，平台已校验，此处再次校验
+	if !models.ValidPluginName(req.Name) || !models.ValidPluginVersion(req.Version) {
+		result.Error = "插件名或版本格式无效"
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(a.ctx, pluginInstallTimeout)
+	defer cancel()
+
+	var args []string
+	switch {
+	case req.BundleURL != "":
+		a.sendPluginInstallProgress(req.InstallID, models.PluginInstallPhaseDownloading, req.BundleURL)
+		bundle, cleanup, err := a.fetchPluginBundle(ctx, req.InstallID, req.BundleURL)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		defer cleanup()
+		args = []string{"install", "file://" + bundle}
+	case req.Version != "":
+		args = []string{"install", "--version", req.Version, req.Name}
+	default:
+		// 无法采集清单时按未安装处理，install对已安装的插件报错时原因在输出中
+		args = []string{"install", req.Name}
+		if output, err := a.listPlugins(ctx); err == nil {
+			inventory := models.PluginInventory{Plugins: parsePluginList(output)}
+			if inventory.Has(req.Name) {
+				args = []string{"update", req.Name}
+			}
+		}
+	}
+
+	a.sendPluginInstallProgress(req.InstallID, models.PluginInstallPhaseInstalling, "logstash-plugin "+strings.Join(args, " "))
+	output, err := a.runPlugin(ctx, args...)
+	result.Output, result.Truncated = truncatePluginOutput(output)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	a.sendPluginInstallProgress(req.InstallID, models.PluginInstallPhaseVerifying, "")
+	listOutput, err := a.listPlugins(ctx)
+	if err != nil {
+		result.Error = "安装后采集插件清单失败: " + err.Error()
+		return result
+	}
+	result.Plugins = parsePluginList(listOutput)
+	for _, plugin := range result.Plugins {
+		if plugin.Name == req.Name {
+			result.InstalledVersion = plugin.Version
+		}
+	}
+	if result.InstalledVersion == "" {
+		result.Error = fmt.Sprintf("安装后插件清单中没有 %s", req.Name)
+		return result
+	}
+
+	if req.Restart && a.logstashCtrl != nil {
+		a.sendPluginInstallProgress(req.InstallID, models.PluginInstallPhaseRestarting, "")
+		if err := a.logstashCtrl.Restart(ctx); err != nil {
+			result.Error = "插件已安装，重启Logstash失败: " + err.Error()
+			return result
+		}
+		a.reportEvent(models.AgentEventLogstashRestarted, fmt.Sprintf("安装插件 %s %s 后重启", req.Name, result.InstalledVersion))
+	}
+
+	result.Success = true
+	a.logger.WithFields(logrus.Fields{
+		"install_id": req.InstallID,
+		"plugin":     req.Name,
+		"version":    result.InstalledVersion,
+	}).Info("Logstash插件安装完成")
+	return result
+}
+
+// localPluginBundle 校验file://离线包位于plugin_bundle_dir内，未配置该目录时不接受本地离线包
+// 比较前解析符号链接，避免目录内的链接指向目录外的文件
+func (a *Agent) localPluginBundle(path string) (string, error) {
+	if a.config.PluginBundleDir == "" {
+		return "", fmt.Errorf("未配置plugin_bundle_dir，不接受file://离线包")
+	}
+	dir, err := filepath.EvalSymlinks(a.config.PluginBundleDir)
+	if err != nil {
+		return "", fmt.Errorf("解析plugin_bundle_dir失败: %w", err)
+	}
+	if dir, err = filepath.Abs(dir); err != nil {
+		return "", fmt.Errorf("解析plugin_bundle_dir失败: %w", err)
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("离线包不存在: %w", err)
+	}
+	if resolved, err = filepath.Abs(resolved); err != nil {
+		return "", fmt.Errorf("解析离线包路径失败: %w", err)
+	}
+	rel, err := filepath.Rel(dir, resolved)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("离线包不在plugin_bundle_dir %s 内", a.config.PluginBundleDir)
+	}
+	return resolved, nil
+}
+
+// fetchPluginBundle 获取离线包的本地路径，http(s)地址下载到数据目录，返回的cleanup删除下载的文件
+func (a *Agent) fetchPluginBundle(ctx context.Context, installID, rawURL string) (string, func(), error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", nil, fmt.Errorf("离线包地址无效: %w", err)
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "file":
+		path, err := a.localPluginBundle(u.Path)
+		if err != nil {
+			return "", nil, err
+		}
+		return path, func() {}, nil
+	case "http", "https":
+	default:
+		return "", nil, fmt.Errorf("离线包地址只支持http、https和file")
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", nil, fmt.Errorf("创建下载请求失败: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return "", nil, fmt.Errorf("下载离线包失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("下载离线包失败: HTTP %d", resp.StatusCode)
+	}
+
+	dir := filepath.Join(a.config.DataDir, "plugin-bundles")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", nil, fmt.Errorf("创建离线包目录失败: %w", err)
+	}
+	path := filepath.Join(dir, installID+".zip")
+	cleanup := func() { os.Remove(path) }
+	file, err := os.Create(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("创建离线包文件失败: %w", err)
+	}
+	n, err := io.Copy(file, io.LimitReader(resp.Body, maxPluginBundleSize+1))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("下载离线包失败: %w", err)
+	}
+	if n > maxPluginBundleSize {
+		cleanup()
+		return "", nil, fmt.Errorf("离线包超过 %d 字节", maxPluginBundleSize)
+	}
+	return path, cleanup, nil
+}
+
+// sendPluginInstallProgress 报告安装阶段，报告失败不影响安装
+func (a *Agent) sendPluginInstallProgress(installID, phase, message string) {
+	progress := models.PluginInstallProgress{InstallID: installID, Phase: phase, Message: message}
+	if err := a.sender.SendMessage(MsgTypePluginInstallProgress, progress); err != nil {
+		a.logger.WithError(err).WithField("install_id", installID).Debug("报告插件安装进度失败")
+	}
+}
+
+// truncatePluginOutput 输出超过上限时保留末尾，错误信息通常在最后
+func truncatePluginOutput(output string) (string, bool) {
+	if len(output) <= models.MaxPluginInstallOutput {
+		return output, false
+	}
+	return output[len(output)-models.MaxPluginInstallOutput:], true
+}
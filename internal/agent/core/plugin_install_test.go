@@ -0,0 +1,215 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/agent/config"
+	"logstash-platform/internal/platform/models"
+)
+
+// pluginInstallRecorder 记录报告的安装进度和结果
+type pluginInstallRecorder struct {
+	mu      sync.Mutex
+	phases  []string
+	results chan *models.PluginInstallResult
+}
+
+func (r *pluginInstallRecorder) SendMessage(msgType string, payload interface{}) error {
+	switch msgType {
+	case MsgTypePluginInstallProgress:
+		r.mu.Lock()
+		r.phases = append(r.phases, payload.(models.PluginInstallProgress).Phase)
+		r.mu.Unlock()
+	case MsgTypePluginInstallResult:
+		r.results <- payload.(*models.PluginInstallResult)
+	}
+	return nil
+}
+
+func newPluginInstallAgent(t *testing.T, run PluginCommand) (*Agent, *pluginInstallRecorder) {
+	sender := &pluginInstallRecorder{results: make(chan *models.PluginInstallResult, 1)}
+	agent := &Agent{
+		config:    &config.AgentConfig{DataDir: t.TempDir()},
+		logger:    logrus.New(),
+		ctx:       context.Background(),
+		sender:    sender,
+		runPlugin: run,
+		listPlugins: func(ctx context.Context) (string, error) {
+			return pluginListOutput + "logstash-filter-translate (3.4.2)\n", nil
+		},
+	}
+	return agent, sender
+}
+
+func waitPluginInstallResult(t *testing.T, sender *pluginInstallRecorder) *models.PluginInstallResult {
+	select {
+	case result := <-sender.results:
+		return result
+	case <-time.After(5 * time.Second):
+		t.Fatal("未收到插件安装结果")
+		return nil
+	}
+}
+
+func TestAgent_HandlePluginInstall(t *testing.T) {
+	var args [][]string
+	agent, sender := newPluginInstallAgent(t, func(ctx context.Context, a ...string) (string, error) {
+		args = append(args, a)
+		return "Validating logstash-filter-translate-3.4.2\nInstallation successful\n", nil
+	})
+	ctrl := new(MockLogstashController)
+	ctrl.On("Restart", mock.Anything).Return(nil)
+	agent.logstashCtrl = ctrl
+
+	require.NoError(t, agent.handleMessage(&WebSocketMessage{
+		Type:    MsgTypePluginInstall,
+		Payload: []byte(`{"install_id":"9b2f4c1e-3d6a-4f8b-a1c2-7e5d9f0b3a64","name":"logstash-filter-translate","version":"3.4.2","restart":true}`),
+	}))
+	result := waitPluginInstallResult(t, sender)
+	agent.wg.Wait()
+
+	assert.True(t, result.Success, result.Error)
+	assert.Equal(t, "9b2f4c1e-3d6a-4f8b-a1c2-7e5d9f0b3a64", result.InstallID)
+	assert.Equal(t, "3.4.2", result.InstalledVersion)
+	assert.Contains(t, result.Output, "Installation successful")
+	assert.Len(t, result.Plugins, 7)
+	assert.Equal(t, [][]string{{"install", "--version", "3.4.2", "logstash-filter-translate"}}, args)
+	assert.Equal(t, []string{models.PluginInstallPhaseInstalling, models.PluginInstallPhaseVerifying, models.PluginInstallPhaseRestarting}, sender.phases)
+	ctrl.AssertExpectations(t)
+
+	// 未指定版本且已安装时更新
+	args = nil
+	require.NoError(t, agent.handleMessage(&WebSocketMessage{
+		Type:    MsgTypePluginInstall,
+		Payload: []byte(`{"install_id":"0c7e1a52-8b4d-4e36-9f1a-2d5b6c8e4f70","name":"logstash-filter-grok"}`),
+	}))
+	result = waitPluginInstallResult(t, sender)
+	agent.wg.Wait()
+	assert.True(t, result.Success, result.Error)
+	assert.Equal(t, "4.4.3", result.InstalledVersion)
+	assert.Equal(t, [][]string{{"update", "logstash-filter-grok"}}, args)
+
+	assert.Error(t, agent.handleMessage(&WebSocketMessage{Type: MsgTypePluginInstall, Payload: []byte(`{"name":"logstash-filter-grok"}`)}))
+	// install_id用于离线包文件名，不是UUID时拒绝
+	assert.Error(t, agent.handleMessage(&WebSocketMessage{Type: MsgTypePluginInstall, Payload: []byte(`{"install_id":"../../etc/cron.d/x","name":"logstash-filter-grok"}`)}))
+}
+
+func TestAgent_InstallPluginFailures(t *testing.T) {
+	agent, _ := newPluginInstallAgent(t, func(ctx context.Context, a ...string) (string, error) {
+		return strings.Repeat("x", models.MaxPluginInstallOutput) + "\nPlugin not found, aborting", errors.New("exit status 1")
+	})
+
+	// 命令失败时保留输出末尾
+	result := agent.installPlugin(models.PluginInstallPayload{InstallID: "inst-1", PluginInstallRequest: models.PluginInstallRequest{Name: "logstash-filter-missing"}})
+	assert.False(t, result.Success)
+	assert.Contains(t, result.Error, "exit status 1")
+	assert.True(t, result.Truncated)
+	assert.Len(t, result.Output, models.MaxPluginInstallOutput)
+	assert.True(t, strings.HasSuffix(result.Output, "Plugin not found, aborting"))
+
+	// 命令成功但清单中没有该插件
+	agent.runPlugin = func(ctx context.Context, a ...string) (string, error) { return "", nil }
+	result = agent.installPlugin(models.PluginInstallPayload{InstallID: "inst-2", PluginInstallRequest: models.PluginInstallRequest{Name: "logstash-filter-missing"}})
+	assert.False(t, result.Success)
+	assert.Contains(t, result.Error, "logstash-filter-missing")
+	assert.NotEmpty(t, result.Plugins)
+
+	// 插件名作为命令行参数，Agent再次校验
+	result = agent.installPlugin(models.PluginInstallPayload{InstallID: "inst-3", PluginInstallRequest: models.PluginInstallRequest{Name: "--local"}})
+	assert.False(t, result.Success)
+	assert.Empty(t, result.Output)
+}
+
+func TestAgent_InstallPluginFromBundle(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/bundle.zip" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("PK\x03\x04"))
+	}))
+	defer server.Close()
+
+	var bundle string
+	agent, sender := newPluginInstallAgent(t, func(ctx context.Context, a ...string) (string, error) {
+		require.Len(t, a, 2)
+		bundle = strings.TrimPrefix(a[1], "file://")
+		content, err := os.ReadFile(bundle)
+		require.NoError(t, err)
+		assert.Equal(t, "PK\x03\x04", string(content))
+		return "Installation successful", nil
+	})
+
+	result := agent.installPlugin(models.PluginInstallPayload{InstallID: "inst-1", PluginInstallRequest: models.PluginInstallRequest{
+		Name: "logstash-filter-translate", BundleURL: server.URL + "/bundle.zip",
+	}})
+	assert.True(t, result.Success, result.Error)
+	assert.Equal(t, []string{models.PluginInstallPhaseDownloading, models.PluginInstallPhaseInstalling, models.PluginInstallPhaseVerifying}, sender.phases)
+	_, err := os.Stat(bundle)
+	assert.True(t, os.IsNotExist(err), "安装后删除下载的离线包")
+
+	result = agent.installPlugin(models.PluginInstallPayload{InstallID: "inst-2", PluginInstallRequest: models.PluginInstallRequest{
+		Name: "logstash-filter-translate", BundleURL: server.URL + "/missing.zip",
+	}})
+	assert.False(t, result.Success)
+	assert.Contains(t, result.Error, "HTTP 404")
+}
+
+func TestAgent_InstallPluginFromLocalBundle(t *testing.T) {
+	bundleDir := t.TempDir()
+	inside := filepath.Join(bundleDir, "translate.zip")
+	require.NoError(t, os.WriteFile(inside, []byte("PK\x03\x04"), 0644))
+	outside := filepath.Join(t.TempDir(), "evil.zip")
+	require.NoError(t, os.WriteFile(outside, []byte("PK\x03\x04"), 0644))
+	require.NoError(t, os.Symlink(outside, filepath.Join(bundleDir, "link.zip")))
+
+	var installed []string
+	agent, _ := newPluginInstallAgent(t, func(ctx context.Context, a ...string) (string, error) {
+		installed = append(installed, strings.TrimPrefix(a[1], "file://"))
+		return "Installation successful", nil
+	})
+	install := func(bundleURL string) *models.PluginInstallResult {
+		return agent.installPlugin(models.PluginInstallPayload{InstallID: "inst-1", PluginInstallRequest: models.PluginInstallRequest{
+			Name: "logstash-filter-translate", BundleURL: bundleURL,
+		}})
+	}
+
+	// 未配置plugin_bundle_dir时不接受本地离线包
+	result := install("file://" + inside)
+	assert.False(t, result.Success)
+	assert.Contains(t, result.Error, "plugin_bundle_dir")
+
+	agent.config.PluginBundleDir = bundleDir
+	for _, bundleURL := range []string{
+		"file://" + outside,
+		"file://" + bundleDir + "/../" + filepath.Base(filepath.Dir(outside)) + "/evil.zip",
+		"file://" + filepath.Join(bundleDir, "link.zip"),
+	} {
+		result = install(bundleURL)
+		assert.False(t, result.Success, bundleURL)
+		assert.Contains(t, result.Error, "plugin_bundle_dir", bundleURL)
+	}
+	assert.Empty(t, installed)
+
+	result = install("file://" + inside)
+	assert.True(t, result.Success, result.Error)
+	require.Len(t, installed, 1)
+	resolved, err := filepath.EvalSymlinks(inside)
+	require.NoError(t, err)
+	assert.Equal(t, resolved, installed[0])
+	_, err = os.Stat(inside)
+	assert.NoError(t, err, "不删除本地离线包")
+}
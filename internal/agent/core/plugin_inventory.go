@@ -0,0 +1,114 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"logstash-platform/internal/platform/models"
+)
+
+// pluginListTimeout logstash-plugin list 需要启动JVM并加载全部插件的gemspec，耗时较长
+const pluginListTimeout = 3 * time.Minute
+
+// PluginLister 执行 logstash-plugin list --verbose 并返回输出
+type PluginLister func(ctx context.Context) (string, error)
+
+// logstashPluginLister 执行与logstash同目录的logstash-plugin
+func logstashPluginLister(logstashPath string) PluginLister {
+	pluginPath := filepath.Join(filepath.Dir(logstashPath), "logstash-plugin")
+	return func(ctx context.Context) (string, error) {
+		output, err := exec.CommandContext(ctx, pluginPath, "list", "--verbose").Output()
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf("执行 logstash-plugin list --verbose 超时")
+		}
+		if err != nil {
+			return "", fmt.Errorf("执行 logstash-plugin list --verbose 失败: %w", err)
+		}
+		return string(output), nil
+	}
+}
+
+// parsePluginList 解析 logstash-plugin list --verbose 的输出
+// 每行为 "名称 (版本)"，集成插件之后以 ├── 或 └── 开头的行为其提供的子插件；其余行（如JDK提示）忽略
+func parsePluginList(output string) []models.LogstashPlugin {
+	var plugins []models.LogstashPlugin
+	var integration, integrationVersion string
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		nested := strings.HasPrefix(trimmed, "├") || strings.HasPrefix(trimmed, "└") ||
+			strings.HasPrefix(trimmed, "|") || strings.HasPrefix(trimmed, "`")
+		fields := strings.Fields(strings.TrimLeft(trimmed, "├└│─|`- "))
+		if len(fields) == 0 || !strings.HasPrefix(fields[0], "logstash-") {
+			continue
+		}
+
+		if nested {
+			if integration != "" {
+				plugins = append(plugins, models.LogstashPlugin{Name: fields[0], Version: integrationVersion, Integration: integration})
+			}
+			continue
+		}
+
+		plugin := models.LogstashPlugin{Name: fields[0]}
+		if len(fields) > 1 {
+			plugin.Version = strings.Trim(fields[1], "()")
+		}
+		plugins = append(plugins, plugin)
+		integration, integrationVersion = "", ""
+		if strings.HasPrefix(plugin.Name, "logstash-integration-") {
+			integration, integrationVersion = plugin.Name, plugin.Version
+		}
+	}
+	return plugins
+}
+
+// runPluginInventory 启动时上报插件清单，之后按间隔重新上报
+func (a *Agent) runPluginInventory(reporter PluginReporter) {
+	defer a.wg.Done()
+
+	if err := a.reportPlugins(reporter); err != nil {
+		a.logger.WithError(err).Warn("上报Logstash插件清单失败")
+	}
+	if a.config.PluginInventoryInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(a.config.PluginInventoryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := a.reportPlugins(reporter); err != nil {
+				a.logger.WithError(err).Warn("上报Logstash插件清单失败")
+			}
+		}
+	}
+}
+
+// reportPlugins 采集并上报插件清单
+func (a *Agent) reportPlugins(reporter PluginReporter) error {
+	ctx, cancel := context.WithTimeout(a.ctx, pluginListTimeout)
+	defer cancel()
+
+	output, err := a.listPlugins(ctx)
+	if err != nil {
+		return err
+	}
+	plugins := parsePluginList(output)
+	if len(plugins) == 0 {
+		return fmt.Errorf("logstash-plugin 的输出中没有插件")
+	}
+
+	report := &models.PluginInventoryReport{Plugins: plugins, CollectedAt: time.Now()}
+	if err := reporter.ReportPlugins(a.ctx, a.config.AgentID, report); err != nil {
+		return err
+	}
+	a.logger.WithField("plugins", len(plugins)).Info("已上报Logstash插件清单")
+	return nil
+}
@@ -0,0 +1,69 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+)
+
+const pluginListOutput = `Using bundled JDK: /usr/share/logstash/jdk
+logstash-codec-json (3.1.1)
+logstash-filter-grok (4.4.3)
+logstash-integration-kafka (11.3.1)
+ ├── logstash-input-kafka
+ └── logstash-output-kafka
+logstash-output-elasticsearch (11.22.2)
+`
+
+func TestParsePluginList(t *testing.T) {
+	plugins := parsePluginList(pluginListOutput)
+
+	assert.Equal(t, []models.LogstashPlugin{
+		{Name: "logstash-codec-json", Version: "3.1.1"},
+		{Name: "logstash-filter-grok", Version: "4.4.3"},
+		{Name: "logstash-integration-kafka", Version: "11.3.1"},
+		{Name: "logstash-input-kafka", Version: "11.3.1", Integration: "logstash-integration-kafka"},
+		{Name: "logstash-output-kafka", Version: "11.3.1", Integration: "logstash-integration-kafka"},
+		{Name: "logstash-output-elasticsearch", Version: "11.22.2"},
+	}, plugins)
+
+	// 旧版本Logstash以ASCII字符绘制树
+	plugins = parsePluginList("logstash-integration-jdbc (5.4.1)\n |-- logstash-input-jdbc\n `-- logstash-filter-jdbc_streaming\n")
+	require.Len(t, plugins, 3)
+	assert.Equal(t, "logstash-filter-jdbc_streaming", plugins[2].Name)
+	assert.Equal(t, "logstash-integration-jdbc", plugins[2].Integration)
+}
+
+type pluginReportingAPIClient struct {
+	*MockAPIClient
+	reports []*models.PluginInventoryReport
+}
+
+func (m *pluginReportingAPIClient) ReportPlugins(ctx context.Context, agentID string, report *models.PluginInventoryReport) error {
+	m.reports = append(m.reports, report)
+	return nil
+}
+
+func TestAgent_ReportPlugins(t *testing.T) {
+	agent, mockAPI, _, _, _, _ := createTestAgent(t)
+	client := &pluginReportingAPIClient{MockAPIClient: mockAPI}
+	agent.ctx, agent.cancel = context.WithCancel(context.Background())
+	defer agent.cancel()
+
+	agent.listPlugins = func(ctx context.Context) (string, error) { return pluginListOutput, nil }
+	require.NoError(t, agent.reportPlugins(client))
+	require.Len(t, client.reports, 1)
+	assert.Len(t, client.reports[0].Plugins, 6)
+	assert.False(t, client.reports[0].CollectedAt.IsZero())
+
+	// logstash-plugin执行失败或没有输出插件时不上报
+	agent.listPlugins = func(ctx context.Context) (string, error) { return "", errors.New("exit status 1") }
+	assert.Error(t, agent.reportPlugins(client))
+	agent.listPlugins = func(ctx context.Context) (string, error) { return "Using bundled JDK\n", nil }
+	assert.Error(t, agent.reportPlugins(client))
+	assert.Len(t, client.reports, 1)
+}
@@ -0,0 +1,123 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+)
+
+// requestReconcile 请求一次配置核对，已有未处理的请求时合并
+func (a *Agent) requestReconcile() {
+	select {
+	case a.reconcileCh <- struct{}{}:
+	default:
+	}
+}
+
+// runReconcile 串行处理配置核对请求，启动和每次WebSocket连接建立时各触发一次
+func (a *Agent) runReconcile(fetcher DesiredConfigFetcher) {
+	defer a.wg.Done()
+
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-a.reconcileCh:
+			if err := a.reconcileConfigs(fetcher); err != nil {
+				a.logger.WithError(err).Warn("按平台期望配置核对本地配置失败")
+			}
+		}
+	}
+}
+
+// reconcileConfigs 获取平台的期望配置并与本地已应用的配置核对：
+// 缺失、版本不符或文件与落盘时的哈希不一致的配置重新部署，期望之外的配置删除，全部变更后只重载一次
+func (a *Agent) reconcileConfigs(fetcher DesiredConfigFetcher) error {
+	if a.inMaintenance() {
+		a.logger.Debug("Agent处于维护模式，跳过配置核对")
+		return nil
+	}
+
+	desired, err := fetcher.GetDesiredConfigs(a.ctx, a.config.AgentID)
+	if err != nil {
+		return fmt.Errorf("获取期望配置失败: %w", err)
+	}
+
+	applied := make(map[string]models.AppliedConfig)
+	for _, ac := range a.GetStatus().AppliedConfigs {
+		applied[ac.ConfigID] = ac
+	}
+
+	changed := 0
+	wanted := make(map[string]bool, len(desired.Configs))
+	for _, cfg := range desired.Configs {
+		wanted[cfg.ConfigID] = true
+		reason := a.outOfSync(cfg, applied)
+		if reason == "" {
+			continue
+		}
+
+		a.logger.WithFields(logrus.Fields{
+			"config_id": cfg.ConfigID,
+			"version":   cfg.Version,
+			"reason":    reason,
+		}).Info("本地配置与平台期望不一致，重新部署")
+		req := configDeployRequest{ConfigID: cfg.ConfigID, Version: cfg.Version, DeploymentID: cfg.DeploymentID}
+		if err := a.deployConfig(a.ctx, req, true); err != nil {
+			a.logger.WithError(err).WithField("config_id", cfg.ConfigID).Warn("补齐配置失败")
+			continue
+		}
+		changed++
+	}
+
+	var orphans []string
+	for configID := range applied {
+		if !wanted[configID] {
+			orphans = append(orphans, configID)
+		}
+	}
+	sort.Strings(orphans)
+	for _, configID := range orphans {
+		a.logger.WithField("config_id", configID).Info("配置不在平台期望中，删除")
+		if err := a.removeConfig(configID, true); err != nil {
+			a.logger.WithError(err).WithField("config_id", configID).Warn("删除多余的配置失败")
+			continue
+		}
+		changed++
+	}
+
+	if changed == 0 {
+		a.logger.WithField("configs", len(desired.Configs)).Debug("本地配置与平台期望一致")
+		return nil
+	}
+	a.logger.WithField("changed", changed).Info("已按平台期望补齐本地配置")
+
+	// 补齐的配置均标记为重载挂起，重载执行后经onReloadFlushed补报应用结果
+	if a.config.EnableAutoReload && a.logstashCtrl.IsRunning() {
+		queued, err := a.requestReload(ReloadSourceReconcile)
+		if err != nil {
+			a.logger.WithError(err).Error("重载Logstash失败")
+		}
+		if !queued {
+			a.onReloadFlushed([]string{ReloadSourceReconcile}, err)
+		}
+	}
+	return nil
+}
+
+// outOfSync 本地配置与期望不一致的原因，一致时返回空字符串
+func (a *Agent) outOfSync(desired models.AgentDesiredConfig, applied map[string]models.AppliedConfig) string {
+	local, ok := applied[desired.ConfigID]
+	if !ok {
+		return "本地缺少该配置"
+	}
+	if local.Version != desired.Version {
+		return fmt.Sprintf("本地版本 %d，期望版本 %d", local.Version, desired.Version)
+	}
+	if _, err := VerifyConfigFile(a.configMgr, desired.ConfigID); err != nil {
+		return fmt.Sprintf("本地文件校验失败: %v", err)
+	}
+	return ""
+}
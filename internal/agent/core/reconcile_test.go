@@ -0,0 +1,99 @@
+package core
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/agent/config"
+	"logstash-platform/internal/platform/models"
+)
+
+type desiredConfigAPIClient struct {
+	*MockAPIClient
+	desired []models.AgentDesiredConfig
+	removed []string
+}
+
+func (m *desiredConfigAPIClient) GetDesiredConfigs(ctx context.Context, agentID string) (*models.AgentDesiredConfigs, error) {
+	return &models.AgentDesiredConfigs{AgentID: agentID, Configs: m.desired}, nil
+}
+
+func (m *desiredConfigAPIClient) ReportConfigRemoved(ctx context.Context, agentID, configID string) error {
+	m.removed = append(m.removed, configID)
+	return nil
+}
+
+func TestAgent_ReconcileConfigs(t *testing.T) {
+	agent, mockAPI, _, mockLogstash, _, _ := createTestAgent(t)
+	configMgr, err := config.NewManager(&config.AgentConfig{ConfigDir: t.TempDir(), ConfigBackupCount: 3}, agent.logger)
+	require.NoError(t, err)
+	agent.configMgr = configMgr
+	agent.ctx, agent.cancel = context.WithCancel(context.Background())
+	defer agent.cancel()
+
+	// 本地：kept与期望一致，stale版本落后，tampered文件被修改，orphan不在期望中
+	for _, cfg := range []*models.Config{
+		{ID: "kept", Version: 2, Content: "input { stdin {} } # kept"},
+		{ID: "stale", Version: 1, Content: "input { stdin {} } # stale v1"},
+		{ID: "tampered", Version: 1, Content: "input { stdin {} } # tampered"},
+		{ID: "orphan", Version: 1, Content: "input { stdin {} } # orphan"},
+	} {
+		require.NoError(t, configMgr.SaveConfig(cfg))
+		agent.status.AppliedConfigs = append(agent.status.AppliedConfigs, models.AppliedConfig{ConfigID: cfg.ID, Version: cfg.Version})
+	}
+	require.NoError(t, os.WriteFile(configMgr.GetConfigPath("tampered"), []byte("input { exec {} }"), 0644))
+
+	client := &desiredConfigAPIClient{MockAPIClient: mockAPI, desired: []models.AgentDesiredConfig{
+		{ConfigID: "kept", Version: 2},
+		{ConfigID: "stale", Version: 2, DeploymentID: "dep-2"},
+		{ConfigID: "tampered", Version: 1},
+		{ConfigID: "missing", Version: 3, DeploymentID: "dep-3"},
+	}}
+	agent.apiClient = client
+
+	for _, id := range []string{"stale", "tampered", "missing"} {
+		mockAPI.On("GetConfig", mock.Anything, id).Return(&models.Config{ID: id, Version: 9, Content: "input { stdin {} } # " + id + " new"}, nil).Once()
+	}
+	mockLogstash.On("ValidateConfig", mock.Anything).Return(nil)
+	mockLogstash.On("IsRunning").Return(true)
+	mockLogstash.On("Reload", mock.Anything).Return(nil).Once()
+	var mu sync.Mutex
+	var reports []models.AppliedConfig
+	mockAPI.On("ReportConfigApplied", mock.Anything, "test-agent", mock.Anything).Run(func(args mock.Arguments) {
+		mu.Lock()
+		defer mu.Unlock()
+		reports = append(reports, *args.Get(2).(*models.AppliedConfig))
+	}).Return(nil)
+
+	require.NoError(t, agent.reconcileConfigs(client))
+
+	// 三个配置重新部署、一个删除，只重载一次
+	mockLogstash.AssertNumberOfCalls(t, "Reload", 1)
+	mockAPI.AssertNumberOfCalls(t, "GetConfig", 3)
+	assert.Equal(t, []string{"orphan"}, client.removed)
+	_, statErr := os.Stat(configMgr.GetConfigPath("orphan"))
+	assert.True(t, os.IsNotExist(statErr))
+
+	versions := make(map[string]int)
+	for _, ac := range agent.GetStatus().AppliedConfigs {
+		versions[ac.ConfigID] = ac.Version
+		assert.False(t, ac.ReloadPending, ac.ConfigID)
+	}
+	assert.Equal(t, map[string]int{"kept": 2, "stale": 2, "tampered": 1, "missing": 3}, versions)
+
+	// 落盘时以reload_queued上报，重载完成后补报，部署ID原样回传
+	require.Len(t, reports, 6)
+	assert.True(t, reports[0].ReloadPending)
+	assert.Equal(t, "dep-2", reports[0].DeploymentID)
+	assert.False(t, reports[5].ReloadPending)
+
+	// 已一致时不再部署和重载
+	require.NoError(t, agent.reconcileConfigs(client))
+	mockLogstash.AssertNumberOfCalls(t, "Reload", 1)
+	mockAPI.AssertNumberOfCalls(t, "GetConfig", 3)
+}
@@ -0,0 +1,294 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+)
+
+// 重载请求来源
+const (
+	ReloadSourceDeploy    = "deploy"    // 平台下发配置
+	ReloadSourceDelete    = "delete"    // 平台删除配置
+	ReloadSourceRequest   = "request"   // 平台显式要求重载
+	ReloadSourceDrift     = "drift"     // 本地配置文件被外部修改
+	ReloadSourceResume    = "resume"    // 资源恢复后补做超过阈值期间推迟的重载
+	ReloadSourceReconcile = "reconcile" // 启动或重新连接后按平台的期望配置补齐本地配置
+)
+
+// ReloadCoordinator 重载协调器
+// 为Agent上的所有重载来源维护统一的滑动窗口预算：窗口内最多执行budget次重载，
+// 超出的请求排队，待最早一次重载滑出窗口后合并为一次重载执行，避免频繁重载冲击Logstash。
+// 设置防抖时间后，配置变更引起的重载请求先进入防抖批次，最后一次请求后静默满防抖时间才合并为一次重载
+type ReloadCoordinator struct {
+	reload   func(ctx context.Context) error
+	budget   int
+	window   time.Duration
+	debounce time.Duration
+	logger   *logrus.Logger
+
+	mu        sync.Mutex
+	history   []time.Time // 窗口内的重载时间，按时间升序
+	queued    []string    // 排队请求的来源
+	timer     *time.Timer
+	nextAt    time.Time
+	ctx       context.Context
+	lastAt    time.Time
+	lastError string
+	onFlush   func(sources []string, err error)
+	now       func() time.Time
+
+	settling    []string // 防抖批次中请求的来源
+	settleTimer *time.Timer
+}
+
+// NewReloadCoordinator 创建重载协调器，budget小于1时不限制重载次数
+func NewReloadCoordinator(budget int, window time.Duration, reload func(ctx context.Context) error, logger *logrus.Logger) *ReloadCoordinator {
+	return &ReloadCoordinator{
+		reload: reload,
+		budget: budget,
+		window: window,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// SetDebounce 设置配置变更重载的防抖时间，0表示不防抖
+// 平台显式要求的重载不防抖，执行时一并完成防抖批次中的请求
+func (c *ReloadCoordinator) SetDebounce(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.debounce = d
+}
+
+// OnFlush 设置排队重载执行完成后的回调，用于向平台补报排队期间挂起的结果
+func (c *ReloadCoordinator) OnFlush(fn func(sources []string, err error)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onFlush = fn
+}
+
+// Request 请求一次重载
+// 配置变更的请求在启用防抖时进入防抖批次并返回queued=true；
+// 否则预算充足时立即执行并返回执行结果，预算耗尽时排队并返回queued=true
+func (c *ReloadCoordinator) Request(ctx context.Context, source string) (queued bool, err error) {
+	c.mu.Lock()
+	if c.debounce > 0 && source != ReloadSourceRequest {
+		c.settle(ctx, source)
+		c.mu.Unlock()
+		return true, nil
+	}
+
+	// 防抖批次中的请求随本次重载一并完成，执行后通过回调补报结果
+	sources := append(c.takeSettling(), source)
+	absorbed := len(sources) > 1
+	if c.limited() {
+		c.prune()
+		if len(c.history) >= c.budget || len(c.queued) > 0 {
+			c.enqueue(ctx, sources...)
+			c.mu.Unlock()
+			return true, nil
+		}
+		// 持锁占用名额，避免并发请求同时通过预算检查
+		c.history = append(c.history, c.now())
+	}
+	onFlush := c.onFlush
+	c.mu.Unlock()
+
+	err = c.execute(ctx, sources)
+	if absorbed && onFlush != nil {
+		onFlush(sources, err)
+	}
+	return false, err
+}
+
+// Status 返回当前预算状态，未启用预算时返回nil
+func (c *ReloadCoordinator) Status() *models.ReloadStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.limited() {
+		return nil
+	}
+	c.prune()
+
+	status := &models.ReloadStatus{
+		Budget:        c.budget,
+		WindowSeconds: int(c.window / time.Second),
+		Used:          len(c.history),
+		Queued:        len(c.queued),
+		LastError:     c.lastError,
+	}
+	if len(c.queued) > 0 {
+		status.QueuedSources = append([]string(nil), c.queued...)
+		next := c.nextAt
+		status.NextAllowedAt = &next
+	}
+	if !c.lastAt.IsZero() {
+		last := c.lastAt
+		status.LastReloadAt = &last
+	}
+	return status
+}
+
+// Stop 放弃排队中的重载
+func (c *ReloadCoordinator) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+	c.queued = nil
+	c.takeSettling()
+}
+
+// limited 是否启用了预算
+func (c *ReloadCoordinator) limited() bool {
+	return c.budget > 0 && c.window > 0
+}
+
+// prune 移除滑出窗口的重载记录，调用方需持有锁
+func (c *ReloadCoordinator) prune() {
+	cutoff := c.now().Add(-c.window)
+	i := 0
+	for i < len(c.history) && !c.history[i].After(cutoff) {
+		i++
+	}
+	c.history = c.history[i:]
+}
+
+// settle 将请求加入防抖批次并重新计时，调用方需持有锁
+func (c *ReloadCoordinator) settle(ctx context.Context, source string) {
+	c.settling = append(c.settling, source)
+	c.ctx = ctx
+	if c.settleTimer != nil {
+		c.settleTimer.Stop()
+	}
+	c.settleTimer = time.AfterFunc(c.debounce, c.settled)
+	c.logger.WithFields(logrus.Fields{
+		"source":   source,
+		"pending":  len(c.settling),
+		"debounce": c.debounce,
+	}).Debug("重载请求进入防抖批次")
+}
+
+// takeSettling 取出防抖批次并停止计时，调用方需持有锁
+func (c *ReloadCoordinator) takeSettling() []string {
+	if c.settleTimer != nil {
+		c.settleTimer.Stop()
+		c.settleTimer = nil
+	}
+	sources := c.settling
+	c.settling = nil
+	return sources
+}
+
+// settled 防抖时间内没有新的请求，将批次合并为一次重载；预算耗尽时转入排队
+func (c *ReloadCoordinator) settled() {
+	c.mu.Lock()
+	sources := c.takeSettling()
+	if len(sources) == 0 || (c.ctx != nil && c.ctx.Err() != nil) {
+		c.mu.Unlock()
+		return
+	}
+	ctx := c.ctx
+	if c.limited() {
+		c.prune()
+		if len(c.history) >= c.budget || len(c.queued) > 0 {
+			c.enqueue(ctx, sources...)
+			c.mu.Unlock()
+			return
+		}
+		c.history = append(c.history, c.now())
+	}
+	onFlush := c.onFlush
+	c.mu.Unlock()
+
+	err := c.execute(ctx, sources)
+	if err != nil {
+		c.logger.WithError(err).WithField("sources", sources).Error("执行防抖合并的重载失败")
+	}
+	if onFlush != nil {
+		onFlush(sources, err)
+	}
+}
+
+// enqueue 排队重载请求，调用方需持有锁
+func (c *ReloadCoordinator) enqueue(ctx context.Context, sources ...string) {
+	c.queued = append(c.queued, sources...)
+	c.ctx = ctx
+
+	if c.timer != nil {
+		return
+	}
+
+	c.nextAt = c.now()
+	if len(c.history) >= c.budget {
+		c.nextAt = c.history[0].Add(c.window)
+	}
+	c.timer = time.AfterFunc(c.nextAt.Sub(c.now()), c.flush)
+
+	c.logger.WithFields(logrus.Fields{
+		"sources":    sources,
+		"budget":     c.budget,
+		"window":     c.window,
+		"next_allow": c.nextAt,
+	}).Warn("重载预算已用尽，重载请求排队")
+}
+
+// flush 预算释放后合并执行排队的重载
+func (c *ReloadCoordinator) flush() {
+	c.mu.Lock()
+	c.timer = nil
+	if len(c.queued) == 0 || (c.ctx != nil && c.ctx.Err() != nil) {
+		c.queued = nil
+		c.mu.Unlock()
+		return
+	}
+	c.prune()
+	if len(c.history) >= c.budget {
+		// 名额仍未释放，等待最早一次重载滑出窗口后重试
+		c.nextAt = c.history[0].Add(c.window)
+		c.timer = time.AfterFunc(c.nextAt.Sub(c.now()), c.flush)
+		c.mu.Unlock()
+		return
+	}
+	c.history = append(c.history, c.now())
+	sources := c.queued
+	c.queued = nil
+	ctx := c.ctx
+	onFlush := c.onFlush
+	c.mu.Unlock()
+
+	err := c.execute(ctx, sources)
+	if err != nil {
+		c.logger.WithError(err).WithField("sources", sources).Error("执行排队的重载失败")
+	}
+	if onFlush != nil {
+		onFlush(sources, err)
+	}
+}
+
+// execute 执行重载并记录结果，调用方已在窗口中占用名额
+func (c *ReloadCoordinator) execute(ctx context.Context, sources []string) error {
+	err := c.reload(ctx)
+
+	c.mu.Lock()
+	now := c.now()
+	c.lastAt = now
+	c.lastError = ""
+	if err != nil {
+		c.lastError = err.Error()
+	}
+	c.mu.Unlock()
+
+	if err == nil && len(sources) > 1 {
+		c.logger.WithField("sources", sources).Info("已合并执行多个重载请求")
+	}
+	return err
+}
@@ -0,0 +1,175 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloadCoordinator_QueuesBeyondBudget(t *testing.T) {
+	var reloads int32
+	coordinator := NewReloadCoordinator(2, 150*time.Millisecond, func(ctx context.Context) error {
+		atomic.AddInt32(&reloads, 1)
+		return nil
+	}, logrus.New())
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		queued, err := coordinator.Request(ctx, ReloadSourceDeploy)
+		require.NoError(t, err)
+		assert.False(t, queued)
+	}
+
+	// 预算用尽后的请求排队
+	queued, err := coordinator.Request(ctx, ReloadSourceDeploy)
+	require.NoError(t, err)
+	assert.True(t, queued)
+	queued, _ = coordinator.Request(ctx, ReloadSourceRequest)
+	assert.True(t, queued)
+
+	status := coordinator.Status()
+	require.NotNil(t, status)
+	assert.Equal(t, 2, status.Used)
+	assert.Equal(t, 2, status.Queued)
+	assert.Equal(t, []string{ReloadSourceDeploy, ReloadSourceRequest}, status.QueuedSources)
+	assert.NotNil(t, status.NextAllowedAt)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&reloads))
+
+	// 窗口滑过后排队请求合并为一次重载
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&reloads) == 3
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 0, coordinator.Status().Queued)
+}
+
+func TestReloadCoordinator_Unlimited(t *testing.T) {
+	coordinator := NewReloadCoordinator(0, time.Minute, func(ctx context.Context) error {
+		return errors.New("boom")
+	}, logrus.New())
+
+	queued, err := coordinator.Request(context.Background(), ReloadSourceRequest)
+	assert.False(t, queued)
+	assert.EqualError(t, err, "boom")
+	assert.Nil(t, coordinator.Status())
+}
+
+func TestReloadCoordinator_StopDropsQueued(t *testing.T) {
+	var reloads int32
+	coordinator := NewReloadCoordinator(1, 50*time.Millisecond, func(ctx context.Context) error {
+		atomic.AddInt32(&reloads, 1)
+		return nil
+	}, logrus.New())
+
+	coordinator.Request(context.Background(), ReloadSourceDeploy)
+	queued, _ := coordinator.Request(context.Background(), ReloadSourceDeploy)
+	assert.True(t, queued)
+
+	coordinator.Stop()
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&reloads))
+}
+
+func TestReloadCoordinator_ConcurrentRequestsRespectBudget(t *testing.T) {
+	var reloads int32
+	release := make(chan struct{})
+	coordinator := NewReloadCoordinator(2, time.Minute, func(ctx context.Context) error {
+		atomic.AddInt32(&reloads, 1)
+		<-release
+		return nil
+	}, logrus.New())
+	defer coordinator.Stop()
+
+	// 重载执行期间并发到达的请求不能越过预算
+	var queuedCount int32
+	done := make(chan struct{})
+	for i := 0; i < 5; i++ {
+		go func() {
+			queued, _ := coordinator.Request(context.Background(), ReloadSourceDrift)
+			if queued {
+				atomic.AddInt32(&queuedCount, 1)
+			}
+			done <- struct{}{}
+		}()
+	}
+
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&queuedCount) == 3
+	}, time.Second, 10*time.Millisecond)
+	close(release)
+	for i := 0; i < 5; i++ {
+		<-done
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&reloads))
+}
+
+func TestReloadCoordinator_OnFlushReportsQueuedResult(t *testing.T) {
+	coordinator := NewReloadCoordinator(1, 50*time.Millisecond, func(ctx context.Context) error {
+		return nil
+	}, logrus.New())
+
+	flushed := make(chan []string, 1)
+	coordinator.OnFlush(func(sources []string, err error) {
+		assert.NoError(t, err)
+		flushed <- sources
+	})
+
+	coordinator.Request(context.Background(), ReloadSourceDeploy)
+	queued, _ := coordinator.Request(context.Background(), ReloadSourceDrift)
+	require.True(t, queued)
+
+	select {
+	case sources := <-flushed:
+		assert.Equal(t, []string{ReloadSourceDrift}, sources)
+	case <-time.After(time.Second):
+		t.Fatal("排队的重载未执行")
+	}
+}
+
+func TestReloadCoordinator_DebounceMergesBurst(t *testing.T) {
+	var reloads int32
+	coordinator := NewReloadCoordinator(0, 0, func(ctx context.Context) error {
+		atomic.AddInt32(&reloads, 1)
+		return nil
+	}, logrus.New())
+	coordinator.SetDebounce(80 * time.Millisecond)
+	defer coordinator.Stop()
+
+	flushed := make(chan []string, 2)
+	coordinator.OnFlush(func(sources []string, err error) {
+		assert.NoError(t, err)
+		flushed <- sources
+	})
+
+	// 静默期内的连续请求不断推迟重载
+	for _, source := range []string{ReloadSourceDeploy, ReloadSourceDeploy, ReloadSourceDelete} {
+		queued, err := coordinator.Request(context.Background(), source)
+		require.NoError(t, err)
+		assert.True(t, queued)
+		time.Sleep(30 * time.Millisecond)
+	}
+	assert.Zero(t, atomic.LoadInt32(&reloads))
+
+	select {
+	case sources := <-flushed:
+		assert.Equal(t, []string{ReloadSourceDeploy, ReloadSourceDeploy, ReloadSourceDelete}, sources)
+	case <-time.After(time.Second):
+		t.Fatal("防抖批次未执行")
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&reloads))
+
+	// 显式重载不防抖，并一并完成防抖批次
+	coordinator.Request(context.Background(), ReloadSourceDrift)
+	queued, err := coordinator.Request(context.Background(), ReloadSourceRequest)
+	require.NoError(t, err)
+	assert.False(t, queued)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&reloads))
+	assert.Equal(t, []string{ReloadSourceDrift, ReloadSourceRequest}, <-flushed)
+	time.Sleep(120 * time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&reloads))
+}
@@ -0,0 +1,128 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrResourcePressure 磁盘或内存使用率超过阈值，拒绝部署新配置
+var ErrResourcePressure = errors.New("资源使用率超过阈值，拒绝部署新配置")
+
+// ResourceUsage 一次资源采样
+type ResourceUsage struct {
+	Disk   map[string]float64 // 目录 -> 所在磁盘的使用率（%）
+	Memory float64            // 主机内存使用率（%）
+}
+
+// ResourceSampler 采样目录所在磁盘和主机内存的使用率
+type ResourceSampler func(dirs []string) (*ResourceUsage, error)
+
+// ResourceLimits 资源使用率阈值，0表示不检查该项
+type ResourceLimits struct {
+	DiskPercent   float64
+	MemoryPercent float64
+}
+
+// ResourceGuard 资源保护
+// 定期采样Logstash数据/日志目录所在磁盘和主机内存的使用率，超过阈值和恢复时回调onChange，
+// 超过阈值时reasons列出超过的各项
+type ResourceGuard struct {
+	dirs     []string
+	limits   ResourceLimits
+	interval time.Duration
+	sample   ResourceSampler
+	onChange func(reasons []string)
+	logger   *logrus.Logger
+
+	mu      sync.Mutex
+	reasons []string
+}
+
+// NewResourceGuard 创建资源保护，dirs中的空目录被忽略
+func NewResourceGuard(dirs []string, limits ResourceLimits, interval time.Duration, sample ResourceSampler,
+	onChange func(reasons []string), logger *logrus.Logger) *ResourceGuard {
+	var checked []string
+	seen := make(map[string]bool)
+	for _, dir := range dirs {
+		if dir != "" && !seen[dir] {
+			seen[dir] = true
+			checked = append(checked, dir)
+		}
+	}
+	return &ResourceGuard{
+		dirs:     checked,
+		limits:   limits,
+		interval: interval,
+		sample:   sample,
+		onChange: onChange,
+		logger:   logger,
+	}
+}
+
+// Run 立即检查一次，之后按间隔检查，直到ctx取消
+func (g *ResourceGuard) Run(ctx context.Context) {
+	g.Check()
+
+	ticker := time.NewTicker(g.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			g.Check()
+		}
+	}
+}
+
+// Check 采样并与阈值比较，超过阈值与否发生变化时回调onChange
+// 采样失败时保持上一次的结论
+func (g *ResourceGuard) Check() {
+	usage, err := g.sample(g.dirs)
+	if err != nil {
+		g.logger.WithError(err).Warn("采样资源使用率失败")
+		return
+	}
+
+	var reasons []string
+	if g.limits.DiskPercent > 0 {
+		for _, dir := range g.dirs {
+			if used, ok := usage.Disk[dir]; ok && used >= g.limits.DiskPercent {
+				reasons = append(reasons, fmt.Sprintf("%s 所在磁盘使用率 %.1f%% 超过阈值 %.0f%%", dir, used, g.limits.DiskPercent))
+			}
+		}
+	}
+	if g.limits.MemoryPercent > 0 && usage.Memory >= g.limits.MemoryPercent {
+		reasons = append(reasons, fmt.Sprintf("主机内存使用率 %.1f%% 超过阈值 %.0f%%", usage.Memory, g.limits.MemoryPercent))
+	}
+
+	g.mu.Lock()
+	changed := (len(reasons) > 0) != (len(g.reasons) > 0)
+	g.reasons = reasons
+	g.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	if len(reasons) > 0 {
+		g.logger.WithField("reasons", reasons).Warn("资源使用率超过阈值")
+	} else {
+		g.logger.Info("资源使用率已恢复到阈值以下")
+	}
+	if g.onChange != nil {
+		g.onChange(reasons)
+	}
+}
+
+// Reasons 最近一次检查超过的阈值，未超过时为空
+func (g *ResourceGuard) Reasons() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]string(nil), g.reasons...)
+}
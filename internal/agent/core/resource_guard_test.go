@@ -0,0 +1,75 @@
+package core
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResourceGuard_Transitions(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel)
+
+	usage := &ResourceUsage{Disk: map[string]float64{"/data": 50, "/logs": 50}, Memory: 40}
+	var sampleErr error
+	var sampled []string
+	var changes [][]string
+	g := NewResourceGuard([]string{"/data", "/logs", "", "/data"}, ResourceLimits{DiskPercent: 90, MemoryPercent: 95}, 0,
+		func(dirs []string) (*ResourceUsage, error) {
+			sampled = dirs
+			return usage, sampleErr
+		},
+		func(reasons []string) { changes = append(changes, reasons) }, logger)
+
+	// 空目录和重复目录被忽略
+	g.Check()
+	assert.Equal(t, []string{"/data", "/logs"}, sampled)
+	assert.Empty(t, changes)
+	assert.Empty(t, g.Reasons())
+
+	// 超过阈值时回调并列出各项
+	usage = &ResourceUsage{Disk: map[string]float64{"/data": 93.5, "/logs": 50}, Memory: 96}
+	g.Check()
+	assert.Len(t, changes, 1)
+	assert.Len(t, changes[0], 2)
+	assert.Contains(t, changes[0][0], "/data")
+	assert.Contains(t, changes[0][1], "主机内存")
+
+	// 仍超过阈值时不重复回调，但更新原因
+	usage = &ResourceUsage{Disk: map[string]float64{"/data": 93.5}, Memory: 40}
+	g.Check()
+	assert.Len(t, changes, 1)
+	assert.Len(t, g.Reasons(), 1)
+
+	// 采样失败时保持上一次的结论
+	sampleErr = errors.New("statfs失败")
+	g.Check()
+	assert.Len(t, changes, 1)
+	assert.Len(t, g.Reasons(), 1)
+
+	// 恢复后回调空原因
+	sampleErr = nil
+	usage = &ResourceUsage{Disk: map[string]float64{"/data": 80}, Memory: 40}
+	g.Check()
+	assert.Len(t, changes, 2)
+	assert.Empty(t, changes[1])
+	assert.Empty(t, g.Reasons())
+}
+
+func TestResourceGuard_DisabledLimits(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel)
+
+	called := false
+	g := NewResourceGuard([]string{"/data"}, ResourceLimits{}, 0,
+		func(dirs []string) (*ResourceUsage, error) {
+			return &ResourceUsage{Disk: map[string]float64{"/data": 100}, Memory: 100}, nil
+		},
+		func(reasons []string) { called = true }, logger)
+
+	g.Check()
+	assert.False(t, called)
+	assert.Empty(t, g.Reasons())
+}
@@ -0,0 +1,24 @@
+package core
+
+import "context"
+
+// traceMetadataKey 上下文中平台消息链路上下文的键
+type traceMetadataKey struct{}
+
+// WithTraceMetadata 在ctx中记录平台消息携带的链路上下文（traceparent、tracestate），为空时返回ctx本身
+// 处理该消息时发往平台的请求与上报携带同一链路上下文，平台据此把整个部署过程归入一条链路
+func WithTraceMetadata(ctx context.Context, metadata map[string]string) context.Context {
+	if len(metadata) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, traceMetadataKey{}, metadata)
+}
+
+// TraceMetadata ctx中记录的链路上下文，没有时返回nil
+func TraceMetadata(ctx context.Context) map[string]string {
+	if ctx == nil {
+		return nil
+	}
+	metadata, _ := ctx.Value(traceMetadataKey{}).(map[string]string)
+	return metadata
+}
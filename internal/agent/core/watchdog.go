@@ -0,0 +1,153 @@
+package core
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LivenessReporter 可被看门狗监测的组件
+// LastAlive 返回组件最近一次确认存活的时间
+type LivenessReporter interface {
+	LastAlive() time.Time
+}
+
+// ConnectionResetter 支持强制重置WebSocket连接的客户端
+type ConnectionResetter interface {
+	ResetWebSocket() error
+}
+
+// watchedComponent 被监测的组件
+type watchedComponent struct {
+	name       string
+	maxSilence func() time.Duration
+	lastAlive  func() time.Time
+	restart    func() error
+
+	stuck      bool
+	restarting bool
+}
+
+// Watchdog 组件存活看门狗
+// 定期检查各组件的存活时间戳，发现卡住的组件时输出全部goroutine堆栈、尝试重启组件，
+// 并通过回调通知Agent上报降级状态
+type Watchdog struct {
+	logger     *logrus.Logger
+	interval   time.Duration
+	components []*watchedComponent
+	mu         sync.Mutex
+
+	// 状态变化回调：有组件卡住时degraded为true，全部恢复后为false
+	onStateChange func(degraded bool, stuck []string)
+	degraded      bool
+}
+
+// NewWatchdog 创建看门狗
+func NewWatchdog(interval time.Duration, logger *logrus.Logger, onStateChange func(degraded bool, stuck []string)) *Watchdog {
+	return &Watchdog{
+		logger:        logger,
+		interval:      interval,
+		onStateChange: onStateChange,
+	}
+}
+
+// Watch 注册被监测组件，restart可以为nil
+func (w *Watchdog) Watch(name string, maxSilence time.Duration, lastAlive func() time.Time, restart func() error) {
+	w.WatchFunc(name, func() time.Duration { return maxSilence }, lastAlive, restart)
+}
+
+// WatchFunc 注册被监测组件，每次检查时重新读取允许的最长静默时间
+// 用于阈值依赖运行中可变参数（如心跳间隔）的组件
+func (w *Watchdog) WatchFunc(name string, maxSilence func() time.Duration, lastAlive func() time.Time, restart func() error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.components = append(w.components, &watchedComponent{
+		name:       name,
+		maxSilence: maxSilence,
+		lastAlive:  lastAlive,
+		restart:    restart,
+	})
+}
+
+// Run 运行看门狗直到上下文取消
+func (w *Watchdog) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			w.check(now)
+		}
+	}
+}
+
+// check 检查一轮全部组件
+func (w *Watchdog) check(now time.Time) {
+	w.mu.Lock()
+	var newlyStuck, stuck []string
+	for _, c := range w.components {
+		silence := now.Sub(c.lastAlive())
+		if silence <= c.maxSilence() {
+			if c.stuck {
+				w.logger.WithField("component", c.name).Info("组件已恢复")
+			}
+			c.stuck = false
+			continue
+		}
+
+		if !c.stuck {
+			newlyStuck = append(newlyStuck, c.name)
+			w.logger.WithFields(logrus.Fields{
+				"component": c.name,
+				"silence":   silence.String(),
+			}).Error("检测到组件卡住")
+		}
+		c.stuck = true
+		stuck = append(stuck, c.name)
+
+		if c.restart != nil && !c.restarting {
+			c.restarting = true
+			go w.restart(c)
+		}
+	}
+
+	degraded := len(stuck) > 0
+	changed := degraded != w.degraded
+	w.degraded = degraded
+	w.mu.Unlock()
+
+	if len(newlyStuck) > 0 {
+		w.dumpStacks()
+	}
+
+	if changed && w.onStateChange != nil {
+		w.onStateChange(degraded, stuck)
+	}
+}
+
+// restart 在独立goroutine中重启组件，避免重启过程本身阻塞看门狗
+func (w *Watchdog) restart(c *watchedComponent) {
+	w.logger.WithField("component", c.name).Warn("尝试重启组件")
+	err := c.restart()
+
+	w.mu.Lock()
+	c.restarting = false
+	w.mu.Unlock()
+
+	if err != nil {
+		w.logger.WithError(err).WithField("component", c.name).Error("重启组件失败")
+	}
+}
+
+// dumpStacks 将全部goroutine堆栈输出到日志，便于排查死锁
+func (w *Watchdog) dumpStacks() {
+	buf := make([]byte, 1<<20)
+	n := runtime.Stack(buf, true)
+	w.logger.WithField("goroutines", runtime.NumGoroutine()).Errorf("goroutine堆栈:\n%s", buf[:n])
+}
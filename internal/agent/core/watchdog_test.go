@@ -0,0 +1,75 @@
+package core
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchdog_DetectsStuckComponent(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel)
+
+	var states []bool
+	var restarts int32
+	w := NewWatchdog(time.Second, logger, func(degraded bool, stuck []string) {
+		states = append(states, degraded)
+		if degraded {
+			assert.Equal(t, []string{"heartbeat"}, stuck)
+		}
+	})
+
+	base := time.Now()
+	lastAlive := base
+	w.Watch("heartbeat", 10*time.Second, func() time.Time { return lastAlive }, func() error {
+		atomic.AddInt32(&restarts, 1)
+		return nil
+	})
+	w.Watch("message_loop", 10*time.Second, func() time.Time { return base.Add(time.Hour) }, nil)
+
+	// 未超时
+	w.check(base.Add(5 * time.Second))
+	assert.Empty(t, states)
+
+	// 超时后标记降级并尝试重启
+	w.check(base.Add(20 * time.Second))
+	assert.Equal(t, []bool{true}, states)
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&restarts) == 1 }, time.Second, 10*time.Millisecond)
+
+	// 组件恢复后解除降级
+	lastAlive = base.Add(25 * time.Second)
+	w.check(base.Add(30 * time.Second))
+	assert.Equal(t, []bool{true, false}, states)
+}
+
+func TestWatchdog_DynamicThreshold(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel)
+
+	var degraded bool
+	w := NewWatchdog(time.Second, logger, func(d bool, stuck []string) { degraded = d })
+
+	base := time.Now()
+	threshold := 10 * time.Second
+	w.WatchFunc("heartbeat", func() time.Duration { return threshold }, func() time.Time { return base }, nil)
+
+	w.check(base.Add(20 * time.Second))
+	assert.True(t, degraded)
+
+	// 心跳间隔调大后阈值随之放宽
+	threshold = time.Minute
+	w.check(base.Add(30 * time.Second))
+	assert.False(t, degraded)
+}
+
+func TestAgent_RestartMessageLoopWhileHandling(t *testing.T) {
+	agent, _, _, _, _, _ := createTestAgent(t)
+
+	// 旧循环仍在处理消息时拒绝启动新循环
+	agent.handleMu.Lock()
+	assert.Error(t, agent.restartMessageLoop())
+	agent.handleMu.Unlock()
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"time"
@@ -20,6 +21,7 @@ type MessageHandler struct {
 	metricsCollector core.MetricsCollector
 	logger           *logrus.Logger
 	agentID          string
+	reloads          *core.ReloadCoordinator
 }
 
 // NewMessageHandler 创建消息处理器
@@ -40,9 +42,17 @@ func NewMessageHandler(
 		metricsCollector: metricsCollector,
 		logger:           logger,
 		agentID:          agentID,
+		// 默认不限制重载次数，与Agent共用预算时通过 WithReloadCoordinator 注入
+		reloads: core.NewReloadCoordinator(0, 0, logstashCtrl.Reload, logger),
 	}
 }
 
+// WithReloadCoordinator 使用指定的重载协调器，与Agent其他重载来源共享预算
+func (h *MessageHandler) WithReloadCoordinator(reloads *core.ReloadCoordinator) *MessageHandler {
+	h.reloads = reloads
+	return h
+}
+
 // HandleMessage 处理接收到的消息
 func (h *MessageHandler) HandleMessage(msgType string, payload []byte) error {
 	h.logger.WithFields(logrus.Fields{
@@ -103,8 +113,8 @@ func (h *MessageHandler) handleConfigDeploy(payload []byte) error {
 		"force":     req.Force,
 	}).Info("收到配置部署请求")
 
-	// 获取配置
-	config, err := h.apiClient.GetConfig(nil, req.ConfigID)
+	// 获取配置，按请求的版本获取以支持回滚到旧版本
+	config, err := core.FetchConfigVersion(nil, h.apiClient, req.ConfigID, req.Version)
 	if err != nil {
 		return fmt.Errorf("获取配置失败: %w", err)
 	}
@@ -113,11 +123,19 @@ func (h *MessageHandler) handleConfigDeploy(payload []byte) error {
 	if config.Version != req.Version && !req.Force {
 		return fmt.Errorf("配置版本不匹配: 期望 %d, 实际 %d", req.Version, config.Version)
 	}
+	if err := core.CheckContentHash(config); err != nil {
+		return err
+	}
 
 	// 保存配置
 	if err := h.configManager.SaveConfig(config); err != nil {
 		return fmt.Errorf("保存配置失败: %w", err)
 	}
+	hash, err := core.VerifyConfigFile(h.configManager, config.ID)
+	if err != nil {
+		h.configManager.RestoreConfig(config.ID)
+		return fmt.Errorf("配置落盘校验失败: %w", err)
+	}
 
 	// 验证配置
 	configPath := h.configManager.GetConfigPath(config.ID)
@@ -128,19 +146,24 @@ func (h *MessageHandler) handleConfigDeploy(payload []byte) error {
 	}
 
 	// 重新加载Logstash
+	reloadPending := false
 	if h.logstashCtrl.IsRunning() {
-		if err := h.logstashCtrl.Reload(nil); err != nil {
+		queued, err := h.reloads.Request(context.Background(), core.ReloadSourceDeploy)
+		if err != nil {
 			// 回滚配置
 			h.configManager.RestoreConfig(config.ID)
 			return fmt.Errorf("重载配置失败: %w", err)
 		}
+		reloadPending = queued
 	}
 
-	// 上报配置应用成功
+	// 上报配置应用成功，重载排队时标记为挂起
 	applied := &models.AppliedConfig{
-		ConfigID:  config.ID,
-		Version:   config.Version,
-		AppliedAt: time.Now(),
+		ConfigID:      config.ID,
+		Version:       config.Version,
+		AppliedAt:     time.Now(),
+		ReloadPending: reloadPending,
+		Hash:          hash,
 	}
 	
 	if err := h.apiClient.ReportConfigApplied(nil, h.agentID, applied); err != nil {
@@ -170,7 +193,7 @@ func (h *MessageHandler) handleConfigDelete(payload []byte) error {
 
 	// 重新加载Logstash
 	if h.logstashCtrl.IsRunning() {
-		if err := h.logstashCtrl.Reload(nil); err != nil {
+		if _, err := h.reloads.Request(context.Background(), core.ReloadSourceDelete); err != nil {
 			h.logger.WithError(err).Warn("重载配置失败")
 		}
 	}
@@ -187,9 +210,14 @@ func (h *MessageHandler) handleReloadRequest(payload []byte) error {
 		return fmt.Errorf("Logstash未运行")
 	}
 
-	if err := h.logstashCtrl.Reload(nil); err != nil {
+	queued, err := h.reloads.Request(context.Background(), core.ReloadSourceRequest)
+	if err != nil {
 		return fmt.Errorf("重载失败: %w", err)
 	}
+	if queued {
+		h.logger.Info("重载预算已用尽，重载请求已排队")
+		return nil
+	}
 
 	h.logger.Info("重载成功")
 	return nil
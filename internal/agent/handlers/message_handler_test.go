@@ -10,6 +10,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"logstash-platform/internal/agent/core"
 	"logstash-platform/internal/platform/models"
 )
@@ -287,6 +288,52 @@ func TestMessageHandler_HandleConfigDeploy(t *testing.T) {
 	})
 }
 
+func TestMessageHandler_ReloadBudgetQueuesDeploy(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.WarnLevel)
+
+	apiClient := new(mockAPIClient)
+	configManager := new(mockConfigManager)
+	logstashCtrl := new(mockLogstashController)
+
+	reloads := core.NewReloadCoordinator(1, time.Hour, logstashCtrl.Reload, logger)
+	defer reloads.Stop()
+	handler := NewMessageHandler(
+		new(mockAgentCore),
+		apiClient,
+		configManager,
+		logstashCtrl,
+		new(mockMetricsCollector),
+		logger,
+		"test-agent",
+	).WithReloadCoordinator(reloads)
+
+	testConfig := &models.Config{ID: "budget-config", Content: "input { stdin {} }", Version: 1}
+	payload, _ := json.Marshal(map[string]interface{}{"config_id": testConfig.ID, "version": 1})
+
+	apiClient.On("GetConfig", mock.Anything, testConfig.ID).Return(testConfig, nil)
+	configManager.On("SaveConfig", testConfig).Return(nil)
+	configManager.On("GetConfigPath", testConfig.ID).Return("/tmp/budget-config.conf")
+	logstashCtrl.On("ValidateConfig", "/tmp/budget-config.conf").Return(nil)
+	logstashCtrl.On("IsRunning").Return(true)
+	logstashCtrl.On("Reload", mock.Anything).Return(nil).Once()
+
+	var reported []*models.AppliedConfig
+	apiClient.On("ReportConfigApplied", mock.Anything, "test-agent", mock.Anything).Return(nil).
+		Run(func(args mock.Arguments) {
+			reported = append(reported, args.Get(2).(*models.AppliedConfig))
+		})
+
+	// 第一次部署用掉预算，第二次部署的重载排队且上报为挂起
+	require.NoError(t, handler.HandleMessage(core.MsgTypeConfigDeploy, payload))
+	require.NoError(t, handler.HandleMessage(core.MsgTypeConfigDeploy, payload))
+
+	require.Len(t, reported, 2)
+	assert.False(t, reported[0].ReloadPending)
+	assert.True(t, reported[1].ReloadPending)
+	logstashCtrl.AssertNumberOfCalls(t, "Reload", 1)
+}
+
 func TestMessageHandler_HandleStatusRequest(t *testing.T) {
 	logger := logrus.New()
 	logger.SetLevel(logrus.WarnLevel)
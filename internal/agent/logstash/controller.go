@@ -3,6 +3,7 @@ package logstash
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -18,6 +19,9 @@ import (
 	"logstash-platform/internal/agent/core"
 )
 
+// validationTimeout 单次配置验证的最长执行时间
+const validationTimeout = 2 * time.Minute
+
 // Controller Logstash控制器实现
 type Controller struct {
 	config        *config.AgentConfig
@@ -38,6 +42,12 @@ type Controller struct {
 	// 控制通道
 	stopChan      chan struct{}
 	stoppedChan   chan struct{}
+	
+	// 配置验证结果缓存
+	validationCache *ValidationCache
+	
+	// 进程意外退出时的回调
+	onExit        func(err error)
 }
 
 // NewController 创建Logstash控制器
@@ -52,6 +62,7 @@ func NewController(cfg *config.AgentConfig, logger *logrus.Logger) core.Logstash
 		status: &core.LogstashStatus{
 			Running: false,
 		},
+		validationCache: NewValidationCache(cfg.ValidationCacheTTL, cfg.ValidationCacheSize),
 	}
 }
 
@@ -252,6 +263,13 @@ func (c *Controller) GetStatus() (*core.LogstashStatus, error) {
 
 // ValidateConfig 验证配置文件
 func (c *Controller) ValidateConfig(configPath string) error {
+	_, err := c.runValidation(configPath)
+	return err
+}
+
+// runValidation 执行 --config.test_and_exit，definitive表示结果是否为确定的语法结论
+// 进程无法启动或超时等执行层面的失败不是确定结论，不应被缓存
+func (c *Controller) runValidation(configPath string) (definitive bool, err error) {
 	c.logger.WithField("path", configPath).Info("验证配置文件")
 	
 	// 构建验证命令
@@ -259,23 +277,68 @@ func (c *Controller) ValidateConfig(configPath string) error {
 		"--config.test_and_exit",
 		"--path.config", configPath,
 	}
+	if c.config.LogstashSettingsDir != "" {
+		args = append(args, "--path.settings", c.config.LogstashSettingsDir)
+	}
 	
 	// 执行验证
-	cmd := exec.Command(c.config.LogstashPath, args...)
+	ctx, cancel := context.WithTimeout(context.Background(), validationTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, c.config.LogstashPath, args...)
 	output, err := cmd.CombinedOutput()
 	
+	if ctx.Err() == context.DeadlineExceeded {
+		return false, fmt.Errorf("配置验证超时(%s)", validationTimeout)
+	}
+	
 	if err != nil {
-		return fmt.Errorf("配置验证失败: %s\n%s", err, string(output))
+		var exitErr *exec.ExitError
+		// 进程正常运行并以非零码退出才是确定的验证失败
+		return errors.As(err, &exitErr), fmt.Errorf("配置验证失败: %s\n%s", err, string(output))
 	}
 	
 	// 检查输出中是否包含错误
 	outputStr := string(output)
 	if strings.Contains(outputStr, "ERROR") || strings.Contains(outputStr, "error") {
-		return fmt.Errorf("配置包含错误:\n%s", outputStr)
+		return true, fmt.Errorf("配置包含错误:\n%s", outputStr)
 	}
 	
 	c.logger.Info("配置验证通过")
-	return nil
+	return true, nil
+}
+
+// ValidateConfigCached 验证配置文件，按内容哈希和Logstash版本缓存验证结果
+func (c *Controller) ValidateConfigCached(configPath string) (bool, error) {
+	digest, err := hashConfigPath(configPath)
+	if err != nil {
+		// 无法计算哈希时退化为直接验证
+		c.logger.WithError(err).Warn("计算配置哈希失败，跳过验证缓存")
+		return false, c.ValidateConfig(configPath)
+	}
+	
+	c.statusMutex.RLock()
+	version := c.status.Version
+	c.statusMutex.RUnlock()
+	
+	if result, ok := c.validationCache.Get(digest, version); ok {
+		c.logger.WithFields(logrus.Fields{
+			"path": configPath,
+			"hash": digest,
+		}).Info("命中配置验证缓存")
+		return true, result
+	}
+	
+	definitive, err := c.runValidation(configPath)
+	if definitive {
+		c.validationCache.Put(digest, version, err)
+	}
+	return false, err
+}
+
+// InvalidateValidationCache 清空验证结果缓存，例如插件变更后
+func (c *Controller) InvalidateValidationCache() {
+	c.validationCache.Clear()
+	c.logger.Info("配置验证缓存已清空")
 }
 
 // 内部方法
@@ -283,11 +346,20 @@ func (c *Controller) ValidateConfig(configPath string) error {
 // buildArgs 构建命令行参数
 func (c *Controller) buildArgs() []string {
 	args := []string{
-		"--path.config", c.config.ConfigDir,
 		"--path.data", c.config.DataDir,
 		"--path.logs", c.config.LogDir,
 	}
 	
+	// 独立管道模式下由设置目录中的pipelines.yml定义管道，指定--path.config会使Logstash忽略pipelines.yml
+	if !c.config.IsolatedPipelines() {
+		args = append([]string{"--path.config", c.config.ConfigDir}, args...)
+	}
+	
+	// keystore和pipelines.yml所在的设置目录
+	if c.config.LogstashSettingsDir != "" {
+		args = append(args, "--path.settings", c.config.LogstashSettingsDir)
+	}
+	
 	// 设置工作线程数
 	if c.config.PipelineWorkers > 0 {
 		args = append(args, "--pipeline.workers", fmt.Sprintf("%d", c.config.PipelineWorkers))
@@ -355,6 +427,7 @@ func (c *Controller) processLogs() {
 func (c *Controller) waitForExit() {
 	c.cmdMutex.Lock()
 	cmd := c.cmd
+	onExit := c.onExit
 	c.cmdMutex.Unlock()
 	
 	if cmd == nil {
@@ -371,6 +444,23 @@ func (c *Controller) waitForExit() {
 		s.Running = false
 		s.PID = 0
 	})
+	
+	// 未经Stop退出视为崩溃
+	select {
+	case <-c.stopChan:
+		return
+	default:
+	}
+	if onExit != nil {
+		onExit(err)
+	}
+}
+
+// OnUnexpectedExit 设置进程未经Stop而退出时的回调
+func (c *Controller) OnUnexpectedExit(fn func(err error)) {
+	c.cmdMutex.Lock()
+	defer c.cmdMutex.Unlock()
+	c.onExit = fn
 }
 
 // waitForStartup 等待启动完成
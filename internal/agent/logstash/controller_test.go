@@ -47,8 +47,18 @@ func TestNewController(t *testing.T) {
 }
 
 func TestController_BuildArgs(t *testing.T) {
-	// 不能直接访问私有方法，跳过此测试
-	t.Skip("buildArgs is a private method")
+	controller := createTestController(t).(*Controller)
+	args := controller.buildArgs()
+	assert.Contains(t, args, "--path.config")
+	assert.Contains(t, args, "--pipeline.workers")
+
+	// 独立管道模式下由pipelines.yml定义管道
+	controller.config.PipelineMode = config.PipelineModeIsolated
+	controller.config.LogstashSettingsDir = "/etc/logstash"
+	args = controller.buildArgs()
+	assert.NotContains(t, args, "--path.config")
+	assert.Contains(t, args, "--path.settings")
+	assert.Contains(t, args, "/etc/logstash")
 }
 
 func TestController_IsRunning(t *testing.T) {
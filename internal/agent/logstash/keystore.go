@@ -0,0 +1,73 @@
+package logstash
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// keystoreTimeout 单次keystore命令的最长执行时间，keystore工具需要启动JVM
+const keystoreTimeout = time.Minute
+
+// keystorePath keystore工具路径，未配置时取Logstash可执行文件同目录下的 logstash-keystore
+func (c *Controller) keystorePath() string {
+	if c.config.LogstashKeystorePath != "" {
+		return c.config.LogstashKeystorePath
+	}
+	return filepath.Join(filepath.Dir(c.config.LogstashPath), "logstash-keystore")
+}
+
+// AddKeystoreSecrets 将密钥写入Logstash keystore，已存在的同名键被覆盖
+// 配置中以 ${名称} 引用，Logstash启动或重载时从keystore读取，密钥值不落入配置文件
+func (c *Controller) AddKeystoreSecrets(ctx context.Context, secrets map[string]string) error {
+	if len(secrets) == 0 {
+		return nil
+	}
+
+	if c.config.LogstashSettingsDir != "" {
+		keystoreFile := filepath.Join(c.config.LogstashSettingsDir, "logstash.keystore")
+		if _, err := os.Stat(keystoreFile); os.IsNotExist(err) {
+			if err := c.runKeystore(ctx, "", "create"); err != nil {
+				return fmt.Errorf("创建keystore失败: %w", err)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(secrets))
+	for name := range secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		// keystore的add不支持覆盖，先删除旧值，键不存在时删除失败可以忽略
+		c.runKeystore(ctx, "", "remove", name)
+		if err := c.runKeystore(ctx, secrets[name], "add", name); err != nil {
+			return fmt.Errorf("写入keystore密钥 %s 失败: %w", name, err)
+		}
+	}
+
+	c.logger.WithField("secrets", names).Info("已将密钥写入Logstash keystore")
+	return nil
+}
+
+// runKeystore 执行keystore命令，stdin为写入的密钥值
+func (c *Controller) runKeystore(ctx context.Context, stdin string, args ...string) error {
+	ctx, cancel := context.WithTimeout(ctx, keystoreTimeout)
+	defer cancel()
+
+	if c.config.LogstashSettingsDir != "" {
+		args = append([]string{"--path.settings", c.config.LogstashSettingsDir}, args...)
+	}
+	cmd := exec.CommandContext(ctx, c.keystorePath(), args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package logstash
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/agent/config"
+)
+
+func TestController_AddKeystoreSecrets(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "calls.log")
+	// 模拟 logstash-keystore：记录参数和标准输入，remove 一个不存在的键时失败
+	script := `#!/bin/sh
+echo "$@ stdin=$(cat)" >> ` + logPath + `
+case "$*" in *"remove new_key"*) exit 1;; esac
+`
+	keystore := filepath.Join(dir, "logstash-keystore")
+	require.NoError(t, os.WriteFile(keystore, []byte(script), 0755))
+
+	cfg := &config.AgentConfig{
+		LogstashPath:         filepath.Join(dir, "logstash"),
+		LogstashKeystorePath: keystore,
+		LogstashSettingsDir:  dir,
+	}
+	controller := NewController(cfg, logrus.New()).(*Controller)
+
+	require.NoError(t, controller.AddKeystoreSecrets(context.Background(), map[string]string{
+		"new_key":     "v1",
+		"es_password": "changeme",
+	}))
+
+	data, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"--path.settings " + dir + " create stdin=",
+		"--path.settings " + dir + " remove es_password stdin=",
+		"--path.settings " + dir + " add es_password stdin=changeme",
+		"--path.settings " + dir + " remove new_key stdin=",
+		"--path.settings " + dir + " add new_key stdin=v1",
+	}, strings.Split(strings.TrimSpace(string(data)), "\n"))
+}
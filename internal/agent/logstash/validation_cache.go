@@ -0,0 +1,151 @@
+package logstash
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// validationEntry 缓存的验证结果
+type validationEntry struct {
+	errMsg    string // 为空表示验证通过
+	createdAt time.Time
+}
+
+// ValidationCache 配置验证结果缓存
+// 以配置内容哈希和Logstash版本为键，避免相同内容重复执行耗时的 --config.test_and_exit
+type ValidationCache struct {
+	mu      sync.Mutex
+	entries map[string]validationEntry
+	ttl     time.Duration
+	maxSize int
+}
+
+// NewValidationCache 创建验证结果缓存，ttl<=0时不缓存
+func NewValidationCache(ttl time.Duration, maxSize int) *ValidationCache {
+	if maxSize <= 0 {
+		maxSize = 256
+	}
+	return &ValidationCache{
+		entries: make(map[string]validationEntry),
+		ttl:     ttl,
+		maxSize: maxSize,
+	}
+}
+
+// Get 查询缓存的验证结果，返回的error为缓存的验证错误
+func (c *ValidationCache) Get(digest, version string) (error, bool) {
+	if c.ttl <= 0 {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := cacheKey(digest, version)
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Since(entry.createdAt) > c.ttl {
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	if entry.errMsg != "" {
+		return errors.New(entry.errMsg), true
+	}
+	return nil, true
+}
+
+// Put 缓存验证结果
+func (c *ValidationCache) Put(digest, version string, result error) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= c.maxSize {
+		c.evictOldest()
+	}
+
+	entry := validationEntry{createdAt: time.Now()}
+	if result != nil {
+		entry.errMsg = result.Error()
+	}
+	c.entries[cacheKey(digest, version)] = entry
+}
+
+// Clear 清空缓存
+func (c *ValidationCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]validationEntry)
+}
+
+// Len 返回缓存条数
+func (c *ValidationCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// evictOldest 淘汰最早写入的缓存项，调用方需持有锁
+func (c *ValidationCache) evictOldest() {
+	var oldestKey string
+	var oldest time.Time
+	for key, entry := range c.entries {
+		if oldestKey == "" || entry.createdAt.Before(oldest) {
+			oldestKey = key
+			oldest = entry.createdAt
+		}
+	}
+	delete(c.entries, oldestKey)
+}
+
+// cacheKey 生成缓存键
+func cacheKey(digest, version string) string {
+	if version == "" {
+		version = "unknown"
+	}
+	return digest + "@" + version
+}
+
+// hashConfigPath 计算配置文件（或目录下全部配置文件）内容的SHA-256
+func hashConfigPath(configPath string) (string, error) {
+	info, err := os.Stat(configPath)
+	if err != nil {
+		return "", err
+	}
+
+	files := []string{configPath}
+	if info.IsDir() {
+		matches, err := filepath.Glob(filepath.Join(configPath, "*.conf"))
+		if err != nil {
+			return "", err
+		}
+		sort.Strings(matches)
+		files = matches
+	}
+
+	h := sha256.New()
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		h.Write([]byte(filepath.Base(file)))
+		h.Write([]byte{0})
+		h.Write(data)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,125 @@
+package logstash
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/agent/config"
+)
+
+func TestValidationCache(t *testing.T) {
+	cache := NewValidationCache(time.Hour, 2)
+
+	_, ok := cache.Get("abc", "8.11.0")
+	assert.False(t, ok)
+
+	cache.Put("abc", "8.11.0", nil)
+	cache.Put("def", "8.11.0", errors.New("配置验证失败"))
+
+	result, ok := cache.Get("abc", "8.11.0")
+	assert.True(t, ok)
+	assert.NoError(t, result)
+
+	result, ok = cache.Get("def", "8.11.0")
+	assert.True(t, ok)
+	assert.EqualError(t, result, "配置验证失败")
+
+	// Logstash版本变化后缓存不再命中
+	_, ok = cache.Get("abc", "8.12.0")
+	assert.False(t, ok)
+
+	// 超出上限时淘汰最早的缓存项
+	cache.Put("ghi", "8.11.0", nil)
+	assert.Equal(t, 2, cache.Len())
+	_, ok = cache.Get("abc", "8.11.0")
+	assert.False(t, ok)
+
+	cache.Clear()
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestValidationCache_Disabled(t *testing.T) {
+	cache := NewValidationCache(0, 10)
+	cache.Put("abc", "", nil)
+
+	_, ok := cache.Get("abc", "")
+	assert.False(t, ok)
+}
+
+func TestController_ValidateConfigCached(t *testing.T) {
+	dir := t.TempDir()
+	counter := filepath.Join(dir, "count")
+
+	// 用脚本模拟logstash，每次调用记录一次
+	script := filepath.Join(dir, "logstash")
+	require.NoError(t, ioutil.WriteFile(script, []byte("#!/bin/sh\necho run >> "+counter+"\necho Configuration OK\n"), 0755))
+
+	configPath := filepath.Join(dir, "test.conf")
+	require.NoError(t, ioutil.WriteFile(configPath, []byte("input { stdin {} }"), 0644))
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.WarnLevel)
+	cfg := config.DefaultConfig()
+	cfg.LogstashPath = script
+	ctrl := NewController(cfg, logger).(*Controller)
+
+	cached, err := ctrl.ValidateConfigCached(configPath)
+	assert.NoError(t, err)
+	assert.False(t, cached)
+
+	cached, err = ctrl.ValidateConfigCached(configPath)
+	assert.NoError(t, err)
+	assert.True(t, cached)
+
+	// 内容变化后重新验证
+	require.NoError(t, ioutil.WriteFile(configPath, []byte("input { stdin {} }\n# changed"), 0644))
+	cached, err = ctrl.ValidateConfigCached(configPath)
+	assert.NoError(t, err)
+	assert.False(t, cached)
+
+	ctrl.InvalidateValidationCache()
+	cached, _ = ctrl.ValidateConfigCached(configPath)
+	assert.False(t, cached)
+
+	data, err := os.ReadFile(counter)
+	require.NoError(t, err)
+	assert.Equal(t, 3, strings.Count(string(data), "run"))
+}
+
+func TestController_ValidateConfigCached_SkipsIndeterminate(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "test.conf")
+	require.NoError(t, ioutil.WriteFile(configPath, []byte("input { stdin {} }"), 0644))
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.WarnLevel)
+	cfg := config.DefaultConfig()
+	cfg.LogstashPath = filepath.Join(dir, "missing-logstash")
+	ctrl := NewController(cfg, logger).(*Controller)
+
+	// Logstash无法启动时不是确定的验证结论，不写入缓存
+	_, err := ctrl.ValidateConfigCached(configPath)
+	assert.Error(t, err)
+	assert.Equal(t, 0, ctrl.validationCache.Len())
+
+	// 进程正常退出的语法错误会被缓存
+	script := filepath.Join(dir, "logstash")
+	require.NoError(t, ioutil.WriteFile(script, []byte("#!/bin/sh\necho 'Expected one of #'\nexit 1\n"), 0755))
+	ctrl.config.LogstashPath = script
+
+	cached, err := ctrl.ValidateConfigCached(configPath)
+	assert.Error(t, err)
+	assert.False(t, cached)
+
+	cached, err = ctrl.ValidateConfigCached(configPath)
+	assert.Error(t, err)
+	assert.True(t, cached)
+}
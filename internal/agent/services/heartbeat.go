@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -23,6 +24,9 @@ type HeartbeatService struct {
 	running   bool
 	mu        sync.Mutex
 	
+	// 间隔变更通知，用于运行中重置定时器
+	intervalChanged chan struct{}
+	
 	// 回调
 	onSuccess func()
 	onFailure func(error)
@@ -32,6 +36,9 @@ type HeartbeatService struct {
 	failureCount int64
 	lastSuccess  time.Time
 	lastFailure  time.Time
+	
+	// 心跳循环最近一次运转的时间（UnixNano），供看门狗检测循环是否卡住
+	lastTick     int64
 }
 
 // NewHeartbeatService 创建心跳服务
@@ -41,6 +48,7 @@ func NewHeartbeatService(agentID string, apiClient core.APIClient, logger *logru
 		apiClient: apiClient,
 		logger:    logger,
 		interval:  30 * time.Second, // 默认30秒
+		intervalChanged: make(chan struct{}, 1),
 	}
 }
 
@@ -70,9 +78,9 @@ func (h *HeartbeatService) Start(ctx context.Context) error {
 // Stop 停止心跳服务
 func (h *HeartbeatService) Stop() error {
 	h.mu.Lock()
-	defer h.mu.Unlock()
 	
 	if !h.running {
+		h.mu.Unlock()
 		return nil
 	}
 	
@@ -83,10 +91,13 @@ func (h *HeartbeatService) Stop() error {
 	if h.cancel != nil {
 		h.cancel()
 	}
+	h.mu.Unlock()
 	
-	// 等待goroutine结束
+	// 等待goroutine结束，心跳循环读取间隔时需要获取锁，因此释放锁后再等待
 	h.wg.Wait()
 	
+	h.mu.Lock()
+	defer h.mu.Unlock()
 	h.logger.WithFields(logrus.Fields{
 		"success_count": h.successCount,
 		"failure_count": h.failureCount,
@@ -107,6 +118,11 @@ func (h *HeartbeatService) SetInterval(interval time.Duration) {
 	
 	h.interval = interval
 	h.logger.WithField("interval", interval).Info("心跳间隔已更新")
+	
+	select {
+	case h.intervalChanged <- struct{}{}:
+	default:
+	}
 }
 
 // SetCallbacks 设置回调函数
@@ -130,15 +146,20 @@ func (h *HeartbeatService) GetStats() (successCount, failureCount int64, lastSuc
 func (h *HeartbeatService) heartbeatLoop() {
 	defer h.wg.Done()
 	
-	ticker := time.NewTicker(h.interval)
+	ticker := time.NewTicker(h.GetInterval())
 	defer ticker.Stop()
 	
+	h.touch()
 	for {
 		select {
 		case <-h.ctx.Done():
 			return
 			
+		case <-h.intervalChanged:
+			ticker.Reset(h.GetInterval())
+			
 		case <-ticker.C:
+			h.touch()
 			h.sendHeartbeat()
 		}
 	}
@@ -206,6 +227,17 @@ func (h *HeartbeatService) IsHealthy() bool {
 	return false
 }
 
+// LastAlive 返回心跳循环最近一次运转的时间
+// 使用原子操作，Stop持有锁等待循环退出时循环仍可打点
+func (h *HeartbeatService) LastAlive() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&h.lastTick))
+}
+
+// touch 记录心跳循环运转
+func (h *HeartbeatService) touch() {
+	atomic.StoreInt64(&h.lastTick, time.Now().UnixNano())
+}
+
 // GetInterval 获取当前心跳间隔
 func (h *HeartbeatService) GetInterval() time.Duration {
 	h.mu.Lock()
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"logstash-platform/internal/platform/models"
+)
+
+// DefaultLogstashAPIURL Logstash监控API默认地址
+const DefaultLogstashAPIURL = "http://localhost:9600"
+
+// nodeStatsResponse _node/stats 响应中用到的部分
+type nodeStatsResponse struct {
+	JVM struct {
+		Mem struct {
+			HeapUsedInBytes int64   `json:"heap_used_in_bytes"`
+			HeapMaxInBytes  int64   `json:"heap_max_in_bytes"`
+			HeapUsedPercent float64 `json:"heap_used_percent"`
+		} `json:"mem"`
+	} `json:"jvm"`
+	Pipelines map[string]nodePipelineStats `json:"pipelines"`
+}
+
+// nodeEventStats 管道或插件的事件计数
+type nodeEventStats struct {
+	In                        int64 `json:"in"`
+	Filtered                  int64 `json:"filtered"`
+	Out                       int64 `json:"out"`
+	DurationInMillis          int64 `json:"duration_in_millis"`
+	QueuePushDurationInMillis int64 `json:"queue_push_duration_in_millis"`
+}
+
+// nodePipelineStats 单个管道的统计
+type nodePipelineStats struct {
+	Events  nodeEventStats `json:"events"`
+	Plugins struct {
+		Inputs  []nodePluginStats `json:"inputs"`
+		Filters []nodePluginStats `json:"filters"`
+		Outputs []nodePluginStats `json:"outputs"`
+	} `json:"plugins"`
+	Queue struct {
+		Type             string `json:"type"`
+		EventsCount      int64  `json:"events_count"`
+		QueueSizeInBytes int64  `json:"queue_size_in_bytes"`
+	} `json:"queue"`
+	// 管道未启用死信队列时监控API不返回该字段
+	DeadLetterQueue *struct {
+		QueueSizeInBytes int64 `json:"queue_size_in_bytes"`
+		DroppedEvents    int64 `json:"dropped_events"`
+		ExpiredEvents    int64 `json:"expired_events"`
+	} `json:"dead_letter_queue"`
+}
+
+// nodePluginStats 单个插件的统计，failures 由grok、date等过滤器报告，documents 由elasticsearch输出报告
+type nodePluginStats struct {
+	ID        string         `json:"id"`
+	Name      string         `json:"name"`
+	Events    nodeEventStats `json:"events"`
+	Failures  int64          `json:"failures"`
+	Documents struct {
+		NonRetryableFailures int64 `json:"non_retryable_failures"`
+		DLQRouted            int64 `json:"dlq_routed"` // 写入死信队列的文档数
+	} `json:"documents"`
+}
+
+// logstashStatsScraper 采集Logstash监控API统计，保留上一次的计数用于计算速率
+type logstashStatsScraper struct {
+	url    string
+	client *http.Client
+
+	mu     sync.Mutex
+	last   map[string]models.PipelineStats
+	lastAt time.Time
+}
+
+// newLogstashStatsScraper 创建监控API采集器
+func newLogstashStatsScraper(url string) *logstashStatsScraper {
+	return &logstashStatsScraper{
+		url:    strings.TrimRight(url, "/"),
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// scrape 请求 _node/stats 并转换为上报的统计
+func (s *logstashStatsScraper) scrape(ctx context.Context) (*models.LogstashStats, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url+"/_node/stats", nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建监控API请求失败: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求Logstash监控API失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Logstash监控API返回状态码 %d", resp.StatusCode)
+	}
+
+	var node nodeStatsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&node); err != nil {
+		return nil, fmt.Errorf("解析Logstash监控API响应失败: %w", err)
+	}
+
+	return s.convert(&node, time.Now()), nil
+}
+
+// convert 转换监控API响应，按与上一次采集的计数差计算速率
+func (s *logstashStatsScraper) convert(node *nodeStatsResponse, now time.Time) *models.LogstashStats {
+	stats := &models.LogstashStats{
+		JVMHeapUsedMB:      float64(node.JVM.Mem.HeapUsedInBytes) / (1 << 20),
+		JVMHeapMaxMB:       float64(node.JVM.Mem.HeapMaxInBytes) / (1 << 20),
+		JVMHeapUsedPercent: node.JVM.Mem.HeapUsedPercent,
+		Pipelines:          make([]models.PipelineStats, 0, len(node.Pipelines)),
+	}
+
+	ids := make([]string, 0, len(node.Pipelines))
+	for id := range node.Pipelines {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elapsed := now.Sub(s.lastAt).Seconds()
+	current := make(map[string]models.PipelineStats, len(ids))
+	for _, id := range ids {
+		p := node.Pipelines[id]
+		pipeline := models.PipelineStats{
+			ID:             id,
+			EventsIn:       p.Events.In,
+			EventsFiltered: p.Events.Filtered,
+			EventsOut:      p.Events.Out,
+			DurationMillis: p.Events.DurationInMillis,
+			QueueType:      p.Queue.Type,
+			QueueEvents:    p.Queue.EventsCount,
+			QueueSizeBytes: p.Queue.QueueSizeInBytes,
+			// 监控API没有单独的队列出入计数：输入插件写入队列即计入in，工作线程取出的批次过滤完成后计入filtered
+			QueuePushed: p.Events.In,
+			QueuePopped: p.Events.Filtered,
+			Plugins:     make([]models.PluginStats, 0, len(p.Plugins.Inputs)+len(p.Plugins.Filters)+len(p.Plugins.Outputs)),
+		}
+		for _, plugin := range p.Plugins.Inputs {
+			pipeline.Plugins = append(pipeline.Plugins, pluginStats(plugin, models.PluginTypeInput))
+		}
+		for _, plugin := range p.Plugins.Filters {
+			pipeline.Plugins = append(pipeline.Plugins, pluginStats(plugin, models.PluginTypeFilter))
+		}
+		for _, plugin := range p.Plugins.Outputs {
+			pipeline.Plugins = append(pipeline.Plugins, pluginStats(plugin, models.PluginTypeOutput))
+		}
+		if dlq := p.DeadLetterQueue; dlq != nil {
+			pipeline.DeadLetter = &models.DeadLetterStats{
+				SizeBytes:     dlq.QueueSizeInBytes,
+				DroppedEvents: dlq.DroppedEvents,
+				ExpiredEvents: dlq.ExpiredEvents,
+			}
+			for _, plugin := range p.Plugins.Outputs {
+				pipeline.DeadLetter.Events += plugin.Documents.DLQRouted
+			}
+		}
+
+		// 计数回退说明Logstash重启或管道重建，本次不计算速率
+		if last, ok := s.last[id]; ok && elapsed > 0 && pipeline.EventsIn >= last.EventsIn && pipeline.EventsOut >= last.EventsOut {
+			pipeline.EventsInRate = float64(pipeline.EventsIn-last.EventsIn) / elapsed
+			pipeline.EventsOutRate = float64(pipeline.EventsOut-last.EventsOut) / elapsed
+		}
+
+		stats.EventsInRate += pipeline.EventsInRate
+		stats.EventsOutRate += pipeline.EventsOutRate
+		stats.Pipelines = append(stats.Pipelines, pipeline)
+		current[id] = pipeline
+	}
+
+	s.last = current
+	s.lastAt = now
+	return stats
+}
+
+// pluginStats 转换单个插件的统计
+func pluginStats(plugin nodePluginStats, pluginType string) models.PluginStats {
+	stats := models.PluginStats{
+		ID:             plugin.ID,
+		Name:           plugin.Name,
+		Type:           pluginType,
+		EventsIn:       plugin.Events.In,
+		EventsOut:      plugin.Events.Out,
+		DurationMillis: plugin.Events.DurationInMillis,
+		Failures:       plugin.Failures + plugin.Documents.NonRetryableFailures,
+	}
+	if pluginType == models.PluginTypeInput {
+		stats.DurationMillis = plugin.Events.QueuePushDurationInMillis
+	}
+	return stats
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/agent/core"
+	"logstash-platform/internal/platform/models"
+)
+
+// nodeStatsBody 模拟 _node/stats 响应，事件计数由参数给出
+func nodeStatsBody(in, out int64) string {
+	return fmt.Sprintf(`{
+		"jvm": {"mem": {"heap_used_in_bytes": 268435456, "heap_max_in_bytes": 1073741824, "heap_used_percent": 25}},
+		"pipelines": {
+			"main": {
+				"events": {"in": %d, "filtered": %d, "out": %d, "duration_in_millis": 1200},
+				"plugins": {
+					"inputs": [{"id": "beats-in", "name": "beats", "events": {"out": %d, "queue_push_duration_in_millis": 30}}],
+					"filters": [{"id": "grok-1", "name": "grok", "events": {"in": %d, "out": %d, "duration_in_millis": 800}, "failures": 2}],
+					"outputs": [{"id": "es-out", "name": "elasticsearch", "events": {"in": %d, "out": %d, "duration_in_millis": 400}, "documents": {"non_retryable_failures": 3}}]
+				},
+				"queue": {"type": "persisted", "events_count": 42, "queue_size_in_bytes": 4096}
+			}
+		}
+	}`, in, out, out, in, in, out, out, out)
+}
+
+func TestLogstashStatsScraper_Scrape(t *testing.T) {
+	var out atomic.Int64
+	out.Store(100)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/_node/stats", r.URL.Path)
+		w.Write([]byte(nodeStatsBody(out.Load(), out.Load())))
+	}))
+	defer server.Close()
+
+	scraper := newLogstashStatsScraper(server.URL + "/")
+
+	stats, err := scraper.scrape(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 256.0, stats.JVMHeapUsedMB)
+	assert.Equal(t, 1024.0, stats.JVMHeapMaxMB)
+	assert.Equal(t, 25.0, stats.JVMHeapUsedPercent)
+	assert.Zero(t, stats.EventsInRate) // 首次采集没有速率
+
+	require.Len(t, stats.Pipelines, 1)
+	pipeline := stats.Pipelines[0]
+	assert.Equal(t, "main", pipeline.ID)
+	assert.EqualValues(t, 100, pipeline.EventsOut)
+	assert.Equal(t, "persisted", pipeline.QueueType)
+	assert.EqualValues(t, 42, pipeline.QueueEvents)
+	assert.EqualValues(t, 4096, pipeline.QueueSizeBytes)
+	assert.EqualValues(t, 100, pipeline.QueuePushed)
+	assert.EqualValues(t, 100, pipeline.QueuePopped)
+	assert.Nil(t, pipeline.DeadLetter) // 未启用死信队列
+
+	require.Len(t, pipeline.Plugins, 3)
+	assert.Equal(t, models.PluginStats{ID: "beats-in", Name: "beats", Type: models.PluginTypeInput, EventsOut: 100, DurationMillis: 30}, pipeline.Plugins[0])
+	assert.Equal(t, models.PluginTypeFilter, pipeline.Plugins[1].Type)
+	assert.EqualValues(t, 800, pipeline.Plugins[1].DurationMillis)
+	assert.EqualValues(t, 2, pipeline.Plugins[1].Failures)
+	assert.EqualValues(t, 3, pipeline.Plugins[2].Failures)
+
+	t.Run("按计数差计算速率", func(t *testing.T) {
+		scraper.lastAt = time.Now().Add(-10 * time.Second)
+		out.Store(600)
+
+		stats, err := scraper.scrape(context.Background())
+		require.NoError(t, err)
+		assert.InDelta(t, 50, stats.Pipelines[0].EventsOutRate, 1)
+		assert.InDelta(t, 50, stats.EventsInRate, 1)
+	})
+
+	t.Run("计数回退时不计算速率", func(t *testing.T) {
+		scraper.lastAt = time.Now().Add(-10 * time.Second)
+		out.Store(10)
+
+		stats, err := scraper.scrape(context.Background())
+		require.NoError(t, err)
+		assert.Zero(t, stats.Pipelines[0].EventsOutRate)
+	})
+}
+
+func TestLogstashStatsScraper_DeadLetterQueue(t *testing.T) {
+	node := &nodeStatsResponse{Pipelines: map[string]nodePipelineStats{}}
+	var p nodePipelineStats
+	p.Events = nodeEventStats{In: 500, Filtered: 480, Out: 470}
+	p.DeadLetterQueue = &struct {
+		QueueSizeInBytes int64 `json:"queue_size_in_bytes"`
+		DroppedEvents    int64 `json:"dropped_events"`
+		ExpiredEvents    int64 `json:"expired_events"`
+	}{QueueSizeInBytes: 2048, DroppedEvents: 1}
+	es := nodePluginStats{ID: "es-out", Name: "elasticsearch"}
+	es.Documents.DLQRouted = 7
+	p.Plugins.Outputs = []nodePluginStats{es, {ID: "stdout-out", Name: "stdout"}}
+	node.Pipelines["beats"] = p
+
+	stats := newLogstashStatsScraper(DefaultLogstashAPIURL).convert(node, time.Now())
+	require.Len(t, stats.Pipelines, 1)
+	pipeline := stats.Pipelines[0]
+	assert.EqualValues(t, 500, pipeline.QueuePushed)
+	assert.EqualValues(t, 480, pipeline.QueuePopped)
+	assert.Equal(t, &models.DeadLetterStats{Events: 7, SizeBytes: 2048, DroppedEvents: 1}, pipeline.DeadLetter)
+}
+
+func TestLogstashStatsScraper_Unavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	_, err := newLogstashStatsScraper(server.URL).scrape(context.Background())
+	assert.ErrorContains(t, err, "503")
+}
+
+func TestMetricsCollector_LogstashEventStats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(nodeStatsBody(120, 100)))
+	}))
+	defer server.Close()
+
+	collector, _, _ := createTestMetricsCollector(t)
+	collector.SetLogstashAPIURL(server.URL)
+
+	metrics := &core.AgentMetrics{}
+	collector.getLogstashEventStats(metrics)
+
+	assert.EqualValues(t, 120, metrics.EventsReceived)
+	assert.EqualValues(t, 100, metrics.EventsSent)
+	assert.EqualValues(t, 3, metrics.EventsFailed) // 只统计输出插件的失败
+	require.NotNil(t, metrics.Logstash)
+	assert.Len(t, metrics.Logstash.Pipelines, 1)
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/shirou/gopsutil/v3/process"
 	"github.com/sirupsen/logrus"
 	"logstash-platform/internal/agent/core"
+	"logstash-platform/internal/platform/models"
 )
 
 // MetricsCollector 指标收集器实现
@@ -29,6 +30,9 @@ type MetricsCollector struct {
 	running         bool
 	mu              sync.Mutex
 	
+	// 间隔变更通知，用于运行中重置定时器
+	intervalChanged chan struct{}
+	
 	// 缓存的指标
 	lastMetrics     *core.AgentMetrics
 	lastMetricsMu   sync.RWMutex
@@ -36,6 +40,9 @@ type MetricsCollector struct {
 	// Logstash进程
 	logstashProcess *process.Process
 	
+	// Logstash监控API
+	statsScraper    *logstashStatsScraper
+	
 	// 统计
 	collectCount    int64
 	reportCount     int64
@@ -52,9 +59,19 @@ func NewMetricsCollector(agentID string, apiClient core.APIClient, logstashCtrl
 		logger:       logger,
 		interval:     60 * time.Second, // 默认60秒
 		startTime:    time.Now(),
+		intervalChanged: make(chan struct{}, 1),
+		statsScraper: newLogstashStatsScraper(DefaultLogstashAPIURL),
 	}
 }
 
+// SetLogstashAPIURL 设置Logstash监控API地址，需在Start之前调用
+func (m *MetricsCollector) SetLogstashAPIURL(url string) {
+	if url == "" {
+		return
+	}
+	m.statsScraper = newLogstashStatsScraper(url)
+}
+
 // Start 启动指标收集
 func (m *MetricsCollector) Start(ctx context.Context) error {
 	m.mu.Lock()
@@ -81,9 +98,9 @@ func (m *MetricsCollector) Start(ctx context.Context) error {
 // Stop 停止指标收集
 func (m *MetricsCollector) Stop() error {
 	m.mu.Lock()
-	defer m.mu.Unlock()
 	
 	if !m.running {
+		m.mu.Unlock()
 		return nil
 	}
 	
@@ -94,10 +111,13 @@ func (m *MetricsCollector) Stop() error {
 	if m.cancel != nil {
 		m.cancel()
 	}
+	m.mu.Unlock()
 	
-	// 等待goroutine结束
+	// 等待goroutine结束，收集循环读取间隔时需要获取锁，因此释放锁后再等待
 	m.wg.Wait()
 	
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.logger.WithFields(logrus.Fields{
 		"collect_count": m.collectCount,
 		"report_count":  m.reportCount,
@@ -134,13 +154,18 @@ func (m *MetricsCollector) SetInterval(interval time.Duration) {
 	
 	m.interval = interval
 	m.logger.WithField("interval", interval).Info("指标收集间隔已更新")
+	
+	select {
+	case m.intervalChanged <- struct{}{}:
+	default:
+	}
 }
 
 // collectLoop 收集循环
 func (m *MetricsCollector) collectLoop() {
 	defer m.wg.Done()
 	
-	ticker := time.NewTicker(m.interval)
+	ticker := time.NewTicker(m.currentInterval())
 	defer ticker.Stop()
 	
 	for {
@@ -148,12 +173,22 @@ func (m *MetricsCollector) collectLoop() {
 		case <-m.ctx.Done():
 			return
 			
+		case <-m.intervalChanged:
+			ticker.Reset(m.currentInterval())
+			
 		case <-ticker.C:
 			m.collectAndReport()
 		}
 	}
 }
 
+// currentInterval 获取当前收集间隔
+func (m *MetricsCollector) currentInterval() time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.interval
+}
+
 // collectAndReport 收集并上报指标
 func (m *MetricsCollector) collectAndReport() {
 	// 收集指标
@@ -200,6 +235,7 @@ func (m *MetricsCollector) collectMetrics() (*core.AgentMetrics, error) {
 	metrics := &core.AgentMetrics{
 		Timestamp: time.Now(),
 		Uptime:    int64(time.Since(m.startTime).Seconds()),
+		CPUCores:  runtime.NumCPU(),
 	}
 	
 	// 收集CPU使用率
@@ -212,6 +248,7 @@ func (m *MetricsCollector) collectMetrics() (*core.AgentMetrics, error) {
 	// 收集内存使用率
 	if memInfo, err := mem.VirtualMemory(); err == nil {
 		metrics.MemoryUsage = memInfo.UsedPercent
+		metrics.MemoryTotalMB = float64(memInfo.Total) / (1 << 20)
 	} else {
 		m.logger.WithError(err).Warn("获取内存使用率失败")
 	}
@@ -219,6 +256,7 @@ func (m *MetricsCollector) collectMetrics() (*core.AgentMetrics, error) {
 	// 收集磁盘使用率（配置目录所在磁盘）
 	if diskInfo, err := disk.Usage("/"); err == nil {
 		metrics.DiskUsage = diskInfo.UsedPercent
+		metrics.DiskTotalMB = float64(diskInfo.Total) / (1 << 20)
 	} else {
 		m.logger.WithError(err).Warn("获取磁盘使用率失败")
 	}
@@ -229,6 +267,7 @@ func (m *MetricsCollector) collectMetrics() (*core.AgentMetrics, error) {
 		if err == nil && status.PID > 0 {
 			m.collectLogstashMetrics(metrics, status.PID)
 		}
+		m.getLogstashEventStats(metrics)
 	}
 	
 	return metrics, nil
@@ -265,22 +304,30 @@ func (m *MetricsCollector) collectLogstashMetrics(metrics *core.AgentMetrics, pi
 			}
 		}
 	}
-	
-	// TODO: 从Logstash API获取事件处理统计
-	// 这里需要Logstash启用监控API
-	m.getLogstashEventStats(metrics)
 }
 
-// getLogstashEventStats 获取Logstash事件统计
+// getLogstashEventStats 从Logstash监控API获取事件统计
+// 事件计数为全部管道的累计值，失败数为输出插件报告的不可重试写入失败；监控API不可用时保持为0
 func (m *MetricsCollector) getLogstashEventStats(metrics *core.AgentMetrics) {
-	// TODO: 实现从Logstash监控API获取统计信息
-	// 默认值
-	metrics.EventsReceived = 0
-	metrics.EventsSent = 0
-	metrics.EventsFailed = 0
-	
-	// 这里可以通过HTTP请求Logstash的监控API
-	// 例如: http://localhost:9600/_node/stats
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	
+	stats, err := m.statsScraper.scrape(ctx)
+	if err != nil {
+		m.logger.WithError(err).Debug("获取Logstash监控统计失败")
+		return
+	}
+	
+	for _, pipeline := range stats.Pipelines {
+		metrics.EventsReceived += pipeline.EventsIn
+		metrics.EventsSent += pipeline.EventsOut
+		for _, plugin := range pipeline.Plugins {
+			if plugin.Type == models.PluginTypeOutput {
+				metrics.EventsFailed += plugin.Failures
+			}
+		}
+	}
+	metrics.Logstash = stats
 }
 
 // GetStats 获取收集器统计信息
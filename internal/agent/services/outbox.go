@@ -0,0 +1,359 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/agent/core"
+	"logstash-platform/internal/platform/models"
+)
+
+// 暂存上报的类型
+const (
+	OutboxConfigApplied = "config_applied"
+	OutboxStatus        = "status"
+	OutboxMetrics       = "metrics"
+)
+
+// ErrPlatformUnreachable 无法连接平台或平台暂时不可用，补发遇到该错误时保留记录等待下一次补发
+var ErrPlatformUnreachable = errors.New("平台不可达")
+
+// StatusError 平台以非成功状态码响应上报，502/503/504视为平台不可达
+type StatusError struct {
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return e.Status
+	}
+	return e.Status + " - " + e.Body
+}
+
+// Unwrap 网关错误时返回ErrPlatformUnreachable，使errors.Is能识别
+func (e *StatusError) Unwrap() error {
+	switch e.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return ErrPlatformUnreachable
+	}
+	return nil
+}
+
+// Rejected 平台明确拒绝该上报（请求无效、对象不存在或已删除、冲突），重试也不会成功
+func (e *StatusError) Rejected() bool {
+	switch e.StatusCode {
+	case http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusGone:
+		return true
+	}
+	return false
+}
+
+// defaultOutboxMaxMetrics 暂存的指标上报条数上限，超过时丢弃最早的指标
+const defaultOutboxMaxMetrics = 360
+
+// OutboxSender 补发暂存上报的客户端，发送失败时不应再次写入暂存队列
+type OutboxSender interface {
+	ReportConfigApplied(ctx context.Context, agentID string, applied *models.AppliedConfig) error
+	ReportStatus(ctx context.Context, agent *models.Agent) error
+	ReportMetrics(ctx context.Context, agentID string, metrics *core.AgentMetrics) error
+}
+
+// outboxEntry 暂存的一条上报，以文件形式保存在暂存目录中，文件名由去重键生成
+type outboxEntry struct {
+	ID       string          `json:"id"`  // 每次写入生成，补发后只删除未被覆盖的记录
+	Key      string          `json:"key"` // 去重键，同一键只保留最新的上报
+	Kind     string          `json:"kind"`
+	AgentID  string          `json:"agent_id"`
+	Payload  json.RawMessage `json:"payload"`
+	QueuedAt time.Time       `json:"queued_at"`
+}
+
+// Outbox 平台不可达时暂存上报的本地队列
+// 状态只保留最新一条，同一配置在同一部署中的应用结果只保留最新一条，指标按采集时间逐条保存并限制条数；
+// 恢复连接后按暂存顺序补发，平台不可达时停止，剩余记录等待下一次补发；其他发送失败的记录直接丢弃，避免阻塞后续上报
+type Outbox struct {
+	dir        string
+	logger     *logrus.Logger
+	maxMetrics int
+	interval   time.Duration
+
+	mu     sync.Mutex // 保护暂存目录中的文件
+	sender OutboxSender
+
+	flushMu sync.Mutex // 同一时刻只有一次补发
+	notify  chan struct{}
+}
+
+// NewOutbox 创建保存在dir目录下的暂存队列，Agent重启后继续补发目录中已有的记录
+func NewOutbox(dir string, logger *logrus.Logger) (*Outbox, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("创建上报暂存目录失败: %w", err)
+	}
+	return &Outbox{
+		dir:        dir,
+		logger:     logger,
+		maxMetrics: defaultOutboxMaxMetrics,
+		interval:   30 * time.Second,
+		notify:     make(chan struct{}, 1),
+	}, nil
+}
+
+// SetSender 设置补发使用的客户端
+func (o *Outbox) SetSender(sender OutboxSender) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.sender = sender
+}
+
+// SetMaxMetrics 设置暂存的指标条数上限，n<=0时使用默认值
+func (o *Outbox) SetMaxMetrics(n int) {
+	if n <= 0 {
+		n = defaultOutboxMaxMetrics
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.maxMetrics = n
+}
+
+// EnqueueConfigApplied 暂存配置应用结果
+func (o *Outbox) EnqueueConfigApplied(agentID string, applied *models.AppliedConfig) error {
+	key := OutboxConfigApplied + "-" + applied.ConfigID
+	if applied.DeploymentID != "" {
+		key += "-" + applied.DeploymentID
+	}
+	return o.enqueue(key, OutboxConfigApplied, agentID, applied)
+}
+
+// EnqueueStatus 暂存状态上报，只保留最新状态
+func (o *Outbox) EnqueueStatus(agent *models.Agent) error {
+	return o.enqueue(OutboxStatus, OutboxStatus, agent.AgentID, agent)
+}
+
+// EnqueueMetrics 暂存指标上报
+func (o *Outbox) EnqueueMetrics(agentID string, metrics *core.AgentMetrics) error {
+	timestamp := metrics.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	key := fmt.Sprintf("%s-%020d", OutboxMetrics, timestamp.UnixNano())
+	if err := o.enqueue(key, OutboxMetrics, agentID, metrics); err != nil {
+		return err
+	}
+	return o.trimMetrics()
+}
+
+// Len 暂存的上报条数
+func (o *Outbox) Len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	entries, _ := o.load()
+	return len(entries)
+}
+
+// Notify 通知已恢复与平台的连接，尽快补发
+func (o *Outbox) Notify() {
+	select {
+	case o.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Start 收到恢复连接的通知或按间隔补发暂存的上报，直到ctx取消
+func (o *Outbox) Start(ctx context.Context) {
+	ticker := time.NewTicker(o.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-o.notify:
+		case <-ticker.C:
+		}
+		if sent, err := o.Flush(ctx); err != nil {
+			o.logger.WithError(err).WithField("sent", sent).Debug("补发暂存上报未完成")
+		}
+	}
+}
+
+// Flush 按暂存顺序补发上报，返回补发成功的条数；平台明确拒绝的记录（如离线期间配置或Agent已被删除）
+// 无法再补发，丢弃后继续，其他错误（平台不可达、5xx、401、429等）停止并保留记录等待下一次补发
+func (o *Outbox) Flush(ctx context.Context) (int, error) {
+	o.flushMu.Lock()
+	defer o.flushMu.Unlock()
+
+	o.mu.Lock()
+	sender := o.sender
+	entries, err := o.load()
+	o.mu.Unlock()
+	if err != nil || sender == nil || len(entries) == 0 {
+		return 0, err
+	}
+
+	sent := 0
+	for _, entry := range entries {
+		if err := o.send(ctx, sender, entry); err != nil {
+			var statusErr *StatusError
+			if !errors.As(err, &statusErr) || !statusErr.Rejected() {
+				return sent, err
+			}
+			o.logger.WithError(err).WithField("key", entry.Key).Warn("丢弃无法补发的暂存上报")
+			o.remove(entry)
+			continue
+		}
+		o.remove(entry)
+		sent++
+	}
+	o.logger.WithField("count", sent).Info("已补发暂存的上报")
+	return sent, nil
+}
+
+// send 按类型补发一条上报，无法解析的记录直接丢弃
+func (o *Outbox) send(ctx context.Context, sender OutboxSender, entry *outboxEntry) error {
+	var err error
+	switch entry.Kind {
+	case OutboxConfigApplied:
+		var applied models.AppliedConfig
+		if err = json.Unmarshal(entry.Payload, &applied); err == nil {
+			return sender.ReportConfigApplied(ctx, entry.AgentID, &applied)
+		}
+	case OutboxStatus:
+		var agent models.Agent
+		if err = json.Unmarshal(entry.Payload, &agent); err == nil {
+			return sender.ReportStatus(ctx, &agent)
+		}
+	case OutboxMetrics:
+		var metrics core.AgentMetrics
+		if err = json.Unmarshal(entry.Payload, &metrics); err == nil {
+			return sender.ReportMetrics(ctx, entry.AgentID, &metrics)
+		}
+	default:
+		err = fmt.Errorf("未知的上报类型 %s", entry.Kind)
+	}
+	o.logger.WithError(err).WithField("key", entry.Key).Warn("丢弃无法解析的暂存上报")
+	return nil
+}
+
+// enqueue 写入一条上报，同一去重键的旧记录被覆盖；先写临时文件再改名，避免崩溃时留下不完整的记录
+func (o *Outbox) enqueue(key, kind, agentID string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("序列化暂存上报失败: %w", err)
+	}
+	entry := outboxEntry{
+		ID:       uuid.New().String(),
+		Key:      key,
+		Kind:     kind,
+		AgentID:  agentID,
+		Payload:  data,
+		QueuedAt: time.Now(),
+	}
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("序列化暂存上报失败: %w", err)
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	path := o.path(key)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0600); err != nil {
+		return fmt.Errorf("写入暂存上报失败: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("写入暂存上报失败: %w", err)
+	}
+	return nil
+}
+
+// remove 删除已补发的记录，补发期间被新的上报覆盖时保留新记录
+func (o *Outbox) remove(sent *outboxEntry) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	path := o.path(sent.Key)
+	current, err := readOutboxEntry(path)
+	if err != nil || current.ID != sent.ID {
+		return
+	}
+	if err := os.Remove(path); err != nil {
+		o.logger.WithError(err).WithField("key", sent.Key).Warn("删除已补发的暂存上报失败")
+	}
+}
+
+// trimMetrics 指标条数超过上限时删除最早的指标
+func (o *Outbox) trimMetrics() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	entries, err := o.load()
+	if err != nil {
+		return err
+	}
+	var metrics []*outboxEntry
+	for _, entry := range entries {
+		if entry.Kind == OutboxMetrics {
+			metrics = append(metrics, entry)
+		}
+	}
+	for i := 0; i < len(metrics)-o.maxMetrics; i++ {
+		os.Remove(o.path(metrics[i].Key))
+	}
+	return nil
+}
+
+// load 读取全部暂存记录，按暂存时间排序；调用方持有o.mu
+func (o *Outbox) load() ([]*outboxEntry, error) {
+	files, err := os.ReadDir(o.dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取上报暂存目录失败: %w", err)
+	}
+
+	entries := make([]*outboxEntry, 0, len(files))
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(o.dir, file.Name())
+		entry, err := readOutboxEntry(path)
+		if err != nil {
+			o.logger.WithError(err).WithField("file", path).Warn("删除损坏的暂存上报")
+			os.Remove(path)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].QueuedAt.Before(entries[j].QueuedAt) })
+	return entries, nil
+}
+
+// path 去重键对应的文件路径
+func (o *Outbox) path(key string) string {
+	return filepath.Join(o.dir, strings.NewReplacer("/", "_", "\\", "_").Replace(key)+".json")
+}
+
+func readOutboxEntry(path string) (*outboxEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entry outboxEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/agent/core"
+	"logstash-platform/internal/platform/models"
+)
+
+// recordingSender 记录补发的上报，down为true时模拟平台仍不可达，status中的上报模拟平台以该状态码响应
+type recordingSender struct {
+	down   bool
+	status map[string]int
+	sent   []string
+}
+
+func (s *recordingSender) ReportConfigApplied(ctx context.Context, agentID string, applied *models.AppliedConfig) error {
+	return s.record(applied.ConfigID + "@" + applied.DeploymentID)
+}
+
+func (s *recordingSender) ReportStatus(ctx context.Context, agent *models.Agent) error {
+	return s.record("status:" + agent.Status)
+}
+
+func (s *recordingSender) ReportMetrics(ctx context.Context, agentID string, metrics *core.AgentMetrics) error {
+	return s.record("metrics:" + metrics.Timestamp.Format("15:04:05"))
+}
+
+func (s *recordingSender) record(name string) error {
+	if s.down {
+		return fmt.Errorf("执行请求失败: %w", ErrPlatformUnreachable)
+	}
+	if code, ok := s.status[name]; ok {
+		return fmt.Errorf("上报失败: %w", &StatusError{StatusCode: code, Status: http.StatusText(code)})
+	}
+	s.sent = append(s.sent, name)
+	return nil
+}
+
+func TestOutbox_FlushWithDedup(t *testing.T) {
+	dir := t.TempDir()
+	outbox, err := NewOutbox(dir, logrus.New())
+	require.NoError(t, err)
+	outbox.SetMaxMetrics(2)
+
+	base := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, outbox.EnqueueConfigApplied("agent-1", &models.AppliedConfig{ConfigID: "cfg-1", Version: 1, DeploymentID: "dep-1"}))
+	require.NoError(t, outbox.EnqueueStatus(&models.Agent{AgentID: "agent-1", Status: "error"}))
+	for i := 0; i < 3; i++ {
+		require.NoError(t, outbox.EnqueueMetrics("agent-1", &core.AgentMetrics{Timestamp: base.Add(time.Duration(i) * time.Minute)}))
+	}
+	// 同一部署的应用结果和状态重复暂存时只保留最新一条
+	require.NoError(t, outbox.EnqueueConfigApplied("agent-1", &models.AppliedConfig{ConfigID: "cfg-1", Version: 1, DeploymentID: "dep-1"}))
+	require.NoError(t, outbox.EnqueueStatus(&models.Agent{AgentID: "agent-1", Status: "online"}))
+	assert.Equal(t, 4, outbox.Len())
+
+	// 未设置客户端时不补发
+	sent, err := outbox.Flush(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+
+	sender := &recordingSender{down: true}
+	outbox.SetSender(sender)
+	_, err = outbox.Flush(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, 4, outbox.Len(), "补发失败时保留全部记录")
+
+	// Agent重启后从暂存目录继续补发
+	restarted, err := NewOutbox(dir, logrus.New())
+	require.NoError(t, err)
+	sender.down = false
+	restarted.SetSender(sender)
+	sent, err = restarted.Flush(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 4, sent)
+	assert.Equal(t, []string{"metrics:10:01:00", "metrics:10:02:00", "cfg-1@dep-1", "status:online"}, sender.sent)
+	assert.Zero(t, restarted.Len())
+}
+
+func TestOutbox_FlushDropsRejectedEntries(t *testing.T) {
+	outbox, err := NewOutbox(t.TempDir(), logrus.New())
+	require.NoError(t, err)
+
+	// 离线期间配置被删除，平台拒绝该应用结果时丢弃，不阻塞之后的状态上报
+	for _, code := range []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusGone} {
+		require.NoError(t, outbox.EnqueueConfigApplied("agent-1", &models.AppliedConfig{ConfigID: "cfg-deleted", Version: 1, DeploymentID: "dep-1"}))
+		require.NoError(t, outbox.EnqueueStatus(&models.Agent{AgentID: "agent-1", Status: "online"}))
+
+		sender := &recordingSender{status: map[string]int{"cfg-deleted@dep-1": code}}
+		outbox.SetSender(sender)
+		sent, err := outbox.Flush(context.Background())
+		require.NoError(t, err, code)
+		assert.Equal(t, 1, sent, code)
+		assert.Equal(t, []string{"status:online"}, sender.sent, code)
+		assert.Zero(t, outbox.Len(), code)
+	}
+}
+
+func TestOutbox_FlushKeepsRetryableEntries(t *testing.T) {
+	// 平台内部错误、令牌失效和限流都可能恢复，保留记录等待下一次补发
+	for _, code := range []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable,
+		http.StatusUnauthorized, http.StatusTooManyRequests} {
+		outbox, err := NewOutbox(t.TempDir(), logrus.New())
+		require.NoError(t, err)
+		require.NoError(t, outbox.EnqueueConfigApplied("agent-1", &models.AppliedConfig{ConfigID: "cfg-1", Version: 1, DeploymentID: "dep-1"}))
+		require.NoError(t, outbox.EnqueueStatus(&models.Agent{AgentID: "agent-1", Status: "online"}))
+
+		sender := &recordingSender{status: map[string]int{"cfg-1@dep-1": code}}
+		outbox.SetSender(sender)
+		sent, err := outbox.Flush(context.Background())
+		var statusErr *StatusError
+		require.True(t, errors.As(err, &statusErr), code)
+		assert.Equal(t, code, statusErr.StatusCode)
+		assert.Zero(t, sent, code)
+		assert.Equal(t, 2, outbox.Len(), code)
+
+		// 平台恢复后按原顺序补发
+		delete(sender.status, "cfg-1@dep-1")
+		sent, err = outbox.Flush(context.Background())
+		require.NoError(t, err, code)
+		assert.Equal(t, 2, sent, code)
+		assert.Equal(t, []string{"cfg-1@dep-1", "status:online"}, sender.sent, code)
+	}
+}
+
+func TestStatusError(t *testing.T) {
+	err := fmt.Errorf("上报失败: %w", &StatusError{StatusCode: http.StatusServiceUnavailable, Status: "503 Service Unavailable"})
+	assert.True(t, errors.Is(err, ErrPlatformUnreachable), "网关错误视为平台不可达")
+	assert.False(t, errors.Is(&StatusError{StatusCode: http.StatusInternalServerError}, ErrPlatformUnreachable))
+	assert.Equal(t, "404 Not Found - 配置不存在", (&StatusError{StatusCode: 404, Status: "404 Not Found", Body: "配置不存在"}).Error())
+}
+
+func TestOutbox_NotifyTriggersFlush(t *testing.T) {
+	outbox, err := NewOutbox(t.TempDir(), logrus.New())
+	require.NoError(t, err)
+	sender := &recordingSender{}
+	outbox.SetSender(sender)
+	require.NoError(t, outbox.EnqueueStatus(&models.Agent{AgentID: "agent-1", Status: "online"}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		outbox.Start(ctx)
+	}()
+	outbox.Notify()
+	require.Eventually(t, func() bool { return outbox.Len() == 0 }, time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+	assert.Equal(t, []string{"status:online"}, sender.sent)
+}
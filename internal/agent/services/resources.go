@@ -0,0 +1,29 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/shirou/gopsutil/v3/disk"
+	"github.com/shirou/gopsutil/v3/mem"
+	"logstash-platform/internal/agent/core"
+)
+
+// SampleResources 采样目录所在磁盘和主机内存的使用率，供资源保护检查
+// 不存在的目录跳过，由资源保护视为未超过阈值
+func SampleResources(dirs []string) (*core.ResourceUsage, error) {
+	memInfo, err := mem.VirtualMemory()
+	if err != nil {
+		return nil, fmt.Errorf("获取内存使用率失败: %w", err)
+	}
+
+	usage := &core.ResourceUsage{
+		Disk:   make(map[string]float64, len(dirs)),
+		Memory: memInfo.UsedPercent,
+	}
+	for _, dir := range dirs {
+		if diskInfo, err := disk.Usage(dir); err == nil {
+			usage.Disk[dir] = diskInfo.UsedPercent
+		}
+	}
+	return usage, nil
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+)
+
+// AgentCertificateHandler Agent客户端证书处理器
+type AgentCertificateHandler struct {
+	certService service.AgentCertificateService
+	logger      *logrus.Logger
+}
+
+// NewAgentCertificateHandler 创建Agent客户端证书处理器
+func NewAgentCertificateHandler(certService service.AgentCertificateService, logger *logrus.Logger) *AgentCertificateHandler {
+	return &AgentCertificateHandler{
+		certService: certService,
+		logger:      logger,
+	}
+}
+
+// Rotate 按Agent提交的新CSR签发客户端证书，旧证书在宽限期后失效
+func (h *AgentCertificateHandler) Rotate(c *gin.Context) {
+	var req models.RotateAgentCertificateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "CSR不能为空")
+		return
+	}
+
+	resp, err := h.certService.Rotate(c.Request.Context(), c.Param("id"), req.CSR, middleware.CurrentUserID(c))
+	if err != nil {
+		h.handleCertificateError(c, err, "轮换客户端证书失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Revoke 吊销Agent的全部客户端证书
+func (h *AgentCertificateHandler) Revoke(c *gin.Context) {
+	resp, err := h.certService.Revoke(c.Request.Context(), c.Param("id"), middleware.CurrentUserID(c))
+	if err != nil {
+		h.handleCertificateError(c, err, "吊销客户端证书失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ListCertificates 获取Agent的客户端证书记录
+func (h *AgentCertificateHandler) ListCertificates(c *gin.Context) {
+	certs, err := h.certService.ListCertificates(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleCertificateError(c, err, "获取客户端证书失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total": len(certs),
+		"items": certs,
+	})
+}
+
+// handleCertificateError 将证书服务错误映射为HTTP响应
+func (h *AgentCertificateHandler) handleCertificateError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidCSR):
+		middleware.AbortWithError(c, apperror.New(apperror.Validation, err.Error()))
+	case errors.Is(err, service.ErrCertificatesDisabled):
+		middleware.AbortWithError(c, apperror.New(apperror.CertificatesDisabled, err.Error()))
+	default:
+		h.logger.Errorf("%s: %v", message, err)
+		middleware.AbortWithError(c, apperror.Wrap(err, message))
+	}
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+)
+
+// AgentDecommissionHandler Agent注销与归档处理器
+type AgentDecommissionHandler struct {
+	decommissioner *service.AgentDecommissioner
+	logger         *logrus.Logger
+}
+
+// NewAgentDecommissionHandler 创建Agent注销与归档处理器
+func NewAgentDecommissionHandler(decommissioner *service.AgentDecommissioner, logger *logrus.Logger) *AgentDecommissionHandler {
+	return &AgentDecommissionHandler{
+		decommissioner: decommissioner,
+		logger:         logger,
+	}
+}
+
+// Decommission 管理员注销Agent，查询参数 reason 记录注销原因
+func (h *AgentDecommissionHandler) Decommission(c *gin.Context) {
+	agentID := c.Param("id")
+	if agentID == "" {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "Agent ID不能为空"))
+		return
+	}
+
+	archived, err := h.decommissioner.Decommission(c.Request.Context(), agentID,
+		models.DecommissionByOperator, middleware.CurrentUserID(c), c.Query("reason"))
+	if err != nil {
+		h.abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, archived)
+}
+
+// Deregister Agent关闭时主动注销，请求体可为空
+func (h *AgentDecommissionHandler) Deregister(c *gin.Context) {
+	agentID := c.Param("id")
+	if agentID == "" {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "Agent ID不能为空"))
+		return
+	}
+	if current := middleware.CurrentAgentID(c); current != "" && current != agentID {
+		middleware.AbortWithError(c, apperror.New(apperror.Forbidden, "令牌与Agent ID不匹配"))
+		return
+	}
+
+	var req models.DeregisterRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			middleware.HandleBindError(c, err, "请求参数无效")
+			return
+		}
+	}
+
+	archived, err := h.decommissioner.Decommission(c.Request.Context(), agentID,
+		models.DecommissionByAgent, agentID, req.Reason)
+	if err != nil {
+		h.abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, archived)
+}
+
+// ListArchived 获取已注销Agent的归档记录
+func (h *AgentDecommissionHandler) ListArchived(c *gin.Context) {
+	var req models.ArchivedAgentListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	resp, err := h.decommissioner.ListArchived(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.Errorf("获取已注销Agent失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "获取已注销Agent失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// abortWithError 将注销失败映射为HTTP错误
+func (h *AgentDecommissionHandler) abortWithError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrAgentNotFound) {
+		middleware.AbortWithError(c, apperror.New(apperror.NotFound, "Agent不存在"))
+		return
+	}
+	h.logger.Errorf("注销Agent失败: %v", err)
+	middleware.AbortWithError(c, apperror.Wrap(err, "注销Agent失败"))
+}
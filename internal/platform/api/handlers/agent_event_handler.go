@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+)
+
+// AgentEventHandler Agent事件时间线处理器
+type AgentEventHandler struct {
+	events service.AgentEventService
+	logger *logrus.Logger
+}
+
+// NewAgentEventHandler 创建Agent事件时间线处理器
+func NewAgentEventHandler(events service.AgentEventService, logger *logrus.Logger) *AgentEventHandler {
+	return &AgentEventHandler{
+		events: events,
+		logger: logger,
+	}
+}
+
+// ListEvents 获取Agent的事件时间线，按时间先后排列
+func (h *AgentEventHandler) ListEvents(c *gin.Context) {
+	var req models.AgentEventListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效，时间格式应为RFC3339")
+		return
+	}
+
+	events, err := h.events.ListEvents(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "Agent不存在") {
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "Agent不存在"))
+			return
+		}
+		h.logger.Errorf("获取Agent事件失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "获取Agent事件失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items": events,
+		"total": len(events),
+	})
+}
+
+// ReportEvent Agent上报重载失败、Logstash崩溃或重启事件
+func (h *AgentEventHandler) ReportEvent(c *gin.Context) {
+	var req models.AgentEventReport
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	if err := h.events.Report(c.Request.Context(), c.Param("id"), &req); err != nil {
+		if strings.HasPrefix(err.Error(), "Agent不存在") {
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "Agent不存在"))
+			return
+		}
+		h.logger.Errorf("记录Agent事件失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "记录Agent事件失败"))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"status": "recorded"})
+}
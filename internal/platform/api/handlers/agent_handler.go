@@ -1,17 +1,22 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
 	"logstash-platform/internal/platform/service"
+	"logstash-platform/pkg/tabular"
 )
 
 // AgentHandler Agent处理器
 type AgentHandler struct {
 	configService service.ConfigService
+	liveness      *service.LivenessMonitor
 	logger        *logrus.Logger
 }
 
@@ -23,21 +28,66 @@ func NewAgentHandler(configService service.ConfigService, logger *logrus.Logger)
 	}
 }
 
+// SetLivenessMonitor 设置Agent存活检测，Agent列表中的状态按最近心跳推导
+func (h *AgentHandler) SetLivenessMonitor(monitor *service.LivenessMonitor) {
+	h.liveness = monitor
+}
+
 // ListAgents 获取Agent列表
 func (h *AgentHandler) ListAgents(c *gin.Context) {
-	// TODO: 实现Agent列表逻辑
-	c.JSON(http.StatusOK, gin.H{
-		"items": []interface{}{},
-		"total": 0,
-		"message": "Agent管理功能待实现",
-	})
+	if h.liveness == nil {
+		c.JSON(http.StatusOK, gin.H{
+			"items": []interface{}{},
+			"total": 0,
+		})
+		return
+	}
+
+	var req models.AgentListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	resp, err := h.liveness.PageAgents(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) {
+			middleware.AbortWithError(c, apperror.New(apperror.InvalidCursor, "游标无效"))
+			return
+		}
+		h.logger.Errorf("获取Agent列表失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "获取Agent列表失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ExportAgents 以CSV或XLSX导出Agent清单，过滤和排序条件与Agent列表相同，逐页读取并流式写出
+func (h *AgentHandler) ExportAgents(c *gin.Context) {
+	var req models.AgentExportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+	if req.Format == "" {
+		req.Format = tabular.FormatCSV
+	}
+	if h.liveness == nil {
+		middleware.AbortWithError(c, apperror.New(apperror.Internal, "未启用Agent存活检测，无法导出Agent清单"))
+		return
+	}
+
+	if err := h.liveness.ExportAgents(c.Request.Context(), &req, exportOpener(c, req.Format, "agents")); err != nil {
+		abortExport(c, h.logger, err, "导出Agent清单失败")
+	}
 }
 
 // GetAgent 获取单个Agent
 func (h *AgentHandler) GetAgent(c *gin.Context) {
 	agentID := c.Param("id")
 	if agentID == "" {
-		middleware.HandleError(c, http.StatusBadRequest, "INVALID_REQUEST", "Agent ID不能为空")
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "Agent ID不能为空"))
 		return
 	}
 
@@ -53,7 +103,7 @@ func (h *AgentHandler) GetAgent(c *gin.Context) {
 func (h *AgentHandler) DeployConfig(c *gin.Context) {
 	agentID := c.Param("id")
 	if agentID == "" {
-		middleware.HandleError(c, http.StatusBadRequest, "INVALID_REQUEST", "Agent ID不能为空")
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "Agent ID不能为空"))
 		return
 	}
 
@@ -65,13 +115,25 @@ func (h *AgentHandler) DeployConfig(c *gin.Context) {
 	})
 }
 
-// BatchDeploy 批量部署
-func BatchDeploy(configService service.ConfigService, logger *logrus.Logger) gin.HandlerFunc {
+// AgentInProject 路径中的Agent不属于请求所属的项目时返回404
+// 尚未注册的Agent不做限制，由各处理器按原有逻辑处理
+func AgentInProject(agents service.AgentService) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// TODO: 实现批量部署逻辑
-		c.JSON(http.StatusOK, gin.H{
-			"status":  "pending",
-			"message": "批量部署功能待实现",
-		})
+		if id := c.Param("id"); id != "" {
+			ctx := c.Request.Context()
+			if agent, err := agents.GetAgent(ctx, id); err == nil && !models.InProject(ctx, agent.Project) {
+				middleware.AbortWithError(c, apperror.New(apperror.NotFound, "Agent不存在"))
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// BatchDeploy 批量部署
+// 支持按Agent ID列表或标签选择器指定目标，与创建部署相同经由部署引擎下发，
+// 统一受下游集群节流并留下部署记录
+func BatchDeploy(engine *service.DeploymentEngine, logger *logrus.Logger) gin.HandlerFunc {
+	return NewDeploymentHandler(nil, engine, logger).CreateDeployment
+}
@@ -1,17 +1,78 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+	"logstash-platform/tests/mocks"
 )
 
+// MockAgentService Agent服务的mock实现
+type MockAgentService struct {
+	mock.Mock
+}
+
+func (m *MockAgentService) Register(ctx context.Context, agent *models.Agent) error {
+	args := m.Called(ctx, agent)
+	return args.Error(0)
+}
+
+func (m *MockAgentService) Heartbeat(ctx context.Context, agentID string, acked []string) (*models.HeartbeatResponse, error) {
+	args := m.Called(ctx, agentID, acked)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.HeartbeatResponse), args.Error(1)
+}
+
+func (m *MockAgentService) EnqueueCommand(ctx context.Context, agentID string, req *models.EnqueueCommandRequest) error {
+	args := m.Called(ctx, agentID, req)
+	return args.Error(0)
+}
+
+func (m *MockAgentService) GetAgent(ctx context.Context, agentID string) (*models.Agent, error) {
+	args := m.Called(ctx, agentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Agent), args.Error(1)
+}
+
+func (m *MockAgentService) SelectAgents(ctx context.Context, selector string) ([]*models.Agent, error) {
+	args := m.Called(ctx, selector)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Agent), args.Error(1)
+}
+
+func (m *MockAgentService) RecordMetrics(ctx context.Context, agentID string, metrics *models.AgentMetrics) error {
+	args := m.Called(ctx, agentID, metrics)
+	return args.Error(0)
+}
+
+func (m *MockAgentService) RecordApplied(ctx context.Context, agentID string, applied models.AppliedConfig) error {
+	args := m.Called(ctx, agentID, applied)
+	return args.Error(0)
+}
+
+func (m *MockAgentService) RemoveApplied(ctx context.Context, agentID, configID string) error {
+	args := m.Called(ctx, agentID, configID)
+	return args.Error(0)
+}
+
 func TestNewAgentHandler(t *testing.T) {
 	mockService := &MockConfigService{}
 	logger := logrus.New()
@@ -32,12 +93,11 @@ func TestAgentHandler_ListAgents(t *testing.T) {
 		expectedBody   map[string]interface{}
 	}{
 		{
-			name:           "获取Agent列表返回空列表",
+			name:           "未设置存活检测时返回空列表",
 			expectedStatus: http.StatusOK,
 			expectedBody: map[string]interface{}{
-				"items":   []interface{}{},
-				"total":   float64(0),
-				"message": "Agent管理功能待实现",
+				"items": []interface{}{},
+				"total": float64(0),
 			},
 		},
 	}
@@ -73,6 +133,39 @@ func TestAgentHandler_ListAgents(t *testing.T) {
 	}
 }
 
+func TestAgentHandler_ListAgentsDerivedStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	agentRepo := &mocks.MockAgentRepository{}
+	agentRepo.On("List", mock.Anything, mock.MatchedBy(func(req *models.AgentListRequest) bool {
+		return req.Group == "edge"
+	})).Return(&models.AgentListResponse{Items: []*models.Agent{
+		{AgentID: "agent-1", Status: "online", LastHeartbeat: time.Now()},
+		{AgentID: "agent-2", Status: "online", LastHeartbeat: time.Now().Add(-10 * time.Minute)},
+	}, Total: 2}, nil)
+
+	handler := NewAgentHandler(&MockConfigService{}, logger)
+	handler.SetLivenessMonitor(service.NewLivenessMonitor(service.LivenessConfig{OfflineAfter: time.Minute}, agentRepo, nil, logger))
+
+	router := gin.New()
+	router.GET("/agents", handler.ListAgents)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/agents?group=edge", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Items []models.Agent `json:"items"`
+		Total int            `json:"total"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 2, response.Total)
+	// 心跳过期的Agent在扫描写回之前即显示为离线
+	assert.Equal(t, models.AgentStatusOnline, response.Items[0].Status)
+	assert.Equal(t, models.AgentStatusOffline, response.Items[1].Status)
+}
+
 func TestAgentHandler_GetAgent(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	
@@ -244,45 +337,90 @@ func TestBatchDeploy(t *testing.T) {
 	
 	tests := []struct {
 		name           string
+		body           string
+		setupMock      func(configRepo *mocks.MockConfigRepository, agents *MockAgentService)
 		expectedStatus int
-		expectedBody   map[string]interface{}
+		expectedCode   string
 	}{
 		{
-			name:           "批量部署返回待实现信息",
-			expectedStatus: http.StatusOK,
-			expectedBody: map[string]interface{}{
-				"status":  "pending",
-				"message": "批量部署功能待实现",
+			name:           "请求体无效",
+			body:           "",
+			setupMock:      func(configRepo *mocks.MockConfigRepository, agents *MockAgentService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "INVALID_REQUEST",
+		},
+		{
+			name:           "未指定部署目标",
+			body:           `{"config_id":"cfg-1"}`,
+			setupMock:      func(configRepo *mocks.MockConfigRepository, agents *MockAgentService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "INVALID_REQUEST",
+		},
+		{
+			name: "选择器语法错误",
+			body: `{"config_id":"cfg-1","selector":"tier in web"}`,
+			setupMock: func(configRepo *mocks.MockConfigRepository, agents *MockAgentService) {
+				configRepo.On("GetByID", mock.Anything, "cfg-1").Return(&models.Config{ID: "cfg-1", Enabled: true}, nil)
+				agents.On("SelectAgents", mock.Anything, "tier in web").Return(nil, fmt.Errorf("%w: tier in web", models.ErrInvalidSelector))
 			},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "INVALID_SELECTOR",
+		},
+		{
+			name: "没有匹配的Agent",
+			body: `{"config_id":"cfg-1","selector":"env=staging"}`,
+			setupMock: func(configRepo *mocks.MockConfigRepository, agents *MockAgentService) {
+				configRepo.On("GetByID", mock.Anything, "cfg-1").Return(&models.Config{ID: "cfg-1", Enabled: true}, nil)
+				agents.On("SelectAgents", mock.Anything, "env=staging").Return([]*models.Agent{}, nil)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "NO_TARGETS",
+		},
+		{
+			name: "配置不存在",
+			body: `{"config_id":"missing","agent_ids":["agent-1"]}`,
+			setupMock: func(configRepo *mocks.MockConfigRepository, agents *MockAgentService) {
+				configRepo.On("GetByID", mock.Anything, "missing").Return(nil, fmt.Errorf("文档不存在"))
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "NOT_FOUND",
+		},
+		{
+			name: "配置已禁用",
+			body: `{"config_id":"cfg-1","agent_ids":["agent-1"]}`,
+			setupMock: func(configRepo *mocks.MockConfigRepository, agents *MockAgentService) {
+				configRepo.On("GetByID", mock.Anything, "cfg-1").Return(&models.Config{ID: "cfg-1", Enabled: false}, nil)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedCode:   "CONFIG_DISABLED",
 		},
 	}
 	
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// 创建mock服务和logger
-			mockService := &MockConfigService{}
+			configRepo := new(mocks.MockConfigRepository)
+			agents := new(MockAgentService)
+			tt.setupMock(configRepo, agents)
 			logger := logrus.New()
+			engine := service.NewDeploymentEngine(new(mocks.MockDeploymentRepository), configRepo, agents,
+				new(mocks.MockMessagePublisher), nil, time.Second, logger)
 			
-			// 创建测试上下文
-			w := httptest.NewRecorder()
-			c, router := gin.CreateTestContext(w)
-			
-			// 注册路由
-			router.POST("/batch-deploy", BatchDeploy(mockService, logger))
-			
-			// 创建请求
-			c.Request = httptest.NewRequest(http.MethodPost, "/batch-deploy", nil)
+			router := gin.New()
+			router.POST("/deploy", BatchDeploy(engine, logger))
 			
-			// 执行请求
-			router.ServeHTTP(w, c.Request)
+			req := httptest.NewRequest(http.MethodPost, "/deploy", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
 			
-			// 验证响应
 			assert.Equal(t, tt.expectedStatus, w.Code)
 			
 			var response map[string]interface{}
 			err := json.Unmarshal(w.Body.Bytes(), &response)
 			assert.NoError(t, err)
-			assert.Equal(t, tt.expectedBody, response)
+			assert.Equal(t, tt.expectedCode, response["code"])
+			configRepo.AssertExpectations(t)
+			agents.AssertExpectations(t)
 		})
 	}
 }
@@ -350,7 +488,6 @@ func TestAgentHandler_FullIntegration(t *testing.T) {
 	router.GET("/api/agents", handler.ListAgents)
 	router.GET("/api/agents/:id", handler.GetAgent)
 	router.POST("/api/agents/:id/deploy", handler.DeployConfig)
-	router.POST("/api/batch-deploy", BatchDeploy(mockService, logger))
 	
 	t.Run("完整Agent管理流程", func(t *testing.T) {
 		// 1. 获取Agent列表
@@ -370,11 +507,5 @@ func TestAgentHandler_FullIntegration(t *testing.T) {
 		req3 := httptest.NewRequest(http.MethodPost, "/api/agents/agent-test/deploy", nil)
 		router.ServeHTTP(w3, req3)
 		assert.Equal(t, http.StatusOK, w3.Code)
-		
-		// 4. 批量部署
-		w4 := httptest.NewRecorder()
-		req4 := httptest.NewRequest(http.MethodPost, "/api/batch-deploy", nil)
-		router.ServeHTTP(w4, req4)
-		assert.Equal(t, http.StatusOK, w4.Code)
 	})
 }
\ No newline at end of file
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+)
+
+// AgentLifecycleHandler Agent注册与心跳处理器
+type AgentLifecycleHandler struct {
+	agentService service.AgentService
+	telemetry    *service.TelemetryPolicy // 未启用间隔协商时为nil
+	metrics      service.MetricsService   // 未启用指标时序存储时为nil
+	events       service.AgentEventService
+	logger       *logrus.Logger
+}
+
+// NewAgentLifecycleHandler 创建Agent注册与心跳处理器
+func NewAgentLifecycleHandler(agentService service.AgentService, logger *logrus.Logger) *AgentLifecycleHandler {
+	return &AgentLifecycleHandler{
+		agentService: agentService,
+		logger:       logger,
+	}
+}
+
+// SetTelemetryPolicy 启用心跳/指标间隔协商，注册响应携带平台要求的间隔，心跳耗时计入平台压力
+func (h *AgentLifecycleHandler) SetTelemetryPolicy(policy *service.TelemetryPolicy) {
+	h.telemetry = policy
+}
+
+// SetMetricsService 启用指标时序存储，上报的指标同时写入按天滚动的指标索引
+func (h *AgentLifecycleHandler) SetMetricsService(metrics service.MetricsService) {
+	h.metrics = metrics
+}
+
+// SetEventService 设置Agent事件时间线，注册时记录首次注册或重新连接事件
+func (h *AgentLifecycleHandler) SetEventService(events service.AgentEventService) {
+	h.events = events
+}
+
+// Register Agent注册
+func (h *AgentLifecycleHandler) Register(c *gin.Context) {
+	var agent models.Agent
+	if err := c.ShouldBindJSON(&agent); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	if agent.AgentID == "" {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "Agent ID不能为空"))
+		return
+	}
+	if agentID := middleware.CurrentAgentID(c); agentID != "" && agentID != agent.AgentID {
+		middleware.AbortWithError(c, apperror.New(apperror.Forbidden, "令牌与Agent ID不匹配"))
+		return
+	}
+	if agent.Project != "" && !models.ProjectNamePattern.MatchString(agent.Project) {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidProject, "项目名称无效"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	eventType := models.AgentEventRegistered
+	if h.events != nil {
+		if _, err := h.agentService.GetAgent(ctx, agent.AgentID); err == nil {
+			eventType = models.AgentEventReconnected
+		}
+	}
+
+	if err := h.agentService.Register(ctx, &agent); err != nil {
+		h.logger.Errorf("Agent注册失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "Agent注册失败"))
+		return
+	}
+	if h.events != nil {
+		if err := h.events.Record(ctx, &models.AgentEvent{
+			AgentID:       agent.AgentID,
+			Type:          eventType,
+			LastHeartbeat: agent.LastHeartbeat,
+		}); err != nil {
+			h.logger.WithError(err).WithField("agent_id", agent.AgentID).Warn("写入Agent事件失败")
+		}
+	}
+
+	resp := models.RegisterResponse{Agent: &agent}
+	if h.telemetry != nil {
+		resp.Telemetry = h.telemetry.Negotiate(agent.AgentID)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Heartbeat Agent心跳，响应中捎带待执行的命令
+func (h *AgentLifecycleHandler) Heartbeat(c *gin.Context) {
+	agentID := c.Param("id")
+	if agentID == "" {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "Agent ID不能为空"))
+		return
+	}
+
+	// 旧版本Agent的心跳请求体可能为空
+	var req models.HeartbeatRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			middleware.HandleBindError(c, err, "请求参数无效")
+			return
+		}
+	}
+
+	start := time.Now()
+	resp, err := h.agentService.Heartbeat(c.Request.Context(), agentID, req.AckedCommands)
+	if h.telemetry != nil {
+		h.telemetry.ObserveHeartbeat(time.Since(start))
+	}
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "Agent不存在") {
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "Agent不存在，请重新注册"))
+			return
+		}
+		h.logger.Errorf("处理心跳失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "处理心跳失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ReportMetrics Agent上报指标
+func (h *AgentLifecycleHandler) ReportMetrics(c *gin.Context) {
+	agentID := c.Param("id")
+	if agentID == "" {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "Agent ID不能为空"))
+		return
+	}
+
+	var req models.MetricsReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	if err := h.agentService.RecordMetrics(c.Request.Context(), agentID, &req.Metrics); err != nil {
+		if strings.HasPrefix(err.Error(), "Agent不存在") {
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "Agent不存在，请重新注册"))
+			return
+		}
+		h.logger.Errorf("记录指标失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "记录指标失败"))
+		return
+	}
+
+	// 最近一次的指标已保存，时序写入失败只影响图表，不要求Agent重报
+	if h.metrics != nil {
+		if err := h.metrics.Record(c.Request.Context(), agentID, &req.Metrics); err != nil {
+			h.logger.WithError(err).WithField("agent_id", agentID).Warn("写入指标时序失败")
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"agent_id": agentID})
+}
+
+// EnqueueCommand 为Agent排入心跳命令
+func (h *AgentLifecycleHandler) EnqueueCommand(c *gin.Context) {
+	agentID := c.Param("id")
+	if agentID == "" {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "Agent ID不能为空"))
+		return
+	}
+
+	var req models.EnqueueCommandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	if err := h.agentService.EnqueueCommand(c.Request.Context(), agentID, &req); err != nil {
+		if strings.HasPrefix(err.Error(), "Agent不存在") {
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "Agent不存在"))
+			return
+		}
+		h.logger.Errorf("下发命令失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "下发命令失败"))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"agent_id": agentID,
+		"type":     req.Type,
+		"status":   "queued",
+	})
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+)
+
+// AgentTokenHandler Agent注册令牌处理器
+type AgentTokenHandler struct {
+	tokenService service.AgentTokenService
+	certService  service.AgentCertificateService // 为nil时注册请求中的CSR被拒绝
+	logger       *logrus.Logger
+}
+
+// NewAgentTokenHandler 创建Agent注册令牌处理器
+func NewAgentTokenHandler(tokenService service.AgentTokenService, logger *logrus.Logger) *AgentTokenHandler {
+	return &AgentTokenHandler{
+		tokenService: tokenService,
+		logger:       logger,
+	}
+}
+
+// SetCertificateService 设置客户端证书服务，注册时可同时申请客户端证书
+func (h *AgentTokenHandler) SetCertificateService(certService service.AgentCertificateService) {
+	h.certService = certService
+}
+
+// Enroll 签发Agent注册令牌，请求中包含CSR时同时签发客户端证书
+// Agent使用共享引导令牌调用时只能为从未注册过的Agent申请，管理员可为已吊销的Agent重新签发
+func (h *AgentTokenHandler) Enroll(c *gin.Context) {
+	var req models.EnrollAgentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "Agent ID不能为空")
+		return
+	}
+	if agentID := middleware.CurrentAgentID(c); agentID != "" && agentID != req.AgentID {
+		middleware.AbortWithError(c, apperror.New(apperror.Forbidden, "令牌与Agent ID不匹配"))
+		return
+	}
+
+	// 先检查CSR，避免令牌已签发而证书签发失败时Agent无法再次申请
+	if req.CSR != "" {
+		if err := h.checkCSR(req.AgentID, req.CSR); err != nil {
+			middleware.AbortWithError(c, err)
+			return
+		}
+	}
+
+	privileged := c.GetString(middleware.ContextUserRole) != models.RoleAgent
+	operator := middleware.CurrentUserID(c)
+	resp, err := h.tokenService.Enroll(c.Request.Context(), req.AgentID, operator, privileged)
+	if err != nil {
+		if errors.Is(err, service.ErrAgentAlreadyEnrolled) {
+			middleware.AbortWithError(c, apperror.New(apperror.AlreadyEnrolled, "Agent已签发过注册令牌，请联系管理员"))
+			return
+		}
+		h.logger.Errorf("签发Agent注册令牌失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "签发注册令牌失败"))
+		return
+	}
+
+	if req.CSR != "" {
+		cert, err := h.certService.Issue(c.Request.Context(), req.AgentID, req.CSR, operator)
+		if err != nil {
+			h.logger.Errorf("签发Agent客户端证书失败: %v", err)
+			middleware.AbortWithError(c, apperror.Wrap(err, "签发客户端证书失败"))
+			return
+		}
+		resp.Certificate = cert
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// checkCSR 检查注册请求中的CSR能否签发
+func (h *AgentTokenHandler) checkCSR(agentID, csr string) *apperror.Error {
+	if h.certService == nil {
+		return apperror.New(apperror.CertificatesDisabled, service.ErrCertificatesDisabled.Error())
+	}
+	if err := h.certService.CheckCSR(agentID, csr); err != nil {
+		if errors.Is(err, service.ErrCertificatesDisabled) {
+			return apperror.New(apperror.CertificatesDisabled, err.Error())
+		}
+		return apperror.New(apperror.Validation, err.Error())
+	}
+	return nil
+}
+
+// Rotate 轮换Agent注册令牌，旧令牌在宽限期后失效
+func (h *AgentTokenHandler) Rotate(c *gin.Context) {
+	agentID := c.Param("id")
+	resp, err := h.tokenService.Rotate(c.Request.Context(), agentID, middleware.CurrentUserID(c))
+	if err != nil {
+		if errors.Is(err, service.ErrAgentNotEnrolled) {
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "Agent没有可用的注册令牌"))
+			return
+		}
+		h.logger.Errorf("轮换Agent注册令牌失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "轮换注册令牌失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Revoke 吊销Agent的全部注册令牌
+func (h *AgentTokenHandler) Revoke(c *gin.Context) {
+	resp, err := h.tokenService.Revoke(c.Request.Context(), c.Param("id"), middleware.CurrentUserID(c))
+	if err != nil {
+		h.logger.Errorf("吊销Agent注册令牌失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "吊销注册令牌失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ListTokens 获取Agent的注册令牌记录（不含密钥）
+func (h *AgentTokenHandler) ListTokens(c *gin.Context) {
+	tokens, err := h.tokenService.ListTokens(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.logger.Errorf("获取Agent注册令牌失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "获取注册令牌失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total": len(tokens),
+		"items": tokens,
+	})
+}
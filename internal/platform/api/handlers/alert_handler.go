@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+)
+
+// AlertHandler 告警处理器
+type AlertHandler struct {
+	alerts service.AlertService
+	logger *logrus.Logger
+}
+
+// NewAlertHandler 创建告警处理器
+func NewAlertHandler(alerts service.AlertService, logger *logrus.Logger) *AlertHandler {
+	return &AlertHandler{
+		alerts: alerts,
+		logger: logger,
+	}
+}
+
+// ListAlerts 获取当前触发中的告警
+func (h *AlertHandler) ListAlerts(c *gin.Context) {
+	alerts, err := h.alerts.ListAlerts(c.Request.Context())
+	if err != nil {
+		h.handleAlertError(c, err, "获取告警列表失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items": alerts,
+		"total": len(alerts),
+	})
+}
+
+// ListRules 获取告警规则列表
+func (h *AlertHandler) ListRules(c *gin.Context) {
+	rules, err := h.alerts.ListRules(c.Request.Context())
+	if err != nil {
+		h.handleAlertError(c, err, "获取告警规则列表失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items": rules,
+		"total": len(rules),
+	})
+}
+
+// CreateRule 创建告警规则
+func (h *AlertHandler) CreateRule(c *gin.Context) {
+	var req models.AlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	rule, err := h.alerts.CreateRule(c.Request.Context(), &req, middleware.CurrentUserID(c))
+	if err != nil {
+		h.handleAlertError(c, err, "创建告警规则失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// GetRule 获取单个告警规则
+func (h *AlertHandler) GetRule(c *gin.Context) {
+	rule, err := h.alerts.GetRule(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleAlertError(c, err, "获取告警规则失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// UpdateRule 更新告警规则
+func (h *AlertHandler) UpdateRule(c *gin.Context) {
+	var req models.AlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	rule, err := h.alerts.UpdateRule(c.Request.Context(), c.Param("id"), &req, middleware.CurrentUserID(c))
+	if err != nil {
+		h.handleAlertError(c, err, "更新告警规则失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteRule 删除告警规则
+func (h *AlertHandler) DeleteRule(c *gin.Context) {
+	if err := h.alerts.DeleteRule(c.Request.Context(), c.Param("id")); err != nil {
+		h.handleAlertError(c, err, "删除告警规则失败")
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// ListSilences 获取尚未结束的告警静默
+func (h *AlertHandler) ListSilences(c *gin.Context) {
+	silences, err := h.alerts.ListSilences(c.Request.Context())
+	if err != nil {
+		h.handleAlertError(c, err, "获取告警静默列表失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items": silences,
+		"total": len(silences),
+	})
+}
+
+// CreateSilence 创建告警静默
+func (h *AlertHandler) CreateSilence(c *gin.Context) {
+	var req models.CreateAlertSilenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	silence, err := h.alerts.CreateSilence(c.Request.Context(), &req, middleware.CurrentUserID(c))
+	if err != nil {
+		h.handleAlertError(c, err, "创建告警静默失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, silence)
+}
+
+// DeleteSilence 删除告警静默
+func (h *AlertHandler) DeleteSilence(c *gin.Context) {
+	if err := h.alerts.DeleteSilence(c.Request.Context(), c.Param("id")); err != nil {
+		h.handleAlertError(c, err, "删除告警静默失败")
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// handleAlertError 将告警服务错误映射为HTTP响应
+func (h *AlertHandler) handleAlertError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrAlertRuleNotFound):
+		middleware.AbortWithError(c, apperror.New(apperror.NotFound, "告警规则不存在"))
+	case errors.Is(err, service.ErrAlertSilenceNotFound):
+		middleware.AbortWithError(c, apperror.New(apperror.NotFound, "告警静默不存在"))
+	case errors.Is(err, service.ErrAlertRuleInvalid), errors.Is(err, service.ErrAlertSilenceInvalid):
+		middleware.AbortWithError(c, apperror.New(apperror.Validation, err.Error()))
+	default:
+		h.logger.Errorf("%s: %v", message, err)
+		middleware.AbortWithError(c, apperror.Wrap(err, message))
+	}
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+)
+
+// ApprovalHandler 配置审批处理器
+type ApprovalHandler struct {
+	approvals service.ApprovalService
+	logger    *logrus.Logger
+}
+
+// NewApprovalHandler 创建配置审批处理器
+func NewApprovalHandler(approvals service.ApprovalService, logger *logrus.Logger) *ApprovalHandler {
+	return &ApprovalHandler{
+		approvals: approvals,
+		logger:    logger,
+	}
+}
+
+// SubmitApproval 审批配置的当前版本
+func (h *ApprovalHandler) SubmitApproval(c *gin.Context) {
+	var req models.ApproveConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	approval, err := h.approvals.Submit(c.Request.Context(), c.Param("id"), &req, middleware.CurrentUserID(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrConfigNotFound):
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "配置不存在"))
+		case errors.Is(err, service.ErrConfigForbidden):
+			middleware.AbortWithError(c, apperror.New(apperror.Forbidden, "无权访问该配置"))
+		case errors.Is(err, service.ErrReviewerNotAllowed):
+			middleware.AbortWithError(c, apperror.New(apperror.ReviewerNotAllowed, err.Error()))
+		default:
+			h.logger.Errorf("提交审批失败: %v", err)
+			middleware.AbortWithError(c, apperror.Wrap(err, "提交审批失败"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, approval)
+}
+
+// ListApprovals 获取配置版本的审批记录和进度，version参数为空时为当前版本
+func (h *ApprovalHandler) ListApprovals(c *gin.Context) {
+	version := 0
+	if v := c.Query("version"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 1 {
+			middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "版本号无效"))
+			return
+		}
+		version = parsed
+	}
+
+	status, err := h.approvals.Status(c.Request.Context(), c.Param("id"), version)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrConfigNotFound):
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "配置不存在"))
+		case errors.Is(err, service.ErrConfigForbidden):
+			middleware.AbortWithError(c, apperror.New(apperror.Forbidden, "无权访问该配置"))
+		default:
+			h.logger.Errorf("获取审批记录失败: %v", err)
+			middleware.AbortWithError(c, apperror.Wrap(err, "获取审批记录失败"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+)
+
+// AuditHandler 审计记录处理器
+type AuditHandler struct {
+	audit  service.AuditService
+	logger *logrus.Logger
+}
+
+// NewAuditHandler 创建审计记录处理器
+func NewAuditHandler(audit service.AuditService, logger *logrus.Logger) *AuditHandler {
+	return &AuditHandler{
+		audit:  audit,
+		logger: logger,
+	}
+}
+
+// ListAuditLog 按操作人、资源类型和时间范围查询审计记录
+func (h *AuditHandler) ListAuditLog(c *gin.Context) {
+	var req models.AuditListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	items, total, err := h.audit.List(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.Errorf("获取审计记录失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "获取审计记录失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total": total,
+		"page":  req.Page,
+		"size":  req.PageSize,
+		"items": items,
+	})
+}
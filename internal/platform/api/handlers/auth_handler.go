@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+)
+
+// AuthHandler 认证与用户管理处理器
+type AuthHandler struct {
+	authService service.AuthService
+	logger      *logrus.Logger
+}
+
+// NewAuthHandler 创建认证处理器
+func NewAuthHandler(authService service.AuthService, logger *logrus.Logger) *AuthHandler {
+	return &AuthHandler{
+		authService: authService,
+		logger:      logger,
+	}
+}
+
+// Login 用户登录，返回访问令牌
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req models.LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "用户名和密码不能为空")
+		return
+	}
+
+	resp, err := h.authService.Login(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			middleware.AbortWithError(c, apperror.New(apperror.InvalidCredentials, "用户名或密码错误"))
+			return
+		}
+		h.logger.Errorf("用户登录失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "登录失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Me 获取当前登录用户
+func (h *AuthHandler) Me(c *gin.Context) {
+	user, err := h.authService.GetUser(c.Request.Context(), middleware.CurrentUserID(c))
+	if err != nil {
+		h.handleUserError(c, err, "获取当前用户失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// ListUsers 获取用户列表
+func (h *AuthHandler) ListUsers(c *gin.Context) {
+	users, err := h.authService.ListUsers(c.Request.Context())
+	if err != nil {
+		h.logger.Errorf("获取用户列表失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "获取用户列表失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total": len(users),
+		"items": users,
+	})
+}
+
+// CreateUser 创建用户
+func (h *AuthHandler) CreateUser(c *gin.Context) {
+	var req models.CreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效，密码至少8位，角色为viewer/editor/admin")
+		return
+	}
+
+	user, err := h.authService.CreateUser(c.Request.Context(), &req, middleware.CurrentUserID(c))
+	if err != nil {
+		h.handleUserError(c, err, "创建用户失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, user)
+}
+
+// UpdateUser 更新用户角色、密码或禁用状态
+func (h *AuthHandler) UpdateUser(c *gin.Context) {
+	var req models.UpdateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	user, err := h.authService.UpdateUser(c.Request.Context(), c.Param("username"), &req)
+	if err != nil {
+		h.handleUserError(c, err, "更新用户失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// DeleteUser 删除用户
+func (h *AuthHandler) DeleteUser(c *gin.Context) {
+	username := c.Param("username")
+	if username == middleware.CurrentUserID(c) {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "不能删除当前登录用户"))
+		return
+	}
+
+	if err := h.authService.DeleteUser(c.Request.Context(), username); err != nil {
+		h.handleUserError(c, err, "删除用户失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "用户已删除"})
+}
+
+// handleUserError 将用户服务错误映射为HTTP响应
+func (h *AuthHandler) handleUserError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		middleware.AbortWithError(c, apperror.New(apperror.NotFound, "用户不存在"))
+	case errors.Is(err, service.ErrUserExists):
+		middleware.AbortWithError(c, apperror.New(apperror.UserExists, "用户已存在"))
+	case errors.Is(err, service.ErrInvalidRole):
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRole, err.Error()))
+	default:
+		h.logger.Errorf("%s: %v", message, err)
+		middleware.AbortWithError(c, apperror.Wrap(err, message))
+	}
+}
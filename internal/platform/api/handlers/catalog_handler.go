@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+)
+
+// CatalogHandler 插件目录处理器
+type CatalogHandler struct {
+	catalog *service.PluginCatalogService
+	logger  *logrus.Logger
+}
+
+// NewCatalogHandler 创建插件目录处理器
+func NewCatalogHandler(catalog *service.PluginCatalogService, logger *logrus.Logger) *CatalogHandler {
+	return &CatalogHandler{
+		catalog: catalog,
+		logger:  logger,
+	}
+}
+
+// ListPlugins 获取插件及其选项的目录，可按区段和插件名筛选
+func (h *CatalogHandler) ListPlugins(c *gin.Context) {
+	var req models.PluginCatalogRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	c.JSON(http.StatusOK, h.catalog.Catalog(&req))
+}
+
+// RefreshPlugins 重新加载插件目录文件，加载失败时保留当前目录
+// 只刷新接收请求的副本，其他副本在文件更新后按间隔自动重新加载
+func (h *CatalogHandler) RefreshPlugins(c *gin.Context) {
+	catalog, err := h.catalog.Refresh(c.Request.Context())
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidPluginCatalog) {
+			middleware.AbortWithError(c, apperror.New(apperror.InvalidPluginCatalog, err.Error()))
+			return
+		}
+		h.logger.Errorf("刷新插件目录失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "刷新插件目录失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, catalog)
+}
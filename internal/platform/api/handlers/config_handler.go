@@ -1,18 +1,29 @@
 package handlers
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
 	"logstash-platform/internal/platform/models"
 	"logstash-platform/internal/platform/service"
+	"logstash-platform/pkg/tabular"
 )
 
 // ConfigHandler 配置处理器
 type ConfigHandler struct {
 	configService service.ConfigService
+	destinations  service.DestinationService // 为nil时不渲染下游集群引用
+	linter        service.ConfigLinter       // 为nil时配置详情不附带静态检查结果
 	logger        *logrus.Logger
 }
 
@@ -24,11 +35,21 @@ func NewConfigHandler(configService service.ConfigService, logger *logrus.Logger
 	}
 }
 
+// SetDestinationService 启用按环境渲染下游集群引用
+func (h *ConfigHandler) SetDestinationService(destinations service.DestinationService) {
+	h.destinations = destinations
+}
+
+// SetConfigLinter 配置详情附带静态检查结果
+func (h *ConfigHandler) SetConfigLinter(linter service.ConfigLinter) {
+	h.linter = linter
+}
+
 // ListConfigs 获取配置列表
 func (h *ConfigHandler) ListConfigs(c *gin.Context) {
 	var req models.ConfigListRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
-		middleware.HandleError(c, http.StatusBadRequest, "INVALID_REQUEST", "请求参数无效")
+		middleware.HandleBindError(c, err, "请求参数无效")
 		return
 	}
 
@@ -39,29 +60,101 @@ func (h *ConfigHandler) ListConfigs(c *gin.Context) {
 
 	resp, err := h.configService.ListConfigs(c.Request.Context(), &req)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) {
+			middleware.AbortWithError(c, apperror.New(apperror.InvalidCursor, "游标无效"))
+			return
+		}
 		h.logger.Errorf("获取配置列表失败: %v", err)
-		middleware.HandleError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "获取配置列表失败")
+		middleware.AbortWithError(c, apperror.Wrap(err, "获取配置列表失败"))
 		return
 	}
 
 	c.JSON(http.StatusOK, resp)
 }
 
+// SearchConfigs 全文搜索配置名称、描述、标签和内容
+func (h *ConfigHandler) SearchConfigs(c *gin.Context) {
+	var req models.ConfigSearchRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		middleware.HandleBindError(c, err, "搜索关键词不能为空")
+		return
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "搜索关键词不能为空"))
+		return
+	}
+	if tags := c.QueryArray("tags[]"); len(tags) > 0 {
+		req.Tags = tags
+	}
+
+	resp, err := h.configService.SearchConfigs(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.Errorf("搜索配置失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "搜索配置失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetConfigStats 按类型、标签、测试状态和启用状态统计配置，用于展示筛选项和汇总
+func (h *ConfigHandler) GetConfigStats(c *gin.Context) {
+	var req models.ConfigStatsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+	if tags := c.QueryArray("tags[]"); len(tags) > 0 {
+		req.Tags = tags
+	}
+
+	stats, err := h.configService.ConfigStats(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.Errorf("统计配置失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "统计配置失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// ExportConfigs 以CSV或XLSX导出配置目录（不含配置内容），过滤条件与配置列表相同，逐页读取并流式写出
+func (h *ConfigHandler) ExportConfigs(c *gin.Context) {
+	var req models.ConfigExportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+	if tags := c.QueryArray("tags[]"); len(tags) > 0 {
+		req.Tags = tags
+	}
+	if req.Format == "" {
+		req.Format = tabular.FormatCSV
+	}
+
+	if err := h.configService.ExportConfigs(c.Request.Context(), &req, exportOpener(c, req.Format, "configs")); err != nil {
+		abortExport(c, h.logger, err, "导出配置目录失败")
+	}
+}
+
 // CreateConfig 创建配置
 func (h *ConfigHandler) CreateConfig(c *gin.Context) {
 	var req models.CreateConfigRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		middleware.HandleError(c, http.StatusBadRequest, "INVALID_REQUEST", "请求参数无效")
+		middleware.HandleBindError(c, err, "请求参数无效")
 		return
 	}
 
-	// TODO: 从JWT或会话中获取用户ID
-	userID := "admin"
+	userID := middleware.CurrentUserID(c)
 
 	config, err := h.configService.CreateConfig(c.Request.Context(), &req, userID)
 	if err != nil {
+		if strings.HasPrefix(err.Error(), "访问控制无效") {
+			middleware.AbortWithError(c, apperror.New(apperror.InvalidACL, err.Error()))
+			return
+		}
 		h.logger.Errorf("创建配置失败: %v", err)
-		middleware.HandleError(c, http.StatusInternalServerError, "CREATE_FAILED", err.Error())
+		middleware.AbortWithError(c, apperror.Wrap(err, "创建配置失败"))
 		return
 	}
 
@@ -72,49 +165,118 @@ func (h *ConfigHandler) CreateConfig(c *gin.Context) {
 func (h *ConfigHandler) GetConfig(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		middleware.HandleError(c, http.StatusBadRequest, "INVALID_REQUEST", "配置ID不能为空")
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "配置ID不能为空"))
 		return
 	}
 
 	config, err := h.configService.GetConfig(c.Request.Context(), id)
 	if err != nil {
 		if err.Error() == "文档不存在" {
-			middleware.HandleError(c, http.StatusNotFound, "NOT_FOUND", "配置不存在")
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "配置不存在"))
+			return
+		}
+		if abortIfForbidden(c, err) {
 			return
 		}
 		h.logger.Errorf("获取配置失败: %v", err)
-		middleware.HandleError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "获取配置失败")
+		middleware.AbortWithError(c, apperror.Wrap(err, "获取配置失败"))
 		return
 	}
 
-	c.JSON(http.StatusOK, config)
+	// 指定版本时返回该版本的历史内容，用于金丝雀回滚等恢复旧版本的场景
+	if v := c.Query("version"); v != "" {
+		version, err := strconv.Atoi(v)
+		if err != nil || version < 1 {
+			middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "版本号无效"))
+			return
+		}
+		if version != config.Version {
+			versioned, ok, err := configVersion(c.Request.Context(), h.configService, config, version)
+			if err != nil {
+				h.logger.Errorf("获取配置历史版本失败: %v", err)
+				middleware.AbortWithError(c, apperror.Wrap(err, "获取配置失败"))
+				return
+			}
+			if !ok {
+				middleware.AbortWithError(c, apperror.New(apperror.NotFound, "配置版本不存在"))
+				return
+			}
+			config = versioned
+		}
+	}
+
+	// Agent携带所在环境获取配置时，返回替换了下游集群引用的内容
+	if environment := c.Query("environment"); environment != "" && h.destinations != nil {
+		rendered, err := h.destinations.RenderConfig(c.Request.Context(), config, environment)
+		if err != nil {
+			handleRenderError(c, h.logger, err)
+			return
+		}
+		resolved := *config
+		resolved.Content = rendered.Content
+		config = &resolved
+	}
+
+	// 附带返回内容的哈希，Agent据此确认落盘的字节与下发的一致
+	hashed := *config
+	hashed.ContentHash = models.ContentHash(config.Content)
+	// 用户查看配置详情时附带静态检查结果，Agent拉取配置时不检查；检查失败不影响返回配置
+	if h.linter != nil && c.GetString(middleware.ContextUserRole) != models.RoleAgent {
+		lint, err := h.linter.LintConfig(c.Request.Context(), config, c.Query("environment"))
+		if err != nil {
+			h.logger.Warnf("配置静态检查失败: %v", err)
+		} else {
+			hashed.Lint = lint
+		}
+	}
+	// Agent携带上次响应的ETag请求时，内容未变化则返回304，省去重复下载较大的配置内容
+	respondWithETag(c, &hashed)
+}
+
+// configVersion 以指定历史版本的内容构造配置，版本不存在或已删除时返回false
+func configVersion(ctx context.Context, configService service.ConfigService, config *models.Config, version int) (*models.Config, bool, error) {
+	history, err := configService.GetConfigHistory(ctx, config.ID)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, record := range history {
+		if record.Version == version && record.ChangeType != "delete" {
+			versioned := *config
+			versioned.Version = record.Version
+			versioned.Content = record.Content
+			return &versioned, true, nil
+		}
+	}
+	return nil, false, nil
 }
 
 // UpdateConfig 更新配置
 func (h *ConfigHandler) UpdateConfig(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		middleware.HandleError(c, http.StatusBadRequest, "INVALID_REQUEST", "配置ID不能为空")
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "配置ID不能为空"))
 		return
 	}
 
 	var req models.UpdateConfigRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		middleware.HandleError(c, http.StatusBadRequest, "INVALID_REQUEST", "请求参数无效")
+		middleware.HandleBindError(c, err, "请求参数无效")
 		return
 	}
 
-	// TODO: 从JWT或会话中获取用户ID
-	userID := "admin"
+	userID := middleware.CurrentUserID(c)
 
 	config, err := h.configService.UpdateConfig(c.Request.Context(), id, &req, userID)
 	if err != nil {
 		if err.Error() == "配置不存在" {
-			middleware.HandleError(c, http.StatusNotFound, "NOT_FOUND", "配置不存在")
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "配置不存在"))
+			return
+		}
+		if abortIfForbidden(c, err) {
 			return
 		}
 		h.logger.Errorf("更新配置失败: %v", err)
-		middleware.HandleError(c, http.StatusInternalServerError, "UPDATE_FAILED", err.Error())
+		middleware.AbortWithError(c, apperror.Wrap(err, "更新配置失败"))
 		return
 	}
 
@@ -125,17 +287,35 @@ func (h *ConfigHandler) UpdateConfig(c *gin.Context) {
 func (h *ConfigHandler) DeleteConfig(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		middleware.HandleError(c, http.StatusBadRequest, "INVALID_REQUEST", "配置ID不能为空")
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "配置ID不能为空"))
 		return
 	}
 
-	if err := h.configService.DeleteConfig(c.Request.Context(), id); err != nil {
-		if err.Error() == "配置不存在" {
-			middleware.HandleError(c, http.StatusNotFound, "NOT_FOUND", "配置不存在")
+	// force=true 时先从运行该配置的Agent上移除配置，等待Agent确认后再删除
+	force := c.Query("force") == "true"
+	if err := h.configService.DeleteConfig(c.Request.Context(), id, force); err != nil {
+		if strings.HasPrefix(err.Error(), "配置不存在") {
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "配置不存在"))
+			return
+		}
+		if abortIfForbidden(c, err) {
+			return
+		}
+		var inUse *service.ConfigInUseError
+		if errors.As(err, &inUse) {
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+				"code":    "CONFIG_IN_USE",
+				"message": "配置仍在Agent上运行，使用 force=true 从Agent移除后删除",
+				"agents":  inUse.AgentIDs,
+			})
+			return
+		}
+		if errors.Is(err, service.ErrConfigRemoveFailed) {
+			middleware.AbortWithError(c, apperror.New(apperror.RemoveFailed, err.Error()))
 			return
 		}
 		h.logger.Errorf("删除配置失败: %v", err)
-		middleware.HandleError(c, http.StatusInternalServerError, "DELETE_FAILED", "删除配置失败")
+		middleware.AbortWithError(c, apperror.Wrap(err, "删除配置失败"))
 		return
 	}
 
@@ -146,18 +326,21 @@ func (h *ConfigHandler) DeleteConfig(c *gin.Context) {
 func (h *ConfigHandler) GetConfigHistory(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		middleware.HandleError(c, http.StatusBadRequest, "INVALID_REQUEST", "配置ID不能为空")
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "配置ID不能为空"))
 		return
 	}
 
 	history, err := h.configService.GetConfigHistory(c.Request.Context(), id)
 	if err != nil {
 		if err.Error() == "配置不存在" {
-			middleware.HandleError(c, http.StatusNotFound, "NOT_FOUND", "配置不存在")
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "配置不存在"))
+			return
+		}
+		if abortIfForbidden(c, err) {
 			return
 		}
 		h.logger.Errorf("获取配置历史失败: %v", err)
-		middleware.HandleError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "获取配置历史失败")
+		middleware.AbortWithError(c, apperror.Wrap(err, "获取配置历史失败"))
 		return
 	}
 
@@ -167,11 +350,47 @@ func (h *ConfigHandler) GetConfigHistory(c *gin.Context) {
 	})
 }
 
+// DiffConfig 比较配置的两个版本，查询参数to省略时与当前版本比较
+func (h *ConfigHandler) DiffConfig(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "配置ID不能为空"))
+		return
+	}
+
+	var req struct {
+		From int `form:"from" binding:"required,min=1"`
+		To   int `form:"to" binding:"omitempty,min=1"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	diff, err := h.configService.DiffVersions(c.Request.Context(), id, req.From, req.To)
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "配置不存在"):
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "配置不存在"))
+		case errors.Is(err, service.ErrConfigForbidden):
+			abortIfForbidden(c, err)
+		case strings.HasPrefix(err.Error(), "未找到版本"):
+			middleware.AbortWithError(c, apperror.New(apperror.VersionNotFound, err.Error()))
+		default:
+			h.logger.Errorf("比较配置版本失败: %v", err)
+			middleware.AbortWithError(c, apperror.Wrap(err, "比较配置版本失败"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
 // RollbackConfig 回滚配置
 func (h *ConfigHandler) RollbackConfig(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		middleware.HandleError(c, http.StatusBadRequest, "INVALID_REQUEST", "配置ID不能为空")
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "配置ID不能为空"))
 		return
 	}
 
@@ -179,19 +398,129 @@ func (h *ConfigHandler) RollbackConfig(c *gin.Context) {
 		Version int `json:"version" binding:"required,min=1"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		middleware.HandleError(c, http.StatusBadRequest, "INVALID_REQUEST", "请求参数无效")
+		middleware.HandleBindError(c, err, "请求参数无效")
 		return
 	}
 
-	// TODO: 从JWT或会话中获取用户ID
-	userID := "admin"
+	userID := middleware.CurrentUserID(c)
 
 	config, err := h.configService.RollbackConfig(c.Request.Context(), id, req.Version, userID)
 	if err != nil {
+		if abortIfForbidden(c, err) {
+			return
+		}
 		h.logger.Errorf("回滚配置失败: %v", err)
-		middleware.HandleError(c, http.StatusInternalServerError, "ROLLBACK_FAILED", err.Error())
+		middleware.AbortWithError(c, apperror.Wrap(err, "回滚配置失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, config)
+}
+// SetConfigACL 设置配置的访问控制，各列表均为空时取消限制
+func (h *ConfigHandler) SetConfigACL(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "配置ID不能为空"))
+		return
+	}
+
+	var acl models.ConfigACL
+	if err := c.ShouldBindJSON(&acl); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	config, err := h.configService.SetConfigACL(c.Request.Context(), id, &acl, middleware.CurrentUserID(c))
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "配置不存在"):
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "配置不存在"))
+		case errors.Is(err, service.ErrConfigForbidden):
+			abortIfForbidden(c, err)
+		case strings.HasPrefix(err.Error(), "访问控制无效"):
+			middleware.AbortWithError(c, apperror.New(apperror.InvalidACL, err.Error()))
+		default:
+			h.logger.Errorf("更新配置访问控制失败: %v", err)
+			middleware.AbortWithError(c, apperror.Wrap(err, "更新配置访问控制失败"))
+		}
 		return
 	}
 
 	c.JSON(http.StatusOK, config)
-}
\ No newline at end of file
+}
+
+// SetScheduledTest 开启或关闭配置的定期测试
+func (h *ConfigHandler) SetScheduledTest(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "配置ID不能为空"))
+		return
+	}
+
+	var req models.SetScheduledTestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	config, err := h.configService.SetScheduledTest(c.Request.Context(), id, *req.Enabled, middleware.CurrentUserID(c))
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "配置不存在"):
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "配置不存在"))
+		case errors.Is(err, service.ErrConfigForbidden):
+			abortIfForbidden(c, err)
+		default:
+			h.logger.Errorf("设置配置定期测试失败: %v", err)
+			middleware.AbortWithError(c, apperror.Wrap(err, "设置配置定期测试失败"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, config)
+}
+
+// respondWithETag 返回JSON响应并附带按响应体计算的ETag，请求的 If-None-Match 与之匹配时返回304
+// 使用弱ETag：响应经压缩后字节不同，但表示的内容相同
+func respondWithETag(c *gin.Context, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		middleware.AbortWithError(c, apperror.Wrap(err, "编码响应失败"))
+		return
+	}
+	sum := sha256.Sum256(body)
+	etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+
+	c.Header("ETag", etag)
+	// 允许缓存但每次使用前须向平台确认，内容可能包含敏感信息，只允许客户端私有缓存
+	c.Header("Cache-Control", "private, no-cache")
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// etagMatches If-None-Match 是否包含指定ETag，按弱比较忽略 W/ 前缀
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	opaque := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == opaque {
+			return true
+		}
+	}
+	return false
+}
+
+// abortIfForbidden 配置级访问控制拒绝时返回403
+func abortIfForbidden(c *gin.Context, err error) bool {
+	if !errors.Is(err, service.ErrConfigForbidden) {
+		return false
+	}
+	middleware.AbortWithError(c, apperror.New(apperror.Forbidden, "无权访问该配置"))
+	return true
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -12,7 +13,11 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+	"logstash-platform/internal/platform/service"
+	"logstash-platform/pkg/tabular"
 )
 
 // MockConfigService is a mock implementation of ConfigService
@@ -36,8 +41,8 @@ func (m *MockConfigService) UpdateConfig(ctx context.Context, id string, req *mo
 	return args.Get(0).(*models.Config), args.Error(1)
 }
 
-func (m *MockConfigService) DeleteConfig(ctx context.Context, id string) error {
-	args := m.Called(ctx, id)
+func (m *MockConfigService) DeleteConfig(ctx context.Context, id string, force bool) error {
+	args := m.Called(ctx, id, force)
 	return args.Error(0)
 }
 
@@ -57,6 +62,27 @@ func (m *MockConfigService) ListConfigs(ctx context.Context, req *models.ConfigL
 	return args.Get(0).(*models.ConfigListResponse), args.Error(1)
 }
 
+func (m *MockConfigService) ConfigStats(ctx context.Context, req *models.ConfigStatsRequest) (*models.ConfigStats, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ConfigStats), args.Error(1)
+}
+
+func (m *MockConfigService) ExportConfigs(ctx context.Context, req *models.ConfigExportRequest, open func() (tabular.Writer, error)) error {
+	args := m.Called(ctx, req, open)
+	return args.Error(0)
+}
+
+func (m *MockConfigService) SearchConfigs(ctx context.Context, req *models.ConfigSearchRequest) (*models.ConfigSearchResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ConfigSearchResponse), args.Error(1)
+}
+
 func (m *MockConfigService) GetConfigHistory(ctx context.Context, configID string) ([]*models.ConfigHistory, error) {
 	args := m.Called(ctx, configID)
 	if args.Get(0) == nil {
@@ -65,6 +91,47 @@ func (m *MockConfigService) GetConfigHistory(ctx context.Context, configID strin
 	return args.Get(0).([]*models.ConfigHistory), args.Error(1)
 }
 
+func (m *MockConfigService) DiffVersions(ctx context.Context, configID string, from, to int) (*service.ConfigDiff, error) {
+	args := m.Called(ctx, configID, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.ConfigDiff), args.Error(1)
+}
+
+func (m *MockConfigService) SetConfigACL(ctx context.Context, configID string, acl *models.ConfigACL, userID string) (*models.Config, error) {
+	args := m.Called(ctx, configID, acl, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Config), args.Error(1)
+}
+
+func (m *MockConfigService) SetScheduledTest(ctx context.Context, configID string, enabled bool, userID string) (*models.Config, error) {
+	args := m.Called(ctx, configID, enabled, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Config), args.Error(1)
+}
+
+func (m *MockConfigService) RecordTestResult(ctx context.Context, configID string, version int, status models.TestStatus) error {
+	args := m.Called(ctx, configID, version, status)
+	return args.Error(0)
+}
+
+func (m *MockConfigService) SetDeleteGuard(agentRepo repository.AgentRepository, remover service.ConfigRemover) {
+	m.Called(agentRepo, remover)
+}
+
+func (m *MockConfigService) SetEventEmitter(events service.EventEmitter) {
+	m.Called(events)
+}
+
+func (m *MockConfigService) SetMaxContentSize(limit int) {
+	m.Called(limit)
+}
+
 func (m *MockConfigService) RollbackConfig(ctx context.Context, configID string, version int, userID string) (*models.Config, error) {
 	args := m.Called(ctx, configID, version, userID)
 	if args.Get(0) == nil {
@@ -78,6 +145,127 @@ func setupTestRouter() *gin.Engine {
 	return gin.New()
 }
 
+func TestConfigHandler_SearchConfigs(t *testing.T) {
+	logger := logrus.New()
+
+	t.Run("按关键词和过滤条件搜索", func(t *testing.T) {
+		mockService := new(MockConfigService)
+		mockService.On("SearchConfigs", mock.Anything, mock.MatchedBy(func(req *models.ConfigSearchRequest) bool {
+			return req.Query == "nginx grok" && req.Type == models.ConfigTypeFilter && req.PageSize == 5
+		})).Return(&models.ConfigSearchResponse{
+			Total: 1,
+			Page:  1,
+			Size:  5,
+			Items: []*models.ConfigSearchHit{{
+				Config:     &models.Config{ID: "1", Name: "nginx"},
+				Score:      1.5,
+				Highlights: map[string][]string{"content": {"<em>grok</em> { }"}},
+			}},
+		}, nil)
+
+		router := setupTestRouter()
+		router.GET("/configs/search", NewConfigHandler(mockService, logger).SearchConfigs)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/configs/search?q=nginx+grok&type=filter&size=5", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var body map[string]interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		item := body["items"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "nginx", item["name"])
+		assert.Equal(t, []interface{}{"<em>grok</em> { }"}, item["highlights"].(map[string]interface{})["content"])
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("缺少关键词", func(t *testing.T) {
+		mockService := new(MockConfigService)
+		router := setupTestRouter()
+		router.GET("/configs/search", NewConfigHandler(mockService, logger).SearchConfigs)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/configs/search?q=+", nil))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "SearchConfigs", mock.Anything, mock.Anything)
+	})
+}
+
+func TestConfigHandler_ExportConfigs(t *testing.T) {
+	logger := logrus.New()
+	writeRows := func(rows ...[]string) func(mock.Arguments) {
+		return func(args mock.Arguments) {
+			w, err := args.Get(2).(func() (tabular.Writer, error))()
+			require.NoError(t, err)
+			for _, row := range rows {
+				require.NoError(t, w.WriteRow(row))
+			}
+			require.NoError(t, w.Flush())
+		}
+	}
+	export := func(mockService *MockConfigService, query string) *httptest.ResponseRecorder {
+		router := setupTestRouter()
+		router.GET("/configs/export", NewConfigHandler(mockService, logger).ExportConfigs)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/configs/export"+query, nil))
+		return w
+	}
+
+	t.Run("默认导出CSV附件", func(t *testing.T) {
+		mockService := new(MockConfigService)
+		mockService.On("ExportConfigs", mock.Anything, mock.MatchedBy(func(req *models.ConfigExportRequest) bool {
+			return req.Format == tabular.FormatCSV && req.Columns == "id,name" && req.Type == models.ConfigTypeFilter
+		}), mock.Anything).Run(writeRows([]string{"id", "name"}, []string{"1", "nginx"})).Return(nil)
+
+		w := export(mockService, "?type=filter&columns=id,name")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Regexp(t, `^attachment; filename=configs-\d{8}-\d{6}\.csv$`, w.Header().Get("Content-Disposition"))
+		assert.Equal(t, "\ufeffid,name\n1,nginx\n", w.Body.String())
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("不支持的格式", func(t *testing.T) {
+		mockService := new(MockConfigService)
+		w := export(mockService, "?format=pdf")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "ExportConfigs", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("无效的列", func(t *testing.T) {
+		mockService := new(MockConfigService)
+		mockService.On("ExportConfigs", mock.Anything, mock.Anything, mock.Anything).
+			Return(fmt.Errorf("%w: %q", service.ErrInvalidExportColumn, "content"))
+
+		w := export(mockService, "?columns=content")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "INVALID_EXPORT_COLUMN")
+		assert.Empty(t, w.Header().Get("Content-Disposition"))
+	})
+
+	t.Run("写出前失败返回错误", func(t *testing.T) {
+		mockService := new(MockConfigService)
+		mockService.On("ExportConfigs", mock.Anything, mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				// XLSX写出器在刷新前不写出响应体
+				_, err := args.Get(2).(func() (tabular.Writer, error))()
+				require.NoError(t, err)
+			}).Return(fmt.Errorf("搜索配置失败"))
+
+		w := export(mockService, "?format=xlsx")
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Empty(t, w.Header().Get("Content-Disposition"))
+	})
+
+	t.Run("写出后失败截断响应", func(t *testing.T) {
+		mockService := new(MockConfigService)
+		mockService.On("ExportConfigs", mock.Anything, mock.Anything, mock.Anything).
+			Run(writeRows([]string{"id"})).Return(fmt.Errorf("搜索配置失败"))
+
+		w := export(mockService, "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "\ufeffid\n", w.Body.String())
+	})
+}
+
 func TestConfigHandler_CreateConfig(t *testing.T) {
 	logger := logrus.New()
 	
@@ -142,7 +330,8 @@ func TestConfigHandler_CreateConfig(t *testing.T) {
 			},
 			expectedCode: http.StatusInternalServerError,
 			checkBody: func(t *testing.T, body map[string]interface{}) {
-				assert.Equal(t, "CREATE_FAILED", body["code"])
+				assert.Equal(t, "INTERNAL_ERROR", body["code"])
+				assert.Equal(t, "创建配置失败", body["message"], "内部错误的细节不返回给调用方")
 			},
 		},
 	}
@@ -306,6 +495,36 @@ func TestConfigHandler_GetConfig(t *testing.T) {
 				assert.Equal(t, "INTERNAL_ERROR", body["code"])
 			},
 		},
+		{
+			name: "get historical version",
+			id:   "config-123?version=1",
+			setup: func(m *MockConfigService) {
+				m.On("GetConfig", mock.Anything, "config-123").
+					Return(&models.Config{ID: "config-123", Content: "filter { mutate {} }", Version: 2}, nil)
+				m.On("GetConfigHistory", mock.Anything, "config-123").
+					Return([]*models.ConfigHistory{
+						{ConfigID: "config-123", Version: 2, Content: "filter { mutate {} }", ChangeType: "update"},
+						{ConfigID: "config-123", Version: 1, Content: "filter { }", ChangeType: "create"},
+					}, nil)
+			},
+			expectedCode: http.StatusOK,
+			checkBody: func(t *testing.T, body map[string]interface{}) {
+				assert.EqualValues(t, 1, body["version"])
+				assert.Equal(t, "filter { }", body["content"])
+			},
+		},
+		{
+			name: "unknown version",
+			id:   "config-123?version=7",
+			setup: func(m *MockConfigService) {
+				m.On("GetConfig", mock.Anything, "config-123").
+					Return(&models.Config{ID: "config-123", Version: 2}, nil)
+				m.On("GetConfigHistory", mock.Anything, "config-123").
+					Return([]*models.ConfigHistory{}, nil)
+			},
+			expectedCode: http.StatusNotFound,
+			checkBody:    func(t *testing.T, body map[string]interface{}) {},
+		},
 		{
 			name:         "empty id",
 			id:           "",
@@ -347,6 +566,39 @@ func TestConfigHandler_GetConfig(t *testing.T) {
 	}
 }
 
+func TestConfigHandler_GetConfigETag(t *testing.T) {
+	mockService := new(MockConfigService)
+	mockService.On("GetConfig", mock.Anything, "config-123").
+		Return(&models.Config{ID: "config-123", Content: "filter { }", Version: 3}, nil)
+
+	handler := NewConfigHandler(mockService, logrus.New())
+	router := setupTestRouter()
+	router.GET("/configs/:id", handler.GetConfig)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/configs/config-123", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	// 携带相同ETag时返回304且没有响应体
+	req := httptest.NewRequest("GET", "/configs/config-123", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.Bytes())
+	assert.Equal(t, etag, w.Header().Get("ETag"))
+
+	// ETag不匹配时返回完整内容
+	req = httptest.NewRequest("GET", "/configs/config-123", nil)
+	req.Header.Set("If-None-Match", `W/"stale"`)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"content_hash"`)
+}
+
 func TestConfigHandler_UpdateConfig(t *testing.T) {
 	logger := logrus.New()
 	
@@ -448,7 +700,7 @@ func TestConfigHandler_DeleteConfig(t *testing.T) {
 			name: "successful delete",
 			id:   "config-123",
 			setup: func(m *MockConfigService) {
-				m.On("DeleteConfig", mock.Anything, "config-123").Return(nil)
+				m.On("DeleteConfig", mock.Anything, "config-123", false).Return(nil)
 			},
 			expectedCode: http.StatusNoContent,
 		},
@@ -456,10 +708,27 @@ func TestConfigHandler_DeleteConfig(t *testing.T) {
 			name: "delete failure",
 			id:   "config-123",
 			setup: func(m *MockConfigService) {
-				m.On("DeleteConfig", mock.Anything, "config-123").Return(assert.AnError)
+				m.On("DeleteConfig", mock.Anything, "config-123", false).Return(assert.AnError)
 			},
 			expectedCode: http.StatusInternalServerError,
 		},
+		{
+			name: "config in use",
+			id:   "config-123",
+			setup: func(m *MockConfigService) {
+				m.On("DeleteConfig", mock.Anything, "config-123", false).
+					Return(&service.ConfigInUseError{ConfigID: "config-123", AgentIDs: []string{"agent-1"}})
+			},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name: "force delete",
+			id:   "config-123?force=true",
+			setup: func(m *MockConfigService) {
+				m.On("DeleteConfig", mock.Anything, "config-123", true).Return(nil)
+			},
+			expectedCode: http.StatusNoContent,
+		},
 	}
 
 	for _, tt := range tests {
@@ -575,6 +844,97 @@ func TestConfigHandler_GetConfigHistory(t *testing.T) {
 	}
 }
 
+func TestConfigHandler_DiffConfig(t *testing.T) {
+	logger := logrus.New()
+
+	tests := []struct {
+		name         string
+		query        string
+		setup        func(*MockConfigService)
+		expectedCode int
+		checkBody    func(*testing.T, map[string]interface{})
+	}{
+		{
+			name:  "successful diff",
+			query: "?from=2&to=5",
+			setup: func(m *MockConfigService) {
+				m.On("DiffVersions", mock.Anything, "config-123", 2, 5).
+					Return(&service.ConfigDiff{
+						ConfigID: "config-123",
+						From:     2,
+						To:       5,
+						Unified:  "--- config-123@v2\n+++ config-123@v5\n@@ -1,1 +1,1 @@\n-filter { old }\n+filter { new }\n",
+						Hunks:    []service.DiffHunk{{OldStart: 1, OldLines: 1, NewStart: 1, NewLines: 1}},
+						Metadata: []service.MetadataChange{{Field: "enabled", From: true, To: false}},
+					}, nil)
+			},
+			expectedCode: http.StatusOK,
+			checkBody: func(t *testing.T, body map[string]interface{}) {
+				assert.Equal(t, float64(5), body["to"])
+				assert.Len(t, body["hunks"], 1)
+				assert.Len(t, body["metadata"], 1)
+			},
+		},
+		{
+			name:  "to defaults to current version",
+			query: "?from=2",
+			setup: func(m *MockConfigService) {
+				m.On("DiffVersions", mock.Anything, "config-123", 2, 0).
+					Return(&service.ConfigDiff{ConfigID: "config-123", From: 2, To: 3}, nil)
+			},
+			expectedCode: http.StatusOK,
+			checkBody: func(t *testing.T, body map[string]interface{}) {
+				assert.Equal(t, float64(3), body["to"])
+			},
+		},
+		{
+			name:         "missing from",
+			query:        "?to=5",
+			setup:        func(m *MockConfigService) {},
+			expectedCode: http.StatusBadRequest,
+			checkBody: func(t *testing.T, body map[string]interface{}) {
+				assert.Equal(t, "INVALID_REQUEST", body["code"])
+			},
+		},
+		{
+			name:  "version not found",
+			query: "?from=9&to=5",
+			setup: func(m *MockConfigService) {
+				m.On("DiffVersions", mock.Anything, "config-123", 9, 5).
+					Return(nil, fmt.Errorf("未找到版本 9 的历史记录"))
+			},
+			expectedCode: http.StatusNotFound,
+			checkBody: func(t *testing.T, body map[string]interface{}) {
+				assert.Equal(t, "VERSION_NOT_FOUND", body["code"])
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockConfigService)
+			tt.setup(mockService)
+
+			handler := NewConfigHandler(mockService, logger)
+			router := setupTestRouter()
+			router.GET("/configs/:id/diff", handler.DiffConfig)
+
+			req := httptest.NewRequest("GET", "/configs/config-123/diff"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+
+			var responseBody map[string]interface{}
+			err := json.Unmarshal(w.Body.Bytes(), &responseBody)
+			assert.NoError(t, err)
+			tt.checkBody(t, responseBody)
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
 func TestConfigHandler_RollbackConfig(t *testing.T) {
 	logger := logrus.New()
 	
@@ -660,6 +1020,75 @@ func TestConfigHandler_RollbackConfig(t *testing.T) {
 			assert.NoError(t, err)
 			tt.checkBody(t, responseBody)
 			
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestConfigHandler_SetScheduledTest(t *testing.T) {
+	logger := logrus.New()
+
+	tests := []struct {
+		name         string
+		body         string
+		setup        func(*MockConfigService)
+		expectedCode int
+	}{
+		{
+			name: "开启定期测试",
+			body: `{"enabled": true}`,
+			setup: func(m *MockConfigService) {
+				m.On("SetScheduledTest", mock.Anything, "config-123", true, "admin").
+					Return(&models.Config{ID: "config-123", ScheduledTest: true}, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "缺少enabled",
+			body:         `{}`,
+			setup:        func(m *MockConfigService) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "无编辑权限",
+			body: `{"enabled": false}`,
+			setup: func(m *MockConfigService) {
+				m.On("SetScheduledTest", mock.Anything, "config-123", false, "admin").
+					Return(nil, fmt.Errorf("%w: config-123", service.ErrConfigForbidden))
+			},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name: "配置不存在",
+			body: `{"enabled": true}`,
+			setup: func(m *MockConfigService) {
+				m.On("SetScheduledTest", mock.Anything, "config-123", true, "admin").
+					Return(nil, fmt.Errorf("配置不存在: 文档不存在"))
+			},
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockConfigService)
+			tt.setup(mockService)
+
+			handler := NewConfigHandler(mockService, logger)
+			router := setupTestRouter()
+			router.PUT("/configs/:id/scheduled-test", handler.SetScheduledTest)
+
+			req := httptest.NewRequest("PUT", "/configs/config-123/scheduled-test", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedCode == http.StatusOK {
+				var config models.Config
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &config))
+				assert.True(t, config.ScheduledTest)
+			}
 			mockService.AssertExpectations(t)
 		})
 	}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/service"
+)
+
+// ConfigUsageHandler 配置使用情况处理器
+type ConfigUsageHandler struct {
+	usage  service.ConfigUsageService
+	logger *logrus.Logger
+}
+
+// NewConfigUsageHandler 创建配置使用情况处理器
+func NewConfigUsageHandler(usage service.ConfigUsageService, logger *logrus.Logger) *ConfigUsageHandler {
+	return &ConfigUsageHandler{
+		usage:  usage,
+		logger: logger,
+	}
+}
+
+// ListConfigAgents 获取运行配置的Agent及其版本
+func (h *ConfigUsageHandler) ListConfigAgents(c *gin.Context) {
+	usage, err := h.usage.ConfigAgents(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrConfigNotFound):
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "配置不存在"))
+		case errors.Is(err, service.ErrConfigForbidden):
+			abortIfForbidden(c, err)
+		default:
+			h.logger.Errorf("获取配置使用情况失败: %v", err)
+			middleware.AbortWithError(c, apperror.Wrap(err, "获取配置使用情况失败"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
+
+// ListAgentConfigs 获取Agent运行的配置及其版本
+func (h *ConfigUsageHandler) ListAgentConfigs(c *gin.Context) {
+	items, err := h.usage.AgentConfigs(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.logger.Errorf("获取Agent运行的配置失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "获取Agent运行的配置失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"agent_id": c.Param("id"),
+		"items":    items,
+		"total":    len(items),
+	})
+}
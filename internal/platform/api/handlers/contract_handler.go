@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+)
+
+// ContractHandler 字段契约处理器
+type ContractHandler struct {
+	contracts service.ContractService
+	logger    *logrus.Logger
+}
+
+// NewContractHandler 创建字段契约处理器
+func NewContractHandler(contracts service.ContractService, logger *logrus.Logger) *ContractHandler {
+	return &ContractHandler{
+		contracts: contracts,
+		logger:    logger,
+	}
+}
+
+// ListContracts 获取字段契约列表
+func (h *ContractHandler) ListContracts(c *gin.Context) {
+	contracts, err := h.contracts.ListContracts(c.Request.Context())
+	if err != nil {
+		h.logger.Errorf("获取字段契约列表失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "获取字段契约列表失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items": contracts,
+		"total": len(contracts),
+	})
+}
+
+// CreateContract 登记字段契约
+func (h *ContractHandler) CreateContract(c *gin.Context) {
+	var req models.CreateFieldContractRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	contract, err := h.contracts.CreateContract(c.Request.Context(), &req, middleware.CurrentUserID(c))
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "字段契约已存在"):
+			middleware.AbortWithError(c, apperror.New(apperror.AlreadyExists, err.Error()))
+		case strings.HasPrefix(err.Error(), "字段契约验证失败"):
+			middleware.AbortWithError(c, apperror.New(apperror.Validation, err.Error()))
+		default:
+			h.logger.Errorf("登记字段契约失败: %v", err)
+			middleware.AbortWithError(c, apperror.Wrap(err, "登记字段契约失败"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, contract)
+}
+
+// GetContract 获取单个字段契约
+func (h *ContractHandler) GetContract(c *gin.Context) {
+	contract, err := h.contracts.GetContract(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		if err.Error() == "文档不存在" {
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "字段契约不存在"))
+			return
+		}
+		h.logger.Errorf("获取字段契约失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "获取字段契约失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, contract)
+}
+
+// UpdateContract 更新字段契约
+func (h *ContractHandler) UpdateContract(c *gin.Context) {
+	var req models.UpdateFieldContractRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	contract, err := h.contracts.UpdateContract(c.Request.Context(), c.Param("name"), &req, middleware.CurrentUserID(c))
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "字段契约不存在"):
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "字段契约不存在"))
+		case strings.HasPrefix(err.Error(), "字段契约验证失败"):
+			middleware.AbortWithError(c, apperror.New(apperror.Validation, err.Error()))
+		default:
+			h.logger.Errorf("更新字段契约失败: %v", err)
+			middleware.AbortWithError(c, apperror.Wrap(err, "更新字段契约失败"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, contract)
+}
+
+// DeleteContract 删除字段契约
+func (h *ContractHandler) DeleteContract(c *gin.Context) {
+	if err := h.contracts.DeleteContract(c.Request.Context(), c.Param("name")); err != nil {
+		if strings.HasPrefix(err.Error(), "字段契约不存在") {
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "字段契约不存在"))
+			return
+		}
+		h.logger.Errorf("删除字段契约失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "删除字段契约失败"))
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
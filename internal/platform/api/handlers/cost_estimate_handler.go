@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+)
+
+// CostEstimateHandler 部署前资源估算处理器
+type CostEstimateHandler struct {
+	estimator service.CostEstimator
+	logger    *logrus.Logger
+}
+
+// NewCostEstimateHandler 创建部署前资源估算处理器
+func NewCostEstimateHandler(estimator service.CostEstimator, logger *logrus.Logger) *CostEstimateHandler {
+	return &CostEstimateHandler{
+		estimator: estimator,
+		logger:    logger,
+	}
+}
+
+// EstimateCost 按基准测试结果和预期速率估算配置所需资源，并检查目标Agent余量
+// 余量不足只在结果中告警，不阻止后续部署
+func (h *CostEstimateHandler) EstimateCost(c *gin.Context) {
+	configID := c.Param("id")
+	if configID == "" {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "配置ID不能为空"))
+		return
+	}
+
+	var req models.CostEstimateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	estimate, err := h.estimator.Estimate(c.Request.Context(), configID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidSelector):
+			middleware.AbortWithError(c, apperror.New(apperror.InvalidSelector, err.Error()))
+		case errors.Is(err, service.ErrConfigNotFound):
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "配置不存在"))
+		case strings.HasPrefix(err.Error(), "Agent不存在"):
+			middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, err.Error()))
+		default:
+			h.logger.Errorf("估算资源失败: %v", err)
+			middleware.AbortWithError(c, apperror.Wrap(err, "估算资源失败"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, estimate)
+}
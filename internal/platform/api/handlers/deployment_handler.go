@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+)
+
+// DeploymentHandler 部署记录处理器
+type DeploymentHandler struct {
+	deploymentService service.DeploymentService
+	engine            *service.DeploymentEngine
+	logger            *logrus.Logger
+}
+
+// NewDeploymentHandler 创建部署记录处理器
+func NewDeploymentHandler(deploymentService service.DeploymentService, engine *service.DeploymentEngine, logger *logrus.Logger) *DeploymentHandler {
+	return &DeploymentHandler{
+		deploymentService: deploymentService,
+		engine:            engine,
+		logger:            logger,
+	}
+}
+
+// CreateDeployment 创建部署，向目标Agent下发配置并跟踪进度
+func (h *DeploymentHandler) CreateDeployment(c *gin.Context) {
+	var req models.CreateDeploymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	if len(req.AgentIDs) == 0 && req.Selector == "" {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "必须指定Agent ID列表或标签选择器"))
+		return
+	}
+
+	userID := middleware.CurrentUserID(c)
+
+	deployment, err := h.engine.Start(c.Request.Context(), &req, userID)
+	if err != nil {
+		h.abortWithDeployError(c, err, "创建部署失败")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, deployment)
+}
+
+// PreviewDeployment 预览部署：各目标Agent渲染后的配置、与当前运行版本的差异和警告，不下发任何消息
+// 请求体与创建部署相同，确认后以同样的请求体创建部署
+func (h *DeploymentHandler) PreviewDeployment(c *gin.Context) {
+	var req models.CreateDeploymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	if len(req.AgentIDs) == 0 && req.Selector == "" {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "必须指定Agent ID列表或标签选择器"))
+		return
+	}
+
+	preview, err := h.engine.Preview(c.Request.Context(), &req)
+	if err != nil {
+		h.abortWithDeployError(c, err, "预览部署失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
+
+// abortWithDeployError 将创建或预览部署的错误映射为HTTP错误
+func (h *DeploymentHandler) abortWithDeployError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, models.ErrInvalidSelector):
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidSelector, err.Error()))
+	case errors.Is(err, service.ErrNoTargets):
+		middleware.AbortWithError(c, apperror.New(apperror.NoTargets, "没有匹配的Agent"))
+	case errors.Is(err, service.ErrInvalidStrategy):
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidStrategy, err.Error()))
+	case errors.Is(err, service.ErrProjectMismatch):
+		middleware.AbortWithError(c, apperror.New(apperror.ProjectMismatch, err.Error()))
+	case errors.Is(err, service.ErrConfigNotFound):
+		middleware.AbortWithError(c, apperror.New(apperror.NotFound, "配置不存在"))
+	case errors.Is(err, service.ErrConfigForbidden):
+		middleware.AbortWithError(c, apperror.New(apperror.Forbidden, "无权部署该配置"))
+	case errors.Is(err, service.ErrConfigDisabled):
+		middleware.AbortWithError(c, apperror.New(apperror.ConfigDisabled, "配置已禁用，无法部署"))
+	case errors.Is(err, models.ErrInvalidConfigID):
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidConfigID, err.Error()))
+	case errors.Is(err, service.ErrConfigNotApproved):
+		middleware.AbortWithError(c, apperror.New(apperror.ConfigNotApproved, err.Error()))
+	case errors.Is(err, service.ErrTestGateFailed):
+		middleware.AbortWithError(c, apperror.New(apperror.TestGateFailed, err.Error()))
+	case errors.Is(err, service.ErrTestGateOverride):
+		middleware.AbortWithError(c, apperror.New(apperror.TestGateOverrideForbidden, err.Error()))
+	case errors.Is(err, service.ErrPluginsMissing):
+		middleware.AbortWithError(c, apperror.New(apperror.PluginsMissing, err.Error()))
+	default:
+		h.logger.Errorf("%s: %v", message, err)
+		middleware.AbortWithError(c, apperror.Wrap(err, message))
+	}
+}
+
+// RollbackDeployment 回滚已结束的部署，向各Agent恢复部署前的版本，返回跟踪回滚进度的新部署记录
+func (h *DeploymentHandler) RollbackDeployment(c *gin.Context) {
+	deployment, err := h.engine.Rollback(c.Request.Context(), c.Param("id"), middleware.CurrentUserID(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrDeploymentNotFound):
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "部署不存在"))
+		case errors.Is(err, service.ErrRollbackNotAllowed):
+			middleware.AbortWithError(c, apperror.New(apperror.RollbackNotAllowed, err.Error()))
+		case errors.Is(err, service.ErrNoTargets):
+			middleware.AbortWithError(c, apperror.New(apperror.NoTargets, err.Error()))
+		case errors.Is(err, service.ErrConfigNotFound):
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "配置不存在"))
+		case errors.Is(err, service.ErrConfigForbidden):
+			middleware.AbortWithError(c, apperror.New(apperror.Forbidden, "无权部署该配置"))
+		default:
+			h.logger.Errorf("回滚部署失败: %v", err)
+			middleware.AbortWithError(c, apperror.Wrap(err, "回滚部署失败"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, deployment)
+}
+
+// ReportConfigApplied Agent上报配置应用结果
+func (h *DeploymentHandler) ReportConfigApplied(c *gin.Context) {
+	agentID := c.Param("id")
+	if agentID == "" {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "Agent ID不能为空"))
+		return
+	}
+
+	var report models.ConfigAppliedReport
+	if err := c.ShouldBindJSON(&report); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	if err := h.engine.RecordResult(c.Request.Context(), agentID, &report); err != nil {
+		switch {
+		case errors.Is(err, service.ErrDeploymentNotFound):
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "部署不存在"))
+		case errors.Is(err, service.ErrNotDeploymentTarget):
+			middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, err.Error()))
+		default:
+			h.logger.Errorf("记录配置应用结果失败: %v", err)
+			middleware.AbortWithError(c, apperror.Wrap(err, "记录配置应用结果失败"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "recorded"})
+}
+
+// DesiredConfigs Agent获取其应运行的配置及版本，启动或重新连接后据此补齐错过的部署和删除
+func (h *DeploymentHandler) DesiredConfigs(c *gin.Context) {
+	desired, err := h.deploymentService.DesiredConfigs(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.logger.Errorf("获取Agent期望配置失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "获取Agent期望配置失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, desired)
+}
+
+// ListDeployments 获取部署记录列表
+func (h *DeploymentHandler) ListDeployments(c *gin.Context) {
+	var req models.DeploymentListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	items, total, err := h.deploymentService.ListDeployments(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.Errorf("获取部署记录列表失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "获取部署记录列表失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total": total,
+		"page":  req.Page,
+		"size":  req.PageSize,
+		"items": items,
+	})
+}
+
+// GetDeployment 获取单个部署记录
+func (h *DeploymentHandler) GetDeployment(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "部署ID不能为空"))
+		return
+	}
+
+	deployment, err := h.deploymentService.GetDeployment(c.Request.Context(), id)
+	if err != nil {
+		if err.Error() == "文档不存在" {
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "部署不存在"))
+			return
+		}
+		h.logger.Errorf("获取部署记录失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "获取部署记录失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, deployment)
+}
+
+// ApproveDeployment 提交部署审批结论，审批人为当前登录用户
+func (h *DeploymentHandler) ApproveDeployment(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "部署ID不能为空"))
+		return
+	}
+
+	var req models.ApproveDeploymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	deployment, err := h.engine.RecordApproval(c.Request.Context(), id, middleware.CurrentUserID(c), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrDeploymentNotFound) {
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "部署不存在"))
+			return
+		}
+		h.logger.Errorf("记录部署审批失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "记录部署审批失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, deployment)
+}
+
+// GetDeploymentReport 导出部署审计报告
+// 服务端只生成HTML报告；报告自带打印样式，需要PDF附件时由浏览器打印生成，format=pdf 返回406说明
+func (h *DeploymentHandler) GetDeploymentReport(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "部署ID不能为空"))
+		return
+	}
+
+	switch c.DefaultQuery("format", "html") {
+	case "html":
+	case "pdf":
+		middleware.AbortWithError(c, apperror.New(apperror.PDFNotSupported, "服务端不生成PDF报告，请下载HTML报告后通过浏览器打印为PDF"))
+		return
+	default:
+		middleware.AbortWithError(c, apperror.New(apperror.UnsupportedFormat, "仅支持html格式的报告"))
+		return
+	}
+
+	report, err := h.deploymentService.RenderReport(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrDeploymentNotFound) {
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "部署不存在"))
+			return
+		}
+		if errors.Is(err, service.ErrDeploymentInProgress) {
+			middleware.AbortWithError(c, apperror.New(apperror.DeploymentInProgress, err.Error()))
+			return
+		}
+		h.logger.Errorf("生成部署报告失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "生成部署报告失败"))
+		return
+	}
+
+	if c.Query("download") == "true" {
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=deployment-%s.html", id))
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", report)
+}
+
+// DestinationThrottleStats 获取各下游集群的部署节流状态
+func DestinationThrottleStats(throttle *service.DestinationThrottle) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"items": throttle.Stats(),
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+)
+
+// DesiredStateHandler 声明式期望状态处理器
+type DesiredStateHandler struct {
+	desiredStateService service.DesiredStateService
+	logger              *logrus.Logger
+}
+
+// NewDesiredStateHandler 创建声明式期望状态处理器
+func NewDesiredStateHandler(desiredStateService service.DesiredStateService, logger *logrus.Logger) *DesiredStateHandler {
+	return &DesiredStateHandler{
+		desiredStateService: desiredStateService,
+		logger:              logger,
+	}
+}
+
+// Apply 应用期望状态，dry_run=true 时只返回计划，force=true 时允许删除仍在使用的配置
+// 请求体支持YAML和JSON
+func (h *DesiredStateHandler) Apply(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "读取请求体失败"))
+		return
+	}
+
+	state, err := parseDesiredState(body)
+	if err != nil {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "期望状态文件格式无效"))
+		return
+	}
+	state.Force = c.Query("force") == "true"
+
+	var plan *models.ApplyPlan
+	if c.Query("dry_run") == "true" {
+		plan, err = h.desiredStateService.Plan(c.Request.Context(), state)
+	} else {
+		userID := middleware.CurrentUserID(c)
+		plan, err = h.desiredStateService.Apply(c.Request.Context(), state, userID)
+	}
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "期望状态无效") {
+			middleware.AbortWithError(c, apperror.New(apperror.InvalidState, err.Error()))
+			return
+		}
+		h.logger.Errorf("应用期望状态失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "应用期望状态失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// parseDesiredState 解析YAML或JSON格式的期望状态
+// YAML先转换为通用结构再按JSON标签解码，保证两种格式字段名一致
+func parseDesiredState(body []byte) (*models.DesiredState, error) {
+	var raw interface{}
+	if err := yaml.Unmarshal(body, &raw); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var state models.DesiredState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
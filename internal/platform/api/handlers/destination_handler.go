@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+)
+
+// DestinationHandler 下游集群注册表处理器
+type DestinationHandler struct {
+	destinations  service.DestinationService
+	configService service.ConfigService
+	logger        *logrus.Logger
+}
+
+// NewDestinationHandler 创建下游集群注册表处理器
+func NewDestinationHandler(destinations service.DestinationService, configService service.ConfigService, logger *logrus.Logger) *DestinationHandler {
+	return &DestinationHandler{
+		destinations:  destinations,
+		configService: configService,
+		logger:        logger,
+	}
+}
+
+// ListDestinations 获取下游集群列表
+func (h *DestinationHandler) ListDestinations(c *gin.Context) {
+	dests, err := h.destinations.ListDestinations(c.Request.Context())
+	if err != nil {
+		h.logger.Errorf("获取下游集群列表失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "获取下游集群列表失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items": dests,
+		"total": len(dests),
+	})
+}
+
+// CreateDestination 创建下游集群
+func (h *DestinationHandler) CreateDestination(c *gin.Context) {
+	var req models.CreateDestinationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	dest, err := h.destinations.CreateDestination(c.Request.Context(), &req, middleware.CurrentUserID(c))
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "下游集群已存在"):
+			middleware.AbortWithError(c, apperror.New(apperror.AlreadyExists, err.Error()))
+		case strings.HasPrefix(err.Error(), "下游集群验证失败"):
+			middleware.AbortWithError(c, apperror.New(apperror.Validation, err.Error()))
+		default:
+			h.logger.Errorf("创建下游集群失败: %v", err)
+			middleware.AbortWithError(c, apperror.Wrap(err, "创建下游集群失败"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, dest)
+}
+
+// GetDestination 获取单个下游集群
+func (h *DestinationHandler) GetDestination(c *gin.Context) {
+	name := c.Param("name")
+
+	dest, err := h.destinations.GetDestination(c.Request.Context(), name)
+	if err != nil {
+		if err.Error() == "文档不存在" {
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "下游集群不存在"))
+			return
+		}
+		h.logger.Errorf("获取下游集群失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "获取下游集群失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, dest)
+}
+
+// UpdateDestination 更新下游集群
+func (h *DestinationHandler) UpdateDestination(c *gin.Context) {
+	var req models.UpdateDestinationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	dest, err := h.destinations.UpdateDestination(c.Request.Context(), c.Param("name"), &req, middleware.CurrentUserID(c))
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "下游集群不存在"):
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "下游集群不存在"))
+		case strings.HasPrefix(err.Error(), "下游集群验证失败"):
+			middleware.AbortWithError(c, apperror.New(apperror.Validation, err.Error()))
+		default:
+			h.logger.Errorf("更新下游集群失败: %v", err)
+			middleware.AbortWithError(c, apperror.Wrap(err, "更新下游集群失败"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, dest)
+}
+
+// DeleteDestination 删除下游集群
+func (h *DestinationHandler) DeleteDestination(c *gin.Context) {
+	if err := h.destinations.DeleteDestination(c.Request.Context(), c.Param("name")); err != nil {
+		if strings.HasPrefix(err.Error(), "下游集群不存在") {
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "下游集群不存在"))
+			return
+		}
+		h.logger.Errorf("删除下游集群失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "删除下游集群失败"))
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// CheckDestination 立即检查下游集群的连通性
+func (h *DestinationHandler) CheckDestination(c *gin.Context) {
+	dest, err := h.destinations.CheckDestination(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "下游集群不存在") {
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "下游集群不存在"))
+			return
+		}
+		h.logger.Errorf("检查下游集群失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "检查下游集群失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, dest)
+}
+
+// RenderConfig 预览配置在指定环境的渲染结果
+func (h *DestinationHandler) RenderConfig(c *gin.Context) {
+	environment := c.Query("environment")
+	if environment == "" {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "environment 不能为空"))
+		return
+	}
+
+	config, err := h.configService.GetConfig(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if err.Error() == "文档不存在" {
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "配置不存在"))
+			return
+		}
+		if abortIfForbidden(c, err) {
+			return
+		}
+		h.logger.Errorf("获取配置失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "获取配置失败"))
+		return
+	}
+
+	rendered, err := h.destinations.RenderConfig(c.Request.Context(), config, environment)
+	if err != nil {
+		handleRenderError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rendered)
+}
+
+// handleRenderError 引用错误属于配置问题，返回422
+func handleRenderError(c *gin.Context, logger *logrus.Logger, err error) {
+	if errors.Is(err, service.ErrUnknownDestination) || errors.Is(err, service.ErrDestinationEnvironment) {
+		middleware.AbortWithError(c, apperror.New(apperror.RenderFailed, err.Error()))
+		return
+	}
+	logger.Errorf("渲染配置失败: %v", err)
+	middleware.AbortWithError(c, apperror.Wrap(err, "渲染配置失败"))
+}
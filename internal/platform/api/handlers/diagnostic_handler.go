@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+)
+
+// DiagnosticHandler Agent远程诊断处理器
+type DiagnosticHandler struct {
+	runner *service.DiagnosticRunner
+	logger *logrus.Logger
+}
+
+// NewDiagnosticHandler 创建远程诊断处理器
+func NewDiagnosticHandler(runner *service.DiagnosticRunner, logger *logrus.Logger) *DiagnosticHandler {
+	return &DiagnosticHandler{
+		runner: runner,
+		logger: logger,
+	}
+}
+
+// Run 在Agent上执行白名单内的诊断命令并返回结果，执行的命令记入审计
+// 命令本身执行失败（如日志文件不存在）时仍返回200，原因在结果的error字段中
+func (h *DiagnosticHandler) Run(c *gin.Context) {
+	agentID := c.Param("id")
+
+	var req models.DiagnosticRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+	if req.Command == models.DiagnosticTailLog && req.Source == models.LogSourcePipeline && req.Pipeline == "" {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "读取管道日志需要指定pipeline"))
+		return
+	}
+	middleware.SetAuditDetail(c, diagnosticDetail(&req))
+
+	result, err := h.runner.Run(c.Request.Context(), agentID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrAgentOffline):
+			middleware.AbortWithError(c, apperror.New(apperror.AgentNotConnected, "Agent未建立WebSocket连接，无法执行诊断"))
+		case errors.Is(err, service.ErrDiagnosticTimeout), errors.Is(err, context.DeadlineExceeded):
+			middleware.AbortWithError(c, apperror.New(apperror.AgentTimeout, err.Error()))
+		default:
+			h.logger.WithError(err).WithField("agent_id", agentID).Error("执行远程诊断失败")
+			middleware.AbortWithError(c, apperror.New(apperror.AgentError, "下发诊断请求失败"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// diagnosticDetail 审计中记录的诊断命令及参数
+func diagnosticDetail(req *models.DiagnosticRequest) string {
+	if req.Command != models.DiagnosticTailLog {
+		return req.Command
+	}
+	source := req.Source
+	if source == "" {
+		source = models.LogSourceLogstash
+	}
+	if source == models.LogSourcePipeline {
+		source += ":" + req.Pipeline
+	}
+	return fmt.Sprintf("%s source=%s lines=%d", req.Command, source, req.Lines)
+}
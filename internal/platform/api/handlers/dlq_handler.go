@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+)
+
+// DLQHandler 死信队列查看处理器
+type DLQHandler struct {
+	inspector *service.DLQInspector
+	logger    *logrus.Logger
+}
+
+// NewDLQHandler 创建死信队列查看处理器
+func NewDLQHandler(inspector *service.DLQInspector, logger *logrus.Logger) *DLQHandler {
+	return &DLQHandler{
+		inspector: inspector,
+		logger:    logger,
+	}
+}
+
+// ListEvents 经Agent分页读取管道死信队列中的事件
+// Agent读取失败（如管道未启用死信队列）时仍返回200，原因在结果的error字段中
+func (h *DLQHandler) ListEvents(c *gin.Context) {
+	agentID := c.Param("id")
+
+	var req models.DLQRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效，需要指定pipeline，size不超过100")
+		return
+	}
+
+	result, err := h.inspector.Read(c.Request.Context(), agentID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrAgentOffline):
+			middleware.AbortWithError(c, apperror.New(apperror.AgentNotConnected, "Agent未建立WebSocket连接，无法读取死信队列"))
+		case errors.Is(err, service.ErrDLQTimeout), errors.Is(err, context.DeadlineExceeded):
+			middleware.AbortWithError(c, apperror.New(apperror.AgentTimeout, err.Error()))
+		default:
+			h.logger.WithError(err).WithField("agent_id", agentID).Error("读取死信队列失败")
+			middleware.AbortWithError(c, apperror.New(apperror.AgentError, "下发死信队列请求失败"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"logstash-platform/internal/platform/apperror"
+)
+
+// ErrorCatalog 返回错误码目录，列出各错误码对应的HTTP状态码和说明
+func ErrorCatalog(c *gin.Context) {
+	entries := apperror.Catalog()
+	c.JSON(http.StatusOK, gin.H{
+		"items": entries,
+		"total": len(entries),
+	})
+}
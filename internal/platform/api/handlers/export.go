@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/service"
+	"logstash-platform/pkg/tabular"
+)
+
+// exportOpener 返回导出写出器的创建函数：设置下载的响应头并在响应体上创建写出器，文件名为 <name>-<UTC时间>.<格式>
+func exportOpener(c *gin.Context, format, name string) func() (tabular.Writer, error) {
+	return func() (tabular.Writer, error) {
+		c.Header("Content-Type", tabular.ContentType(format))
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s-%s.%s", name, time.Now().UTC().Format("20060102-150405"), format))
+		c.Status(http.StatusOK)
+		return tabular.NewWriter(format, c.Writer, name)
+	}
+}
+
+// abortExport 处理导出失败
+// 响应体已开始写出时状态码无法更改，只记录日志并中断，客户端得到被截断的文件
+func abortExport(c *gin.Context, logger *logrus.Logger, err error, msg string) {
+	if errors.Is(err, service.ErrInvalidExportColumn) {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidExportColumn, err.Error()))
+		return
+	}
+	logger.Errorf("%s: %v", msg, err)
+	if c.Writer.Written() {
+		c.Abort()
+		return
+	}
+	c.Writer.Header().Del("Content-Disposition")
+	middleware.AbortWithError(c, apperror.Wrap(err, msg))
+}
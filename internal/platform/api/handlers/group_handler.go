@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+)
+
+// GroupHandler Agent分组处理器
+type GroupHandler struct {
+	groupService service.GroupService
+	logger       *logrus.Logger
+}
+
+// NewGroupHandler 创建Agent分组处理器
+func NewGroupHandler(groupService service.GroupService, logger *logrus.Logger) *GroupHandler {
+	return &GroupHandler{
+		groupService: groupService,
+		logger:       logger,
+	}
+}
+
+// ListGroups 获取分组列表
+func (h *GroupHandler) ListGroups(c *gin.Context) {
+	groups, err := h.groupService.ListGroups(c.Request.Context())
+	if err != nil {
+		h.logger.Errorf("获取分组列表失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "获取分组列表失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items": groups,
+		"total": len(groups),
+	})
+}
+
+// CreateGroup 创建分组
+func (h *GroupHandler) CreateGroup(c *gin.Context) {
+	var req models.CreateGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	userID := middleware.CurrentUserID(c)
+
+	group, err := h.groupService.CreateGroup(c.Request.Context(), &req, userID)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "分组已存在") {
+			middleware.AbortWithError(c, apperror.New(apperror.AlreadyExists, err.Error()))
+			return
+		}
+		h.logger.Errorf("创建分组失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "创建分组失败"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, group)
+}
+
+// GetGroup 获取单个分组
+func (h *GroupHandler) GetGroup(c *gin.Context) {
+	name := c.Param("name")
+	if name == "" {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "分组名称不能为空"))
+		return
+	}
+
+	group, err := h.groupService.GetGroup(c.Request.Context(), name)
+	if err != nil {
+		if err.Error() == "文档不存在" {
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "分组不存在"))
+			return
+		}
+		h.logger.Errorf("获取分组失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "获取分组失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+// UpdateGroup 更新分组
+func (h *GroupHandler) UpdateGroup(c *gin.Context) {
+	name := c.Param("name")
+	if name == "" {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "分组名称不能为空"))
+		return
+	}
+
+	var req models.UpdateGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	userID := middleware.CurrentUserID(c)
+
+	group, err := h.groupService.UpdateGroup(c.Request.Context(), name, &req, userID)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "分组不存在") {
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "分组不存在"))
+			return
+		}
+		h.logger.Errorf("更新分组失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "更新分组失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+// DeleteGroup 删除分组
+func (h *GroupHandler) DeleteGroup(c *gin.Context) {
+	name := c.Param("name")
+	if name == "" {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "分组名称不能为空"))
+		return
+	}
+
+	if err := h.groupService.DeleteGroup(c.Request.Context(), name); err != nil {
+		if strings.HasPrefix(err.Error(), "分组不存在") {
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "分组不存在"))
+			return
+		}
+		h.logger.Errorf("删除分组失败: %v", err)
+		middleware.AbortWithError(c, apperror.New(apperror.Conflict, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// GetAgentSettings 获取Agent的生效设置
+func (h *GroupHandler) GetAgentSettings(c *gin.Context) {
+	agentID := c.Param("id")
+	if agentID == "" {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "Agent ID不能为空"))
+		return
+	}
+
+	settings, err := h.groupService.GetEffectiveSettings(c.Request.Context(), agentID)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "Agent不存在") {
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "Agent不存在"))
+			return
+		}
+		h.logger.Errorf("获取Agent设置失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "获取Agent设置失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateAgentSettings 更新Agent的分组和设置覆盖
+func (h *GroupHandler) UpdateAgentSettings(c *gin.Context) {
+	agentID := c.Param("id")
+	if agentID == "" {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "Agent ID不能为空"))
+		return
+	}
+
+	var req models.UpdateAgentSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	settings, err := h.groupService.UpdateAgentSettings(c.Request.Context(), agentID, &req)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "Agent不存在") || strings.HasPrefix(err.Error(), "分组不存在") {
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, err.Error()))
+			return
+		}
+		h.logger.Errorf("更新Agent设置失败: %v", err)
+		middleware.AbortWithError(c, apperror.New(apperror.Validation, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
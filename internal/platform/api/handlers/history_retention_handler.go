@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+)
+
+// HistoryRetentionHandler 配置历史清理处理器
+type HistoryRetentionHandler struct {
+	pruner *service.ConfigHistoryPruner
+	logger *logrus.Logger
+}
+
+// NewHistoryRetentionHandler 创建配置历史清理处理器
+func NewHistoryRetentionHandler(pruner *service.ConfigHistoryPruner, logger *logrus.Logger) *HistoryRetentionHandler {
+	return &HistoryRetentionHandler{
+		pruner: pruner,
+		logger: logger,
+	}
+}
+
+// PreviewPrune 预览按保留策略将被清理的历史，查询参数可覆盖配置的保留版本数和天数
+func (h *HistoryRetentionHandler) PreviewPrune(c *gin.Context) {
+	var req models.HistoryPrunePreviewRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+	policy := h.pruner.Policy()
+	if req.KeepVersions != nil {
+		policy.KeepVersions = *req.KeepVersions
+	}
+	if req.KeepDays != nil {
+		policy.KeepDays = *req.KeepDays
+	}
+
+	preview, err := h.pruner.Preview(c.Request.Context(), policy)
+	if err != nil {
+		if errors.Is(err, service.ErrRetentionPolicyEmpty) {
+			middleware.AbortWithError(c, apperror.New(apperror.BadRequest, "未设置保留版本数或天数"))
+			return
+		}
+		h.logger.Errorf("预览配置历史清理失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "预览配置历史清理失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"preview":  preview,
+		"last_run": h.pruner.LastRun(),
+	})
+}
+
+// TriggerPrune 立即在后台按配置的保留策略清理一次
+func (h *HistoryRetentionHandler) TriggerPrune(c *gin.Context) {
+	if err := h.pruner.Trigger(); err != nil {
+		switch {
+		case errors.Is(err, service.ErrRetentionPolicyEmpty):
+			middleware.AbortWithError(c, apperror.New(apperror.BadRequest, "未配置配置历史保留策略"))
+		case errors.Is(err, service.ErrHistoryPruneRunning):
+			middleware.AbortWithError(c, apperror.New(apperror.Conflict, "配置历史清理正在进行中"))
+		default:
+			h.logger.Errorf("启动配置历史清理失败: %v", err)
+			middleware.AbortWithError(c, apperror.Wrap(err, "启动配置历史清理失败"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "配置历史清理已开始"})
+}
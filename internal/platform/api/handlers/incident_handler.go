@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+)
+
+// IncidentHandler 错误事件处理器
+type IncidentHandler struct {
+	incidentService service.IncidentService
+	logger          *logrus.Logger
+}
+
+// NewIncidentHandler 创建错误事件处理器
+func NewIncidentHandler(incidentService service.IncidentService, logger *logrus.Logger) *IncidentHandler {
+	return &IncidentHandler{
+		incidentService: incidentService,
+		logger:          logger,
+	}
+}
+
+// ReportError Agent上报错误
+func (h *IncidentHandler) ReportError(c *gin.Context) {
+	agentID := c.Param("id")
+	if agentID == "" {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "Agent ID不能为空"))
+		return
+	}
+
+	var req models.AgentErrorReport
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	incident, err := h.incidentService.ReportError(c.Request.Context(), agentID, &req)
+	if err != nil {
+		h.logger.Errorf("记录Agent错误失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "记录Agent错误失败"))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"incident_id":    incident.ID,
+		"affected_count": incident.AffectedCount,
+	})
+}
+
+// ListIncidents 获取事件列表
+func (h *IncidentHandler) ListIncidents(c *gin.Context) {
+	var req models.IncidentListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	items, total, err := h.incidentService.ListIncidents(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.Errorf("获取事件列表失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "获取事件列表失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total": total,
+		"page":  req.Page,
+		"size":  req.PageSize,
+		"items": items,
+	})
+}
+
+// GetIncident 获取单个事件
+func (h *IncidentHandler) GetIncident(c *gin.Context) {
+	incident, err := h.incidentService.GetIncident(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if err.Error() == "文档不存在" {
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "事件不存在"))
+			return
+		}
+		h.logger.Errorf("获取事件失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "获取事件失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, incident)
+}
+
+// ResolveIncident 解决事件
+func (h *IncidentHandler) ResolveIncident(c *gin.Context) {
+	var req models.ResolveIncidentRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			middleware.HandleBindError(c, err, "请求参数无效")
+			return
+		}
+	}
+
+	userID := middleware.CurrentUserID(c)
+
+	incident, err := h.incidentService.ResolveIncident(c.Request.Context(), c.Param("id"), &req, userID)
+	if err != nil {
+		switch {
+		case err.Error() == "文档不存在":
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "事件不存在"))
+		case strings.HasPrefix(err.Error(), "事件已解决"):
+			middleware.AbortWithError(c, apperror.New(apperror.Conflict, "事件已解决"))
+		default:
+			h.logger.Errorf("解决事件失败: %v", err)
+			middleware.AbortWithError(c, apperror.Wrap(err, "解决事件失败"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, incident)
+}
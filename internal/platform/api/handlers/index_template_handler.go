@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+)
+
+// IndexTemplateHandler 索引模板处理器
+type IndexTemplateHandler struct {
+	templateService service.IndexTemplateService
+	logger          *logrus.Logger
+}
+
+// NewIndexTemplateHandler 创建索引模板处理器
+func NewIndexTemplateHandler(templateService service.IndexTemplateService, logger *logrus.Logger) *IndexTemplateHandler {
+	return &IndexTemplateHandler{
+		templateService: templateService,
+		logger:          logger,
+	}
+}
+
+// GenerateIndexTemplate 根据配置推断的输出字段生成ES索引模板
+func (h *IndexTemplateHandler) GenerateIndexTemplate(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "配置ID不能为空"))
+		return
+	}
+
+	var req models.GenerateIndexTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效，index_patterns不能为空")
+		return
+	}
+
+	result, err := h.templateService.Generate(c.Request.Context(), id, &req)
+	if err != nil {
+		switch {
+		case err.Error() == "文档不存在":
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "配置不存在"))
+		case errors.Is(err, service.ErrConfigForbidden):
+			abortIfForbidden(c, err)
+		case strings.HasPrefix(err.Error(), "字段类型无效"):
+			middleware.AbortWithError(c, apperror.New(apperror.InvalidFieldType, err.Error()))
+		default:
+			h.logger.Errorf("生成索引模板失败: %v", err)
+			middleware.AbortWithError(c, apperror.Wrap(err, "生成索引模板失败"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
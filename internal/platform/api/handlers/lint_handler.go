@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+)
+
+// LintHandler 配置静态检查处理器
+type LintHandler struct {
+	linter service.ConfigLinter
+	logger *logrus.Logger
+}
+
+// NewLintHandler 创建配置静态检查处理器
+func NewLintHandler(linter service.ConfigLinter, logger *logrus.Logger) *LintHandler {
+	return &LintHandler{
+		linter: linter,
+		logger: logger,
+	}
+}
+
+// LintConfig 按项目启用的规则检查配置的当前内容，请求体可为空
+func (h *LintHandler) LintConfig(c *gin.Context) {
+	id := c.Param("id")
+
+	var req models.LintConfigRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			middleware.HandleBindError(c, err, "请求参数无效")
+			return
+		}
+	}
+
+	result, err := h.linter.Lint(c.Request.Context(), id, &req)
+	if err != nil {
+		if err.Error() == "文档不存在" {
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "配置不存在"))
+			return
+		}
+		if abortIfForbidden(c, err) {
+			return
+		}
+		h.logger.Errorf("配置静态检查失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "配置静态检查失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ListRules 获取当前项目的静态检查规则及是否启用
+func (h *LintHandler) ListRules(c *gin.Context) {
+	rules, err := h.linter.Rules(c.Request.Context())
+	if err != nil {
+		h.logger.Errorf("获取静态检查规则失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "获取静态检查规则失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rules": rules})
+}
+
+// UpdateRules 设置当前项目的静态检查规则开关
+func (h *LintHandler) UpdateRules(c *gin.Context) {
+	var req models.UpdateLintRulesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	rules, err := h.linter.UpdateRules(c.Request.Context(), req.Rules, middleware.CurrentUserID(c))
+	if err != nil {
+		if errors.Is(err, service.ErrUnknownLintRule) {
+			middleware.AbortWithError(c, apperror.New(apperror.BadRequest, err.Error()))
+			return
+		}
+		h.logger.Errorf("更新静态检查规则失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "更新静态检查规则失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rules": rules})
+}
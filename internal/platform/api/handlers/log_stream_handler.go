@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+)
+
+// 浏览器实时日志连接的保活参数
+const (
+	logStreamPingInterval = 30 * time.Second
+	logStreamWriteTimeout = 10 * time.Second
+)
+
+// LogStreamHandler 浏览器实时日志流处理器
+type LogStreamHandler struct {
+	relay    *service.LogStreamRelay
+	logger   *logrus.Logger
+	upgrader websocket.Upgrader
+}
+
+// NewLogStreamHandler 创建实时日志流处理器
+// allowedOrigins为空时只接受与平台同源的页面，"*"接受任意来源
+func NewLogStreamHandler(relay *service.LogStreamRelay, allowedOrigins []string, logger *logrus.Logger) *LogStreamHandler {
+	return &LogStreamHandler{
+		relay:  relay,
+		logger: logger,
+		upgrader: websocket.Upgrader{
+			CheckOrigin: originChecker(allowedOrigins),
+		},
+	}
+}
+
+// originChecker 按允许的来源校验WebSocket请求的Origin，未列出的来源按gorilla默认规则要求同源
+func originChecker(allowedOrigins []string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		for _, allowed := range allowedOrigins {
+			if allowed == "*" || allowed == origin {
+				return true
+			}
+		}
+		return sameOrigin(r, origin)
+	}
+}
+
+// sameOrigin Origin的主机是否与请求的Host一致
+func sameOrigin(r *http.Request, origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// Stream 升级为WebSocket连接并持续推送Agent的Logstash日志
+// 查询参数：source（logstash|pipeline）、pipeline、level（最低级别）、rate（每秒行数）、backlog（先推送的末尾行数）
+// 每条消息为一个log_lines批次；Agent结束跟踪时推送closed为true的批次后关闭连接
+func (h *LogStreamHandler) Stream(c *gin.Context) {
+	agentID := c.Param("id")
+
+	var query models.LogStreamQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+	if query.Source == "" {
+		query.Source = models.LogSourceLogstash
+	}
+	if query.Source == models.LogSourcePipeline && query.Pipeline == "" {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "跟踪管道日志需要指定pipeline"))
+		return
+	}
+
+	// 升级前打开流，Agent未连接或流数已满时仍可返回HTTP错误
+	stream, err := h.relay.Open(agentID, models.LogTailStartPayload{
+		Source:   query.Source,
+		Pipeline: query.Pipeline,
+		MinLevel: query.Level,
+		MaxRate:  query.Rate,
+		Backlog:  query.Backlog,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrAgentOffline):
+			middleware.AbortWithError(c, apperror.New(apperror.AgentNotConnected, "Agent未建立WebSocket连接，无法跟踪日志"))
+		case errors.Is(err, service.ErrTooManyLogStreams):
+			middleware.AbortWithError(c, apperror.New(apperror.TooManyStreams, err.Error()))
+		default:
+			h.logger.WithError(err).WithField("agent_id", agentID).Error("打开实时日志流失败")
+			middleware.AbortWithError(c, apperror.New(apperror.AgentError, "下发日志跟踪请求失败"))
+		}
+		return
+	}
+	defer h.relay.Close(stream)
+
+	ws, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// 升级失败时Upgrader已写入HTTP错误响应
+		h.logger.WithError(err).WithField("agent_id", agentID).Warn("建立实时日志连接失败")
+		return
+	}
+	defer ws.Close()
+
+	// 浏览器不发送数据，读循环只用于发现连接关闭
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(logStreamPingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case batch := <-stream.Batches():
+			if !writeLogBatch(ws, batch) {
+				return
+			}
+		case <-ping.C:
+			if err := ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(logStreamWriteTimeout)); err != nil {
+				return
+			}
+		case <-stream.Done():
+			// 结束前写出已转发的批次，其中可能包含Agent结束跟踪的原因
+			for {
+				select {
+				case batch := <-stream.Batches():
+					if !writeLogBatch(ws, batch) {
+						return
+					}
+					continue
+				default:
+				}
+				break
+			}
+			ws.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, ""), time.Now().Add(time.Second))
+			return
+		case <-gone:
+			return
+		}
+	}
+}
+
+// writeLogBatch 写出一个日志批次，返回连接是否应继续；Agent结束跟踪的批次写出后关闭连接
+func writeLogBatch(ws *websocket.Conn, batch *models.LogLinesMessage) bool {
+	ws.SetWriteDeadline(time.Now().Add(logStreamWriteTimeout))
+	if err := ws.WriteJSON(batch); err != nil {
+		return false
+	}
+	if batch.Closed {
+		ws.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, batch.Error), time.Now().Add(time.Second))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+)
+
+// MetricsHandler Agent指标时序处理器
+type MetricsHandler struct {
+	metricsService service.MetricsService
+	logger         *logrus.Logger
+}
+
+// NewMetricsHandler 创建Agent指标时序处理器
+func NewMetricsHandler(metricsService service.MetricsService, logger *logrus.Logger) *MetricsHandler {
+	return &MetricsHandler{
+		metricsService: metricsService,
+		logger:         logger,
+	}
+}
+
+// GetAgentMetrics 按时间范围查询Agent的CPU、内存、磁盘和Logstash事件吞吐时序
+func (h *MetricsHandler) GetAgentMetrics(c *gin.Context) {
+	agentID := c.Param("id")
+
+	var req models.MetricsQueryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效，时间格式应为RFC3339")
+		return
+	}
+
+	series, err := h.metricsService.Query(c.Request.Context(), agentID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidMetricsQuery):
+			middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, err.Error()))
+		case strings.HasPrefix(err.Error(), "Agent不存在"):
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "Agent不存在"))
+		default:
+			h.logger.Errorf("查询Agent指标失败: %v", err)
+			middleware.AbortWithError(c, apperror.Wrap(err, "查询Agent指标失败"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, series)
+}
+
+// GetAgentPipelines 获取Agent最近一次上报的各管道事件、队列和死信队列统计
+func (h *MetricsHandler) GetAgentPipelines(c *gin.Context) {
+	stats, err := h.metricsService.LatestPipelines(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "Agent不存在") {
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "Agent不存在"))
+			return
+		}
+		h.logger.Errorf("获取Agent管道统计失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "获取Agent管道统计失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
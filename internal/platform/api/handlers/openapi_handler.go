@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"logstash-platform/internal/platform/openapi"
+)
+
+// openAPIInfo OpenAPI文档的基本信息
+var openAPIInfo = openapi.Info{
+	Title:   "Logstash管理平台 API",
+	Version: "v1",
+	Description: "除登录、协议和文档接口外均需携带 Authorization: Bearer <token>；" +
+		"按项目隔离的接口通过请求头 X-Project 或查询参数 project 指定项目",
+}
+
+// OpenAPISpec 返回平台REST API的OpenAPI文档，首次请求时按已注册的路由和 APISpecs 生成
+func OpenAPISpec(routes func() gin.RoutesInfo) gin.HandlerFunc {
+	var once sync.Once
+	var doc *openapi.Document
+	return func(c *gin.Context) {
+		once.Do(func() {
+			doc = openapi.Generate(openAPIInfo, routes(), APISpecs())
+		})
+		c.JSON(http.StatusOK, doc)
+	}
+}
+
+// swaggerUIPage 从CDN加载Swagger UI的页面，%s 为文档地址
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<title>Logstash管理平台 API</title>
+<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+<script>
+window.onload = function () {
+  window.ui = SwaggerUIBundle({ url: %q, dom_id: "#swagger-ui", persistAuthorization: true });
+};
+</script>
+</body>
+</html>
+`
+
+// SwaggerUI 返回浏览OpenAPI文档的Swagger UI页面
+func SwaggerUI(specURL string) gin.HandlerFunc {
+	page := []byte(fmt.Sprintf(swaggerUIPage, specURL))
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", page)
+	}
+}
@@ -0,0 +1,348 @@
+package handlers
+
+import (
+	"net/http"
+
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/openapi"
+	"logstash-platform/internal/platform/service"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// 接口描述中只有一两个字段的确认响应
+var (
+	messageResponse = struct {
+		Message string `json:"message"`
+	}{}
+	recordedResponse = struct {
+		Status string `json:"status"`
+	}{}
+	lintRulesResponse = struct {
+		Rules []models.LintRule `json:"rules"`
+	}{}
+)
+
+// APISpecs 返回各处理器的接口描述，按 openapi.HandlerName 的登记名索引
+// 新增路由时需在这里登记，api 包的测试会检查路由与描述一一对应
+func APISpecs() map[string]openapi.Spec {
+	return map[string]openapi.Spec{
+		// 文档与协议
+		"OpenAPISpec":    {Summary: "获取平台API的OpenAPI文档", Public: true},
+		"SwaggerUI":      {Summary: "浏览API文档的Swagger UI页面", ResponseType: "text/html", Public: true},
+		"ProtocolSchema": {Summary: "获取Agent通信协议的JSON Schema", Public: true},
+		"ErrorCatalog": {Summary: "获取错误码目录", Description: "错误响应为 application/problem+json，type 为 /api/v1/errors#<code>",
+			Response: openapi.List(apperror.Entry{}), Public: true},
+
+		// 认证与用户
+		"AuthHandler.Login":     {Summary: "用户登录", Request: models.LoginRequest{}, Response: models.LoginResponse{}, Public: true},
+		"AuthHandler.Me":        {Summary: "获取当前用户", Response: models.User{}},
+		"AuthHandler.ListUsers": {Summary: "获取用户列表", Response: openapi.List(models.User{})},
+		"AuthHandler.CreateUser": {Summary: "创建用户", Request: models.CreateUserRequest{}, Response: models.User{},
+			Status: http.StatusCreated},
+		"AuthHandler.UpdateUser": {Summary: "更新用户", Request: models.UpdateUserRequest{}, Response: models.User{}},
+		"AuthHandler.DeleteUser": {Summary: "删除用户", Response: messageResponse},
+		"AuditHandler.ListAuditLog": {Summary: "查询审计记录", Description: "需要admin角色",
+			Query: models.AuditListRequest{}, Response: openapi.Page(models.AuditEntry{})},
+
+		// 配置
+		"ConfigHandler.ListConfigs": {Summary: "获取配置列表", Query: models.ConfigListRequest{}, Response: models.ConfigListResponse{}},
+		"ConfigHandler.SearchConfigs": {Summary: "全文搜索配置", Description: "在名称、描述、标签和配置内容中模糊匹配",
+			Query: models.ConfigSearchRequest{}, Response: models.ConfigSearchResponse{}},
+		"ConfigHandler.GetConfigStats": {Summary: "统计配置", Description: "按类型、标签、测试状态和启用状态返回配置数量，每个维度的分桶不应用该维度自身的过滤条件",
+			Query: models.ConfigStatsRequest{}, Response: models.ConfigStats{}},
+		"ConfigHandler.ExportConfigs": {Summary: "导出配置目录", Description: "以CSV或XLSX流式导出匹配列表过滤条件的配置元数据（不含配置内容），忽略分页参数；columns未指定时导出全部列",
+			Query: models.ConfigExportRequest{}, ResponseType: "text/csv"},
+		"ConfigHandler.CreateConfig": {Summary: "创建配置", Request: models.CreateConfigRequest{}, Response: models.Config{},
+			Status: http.StatusCreated},
+		"ConfigHandler.GetConfig": {Summary: "获取单个配置", Response: models.Config{}, Params: []openapi.Param{
+			{Name: "version", Description: "历史版本号，默认当前版本"},
+			{Name: "environment", Description: "所在环境，指定时返回替换了下游集群引用的内容"},
+		}},
+		"ConfigHandler.UpdateConfig": {Summary: "更新配置", Description: "内容变更时版本号递增并清空测试状态",
+			Request: models.UpdateConfigRequest{}, Response: models.Config{}},
+		"ConfigHandler.DeleteConfig": {Summary: "删除配置", Status: http.StatusNoContent, Params: []openapi.Param{
+			{Name: "force", Description: "为true时允许删除仍被Agent应用的配置"},
+		}},
+		"ConfigHandler.GetConfigHistory": {Summary: "获取配置历史", Response: openapi.List(models.ConfigHistory{})},
+		"ConfigHandler.DiffConfig": {Summary: "比较配置版本", Description: "查询参数to省略时与当前版本比较",
+			Query: struct {
+				From int `form:"from" binding:"required"`
+				To   int `form:"to"`
+			}{}, Response: service.ConfigDiff{}},
+		"ConfigHandler.SetConfigACL": {Summary: "设置配置级访问控制", Request: models.ConfigACL{}, Response: models.Config{}},
+		"ConfigHandler.SetScheduledTest": {Summary: "开启或关闭配置的定期测试", Description: "开启后每天用配置的当前版本运行其上保存的测试数据集",
+			Request: models.SetScheduledTestRequest{}, Response: models.Config{}},
+		"ConfigHandler.RollbackConfig": {Summary: "回滚配置", Description: "以历史版本的内容生成新版本",
+			Request: struct {
+				Version int `json:"version" binding:"required"`
+			}{}, Response: models.Config{}},
+		"ConfigUsageHandler.ListConfigAgents": {Summary: "获取运行该配置的Agent及版本", Response: models.ConfigUsage{}},
+		"ApprovalHandler.SubmitApproval": {Summary: "审批配置当前版本", Request: models.ApproveConfigRequest{},
+			Response: models.ConfigApproval{}, Status: http.StatusCreated},
+		"ApprovalHandler.ListApprovals": {Summary: "获取版本审批进度", Response: models.ConfigApprovalStatus{}, Params: []openapi.Param{
+			{Name: "version", Description: "配置版本，默认当前版本"},
+		}},
+		"DestinationHandler.RenderConfig": {Summary: "预览按环境渲染的配置", Response: models.RenderedConfig{}, Params: []openapi.Param{
+			{Name: "environment", Description: "所在环境", Required: true},
+		}},
+		"IndexTemplateHandler.GenerateIndexTemplate": {Summary: "生成ES索引模板", Request: models.GenerateIndexTemplateRequest{},
+			Response: models.IndexTemplateResult{}},
+		"ValidationHandler.ValidateConfig": {Summary: "使用Logstash校验配置语法", Request: models.ValidateConfigRequest{},
+			Response: models.ConfigValidationResult{}},
+		"RevalidationHandler.ListRevalidations": {Summary: "获取定期重新校验结果", Query: models.ConfigRevalidationListRequest{},
+			Response: struct {
+				Total   int64                        `json:"total"`
+				Page    int                          `json:"page"`
+				Size    int                          `json:"size"`
+				Items   []*models.ConfigRevalidation `json:"items"`
+				LastRun *models.RevalidationRun      `json:"last_run"`
+			}{}},
+		"RevalidationHandler.TriggerRevalidation": {Summary: "立即重新校验全部启用的配置", Response: messageResponse,
+			Status: http.StatusAccepted},
+		"ScheduledTestHandler.ListScheduledTests": {Summary: "获取配置的定期测试记录", Query: models.ScheduledTestListRequest{},
+			Response: struct {
+				Total   int64                      `json:"total"`
+				Page    int                        `json:"page"`
+				Size    int                        `json:"size"`
+				Items   []*models.ScheduledTestRun `json:"items"`
+				LastRun *models.ScheduledTestSweep `json:"last_run"`
+			}{}},
+		"ScheduledTestHandler.TriggerScheduledTests": {Summary: "立即运行全部定期测试", Response: messageResponse,
+			Status: http.StatusAccepted},
+		"LintHandler.LintConfig": {Summary: "静态检查配置内容", Description: "按当前项目启用的规则检查，请求体可省略，未指定环境时按配置标签判断是否为生产环境",
+			Request: models.LintConfigRequest{}, Response: models.LintResult{}},
+		"LintHandler.ListRules": {Summary: "获取当前项目的静态检查规则", Response: lintRulesResponse},
+		"CatalogHandler.ListPlugins": {Summary: "获取插件目录", Description: "插件名称及其选项，供配置编辑器自动补全；静态检查按目录检查未知选项",
+			Query: models.PluginCatalogRequest{}, Response: models.PluginCatalog{}},
+		"CatalogHandler.RefreshPlugins": {Summary: "重新加载插件目录", Description: "重新读取 plugin_catalog.file，文件无效时返回422并保留当前目录；只刷新接收请求的副本，需要管理员权限",
+			Response: models.PluginCatalog{}},
+		"LintHandler.UpdateRules": {Summary: "设置当前项目的静态检查规则开关", Description: "未列出的规则保持原值，需要管理员权限",
+			Request: models.UpdateLintRulesRequest{}, Response: lintRulesResponse},
+		"HistoryRetentionHandler.PreviewPrune": {Summary: "预览配置历史清理", Description: "按保留策略列出将被清理的版本，不删除记录；查询参数可覆盖配置的保留版本数和天数",
+			Query: models.HistoryPrunePreviewRequest{},
+			Response: struct {
+				Preview *models.HistoryPruneRun `json:"preview"`
+				LastRun *models.HistoryPruneRun `json:"last_run"`
+			}{}},
+		"HistoryRetentionHandler.TriggerPrune": {Summary: "立即按保留策略清理配置历史", Response: messageResponse,
+			Status: http.StatusAccepted},
+		"CostEstimateHandler.EstimateCost": {Summary: "部署前资源估算", Request: models.CostEstimateRequest{},
+			Response: models.CostEstimate{}},
+
+		// 测试
+		"TestHandler.CreateTest": {Summary: "创建测试任务", Description: "异步执行，通过 GET /api/v1/test/{id}/result 轮询结果；" +
+			"也可以 multipart/form-data 提交，request 字段为JSON请求、file 字段为随请求上传的 type=file 测试文件；" +
+			"type=elasticsearch 时从允许读取的业务索引中抽取文档作为样本，type=replay 时回放HAR或JSON数组中的HTTP请求；" +
+			"masking 中的脱敏规则在保存测试结果前应用",
+			Request: models.TestConfigRequest{}, Status: http.StatusAccepted, Response: struct {
+				TestID  string `json:"test_id"`
+				Status  string `json:"status"`
+				Message string `json:"message"`
+				FileID  string `json:"file_id,omitempty"`
+			}{}},
+		"TestHandler.GetTestResult": {Summary: "获取测试结果", Response: models.TestResult{}},
+		"TestHandler.UploadTestFile": {Summary: "上传测试文件", Description: "按行作为 type=file 测试数据的样本，支持gzip压缩的文件，有效期过后自动删除",
+			Request: struct {
+				File string `json:"file"`
+			}{}, RequestType: "multipart/form-data", Status: http.StatusCreated, Response: models.TestFile{}},
+		"TestHandler.GetTestFile":    {Summary: "获取测试文件信息", Response: models.TestFile{}},
+		"TestHandler.DeleteTestFile": {Summary: "删除测试文件", Status: http.StatusNoContent},
+		"TestHandler.CompareTest": {Summary: "同一组样本对比两个配置版本的输出", Request: models.TestCompareRequest{},
+			Response: models.TestCompareResult{}},
+		"TestHandler.RunDatasets":         {Summary: "运行配置上保存的全部测试数据集", Response: models.RunTestDatasetsResult{}},
+		"TestDatasetHandler.ListDatasets": {Summary: "获取配置上的测试数据集", Response: openapi.List(models.TestDataset{})},
+		"TestDatasetHandler.CreateDataset": {Summary: "保存测试数据集", Request: models.CreateTestDatasetRequest{},
+			Response: models.TestDataset{}, Status: http.StatusCreated},
+		"TestDatasetHandler.GetDataset": {Summary: "获取单个测试数据集", Response: models.TestDataset{}},
+		"TestDatasetHandler.UpdateDataset": {Summary: "更新测试数据集", Request: models.UpdateTestDatasetRequest{},
+			Response: models.TestDataset{}},
+		"TestDatasetHandler.DeleteDataset": {Summary: "删除测试数据集", Status: http.StatusNoContent},
+
+		// Agent
+		"AgentHandler.ListAgents": {Summary: "获取Agent列表", Description: "支持按状态、分组、Logstash版本、已应用配置过滤和按主机名或IP搜索；状态按最近心跳推导，未指定page、size和cursor时返回全部Agent",
+			Query: models.AgentListRequest{}, Response: models.AgentListResponse{}},
+		"AgentHandler.ExportAgents": {Summary: "导出Agent清单", Description: "以CSV或XLSX流式导出匹配列表过滤条件的Agent，忽略分页参数；columns未指定时导出全部列",
+			Query: models.AgentExportRequest{}, ResponseType: "text/csv"},
+		"AgentHandler.GetAgent":     {Summary: "获取单个Agent"},
+		"AgentHandler.DeployConfig": {Summary: "部署配置到Agent"},
+		"AgentDecommissionHandler.Decommission": {Summary: "注销Agent", Description: "写入归档后从Agent列表删除，关闭其WebSocket连接并停止心跳巡检，事件时间线保留；仅管理员可调用",
+			Response: models.ArchivedAgent{}, Params: []openapi.Param{
+				{Name: "reason", Description: "注销原因，写入归档记录和注销事件"},
+			}},
+		"AgentDecommissionHandler.ListArchived": {Summary: "获取已注销Agent", Description: "按注销时间倒序返回归档记录",
+			Query: models.ArchivedAgentListRequest{}, Response: models.ArchivedAgentListResponse{}},
+		"AgentDecommissionHandler.Deregister": {Summary: "Agent主动注销", Request: models.DeregisterRequest{}, Response: models.ArchivedAgent{}},
+		"AgentLifecycleHandler.EnqueueCommand": {Summary: "排入心跳命令", Request: models.EnqueueCommandRequest{},
+			Status: http.StatusAccepted, Response: struct {
+				AgentID string `json:"agent_id"`
+				Type    string `json:"type"`
+				Status  string `json:"status"`
+			}{}},
+		"MetricsHandler.GetAgentMetrics": {Summary: "查询Agent指标时序（按间隔聚合）", Query: models.MetricsQueryRequest{},
+			Response: models.MetricsSeries{}},
+		"MetricsHandler.GetAgentPipelines": {Summary: "获取Agent最新的各管道统计", Description: "事件计数、队列出入和死信队列统计，取自Agent最近一次指标上报",
+			Response: models.AgentPipelineStats{}},
+		"AgentEventHandler.ListEvents": {Summary: "获取Agent事件时间线", Query: models.AgentEventListRequest{},
+			Response: openapi.List(models.AgentEvent{})},
+		"ConfigUsageHandler.ListAgentConfigs": {Summary: "获取Agent运行的配置及版本", Response: struct {
+			AgentID string                     `json:"agent_id"`
+			Items   []*models.AgentConfigUsage `json:"items"`
+			Total   int                        `json:"total"`
+		}{}},
+		"GroupHandler.GetAgentSettings": {Summary: "获取Agent生效设置", Response: models.EffectiveSettings{}},
+		"GroupHandler.UpdateAgentSettings": {Summary: "更新Agent分组及设置覆盖", Request: models.UpdateAgentSettingsRequest{},
+			Response: models.EffectiveSettings{}},
+		"LogStreamHandler.Stream": {Summary: "实时跟踪Agent的Logstash日志", Description: "升级为WebSocket连接，每条消息为一个log_lines批次",
+			Query: models.LogStreamQuery{}, Status: http.StatusSwitchingProtocols},
+		"DiagnosticHandler.Run": {Summary: "在Agent上执行白名单内的诊断命令", Request: models.DiagnosticRequest{},
+			Response: models.DiagnosticResult{}},
+		"PluginInventoryHandler.Get": {Summary: "获取Agent上报的Logstash插件清单", Response: models.PluginInventory{}},
+		"PluginInventoryHandler.Report": {Summary: "Agent上报已安装的Logstash插件清单", Description: "覆盖之前的清单，部署前按清单检查配置引用的插件",
+			Request: models.PluginInventoryReport{}, Response: struct {
+				AgentID string `json:"agent_id"`
+			}{}},
+		"PluginInstallHandler.Install": {Summary: "经Agent安装或更新Logstash插件", Description: "需要管理员角色，Agent须已建立WebSocket连接；同一Agent同时只允许一个安装。未指定version时安装最新版本，指定bundle_url时从离线包安装。返回202，之后查询安装记录获取进度和结果",
+			Request: models.PluginInstallRequest{}, Response: models.PluginInstall{}},
+		"PluginInstallHandler.ListInstalls": {Summary: "获取Agent的插件安装记录", Description: "按创建时间倒序",
+			Query: struct {
+				Size int `form:"size"`
+			}{}, Response: openapi.List(models.PluginInstall{})},
+		"PluginInstallHandler.GetInstall": {Summary: "获取插件安装的进度、结果和输出", Response: models.PluginInstall{}},
+		"DLQHandler.ListEvents": {Summary: "经Agent分页读取管道死信队列中的事件", Query: models.DLQRequest{},
+			Response: models.DLQResult{}},
+		"AgentTokenHandler.ListTokens":             {Summary: "获取Agent注册令牌记录", Response: openapi.List(models.AgentToken{})},
+		"AgentTokenHandler.Revoke":                 {Summary: "吊销Agent注册令牌", Response: models.RevokeAgentTokensResponse{}},
+		"AgentCertificateHandler.ListCertificates": {Summary: "获取Agent客户端证书记录", Response: openapi.List(models.AgentCertificate{})},
+		"AgentCertificateHandler.Revoke":           {Summary: "吊销Agent客户端证书", Response: models.RevokeAgentCertificatesResponse{}},
+
+		// Agent调用的接口，见 docs/protocol
+		"AgentTokenHandler.Enroll": {Summary: "签发Agent注册令牌", Request: models.EnrollAgentRequest{},
+			Response: models.AgentTokenResponse{}, Status: http.StatusCreated},
+		"AgentLifecycleHandler.Register": {Summary: "Agent注册", Request: models.Agent{}, Response: models.RegisterResponse{}},
+		"AgentLifecycleHandler.Heartbeat": {Summary: "Agent心跳（捎带待执行命令）", Request: models.HeartbeatRequest{},
+			Response: models.HeartbeatResponse{}},
+		"AgentLifecycleHandler.ReportMetrics": {Summary: "Agent上报指标", Request: models.MetricsReportRequest{}, Response: struct {
+			AgentID string `json:"agent_id"`
+		}{}},
+		"IncidentHandler.ReportError": {Summary: "Agent上报错误（按指纹归并为事件）", Request: models.AgentErrorReport{},
+			Status: http.StatusAccepted},
+		"DeploymentHandler.ReportConfigApplied": {Summary: "Agent上报配置应用结果", Request: models.ConfigAppliedReport{},
+			Response: recordedResponse},
+		"DeploymentHandler.DesiredConfigs": {Summary: "Agent获取应运行的配置及版本", Description: "按向该Agent下发过的部署推导，每个配置取最近一次对该Agent生效的部署；Agent启动或重新连接后据此补齐缺失的配置、删除多余的配置",
+			Response: models.AgentDesiredConfigs{}},
+		"AgentTokenHandler.Rotate": {Summary: "轮换注册令牌", Response: models.AgentTokenResponse{}},
+		"AgentCertificateHandler.Rotate": {Summary: "轮换客户端证书", Description: "使用新私钥生成的CSR申请证书，旧证书在宽限期后失效",
+			Request: models.RotateAgentCertificateRequest{}, Response: models.IssuedCertificate{}},
+		"WebSocketHandler.Poll": {Summary: "长轮询接收平台消息", Description: "供代理不允许WebSocket的Agent使用，没有消息时等待最多wait时间；received为上一批消息的序号，不符时平台重发该批消息",
+			Query: models.PollRequest{}, Response: models.PollResponse{}},
+		"WebSocketHandler.Upload": {Summary: "经长轮询通道上报消息", Request: models.PollUpload{}, Response: recordedResponse,
+			Status: http.StatusAccepted},
+		"AgentEventHandler.ReportEvent": {Summary: "Agent上报重载失败、Logstash崩溃或重启事件", Request: models.AgentEventReport{},
+			Response: recordedResponse, Status: http.StatusAccepted},
+		"SecretHandler.ResolveSecrets": {Summary: "获取待部署配置引用的密钥值", Request: models.ResolveSecretsRequest{},
+			Response: models.ResolvedSecrets{}},
+
+		// 告警
+		"AlertHandler.ListAlerts":   {Summary: "获取当前触发中的告警", Response: openapi.List(models.Alert{})},
+		"AlertHandler.ListRules":    {Summary: "获取告警规则列表", Response: openapi.List(models.AlertRule{})},
+		"AlertHandler.CreateRule":   {Summary: "创建告警规则", Request: models.AlertRuleRequest{}, Response: models.AlertRule{}, Status: http.StatusCreated},
+		"AlertHandler.GetRule":      {Summary: "获取单个告警规则", Response: models.AlertRule{}},
+		"AlertHandler.UpdateRule":   {Summary: "更新告警规则", Request: models.AlertRuleRequest{}, Response: models.AlertRule{}},
+		"AlertHandler.DeleteRule":   {Summary: "删除告警规则", Status: http.StatusNoContent},
+		"AlertHandler.ListSilences": {Summary: "获取尚未结束的静默", Response: openapi.List(models.AlertSilence{})},
+		"AlertHandler.CreateSilence": {Summary: "创建静默", Request: models.CreateAlertSilenceRequest{}, Response: models.AlertSilence{},
+			Status: http.StatusCreated},
+		"AlertHandler.DeleteSilence": {Summary: "删除静默", Status: http.StatusNoContent},
+
+		// 分组、密钥、下游集群
+		"GroupHandler.ListGroups":   {Summary: "获取分组列表", Response: openapi.List(models.AgentGroup{})},
+		"GroupHandler.CreateGroup":  {Summary: "创建分组", Request: models.CreateGroupRequest{}, Response: models.AgentGroup{}, Status: http.StatusCreated},
+		"GroupHandler.GetGroup":     {Summary: "获取单个分组", Response: models.AgentGroup{}},
+		"GroupHandler.UpdateGroup":  {Summary: "更新分组默认设置", Request: models.UpdateGroupRequest{}, Response: models.AgentGroup{}},
+		"GroupHandler.DeleteGroup":  {Summary: "删除分组", Status: http.StatusNoContent},
+		"SecretHandler.ListSecrets": {Summary: "获取密钥列表（仅元数据）", Response: openapi.List(models.Secret{})},
+		"SecretHandler.CreateSecret": {Summary: "创建密钥", Request: models.CreateSecretRequest{}, Response: models.Secret{},
+			Status: http.StatusCreated},
+		"SecretHandler.GetSecret":             {Summary: "获取单个密钥的元数据", Response: models.Secret{}},
+		"SecretHandler.UpdateSecret":          {Summary: "替换密钥值", Request: models.UpdateSecretRequest{}, Response: models.Secret{}},
+		"SecretHandler.DeleteSecret":          {Summary: "删除密钥", Status: http.StatusNoContent},
+		"DestinationHandler.ListDestinations": {Summary: "获取下游集群列表", Response: openapi.List(models.Destination{})},
+		"DestinationHandler.CreateDestination": {Summary: "创建下游集群", Request: models.CreateDestinationRequest{},
+			Response: models.Destination{}, Status: http.StatusCreated},
+		"DestinationHandler.GetDestination": {Summary: "获取单个下游集群", Response: models.Destination{}},
+		"DestinationHandler.UpdateDestination": {Summary: "更新下游集群", Request: models.UpdateDestinationRequest{},
+			Response: models.Destination{}},
+		"DestinationHandler.DeleteDestination": {Summary: "删除下游集群", Status: http.StatusNoContent},
+		"DestinationHandler.CheckDestination":  {Summary: "立即检查连通性", Response: models.Destination{}},
+		"WebhookHandler.ListWebhooks":          {Summary: "获取出站事件订阅列表", Response: openapi.List(models.Webhook{})},
+		"WebhookHandler.CreateWebhook": {Summary: "创建出站事件订阅", Description: "响应中的secret只返回这一次，用于校验推送请求的 X-Webhook-Signature",
+			Request: models.CreateWebhookRequest{}, Response: models.CreatedWebhook{}, Status: http.StatusCreated},
+		"WebhookHandler.GetWebhook":    {Summary: "获取单个出站事件订阅", Response: models.Webhook{}},
+		"WebhookHandler.UpdateWebhook": {Summary: "更新出站事件订阅", Request: models.UpdateWebhookRequest{}, Response: models.Webhook{}},
+		"WebhookHandler.DeleteWebhook": {Summary: "删除出站事件订阅", Status: http.StatusNoContent},
+		"WebhookHandler.ListDeliveries": {Summary: "获取订阅最近的推送记录", Query: models.WebhookDeliveryListRequest{},
+			Response: openapi.List(models.WebhookDelivery{})},
+
+		// 系统与字段契约
+		"WorkersStatus": {Summary: "后台子系统运行状态", Response: models.WorkersSnapshot{}},
+		"MigrationStatus": {Summary: "索引迁移状态", Description: "平台定义的各索引迁移及其执行记录，current为从第一个迁移起连续执行成功的最高版本",
+			Response: elasticsearch.MigrationStatus{}},
+		"ApplyMigrations": {Summary: "执行索引迁移", Description: "按版本顺序执行尚未成功的迁移，遇到失败时停止并返回错误；其他实例正在执行时返回409，仅管理员",
+			Response: elasticsearch.MigrationStatus{}},
+		"ContractHandler.ListContracts": {Summary: "获取字段契约列表", Response: openapi.List(models.FieldContract{})},
+		"ContractHandler.CreateContract": {Summary: "登记字段契约", Request: models.CreateFieldContractRequest{},
+			Response: models.FieldContract{}, Status: http.StatusCreated},
+		"ContractHandler.GetContract": {Summary: "获取单个字段契约", Response: models.FieldContract{}},
+		"ContractHandler.UpdateContract": {Summary: "更新字段契约", Request: models.UpdateFieldContractRequest{},
+			Response: models.FieldContract{}},
+		"ContractHandler.DeleteContract": {Summary: "删除字段契约", Status: http.StatusNoContent},
+
+		// 流水线
+		"PipelineHandler.ListPipelines": {Summary: "获取流水线列表", Response: openapi.List(models.Pipeline{})},
+		"PipelineHandler.CreatePipeline": {Summary: "创建流水线", Request: models.CreatePipelineRequest{}, Response: models.Pipeline{},
+			Status: http.StatusCreated},
+		"PipelineHandler.GetPipeline":         {Summary: "获取单个流水线", Response: models.Pipeline{}},
+		"PipelineHandler.UpdatePipeline":      {Summary: "更新流水线组成", Request: models.UpdatePipelineRequest{}, Response: models.Pipeline{}},
+		"PipelineHandler.DeletePipeline":      {Summary: "删除流水线", Status: http.StatusNoContent},
+		"PipelineHandler.GetPipelineVersions": {Summary: "获取流水线历史版本", Response: openapi.List(models.PipelineVersion{})},
+		"PipelineHandler.RenderPipeline":      {Summary: "按最新配置拼装并校验", Response: models.PipelineRender{}},
+		"PipelineHandler.DeployPipeline": {Summary: "部署流水线到Agent", Request: models.DeployPipelineRequest{},
+			Response: models.Deployment{}, Status: http.StatusAccepted},
+
+		// 部署
+		"DeploymentHandler.ListDeployments": {Summary: "获取部署记录列表", Query: models.DeploymentListRequest{},
+			Response: openapi.Page(models.Deployment{})},
+		"DeploymentHandler.CreateDeployment": {Summary: "创建部署并下发到目标Agent", Description: "按Agent ID列表或标签选择器指定目标，受测试门禁和下游集群节流约束",
+			Request: models.CreateDeploymentRequest{}, Response: models.Deployment{}, Status: http.StatusAccepted},
+		"DeploymentHandler.PreviewDeployment": {Summary: "预览部署", Description: "请求体与创建部署相同，返回各目标Agent按所在环境渲染后的配置内容（密钥显示为keystore引用）、与当前运行版本的差异，以及缺少插件、未通过测试、被回滚固定等警告，不创建部署也不下发消息",
+			Request: models.CreateDeploymentRequest{}, Response: models.DeploymentPreview{}},
+		"DestinationThrottleStats": {Summary: "下游集群节流状态", Response: struct {
+			Items map[string]service.DestinationStats `json:"items"`
+		}{}},
+		"DeploymentHandler.GetDeployment": {Summary: "获取单个部署记录", Response: models.Deployment{}},
+		"DeploymentHandler.ApproveDeployment": {Summary: "提交部署审批", Request: models.ApproveDeploymentRequest{},
+			Response: models.Deployment{}},
+		"DeploymentHandler.RollbackDeployment": {Summary: "回滚部署", Description: "按各Agent的配置应用记录恢复部署前的版本，返回跟踪回滚进度的回滚部署记录",
+			Response: models.Deployment{}, Status: http.StatusAccepted},
+		"DeploymentHandler.GetDeploymentReport": {Summary: "导出部署审计报告", ResponseType: "text/html", Params: []openapi.Param{
+			{Name: "format", Description: "html（默认）"},
+			{Name: "download", Description: "为true时作为附件下载"},
+		}},
+
+		// 事件、报表与期望状态
+		"IncidentHandler.ListIncidents": {Summary: "获取事件列表", Query: models.IncidentListRequest{},
+			Response: openapi.Page(models.Incident{})},
+		"IncidentHandler.GetIncident":     {Summary: "获取单个事件", Response: models.Incident{}},
+		"IncidentHandler.ResolveIncident": {Summary: "解决事件", Request: models.ResolveIncidentRequest{}, Response: models.Incident{}},
+		"ReportHandler.GetDeliveryReport": {Summary: "变更交付指标（DORA）", Query: models.DeliveryReportRequest{},
+			Response: models.DeliveryReport{}},
+		"OverviewHandler.GetOverview": {Summary: "仪表盘总览", Description: "按状态统计的Agent、按类型和测试状态统计的配置、最近24小时的部署及成功率、CPU使用率最高的Agent和最近失败的部署",
+			Response: models.FleetOverview{}},
+		"DesiredStateHandler.Apply": {Summary: "计划或应用期望状态", Description: "请求体为YAML或JSON格式的期望状态文件",
+			Request: models.DesiredState{}, RequestType: "application/yaml", Response: models.ApplyPlan{}, Params: []openapi.Param{
+				{Name: "dry_run", Description: "为true时只返回计划，不执行变更"},
+				{Name: "force", Description: "prune时允许删除仍被Agent应用的配置"},
+			}},
+	}
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/service"
+)
+
+// OverviewHandler 仪表盘总览处理器
+type OverviewHandler struct {
+	overviewService service.OverviewService
+	logger          *logrus.Logger
+}
+
+// NewOverviewHandler 创建仪表盘总览处理器
+func NewOverviewHandler(overviewService service.OverviewService, logger *logrus.Logger) *OverviewHandler {
+	return &OverviewHandler{
+		overviewService: overviewService,
+		logger:          logger,
+	}
+}
+
+// GetOverview 获取仪表盘总览（Agent状态、配置、最近24小时部署、CPU最高的Agent和最近的失败部署）
+func (h *OverviewHandler) GetOverview(c *gin.Context) {
+	overview, err := h.overviewService.Overview(c.Request.Context())
+	if err != nil {
+		h.logger.Errorf("生成仪表盘总览失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "生成仪表盘总览失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, overview)
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+)
+
+// PipelineHandler 流水线处理器
+type PipelineHandler struct {
+	pipelines service.PipelineService
+	logger    *logrus.Logger
+}
+
+// NewPipelineHandler 创建流水线处理器
+func NewPipelineHandler(pipelines service.PipelineService, logger *logrus.Logger) *PipelineHandler {
+	return &PipelineHandler{
+		pipelines: pipelines,
+		logger:    logger,
+	}
+}
+
+// ListPipelines 获取流水线列表
+func (h *PipelineHandler) ListPipelines(c *gin.Context) {
+	pipelines, err := h.pipelines.ListPipelines(c.Request.Context())
+	if err != nil {
+		h.logger.Errorf("获取流水线列表失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "获取流水线列表失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items": pipelines,
+		"total": len(pipelines),
+	})
+}
+
+// CreatePipeline 创建流水线
+func (h *PipelineHandler) CreatePipeline(c *gin.Context) {
+	var req models.CreatePipelineRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	pipeline, err := h.pipelines.CreatePipeline(c.Request.Context(), &req, middleware.CurrentUserID(c))
+	if err != nil {
+		h.handlePipelineError(c, err, "创建流水线失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, pipeline)
+}
+
+// GetPipeline 获取单个流水线
+func (h *PipelineHandler) GetPipeline(c *gin.Context) {
+	pipeline, err := h.pipelines.GetPipeline(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handlePipelineError(c, err, "获取流水线失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, pipeline)
+}
+
+// UpdatePipeline 更新流水线组成
+func (h *PipelineHandler) UpdatePipeline(c *gin.Context) {
+	var req models.UpdatePipelineRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	pipeline, err := h.pipelines.UpdatePipeline(c.Request.Context(), c.Param("id"), &req, middleware.CurrentUserID(c))
+	if err != nil {
+		h.handlePipelineError(c, err, "更新流水线失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, pipeline)
+}
+
+// DeletePipeline 删除流水线
+func (h *PipelineHandler) DeletePipeline(c *gin.Context) {
+	if err := h.pipelines.DeletePipeline(c.Request.Context(), c.Param("id")); err != nil {
+		h.handlePipelineError(c, err, "删除流水线失败")
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// GetPipelineVersions 获取流水线历史版本
+func (h *PipelineHandler) GetPipelineVersions(c *gin.Context) {
+	versions, err := h.pipelines.GetPipelineVersions(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handlePipelineError(c, err, "获取流水线版本失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items": versions,
+		"total": len(versions),
+	})
+}
+
+// RenderPipeline 按引用配置的最新内容拼装并校验流水线
+func (h *PipelineHandler) RenderPipeline(c *gin.Context) {
+	render, err := h.pipelines.RenderPipeline(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handlePipelineError(c, err, "拼装流水线失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, render)
+}
+
+// DeployPipeline 部署流水线到Agent
+func (h *PipelineHandler) DeployPipeline(c *gin.Context) {
+	var req models.DeployPipelineRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	if len(req.AgentIDs) == 0 && req.Selector == "" {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "必须指定Agent ID列表或标签选择器"))
+		return
+	}
+
+	deployment, err := h.pipelines.DeployPipeline(c.Request.Context(), c.Param("id"), &req, middleware.CurrentUserID(c))
+	if err != nil {
+		h.handlePipelineError(c, err, "部署流水线失败")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, deployment)
+}
+
+// handlePipelineError 将流水线服务错误映射为HTTP响应
+func (h *PipelineHandler) handlePipelineError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrPipelineNotFound):
+		middleware.AbortWithError(c, apperror.New(apperror.NotFound, "流水线不存在"))
+	case errors.Is(err, service.ErrPipelineExists):
+		middleware.AbortWithError(c, apperror.New(apperror.AlreadyExists, err.Error()))
+	case errors.Is(err, service.ErrPipelineInvalid):
+		middleware.AbortWithError(c, apperror.New(apperror.Validation, err.Error()))
+	case errors.Is(err, service.ErrConfigForbidden):
+		middleware.AbortWithError(c, apperror.New(apperror.Forbidden, "无权访问流水线引用的配置"))
+	case errors.Is(err, models.ErrInvalidSelector):
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidSelector, err.Error()))
+	case errors.Is(err, service.ErrNoTargets):
+		middleware.AbortWithError(c, apperror.New(apperror.NoTargets, "没有匹配的Agent"))
+	case errors.Is(err, service.ErrConfigDisabled):
+		middleware.AbortWithError(c, apperror.New(apperror.ConfigDisabled, "流水线配置已禁用，无法部署"))
+	case errors.Is(err, models.ErrInvalidConfigID):
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidConfigID, err.Error()))
+	case errors.Is(err, service.ErrConfigNotApproved):
+		middleware.AbortWithError(c, apperror.New(apperror.ConfigNotApproved, err.Error()))
+	case errors.Is(err, service.ErrTestGateFailed):
+		middleware.AbortWithError(c, apperror.New(apperror.TestGateFailed, err.Error()))
+	case errors.Is(err, service.ErrPluginsMissing):
+		middleware.AbortWithError(c, apperror.New(apperror.PluginsMissing, err.Error()))
+	default:
+		h.logger.Errorf("%s: %v", message, err)
+		middleware.AbortWithError(c, apperror.Wrap(err, message))
+	}
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+)
+
+// PluginInstallHandler 插件远程安装处理器
+type PluginInstallHandler struct {
+	installer *service.PluginInstaller
+	logger    *logrus.Logger
+}
+
+// NewPluginInstallHandler 创建插件远程安装处理器
+func NewPluginInstallHandler(installer *service.PluginInstaller, logger *logrus.Logger) *PluginInstallHandler {
+	return &PluginInstallHandler{
+		installer: installer,
+		logger:    logger,
+	}
+}
+
+// Install 下发插件安装或更新请求，返回202和pending状态的安装记录
+func (h *PluginInstallHandler) Install(c *gin.Context) {
+	agentID := c.Param("id")
+
+	var req models.PluginInstallRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效，需要指定name")
+		return
+	}
+
+	install, err := h.installer.Start(c.Request.Context(), agentID, &req, middleware.CurrentUserID(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidPluginInstall):
+			middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, err.Error()))
+		case errors.Is(err, service.ErrAgentOffline):
+			middleware.AbortWithError(c, apperror.New(apperror.AgentNotConnected, "Agent未建立WebSocket连接，无法安装插件"))
+		case errors.Is(err, service.ErrPluginInstallRunning):
+			middleware.AbortWithError(c, apperror.New(apperror.Conflict, err.Error()))
+		default:
+			h.logger.WithError(err).WithField("agent_id", agentID).Error("下发插件安装请求失败")
+			middleware.AbortWithError(c, apperror.Wrap(err, "下发插件安装请求失败"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, install)
+}
+
+// ListInstalls 获取Agent最近的插件安装记录
+func (h *PluginInstallHandler) ListInstalls(c *gin.Context) {
+	size, _ := strconv.Atoi(c.Query("size"))
+	installs, err := h.installer.List(c.Request.Context(), c.Param("id"), size)
+	if err != nil {
+		h.logger.Errorf("获取插件安装记录失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "获取插件安装记录失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items": installs,
+		"total": len(installs),
+	})
+}
+
+// GetInstall 获取插件安装的进度和结果，含logstash-plugin的输出
+func (h *PluginInstallHandler) GetInstall(c *gin.Context) {
+	install, err := h.installer.Get(c.Request.Context(), c.Param("id"), c.Param("install_id"))
+	if err != nil {
+		if errors.Is(err, service.ErrPluginInstallNotFound) {
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "插件安装记录不存在"))
+			return
+		}
+		h.logger.Errorf("获取插件安装记录失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "获取插件安装记录失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, install)
+}
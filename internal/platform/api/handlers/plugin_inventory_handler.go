@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+)
+
+// PluginInventoryHandler Agent插件清单处理器
+type PluginInventoryHandler struct {
+	plugins service.PluginInventoryService
+	logger  *logrus.Logger
+}
+
+// NewPluginInventoryHandler 创建Agent插件清单处理器
+func NewPluginInventoryHandler(plugins service.PluginInventoryService, logger *logrus.Logger) *PluginInventoryHandler {
+	return &PluginInventoryHandler{
+		plugins: plugins,
+		logger:  logger,
+	}
+}
+
+// Report Agent上报已安装的Logstash插件清单
+func (h *PluginInventoryHandler) Report(c *gin.Context) {
+	agentID := c.Param("id")
+
+	var req models.PluginInventoryReport
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	if err := h.plugins.Report(c.Request.Context(), agentID, &req); err != nil {
+		h.logger.Errorf("保存Agent插件清单失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "保存Agent插件清单失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"agent_id": agentID})
+}
+
+// Get 获取Agent最近一次上报的插件清单
+func (h *PluginInventoryHandler) Get(c *gin.Context) {
+	inventory, err := h.plugins.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, service.ErrPluginInventoryNotFound) {
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "Agent尚未上报插件清单"))
+			return
+		}
+		h.logger.Errorf("获取Agent插件清单失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "获取Agent插件清单失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, inventory)
+}
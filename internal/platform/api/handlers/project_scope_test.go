@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+	"logstash-platform/internal/platform/service"
+	"logstash-platform/tests/mocks"
+)
+
+// projectTokens 按令牌返回固定身份
+type projectTokens map[string]*models.TokenClaims
+
+func (v projectTokens) VerifyToken(ctx context.Context, token string) (*models.TokenClaims, error) {
+	if claims, ok := v[token]; ok {
+		return claims, nil
+	}
+	return nil, errors.New("令牌无效")
+}
+
+// memPipelineRepository 内存中的流水线仓库，仅实现按ID读取
+type memPipelineRepository struct {
+	repository.PipelineRepository
+	pipelines map[string]*models.Pipeline
+}
+
+func (r *memPipelineRepository) GetByID(ctx context.Context, id string) (*models.Pipeline, error) {
+	pipeline, ok := r.pipelines[id]
+	if !ok {
+		return nil, errors.New("文档不存在")
+	}
+	cp := *pipeline
+	return &cp, nil
+}
+
+// TestProjectScopedRoutes 只在payments项目有editor角色的用户不能清理或部署其他项目的配置，也不能变更平台级的分组
+func TestProjectScopedRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel)
+
+	configs := map[string]*models.Config{
+		"pay-in":      {ID: "pay-in", Name: "pay-in", Type: models.ConfigTypeInput, Content: "input {}", Project: "payments"},
+		"pay-out":     {ID: "pay-out", Name: "pay-out", Type: models.ConfigTypeOutput, Content: "output {}", Project: "payments"},
+		"default-in":  {ID: "default-in", Name: "default-in", Type: models.ConfigTypeInput, Content: "input {}"},
+		"default-out": {ID: "default-out", Name: "default-out", Type: models.ConfigTypeOutput, Content: "output {}"},
+	}
+	configRepo := new(mocks.MockConfigRepository)
+	for id, config := range configs {
+		configRepo.On("GetByID", mock.Anything, id).Return(config, nil)
+	}
+	// 仓库按请求中的项目过滤配置列表
+	for _, project := range []string{"payments", models.DefaultProject} {
+		resp := &models.ConfigListResponse{Page: 1, Size: 100}
+		for _, config := range configs {
+			if models.ProjectOf(config.Project) == project {
+				resp.Items = append(resp.Items, config)
+			}
+		}
+		resp.Total = int64(len(resp.Items))
+		configRepo.On("List", mock.Anything, mock.MatchedBy(func(req *models.ConfigListRequest) bool {
+			return req.Project == project
+		})).Return(resp, nil)
+	}
+	configRepo.On("Delete", mock.Anything, mock.Anything).Return(nil)
+
+	shared := &models.AgentGroup{Name: "shared"}
+	groupRepo := new(mocks.MockGroupRepository)
+	groupRepo.On("List", mock.Anything).Return([]*models.AgentGroup{shared}, nil)
+	groupRepo.On("GetByName", mock.Anything, "shared").Return(shared, nil)
+	groupRepo.On("Delete", mock.Anything, "shared").Return(nil)
+	agentRepo := new(mocks.MockAgentRepository)
+	agentRepo.On("ListByAppliedConfig", mock.Anything, mock.Anything).Return([]*models.Agent{}, nil)
+	agentRepo.On("ListByGroup", mock.Anything, "shared").Return([]*models.Agent{}, nil)
+
+	groupService := service.NewGroupService(groupRepo, agentRepo, nil, logger)
+	desiredState := service.NewDesiredStateService(service.NewConfigService(configRepo, logger), groupService, agentRepo, logger)
+	pipelines := service.NewPipelineService(&memPipelineRepository{pipelines: map[string]*models.Pipeline{
+		"pay-pipeline":     {ID: "pay-pipeline", Name: "pay-pipeline", ConfigIDs: []string{"pay-in", "pay-out"}},
+		"default-pipeline": {ID: "default-pipeline", Name: "default-pipeline", ConfigIDs: []string{"default-in", "default-out"}},
+	}}, configRepo, nil, nil, logger)
+
+	verifier := projectTokens{
+		"scoped-token": {Subject: "bob", Role: models.RoleViewer, ProjectRoles: map[string]string{"payments": models.RoleEditor}},
+		"editor-token": {Subject: "carol", Role: models.RoleEditor},
+	}
+	router := gin.New()
+	v1 := router.Group("/api/v1", middleware.Authenticate(verifier))
+	scoped := middleware.ProjectScope()
+	v1.POST("/apply", scoped, middleware.RequireRole(models.RoleEditor), NewDesiredStateHandler(desiredState, logger).Apply)
+	readWrite := middleware.RequireRoleByMethod(models.RoleViewer, models.RoleEditor)
+	pipelineHandler := NewPipelineHandler(pipelines, logger)
+	pipelineRoutes := v1.Group("/pipelines", scoped, readWrite)
+	pipelineRoutes.GET("/:id/render", pipelineHandler.RenderPipeline)
+	pipelineRoutes.POST("/:id/deploy", pipelineHandler.DeployPipeline)
+	v1.POST("/groups", readWrite, NewGroupHandler(groupService, logger).CreateGroup)
+
+	token := "scoped-token"
+	request := func(method, target, project, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, target, bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		if project != "" {
+			req.Header.Set(middleware.ProjectHeader, project)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("未分配的项目拒绝清理", func(t *testing.T) {
+		w := request("POST", "/api/v1/apply", "", `{"prune": true}`)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		configRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+
+	t.Run("清理只删除本项目的配置", func(t *testing.T) {
+		w := request("POST", "/api/v1/apply", "payments", `{"prune": true}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		configRepo.AssertCalled(t, "Delete", mock.Anything, "pay-in")
+		configRepo.AssertCalled(t, "Delete", mock.Anything, "pay-out")
+		configRepo.AssertNotCalled(t, "Delete", mock.Anything, "default-in")
+		configRepo.AssertNotCalled(t, "Delete", mock.Anything, "default-out")
+		groupRepo.AssertNotCalled(t, "Delete", mock.Anything, "shared")
+	})
+
+	t.Run("不能变更平台级的分组", func(t *testing.T) {
+		w := request("POST", "/api/v1/groups", "payments", `{"name": "payments-group"}`)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = request("POST", "/api/v1/apply", "payments", `{"groups": [{"name": "shared", "description": "changed"}]}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var plan models.ApplyPlan
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &plan))
+		require.Len(t, plan.Actions, 1)
+		assert.Contains(t, plan.Actions[0].Error, "全局editor")
+		assert.Equal(t, 1, plan.Summary["failed"])
+		groupRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("不能部署或拼装其他项目的流水线", func(t *testing.T) {
+		w := request("POST", "/api/v1/pipelines/default-pipeline/deploy", "payments", `{"agent_ids": ["agent-1"]}`)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		w = request("GET", "/api/v1/pipelines/default-pipeline/render", "payments", "")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		w = request("POST", "/api/v1/pipelines/default-pipeline/deploy", "", `{"agent_ids": ["agent-1"]}`)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = request("GET", "/api/v1/pipelines/pay-pipeline/render", "payments", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("全局editor清理未声明的分组", func(t *testing.T) {
+		token = "editor-token"
+		w := request("POST", "/api/v1/apply", "", `{"prune": true}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		groupRepo.AssertCalled(t, "Delete", mock.Anything, "shared")
+	})
+}
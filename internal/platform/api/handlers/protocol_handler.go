@@ -0,0 +1,15 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"logstash-platform/internal/platform/protocol"
+)
+
+// ProtocolSchema 返回Agent通信协议的JSON Schema，供第三方Agent实现参考
+func ProtocolSchema(c *gin.Context) {
+	c.Header("X-Protocol-Version", protocol.Version)
+	c.JSON(http.StatusOK, protocol.Generate())
+}
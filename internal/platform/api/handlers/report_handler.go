@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+)
+
+// ReportHandler 报表处理器
+type ReportHandler struct {
+	metricsService service.DeliveryMetricsService
+	logger         *logrus.Logger
+}
+
+// NewReportHandler 创建报表处理器
+func NewReportHandler(metricsService service.DeliveryMetricsService, logger *logrus.Logger) *ReportHandler {
+	return &ReportHandler{
+		metricsService: metricsService,
+		logger:         logger,
+	}
+}
+
+// GetDeliveryReport 获取变更交付指标（部署频率、前置时间、变更失败率、MTTR）
+func (h *ReportHandler) GetDeliveryReport(c *gin.Context) {
+	var req models.DeliveryReportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效，时间格式应为RFC3339")
+		return
+	}
+
+	report, err := h.metricsService.Report(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.Errorf("生成变更交付报表失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "生成变更交付报表失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
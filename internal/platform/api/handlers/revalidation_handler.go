@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+)
+
+// RevalidationHandler 配置定期重新校验处理器
+type RevalidationHandler struct {
+	revalidator *service.ConfigRevalidator
+	logger      *logrus.Logger
+}
+
+// NewRevalidationHandler 创建配置定期重新校验处理器
+func NewRevalidationHandler(revalidator *service.ConfigRevalidator, logger *logrus.Logger) *RevalidationHandler {
+	return &RevalidationHandler{
+		revalidator: revalidator,
+		logger:      logger,
+	}
+}
+
+// ListRevalidations 获取各配置最近一次的重新校验结果及最近一次执行汇总
+func (h *RevalidationHandler) ListRevalidations(c *gin.Context) {
+	var req models.ConfigRevalidationListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	items, total, err := h.revalidator.ListResults(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.Errorf("获取重新校验结果失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "获取重新校验结果失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":    total,
+		"page":     req.Page,
+		"size":     req.PageSize,
+		"items":    items,
+		"last_run": h.revalidator.LastRun(),
+	})
+}
+
+// TriggerRevalidation 立即在后台执行一次重新校验
+func (h *RevalidationHandler) TriggerRevalidation(c *gin.Context) {
+	if err := h.revalidator.Trigger(); err != nil {
+		if errors.Is(err, service.ErrRevalidationRunning) {
+			middleware.AbortWithError(c, apperror.New(apperror.Conflict, "重新校验正在进行中"))
+			return
+		}
+		h.logger.Errorf("启动重新校验失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "启动重新校验失败"))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "重新校验已开始"})
+}
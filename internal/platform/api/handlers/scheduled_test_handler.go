@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+)
+
+// ScheduledTestHandler 配置定期测试处理器
+type ScheduledTestHandler struct {
+	runner *service.ScheduledTestRunner
+	logger *logrus.Logger
+}
+
+// NewScheduledTestHandler 创建配置定期测试处理器
+func NewScheduledTestHandler(runner *service.ScheduledTestRunner, logger *logrus.Logger) *ScheduledTestHandler {
+	return &ScheduledTestHandler{
+		runner: runner,
+		logger: logger,
+	}
+}
+
+// ListScheduledTests 获取配置的定期测试记录及最近一次执行汇总
+func (h *ScheduledTestHandler) ListScheduledTests(c *gin.Context) {
+	var req models.ScheduledTestListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	items, total, err := h.runner.ListRuns(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrConfigNotFound) {
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "配置不存在"))
+			return
+		}
+		if abortIfForbidden(c, err) {
+			return
+		}
+		h.logger.Errorf("获取定期测试记录失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "获取定期测试记录失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":    total,
+		"page":     req.Page,
+		"size":     req.PageSize,
+		"items":    items,
+		"last_run": h.runner.LastRun(),
+	})
+}
+
+// TriggerScheduledTests 立即在后台执行一次定期测试
+func (h *ScheduledTestHandler) TriggerScheduledTests(c *gin.Context) {
+	if err := h.runner.Trigger(); err != nil {
+		if errors.Is(err, service.ErrScheduledTestsRunning) {
+			middleware.AbortWithError(c, apperror.New(apperror.Conflict, "定期测试正在进行中"))
+			return
+		}
+		h.logger.Errorf("启动定期测试失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "启动定期测试失败"))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "定期测试已开始"})
+}
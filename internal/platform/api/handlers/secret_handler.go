@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+)
+
+// SecretHandler 密钥管理处理器
+type SecretHandler struct {
+	secrets service.SecretService
+	logger  *logrus.Logger
+}
+
+// NewSecretHandler 创建密钥管理处理器
+func NewSecretHandler(secrets service.SecretService, logger *logrus.Logger) *SecretHandler {
+	return &SecretHandler{
+		secrets: secrets,
+		logger:  logger,
+	}
+}
+
+// ListSecrets 获取密钥列表（仅元数据）
+func (h *SecretHandler) ListSecrets(c *gin.Context) {
+	secrets, err := h.secrets.ListSecrets(c.Request.Context())
+	if err != nil {
+		h.handleSecretError(c, err, "获取密钥列表失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items": secrets,
+		"total": len(secrets),
+	})
+}
+
+// CreateSecret 创建密钥
+func (h *SecretHandler) CreateSecret(c *gin.Context) {
+	var req models.CreateSecretRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	secret, err := h.secrets.CreateSecret(c.Request.Context(), &req, middleware.CurrentUserID(c))
+	if err != nil {
+		h.handleSecretError(c, err, "创建密钥失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, secret)
+}
+
+// GetSecret 获取单个密钥的元数据
+func (h *SecretHandler) GetSecret(c *gin.Context) {
+	secret, err := h.secrets.GetSecret(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		h.handleSecretError(c, err, "获取密钥失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, secret)
+}
+
+// UpdateSecret 替换密钥值
+func (h *SecretHandler) UpdateSecret(c *gin.Context) {
+	var req models.UpdateSecretRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	secret, err := h.secrets.UpdateSecret(c.Request.Context(), c.Param("name"), &req, middleware.CurrentUserID(c))
+	if err != nil {
+		h.handleSecretError(c, err, "更新密钥失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, secret)
+}
+
+// DeleteSecret 删除密钥
+func (h *SecretHandler) DeleteSecret(c *gin.Context) {
+	if err := h.secrets.DeleteSecret(c.Request.Context(), c.Param("name")); err != nil {
+		h.handleSecretError(c, err, "删除密钥失败")
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// ResolveSecrets Agent获取待部署配置引用的密钥值
+func (h *SecretHandler) ResolveSecrets(c *gin.Context) {
+	var req models.ResolveSecretsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	resolved, err := h.secrets.ResolveForConfig(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		h.handleSecretError(c, err, "获取密钥失败")
+		return
+	}
+
+	// 响应包含明文，禁止中间代理缓存
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, resolved)
+}
+
+// handleSecretError 将密钥服务错误映射为HTTP响应
+func (h *SecretHandler) handleSecretError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrSecretsDisabled):
+		middleware.AbortWithError(c, apperror.New(apperror.SecretsDisabled, err.Error()))
+	case errors.Is(err, service.ErrSecretNotFound):
+		middleware.AbortWithError(c, apperror.New(apperror.NotFound, "密钥不存在"))
+	case errors.Is(err, service.ErrConfigNotFound):
+		middleware.AbortWithError(c, apperror.New(apperror.NotFound, "配置不存在"))
+	case errors.Is(err, service.ErrSecretExists):
+		middleware.AbortWithError(c, apperror.New(apperror.AlreadyExists, err.Error()))
+	case errors.Is(err, service.ErrSecretInvalid):
+		middleware.AbortWithError(c, apperror.New(apperror.Validation, err.Error()))
+	case errors.Is(err, service.ErrSecretNotReferenced), errors.Is(err, service.ErrConfigNotAssigned):
+		middleware.AbortWithError(c, apperror.New(apperror.Forbidden, err.Error()))
+	default:
+		h.logger.Errorf("%s: %v", message, err)
+		middleware.AbortWithError(c, apperror.Wrap(err, message))
+	}
+}
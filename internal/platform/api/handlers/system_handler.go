@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// metricsPrefix 平台导出指标的名称前缀
+const metricsPrefix = "logstash_platform_"
+
+// WorkersStatus 获取平台各后台子系统的运行状态
+func WorkersStatus(registry *service.WorkerRegistry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, registry.Snapshot())
+	}
+}
+
+// MigrationStatus 获取ES索引迁移的执行状态
+func MigrationStatus(es elasticsearch.ClientInterface, logger *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status, err := es.MigrationStatus(c.Request.Context())
+		if err != nil {
+			logger.Errorf("获取索引迁移状态失败: %v", err)
+			middleware.AbortWithError(c, apperror.Wrap(err, "获取索引迁移状态失败"))
+			return
+		}
+		c.JSON(http.StatusOK, status)
+	}
+}
+
+// ApplyMigrations 立即执行尚未执行的ES索引迁移，用于关闭启动时自动执行或重试失败的迁移
+func ApplyMigrations(es elasticsearch.ClientInterface, logger *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status, err := es.Migrate(c.Request.Context())
+		if err != nil {
+			if errors.Is(err, elasticsearch.ErrMigrationLocked) {
+				middleware.AbortWithError(c, apperror.New(apperror.Conflict, err.Error()))
+				return
+			}
+			logger.Errorf("执行索引迁移失败: %v", err)
+			middleware.AbortWithError(c, apperror.Wrap(err, "执行索引迁移失败"))
+			return
+		}
+		c.JSON(http.StatusOK, status)
+	}
+}
+
+// WorkerMetrics 以Prometheus文本格式导出后台子系统的运行状态
+func WorkerMetrics(registry *service.WorkerRegistry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var buf bytes.Buffer
+		writeWorkerMetrics(&buf, registry.Snapshot())
+		c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", buf.Bytes())
+	}
+}
+
+// writeWorkerMetrics 输出Prometheus文本格式
+// 通用指标以 worker 标签区分子系统，子系统特有的数值导出为 logstash_platform_<子系统>_<名称>
+func writeWorkerMetrics(w io.Writer, snapshot *models.WorkersSnapshot) {
+	family := func(name, kind, help string) {
+		fmt.Fprintf(w, "# HELP %s%s %s\n# TYPE %s%s %s\n", metricsPrefix, name, help, metricsPrefix, name, kind)
+	}
+	sample := func(name, worker string, value float64) {
+		fmt.Fprintf(w, "%s%s{worker=%q} %g\n", metricsPrefix, name, worker, value)
+	}
+
+	family("worker_up", "gauge", "后台子系统是否在运行")
+	for _, s := range snapshot.Workers {
+		up := 0.0
+		if s.Running {
+			up = 1
+		}
+		sample("worker_up", s.Name, up)
+	}
+
+	var loops []models.WorkerStatus
+	for _, s := range snapshot.Workers {
+		if s.Kind == models.WorkerKindLoop {
+			loops = append(loops, s)
+		}
+	}
+	if len(loops) > 0 {
+		family("worker_lag_seconds", "gauge", "周期任务超过预期执行时间仍未开始下一次执行的时长")
+		for _, s := range loops {
+			sample("worker_lag_seconds", s.Name, s.LagSeconds)
+		}
+		family("worker_interval_seconds", "gauge", "周期任务的执行间隔")
+		for _, s := range loops {
+			sample("worker_interval_seconds", s.Name, s.IntervalSeconds)
+		}
+		family("worker_runs_total", "counter", "周期任务的执行次数")
+		for _, s := range loops {
+			sample("worker_runs_total", s.Name, float64(s.Runs))
+		}
+		family("worker_failures_total", "counter", "周期任务执行失败的次数")
+		for _, s := range loops {
+			sample("worker_failures_total", s.Name, float64(s.Failures))
+		}
+		family("worker_last_run_timestamp_seconds", "gauge", "周期任务最近一次开始执行的时间")
+		for _, s := range loops {
+			if s.LastRunAt != nil {
+				sample("worker_last_run_timestamp_seconds", s.Name, float64(s.LastRunAt.Unix()))
+			}
+		}
+		family("worker_last_duration_seconds", "gauge", "周期任务最近一次执行的耗时")
+		for _, s := range loops {
+			sample("worker_last_duration_seconds", s.Name, s.LastDuration)
+		}
+	}
+
+	for _, s := range snapshot.Workers {
+		names := make([]string, 0, len(s.Gauges))
+		for name := range s.Gauges {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			metric := fmt.Sprintf("%s%s_%s", metricsPrefix, s.Name, name)
+			fmt.Fprintf(w, "# TYPE %s gauge\n%s %g\n", metric, metric, s.Gauges[name])
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"logstash-platform/internal/platform/models"
+)
+
+func TestWriteWorkerMetrics(t *testing.T) {
+	lastRun := time.Unix(1772359200, 0)
+	snapshot := &models.WorkersSnapshot{
+		Workers: []models.WorkerStatus{
+			{Name: "command_queue", Kind: models.WorkerKindQueue, Running: true, Gauges: map[string]float64{"unacked": 2, "pending": 5}},
+			{Name: "liveness_monitor", Kind: models.WorkerKindLoop, Running: true, IntervalSeconds: 30, LastRunAt: &lastRun, Runs: 7, Failures: 1, LagSeconds: 4.5},
+		},
+	}
+
+	var buf bytes.Buffer
+	writeWorkerMetrics(&buf, snapshot)
+	out := buf.String()
+
+	assert.Contains(t, out, "# TYPE logstash_platform_worker_up gauge\n")
+	assert.Contains(t, out, `logstash_platform_worker_up{worker="command_queue"} 1`+"\n")
+	assert.Contains(t, out, `logstash_platform_worker_lag_seconds{worker="liveness_monitor"} 4.5`+"\n")
+	assert.Contains(t, out, "# TYPE logstash_platform_worker_runs_total counter\n")
+	assert.Contains(t, out, `logstash_platform_worker_runs_total{worker="liveness_monitor"} 7`+"\n")
+	assert.Contains(t, out, `logstash_platform_worker_last_run_timestamp_seconds{worker="liveness_monitor"} 1.7723592e+09`+"\n")
+	// 非周期任务不导出执行次数
+	assert.NotContains(t, out, `logstash_platform_worker_runs_total{worker="command_queue"}`)
+	// 子系统特有的数值按名称排序导出
+	assert.Contains(t, out, "logstash_platform_command_queue_pending 5\n# TYPE logstash_platform_command_queue_unacked gauge\nlogstash_platform_command_queue_unacked 2\n")
+}
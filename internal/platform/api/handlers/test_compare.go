@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+)
+
+// defaultCompareIgnoreFields 未指定忽略字段时不参与比较的字段，每次处理都会变化
+var defaultCompareIgnoreFields = []string{"@timestamp"}
+
+// CompareTest 同一组样本分别经两个配置版本处理，逐条返回输出的字段级差异
+// 样本数有上限，请求同步执行并直接返回对比结果
+func (h *TestHandler) CompareTest(c *gin.Context) {
+	var req models.TestCompareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	base, ok := h.compareConfig(c, req.Base)
+	if !ok {
+		return
+	}
+	candidate, ok := h.compareConfig(c, req.Candidate)
+	if !ok {
+		return
+	}
+
+	ignore := req.IgnoreFields
+	if len(ignore) == 0 {
+		ignore = defaultCompareIgnoreFields
+	}
+
+	started := time.Now()
+	outputs := h.processSamples([]*models.Config{base, candidate}, req.Samples)
+	baseOutputs, candidateOutputs := outputs[0], outputs[1]
+
+	result := &models.TestCompareResult{
+		Base:         models.TestCompareSide{ConfigID: base.ID, Version: base.Version},
+		Candidate:    models.TestCompareSide{ConfigID: candidate.ID, Version: candidate.Version},
+		Identical:    true,
+		EventCount:   len(req.Samples),
+		IgnoreFields: ignore,
+		Events:       make([]models.EventComparison, len(req.Samples)),
+	}
+	for i, sample := range req.Samples {
+		event := compareOutputs(baseOutputs[i], candidateOutputs[i], ignore)
+		event.Index = i
+		event.Input = sample
+		if !event.Identical {
+			result.Identical = false
+			result.ChangedCount++
+		}
+		result.Events[i] = event
+	}
+	result.DurationMs = time.Since(started).Milliseconds()
+
+	h.logger.WithFields(logrus.Fields{
+		"base":      base.ID,
+		"candidate": candidate.ID,
+		"samples":   len(req.Samples),
+		"changed":   result.ChangedCount,
+	}).Info("配置版本对比测试完成")
+
+	c.JSON(http.StatusOK, result)
+}
+
+// compareConfig 获取对比一侧的配置，指定版本时使用该版本的历史内容；失败时已写入错误响应
+func (h *TestHandler) compareConfig(c *gin.Context, side models.TestCompareSide) (*models.Config, bool) {
+	config, err := h.configService.GetConfig(c.Request.Context(), side.ConfigID)
+	if err != nil {
+		if err.Error() == "文档不存在" {
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "配置不存在: "+side.ConfigID))
+			return nil, false
+		}
+		if abortIfForbidden(c, err) {
+			return nil, false
+		}
+		h.logger.Errorf("获取配置失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "获取配置失败"))
+		return nil, false
+	}
+	if side.Version == 0 || side.Version == config.Version {
+		return config, true
+	}
+
+	versioned, ok, err := configVersion(c.Request.Context(), h.configService, config, side.Version)
+	if err != nil {
+		h.logger.Errorf("获取配置历史版本失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "获取配置失败"))
+		return nil, false
+	}
+	if !ok {
+		middleware.AbortWithError(c, apperror.New(apperror.NotFound, "配置版本不存在: "+side.ConfigID))
+		return nil, false
+	}
+	return versioned, true
+}
+
+// processSamples 用同一个有界工作池以每个配置处理全部样本，结果按配置和输入顺序返回
+func (h *TestHandler) processSamples(configs []*models.Config, samples []string) [][]models.TestOutput {
+	outputs := make([][]models.TestOutput, len(configs))
+	for side := range configs {
+		outputs[side] = make([]models.TestOutput, len(samples))
+	}
+	total := len(configs) * len(samples)
+
+	workers := h.parallelism
+	if workers < 1 {
+		workers = defaultTestParallelism
+	}
+	if workers > total {
+		workers = total
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				side, i := job%len(configs), job/len(configs)
+				outputs[side][i] = h.process(configs[side], i, samples[i])
+			}
+		}()
+	}
+	for job := 0; job < total; job++ {
+		jobs <- job
+	}
+	close(jobs)
+	wg.Wait()
+
+	return outputs
+}
+
+// ProcessSamples 用配置处理全部样本，结果按输入顺序返回，供定期测试等后台任务运行测试数据集
+func (h *TestHandler) ProcessSamples(config *models.Config, samples []string) []models.TestOutput {
+	return h.processSamples([]*models.Config{config}, samples)[0]
+}
+
+// compareOutputs 比较一条样本在两个版本下的输出
+// 任一侧处理失败时不比较字段，两侧失败原因相同视为一致
+func compareOutputs(base, candidate models.TestOutput, ignore []string) models.EventComparison {
+	event := models.EventComparison{BaseError: base.Error, CandidateError: candidate.Error}
+	if base.Error == "" && candidate.Error == "" {
+		event.Diffs = diffFields(base.Output, candidate.Output, ignore)
+	}
+	event.Identical = base.Error == candidate.Error && len(event.Diffs) == 0
+	return event
+}
+
+// diffFields 按字段路径比较两个事件，嵌套对象展开为以.连接的路径，数组整体比较
+func diffFields(base, candidate map[string]interface{}, ignore []string) []models.FieldDiff {
+	baseFields := make(map[string]interface{})
+	candidateFields := make(map[string]interface{})
+	flattenFields("", base, baseFields)
+	flattenFields("", candidate, candidateFields)
+
+	paths := make([]string, 0, len(baseFields)+len(candidateFields))
+	for path := range baseFields {
+		paths = append(paths, path)
+	}
+	for path := range candidateFields {
+		if _, ok := baseFields[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	var diffs []models.FieldDiff
+	for _, path := range paths {
+		if ignoredField(path, ignore) {
+			continue
+		}
+		baseValue, inBase := baseFields[path]
+		candidateValue, inCandidate := candidateFields[path]
+		switch {
+		case !inBase:
+			diffs = append(diffs, models.FieldDiff{Field: path, Change: models.FieldAdded, Candidate: candidateValue})
+		case !inCandidate:
+			diffs = append(diffs, models.FieldDiff{Field: path, Change: models.FieldRemoved, Base: baseValue})
+		case !reflect.DeepEqual(baseValue, candidateValue):
+			diffs = append(diffs, models.FieldDiff{Field: path, Change: models.FieldChanged, Base: baseValue, Candidate: candidateValue})
+		}
+	}
+	return diffs
+}
+
+// flattenFields 把嵌套对象展开到out中，空对象作为叶子值保留
+func flattenFields(prefix string, event map[string]interface{}, out map[string]interface{}) {
+	for key, value := range event {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		if nested, ok := value.(map[string]interface{}); ok && len(nested) > 0 {
+			flattenFields(path, nested, out)
+			continue
+		}
+		out[path] = value
+	}
+}
+
+// ignoredField 字段本身或其所在的对象在忽略列表中
+func ignoredField(path string, ignore []string) bool {
+	for _, field := range ignore {
+		if path == field || strings.HasPrefix(path, field+".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+)
+
+// TestDatasetHandler 测试数据集处理器
+type TestDatasetHandler struct {
+	datasets service.TestDatasetService
+	logger   *logrus.Logger
+}
+
+// NewTestDatasetHandler 创建测试数据集处理器
+func NewTestDatasetHandler(datasets service.TestDatasetService, logger *logrus.Logger) *TestDatasetHandler {
+	return &TestDatasetHandler{
+		datasets: datasets,
+		logger:   logger,
+	}
+}
+
+// ListDatasets 获取配置上的测试数据集
+func (h *TestDatasetHandler) ListDatasets(c *gin.Context) {
+	datasets, err := h.datasets.ListDatasets(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleDatasetError(c, h.logger, err, "获取测试数据集列表失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items": datasets,
+		"total": len(datasets),
+	})
+}
+
+// CreateDataset 在配置上保存测试数据集
+func (h *TestDatasetHandler) CreateDataset(c *gin.Context) {
+	var req models.CreateTestDatasetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	dataset, err := h.datasets.CreateDataset(c.Request.Context(), c.Param("id"), &req, middleware.CurrentUserID(c))
+	if err != nil {
+		handleDatasetError(c, h.logger, err, "保存测试数据集失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, dataset)
+}
+
+// GetDataset 获取单个测试数据集
+func (h *TestDatasetHandler) GetDataset(c *gin.Context) {
+	dataset, err := h.datasets.GetDataset(c.Request.Context(), c.Param("id"), c.Param("dataset"))
+	if err != nil {
+		handleDatasetError(c, h.logger, err, "获取测试数据集失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, dataset)
+}
+
+// UpdateDataset 更新测试数据集
+func (h *TestDatasetHandler) UpdateDataset(c *gin.Context) {
+	var req models.UpdateTestDatasetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	dataset, err := h.datasets.UpdateDataset(c.Request.Context(), c.Param("id"), c.Param("dataset"), &req, middleware.CurrentUserID(c))
+	if err != nil {
+		handleDatasetError(c, h.logger, err, "更新测试数据集失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, dataset)
+}
+
+// DeleteDataset 删除测试数据集
+func (h *TestDatasetHandler) DeleteDataset(c *gin.Context) {
+	if err := h.datasets.DeleteDataset(c.Request.Context(), c.Param("id"), c.Param("dataset")); err != nil {
+		handleDatasetError(c, h.logger, err, "删除测试数据集失败")
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// RunDatasets 用配置的当前版本运行其上保存的全部测试数据集，逐个断言记录结果
+// 请求同步执行；任一断言失败时整体状态为failed，仍返回200
+func (h *TestHandler) RunDatasets(c *gin.Context) {
+	if h.datasets == nil {
+		middleware.AbortWithError(c, apperror.New(apperror.DatasetsUnavailable, "未启用测试数据集"))
+		return
+	}
+	ctx := c.Request.Context()
+	configID := c.Param("id")
+
+	config, err := h.configService.GetConfig(ctx, configID)
+	if err != nil {
+		if err.Error() == "文档不存在" {
+			middleware.AbortWithError(c, apperror.New(apperror.NotFound, "配置不存在"))
+			return
+		}
+		if abortIfForbidden(c, err) {
+			return
+		}
+		h.logger.Errorf("获取配置失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "获取配置失败"))
+		return
+	}
+
+	datasets, err := h.datasets.ListDatasets(ctx, configID)
+	if err != nil {
+		handleDatasetError(c, h.logger, err, "获取测试数据集列表失败")
+		return
+	}
+
+	result := service.RunDatasets(ctx, h.datasets, config, datasets, h.ProcessSamples, h.logger)
+
+	h.logger.WithFields(logrus.Fields{
+		"config_id": config.ID,
+		"version":   config.Version,
+		"datasets":  len(datasets),
+		"status":    result.Status,
+	}).Info("测试数据集运行完成")
+
+	c.JSON(http.StatusOK, result)
+}
+
+// handleDatasetError 把测试数据集服务的错误映射为HTTP响应
+func handleDatasetError(c *gin.Context, logger *logrus.Logger, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrConfigNotFound):
+		middleware.AbortWithError(c, apperror.New(apperror.NotFound, "配置不存在"))
+	case errors.Is(err, service.ErrConfigForbidden):
+		middleware.AbortWithError(c, apperror.New(apperror.Forbidden, "无权访问该配置"))
+	case errors.Is(err, service.ErrDatasetNotFound):
+		middleware.AbortWithError(c, apperror.New(apperror.NotFound, "测试数据集不存在"))
+	case errors.Is(err, service.ErrDatasetExists):
+		middleware.AbortWithError(c, apperror.New(apperror.AlreadyExists, err.Error()))
+	case errors.Is(err, service.ErrDatasetInvalid):
+		middleware.AbortWithError(c, apperror.New(apperror.Validation, err.Error()))
+	default:
+		logger.Errorf("%s: %v", message, err)
+		middleware.AbortWithError(c, apperror.Wrap(err, message))
+	}
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+)
+
+// maxTestRequestPart multipart创建测试时request部分的字节数上限
+const maxTestRequestPart = 1 << 20
+
+// SetFileService 设置测试文件服务，之后可以上传文件并以文件内容作为测试样本
+func (h *TestHandler) SetFileService(files *service.TestFileService) {
+	h.files = files
+}
+
+// UploadTestFile 上传测试文件，multipart表单的file字段为文件内容，支持gzip压缩的文件
+// 返回的文件ID可在有效期内作为 type=file 测试数据的 file_id 重复使用
+func (h *TestHandler) UploadTestFile(c *gin.Context) {
+	if h.files == nil {
+		middleware.AbortWithError(c, apperror.New(apperror.TestFilesUnavailable, "未启用测试文件"))
+		return
+	}
+	mr, err := c.Request.MultipartReader()
+	if err != nil {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "请求必须为 multipart/form-data"))
+		return
+	}
+
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			middleware.HandleBindError(c, err, "读取上传文件失败")
+			return
+		}
+		if part.FormName() != "file" {
+			continue
+		}
+		file, err := h.files.Upload(c.Request.Context(), part.FileName(), part)
+		if err != nil {
+			handleTestFileError(c, h.logger, err, "上传测试文件失败")
+			return
+		}
+		c.JSON(http.StatusCreated, file)
+		return
+	}
+	middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "缺少file字段"))
+}
+
+// GetTestFile 获取测试文件信息
+func (h *TestHandler) GetTestFile(c *gin.Context) {
+	if h.files == nil {
+		middleware.AbortWithError(c, apperror.New(apperror.TestFilesUnavailable, "未启用测试文件"))
+		return
+	}
+	file, err := h.files.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleTestFileError(c, h.logger, err, "获取测试文件失败")
+		return
+	}
+	c.JSON(http.StatusOK, file)
+}
+
+// DeleteTestFile 在有效期结束前删除测试文件
+func (h *TestHandler) DeleteTestFile(c *gin.Context) {
+	if h.files == nil {
+		middleware.AbortWithError(c, apperror.New(apperror.TestFilesUnavailable, "未启用测试文件"))
+		return
+	}
+	if err := h.files.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		handleTestFileError(c, h.logger, err, "删除测试文件失败")
+		return
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// bindTestRequest 绑定创建测试的请求
+// 请求体为JSON，或为multipart表单：request字段为JSON请求，file字段为随请求上传的测试文件，
+// 上传的文件保存后作为 type=file 测试数据的文件
+func (h *TestHandler) bindTestRequest(c *gin.Context, req *models.TestConfigRequest) bool {
+	if c.ContentType() != binding.MIMEMultipartPOSTForm {
+		if err := c.ShouldBindJSON(req); err != nil {
+			middleware.HandleBindError(c, err, "请求参数无效")
+			return false
+		}
+		return true
+	}
+	if h.files == nil {
+		middleware.AbortWithError(c, apperror.New(apperror.TestFilesUnavailable, "未启用测试文件"))
+		return false
+	}
+	mr, err := c.Request.MultipartReader()
+	if err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return false
+	}
+
+	var body []byte
+	var uploaded *models.TestFile
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			middleware.HandleBindError(c, err, "请求参数无效")
+			return false
+		}
+		switch part.FormName() {
+		case "request":
+			if body, err = io.ReadAll(io.LimitReader(part, maxTestRequestPart+1)); err != nil {
+				middleware.HandleBindError(c, err, "请求参数无效")
+				return false
+			}
+			if len(body) > maxTestRequestPart {
+				middleware.AbortWithError(c, apperror.New(apperror.PayloadTooLarge, fmt.Sprintf("request字段超过 %d 字节", maxTestRequestPart)))
+				return false
+			}
+		case "file":
+			if uploaded, err = h.files.Upload(c.Request.Context(), part.FileName(), part); err != nil {
+				handleTestFileError(c, h.logger, err, "上传测试文件失败")
+				return false
+			}
+		}
+	}
+
+	if body == nil {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "缺少request字段"))
+		return false
+	}
+	if err := binding.JSON.BindBody(body, req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return false
+	}
+	if uploaded != nil {
+		if req.TestData.Type != "file" {
+			middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "上传文件时测试数据类型必须为file"))
+			return false
+		}
+		if req.TestData.File == nil {
+			req.TestData.File = &models.TestFileSource{}
+		}
+		req.TestData.File.FileID = uploaded.ID
+	}
+	return true
+}
+
+// loadFileSamples 把 type=file 测试数据的文件内容读取为样本，最多读取样本条数上限行
+func (h *TestHandler) loadFileSamples(c *gin.Context, data *models.TestData) bool {
+	if data.Type != "file" {
+		return true
+	}
+	if h.files == nil {
+		middleware.AbortWithError(c, apperror.New(apperror.TestFilesUnavailable, "未启用测试文件"))
+		return false
+	}
+	if data.File == nil || data.File.FileID == "" {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "file类型的测试数据需要file.file_id或上传文件"))
+		return false
+	}
+
+	h.mu.RLock()
+	limits := h.limits
+	h.mu.RUnlock()
+	maxLines := limits.MaxSamples
+	if data.File.MaxLines > 0 && data.File.MaxLines < maxLines {
+		maxLines = data.File.MaxLines
+	}
+
+	samples, err := h.files.ReadSamples(c.Request.Context(), data.File.FileID, maxLines, limits.MaxSampleBytes)
+	if err != nil {
+		handleTestFileError(c, h.logger, err, "读取测试文件失败")
+		return false
+	}
+	if len(samples) == 0 {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "测试文件中没有样本"))
+		return false
+	}
+	data.Samples = samples
+	return true
+}
+
+// handleTestFileError 把测试文件服务的错误映射为HTTP响应
+func handleTestFileError(c *gin.Context, logger *logrus.Logger, err error, message string) {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		middleware.HandleBindError(c, err, message)
+	case errors.Is(err, service.ErrTestFileNotFound):
+		middleware.AbortWithError(c, apperror.New(apperror.TestFileNotFound, "测试文件不存在或已过期"))
+	case errors.Is(err, service.ErrTestFileTooLarge):
+		middleware.AbortWithError(c, apperror.New(apperror.TestFileTooLarge, err.Error()))
+	case errors.Is(err, service.ErrTestFileInvalid):
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, err.Error()))
+	default:
+		logger.Errorf("%s: %v", message, err)
+		middleware.AbortWithError(c, apperror.Wrap(err, message))
+	}
+}
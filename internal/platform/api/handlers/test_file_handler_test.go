@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+	"logstash-platform/pkg/filestore"
+)
+
+// multipartBody 构造multipart请求体，request不为nil时写入JSON的request字段，file不为空时写入file字段
+func multipartBody(t *testing.T, request interface{}, file string) (*bytes.Buffer, string) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	if request != nil {
+		data, err := json.Marshal(request)
+		require.NoError(t, err)
+		require.NoError(t, w.WriteField("request", string(data)))
+	}
+	if file != "" {
+		part, err := w.CreateFormFile("file", "access.log")
+		require.NoError(t, err)
+		part.Write([]byte(file))
+	}
+	require.NoError(t, w.Close())
+	return &buf, w.FormDataContentType()
+}
+
+func TestTestFileHandlers(t *testing.T) {
+	router, handler, mockService := setupTestHandlerRouter()
+	router.POST("/test", handler.CreateTest)
+	router.POST("/test/files", handler.UploadTestFile)
+	router.GET("/test/files/:id", handler.GetTestFile)
+	router.DELETE("/test/files/:id", handler.DeleteTestFile)
+	mockService.On("GetConfig", mock.Anything, "config-123").Return(&models.Config{ID: "config-123", Version: 1}, nil)
+	handler.process = func(config *models.Config, index int, sample string) models.TestOutput {
+		return models.TestOutput{Input: sample}
+	}
+
+	t.Run("未启用测试文件", func(t *testing.T) {
+		body, _ := json.Marshal(models.TestConfigRequest{
+			ConfigID: "config-123",
+			TestData: models.TestData{Type: "file", File: &models.TestFileSource{FileID: "abc"}},
+		})
+		req, _ := http.NewRequest("POST", "/test", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+
+	store, err := filestore.NewLocal(t.TempDir(), 64, time.Hour)
+	require.NoError(t, err)
+	handler.SetFileService(service.NewTestFileService(service.TestFileConfig{}, store, logrus.New()))
+
+	var fileID string
+	t.Run("上传文件后按ID引用", func(t *testing.T) {
+		body, contentType := multipartBody(t, nil, "line1\n\nline2\n")
+		req, _ := http.NewRequest("POST", "/test/files", body)
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		var file models.TestFile
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &file))
+		assert.Equal(t, "access.log", file.Name)
+		fileID = file.ID
+
+		reqBody, _ := json.Marshal(models.TestConfigRequest{
+			ConfigID: "config-123",
+			TestData: models.TestData{Type: "file", File: &models.TestFileSource{FileID: fileID, MaxLines: 1}},
+		})
+		req, _ = http.NewRequest("POST", "/test", bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusAccepted, w.Code)
+		assert.Contains(t, w.Body.String(), fileID)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Eventually(t, func() bool {
+			handler.mu.RLock()
+			defer handler.mu.RUnlock()
+			result := handler.testResults[response["test_id"].(string)]
+			return result != nil && result.Status == "completed" && len(result.Results) == 1
+		}, time.Second, 10*time.Millisecond, "max_lines限制读取的行数")
+	})
+
+	t.Run("multipart创建测试时上传文件", func(t *testing.T) {
+		body, contentType := multipartBody(t, models.TestConfigRequest{
+			ConfigID: "config-123",
+			TestData: models.TestData{Type: "file"},
+		}, "a\nb\n")
+		req, _ := http.NewRequest("POST", "/test", body)
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusAccepted, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.NotEmpty(t, response["file_id"])
+	})
+
+	t.Run("上传文件但测试数据类型不是file", func(t *testing.T) {
+		body, contentType := multipartBody(t, models.TestConfigRequest{
+			ConfigID: "config-123",
+			TestData: models.TestData{Type: "sample", Samples: []string{"x"}},
+		}, "a\n")
+		req, _ := http.NewRequest("POST", "/test", body)
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("超过文件大小上限", func(t *testing.T) {
+		body, contentType := multipartBody(t, nil, string(bytes.Repeat([]byte("x"), 65)))
+		req, _ := http.NewRequest("POST", "/test/files", body)
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		assert.Contains(t, w.Body.String(), "TEST_FILE_TOO_LARGE")
+	})
+
+	t.Run("删除后返回404", func(t *testing.T) {
+		req, _ := http.NewRequest("DELETE", "/test/files/"+fileID, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+
+		req, _ = http.NewRequest("GET", "/test/files/"+fileID, nil)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), "TEST_FILE_NOT_FOUND")
+	})
+}
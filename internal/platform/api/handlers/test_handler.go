@@ -11,34 +11,140 @@ import (
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
 	"logstash-platform/internal/platform/models"
 	"logstash-platform/internal/platform/service"
 )
 
+// defaultTestParallelism 样本测试默认并发数
+const defaultTestParallelism = 8
+
+// sampleProcessDelay 模拟单条样本的处理耗时
+var sampleProcessDelay = 100 * time.Millisecond
+
 // TestHandler 测试处理器
 type TestHandler struct {
 	configService service.ConfigService
+	contracts     service.ContractService // 未设置时不验证字段契约
+	datasets      service.TestDatasetService // 未设置时不能运行保存的测试数据集
+	files         *service.TestFileService   // 未设置时不能上传测试文件
+	esSource      *service.ESTestSource      // 未设置时不能从索引抽取测试样本
+	emitter       service.EventEmitter       // 未设置时不发布测试结束事件
 	logger        *logrus.Logger
+	parallelism   int // 样本测试的最大并发数
+	limits        TestLimits // 测试任务的并发、排队和样本限制
+	process       func(config *models.Config, index int, sample string) models.TestOutput // 单条样本的处理，默认为processSample
 	
 	// 临时存储测试结果
 	testResults map[string]*models.TestResult
 	mu          sync.RWMutex
+	draining    bool           // 平台正在关闭，不再创建新测试
+	running     sync.WaitGroup // 后台执行中和排队的测试
+	queue       []testJob      // 等待执行名额的测试，按提交顺序
+	active      int            // 正在执行的测试任务数
 }
 
 // NewTestHandler 创建测试处理器
 func NewTestHandler(configService service.ConfigService, logger *logrus.Logger) *TestHandler {
-	return &TestHandler{
+	h := &TestHandler{
 		configService: configService,
 		logger:        logger,
+		parallelism:   defaultTestParallelism,
+		limits:        TestLimits{}.withDefaults(),
 		testResults:   make(map[string]*models.TestResult),
 	}
+	h.process = h.processSample
+	return h
+}
+
+// SetParallelism 设置样本测试的最大并发数，小于1时使用默认值
+func (h *TestHandler) SetParallelism(n int) {
+	if n < 1 {
+		n = defaultTestParallelism
+	}
+	h.parallelism = n
+}
+
+// SetContractService 设置字段契约服务，样本测试完成后用输出事件验证下游消费方登记的契约
+func (h *TestHandler) SetContractService(contracts service.ContractService) {
+	h.contracts = contracts
+}
+
+// SetDatasetService 设置测试数据集服务，用于运行配置上保存的回归测试
+func (h *TestHandler) SetDatasetService(datasets service.TestDatasetService) {
+	h.datasets = datasets
+}
+
+// SetEventEmitter 设置平台事件的发布方，之后测试结束时发布 test.completed
+func (h *TestHandler) SetEventEmitter(emitter service.EventEmitter) {
+	h.emitter = emitter
+}
+
+// emitCompleted 发布测试结束事件，事件内容为测试结果的摘要，不含逐条样本的输出
+func (h *TestHandler) emitCompleted(ctx context.Context, testID, configID string) {
+	if h.emitter == nil {
+		return
+	}
+	h.mu.RLock()
+	result, exists := h.testResults[testID]
+	var summary models.TestResult
+	if exists {
+		summary = *result
+		summary.Results = nil
+		summary.Errors = append([]string(nil), result.Errors...)
+	}
+	h.mu.RUnlock()
+	if !exists || summary.Status == "running" {
+		return
+	}
+	h.emitter.Emit(ctx, models.WebhookEventTestCompleted, map[string]interface{}{
+		"config_id": configID,
+		"result":    &summary,
+	})
+}
+
+// WorkerStatus 报告正在执行和排队的测试任务数、任务并发上限和样本处理并发上限
+func (h *TestHandler) WorkerStatus(now time.Time) models.WorkerStatus {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	running := 0
+	for _, result := range h.testResults {
+		if result.Status == "running" {
+			running++
+		}
+	}
+	return models.WorkerStatus{
+		Name:    "test_engine",
+		Kind:    models.WorkerKindPool,
+		Running: true,
+		Gauges: map[string]float64{
+			"running_tests":        float64(running),
+			"queued_tests":         float64(len(h.queue)),
+			"max_concurrent_tests": float64(h.limits.MaxConcurrentJobs),
+			"parallelism":          float64(h.parallelism),
+		},
+	}
 }
 
 // CreateTest 创建测试任务
 func (h *TestHandler) CreateTest(c *gin.Context) {
 	var req models.TestConfigRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		middleware.HandleError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+	if !h.bindTestRequest(c, &req) || !h.loadSamples(c, &req.TestData) {
+		return
+	}
+	assertions, err := service.NewAssertionSet(req.TestData.Assertions, len(req.TestData.Samples))
+	if err != nil {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, err.Error()))
+		return
+	}
+	masker, err := service.NewTestMasker(req.TestData.Masking)
+	if err != nil {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, err.Error()))
+		return
+	}
+	if err := h.checkSampleLimits(&req.TestData); err != nil {
+		middleware.AbortWithError(c, err)
 		return
 	}
 
@@ -54,39 +160,107 @@ func (h *TestHandler) CreateTest(c *gin.Context) {
 		Results:     []models.TestOutput{},
 		Errors:      []string{},
 		StartTime:   time.Now(),
+		Project:     models.ProjectFrom(c.Request.Context()),
 	}
 
-	// TODO: 将测试任务保存到存储中
-	h.storeTestResult(testID, testResult)
+	// 异步执行测试，保留请求身份和项目以检查配置级访问控制
+	ctx := models.WithPrincipal(context.Background(), models.PrincipalFrom(c.Request.Context()))
+	ctx = models.WithProject(ctx, testResult.Project)
+	job := testJob{id: testID, ctx: ctx, run: func(ctx context.Context) {
+		h.executeTest(ctx, testID, &req, assertions, masker)
+		h.emitCompleted(ctx, testID, req.ConfigID)
+	}}
 
-	// 异步执行测试
-	go h.executeTest(testID, &req)
+	// TODO: 将测试任务保存到存储中
+	h.mu.Lock()
+	if h.draining {
+		h.mu.Unlock()
+		middleware.AbortWithError(c, service.ErrShuttingDown)
+		return
+	}
+	h.testResults[testID] = testResult
+	h.running.Add(1)
+	if !h.enqueueLocked(job) {
+		delete(h.testResults, testID)
+		h.running.Done()
+		h.mu.Unlock()
+		middleware.AbortWithError(c, apperror.New(apperror.TestQueueFull, fmt.Sprintf("排队的测试已达上限 %d，请稍后重试", h.limits.MaxQueuedJobs)))
+		return
+	}
+	status, position := testResult.Status, h.queuePositionLocked(testID)
+	h.mu.Unlock()
 
-	c.JSON(http.StatusAccepted, gin.H{
+	resp := gin.H{
 		"test_id": testID,
-		"status":  "running",
+		"status":  status,
 		"message": "测试任务已创建",
-	})
+	}
+	if position > 0 {
+		resp["queue_position"] = position
+	}
+	if req.TestData.File != nil {
+		resp["file_id"] = req.TestData.File.FileID
+	}
+	c.JSON(http.StatusAccepted, resp)
+}
+
+// Drain 停止创建新测试，取消排队的测试并等待进行中的测试结束
+// 测试结果只保存在内存中，ctx到期时仍在运行的测试标记为失败，配置的测试状态保持不变
+func (h *TestHandler) Drain(ctx context.Context) error {
+	h.mu.Lock()
+	h.draining = true
+	h.cancelQueuedLocked(time.Now())
+	h.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		h.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	interrupted := 0
+	now := time.Now()
+	for _, result := range h.testResults {
+		if result.Status == "running" {
+			result.Status = "failed"
+			result.Errors = append(result.Errors, "平台关闭，测试未完成")
+			result.EndTime = &now
+			interrupted++
+		}
+	}
+	return fmt.Errorf("%d个测试未在关闭前结束", interrupted)
 }
 
 // GetTestResult 获取测试结果
 func (h *TestHandler) GetTestResult(c *gin.Context) {
 	testID := c.Param("id")
 	if testID == "" {
-		middleware.HandleError(c, http.StatusBadRequest, "INVALID_REQUEST", "测试ID不能为空")
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "测试ID不能为空"))
 		return
 	}
 
 	h.mu.RLock()
 	result, exists := h.testResults[testID]
+	var snapshot models.TestResult
+	if exists {
+		snapshot = *result
+		snapshot.QueuePosition = h.queuePositionLocked(testID)
+	}
 	h.mu.RUnlock()
 
-	if !exists {
-		middleware.HandleError(c, http.StatusNotFound, "TEST_NOT_FOUND", "测试任务不存在")
+	if !exists || !models.InProject(c.Request.Context(), result.Project) {
+		middleware.AbortWithError(c, apperror.New(apperror.TestNotFound, "测试任务不存在"))
 		return
 	}
 
-	c.JSON(http.StatusOK, result)
+	c.JSON(http.StatusOK, &snapshot)
 }
 
 // 辅助方法
@@ -113,11 +287,11 @@ func (h *TestHandler) updateTestResult(testID string, update func(*models.TestRe
 }
 
 // executeTest 执行测试
-func (h *TestHandler) executeTest(testID string, req *models.TestConfigRequest) {
+func (h *TestHandler) executeTest(ctx context.Context, testID string, req *models.TestConfigRequest, assertions *service.AssertionSet, masker *service.TestMasker) {
 	h.logger.WithField("test_id", testID).Info("开始执行配置测试")
 
 	// 获取配置
-	config, err := h.configService.GetConfig(context.Background(), req.ConfigID)
+	config, err := h.configService.GetConfig(ctx, req.ConfigID)
 	if err != nil {
 		h.updateTestResult(testID, func(result *models.TestResult) {
 			result.Status = "failed"
@@ -130,8 +304,8 @@ func (h *TestHandler) executeTest(testID string, req *models.TestConfigRequest)
 
 	// 根据测试数据类型执行测试
 	switch req.TestData.Type {
-	case "sample":
-		h.executeSampleTest(testID, config, req.TestData.Samples)
+	case "sample", "file", "elasticsearch", "replay":
+		h.executeSampleTest(ctx, testID, config, req.TestData.Samples, assertions, masker, req.Targets)
 	case "kafka":
 		h.executeKafkaTest(testID, config, &req.TestData.KafkaConfig)
 	default:
@@ -145,7 +319,9 @@ func (h *TestHandler) executeTest(testID string, req *models.TestConfigRequest)
 }
 
 // executeSampleTest 执行样本数据测试
-func (h *TestHandler) executeSampleTest(testID string, config *models.Config, samples []string) {
+// 样本通过有界工作池并行处理并检查断言，结果按输入顺序写回；全部完成后验证写入目标上登记的字段契约，
+// 并把测试结论记录到配置的测试状态上：只有全部样本处理成功、断言全部通过且没有违反契约时才为passed
+func (h *TestHandler) executeSampleTest(ctx context.Context, testID string, config *models.Config, samples []string, assertions *service.AssertionSet, masker *service.TestMasker, targets []string) {
 	h.logger.WithField("test_id", testID).Info("执行样本数据测试")
 
 	// 更新输入计数
@@ -153,35 +329,190 @@ func (h *TestHandler) executeSampleTest(testID string, config *models.Config, sa
 		result.InputCount = len(samples)
 	})
 
-	// TODO: 实际的Logstash测试逻辑
-	// 这里简化处理，假设所有样本都成功处理
-	for i, sample := range samples {
-		output := models.TestOutput{
-			Input: sample,
-			Output: map[string]interface{}{
-				"message": sample,
-				"@timestamp": time.Now().Format(time.RFC3339),
-				"test_field": fmt.Sprintf("processed_%d", i),
-			},
+	workers := h.parallelism
+	if workers < 1 {
+		workers = defaultTestParallelism
+	}
+	if workers > len(samples) {
+		workers = len(samples)
+	}
+
+	started := time.Now()
+	outputs := make([]models.TestOutput, len(samples))
+	stored := make([]models.TestOutput, len(samples)) // 脱敏后保存到测试结果的输出
+	indexes := make(chan int)
+	done := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				// 每个下标只由一个worker写入，完成后经done通知汇总方
+				output := h.process(config, i, samples[i])
+				if !assertions.Empty() {
+					passed, outcomes := assertions.Check(i, output)
+					output.Passed = &passed
+					output.Assertions = outcomes
+				}
+				outputs[i] = output
+				stored[i] = masker.Mask(output)
+				done <- i
+			}
+		}()
+	}
+
+	// 任务超时后不再分发剩余样本，已在处理的样本完成后结束
+	go func() {
+	feed:
+		for i := range samples {
+			select {
+			case indexes <- i:
+			case <-ctx.Done():
+				break feed
+			}
 		}
+		close(indexes)
+		wg.Wait()
+		close(done)
+	}()
 
+	// 每完成一条样本更新一次进度；结果只追加已连续完成的前缀，保持与输入顺序一致
+	finished := make([]bool, len(samples))
+	next, processed := 0, 0
+	for i := range done {
+		finished[i] = true
+		processed++
+		ready := next
+		for next < len(samples) && finished[next] {
+			next++
+		}
+		elapsed := time.Since(started)
 		h.updateTestResult(testID, func(result *models.TestResult) {
-			result.Results = append(result.Results, output)
-			result.OutputCount++
+			result.Results = append(result.Results, stored[ready:next]...)
+			if outputs[i].Error == "" {
+				result.OutputCount++
+			}
+			if outputs[i].Passed != nil {
+				if *outputs[i].Passed {
+					result.PassedSamples++
+				} else {
+					result.FailedSamples++
+				}
+			}
+			result.Parallelism = workers
+			result.DurationMs = elapsed.Milliseconds()
+			if elapsed > 0 {
+				result.Throughput = float64(result.OutputCount) / elapsed.Seconds()
+			}
 		})
+	}
 
-		// 模拟处理延迟
-		time.Sleep(100 * time.Millisecond)
+	elapsed := time.Since(started)
+
+	// 超时的测试没有完整结论，配置的测试状态保持不变
+	if processed < len(samples) {
+		h.updateTestResult(testID, func(result *models.TestResult) {
+			result.Status = "failed"
+			result.Errors = append(result.Errors, fmt.Sprintf("测试执行超时，已处理 %d/%d 条样本", processed, len(samples)))
+			endTime := time.Now()
+			result.EndTime = &endTime
+		})
+		h.logger.WithFields(logrus.Fields{
+			"test_id":   testID,
+			"samples":   len(samples),
+			"processed": processed,
+			"duration":  elapsed,
+		}).Warn("样本数据测试超时")
+		return
 	}
 
-	// 标记测试完成
+	report, contractErr := h.verifyContracts(ctx, config, outputs, targets)
+
+	// 标记测试完成，有样本未通过断言或违反字段契约时测试失败
+	status := models.TestStatusPassed
 	h.updateTestResult(testID, func(result *models.TestResult) {
+		result.Parallelism = workers
+		result.DurationMs = elapsed.Milliseconds()
+		if elapsed > 0 {
+			result.Throughput = float64(len(samples)) / elapsed.Seconds()
+		}
 		result.Status = "completed"
+		result.Contracts = report
+		if contractErr != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("验证字段契约失败: %v", contractErr))
+		}
+		if report != nil {
+			for _, v := range report.Violations {
+				result.Errors = append(result.Errors, contractViolationMessage(v))
+			}
+			if len(report.Violations) > 0 {
+				result.Status = "failed"
+			}
+		}
+		if result.FailedSamples > 0 {
+			result.Status = "failed"
+			result.Errors = append(result.Errors, fmt.Sprintf("%d条样本未通过断言", result.FailedSamples))
+		}
+		if result.Status != "completed" || result.OutputCount < len(samples) || contractErr != nil {
+			status = models.TestStatusFailed
+		}
 		endTime := time.Now()
 		result.EndTime = &endTime
 	})
 
-	h.logger.WithField("test_id", testID).Info("样本数据测试完成")
+	if err := h.configService.RecordTestResult(ctx, config.ID, config.Version, status); err != nil {
+		h.logger.WithError(err).WithField("config_id", config.ID).Warn("记录配置测试状态失败")
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"test_id":  testID,
+		"samples":  len(samples),
+		"workers":  workers,
+		"duration": elapsed,
+	}).Info("样本数据测试完成")
+}
+
+// verifyContracts 用处理成功的输出事件验证字段契约，未设置契约服务时返回nil
+func (h *TestHandler) verifyContracts(ctx context.Context, config *models.Config, outputs []models.TestOutput, targets []string) (*models.ContractReport, error) {
+	if h.contracts == nil {
+		return nil, nil
+	}
+	events := make([]map[string]interface{}, 0, len(outputs))
+	for _, output := range outputs {
+		if output.Error == "" && output.Output != nil {
+			events = append(events, output.Output)
+		}
+	}
+	return h.contracts.Verify(ctx, config, targets, events)
+}
+
+// contractViolationMessage 契约违反的错误描述
+func contractViolationMessage(v models.ContractViolation) string {
+	if v.Reason == models.ContractViolationMissing {
+		return fmt.Sprintf("违反字段契约 %s（%s）: %d条输出缺少字段 %s", v.Contract, v.Consumer, v.Samples, v.Field)
+	}
+	return fmt.Sprintf("违反字段契约 %s（%s）: %d条输出的字段 %s 类型为 %s，契约要求 %s", v.Contract, v.Consumer, v.Samples, v.Field, v.Actual, v.Expected)
+}
+
+// processSample 用配置处理单条样本
+func (h *TestHandler) processSample(config *models.Config, index int, sample string) models.TestOutput {
+	// TODO: 实际的Logstash测试逻辑
+	// 这里简化处理，假设所有样本都成功处理
+	output := models.TestOutput{
+		Input: sample,
+		Output: map[string]interface{}{
+			"message":    sample,
+			"@timestamp": time.Now().Format(time.RFC3339),
+			"test_field": fmt.Sprintf("processed_%d", index),
+		},
+	}
+
+	// 模拟处理延迟
+	time.Sleep(sampleProcessDelay)
+
+	return output
 }
 
 // executeKafkaTest 执行Kafka数据测试
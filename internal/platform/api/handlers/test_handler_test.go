@@ -2,8 +2,10 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -14,7 +16,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/api/middleware"
 	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
 )
 
 func setupTestHandlerRouter() (*gin.Engine, *TestHandler, *MockConfigService) {
@@ -24,6 +28,7 @@ func setupTestHandlerRouter() (*gin.Engine, *TestHandler, *MockConfigService) {
 	logger.SetLevel(logrus.WarnLevel)
 	
 	mockService := new(MockConfigService)
+	mockService.On("RecordTestResult", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
 	handler := NewTestHandler(mockService, logger)
 	
 	router := gin.New()
@@ -175,12 +180,15 @@ func TestCreateTest(t *testing.T) {
 		// 验证响应 - 应该是验证错误
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 		
-		var response map[string]interface{}
+		var response middleware.ErrorResponse
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		require.NoError(t, err)
 		
-		assert.Equal(t, "INVALID_REQUEST", response["code"])
-		assert.Contains(t, response["message"], "oneof")
+		assert.Equal(t, "INVALID_REQUEST", response.Code)
+		require.Len(t, response.Errors, 1)
+		assert.Equal(t, "test_data.type", response.Errors[0].Field)
+		assert.Equal(t, "oneof", response.Errors[0].Rule)
+		assert.Equal(t, "type必须是[sample kafka file elasticsearch replay]中的一个", response.Errors[0].Message)
 	})
 }
 
@@ -299,7 +307,7 @@ func TestExecuteSampleTest(t *testing.T) {
 		handler.storeTestResult(testID, testResult)
 
 		// 执行测试
-		handler.executeSampleTest(testID, config, samples)
+		handler.executeSampleTest(context.Background(), testID, config, samples, nil, nil, nil)
 
 		// 验证结果
 		handler.mu.RLock()
@@ -340,7 +348,7 @@ func TestExecuteSampleTest(t *testing.T) {
 		handler.storeTestResult(testID, testResult)
 
 		// 执行测试
-		handler.executeSampleTest(testID, config, samples)
+		handler.executeSampleTest(context.Background(), testID, config, samples, nil, nil, nil)
 
 		// 验证结果
 		handler.mu.RLock()
@@ -355,6 +363,395 @@ func TestExecuteSampleTest(t *testing.T) {
 	})
 }
 
+func TestExecuteSampleTest_ParallelOrdered(t *testing.T) {
+	_, handler, _ := setupTestHandlerRouter()
+	handler.SetParallelism(4)
+
+	testID := "parallel-sample-test"
+	config := &models.Config{ID: "config-123", Content: "filter {}"}
+	samples := make([]string, 12)
+	for i := range samples {
+		samples[i] = fmt.Sprintf("line %d", i)
+	}
+
+	handler.storeTestResult(testID, &models.TestResult{
+		TestID:    testID,
+		Status:    "running",
+		StartTime: time.Now(),
+		Results:   []models.TestOutput{},
+		Errors:    []string{},
+	})
+
+	started := time.Now()
+	handler.executeSampleTest(context.Background(), testID, config, samples, nil, nil, nil)
+	elapsed := time.Since(started)
+
+	handler.mu.RLock()
+	result := handler.testResults[testID]
+	handler.mu.RUnlock()
+
+	// 12条样本、4个并发，耗时应明显少于串行的12倍单条延迟
+	assert.Less(t, elapsed, time.Duration(len(samples))*sampleProcessDelay/2)
+
+	assert.Equal(t, "completed", result.Status)
+	assert.Equal(t, 12, result.OutputCount)
+	assert.Equal(t, 4, result.Parallelism)
+	assert.Greater(t, result.Throughput, 0.0)
+	require.Len(t, result.Results, 12)
+	for i, output := range result.Results {
+		assert.Equal(t, samples[i], output.Input)
+		assert.Equal(t, fmt.Sprintf("processed_%d", i), output.Output["test_field"])
+	}
+}
+
+func TestExecuteSampleTest_ReportsProgress(t *testing.T) {
+	_, handler, _ := setupTestHandlerRouter()
+	handler.SetParallelism(2)
+
+	testID := "progress-sample-test"
+	samples := []string{"a", "b", "c", "d", "e", "f"}
+	handler.storeTestResult(testID, &models.TestResult{
+		TestID:    testID,
+		Status:    "running",
+		StartTime: time.Now(),
+		Results:   []models.TestOutput{},
+		Errors:    []string{},
+	})
+
+	done := make(chan struct{})
+	go func() {
+		handler.executeSampleTest(context.Background(), testID, &models.Config{ID: "config-123"}, samples, nil, nil, nil)
+		close(done)
+	}()
+
+	// 测试结束前即可看到部分结果，且已有结果保持输入顺序
+	assert.Eventually(t, func() bool {
+		handler.mu.RLock()
+		defer handler.mu.RUnlock()
+		result := handler.testResults[testID]
+		if result.Status != "running" || result.OutputCount == 0 {
+			return false
+		}
+		for i, output := range result.Results {
+			if output.Input != samples[i] {
+				return false
+			}
+		}
+		return true
+	}, 2*time.Second, 5*time.Millisecond)
+	<-done
+}
+
+// stubContractService 返回预设契约验证结果的字段契约服务
+type stubContractService struct {
+	service.ContractService
+	report  *models.ContractReport
+	targets []string
+	events  int
+}
+
+func (s *stubContractService) Verify(ctx context.Context, config *models.Config, targets []string, outputs []map[string]interface{}) (*models.ContractReport, error) {
+	s.targets = targets
+	s.events = len(outputs)
+	return s.report, nil
+}
+
+func TestExecuteSampleTest_ContractViolation(t *testing.T) {
+	_, handler, _ := setupTestHandlerRouter()
+	contracts := &stubContractService{report: &models.ContractReport{
+		Targets:   []string{"logs-nginx"},
+		Contracts: []string{"nginx-dashboard"},
+		Violations: []models.ContractViolation{{
+			Contract: "nginx-dashboard", Consumer: "Nginx仪表盘", Field: "status",
+			Reason: models.ContractViolationMissing, Samples: 2,
+		}},
+	}}
+	handler.SetContractService(contracts)
+
+	testID := "contract-sample-test"
+	handler.storeTestResult(testID, &models.TestResult{
+		TestID:    testID,
+		Status:    "running",
+		StartTime: time.Now(),
+		Results:   []models.TestOutput{},
+		Errors:    []string{},
+	})
+
+	handler.executeSampleTest(context.Background(), testID, &models.Config{ID: "config-123"}, []string{"a", "b"}, nil, nil, []string{"logs-nginx"})
+
+	handler.mu.RLock()
+	result := handler.testResults[testID]
+	handler.mu.RUnlock()
+
+	assert.Equal(t, "failed", result.Status)
+	assert.Equal(t, []string{"logs-nginx"}, contracts.targets)
+	assert.Equal(t, 2, contracts.events)
+	assert.Same(t, contracts.report, result.Contracts)
+	require.Len(t, result.Errors, 1)
+	assert.Contains(t, result.Errors[0], "nginx-dashboard")
+	assert.Contains(t, result.Errors[0], "status")
+}
+
+func TestExecuteSampleTest_Assertions(t *testing.T) {
+	_, handler, mockService := setupTestHandlerRouter()
+	handler.process = func(config *models.Config, index int, sample string) models.TestOutput {
+		return models.TestOutput{Input: sample, Output: map[string]interface{}{"message": sample, "tags": []interface{}{"nginx"}}}
+	}
+	config := &models.Config{ID: "config-123", Version: 3}
+
+	run := func(testID string, assertions []models.TestAssertion, samples []string) *models.TestResult {
+		set, err := service.NewAssertionSet(assertions, len(samples))
+		require.NoError(t, err)
+		handler.storeTestResult(testID, &models.TestResult{TestID: testID, Status: "running", Results: []models.TestOutput{}, Errors: []string{}})
+		handler.executeSampleTest(context.Background(), testID, config, samples, set, nil, nil)
+		handler.mu.RLock()
+		defer handler.mu.RUnlock()
+		return handler.testResults[testID]
+	}
+
+	result := run("assert-pass", []models.TestAssertion{{Type: models.AssertTag, Tag: "nginx"}}, []string{"a", "b"})
+	assert.Equal(t, "completed", result.Status)
+	assert.Equal(t, 2, result.PassedSamples)
+	require.NotNil(t, result.Results[0].Passed)
+	assert.True(t, *result.Results[0].Passed)
+	mockService.AssertCalled(t, "RecordTestResult", mock.Anything, "config-123", 3, models.TestStatusPassed)
+
+	result = run("assert-fail", []models.TestAssertion{{Type: models.AssertRegexp, Field: "message", Pattern: "^a$"}}, []string{"a", "b"})
+	assert.Equal(t, "failed", result.Status)
+	assert.Equal(t, 1, result.PassedSamples)
+	assert.Equal(t, 1, result.FailedSamples)
+	assert.False(t, *result.Results[1].Passed)
+	require.Len(t, result.Results[1].Assertions, 1)
+	assert.Equal(t, "b", result.Results[1].Assertions[0].Actual)
+	assert.Contains(t, result.Errors, "1条样本未通过断言")
+	mockService.AssertCalled(t, "RecordTestResult", mock.Anything, "config-123", 3, models.TestStatusFailed)
+}
+
+func TestCreateTest_InvalidAssertion(t *testing.T) {
+	router, handler, _ := setupTestHandlerRouter()
+	router.POST("/test", handler.CreateTest)
+
+	body, _ := json.Marshal(models.TestConfigRequest{
+		ConfigID: "test-config-123",
+		TestData: models.TestData{
+			Type:       "sample",
+			Samples:    []string{"line"},
+			Assertions: []models.TestAssertion{{Type: models.AssertRegexp, Field: "message", Pattern: "("}},
+		},
+	})
+	req, _ := http.NewRequest("POST", "/test", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "正则表达式无效")
+}
+
+func TestTestHandler_Drain(t *testing.T) {
+	router, handler, mockService := setupTestHandlerRouter()
+	router.POST("/test", handler.CreateTest)
+	mockService.On("GetConfig", mock.Anything, "config-123").Return(&models.Config{ID: "config-123", Version: 1}, nil)
+	release := make(chan struct{})
+	handler.process = func(config *models.Config, index int, sample string) models.TestOutput {
+		<-release
+		return models.TestOutput{Input: sample}
+	}
+
+	create := func() *httptest.ResponseRecorder {
+		body, _ := json.Marshal(models.TestConfigRequest{
+			ConfigID: "config-123",
+			TestData: models.TestData{Type: "sample", Samples: []string{"line"}},
+		})
+		req, _ := http.NewRequest("POST", "/test", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	w := create()
+	require.Equal(t, http.StatusAccepted, w.Code)
+	var created map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	testID := created["test_id"].(string)
+
+	// 超时仍在运行的测试标记为失败，之后不再接受新测试
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Error(t, handler.Drain(ctx))
+	handler.mu.RLock()
+	assert.Equal(t, "failed", handler.testResults[testID].Status)
+	assert.Contains(t, handler.testResults[testID].Errors, "平台关闭，测试未完成")
+	handler.mu.RUnlock()
+
+	w = create()
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), "SHUTTING_DOWN")
+
+	close(release)
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, handler.Drain(ctx))
+}
+
+func TestTestHandler_Queue(t *testing.T) {
+	router, handler, mockService := setupTestHandlerRouter()
+	router.POST("/test", handler.CreateTest)
+	router.GET("/test/:id", handler.GetTestResult)
+	handler.SetLimits(TestLimits{MaxConcurrentJobs: 1, MaxQueuedJobs: 1})
+	mockService.On("GetConfig", mock.Anything, "config-123").Return(&models.Config{ID: "config-123", Version: 1}, nil)
+	release := make(chan struct{})
+	handler.process = func(config *models.Config, index int, sample string) models.TestOutput {
+		<-release
+		return models.TestOutput{Input: sample}
+	}
+
+	create := func() (*httptest.ResponseRecorder, map[string]interface{}) {
+		body, _ := json.Marshal(models.TestConfigRequest{
+			ConfigID: "config-123",
+			TestData: models.TestData{Type: "sample", Samples: []string{"line"}},
+		})
+		req, _ := http.NewRequest("POST", "/test", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w, resp
+	}
+	result := func(testID string) models.TestResult {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/test/"+testID, nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		var result models.TestResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		return result
+	}
+
+	w, first := create()
+	require.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "running", first["status"])
+
+	// 名额已占满时排队并报告位置
+	w, second := create()
+	require.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "queued", second["status"])
+	assert.Equal(t, float64(1), second["queue_position"])
+	queued := result(second["test_id"].(string))
+	assert.Equal(t, "queued", queued.Status)
+	assert.Equal(t, 1, queued.QueuePosition)
+
+	// 队列已满时拒绝
+	w, _ = create()
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Contains(t, w.Body.String(), "TEST_QUEUE_FULL")
+	assert.Equal(t, float64(1), handler.WorkerStatus(time.Now()).Gauges["queued_tests"])
+
+	// 前一个任务结束后队首的任务开始执行
+	close(release)
+	require.Eventually(t, func() bool {
+		return result(second["test_id"].(string)).Status == "completed"
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "completed", result(first["test_id"].(string)).Status)
+	assert.Zero(t, result(second["test_id"].(string)).QueuePosition)
+}
+
+func TestTestHandler_DrainCancelsQueued(t *testing.T) {
+	router, handler, mockService := setupTestHandlerRouter()
+	router.POST("/test", handler.CreateTest)
+	handler.SetLimits(TestLimits{MaxConcurrentJobs: 1})
+	mockService.On("GetConfig", mock.Anything, "config-123").Return(&models.Config{ID: "config-123", Version: 1}, nil)
+	release := make(chan struct{})
+	handler.process = func(config *models.Config, index int, sample string) models.TestOutput {
+		<-release
+		return models.TestOutput{Input: sample}
+	}
+
+	var ids []string
+	for i := 0; i < 2; i++ {
+		body, _ := json.Marshal(models.TestConfigRequest{
+			ConfigID: "config-123",
+			TestData: models.TestData{Type: "sample", Samples: []string{"line"}},
+		})
+		req, _ := http.NewRequest("POST", "/test", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusAccepted, w.Code)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		ids = append(ids, resp["test_id"].(string))
+	}
+
+	// 排队的测试不再执行，关闭只等待正在执行的测试
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, handler.Drain(ctx))
+
+	handler.mu.RLock()
+	defer handler.mu.RUnlock()
+	assert.Equal(t, "completed", handler.testResults[ids[0]].Status)
+	assert.Equal(t, "failed", handler.testResults[ids[1]].Status)
+	assert.Contains(t, handler.testResults[ids[1]].Errors, "平台关闭，测试未开始执行")
+}
+
+func TestCreateTest_SampleLimits(t *testing.T) {
+	router, handler, mockService := setupTestHandlerRouter()
+	router.POST("/test", handler.CreateTest)
+	handler.SetLimits(TestLimits{MaxSamples: 2, MaxSampleBytes: 8})
+
+	create := func(samples ...string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(models.TestConfigRequest{
+			ConfigID: "config-123",
+			TestData: models.TestData{Type: "sample", Samples: samples},
+		})
+		req, _ := http.NewRequest("POST", "/test", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := create("a", "b", "c")
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Contains(t, w.Body.String(), "样本数 3 超过上限 2")
+
+	w = create("12345", "67890")
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Contains(t, w.Body.String(), "TEST_TOO_LARGE")
+	mockService.AssertNotCalled(t, "GetConfig", mock.Anything, mock.Anything)
+}
+
+func TestExecuteSampleTest_Timeout(t *testing.T) {
+	_, handler, mockService := setupTestHandlerRouter()
+	handler.SetParallelism(1)
+	handler.process = func(config *models.Config, index int, sample string) models.TestOutput {
+		time.Sleep(20 * time.Millisecond)
+		return models.TestOutput{Input: sample}
+	}
+
+	testID := "timeout-test"
+	handler.storeTestResult(testID, &models.TestResult{TestID: testID, Status: "running"})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	samples := make([]string, 20)
+	handler.executeSampleTest(ctx, testID, &models.Config{ID: "config-123", Version: 1}, samples, nil, nil, nil)
+
+	handler.mu.RLock()
+	result := handler.testResults[testID]
+	handler.mu.RUnlock()
+	assert.Equal(t, "failed", result.Status)
+	require.Len(t, result.Errors, 1)
+	assert.Contains(t, result.Errors[0], "测试执行超时")
+	assert.Less(t, len(result.Results), len(samples))
+	assert.NotNil(t, result.EndTime)
+	mockService.AssertNotCalled(t, "RecordTestResult", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
 func TestExecuteKafkaTest(t *testing.T) {
 	_, handler, _ := setupTestHandlerRouter()
 
@@ -560,4 +957,173 @@ func TestUpdateNonExistentTestResult(t *testing.T) {
 	handler.mu.RUnlock()
 
 	assert.False(t, exists)
-}
\ No newline at end of file
+}
+func TestCompareTest(t *testing.T) {
+	router, handler, mockService := setupTestHandlerRouter()
+	router.POST("/tests/compare", handler.CompareTest)
+
+	mockService.On("GetConfig", mock.Anything, "config-1").
+		Return(&models.Config{ID: "config-1", Content: "v2", Version: 2}, nil)
+	mockService.On("GetConfigHistory", mock.Anything, "config-1").
+		Return([]*models.ConfigHistory{
+			{ConfigID: "config-1", Version: 2, Content: "v2", ChangeType: "update"},
+			{ConfigID: "config-1", Version: 1, Content: "v1", ChangeType: "create"},
+		}, nil)
+	mockService.On("GetConfig", mock.Anything, "missing").Return(nil, errors.New("文档不存在"))
+
+	// v2相比v1：解析出的level改名为log.level，新增geo.country，固定样本解析失败
+	handler.process = func(config *models.Config, index int, sample string) models.TestOutput {
+		output := models.TestOutput{Input: sample, Output: map[string]interface{}{
+			"message":    sample,
+			"@timestamp": time.Now().Format(time.RFC3339Nano),
+		}}
+		if config.Content == "v1" {
+			output.Output["level"] = "INFO"
+			return output
+		}
+		if sample == "broken" {
+			return models.TestOutput{Input: sample, Error: "_grokparsefailure"}
+		}
+		output.Output["log"] = map[string]interface{}{"level": "INFO"}
+		output.Output["geo"] = map[string]interface{}{"country": "CN"}
+		return output
+	}
+
+	send := func(body interface{}) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		req, _ := http.NewRequest("POST", "/tests/compare", bytes.NewBuffer(data))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("逐条返回字段差异", func(t *testing.T) {
+		w := send(models.TestCompareRequest{
+			Base:         models.TestCompareSide{ConfigID: "config-1", Version: 1},
+			Candidate:    models.TestCompareSide{ConfigID: "config-1"},
+			Samples:      []string{"line a", "broken"},
+			IgnoreFields: []string{"@timestamp", "geo"},
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var result models.TestCompareResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		assert.Equal(t, models.TestCompareSide{ConfigID: "config-1", Version: 1}, result.Base)
+		assert.Equal(t, models.TestCompareSide{ConfigID: "config-1", Version: 2}, result.Candidate)
+		assert.False(t, result.Identical)
+		assert.Equal(t, 2, result.EventCount)
+		assert.Equal(t, 2, result.ChangedCount)
+		require.Len(t, result.Events, 2)
+
+		first := result.Events[0]
+		assert.Equal(t, "line a", first.Input)
+		assert.Equal(t, []models.FieldDiff{
+			{Field: "level", Change: models.FieldRemoved, Base: "INFO"},
+			{Field: "log.level", Change: models.FieldAdded, Candidate: "INFO"},
+		}, first.Diffs, "忽略的字段及其子字段不参与比较")
+
+		second := result.Events[1]
+		assert.Equal(t, 1, second.Index)
+		assert.Equal(t, "_grokparsefailure", second.CandidateError)
+		assert.Empty(t, second.Diffs)
+	})
+
+	t.Run("同一版本输出一致", func(t *testing.T) {
+		w := send(models.TestCompareRequest{
+			Base:      models.TestCompareSide{ConfigID: "config-1", Version: 1},
+			Candidate: models.TestCompareSide{ConfigID: "config-1", Version: 1},
+			Samples:   []string{"line a", "line b"},
+		})
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var result models.TestCompareResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		assert.True(t, result.Identical, "默认忽略@timestamp")
+		assert.Zero(t, result.ChangedCount)
+		assert.Equal(t, []string{"@timestamp"}, result.IgnoreFields)
+	})
+
+	t.Run("版本或配置不存在", func(t *testing.T) {
+		w := send(models.TestCompareRequest{
+			Base:      models.TestCompareSide{ConfigID: "config-1", Version: 5},
+			Candidate: models.TestCompareSide{ConfigID: "config-1"},
+			Samples:   []string{"line a"},
+		})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = send(models.TestCompareRequest{
+			Base:      models.TestCompareSide{ConfigID: "config-1"},
+			Candidate: models.TestCompareSide{ConfigID: "missing"},
+			Samples:   []string{"line a"},
+		})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("没有样本", func(t *testing.T) {
+		w := send(models.TestCompareRequest{
+			Base:      models.TestCompareSide{ConfigID: "config-1"},
+			Candidate: models.TestCompareSide{ConfigID: "config-1", Version: 1},
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+// fakeDatasetService 返回固定的数据集并记录运行结果
+type fakeDatasetService struct {
+	service.TestDatasetService
+	datasets []*models.TestDataset
+	recorded map[string]*models.DatasetRun
+}
+
+func (f *fakeDatasetService) ListDatasets(ctx context.Context, configID string) ([]*models.TestDataset, error) {
+	return f.datasets, nil
+}
+
+func (f *fakeDatasetService) RecordRun(ctx context.Context, dataset *models.TestDataset, run *models.DatasetRun) error {
+	f.recorded[dataset.ID] = run
+	return nil
+}
+
+func TestRunDatasets(t *testing.T) {
+	router, handler, mockService := setupTestHandlerRouter()
+	router.POST("/configs/:id/datasets/run", handler.RunDatasets)
+
+	mockService.On("GetConfig", mock.Anything, "config-1").
+		Return(&models.Config{ID: "config-1", Content: "filter {}", Version: 4}, nil)
+	datasets := &fakeDatasetService{
+		datasets: []*models.TestDataset{
+			{ID: "ds-1", Name: "access", Samples: []models.DatasetSample{
+				{Input: "a", Expected: map[string]interface{}{"message": "a"}},
+				{Input: "b"},
+			}},
+			{ID: "ds-2", Name: "error", Samples: []models.DatasetSample{
+				{Input: "c", Expected: map[string]interface{}{"message": "not c"}},
+			}},
+		},
+		recorded: make(map[string]*models.DatasetRun),
+	}
+	handler.SetDatasetService(datasets)
+	handler.process = func(config *models.Config, index int, sample string) models.TestOutput {
+		return models.TestOutput{Input: sample, Output: map[string]interface{}{"message": sample}}
+	}
+
+	req, _ := http.NewRequest("POST", "/configs/config-1/datasets/run", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var result models.RunTestDatasetsResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, 4, result.ConfigVersion)
+	assert.Equal(t, models.DatasetRunFailed, result.Status)
+	require.Len(t, result.Runs, 2)
+	assert.Equal(t, models.DatasetRunPassed, result.Runs[0].Status)
+	assert.Equal(t, 2, result.Runs[0].Passed)
+	assert.Equal(t, models.DatasetRunFailed, result.Runs[1].Status)
+	assert.Equal(t, "c", result.Runs[1].Assertions[0].Actual, "样本按数据集切分")
+
+	require.Contains(t, datasets.recorded, "ds-1")
+	require.Contains(t, datasets.recorded, "ds-2")
+	assert.Equal(t, 4, datasets.recorded["ds-2"].ConfigVersion)
+}
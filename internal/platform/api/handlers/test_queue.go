@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+)
+
+// 测试任务资源限制的默认值
+const (
+	defaultMaxConcurrentTestJobs = 4
+	defaultMaxQueuedTestJobs     = 100
+	defaultTestJobTimeout        = 5 * time.Minute
+	defaultMaxTestSamples        = 10000
+	defaultMaxTestSampleBytes    = 10 << 20
+)
+
+// TestLimits 异步测试任务的资源限制，为0的字段使用默认值
+type TestLimits struct {
+	MaxConcurrentJobs int           // 同时执行的测试任务数，超过的任务排队
+	MaxQueuedJobs     int           // 排队任务数上限，队列已满时拒绝新测试
+	JobTimeout        time.Duration // 单个任务从开始执行起的超时，超时后不再处理剩余样本
+	MaxSamples        int           // 单个任务的样本条数上限
+	MaxSampleBytes    int64         // 单个任务全部样本的字节数上限，限制任务在内存中保留的输入和结果
+}
+
+// withDefaults 为未设置的限制填入默认值
+func (l TestLimits) withDefaults() TestLimits {
+	if l.MaxConcurrentJobs < 1 {
+		l.MaxConcurrentJobs = defaultMaxConcurrentTestJobs
+	}
+	if l.MaxQueuedJobs < 1 {
+		l.MaxQueuedJobs = defaultMaxQueuedTestJobs
+	}
+	if l.JobTimeout <= 0 {
+		l.JobTimeout = defaultTestJobTimeout
+	}
+	if l.MaxSamples < 1 {
+		l.MaxSamples = defaultMaxTestSamples
+	}
+	if l.MaxSampleBytes < 1 {
+		l.MaxSampleBytes = defaultMaxTestSampleBytes
+	}
+	return l
+}
+
+// testJob 等待执行的测试任务
+type testJob struct {
+	id  string
+	ctx context.Context
+	run func(ctx context.Context)
+}
+
+// SetLimits 设置异步测试任务的并发数、排队上限、超时和样本限制
+func (h *TestHandler) SetLimits(limits TestLimits) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.limits = limits.withDefaults()
+}
+
+// checkSampleLimits 检查测试数据的样本条数和总字节数
+func (h *TestHandler) checkSampleLimits(data *models.TestData) error {
+	h.mu.RLock()
+	limits := h.limits
+	h.mu.RUnlock()
+
+	if len(data.Samples) > limits.MaxSamples {
+		return apperror.New(apperror.TestTooLarge, fmt.Sprintf("样本数 %d 超过上限 %d", len(data.Samples), limits.MaxSamples))
+	}
+	var size int64
+	for _, sample := range data.Samples {
+		size += int64(len(sample))
+	}
+	if size > limits.MaxSampleBytes {
+		return apperror.New(apperror.TestTooLarge, fmt.Sprintf("样本共 %d 字节，超过上限 %d 字节", size, limits.MaxSampleBytes))
+	}
+	return nil
+}
+
+// enqueueLocked 有空闲名额时立即执行任务，否则放入队列并标记为排队中；队列已满时返回false
+// 调用方持有h.mu，任务开始前已计入h.running
+func (h *TestHandler) enqueueLocked(job testJob) bool {
+	if h.active < h.limits.MaxConcurrentJobs {
+		h.startLocked(job)
+		return true
+	}
+	if len(h.queue) >= h.limits.MaxQueuedJobs {
+		return false
+	}
+	h.queue = append(h.queue, job)
+	if result, exists := h.testResults[job.id]; exists {
+		result.Status = "queued"
+	}
+	return true
+}
+
+// startLocked 占用一个执行名额并在后台执行任务，结束后释放名额并启动队首的任务
+func (h *TestHandler) startLocked(job testJob) {
+	h.active++
+	if result, exists := h.testResults[job.id]; exists {
+		result.Status = "running"
+		result.StartTime = time.Now()
+	}
+	timeout := h.limits.JobTimeout
+	go func() {
+		defer h.running.Done()
+		ctx, cancel := context.WithTimeout(job.ctx, timeout)
+		job.run(ctx)
+		cancel()
+
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.active--
+		if len(h.queue) > 0 && !h.draining {
+			next := h.queue[0]
+			h.queue = h.queue[1:]
+			h.startLocked(next)
+		}
+	}()
+}
+
+// queuePositionLocked 任务在队列中的位置，从1开始，不在队列中时为0
+func (h *TestHandler) queuePositionLocked(testID string) int {
+	for i, job := range h.queue {
+		if job.id == testID {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// cancelQueuedLocked 取消全部排队的任务，标记为失败
+func (h *TestHandler) cancelQueuedLocked(now time.Time) {
+	for _, job := range h.queue {
+		if result, exists := h.testResults[job.id]; exists {
+			result.Status = "failed"
+			result.Errors = append(result.Errors, "平台关闭，测试未开始执行")
+			result.EndTime = &now
+		}
+		h.running.Done()
+	}
+	h.queue = nil
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+)
+
+// SetESSource 设置Elasticsearch测试数据源，之后可以从索引中抽取文档作为测试样本
+func (h *TestHandler) SetESSource(source *service.ESTestSource) {
+	h.esSource = source
+}
+
+// loadSamples 按测试数据类型把文件、索引或回放记录中的数据读取为样本，其他类型的样本由请求直接提供
+func (h *TestHandler) loadSamples(c *gin.Context, data *models.TestData) bool {
+	switch data.Type {
+	case "file":
+		return h.loadFileSamples(c, data)
+	case "elasticsearch":
+		return h.loadESSamples(c, data)
+	case "replay":
+		return h.loadReplaySamples(c, data)
+	}
+	return true
+}
+
+// loadReplaySamples 把 type=replay 测试数据中的HTTP请求记录解析为样本，样本条数由样本限制检查
+func (h *TestHandler) loadReplaySamples(c *gin.Context, data *models.TestData) bool {
+	if data.Replay == nil {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "replay类型的测试数据需要replay.format和replay.content"))
+		return false
+	}
+	samples, err := service.ReplaySamples(data.Replay)
+	if err != nil {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, err.Error()))
+		return false
+	}
+	if len(samples) == 0 {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "回放记录中没有可回放的请求"))
+		return false
+	}
+	data.Samples = samples
+	return true
+}
+
+// loadESSamples 从 type=elasticsearch 测试数据的源索引抽取文档作为样本，最多抽取样本条数上限个
+func (h *TestHandler) loadESSamples(c *gin.Context, data *models.TestData) bool {
+	if h.esSource == nil {
+		middleware.AbortWithError(c, apperror.New(apperror.TestSourceUnavailable, "未启用Elasticsearch测试数据源"))
+		return false
+	}
+	if data.Elasticsearch == nil {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "elasticsearch类型的测试数据需要elasticsearch.index"))
+		return false
+	}
+
+	h.mu.RLock()
+	maxSamples := h.limits.MaxSamples
+	h.mu.RUnlock()
+	samples, err := h.esSource.Sample(c.Request.Context(), data.Elasticsearch, maxSamples)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrTestSourceForbidden):
+			middleware.AbortWithError(c, apperror.New(apperror.TestSourceForbidden, err.Error()))
+		case errors.Is(err, service.ErrTestSourceInvalid):
+			middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, err.Error()))
+		default:
+			h.logger.Errorf("抽取测试样本失败: %v", err)
+			middleware.AbortWithError(c, apperror.Wrap(err, "抽取测试样本失败"))
+		}
+		return false
+	}
+	if len(samples) == 0 {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "源索引中没有匹配的文档"))
+		return false
+	}
+	data.Samples = samples
+	return true
+}
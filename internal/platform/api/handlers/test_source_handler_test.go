@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+)
+
+// stubEventSampleRepository 返回固定文档
+type stubEventSampleRepository struct {
+	docs []json.RawMessage
+}
+
+func (r *stubEventSampleRepository) Sample(ctx context.Context, index string, query map[string]interface{}, size int, random bool) ([]json.RawMessage, error) {
+	return r.docs, nil
+}
+
+func TestCreateTest_ElasticsearchSource(t *testing.T) {
+	router, handler, mockService := setupTestHandlerRouter()
+	router.POST("/test", handler.CreateTest)
+	mockService.On("GetConfig", mock.Anything, "config-123").Return(&models.Config{ID: "config-123", Version: 1}, nil)
+	handler.process = func(config *models.Config, index int, sample string) models.TestOutput {
+		return models.TestOutput{Input: sample}
+	}
+
+	create := func(index string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(models.TestConfigRequest{
+			ConfigID: "config-123",
+			TestData: models.TestData{Type: "elasticsearch", Elasticsearch: &models.TestESSource{Index: index, Field: "message"}},
+		})
+		req, _ := http.NewRequest("POST", "/test", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := create("logs-nginx")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), "TEST_SOURCE_UNAVAILABLE")
+
+	repo := &stubEventSampleRepository{docs: []json.RawMessage{json.RawMessage(`{"message":"GET /"}`)}}
+	handler.SetESSource(service.NewESTestSource(service.ESTestSourceConfig{AllowedIndices: []string{"logs-*"}}, repo, logrus.New()))
+
+	w = create("logstash_users")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "TEST_SOURCE_FORBIDDEN")
+
+	w = create("logs-nginx")
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	repo.docs = nil
+	w = create("logs-nginx")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "源索引中没有匹配的文档")
+}
+
+func TestCreateTest_ReplayWithMasking(t *testing.T) {
+	router, handler, mockService := setupTestHandlerRouter()
+	router.POST("/test", handler.CreateTest)
+	mockService.On("GetConfig", mock.Anything, "config-123").Return(&models.Config{ID: "config-123", Version: 1}, nil)
+	handler.process = func(config *models.Config, index int, sample string) models.TestOutput {
+		return models.TestOutput{Input: sample, Output: map[string]interface{}{"message": sample, "email": "a@example.com"}}
+	}
+
+	create := func(data models.TestData) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(models.TestConfigRequest{ConfigID: "config-123", TestData: data})
+		req, _ := http.NewRequest("POST", "/test", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	har := json.RawMessage(`{"log": {"entries": [{"request": {"method": "POST", "url": "http://logs/", "postData": {"text": "card=4111111111111111"}}}]}}`)
+
+	w := create(models.TestData{
+		Type:    "replay",
+		Replay:  &models.TestReplaySource{Format: "har", Content: har},
+		Masking: []models.TestMaskRule{{Pattern: `\d{16}`, Replacement: "****"}, {Field: "email"}},
+	})
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	var result *models.TestResult
+	assert.Eventually(t, func() bool {
+		handler.mu.RLock()
+		defer handler.mu.RUnlock()
+		result = handler.testResults[response["test_id"].(string)]
+		return result != nil && result.Status == "completed"
+	}, time.Second, 10*time.Millisecond)
+	if assert.Len(t, result.Results, 1) {
+		// 配置了按字段的规则时，非JSON的原始输入整体替换
+		assert.Equal(t, "[REDACTED]", result.Results[0].Input)
+		assert.Equal(t, map[string]interface{}{"message": "card=****", "email": "[REDACTED]"}, result.Results[0].Output)
+	}
+
+	w = create(models.TestData{
+		Type:    "replay",
+		Replay:  &models.TestReplaySource{Format: "har", Content: har},
+		Masking: []models.TestMaskRule{{Pattern: "("}},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "脱敏规则无效")
+
+	w = create(models.TestData{Type: "replay", Replay: &models.TestReplaySource{Format: "json", Content: json.RawMessage(`[]`)}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "没有可回放的请求")
+}
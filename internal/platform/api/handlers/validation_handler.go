@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+)
+
+// ValidationHandler 配置校验处理器
+type ValidationHandler struct {
+	validator service.ConfigValidator
+	logger    *logrus.Logger
+}
+
+// NewValidationHandler 创建配置校验处理器
+func NewValidationHandler(validator service.ConfigValidator, logger *logrus.Logger) *ValidationHandler {
+	return &ValidationHandler{
+		validator: validator,
+		logger:    logger,
+	}
+}
+
+// ValidateConfig 使用Logstash校验配置语法
+// 校验本身成功时总是返回200，配置是否有效见结果中的valid字段
+func (h *ValidationHandler) ValidateConfig(c *gin.Context) {
+	var req models.ValidateConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	result, err := h.validator.Validate(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrValidatorUnavailable) {
+			middleware.AbortWithError(c, apperror.New(apperror.ValidatorUnavailable, "平台未配置可用的Logstash，无法校验配置"))
+			return
+		}
+		h.logger.Errorf("校验配置失败: %v", err)
+		middleware.AbortWithError(c, apperror.Wrap(err, "校验配置失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+)
+
+// WebhookHandler 出站事件订阅处理器
+type WebhookHandler struct {
+	webhooks service.WebhookService
+	logger   *logrus.Logger
+}
+
+// NewWebhookHandler 创建出站事件订阅处理器
+func NewWebhookHandler(webhooks service.WebhookService, logger *logrus.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		webhooks: webhooks,
+		logger:   logger,
+	}
+}
+
+// ListWebhooks 获取订阅列表
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	webhooks, err := h.webhooks.ListWebhooks(c.Request.Context())
+	if err != nil {
+		h.handleWebhookError(c, err, "获取webhook列表失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items": webhooks,
+		"total": len(webhooks),
+	})
+}
+
+// CreateWebhook 创建订阅，响应中的secret只返回这一次
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req models.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	webhook, err := h.webhooks.CreateWebhook(c.Request.Context(), &req, middleware.CurrentUserID(c))
+	if err != nil {
+		h.handleWebhookError(c, err, "创建webhook失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, webhook)
+}
+
+// GetWebhook 获取单个订阅
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	webhook, err := h.webhooks.GetWebhook(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleWebhookError(c, err, "获取webhook失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+// UpdateWebhook 更新订阅
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	var req models.UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	webhook, err := h.webhooks.UpdateWebhook(c.Request.Context(), c.Param("id"), &req, middleware.CurrentUserID(c))
+	if err != nil {
+		h.handleWebhookError(c, err, "更新webhook失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+// DeleteWebhook 删除订阅
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	if err := h.webhooks.DeleteWebhook(c.Request.Context(), c.Param("id")); err != nil {
+		h.handleWebhookError(c, err, "删除webhook失败")
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// ListDeliveries 获取订阅最近的推送记录
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	var req models.WebhookDeliveryListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		middleware.HandleBindError(c, err, "查询参数无效")
+		return
+	}
+
+	deliveries, err := h.webhooks.ListDeliveries(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		h.handleWebhookError(c, err, "获取webhook推送记录失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items": deliveries,
+		"total": len(deliveries),
+	})
+}
+
+// handleWebhookError 将订阅服务错误映射为HTTP响应
+func (h *WebhookHandler) handleWebhookError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrWebhookNotFound):
+		middleware.AbortWithError(c, apperror.New(apperror.NotFound, "webhook不存在"))
+	case errors.Is(err, service.ErrWebhookInvalid):
+		middleware.AbortWithError(c, apperror.New(apperror.Validation, err.Error()))
+	default:
+		h.logger.Errorf("%s: %v", message, err)
+		middleware.AbortWithError(c, apperror.Wrap(err, message))
+	}
+}
@@ -1,17 +1,353 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+	"logstash-platform/internal/platform/tracing"
+	"logstash-platform/internal/platform/websocket"
 )
 
-// WebSocketHandler WebSocket处理器
-func WebSocketHandler(logger *logrus.Logger) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// TODO: 实现WebSocket逻辑
-		logger.Info("WebSocket连接请求")
-		c.JSON(200, gin.H{
-			"message": "WebSocket功能待实现",
-		})
+// WebSocketHandler Agent WebSocket连接处理器
+// 连接交给Hub管理，Agent经WebSocket上报的消息与对应的HTTP接口走同一套服务
+type WebSocketHandler struct {
+	hub          *websocket.Hub
+	agentService service.AgentService
+	engine       *service.DeploymentEngine
+	telemetry    *service.TelemetryPolicy
+	metrics      service.MetricsService
+	logStreams   *service.LogStreamRelay
+	diagnostics  *service.DiagnosticRunner
+	dlq          *service.DLQInspector
+	plugins      *service.PluginInstaller
+	liveness     *service.LivenessMonitor
+	logger       *logrus.Logger
+
+	// 经WebSocket转发的心跳命令，在该Agent下一次心跳时确认
+	mu        sync.Mutex
+	forwarded map[string][]string
+	// 当前连接上发送过心跳的Agent，连接断开时立即判定离线
+	heartbeating map[string]bool
+}
+
+// NewWebSocketHandler 创建WebSocket处理器
+func NewWebSocketHandler(hub *websocket.Hub, agentService service.AgentService, engine *service.DeploymentEngine, logger *logrus.Logger) *WebSocketHandler {
+	return &WebSocketHandler{
+		hub:          hub,
+		agentService: agentService,
+		engine:       engine,
+		logger:       logger,
+		forwarded:    make(map[string][]string),
+		heartbeating: make(map[string]bool),
+	}
+}
+
+// SetTelemetryPolicy 设置间隔协商策略，WebSocket心跳的处理耗时同样计入平台压力
+func (h *WebSocketHandler) SetTelemetryPolicy(policy *service.TelemetryPolicy) {
+	h.telemetry = policy
+}
+
+// SetMetricsService 启用指标时序存储，经WebSocket上报的指标同样写入指标索引
+func (h *WebSocketHandler) SetMetricsService(metrics service.MetricsService) {
+	h.metrics = metrics
+}
+
+// SetLogStreamRelay 启用实时日志，Agent推送的日志行转发给订阅的浏览器
+func (h *WebSocketHandler) SetLogStreamRelay(relay *service.LogStreamRelay) {
+	h.logStreams = relay
+}
+
+// SetDiagnosticRunner 启用远程诊断，Agent回复的诊断结果交给等待的请求
+func (h *WebSocketHandler) SetDiagnosticRunner(runner *service.DiagnosticRunner) {
+	h.diagnostics = runner
+}
+
+// SetDLQInspector 启用死信队列查看，Agent回复的死信事件交给等待的请求
+func (h *WebSocketHandler) SetDLQInspector(inspector *service.DLQInspector) {
+	h.dlq = inspector
+}
+
+// SetPluginInstaller 启用插件远程安装，Agent报告的安装进度和结果写入安装记录
+func (h *WebSocketHandler) SetPluginInstaller(installer *service.PluginInstaller) {
+	h.plugins = installer
+}
+
+// SetLivenessMonitor 启用断线即离线，经WebSocket发送心跳的Agent断开连接时不必等待心跳过期
+func (h *WebSocketHandler) SetLivenessMonitor(liveness *service.LivenessMonitor) {
+	h.liveness = liveness
+}
+
+// AgentDisconnected 实现websocket.DisconnectListener，经WebSocket发送心跳的Agent断开时判定为离线
+func (h *WebSocketHandler) AgentDisconnected(agentID string) {
+	h.mu.Lock()
+	heartbeating := h.heartbeating[agentID]
+	delete(h.heartbeating, agentID)
+	h.mu.Unlock()
+
+	if !heartbeating || h.liveness == nil {
+		return
+	}
+	if err := h.liveness.Disconnected(context.Background(), agentID); err != nil {
+		h.logger.WithError(err).WithField("agent_id", agentID).Warn("标记Agent离线失败")
+	}
+}
+
+// Connect 升级为WebSocket连接，令牌及其与agent_id的绑定已由中间件校验
+func (h *WebSocketHandler) Connect(c *gin.Context) {
+	agentID := c.Query("agent_id")
+	if agentID == "" {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, "Agent ID不能为空"))
+		return
+	}
+
+	h.logger.WithField("agent_id", agentID).Info("WebSocket连接请求")
+	if err := h.hub.ServeAgent(c.Writer, c.Request, agentID); err != nil {
+		// 升级失败时Upgrader已写入HTTP错误响应
+		h.logger.WithError(err).WithField("agent_id", agentID).Warn("建立WebSocket连接失败")
+	}
+}
+
+// Poll 长轮询接收平台推送的消息，供代理不允许WebSocket的Agent使用，令牌与Agent ID的绑定已由中间件校验
+func (h *WebSocketHandler) Poll(c *gin.Context) {
+	var req models.PollRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	resp, err := h.hub.ServePoll(c.Request.Context(), c.Param("id"), c.ClientIP(), &req)
+	if err != nil {
+		middleware.AbortWithError(c, apperror.New(apperror.InvalidRequest, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Upload 接收Agent经长轮询通道上报的消息，与经WebSocket上报的消息走同一套处理
+func (h *WebSocketHandler) Upload(c *gin.Context) {
+	var req models.PollUpload
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.HandleBindError(c, err, "请求参数无效")
+		return
+	}
+
+	h.hub.HandlePolled(c.Request.Context(), c.Param("id"), req.Messages)
+	c.JSON(http.StatusAccepted, gin.H{"status": "recorded"})
+}
+
+// HandleMessage 实现websocket.MessageHandler，处理Agent上报的消息
+// 消息带有链路上下文时（如Agent应用平台下发的配置后的上报）在同一链路下记录处理过程
+func (h *WebSocketHandler) HandleMessage(ctx context.Context, agentID string, msg *models.WebSocketMessage) error {
+	if len(msg.Metadata) == 0 {
+		return h.handleMessage(ctx, agentID, msg)
+	}
+
+	ctx, span := tracing.Tracer().Start(tracing.Extract(ctx, msg.Metadata), "websocket "+msg.Type,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attribute.String("agent.id", agentID)),
+	)
+	defer span.End()
+
+	err := h.handleMessage(ctx, agentID, msg)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+// handleMessage 按消息类型分发
+func (h *WebSocketHandler) handleMessage(ctx context.Context, agentID string, msg *models.WebSocketMessage) error {
+	switch msg.Type {
+	case models.MsgTypeHeartbeat:
+		return h.handleHeartbeat(ctx, agentID, msg.Payload)
+	case models.MsgTypeConfigApplied:
+		return h.handleConfigApplied(ctx, agentID, msg.Payload)
+	case models.MsgTypeError:
+		h.logger.WithFields(logrus.Fields{
+			"agent_id": agentID,
+			"payload":  string(msg.Payload),
+		}).Warn("Agent处理消息失败")
+		return nil
+	case models.MsgTypeMetricsReport:
+		return h.handleMetricsReport(ctx, agentID, msg.Payload)
+	case models.MsgTypeLogLines:
+		return h.handleLogLines(agentID, msg.Payload)
+	case models.MsgTypeDiagnosticResult:
+		return h.handleDiagnosticResult(agentID, msg.Payload)
+	case models.MsgTypeDLQResult:
+		return h.handleDLQResult(agentID, msg.Payload)
+	case models.MsgTypePluginInstallProgress:
+		return h.handlePluginInstallProgress(ctx, agentID, msg.Payload)
+	case models.MsgTypePluginInstallResult:
+		return h.handlePluginInstallResult(ctx, agentID, msg.Payload)
+	case models.MsgTypeStatusReport:
+		h.logger.WithFields(logrus.Fields{
+			"agent_id": agentID,
+			"type":     msg.Type,
+		}).Debug("收到Agent上报")
+		return nil
+	default:
+		return fmt.Errorf("未知消息类型: %s", msg.Type)
+	}
+}
+
+// handleHeartbeat 更新心跳时间，并把心跳命令队列中等待的命令经WebSocket转发
+// 心跳同时确认已转发的命令和Agent此前经HTTP心跳收到的命令
+func (h *WebSocketHandler) handleHeartbeat(ctx context.Context, agentID string, payload json.RawMessage) error {
+	var heartbeat models.HeartbeatMessage
+	if len(payload) > 0 {
+		if err := json.Unmarshal(payload, &heartbeat); err != nil {
+			return fmt.Errorf("解析心跳失败: %w", err)
+		}
+	}
+
+	h.mu.Lock()
+	acked := append(h.forwarded[agentID], heartbeat.AckedCommands...)
+	delete(h.forwarded, agentID)
+	h.heartbeating[agentID] = true
+	h.mu.Unlock()
+
+	start := time.Now()
+	resp, err := h.agentService.Heartbeat(ctx, agentID, acked)
+	if h.telemetry != nil {
+		h.telemetry.ObserveHeartbeat(time.Since(start))
+	}
+	if err != nil {
+		return fmt.Errorf("处理心跳失败: %w", err)
+	}
+
+	var forwarded []string
+	for _, cmd := range resp.Commands {
+		if err := h.hub.PublishContext(tracing.Extract(ctx, cmd.Metadata), agentID, cmd.Type, cmd.Payload); err != nil {
+			h.logger.WithError(err).WithField("agent_id", agentID).Warn("转发心跳命令失败")
+			continue
+		}
+		forwarded = append(forwarded, cmd.ID)
+	}
+	if len(forwarded) > 0 {
+		h.mu.Lock()
+		h.forwarded[agentID] = append(h.forwarded[agentID], forwarded...)
+		h.mu.Unlock()
+	}
+	return nil
+}
+
+// handleConfigApplied 记录配置应用结果
+func (h *WebSocketHandler) handleConfigApplied(ctx context.Context, agentID string, payload json.RawMessage) error {
+	var report models.ConfigAppliedReport
+	if err := json.Unmarshal(payload, &report); err != nil {
+		return fmt.Errorf("解析配置应用结果失败: %w", err)
+	}
+	if report.ConfigID == "" {
+		return fmt.Errorf("配置应用结果缺少config_id")
+	}
+	if h.engine == nil {
+		return nil
+	}
+	return h.engine.RecordResult(ctx, agentID, &report)
+}
+
+// handleMetricsReport 记录Agent上报的指标
+func (h *WebSocketHandler) handleMetricsReport(ctx context.Context, agentID string, payload json.RawMessage) error {
+	var report models.MetricsReportMessage
+	if err := json.Unmarshal(payload, &report); err != nil {
+		return fmt.Errorf("解析指标上报失败: %w", err)
+	}
+	if err := h.agentService.RecordMetrics(ctx, agentID, &report.Metrics); err != nil {
+		return err
+	}
+	if h.metrics != nil {
+		if err := h.metrics.Record(ctx, agentID, &report.Metrics); err != nil {
+			h.logger.WithError(err).WithField("agent_id", agentID).Warn("写入指标时序失败")
+		}
+	}
+	return nil
+}
+
+// handleLogLines 转发Agent推送的日志行
+func (h *WebSocketHandler) handleLogLines(agentID string, payload json.RawMessage) error {
+	if h.logStreams == nil {
+		return nil
+	}
+	var lines models.LogLinesMessage
+	if err := json.Unmarshal(payload, &lines); err != nil {
+		return fmt.Errorf("解析日志行失败: %w", err)
+	}
+	return h.logStreams.Deliver(agentID, &lines)
+}
+
+// handleDiagnosticResult 转交Agent回复的诊断结果
+func (h *WebSocketHandler) handleDiagnosticResult(agentID string, payload json.RawMessage) error {
+	if h.diagnostics == nil {
+		return nil
+	}
+	var result models.DiagnosticResult
+	if err := json.Unmarshal(payload, &result); err != nil {
+		return fmt.Errorf("解析诊断结果失败: %w", err)
+	}
+	if result.RequestID == "" {
+		return fmt.Errorf("诊断结果缺少request_id")
+	}
+	h.diagnostics.Deliver(agentID, &result)
+	return nil
+}
+
+// handleDLQResult 转交Agent回复的死信事件
+func (h *WebSocketHandler) handleDLQResult(agentID string, payload json.RawMessage) error {
+	if h.dlq == nil {
+		return nil
+	}
+	var result models.DLQResult
+	if err := json.Unmarshal(payload, &result); err != nil {
+		return fmt.Errorf("解析死信队列结果失败: %w", err)
+	}
+	if result.RequestID == "" {
+		return fmt.Errorf("死信队列结果缺少request_id")
+	}
+	h.dlq.Deliver(agentID, &result)
+	return nil
+}
+
+// handlePluginInstallProgress 记录Agent报告的插件安装进度
+func (h *WebSocketHandler) handlePluginInstallProgress(ctx context.Context, agentID string, payload json.RawMessage) error {
+	if h.plugins == nil {
+		return nil
+	}
+	var progress models.PluginInstallProgress
+	if err := json.Unmarshal(payload, &progress); err != nil {
+		return fmt.Errorf("解析插件安装进度失败: %w", err)
+	}
+	if progress.InstallID == "" {
+		return fmt.Errorf("插件安装进度缺少install_id")
+	}
+	return h.plugins.Progress(ctx, agentID, &progress)
+}
+
+// handlePluginInstallResult 记录Agent报告的插件安装结果
+func (h *WebSocketHandler) handlePluginInstallResult(ctx context.Context, agentID string, payload json.RawMessage) error {
+	if h.plugins == nil {
+		return nil
+	}
+	var result models.PluginInstallResult
+	if err := json.Unmarshal(payload, &result); err != nil {
+		return fmt.Errorf("解析插件安装结果失败: %w", err)
+	}
+	if result.InstallID == "" {
+		return fmt.Errorf("插件安装结果缺少install_id")
 	}
-}
\ No newline at end of file
+	return h.plugins.Complete(ctx, agentID, &result)
+}
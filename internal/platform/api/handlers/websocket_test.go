@@ -1,306 +1,286 @@
 package handlers
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	gorilla "github.com/gorilla/websocket"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+	"logstash-platform/internal/platform/websocket"
 )
 
-func TestWebSocketHandler(t *testing.T) {
+func newTestWebSocketHandler(t *testing.T, agentService *MockAgentService) (*WebSocketHandler, *websocket.Hub, *httptest.Server) {
 	gin.SetMode(gin.TestMode)
-	
-	tests := []struct {
-		name           string
-		setupLogger    func() *logrus.Logger
-		expectedStatus int
-		expectedBody   map[string]interface{}
-		checkLog       bool
-		expectedLog    string
-	}{
-		{
-			name: "WebSocket连接请求返回待实现信息",
-			setupLogger: func() *logrus.Logger {
-				logger := logrus.New()
-				logger.SetLevel(logrus.InfoLevel)
-				return logger
-			},
-			expectedStatus: http.StatusOK,
-			expectedBody: map[string]interface{}{
-				"message": "WebSocket功能待实现",
-			},
-			checkLog:    true,
-			expectedLog: "WebSocket连接请求",
-		},
-	}
-	
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			// 设置logger
-			logger := tt.setupLogger()
-			
-			// 捕获日志输出
-			var logBuffer bytes.Buffer
-			if tt.checkLog {
-				logger.SetOutput(&logBuffer)
-			}
-			
-			// 创建handler
-			handler := WebSocketHandler(logger)
-			
-			// 创建测试上下文
-			w := httptest.NewRecorder()
-			c, router := gin.CreateTestContext(w)
-			
-			// 注册路由
-			router.GET("/ws", handler)
-			
-			// 创建请求
-			c.Request = httptest.NewRequest(http.MethodGet, "/ws", nil)
-			
-			// 执行请求
-			router.ServeHTTP(w, c.Request)
-			
-			// 验证响应
-			assert.Equal(t, tt.expectedStatus, w.Code)
-			
-			var response map[string]interface{}
-			err := json.Unmarshal(w.Body.Bytes(), &response)
-			assert.NoError(t, err)
-			assert.Equal(t, tt.expectedBody, response)
-			
-			// 检查日志
-			if tt.checkLog {
-				logOutput := logBuffer.String()
-				assert.Contains(t, logOutput, tt.expectedLog)
-			}
-		})
-	}
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	hub := websocket.NewHub(websocket.Config{}, logger)
+	handler := NewWebSocketHandler(hub, agentService, nil, logger)
+	hub.SetHandler(handler)
+
+	router := gin.New()
+	router.GET("/ws", handler.Connect)
+	server := httptest.NewServer(router)
+	t.Cleanup(func() {
+		hub.Close()
+		server.Close()
+	})
+	return handler, hub, server
 }
 
-// TestWebSocketHandler_WithDifferentLogLevels 测试不同日志级别
-func TestWebSocketHandler_WithDifferentLogLevels(t *testing.T) {
-	gin.SetMode(gin.TestMode)
-	
-	tests := []struct {
-		name        string
-		logLevel    logrus.Level
-		expectLog   bool
-	}{
-		{
-			name:      "Debug级别记录日志",
-			logLevel:  logrus.DebugLevel,
-			expectLog: true,
-		},
-		{
-			name:      "Info级别记录日志",
-			logLevel:  logrus.InfoLevel,
-			expectLog: true,
-		},
-		{
-			name:      "Warn级别不记录Info日志",
-			logLevel:  logrus.WarnLevel,
-			expectLog: false,
-		},
-		{
-			name:      "Error级别不记录Info日志",
-			logLevel:  logrus.ErrorLevel,
-			expectLog: false,
-		},
-	}
-	
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			// 创建logger并设置级别
-			logger := logrus.New()
-			logger.SetLevel(tt.logLevel)
-			
-			// 捕获日志输出
-			var logBuffer bytes.Buffer
-			logger.SetOutput(&logBuffer)
-			
-			// 创建handler
-			handler := WebSocketHandler(logger)
-			
-			// 创建测试上下文
-			w := httptest.NewRecorder()
-			c, _ := gin.CreateTestContext(w)
-			
-			// 创建请求
-			c.Request = httptest.NewRequest(http.MethodGet, "/ws", nil)
-			
-			// 执行handler
-			handler(c)
-			
-			// 验证日志输出
-			logOutput := logBuffer.String()
-			if tt.expectLog {
-				assert.Contains(t, logOutput, "WebSocket连接请求")
-			} else {
-				assert.NotContains(t, logOutput, "WebSocket连接请求")
-			}
-		})
-	}
+func TestWebSocketHandler_Connect(t *testing.T) {
+	_, hub, server := newTestWebSocketHandler(t, &MockAgentService{})
+
+	t.Run("缺少agent_id", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/ws")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("建立连接并接收推送", func(t *testing.T) {
+		url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws?agent_id=agent-1"
+		conn, _, err := gorilla.DefaultDialer.Dial(url, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		require.Eventually(t, func() bool { return hub.IsConnected("agent-1") }, time.Second, 10*time.Millisecond)
+		require.NoError(t, hub.Publish("agent-1", models.MsgTypeReloadRequest, nil))
+
+		var msg models.WebSocketMessage
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		require.NoError(t, conn.ReadJSON(&msg))
+		assert.Equal(t, models.MsgTypeReloadRequest, msg.Type)
+	})
 }
 
-// TestWebSocketHandler_Concurrent 测试并发WebSocket请求
-func TestWebSocketHandler_Concurrent(t *testing.T) {
-	gin.SetMode(gin.TestMode)
-	
+func TestWebSocketHandler_Heartbeat(t *testing.T) {
+	agentService := &MockAgentService{}
+	handler, hub, server := newTestWebSocketHandler(t, agentService)
+	ctx := context.Background()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws?agent_id=agent-1"
+	conn, _, err := gorilla.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.Eventually(t, func() bool { return hub.IsConnected("agent-1") }, time.Second, 10*time.Millisecond)
+
+	// 心跳命令队列中等待的命令经WebSocket转发
+	agentService.On("Heartbeat", mock.Anything, "agent-1", []string(nil)).Return(&models.HeartbeatResponse{
+		Commands: []models.PendingCommand{{ID: "cmd-1", Type: models.MsgTypeLogLevel, Payload: json.RawMessage(`{"level":"debug"}`)}},
+	}, nil).Once()
+	require.NoError(t, handler.HandleMessage(ctx, "agent-1", &models.WebSocketMessage{Type: models.MsgTypeHeartbeat}))
+
+	var msg models.WebSocketMessage
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	require.NoError(t, conn.ReadJSON(&msg))
+	assert.Equal(t, models.MsgTypeLogLevel, msg.Type)
+	assert.JSONEq(t, `{"level":"debug"}`, string(msg.Payload))
+
+	// 下一次心跳确认已转发的命令，以及Agent此前经HTTP心跳收到的命令
+	agentService.On("Heartbeat", mock.Anything, "agent-1", []string{"cmd-1", "cmd-0"}).Return(&models.HeartbeatResponse{}, nil).Once()
+	require.NoError(t, handler.HandleMessage(ctx, "agent-1", &models.WebSocketMessage{
+		Type:    models.MsgTypeHeartbeat,
+		Payload: json.RawMessage(`{"agent_id":"agent-1","acked_commands":["cmd-0"]}`),
+	}))
+
+	agentService.AssertExpectations(t)
+}
+
+func TestWebSocketHandler_HandleMessage(t *testing.T) {
+	agentService := &MockAgentService{}
+	handler, _, _ := newTestWebSocketHandler(t, agentService)
+	ctx := context.Background()
+
+	err := handler.HandleMessage(ctx, "agent-1", &models.WebSocketMessage{Type: models.MsgTypeConfigApplied, Payload: json.RawMessage(`{"version":1}`)})
+	assert.Error(t, err)
+
+	// 指标上报记录到Agent
+	agentService.On("RecordMetrics", mock.Anything, "agent-1", mock.MatchedBy(func(m *models.AgentMetrics) bool {
+		return m.CPUUsage == 42 && m.CPUCores == 8
+	})).Return(nil).Once()
+	assert.NoError(t, handler.HandleMessage(ctx, "agent-1", &models.WebSocketMessage{
+		Type:    models.MsgTypeMetricsReport,
+		Payload: json.RawMessage(`{"agent_id":"agent-1","metrics":{"cpu_usage":42,"cpu_cores":8}}`),
+	}))
+	agentService.AssertExpectations(t)
+
+	assert.NoError(t, handler.HandleMessage(ctx, "agent-1", &models.WebSocketMessage{Type: models.MsgTypeStatusReport}))
+	assert.Error(t, handler.HandleMessage(ctx, "agent-1", &models.WebSocketMessage{Type: "unknown"}))
+}
+
+func TestLogStreamHandler_Stream(t *testing.T) {
+	handler, hub, server := newTestWebSocketHandler(t, &MockAgentService{})
 	logger := logrus.New()
-	handler := WebSocketHandler(logger)
-	
+	logger.SetLevel(logrus.ErrorLevel)
+	relay := service.NewLogStreamRelay(service.LogStreamConfig{MaxRate: 100}, hub, logger)
+	handler.SetLogStreamRelay(relay)
+
 	router := gin.New()
-	router.GET("/ws", handler)
-	
-	// 并发请求数
-	concurrency := 100
-	done := make(chan bool, concurrency)
-	
-	// 启动并发请求
-	for i := 0; i < concurrency; i++ {
-		go func() {
-			w := httptest.NewRecorder()
-			req := httptest.NewRequest(http.MethodGet, "/ws", nil)
-			router.ServeHTTP(w, req)
-			
-			// 验证响应
-			assert.Equal(t, http.StatusOK, w.Code)
-			
-			var response map[string]interface{}
-			err := json.Unmarshal(w.Body.Bytes(), &response)
-			assert.NoError(t, err)
-			assert.Equal(t, "WebSocket功能待实现", response["message"])
-			
-			done <- true
-		}()
-	}
-	
-	// 等待所有请求完成
-	for i := 0; i < concurrency; i++ {
-		<-done
-	}
-}
+	router.GET("/agents/:id/logs/stream", NewLogStreamHandler(relay, nil, logger).Stream)
+	browserServer := httptest.NewServer(router)
+	defer browserServer.Close()
+	streamURL := "ws" + strings.TrimPrefix(browserServer.URL, "http") + "/agents/agent-1/logs/stream?level=warn"
 
-// TestWebSocketHandler_LoggerFormats 测试不同的日志格式
-func TestWebSocketHandler_LoggerFormats(t *testing.T) {
-	gin.SetMode(gin.TestMode)
-	
-	tests := []struct {
-		name          string
-		formatter     logrus.Formatter
-		checkContains string
-	}{
-		{
-			name:          "文本格式",
-			formatter:     &logrus.TextFormatter{DisableTimestamp: true},
-			checkContains: "level=info msg=\"WebSocket连接请求\"",
-		},
-		{
-			name:          "JSON格式",
-			formatter:     &logrus.JSONFormatter{},
-			checkContains: `"msg":"WebSocket连接请求"`,
-		},
-	}
-	
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			// 创建logger并设置格式
-			logger := logrus.New()
-			logger.SetFormatter(tt.formatter)
-			
-			// 捕获日志输出
-			var logBuffer bytes.Buffer
-			logger.SetOutput(&logBuffer)
-			
-			// 创建handler
-			handler := WebSocketHandler(logger)
-			
-			// 创建测试上下文
-			w := httptest.NewRecorder()
-			c, _ := gin.CreateTestContext(w)
-			c.Request = httptest.NewRequest(http.MethodGet, "/ws", nil)
-			
-			// 执行handler
-			handler(c)
-			
-			// 验证日志格式
-			logOutput := logBuffer.String()
-			assert.Contains(t, logOutput, tt.checkContains)
-		})
+	// Agent未连接时返回409，不排入心跳命令队列
+	resp, err := http.Get(browserServer.URL + "/agents/agent-1/logs/stream")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	resp, err = http.Get(browserServer.URL + "/agents/agent-1/logs/stream?source=pipeline")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	agent, _, err := gorilla.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws?agent_id=agent-1", nil)
+	require.NoError(t, err)
+	defer agent.Close()
+	require.Eventually(t, func() bool { return hub.IsConnected("agent-1") }, time.Second, 10*time.Millisecond)
+
+	browser, _, err := gorilla.DefaultDialer.Dial(streamURL, nil)
+	require.NoError(t, err)
+	defer browser.Close()
+
+	var msg models.WebSocketMessage
+	agent.SetReadDeadline(time.Now().Add(time.Second))
+	require.NoError(t, agent.ReadJSON(&msg))
+	require.Equal(t, models.MsgTypeLogTailStart, msg.Type)
+	var start models.LogTailStartPayload
+	require.NoError(t, json.Unmarshal(msg.Payload, &start))
+	assert.Equal(t, models.LogSourceLogstash, start.Source)
+	assert.Equal(t, "warn", start.MinLevel)
+	assert.Equal(t, 100, start.MaxRate, "未指定速率时取平台上限")
+
+	sendLines := func(lines models.LogLinesMessage) {
+		payload, err := json.Marshal(lines)
+		require.NoError(t, err)
+		require.NoError(t, agent.WriteJSON(models.WebSocketMessage{Type: models.MsgTypeLogLines, Payload: payload}))
 	}
+	sendLines(models.LogLinesMessage{StreamID: start.StreamID, Lines: []models.LogLine{
+		{Level: "INFO", Message: "Pipeline started"},
+		{Level: "ERROR", Message: "Connection refused"},
+	}})
+
+	var batch models.LogLinesMessage
+	browser.SetReadDeadline(time.Now().Add(time.Second))
+	require.NoError(t, browser.ReadJSON(&batch))
+	require.Len(t, batch.Lines, 1, "平台同样按最低级别过滤")
+	assert.Equal(t, "Connection refused", batch.Lines[0].Message)
+
+	// Agent结束跟踪后浏览器收到原因并关闭连接
+	sendLines(models.LogLinesMessage{StreamID: start.StreamID, Closed: true, Error: "日志文件不存在"})
+	require.NoError(t, browser.ReadJSON(&batch))
+	assert.True(t, batch.Closed)
+	assert.Equal(t, "日志文件不存在", batch.Error)
+	_, _, err = browser.ReadMessage()
+	assert.True(t, gorilla.IsCloseError(err, gorilla.CloseNormalClosure))
 }
 
-// BenchmarkWebSocketHandler 性能测试
-func BenchmarkWebSocketHandler(b *testing.B) {
-	gin.SetMode(gin.TestMode)
-	
+func TestDiagnosticHandler_Run(t *testing.T) {
+	handler, hub, server := newTestWebSocketHandler(t, &MockAgentService{})
 	logger := logrus.New()
-	logger.SetOutput(nil) // 禁用日志输出以获得准确的性能数据
-	
-	handler := WebSocketHandler(logger)
+	logger.SetLevel(logrus.ErrorLevel)
+	runner := service.NewDiagnosticRunner(hub, time.Second, logger)
+	handler.SetDiagnosticRunner(runner)
+
 	router := gin.New()
-	router.GET("/ws", handler)
-	
-	b.ResetTimer()
-	b.RunParallel(func(pb *testing.PB) {
-		for pb.Next() {
-			w := httptest.NewRecorder()
-			req := httptest.NewRequest(http.MethodGet, "/ws", nil)
-			router.ServeHTTP(w, req)
+	router.POST("/agents/:id/diagnostics", NewDiagnosticHandler(runner, logger).Run)
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/agents/agent-1/diagnostics", strings.NewReader(body)))
+		return w
+	}
+
+	// 白名单之外的命令直接拒绝
+	assert.Equal(t, http.StatusBadRequest, post(`{"command":"rm -rf /"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post(`{"command":"tail_log","lines":5000}`).Code)
+	assert.Equal(t, http.StatusConflict, post(`{"command":"logstash_version"}`).Code)
+
+	agent, _, err := gorilla.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws?agent_id=agent-1", nil)
+	require.NoError(t, err)
+	defer agent.Close()
+	require.Eventually(t, func() bool { return hub.IsConnected("agent-1") }, time.Second, 10*time.Millisecond)
+
+	// 模拟Agent执行诊断并回复
+	go func() {
+		var msg models.WebSocketMessage
+		agent.SetReadDeadline(time.Now().Add(time.Second))
+		if err := agent.ReadJSON(&msg); err != nil || msg.Type != models.MsgTypeDiagnosticRequest {
+			return
 		}
-	})
+		var req models.DiagnosticPayload
+		json.Unmarshal(msg.Payload, &req)
+		payload, _ := json.Marshal(models.DiagnosticResult{RequestID: req.RequestID, Command: req.Command, Output: "x\ny"})
+		agent.WriteJSON(models.WebSocketMessage{Type: models.MsgTypeDiagnosticResult, Payload: payload})
+	}()
+
+	w := post(`{"command":"tail_log","lines":2}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	var result models.DiagnosticResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, models.DiagnosticTailLog, result.Command)
+	assert.Equal(t, "x\ny", result.Output)
 }
 
-// TestWebSocketHandler_Integration 集成测试
-func TestWebSocketHandler_Integration(t *testing.T) {
-	gin.SetMode(gin.TestMode)
-	
-	// 创建真实的logger配置
+func TestDLQHandler_ListEvents(t *testing.T) {
+	handler, hub, server := newTestWebSocketHandler(t, &MockAgentService{})
 	logger := logrus.New()
-	logger.SetLevel(logrus.DebugLevel)
-	logger.SetFormatter(&logrus.JSONFormatter{})
-	
-	// 捕获日志
-	var logBuffer bytes.Buffer
-	logger.SetOutput(&logBuffer)
-	
-	// 创建路由
+	logger.SetLevel(logrus.ErrorLevel)
+	inspector := service.NewDLQInspector(hub, time.Second, logger)
+	handler.SetDLQInspector(inspector)
+
 	router := gin.New()
-	router.Use(gin.Recovery())
-	router.GET("/api/ws", WebSocketHandler(logger))
-	
-	t.Run("WebSocket端点集成测试", func(t *testing.T) {
-		// 发送请求
+	router.GET("/agents/:id/dlq", NewDLQHandler(inspector, logger).ListEvents)
+	get := func(query string) *httptest.ResponseRecorder {
 		w := httptest.NewRecorder()
-		req := httptest.NewRequest(http.MethodGet, "/api/ws", nil)
-		req.Header.Set("Upgrade", "websocket")
-		req.Header.Set("Connection", "upgrade")
-		
-		router.ServeHTTP(w, req)
-		
-		// 验证响应
-		assert.Equal(t, http.StatusOK, w.Code)
-		
-		var response map[string]interface{}
-		err := json.Unmarshal(w.Body.Bytes(), &response)
-		assert.NoError(t, err)
-		assert.Equal(t, "WebSocket功能待实现", response["message"])
-		
-		// 验证日志
-		logOutput := logBuffer.String()
-		assert.Contains(t, logOutput, "WebSocket连接请求")
-		
-		// 解析JSON日志验证结构
-		var logEntry map[string]interface{}
-		err = json.Unmarshal([]byte(logOutput), &logEntry)
-		assert.NoError(t, err)
-		assert.Equal(t, "info", logEntry["level"])
-		assert.Equal(t, "WebSocket连接请求", logEntry["msg"])
-	})
-}
\ No newline at end of file
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/agents/agent-1/dlq"+query, nil))
+		return w
+	}
+
+	assert.Equal(t, http.StatusBadRequest, get("").Code)
+	assert.Equal(t, http.StatusBadRequest, get("?pipeline=main&size=500").Code)
+	assert.Equal(t, http.StatusConflict, get("?pipeline=main").Code)
+
+	agent, _, err := gorilla.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws?agent_id=agent-1", nil)
+	require.NoError(t, err)
+	defer agent.Close()
+	require.Eventually(t, func() bool { return hub.IsConnected("agent-1") }, time.Second, 10*time.Millisecond)
+
+	// 模拟Agent读取死信队列并回复
+	requests := make(chan models.DLQPayload, 1)
+	go func() {
+		var msg models.WebSocketMessage
+		agent.SetReadDeadline(time.Now().Add(time.Second))
+		if err := agent.ReadJSON(&msg); err != nil || msg.Type != models.MsgTypeDLQRequest {
+			return
+		}
+		var req models.DLQPayload
+		json.Unmarshal(msg.Payload, &req)
+		requests <- req
+		payload, _ := json.Marshal(models.DLQResult{RequestID: req.RequestID, Pipeline: req.Pipeline, Page: req.Page, PageSize: req.PageSize,
+			Entries: []models.DLQEntry{{Segment: "1.log", Reason: "mapper_parsing_exception", Event: json.RawMessage(`{"message":"x"}`)}}})
+		agent.WriteJSON(models.WebSocketMessage{Type: models.MsgTypeDLQResult, Payload: payload})
+	}()
+
+	w := get("?pipeline=main&page=2")
+	require.Equal(t, http.StatusOK, w.Code)
+	req := <-requests
+	assert.Equal(t, models.DLQRequest{Pipeline: "main", Page: 2, PageSize: models.DefaultDLQPageSize}, req.DLQRequest)
+	var result models.DLQResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	require.Len(t, result.Entries, 1)
+	assert.Equal(t, "mapper_parsing_exception", result.Entries[0].Reason)
+	assert.JSONEq(t, `{"message":"x"}`, string(result.Entries[0].Event))
+}
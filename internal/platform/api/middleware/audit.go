@@ -0,0 +1,206 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+)
+
+// contextSkipAudit 上下文中标记请求不记录审计的键
+const contextSkipAudit = "skip_audit"
+
+// contextOmitAuditBody 上下文中标记审计不记录请求体摘要的键
+const contextOmitAuditBody = "omit_audit_body"
+
+// contextAuditDetail 上下文中保存处理器补充的审计说明的键
+const contextAuditDetail = "audit_detail"
+
+// maxAuditErrorBody 失败响应中为提取错误信息最多保留的字节数
+const maxAuditErrorBody = 4096
+
+// AuditRecorder 审计记录的保存接口
+type AuditRecorder interface {
+	Record(ctx context.Context, entry *models.AuditEntry) error
+}
+
+// SkipAudit 标记路由组内的请求不记录审计，用于Agent上报等高频的非人工操作
+func SkipAudit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(contextSkipAudit, true)
+		c.Next()
+	}
+}
+
+// OmitAuditBody 标记路由组内的请求不记录请求体摘要，用于请求体含密钥值的接口
+// 低熵的密码可由摘要穷举还原，这类请求只记录操作人和目标资源
+func OmitAuditBody() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(contextOmitAuditBody, true)
+		c.Next()
+	}
+}
+
+// SetAuditDetail 补充本次请求的审计说明，例如执行的诊断命令，请求体只记录摘要时据此了解操作内容
+func SetAuditDetail(c *gin.Context, detail string) {
+	c.Set(contextAuditDetail, detail)
+}
+
+// Audit 审计中间件，记录每个变更类请求（非GET/HEAD/OPTIONS）的操作人、目标资源、请求体摘要和结果
+// recorder为nil时不记录；保存失败只记录日志，不影响请求结果
+func Audit(recorder AuditRecorder, logger *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if recorder == nil {
+			c.Next()
+			return
+		}
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		var body []byte
+		if c.Request.Body != nil {
+			var err error
+			body, err = io.ReadAll(c.Request.Body)
+			c.Request.Body.Close()
+			if err != nil {
+				// 处理器读取到已读出的部分后得到同样的错误，例如请求体超过上限
+				c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), failedReader{err: err}))
+				body = nil
+			} else {
+				c.Request.Body = io.NopCloser(bytes.NewReader(body))
+			}
+		}
+
+		writer := &auditResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		// 未匹配路由的请求没有目标资源，不记录
+		route := c.FullPath()
+		if route == "" || c.GetBool(contextSkipAudit) {
+			return
+		}
+
+		// 路由组的标记在处理请求时才设置，摘要在请求结束后计算
+		var digest string
+		if len(body) > 0 && !c.GetBool(contextOmitAuditBody) {
+			sum := sha256.Sum256(body)
+			digest = hex.EncodeToString(sum[:])
+		}
+
+		status := c.Writer.Status()
+		entry := &models.AuditEntry{
+			Timestamp:    start,
+			Actor:        CurrentUserID(c),
+			Role:         c.GetString(ContextUserRole),
+			Action:       auditAction(c.Request.Method, route),
+			Method:       c.Request.Method,
+			Route:        route,
+			Path:         c.Request.URL.Path,
+			ResourceType: auditResourceType(route),
+			ResourceID:   auditResourceID(c),
+			BodyDigest:   digest,
+			Detail:       c.GetString(contextAuditDetail),
+			Status:       status,
+			Outcome:      models.AuditOutcomeSuccess,
+			ClientIP:     c.ClientIP(),
+			LatencyMs:    time.Since(start).Milliseconds(),
+		}
+		if status >= http.StatusBadRequest {
+			entry.Outcome = models.AuditOutcomeFailure
+			entry.Error = auditError(writer.body.Bytes())
+		}
+
+		// 客户端断开时仍需保存审计记录
+		if err := recorder.Record(context.WithoutCancel(c.Request.Context()), entry); err != nil {
+			logger.WithError(err).WithFields(logrus.Fields{
+				"actor": entry.Actor,
+				"route": entry.Route,
+			}).Error("保存审计记录失败")
+		}
+	}
+}
+
+// auditResponseWriter 在响应为失败状态时保留响应体，用于提取错误信息
+type auditResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write 写入响应，失败响应的前若干字节同时写入缓冲区
+func (w *auditResponseWriter) Write(data []byte) (int, error) {
+	if w.Status() >= http.StatusBadRequest && w.body.Len() < maxAuditErrorBody {
+		remaining := maxAuditErrorBody - w.body.Len()
+		if len(data) < remaining {
+			remaining = len(data)
+		}
+		w.body.Write(data[:remaining])
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// auditAction 按请求方法和路由推断操作类型
+func auditAction(method, route string) string {
+	switch {
+	case strings.HasSuffix(route, "/rollback"):
+		return models.AuditActionRollback
+	case strings.HasSuffix(route, "/deploy"):
+		return models.AuditActionDeploy
+	case strings.HasSuffix(route, "/diagnostics"):
+		return models.AuditActionDiagnostic
+	case method == http.MethodDelete:
+		return models.AuditActionDelete
+	case method == http.MethodPut || method == http.MethodPatch:
+		return models.AuditActionUpdate
+	default:
+		return models.AuditActionCreate
+	}
+}
+
+// auditResourceType 路由中 /api/v1 之后的第一级路径作为资源类型
+func auditResourceType(route string) string {
+	route = strings.TrimPrefix(route, "/api/v1")
+	segment, _, _ := strings.Cut(strings.TrimPrefix(route, "/"), "/")
+	return segment
+}
+
+// auditResourceID 路由参数中的资源标识，多个参数时按路由中的顺序以/连接
+func auditResourceID(c *gin.Context) string {
+	values := make([]string, 0, len(c.Params))
+	for _, param := range c.Params {
+		values = append(values, param.Value)
+	}
+	return strings.Join(values, "/")
+}
+
+// auditError 从统一错误响应中提取错误信息
+func auditError(body []byte) string {
+	var resp ErrorResponse
+	if err := json.Unmarshal(body, &resp); err != nil || resp.Code == "" {
+		return ""
+	}
+	return resp.Code + ": " + resp.Message
+}
+
+// failedReader 读取时返回err
+type failedReader struct {
+	err error
+}
+
+func (r failedReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
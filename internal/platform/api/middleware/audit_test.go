@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+)
+
+type recordingAuditor struct {
+	entries []*models.AuditEntry
+	err     error
+}
+
+func (r *recordingAuditor) Record(ctx context.Context, entry *models.AuditEntry) error {
+	r.entries = append(r.entries, entry)
+	return r.err
+}
+
+func newAuditRouter(auditor AuditRecorder) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	router := gin.New()
+	v1 := router.Group("/api/v1", func(c *gin.Context) {
+		c.Set(ContextUserID, "alice")
+		c.Set(ContextUserRole, models.RoleEditor)
+		c.Next()
+	}, Audit(auditor, logger))
+
+	v1.GET("/configs/:id", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{}) })
+	v1.PUT("/configs/:id", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.JSON(http.StatusOK, gin.H{"body": string(body)})
+	})
+	v1.POST("/configs/:id/rollback", func(c *gin.Context) {
+		AbortWithError(c, apperror.New(apperror.NotFound, "配置不存在"))
+	})
+	v1.DELETE("/groups/:name", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	v1.POST("/agents/:id/heartbeat", SkipAudit(), func(c *gin.Context) { c.Status(http.StatusOK) })
+	v1.PUT("/secrets/:name", OmitAuditBody(), func(c *gin.Context) { c.Status(http.StatusOK) })
+	v1.POST("/agents/:id/diagnostics", func(c *gin.Context) {
+		SetAuditDetail(c, "logstash_version")
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func TestAudit(t *testing.T) {
+	t.Run("records mutating request with body digest", func(t *testing.T) {
+		auditor := &recordingAuditor{}
+		router := newAuditRouter(auditor)
+
+		body := `{"content":"input {}"}`
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/v1/configs/c1", strings.NewReader(body)))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "input {}", "处理器仍能读取请求体")
+		require.Len(t, auditor.entries, 1)
+
+		entry := auditor.entries[0]
+		sum := sha256.Sum256([]byte(body))
+		assert.Equal(t, "alice", entry.Actor)
+		assert.Equal(t, models.RoleEditor, entry.Role)
+		assert.Equal(t, models.AuditActionUpdate, entry.Action)
+		assert.Equal(t, "/api/v1/configs/:id", entry.Route)
+		assert.Equal(t, "configs", entry.ResourceType)
+		assert.Equal(t, "c1", entry.ResourceID)
+		assert.Equal(t, hex.EncodeToString(sum[:]), entry.BodyDigest)
+		assert.Equal(t, models.AuditOutcomeSuccess, entry.Outcome)
+		assert.False(t, entry.Timestamp.IsZero())
+	})
+
+	t.Run("records failure with error message", func(t *testing.T) {
+		auditor := &recordingAuditor{}
+		router := newAuditRouter(auditor)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/configs/c1/rollback", nil))
+
+		require.Len(t, auditor.entries, 1)
+		entry := auditor.entries[0]
+		assert.Equal(t, models.AuditActionRollback, entry.Action)
+		assert.Equal(t, http.StatusNotFound, entry.Status)
+		assert.Equal(t, models.AuditOutcomeFailure, entry.Outcome)
+		assert.Equal(t, "NOT_FOUND: 配置不存在", entry.Error)
+		assert.Empty(t, entry.BodyDigest)
+	})
+
+	t.Run("skips reads, skipped routes and unmatched paths", func(t *testing.T) {
+		auditor := &recordingAuditor{}
+		router := newAuditRouter(auditor)
+
+		for _, req := range []*http.Request{
+			httptest.NewRequest(http.MethodGet, "/api/v1/configs/c1", nil),
+			httptest.NewRequest(http.MethodPost, "/api/v1/agents/a1/heartbeat", nil),
+			httptest.NewRequest(http.MethodPost, "/api/v1/unknown", nil),
+		} {
+			router.ServeHTTP(httptest.NewRecorder(), req)
+		}
+		assert.Empty(t, auditor.entries)
+	})
+
+	t.Run("omits body digest for secret values", func(t *testing.T) {
+		auditor := &recordingAuditor{}
+		router := newAuditRouter(auditor)
+
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/api/v1/secrets/es_password", strings.NewReader(`{"value":"changeme"}`)))
+
+		require.Len(t, auditor.entries, 1)
+		assert.Equal(t, "secrets", auditor.entries[0].ResourceType)
+		assert.Equal(t, "es_password", auditor.entries[0].ResourceID)
+		assert.Empty(t, auditor.entries[0].BodyDigest)
+	})
+
+	t.Run("records diagnostic command as detail", func(t *testing.T) {
+		auditor := &recordingAuditor{}
+		router := newAuditRouter(auditor)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/agents/agent-1/diagnostics", strings.NewReader(`{"command":"logstash_version"}`)))
+
+		require.Len(t, auditor.entries, 1)
+		assert.Equal(t, models.AuditActionDiagnostic, auditor.entries[0].Action)
+		assert.Equal(t, "logstash_version", auditor.entries[0].Detail)
+		assert.Equal(t, "agent-1", auditor.entries[0].ResourceID)
+	})
+
+	t.Run("record failure does not affect response", func(t *testing.T) {
+		auditor := &recordingAuditor{err: errors.New("es unavailable")}
+		router := newAuditRouter(auditor)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/v1/groups/web", nil))
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		require.Len(t, auditor.entries, 1)
+		assert.Equal(t, models.AuditActionDelete, auditor.entries[0].Action)
+		assert.Equal(t, "groups", auditor.entries[0].ResourceType)
+		assert.Equal(t, "web", auditor.entries[0].ResourceID)
+	})
+}
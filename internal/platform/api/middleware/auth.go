@@ -1,14 +1,221 @@
 package middleware
 
 import (
+	"context"
+	"crypto/x509"
+	"net/http"
+	"strings"
+
 	"github.com/gin-gonic/gin"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/agentauth"
+)
+
+// 上下文中保存身份信息的键
+const (
+	ContextUserID   = "user_id"
+	ContextUserRole = "user_role"
+	ContextAgentID  = "agent_id" // 注册令牌绑定的Agent
+
+	ContextProjectRoles = "project_roles" // 令牌中的项目角色
+	ContextProject      = "project"       // 请求所属的项目
 )
 
+// DefaultUserID 未启用认证时记录的操作人
+const DefaultUserID = "admin"
+
+// TokenVerifier 访问令牌校验接口
+type TokenVerifier interface {
+	VerifyToken(ctx context.Context, token string) (*models.TokenClaims, error)
+}
+
 // AuthorizeWebSocket WebSocket认证中间件
+// 使用注册令牌连接时，查询参数 agent_id 必须是令牌绑定的Agent
 func AuthorizeWebSocket() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// TODO: 实现WebSocket认证逻辑
-		// 暂时允许所有连接
+		if agentID := c.GetString(ContextAgentID); agentID != "" && c.Query("agent_id") != agentID {
+			AbortWithError(c, apperror.New(apperror.Forbidden, "令牌与Agent ID不匹配"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireAgentIdentity 使用注册令牌访问时，路径中的Agent ID必须是令牌绑定的Agent
+func RequireAgentIdentity() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		agentID := c.GetString(ContextAgentID)
+		if id := c.Param("id"); agentID != "" && id != "" && id != agentID {
+			AbortWithError(c, apperror.New(apperror.Forbidden, "令牌与Agent ID不匹配"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireEnrolledAgent required为true时，Agent身份必须来自注册令牌，拒绝共享令牌
+// 注册令牌申请接口本身不使用该中间件，共享令牌仍可作为引导令牌申请注册令牌
+func RequireEnrolledAgent(required bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if required && c.GetString(ContextUserRole) == models.RoleAgent && c.GetString(ContextAgentID) == "" {
+			AbortWithError(c, apperror.New(apperror.Unauthorized, "需要使用Agent注册令牌"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// PeerVerifier 客户端证书校验接口
+type PeerVerifier interface {
+	// VerifyPeer 检查TLS层已校验过证书链的客户端证书是否仍然可用，返回证书绑定的Agent ID
+	VerifyPeer(ctx context.Context, cert *x509.Certificate) (string, error)
+}
+
+// RequireClientCertificate Agent须出示平台CA签发的客户端证书，证书绑定的Agent须与令牌绑定的Agent一致
+// verifier为nil时表示未启用客户端证书；required为false时放行未出示证书的请求，便于Agent逐步切换，出示的证书仍须有效
+// 使用共享令牌访问时以证书绑定的Agent作为请求的Agent身份，由后续中间件检查路径和查询参数中的Agent ID
+func RequireClientCertificate(verifier PeerVerifier, required bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if role := c.GetString(ContextUserRole); verifier == nil || (role != "" && role != models.RoleAgent) {
+			c.Next()
+			return
+		}
+
+		tlsState := c.Request.TLS
+		if tlsState == nil || len(tlsState.VerifiedChains) == 0 {
+			if required {
+				AbortWithError(c, apperror.New(apperror.Unauthorized, "需要平台签发的客户端证书"))
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
+		certAgentID, err := verifier.VerifyPeer(c.Request.Context(), tlsState.VerifiedChains[0][0])
+		if err != nil {
+			AbortWithError(c, apperror.New(apperror.Unauthorized, err.Error()))
+			c.Abort()
+			return
+		}
+		if agentID := c.GetString(ContextAgentID); agentID != "" && agentID != certAgentID {
+			AbortWithError(c, apperror.New(apperror.Forbidden, "客户端证书与Agent ID不匹配"))
+			c.Abort()
+			return
+		}
+		c.Set(ContextAgentID, certAgentID)
+		c.Next()
+	}
+}
+
+// Authenticate 校验Bearer令牌并将用户身份写入上下文
+// verifier为nil时表示未启用认证，所有请求按管理员处理
+func Authenticate(verifier TokenVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if verifier == nil {
+			c.Next()
+			return
+		}
+
+		token, ok := bearerToken(c)
+		if !ok || token == "" {
+			AbortWithError(c, apperror.New(apperror.Unauthorized, "缺少访问令牌"))
+			c.Abort()
+			return
+		}
+
+		claims, err := verifier.VerifyToken(c.Request.Context(), token)
+		if err != nil {
+			AbortWithError(c, apperror.New(apperror.Unauthorized, err.Error()))
+			c.Abort()
+			return
+		}
+
+		c.Set(ContextUserID, claims.Subject)
+		c.Set(ContextUserRole, claims.Role)
+		c.Set(ContextProjectRoles, claims.ProjectRoles)
+		// 服务层按请求身份检查配置级访问控制
+		c.Request = c.Request.WithContext(models.WithPrincipal(c.Request.Context(), &models.Principal{
+			User:         claims.Subject,
+			Role:         claims.Role,
+			PlatformRole: claims.Role,
+			Teams:        claims.Teams,
+		}))
+		if claims.AgentID != "" {
+			c.Set(ContextAgentID, claims.AgentID)
+			// 向Agent证明平台持有其令牌记录
+			if nonce := c.GetHeader(agentauth.NonceHeader); nonce != "" && len(claims.ProofKey) > 0 {
+				c.Header(agentauth.ProofHeader, agentauth.Proof(claims.ProofKey, nonce))
+			}
+		}
+		c.Next()
+	}
+}
+
+// AccessTokenQuery 浏览器发起WebSocket连接时无法设置请求头，令牌经该查询参数传递
+const AccessTokenQuery = "access_token"
+
+// bearerToken 读取Authorization请求头中的Bearer令牌，WebSocket升级请求没有请求头时读取查询参数
+func bearerToken(c *gin.Context) (string, bool) {
+	if header := c.GetHeader("Authorization"); header != "" || !isWebSocketUpgrade(c.Request) {
+		return strings.CutPrefix(header, "Bearer ")
+	}
+	token := c.Query(AccessTokenQuery)
+	return token, token != ""
+}
+
+// isWebSocketUpgrade 请求是否为WebSocket升级请求
+func isWebSocketUpgrade(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
+
+// RequireRole 要求当前用户至少具备指定角色
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !roleAllowed(c, role) {
+			AbortWithError(c, apperror.New(apperror.Forbidden, "权限不足，需要"+role+"角色"))
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// RequireRoleByMethod 读请求（GET/HEAD）要求readRole，其余请求要求writeRole
+func RequireRoleByMethod(readRole, writeRole string) gin.HandlerFunc {
+	read := RequireRole(readRole)
+	write := RequireRole(writeRole)
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			read(c)
+			return
+		}
+		write(c)
+	}
+}
+
+// CurrentAgentID 获取注册令牌或客户端证书绑定的Agent，共享令牌和用户令牌返回空
+func CurrentAgentID(c *gin.Context) string {
+	return c.GetString(ContextAgentID)
+}
+
+// CurrentUserID 获取当前操作人，未启用认证时返回DefaultUserID
+func CurrentUserID(c *gin.Context) string {
+	if userID := c.GetString(ContextUserID); userID != "" {
+		return userID
+	}
+	return DefaultUserID
+}
+
+// roleAllowed 判断当前请求的角色是否满足要求
+// 上下文中没有身份信息说明未启用认证，直接放行
+func roleAllowed(c *gin.Context, required string) bool {
+	if _, authenticated := c.Get(ContextUserID); !authenticated {
+		return true
+	}
+	return models.RoleAllows(c.GetString(ContextUserRole), required)
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"logstash-platform/internal/platform/apperror"
+)
+
+// BodyLimits 请求体大小上限（字节）
+type BodyLimits struct {
+	Default int64            // 未匹配Routes时的上限，<=0表示不限制
+	Routes  map[string]int64 // 按路径前缀覆盖默认上限，最长的前缀优先，<=0表示不限制
+}
+
+// limitFor 请求路径适用的上限
+func (l BodyLimits) limitFor(path string) int64 {
+	limit, matched := l.Default, ""
+	for prefix, routeLimit := range l.Routes {
+		if len(prefix) > len(matched) && strings.HasPrefix(path, prefix) {
+			limit, matched = routeLimit, prefix
+		}
+	}
+	return limit
+}
+
+// BodyLimit 限制请求体大小
+// 声明的 Content-Length 超过上限时直接返回413；未声明长度的请求读取超过上限时读取失败，绑定请求时同样返回413
+func BodyLimit(limits BodyLimits) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := limits.limitFor(c.Request.URL.Path)
+		if limit <= 0 || c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+		if c.Request.ContentLength > limit {
+			AbortWithError(c, payloadTooLarge(limit))
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
+
+// payloadTooLarge 请求体超过上限的错误
+func payloadTooLarge(limit int64) *apperror.Error {
+	return apperror.New(apperror.PayloadTooLarge, fmt.Sprintf("请求体超过上限 %d 字节", limit))
+}
@@ -0,0 +1,176 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultCompressMinSize 响应体小于该字节数时不压缩，压缩小响应得不偿失
+const DefaultCompressMinSize = 1024
+
+// gzipWriterPool 复用gzip压缩器，避免每个请求重新分配压缩状态
+var gzipWriterPool = sync.Pool{
+	New: func() interface{} { return gzip.NewWriter(nil) },
+}
+
+// Compress 客户端接受gzip时压缩响应体
+// 先缓冲响应体，达到minSize后才开始压缩，minSize<=0时使用 DefaultCompressMinSize；
+// 只压缩文本、JSON、YAML等可压缩的内容类型，WebSocket升级请求和已设置 Content-Encoding 的响应保持原样
+func Compress(minSize int) gin.HandlerFunc {
+	if minSize <= 0 {
+		minSize = DefaultCompressMinSize
+	}
+	return func(c *gin.Context) {
+		if !acceptsGzip(c.Request) || isWebSocketUpgrade(c.Request) || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		w := &gzipResponseWriter{ResponseWriter: c.Writer, minSize: minSize}
+		c.Writer = w
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		defer func() {
+			w.finish()
+			c.Writer = w.ResponseWriter
+		}()
+		c.Next()
+	}
+}
+
+// acceptsGzip 请求的 Accept-Encoding 是否接受gzip（q=0表示拒绝）
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			continue
+		}
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if q, err := strconv.ParseFloat(v, 64); err == nil && q == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// compressibleContentType 内容类型是否值得压缩
+func compressibleContentType(contentType string) bool {
+	mediaType, _, _ := strings.Cut(strings.ToLower(contentType), ";")
+	mediaType = strings.TrimSpace(mediaType)
+	if strings.HasPrefix(mediaType, "text/") && mediaType != "text/event-stream" {
+		return true
+	}
+	for _, marker := range []string{"json", "javascript", "xml", "yaml"} {
+		if strings.Contains(mediaType, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// gzipResponseWriter 缓冲响应体，超过阈值后以gzip写出
+type gzipResponseWriter struct {
+	gin.ResponseWriter
+	minSize int
+	buf     []byte
+	gz      *gzip.Writer
+	decided bool // 是否已决定压缩与否，此后不再缓冲
+}
+
+// Write 写入响应体
+func (w *gzipResponseWriter) Write(data []byte) (int, error) {
+	if w.decided {
+		if w.gz != nil {
+			return w.gz.Write(data)
+		}
+		return w.ResponseWriter.Write(data)
+	}
+
+	w.buf = append(w.buf, data...)
+	if len(w.buf) >= w.minSize {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+// WriteString 写入字符串响应体
+func (w *gzipResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Written 缓冲中的响应体同样视为已写出，避免后续中间件再次写入响应
+func (w *gzipResponseWriter) Written() bool {
+	return len(w.buf) > 0 || w.ResponseWriter.Written()
+}
+
+// Size 已写出的响应体字节数，压缩时为压缩后的字节数
+func (w *gzipResponseWriter) Size() int {
+	if !w.decided && len(w.buf) > 0 {
+		return len(w.buf)
+	}
+	return w.ResponseWriter.Size()
+}
+
+// Flush 流式响应在刷新时立即决定是否压缩并写出缓冲内容
+func (w *gzipResponseWriter) Flush() {
+	if !w.decided {
+		if err := w.decide(true); err != nil {
+			return
+		}
+	}
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide 决定是否压缩并写出缓冲的内容；compress为false或响应不适合压缩时原样写出
+func (w *gzipResponseWriter) decide(compress bool) error {
+	w.decided = true
+	header := w.Header()
+	// 响应头已经写出时无法再声明 Content-Encoding
+	if compress && (w.ResponseWriter.Written() || header.Get("Content-Encoding") != "" ||
+		!compressibleContentType(header.Get("Content-Type"))) {
+		compress = false
+	}
+
+	if compress {
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length")
+		w.gz = gzipWriterPool.Get().(*gzip.Writer)
+		w.gz.Reset(w.ResponseWriter)
+	}
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.gz != nil {
+		_, err = w.gz.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// finish 请求处理结束，写出未达到阈值的缓冲内容或结束压缩流
+func (w *gzipResponseWriter) finish() {
+	if !w.decided {
+		_ = w.decide(false)
+	}
+	if w.gz != nil {
+		_ = w.gz.Close()
+		gzipWriterPool.Put(w.gz)
+		w.gz = nil
+	}
+}
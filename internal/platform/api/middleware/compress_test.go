@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/apperror"
+)
+
+func TestCompress(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	large := strings.Repeat("input { stdin {} } ", 200)
+	router := gin.New()
+	router.Use(Compress(512), ErrorHandler())
+	router.GET("/large", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"content": large}) })
+	router.GET("/small", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"content": "filter { }"}) })
+	router.GET("/binary", func(c *gin.Context) { c.Data(http.StatusOK, "application/octet-stream", []byte(large)) })
+	router.GET("/error", func(c *gin.Context) { AbortWithError(c, apperror.New(apperror.NotFound, "配置不存在")) })
+
+	get := func(path, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("large json is compressed", func(t *testing.T) {
+		w := get("/large", "gzip, deflate")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		assert.Contains(t, w.Header().Values("Vary"), "Accept-Encoding")
+		assert.Less(t, w.Body.Len(), len(large))
+
+		gz, err := gzip.NewReader(w.Body)
+		require.NoError(t, err)
+		body, err := io.ReadAll(gz)
+		require.NoError(t, err)
+		assert.Contains(t, string(body), "input { stdin {} }")
+	})
+
+	t.Run("client without gzip", func(t *testing.T) {
+		w := get("/large", "")
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Contains(t, w.Body.String(), "input { stdin {} }")
+
+		w = get("/large", "gzip;q=0")
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+	})
+
+	t.Run("small response is not compressed", func(t *testing.T) {
+		w := get("/small", "gzip")
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.JSONEq(t, `{"content":"filter { }"}`, w.Body.String())
+	})
+
+	t.Run("incompressible content type", func(t *testing.T) {
+		w := get("/binary", "gzip")
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, large, w.Body.String())
+	})
+
+	t.Run("error response written once", func(t *testing.T) {
+		w := get("/error", "gzip")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		var problem ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+		assert.Equal(t, "NOT_FOUND", problem.Code)
+	})
+}
@@ -4,57 +4,87 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	"logstash-platform/internal/platform/apperror"
 )
 
-// ErrorResponse 错误响应
+// ProblemContentType 错误响应的内容类型
+const ProblemContentType = "application/problem+json"
+
+// ProblemTypeBase 错误类型URI的前缀，后接错误码，对应 GET /api/v1/errors 返回的错误码目录
+const ProblemTypeBase = "/api/v1/errors#"
+
+// ErrorResponse 错误响应，格式为 RFC 7807 problem+json
+// code、message、errors 为扩展成员，message 与 detail 相同，保留给按旧格式解析的调用方
 type ErrorResponse struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
-	Details string `json:"details,omitempty"`
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     string       `json:"code"`
+	Message  string       `json:"message"`
+	Errors   []FieldError `json:"errors,omitempty"` // 请求绑定或校验失败时逐字段的原因
 }
 
-// ErrorHandler 错误处理中间件
+// ErrorHandler 错误处理中间件，处理器经 c.Error 记录的错误按错误码目录返回
 func ErrorHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Next()
 
-		// 处理错误
-		if len(c.Errors) > 0 {
-			err := c.Errors.Last()
-			
-			// 根据错误类型返回不同的状态码
-			var statusCode int
-			var code string
-			
-			switch err.Type {
-			case gin.ErrorTypeBind:
-				statusCode = http.StatusBadRequest
-				code = "INVALID_REQUEST"
-			case gin.ErrorTypePublic:
-				statusCode = http.StatusBadRequest
-				code = "BAD_REQUEST"
-			default:
-				statusCode = http.StatusInternalServerError
-				code = "INTERNAL_ERROR"
-			}
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		err := c.Errors.Last()
 
-			// 设置状态码
-			if c.Writer.Status() == http.StatusOK {
-				c.Status(statusCode)
+		switch err.Type {
+		case gin.ErrorTypeBind:
+			if problem := apperror.From(err.Err); problem.Code == apperror.PayloadTooLarge {
+				writeProblem(c, problem.Code.Status(), problem, nil)
+				return
 			}
-
-			c.JSON(c.Writer.Status(), ErrorResponse{
-				Code:    code,
-				Message: err.Error(),
-			})
+			problem := apperror.New(apperror.InvalidRequest, err.Error())
+			writeProblem(c, problem.Code.Status(), problem, TranslateBindError(err.Err, RequestLanguage(c)))
+		case gin.ErrorTypePublic:
+			problem := apperror.New(apperror.BadRequest, err.Error())
+			writeProblem(c, problem.Code.Status(), problem, nil)
+		default:
+			problem := apperror.From(err.Err)
+			if problem.Code != apperror.Internal {
+				writeProblem(c, problem.Code.Status(), problem, nil)
+				return
+			}
+			// 未带错误码的错误沿用错误信息作为说明，处理器已设置的状态码保持不变
+			status := problem.Code.Status()
+			if c.Writer.Status() != http.StatusOK {
+				status = c.Writer.Status()
+			}
+			writeProblem(c, status, &apperror.Error{Code: apperror.Internal, Message: err.Error(), Cause: err.Err}, nil)
 		}
 	}
 }
 
-// HandleError 处理错误的辅助函数
-func HandleError(c *gin.Context, statusCode int, code, message string) {
-	c.AbortWithStatusJSON(statusCode, ErrorResponse{
-		Code:    code,
-		Message: message,
-	})
-}
\ No newline at end of file
+// AbortWithError 按错误码目录返回错误响应并中止处理，未带错误码的错误按原因归类
+func AbortWithError(c *gin.Context, err error) {
+	problem := apperror.From(err)
+	writeProblem(c, problem.Code.Status(), problem, nil)
+	c.Abort()
+}
+
+// writeProblem 写入problem+json错误响应
+func writeProblem(c *gin.Context, status int, err *apperror.Error, fields []FieldError) {
+	problem := ErrorResponse{
+		Type:    ProblemTypeBase + string(err.Code),
+		Title:   err.Code.Title(),
+		Status:  status,
+		Detail:  err.Message,
+		Code:    string(err.Code),
+		Message: err.Message,
+		Errors:  fields,
+	}
+	if c.Request != nil {
+		problem.Instance = c.Request.URL.Path
+	}
+	c.Header("Content-Type", ProblemContentType)
+	c.JSON(status, problem)
+}
@@ -3,12 +3,16 @@ package middleware
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/pkg/elasticsearch"
 )
 
 func TestErrorHandler(t *testing.T) {
@@ -43,7 +47,8 @@ func TestErrorHandler(t *testing.T) {
 			checkResponse: func(t *testing.T, resp map[string]interface{}) {
 				assert.Equal(t, "INVALID_REQUEST", resp["code"])
 				assert.Equal(t, "invalid json", resp["message"])
-				assert.Nil(t, resp["details"])
+				assert.Equal(t, "/api/v1/errors#INVALID_REQUEST", resp["type"])
+				assert.Equal(t, float64(http.StatusBadRequest), resp["status"])
 			},
 		},
 		{
@@ -141,91 +146,70 @@ func TestErrorHandler(t *testing.T) {
 	}
 }
 
-func TestHandleError(t *testing.T) {
+func TestAbortWithError(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	tests := []struct {
 		name           string
-		statusCode     int
-		code           string
-		message        string
+		err            error
 		expectedStatus int
-		expectedBody   ErrorResponse
+		expectedCode   string
+		expectedMsg    string
 	}{
 		{
-			name:           "bad request error",
-			statusCode:     http.StatusBadRequest,
-			code:           "INVALID_PARAM",
-			message:        "参数错误",
-			expectedStatus: http.StatusBadRequest,
-			expectedBody: ErrorResponse{
-				Code:    "INVALID_PARAM",
-				Message: "参数错误",
-			},
+			name:           "按错误码返回状态码",
+			err:            apperror.New(apperror.NotFound, "配置不存在"),
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "NOT_FOUND",
+			expectedMsg:    "配置不存在",
 		},
 		{
-			name:           "unauthorized error",
-			statusCode:     http.StatusUnauthorized,
-			code:           "UNAUTHORIZED",
-			message:        "未授权访问",
-			expectedStatus: http.StatusUnauthorized,
-			expectedBody: ErrorResponse{
-				Code:    "UNAUTHORIZED",
-				Message: "未授权访问",
-			},
+			name:           "业务错误码",
+			err:            apperror.New(apperror.TestGateFailed, "最近一次测试未通过"),
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedCode:   "TEST_GATE_FAILED",
+			expectedMsg:    "最近一次测试未通过",
 		},
 		{
-			name:           "not found error",
-			statusCode:     http.StatusNotFound,
-			code:           "NOT_FOUND",
-			message:        "资源不存在",
-			expectedStatus: http.StatusNotFound,
-			expectedBody: ErrorResponse{
-				Code:    "NOT_FOUND",
-				Message: "资源不存在",
-			},
+			name:           "包装的Elasticsearch不可用",
+			err:            apperror.Wrap(fmt.Errorf("搜索失败: %w", elasticsearch.ErrUnavailable), "获取配置列表失败"),
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedCode:   "ES_UNAVAILABLE",
+			expectedMsg:    "获取配置列表失败",
 		},
 		{
-			name:           "internal server error",
-			statusCode:     http.StatusInternalServerError,
-			code:           "INTERNAL_ERROR",
-			message:        "服务器内部错误",
+			name:           "未带错误码的错误不返回细节",
+			err:            errors.New("database connection failed"),
 			expectedStatus: http.StatusInternalServerError,
-			expectedBody: ErrorResponse{
-				Code:    "INTERNAL_ERROR",
-				Message: "服务器内部错误",
-			},
-		},
-		{
-			name:           "custom error code",
-			statusCode:     http.StatusForbidden,
-			code:           "PERMISSION_DENIED",
-			message:        "权限不足",
-			expectedStatus: http.StatusForbidden,
-			expectedBody: ErrorResponse{
-				Code:    "PERMISSION_DENIED",
-				Message: "权限不足",
-			},
+			expectedCode:   "INTERNAL_ERROR",
+			expectedMsg:    "平台内部错误",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Setup
 			w := httptest.NewRecorder()
 			c, _ := gin.CreateTestContext(w)
-			
-			// Execute
-			HandleError(c, tt.statusCode, tt.code, tt.message)
-			
-			// Assert
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/configs/c1", nil)
+
+			AbortWithError(c, tt.err)
+
 			assert.Equal(t, tt.expectedStatus, w.Code)
 			assert.True(t, c.IsAborted())
-			
+			assert.Equal(t, ProblemContentType, w.Header().Get("Content-Type"))
+
 			var response ErrorResponse
 			err := json.Unmarshal(w.Body.Bytes(), &response)
 			assert.NoError(t, err)
-			assert.Equal(t, tt.expectedBody, response)
+			assert.Equal(t, ErrorResponse{
+				Type:     "/api/v1/errors#" + tt.expectedCode,
+				Title:    apperror.Code(tt.expectedCode).Title(),
+				Status:   tt.expectedStatus,
+				Detail:   tt.expectedMsg,
+				Instance: "/api/v1/configs/c1",
+				Code:     tt.expectedCode,
+				Message:  tt.expectedMsg,
+			}, response)
 		})
 	}
 }
@@ -263,21 +247,32 @@ func TestErrorResponseSerialization(t *testing.T) {
 		expected string
 	}{
 		{
-			name: "with details",
+			name: "with field errors",
 			response: ErrorResponse{
-				Code:    "ERROR_CODE",
-				Message: "Error message",
-				Details: "Additional details",
+				Type:    "/api/v1/errors#INVALID_REQUEST",
+				Title:   "请求参数无效",
+				Status:  http.StatusBadRequest,
+				Detail:  "请求参数无效",
+				Code:    "INVALID_REQUEST",
+				Message: "请求参数无效",
+				Errors:  []FieldError{{Field: "name", Rule: "required", Message: "name为必填字段"}},
 			},
-			expected: `{"code":"ERROR_CODE","message":"Error message","details":"Additional details"}`,
+			expected: `{"type":"/api/v1/errors#INVALID_REQUEST","title":"请求参数无效","status":400,"detail":"请求参数无效",` +
+				`"code":"INVALID_REQUEST","message":"请求参数无效","errors":[{"field":"name","rule":"required","message":"name为必填字段"}]}`,
 		},
 		{
-			name: "without details",
+			name: "without field errors",
 			response: ErrorResponse{
-				Code:    "ERROR_CODE",
-				Message: "Error message",
+				Type:     "/api/v1/errors#NOT_FOUND",
+				Title:    "资源不存在",
+				Status:   http.StatusNotFound,
+				Detail:   "配置不存在",
+				Instance: "/api/v1/configs/c1",
+				Code:     "NOT_FOUND",
+				Message:  "配置不存在",
 			},
-			expected: `{"code":"ERROR_CODE","message":"Error message"}`,
+			expected: `{"type":"/api/v1/errors#NOT_FOUND","title":"资源不存在","status":404,"detail":"配置不存在",` +
+				`"instance":"/api/v1/configs/c1","code":"NOT_FOUND","message":"配置不存在"}`,
 		},
 	}
 
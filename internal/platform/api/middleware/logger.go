@@ -1,10 +1,13 @@
 package middleware
 
 import (
+	"net/url"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
+
+	"logstash-platform/internal/platform/tracing"
 )
 
 // Logger 日志中间件
@@ -12,7 +15,7 @@ func Logger(logger *logrus.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 		path := c.Request.URL.Path
-		raw := c.Request.URL.RawQuery
+		raw := redactQuery(c.Request.URL.RawQuery)
 
 		// 处理请求
 		c.Next()
@@ -39,6 +42,10 @@ func Logger(logger *logrus.Logger) gin.HandlerFunc {
 			"latency":    latency,
 			"user_agent": c.Request.UserAgent(),
 		})
+		// 启用链路追踪时按trace ID关联日志与链路
+		if traceID := tracing.TraceID(c.Request.Context()); traceID != "" {
+			entry = entry.WithField("trace_id", traceID)
+		}
 
 		if len(c.Errors) > 0 {
 			entry.Error(c.Errors.String())
@@ -46,4 +53,17 @@ func Logger(logger *logrus.Logger) gin.HandlerFunc {
 			entry.Info("HTTP请求")
 		}
 	}
-}
\ No newline at end of file
+}
+
+// redactQuery 隐去查询参数中的访问令牌
+func redactQuery(raw string) string {
+	if raw == "" {
+		return raw
+	}
+	values, err := url.ParseQuery(raw)
+	if err != nil || values.Get(AccessTokenQuery) == "" {
+		return raw
+	}
+	values.Set(AccessTokenQuery, "REDACTED")
+	return values.Encode()
+}
@@ -65,6 +65,20 @@ func TestLogger(t *testing.T) {
 			},
 			shouldLog: true,
 		},
+		{
+			name:   "access token is redacted",
+			method: "GET",
+			path:   "/api/v1/agents/agent-1/logs/stream",
+			query:  "access_token=secret.jwt&level=warn",
+			setupHandler: func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"status": "ok"})
+			},
+			expectedLevel: "info",
+			checkLog: func(t *testing.T, fields map[string]interface{}) {
+				assert.Equal(t, "/api/v1/agents/agent-1/logs/stream?access_token=REDACTED&level=warn", fields["path"])
+			},
+			shouldLog: true,
+		},
 		{
 			name:      "request with user agent",
 			method:    "GET",
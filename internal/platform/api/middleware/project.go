@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"logstash-platform/internal/platform/apperror"
+	"logstash-platform/internal/platform/models"
+)
+
+// ProjectHeader 指定请求所属项目的请求头，也可以使用查询参数 project，均未指定时为默认项目
+const ProjectHeader = "X-Project"
+
+// ProjectScope 确定请求所属的项目，并将当前角色替换为用户在该项目内的有效角色
+// 需放在 Authenticate 之后、RequireRole 之前；Agent身份不受项目限制，按Agent ID访问自身资源
+func ProjectScope() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString(ContextUserRole) == models.RoleAgent {
+			c.Next()
+			return
+		}
+
+		project := c.GetHeader(ProjectHeader)
+		if project == "" {
+			project = c.Query("project")
+		}
+		project = models.ProjectOf(project)
+		if !models.ProjectNamePattern.MatchString(project) {
+			AbortWithError(c, apperror.New(apperror.InvalidProject, "项目名称无效"))
+			c.Abort()
+			return
+		}
+
+		ctx := models.WithProject(c.Request.Context(), project)
+		if _, authenticated := c.Get(ContextUserID); authenticated {
+			value, _ := c.Get(ContextProjectRoles)
+			projectRoles, _ := value.(map[string]string)
+			role := models.ProjectRole(c.GetString(ContextUserRole), projectRoles, project)
+			if role == "" {
+				AbortWithError(c, apperror.New(apperror.Forbidden, "无权访问项目"+project))
+				c.Abort()
+				return
+			}
+			c.Set(ContextUserRole, role)
+			// 配置级访问控制按项目内角色判断管理员身份
+			if p := models.PrincipalFrom(ctx); p != nil {
+				scoped := *p
+				scoped.Role = role
+				ctx = models.WithPrincipal(ctx, &scoped)
+			}
+		}
+
+		c.Set(ContextProject, project)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
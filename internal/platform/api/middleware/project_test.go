@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"logstash-platform/internal/platform/models"
+)
+
+func TestProjectScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	verifier := staticVerifier{
+		"admin-token":  {Subject: "root", Role: models.RoleAdmin, ProjectRoles: map[string]string{"payments": models.RoleViewer}},
+		"global-token": {Subject: "alice", Role: models.RoleEditor},
+		"scoped-token": {Subject: "bob", Role: models.RoleViewer, ProjectRoles: map[string]string{"payments": models.RoleEditor}},
+		"agent-token":  {Subject: "agent", Role: models.RoleAgent},
+	}
+
+	router := gin.New()
+	router.Use(Authenticate(verifier), ProjectScope(), RequireRoleByMethod(models.RoleViewer, models.RoleEditor))
+	handler := func(c *gin.Context) {
+		p := models.PrincipalFrom(c.Request.Context())
+		c.String(http.StatusOK, models.ProjectFrom(c.Request.Context())+"/"+p.Role)
+	}
+	router.GET("/configs", handler)
+	router.POST("/configs", handler)
+
+	tests := []struct {
+		name           string
+		token          string
+		method         string
+		target         string
+		project        string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "default project", token: "global-token", method: "GET", target: "/configs", expectedStatus: http.StatusOK, expectedBody: "default/editor"},
+		{name: "project from header", token: "global-token", method: "GET", target: "/configs", project: "payments", expectedStatus: http.StatusOK, expectedBody: "payments/editor"},
+		{name: "project from query", token: "global-token", method: "GET", target: "/configs?project=search", expectedStatus: http.StatusOK, expectedBody: "search/editor"},
+		{name: "invalid project", token: "global-token", method: "GET", target: "/configs", project: "Bad Name", expectedStatus: http.StatusBadRequest},
+		{name: "project role raises global role", token: "scoped-token", method: "POST", target: "/configs", project: "payments", expectedStatus: http.StatusOK, expectedBody: "payments/editor"},
+		{name: "unassigned project denied", token: "scoped-token", method: "GET", target: "/configs", expectedStatus: http.StatusForbidden},
+		{name: "global admin everywhere", token: "admin-token", method: "POST", target: "/configs", project: "payments", expectedStatus: http.StatusOK, expectedBody: "payments/admin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.target, nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			if tt.project != "" {
+				req.Header.Set(ProjectHeader, tt.project)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.Equal(t, tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+
+	t.Run("agent identity is not scoped", func(t *testing.T) {
+		agentRouter := gin.New()
+		agentRouter.Use(Authenticate(verifier), ProjectScope())
+		agentRouter.GET("/agents/:id", func(c *gin.Context) {
+			c.String(http.StatusOK, models.ProjectFrom(c.Request.Context()))
+		})
+
+		req, _ := http.NewRequest("GET", "/agents/agent-1", nil)
+		req.Header.Set("Authorization", "Bearer agent-token")
+		req.Header.Set(ProjectHeader, "payments")
+		w := httptest.NewRecorder()
+		agentRouter.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Body.String())
+	})
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"logstash-platform/internal/platform/tracing"
+)
+
+func TestTracing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	shutdown, err := tracing.Setup(context.Background(), tracing.Config{})
+	require.NoError(t, err)
+	defer shutdown(context.Background())
+
+	router := gin.New()
+	router.Use(Tracing())
+	var handlerTraceID string
+	router.GET("/api/v1/configs/:id", func(c *gin.Context) {
+		handlerTraceID = tracing.TraceID(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	t.Run("接续请求头中的上游链路", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/configs/c1", nil)
+		req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", handlerTraceID)
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", w.Header().Get(TraceIDHeader))
+	})
+
+	t.Run("没有上游链路且未启用导出", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/configs/c1", nil))
+
+		assert.Empty(t, handlerTraceID)
+		assert.Empty(t, w.Header().Get(TraceIDHeader))
+	})
+}
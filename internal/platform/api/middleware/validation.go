@@ -0,0 +1,158 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/zh"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	entranslations "github.com/go-playground/validator/v10/translations/en"
+	zhtranslations "github.com/go-playground/validator/v10/translations/zh"
+
+	"logstash-platform/internal/platform/apperror"
+)
+
+// 校验错误说明的语言
+const (
+	LangZH = "zh"
+	LangEN = "en"
+)
+
+// FieldError 单个字段未通过绑定或校验的原因
+type FieldError struct {
+	Field   string `json:"field,omitempty"` // 字段名，与请求中的JSON或查询参数名一致，嵌套字段以.分隔；请求体整体无效时为空
+	Rule    string `json:"rule"`            // 未通过的校验规则，如 required、oneof；类型不符为 type，请求体不是JSON为 json
+	Message string `json:"message"`         // 按请求语言本地化的说明
+}
+
+// translators 校验规则的中英文翻译
+var translators = ut.New(zh.New(), zh.New(), en.New())
+
+// init 校验错误使用json/form标签中的字段名并注册翻译，须在首次绑定请求之前完成
+func init() {
+	validate, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	validate.RegisterTagNameFunc(tagName)
+
+	zhTrans, _ := translators.GetTranslator(LangZH)
+	enTrans, _ := translators.GetTranslator(LangEN)
+	if err := zhtranslations.RegisterDefaultTranslations(validate, zhTrans); err != nil {
+		panic(fmt.Sprintf("注册校验翻译失败: %v", err))
+	}
+	if err := entranslations.RegisterDefaultTranslations(validate, enTrans); err != nil {
+		panic(fmt.Sprintf("注册校验翻译失败: %v", err))
+	}
+}
+
+// tagName 字段在请求中的名称，依次取json、form标签，均未设置时为结构体字段名
+func tagName(field reflect.StructField) string {
+	for _, key := range []string{"json", "form"} {
+		name := strings.SplitN(field.Tag.Get(key), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// RequestLanguage 按 Accept-Language 选择校验错误说明的语言，首选英语时为en，其余为zh
+func RequestLanguage(c *gin.Context) string {
+	accept := strings.TrimSpace(c.GetHeader("Accept-Language"))
+	if strings.HasPrefix(strings.ToLower(accept), "en") {
+		return LangEN
+	}
+	return LangZH
+}
+
+// TranslateBindError 将请求绑定或校验失败的错误转换为逐字段的原因
+func TranslateBindError(err error, lang string) []FieldError {
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		trans, _ := translators.GetTranslator(lang)
+		fields := make([]FieldError, 0, len(validationErrors))
+		for _, fe := range validationErrors {
+			fields = append(fields, FieldError{
+				Field:   fieldPath(fe.Namespace()),
+				Rule:    fe.Tag(),
+				Message: fe.Translate(trans),
+			})
+		}
+		return fields
+	}
+
+	english := lang == LangEN
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	var numErr *strconv.NumError
+	var timeErr *time.ParseError
+	switch {
+	case errors.As(err, &typeErr):
+		message := fmt.Sprintf("%s的类型应为%s", typeErr.Field, typeErr.Type)
+		if english {
+			message = fmt.Sprintf("%s must be of type %s", typeErr.Field, typeErr.Type)
+		}
+		return []FieldError{{Field: typeErr.Field, Rule: "type", Message: message}}
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		message := "请求体不是有效的JSON"
+		if english {
+			message = "request body is not valid JSON"
+		}
+		return []FieldError{{Rule: "json", Message: message}}
+	case errors.Is(err, io.EOF):
+		message := "请求体不能为空"
+		if english {
+			message = "request body is required"
+		}
+		return []FieldError{{Rule: "required", Message: message}}
+	case errors.As(err, &numErr):
+		message := fmt.Sprintf("参数值%q不是有效的数字", numErr.Num)
+		if english {
+			message = fmt.Sprintf("value %q is not a valid number", numErr.Num)
+		}
+		return []FieldError{{Rule: "type", Message: message}}
+	case errors.As(err, &timeErr):
+		message := fmt.Sprintf("时间%q的格式无效，应为RFC3339", timeErr.Value)
+		if english {
+			message = fmt.Sprintf("time %q is invalid, expected RFC3339", timeErr.Value)
+		}
+		return []FieldError{{Rule: "time", Message: message}}
+	default:
+		return []FieldError{{Rule: "invalid", Message: err.Error()}}
+	}
+}
+
+// fieldPath 去掉命名空间开头的请求结构体名，如 TestConfigRequest.test_data.type 为 test_data.type
+func fieldPath(namespace string) string {
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+// HandleBindError 返回请求绑定或校验失败的错误响应并中止处理，errors 中逐个列出未通过的字段
+func HandleBindError(c *gin.Context, err error, message string) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		AbortWithError(c, payloadTooLarge(tooLarge.Limit))
+		return
+	}
+	problem := apperror.New(apperror.InvalidRequest, message)
+	writeProblem(c, problem.Code.Status(), problem, TranslateBindError(err, RequestLanguage(c)))
+	c.Abort()
+}
@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type validationRequest struct {
+	Name   string `json:"name" binding:"required"`
+	Status string `json:"status" binding:"omitempty,oneof=active inactive"`
+	Limits struct {
+		Size int `json:"size" binding:"max=100"`
+	} `json:"limits"`
+}
+
+type validationQuery struct {
+	Size int `form:"size" binding:"min=1"`
+}
+
+func bindRequest(t *testing.T, method, target, body, lang string) ErrorResponse {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/bind", func(c *gin.Context) {
+		var req validationRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			HandleBindError(c, err, "请求参数无效")
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+	router.GET("/bind", func(c *gin.Context) {
+		var query validationQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			HandleBindError(c, err, "请求参数无效")
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if lang != "" {
+		req.Header.Set("Accept-Language", lang)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	var resp ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "INVALID_REQUEST", resp.Code)
+	assert.Equal(t, "请求参数无效", resp.Message)
+	return resp
+}
+
+func TestHandleBindError(t *testing.T) {
+	t.Run("逐字段列出校验失败，字段名取json标签", func(t *testing.T) {
+		resp := bindRequest(t, http.MethodPost, "/bind", `{"status":"deleted","limits":{"size":500}}`, "")
+		assert.Equal(t, []FieldError{
+			{Field: "name", Rule: "required", Message: "name为必填字段"},
+			{Field: "status", Rule: "oneof", Message: "status必须是[active inactive]中的一个"},
+			{Field: "limits.size", Rule: "max", Message: "size必须小于或等于100"},
+		}, resp.Errors)
+	})
+
+	t.Run("按Accept-Language返回英文说明", func(t *testing.T) {
+		resp := bindRequest(t, http.MethodPost, "/bind", `{"status":"deleted","name":"a"}`, "en-US,en;q=0.9")
+		assert.Equal(t, []FieldError{
+			{Field: "status", Rule: "oneof", Message: "status must be one of [active inactive]"},
+		}, resp.Errors)
+	})
+
+	t.Run("查询参数取form标签", func(t *testing.T) {
+		resp := bindRequest(t, http.MethodGet, "/bind?size=0", "", "")
+		assert.Equal(t, []FieldError{{Field: "size", Rule: "min", Message: "size最小只能为1"}}, resp.Errors)
+
+		resp = bindRequest(t, http.MethodGet, "/bind?size=abc", "", "en")
+		assert.Equal(t, []FieldError{{Rule: "type", Message: `value "abc" is not a valid number`}}, resp.Errors)
+	})
+
+	t.Run("类型不符", func(t *testing.T) {
+		resp := bindRequest(t, http.MethodPost, "/bind", `{"name":"a","limits":{"size":"big"}}`, "")
+		assert.Equal(t, []FieldError{{Field: "limits.size", Rule: "type", Message: "limits.size的类型应为int"}}, resp.Errors)
+	})
+
+	t.Run("请求体无效或为空", func(t *testing.T) {
+		resp := bindRequest(t, http.MethodPost, "/bind", `{"name":`, "")
+		assert.Equal(t, []FieldError{{Rule: "json", Message: "请求体不是有效的JSON"}}, resp.Errors)
+
+		resp = bindRequest(t, http.MethodPost, "/bind", "", "")
+		assert.Equal(t, []FieldError{{Rule: "required", Message: "请求体不能为空"}}, resp.Errors)
+	})
+}
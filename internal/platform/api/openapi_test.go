@@ -0,0 +1,48 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"logstash-platform/internal/platform/api/handlers"
+	"logstash-platform/internal/platform/openapi"
+)
+
+func TestOpenAPISpecsCoverRoutes(t *testing.T) {
+	s := NewServer(logrus.New(), nil)
+	s.SetupRoutes()
+
+	undocumented, unused := openapi.Coverage(s.router.Routes(), handlers.APISpecs())
+	assert.Empty(t, undocumented, "新增的路由需要在 handlers.APISpecs 中登记接口描述")
+	assert.Empty(t, unused, "接口描述没有对应的路由")
+}
+
+func TestOpenAPISpecServed(t *testing.T) {
+	s := NewServer(logrus.New(), nil)
+	s.SetupRoutes()
+
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil))
+	require.Equal(t, http.StatusOK, w.Code, "文档无需令牌")
+
+	var doc openapi.Document
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	assert.Equal(t, openapi.Version, doc.OpenAPI)
+
+	get := doc.Paths["/api/v1/configs/{id}"]["get"]
+	require.NotNil(t, get)
+	assert.Equal(t, "getConfig", get.OperationID)
+	assert.Equal(t, "#/components/schemas/Config", get.Responses["200"].Content["application/json"].Schema.Ref)
+	assert.Contains(t, doc.Components.Schemas, "Config")
+
+	w = httptest.NewRecorder()
+	s.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/docs", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"/api/v1/openapi.json"`)
+}
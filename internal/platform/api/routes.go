@@ -1,13 +1,22 @@
 package api
 
 import (
+	"context"
+	"crypto/tls"
+	"sync"
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
 	"logstash-platform/internal/platform/api/handlers"
 	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/models"
 	"logstash-platform/internal/platform/repository"
 	"logstash-platform/internal/platform/service"
+	"logstash-platform/internal/platform/websocket"
 	"logstash-platform/pkg/elasticsearch"
+	"logstash-platform/pkg/filestore"
 )
 
 // Server API服务器
@@ -16,21 +25,620 @@ type Server struct {
 	logger         *logrus.Logger
 	esClient       elasticsearch.ClientInterface
 	configService  service.ConfigService
+	groupService   service.GroupService
+	deployService  service.DeploymentService
+	agentService   service.AgentService
+	throttle       *service.DestinationThrottle
+	desiredState   service.DesiredStateService
+	incidents      service.IncidentService
+	metrics        service.DeliveryMetricsService
+	overview       service.OverviewService
+	templates      service.IndexTemplateService
+	validator      service.ConfigValidator
+	estimator      service.CostEstimator
+	engine         *service.DeploymentEngine
+	cmdbSync       *service.CMDBSync // 未启用CMDB集成时为nil
+	auth           service.AuthService
+	agentTokens    service.AgentTokenService
+	agentCerts     service.AgentCertificateService
+	agentCA        *service.CertificateAuthority // 未启用Agent客户端证书或CA加载失败时为nil
+	peerVerifier   middleware.PeerVerifier       // 未启用Agent客户端证书时为nil
+	destinations   service.DestinationService
+	destMonitor    *service.DestinationMonitor // 未启用定期连通性检查时为nil
+	telemetry      *service.TelemetryPolicy    // 未启用心跳间隔协商时为nil
+	testFiles      *service.TestFileService    // 测试文件目录不可用时为nil，上传测试文件返回503
+	esTestSource   *service.ESTestSource       // 未配置允许读取的索引时为nil，elasticsearch类型的测试返回503
+	pluginCatalog  *service.PluginCatalogService
+	hub            *websocket.Hub
+	logStreams     *service.LogStreamRelay
+	diagnostics    *service.DiagnosticRunner
+	dlq            *service.DLQInspector
+	linter         service.ConfigLinter
+	plugins        service.PluginInventoryService
+	pluginInstalls *service.PluginInstaller
+	liveness       *service.LivenessMonitor
+	decommissioner *service.AgentDecommissioner
+	elector        *service.LeaderElector // 未启用领导者选举时为nil，后台任务直接在本副本运行
+	electorDone    chan struct{}          // 领导者选举退出并释放租约后关闭
+	revalidator    *service.ConfigRevalidator
+	scheduledTests *service.ScheduledTestRunner
+	historyPruner  *service.ConfigHistoryPruner
+	approvals      service.ApprovalService
+	contracts      service.ContractService
+	datasets       service.TestDatasetService
+	pipelines      service.PipelineService
+	agentMetrics   service.MetricsService
+	alerts         service.AlertService
+	alertEngine    *service.AlertEngine // 未启用告警评估时不启动，规则仍可管理
+	audit          service.AuditService
+	agentEvents    service.AgentEventService
+	secrets        service.SecretService
+	configUsage    service.ConfigUsageService
+	webhooks       service.WebhookService
+	dispatcher     *service.WebhookDispatcher
+	workers        *service.WorkerRegistry
+	shutdown       *service.ShutdownCoordinator
+	revalidate     bool // 是否每天定期重新校验配置
+	scheduleTests  bool // 是否每天定期运行标记配置的测试数据集
+	pruneHistory   bool // 是否每天按保留策略清理配置历史
+	alerting       bool // 是否定期评估告警规则
+	verifier       middleware.TokenVerifier // 未启用认证时为nil
+	requireEnrollment bool                  // Agent接口只接受注册令牌，共享令牌只能用于申请注册令牌
+	requireClientCert bool                  // Agent接口要求出示平台CA签发的客户端证书
+	testParallelism int                     // 样本测试的最大并发数
+	testLimits      handlers.TestLimits     // 异步测试任务的并发、排队和样本限制
 }
 
 // NewServer 创建新的API服务器
 func NewServer(logger *logrus.Logger, esClient elasticsearch.ClientInterface) *Server {
 	// 创建仓库层
 	configRepo := repository.NewConfigRepository(esClient, logger)
-	
+	agentRepo := repository.NewAgentRepository(esClient, logger)
+	groupRepo := repository.NewGroupRepository(esClient, logger)
+	deployRepo := repository.NewDeploymentRepository(esClient, logger)
+	incidentRepo := repository.NewIncidentRepository(esClient, logger)
+	userRepo := repository.NewUserRepository(esClient, logger)
+	agentTokenRepo := repository.NewAgentTokenRepository(esClient, logger)
+	agentCertRepo := repository.NewAgentCertificateRepository(esClient, logger)
+	destRepo := repository.NewDestinationRepository(esClient, logger)
+	agentEventRepo := repository.NewAgentEventRepository(esClient, logger)
+	revalidationRepo := repository.NewConfigRevalidationRepository(esClient, logger)
+	scheduledTestRunRepo := repository.NewScheduledTestRunRepository(esClient, logger)
+	historyRepo := repository.NewConfigHistoryRepository(esClient, logger)
+	approvalRepo := repository.NewApprovalRepository(esClient, logger)
+	contractRepo := repository.NewContractRepository(esClient, logger)
+	datasetRepo := repository.NewTestDatasetRepository(esClient, logger)
+	lintSettingsRepo := repository.NewLintSettingsRepository(esClient, logger)
+	pluginRepo := repository.NewPluginInventoryRepository(esClient, logger)
+	pluginInstallRepo := repository.NewPluginInstallRepository(esClient, logger)
+	pipelineRepo := repository.NewPipelineRepository(esClient, logger)
+	metricsRepo := repository.NewMetricsRepository(esClient, logger)
+	alertRuleRepo := repository.NewAlertRuleRepository(esClient, logger)
+	alertSilenceRepo := repository.NewAlertSilenceRepository(esClient, logger)
+	auditRepo := repository.NewAuditRepository(esClient, logger)
+	secretRepo := repository.NewSecretRepository(esClient, logger)
+	appliedConfigRepo := repository.NewAppliedConfigRepository(esClient, logger)
+	webhookRepo := repository.NewWebhookRepository(esClient, logger)
+	webhookDeliveryRepo := repository.NewWebhookDeliveryRepository(esClient, logger)
+
+	// 心跳命令队列，设置变更等消息经由心跳下发给Agent
+	commandQueue := service.NewCommandQueue(0)
+
+	// Agent WebSocket连接管理，推送给未连接Agent的消息排入心跳命令队列
+	hub := websocket.NewHub(websocket.Config{
+		PingInterval:    viper.GetDuration("websocket.ping_interval"),
+		PongTimeout:     viper.GetDuration("websocket.pong_timeout"),
+		WriteTimeout:    viper.GetDuration("websocket.write_timeout"),
+		ReadBufferSize:  viper.GetInt("websocket.read_buffer_size"),
+		WriteBufferSize: viper.GetInt("websocket.write_buffer_size"),
+		MaxFrameSize:    viper.GetInt("websocket.max_frame_size"),
+		ChunkTimeout:    viper.GetDuration("websocket.chunk_timeout"),
+		MaxMessageSize:  viper.GetInt64("websocket.max_message_size"),
+		SendBuffer:      viper.GetInt("websocket.send_buffer"),
+		ResendTTL:       viper.GetDuration("websocket.resend_ttl"),
+		ResendLimit:     viper.GetInt("websocket.resend_limit"),
+		PollMaxWait:     viper.GetDuration("websocket.long_poll_max_wait"),
+	}, logger)
+	hub.SetFallback(commandQueue)
+
+	// 浏览器实时日志经Agent的WebSocket连接转发，Agent未连接时不排入心跳命令队列
+	logStreams := service.NewLogStreamRelay(service.LogStreamConfig{
+		MaxRate:    viper.GetInt("log_stream.max_rate"),
+		MaxStreams: viper.GetInt("log_stream.max_streams"),
+		Buffer:     viper.GetInt("log_stream.buffer"),
+	}, hub, logger)
+
+	// 按下游集群限制并发重载
+	throttle := service.NewDestinationThrottle(
+		viper.GetInt("deployment.destination_concurrency"),
+		destinationLimits(logger),
+	)
+	if viper.GetBool("deployment.queue_depth.enabled") {
+		throttle.SetQueueDepthProbe(service.NewESQueueProbe(service.ESQueueProbeConfig{
+			Scheme:   viper.GetString("deployment.queue_depth.scheme"),
+			Username: viper.GetString("deployment.queue_depth.username"),
+			Password: viper.GetString("deployment.queue_depth.password"),
+			Timeout:  viper.GetDuration("deployment.queue_depth.timeout"),
+		}), viper.GetInt("deployment.queue_depth.max_depth"), viper.GetDuration("deployment.queue_depth.poll_interval"))
+	}
+
 	// 创建服务层
 	configService := service.NewConfigService(configRepo, logger)
+	configService.SetMaxContentSize(viper.GetInt("configs.max_content_size"))
+	groupService := service.NewGroupService(groupRepo, agentRepo, hub, logger)
+	agentService := service.NewAgentService(agentRepo, configRepo, commandQueue, logger)
+	deployService := service.NewDeploymentService(deployRepo, configRepo, logger)
+
+	// 下游集群注册表，连通性检查使用平台环境变量中的同名密钥
+	destinations := service.NewDestinationService(destRepo,
+		service.NewNetworkProber(viper.GetDuration("destinations.check_timeout")), service.EnvSecretResolver{}, logger)
+	var destMonitor *service.DestinationMonitor
+	if interval := viper.GetDuration("destinations.check_interval"); interval > 0 {
+		destMonitor = service.NewDestinationMonitor(destinations, interval, logger)
+	}
+
+	// 外部CMDB集成：补全Agent业务元数据并回写生命周期事件
+	var cmdbSync *service.CMDBSync
+	if viper.GetBool("cmdb.enabled") {
+		connector := service.NewRESTConnector(service.RESTConnectorConfig{
+			BaseURL:      viper.GetString("cmdb.base_url"),
+			Token:        viper.GetString("cmdb.token"),
+			LookupPath:   viper.GetString("cmdb.lookup_path"),
+			ResultPath:   viper.GetString("cmdb.result_path"),
+			FieldMapping: viper.GetStringMapString("cmdb.field_mapping"),
+			EventsPath:   viper.GetString("cmdb.events_path"),
+			Timeout:      viper.GetDuration("cmdb.timeout"),
+		})
+		cmdbSync = service.NewCMDBSync(agentRepo, connector, viper.GetDuration("cmdb.interval"), logger)
+	}
+
+	// 认证：用户使用登录签发的JWT，Agent使用平台签发的注册令牌或共享令牌
+	agentTokens := service.NewAgentTokenService(agentTokenRepo, viper.GetDuration("security.agent_token_rotation_grace"), logger)
+	authService := service.NewAuthService(userRepo, viper.GetString("security.jwt_secret"),
+		time.Duration(viper.GetInt("security.jwt_expire_hours"))*time.Hour, viper.GetStringSlice("security.agent_tokens"), agentTokens, logger)
+	var verifier middleware.TokenVerifier
+	if viper.GetBool("security.auth_enabled") {
+		verifier = authService
+	}
+
+	// Agent客户端证书：平台CA按注册时提交的CSR签发以Agent ID为CN的证书，HTTPS监听据此校验Agent身份
+	// CA加载失败时仍校验Agent接口，出示的证书一律被拒绝，不会退化为不校验
+	var agentCA *service.CertificateAuthority
+	var peerVerifier middleware.PeerVerifier
+	if viper.GetBool("security.mtls.enabled") {
+		ca, generated, err := service.LoadCertificateAuthority(viper.GetString("security.mtls.ca_cert_file"), viper.GetString("security.mtls.ca_key_file"))
+		if err != nil {
+			logger.WithError(err).Error("加载平台CA失败，无法签发和校验Agent客户端证书")
+		} else {
+			if generated {
+				logger.Warn("未找到平台CA，已生成新的CA；多副本部署须让各副本使用同一份 security.mtls.ca_cert_file 和 ca_key_file")
+			}
+			agentCA = ca
+		}
+		if !viper.GetBool("server.tls.enabled") {
+			logger.Warn("security.mtls.enabled 需要同时启用 server.tls，否则Agent无法出示客户端证书")
+		}
+	}
+	agentCerts := service.NewAgentCertificateService(agentCA, agentCertRepo, viper.GetDuration("security.mtls.cert_ttl"),
+		viper.GetDuration("security.mtls.rotation_grace"), logger)
+	if viper.GetBool("security.mtls.enabled") {
+		peerVerifier = agentCerts
+	}
+
+	// 按在线Agent数和平台负载协商心跳/指标间隔
+	var telemetry *service.TelemetryPolicy
+	if viper.GetBool("agent_telemetry.adaptive") {
+		telemetry = service.NewTelemetryPolicy(service.TelemetryPolicyConfig{
+			HeartbeatRate: viper.GetFloat64("agent_telemetry.heartbeat_rate"),
+			MetricsRate:   viper.GetFloat64("agent_telemetry.metrics_rate"),
+			TargetLatency: viper.GetDuration("agent_telemetry.target_latency"),
+			MaxPressure:   viper.GetFloat64("agent_telemetry.max_pressure"),
+			Interval:      viper.GetDuration("agent_telemetry.evaluate_interval"),
+		}, agentRepo, hub, logger)
+	}
+
+	// 按最近心跳推导Agent状态，心跳过期时标记离线并写入Agent事件日志
+	liveness := service.NewLivenessMonitor(service.LivenessConfig{
+		OfflineAfter:  viper.GetDuration("agent_liveness.offline_after"),
+		DegradedAfter: viper.GetDuration("agent_liveness.degraded_after"),
+		Interval:      viper.GetDuration("agent_liveness.scan_interval"),
+	}, agentRepo, agentEventRepo, logger)
+	liveness.SetTelemetryPolicy(telemetry)
+
+	incidents := service.NewIncidentService(incidentRepo, logger)
+	validator := service.NewLogstashValidator(viper.GetString("test_engine.logstash_bin"), viper.GetString("test_engine.temp_dir"),
+		viper.GetDuration("test_engine.test_timeout"), viper.GetInt("test_engine.max_concurrent_tests"), logger)
+
+	// 按当前的校验器和内容规则定期重新校验启用的配置，新出现的失败写入事件收件箱
+	revalidator := service.NewConfigRevalidator(service.RevalidationConfig{
+		RunAt: viper.GetString("config_revalidation.run_at"),
+	}, configRepo, revalidationRepo, validator, incidents, logger)
+
+	// 每天用标记为定期测试的配置运行其测试数据集，此前通过的配置开始失败时写入事件收件箱并发布 test.regressed
+	datasets := service.NewTestDatasetService(datasetRepo, configRepo, logger)
+	scheduledTests := service.NewScheduledTestRunner(service.ScheduledTestConfig{
+		RunAt: viper.GetString("scheduled_tests.run_at"),
+	}, configRepo, scheduledTestRunRepo, datasets, incidents, logger)
+
+	// 按保留策略定期清理配置历史，最新版本和未结束部署引用的版本总是保留
+	historyPruner := service.NewConfigHistoryPruner(service.HistoryRetentionConfig{
+		Policy: models.HistoryRetentionPolicy{
+			KeepVersions: viper.GetInt("config_history.retention.keep_versions"),
+			KeepDays:     viper.GetInt("config_history.retention.keep_days"),
+		},
+		RunAt: viper.GetString("config_history.retention.run_at"),
+	}, historyRepo, deployRepo, logger)
+
+	engine := service.NewDeploymentEngine(deployRepo, configRepo, agentService, hub, throttle,
+		viper.GetDuration("deployment.agent_timeout"), logger)
+	engine.SetThrottleHold(viper.GetDuration("deployment.throttle_hold"))
+	engine.SetAckRetries(viper.GetInt("deployment.ack_retries"))
+	hub.SetConnectListener(engine)
+
+	// 配置版本审批：达到要求的通过数后才能部署
+	approvals := service.NewApprovalService(viper.GetInt("approvals.required_approvals"), approvalRepo, configRepo, logger)
+	engine.SetApprovalService(approvals)
+	if viper.GetBool("deployment.test_gate.enabled") {
+		engine.SetTestGate(service.NewTestGate(time.Duration(viper.GetInt("deployment.test_gate.max_age_days"))*24*time.Hour,
+			viper.GetString("deployment.test_gate.override_role"), logger))
+	}
+
+	// Agent事件时间线：注册、配置应用结果和Agent上报的重载失败、Logstash崩溃/重启
+	agentEvents := service.NewAgentEventService(agentEventRepo, agentRepo, logger)
+	engine.SetEventService(agentEvents)
+
+	// Agent注销：写入归档后从Agent列表删除，关闭连接并停止心跳巡检
+	decommissioner := service.NewAgentDecommissioner(agentRepo, repository.NewAgentArchiveRepository(esClient, logger), commandQueue, liveness, logger)
+	decommissioner.SetEventService(agentEvents)
+	decommissioner.SetDisconnector(hub)
+
+	// 插件清单：部署前检查目标Agent是否安装了配置引用的全部插件，未上报清单的Agent不检查
+	plugins := service.NewPluginInventoryService(pluginRepo, logger)
+	engine.SetPluginInventory(plugins)
+
+	// 配置使用情况：按Agent上报的应用结果维护Agent与配置版本的映射
+	configUsage := service.NewConfigUsageService(appliedConfigRepo, configRepo, logger)
+	engine.SetConfigUsageService(configUsage)
+
+	// 删除仍在Agent上运行的配置需要强制删除，由部署引擎下发config_delete并等待Agent确认
+	configService.SetDeleteGuard(agentRepo, engine)
+
+	// 出站事件推送：配置创建、部署结束、Agent离线和测试结束时通知订阅的webhook
+	dispatcher := service.NewWebhookDispatcher(service.WebhookConfig{
+		MaxAttempts:    viper.GetInt("webhooks.max_attempts"),
+		InitialBackoff: viper.GetDuration("webhooks.initial_backoff"),
+		MaxBackoff:     viper.GetDuration("webhooks.max_backoff"),
+		Timeout:        viper.GetDuration("webhooks.timeout"),
+		Workers:        viper.GetInt("webhooks.workers"),
+		QueueSize:      viper.GetInt("webhooks.queue_size"),
+	}, webhookRepo, webhookDeliveryRepo, logger)
+	configService.SetEventEmitter(dispatcher)
+	engine.SetEventEmitter(dispatcher)
+	liveness.SetEventEmitter(dispatcher)
+	scheduledTests.SetEventEmitter(dispatcher)
+
+	// 告警：按规则定期评估Agent注册表、指标索引和部署结果，经配置的渠道发送通知
+	alertEngine := service.NewAlertEngine(service.AlertingConfig{
+		Interval:     viper.GetDuration("alerting.evaluate_interval"),
+		MetricWindow: viper.GetDuration("alerting.metric_window"),
+	}, alertRuleRepo, alertSilenceRepo, agentRepo, metricsRepo, deployRepo, alertNotifiers(logger), logger)
+
+	// 密钥：值使用主密钥加密保存，未配置主密钥时密钥接口返回503
+	var secretCipher *service.SecretCipher
+	if masterKey := viper.GetString("secrets.master_key"); masterKey != "" {
+		cipher, err := service.NewSecretCipher(masterKey)
+		if err != nil {
+			logger.WithError(err).Error("secrets.master_key 无效，密钥管理不可用")
+		} else {
+			secretCipher = cipher
+		}
+	}
+	secrets := service.NewSecretService(secretRepo, configRepo, deployRepo, appliedConfigRepo, secretCipher, logger)
+
+	// 测试文件：上传的日志文件保存在本地目录，有效期过后清理
+	var testFiles *service.TestFileService
+	if store, err := filestore.NewLocal(viper.GetString("test_files.dir"), viper.GetInt64("test_files.max_file_size"),
+		viper.GetDuration("test_files.ttl")); err != nil {
+		logger.WithError(err).Error("测试文件存储不可用，不能上传测试文件")
+	} else {
+		testFiles = service.NewTestFileService(service.TestFileConfig{
+			CleanupInterval: viper.GetDuration("test_files.cleanup_interval"),
+		}, store, logger)
+	}
+
+	// 插件目录：内置或从文件加载的插件选项，供编辑器自动补全和静态检查未知选项
+	pluginCatalog := service.NewPluginCatalogService(service.PluginCatalogConfig{
+		File:           viper.GetString("plugin_catalog.file"),
+		ReloadInterval: viper.GetDuration("plugin_catalog.reload_interval"),
+	}, logger)
+
+	// Elasticsearch测试数据源：从允许读取的业务索引中抽取文档作为测试样本
+	var esTestSource *service.ESTestSource
+	if allowed := viper.GetStringSlice("test_sources.elasticsearch.allowed_indices"); len(allowed) > 0 {
+		esTestSource = service.NewESTestSource(service.ESTestSourceConfig{AllowedIndices: allowed},
+			repository.NewEventSampleRepository(esClient, logger), logger)
+	}
+
+	// 部署预览按Agent所在环境渲染下游集群引用，并检查引用的密钥是否存在
+	engine.SetDestinationService(destinations)
+	engine.SetSecretService(secrets)
+
+	// 后台子系统运行状态，用于发现控制面自身处理不过来的情况
+	workers := service.NewWorkerRegistry()
+	workers.Register(commandQueue, hub, logStreams, engine, throttle, liveness, revalidator, scheduledTests, historyPruner, dispatcher)
+	if cmdbSync != nil {
+		workers.Register(cmdbSync)
+	}
+	if destMonitor != nil {
+		workers.Register(destMonitor)
+	}
+	if telemetry != nil {
+		workers.Register(telemetry)
+	}
+	if testFiles != nil {
+		workers.Register(testFiles)
+	}
+	if viper.GetString("plugin_catalog.file") != "" {
+		workers.Register(pluginCatalog)
+	}
+	if viper.GetBool("alerting.enabled") {
+		workers.Register(alertEngine)
+	}
+
+	// 平台关闭时等待进行中的部署和测试
+	shutdown := service.NewShutdownCoordinator(logger)
+	shutdown.Register("deployment_engine", engine)
+
+	// 多副本部署时只有持有租约的副本运行周期任务
+	var elector *service.LeaderElector
+	if viper.GetBool("leader_election.enabled") {
+		elector = service.NewLeaderElector(service.LeaderElectionConfig{
+			LeaseName:     viper.GetString("leader_election.lease_name"),
+			Identity:      viper.GetString("leader_election.identity"),
+			TTL:           viper.GetDuration("leader_election.ttl"),
+			RenewInterval: viper.GetDuration("leader_election.renew_interval"),
+		}, repository.NewLeaseRepository(esClient, logger), logger)
+		workers.Register(elector)
+	}
 
 	return &Server{
 		logger:        logger,
 		esClient:      esClient,
 		configService: configService,
+		groupService:  groupService,
+		deployService: deployService,
+		agentService:  agentService,
+		throttle:      throttle,
+		desiredState:  service.NewDesiredStateService(configService, groupService, agentRepo, logger),
+		incidents:     incidents,
+		metrics:       service.NewDeliveryMetricsService(deployRepo, configRepo, logger),
+		overview:      service.NewOverviewService(repository.NewOverviewRepository(esClient, logger), logger),
+		templates:     service.NewIndexTemplateService(configService, logger),
+		validator:     validator,
+		estimator: service.NewCostEstimator(service.CostEstimateConfig{
+			UtilizationTarget: viper.GetFloat64("cost_estimate.utilization_target"),
+			MetricsMaxAge:     viper.GetDuration("cost_estimate.metrics_max_age"),
+			QueueBuffer:       viper.GetDuration("cost_estimate.queue_buffer"),
+		}, configRepo, agentRepo, agentService, logger),
+		engine:   engine,
+		cmdbSync: cmdbSync,
+		auth:     authService,
+		verifier: verifier,
+
+		agentTokens:       agentTokens,
+		agentCerts:        agentCerts,
+		agentCA:           agentCA,
+		peerVerifier:      peerVerifier,
+		destinations:      destinations,
+		destMonitor:       destMonitor,
+		testFiles:         testFiles,
+		esTestSource:      esTestSource,
+		pluginCatalog:     pluginCatalog,
+		telemetry:         telemetry,
+		hub:               hub,
+		logStreams:        logStreams,
+		diagnostics:       service.NewDiagnosticRunner(hub, viper.GetDuration("diagnostics.timeout"), logger),
+		dlq:               service.NewDLQInspector(hub, viper.GetDuration("diagnostics.dlq_timeout"), logger),
+		plugins:           plugins,
+		pluginInstalls:    service.NewPluginInstaller(pluginInstallRepo, hub, plugins, viper.GetDuration("plugins.install_timeout"), logger),
+		linter:            service.NewConfigLinter(configService, lintSettingsRepo, pluginCatalog, viper.GetStringSlice("lint.production_environments"), logger),
+		liveness:          liveness,
+		decommissioner:    decommissioner,
+		elector:           elector,
+		revalidator:       revalidator,
+		scheduledTests:    scheduledTests,
+		historyPruner:     historyPruner,
+		approvals:         approvals,
+		contracts:         service.NewContractService(contractRepo, logger),
+		datasets:          datasets,
+		pipelines:         service.NewPipelineService(pipelineRepo, configRepo, validator, engine, logger),
+		agentMetrics:      service.NewMetricsService(metricsRepo, agentRepo, logger),
+		alerts:            service.NewAlertService(alertRuleRepo, alertSilenceRepo, alertEngine, logger),
+		alertEngine:       alertEngine,
+		audit:             service.NewAuditService(auditRepo, logger),
+		agentEvents:       agentEvents,
+		secrets:           secrets,
+		configUsage:       configUsage,
+		webhooks:          service.NewWebhookService(webhookRepo, webhookDeliveryRepo, logger),
+		dispatcher:        dispatcher,
+		alerting:          viper.GetBool("alerting.enabled"),
+		workers:           workers,
+		shutdown:          shutdown,
+		revalidate:        viper.GetBool("config_revalidation.enabled"),
+		scheduleTests:     viper.GetBool("scheduled_tests.enabled"),
+		pruneHistory:      viper.GetBool("config_history.retention.enabled"),
+		requireEnrollment: viper.GetBool("security.require_agent_enrollment"),
+		requireClientCert: viper.GetBool("security.mtls.require_client_cert"),
+
+		testParallelism: viper.GetInt("test_engine.max_concurrent_tests"),
+		testLimits: handlers.TestLimits{
+			MaxConcurrentJobs: viper.GetInt("test_engine.max_concurrent_jobs"),
+			MaxQueuedJobs:     viper.GetInt("test_engine.max_queued_jobs"),
+			JobTimeout:        viper.GetDuration("test_engine.job_timeout"),
+			MaxSamples:        viper.GetInt("test_engine.max_samples"),
+			MaxSampleBytes:    viper.GetInt64("test_engine.max_sample_bytes"),
+		},
+	}
+}
+
+// TLSConfig HTTPS监听使用的TLS配置，启用Agent客户端证书时接受平台CA签发的客户端证书
+func (s *Server) TLSConfig() *tls.Config {
+	if s.agentCA == nil {
+		return &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	return s.agentCA.TLSConfig()
+}
+
+// Bootstrap 启用认证且平台中没有用户时创建初始管理员
+func (s *Server) Bootstrap(ctx context.Context) error {
+	if s.verifier == nil {
+		return nil
+	}
+	return s.auth.EnsureBootstrapAdmin(ctx,
+		viper.GetString("security.bootstrap_admin.username"),
+		viper.GetString("security.bootstrap_admin.password"))
+}
+
+// StartBackgroundJobs 启动后台任务，直到ctx取消
+// 启用领导者选举时周期任务只在持有租约的副本运行，领导者退出或失联后由其他副本接管
+func (s *Server) StartBackgroundJobs(ctx context.Context) {
+	// 间隔协商依据本副本的连接负载，每个副本都需要运行
+	if s.telemetry != nil {
+		go s.telemetry.Start(ctx)
+	}
+	// 事件在发生事件的副本上推送
+	go s.dispatcher.Start(ctx)
+	// 测试文件保存在接收上传的副本上
+	if s.testFiles != nil {
+		go s.testFiles.Start(ctx)
+	}
+	// 插件目录由每个副本各自加载，未配置目录文件时立即返回
+	go s.pluginCatalog.Start(ctx)
+
+	if s.elector == nil {
+		go s.runLeaderJobs(ctx)
+		return
+	}
+	s.electorDone = make(chan struct{})
+	go func() {
+		defer close(s.electorDone)
+		s.elector.Run(ctx, s.runLeaderJobs)
+	}()
+}
+
+// runLeaderJobs 运行只允许单个副本执行的后台任务，阻塞直到ctx取消且任务全部退出
+func (s *Server) runLeaderJobs(ctx context.Context) {
+	var wg sync.WaitGroup
+	run := func(start func(ctx context.Context)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start(ctx)
+		}()
+	}
+
+	if s.cmdbSync != nil {
+		run(s.cmdbSync.Start)
+	}
+	if s.destMonitor != nil {
+		run(s.destMonitor.Start)
+	}
+	run(s.liveness.Start)
+	if s.revalidate {
+		run(s.revalidator.Start)
+	}
+	if s.scheduleTests {
+		run(s.scheduledTests.Start)
+	}
+	if s.pruneHistory {
+		run(s.historyPruner.Start)
+	}
+	if s.alerting {
+		run(s.alertEngine.Start)
+	}
+
+	// 平台重启或接管后处理遗留的未结束部署
+	if err := s.engine.Recover(ctx); err != nil {
+		s.logger.WithError(err).Error("恢复未结束部署失败")
+	}
+	if err := s.pluginInstalls.Recover(ctx); err != nil {
+		s.logger.WithError(err).Error("恢复未结束的插件安装失败")
+	}
+	wg.Wait()
+}
+
+// Drain 停止接受新的部署和测试，等待进行中的任务结束，ctx到期时保存未结束部署的进度
+// 排空期间HTTP服务和Agent连接保持可用，进行中的部署仍能收到Agent上报的结果；应在关闭HTTP服务之前调用
+func (s *Server) Drain(ctx context.Context) error {
+	return s.shutdown.Drain(ctx)
+}
+
+// Shutdown 关闭Agent WebSocket连接，http.Server.Shutdown不会关闭已升级的连接
+// 启用领导者选举时等待后台任务退出并释放租约，其他副本无需等待租约过期即可接管；调用前应先取消后台任务的ctx
+func (s *Server) Shutdown() {
+	s.hub.Close()
+	if s.electorDone != nil {
+		<-s.electorDone
+	}
+}
+
+// destinationLimits 读取按集群覆盖的并发上限
+// 集群标识包含 "." 和 ":"，不能作为viper的map键，因此配置为 {destination, limit} 列表
+func destinationLimits(logger *logrus.Logger) map[string]int {
+	var entries []struct {
+		Destination string `mapstructure:"destination"`
+		Limit       int    `mapstructure:"limit"`
+	}
+	if err := viper.UnmarshalKey("deployment.destination_limits", &entries); err != nil {
+		logger.WithError(err).Error("解析 deployment.destination_limits 失败，忽略按集群覆盖的并发上限")
+		return nil
+	}
+
+	limits := make(map[string]int, len(entries))
+	for _, entry := range entries {
+		if entry.Destination == "" {
+			logger.Warn("deployment.destination_limits 中存在缺少 destination 的条目，已忽略")
+			continue
+		}
+		limits[entry.Destination] = entry.Limit
+	}
+	return limits
+}
+
+// alertNotifiers 按 alerting.channels 创建通知渠道，无效的渠道记录日志后忽略
+func alertNotifiers(logger *logrus.Logger) map[string]service.AlertNotifier {
+	var channels []service.AlertChannelConfig
+	if err := viper.UnmarshalKey("alerting.channels", &channels); err != nil {
+		logger.WithError(err).Error("解析 alerting.channels 失败，告警不会发送通知")
+		return nil
+	}
+
+	notifiers := make(map[string]service.AlertNotifier, len(channels))
+	for _, channel := range channels {
+		if channel.Name == "" {
+			logger.Warn("alerting.channels 中存在缺少 name 的条目，已忽略")
+			continue
+		}
+		notifier, err := service.NewAlertNotifier(channel)
+		if err != nil {
+			logger.WithError(err).Warn("告警通知渠道配置无效，已忽略")
+			continue
+		}
+		notifiers[channel.Name] = notifier
+	}
+	return notifiers
+}
+
+// bodyLimits 读取请求体大小上限，server.body_limit.routes 中的路径前缀覆盖默认上限
+func bodyLimits() middleware.BodyLimits {
+	limits := middleware.BodyLimits{
+		Default: viper.GetInt64("server.body_limit.default"),
+		Routes:  make(map[string]int64),
 	}
+	for prefix := range viper.GetStringMap("server.body_limit.routes") {
+		limits.Routes[prefix] = viper.GetInt64("server.body_limit.routes." + prefix)
+	}
+	return limits
 }
 
 // SetupRoutes 设置路由
@@ -39,55 +647,429 @@ func (s *Server) SetupRoutes() *gin.Engine {
 
 	// 全局中间件
 	router.Use(gin.Recovery())
+	router.Use(middleware.Tracing())
 	router.Use(middleware.Logger(s.logger))
+	// 压缩须在错误处理之外，错误响应同样经压缩写出
+	if viper.GetBool("server.compression.enabled") {
+		router.Use(middleware.Compress(viper.GetInt("server.compression.min_size")))
+	}
 	router.Use(middleware.ErrorHandler())
 	router.Use(middleware.CORS())
+	router.Use(middleware.BodyLimit(bodyLimits()))
 
 	// 健康检查
 	router.GET("/health", handlers.HealthCheck)
 
-	// API v1路由组
-	v1 := router.Group("/api/v1")
+	// 后台子系统指标，供Prometheus抓取（无需令牌）
+	router.GET("/metrics", handlers.WorkerMetrics(s.workers))
+
+	// Agent通信协议（无需令牌）
+	router.GET("/api/v1/protocol", handlers.ProtocolSchema)
+
+	// 错误码目录（无需令牌），错误响应的 type 指向其中的错误码
+	router.GET("/api/v1/errors", handlers.ErrorCatalog)
+
+	// 平台API的OpenAPI文档及Swagger UI（无需令牌），文档按最终的路由表生成
+	router.GET("/api/v1/openapi.json", handlers.OpenAPISpec(router.Routes))
+	router.GET("/api/v1/docs", handlers.SwaggerUI("/api/v1/openapi.json"))
+
+	// 认证路由（无需令牌）
+	authHandler := handlers.NewAuthHandler(s.auth, s.logger)
+	router.POST("/api/v1/auth/login", authHandler.Login) // 用户登录
+
+	// 读请求需要viewer角色，写请求需要editor角色
+	readWrite := middleware.RequireRoleByMethod(models.RoleViewer, models.RoleEditor)
+
+	// 按项目隔离的路由，请求头 X-Project 或查询参数 project 指定项目，角色按用户在该项目内的角色判断
+	scoped := middleware.ProjectScope()
+
+	// Agent消息通道，经WebSocket或长轮询上报的消息由同一处理器处理
+	wsHandler := handlers.NewWebSocketHandler(s.hub, s.agentService, s.engine, s.logger)
+	wsHandler.SetTelemetryPolicy(s.telemetry)
+	wsHandler.SetMetricsService(s.agentMetrics)
+	wsHandler.SetLogStreamRelay(s.logStreams)
+	wsHandler.SetDiagnosticRunner(s.diagnostics)
+	wsHandler.SetDLQInspector(s.dlq)
+	wsHandler.SetPluginInstaller(s.pluginInstalls)
+	wsHandler.SetLivenessMonitor(s.liveness)
+	s.hub.SetHandler(wsHandler)
+	s.hub.SetDisconnectListener(wsHandler)
+
+	// API v1路由组，全部需要认证，变更类请求记录审计
+	v1 := router.Group("/api/v1", middleware.Authenticate(s.verifier), middleware.Audit(s.audit, s.logger))
 	{
+		v1.GET("/auth/me", authHandler.Me) // 获取当前用户
+
+		// 审计记录路由
+		auditHandler := handlers.NewAuditHandler(s.audit, s.logger)
+		v1.GET("/audit", middleware.RequireRole(models.RoleAdmin), auditHandler.ListAuditLog) // 查询审计记录
+
+		// 用户管理路由
+		users := v1.Group("/users", middleware.RequireRole(models.RoleAdmin))
+		{
+			users.GET("", authHandler.ListUsers)               // 获取用户列表
+			users.POST("", authHandler.CreateUser)             // 创建用户
+			users.PUT("/:username", authHandler.UpdateUser)    // 更新用户
+			users.DELETE("/:username", authHandler.DeleteUser) // 删除用户
+		}
+
 		// 配置管理路由
-		configs := v1.Group("/configs")
+		configs := v1.Group("/configs", scoped, readWrite)
 		{
 			configHandler := handlers.NewConfigHandler(s.configService, s.logger)
+			configHandler.SetDestinationService(s.destinations)
+			configHandler.SetConfigLinter(s.linter)
+			destinationHandler := handlers.NewDestinationHandler(s.destinations, s.configService, s.logger)
 			
 			configs.GET("", configHandler.ListConfigs)        // 获取配置列表
+			configs.GET("/search", configHandler.SearchConfigs) // 全文搜索配置
+			configs.GET("/stats", configHandler.GetConfigStats) // 按类型、标签、测试状态统计配置
+			configs.GET("/export", configHandler.ExportConfigs) // 以CSV或XLSX导出配置目录
 			configs.POST("", configHandler.CreateConfig)      // 创建配置
 			configs.GET("/:id", configHandler.GetConfig)      // 获取单个配置
 			configs.PUT("/:id", configHandler.UpdateConfig)   // 更新配置
 			configs.DELETE("/:id", configHandler.DeleteConfig) // 删除配置
 			configs.GET("/:id/history", configHandler.GetConfigHistory) // 获取配置历史
+			configs.GET("/:id/diff", configHandler.DiffConfig)          // 比较配置版本
+			configs.PUT("/:id/acl", configHandler.SetConfigACL)         // 设置配置级访问控制
+			configs.PUT("/:id/scheduled-test", configHandler.SetScheduledTest) // 开启或关闭配置的定期测试
 			configs.POST("/:id/rollback", configHandler.RollbackConfig) // 回滚配置
+
+			usageHandler := handlers.NewConfigUsageHandler(s.configUsage, s.logger)
+			configs.GET("/:id/agents", usageHandler.ListConfigAgents) // 获取运行该配置的Agent及版本
+
+			approvalHandler := handlers.NewApprovalHandler(s.approvals, s.logger)
+			configs.POST("/:id/approvals", approvalHandler.SubmitApproval) // 审批配置当前版本
+			configs.GET("/:id/approvals", approvalHandler.ListApprovals)   // 获取版本审批进度
+			configs.GET("/:id/render", destinationHandler.RenderConfig)  // 预览按环境渲染的配置
+
+			templateHandler := handlers.NewIndexTemplateHandler(s.templates, s.logger)
+			configs.POST("/:id/generate-index-template", templateHandler.GenerateIndexTemplate) // 生成ES索引模板
+
+			validationHandler := handlers.NewValidationHandler(s.validator, s.logger)
+			configs.POST("/validate", validationHandler.ValidateConfig) // 使用Logstash校验配置语法
+
+			lintHandler := handlers.NewLintHandler(s.linter, s.logger)
+			configs.POST("/:id/lint", lintHandler.LintConfig) // 按项目启用的规则静态检查配置内容
+
+			revalidationHandler := handlers.NewRevalidationHandler(s.revalidator, s.logger)
+			configs.GET("/revalidations", revalidationHandler.ListRevalidations) // 获取定期重新校验结果
+			configs.POST("/revalidate", revalidationHandler.TriggerRevalidation) // 立即重新校验全部启用的配置
+
+			scheduledTestHandler := handlers.NewScheduledTestHandler(s.scheduledTests, s.logger)
+			configs.GET("/:id/scheduled-tests", scheduledTestHandler.ListScheduledTests)       // 获取配置的定期测试记录
+			configs.POST("/scheduled-tests/run", scheduledTestHandler.TriggerScheduledTests) // 立即运行全部定期测试
+
+			retentionHandler := handlers.NewHistoryRetentionHandler(s.historyPruner, s.logger)
+			configs.GET("/history/prune", middleware.RequireRole(models.RoleAdmin), retentionHandler.PreviewPrune)  // 预览按保留策略将被清理的配置历史
+			configs.POST("/history/prune", middleware.RequireRole(models.RoleAdmin), retentionHandler.TriggerPrune) // 立即按保留策略清理配置历史
+
+			estimateHandler := handlers.NewCostEstimateHandler(s.estimator, s.logger)
+			configs.POST("/:id/estimate", estimateHandler.EstimateCost) // 部署前资源估算
+
+			datasetHandler := handlers.NewTestDatasetHandler(s.datasets, s.logger)
+			configs.GET("/:id/datasets", datasetHandler.ListDatasets)              // 获取配置上的测试数据集
+			configs.POST("/:id/datasets", datasetHandler.CreateDataset)            // 保存测试数据集
+			configs.GET("/:id/datasets/:dataset", datasetHandler.GetDataset)       // 获取单个测试数据集
+			configs.PUT("/:id/datasets/:dataset", datasetHandler.UpdateDataset)    // 更新测试数据集
+			configs.DELETE("/:id/datasets/:dataset", datasetHandler.DeleteDataset) // 删除测试数据集
+		}
+
+		// 插件目录路由，刷新目录需要管理员权限
+		catalog := v1.Group("/catalog", middleware.RequireRoleByMethod(models.RoleViewer, models.RoleAdmin))
+		{
+			catalogHandler := handlers.NewCatalogHandler(s.pluginCatalog, s.logger)
+			catalog.GET("/plugins", catalogHandler.ListPlugins)             // 获取插件及其选项的目录
+			catalog.POST("/plugins/refresh", catalogHandler.RefreshPlugins) // 重新加载插件目录
+		}
+
+		// 静态检查规则路由，修改规则开关需要管理员权限
+		lint := v1.Group("/lint", scoped, middleware.RequireRoleByMethod(models.RoleViewer, models.RoleAdmin))
+		{
+			lintHandler := handlers.NewLintHandler(s.linter, s.logger)
+			lint.GET("/rules", lintHandler.ListRules)   // 获取当前项目的静态检查规则
+			lint.PUT("/rules", lintHandler.UpdateRules) // 设置当前项目的规则开关
 		}
 
 		// 测试路由
-		test := v1.Group("/test")
+		test := v1.Group("/test", scoped, readWrite)
 		{
 			testHandler := handlers.NewTestHandler(s.configService, s.logger)
+			testHandler.SetParallelism(s.testParallelism)
+			testHandler.SetLimits(s.testLimits)
+			testHandler.SetContractService(s.contracts)
+			testHandler.SetDatasetService(s.datasets)
+			testHandler.SetFileService(s.testFiles)
+			testHandler.SetESSource(s.esTestSource)
+			testHandler.SetEventEmitter(s.dispatcher)
+			s.scheduledTests.SetProcessor(testHandler.ProcessSamples)
+			s.workers.Register(testHandler)
+			s.shutdown.Register("test_engine", testHandler)
 			
 			test.POST("", testHandler.CreateTest)              // 创建测试任务
 			test.GET("/:id/result", testHandler.GetTestResult) // 获取测试结果
+			test.POST("/files", testHandler.UploadTestFile)         // 上传测试文件
+			test.GET("/files/:id", testHandler.GetTestFile)         // 获取测试文件信息
+			test.DELETE("/files/:id", testHandler.DeleteTestFile)   // 删除测试文件
+
+			v1.POST("/tests/compare", scoped, readWrite, testHandler.CompareTest)             // 同一组样本对比两个配置版本的输出
+			v1.POST("/configs/:id/datasets/run", scoped, readWrite, testHandler.RunDatasets) // 运行配置上保存的全部测试数据集
 		}
 
+		tokenHandler := handlers.NewAgentTokenHandler(s.agentTokens, s.logger)
+		tokenHandler.SetCertificateService(s.agentCerts)
+		certHandler := handlers.NewAgentCertificateHandler(s.agentCerts, s.logger)
+
 		// Agent管理路由
-		agents := v1.Group("/agents")
+		agents := v1.Group("/agents", scoped, readWrite, handlers.AgentInProject(s.agentService))
 		{
 			agentHandler := handlers.NewAgentHandler(s.configService, s.logger)
+			agentHandler.SetLivenessMonitor(s.liveness)
 			
 			agents.GET("", agentHandler.ListAgents)           // 获取Agent列表
+			agents.GET("/export", agentHandler.ExportAgents)  // 以CSV或XLSX导出Agent清单
 			agents.GET("/:id", agentHandler.GetAgent)         // 获取单个Agent
 			agents.POST("/:id/deploy", agentHandler.DeployConfig) // 部署配置到Agent
+
+			decommissionHandler := handlers.NewAgentDecommissionHandler(s.decommissioner, s.logger)
+			agents.DELETE("/:id", middleware.RequireRole(models.RoleAdmin), decommissionHandler.Decommission) // 注销Agent并归档
+			agents.GET("/archived", decommissionHandler.ListArchived)                                       // 获取已注销Agent的归档记录
+
+			lifecycleHandler := handlers.NewAgentLifecycleHandler(s.agentService, s.logger)
+			agents.POST("/:id/commands", lifecycleHandler.EnqueueCommand) // 排入心跳命令
+
+			metricsHandler := handlers.NewMetricsHandler(s.agentMetrics, s.logger)
+			agents.GET("/:id/metrics", metricsHandler.GetAgentMetrics)     // 查询Agent指标时序（按间隔聚合）
+			agents.GET("/:id/pipelines", metricsHandler.GetAgentPipelines) // 获取Agent最新的各管道统计
+
+			eventHandler := handlers.NewAgentEventHandler(s.agentEvents, s.logger)
+			agents.GET("/:id/events", eventHandler.ListEvents) // 获取Agent事件时间线
+
+			usageHandler := handlers.NewConfigUsageHandler(s.configUsage, s.logger)
+			agents.GET("/:id/configs", usageHandler.ListAgentConfigs) // 获取Agent运行的配置及版本
+
+			groupHandler := handlers.NewGroupHandler(s.groupService, s.logger)
+			agents.GET("/:id/settings", groupHandler.GetAgentSettings)    // 获取Agent生效设置
+			agents.PUT("/:id/settings", groupHandler.UpdateAgentSettings) // 更新Agent分组及设置覆盖
+
+			var allowedOrigins []string
+			if viper.GetBool("security.cors.enabled") {
+				allowedOrigins = viper.GetStringSlice("security.cors.allowed_origins")
+			}
+			logStreamHandler := handlers.NewLogStreamHandler(s.logStreams, allowedOrigins, s.logger)
+			agents.GET("/:id/logs/stream", logStreamHandler.Stream) // 实时跟踪Agent的Logstash日志（WebSocket）
+
+			diagnosticHandler := handlers.NewDiagnosticHandler(s.diagnostics, s.logger)
+			agents.POST("/:id/diagnostics", diagnosticHandler.Run) // 在Agent上执行白名单内的诊断命令
+			dlqHandler := handlers.NewDLQHandler(s.dlq, s.logger)
+			agents.GET("/:id/dlq", dlqHandler.ListEvents) // 经Agent分页读取管道死信队列中的事件
+			pluginHandler := handlers.NewPluginInventoryHandler(s.plugins, s.logger)
+			agents.GET("/:id/plugins", pluginHandler.Get) // 获取Agent上报的Logstash插件清单
+			installHandler := handlers.NewPluginInstallHandler(s.pluginInstalls, s.logger)
+			agents.POST("/:id/plugins/install", middleware.RequireRole(models.RoleAdmin), installHandler.Install) // 经Agent安装或更新Logstash插件
+			agents.GET("/:id/plugins/installs", installHandler.ListInstalls)                                      // 获取Agent的插件安装记录
+			agents.GET("/:id/plugins/installs/:install_id", installHandler.GetInstall)                            // 获取插件安装的进度、结果和输出
+
+			agents.GET("/:id/tokens", tokenHandler.ListTokens) // 获取Agent注册令牌记录
+			agents.DELETE("/:id/tokens", tokenHandler.Revoke)  // 吊销Agent注册令牌
+			agents.GET("/:id/certificates", certHandler.ListCertificates) // 获取Agent客户端证书记录
+			agents.DELETE("/:id/certificates", certHandler.Revoke)        // 吊销Agent客户端证书
+		}
+
+		// 告警路由，告警规则和静默是平台级资源，按全局角色授权
+		alerts := v1.Group("/alerts", readWrite)
+		{
+			alertHandler := handlers.NewAlertHandler(s.alerts, s.logger)
+			alerts.GET("", alertHandler.ListAlerts)                    // 获取当前触发中的告警
+			alerts.GET("/rules", alertHandler.ListRules)               // 获取告警规则列表
+			alerts.POST("/rules", alertHandler.CreateRule)             // 创建告警规则
+			alerts.GET("/rules/:id", alertHandler.GetRule)             // 获取单个告警规则
+			alerts.PUT("/rules/:id", alertHandler.UpdateRule)          // 更新告警规则
+			alerts.DELETE("/rules/:id", alertHandler.DeleteRule)       // 删除告警规则
+			alerts.GET("/silences", alertHandler.ListSilences)         // 获取尚未结束的静默
+			alerts.POST("/silences", alertHandler.CreateSilence)       // 创建静默
+			alerts.DELETE("/silences/:id", alertHandler.DeleteSilence) // 删除静默
 		}
 
+		// Agent申请注册令牌，可使用共享令牌作为引导令牌
+		v1.POST("/agents/enroll", middleware.RequireRole(models.RoleAgent), middleware.SkipAudit(), tokenHandler.Enroll)
+
+		// Agent上报路由，使用Agent注册令牌（或未强制注册时的共享令牌）访问，启用客户端证书时还须出示证书
+		agentAPI := v1.Group("/agents", middleware.RequireRole(models.RoleAgent),
+			middleware.RequireEnrolledAgent(s.requireEnrollment), middleware.RequireClientCertificate(s.peerVerifier, s.requireClientCert),
+			middleware.RequireAgentIdentity(), middleware.SkipAudit())
+		{
+			lifecycleHandler := handlers.NewAgentLifecycleHandler(s.agentService, s.logger)
+			lifecycleHandler.SetTelemetryPolicy(s.telemetry)
+			lifecycleHandler.SetMetricsService(s.agentMetrics)
+			lifecycleHandler.SetEventService(s.agentEvents)
+			agentAPI.POST("/register", lifecycleHandler.Register)       // Agent注册
+			agentAPI.POST("/:id/heartbeat", lifecycleHandler.Heartbeat) // Agent心跳（捎带待执行命令）
+			agentAPI.POST("/:id/metrics", lifecycleHandler.ReportMetrics) // Agent上报指标
+
+			deregisterHandler := handlers.NewAgentDecommissionHandler(s.decommissioner, s.logger)
+			agentAPI.POST("/:id/deregister", deregisterHandler.Deregister) // Agent关闭时主动注销
+
+			agentAPI.GET("/:id/messages", wsHandler.Poll)    // 长轮询接收平台消息（代理不允许WebSocket时）
+			agentAPI.POST("/:id/messages", wsHandler.Upload) // 经长轮询通道上报消息
+
+			incidentHandler := handlers.NewIncidentHandler(s.incidents, s.logger)
+			agentAPI.POST("/:id/errors", incidentHandler.ReportError) // Agent上报错误（按指纹归并为事件）
+
+			deploymentHandler := handlers.NewDeploymentHandler(s.deployService, s.engine, s.logger)
+			agentAPI.POST("/:id/configs/applied", deploymentHandler.ReportConfigApplied) // Agent上报配置应用结果
+			agentAPI.GET("/:id/desired-configs", deploymentHandler.DesiredConfigs)        // Agent获取应运行的配置及版本
+
+			agentAPI.POST("/:id/token/rotate", tokenHandler.Rotate) // 轮换注册令牌
+			agentAPI.POST("/:id/certificate/rotate", certHandler.Rotate) // 轮换客户端证书
+
+			eventHandler := handlers.NewAgentEventHandler(s.agentEvents, s.logger)
+			agentAPI.POST("/:id/events", eventHandler.ReportEvent) // Agent上报重载失败、Logstash崩溃或重启事件
+
+			agentPluginHandler := handlers.NewPluginInventoryHandler(s.plugins, s.logger)
+			agentAPI.POST("/:id/plugins", agentPluginHandler.Report) // Agent上报已安装的Logstash插件清单
+
+			secretHandler := handlers.NewSecretHandler(s.secrets, s.logger)
+			agentAPI.POST("/:id/secrets/resolve", secretHandler.ResolveSecrets) // 获取待部署配置引用的密钥值
+		}
+
+		// Agent分组路由，分组是平台级资源，按全局角色授权
+		groups := v1.Group("/groups", readWrite)
+		{
+			groupHandler := handlers.NewGroupHandler(s.groupService, s.logger)
+
+			groups.GET("", groupHandler.ListGroups)           // 获取分组列表
+			groups.POST("", groupHandler.CreateGroup)         // 创建分组
+			groups.GET("/:name", groupHandler.GetGroup)       // 获取单个分组
+			groups.PUT("/:name", groupHandler.UpdateGroup)    // 更新分组默认设置
+			groups.DELETE("/:name", groupHandler.DeleteGroup) // 删除分组
+		}
+
+		// 密钥管理路由，值只写不读，变更需要admin角色，审计不记录请求体摘要
+		secrets := v1.Group("/secrets", middleware.RequireRoleByMethod(models.RoleViewer, models.RoleAdmin), middleware.OmitAuditBody())
+		{
+			secretHandler := handlers.NewSecretHandler(s.secrets, s.logger)
+
+			secrets.GET("", secretHandler.ListSecrets)           // 获取密钥列表（仅元数据）
+			secrets.POST("", secretHandler.CreateSecret)         // 创建密钥
+			secrets.GET("/:name", secretHandler.GetSecret)       // 获取单个密钥的元数据
+			secrets.PUT("/:name", secretHandler.UpdateSecret)    // 替换密钥值
+			secrets.DELETE("/:name", secretHandler.DeleteSecret) // 删除密钥
+		}
+
+		// 出站事件订阅路由，需要admin角色，审计不记录请求体摘要（包含签名密钥）
+		webhooks := v1.Group("/webhooks", middleware.RequireRole(models.RoleAdmin), middleware.OmitAuditBody())
+		{
+			webhookHandler := handlers.NewWebhookHandler(s.webhooks, s.logger)
+
+			webhooks.GET("", webhookHandler.ListWebhooks)                  // 获取订阅列表
+			webhooks.POST("", webhookHandler.CreateWebhook)                // 创建订阅
+			webhooks.GET("/:id", webhookHandler.GetWebhook)                // 获取单个订阅
+			webhooks.PUT("/:id", webhookHandler.UpdateWebhook)             // 更新订阅
+			webhooks.DELETE("/:id", webhookHandler.DeleteWebhook)          // 删除订阅
+			webhooks.GET("/:id/deliveries", webhookHandler.ListDeliveries) // 获取最近的推送记录
+		}
+
+		// 下游集群注册表路由，按全局角色授权
+		destinations := v1.Group("/destinations", readWrite)
+		{
+			destinationHandler := handlers.NewDestinationHandler(s.destinations, s.configService, s.logger)
+
+			destinations.GET("", destinationHandler.ListDestinations)              // 获取下游集群列表
+			destinations.POST("", destinationHandler.CreateDestination)            // 创建下游集群
+			destinations.GET("/:name", destinationHandler.GetDestination)          // 获取单个下游集群
+			destinations.PUT("/:name", destinationHandler.UpdateDestination)       // 更新下游集群
+			destinations.DELETE("/:name", destinationHandler.DeleteDestination)    // 删除下游集群
+			destinations.POST("/:name/check", destinationHandler.CheckDestination) // 立即检查连通性
+		}
+
+		// 平台自身运行状态，包含所有项目的汇总数据，需要admin角色
+		system := v1.Group("/system", middleware.RequireRole(models.RoleAdmin))
+		{
+			system.GET("/workers", handlers.WorkersStatus(s.workers))                   // 后台子系统运行状态
+			system.GET("/migrations", handlers.MigrationStatus(s.esClient, s.logger)) // 索引迁移状态
+			system.POST("/migrations", handlers.ApplyMigrations(s.esClient, s.logger)) // 执行索引迁移
+		}
+
+		// 字段契约路由，按全局角色授权
+		contracts := v1.Group("/contracts", readWrite)
+		{
+			contractHandler := handlers.NewContractHandler(s.contracts, s.logger)
+
+			contracts.GET("", contractHandler.ListContracts)         // 获取字段契约列表
+			contracts.POST("", contractHandler.CreateContract)       // 登记字段契约
+			contracts.GET("/:name", contractHandler.GetContract)     // 获取单个字段契约
+			contracts.PUT("/:name", contractHandler.UpdateContract)  // 更新字段契约
+			contracts.DELETE("/:name", contractHandler.DeleteContract) // 删除字段契约
+		}
+
+		// 流水线路由，流水线本身不属于项目，按全局角色授权；
+		// 拼装和部署读取组成配置并按配置所属项目检查，按请求项目内的角色授权
+		pipelineHandler := handlers.NewPipelineHandler(s.pipelines, s.logger)
+		pipelines := v1.Group("/pipelines", readWrite)
+		{
+			pipelines.GET("", pipelineHandler.ListPipelines)                  // 获取流水线列表
+			pipelines.POST("", pipelineHandler.CreatePipeline)                // 创建流水线
+			pipelines.GET("/:id", pipelineHandler.GetPipeline)                // 获取单个流水线
+			pipelines.PUT("/:id", pipelineHandler.UpdatePipeline)             // 更新流水线组成
+			pipelines.DELETE("/:id", pipelineHandler.DeletePipeline)          // 删除流水线
+			pipelines.GET("/:id/versions", pipelineHandler.GetPipelineVersions) // 获取流水线历史版本
+		}
+		projectPipelines := v1.Group("/pipelines", scoped, readWrite)
+		{
+			projectPipelines.GET("/:id/render", pipelineHandler.RenderPipeline)  // 按最新配置拼装并校验
+			projectPipelines.POST("/:id/deploy", pipelineHandler.DeployPipeline) // 部署流水线到Agent
+		}
+
+		// 部署记录路由
+		deployments := v1.Group("/deployments", scoped, readWrite)
+		{
+			deploymentHandler := handlers.NewDeploymentHandler(s.deployService, s.engine, s.logger)
+
+			deployments.GET("", deploymentHandler.ListDeployments)            // 获取部署记录列表
+			deployments.POST("", deploymentHandler.CreateDeployment)          // 创建部署并下发到目标Agent
+			deployments.POST("/preview", deploymentHandler.PreviewDeployment) // 预览部署，不下发
+			deployments.GET("/throttle", handlers.DestinationThrottleStats(s.throttle)) // 下游集群节流状态
+			deployments.GET("/:id", deploymentHandler.GetDeployment)          // 获取单个部署记录
+			deployments.POST("/:id/approvals", deploymentHandler.ApproveDeployment) // 提交部署审批
+			deployments.POST("/:id/rollback", deploymentHandler.RollbackDeployment) // 回滚部署，恢复各Agent部署前的版本
+			deployments.GET("/:id/report", deploymentHandler.GetDeploymentReport) // 导出部署审计报告
+		}
+
+		// 错误事件路由，按全局角色授权
+		incidents := v1.Group("/incidents", readWrite)
+		{
+			incidentHandler := handlers.NewIncidentHandler(s.incidents, s.logger)
+
+			incidents.GET("", incidentHandler.ListIncidents)               // 获取事件列表
+			incidents.GET("/:id", incidentHandler.GetIncident)             // 获取单个事件
+			incidents.POST("/:id/resolve", incidentHandler.ResolveIncident) // 解决事件
+		}
+
+		// 报表路由，只统计请求项目的部署记录
+		reports := v1.Group("/reports", scoped, readWrite)
+		{
+			reportHandler := handlers.NewReportHandler(s.metrics, s.logger)
+
+			reports.GET("/delivery", reportHandler.GetDeliveryReport) // 变更交付指标（DORA）
+		}
+
+		// 仪表盘总览
+		overviewHandler := handlers.NewOverviewHandler(s.overview, s.logger)
+		v1.GET("/overview", scoped, readWrite, overviewHandler.GetOverview) // Agent、配置和部署的汇总统计
+
+		// 声明式期望状态（lpctl apply），配置按请求项目处理，平台级的分组需要全局editor角色
+		desiredStateHandler := handlers.NewDesiredStateHandler(s.desiredState, s.logger)
+		v1.POST("/apply", scoped, middleware.RequireRole(models.RoleEditor), desiredStateHandler.Apply) // 计划或应用期望状态
+
 		// 批量操作路由
-		v1.POST("/deploy", handlers.BatchDeploy(s.configService, s.logger)) // 批量部署
+		v1.POST("/deploy", scoped, middleware.RequireRole(models.RoleEditor), handlers.BatchDeploy(s.engine, s.logger)) // 批量部署（按Agent ID或标签选择器）
 	}
 
 	// WebSocket路由
-	router.GET("/ws", middleware.AuthorizeWebSocket(), handlers.WebSocketHandler(s.logger))
+	router.GET("/ws", middleware.Authenticate(s.verifier), middleware.RequireRole(models.RoleAgent),
+		middleware.RequireEnrolledAgent(s.requireEnrollment), middleware.RequireClientCertificate(s.peerVerifier, s.requireClientCert),
+		middleware.AuthorizeWebSocket(), wsHandler.Connect)
 
 	s.router = router
 	return router
@@ -0,0 +1,243 @@
+// Package apperror 平台统一的错误码目录
+//
+// 服务和处理器返回带错误码的 *Error，HTTP层按目录中错误码对应的状态码返回 RFC 7807
+// problem+json 响应，处理器不再自行挑选状态码。未带错误码的错误按原因归类：
+// Elasticsearch不可达为 ES_UNAVAILABLE，文档不存在为 NOT_FOUND，版本冲突为 CONFLICT，其余为 INTERNAL_ERROR。
+package apperror
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// Code 错误码，对调用方稳定，响应中的 code 字段
+type Code string
+
+// 通用错误码
+const (
+	InvalidRequest Code = "INVALID_REQUEST" // 请求格式或参数无效
+	BadRequest     Code = "BAD_REQUEST"     // 请求无法处理
+	Validation     Code = "VALIDATION"      // 提交的内容未通过校验
+	Unauthorized   Code = "UNAUTHORIZED"    // 未认证或令牌无效
+	Forbidden      Code = "FORBIDDEN"       // 无权执行该操作
+	NotFound       Code = "NOT_FOUND"       // 资源不存在
+	Conflict       Code = "CONFLICT"        // 与资源的当前状态冲突
+	AlreadyExists  Code = "ALREADY_EXISTS"  // 资源已存在
+	Internal       Code = "INTERNAL_ERROR"  // 平台内部错误
+	ESUnavailable  Code = "ES_UNAVAILABLE"  // Elasticsearch不可达
+)
+
+// 业务错误码
+const (
+	InvalidCredentials        Code = "INVALID_CREDENTIALS"
+	InvalidRole               Code = "INVALID_ROLE"
+	UserExists                Code = "USER_EXISTS"
+	InvalidProject            Code = "INVALID_PROJECT"
+	ProjectMismatch           Code = "PROJECT_MISMATCH"
+	InvalidACL                Code = "INVALID_ACL"
+	InvalidCursor             Code = "INVALID_CURSOR"
+	InvalidSelector           Code = "INVALID_SELECTOR"
+	InvalidConfigID           Code = "INVALID_CONFIG_ID"
+	InvalidExportColumn       Code = "INVALID_EXPORT_COLUMN"
+	InvalidFieldType          Code = "INVALID_FIELD_TYPE"
+	InvalidState              Code = "INVALID_STATE"
+	InvalidStrategy           Code = "INVALID_STRATEGY"
+	UnsupportedFormat         Code = "UNSUPPORTED_FORMAT"
+	NoTargets                 Code = "NO_TARGETS"
+	VersionNotFound           Code = "VERSION_NOT_FOUND"
+	TestNotFound              Code = "TEST_NOT_FOUND"
+	ConfigDisabled            Code = "CONFIG_DISABLED"
+	ConfigNotApproved         Code = "CONFIG_NOT_APPROVED"
+	ReviewerNotAllowed        Code = "REVIEWER_NOT_ALLOWED"
+	DeploymentInProgress      Code = "DEPLOYMENT_IN_PROGRESS"
+	RollbackNotAllowed        Code = "ROLLBACK_NOT_ALLOWED"
+	TestGateFailed            Code = "TEST_GATE_FAILED"
+	TestGateOverrideForbidden Code = "TEST_GATE_OVERRIDE_FORBIDDEN"
+	PluginsMissing            Code = "PLUGINS_MISSING"
+	AlreadyEnrolled           Code = "ALREADY_ENROLLED"
+	AgentNotConnected         Code = "AGENT_NOT_CONNECTED"
+	AgentError                Code = "AGENT_ERROR"
+	AgentTimeout              Code = "AGENT_TIMEOUT"
+	RemoveFailed              Code = "REMOVE_FAILED"
+	RenderFailed              Code = "RENDER_FAILED"
+	TooManyStreams            Code = "TOO_MANY_STREAMS"
+	PDFNotSupported           Code = "PDF_NOT_SUPPORTED"
+	ValidatorUnavailable      Code = "VALIDATOR_UNAVAILABLE"
+	SecretsDisabled           Code = "SECRETS_DISABLED"
+	CertificatesDisabled      Code = "CERTIFICATES_DISABLED"
+	DatasetsUnavailable       Code = "DATASETS_UNAVAILABLE"
+	ShuttingDown              Code = "SHUTTING_DOWN"
+	PayloadTooLarge           Code = "PAYLOAD_TOO_LARGE"
+	ConfigTooLarge            Code = "CONFIG_TOO_LARGE"
+	TestQueueFull             Code = "TEST_QUEUE_FULL"
+	TestTooLarge              Code = "TEST_TOO_LARGE"
+	TestFileNotFound          Code = "TEST_FILE_NOT_FOUND"
+	TestFileTooLarge          Code = "TEST_FILE_TOO_LARGE"
+	TestFilesUnavailable      Code = "TEST_FILES_UNAVAILABLE"
+	TestSourceForbidden       Code = "TEST_SOURCE_FORBIDDEN"
+	TestSourceUnavailable     Code = "TEST_SOURCE_UNAVAILABLE"
+	InvalidPluginCatalog      Code = "INVALID_PLUGIN_CATALOG"
+)
+
+// Entry 错误码目录中的一项
+type Entry struct {
+	Code   Code   `json:"code"`
+	Status int    `json:"status"` // HTTP状态码
+	Title  string `json:"title"`  // 错误码的简短说明，即problem+json的title
+}
+
+// catalog 错误码目录，错误码与HTTP状态码一一对应
+var catalog = map[Code]Entry{
+	InvalidRequest: {Status: http.StatusBadRequest, Title: "请求参数无效"},
+	BadRequest:     {Status: http.StatusBadRequest, Title: "请求无法处理"},
+	Validation:     {Status: http.StatusBadRequest, Title: "内容未通过校验"},
+	Unauthorized:   {Status: http.StatusUnauthorized, Title: "未认证"},
+	Forbidden:      {Status: http.StatusForbidden, Title: "无权执行该操作"},
+	NotFound:       {Status: http.StatusNotFound, Title: "资源不存在"},
+	Conflict:       {Status: http.StatusConflict, Title: "与资源的当前状态冲突"},
+	AlreadyExists:  {Status: http.StatusConflict, Title: "资源已存在"},
+	Internal:       {Status: http.StatusInternalServerError, Title: "平台内部错误"},
+	ESUnavailable:  {Status: http.StatusServiceUnavailable, Title: "Elasticsearch不可用"},
+
+	InvalidCredentials:        {Status: http.StatusUnauthorized, Title: "用户名或密码错误"},
+	InvalidRole:               {Status: http.StatusBadRequest, Title: "角色无效"},
+	UserExists:                {Status: http.StatusConflict, Title: "用户已存在"},
+	InvalidProject:            {Status: http.StatusBadRequest, Title: "项目无效"},
+	ProjectMismatch:           {Status: http.StatusBadRequest, Title: "不属于同一项目"},
+	InvalidACL:                {Status: http.StatusBadRequest, Title: "访问控制无效"},
+	InvalidCursor:             {Status: http.StatusBadRequest, Title: "游标无效"},
+	InvalidSelector:           {Status: http.StatusBadRequest, Title: "标签选择器无效"},
+	InvalidConfigID:           {Status: http.StatusBadRequest, Title: "配置ID无效"},
+	InvalidExportColumn:       {Status: http.StatusBadRequest, Title: "导出列无效"},
+	InvalidFieldType:          {Status: http.StatusBadRequest, Title: "字段类型无效"},
+	InvalidState:              {Status: http.StatusBadRequest, Title: "状态无效"},
+	InvalidStrategy:           {Status: http.StatusBadRequest, Title: "部署策略无效"},
+	UnsupportedFormat:         {Status: http.StatusBadRequest, Title: "不支持的格式"},
+	NoTargets:                 {Status: http.StatusBadRequest, Title: "没有匹配的Agent"},
+	VersionNotFound:           {Status: http.StatusNotFound, Title: "版本不存在"},
+	TestNotFound:              {Status: http.StatusNotFound, Title: "测试不存在"},
+	ConfigDisabled:            {Status: http.StatusConflict, Title: "配置已禁用"},
+	ConfigNotApproved:         {Status: http.StatusConflict, Title: "配置未通过审批"},
+	ReviewerNotAllowed:        {Status: http.StatusForbidden, Title: "不能审批该配置版本"},
+	DeploymentInProgress:      {Status: http.StatusConflict, Title: "部署尚未完成"},
+	RollbackNotAllowed:        {Status: http.StatusConflict, Title: "部署无法回滚"},
+	TestGateFailed:            {Status: http.StatusPreconditionFailed, Title: "未通过测试门禁"},
+	TestGateOverrideForbidden: {Status: http.StatusForbidden, Title: "不能跳过测试门禁"},
+	PluginsMissing:            {Status: http.StatusPreconditionFailed, Title: "目标Agent缺少插件"},
+	AlreadyEnrolled:           {Status: http.StatusConflict, Title: "Agent已注册"},
+	AgentNotConnected:         {Status: http.StatusConflict, Title: "Agent未连接"},
+	AgentError:                {Status: http.StatusBadGateway, Title: "Agent处理失败"},
+	AgentTimeout:              {Status: http.StatusGatewayTimeout, Title: "等待Agent超时"},
+	RemoveFailed:              {Status: http.StatusGatewayTimeout, Title: "从Agent移除失败"},
+	RenderFailed:              {Status: http.StatusUnprocessableEntity, Title: "渲染配置失败"},
+	TooManyStreams:            {Status: http.StatusTooManyRequests, Title: "实时日志流过多"},
+	PDFNotSupported:           {Status: http.StatusNotAcceptable, Title: "不支持PDF格式"},
+	ValidatorUnavailable:      {Status: http.StatusServiceUnavailable, Title: "校验器不可用"},
+	SecretsDisabled:           {Status: http.StatusServiceUnavailable, Title: "未启用密钥"},
+	CertificatesDisabled:      {Status: http.StatusServiceUnavailable, Title: "未启用客户端证书"},
+	DatasetsUnavailable:       {Status: http.StatusServiceUnavailable, Title: "未启用测试数据集"},
+	ShuttingDown:              {Status: http.StatusServiceUnavailable, Title: "平台正在关闭"},
+	PayloadTooLarge:           {Status: http.StatusRequestEntityTooLarge, Title: "请求体过大"},
+	ConfigTooLarge:            {Status: http.StatusRequestEntityTooLarge, Title: "配置内容过大"},
+	TestQueueFull:             {Status: http.StatusTooManyRequests, Title: "测试队列已满"},
+	TestTooLarge:              {Status: http.StatusRequestEntityTooLarge, Title: "测试样本过多"},
+	TestFileNotFound:          {Status: http.StatusNotFound, Title: "测试文件不存在"},
+	TestFileTooLarge:          {Status: http.StatusRequestEntityTooLarge, Title: "测试文件过大"},
+	TestFilesUnavailable:      {Status: http.StatusServiceUnavailable, Title: "未启用测试文件"},
+	TestSourceForbidden:       {Status: http.StatusForbidden, Title: "不允许读取的测试数据源"},
+	TestSourceUnavailable:     {Status: http.StatusServiceUnavailable, Title: "未启用测试数据源"},
+	InvalidPluginCatalog:      {Status: http.StatusUnprocessableEntity, Title: "插件目录文件无效"},
+}
+
+// Status 错误码对应的HTTP状态码，目录中没有的错误码为500
+func (c Code) Status() int {
+	if entry, ok := catalog[c]; ok {
+		return entry.Status
+	}
+	return http.StatusInternalServerError
+}
+
+// Title 错误码的简短说明
+func (c Code) Title() string {
+	if entry, ok := catalog[c]; ok {
+		return entry.Title
+	}
+	return catalog[Internal].Title
+}
+
+// Catalog 按错误码排序的完整目录
+func Catalog() []Entry {
+	entries := make([]Entry, 0, len(catalog))
+	for code, entry := range catalog {
+		entry.Code = code
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Code < entries[j].Code })
+	return entries
+}
+
+// Error 带错误码的错误，Message 返回给调用方，Cause 只用于日志和 errors.Is 判断
+type Error struct {
+	Code    Code
+	Message string
+	Cause   error
+}
+
+// New 创建带错误码的错误
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Wrap 以调用方可见的说明包装原因，错误码按原因归类
+// 原因中带错误码时沿用其错误码，并把其说明附在message之后；其余原因的细节不返回给调用方
+func Wrap(err error, message string) *Error {
+	var coded *Error
+	if errors.As(err, &coded) {
+		return &Error{Code: coded.Code, Message: message + ": " + coded.Message, Cause: err}
+	}
+	return &Error{Code: classify(err), Message: message, Cause: err}
+}
+
+// From 将任意错误转换为带错误码的错误
+// 原因链中带错误码时沿用其错误码，说明为完整的错误信息；其余按原因归类，说明为错误码的简短说明
+func From(err error) *Error {
+	var coded *Error
+	if errors.As(err, &coded) {
+		if coded == err {
+			return coded
+		}
+		return &Error{Code: coded.Code, Message: err.Error(), Cause: err}
+	}
+	code := classify(err)
+	return &Error{Code: code, Message: code.Title(), Cause: err}
+}
+
+// classify 按原因归类未带错误码的错误
+func classify(err error) Code {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		return PayloadTooLarge
+	case errors.Is(err, elasticsearch.ErrUnavailable):
+		return ESUnavailable
+	case errors.Is(err, elasticsearch.ErrNotFound):
+		return NotFound
+	case errors.Is(err, elasticsearch.ErrVersionConflict):
+		return Conflict
+	default:
+		return Internal
+	}
+}
+
+// Error 实现error接口，只包含调用方可见的说明
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap 返回原因
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
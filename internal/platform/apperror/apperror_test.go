@@ -0,0 +1,89 @@
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"logstash-platform/pkg/elasticsearch"
+)
+
+func TestCodeStatus(t *testing.T) {
+	assert.Equal(t, http.StatusNotFound, NotFound.Status())
+	assert.Equal(t, http.StatusConflict, Conflict.Status())
+	assert.Equal(t, http.StatusBadRequest, Validation.Status())
+	assert.Equal(t, http.StatusServiceUnavailable, ESUnavailable.Status())
+	assert.Equal(t, http.StatusInternalServerError, Code("UNKNOWN").Status(), "目录中没有的错误码为500")
+	assert.Equal(t, Internal.Title(), Code("UNKNOWN").Title())
+}
+
+func TestCatalog(t *testing.T) {
+	entries := Catalog()
+	assert.Len(t, entries, len(catalog))
+	for i, entry := range entries {
+		assert.NotEmpty(t, entry.Title, entry.Code)
+		assert.NotZero(t, entry.Status, entry.Code)
+		if i > 0 {
+			assert.Less(t, entries[i-1].Code, entry.Code, "按错误码排序")
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		expectedErr *Error
+	}{
+		{
+			name:        "Elasticsearch不可用",
+			err:         fmt.Errorf("搜索失败: %w", elasticsearch.ErrUnavailable),
+			expectedErr: &Error{Code: ESUnavailable, Message: "获取配置失败"},
+		},
+		{
+			name:        "文档不存在",
+			err:         fmt.Errorf("获取文档失败: %w", elasticsearch.ErrNotFound),
+			expectedErr: &Error{Code: NotFound, Message: "获取配置失败"},
+		},
+		{
+			name:        "版本冲突",
+			err:         elasticsearch.ErrVersionConflict,
+			expectedErr: &Error{Code: Conflict, Message: "获取配置失败"},
+		},
+		{
+			name:        "沿用原因的错误码",
+			err:         fmt.Errorf("部署失败: %w", New(ConfigDisabled, "配置已禁用")),
+			expectedErr: &Error{Code: ConfigDisabled, Message: "获取配置失败: 配置已禁用"},
+		},
+		{
+			name:        "其余为内部错误",
+			err:         errors.New("connection reset"),
+			expectedErr: &Error{Code: Internal, Message: "获取配置失败"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Wrap(tt.err, "获取配置失败")
+			assert.Equal(t, tt.expectedErr.Code, err.Code)
+			assert.Equal(t, tt.expectedErr.Message, err.Error())
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestFrom(t *testing.T) {
+	coded := New(NotFound, "配置不存在")
+	assert.Same(t, coded, From(coded))
+
+	err := From(fmt.Errorf("回滚失败: %w", coded))
+	assert.Equal(t, NotFound, err.Code)
+	assert.Equal(t, "回滚失败: 配置不存在", err.Message)
+
+	err = From(fmt.Errorf("索引失败: %w", elasticsearch.ErrUnavailable))
+	assert.Equal(t, ESUnavailable, err.Code)
+	assert.Equal(t, ESUnavailable.Title(), err.Message, "未带错误码的错误只返回错误码的说明")
+}
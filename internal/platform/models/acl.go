@@ -0,0 +1,132 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// 配置级权限
+const (
+	PermissionRead   = "read"   // 查看配置内容、历史和差异
+	PermissionEdit   = "edit"   // 修改、删除、回滚配置及调整访问控制
+	PermissionDeploy = "deploy" // 将配置部署到Agent
+)
+
+// 访问控制主体前缀，主体写作 user:<用户名> 或 team:<团队>
+const (
+	PrincipalUserPrefix = "user:"
+	PrincipalTeamPrefix = "team:"
+)
+
+// ConfigACL 配置的访问控制列表
+// 为空时不做额外限制，仅按角色控制；设置后只有列出的主体可以访问，编辑和部署权限均隐含读取权限
+type ConfigACL struct {
+	Readers   []string `json:"readers,omitempty"`
+	Editors   []string `json:"editors,omitempty"`
+	Deployers []string `json:"deployers,omitempty"`
+}
+
+// Empty 是否未设置任何主体
+func (a *ConfigACL) Empty() bool {
+	return a == nil || len(a.Readers)+len(a.Editors)+len(a.Deployers) == 0
+}
+
+// Normalize 校验主体格式并去重排序，读取列表补齐拥有编辑或部署权限的主体，便于列表查询按单个字段过滤
+func (a *ConfigACL) Normalize() error {
+	for _, list := range [][]string{a.Readers, a.Editors, a.Deployers} {
+		for _, p := range list {
+			name, ok := strings.CutPrefix(p, PrincipalUserPrefix)
+			if !ok {
+				name, ok = strings.CutPrefix(p, PrincipalTeamPrefix)
+			}
+			if !ok || name == "" {
+				return fmt.Errorf("无效的访问控制主体: %q，应为 user:<用户名> 或 team:<团队>", p)
+			}
+		}
+	}
+
+	a.Editors = normalizePrincipals(a.Editors)
+	a.Deployers = normalizePrincipals(a.Deployers)
+	a.Readers = normalizePrincipals(slices.Concat(a.Readers, a.Editors, a.Deployers))
+	return nil
+}
+
+// Allows 判断主体是否拥有配置的指定权限
+func (a *ConfigACL) Allows(p *Principal, permission string) bool {
+	if a.Empty() || p.Unrestricted() {
+		return true
+	}
+
+	var granted []string
+	switch permission {
+	case PermissionRead:
+		granted = slices.Concat(a.Readers, a.Editors, a.Deployers)
+	case PermissionEdit:
+		granted = a.Editors
+	case PermissionDeploy:
+		granted = a.Deployers
+	default:
+		return false
+	}
+	for _, name := range p.Principals() {
+		if slices.Contains(granted, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizePrincipals 去重并排序
+func normalizePrincipals(principals []string) []string {
+	if len(principals) == 0 {
+		return nil
+	}
+	sorted := slices.Clone(principals)
+	slices.Sort(sorted)
+	return slices.Compact(sorted)
+}
+
+// Principal 发起请求的身份，由认证中间件写入请求上下文
+type Principal struct {
+	User         string
+	Role         string // 当前请求的角色，按项目隔离的路由中为项目内的角色
+	PlatformRole string // 令牌中的全局角色，平台级资源（如分组）按该角色授权
+	Teams        []string
+}
+
+// PlatformAllows 全局角色是否满足要求，未启用认证（无身份）时不限制
+func (p *Principal) PlatformAllows(required string) bool {
+	return p == nil || RoleAllows(p.PlatformRole, required)
+}
+
+// Unrestricted 不受配置访问控制限制的身份：未启用认证（无身份）、管理员和Agent
+// Agent需要拉取下发给它的配置内容
+func (p *Principal) Unrestricted() bool {
+	return p == nil || p.Role == RoleAdmin || p.Role == RoleAgent
+}
+
+// Principals 身份对应的全部访问控制主体
+func (p *Principal) Principals() []string {
+	principals := make([]string, 0, len(p.Teams)+1)
+	principals = append(principals, PrincipalUserPrefix+p.User)
+	for _, team := range p.Teams {
+		principals = append(principals, PrincipalTeamPrefix+team)
+	}
+	return principals
+}
+
+// principalKey 上下文中保存身份的键
+type principalKey struct{}
+
+// WithPrincipal 在上下文中记录请求身份
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom 获取上下文中的请求身份，后台任务等没有身份时返回nil
+func PrincipalFrom(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
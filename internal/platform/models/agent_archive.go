@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// 注销的发起方
+const (
+	DecommissionByOperator = "operator" // 管理员经 DELETE /api/v1/agents/:id 注销
+	DecommissionByAgent    = "agent"    // Agent关闭时主动注销
+)
+
+// DeregisterRequest Agent关闭时主动注销的请求
+type DeregisterRequest struct {
+	Reason string `json:"reason"` // 注销原因，例如主机下线
+}
+
+// ArchivedAgent 已注销Agent的归档记录，保留注销时Agent的全部信息
+// 同一Agent ID注销后重新注册、再次注销时产生新的归档记录
+type ArchivedAgent struct {
+	ArchiveID string `json:"archive_id"`
+	Agent
+	Initiator        string    `json:"initiator"` // operator 或 agent
+	DecommissionedBy string    `json:"decommissioned_by"`
+	DecommissionedAt time.Time `json:"decommissioned_at"`
+	Reason           string    `json:"reason,omitempty"`
+}
+
+// ArchivedAgentListRequest 已注销Agent列表请求，按注销时间倒序
+type ArchivedAgentListRequest struct {
+	AgentID  string `form:"agent_id"`
+	Page     int    `form:"page,default=1" binding:"min=1"`
+	PageSize int    `form:"size,default=20" binding:"min=1,max=100"`
+
+	// Project 只返回该项目的归档记录，为空时不过滤，由服务层按请求的项目填写
+	Project string `form:"-" json:"-"`
+}
+
+// ArchivedAgentListResponse 已注销Agent列表响应
+type ArchivedAgentListResponse struct {
+	Total int64            `json:"total"`
+	Page  int              `json:"page"`
+	Size  int              `json:"size"`
+	Items []*ArchivedAgent `json:"items"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+)
+
+// Agent客户端证书状态
+const (
+	AgentCertificateActive  = "active"
+	AgentCertificateRevoked = "revoked"
+)
+
+// AgentCertificate 平台CA签发给单个Agent的客户端证书记录
+// 证书序列号即文档ID，证书的CN为Agent ID；平台不保存Agent私钥
+type AgentCertificate struct {
+	Serial      string     `json:"serial"` // 十六进制序列号
+	AgentID     string     `json:"agent_id"`
+	Status      string     `json:"status"`      // active, revoked
+	Fingerprint string     `json:"fingerprint"` // 证书DER的SHA-256
+	NotBefore   time.Time  `json:"not_before"`
+	NotAfter    time.Time  `json:"not_after"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CreatedBy   string     `json:"created_by"`
+	RetiresAt   *time.Time `json:"retires_at,omitempty"` // 轮换后旧证书的宽限截止时间
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	RevokedBy   string     `json:"revoked_by,omitempty"`
+	RotatedTo   string     `json:"rotated_to,omitempty"` // 轮换产生的新证书序列号
+}
+
+// Usable 证书在指定时间是否可用于访问Agent接口
+func (c *AgentCertificate) Usable(now time.Time) bool {
+	if c.Status != AgentCertificateActive || now.Before(c.NotBefore) || !now.Before(c.NotAfter) {
+		return false
+	}
+	return c.RetiresAt == nil || now.Before(*c.RetiresAt)
+}
+
+// IssuedCertificate 签发的客户端证书
+type IssuedCertificate struct {
+	Serial        string    `json:"serial"`
+	Certificate   string    `json:"certificate"`    // PEM编码的客户端证书
+	CACertificate string    `json:"ca_certificate"` // PEM编码的平台CA证书，Agent可用于校验平台
+	NotAfter      time.Time `json:"not_after"`
+}
+
+// RotateAgentCertificateRequest 客户端证书轮换请求，Agent使用新私钥生成CSR
+type RotateAgentCertificateRequest struct {
+	CSR string `json:"csr" binding:"required"` // PEM编码的证书签名请求
+}
+
+// RevokeAgentCertificatesResponse 吊销结果
+type RevokeAgentCertificatesResponse struct {
+	AgentID string `json:"agent_id"`
+	Revoked int    `json:"revoked"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+)
+
+// Agent状态
+const (
+	AgentStatusOnline   = "online"
+	AgentStatusDegraded = "degraded" // 心跳延迟但尚未判定离线
+	AgentStatusWarning  = "warning"  // Agent上报磁盘或内存使用率超过阈值，暂不接受新的配置部署
+	AgentStatusOffline  = "offline"
+
+	AgentStatusDecommissioned = "decommissioned" // 已注销，仅出现在归档记录中
+)
+
+// Agent事件日志中的其他事件类型，registered 和 status_changed 与生命周期事件类型共用
+const (
+	AgentEventReconnected       = "reconnected"         // 已知Agent重新注册（Agent重启或平台丢失其状态后）
+	AgentEventConfigApplied     = "config_applied"      // Agent上报配置应用成功
+	AgentEventConfigApplyFailed = "config_apply_failed" // Agent上报配置应用失败
+	AgentEventReloadFailed      = "reload_failed"       // Agent上报Logstash重载失败
+	AgentEventLogstashCrashed   = "logstash_crashed"    // Agent上报Logstash进程意外退出
+	AgentEventLogstashRestarted = "logstash_restarted"  // Agent上报已重新拉起Logstash
+	AgentEventResourcePressure  = "resource_pressure"   // Agent上报磁盘或内存使用率超过阈值
+	AgentEventResourceRecovered = "resource_recovered"  // Agent上报资源使用率已恢复到阈值以下
+	AgentEventDecommissioned    = "decommissioned"      // Agent被管理员注销或关闭时主动注销，已从Agent列表移入归档
+)
+
+// AgentEvent Agent事件日志中的一条记录
+type AgentEvent struct {
+	ID            string    `json:"id"`
+	AgentID       string    `json:"agent_id"`
+	Type          string    `json:"type"`           // 与生命周期事件类型一致，如 status_changed
+	From          string    `json:"from,omitempty"` // 状态变更前的状态
+	To            string    `json:"to,omitempty"`   // 状态变更后的状态
+	Reason        string    `json:"reason,omitempty"`
+	ConfigID      string    `json:"config_id,omitempty"` // 配置相关事件的配置
+	ConfigVersion int       `json:"config_version,omitempty"`
+	DeploymentID  string    `json:"deployment_id,omitempty"`
+	LastHeartbeat time.Time `json:"last_heartbeat"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// AgentEventReport Agent自身上报的事件
+type AgentEventReport struct {
+	Type      string    `json:"type" binding:"required,oneof=reload_failed logstash_crashed logstash_restarted resource_pressure resource_recovered"`
+	Reason    string    `json:"reason"`
+	ConfigID  string    `json:"config_id"`
+	Timestamp time.Time `json:"timestamp"` // 事件发生时间，默认为平台收到的时间
+}
+
+// AgentEventListRequest Agent事件时间线查询条件
+type AgentEventListRequest struct {
+	Type  string    `form:"type"`
+	Since time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00"` // 事件时间下限
+	Until time.Time `form:"until" time_format:"2006-01-02T15:04:05Z07:00"` // 事件时间上限
+	Size  int       `form:"size,default=100" binding:"min=1,max=1000"`     // 返回范围内最近的若干条
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+)
+
+// Agent注册令牌状态
+const (
+	AgentTokenActive  = "active"
+	AgentTokenRevoked = "revoked"
+)
+
+// AgentToken 平台签发给单个Agent的注册令牌，令牌格式见 pkg/agentauth
+// 令牌ID即文档ID，平台只保存密钥的SHA-256
+type AgentToken struct {
+	ID         string     `json:"id"`
+	AgentID    string     `json:"agent_id"`
+	SecretHash string     `json:"secret_hash,omitempty"` // 仅存储使用，接口返回前清空
+	Status     string     `json:"status"`                // active, revoked
+	CreatedAt  time.Time  `json:"created_at"`
+	CreatedBy  string     `json:"created_by"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"` // 轮换后旧令牌的宽限截止时间
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	RevokedBy  string     `json:"revoked_by,omitempty"`
+	RotatedTo  string     `json:"rotated_to,omitempty"` // 轮换产生的新令牌ID
+}
+
+// Sanitized 返回去除密钥哈希的副本
+func (t *AgentToken) Sanitized() *AgentToken {
+	cp := *t
+	cp.SecretHash = ""
+	return &cp
+}
+
+// Usable 令牌在指定时间是否可用
+func (t *AgentToken) Usable(now time.Time) bool {
+	if t.Status != AgentTokenActive {
+		return false
+	}
+	return t.ExpiresAt == nil || now.Before(*t.ExpiresAt)
+}
+
+// EnrollAgentRequest Agent注册令牌申请
+// 启用客户端证书时可同时提交CSR，平台CA签发以Agent ID为CN的客户端证书
+type EnrollAgentRequest struct {
+	AgentID string `json:"agent_id" binding:"required"`
+	CSR     string `json:"csr,omitempty"` // PEM编码的证书签名请求
+}
+
+// AgentTokenResponse 签发的注册令牌，明文令牌只在签发时返回一次
+type AgentTokenResponse struct {
+	AgentID  string    `json:"agent_id"`
+	TokenID  string    `json:"token_id"`
+	Token    string    `json:"token"`
+	IssuedAt time.Time `json:"issued_at"`
+
+	Certificate *IssuedCertificate `json:"certificate,omitempty"` // 申请时提交了CSR才返回
+}
+
+// RevokeAgentTokensResponse 吊销结果
+type RevokeAgentTokensResponse struct {
+	AgentID string `json:"agent_id"`
+	Revoked int    `json:"revoked"`
+}
@@ -0,0 +1,138 @@
+package models
+
+import (
+	"time"
+)
+
+// 告警规则类型
+const (
+	AlertRuleAgentOffline      = "agent_offline"       // Agent心跳超过持续时间未更新
+	AlertRuleConfigApplyFailed = "config_apply_failed" // 回看窗口内有部署结果为失败
+	AlertRuleMetricThreshold   = "metric_threshold"    // 指标越过阈值并持续一段时间
+)
+
+// 阈值规则可用的指标，对应指标时序索引中的字段
+const (
+	AlertMetricCPUUsage      = "cpu_usage"
+	AlertMetricMemoryUsage   = "memory_usage"
+	AlertMetricDiskUsage     = "disk_usage"
+	AlertMetricJVMHeapUsed   = "jvm_heap_used_percent"
+	AlertMetricEventsInRate  = "events_in_rate"
+	AlertMetricEventsOutRate = "events_out_rate"
+)
+
+// 阈值比较方式
+const (
+	AlertOperatorAbove = ">"
+	AlertOperatorBelow = "<"
+)
+
+// 告警级别
+const (
+	AlertSeverityInfo     = "info"
+	AlertSeverityWarning  = "warning"
+	AlertSeverityCritical = "critical"
+)
+
+// 告警状态
+const (
+	AlertStateFiring   = "firing"
+	AlertStateResolved = "resolved"
+)
+
+// 通知渠道类型
+const (
+	AlertChannelWebhook  = "webhook"
+	AlertChannelEmail    = "email"
+	AlertChannelDingTalk = "dingtalk"
+	AlertChannelWeCom    = "wecom" // 企业微信群机器人
+)
+
+// AlertRule 告警规则
+type AlertRule struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Type        string    `json:"type"`               // agent_offline, config_apply_failed, metric_threshold
+	Selector    string    `json:"selector,omitempty"` // Agent标签选择器，为空时匹配全部Agent
+	Metric      string    `json:"metric,omitempty"`   // 阈值规则的指标
+	Operator    string    `json:"operator,omitempty"` // 阈值规则的比较方式，> 或 <
+	Threshold   float64   `json:"threshold,omitempty"`
+	ForSeconds  int       `json:"for_seconds"` // 离线规则为离线时长，阈值规则为条件持续时间，应用失败规则为回看窗口
+	Severity    string    `json:"severity"`
+	Channels    []string  `json:"channels"` // 通知渠道名称，对应平台配置 alerting.channels
+	Enabled     bool      `json:"enabled"`
+	CreatedBy   string    `json:"created_by"`
+	UpdatedBy   string    `json:"updated_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// For 规则的持续时间
+func (r *AlertRule) For() time.Duration {
+	return time.Duration(r.ForSeconds) * time.Second
+}
+
+// AlertRuleRequest 创建或更新告警规则的请求
+type AlertRuleRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	Description string   `json:"description"`
+	Type        string   `json:"type" binding:"required,oneof=agent_offline config_apply_failed metric_threshold"`
+	Selector    string   `json:"selector"`
+	Metric      string   `json:"metric"`
+	Operator    string   `json:"operator"`
+	Threshold   float64  `json:"threshold"`
+	ForSeconds  int      `json:"for_seconds" binding:"min=0"`
+	Severity    string   `json:"severity" binding:"omitempty,oneof=info warning critical"`
+	Channels    []string `json:"channels"`
+	Enabled     *bool    `json:"enabled"` // 默认启用
+}
+
+// AlertSilence 告警静默，时间范围内匹配的告警不发送通知
+// 规则ID和Agent ID至少指定一个，同时指定时两者都匹配才静默
+type AlertSilence struct {
+	ID        string    `json:"id"`
+	RuleID    string    `json:"rule_id,omitempty"`
+	AgentID   string    `json:"agent_id,omitempty"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	Comment   string    `json:"comment"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Active 静默在指定时间是否生效
+func (s *AlertSilence) Active(now time.Time) bool {
+	return !now.Before(s.StartsAt) && now.Before(s.EndsAt)
+}
+
+// Matches 静默是否覆盖该告警
+func (s *AlertSilence) Matches(alert *Alert) bool {
+	return (s.RuleID == "" || s.RuleID == alert.RuleID) && (s.AgentID == "" || s.AgentID == alert.AgentID)
+}
+
+// CreateAlertSilenceRequest 创建告警静默的请求
+type CreateAlertSilenceRequest struct {
+	RuleID   string    `json:"rule_id"`
+	AgentID  string    `json:"agent_id"`
+	StartsAt time.Time `json:"starts_at"` // 默认立即生效
+	EndsAt   time.Time `json:"ends_at" binding:"required"`
+	Comment  string    `json:"comment" binding:"required"`
+}
+
+// Alert 规则针对单个Agent产生的告警
+type Alert struct {
+	ID              string     `json:"id"` // 规则ID/Agent ID
+	RuleID          string     `json:"rule_id"`
+	RuleName        string     `json:"rule_name"`
+	Type            string     `json:"type"`
+	Severity        string     `json:"severity"`
+	AgentID         string     `json:"agent_id"`
+	State           string     `json:"state"`           // firing, resolved
+	Value           *float64   `json:"value,omitempty"` // 阈值规则最近一次评估的指标值
+	Message         string     `json:"message"`
+	StartsAt        time.Time  `json:"starts_at"`
+	ResolvedAt      *time.Time `json:"resolved_at,omitempty"`
+	LastEvaluatedAt time.Time  `json:"last_evaluated_at"`
+	Silenced        bool       `json:"silenced"`
+}
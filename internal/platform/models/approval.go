@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+)
+
+// ConfigApproval 审批人对配置某个版本的审批记录
+// 每个审批人对同一版本只保留最后一次结论
+type ConfigApproval struct {
+	ID            string    `json:"id"`
+	ConfigID      string    `json:"config_id"`
+	ConfigVersion int       `json:"config_version"`
+	Reviewer      string    `json:"reviewer"`
+	Decision      string    `json:"decision"` // approved, rejected
+	Comment       string    `json:"comment,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// ApproveConfigRequest 提交审批请求，审批对象为配置的当前版本
+type ApproveConfigRequest struct {
+	Decision string `json:"decision" binding:"required,oneof=approved rejected"`
+	Comment  string `json:"comment"`
+}
+
+// ConfigApprovalStatus 配置某个版本的审批进度
+type ConfigApprovalStatus struct {
+	ConfigID      string            `json:"config_id"`
+	ConfigVersion int               `json:"config_version"`
+	Required      int               `json:"required"` // 部署前需要的通过数，0表示不要求审批
+	Approvals     int               `json:"approvals"`
+	Rejected      bool              `json:"rejected"` // 任一审批人驳回即不可部署，直到其改为通过或提交新版本
+	Approved      bool              `json:"approved"`
+	Reviewers     []string          `json:"reviewers"` // 指派的审批人，为空时提交者以外的任何人均可审批
+	Pending       []string          `json:"pending"`   // 尚未给出结论的指派审批人
+	Records       []*ConfigApproval `json:"records"`
+}
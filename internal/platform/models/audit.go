@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+)
+
+// 审计记录的操作类型
+const (
+	AuditActionCreate     = "create"
+	AuditActionUpdate     = "update"
+	AuditActionDelete     = "delete"
+	AuditActionDeploy     = "deploy"
+	AuditActionRollback   = "rollback"
+	AuditActionDiagnostic = "diagnostic" // 在Agent上执行远程诊断
+)
+
+// 审计记录的操作结果
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+)
+
+// AuditEntry 一次变更类API调用的审计记录
+type AuditEntry struct {
+	ID           string    `json:"id"`
+	Timestamp    time.Time `json:"timestamp"`
+	Actor        string    `json:"actor"` // 操作人，未启用认证时为默认用户
+	Role         string    `json:"role,omitempty"`
+	Action       string    `json:"action"` // create, update, delete, deploy, rollback, diagnostic
+	Method       string    `json:"method"`
+	Route        string    `json:"route"`                 // 路由模板，如 /api/v1/configs/:id
+	Path         string    `json:"path"`                  // 实际请求路径
+	ResourceType string    `json:"resource_type"`         // 路由的第一级资源，如 configs
+	ResourceID   string    `json:"resource_id,omitempty"` // 路由参数中的资源标识
+	BodyDigest   string    `json:"body_digest,omitempty"` // 请求体的SHA-256，不保存请求体本身
+	Detail       string    `json:"detail,omitempty"`      // 处理器补充的操作说明，如执行的诊断命令
+	Status       int       `json:"status"`
+	Outcome      string    `json:"outcome"`         // success, failure
+	Error        string    `json:"error,omitempty"` // 失败时响应中的错误信息
+	ClientIP     string    `json:"client_ip"`
+	LatencyMs    int64     `json:"latency_ms"`
+}
+
+// AuditListRequest 审计记录查询条件
+type AuditListRequest struct {
+	User         string    `form:"user"`
+	ResourceType string    `form:"resource_type"`
+	ResourceID   string    `form:"resource_id"`
+	Action       string    `form:"action"`
+	Since        time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00"` // 操作时间下限
+	Until        time.Time `form:"until" time_format:"2006-01-02T15:04:05Z07:00"` // 操作时间上限
+	Page         int       `form:"page,default=1" binding:"min=1"`
+	PageSize     int       `form:"size,default=20" binding:"min=1,max=200"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+)
+
+// Agent生命周期事件类型
+const (
+	AgentEventRegistered    = "registered"     // 首次出现在平台
+	AgentEventStatusChanged = "status_changed" // 在线状态变化
+	AgentEventRemoved       = "removed"        // 从平台移除
+)
+
+// AgentLifecycleEvent 推送给CMDB的Agent生命周期事件
+type AgentLifecycleEvent struct {
+	Type           string            `json:"type"`
+	AgentID        string            `json:"agent_id"`
+	Hostname       string            `json:"hostname"`
+	IP             string            `json:"ip"`
+	Status         string            `json:"status,omitempty"`
+	PreviousStatus string            `json:"previous_status,omitempty"`
+	Group          string            `json:"group,omitempty"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+	Timestamp      time.Time         `json:"timestamp"`
+}
+
+// CMDBSyncResult 一次CMDB同步的结果
+type CMDBSyncResult struct {
+	Agents   int       `json:"agents"`   // 参与同步的Agent数
+	Enriched int       `json:"enriched"` // 元数据发生变化并已更新的Agent数
+	Missing  int       `json:"missing"`  // CMDB中找不到的Agent数
+	Failed   int       `json:"failed"`   // 查询或保存失败的Agent数
+	Events   int       `json:"events"`   // 推送的生命周期事件数
+	SyncedAt time.Time `json:"synced_at"`
+}
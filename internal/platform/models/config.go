@@ -1,6 +1,8 @@
 package models
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"time"
 )
 
@@ -11,6 +13,8 @@ const (
 	ConfigTypeInput  ConfigType = "input"
 	ConfigTypeFilter ConfigType = "filter"
 	ConfigTypeOutput ConfigType = "output"
+	// ConfigTypePipeline 由流水线拼装生成的完整管道，不能通过配置接口创建
+	ConfigTypePipeline ConfigType = "pipeline"
 )
 
 // TestStatus 测试状态
@@ -30,16 +34,49 @@ type Config struct {
 	Description string     `json:"description"`
 	Type        ConfigType `json:"type" binding:"required,oneof=input filter output"`
 	Content     string     `json:"content" binding:"required"`
+	Project     string     `json:"project,omitempty"` // 所属项目，为空表示默认项目
+	ContentHash string     `json:"content_hash,omitempty"` // 返回给Agent的内容的SHA-256，不持久化
+	Lint        *LintResult `json:"lint,omitempty"`        // 配置详情附带的静态检查结果，不持久化
 	Tags        []string   `json:"tags"`
+	Destinations []string  `json:"destinations,omitempty"` // 下游集群标识，用于部署节流
+	Team        string     `json:"team,omitempty"`         // 负责团队，用于变更指标统计
+	ACL         *ConfigACL `json:"acl,omitempty"`          // 配置级访问控制，为空时仅按角色控制
+	Reviewers   []string   `json:"reviewers,omitempty"`    // 当前版本指派的审批人
+	PipelineID  string     `json:"pipeline_id,omitempty"`  // 由流水线生成时为流水线ID，内容随流水线部署更新
+	PipelineSettings *PipelineSettings `json:"pipeline_settings,omitempty"` // Agent以独立管道模式运行时该配置所在管道的参数
 	Version     int        `json:"version"`
 	Enabled     bool       `json:"enabled"`
 	TestStatus  TestStatus `json:"test_status"`
+	TestedAt    *time.Time `json:"tested_at,omitempty"` // 最近一次样本测试完成的时间，内容变更后清空
+	ScheduledTest bool     `json:"scheduled_test,omitempty"` // 每天用保存的测试数据集定期重新测试
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
 	CreatedBy   string     `json:"created_by"`
 	UpdatedBy   string     `json:"updated_by"`
 }
 
+// Logstash管道的队列类型
+const (
+	QueueTypeMemory    = "memory"
+	QueueTypePersisted = "persisted"
+)
+
+// PipelineSettings 配置独立运行时的Logstash管道参数，写入Agent管理的pipelines.yml
+// 仅在Agent以独立管道模式（pipeline_mode: isolated）运行时生效，未设置的参数沿用Agent的全局设置
+type PipelineSettings struct {
+	Workers       int    `json:"workers,omitempty" binding:"omitempty,min=1,max=256"`             // pipeline.workers
+	BatchSize     int    `json:"batch_size,omitempty" binding:"omitempty,min=1,max=100000"`       // pipeline.batch.size
+	QueueType     string `json:"queue_type,omitempty" binding:"omitempty,oneof=memory persisted"` // queue.type
+	QueueMaxBytes string `json:"queue_max_bytes,omitempty" binding:"omitempty,max=32"`            // queue.max_bytes，如 1gb，仅持久化队列生效
+}
+
+// ContentHash 计算配置内容的SHA-256，以十六进制表示
+// 平台在下发内容上附带该哈希，Agent据此确认写入的字节与下发的一致
+func ContentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
 // ConfigHistory 配置历史记录
 type ConfigHistory struct {
 	ID         string     `json:"id"`
@@ -50,6 +87,12 @@ type ConfigHistory struct {
 	ChangeLog  string     `json:"change_log"`
 	ModifiedBy string     `json:"modified_by"`
 	ModifiedAt time.Time  `json:"modified_at"`
+
+	// 该版本的元数据快照，早期的历史记录没有保存
+	Name        string   `json:"name,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Enabled     *bool    `json:"enabled,omitempty"`
 }
 
 // ConfigListRequest 配置列表请求
@@ -57,8 +100,27 @@ type ConfigListRequest struct {
 	Type     ConfigType `form:"type"`
 	Tags     []string   `form:"tags"`
 	Enabled  *bool      `form:"enabled"`
+	ScheduledTest bool  `form:"scheduled_test"` // 只返回开启了定期测试的配置
 	Page     int        `form:"page,default=1"`
 	PageSize int        `form:"size,default=10"`
+	Sort     string     `form:"sort" binding:"omitempty,oneof=updated_at name version"` // 默认 updated_at
+	Order    string     `form:"order" binding:"omitempty,oneof=asc desc"`               // 默认名称升序，其余字段降序
+
+	// Cursor 上一页响应中的 next_cursor，指定时按游标翻页（search_after）并忽略 page，
+	// 排序条件需与上一页相同；翻阅大量配置时避免深分页
+	Cursor string `form:"cursor"`
+
+	// After 游标解析出的排序值，由服务层填写
+	After []interface{} `form:"-" json:"-"`
+
+	// Project 只返回该项目的配置，为空时不过滤，由服务层按请求的项目填写
+	Project string `form:"-" json:"-"`
+
+	// Principals 只返回这些主体可读取的配置（以及未设置访问控制的配置），为空时不过滤，由服务层按请求身份填写
+	Principals []string `form:"-" json:"-"`
+
+	// OmitContent 不读取配置内容，导出目录时由服务层设置以减小每页的响应
+	OmitContent bool `form:"-" json:"-"`
 }
 
 // ConfigListResponse 配置列表响应
@@ -67,6 +129,57 @@ type ConfigListResponse struct {
 	Page  int       `json:"page"`
 	Size  int       `json:"size"`
 	Items []*Config `json:"items"`
+	// NextCursor 获取下一页的游标，本页不足一页时为空
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// AgentListRequest Agent列表请求，未指定 page、size 和 cursor 时返回全部Agent
+type AgentListRequest struct {
+	Status          string `form:"status"`           // 按心跳巡检持久化的状态过滤
+	Group           string `form:"group"`            // 所属分组
+	LogstashVersion string `form:"logstash_version"` // Logstash版本，精确匹配
+	ConfigID        string `form:"config_id"`        // 只返回已应用该配置的Agent
+	Query           string `form:"q"`                // 按主机名（不区分大小写的子串）或IP（地址或CIDR）搜索
+
+	Sort     string `form:"sort" binding:"omitempty,oneof=updated_at name version"` // updated_at 为最近心跳，name 为主机名，version 为Logstash版本
+	Order    string `form:"order" binding:"omitempty,oneof=asc desc"`
+	Page     int    `form:"page" binding:"omitempty,min=1"`             // 按页码翻页，指定 cursor 时忽略
+	PageSize int    `form:"size" binding:"omitempty,min=1,max=1000"` // 指定 page 或 cursor 而未指定时每页100个
+	Cursor   string `form:"cursor"`                                  // 上一页响应中的 next_cursor
+
+	// After 游标解析出的排序值，由服务层填写
+	After []interface{} `form:"-" json:"-"`
+
+	// Project 只返回该项目的Agent，为空时不过滤，由服务层按请求的项目填写
+	Project string `form:"-" json:"-"`
+}
+
+// AgentListResponse Agent列表响应
+type AgentListResponse struct {
+	Items      []*Agent `json:"items"`
+	Total      int      `json:"total"` // 请求项目内的Agent总数
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
+
+// ConfigSearchRequest 配置全文搜索请求，在名称、描述、标签和配置内容中模糊匹配，支持与列表相同的过滤条件
+type ConfigSearchRequest struct {
+	Query string `form:"q" binding:"required"`
+	ConfigListRequest
+}
+
+// ConfigSearchHit 配置搜索结果，不包含配置内容，内容的匹配片段见 Highlights
+type ConfigSearchHit struct {
+	*Config
+	Score      float64             `json:"score"`
+	Highlights map[string][]string `json:"highlights,omitempty"` // 字段 -> 匹配片段，匹配的词以 <em></em> 标记
+}
+
+// ConfigSearchResponse 配置搜索响应，按相关度排序
+type ConfigSearchResponse struct {
+	Total int64              `json:"total"`
+	Page  int                `json:"page"`
+	Size  int                `json:"size"`
+	Items []*ConfigSearchHit `json:"items"`
 }
 
 // CreateConfigRequest 创建配置请求
@@ -76,6 +189,11 @@ type CreateConfigRequest struct {
 	Type        ConfigType `json:"type" binding:"required,oneof=input filter output"`
 	Content     string     `json:"content" binding:"required"`
 	Tags        []string   `json:"tags"`
+	Destinations []string  `json:"destinations"` // 为空时从配置内容自动识别
+	Team        string     `json:"team"`
+	ACL         *ConfigACL `json:"acl,omitempty"` // 创建时即限制访问，编辑者中必须包含创建者
+	Reviewers   []string   `json:"reviewers"`     // 指派的审批人
+	PipelineSettings *PipelineSettings `json:"pipeline_settings,omitempty"` // 独立管道模式下的管道参数
 }
 
 // UpdateConfigRequest 更新配置请求
@@ -85,20 +203,56 @@ type UpdateConfigRequest struct {
 	Type        ConfigType `json:"type" binding:"required,oneof=input filter output"`
 	Content     string     `json:"content" binding:"required"`
 	Tags        []string   `json:"tags"`
+	Destinations []string  `json:"destinations"` // 为空时从配置内容自动识别
+	Team        string     `json:"team"`
 	Enabled     *bool      `json:"enabled"`
+	Reviewers   []string   `json:"reviewers"` // 为新版本指派的审批人，每次更新重新指派
+	PipelineSettings *PipelineSettings `json:"pipeline_settings,omitempty"` // 独立管道模式下的管道参数
 }
 
 // TestConfigRequest 测试配置请求
 type TestConfigRequest struct {
 	ConfigID string   `json:"config_id" binding:"required"`
 	TestData TestData `json:"test_data" binding:"required"`
+	Targets  []string `json:"targets"` // 管道写入的索引或Topic，用于匹配字段契约，为空时从配置的输出插件识别
 }
 
 // TestData 测试数据
 type TestData struct {
-	Type        string      `json:"type" binding:"required,oneof=sample kafka"`
-	Samples     []string    `json:"samples,omitempty"`
-	KafkaConfig KafkaConfig `json:"kafka_config,omitempty"`
+	Type          string            `json:"type" binding:"required,oneof=sample kafka file elasticsearch replay"`
+	Samples       []string          `json:"samples,omitempty"`
+	Assertions    []TestAssertion   `json:"assertions,omitempty" binding:"omitempty,dive"` // 对样本输出的期望
+	KafkaConfig   KafkaConfig       `json:"kafka_config,omitempty"`
+	File          *TestFileSource   `json:"file,omitempty"`          // type为file时读取的测试文件
+	Elasticsearch *TestESSource     `json:"elasticsearch,omitempty"` // type为elasticsearch时抽取文档的源索引
+	Replay        *TestReplaySource `json:"replay,omitempty"`        // type为replay时回放的HTTP请求
+	Masking       []TestMaskRule    `json:"masking,omitempty"`       // 保存测试结果前的脱敏规则，适用于所有类型
+}
+
+// 测试断言类型
+const (
+	AssertEquals = "equals" // 字段等于给定值
+	AssertExists = "exists" // 字段存在
+	AssertTag    = "tag"    // tags中包含给定标签
+	AssertRegexp = "regexp" // 字段的字符串值匹配正则表达式
+)
+
+// TestAssertion 对样本输出的断言
+type TestAssertion struct {
+	Type    string      `json:"type" binding:"required,oneof=equals exists tag regexp"`
+	Field   string      `json:"field,omitempty"`   // 以点号分隔的字段路径，tag断言不需要
+	Value   interface{} `json:"value,omitempty"`   // equals断言的期望值
+	Tag     string      `json:"tag,omitempty"`     // tag断言的标签
+	Pattern string      `json:"pattern,omitempty"` // regexp断言的正则表达式
+	Sample  *int        `json:"sample,omitempty"`  // 只作用于该下标的样本，为空时作用于全部样本
+}
+
+// AssertionOutcome 一个断言在单条样本上的结果
+type AssertionOutcome struct {
+	Assertion TestAssertion `json:"assertion"`
+	Passed    bool          `json:"passed"`
+	Actual    interface{}   `json:"actual,omitempty"`
+	Message   string        `json:"message,omitempty"` // 未通过的原因
 }
 
 // KafkaConfig Kafka配置
@@ -113,20 +267,30 @@ type KafkaConfig struct {
 // TestResult 测试结果
 type TestResult struct {
 	TestID      string        `json:"test_id"`
-	Status      string        `json:"status"` // running, completed, failed
+	Status      string        `json:"status"` // queued, running, completed, failed
+	QueuePosition int         `json:"queue_position,omitempty"` // 排队等待执行时在队列中的位置，从1开始
 	InputCount  int           `json:"input_count"`
 	OutputCount int           `json:"output_count"`
 	Results     []TestOutput  `json:"results"`
 	Errors      []string      `json:"errors"`
 	StartTime   time.Time     `json:"start_time"`
 	EndTime     *time.Time    `json:"end_time"`
+	Parallelism int           `json:"parallelism,omitempty"` // 实际使用的并发数
+	DurationMs  int64         `json:"duration_ms,omitempty"` // 样本处理总耗时（毫秒）
+	Throughput  float64       `json:"throughput,omitempty"`  // 吞吐量（条/秒）
+	Contracts   *ContractReport `json:"contracts,omitempty"`   // 字段契约验证结果
+	PassedSamples int         `json:"passed_samples,omitempty"` // 通过全部断言的样本数
+	FailedSamples int         `json:"failed_samples,omitempty"` // 处理失败或有断言未通过的样本数
+	Project     string        `json:"project,omitempty"`     // 创建测试的请求所属的项目
 }
 
 // TestOutput 测试输出
 type TestOutput struct {
-	Input  string                 `json:"input"`
-	Output map[string]interface{} `json:"output"`
-	Error  string                 `json:"error,omitempty"`
+	Input      string                 `json:"input"`
+	Output     map[string]interface{} `json:"output"`
+	Error      string                 `json:"error,omitempty"`
+	Passed     *bool                  `json:"passed,omitempty"`     // 未设置断言时为空
+	Assertions []AssertionOutcome     `json:"assertions,omitempty"` // 作用于该样本的断言结果
 }
 
 // Agent 代理信息
@@ -138,6 +302,35 @@ type Agent struct {
 	Status          string          `json:"status"` // online, offline, error
 	LastHeartbeat   time.Time       `json:"last_heartbeat"`
 	AppliedConfigs  []AppliedConfig `json:"applied_configs"`
+	Group           string            `json:"group,omitempty"`    // 所属分组
+	Labels          map[string]string `json:"labels,omitempty"`   // Agent自身上报的标签
+	Settings        *AgentSettings    `json:"settings,omitempty"` // Agent级设置覆盖
+	Metadata        map[string]string `json:"metadata,omitempty"`           // 从CMDB同步的业务元数据（服务、负责人、成本中心等）
+	MetadataSyncedAt *time.Time       `json:"metadata_synced_at,omitempty"` // 最近一次CMDB同步时间
+	Reload          *ReloadStatus     `json:"reload,omitempty"`   // Agent上报的重载预算状态
+	Metrics         *AgentMetrics     `json:"metrics,omitempty"`  // Agent最近一次上报的指标
+	Project         string            `json:"project,omitempty"`  // 所属项目，由Agent注册时上报，为空表示默认项目
+	Environment     string            `json:"environment,omitempty"` // 所在环境，由Agent注册时上报，平台预览部署时据此渲染下游集群引用
+	ResourcePressure *ResourcePressure `json:"resource_pressure,omitempty"` // Agent上报的资源超限情况，未超限时为空
+}
+
+// ResourcePressure Agent的磁盘或内存使用率超过阈值的情况
+type ResourcePressure struct {
+	Reasons       []string  `json:"reasons"`        // 超过的阈值，例如磁盘使用率
+	Since         time.Time `json:"since"`          // 开始超过阈值的时间
+	ReloadsPaused bool      `json:"reloads_paused"` // 是否暂停了自动重载和崩溃后的自动重启
+}
+
+// ReloadStatus Agent的重载预算状态
+type ReloadStatus struct {
+	Budget        int        `json:"budget"`                    // 每个窗口内允许的重载次数
+	WindowSeconds int        `json:"window_seconds"`            // 统计窗口（秒）
+	Used          int        `json:"used"`                      // 当前窗口内已执行的重载次数
+	Queued        int        `json:"queued"`                    // 等待预算释放的重载请求数（执行时合并为一次）
+	QueuedSources []string   `json:"queued_sources,omitempty"`  // 排队请求的来源
+	NextAllowedAt *time.Time `json:"next_allowed_at,omitempty"` // 排队请求预计执行时间
+	LastReloadAt  *time.Time `json:"last_reload_at,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
 }
 
 // AppliedConfig 已应用的配置
@@ -145,6 +338,10 @@ type AppliedConfig struct {
 	ConfigID  string    `json:"config_id"`
 	Version   int       `json:"version"`
 	AppliedAt time.Time `json:"applied_at"`
+	ValidationCached bool `json:"validation_cached,omitempty"` // 配置验证结果是否来自Agent本地缓存
+	DeploymentID string `json:"deployment_id,omitempty"` // 触发本次应用的部署记录
+	ReloadPending bool `json:"reload_pending,omitempty"` // 配置已落盘，重载因预算耗尽仍在排队
+	Hash string `json:"hash,omitempty"` // Agent读回落盘文件计算的SHA-256，与下发内容的content_hash一致
 }
 
 // DeployRequest 部署请求
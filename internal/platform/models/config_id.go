@@ -0,0 +1,25 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// ErrInvalidConfigID 配置ID不能安全地用作Agent上的配置文件名
+var ErrInvalidConfigID = errors.New("配置ID无效")
+
+// MaxConfigIDLength 配置ID的最大长度
+const MaxConfigIDLength = 128
+
+// configIDPattern 配置ID允许的字符
+// 平台生成的ID为UUID；Agent以ID拼接配置、备份和元数据文件名，不允许路径分隔符，也不允许以.开头（与 .metadata 等目录冲突）
+var configIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// ValidateConfigID 检查配置ID只包含字母、数字、.、_、-，以字母或数字开头且不超过128个字符
+func ValidateConfigID(id string) error {
+	if len(id) > MaxConfigIDLength || !configIDPattern.MatchString(id) {
+		return fmt.Errorf("%w: %q，只能包含字母、数字、.、_、-，以字母或数字开头，最长%d个字符", ErrInvalidConfigID, id, MaxConfigIDLength)
+	}
+	return nil
+}
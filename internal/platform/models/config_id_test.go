@@ -0,0 +1,19 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateConfigID(t *testing.T) {
+	for _, id := range []string{uuid.New().String(), "nginx", "app.v2", "team_a-web", strings.Repeat("a", MaxConfigIDLength)} {
+		assert.NoError(t, ValidateConfigID(id), id)
+	}
+
+	for _, id := range []string{"", "../../etc/cron.d/x", "a/b", `a\b`, ".hidden", "..", "-flag", "a b", "a\x00b", strings.Repeat("a", MaxConfigIDLength+1)} {
+		assert.ErrorIs(t, ValidateConfigID(id), ErrInvalidConfigID, id)
+	}
+}
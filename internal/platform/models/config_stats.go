@@ -0,0 +1,26 @@
+package models
+
+// ConfigStatsRequest 配置统计请求，过滤条件与配置列表相同
+// 每个维度的分桶不应用该维度自身的过滤条件，例如按类型过滤时仍返回其他类型的数量，便于展示筛选项
+type ConfigStatsRequest struct {
+	Type    ConfigType `form:"type"`
+	Tags    []string   `form:"tags"`
+	Enabled *bool      `form:"enabled"`
+	TagSize int        `form:"tag_size,default=20"` // 返回数量最多的标签个数，最大100
+
+	// Project 只统计该项目的配置，为空时不过滤，由服务层按请求的项目填写
+	Project string `form:"-" json:"-"`
+
+	// Principals 只统计这些主体可读取的配置，为空时不过滤，由服务层按请求身份填写
+	Principals []string `form:"-" json:"-"`
+}
+
+// ConfigStats 配置的分面统计
+type ConfigStats struct {
+	Total        int64            `json:"total"`          // 符合全部过滤条件的配置数
+	ByType       map[string]int64 `json:"by_type"`        // filter、input、output 等
+	ByTag        map[string]int64 `json:"by_tag"`         // 数量最多的 tag_size 个标签
+	OtherTags    int64            `json:"other_tags"`     // 其余标签的配置数之和，一个配置有多个标签时重复计数
+	ByTestStatus map[string]int64 `json:"by_test_status"` // 从未测试过的配置计为 untested
+	ByEnabled    map[string]int64 `json:"by_enabled"`     // 键为 true、false
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+)
+
+// AppliedConfigMapping Agent已应用的配置版本，每个Agent和配置一条记录，由Agent上报的配置应用结果维护
+type AppliedConfigMapping struct {
+	AgentID       string    `json:"agent_id"`
+	ConfigID      string    `json:"config_id"`
+	Version       int       `json:"version"`
+	Hash          string    `json:"hash,omitempty"`
+	DeploymentID  string    `json:"deployment_id,omitempty"`
+	ReloadPending bool      `json:"reload_pending,omitempty"`
+	AppliedAt     time.Time `json:"applied_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// ConfigVersionCount 运行某个配置版本的Agent数
+type ConfigVersionCount struct {
+	Version int `json:"version"`
+	Agents  int `json:"agents"`
+}
+
+// ConfigUsageAgent 运行配置的Agent
+type ConfigUsageAgent struct {
+	AgentID       string    `json:"agent_id"`
+	Version       int       `json:"version"`
+	Outdated      bool      `json:"outdated"` // 运行的版本低于配置当前版本
+	ReloadPending bool      `json:"reload_pending,omitempty"`
+	DeploymentID  string    `json:"deployment_id,omitempty"`
+	AppliedAt     time.Time `json:"applied_at"`
+}
+
+// ConfigUsage 配置的使用情况：哪些Agent运行该配置以及运行的版本
+type ConfigUsage struct {
+	ConfigID       string               `json:"config_id"`
+	CurrentVersion int                  `json:"current_version"`
+	Total          int                  `json:"total"`
+	Outdated       int                  `json:"outdated"`
+	Versions       []ConfigVersionCount `json:"versions"` // 按版本倒序
+	Agents         []ConfigUsageAgent   `json:"agents"`
+}
+
+// AgentConfigUsage Agent运行的配置
+type AgentConfigUsage struct {
+	ConfigID       string    `json:"config_id"`
+	ConfigName     string    `json:"config_name,omitempty"`
+	Version        int       `json:"version"`
+	CurrentVersion int       `json:"current_version,omitempty"` // 配置已删除时为0
+	Outdated       bool      `json:"outdated"`
+	Deleted        bool      `json:"deleted,omitempty"` // 配置已在平台删除，Agent上仍在运行
+	ReloadPending  bool      `json:"reload_pending,omitempty"`
+	DeploymentID   string    `json:"deployment_id,omitempty"`
+	AppliedAt      time.Time `json:"applied_at"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+)
+
+// 契约违反原因
+const (
+	ContractViolationMissing      = "missing"       // 输出事件缺少契约要求的字段
+	ContractViolationTypeMismatch = "type_mismatch" // 字段类型与契约不一致
+)
+
+// FieldContract 下游消费方（仪表盘、告警等）对索引或Topic登记的字段契约
+// 测试引擎用管道输出的样本事件验证契约，防止过滤器变更删除或改变消费方依赖的字段
+type FieldContract struct {
+	Name        string          `json:"name"`
+	Consumer    string          `json:"consumer"` // 依赖这些字段的仪表盘、告警等
+	Description string          `json:"description"`
+	Target      string          `json:"target"` // 索引或Topic名称，支持 * 通配，如 logs-nginx-*
+	Fields      []ContractField `json:"fields"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	CreatedBy   string          `json:"created_by"`
+	UpdatedBy   string          `json:"updated_by"`
+}
+
+// ContractField 契约要求的字段，每个输出事件都必须包含
+type ContractField struct {
+	Path string `json:"path" binding:"required"`                                                        // 以点号分隔的字段路径
+	Type string `json:"type" binding:"omitempty,oneof=keyword text long double boolean date ip object"` // 为空时只要求字段存在
+}
+
+// CreateFieldContractRequest 登记字段契约请求
+type CreateFieldContractRequest struct {
+	Name        string          `json:"name" binding:"required,min=1,max=64"`
+	Consumer    string          `json:"consumer" binding:"required"`
+	Description string          `json:"description"`
+	Target      string          `json:"target" binding:"required"`
+	Fields      []ContractField `json:"fields" binding:"required,min=1,dive"`
+}
+
+// UpdateFieldContractRequest 更新字段契约请求
+type UpdateFieldContractRequest struct {
+	Consumer    string          `json:"consumer" binding:"required"`
+	Description string          `json:"description"`
+	Target      string          `json:"target" binding:"required"`
+	Fields      []ContractField `json:"fields" binding:"required,min=1,dive"`
+}
+
+// ContractReport 测试输出的契约验证结果
+type ContractReport struct {
+	Targets    []string            `json:"targets"`   // 管道写入的索引或Topic
+	Contracts  []string            `json:"contracts"` // 参与验证的契约名称
+	Violations []ContractViolation `json:"violations"`
+}
+
+// ContractViolation 契约违反记录，同一契约字段的同类问题合并为一条
+type ContractViolation struct {
+	Contract string `json:"contract"`
+	Consumer string `json:"consumer"`
+	Field    string `json:"field"`
+	Reason   string `json:"reason"` // missing, type_mismatch
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"` // 首个不一致样本中的实际类型
+	Samples  int    `json:"samples"`          // 不满足契约的样本数
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+)
+
+// PipelineBenchmark 管道在单个Agent上的基准测试结果
+type PipelineBenchmark struct {
+	EventsPerSecond float64 `json:"events_per_second" binding:"required,gt=0"` // 压测吞吐（条/秒）
+	CPUCores        float64 `json:"cpu_cores" binding:"required,gt=0"`         // 压测期间消耗的CPU核数
+	HeapMB          float64 `json:"heap_mb" binding:"required,gt=0"`           // 压测期间的堆内存峰值（MB）
+	AvgEventBytes   int     `json:"avg_event_bytes,omitempty"`                 // 平均事件大小（字节），默认1024
+}
+
+// CostEstimateRequest 部署前资源估算请求
+// 指定目标Agent时预期速率平均分摊到各Agent，并按各Agent近期指标检查余量
+type CostEstimateRequest struct {
+	Benchmark          PipelineBenchmark `json:"benchmark" binding:"required"`
+	ExpectedRate       float64           `json:"expected_rate" binding:"required,gt=0"` // 预期总事件速率（条/秒）
+	AgentIDs           []string          `json:"agent_ids,omitempty"`
+	Selector           string            `json:"selector,omitempty"`
+	QueueBufferSeconds int               `json:"queue_buffer_seconds,omitempty"` // 持久化队列需缓冲的下游不可用时长，默认取平台配置
+}
+
+// ResourceProjection 单个Agent运行该管道所需的资源
+type ResourceProjection struct {
+	EventsPerSecond float64 `json:"events_per_second"` // 分摊到每个Agent的事件速率
+	CPUCores        float64 `json:"cpu_cores"`
+	HeapMB          float64 `json:"heap_mb"`
+	QueueDiskMB     float64 `json:"queue_disk_mb"`
+}
+
+// AgentHeadroom 目标Agent的资源余量检查结果
+type AgentHeadroom struct {
+	AgentID           string     `json:"agent_id"`
+	Hostname          string     `json:"hostname,omitempty"`
+	MetricsAt         *time.Time `json:"metrics_at,omitempty"` // 用于检查的指标上报时间
+	Checked           bool       `json:"checked"`              // 缺少近期指标时不检查
+	AvailableCPUCores float64    `json:"available_cpu_cores"`
+	AvailableMemoryMB float64    `json:"available_memory_mb"`
+	AvailableDiskMB   float64    `json:"available_disk_mb"`
+	Sufficient        bool       `json:"sufficient"`
+	Warnings          []string   `json:"warnings,omitempty"`
+}
+
+// CostEstimate 部署前资源估算结果
+type CostEstimate struct {
+	ConfigID          string             `json:"config_id"`
+	ConfigVersion     int                `json:"config_version"`
+	UtilizationTarget float64            `json:"utilization_target"` // 检查余量时允许的资源利用率上限
+	PerAgent          ResourceProjection `json:"per_agent"`
+	Agents            []AgentHeadroom    `json:"agents"`
+	Warnings          []string           `json:"warnings,omitempty"` // 各Agent告警汇总
+}
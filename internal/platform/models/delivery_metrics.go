@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+)
+
+// DeliveryReportRequest 变更交付指标报表请求
+type DeliveryReportRequest struct {
+	Team  string    `form:"team"`
+	Since time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00"` // 默认最近30天
+	Until time.Time `form:"until" time_format:"2006-01-02T15:04:05Z07:00"` // 默认当前时间
+}
+
+// DeliveryMetrics 一组部署的DORA类指标
+type DeliveryMetrics struct {
+	Team                  string  `json:"team,omitempty"`
+	Deployments           int     `json:"deployments"`            // 已结束的部署数
+	SuccessfulDeployments int     `json:"successful_deployments"` // 成功完成的部署数
+	FailedDeployments     int     `json:"failed_deployments"`     // 失败或被回滚的部署数
+	DeploymentsPerDay     float64 `json:"deployments_per_day"`    // 部署频率（成功部署/天）
+	LeadTimeSeconds       float64 `json:"lead_time_seconds"`      // 变更前置时间中位数：配置版本产生到部署完成
+	ChangeFailureRate     float64 `json:"change_failure_rate"`    // 变更失败率
+	MTTRSeconds           float64 `json:"mttr_seconds"`           // 平均恢复时间：失败部署到同一配置下次成功部署
+	Recoveries            int     `json:"recoveries"`             // 已恢复的失败次数
+}
+
+// DeliveryReport 变更交付指标报表
+type DeliveryReport struct {
+	Since   time.Time         `json:"since"`
+	Until   time.Time         `json:"until"`
+	Overall DeliveryMetrics   `json:"overall"`
+	Teams   []DeliveryMetrics `json:"teams"`
+}
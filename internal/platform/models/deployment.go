@@ -0,0 +1,221 @@
+package models
+
+import (
+	"time"
+)
+
+// DeploymentStatus 部署状态
+type DeploymentStatus string
+
+const (
+	DeploymentStatusPending    DeploymentStatus = "pending"
+	DeploymentStatusRunning    DeploymentStatus = "running"
+	DeploymentStatusCompleted  DeploymentStatus = "completed"
+	DeploymentStatusFailed     DeploymentStatus = "failed"
+	DeploymentStatusRolledBack DeploymentStatus = "rolled_back"
+)
+
+// Deployment 部署记录
+type Deployment struct {
+	ID              string               `json:"id"`
+	ConfigID        string               `json:"config_id"`
+	ConfigName      string               `json:"config_name"`
+	ConfigVersion   int                  `json:"config_version"`
+	Team            string               `json:"team,omitempty"`    // 部署时配置所属团队
+	Project         string               `json:"project,omitempty"` // 配置所属项目，为空表示默认项目
+	PreviousVersion int                  `json:"previous_version"`  // 部署前Agent上的版本，0表示首次部署
+	AgentIDs        []string             `json:"agent_ids"`
+	Strategy        string               `json:"strategy,omitempty"` // all（默认）或 canary
+	Canary          *CanaryStatus        `json:"canary,omitempty"`   // 金丝雀部署进度，仅canary策略
+	Rollout         *RolloutStatus       `json:"rollout,omitempty"`  // 分批下发进度，仅指定了分批参数
+	Status          DeploymentStatus     `json:"status"`
+	Results         []DeploymentResult   `json:"results"`
+	Approvals       []DeploymentApproval `json:"approvals"`
+	TestGateSkipped bool                 `json:"test_gate_skipped,omitempty"` // 创建者跳过了测试门禁
+	RollbackOf      string               `json:"rollback_of,omitempty"`       // 回滚部署所回滚的原部署ID
+	RolledBackBy    string               `json:"rolled_back_by,omitempty"`    // 回滚该部署的回滚部署ID
+	CreatedBy       string               `json:"created_by"`
+	CreatedAt       time.Time            `json:"created_at"`
+	StartedAt       *time.Time           `json:"started_at"`
+	CompletedAt     *time.Time           `json:"completed_at"`
+}
+
+// IsFinished 部署是否已结束
+func (d *Deployment) IsFinished() bool {
+	switch d.Status {
+	case DeploymentStatusCompleted, DeploymentStatusFailed, DeploymentStatusRolledBack:
+		return true
+	}
+	return false
+}
+
+// DeploymentResult 单个Agent的部署结果
+type DeploymentResult struct {
+	AgentID         string     `json:"agent_id"`
+	Status          string     `json:"status"` // pending, applied, failed, rolled_back, skipped
+	Message         string     `json:"message,omitempty"`
+	PreviousVersion int        `json:"previous_version,omitempty"` // 金丝雀部署前Agent上的版本，回滚时恢复，0表示原本没有该配置
+	Version         int        `json:"version,omitempty"`          // 回滚部署向该Agent恢复的版本，0表示删除原部署前不存在的配置
+	Attempts        int        `json:"attempts,omitempty"`         // 已下发config_deploy的次数，Agent重新连接后未上报结果时会重新下发
+	StartedAt       *time.Time `json:"started_at"`
+	FinishedAt      *time.Time `json:"finished_at"`
+}
+
+// 单个Agent的部署结果状态
+const (
+	DeploymentResultPending = "pending"
+	DeploymentResultApplied = "applied"
+	DeploymentResultFailed  = "failed"
+
+	DeploymentResultRolledBack = "rolled_back" // 金丝雀未通过观察，已恢复部署前的版本
+	DeploymentResultSkipped    = "skipped"     // 金丝雀未通过观察，或回滚时Agent已不在运行原部署的版本，未向该Agent下发
+)
+
+// 部署策略
+const (
+	DeploymentStrategyAll    = "all"    // 同时下发全部目标
+	DeploymentStrategyCanary = "canary" // 先下发部分金丝雀Agent，观察期后自动推广或回滚
+
+	DeploymentStrategyRollback = "rollback" // 回滚部署，向各Agent恢复原部署前的版本，由 POST /deployments/:id/rollback 创建
+)
+
+// CanaryPhase 金丝雀部署阶段
+// canary → soaking → promoting → promoted，金丝雀下发失败或观察期内不健康时进入 rolled_back
+type CanaryPhase string
+
+const (
+	CanaryPhaseCanary     CanaryPhase = "canary"      // 向金丝雀Agent下发
+	CanaryPhaseSoaking    CanaryPhase = "soaking"     // 观察金丝雀Agent上报的健康状态和指标
+	CanaryPhasePromoting  CanaryPhase = "promoting"   // 观察通过，向其余Agent下发
+	CanaryPhasePromoted   CanaryPhase = "promoted"    // 已推广到全部目标
+	CanaryPhaseRolledBack CanaryPhase = "rolled_back" // 已回滚金丝雀Agent
+)
+
+// CanaryOptions 金丝雀部署参数
+// Count 和 Percent 都未指定时取1个金丝雀，金丝雀数不少于1且少于目标数
+type CanaryOptions struct {
+	Count        int     `json:"count,omitempty" binding:"min=0"`                // 金丝雀Agent数
+	Percent      int     `json:"percent,omitempty" binding:"min=0,max=100"`      // 按目标数的百分比选取金丝雀，向上取整
+	SoakSeconds  int     `json:"soak_seconds,omitempty" binding:"min=0"`         // 观察期（秒），默认600
+	MaxErrorRate float64 `json:"max_error_rate,omitempty" binding:"min=0,max=1"` // 观察期内允许的失败事件占比，默认0.01
+}
+
+// CanaryStatus 金丝雀部署进度
+type CanaryStatus struct {
+	Options   CanaryOptions `json:"options"`
+	AgentIDs  []string      `json:"agent_ids"` // 金丝雀Agent，取目标列表的前若干个
+	Phase     CanaryPhase   `json:"phase"`
+	SoakUntil *time.Time    `json:"soak_until,omitempty"`
+	Checks    []CanaryCheck `json:"checks,omitempty"` // 观察期结束时各金丝雀的健康检查结果
+	Reason    string        `json:"reason,omitempty"` // 推广或回滚的原因
+}
+
+// CanaryCheck 单个金丝雀Agent的健康检查结果
+type CanaryCheck struct {
+	AgentID   string  `json:"agent_id"`
+	Healthy   bool    `json:"healthy"`
+	Status    string  `json:"status"`           // 检查时Agent的状态
+	ErrorRate float64 `json:"error_rate"`       // 观察期内失败事件占比
+	Reason    string  `json:"reason,omitempty"` // 不健康的原因
+}
+
+// RolloutOptions 分批下发参数
+// BatchSize 和 BatchPercent 至少指定一个，按目标列表的顺序分批，一批全部上报结果后再下发下一批；
+// canary策略下金丝雀仍一次下发，观察通过后其余Agent分批下发
+type RolloutOptions struct {
+	BatchSize            int  `json:"batch_size,omitempty" binding:"min=0"`                  // 每批Agent数，同时指定时优先于BatchPercent
+	BatchPercent         int  `json:"batch_percent,omitempty" binding:"min=0,max=100"`       // 按分批下发的Agent数的百分比确定每批Agent数，向上取整
+	WaitForHealthy       bool `json:"wait_for_healthy,omitempty"`                            // 一批应用后等待其Agent在线并上报指标，再下发下一批
+	HealthTimeoutSeconds int  `json:"health_timeout_seconds,omitempty" binding:"min=0"`      // 等待健康的时长上限（秒），默认300，超时仍不健康的Agent计为失败
+	MaxFailurePercent    int  `json:"max_failure_percent,omitempty" binding:"min=0,max=100"` // 允许失败的Agent占分批下发的Agent数的百分比，超过时中止部署，默认0即任一Agent失败即中止
+}
+
+// RolloutStatus 分批下发进度
+type RolloutStatus struct {
+	Options RolloutOptions `json:"options"`
+	Batches int            `json:"batches"`           // 总批数
+	Current int            `json:"current"`           // 正在下发的批次，从1开始，0表示尚未开始
+	Failed  int            `json:"failed"`            // 已失败或等待健康超时的Agent数
+	Aborted bool           `json:"aborted,omitempty"` // 失败超过上限，其余批次未下发
+	Reason  string         `json:"reason,omitempty"`  // 中止的原因
+}
+
+// CreateDeploymentRequest 创建部署请求
+// AgentIDs 和 Selector 至少指定一个，同时指定时取并集
+// Strategy 为 canary 时按 Canary 参数先向部分Agent下发，未指定参数时使用默认值
+// 指定 Rollout 时按批下发，未指定时同时下发全部目标
+type CreateDeploymentRequest struct {
+	ConfigID string          `json:"config_id" binding:"required"`
+	AgentIDs []string        `json:"agent_ids"`
+	Selector string          `json:"selector"`
+	Strategy string          `json:"strategy" binding:"omitempty,oneof=all canary"`
+	Canary   *CanaryOptions  `json:"canary"`
+	Rollout  *RolloutOptions `json:"rollout"`
+	// SkipTestGate 跳过测试门禁，需要平台配置的提升角色
+	SkipTestGate bool `json:"skip_test_gate"`
+}
+
+// ConfigAppliedReport Agent上报的配置应用结果
+type ConfigAppliedReport struct {
+	ConfigID     string    `json:"config_id" binding:"required"`
+	Version      int       `json:"version"`
+	AppliedAt    time.Time `json:"applied_at"`
+	Status       string    `json:"status"` // success, failed, apply_failed（验证失败已恢复之前的配置）, reload_queued, removed（Agent已删除配置）
+	DeploymentID string    `json:"deployment_id"`
+	Error        string    `json:"error"`
+	Hash         string    `json:"hash,omitempty"` // 落盘配置文件的SHA-256
+}
+
+// Failed 上报是否为部署失败，apply_failed表示Agent验证新配置失败，已恢复之前的配置且未重载
+func (r *ConfigAppliedReport) Failed() bool {
+	return r.Status == "failed" || r.Status == "apply_failed"
+}
+
+// 审批结论
+const (
+	ApprovalApproved = "approved"
+	ApprovalRejected = "rejected"
+)
+
+// DeploymentApproval 部署审批记录
+type DeploymentApproval struct {
+	Approver  string    `json:"approver"`
+	Decision  string    `json:"decision"` // approved, rejected
+	Comment   string    `json:"comment,omitempty"`
+	DecidedAt time.Time `json:"decided_at"`
+}
+
+// ApproveDeploymentRequest 提交部署审批请求
+type ApproveDeploymentRequest struct {
+	Decision string `json:"decision" binding:"required,oneof=approved rejected"`
+	Comment  string `json:"comment"`
+}
+
+// DeploymentListRequest 部署列表请求
+type DeploymentListRequest struct {
+	ConfigID string           `form:"config_id"`
+	AgentID  string           `form:"agent_id"` // 只返回目标包含该Agent的部署记录
+	Status   DeploymentStatus `form:"status"`
+	Team     string           `form:"team"`
+	Since    time.Time        `form:"since" time_format:"2006-01-02T15:04:05Z07:00"` // 创建时间下限
+	Until    time.Time        `form:"until" time_format:"2006-01-02T15:04:05Z07:00"` // 创建时间上限
+	Page     int              `form:"page,default=1"`
+	PageSize int              `form:"size,default=10"`
+
+	// Project 只返回该项目的部署记录，为空时不过滤，由服务层按请求的项目填写
+	Project string `form:"-"`
+}
+
+// AgentDesiredConfig Agent应运行的配置版本，由向该Agent下发过的部署记录推导
+type AgentDesiredConfig struct {
+	ConfigID     string `json:"config_id"`
+	Version      int    `json:"version"`
+	DeploymentID string `json:"deployment_id,omitempty"` // 确定该版本的部署，Agent补齐配置后上报应用结果时原样回传
+}
+
+// AgentDesiredConfigs GET /agents/:id/desired-configs 的响应，Agent据此补齐缺失的配置并删除多余的配置
+type AgentDesiredConfigs struct {
+	AgentID     string               `json:"agent_id"`
+	Configs     []AgentDesiredConfig `json:"configs"`
+	GeneratedAt time.Time            `json:"generated_at"`
+}
@@ -0,0 +1,50 @@
+package models
+
+// 部署预览的警告类型
+const (
+	PreviewWarningDisabled       = "disabled"        // 配置已禁用
+	PreviewWarningNotApproved    = "not_approved"    // 配置当前版本未通过审批
+	PreviewWarningUntested       = "untested"        // 配置当前版本未在有效期内通过测试
+	PreviewWarningMissingPlugins = "missing_plugins" // Agent缺少配置引用的插件
+	PreviewWarningMissingSecrets = "missing_secrets" // 配置引用的平台密钥不存在
+	PreviewWarningPinned         = "pinned"          // Agent运行的版本由回滚部署恢复，部署将覆盖回滚
+	PreviewWarningUnregistered   = "unregistered"    // Agent尚未注册，上线后才会下发
+	PreviewWarningRenderFailed   = "render_failed"   // 按Agent所在环境渲染配置失败，该Agent拉取配置时同样会失败
+)
+
+// DeploymentPreviewWarning 部署预览的警告
+type DeploymentPreviewWarning struct {
+	Type    string `json:"type"`
+	AgentID string `json:"agent_id,omitempty"` // 为空表示针对整个部署
+	Message string `json:"message"`
+	// Blocking 以同样的请求创建部署时会被拒绝
+	Blocking bool `json:"blocking"`
+}
+
+// AgentDeploymentPreview 单个目标Agent的部署预览
+type AgentDeploymentPreview struct {
+	AgentID        string `json:"agent_id"`
+	Hostname       string `json:"hostname,omitempty"`
+	Environment    string `json:"environment"`     // 渲染下游集群引用所用的环境
+	CurrentVersion int    `json:"current_version"` // Agent当前运行的版本，0表示尚未运行该配置
+	// Content 渲染后的配置内容，密钥引用替换为keystore引用 ${名称}，不包含密钥值
+	Content   string `json:"content"`
+	Changed   bool   `json:"changed"`
+	Unified   string `json:"unified"` // 与当前运行版本按同样方式渲染后的统一格式差异，内容相同时为空
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+}
+
+// DeploymentPreview 部署预览，不创建部署也不下发任何消息
+type DeploymentPreview struct {
+	ConfigID       string                     `json:"config_id"`
+	ConfigName     string                     `json:"config_name"`
+	ConfigVersion  int                        `json:"config_version"`
+	Strategy       string                     `json:"strategy"`
+	CanaryAgentIDs []string                   `json:"canary_agent_ids,omitempty"` // canary策略先下发的Agent
+	Batches        [][]string                 `json:"batches,omitempty"`          // 指定分批参数时各批下发的Agent
+	Agents         []AgentDeploymentPreview   `json:"agents"`
+	Warnings       []DeploymentPreviewWarning `json:"warnings"`
+	// Deployable 没有阻止创建部署的警告
+	Deployable bool `json:"deployable"`
+}
@@ -0,0 +1,52 @@
+package models
+
+// DesiredState 声明式的平台期望状态，用于 lpctl apply
+type DesiredState struct {
+	APIVersion string          `json:"api_version"`
+	Configs    []DesiredConfig `json:"configs"`
+	Groups     []DesiredGroup  `json:"groups"`
+	Prune      bool            `json:"prune"` // 为true时删除文件中未声明的配置和分组，分组只在全局editor角色时清理
+	Force      bool            `json:"-"`     // 为true时允许删除仍被Agent应用的配置，由请求参数指定
+}
+
+// DesiredConfig 期望的配置，以名称作为唯一标识
+type DesiredConfig struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Type        ConfigType `json:"type"`
+	Content     string     `json:"content"`
+	Tags        []string   `json:"tags"`
+	Team        string     `json:"team"`
+	Enabled     *bool      `json:"enabled"`
+}
+
+// DesiredGroup 期望的Agent分组
+type DesiredGroup struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Settings    AgentSettings `json:"settings"`
+}
+
+// 计划动作类型
+const (
+	PlanActionCreate = "create"
+	PlanActionUpdate = "update"
+	PlanActionDelete = "delete"
+	PlanActionNoop   = "noop"
+)
+
+// PlanAction 单个资源的变更动作
+type PlanAction struct {
+	Kind    string   `json:"kind"` // config, group
+	Name    string   `json:"name"`
+	Action  string   `json:"action"`
+	Changes []string `json:"changes,omitempty"` // 发生变化的字段
+	Error   string   `json:"error,omitempty"`   // 执行失败时的错误信息
+}
+
+// ApplyPlan 期望状态与当前状态的差异计划
+type ApplyPlan struct {
+	Actions []PlanAction   `json:"actions"`
+	Summary map[string]int `json:"summary"`
+	Applied bool           `json:"applied"`
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"time"
+)
+
+// 下游集群类型
+const (
+	DestinationTypeElasticsearch = "elasticsearch"
+	DestinationTypeKafka         = "kafka"
+)
+
+// DefaultEnvironment 未配置对应环境时使用的连接信息
+const DefaultEnvironment = "default"
+
+// Destination 下游集群（ES/Kafka）
+// 名称即文档ID，配置通过 destination => "名称" 引用，渲染时按环境替换为实际的连接参数
+type Destination struct {
+	Name         string                          `json:"name"`
+	Type         string                          `json:"type"`
+	Description  string                          `json:"description"`
+	Tags         []string                        `json:"tags,omitempty"`
+	Environments map[string]*DestinationEndpoint `json:"environments"`     // 按环境的连接信息
+	Checks       map[string]*DestinationCheck    `json:"checks,omitempty"` // 按环境的最近一次连通性检查结果
+	CreatedAt    time.Time                       `json:"created_at"`
+	UpdatedAt    time.Time                       `json:"updated_at"`
+	CreatedBy    string                          `json:"created_by"`
+	UpdatedBy    string                          `json:"updated_by"`
+}
+
+// Endpoint 获取环境对应的连接信息，未配置时回退到default环境
+func (d *Destination) Endpoint(environment string) (*DestinationEndpoint, bool) {
+	if ep, ok := d.Environments[environment]; ok {
+		return ep, true
+	}
+	ep, ok := d.Environments[DefaultEnvironment]
+	return ep, ok
+}
+
+// DestinationEndpoint 下游集群在某个环境中的连接信息
+// 平台不保存密码明文，PasswordSecret 是Agent侧Logstash keystore（或环境变量）中的密钥名称
+type DestinationEndpoint struct {
+	Hosts          []string `json:"hosts"`                     // ES为节点地址，Kafka为broker地址
+	SSL            bool     `json:"ssl,omitempty"`             // 是否使用TLS连接
+	Username       string   `json:"username,omitempty"`        // 认证用户名
+	PasswordSecret string   `json:"password_secret,omitempty"` // 密码所在的密钥名称
+}
+
+// DestinationCheck 连通性检查结果
+type DestinationCheck struct {
+	Healthy   bool      `json:"healthy"`
+	Reachable int       `json:"reachable"` // 可连通的地址数
+	Total     int       `json:"total"`
+	LatencyMs int64     `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// CreateDestinationRequest 创建下游集群请求
+type CreateDestinationRequest struct {
+	Name         string                          `json:"name" binding:"required,min=1,max=64"`
+	Type         string                          `json:"type" binding:"required,oneof=elasticsearch kafka"`
+	Description  string                          `json:"description"`
+	Tags         []string                        `json:"tags"`
+	Environments map[string]*DestinationEndpoint `json:"environments" binding:"required"`
+}
+
+// UpdateDestinationRequest 更新下游集群请求
+type UpdateDestinationRequest struct {
+	Description  string                          `json:"description"`
+	Tags         []string                        `json:"tags"`
+	Environments map[string]*DestinationEndpoint `json:"environments" binding:"required"`
+}
+
+// RenderedConfig 按环境渲染后的配置
+type RenderedConfig struct {
+	ConfigID     string   `json:"config_id"`
+	Version      int      `json:"version"`
+	Environment  string   `json:"environment"`
+	Content      string   `json:"content"`
+	Destinations []string `json:"destinations"` // 配置引用的下游集群名称
+}
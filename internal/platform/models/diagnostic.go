@@ -0,0 +1,54 @@
+package models
+
+// 远程诊断的WebSocket消息类型，需与Agent端core包中的定义保持一致
+const (
+	MsgTypeDiagnosticRequest = "diagnostic_request" // 平台要求Agent执行诊断命令
+	MsgTypeDiagnosticResult  = "diagnostic_result"  // Agent回复诊断结果
+)
+
+// 允许远程执行的诊断命令，Agent只执行这些固定的操作，不接受任意命令
+const (
+	DiagnosticLogstashVersion = "logstash_version" // logstash --version
+	DiagnosticDataDirUsage    = "data_dir_usage"   // 数据目录的磁盘占用
+	DiagnosticListConfigs     = "list_configs"     // 配置目录中的.conf文件
+	DiagnosticTailLog         = "tail_log"         // 日志文件末尾的若干行
+)
+
+// DiagnosticCommands 允许的诊断命令
+var DiagnosticCommands = []string{DiagnosticLogstashVersion, DiagnosticDataDirUsage, DiagnosticListConfigs, DiagnosticTailLog}
+
+// MaxDiagnosticTailLines tail_log 最多返回的行数
+const MaxDiagnosticTailLines = 1000
+
+// DiagnosticRequest 诊断请求
+type DiagnosticRequest struct {
+	Command  string `json:"command" binding:"required,oneof=logstash_version data_dir_usage list_configs tail_log"`
+	Source   string `json:"source,omitempty" binding:"omitempty,oneof=logstash pipeline"` // tail_log 的日志来源，默认logstash
+	Pipeline string `json:"pipeline,omitempty"`                                           // source为pipeline时的管道ID
+	Lines    int    `json:"lines,omitempty" binding:"omitempty,min=1,max=1000"`           // tail_log 的行数，默认100
+}
+
+// DiagnosticPayload diagnostic_request 消息内容
+type DiagnosticPayload struct {
+	RequestID string `json:"request_id" binding:"required"`
+	DiagnosticRequest
+}
+
+// DiagnosticFile 配置或数据目录中的一个条目
+type DiagnosticFile struct {
+	Name       string `json:"name"`
+	SizeBytes  int64  `json:"size_bytes"`
+	ModifiedAt string `json:"modified_at,omitempty"` // RFC3339
+}
+
+// DiagnosticResult diagnostic_result 消息内容，也是诊断接口的响应
+type DiagnosticResult struct {
+	RequestID  string           `json:"request_id" binding:"required"`
+	Command    string           `json:"command"`
+	Output     string           `json:"output,omitempty"`      // 命令输出或日志行
+	Files      []DiagnosticFile `json:"files,omitempty"`       // list_configs 的文件、data_dir_usage 的一级子目录占用
+	TotalBytes int64            `json:"total_bytes,omitempty"` // data_dir_usage 的总占用
+	Truncated  bool             `json:"truncated,omitempty"`   // 输出超过大小上限被截断
+	Error      string           `json:"error,omitempty"`       // 执行失败的原因
+	DurationMs int64            `json:"duration_ms"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import "encoding/json"
+
+// 死信队列查看的WebSocket消息类型，需与Agent端core包中的定义保持一致
+const (
+	MsgTypeDLQRequest = "dlq_request" // 平台要求Agent读取管道的死信队列
+	MsgTypeDLQResult  = "dlq_result"  // Agent回复读取到的死信事件
+)
+
+// 死信队列查看的限制
+const (
+	DefaultDLQPageSize = 20
+	MaxDLQPageSize     = 100
+	MaxDLQEventBytes   = 16 * 1024 // 单个事件内容（JSON）的大小上限，超过时截断
+)
+
+// DLQRequest 死信队列查看请求，按段文件先后顺序分页
+type DLQRequest struct {
+	Pipeline string `form:"pipeline" json:"pipeline" binding:"required"`
+	Page     int    `form:"page,default=1" json:"page" binding:"min=1"`
+	PageSize int    `form:"size,default=20" json:"size" binding:"min=1,max=100"`
+}
+
+// DLQPayload dlq_request 消息内容
+type DLQPayload struct {
+	RequestID string `json:"request_id" binding:"required"`
+	DLQRequest
+}
+
+// DLQEntry 死信队列中的一个事件
+type DLQEntry struct {
+	Segment    string          `json:"segment"`             // 所在的段文件
+	EntryTime  string          `json:"entry_time"`          // 写入死信队列的时间
+	PluginType string          `json:"plugin_type"`         // 写入死信队列的插件类型，如elasticsearch
+	PluginID   string          `json:"plugin_id"`           // 写入死信队列的插件ID
+	Reason     string          `json:"reason"`              // 写入死信队列的原因，通常为输出返回的错误
+	Event      json.RawMessage `json:"event,omitempty"`     // 事件字段，@metadata 中为元数据；被截断时为截断后的JSON文本
+	Truncated  bool            `json:"truncated,omitempty"` // 事件超过大小上限被截断
+	Error      string          `json:"error,omitempty"`     // 事件内容无法解析的原因
+}
+
+// DLQResult dlq_result 消息内容，也是死信队列查看接口的响应
+type DLQResult struct {
+	RequestID  string     `json:"request_id" binding:"required"`
+	Pipeline   string     `json:"pipeline"`
+	Page       int        `json:"page"`
+	PageSize   int        `json:"size"`
+	Segments   int        `json:"segments"`   // 已写完的段文件数
+	SizeBytes  int64      `json:"size_bytes"` // 段文件的总大小
+	Entries    []DLQEntry `json:"entries"`
+	HasMore    bool       `json:"has_more"`        // 之后还有事件
+	Error      string     `json:"error,omitempty"` // 读取失败的原因，如管道未启用死信队列
+	DurationMs int64      `json:"duration_ms"`
+}
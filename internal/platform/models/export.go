@@ -0,0 +1,19 @@
+package models
+
+// ExportOptions 导出的格式和列
+type ExportOptions struct {
+	Format  string `form:"format,default=csv" binding:"omitempty,oneof=csv xlsx"` // csv（默认）或 xlsx
+	Columns string `form:"columns"`                                               // 逗号分隔的列名，按给定顺序导出，为空时导出全部列
+}
+
+// AgentExportRequest GET /agents/export 的请求，过滤和排序条件与Agent列表相同，忽略分页参数
+type AgentExportRequest struct {
+	AgentListRequest
+	ExportOptions
+}
+
+// ConfigExportRequest GET /configs/export 的请求，过滤和排序条件与配置列表相同，忽略分页参数
+type ConfigExportRequest struct {
+	ConfigListRequest
+	ExportOptions
+}
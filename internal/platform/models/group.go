@@ -0,0 +1,95 @@
+package models
+
+import (
+	"time"
+)
+
+// AgentSettings Agent运行参数
+// 既用于分组默认值，也用于单个Agent的覆盖值；指针字段为nil表示未设置，继承上一级的值
+type AgentSettings struct {
+	HeartbeatInterval *int              `json:"heartbeat_interval,omitempty"` // 心跳间隔（秒）
+	MetricsInterval   *int              `json:"metrics_interval,omitempty"`   // 指标上报间隔（秒）
+	EnableAutoReload  *bool             `json:"enable_auto_reload,omitempty"` // 是否启用自动重载
+	Labels            map[string]string `json:"labels,omitempty"`             // 标签集合
+}
+
+// Merge 用override覆盖当前设置，返回合并后的新设置
+// 标签按键合并，override中的同名标签优先
+func (s AgentSettings) Merge(override *AgentSettings) AgentSettings {
+	merged := AgentSettings{
+		HeartbeatInterval: s.HeartbeatInterval,
+		MetricsInterval:   s.MetricsInterval,
+		EnableAutoReload:  s.EnableAutoReload,
+	}
+
+	if len(s.Labels) > 0 {
+		merged.Labels = make(map[string]string, len(s.Labels))
+		for k, v := range s.Labels {
+			merged.Labels[k] = v
+		}
+	}
+
+	if override == nil {
+		return merged
+	}
+
+	if override.HeartbeatInterval != nil {
+		merged.HeartbeatInterval = override.HeartbeatInterval
+	}
+	if override.MetricsInterval != nil {
+		merged.MetricsInterval = override.MetricsInterval
+	}
+	if override.EnableAutoReload != nil {
+		merged.EnableAutoReload = override.EnableAutoReload
+	}
+	if len(override.Labels) > 0 {
+		if merged.Labels == nil {
+			merged.Labels = make(map[string]string, len(override.Labels))
+		}
+		for k, v := range override.Labels {
+			merged.Labels[k] = v
+		}
+	}
+
+	return merged
+}
+
+// AgentGroup Agent分组
+// 分组名称即文档ID，Agent通过名称引用所属分组
+type AgentGroup struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Settings    AgentSettings `json:"settings"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+	CreatedBy   string        `json:"created_by"`
+	UpdatedBy   string        `json:"updated_by"`
+}
+
+// CreateGroupRequest 创建分组请求
+type CreateGroupRequest struct {
+	Name        string        `json:"name" binding:"required,min=1,max=64"`
+	Description string        `json:"description"`
+	Settings    AgentSettings `json:"settings"`
+}
+
+// UpdateGroupRequest 更新分组请求
+type UpdateGroupRequest struct {
+	Description string        `json:"description"`
+	Settings    AgentSettings `json:"settings"`
+}
+
+// UpdateAgentSettingsRequest 更新单个Agent设置请求
+type UpdateAgentSettingsRequest struct {
+	Group    *string        `json:"group"`    // 所属分组，为nil时保持不变，空字符串表示移出分组
+	Settings *AgentSettings `json:"settings"` // Agent级覆盖值，为nil时保持不变
+}
+
+// EffectiveSettings Agent的最终生效设置
+type EffectiveSettings struct {
+	AgentID  string         `json:"agent_id"`
+	Group    string         `json:"group,omitempty"`
+	Defaults *AgentSettings `json:"defaults,omitempty"` // 分组默认值
+	Override *AgentSettings `json:"override,omitempty"` // Agent级覆盖值
+	Settings AgentSettings  `json:"settings"`           // 合并后的生效值
+}
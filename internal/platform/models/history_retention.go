@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+)
+
+// HistoryRetentionPolicy 配置历史保留策略
+// 满足任一条件的版本保留：各配置最近 KeepVersions 个版本、KeepDays 天内修改的版本、未结束部署引用的版本，
+// 两者都为0时不清理
+type HistoryRetentionPolicy struct {
+	KeepVersions int `json:"keep_versions"` // 每个配置保留的最近版本数
+	KeepDays     int `json:"keep_days"`     // 保留最近多少天内修改的版本
+}
+
+// Enabled 策略是否会清理历史
+func (p HistoryRetentionPolicy) Enabled() bool {
+	return p.KeepVersions > 0 || p.KeepDays > 0
+}
+
+// ConfigHistoryRecord 清理时使用的历史记录摘要，不含配置内容
+type ConfigHistoryRecord struct {
+	ID         string    `json:"id"`
+	Index      string    `json:"-"` // 记录所在的索引，启用生命周期管理后为滚动出的具体索引
+	ConfigID   string    `json:"config_id"`
+	Version    int       `json:"version"`
+	ChangeType string    `json:"change_type"`
+	ModifiedAt time.Time `json:"modified_at"`
+}
+
+// HistoryPruneRun 一次配置历史清理的汇总
+type HistoryPruneRun struct {
+	DryRun     bool                   `json:"dry_run"` // 预览时不删除，Pruned为将删除的记录数
+	Policy     HistoryRetentionPolicy `json:"policy"`
+	StartedAt  time.Time              `json:"started_at"`
+	FinishedAt time.Time              `json:"finished_at"`
+	Configs    int                    `json:"configs"` // 检查的配置数，包括已删除的配置
+	Kept       int                    `json:"kept"`    // 保留的历史记录数
+	Pruned     int                    `json:"pruned"`  // 删除的历史记录数
+	Errored    int                    `json:"errored"` // 读取或删除出错的配置数
+	Items      []HistoryPruneItem     `json:"items"`   // 有版本被清理的配置
+}
+
+// HistoryPruneItem 单个配置被清理的版本
+type HistoryPruneItem struct {
+	ConfigID string `json:"config_id"`
+	Versions []int  `json:"versions"` // 从旧到新
+	Records  int    `json:"records"`  // 同一版本可能有多条记录（例如删除记录）
+}
+
+// HistoryPrunePreviewRequest 清理预览请求，未指定的参数使用配置的保留策略
+type HistoryPrunePreviewRequest struct {
+	KeepVersions *int `form:"keep_versions" binding:"omitempty,min=0"`
+	KeepDays     *int `form:"keep_days" binding:"omitempty,min=0"`
+}
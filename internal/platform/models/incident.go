@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+)
+
+// IncidentStatus 事件状态
+type IncidentStatus string
+
+const (
+	IncidentStatusOpen     IncidentStatus = "open"
+	IncidentStatusResolved IncidentStatus = "resolved"
+)
+
+// Incident 聚合后的Agent错误事件
+// 同一指纹的错误无论来自多少个Agent都归并到一个未解决事件中
+type Incident struct {
+	ID             string          `json:"id"`
+	Fingerprint    string          `json:"fingerprint"`
+	ErrorType      string          `json:"error_type"`
+	ConfigID       string          `json:"config_id,omitempty"`
+	Message        string          `json:"message"` // 首次出现时的原始错误信息
+	Status         IncidentStatus  `json:"status"`
+	AffectedAgents []string        `json:"affected_agents"`
+	AffectedCount  int             `json:"affected_count"`
+	Occurrences    int64           `json:"occurrences"`
+	Timeline       []IncidentEvent `json:"timeline"` // 仅保留最近的事件
+	FirstSeen      time.Time       `json:"first_seen"`
+	LastSeen       time.Time       `json:"last_seen"`
+	ResolvedAt     *time.Time      `json:"resolved_at,omitempty"`
+	ResolvedBy     string          `json:"resolved_by,omitempty"`
+	Resolution     string          `json:"resolution,omitempty"`
+}
+
+// IncidentEvent 事件时间线条目
+type IncidentEvent struct {
+	Timestamp time.Time `json:"timestamp"`
+	AgentID   string    `json:"agent_id"`
+	Message   string    `json:"message"`
+}
+
+// AgentErrorReport Agent上报的错误
+type AgentErrorReport struct {
+	ErrorType string    `json:"error_type" binding:"required"` // 如 plugin_missing, config_invalid, pipeline_crash
+	Message   string    `json:"message" binding:"required"`
+	ConfigID  string    `json:"config_id"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// IncidentListRequest 事件列表请求
+type IncidentListRequest struct {
+	Status   IncidentStatus `form:"status"`
+	Page     int            `form:"page,default=1"`
+	PageSize int            `form:"size,default=10"`
+}
+
+// ResolveIncidentRequest 解决事件请求
+type ResolveIncidentRequest struct {
+	Resolution string `json:"resolution"`
+}
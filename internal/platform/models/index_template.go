@@ -0,0 +1,47 @@
+package models
+
+// 索引字段类型
+const (
+	FieldTypeKeyword = "keyword"
+	FieldTypeText    = "text"
+	FieldTypeLong    = "long"
+	FieldTypeDouble  = "double"
+	FieldTypeBoolean = "boolean"
+	FieldTypeDate    = "date"
+	FieldTypeIP      = "ip"
+	FieldTypeObject  = "object"
+)
+
+// 字段类型的推断来源
+const (
+	FieldSourceConfig   = "config"   // 来自配置内容（grok捕获、mutate convert等）
+	FieldSourceSample   = "sample"   // 来自样本输出事件
+	FieldSourceOverride = "override" // 来自请求中的显式覆盖
+)
+
+// InferredField 推断出的输出字段
+type InferredField struct {
+	Name   string `json:"name"` // 以点号分隔的字段路径
+	Type   string `json:"type"`
+	Source string `json:"source"`
+}
+
+// GenerateIndexTemplateRequest 生成索引模板请求
+type GenerateIndexTemplateRequest struct {
+	Name          string                   `json:"name"`                                    // 模板名称，默认使用配置名称
+	IndexPatterns []string                 `json:"index_patterns" binding:"required,min=1"` // 模板匹配的索引模式
+	Samples       []map[string]interface{} `json:"samples"`                                 // 管道输出的样本事件
+	Overrides     map[string]string        `json:"overrides"`                               // 字段路径 -> 字段类型
+	Shards        int                      `json:"shards"`
+	Replicas      *int                     `json:"replicas"`
+	Priority      int                      `json:"priority"`
+}
+
+// IndexTemplateResult 生成的索引模板
+// Template可直接作为 PUT _index_template/<name> 的请求体
+type IndexTemplateResult struct {
+	Name     string                 `json:"name"`
+	ConfigID string                 `json:"config_id"`
+	Fields   []InferredField        `json:"fields"`
+	Template map[string]interface{} `json:"template"`
+}
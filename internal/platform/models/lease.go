@@ -0,0 +1,14 @@
+package models
+
+import (
+	"time"
+)
+
+// Lease 多副本部署时后台任务的领导者租约，持有者在到期前续约，到期未续约时其他副本可以接管
+type Lease struct {
+	Name       string    `json:"name"`
+	Holder     string    `json:"holder"` // 持有租约的平台副本标识
+	AcquiredAt time.Time `json:"acquired_at"`
+	RenewedAt  time.Time `json:"renewed_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
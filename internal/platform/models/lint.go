@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// 配置静态检查问题的严重级别
+const (
+	LintSeverityError   = "error"
+	LintSeverityWarning = "warning"
+	LintSeverityInfo    = "info"
+)
+
+// 配置静态检查规则
+const (
+	LintRuleSyntax              = "syntax"                // 内容无法解析
+	LintRuleStdoutInProduction  = "stdout_in_production"  // 生产环境的配置仍保留stdout输出
+	LintRuleGrokUnanchored      = "grok_unanchored"       // grok模式没有以^锚定开头
+	LintRuleDateMissingTimezone = "date_missing_timezone" // date过滤器未设置timezone且格式不含时区
+	LintRuleDeprecatedOption    = "deprecated_option"     // 使用了已弃用的插件选项
+	LintRuleUnknownOption       = "unknown_option"        // 使用了插件目录中没有的选项
+	LintRuleMissingPluginID     = "missing_plugin_id"     // 插件未设置id，管道统计中难以区分
+)
+
+// LintRule 静态检查规则及其在项目内是否启用
+type LintRule struct {
+	ID          string `json:"id"`
+	Severity    string `json:"severity"`
+	Description string `json:"description"`
+	Enabled     bool   `json:"enabled"`
+}
+
+// LintIssue 静态检查发现的一个问题
+type LintIssue struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Line     int    `json:"line,omitempty"`   // 从1开始，无法定位时为0
+	Plugin   string `json:"plugin,omitempty"` // 问题所在的插件，如 filter/grok
+	Message  string `json:"message"`
+}
+
+// LintResult 配置静态检查结果
+type LintResult struct {
+	Production bool        `json:"production"` // 按生产环境检查
+	Issues     []LintIssue `json:"issues"`
+	Errors     int         `json:"errors"`
+	Warnings   int         `json:"warnings"`
+	Infos      int         `json:"infos"`
+}
+
+// LintConfigRequest 配置静态检查请求
+type LintConfigRequest struct {
+	Environment string `json:"environment,omitempty"` // 部署的目标环境，为空时按配置标签判断是否为生产环境
+}
+
+// LintSettings 项目的静态检查规则开关，未列出的规则使用默认值
+type LintSettings struct {
+	Project   string          `json:"project"`
+	Rules     map[string]bool `json:"rules"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	UpdatedBy string          `json:"updated_by"`
+}
+
+// UpdateLintRulesRequest 更新项目的静态检查规则开关
+type UpdateLintRulesRequest struct {
+	Rules map[string]bool `json:"rules" binding:"required"`
+}
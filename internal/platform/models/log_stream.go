@@ -0,0 +1,74 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// 实时日志流的WebSocket消息类型，需与Agent端core包中的定义保持一致
+const (
+	MsgTypeLogTailStart = "log_tail_start" // 平台要求Agent开始跟踪Logstash日志
+	MsgTypeLogTailStop  = "log_tail_stop"  // 平台要求Agent停止跟踪
+	MsgTypeLogLines     = "log_lines"      // Agent推送跟踪到的日志行
+)
+
+// 跟踪的日志来源
+const (
+	LogSourceLogstash = "logstash" // logstash-plain.log
+	LogSourcePipeline = "pipeline" // 开启 pipeline.separate_logs 后单个管道的 pipeline_<id>.log
+)
+
+// LogLevels Logstash日志级别，由低到高
+var LogLevels = []string{"trace", "debug", "info", "warn", "error", "fatal"}
+
+// LogLevelRank 日志级别的序号，未知级别返回-1
+func LogLevelRank(level string) int {
+	level = strings.ToLower(strings.TrimSpace(level))
+	for i, l := range LogLevels {
+		if l == level {
+			return i
+		}
+	}
+	return -1
+}
+
+// LogTailStartPayload log_tail_start 消息内容
+type LogTailStartPayload struct {
+	StreamID string `json:"stream_id" binding:"required"`
+	Source   string `json:"source" binding:"required,oneof=logstash pipeline"`
+	Pipeline string `json:"pipeline,omitempty"`       // source为pipeline时的管道ID
+	MinLevel string `json:"min_level,omitempty"`      // 只推送不低于该级别的日志行，无法识别级别的行（如堆栈）随上一行过滤
+	MaxRate  int    `json:"max_rate" binding:"min=1"` // 每秒最多推送的行数，超出的行丢弃并计入dropped
+	Backlog  int    `json:"backlog,omitempty"`        // 开始时先推送文件末尾的行数
+}
+
+// LogStreamQuery 浏览器打开实时日志流的查询参数
+type LogStreamQuery struct {
+	Source   string `form:"source" binding:"omitempty,oneof=logstash pipeline"` // 默认logstash
+	Pipeline string `form:"pipeline"`
+	Level    string `form:"level" binding:"omitempty,oneof=trace debug info warn error fatal"`
+	Rate     int    `form:"rate" binding:"omitempty,min=1"` // 每秒最多转发的行数，不能超过平台上限
+	Backlog  int    `form:"backlog" binding:"omitempty,min=0,max=1000"`
+}
+
+// LogTailStopPayload log_tail_stop 消息内容
+type LogTailStopPayload struct {
+	StreamID string `json:"stream_id" binding:"required"`
+}
+
+// LogLine 一行Logstash日志，Level和Logger从plain格式的行首解析，无法解析时为空
+type LogLine struct {
+	Timestamp time.Time `json:"timestamp"` // Agent读取到该行的时间
+	Level     string    `json:"level,omitempty"`
+	Logger    string    `json:"logger,omitempty"`
+	Message   string    `json:"message"`
+}
+
+// LogLinesMessage log_lines 消息内容，Agent按批推送
+type LogLinesMessage struct {
+	StreamID string    `json:"stream_id" binding:"required"`
+	Lines    []LogLine `json:"lines"`
+	Dropped  int       `json:"dropped,omitempty"` // 自上一批以来因超过速率上限丢弃的行数
+	Closed   bool      `json:"closed,omitempty"`  // Agent已结束该流，例如文件无法读取或达到最长跟踪时间
+	Error    string    `json:"error,omitempty"`   // 结束的原因
+}
@@ -0,0 +1,260 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 平台推送给Agent的消息类型，需与Agent端core包中的定义保持一致
+const (
+	MsgTypeConfigDeploy   = "config_deploy"   // 配置部署
+	MsgTypeConfigDelete   = "config_delete"   // 配置删除
+	MsgTypeReloadRequest  = "reload_request"  // 重载请求
+	MsgTypeStatusRequest  = "status_request"  // 状态请求
+	MsgTypeMetricsRequest = "metrics_request" // 指标请求
+	MsgTypeSettingsUpdate = "settings_update" // 运行参数下发
+	MsgTypeChunk          = "chunk"           // 大消息分片，封包格式见 pkg/wschunk
+)
+
+// 心跳捎带的轻量命令类型，用于无法建立WebSocket连接的Agent
+const (
+	MsgTypeSyncHint    = "sync_hint"   // 配置同步提示
+	MsgTypeLogLevel    = "log_level"   // 日志级别变更
+	MsgTypeMaintenance = "maintenance" // 维护模式开关
+
+	MsgTypeTelemetryIntervals = "telemetry_intervals" // 平台按负载协商的心跳/指标间隔
+
+	MsgTypeInvalidateValidationCache = "invalidate_validation_cache" // 清空配置验证缓存，如插件变更后
+)
+
+// PendingCommand 等待Agent通过心跳领取的命令
+type PendingCommand struct {
+	ID         string            `json:"id"` // Agent处理后在下一次心跳中回传确认
+	Type       string            `json:"type"`
+	Payload    json.RawMessage   `json:"payload"`
+	CreatedAt  time.Time         `json:"created_at"`
+	Metadata   map[string]string `json:"metadata,omitempty"` // 链路上下文，键为W3C Trace Context的 traceparent、tracestate
+	Deliveries int               `json:"-"`                  // 已随心跳下发的次数
+}
+
+// HeartbeatRequest Agent心跳请求
+type HeartbeatRequest struct {
+	Timestamp     int64    `json:"timestamp"`
+	AckedCommands []string `json:"acked_commands,omitempty"` // 已交给Agent处理的命令ID
+}
+
+// HeartbeatResponse 心跳响应
+type HeartbeatResponse struct {
+	ServerTime time.Time        `json:"server_time"`
+	Commands   []PendingCommand `json:"commands,omitempty"`
+}
+
+// EnqueueCommandRequest 下发心跳命令请求
+type EnqueueCommandRequest struct {
+	Type    string          `json:"type" binding:"required,oneof=sync_hint log_level maintenance invalidate_validation_cache telemetry_intervals"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// Agent上报给平台的WebSocket消息类型，需与Agent端core包中的定义保持一致
+const (
+	MsgTypeHeartbeat     = "heartbeat"      // 心跳
+	MsgTypeStatusReport  = "status_report"  // 状态上报
+	MsgTypeMetricsReport = "metrics_report" // 指标上报
+	MsgTypeConfigApplied = "config_applied" // 配置已应用
+	MsgTypeError         = "error"          // 消息处理失败
+	MsgTypeAck           = "ack"            // 确认已处理带id的平台消息
+)
+
+// WebSocketMessage 平台与Agent之间的WebSocket消息封包，需与Agent端core.WebSocketMessage保持一致
+type WebSocketMessage struct {
+	ID        string            `json:"id,omitempty"` // 需要确认的消息（config_deploy、config_delete）的ID，Agent处理后以ack回传；分片消息的每个分片都携带
+	Type      string            `json:"type" binding:"required"`
+	Timestamp time.Time         `json:"timestamp"`
+	Payload   json.RawMessage   `json:"payload"`
+	Metadata  map[string]string `json:"metadata,omitempty"` // 链路上下文，键为W3C Trace Context的 traceparent、tracestate；处理该消息时发出的请求携带同一链路上下文
+}
+
+// MessageAck ack 消息内容，Agent处理完带id的消息后回传，平台据此从重发队列中移除
+// Agent重复收到已处理过的id时不再处理，只重新确认
+type MessageAck struct {
+	IDs []string `json:"ids" binding:"required"`
+}
+
+// Agent接收平台消息的传输方式
+const (
+	TransportWebSocket = "websocket"
+	TransportLongPoll  = "long_poll" // 代理不允许WebSocket时经HTTP长轮询接收
+)
+
+// PollRequest 长轮询请求参数
+type PollRequest struct {
+	Wait     time.Duration `form:"wait"`     // 没有消息时的最长等待时间，例如 30s，超过平台上限时按上限
+	Received uint64        `form:"received"` // 已收到的最后一批消息的序号，与平台记录不符时平台重发该批消息
+}
+
+// PollResponse 长轮询响应，等待超时时 messages 为空
+type PollResponse struct {
+	Batch    uint64            `json:"batch"`    // 本批消息的序号，下次轮询以 received 回传；没有消息时为最后一批的序号
+	Messages []json.RawMessage `json:"messages"` // 与WebSocket帧相同的消息封包，可能包含分片消息
+}
+
+// PollUpload Agent经长轮询通道上报的消息，处理方式与经WebSocket上报的相同
+type PollUpload struct {
+	Messages []WebSocketMessage `json:"messages" binding:"required,dive"`
+}
+
+// ConfigRef 配置及其版本
+type ConfigRef struct {
+	ConfigID string `json:"config_id" binding:"required"`
+	Version  int    `json:"version"`
+}
+
+// ConfigDeployPayload config_deploy 消息内容，Agent据此拉取配置内容
+type ConfigDeployPayload struct {
+	ConfigID     string `json:"config_id" binding:"required"`
+	Version      int    `json:"version" binding:"required"`
+	DeploymentID string `json:"deployment_id,omitempty"` // 平台发起的部署，Agent上报结果时回传
+	Force        bool   `json:"force,omitempty"`         // 忽略版本校验
+}
+
+// ConfigDeletePayload config_delete 消息内容
+type ConfigDeletePayload struct {
+	ConfigID string `json:"config_id" binding:"required"`
+}
+
+// SyncHintPayload sync_hint 命令内容，列出Agent应持有的配置版本
+type SyncHintPayload struct {
+	Configs []ConfigRef `json:"configs" binding:"required"`
+}
+
+// LogLevelPayload log_level 命令内容
+type LogLevelPayload struct {
+	Level string `json:"level" binding:"required"` // debug, info, warn, error
+}
+
+// MaintenancePayload maintenance 命令内容
+type MaintenancePayload struct {
+	Enabled bool   `json:"enabled"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// TelemetryIntervals telemetry_intervals 命令内容，也随注册响应返回
+// 间隔是平台要求的下限（秒），Agent取其与本地设置中的较大值，并限制在本地配置的上下限内；0表示不限制
+type TelemetryIntervals struct {
+	HeartbeatInterval int       `json:"heartbeat_interval"`
+	MetricsInterval   int       `json:"metrics_interval"`
+	FleetSize         int       `json:"fleet_size,omitempty"` // 计算时的在线Agent数
+	Pressure          float64   `json:"pressure,omitempty"`   // 计算时的平台压力系数，1表示无压力
+	ComputedAt        time.Time `json:"computed_at"`
+}
+
+// RegisterResponse Agent注册响应
+type RegisterResponse struct {
+	*Agent
+	Telemetry *TelemetryIntervals `json:"telemetry,omitempty"` // 未启用间隔协商时为空
+}
+
+// HeartbeatMessage Agent经WebSocket发送的心跳
+type HeartbeatMessage struct {
+	AgentID       string   `json:"agent_id" binding:"required"`
+	Timestamp     *int64   `json:"timestamp"`
+	AckedCommands []string `json:"acked_commands,omitempty"` // 经HTTP心跳收到并处理完成的命令ID
+}
+
+// ConfigAppliedMessage Agent经WebSocket上报的配置应用结果
+type ConfigAppliedMessage struct {
+	AgentID      string    `json:"agent_id" binding:"required"`
+	ConfigID     string    `json:"config_id" binding:"required"`
+	Version      int       `json:"version"`
+	AppliedAt    time.Time `json:"applied_at"`
+	DeploymentID string    `json:"deployment_id,omitempty"`
+	Hash         string    `json:"hash,omitempty"` // 落盘配置文件的SHA-256
+}
+
+// MetricsReportMessage Agent经WebSocket上报的指标
+type MetricsReportMessage struct {
+	AgentID string       `json:"agent_id"`
+	Metrics AgentMetrics `json:"metrics" binding:"required"`
+}
+
+// ErrorMessage Agent处理平台消息失败时的回复
+type ErrorMessage struct {
+	Error   string `json:"error" binding:"required"`
+	MsgType string `json:"msg_type"` // 处理失败的消息类型
+}
+
+// AgentMetrics Agent上报的指标，需与Agent端core.AgentMetrics保持一致
+type AgentMetrics struct {
+	Timestamp      time.Time      `json:"timestamp"`
+	CPUUsage       float64        `json:"cpu_usage"`                 // CPU使用率 (%)
+	MemoryUsage    float64        `json:"memory_usage"`              // 内存使用率 (%)
+	DiskUsage      float64        `json:"disk_usage"`                // 磁盘使用率 (%)
+	EventsReceived int64          `json:"events_received"`           // 接收事件数
+	EventsSent     int64          `json:"events_sent"`               // 发送事件数
+	EventsFailed   int64          `json:"events_failed"`             // 失败事件数
+	Uptime         int64          `json:"uptime"`                    // 运行时间 (秒)
+	CPUCores       int            `json:"cpu_cores,omitempty"`       // 主机CPU核数
+	MemoryTotalMB  float64        `json:"memory_total_mb,omitempty"` // 主机内存总量 (MB)
+	DiskTotalMB    float64        `json:"disk_total_mb,omitempty"`   // 数据盘总容量 (MB)
+	Logstash       *LogstashStats `json:"logstash,omitempty"`        // Logstash监控API统计，API不可用时为空
+}
+
+// LogstashStats 从Logstash监控API（_node/stats）采集的统计
+// 速率按相邻两次采集的计数差计算，首次采集或Logstash重启后计数回退时为0
+type LogstashStats struct {
+	JVMHeapUsedMB      float64         `json:"jvm_heap_used_mb"`
+	JVMHeapMaxMB       float64         `json:"jvm_heap_max_mb"`
+	JVMHeapUsedPercent float64         `json:"jvm_heap_used_percent"`
+	EventsInRate       float64         `json:"events_in_rate"`  // 全部管道每秒接收事件数
+	EventsOutRate      float64         `json:"events_out_rate"` // 全部管道每秒输出事件数
+	Pipelines          []PipelineStats `json:"pipelines"`
+}
+
+// PipelineStats 单个Logstash管道的统计
+type PipelineStats struct {
+	ID             string           `json:"id"`
+	EventsIn       int64            `json:"events_in"`
+	EventsFiltered int64            `json:"events_filtered"`
+	EventsOut      int64            `json:"events_out"`
+	EventsInRate   float64          `json:"events_in_rate"`
+	EventsOutRate  float64          `json:"events_out_rate"`
+	DurationMillis int64            `json:"duration_millis"`            // 事件在过滤和输出阶段累计耗时
+	QueueType      string           `json:"queue_type,omitempty"`       // memory 或 persisted
+	QueueEvents    int64            `json:"queue_events"`               // 队列中等待处理的事件数
+	QueueSizeBytes int64            `json:"queue_size_bytes,omitempty"` // 持久化队列占用的磁盘空间
+	QueuePushed    int64            `json:"queue_pushed"`               // 输入插件写入队列的事件数
+	QueuePopped    int64            `json:"queue_popped"`               // 工作线程从队列取出并完成过滤的事件数
+	DeadLetter     *DeadLetterStats `json:"dead_letter,omitempty"`      // 死信队列统计，管道未启用死信队列时为空
+	Plugins        []PluginStats    `json:"plugins"`
+}
+
+// DeadLetterStats 管道死信队列的统计
+type DeadLetterStats struct {
+	Events        int64 `json:"events"`         // 输出插件写入死信队列的事件数
+	SizeBytes     int64 `json:"size_bytes"`     // 死信队列占用的磁盘空间
+	DroppedEvents int64 `json:"dropped_events"` // 死信队列已满被丢弃的事件数
+	ExpiredEvents int64 `json:"expired_events"` // 超过保留时间被清理的事件数
+}
+
+// 管道插件类型
+const (
+	PluginTypeInput  = "input"
+	PluginTypeFilter = "filter"
+	PluginTypeOutput = "output"
+)
+
+// PluginStats 管道中单个插件的统计
+type PluginStats struct {
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	Type           string `json:"type"` // input, filter, output
+	EventsIn       int64  `json:"events_in"`
+	EventsOut      int64  `json:"events_out"`
+	DurationMillis int64  `json:"duration_millis"`    // 插件处理事件的累计耗时，输入插件为写入队列的耗时
+	Failures       int64  `json:"failures,omitempty"` // 插件报告的失败数，输出插件为不可重试的写入失败
+}
+
+// MetricsReportRequest Agent经HTTP上报指标的请求体
+type MetricsReportRequest struct {
+	Metrics AgentMetrics `json:"metrics" binding:"required"`
+}
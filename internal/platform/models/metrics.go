@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+)
+
+// MetricsSample 写入指标时序索引的一条Agent指标
+type MetricsSample struct {
+	AgentID string `json:"agent_id"`
+	AgentMetrics
+}
+
+// MetricsQueryRequest Agent指标时序查询请求
+type MetricsQueryRequest struct {
+	From     time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"` // 默认最近1小时
+	To       time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`   // 默认当前时间
+	Interval string    `form:"interval"`                                     // 聚合间隔，如 30s、5m、1h，为空时按时间范围自动选择
+}
+
+// MetricsSeries Agent指标时序，按聚合间隔分桶
+type MetricsSeries struct {
+	AgentID  string         `json:"agent_id"`
+	From     time.Time      `json:"from"`
+	To       time.Time      `json:"to"`
+	Interval string         `json:"interval"`
+	Points   []MetricsPoint `json:"points"`
+}
+
+// MetricsPoint 一个聚合间隔内的指标均值，间隔内没有上报时各值为空
+type MetricsPoint struct {
+	Timestamp          time.Time `json:"timestamp"`
+	Samples            int64     `json:"samples"`
+	CPUUsage           *float64  `json:"cpu_usage"`
+	MemoryUsage        *float64  `json:"memory_usage"`
+	DiskUsage          *float64  `json:"disk_usage"`
+	EventsInRate       *float64  `json:"events_in_rate"`  // Logstash每秒接收事件数
+	EventsOutRate      *float64  `json:"events_out_rate"` // Logstash每秒输出事件数
+	JVMHeapUsedPercent *float64  `json:"jvm_heap_used_percent"`
+}
+
+// AgentPipelineStats Agent最近一次上报的各管道统计
+type AgentPipelineStats struct {
+	AgentID    string          `json:"agent_id"`
+	ReportedAt *time.Time      `json:"reported_at,omitempty"` // 指标采集时间，Agent尚未上报Logstash统计时为空
+	Pipelines  []PipelineStats `json:"pipelines"`
+}
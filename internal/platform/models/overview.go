@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// FleetOverview 仪表盘总览，一次请求返回Agent、配置和部署的汇总
+type FleetOverview struct {
+	Agents         AgentOverview       `json:"agents"`
+	Configs        ConfigOverview      `json:"configs"`
+	Deployments    DeploymentOverview  `json:"deployments"`
+	TopCPUAgents   []AgentCPUUsage     `json:"top_cpu_agents"`  // 最近一次上报的CPU使用率最高的在线Agent
+	RecentFailures []DeploymentFailure `json:"recent_failures"` // 最近失败或已回滚的部署，按创建时间倒序
+	GeneratedAt    time.Time           `json:"generated_at"`
+}
+
+// AgentOverview Agent数量汇总
+type AgentOverview struct {
+	Total    int64            `json:"total"`
+	ByStatus map[string]int64 `json:"by_status"` // online、degraded、warning、offline 等
+}
+
+// ConfigOverview 配置数量汇总
+type ConfigOverview struct {
+	Total        int64            `json:"total"`
+	Enabled      int64            `json:"enabled"`
+	ByType       map[string]int64 `json:"by_type"`
+	ByTestStatus map[string]int64 `json:"by_test_status"` // untested、testing、passed、failed
+}
+
+// DeploymentOverview 统计窗口内创建的部署汇总
+type DeploymentOverview struct {
+	Since       time.Time        `json:"since"`
+	Total       int64            `json:"total"`
+	ByStatus    map[string]int64 `json:"by_status"`
+	SuccessRate *float64         `json:"success_rate"` // 已结束的部署中成功的比例（0-1），窗口内没有已结束的部署时为空
+}
+
+// AgentCPUUsage Agent最近一次上报的CPU使用率
+type AgentCPUUsage struct {
+	AgentID    string    `json:"agent_id"`
+	Hostname   string    `json:"hostname"`
+	Status     string    `json:"status"`
+	CPUUsage   float64   `json:"cpu_usage"`
+	ReportedAt time.Time `json:"reported_at"` // 指标采集时间
+}
+
+// DeploymentFailure 失败或已回滚的部署摘要
+type DeploymentFailure struct {
+	ID            string           `json:"id"`
+	ConfigID      string           `json:"config_id"`
+	ConfigName    string           `json:"config_name"`
+	ConfigVersion int              `json:"config_version"`
+	Status        DeploymentStatus `json:"status"`
+	CreatedBy     string           `json:"created_by"`
+	CreatedAt     time.Time        `json:"created_at"`
+	CompletedAt   *time.Time       `json:"completed_at,omitempty"`
+}
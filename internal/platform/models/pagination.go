@@ -0,0 +1,60 @@
+package models
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// 配置和Agent列表的排序字段
+const (
+	SortUpdatedAt = "updated_at" // 配置的更新时间；Agent的最近心跳时间
+	SortName      = "name"       // 配置名称；Agent主机名
+	SortVersion   = "version"    // 配置版本；Agent的Logstash版本
+)
+
+// 排序方向
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// SortOrder 排序方向，未指定时名称升序，其余字段降序
+func SortOrder(field, order string) string {
+	if order != "" {
+		return order
+	}
+	if field == SortName {
+		return SortAsc
+	}
+	return SortDesc
+}
+
+// EncodeCursor 将上一页最后一条记录的排序值编码为游标
+func EncodeCursor(values []interface{}) string {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor 解析游标中的排序值，数字保持原样以免时间戳等大整数丢失精度
+func DecodeCursor(cursor string) ([]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("游标格式无效: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var values []interface{}
+	if err := decoder.Decode(&values); err != nil {
+		return nil, fmt.Errorf("游标内容无效: %w", err)
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("游标内容为空")
+	}
+	return values, nil
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+)
+
+// Pipeline 由多个输入、过滤、输出配置按顺序拼装的可部署流水线
+// 每次组成或引用配置的内容变化都会产生新版本，部署时将拼装结果写入与流水线同ID的配置再下发给Agent
+type Pipeline struct {
+	ID          string              `json:"id"`
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	ConfigIDs   []string            `json:"config_ids"` // 按顺序引用的配置，依次为输入、过滤、输出
+	Components  []PipelineComponent `json:"components"` // 当前版本拼装时各配置的版本
+	Content     string              `json:"content"`    // 当前版本拼装出的流水线文件
+	Version     int                 `json:"version"`
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+	CreatedBy   string              `json:"created_by"`
+	UpdatedBy   string              `json:"updated_by"`
+}
+
+// PipelineComponent 流水线引用的配置快照
+type PipelineComponent struct {
+	ConfigID string     `json:"config_id"`
+	Name     string     `json:"name"`
+	Type     ConfigType `json:"type"`
+	Version  int        `json:"version"`
+}
+
+// PipelineVersion 流水线历史版本
+type PipelineVersion struct {
+	PipelineID string              `json:"pipeline_id"`
+	Version    int                 `json:"version"`
+	ConfigIDs  []string            `json:"config_ids"`
+	Components []PipelineComponent `json:"components"`
+	Content    string              `json:"content"`
+	ModifiedBy string              `json:"modified_by"`
+	ModifiedAt time.Time           `json:"modified_at"`
+}
+
+// CreatePipelineRequest 创建流水线请求
+type CreatePipelineRequest struct {
+	Name        string   `json:"name" binding:"required,min=1,max=100"`
+	Description string   `json:"description"`
+	ConfigIDs   []string `json:"config_ids" binding:"required,min=1"`
+}
+
+// UpdatePipelineRequest 更新流水线请求
+type UpdatePipelineRequest struct {
+	Description string   `json:"description"`
+	ConfigIDs   []string `json:"config_ids" binding:"required,min=1"`
+}
+
+// PipelineRender 按引用配置的最新内容拼装的结果
+type PipelineRender struct {
+	PipelineID string                  `json:"pipeline_id"`
+	Version    int                     `json:"version"` // 当前保存的版本
+	Changed    bool                    `json:"changed"` // 引用的配置在当前版本之后有修改，部署时将产生新版本
+	Components []PipelineComponent     `json:"components"`
+	Content    string                  `json:"content"`
+	Validation *ConfigValidationResult `json:"validation,omitempty"` // 平台没有可用的Logstash时为空
+}
+
+// DeployPipelineRequest 部署流水线请求
+// AgentIDs 和 Selector 至少指定一个，同时指定时取并集
+type DeployPipelineRequest struct {
+	AgentIDs []string `json:"agent_ids"`
+	Selector string   `json:"selector"`
+}
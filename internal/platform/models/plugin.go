@@ -0,0 +1,156 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// LogstashPlugin Agent上安装的Logstash插件
+type LogstashPlugin struct {
+	Name        string `json:"name"`                  // 插件gem名，如 logstash-filter-grok
+	Version     string `json:"version,omitempty"`     // 插件版本，集成插件提供的子插件为集成插件的版本
+	Integration string `json:"integration,omitempty"` // 由集成插件提供时为集成插件的名称，如 logstash-integration-kafka
+}
+
+// PluginInventoryReport Agent上报的插件清单（logstash-plugin list --verbose）
+type PluginInventoryReport struct {
+	Plugins     []LogstashPlugin `json:"plugins" binding:"required"`
+	CollectedAt time.Time        `json:"collected_at"` // Agent执行logstash-plugin的时间
+}
+
+// PluginInventory 平台保存的Agent插件清单
+type PluginInventory struct {
+	AgentID     string           `json:"agent_id"`
+	Plugins     []LogstashPlugin `json:"plugins"`
+	CollectedAt time.Time        `json:"collected_at"`
+	ReportedAt  time.Time        `json:"reported_at"`
+}
+
+// Has 清单中是否包含插件，集成插件提供的子插件同样计入
+func (i *PluginInventory) Has(name string) bool {
+	for _, plugin := range i.Plugins {
+		if plugin.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// AgentPluginCheck 单个Agent的插件兼容性检查结果
+type AgentPluginCheck struct {
+	AgentID string   `json:"agent_id"`
+	Missing []string `json:"missing,omitempty"` // 配置引用但Agent未安装的插件
+	Unknown bool     `json:"unknown,omitempty"` // Agent尚未上报插件清单，未检查
+}
+
+// PluginName 插件在配置中的区段和名称对应的gem名，如 filter、grok 对应 logstash-filter-grok
+func PluginName(section, name string) string {
+	return "logstash-" + section + "-" + strings.ToLower(name)
+}
+
+// 插件安装相关的消息类型（仅WebSocket）
+const (
+	MsgTypePluginInstall         = "plugin_install"          // 平台请求Agent安装或更新插件
+	MsgTypePluginInstallProgress = "plugin_install_progress" // Agent报告安装进度
+	MsgTypePluginInstallResult   = "plugin_install_result"   // Agent报告安装结果
+)
+
+// 插件安装状态
+const (
+	PluginInstallPending   = "pending"   // 已下发，Agent尚未开始
+	PluginInstallRunning   = "running"   // Agent正在下载或安装
+	PluginInstallSucceeded = "succeeded" // 安装成功
+	PluginInstallFailed    = "failed"    // 安装失败或等待Agent超时
+)
+
+// 插件安装阶段，Agent在进度中报告
+const (
+	PluginInstallPhaseDownloading = "downloading" // 下载离线包
+	PluginInstallPhaseInstalling  = "installing"  // 执行logstash-plugin
+	PluginInstallPhaseVerifying   = "verifying"   // 重新采集插件清单确认安装的版本
+	PluginInstallPhaseRestarting  = "restarting"  // 重启Logstash加载新插件
+)
+
+// MaxPluginInstallOutput 安装结果中保留的logstash-plugin输出上限，超过时保留末尾
+const MaxPluginInstallOutput = 64 * 1024
+
+// PluginInstallRequest 安装或更新插件请求
+// 未指定version时安装最新版本，已安装时更新到最新版本；指定bundle_url时从离线包安装，Agent无需访问RubyGems
+type PluginInstallRequest struct {
+	Name      string `json:"name" binding:"required"`                      // 插件gem名，如 logstash-filter-translate
+	Version   string `json:"version,omitempty"`                            // 安装的版本，为空时为最新版本
+	BundleURL string `json:"bundle_url,omitempty" binding:"omitempty,url"` // 离线包（logstash-plugin prepare-offline-pack生成的zip）的地址，支持http(s)和Agent本机的file://
+	Restart   bool   `json:"restart,omitempty"`                            // 安装成功后重启Logstash加载新插件
+}
+
+// PluginInstallPayload 下发给Agent的插件安装消息
+type PluginInstallPayload struct {
+	InstallID string `json:"install_id"`
+	PluginInstallRequest
+}
+
+// PluginInstallProgress Agent报告的安装进度
+type PluginInstallProgress struct {
+	InstallID string `json:"install_id"`
+	Phase     string `json:"phase"`             // downloading、installing、verifying、restarting
+	Message   string `json:"message,omitempty"` // 阶段说明
+}
+
+// PluginInstallResult Agent报告的安装结果
+type PluginInstallResult struct {
+	InstallID        string           `json:"install_id"`
+	Success          bool             `json:"success"`
+	Error            string           `json:"error,omitempty"`
+	Output           string           `json:"output,omitempty"`            // logstash-plugin的输出
+	Truncated        bool             `json:"truncated,omitempty"`         // 输出超过64KB时只保留末尾
+	InstalledVersion string           `json:"installed_version,omitempty"` // 安装后插件清单中的版本
+	Plugins          []LogstashPlugin `json:"plugins,omitempty"`           // 安装后重新采集的插件清单，平台据此更新Agent的插件清单
+	DurationMs       int64            `json:"duration_ms"`
+}
+
+// PluginInstall 平台记录的一次插件安装
+type PluginInstall struct {
+	ID               string     `json:"id"`
+	AgentID          string     `json:"agent_id"`
+	Name             string     `json:"name"`
+	Version          string     `json:"version,omitempty"`
+	BundleURL        string     `json:"bundle_url,omitempty"`
+	Restart          bool       `json:"restart,omitempty"`
+	Status           string     `json:"status"`
+	Phase            string     `json:"phase,omitempty"` // 最近一次报告的阶段
+	Error            string     `json:"error,omitempty"`
+	Output           string     `json:"output,omitempty"`
+	Truncated        bool       `json:"truncated,omitempty"`
+	InstalledVersion string     `json:"installed_version,omitempty"`
+	RequestedBy      string     `json:"requested_by"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	FinishedAt       *time.Time `json:"finished_at,omitempty"`
+}
+
+// IsFinished 安装是否已结束
+func (i *PluginInstall) IsFinished() bool {
+	return i.Status == PluginInstallSucceeded || i.Status == PluginInstallFailed
+}
+
+// ValidPluginName 插件gem名是否为 logstash-<类型>-<名称>
+func ValidPluginName(name string) bool {
+	return pluginNamePattern.MatchString(name)
+}
+
+// ValidPluginVersion 插件版本是否只包含数字、字母、点和连字符
+func ValidPluginVersion(version string) bool {
+	return version == "" || pluginVersionPattern.MatchString(version)
+}
+
+// ValidPluginInstallID 安装ID是否为平台生成的UUID，Agent用它命名下载的离线包
+func ValidPluginInstallID(id string) bool {
+	return pluginInstallIDPattern.MatchString(id)
+}
+
+var (
+	pluginInstallIDPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+	pluginNamePattern      = regexp.MustCompile(`^logstash-(input|filter|output|codec|integration)-[a-z0-9][a-z0-9_-]*$`)
+	pluginVersionPattern   = regexp.MustCompile(`^[0-9][0-9A-Za-z.-]*$`)
+)
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// PluginCatalog Logstash插件及其选项的目录，供配置编辑器自动补全和静态检查未知选项
+type PluginCatalog struct {
+	Version  string                    `json:"version"`            // 目录数据的版本
+	Logstash string                    `json:"logstash,omitempty"` // 目录对应的Logstash版本
+	Source   string                    `json:"source"`             // builtin为平台内置的目录，否则为加载的文件路径
+	LoadedAt time.Time                 `json:"loaded_at"`
+	Common   map[string][]PluginOption `json:"common"` // 各区段所有插件共有的选项，键为 input、filter、output、codec
+	Plugins  []PluginSchema            `json:"plugins"`
+}
+
+// PluginSchema 单个插件的选项
+type PluginSchema struct {
+	Section     string         `json:"section"` // input、filter、output、codec
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Options     []PluginOption `json:"options"`
+}
+
+// PluginOption 插件选项
+type PluginOption struct {
+	Name        string      `json:"name"`
+	Type        string      `json:"type"` // string、number、boolean、array、hash、codec、password、path、uri
+	Required    bool        `json:"required,omitempty"`
+	Default     interface{} `json:"default,omitempty"`
+	Values      []string    `json:"values,omitempty"` // 可选的取值，为空时不限制
+	Description string      `json:"description,omitempty"`
+	Deprecated  bool        `json:"deprecated,omitempty"`
+	ReplacedBy  string      `json:"replaced_by,omitempty"` // 已弃用选项的替代选项
+}
+
+// PluginCatalogRequest 查询插件目录，条件为空时返回全部插件
+type PluginCatalogRequest struct {
+	Section string `form:"section" binding:"omitempty,oneof=input filter output codec"`
+	Name    string `form:"name"`
+}
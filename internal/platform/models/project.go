@@ -0,0 +1,64 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+)
+
+// DefaultProject 默认项目，引入项目之前创建的配置、Agent和部署记录没有项目字段，均视为属于默认项目
+const DefaultProject = "default"
+
+// ProjectNamePattern 项目名称规则
+var ProjectNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// ProjectOf 规范化资源的项目，为空时返回默认项目
+func ProjectOf(project string) string {
+	if project == "" {
+		return DefaultProject
+	}
+	return project
+}
+
+// ValidateProjectRoles 校验用户的项目角色，键为项目名称，值为用户角色
+func ValidateProjectRoles(roles map[string]string) error {
+	for project, role := range roles {
+		if !ProjectNamePattern.MatchString(project) {
+			return fmt.Errorf("无效的项目名称: %q", project)
+		}
+		if !ValidRole(role) {
+			return fmt.Errorf("项目 %s 的角色无效: %q", project, role)
+		}
+	}
+	return nil
+}
+
+// ProjectRole 计算用户在项目内的有效角色，无权访问该项目时返回空
+// 全局管理员在所有项目内均为管理员；未分配项目角色的用户在所有项目内使用全局角色；
+// 分配了项目角色的用户只能访问已分配的项目
+func ProjectRole(role string, projectRoles map[string]string, project string) string {
+	if role == RoleAdmin || len(projectRoles) == 0 {
+		return role
+	}
+	return projectRoles[project]
+}
+
+// projectKey 上下文中保存当前项目的键
+type projectKey struct{}
+
+// WithProject 在上下文中记录请求所属的项目
+func WithProject(ctx context.Context, project string) context.Context {
+	return context.WithValue(ctx, projectKey{}, project)
+}
+
+// ProjectFrom 获取上下文中的项目，后台任务和Agent请求没有项目时返回空，表示不按项目过滤
+func ProjectFrom(ctx context.Context) string {
+	project, _ := ctx.Value(projectKey{}).(string)
+	return project
+}
+
+// InProject 判断资源的项目是否在上下文的项目范围内
+func InProject(ctx context.Context, project string) bool {
+	scope := ProjectFrom(ctx)
+	return scope == "" || scope == ProjectOf(project)
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjectRole(t *testing.T) {
+	roles := map[string]string{"payments": RoleEditor, "search": RoleViewer}
+
+	assert.Equal(t, RoleAdmin, ProjectRole(RoleAdmin, roles, "other"))
+	assert.Equal(t, RoleViewer, ProjectRole(RoleViewer, nil, "other"))
+	assert.Equal(t, RoleEditor, ProjectRole(RoleViewer, roles, "payments"))
+	assert.Equal(t, RoleViewer, ProjectRole(RoleEditor, roles, "search"))
+	assert.Empty(t, ProjectRole(RoleEditor, roles, DefaultProject))
+}
+
+func TestValidateProjectRoles(t *testing.T) {
+	assert.NoError(t, ValidateProjectRoles(map[string]string{"payments": RoleEditor}))
+	assert.Error(t, ValidateProjectRoles(map[string]string{"Payments": RoleEditor}))
+	assert.Error(t, ValidateProjectRoles(map[string]string{"payments": RoleAgent}))
+}
+
+func TestInProject(t *testing.T) {
+	assert.True(t, InProject(context.Background(), "payments"))
+
+	ctx := WithProject(context.Background(), DefaultProject)
+	assert.True(t, InProject(ctx, ""))
+	assert.True(t, InProject(ctx, DefaultProject))
+	assert.False(t, InProject(ctx, "payments"))
+}
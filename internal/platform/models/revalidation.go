@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+)
+
+// ErrorTypeRevalidationFailed 定期重新校验未通过时写入事件收件箱的错误类型
+const ErrorTypeRevalidationFailed = "config_revalidation_failed"
+
+// ConfigRevalidation 配置最近一次定期重新校验的结果，按配置ID保存
+type ConfigRevalidation struct {
+	ConfigID      string              `json:"config_id"`
+	ConfigName    string              `json:"config_name"`
+	ConfigVersion int                 `json:"config_version"`
+	Valid         bool                `json:"valid"`
+	SyntaxChecked bool                `json:"syntax_checked"` // 平台没有可用的Logstash时只检查内容规则
+	Errors        []ConfigSyntaxError `json:"errors"`
+	FailingSince  *time.Time          `json:"failing_since,omitempty"` // 连续未通过的起始时间
+	IncidentID    string              `json:"incident_id,omitempty"`   // 新出现失败时创建的事件
+	CheckedAt     time.Time           `json:"checked_at"`
+}
+
+// RevalidationRun 一次重新校验的汇总
+type RevalidationRun struct {
+	StartedAt   time.Time `json:"started_at"`
+	FinishedAt  time.Time `json:"finished_at"`
+	Checked     int       `json:"checked"`
+	Failed      int       `json:"failed"`
+	Errored     int       `json:"errored"`      // 校验本身出错（如超时）未得出结论的配置数
+	NewlyFailed []string  `json:"newly_failed"` // 本次新出现失败的配置ID
+	Recovered   []string  `json:"recovered"`    // 本次恢复通过的配置ID
+}
+
+// ConfigRevalidationListRequest 重新校验结果列表请求
+type ConfigRevalidationListRequest struct {
+	Failing  bool `form:"failing"` // 只返回未通过的配置
+	Page     int  `form:"page,default=1"`
+	PageSize int  `form:"size,default=10"`
+}
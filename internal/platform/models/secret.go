@@ -0,0 +1,75 @@
+package models
+
+import (
+	"regexp"
+	"sort"
+	"time"
+)
+
+// secretRefPattern 配置内容中的密钥引用 ${secret:名称}，名称与Logstash keystore的键名规则一致
+var secretRefPattern = regexp.MustCompile(`\$\{secret:([A-Za-z_][A-Za-z0-9_.]*)\}`)
+
+// SecretNamePattern 密钥名称规则
+var SecretNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// Secret 密钥的元数据，密钥值只写不读，API不返回
+type Secret struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Version     int       `json:"version"` // 每次更新值时递增
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	CreatedBy   string    `json:"created_by"`
+	UpdatedBy   string    `json:"updated_by"`
+}
+
+// EncryptedSecret 存储中的密钥，值使用平台主密钥加密
+type EncryptedSecret struct {
+	Secret
+	Ciphertext string `json:"ciphertext"` // base64编码的随机数和AES-GCM密文
+}
+
+// CreateSecretRequest 创建密钥请求
+type CreateSecretRequest struct {
+	Name        string `json:"name" binding:"required,min=1,max=128"`
+	Value       string `json:"value" binding:"required"`
+	Description string `json:"description"`
+}
+
+// UpdateSecretRequest 更新密钥请求，值整体替换
+type UpdateSecretRequest struct {
+	Value       string `json:"value" binding:"required"`
+	Description string `json:"description"`
+}
+
+// ResolveSecretsRequest Agent获取配置引用的密钥值
+type ResolveSecretsRequest struct {
+	ConfigID string   `json:"config_id" binding:"required"`
+	Names    []string `json:"names" binding:"required,min=1"`
+}
+
+// ResolvedSecrets 密钥名称到明文值的映射，只通过Agent接口返回
+type ResolvedSecrets struct {
+	Secrets map[string]string `json:"secrets"`
+}
+
+// SecretReferences 获取配置内容中 ${secret:名称} 引用的密钥，按名称排序去重
+func SecretReferences(content string) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, m := range secretRefPattern.FindAllStringSubmatch(content, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			names = append(names, m[1])
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ReplaceSecretReferences 将配置内容中的 ${secret:名称} 替换为replace的返回值
+func ReplaceSecretReferences(content string, replace func(name string) string) string {
+	return secretRefPattern.ReplaceAllStringFunc(content, func(ref string) string {
+		return replace(secretRefPattern.FindStringSubmatch(ref)[1])
+	})
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecretReferences(t *testing.T) {
+	content := `output {
+  elasticsearch { user => "logstash" password => "${secret:es_password}" }
+  kafka { sasl_jaas_config => "${secret:kafka.jaas}" }
+  http { headers => { "X-Key" => "${secret:es_password}" "X-Env" => "${HOSTNAME}" } }
+}`
+	assert.Equal(t, []string{"es_password", "kafka.jaas"}, SecretReferences(content))
+	assert.Empty(t, SecretReferences(`input { stdin {} }`))
+
+	replaced := ReplaceSecretReferences(content, func(name string) string { return "${" + name + "}" })
+	assert.Contains(t, replaced, `password => "${es_password}"`)
+	assert.Contains(t, replaced, `"${kafka.jaas}"`)
+	assert.Contains(t, replaced, `"${HOSTNAME}"`)
+}
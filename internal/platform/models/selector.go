@@ -0,0 +1,229 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// 选择器运算符
+const (
+	SelectorOpEquals    = "="
+	SelectorOpNotEquals = "!="
+	SelectorOpIn        = "in"
+	SelectorOpNotIn     = "notin"
+	SelectorOpExists    = "exists"
+	SelectorOpNotExists = "!exists"
+)
+
+// ErrInvalidSelector 标签选择器语法无效
+var ErrInvalidSelector = errors.New("标签选择器无效")
+
+// LabelRequirement 单个标签条件
+type LabelRequirement struct {
+	Key      string
+	Operator string
+	Values   []string
+}
+
+// LabelSelector 标签选择器，语法与Kubernetes类似，多个条件之间为与关系
+//
+//	env=prod,dc!=west,tier in (web,api),canary,!deprecated
+type LabelSelector struct {
+	Requirements []LabelRequirement
+}
+
+// ParseLabelSelector 解析标签选择器
+func ParseLabelSelector(s string) (*LabelSelector, error) {
+	selector := &LabelSelector{}
+	for _, term := range splitSelectorTerms(s) {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+
+		req, err := parseRequirement(term)
+		if err != nil {
+			return nil, err
+		}
+		selector.Requirements = append(selector.Requirements, req)
+	}
+
+	if len(selector.Requirements) == 0 {
+		return nil, fmt.Errorf("%w: 不能为空", ErrInvalidSelector)
+	}
+	return selector, nil
+}
+
+// Matches 判断标签集合是否满足选择器
+func (s *LabelSelector) Matches(labels map[string]string) bool {
+	for _, req := range s.Requirements {
+		value, ok := labels[req.Key]
+		switch req.Operator {
+		case SelectorOpEquals:
+			if !ok || value != req.Values[0] {
+				return false
+			}
+		case SelectorOpNotEquals:
+			if ok && value == req.Values[0] {
+				return false
+			}
+		case SelectorOpIn:
+			if !ok || !containsValue(req.Values, value) {
+				return false
+			}
+		case SelectorOpNotIn:
+			if ok && containsValue(req.Values, value) {
+				return false
+			}
+		case SelectorOpExists:
+			if !ok {
+				return false
+			}
+		case SelectorOpNotExists:
+			if ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// EqualityTerms 返回选择器中的等值条件，供仓库层预过滤
+func (s *LabelSelector) EqualityTerms() map[string]string {
+	terms := make(map[string]string)
+	for _, req := range s.Requirements {
+		if req.Operator == SelectorOpEquals {
+			terms[req.Key] = req.Values[0]
+		}
+	}
+	return terms
+}
+
+// String 返回规范化后的选择器字符串
+func (s *LabelSelector) String() string {
+	parts := make([]string, 0, len(s.Requirements))
+	for _, req := range s.Requirements {
+		switch req.Operator {
+		case SelectorOpExists:
+			parts = append(parts, req.Key)
+		case SelectorOpNotExists:
+			parts = append(parts, "!"+req.Key)
+		case SelectorOpIn, SelectorOpNotIn:
+			parts = append(parts, fmt.Sprintf("%s %s (%s)", req.Key, req.Operator, strings.Join(req.Values, ",")))
+		default:
+			parts = append(parts, req.Key+req.Operator+req.Values[0])
+		}
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
+
+// parseRequirement 解析单个条件
+func parseRequirement(term string) (LabelRequirement, error) {
+	if strings.HasPrefix(term, "!") {
+		key := strings.TrimSpace(term[1:])
+		if !validLabelKey(key) {
+			return LabelRequirement{}, fmt.Errorf("%w: %s", ErrInvalidSelector, term)
+		}
+		return LabelRequirement{Key: key, Operator: SelectorOpNotExists}, nil
+	}
+
+	if i := strings.Index(term, "!="); i > 0 {
+		return equalityRequirement(term, term[:i], SelectorOpNotEquals, term[i+2:])
+	}
+	if i := strings.Index(term, "=="); i > 0 {
+		return equalityRequirement(term, term[:i], SelectorOpEquals, term[i+2:])
+	}
+	if i := strings.Index(term, "="); i > 0 {
+		return equalityRequirement(term, term[:i], SelectorOpEquals, term[i+1:])
+	}
+
+	fields := strings.Fields(term)
+	if len(fields) >= 2 && (fields[1] == SelectorOpIn || fields[1] == SelectorOpNotIn) {
+		rest := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(term[len(fields[0]):]), fields[1]))
+		if !strings.HasPrefix(rest, "(") || !strings.HasSuffix(rest, ")") {
+			return LabelRequirement{}, fmt.Errorf("%w: %s", ErrInvalidSelector, term)
+		}
+		var values []string
+		for _, v := range strings.Split(rest[1:len(rest)-1], ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+		if len(values) == 0 {
+			return LabelRequirement{}, fmt.Errorf("%w: %s", ErrInvalidSelector, term)
+		}
+		return LabelRequirement{Key: fields[0], Operator: fields[1], Values: values}, nil
+	}
+
+	if len(fields) == 1 && validLabelKey(fields[0]) {
+		return LabelRequirement{Key: fields[0], Operator: SelectorOpExists}, nil
+	}
+
+	return LabelRequirement{}, fmt.Errorf("%w: %s", ErrInvalidSelector, term)
+}
+
+// equalityRequirement 构造等值或不等值条件
+func equalityRequirement(term, key, op, value string) (LabelRequirement, error) {
+	key = strings.TrimSpace(key)
+	value = strings.TrimSpace(value)
+	if !validLabelKey(key) {
+		return LabelRequirement{}, fmt.Errorf("%w: %s", ErrInvalidSelector, term)
+	}
+	return LabelRequirement{Key: key, Operator: op, Values: []string{value}}, nil
+}
+
+// validLabelKey 判断标签键是否合法
+func validLabelKey(key string) bool {
+	return key != "" && !strings.ContainsAny(key, " =!(),")
+}
+
+// splitSelectorTerms 按逗号拆分条件，括号内的逗号不拆分
+func splitSelectorTerms(s string) []string {
+	var terms []string
+	depth, start := 0, 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				terms = append(terms, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(terms, s[start:])
+}
+
+// containsValue 判断值是否在列表中
+func containsValue(values []string, v string) bool {
+	for _, item := range values {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+// SelectorLabels 返回用于选择器匹配的标签集合
+// 平台侧的设置覆盖先合并，Agent本地上报的同名标签优先（与Agent端合并顺序一致）；所属分组以 group 标签参与匹配
+func (a *Agent) SelectorLabels() map[string]string {
+	labels := make(map[string]string)
+	if a.Settings != nil {
+		for k, v := range a.Settings.Labels {
+			labels[k] = v
+		}
+	}
+	for k, v := range a.Labels {
+		labels[k] = v
+	}
+	if a.Group != "" {
+		labels["group"] = a.Group
+	}
+	return labels
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLabelSelector_Matches(t *testing.T) {
+	labels := map[string]string{"env": "prod", "dc": "east", "tier": "web", "canary": "true"}
+
+	tests := []struct {
+		selector string
+		want     bool
+	}{
+		{"env=prod", true},
+		{"env==prod,dc=east", true},
+		{"env=prod,dc=west", false},
+		{"dc!=west", true},
+		{"tier in (web, api)", true},
+		{"tier notin (web,api)", false},
+		{"env=prod,tier in (api,worker)", false},
+		{"canary", true},
+		{"!deprecated", true},
+		{"!canary", false},
+		{"region!=us", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			selector, err := ParseLabelSelector(tt.selector)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, selector.Matches(labels))
+		})
+	}
+}
+
+func TestParseLabelSelector_Invalid(t *testing.T) {
+	for _, s := range []string{"", " , ", "=prod", "tier in web", "tier in ()", "a b c"} {
+		_, err := ParseLabelSelector(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestLabelSelector_EqualityTerms(t *testing.T) {
+	selector, err := ParseLabelSelector("env=prod,dc!=west,tier in (web)")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"env": "prod"}, selector.EqualityTerms())
+}
@@ -0,0 +1,55 @@
+package models
+
+// 字段差异类型
+const (
+	FieldAdded   = "added"   // 只在候选版本的输出中出现
+	FieldRemoved = "removed" // 只在基准版本的输出中出现
+	FieldChanged = "changed" // 两个版本的值不同
+)
+
+// MaxCompareSamples 对比测试一次最多处理的样本数，两个版本各处理一遍，请求同步返回
+const MaxCompareSamples = 200
+
+// TestCompareSide 对比的一侧：配置ID及可选的历史版本
+type TestCompareSide struct {
+	ConfigID string `json:"config_id" binding:"required"`
+	Version  int    `json:"version,omitempty" binding:"omitempty,min=1"` // 为空时取当前版本
+}
+
+// TestCompareRequest 对比测试请求，同一组样本分别经两个配置版本处理后逐条比较输出
+type TestCompareRequest struct {
+	Base         TestCompareSide `json:"base" binding:"required"`
+	Candidate    TestCompareSide `json:"candidate" binding:"required"`
+	Samples      []string        `json:"samples" binding:"required,min=1,max=200"`
+	IgnoreFields []string        `json:"ignore_fields,omitempty"` // 不参与比较的字段（以.连接的路径），为空时忽略@timestamp
+}
+
+// FieldDiff 一个字段的差异，嵌套字段以.连接路径
+type FieldDiff struct {
+	Field     string      `json:"field"`
+	Change    string      `json:"change"` // added, removed, changed
+	Base      interface{} `json:"base,omitempty"`
+	Candidate interface{} `json:"candidate,omitempty"`
+}
+
+// EventComparison 单条样本在两个版本下的输出比较
+type EventComparison struct {
+	Index          int         `json:"index"`
+	Input          string      `json:"input"`
+	Identical      bool        `json:"identical"`
+	BaseError      string      `json:"base_error,omitempty"`
+	CandidateError string      `json:"candidate_error,omitempty"`
+	Diffs          []FieldDiff `json:"diffs,omitempty"`
+}
+
+// TestCompareResult 对比测试结果
+type TestCompareResult struct {
+	Base         TestCompareSide   `json:"base"`      // 实际使用的版本
+	Candidate    TestCompareSide   `json:"candidate"` // 实际使用的版本
+	Identical    bool              `json:"identical"` // 全部样本的输出一致
+	EventCount   int               `json:"event_count"`
+	ChangedCount int               `json:"changed_count"`
+	IgnoreFields []string          `json:"ignore_fields"`
+	Events       []EventComparison `json:"events"`
+	DurationMs   int64             `json:"duration_ms"`
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+)
+
+// 测试数据集运行状态
+const (
+	DatasetRunPassed = "passed"
+	DatasetRunFailed = "failed"
+)
+
+// TestDataset 保存在配置上的命名样本数据集，用于管道的回归测试
+// 样本可以附带期望输出，运行时逐个字段断言；未附带期望输出的样本只断言处理成功
+type TestDataset struct {
+	ID          string          `json:"id"`
+	ConfigID    string          `json:"config_id"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Samples     []DatasetSample `json:"samples"`
+	LastRun     *DatasetRun     `json:"last_run,omitempty"` // 最近一次运行结果
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	CreatedBy   string          `json:"created_by"`
+	UpdatedBy   string          `json:"updated_by"`
+}
+
+// DatasetSample 数据集中的一条样本
+type DatasetSample struct {
+	Input    string                 `json:"input" binding:"required"`
+	Expected map[string]interface{} `json:"expected,omitempty"` // 以点号分隔的字段路径到期望值
+}
+
+// CreateTestDatasetRequest 创建测试数据集请求
+type CreateTestDatasetRequest struct {
+	Name        string          `json:"name" binding:"required,min=1,max=64"`
+	Description string          `json:"description"`
+	Samples     []DatasetSample `json:"samples" binding:"required,min=1,max=500,dive"`
+}
+
+// UpdateTestDatasetRequest 更新测试数据集请求
+type UpdateTestDatasetRequest struct {
+	Description string          `json:"description"`
+	Samples     []DatasetSample `json:"samples" binding:"required,min=1,max=500,dive"`
+}
+
+// DatasetRun 数据集的一次运行结果
+type DatasetRun struct {
+	DatasetID     string            `json:"dataset_id"`
+	DatasetName   string            `json:"dataset_name"`
+	ConfigVersion int               `json:"config_version"`
+	Status        string            `json:"status"` // passed, failed
+	Passed        int               `json:"passed"` // 通过的断言数
+	Failed        int               `json:"failed"` // 失败的断言数
+	Assertions    []AssertionResult `json:"assertions"`
+	StartedAt     time.Time         `json:"started_at"`
+	DurationMs    int64             `json:"duration_ms"`
+}
+
+// AssertionResult 单个断言的结果，Field为空表示断言样本处理成功
+type AssertionResult struct {
+	Sample   int         `json:"sample"`
+	Field    string      `json:"field,omitempty"`
+	Expected interface{} `json:"expected,omitempty"`
+	Actual   interface{} `json:"actual,omitempty"`
+	Passed   bool        `json:"passed"`
+	Error    string      `json:"error,omitempty"`
+}
+
+// RunTestDatasetsResult 运行配置上全部数据集的结果
+type RunTestDatasetsResult struct {
+	ConfigID      string       `json:"config_id"`
+	ConfigVersion int          `json:"config_version"`
+	Status        string       `json:"status"` // 任一数据集失败时为failed
+	Runs          []DatasetRun `json:"runs"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+// TestFile 上传的测试文件，有效期过后被清理
+type TestFile struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"` // 上传的字节数，gzip压缩的文件为压缩后的大小
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// TestFileSource type为file的测试数据，按行读取文件作为样本，跳过空行，支持gzip压缩的文件
+type TestFileSource struct {
+	FileID   string `json:"file_id"`             // 已上传的测试文件，以multipart创建测试时由随请求上传的文件填入
+	MaxLines int    `json:"max_lines,omitempty"` // 最多读取的行数，默认及上限为测试任务的样本条数上限
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+)
+
+// ErrorTypeScheduledTestRegressed 定期测试由通过变为失败时写入事件收件箱的错误类型
+const ErrorTypeScheduledTestRegressed = "scheduled_test_regressed"
+
+// ScheduledTestRun 一个配置的一次定期测试，用配置的当前版本运行其上保存的全部测试数据集
+type ScheduledTestRun struct {
+	ID             string       `json:"id"`
+	ConfigID       string       `json:"config_id"`
+	ConfigName     string       `json:"config_name"`
+	ConfigVersion  int          `json:"config_version"`
+	Status         string       `json:"status"`                    // passed, failed
+	PreviousStatus string       `json:"previous_status,omitempty"` // 上一次定期测试的状态，首次运行时为配置原有的测试状态
+	Regressed      bool         `json:"regressed"`                 // 此前通过、本次失败
+	Datasets       []DatasetRun `json:"datasets"`
+	FailingSince   *time.Time   `json:"failing_since,omitempty"` // 连续失败的起始时间
+	IncidentID     string       `json:"incident_id,omitempty"`   // 由通过变为失败时创建的事件
+	StartedAt      time.Time    `json:"started_at"`
+	DurationMs     int64        `json:"duration_ms"`
+}
+
+// ScheduledTestSweep 一次定期测试的汇总
+type ScheduledTestSweep struct {
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Tested     int       `json:"tested"`
+	Failed     int       `json:"failed"`
+	Skipped    int       `json:"skipped"`   // 没有保存测试数据集的配置数
+	Errored    int       `json:"errored"`   // 读取数据集或保存结果出错、未得出结论的配置数
+	Regressed  []string  `json:"regressed"` // 本次由通过变为失败的配置ID
+	Recovered  []string  `json:"recovered"` // 本次恢复通过的配置ID
+}
+
+// ScheduledTestListRequest 配置的定期测试记录列表请求
+type ScheduledTestListRequest struct {
+	Page     int `form:"page,default=1"`
+	PageSize int `form:"size,default=10"`
+}
+
+// SetScheduledTestRequest 开启或关闭配置的定期测试
+type SetScheduledTestRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
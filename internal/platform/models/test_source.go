@@ -0,0 +1,30 @@
+package models
+
+import (
+	"encoding/json"
+)
+
+// TestESSource type为elasticsearch的测试数据，从源索引中抽取文档作为样本，使测试使用生产中的真实数据
+type TestESSource struct {
+	Index  string                 `json:"index" binding:"required"` // 源索引或索引模式，必须在平台允许读取的范围内
+	Query  map[string]interface{} `json:"query,omitempty"`          // 筛选文档的ES查询，默认为match_all
+	Field  string                 `json:"field,omitempty"`          // 作为样本的字段，支持点号分隔的路径，例如 event.original；为空时以整个文档的JSON作为样本
+	Size   int                    `json:"size,omitempty"`           // 抽取的文档数，默认及上限为测试任务的样本条数上限
+	Random bool                   `json:"random,omitempty"`         // 随机抽取文档，默认抽取@timestamp最新的文档
+}
+
+// TestReplaySource type为replay的测试数据，回放HTTP输入收到的请求作为样本
+type TestReplaySource struct {
+	Format  string          `json:"format" binding:"required,oneof=har json"` // har为浏览器或代理导出的HAR，以请求体作为样本；json为捕获的事件数组
+	Content json.RawMessage `json:"content" binding:"required"`               // HAR文档或JSON数组
+	Field   string          `json:"field,omitempty"`                          // json格式中作为样本的字段，支持点号分隔的路径；为空时字符串元素直接作为样本，对象元素以JSON作为样本
+}
+
+// TestMaskRule 保存测试结果前的脱敏规则，用于不能保存原始生产数据的场景
+// 只设置pattern时替换输入、输出和错误信息中所有匹配的内容；只设置field时替换输出中该字段的整个值；
+// 两者都设置时只替换该字段中匹配的内容。按field脱敏不影响原始输入，需要时同时设置只有pattern的规则
+type TestMaskRule struct {
+	Field       string `json:"field,omitempty"`       // 以点号分隔的输出字段路径，包括其下的子字段
+	Pattern     string `json:"pattern,omitempty"`     // 正则表达式
+	Replacement string `json:"replacement,omitempty"` // 替换的内容，可以引用pattern的分组，例如 $1***，默认为 [REDACTED]
+}
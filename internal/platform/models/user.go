@@ -0,0 +1,104 @@
+package models
+
+import (
+	"time"
+)
+
+// 用户角色，权限依次递增
+const (
+	RoleViewer = "viewer" // 只读
+	RoleEditor = "editor" // 可修改配置、发起部署、管理Agent
+	RoleAdmin  = "admin"  // 可管理用户
+
+	// RoleAgent Agent使用共享令牌访问注册、心跳、上报等接口时的身份，不属于用户角色体系
+	RoleAgent = "agent"
+)
+
+// roleLevels 角色权限等级
+var roleLevels = map[string]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleAdmin:  3,
+}
+
+// ValidRole 判断角色是否有效
+func ValidRole(role string) bool {
+	_, ok := roleLevels[role]
+	return ok
+}
+
+// RoleAllows 判断role是否具备required角色的权限
+// Agent接口只允许Agent身份和管理员访问，Agent身份不能访问任何用户接口
+func RoleAllows(role, required string) bool {
+	if required == RoleAgent {
+		return role == RoleAgent || role == RoleAdmin
+	}
+	level, ok := roleLevels[role]
+	return ok && level >= roleLevels[required]
+}
+
+// User 平台用户
+// 用户名即文档ID
+type User struct {
+	Username     string            `json:"username"`
+	PasswordHash string            `json:"password_hash,omitempty"` // 仅存储使用，接口返回前清空
+	Role         string            `json:"role"`
+	Teams        []string          `json:"teams,omitempty"`         // 所属团队，用于配置级访问控制
+	ProjectRoles map[string]string `json:"project_roles,omitempty"` // 项目角色，设置后只能访问列出的项目（全局管理员除外）
+	Disabled     bool              `json:"disabled"`
+	LastLoginAt  *time.Time        `json:"last_login_at,omitempty"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+	CreatedBy    string            `json:"created_by"`
+}
+
+// Sanitized 返回去除口令哈希的副本
+func (u *User) Sanitized() *User {
+	cp := *u
+	cp.PasswordHash = ""
+	return &cp
+}
+
+// LoginRequest 登录请求
+type LoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// LoginResponse 登录响应
+type LoginResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	User      *User     `json:"user"`
+}
+
+// CreateUserRequest 创建用户请求
+type CreateUserRequest struct {
+	Username     string            `json:"username" binding:"required,min=1,max=64"`
+	Password     string            `json:"password" binding:"required,min=8"`
+	Role         string            `json:"role" binding:"required,oneof=viewer editor admin"`
+	Teams        []string          `json:"teams"`
+	ProjectRoles map[string]string `json:"project_roles"` // 项目名称到角色，为空时在所有项目内使用全局角色
+}
+
+// UpdateUserRequest 更新用户请求，字段为nil时保持不变
+type UpdateUserRequest struct {
+	Password     *string            `json:"password" binding:"omitempty,min=8"`
+	Role         *string            `json:"role" binding:"omitempty,oneof=viewer editor admin"`
+	Disabled     *bool              `json:"disabled"`
+	Teams        *[]string          `json:"teams"`
+	ProjectRoles *map[string]string `json:"project_roles"`
+}
+
+// TokenClaims 访问令牌中携带的身份信息
+type TokenClaims struct {
+	Subject      string            `json:"sub"`                     // 用户名
+	Role         string            `json:"role"`                    // 签发时的角色
+	Teams        []string          `json:"teams,omitempty"`         // 签发时所属的团队
+	ProjectRoles map[string]string `json:"project_roles,omitempty"` // 签发时的项目角色
+	IssuedAt     int64             `json:"iat"`
+	ExpiresAt    int64             `json:"exp"`
+
+	AgentID  string `json:"-"` // 注册令牌绑定的Agent，共享令牌和用户令牌为空
+	ProofKey []byte `json:"-"` // 注册令牌的密钥哈希，用于向Agent证明平台身份
+}
@@ -0,0 +1,23 @@
+package models
+
+// ValidateConfigRequest 配置语法校验请求
+type ValidateConfigRequest struct {
+	Type    ConfigType `json:"type" binding:"omitempty,oneof=input filter output"`
+	Content string     `json:"content" binding:"required"`
+}
+
+// ConfigSyntaxError 配置语法错误
+// Line和Column从1开始，无法定位时为0
+type ConfigSyntaxError struct {
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// ConfigValidationResult 配置语法校验结果
+type ConfigValidationResult struct {
+	Valid      bool                `json:"valid"`
+	Errors     []ConfigSyntaxError `json:"errors"`
+	Output     string              `json:"output,omitempty"` // Logstash原始输出，便于排查
+	DurationMs int64               `json:"duration_ms"`
+}
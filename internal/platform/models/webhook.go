@@ -0,0 +1,120 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 可订阅的平台事件
+const (
+	WebhookEventConfigCreated  = "config.created"  // 创建配置
+	WebhookEventConfigDeployed = "config.deployed" // 部署结束（成功、部分失败或回滚）
+	WebhookEventAgentOffline   = "agent.offline"   // Agent被判定离线
+	WebhookEventTestCompleted  = "test.completed"  // 配置测试结束
+	WebhookEventTestRegressed  = "test.regressed"  // 定期测试中此前通过的配置开始失败
+)
+
+// WebhookEvents 全部可订阅的事件
+var WebhookEvents = []string{
+	WebhookEventConfigCreated,
+	WebhookEventConfigDeployed,
+	WebhookEventAgentOffline,
+	WebhookEventTestCompleted,
+	WebhookEventTestRegressed,
+}
+
+// 推送记录状态
+const (
+	WebhookDeliveryPending   = "pending"   // 等待发送或等待重试
+	WebhookDeliverySucceeded = "succeeded" // 接收方返回2xx
+	WebhookDeliveryFailed    = "failed"    // 重试次数用尽仍未成功
+)
+
+// Webhook 出站事件通知的订阅
+// 签名密钥只在创建时返回一次，之后的API响应不包含密钥
+type Webhook struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	URL         string    `json:"url"`
+	Events      []string  `json:"events"` // 订阅的事件，见 WebhookEvents
+	Enabled     bool      `json:"enabled"`
+	Description string    `json:"description,omitempty"`
+	CreatedBy   string    `json:"created_by"`
+	UpdatedBy   string    `json:"updated_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Subscribes 是否订阅了该事件
+func (w *Webhook) Subscribes(event string) bool {
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// StoredWebhook 存储中的订阅，包含签名密钥
+type StoredWebhook struct {
+	Webhook
+	Secret string `json:"secret"`
+}
+
+// CreatedWebhook 创建订阅的响应，secret 只在此时返回
+type CreatedWebhook struct {
+	Webhook
+	Secret string `json:"secret"`
+}
+
+// CreateWebhookRequest 创建订阅请求，未指定secret时由平台生成
+type CreateWebhookRequest struct {
+	Name        string   `json:"name" binding:"required,min=1,max=64"`
+	URL         string   `json:"url" binding:"required,url"`
+	Events      []string `json:"events" binding:"required,min=1,dive,oneof=config.created config.deployed agent.offline test.completed test.regressed"`
+	Secret      string   `json:"secret" binding:"omitempty,min=16"`
+	Enabled     *bool    `json:"enabled"` // 默认启用
+	Description string   `json:"description"`
+}
+
+// UpdateWebhookRequest 更新订阅请求，整体替换地址和订阅的事件；secret 为空时保留原密钥
+type UpdateWebhookRequest struct {
+	Name        string   `json:"name" binding:"required,min=1,max=64"`
+	URL         string   `json:"url" binding:"required,url"`
+	Events      []string `json:"events" binding:"required,min=1,dive,oneof=config.created config.deployed agent.offline test.completed test.regressed"`
+	Secret      string   `json:"secret" binding:"omitempty,min=16"`
+	Enabled     bool     `json:"enabled"`
+	Description string   `json:"description"`
+}
+
+// WebhookEvent 推送给接收方的请求体
+type WebhookEvent struct {
+	ID        string      `json:"id"`   // 事件ID，重试时不变，接收方可据此去重
+	Type      string      `json:"type"` // 事件类型，如 config.deployed
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data"` // 事件内容，如配置、部署记录、Agent或测试结果
+}
+
+// WebhookDelivery 一次事件推送的记录
+type WebhookDelivery struct {
+	ID           string          `json:"id"`
+	WebhookID    string          `json:"webhook_id"`
+	EventID      string          `json:"event_id"`
+	Event        string          `json:"event"`
+	Status       string          `json:"status"`   // pending, succeeded, failed
+	Attempts     int             `json:"attempts"` // 已发送的次数
+	ResponseCode int             `json:"response_code,omitempty"`
+	Error        string          `json:"error,omitempty"` // 最近一次发送失败的原因
+	Payload      json.RawMessage `json:"payload"`         // 发送的请求体
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+	NextRetryAt  *time.Time      `json:"next_retry_at,omitempty"`
+	DeliveredAt  *time.Time      `json:"delivered_at,omitempty"`
+}
+
+// WebhookDeliveryListRequest 推送记录查询条件
+type WebhookDeliveryListRequest struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending succeeded failed"`
+	Event  string `form:"event"`
+	Size   int    `form:"size,default=50" binding:"min=1,max=500"` // 返回最近的若干条
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+)
+
+// 后台子系统类型
+const (
+	WorkerKindLoop        = "loop"        // 周期执行的任务
+	WorkerKindQueue       = "queue"       // 待处理的消息或命令队列
+	WorkerKindPool        = "pool"        // 有并发上限的任务执行
+	WorkerKindConnections = "connections" // 长连接管理
+)
+
+// WorkerStatus 单个后台子系统的运行状态
+type WorkerStatus struct {
+	Name            string             `json:"name"`
+	Kind            string             `json:"kind"`
+	Running         bool               `json:"running"`                    // 周期任务是否已启动，其余类型始终为true
+	IntervalSeconds float64            `json:"interval_seconds,omitempty"` // 周期任务的执行间隔
+	LastRunAt       *time.Time         `json:"last_run_at,omitempty"`      // 最近一次开始执行的时间
+	LastDuration    float64            `json:"last_duration_seconds,omitempty"`
+	LastError       string             `json:"last_error,omitempty"`
+	Runs            int64              `json:"runs"`
+	Failures        int64              `json:"failures"`
+	LagSeconds      float64            `json:"lag_seconds"`      // 超过预期执行时间仍未开始下一次执行的时长
+	Gauges          map[string]float64 `json:"gauges,omitempty"` // 队列长度、并发数、积压等
+}
+
+// WorkersSnapshot 全部后台子系统的运行状态
+type WorkersSnapshot struct {
+	CollectedAt time.Time      `json:"collected_at"`
+	Workers     []WorkerStatus `json:"workers"`
+	Lagging     []string       `json:"lagging"` // 落后超过一个执行间隔的周期任务
+}
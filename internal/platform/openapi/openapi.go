@@ -0,0 +1,392 @@
+// Package openapi 生成管理平台REST API的 OpenAPI 3.1 文档
+//
+// 文档由gin的路由表和处理器上的接口描述（Spec）合成：路径、方法和路径参数取自已注册的路由，
+// 请求体、响应体和查询参数的Schema由描述中的Go类型原型反射生成，规则与 protocol 包的协议文档相同。
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"logstash-platform/internal/platform/api/middleware"
+	"logstash-platform/internal/platform/protocol"
+)
+
+// Version 文档遵循的OpenAPI版本，3.1的Schema与JSON Schema 2020-12兼容
+const Version = "3.1.0"
+
+// 请求体和响应体的默认媒体类型
+const contentTypeJSON = "application/json"
+
+// Spec 处理器的接口描述，按处理器名称（如 ConfigHandler.ListConfigs）登记
+type Spec struct {
+	Summary      string
+	Description  string
+	Query        interface{} // 查询参数结构体原型，按form标签生成参数
+	Params       []Param     // 处理器直接读取的其他查询参数
+	Request      interface{} // 请求体原型，nil表示无请求体
+	RequestType  string      // 请求体媒体类型，默认 application/json
+	Response     interface{} // 成功响应体原型，nil表示不描述响应内容
+	ResponseType string      // 响应体媒体类型，默认 application/json
+	Status       int         // 成功状态码，默认200
+	Public       bool        // 无需认证令牌
+}
+
+// Param 查询参数
+type Param struct {
+	Name        string
+	Description string
+	Required    bool
+}
+
+// listResponse 列表响应的原型，见 List 和 Page
+type listResponse struct {
+	item  interface{}
+	paged bool
+}
+
+// List 描述 {"items": [...], "total": n} 形式的列表响应，item为列表元素的原型
+func List(item interface{}) interface{} {
+	return listResponse{item: item}
+}
+
+// Page 描述在 List 基础上带 page、size 的分页列表响应
+func Page(item interface{}) interface{} {
+	return listResponse{item: item, paged: true}
+}
+
+// Info 文档的基本信息
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Document OpenAPI文档
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Tags       []Tag                 `json:"tags,omitempty"`
+	Paths      map[string]PathItem   `json:"paths"`
+	Components Components            `json:"components"`
+	Security   []map[string][]string `json:"security,omitempty"`
+}
+
+// Tag 接口分组，按 /api/v1 之后的第一段路径划分
+type Tag struct {
+	Name string `json:"name"`
+}
+
+// PathItem 一个路径下按小写方法名索引的操作
+type PathItem map[string]*Operation
+
+// Operation 一个接口
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+// Parameter 路径或查询参数
+type Parameter struct {
+	Name        string           `json:"name"`
+	In          string           `json:"in"`
+	Description string           `json:"description,omitempty"`
+	Required    bool             `json:"required,omitempty"`
+	Schema      *protocol.Schema `json:"schema"`
+}
+
+// RequestBody 请求体
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response 响应
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType 某种媒体类型的内容
+type MediaType struct {
+	Schema *protocol.Schema `json:"schema"`
+}
+
+// Components 可复用的Schema和认证方式
+type Components struct {
+	Schemas         map[string]*protocol.Schema `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme   `json:"securitySchemes"`
+}
+
+// SecurityScheme 认证方式
+type SecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme"`
+}
+
+// securityBearer 令牌认证方式的名称
+const securityBearer = "bearerAuth"
+
+// apiPrefix 只为该前缀下的路由生成文档
+const apiPrefix = "/api/"
+
+// Generate 按路由表和处理器的接口描述生成文档，没有描述的路由只包含路径和路径参数
+func Generate(info Info, routes gin.RoutesInfo, specs map[string]Spec) *Document {
+	g := protocol.NewSchemaGenerator("#/components/schemas/")
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]PathItem),
+		Components: Components{
+			SecuritySchemes: map[string]SecurityScheme{
+				securityBearer: {Type: "http", Scheme: "bearer"},
+			},
+		},
+		Security: []map[string][]string{{securityBearer: {}}},
+	}
+	errorSchema := g.SchemaOf(middleware.ErrorResponse{})
+
+	// 同一处理器挂在多个路由上时 operationId 追加序号保持唯一
+	sorted := append(gin.RoutesInfo(nil), routes...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Path != sorted[j].Path {
+			return sorted[i].Path < sorted[j].Path
+		}
+		return sorted[i].Method < sorted[j].Method
+	})
+	operationIDs := make(map[string]int)
+	tags := make(map[string]bool)
+
+	for _, route := range sorted {
+		if !strings.HasPrefix(route.Path, apiPrefix) {
+			continue
+		}
+		name := HandlerName(route.Handler)
+		spec := specs[name]
+		path, pathParams := convertPath(route.Path)
+
+		op := &Operation{
+			OperationID: operationID(name, operationIDs),
+			Summary:     spec.Summary,
+			Description: spec.Description,
+			Parameters:  pathParams,
+			Responses:   make(map[string]*Response),
+		}
+		if tag := routeTag(route.Path); tag != "" {
+			op.Tags = []string{tag}
+			tags[tag] = true
+		}
+		if spec.Public {
+			op.Security = []map[string][]string{{}}
+		}
+		op.Parameters = append(op.Parameters, queryParams(g, spec.Query)...)
+		for _, p := range spec.Params {
+			op.Parameters = append(op.Parameters, Parameter{
+				Name: p.Name, In: "query", Description: p.Description, Required: p.Required,
+				Schema: &protocol.Schema{Types: []string{"string"}},
+			})
+		}
+
+		if spec.Request != nil {
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content:  map[string]MediaType{orDefault(spec.RequestType, contentTypeJSON): {Schema: g.SchemaOf(spec.Request)}},
+			}
+		}
+
+		status := spec.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		success := &Response{Description: http.StatusText(status)}
+		if spec.Response != nil && status != http.StatusNoContent {
+			success.Content = map[string]MediaType{orDefault(spec.ResponseType, contentTypeJSON): {Schema: responseSchema(g, spec.Response)}}
+		}
+		op.Responses[strconv.Itoa(status)] = success
+		op.Responses["default"] = &Response{
+			Description: "错误",
+			Content:     map[string]MediaType{middleware.ProblemContentType: {Schema: errorSchema}},
+		}
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(PathItem)
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = op
+	}
+
+	for tag := range tags {
+		doc.Tags = append(doc.Tags, Tag{Name: tag})
+	}
+	sort.Slice(doc.Tags, func(i, j int) bool { return doc.Tags[i].Name < doc.Tags[j].Name })
+	doc.Components.Schemas = g.Defs()
+	return doc
+}
+
+// Coverage 检查描述与路由是否一致，返回没有描述的路由和没有对应路由的描述
+func Coverage(routes gin.RoutesInfo, specs map[string]Spec) (undocumented, unused []string) {
+	used := make(map[string]bool)
+	for _, route := range routes {
+		if !strings.HasPrefix(route.Path, apiPrefix) {
+			continue
+		}
+		name := HandlerName(route.Handler)
+		if _, ok := specs[name]; ok {
+			used[name] = true
+			continue
+		}
+		undocumented = append(undocumented, fmt.Sprintf("%s %s (%s)", route.Method, route.Path, name))
+	}
+	for name := range specs {
+		if !used[name] {
+			unused = append(unused, name)
+		}
+	}
+	sort.Strings(undocumented)
+	sort.Strings(unused)
+	return undocumented, unused
+}
+
+// HandlerName 把gin记录的处理器函数名转换为描述的登记名：
+// 方法值 pkg.(*ConfigHandler).ListConfigs-fm 为 ConfigHandler.ListConfigs，
+// 返回闭包的构造函数 pkg.WorkersStatus.func1 为 WorkersStatus
+func HandlerName(handler string) string {
+	name := handler[strings.LastIndex(handler, "/")+1:]
+	if i := strings.Index(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.TrimSuffix(name, "-fm")
+	if i := strings.Index(name, ".func"); i >= 0 {
+		name = name[:i]
+	}
+	name = strings.NewReplacer("(*", "", ")", "").Replace(name)
+	return name
+}
+
+// convertPath 把gin的 :id、*path 参数改写为 {id}，并生成路径参数
+func convertPath(path string) (string, []Parameter) {
+	segments := strings.Split(path, "/")
+	var params []Parameter
+	for i, segment := range segments {
+		if segment == "" || (segment[0] != ':' && segment[0] != '*') {
+			continue
+		}
+		name := segment[1:]
+		segments[i] = "{" + name + "}"
+		params = append(params, Parameter{
+			Name: name, In: "path", Required: true,
+			Schema: &protocol.Schema{Types: []string{"string"}},
+		})
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// routeTag /api/v1/configs/{id} 的分组为 configs
+func routeTag(path string) string {
+	parts := strings.Split(strings.TrimPrefix(path, apiPrefix), "/")
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[1]
+}
+
+// operationID 处理器方法名的小驼峰形式，重复时追加序号
+func operationID(name string, seen map[string]int) string {
+	method := name[strings.LastIndex(name, ".")+1:]
+	id := strings.ToLower(method[:1]) + method[1:]
+	seen[id]++
+	if n := seen[id]; n > 1 {
+		id += strconv.Itoa(n)
+	}
+	return id
+}
+
+// responseSchema 生成响应体Schema，列表响应展开为items和total
+func responseSchema(g *protocol.SchemaGenerator, v interface{}) *protocol.Schema {
+	list, ok := v.(listResponse)
+	if !ok {
+		return g.SchemaOf(v)
+	}
+	s := &protocol.Schema{
+		Types: []string{"object"},
+		Properties: map[string]*protocol.Schema{
+			"items": {Types: []string{"array"}, Items: g.SchemaOf(list.item)},
+			"total": {Types: []string{"integer"}},
+		},
+		Required: []string{"items", "total"},
+	}
+	if list.paged {
+		s.Properties["page"] = &protocol.Schema{Types: []string{"integer"}}
+		s.Properties["size"] = &protocol.Schema{Types: []string{"integer"}}
+	}
+	return s
+}
+
+// queryParams 按form标签展开查询参数结构体，binding:"required"的字段为必填
+func queryParams(g *protocol.SchemaGenerator, v interface{}) []Parameter {
+	if v == nil {
+		return nil
+	}
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	var params []Parameter
+	addQueryFields(g, t, &params)
+	return params
+}
+
+func addQueryFields(g *protocol.SchemaGenerator, t reflect.Type, params *[]Parameter) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("form"), ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			addQueryFields(g, f.Type, params)
+			continue
+		}
+		if name == "" || name == "-" || !f.IsExported() {
+			continue
+		}
+
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		*params = append(*params, Parameter{
+			Name:     name,
+			In:       "query",
+			Required: strings.Contains(","+f.Tag.Get("binding")+",", ",required,"),
+			Schema:   nonNull(g.TypeSchema(ft)),
+		})
+	}
+}
+
+// nonNull 查询参数不会为null，去掉切片类型上的null
+func nonNull(s *protocol.Schema) *protocol.Schema {
+	types := s.Types[:0:0]
+	for _, t := range s.Types {
+		if t != "null" {
+			types = append(types, t)
+		}
+	}
+	s.Types = types
+	return s
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
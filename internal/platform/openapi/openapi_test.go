@@ -0,0 +1,79 @@
+package openapi
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlerName(t *testing.T) {
+	assert.Equal(t, "ConfigHandler.ListConfigs",
+		HandlerName("logstash-platform/internal/platform/api/handlers.(*ConfigHandler).ListConfigs-fm"))
+	assert.Equal(t, "WorkersStatus", HandlerName("logstash-platform/internal/platform/api/handlers.WorkersStatus.func1"))
+	assert.Equal(t, "HealthCheck", HandlerName("logstash-platform/internal/platform/api/handlers.HealthCheck"))
+}
+
+type listQuery struct {
+	Name    string   `form:"name" binding:"required"`
+	Tags    []string `form:"tags"`
+	Enabled *bool    `form:"enabled"`
+	Hidden  string   `form:"-"`
+}
+
+type pageQuery struct {
+	listQuery
+	Page int `form:"page,default=1"`
+}
+
+type item struct {
+	ID string `json:"id"`
+}
+
+func TestGenerate(t *testing.T) {
+	routes := gin.RoutesInfo{
+		{Method: http.MethodGet, Path: "/api/v1/items", Handler: "pkg.(*ItemHandler).ListItems-fm"},
+		{Method: http.MethodGet, Path: "/api/v1/items/:id", Handler: "pkg.(*ItemHandler).GetItem-fm"},
+		{Method: http.MethodDelete, Path: "/api/v1/items/:id", Handler: "pkg.(*ItemHandler).DeleteItem-fm"},
+		{Method: http.MethodGet, Path: "/health", Handler: "pkg.HealthCheck"},
+	}
+	specs := map[string]Spec{
+		"ItemHandler.ListItems":  {Summary: "列表", Query: pageQuery{}, Response: Page(item{})},
+		"ItemHandler.GetItem":    {Summary: "详情", Response: item{}},
+		"ItemHandler.DeleteItem": {Summary: "删除", Status: http.StatusNoContent, Public: true},
+	}
+	doc := Generate(Info{Title: "test", Version: "v1"}, routes, specs)
+
+	assert.NotContains(t, doc.Paths, "/health", "只为 /api/ 下的路由生成文档")
+	require.Contains(t, doc.Paths, "/api/v1/items/{id}")
+	assert.Equal(t, []Tag{{Name: "items"}}, doc.Tags)
+
+	list := doc.Paths["/api/v1/items"]["get"]
+	require.NotNil(t, list)
+	require.Len(t, list.Parameters, 4, "嵌入结构体的字段展开，form:\"-\"的字段跳过")
+	assert.Equal(t, "name", list.Parameters[0].Name)
+	assert.True(t, list.Parameters[0].Required)
+	assert.Equal(t, []string{"array"}, list.Parameters[1].Schema.Types)
+	assert.Equal(t, []string{"boolean"}, list.Parameters[2].Schema.Types)
+	assert.Equal(t, "page", list.Parameters[3].Name)
+	page := list.Responses["200"].Content[contentTypeJSON].Schema
+	assert.Equal(t, "#/components/schemas/item", page.Properties["items"].Items.Ref)
+	assert.Contains(t, page.Properties, "size")
+
+	get := doc.Paths["/api/v1/items/{id}"]["get"]
+	require.Len(t, get.Parameters, 1)
+	assert.Equal(t, Parameter{Name: "id", In: "path", Required: true, Schema: get.Parameters[0].Schema}, get.Parameters[0])
+	assert.Nil(t, get.Security, "默认使用文档级的令牌认证")
+
+	del := doc.Paths["/api/v1/items/{id}"]["delete"]
+	assert.Nil(t, del.Responses["204"].Content)
+	assert.Equal(t, []map[string][]string{{}}, del.Security)
+	assert.Contains(t, doc.Components.Schemas, "ErrorResponse")
+	assert.Contains(t, del.Responses["default"].Content, "application/problem+json")
+
+	undocumented, unused := Coverage(routes, map[string]Spec{"ItemHandler.ListItems": {}, "Stale": {}})
+	assert.Equal(t, []string{"DELETE /api/v1/items/:id (ItemHandler.DeleteItem)", "GET /api/v1/items/:id (ItemHandler.GetItem)"}, undocumented)
+	assert.Equal(t, []string{"Stale"}, unused)
+}
@@ -0,0 +1,343 @@
+package protocol
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/wschunk"
+)
+
+// Check 一致性检查项结果
+type Check struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Report 一致性测试报告
+type Report struct {
+	AgentID string  `json:"agent_id"`
+	Version string  `json:"protocol_version"`
+	Checks  []Check `json:"checks"`
+}
+
+// Passed 全部检查项均通过
+func (r *Report) Passed() bool {
+	for _, c := range r.Checks {
+		if !c.Passed {
+			return false
+		}
+	}
+	return len(r.Checks) > 0
+}
+
+// Harness 协议一致性测试工具
+//
+// 扮演管理平台：被测Agent把服务器地址指向Harness后，Harness按协议文档校验Agent发出的每个
+// HTTP请求和WebSocket消息，并在Agent连接后依次下发 config_deploy 和 status_request，
+// 检查Agent是否拉取配置并回复 config_applied、status_report。
+type Harness struct {
+	validator *Validator
+	config    *models.Config
+	timeout   time.Duration
+	logger    *logrus.Logger
+	upgrader  websocket.Upgrader
+	assembler *wschunk.Assembler
+
+	mu        sync.Mutex
+	agentID   string
+	conn      *websocket.Conn
+	violation []Check
+	fetched   bool
+	connected chan struct{}
+	applied   chan models.ConfigAppliedReport
+	reported  chan struct{}
+}
+
+// NewHarness 创建一致性测试工具，config为下发给Agent的配置，timeout为每一步等待Agent响应的时间
+func NewHarness(config *models.Config, timeout time.Duration, logger *logrus.Logger) *Harness {
+	return &Harness{
+		validator: NewValidator(nil),
+		config:    config,
+		timeout:   timeout,
+		logger:    logger,
+		assembler: wschunk.NewAssembler(timeout, 0),
+		connected: make(chan struct{}),
+		applied:   make(chan models.ConfigAppliedReport, 1),
+		reported:  make(chan struct{}, 1),
+	}
+}
+
+// ServeHTTP 处理被测Agent的HTTP请求和WebSocket连接
+func (h *Harness) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == WebSocketPath {
+		h.serveWebSocket(w, r)
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	endpoint := r.Method + " " + r.URL.Path
+	if err := h.validator.ValidateRequest(r.Method, r.URL.Path, body); err != nil {
+		h.violate("http_request", err.Error())
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.logger.WithField("endpoint", endpoint).Debug("收到Agent请求")
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v1/agents/register":
+		writeJSON(w, http.StatusOK, json.RawMessage(body))
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/heartbeat"):
+		writeJSON(w, http.StatusOK, models.HeartbeatResponse{ServerTime: time.Now()})
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/api/v1/configs/"):
+		if strings.TrimPrefix(r.URL.Path, "/api/v1/configs/") != h.config.ID {
+			http.Error(w, "配置不存在", http.StatusNotFound)
+			return
+		}
+		h.mu.Lock()
+		h.fetched = true
+		h.mu.Unlock()
+		writeJSON(w, http.StatusOK, h.config)
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/configs/applied"):
+		var report models.ConfigAppliedReport
+		json.Unmarshal(body, &report)
+		h.notifyApplied(report)
+		writeJSON(w, http.StatusOK, map[string]string{"message": "ok"})
+	default:
+		writeJSON(w, http.StatusOK, map[string]string{"message": "ok"})
+	}
+}
+
+// Run 等待Agent连接并执行检查，ctx取消或任一步骤超时后返回报告
+func (h *Harness) Run(ctx context.Context) *Report {
+	report := &Report{Version: Version}
+
+	select {
+	case <-h.connected:
+		h.mu.Lock()
+		report.AgentID = h.agentID
+		h.mu.Unlock()
+		report.Checks = append(report.Checks, Check{Name: "websocket_connect", Passed: report.AgentID != ""})
+	case <-ctx.Done():
+		report.Checks = append(report.Checks, Check{Name: "websocket_connect", Detail: "等待Agent连接超时"})
+		return h.finish(report)
+	}
+
+	report.Checks = append(report.Checks, h.checkDeploy(ctx))
+	report.Checks = append(report.Checks, h.checkStatus(ctx))
+	return h.finish(report)
+}
+
+// checkDeploy 下发config_deploy，Agent需拉取配置并回传部署ID
+func (h *Harness) checkDeploy(ctx context.Context) Check {
+	check := Check{Name: "config_deploy"}
+	deploymentID := "conformance-" + uuid.New().String()
+	if err := h.send(models.MsgTypeConfigDeploy, models.ConfigDeployPayload{
+		ConfigID:     h.config.ID,
+		Version:      h.config.Version,
+		DeploymentID: deploymentID,
+	}); err != nil {
+		check.Detail = err.Error()
+		return check
+	}
+
+	timer := time.NewTimer(h.timeout)
+	defer timer.Stop()
+	select {
+	case report := <-h.applied:
+		h.mu.Lock()
+		fetched := h.fetched
+		h.mu.Unlock()
+		switch {
+		case !fetched:
+			check.Detail = "Agent未通过HTTP拉取配置内容"
+		case report.ConfigID != h.config.ID || report.Version != h.config.Version:
+			check.Detail = fmt.Sprintf("上报的配置 %s v%d 与下发的 %s v%d 不一致", report.ConfigID, report.Version, h.config.ID, h.config.Version)
+		case report.DeploymentID != deploymentID:
+			check.Detail = fmt.Sprintf("deployment_id 应为 %s，实际为 %q", deploymentID, report.DeploymentID)
+		case report.Failed():
+			check.Detail = "Agent上报部署失败: " + report.Error
+		default:
+			check.Passed = true
+		}
+	case <-timer.C:
+		check.Detail = "未收到config_applied"
+	case <-ctx.Done():
+		check.Detail = ctx.Err().Error()
+	}
+	return check
+}
+
+// checkStatus 下发status_request，Agent需回复status_report
+func (h *Harness) checkStatus(ctx context.Context) Check {
+	check := Check{Name: "status_request"}
+	if err := h.send(models.MsgTypeStatusRequest, nil); err != nil {
+		check.Detail = err.Error()
+		return check
+	}
+
+	timer := time.NewTimer(h.timeout)
+	defer timer.Stop()
+	select {
+	case <-h.reported:
+		check.Passed = true
+	case <-timer.C:
+		check.Detail = "未收到status_report"
+	case <-ctx.Done():
+		check.Detail = ctx.Err().Error()
+	}
+	return check
+}
+
+// finish 汇总过程中发现的协议违规并关闭连接
+func (h *Harness) finish(report *Report) *Report {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.violation) == 0 {
+		report.Checks = append(report.Checks, Check{Name: "schema", Passed: true})
+	}
+	report.Checks = append(report.Checks, h.violation...)
+	if h.conn != nil {
+		h.conn.Close()
+	}
+	return report
+}
+
+func (h *Harness) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	agentID := r.URL.Query().Get("agent_id")
+	if agentID == "" {
+		h.violate("websocket_connect", "连接缺少查询参数 agent_id")
+	}
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		h.violate("websocket_connect", err.Error())
+		return
+	}
+
+	h.mu.Lock()
+	if h.conn != nil {
+		h.mu.Unlock()
+		conn.Close()
+		return
+	}
+	h.conn = conn
+	h.agentID = agentID
+	h.mu.Unlock()
+	close(h.connected)
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		h.handleFrame(data)
+	}
+}
+
+// handleFrame 校验Agent发来的消息，分片消息重组后按原始类型校验
+func (h *Harness) handleFrame(data []byte) {
+	msgType, err := h.validator.ValidateMessage(ToPlatform, data)
+	if err != nil {
+		h.violate("websocket_message", err.Error())
+		return
+	}
+
+	var msg models.WebSocketMessage
+	json.Unmarshal(data, &msg)
+	payload := []byte(msg.Payload)
+
+	if msgType == wschunk.MsgType {
+		var env wschunk.Envelope
+		json.Unmarshal(payload, &env)
+		var complete bool
+		msgType, payload, complete, err = h.assembler.Add(&env)
+		if err != nil {
+			h.violate("websocket_chunk", err.Error())
+			return
+		}
+		if !complete {
+			return
+		}
+		if err := h.validator.ValidatePayload(ToPlatform, msgType, payload); err != nil {
+			h.violate("websocket_message", err.Error())
+			return
+		}
+	}
+
+	switch msgType {
+	case models.MsgTypeConfigApplied:
+		var applied models.ConfigAppliedMessage
+		json.Unmarshal(payload, &applied)
+		h.notifyApplied(models.ConfigAppliedReport{
+			ConfigID:     applied.ConfigID,
+			Version:      applied.Version,
+			AppliedAt:    applied.AppliedAt,
+			DeploymentID: applied.DeploymentID,
+			Hash:         applied.Hash,
+		})
+	case models.MsgTypeStatusReport:
+		select {
+		case h.reported <- struct{}{}:
+		default:
+		}
+	case models.MsgTypeError:
+		var msg models.ErrorMessage
+		json.Unmarshal(payload, &msg)
+		h.logger.WithField("msg_type", msg.MsgType).Warnf("Agent处理消息失败: %s", msg.Error)
+	}
+}
+
+func (h *Harness) send(msgType string, payload interface{}) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	msg := models.WebSocketMessage{Type: msgType, Timestamp: time.Now(), Payload: raw}
+	if msgType == models.MsgTypeConfigDeploy {
+		msg.ID = uuid.New().String()
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.conn == nil {
+		return fmt.Errorf("Agent未建立WebSocket连接")
+	}
+	return h.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+func (h *Harness) notifyApplied(report models.ConfigAppliedReport) {
+	select {
+	case h.applied <- report:
+	default:
+	}
+}
+
+func (h *Harness) violate(name, detail string) {
+	h.logger.WithField("check", name).Warn(detail)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.violation = append(h.violation, Check{Name: name, Detail: detail})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
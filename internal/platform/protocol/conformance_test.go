@@ -0,0 +1,96 @@
+package protocol
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"logstash-platform/internal/agent/client"
+	"logstash-platform/internal/agent/config"
+	"logstash-platform/internal/agent/core"
+	"logstash-platform/internal/platform/models"
+)
+
+// scriptedAgent 用Agent自带的客户端实现最小的消息处理，作为一致性测试的被测对象
+type scriptedAgent struct {
+	client  *client.Client
+	agentID string
+	skipID  bool // 模拟不回传deployment_id的实现
+}
+
+func (a *scriptedAgent) HandleMessage(msgType string, payload []byte) error {
+	ctx := context.Background()
+	switch msgType {
+	case core.MsgTypeConfigDeploy:
+		var req models.ConfigDeployPayload
+		if err := json.Unmarshal(payload, &req); err != nil {
+			return err
+		}
+		cfg, err := a.client.GetConfig(ctx, req.ConfigID)
+		if err != nil {
+			return err
+		}
+		applied := &models.AppliedConfig{ConfigID: cfg.ID, Version: cfg.Version, AppliedAt: time.Now(), DeploymentID: req.DeploymentID}
+		if a.skipID {
+			applied.DeploymentID = ""
+		}
+		return a.client.ReportConfigApplied(ctx, a.agentID, applied)
+	case core.MsgTypeStatusRequest:
+		return a.client.ReportStatus(ctx, &models.Agent{AgentID: a.agentID, Status: "online", LastHeartbeat: time.Now()})
+	}
+	return nil
+}
+
+func (a *scriptedAgent) OnConnect() error       { return nil }
+func (a *scriptedAgent) OnDisconnect(err error) {}
+
+func runConformance(t *testing.T, skipID bool) *Report {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	harness := NewHarness(&models.Config{ID: "conformance", Name: "conformance", Content: "input { stdin {} }", Version: 3}, 2*time.Second, logger)
+	server := httptest.NewServer(harness)
+	defer server.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.ServerURL = server.URL
+	cfg.AgentID = "agent-conformance"
+	c, err := client.NewClient(cfg, logger)
+	require.NoError(t, err)
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	require.NoError(t, c.Register(ctx, &models.Agent{AgentID: cfg.AgentID, Hostname: "host"}))
+	require.NoError(t, c.SendHeartbeat(ctx, cfg.AgentID))
+	// ConnectWebSocket 阻塞到连接关闭
+	go c.ConnectWebSocket(ctx, cfg.AgentID, &scriptedAgent{client: c, agentID: cfg.AgentID, skipID: skipID})
+
+	return harness.Run(ctx)
+}
+
+func TestHarness_ConformingAgent(t *testing.T) {
+	report := runConformance(t, false)
+
+	assert.True(t, report.Passed(), "%+v", report.Checks)
+	assert.Equal(t, "agent-conformance", report.AgentID)
+	assert.Equal(t, Version, report.Version)
+}
+
+func TestHarness_ReportsMissingDeploymentID(t *testing.T) {
+	report := runConformance(t, true)
+
+	assert.False(t, report.Passed())
+	for _, c := range report.Checks {
+		if c.Name == "config_deploy" {
+			assert.False(t, c.Passed)
+			assert.Contains(t, c.Detail, "deployment_id")
+		}
+	}
+}
@@ -0,0 +1,287 @@
+// Package protocol 描述Agent与管理平台之间的通信协议
+//
+// 协议包括Agent调用的HTTP接口和双向的WebSocket消息，本包从平台使用的Go类型生成带版本的
+// JSON Schema 文档（见 docs/protocol），并提供一致性测试工具，供第三方Agent实现对照验证。
+package protocol
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/wschunk"
+)
+
+// Version 协议版本，消息或接口出现不兼容变更时递增
+const Version = "1"
+
+// WebSocketPath Agent建立WebSocket连接的路径，连接时通过查询参数 agent_id 标识自身
+const WebSocketPath = "/ws"
+
+// Direction 消息方向
+type Direction string
+
+const (
+	ToAgent    Direction = "platform_to_agent" // 平台推送给Agent
+	ToPlatform Direction = "agent_to_platform" // Agent上报给平台
+	Both       Direction = "both"              // 双向
+)
+
+// 消息可使用的传输方式
+const (
+	TransportWebSocket = "websocket" // WebSocket消息
+	TransportHeartbeat = "heartbeat" // 随HTTP心跳响应捎带，见 HeartbeatResponse.commands
+	TransportLongPoll  = "long_poll" // 无法建立WebSocket连接时经 /api/v1/agents/{id}/messages 长轮询收发
+)
+
+// Message 协议中的一种消息
+type Message struct {
+	Type        string
+	Direction   Direction
+	Transports  []string
+	Description string
+	Payload     interface{} // payload对应的Go类型原型，nil表示内容不限
+}
+
+// Endpoint Agent调用的HTTP接口
+type Endpoint struct {
+	Method      string
+	Path        string // 路径参数写作 {id}
+	Description string
+	Request     interface{} // 请求体原型，nil表示无请求体
+	Response    interface{} // 响应体原型，nil表示不关心响应内容
+	Require     []string    // 除binding标签外请求体额外必填的字段
+}
+
+// Messages 返回协议中的全部消息
+func Messages() []Message {
+	ws := []string{TransportWebSocket, TransportLongPoll}
+	both := []string{TransportWebSocket, TransportLongPoll, TransportHeartbeat}
+	return []Message{
+		{Type: models.MsgTypeConfigDeploy, Direction: ToAgent, Transports: ws, Payload: models.ConfigDeployPayload{},
+			Description: "部署配置，Agent通过 GET /api/v1/configs/{id} 拉取内容，应用后回复 config_applied；消息携带id，Agent处理后以 ack 确认，重复收到同一id时不再处理"},
+		{Type: models.MsgTypeConfigDelete, Direction: ToAgent, Transports: ws, Payload: models.ConfigDeletePayload{},
+			Description: "删除配置，删除后以 status=removed 上报 POST /api/v1/agents/{id}/configs/applied 确认；消息携带id，处理方式同 config_deploy"},
+		{Type: models.MsgTypeReloadRequest, Direction: ToAgent, Transports: ws,
+			Description: "请求重载Logstash，payload可为空"},
+		{Type: models.MsgTypeStatusRequest, Direction: ToAgent, Transports: ws,
+			Description: "请求Agent回复 status_report，payload可为空"},
+		{Type: models.MsgTypeMetricsRequest, Direction: ToAgent, Transports: ws,
+			Description: "请求Agent回复 metrics_report，payload可为空"},
+		{Type: models.MsgTypeSettingsUpdate, Direction: ToAgent, Transports: ws, Payload: models.AgentSettings{},
+			Description: "下发生效的运行参数"},
+		{Type: models.MsgTypeSyncHint, Direction: ToAgent, Transports: both, Payload: models.SyncHintPayload{},
+			Description: "列出Agent应持有的配置版本，Agent拉取缺失或过期的配置"},
+		{Type: models.MsgTypeLogLevel, Direction: ToAgent, Transports: both, Payload: models.LogLevelPayload{},
+			Description: "调整Agent日志级别"},
+		{Type: models.MsgTypeMaintenance, Direction: ToAgent, Transports: both, Payload: models.MaintenancePayload{},
+			Description: "开关维护模式"},
+		{Type: models.MsgTypeInvalidateValidationCache, Direction: ToAgent, Transports: both,
+			Description: "清空配置验证缓存，payload可为空"},
+		{Type: models.MsgTypeTelemetryIntervals, Direction: ToAgent, Transports: both, Payload: models.TelemetryIntervals{},
+			Description: "平台按在线Agent数和负载协商的心跳/指标间隔下限（秒），Agent在本地配置的范围内取较大值，0表示不限制"},
+		{Type: models.MsgTypeLogTailStart, Direction: ToAgent, Transports: ws, Payload: models.LogTailStartPayload{},
+			Description: "开始跟踪Logstash日志，Agent按 min_level 和 max_rate 过滤后以 log_lines 分批推送，只经WebSocket下发"},
+		{Type: models.MsgTypeLogTailStop, Direction: ToAgent, Transports: ws, Payload: models.LogTailStopPayload{},
+			Description: "停止跟踪日志，连接断开时Agent同样停止全部跟踪"},
+		{Type: models.MsgTypeDiagnosticRequest, Direction: ToAgent, Transports: ws, Payload: models.DiagnosticPayload{},
+			Description: "执行白名单内的诊断命令（logstash_version、data_dir_usage、list_configs、tail_log），Agent以 diagnostic_result 回复，只经WebSocket下发"},
+		{Type: models.MsgTypeDLQRequest, Direction: ToAgent, Transports: ws, Payload: models.DLQPayload{},
+			Description: "按段文件先后顺序读取 <path.data>/dead_letter_queue/<pipeline> 中第page页的死信事件，Agent以 dlq_result 回复，只经WebSocket下发"},
+		{Type: models.MsgTypePluginInstall, Direction: ToAgent, Transports: ws, Payload: models.PluginInstallPayload{},
+			Description: "以 logstash-plugin 安装或更新插件（bundle_url 为离线包），Agent以 plugin_install_progress 报告阶段、以 plugin_install_result 报告结果，只经WebSocket下发"},
+		{Type: models.MsgTypeHeartbeat, Direction: ToPlatform, Transports: ws, Payload: models.HeartbeatMessage{},
+			Description: "心跳，WebSocket不可用时改用 POST /api/v1/agents/{id}/heartbeat"},
+		{Type: models.MsgTypeStatusReport, Direction: ToPlatform, Transports: ws, Payload: models.Agent{},
+			Description: "上报Agent状态"},
+		{Type: models.MsgTypeMetricsReport, Direction: ToPlatform, Transports: ws, Payload: models.MetricsReportMessage{},
+			Description: "上报运行指标"},
+		{Type: models.MsgTypeConfigApplied, Direction: ToPlatform, Transports: ws, Payload: models.ConfigAppliedMessage{},
+			Description: "配置已应用，deployment_id 需原样回传 config_deploy 中的值"},
+		{Type: models.MsgTypeLogLines, Direction: ToPlatform, Transports: ws, Payload: models.LogLinesMessage{},
+			Description: "推送跟踪到的日志行，closed为true表示Agent已结束该流"},
+		{Type: models.MsgTypeDiagnosticResult, Direction: ToPlatform, Transports: ws, Payload: models.DiagnosticResult{},
+			Description: "诊断结果，request_id 需原样回传 diagnostic_request 中的值，命令执行失败时原因在error中"},
+		{Type: models.MsgTypeDLQResult, Direction: ToPlatform, Transports: ws, Payload: models.DLQResult{},
+			Description: "死信事件，request_id 需原样回传 dlq_request 中的值，事件超过16KB时截断，读取失败时原因在error中"},
+		{Type: models.MsgTypePluginInstallProgress, Direction: ToPlatform, Transports: ws, Payload: models.PluginInstallProgress{},
+			Description: "插件安装进入新阶段（downloading、installing、verifying、restarting），install_id 需原样回传 plugin_install 中的值"},
+		{Type: models.MsgTypePluginInstallResult, Direction: ToPlatform, Transports: ws, Payload: models.PluginInstallResult{},
+			Description: "插件安装结果，输出超过64KB时只保留末尾；成功时附带重新采集的插件清单，平台据此更新Agent的插件清单"},
+		{Type: models.MsgTypeAck, Direction: ToPlatform, Transports: ws, Payload: models.MessageAck{},
+			Description: "确认已处理带id的平台消息（含处理失败的），平台重发Agent重新连接前未确认的 config_deploy、config_delete"},
+		{Type: models.MsgTypeError, Direction: ToPlatform, Transports: ws, Payload: models.ErrorMessage{},
+			Description: "处理平台消息失败"},
+		{Type: wschunk.MsgType, Direction: Both, Transports: ws, Payload: wschunk.Envelope{},
+			Description: "超过帧大小上限的消息拆分为多个分片发送，接收端按 message_id 重组并校验SHA-256后按原始类型处理"},
+	}
+}
+
+// Endpoints 返回Agent调用的HTTP接口，请求均需携带 Authorization: Bearer <token>
+func Endpoints() []Endpoint {
+	return []Endpoint{
+		{Method: http.MethodPost, Path: "/api/v1/agents/register", Request: models.Agent{}, Response: models.RegisterResponse{},
+			Require: []string{"agent_id"}, Description: "Agent启动时注册，平台启用间隔协商时在 telemetry 中返回当前的间隔下限"},
+		{Method: http.MethodPost, Path: "/api/v1/agents/{id}/heartbeat", Request: models.HeartbeatRequest{}, Response: models.HeartbeatResponse{},
+			Description: "HTTP心跳，响应中捎带待执行的命令，Agent在下一次心跳的 acked_commands 中确认"},
+		{Method: http.MethodPost, Path: "/api/v1/agents/{id}/metrics", Request: models.MetricsReportRequest{},
+			Description: "HTTP上报指标，平台保留最近一次上报用于部署前资源估算的余量检查"},
+		{Method: http.MethodGet, Path: "/api/v1/configs/{id}", Response: models.Config{},
+			Description: "拉取配置内容，查询参数 environment 指定所在环境时返回替换了下游集群引用的内容"},
+		{Method: http.MethodGet, Path: "/api/v1/agents/{id}/desired-configs", Response: models.AgentDesiredConfigs{},
+			Description: "获取应运行的配置及版本，Agent启动或重新连接后与本地文件核对，下载缺失或版本不符的配置、删除多余的配置后只重载一次"},
+		{Method: http.MethodPost, Path: "/api/v1/agents/{id}/configs/applied", Request: models.ConfigAppliedReport{},
+			Description: "上报配置应用结果，status 为 success、failed、apply_failed（Agent验证失败并已恢复之前的配置）或 reload_queued"},
+		{Method: http.MethodPost, Path: "/api/v1/agents/{id}/errors", Request: models.AgentErrorReport{},
+			Description: "上报运行错误，平台按指纹归并为事件"},
+		{Method: http.MethodPost, Path: "/api/v1/agents/{id}/events", Request: models.AgentEventReport{},
+			Description: "上报重载失败、Logstash崩溃或重启事件，写入Agent事件时间线"},
+		{Method: http.MethodPost, Path: "/api/v1/agents/{id}/plugins", Request: models.PluginInventoryReport{},
+			Description: "上报 logstash-plugin list --verbose 列出的插件及版本，集成插件提供的子插件带 integration，平台部署前据此检查配置引用的插件"},
+		{Method: http.MethodPost, Path: "/api/v1/agents/{id}/secrets/resolve", Request: models.ResolveSecretsRequest{}, Response: models.ResolvedSecrets{},
+			Description: "获取配置内容中 ${secret:名称} 引用的密钥值，只能获取该配置当前或历史版本引用的密钥"},
+		{Method: http.MethodPost, Path: "/api/v1/agents/{id}/deregister", Request: models.DeregisterRequest{}, Response: models.ArchivedAgent{},
+			Description: "启用 deregister_on_shutdown 时Agent关闭前主动注销，平台归档后从Agent列表删除，之后的心跳返回404，重新注册时作为新Agent加入"},
+		{Method: http.MethodGet, Path: "/api/v1/agents/{id}/messages", Response: models.PollResponse{},
+			Description: "代理不允许WebSocket时长轮询接收平台消息，没有消息时等待最多 wait（如30s）；received 填上一次响应的 batch，不符时平台重发该批消息；超过 pong_timeout 未再次轮询视为断开"},
+		{Method: http.MethodPost, Path: "/api/v1/agents/{id}/messages", Request: models.PollUpload{},
+			Description: "经长轮询通道上报消息，messages 中每条消息与WebSocket消息格式相同"},
+	}
+}
+
+// MessageSchema 文档中的消息描述
+type MessageSchema struct {
+	Type        string    `json:"type"`
+	Direction   Direction `json:"direction"`
+	Transports  []string  `json:"transports"`
+	Description string    `json:"description"`
+	Payload     *Schema   `json:"payload"`
+}
+
+// EndpointSchema 文档中的HTTP接口描述
+type EndpointSchema struct {
+	Method      string  `json:"method"`
+	Path        string  `json:"path"`
+	Description string  `json:"description"`
+	Request     *Schema `json:"request,omitempty"`
+	Response    *Schema `json:"response,omitempty"`
+}
+
+// WebSocketSchema 文档中的WebSocket部分
+type WebSocketSchema struct {
+	Path     string          `json:"path"`
+	Query    []string        `json:"query"`
+	Envelope *Schema         `json:"envelope"`
+	Messages []MessageSchema `json:"messages"`
+}
+
+// Document 协议文档，顶层即合法的JSON Schema，$defs 中为各消息引用的类型
+type Document struct {
+	Schema    string             `json:"$schema"`
+	ID        string             `json:"$id"`
+	Title     string             `json:"title"`
+	Version   string             `json:"version"`
+	WebSocket WebSocketSchema    `json:"websocket"`
+	HTTP      []EndpointSchema   `json:"http"`
+	Defs      map[string]*Schema `json:"$defs"`
+}
+
+// Generate 从Go类型生成当前版本的协议文档
+func Generate() *Document {
+	g := newGenerator()
+	doc := &Document{
+		Schema:  "https://json-schema.org/draft/2020-12/schema",
+		ID:      fmt.Sprintf("https://logstash-platform/protocol/agent/v%s.json", Version),
+		Title:   "Logstash Agent ⇄ 管理平台通信协议",
+		Version: Version,
+		WebSocket: WebSocketSchema{
+			Path:     WebSocketPath,
+			Query:    []string{"agent_id"},
+			Envelope: g.schemaOf(models.WebSocketMessage{}),
+		},
+	}
+
+	for _, m := range Messages() {
+		doc.WebSocket.Messages = append(doc.WebSocket.Messages, MessageSchema{
+			Type:        m.Type,
+			Direction:   m.Direction,
+			Transports:  m.Transports,
+			Description: m.Description,
+			Payload:     g.schemaOf(m.Payload),
+		})
+	}
+
+	for _, e := range Endpoints() {
+		es := EndpointSchema{Method: e.Method, Path: e.Path, Description: e.Description}
+		if e.Request != nil {
+			es.Request = g.schemaOf(e.Request)
+			if len(e.Require) > 0 {
+				// 额外必填字段套在引用外层，不影响其他位置对同一类型的引用
+				es.Request = &Schema{AnyOf: []*Schema{es.Request}, Required: e.Require}
+			}
+		}
+		if e.Response != nil {
+			es.Response = g.schemaOf(e.Response)
+		}
+		doc.HTTP = append(doc.HTTP, es)
+	}
+
+	doc.Defs = g.defs
+	return doc
+}
+
+// MarshalDocument 生成格式化的协议文档
+func MarshalDocument() ([]byte, error) {
+	data, err := json.MarshalIndent(Generate(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// findMessage 查找指定方向的消息定义
+func (d *Document) findMessage(msgType string, direction Direction) (*MessageSchema, bool) {
+	for i := range d.WebSocket.Messages {
+		m := &d.WebSocket.Messages[i]
+		if m.Type != msgType {
+			continue
+		}
+		if m.Direction == Both || m.Direction == direction {
+			return m, true
+		}
+	}
+	return nil, false
+}
+
+// findEndpoint 按方法和路径查找接口定义，{id} 匹配任意单个路径段
+func (d *Document) findEndpoint(method, path string) (*EndpointSchema, bool) {
+	for i := range d.HTTP {
+		e := &d.HTTP[i]
+		if e.Method == method && matchPath(e.Path, path) {
+			return e, true
+		}
+	}
+	return nil, false
+}
+
+func matchPath(pattern, path string) bool {
+	ps := strings.Split(strings.Trim(pattern, "/"), "/")
+	as := strings.Split(strings.Trim(path, "/"), "/")
+	if len(ps) != len(as) {
+		return false
+	}
+	for i := range ps {
+		if strings.HasPrefix(ps[i], "{") && strings.HasSuffix(ps[i], "}") {
+			if as[i] == "" {
+				return false
+			}
+			continue
+		}
+		if ps[i] != as[i] {
+			return false
+		}
+	}
+	return true
+}
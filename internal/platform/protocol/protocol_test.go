@@ -0,0 +1,128 @@
+package protocol
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "重新生成 docs/protocol 下的协议文档")
+
+const documentPath = "../../../docs/protocol/agent-protocol.v" + Version + ".json"
+
+func TestDocumentUpToDate(t *testing.T) {
+	data, err := MarshalDocument()
+	require.NoError(t, err)
+
+	if *update {
+		require.NoError(t, os.WriteFile(documentPath, data, 0644))
+	}
+
+	published, err := os.ReadFile(documentPath)
+	require.NoError(t, err)
+	assert.Equal(t, string(published), string(data), "协议类型已变更，请执行 go test ./internal/platform/protocol -update 更新文档")
+}
+
+func TestGenerate_StructSchemas(t *testing.T) {
+	doc := Generate()
+
+	deploy := doc.Defs["ConfigDeployPayload"]
+	require.NotNil(t, deploy)
+	assert.Equal(t, []string{"config_id", "version"}, deploy.Required)
+	assert.Equal(t, []string{"string"}, deploy.Properties["deployment_id"].Types)
+
+	// 嵌入类型、时间、可空字段
+	agent := doc.Defs["Agent"]
+	require.NotNil(t, agent)
+	assert.Equal(t, "date-time", agent.Properties["last_heartbeat"].Format)
+	assert.Equal(t, []string{"array", "null"}, agent.Properties["applied_configs"].Types)
+	assert.Equal(t, "#/$defs/AgentSettings", agent.Properties["settings"].AnyOf[0].Ref)
+
+	chunk := doc.Defs["Envelope"]
+	require.NotNil(t, chunk)
+	assert.Equal(t, "base64", chunk.Properties["data"].ContentEncoding)
+
+	// 生成的文档可以原样解析回来
+	data, err := json.Marshal(doc)
+	require.NoError(t, err)
+	var parsed Document
+	require.NoError(t, json.Unmarshal(data, &parsed))
+	assert.Equal(t, doc.Defs["HeartbeatRequest"], parsed.Defs["HeartbeatRequest"])
+}
+
+func TestValidator(t *testing.T) {
+	v := NewValidator(nil)
+
+	tests := []struct {
+		name    string
+		dir     Direction
+		message string
+		wantErr string
+	}{
+		{
+			name:    "valid config_applied",
+			dir:     ToPlatform,
+			message: `{"type":"config_applied","timestamp":"2026-01-02T03:04:05Z","payload":{"agent_id":"a1","config_id":"c1","version":2,"applied_at":"2026-01-02T03:04:05Z","deployment_id":"d1"}}`,
+		},
+		{
+			name:    "heartbeat with null timestamp",
+			dir:     ToPlatform,
+			message: `{"type":"heartbeat","timestamp":"2026-01-02T03:04:05Z","payload":{"agent_id":"a1","timestamp":null}}`,
+		},
+		{
+			name:    "missing required field",
+			dir:     ToPlatform,
+			message: `{"type":"config_applied","timestamp":"2026-01-02T03:04:05Z","payload":{"agent_id":"a1","version":2}}`,
+			wantErr: "缺少必填字段 config_id",
+		},
+		{
+			name:    "wrong field type",
+			dir:     ToPlatform,
+			message: `{"type":"metrics_report","timestamp":"2026-01-02T03:04:05Z","payload":{"metrics":{"cpu_usage":"high"}}}`,
+			wantErr: "$.metrics.cpu_usage: 应为number",
+		},
+		{
+			name:    "bad timestamp",
+			dir:     ToPlatform,
+			message: `{"type":"heartbeat","timestamp":"yesterday","payload":{"agent_id":"a1"}}`,
+			wantErr: "不是RFC 3339时间",
+		},
+		{
+			name:    "message in wrong direction",
+			dir:     ToPlatform,
+			message: `{"type":"config_deploy","timestamp":"2026-01-02T03:04:05Z","payload":{"config_id":"c1","version":1}}`,
+			wantErr: "未定义的agent_to_platform消息类型",
+		},
+		{
+			name:    "unknown fields are allowed",
+			dir:     ToAgent,
+			message: `{"type":"config_deploy","timestamp":"2026-01-02T03:04:05Z","payload":{"config_id":"c1","version":1,"priority":"high"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := v.ValidateMessage(tt.dir, []byte(tt.message))
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidator_Request(t *testing.T) {
+	v := NewValidator(nil)
+
+	assert.NoError(t, v.ValidateRequest("POST", "/api/v1/agents/register", []byte(`{"agent_id":"a1","hostname":"h"}`)))
+	assert.ErrorContains(t, v.ValidateRequest("POST", "/api/v1/agents/register", []byte(`{"hostname":"h"}`)), "agent_id")
+	assert.NoError(t, v.ValidateRequest("POST", "/api/v1/agents/a1/heartbeat", nil))
+	assert.NoError(t, v.ValidateRequest("GET", "/api/v1/configs/c1", nil))
+	assert.ErrorContains(t, v.ValidateRequest("PUT", "/api/v1/agents/a1/heartbeat", nil), "未定义的接口")
+}
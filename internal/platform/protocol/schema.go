@@ -0,0 +1,211 @@
+package protocol
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Schema JSON Schema (draft 2020-12) 的子集，足以描述协议中的Go类型
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Types                []string           `json:"-"` // 单个类型序列化为字符串，多个序列化为数组
+	Format               string             `json:"format,omitempty"`
+	ContentEncoding      string             `json:"contentEncoding,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Const                interface{}        `json:"const,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+}
+
+// MarshalJSON 输出 type 字段，其余字段按结构体标签序列化
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	type plain Schema
+	out := struct {
+		Type interface{} `json:"type,omitempty"`
+		*plain
+	}{plain: (*plain)(s)}
+	switch len(s.Types) {
+	case 0:
+	case 1:
+		out.Type = s.Types[0]
+	default:
+		out.Type = s.Types
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON 兼容 type 为字符串或数组两种写法
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	type plain Schema
+	in := struct {
+		Type json.RawMessage `json:"type"`
+		*plain
+	}{plain: (*plain)(s)}
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	s.Types = nil
+	if len(in.Type) == 0 {
+		return nil
+	}
+	var single string
+	if err := json.Unmarshal(in.Type, &single); err == nil {
+		s.Types = []string{single}
+		return nil
+	}
+	return json.Unmarshal(in.Type, &s.Types)
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// generator 通过反射从Go类型生成Schema，具名结构体放入defs并以$ref引用
+type generator struct {
+	defs      map[string]*Schema
+	refPrefix string
+}
+
+func newGenerator() *generator {
+	return &generator{defs: make(map[string]*Schema), refPrefix: "#/$defs/"}
+}
+
+// SchemaGenerator 供其他文档（如OpenAPI）复用的Schema生成器，规则与协议文档相同
+type SchemaGenerator struct {
+	g *generator
+}
+
+// NewSchemaGenerator 创建Schema生成器，具名结构体的引用写作 refPrefix+类型名
+func NewSchemaGenerator(refPrefix string) *SchemaGenerator {
+	g := newGenerator()
+	g.refPrefix = refPrefix
+	return &SchemaGenerator{g: g}
+}
+
+// SchemaOf 生成值v对应类型的Schema，v为nil时表示任意JSON
+func (s *SchemaGenerator) SchemaOf(v interface{}) *Schema {
+	return s.g.schemaOf(v)
+}
+
+// TypeSchema 生成类型t的Schema
+func (s *SchemaGenerator) TypeSchema(t reflect.Type) *Schema {
+	return s.g.typeSchema(t)
+}
+
+// Defs 已生成的具名结构体定义
+func (s *SchemaGenerator) Defs() map[string]*Schema {
+	return s.g.defs
+}
+
+// schemaOf 生成值v对应类型的Schema，v为nil时表示任意JSON
+func (g *generator) schemaOf(v interface{}) *Schema {
+	if v == nil {
+		return &Schema{}
+	}
+	return g.typeSchema(reflect.TypeOf(v))
+}
+
+func (g *generator) typeSchema(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Types: []string{"string"}, Format: "date-time"}
+	case rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return nullable(g.typeSchema(t.Elem()))
+	case reflect.Interface:
+		return &Schema{}
+	case reflect.Bool:
+		return &Schema{Types: []string{"boolean"}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Types: []string{"integer"}}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Types: []string{"number"}}
+	case reflect.String:
+		return &Schema{Types: []string{"string"}}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Types: []string{"string", "null"}, ContentEncoding: "base64"}
+		}
+		return nullable(&Schema{Types: []string{"array"}, Items: g.typeSchema(t.Elem())})
+	case reflect.Array:
+		return &Schema{Types: []string{"array"}, Items: g.typeSchema(t.Elem())}
+	case reflect.Map:
+		return nullable(&Schema{Types: []string{"object"}, AdditionalProperties: g.typeSchema(t.Elem())})
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		name := t.Name()
+		if _, ok := g.defs[name]; !ok {
+			g.defs[name] = nil // 先占位，防止递归类型死循环
+			g.defs[name] = g.structSchema(t)
+		}
+		return &Schema{Ref: g.refPrefix + name}
+	}
+	return &Schema{}
+}
+
+// structSchema 按encoding/json的规则展开结构体字段，binding:"required"的字段列为必填
+func (g *generator) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Types: []string{"object"}, Properties: make(map[string]*Schema)}
+	g.addFields(s, t)
+	sort.Strings(s.Required)
+	return s
+}
+
+func (g *generator) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		// 无标签的匿名结构体字段与外层字段合并
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.addFields(s, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		s.Properties[name] = g.typeSchema(f.Type)
+		if strings.Contains(","+f.Tag.Get("binding")+",", ",required,") {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// nullable 允许值为null，对应Go中的nil指针、切片和map
+func nullable(s *Schema) *Schema {
+	if s.Ref != "" {
+		return &Schema{AnyOf: []*Schema{s, {Types: []string{"null"}}}}
+	}
+	if len(s.Types) > 0 {
+		s.Types = append(s.Types, "null")
+	}
+	return s
+}
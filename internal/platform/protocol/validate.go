@@ -0,0 +1,178 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Validator 按协议文档校验消息和HTTP请求体
+// 只检查类型、必填字段和格式，未知字段一律放行，保证旧Agent兼容新增字段
+type Validator struct {
+	doc *Document
+}
+
+// NewValidator 创建校验器，doc为nil时使用当前版本的协议文档
+func NewValidator(doc *Document) *Validator {
+	if doc == nil {
+		doc = Generate()
+	}
+	return &Validator{doc: doc}
+}
+
+// ValidateMessage 校验一条完整的WebSocket消息（含封包）
+func (v *Validator) ValidateMessage(direction Direction, data []byte) (string, error) {
+	var msg struct {
+		Type    string          `json:"type"`
+		Payload json.RawMessage `json:"payload"`
+	}
+	if err := v.check(v.doc.WebSocket.Envelope, data, "消息封包"); err != nil {
+		return "", err
+	}
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return "", fmt.Errorf("消息封包: %w", err)
+	}
+	return msg.Type, v.ValidatePayload(direction, msg.Type, msg.Payload)
+}
+
+// ValidatePayload 校验指定类型消息的payload
+func (v *Validator) ValidatePayload(direction Direction, msgType string, payload []byte) error {
+	m, ok := v.doc.findMessage(msgType, direction)
+	if !ok {
+		return fmt.Errorf("未定义的%s消息类型: %s", direction, msgType)
+	}
+	if len(payload) == 0 {
+		payload = []byte("null")
+	}
+	return v.check(m.Payload, payload, msgType)
+}
+
+// ValidateRequest 校验Agent发出的HTTP请求体
+func (v *Validator) ValidateRequest(method, path string, body []byte) error {
+	e, ok := v.doc.findEndpoint(method, path)
+	if !ok {
+		return fmt.Errorf("未定义的接口: %s %s", method, path)
+	}
+	if e.Request == nil || len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	return v.check(e.Request, body, method+" "+e.Path)
+}
+
+func (v *Validator) check(s *Schema, data []byte, subject string) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return fmt.Errorf("%s: 不是合法的JSON: %w", subject, err)
+	}
+
+	var errs []string
+	v.validate(s, value, "$", &errs)
+	if len(errs) > 0 {
+		return fmt.Errorf("%s: %s", subject, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (v *Validator) validate(s *Schema, value interface{}, path string, errs *[]string) {
+	if s == nil {
+		return
+	}
+	if s.Ref != "" {
+		def, ok := v.doc.Defs[strings.TrimPrefix(s.Ref, "#/$defs/")]
+		if !ok {
+			*errs = append(*errs, fmt.Sprintf("%s: 无法解析的引用 %s", path, s.Ref))
+			return
+		}
+		v.validate(def, value, path, errs)
+	}
+
+	if len(s.AnyOf) > 0 {
+		var first []string
+		matched := false
+		for i, alt := range s.AnyOf {
+			var altErrs []string
+			v.validate(alt, value, path, &altErrs)
+			if len(altErrs) == 0 {
+				matched = true
+				break
+			}
+			if i == 0 {
+				first = altErrs
+			}
+		}
+		if !matched {
+			*errs = append(*errs, first...)
+			return
+		}
+	}
+
+	if len(s.Types) > 0 && !matchesType(s.Types, value) {
+		*errs = append(*errs, fmt.Sprintf("%s: 应为%s，实际为%s", path, strings.Join(s.Types, "或"), jsonType(value)))
+		return
+	}
+	if s.Const != nil && fmt.Sprint(s.Const) != fmt.Sprint(value) {
+		*errs = append(*errs, fmt.Sprintf("%s: 应为 %v", path, s.Const))
+	}
+
+	switch val := value.(type) {
+	case string:
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, val); err != nil {
+				*errs = append(*errs, fmt.Sprintf("%s: 不是RFC 3339时间", path))
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := val[name]; !ok {
+				*errs = append(*errs, fmt.Sprintf("%s: 缺少必填字段 %s", path, name))
+			}
+		}
+		for name, field := range val {
+			if prop, ok := s.Properties[name]; ok {
+				v.validate(prop, field, path+"."+name, errs)
+			} else if s.AdditionalProperties != nil {
+				v.validate(s.AdditionalProperties, field, path+"."+name, errs)
+			}
+		}
+	case []interface{}:
+		for i, item := range val {
+			v.validate(s.Items, item, fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	}
+}
+
+func matchesType(types []string, value interface{}) bool {
+	actual := jsonType(value)
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonType 返回解码值对应的JSON Schema类型
+func jsonType(value interface{}) string {
+	switch val := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := val.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return "unknown"
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// AgentArchiveRepository 已注销Agent的归档仓库接口
+type AgentArchiveRepository interface {
+	Save(ctx context.Context, archived *models.ArchivedAgent) error
+	// List 按注销时间倒序获取归档记录
+	List(ctx context.Context, req *models.ArchivedAgentListRequest) (*models.ArchivedAgentListResponse, error)
+}
+
+// agentArchiveRepository 已注销Agent的归档仓库实现
+type agentArchiveRepository struct {
+	esClient elasticsearch.ClientInterface
+	logger   *logrus.Logger
+}
+
+// NewAgentArchiveRepository 创建已注销Agent的归档仓库
+func NewAgentArchiveRepository(esClient elasticsearch.ClientInterface, logger *logrus.Logger) AgentArchiveRepository {
+	return &agentArchiveRepository{
+		esClient: esClient,
+		logger:   logger,
+	}
+}
+
+// Save 保存归档记录
+func (r *agentArchiveRepository) Save(ctx context.Context, archived *models.ArchivedAgent) error {
+	if err := r.esClient.Index(ctx, "logstash_agents_archive", archived.ArchiveID, archived); err != nil {
+		return fmt.Errorf("保存Agent归档记录失败: %w", err)
+	}
+	return nil
+}
+
+// List 按注销时间倒序获取归档记录
+func (r *agentArchiveRepository) List(ctx context.Context, req *models.ArchivedAgentListRequest) (*models.ArchivedAgentListResponse, error) {
+	query := map[string]interface{}{
+		"from": (req.Page - 1) * req.PageSize,
+		"size": req.PageSize,
+		"sort": []map[string]interface{}{
+			{"decommissioned_at": map[string]string{"order": "desc"}},
+		},
+	}
+
+	var filter []map[string]interface{}
+	if req.AgentID != "" {
+		filter = append(filter, map[string]interface{}{
+			"term": map[string]interface{}{"agent_id": req.AgentID},
+		})
+	}
+	if req.Project != "" {
+		filter = append(filter, projectFilter(req.Project))
+	}
+	if len(filter) > 0 {
+		query["query"] = map[string]interface{}{
+			"bool": map[string]interface{}{"filter": filter},
+		}
+	}
+
+	var result struct {
+		Hits struct {
+			Total struct {
+				Value int64 `json:"value"`
+			} `json:"total"`
+			Hits []struct {
+				Source models.ArchivedAgent `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+
+	if err := r.esClient.Search(ctx, "logstash_agents_archive", query, &result); err != nil {
+		return nil, fmt.Errorf("搜索Agent归档记录失败: %w", err)
+	}
+
+	response := &models.ArchivedAgentListResponse{
+		Total: result.Hits.Total.Value,
+		Page:  req.Page,
+		Size:  req.PageSize,
+		Items: make([]*models.ArchivedAgent, 0, len(result.Hits.Hits)),
+	}
+	for _, hit := range result.Hits.Hits {
+		archived := hit.Source
+		response.Items = append(response.Items, &archived)
+	}
+	return response, nil
+}
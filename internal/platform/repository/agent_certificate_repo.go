@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// AgentCertificateRepository Agent客户端证书仓库接口
+type AgentCertificateRepository interface {
+	Save(ctx context.Context, cert *models.AgentCertificate) error
+	GetBySerial(ctx context.Context, serial string) (*models.AgentCertificate, error)
+	ListByAgent(ctx context.Context, agentID string) ([]*models.AgentCertificate, error)
+}
+
+// agentCertificateRepository Agent客户端证书仓库实现
+type agentCertificateRepository struct {
+	esClient elasticsearch.ClientInterface
+	logger   *logrus.Logger
+}
+
+// NewAgentCertificateRepository 创建Agent客户端证书仓库
+func NewAgentCertificateRepository(esClient elasticsearch.ClientInterface, logger *logrus.Logger) AgentCertificateRepository {
+	return &agentCertificateRepository{
+		esClient: esClient,
+		logger:   logger,
+	}
+}
+
+// Save 保存证书记录（不存在则创建）
+func (r *agentCertificateRepository) Save(ctx context.Context, cert *models.AgentCertificate) error {
+	if err := r.esClient.Index(ctx, "logstash_agent_certificates", cert.Serial, cert); err != nil {
+		return fmt.Errorf("保存Agent证书失败: %w", err)
+	}
+	return nil
+}
+
+// GetBySerial 根据序列号获取证书记录
+func (r *agentCertificateRepository) GetBySerial(ctx context.Context, serial string) (*models.AgentCertificate, error) {
+	var cert models.AgentCertificate
+	if err := r.esClient.Get(ctx, "logstash_agent_certificates", serial, &cert); err != nil {
+		return nil, err
+	}
+	return &cert, nil
+}
+
+// ListByAgent 获取Agent的全部证书记录（含已吊销），按签发时间倒序
+func (r *agentCertificateRepository) ListByAgent(ctx context.Context, agentID string) ([]*models.AgentCertificate, error) {
+	query := map[string]interface{}{
+		"query": map[string]interface{}{
+			"term": map[string]interface{}{"agent_id": agentID},
+		},
+		"sort": []map[string]interface{}{
+			{"created_at": map[string]string{"order": "desc"}},
+		},
+		"size": 100, // 单个Agent的证书数量有限
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Source models.AgentCertificate `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+
+	if err := r.esClient.Search(ctx, "logstash_agent_certificates", query, &result); err != nil {
+		return nil, fmt.Errorf("搜索Agent证书失败: %w", err)
+	}
+
+	certs := make([]*models.AgentCertificate, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		cert := hit.Source
+		certs = append(certs, &cert)
+	}
+
+	return certs, nil
+}
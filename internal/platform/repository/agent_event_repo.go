@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// AgentEventRepository Agent事件日志仓库接口
+type AgentEventRepository interface {
+	Save(ctx context.Context, event *models.AgentEvent) error
+	ListByAgent(ctx context.Context, agentID string, size int) ([]*models.AgentEvent, error)
+	// List 按类型和时间范围获取Agent的事件，按时间倒序
+	List(ctx context.Context, agentID string, req *models.AgentEventListRequest) ([]*models.AgentEvent, error)
+}
+
+// agentEventRepository Agent事件日志仓库实现
+type agentEventRepository struct {
+	esClient elasticsearch.ClientInterface
+	logger   *logrus.Logger
+}
+
+// NewAgentEventRepository 创建Agent事件日志仓库
+func NewAgentEventRepository(esClient elasticsearch.ClientInterface, logger *logrus.Logger) AgentEventRepository {
+	return &agentEventRepository{
+		esClient: esClient,
+		logger:   logger,
+	}
+}
+
+// Save 保存事件
+func (r *agentEventRepository) Save(ctx context.Context, event *models.AgentEvent) error {
+	if err := r.esClient.Index(ctx, "logstash_agent_events", event.ID, event); err != nil {
+		return fmt.Errorf("保存Agent事件失败: %w", err)
+	}
+	return nil
+}
+
+// ListByAgent 获取Agent最近的事件，按时间倒序
+func (r *agentEventRepository) ListByAgent(ctx context.Context, agentID string, size int) ([]*models.AgentEvent, error) {
+	return r.List(ctx, agentID, &models.AgentEventListRequest{Size: size})
+}
+
+// List 按条件获取Agent的事件
+func (r *agentEventRepository) List(ctx context.Context, agentID string, req *models.AgentEventListRequest) ([]*models.AgentEvent, error) {
+	size := req.Size
+	if size <= 0 {
+		size = 100
+	}
+
+	filter := []map[string]interface{}{
+		{"term": map[string]interface{}{"agent_id": agentID}},
+	}
+	if req.Type != "" {
+		filter = append(filter, map[string]interface{}{
+			"term": map[string]interface{}{"type": req.Type},
+		})
+	}
+	if !req.Since.IsZero() || !req.Until.IsZero() {
+		createdAt := map[string]interface{}{}
+		if !req.Since.IsZero() {
+			createdAt["gte"] = req.Since
+		}
+		if !req.Until.IsZero() {
+			createdAt["lt"] = req.Until
+		}
+		filter = append(filter, map[string]interface{}{
+			"range": map[string]interface{}{"created_at": createdAt},
+		})
+	}
+
+	query := map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{"filter": filter},
+		},
+		"sort": []map[string]interface{}{
+			{"created_at": map[string]string{"order": "desc"}},
+		},
+		"size": size,
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Source models.AgentEvent `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+
+	if err := r.esClient.Search(ctx, "logstash_agent_events", query, &result); err != nil {
+		return nil, fmt.Errorf("搜索Agent事件失败: %w", err)
+	}
+
+	events := make([]*models.AgentEvent, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		event := hit.Source
+		events = append(events, &event)
+	}
+
+	return events, nil
+}
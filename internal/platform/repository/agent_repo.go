@@ -0,0 +1,315 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// agentPageSize 列表查询每页读取的Agent数，超过一页时按 search_after 继续翻页
+const agentPageSize = 1000
+
+// AgentRepository Agent仓库接口
+type AgentRepository interface {
+	Save(ctx context.Context, agent *models.Agent) error
+	GetByID(ctx context.Context, agentID string) (*models.Agent, error)
+	// Delete 从Agent列表中删除Agent，注销时先写入归档
+	Delete(ctx context.Context, agentID string) error
+	ListByGroup(ctx context.Context, group string) ([]*models.Agent, error)
+	ListByLabels(ctx context.Context, labels map[string]string) ([]*models.Agent, error)
+	ListByAppliedConfig(ctx context.Context, configID string) ([]*models.Agent, error)
+	// List 按过滤条件、排序和分页获取Agent，PageSize须大于0
+	List(ctx context.Context, req *models.AgentListRequest) (*models.AgentListResponse, error)
+}
+
+// agentRepository Agent仓库实现
+type agentRepository struct {
+	esClient elasticsearch.ClientInterface
+	logger   *logrus.Logger
+}
+
+// NewAgentRepository 创建Agent仓库
+func NewAgentRepository(esClient elasticsearch.ClientInterface, logger *logrus.Logger) AgentRepository {
+	return &agentRepository{
+		esClient: esClient,
+		logger:   logger,
+	}
+}
+
+// Save 保存Agent（不存在则创建）
+func (r *agentRepository) Save(ctx context.Context, agent *models.Agent) error {
+	if agent.AgentID == "" {
+		return fmt.Errorf("Agent ID不能为空")
+	}
+
+	if err := r.esClient.Index(ctx, "logstash_agents", agent.AgentID, agent); err != nil {
+		return fmt.Errorf("保存Agent失败: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID 根据ID获取Agent
+func (r *agentRepository) GetByID(ctx context.Context, agentID string) (*models.Agent, error) {
+	var agent models.Agent
+	if err := r.esClient.Get(ctx, "logstash_agents", agentID, &agent); err != nil {
+		return nil, err
+	}
+	return &agent, nil
+}
+
+// Delete 删除Agent
+func (r *agentRepository) Delete(ctx context.Context, agentID string) error {
+	if err := r.esClient.Delete(ctx, "logstash_agents", agentID); err != nil {
+		return fmt.Errorf("删除Agent失败: %w", err)
+	}
+	return nil
+}
+
+// ListByGroup 获取分组下的全部Agent
+func (r *agentRepository) ListByGroup(ctx context.Context, group string) ([]*models.Agent, error) {
+	query := map[string]interface{}{
+		"query": map[string]interface{}{
+			"term": map[string]interface{}{
+				"group": group,
+			},
+		},
+	}
+
+	return r.search(ctx, query)
+}
+
+// ListByAppliedConfig 获取已应用指定配置的Agent
+func (r *agentRepository) ListByAppliedConfig(ctx context.Context, configID string) ([]*models.Agent, error) {
+	query := map[string]interface{}{
+		"query": map[string]interface{}{
+			"nested": map[string]interface{}{
+				"path": "applied_configs",
+				"query": map[string]interface{}{
+					"term": map[string]interface{}{
+						"applied_configs.config_id": configID,
+					},
+				},
+			},
+		},
+	}
+
+	return r.search(ctx, query)
+}
+
+// ListByLabels 获取标签匹配的Agent
+// 标签可能来自Agent自身上报，也可能来自平台侧的设置覆盖，任一处匹配即可；labels为空时返回全部Agent
+func (r *agentRepository) ListByLabels(ctx context.Context, labels map[string]string) ([]*models.Agent, error) {
+	must := make([]map[string]interface{}, 0, len(labels))
+	for key, value := range labels {
+		must = append(must, map[string]interface{}{
+			"bool": map[string]interface{}{
+				"should": []map[string]interface{}{
+					{"term": map[string]interface{}{"labels." + key: value}},
+					{"term": map[string]interface{}{"settings.labels." + key: value}},
+				},
+				"minimum_should_match": 1,
+			},
+		})
+	}
+
+	query := map[string]interface{}{}
+	if len(must) > 0 {
+		query["query"] = map[string]interface{}{
+			"bool": map[string]interface{}{"must": must},
+		}
+	}
+
+	return r.search(ctx, query)
+}
+
+// search 执行Agent搜索，按agent_id排序并用 search_after 翻页读取全部结果
+// 避免固定size截断大规模Agent集群
+func (r *agentRepository) search(ctx context.Context, query map[string]interface{}) ([]*models.Agent, error) {
+	query["size"] = agentPageSize
+	query["sort"] = []map[string]interface{}{
+		{"agent_id": map[string]string{"order": "asc"}},
+	}
+
+	var agents []*models.Agent
+	for {
+		var result struct {
+			Hits struct {
+				Hits []struct {
+					Source models.Agent  `json:"_source"`
+					Sort   []interface{} `json:"sort"`
+				} `json:"hits"`
+			} `json:"hits"`
+		}
+
+		if err := r.esClient.Search(ctx, "logstash_agents", query, &result); err != nil {
+			return nil, fmt.Errorf("搜索Agent失败: %w", err)
+		}
+
+		hits := result.Hits.Hits
+		for _, hit := range hits {
+			agent := hit.Source
+			agents = append(agents, &agent)
+		}
+
+		if len(hits) < agentPageSize || len(hits[len(hits)-1].Sort) == 0 {
+			break
+		}
+		query["search_after"] = hits[len(hits)-1].Sort
+	}
+
+	if agents == nil {
+		agents = make([]*models.Agent, 0)
+	}
+	return agents, nil
+}
+
+// List 按过滤条件、排序和分页获取Agent
+// 按Agent ID决出同值记录的先后，保证游标翻页不重复不遗漏
+func (r *agentRepository) List(ctx context.Context, req *models.AgentListRequest) (*models.AgentListResponse, error) {
+	order := models.SortOrder(req.Sort, req.Order)
+	query := map[string]interface{}{
+		"size":             req.PageSize,
+		"track_total_hits": true,
+		"sort": []map[string]interface{}{
+			agentSortField(req.Sort, order),
+			{"agent_id": map[string]string{"order": order}},
+		},
+	}
+	if len(req.After) > 0 {
+		query["search_after"] = req.After
+	} else if req.Page > 1 {
+		query["from"] = (req.Page - 1) * req.PageSize
+	}
+
+	if filter := agentFilters(req); len(filter) > 0 {
+		query["query"] = map[string]interface{}{
+			"bool": map[string]interface{}{"filter": filter},
+		}
+	}
+
+	var result struct {
+		Hits struct {
+			Total struct {
+				Value int64 `json:"value"`
+			} `json:"total"`
+			Hits []struct {
+				Source models.Agent  `json:"_source"`
+				Sort   []interface{} `json:"sort"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+
+	if err := r.esClient.Search(ctx, "logstash_agents", query, &result); err != nil {
+		return nil, fmt.Errorf("搜索Agent失败: %w", err)
+	}
+
+	response := &models.AgentListResponse{
+		Total: int(result.Hits.Total.Value),
+		Items: make([]*models.Agent, 0, len(result.Hits.Hits)),
+	}
+	for _, hit := range result.Hits.Hits {
+		agent := hit.Source
+		response.Items = append(response.Items, &agent)
+	}
+	if hits := result.Hits.Hits; len(hits) > 0 && len(hits) == req.PageSize {
+		response.NextCursor = models.EncodeCursor(hits[len(hits)-1].Sort)
+	}
+
+	return response, nil
+}
+
+// agentFilters Agent列表的过滤条件
+func agentFilters(req *models.AgentListRequest) []map[string]interface{} {
+	var filter []map[string]interface{}
+	term := func(field, value string) {
+		if value != "" {
+			filter = append(filter, map[string]interface{}{
+				"term": map[string]interface{}{field: value},
+			})
+		}
+	}
+	term("status", req.Status)
+	term("group", req.Group)
+	term("logstash_version", req.LogstashVersion)
+
+	if req.ConfigID != "" {
+		filter = append(filter, map[string]interface{}{
+			"nested": map[string]interface{}{
+				"path": "applied_configs",
+				"query": map[string]interface{}{
+					"term": map[string]interface{}{"applied_configs.config_id": req.ConfigID},
+				},
+			},
+		})
+	}
+	if q := strings.TrimSpace(req.Query); q != "" {
+		filter = append(filter, agentSearchQuery(q))
+	}
+	if req.Project != "" {
+		filter = append(filter, projectFilter(req.Project))
+	}
+	return filter
+}
+
+// agentSearchQuery 主机名按不区分大小写的子串匹配；输入为IP地址或CIDR时同时按IP匹配
+// 主机名以 keyword 保存，不分词，子串匹配比全文检索更符合按主机名查找的习惯
+func agentSearchQuery(q string) map[string]interface{} {
+	should := []map[string]interface{}{
+		{"wildcard": map[string]interface{}{
+			"hostname": map[string]interface{}{
+				"value":            "*" + wildcardEscaper.Replace(q) + "*",
+				"case_insensitive": true,
+			},
+		}},
+	}
+	if _, _, err := net.ParseCIDR(q); err == nil || net.ParseIP(q) != nil {
+		should = append(should, map[string]interface{}{
+			"term": map[string]interface{}{"ip": q},
+		})
+	}
+	return map[string]interface{}{
+		"bool": map[string]interface{}{
+			"should":               should,
+			"minimum_should_match": 1,
+		},
+	}
+}
+
+// wildcardEscaper 转义wildcard查询中的通配符，输入按字面匹配
+var wildcardEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`)
+
+// agentSortField 排序字段对应的ES排序条件
+// Logstash版本按数字逐段比较（8.10.0 大于 8.9.1）：脚本将各数字段补齐到相同宽度后按字符串排序
+func agentSortField(field, order string) map[string]interface{} {
+	switch field {
+	case models.SortName:
+		return map[string]interface{}{"hostname": map[string]string{"order": order}}
+	case models.SortVersion:
+		return map[string]interface{}{"_script": map[string]interface{}{
+			"type":   "string",
+			"order":  order,
+			"script": map[string]interface{}{"lang": "painless", "source": versionSortScript},
+		}}
+	default:
+		return map[string]interface{}{"last_heartbeat": map[string]string{"order": order}}
+	}
+}
+
+// versionSortScript 将 logstash_version 的各数字段左侧补零到8位，非数字段保持原样
+const versionSortScript = `
+if (doc['logstash_version'].size() == 0) { return ''; }
+StringBuilder key = new StringBuilder();
+for (String part : doc['logstash_version'].value.splitOnToken('.')) {
+  boolean numeric = part.length() > 0 && part.length() <= 8;
+  for (int i = 0; i < part.length() && numeric; i++) { numeric = Character.isDigit(part.charAt(i)); }
+  if (key.length() > 0) { key.append('.'); }
+  if (numeric) { key.append('00000000'.substring(part.length())); }
+  key.append(part);
+}
+return key.toString();`
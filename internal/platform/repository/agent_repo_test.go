@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/tests/mocks"
+)
+
+func TestAgentRepository_ListByLabelsPaginates(t *testing.T) {
+	ctx := context.Background()
+	esClient := new(mocks.MockElasticsearchClient)
+
+	// 第一页满页，第二页不足一页
+	pages := [][]string{make([]string, agentPageSize), {"agent-last"}}
+	for i := range pages[0] {
+		pages[0][i] = fmt.Sprintf("agent-%05d", i)
+	}
+
+	var searchAfter []interface{}
+	call := 0
+	esClient.On("Search", ctx, "logstash_agents", mock.Anything, mock.Anything).
+		Return(nil).
+		Run(func(args mock.Arguments) {
+			query := args.Get(2).(map[string]interface{})
+			if after, ok := query["search_after"]; ok {
+				searchAfter = after.([]interface{})
+			}
+
+			hits := make([]map[string]interface{}, 0, len(pages[call]))
+			for _, id := range pages[call] {
+				hits = append(hits, map[string]interface{}{
+					"_source": map[string]interface{}{"agent_id": id},
+					"sort":    []interface{}{id},
+				})
+			}
+			call++
+
+			data, _ := json.Marshal(map[string]interface{}{"hits": map[string]interface{}{"hits": hits}})
+			require.NoError(t, json.Unmarshal(data, args.Get(3)))
+		})
+
+	repo := NewAgentRepository(esClient, logrus.New())
+	agents, err := repo.ListByLabels(ctx, map[string]string{"env": "prod"})
+	require.NoError(t, err)
+
+	assert.Len(t, agents, agentPageSize+1)
+	assert.Equal(t, "agent-last", agents[agentPageSize].AgentID)
+	assert.Equal(t, []interface{}{pages[0][agentPageSize-1]}, searchAfter)
+	esClient.AssertNumberOfCalls(t, "Search", 2)
+}
+
+func TestAgentRepository_List(t *testing.T) {
+	ctx := context.Background()
+	esClient := new(mocks.MockElasticsearchClient)
+
+	var query map[string]interface{}
+	esClient.On("Search", ctx, "logstash_agents", mock.Anything, mock.Anything).
+		Return(nil).
+		Run(func(args mock.Arguments) {
+			query = args.Get(2).(map[string]interface{})
+			data, _ := json.Marshal(map[string]interface{}{"hits": map[string]interface{}{
+				"total": map[string]interface{}{"value": 7},
+				"hits": []map[string]interface{}{
+					{"_source": map[string]interface{}{"agent_id": "a1"}, "sort": []interface{}{"00000008.00000010", "a1"}},
+					{"_source": map[string]interface{}{"agent_id": "a2"}, "sort": []interface{}{"00000008.00000009", "a2"}},
+				},
+			}})
+			require.NoError(t, json.Unmarshal(data, args.Get(3)))
+		})
+
+	repo := NewAgentRepository(esClient, logrus.New())
+	resp, err := repo.List(ctx, &models.AgentListRequest{
+		Status:   models.AgentStatusOnline,
+		ConfigID: "cfg-1",
+		Query:    "10.0.0.0/24",
+		Sort:     models.SortVersion,
+		PageSize: 2,
+		After:    []interface{}{"00000008.00000011", "a0"},
+		Project:  "payments",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 7, resp.Total)
+	require.Len(t, resp.Items, 2)
+	// 满页时返回下一页游标
+	values, err := models.DecodeCursor(resp.NextCursor)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"00000008.00000009", "a2"}, values)
+
+	assert.Equal(t, []interface{}{"00000008.00000011", "a0"}, query["search_after"])
+	assert.NotContains(t, query, "from")
+	sorts := query["sort"].([]map[string]interface{})
+	assert.Contains(t, sorts[0], "_script")
+	assert.Equal(t, map[string]interface{}{"agent_id": map[string]string{"order": "desc"}}, sorts[1])
+
+	filter := query["query"].(map[string]interface{})["bool"].(map[string]interface{})["filter"].([]map[string]interface{})
+	require.Len(t, filter, 4)
+	assert.Equal(t, map[string]interface{}{"term": map[string]interface{}{"status": "online"}}, filter[0])
+	assert.Contains(t, filter[1], "nested")
+	// CIDR同时按IP匹配
+	search := filter[2]["bool"].(map[string]interface{})["should"].([]map[string]interface{})
+	require.Len(t, search, 2)
+	assert.Equal(t, map[string]interface{}{"term": map[string]interface{}{"ip": "10.0.0.0/24"}}, search[1])
+	assert.Equal(t, projectFilter("payments"), filter[3])
+}
+
+func TestAgentSearchQuery_EscapesWildcards(t *testing.T) {
+	query := agentSearchQuery("web*01")
+	should := query["bool"].(map[string]interface{})["should"].([]map[string]interface{})
+	require.Len(t, should, 1)
+	hostname := should[0]["wildcard"].(map[string]interface{})["hostname"].(map[string]interface{})
+	assert.Equal(t, `*web\*01*`, hostname["value"])
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// AgentTokenRepository Agent注册令牌仓库接口
+type AgentTokenRepository interface {
+	Save(ctx context.Context, token *models.AgentToken) error
+	GetByID(ctx context.Context, id string) (*models.AgentToken, error)
+	ListByAgent(ctx context.Context, agentID string) ([]*models.AgentToken, error)
+}
+
+// agentTokenRepository Agent注册令牌仓库实现
+type agentTokenRepository struct {
+	esClient elasticsearch.ClientInterface
+	logger   *logrus.Logger
+}
+
+// NewAgentTokenRepository 创建Agent注册令牌仓库
+func NewAgentTokenRepository(esClient elasticsearch.ClientInterface, logger *logrus.Logger) AgentTokenRepository {
+	return &agentTokenRepository{
+		esClient: esClient,
+		logger:   logger,
+	}
+}
+
+// Save 保存令牌（不存在则创建）
+func (r *agentTokenRepository) Save(ctx context.Context, token *models.AgentToken) error {
+	if err := r.esClient.Index(ctx, "logstash_agent_tokens", token.ID, token); err != nil {
+		return fmt.Errorf("保存Agent令牌失败: %w", err)
+	}
+	return nil
+}
+
+// GetByID 根据令牌ID获取令牌
+func (r *agentTokenRepository) GetByID(ctx context.Context, id string) (*models.AgentToken, error) {
+	var token models.AgentToken
+	if err := r.esClient.Get(ctx, "logstash_agent_tokens", id, &token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// ListByAgent 获取Agent的全部令牌（含已吊销），按签发时间倒序
+func (r *agentTokenRepository) ListByAgent(ctx context.Context, agentID string) ([]*models.AgentToken, error) {
+	query := map[string]interface{}{
+		"query": map[string]interface{}{
+			"term": map[string]interface{}{"agent_id": agentID},
+		},
+		"sort": []map[string]interface{}{
+			{"created_at": map[string]string{"order": "desc"}},
+		},
+		"size": 100, // 单个Agent的令牌数量有限
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Source models.AgentToken `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+
+	if err := r.esClient.Search(ctx, "logstash_agent_tokens", query, &result); err != nil {
+		return nil, fmt.Errorf("搜索Agent令牌失败: %w", err)
+	}
+
+	tokens := make([]*models.AgentToken, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		token := hit.Source
+		tokens = append(tokens, &token)
+	}
+
+	return tokens, nil
+}
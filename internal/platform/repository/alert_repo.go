@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// AlertRuleRepository 告警规则仓库接口
+type AlertRuleRepository interface {
+	Create(ctx context.Context, rule *models.AlertRule) error
+	Update(ctx context.Context, rule *models.AlertRule) error
+	Delete(ctx context.Context, id string) error
+	GetByID(ctx context.Context, id string) (*models.AlertRule, error)
+	List(ctx context.Context) ([]*models.AlertRule, error)
+}
+
+// alertRuleRepository 告警规则仓库实现
+type alertRuleRepository struct {
+	esClient elasticsearch.ClientInterface
+	logger   *logrus.Logger
+}
+
+// NewAlertRuleRepository 创建告警规则仓库
+func NewAlertRuleRepository(esClient elasticsearch.ClientInterface, logger *logrus.Logger) AlertRuleRepository {
+	return &alertRuleRepository{
+		esClient: esClient,
+		logger:   logger,
+	}
+}
+
+// Create 创建告警规则
+func (r *alertRuleRepository) Create(ctx context.Context, rule *models.AlertRule) error {
+	if rule.ID == "" {
+		rule.ID = uuid.New().String()
+	}
+
+	now := time.Now()
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+
+	if err := r.esClient.Index(ctx, "logstash_alert_rules", rule.ID, rule); err != nil {
+		return fmt.Errorf("创建告警规则失败: %w", err)
+	}
+
+	return nil
+}
+
+// Update 更新告警规则
+func (r *alertRuleRepository) Update(ctx context.Context, rule *models.AlertRule) error {
+	existing, err := r.GetByID(ctx, rule.ID)
+	if err != nil {
+		return fmt.Errorf("获取现有告警规则失败: %w", err)
+	}
+
+	rule.CreatedAt = existing.CreatedAt
+	rule.CreatedBy = existing.CreatedBy
+	rule.UpdatedAt = time.Now()
+
+	if err := r.esClient.Index(ctx, "logstash_alert_rules", rule.ID, rule); err != nil {
+		return fmt.Errorf("更新告警规则失败: %w", err)
+	}
+
+	return nil
+}
+
+// Delete 删除告警规则
+func (r *alertRuleRepository) Delete(ctx context.Context, id string) error {
+	if err := r.esClient.Delete(ctx, "logstash_alert_rules", id); err != nil {
+		return fmt.Errorf("删除告警规则失败: %w", err)
+	}
+	return nil
+}
+
+// GetByID 根据ID获取告警规则
+func (r *alertRuleRepository) GetByID(ctx context.Context, id string) (*models.AlertRule, error) {
+	var rule models.AlertRule
+	if err := r.esClient.Get(ctx, "logstash_alert_rules", id, &rule); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// List 获取全部告警规则
+func (r *alertRuleRepository) List(ctx context.Context) ([]*models.AlertRule, error) {
+	query := map[string]interface{}{
+		"sort": []map[string]interface{}{
+			{"name": map[string]string{"order": "asc"}},
+		},
+		"size": 1000, // 规则数量有限，一次性返回
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Source models.AlertRule `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+
+	if err := r.esClient.Search(ctx, "logstash_alert_rules", query, &result); err != nil {
+		return nil, fmt.Errorf("搜索告警规则失败: %w", err)
+	}
+
+	rules := make([]*models.AlertRule, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		rule := hit.Source
+		rules = append(rules, &rule)
+	}
+
+	return rules, nil
+}
+
+// AlertSilenceRepository 告警静默仓库接口
+type AlertSilenceRepository interface {
+	Create(ctx context.Context, silence *models.AlertSilence) error
+	Delete(ctx context.Context, id string) error
+	GetByID(ctx context.Context, id string) (*models.AlertSilence, error)
+	// ListActive 获取在指定时间尚未结束的静默，包括尚未开始的
+	ListActive(ctx context.Context, now time.Time) ([]*models.AlertSilence, error)
+}
+
+// alertSilenceRepository 告警静默仓库实现
+type alertSilenceRepository struct {
+	esClient elasticsearch.ClientInterface
+	logger   *logrus.Logger
+}
+
+// NewAlertSilenceRepository 创建告警静默仓库
+func NewAlertSilenceRepository(esClient elasticsearch.ClientInterface, logger *logrus.Logger) AlertSilenceRepository {
+	return &alertSilenceRepository{
+		esClient: esClient,
+		logger:   logger,
+	}
+}
+
+// Create 创建告警静默
+func (r *alertSilenceRepository) Create(ctx context.Context, silence *models.AlertSilence) error {
+	if silence.ID == "" {
+		silence.ID = uuid.New().String()
+	}
+	silence.CreatedAt = time.Now()
+
+	if err := r.esClient.Index(ctx, "logstash_alert_silences", silence.ID, silence); err != nil {
+		return fmt.Errorf("创建告警静默失败: %w", err)
+	}
+
+	return nil
+}
+
+// Delete 删除告警静默
+func (r *alertSilenceRepository) Delete(ctx context.Context, id string) error {
+	if err := r.esClient.Delete(ctx, "logstash_alert_silences", id); err != nil {
+		return fmt.Errorf("删除告警静默失败: %w", err)
+	}
+	return nil
+}
+
+// GetByID 根据ID获取告警静默
+func (r *alertSilenceRepository) GetByID(ctx context.Context, id string) (*models.AlertSilence, error) {
+	var silence models.AlertSilence
+	if err := r.esClient.Get(ctx, "logstash_alert_silences", id, &silence); err != nil {
+		return nil, err
+	}
+	return &silence, nil
+}
+
+// ListActive 获取尚未结束的静默，按开始时间排序
+func (r *alertSilenceRepository) ListActive(ctx context.Context, now time.Time) ([]*models.AlertSilence, error) {
+	query := map[string]interface{}{
+		"query": map[string]interface{}{
+			"range": map[string]interface{}{
+				"ends_at": map[string]interface{}{"gt": now.Format(time.RFC3339)},
+			},
+		},
+		"sort": []map[string]interface{}{
+			{"starts_at": map[string]string{"order": "asc"}},
+		},
+		"size": 1000,
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Source models.AlertSilence `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+
+	if err := r.esClient.Search(ctx, "logstash_alert_silences", query, &result); err != nil {
+		return nil, fmt.Errorf("搜索告警静默失败: %w", err)
+	}
+
+	silences := make([]*models.AlertSilence, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		silence := hit.Source
+		silences = append(silences, &silence)
+	}
+
+	return silences, nil
+}
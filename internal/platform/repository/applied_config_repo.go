@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// AppliedConfigRepository Agent已应用配置的映射仓库接口
+type AppliedConfigRepository interface {
+	Save(ctx context.Context, mapping *models.AppliedConfigMapping) error
+	Delete(ctx context.Context, agentID, configID string) error
+	ListByConfig(ctx context.Context, configID string) ([]*models.AppliedConfigMapping, error)
+	ListByAgent(ctx context.Context, agentID string) ([]*models.AppliedConfigMapping, error)
+}
+
+// appliedConfigRepository Agent已应用配置的映射仓库实现
+type appliedConfigRepository struct {
+	esClient elasticsearch.ClientInterface
+	logger   *logrus.Logger
+}
+
+// NewAppliedConfigRepository 创建Agent已应用配置的映射仓库
+func NewAppliedConfigRepository(esClient elasticsearch.ClientInterface, logger *logrus.Logger) AppliedConfigRepository {
+	return &appliedConfigRepository{
+		esClient: esClient,
+		logger:   logger,
+	}
+}
+
+// Save 保存映射，同一Agent和配置只保留最近一次应用的版本
+func (r *appliedConfigRepository) Save(ctx context.Context, mapping *models.AppliedConfigMapping) error {
+	mapping.UpdatedAt = time.Now()
+
+	id := mapping.AgentID + ":" + mapping.ConfigID
+	if err := r.esClient.Index(ctx, "logstash_applied_configs", id, mapping); err != nil {
+		return fmt.Errorf("保存已应用配置失败: %w", err)
+	}
+	return nil
+}
+
+// Delete 删除Agent上指定配置的映射
+func (r *appliedConfigRepository) Delete(ctx context.Context, agentID, configID string) error {
+	if err := r.esClient.Delete(ctx, "logstash_applied_configs", agentID+":"+configID); err != nil {
+		return fmt.Errorf("删除已应用配置失败: %w", err)
+	}
+	return nil
+}
+
+// ListByConfig 获取运行指定配置的全部Agent，按Agent ID排序
+func (r *appliedConfigRepository) ListByConfig(ctx context.Context, configID string) ([]*models.AppliedConfigMapping, error) {
+	return r.list(ctx, "config_id", configID, "agent_id")
+}
+
+// ListByAgent 获取Agent运行的全部配置，按配置ID排序
+func (r *appliedConfigRepository) ListByAgent(ctx context.Context, agentID string) ([]*models.AppliedConfigMapping, error) {
+	return r.list(ctx, "agent_id", agentID, "config_id")
+}
+
+// list 按字段精确匹配获取映射
+func (r *appliedConfigRepository) list(ctx context.Context, field, value, sortField string) ([]*models.AppliedConfigMapping, error) {
+	query := map[string]interface{}{
+		"query": map[string]interface{}{
+			"term": map[string]interface{}{field: value},
+		},
+		"sort": []map[string]interface{}{
+			{sortField: map[string]string{"order": "asc"}},
+		},
+		"size": 10000, // 单个配置的Agent数和单个Agent的配置数均有限，一次性返回
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Source models.AppliedConfigMapping `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+
+	if err := r.esClient.Search(ctx, "logstash_applied_configs", query, &result); err != nil {
+		return nil, fmt.Errorf("搜索已应用配置失败: %w", err)
+	}
+
+	mappings := make([]*models.AppliedConfigMapping, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		mapping := hit.Source
+		mappings = append(mappings, &mapping)
+	}
+
+	return mappings, nil
+}
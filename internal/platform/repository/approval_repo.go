@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// ApprovalRepository 配置审批记录仓库接口
+type ApprovalRepository interface {
+	Save(ctx context.Context, approval *models.ConfigApproval) error
+	ListByVersion(ctx context.Context, configID string, version int) ([]*models.ConfigApproval, error)
+}
+
+// approvalRepository 配置审批记录仓库实现
+type approvalRepository struct {
+	esClient elasticsearch.ClientInterface
+	logger   *logrus.Logger
+}
+
+// NewApprovalRepository 创建配置审批记录仓库
+func NewApprovalRepository(esClient elasticsearch.ClientInterface, logger *logrus.Logger) ApprovalRepository {
+	return &approvalRepository{
+		esClient: esClient,
+		logger:   logger,
+	}
+}
+
+// Save 保存审批记录，文档ID由配置、版本和审批人确定，重复审批覆盖之前的结论
+func (r *approvalRepository) Save(ctx context.Context, approval *models.ConfigApproval) error {
+	approval.ID = fmt.Sprintf("%s-v%d-%s", approval.ConfigID, approval.ConfigVersion, approval.Reviewer)
+	if err := r.esClient.Index(ctx, "logstash_config_approvals", approval.ID, approval); err != nil {
+		return fmt.Errorf("保存审批记录失败: %w", err)
+	}
+	return nil
+}
+
+// ListByVersion 获取配置某个版本的全部审批记录，按时间正序
+func (r *approvalRepository) ListByVersion(ctx context.Context, configID string, version int) ([]*models.ConfigApproval, error) {
+	query := map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must": []map[string]interface{}{
+					{"term": map[string]interface{}{"config_id": configID}},
+					{"term": map[string]interface{}{"config_version": version}},
+				},
+			},
+		},
+		"sort": []map[string]interface{}{
+			{"created_at": map[string]string{"order": "asc"}},
+		},
+		"size": 1000,
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Source models.ConfigApproval `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+
+	if err := r.esClient.Search(ctx, "logstash_config_approvals", query, &result); err != nil {
+		return nil, fmt.Errorf("搜索审批记录失败: %w", err)
+	}
+
+	approvals := make([]*models.ConfigApproval, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		approval := hit.Source
+		approvals = append(approvals, &approval)
+	}
+
+	return approvals, nil
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// AuditRepository 审计记录仓库接口，审计记录只追加不修改
+type AuditRepository interface {
+	Create(ctx context.Context, entry *models.AuditEntry) error
+	List(ctx context.Context, req *models.AuditListRequest) ([]*models.AuditEntry, int64, error)
+}
+
+// auditRepository 审计记录仓库实现
+type auditRepository struct {
+	esClient elasticsearch.ClientInterface
+	logger   *logrus.Logger
+}
+
+// NewAuditRepository 创建审计记录仓库
+func NewAuditRepository(esClient elasticsearch.ClientInterface, logger *logrus.Logger) AuditRepository {
+	return &auditRepository{
+		esClient: esClient,
+		logger:   logger,
+	}
+}
+
+// Create 写入审计记录
+func (r *auditRepository) Create(ctx context.Context, entry *models.AuditEntry) error {
+	if entry.ID == "" {
+		entry.ID = uuid.New().String()
+	}
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+
+	if err := r.esClient.Index(ctx, "logstash_audit_log", entry.ID, entry); err != nil {
+		return fmt.Errorf("保存审计记录失败: %w", err)
+	}
+	return nil
+}
+
+// List 按条件分页查询审计记录，按操作时间倒序
+func (r *auditRepository) List(ctx context.Context, req *models.AuditListRequest) ([]*models.AuditEntry, int64, error) {
+	query := map[string]interface{}{
+		"from": (req.Page - 1) * req.PageSize,
+		"size": req.PageSize,
+		"sort": []map[string]interface{}{
+			{"timestamp": map[string]string{"order": "desc"}},
+		},
+	}
+
+	var must []map[string]interface{}
+	terms := []struct {
+		field string
+		value string
+	}{
+		{"actor", req.User},
+		{"resource_type", req.ResourceType},
+		{"resource_id", req.ResourceID},
+		{"action", req.Action},
+	}
+	for _, term := range terms {
+		if term.value != "" {
+			must = append(must, map[string]interface{}{
+				"term": map[string]interface{}{term.field: term.value},
+			})
+		}
+	}
+	if !req.Since.IsZero() || !req.Until.IsZero() {
+		timestamp := map[string]interface{}{}
+		if !req.Since.IsZero() {
+			timestamp["gte"] = req.Since
+		}
+		if !req.Until.IsZero() {
+			timestamp["lt"] = req.Until
+		}
+		must = append(must, map[string]interface{}{
+			"range": map[string]interface{}{"timestamp": timestamp},
+		})
+	}
+	if len(must) > 0 {
+		query["query"] = map[string]interface{}{
+			"bool": map[string]interface{}{"must": must},
+		}
+	}
+
+	var result struct {
+		Hits struct {
+			Total struct {
+				Value int64 `json:"value"`
+			} `json:"total"`
+			Hits []struct {
+				Source models.AuditEntry `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+
+	if err := r.esClient.Search(ctx, "logstash_audit_log", query, &result); err != nil {
+		return nil, 0, fmt.Errorf("搜索审计记录失败: %w", err)
+	}
+
+	entries := make([]*models.AuditEntry, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		entry := hit.Source
+		entries = append(entries, &entry)
+	}
+
+	return entries, result.Hits.Total.Value, nil
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// configHistoryIndex 配置历史索引，启用生命周期管理后为同名别名
+const configHistoryIndex = "logstash_config_history"
+
+// maxHistoryConfigs 清理时一次读取的配置数上限
+const maxHistoryConfigs = 10000
+
+// ConfigHistoryRepository 配置历史的清理仓库接口，读写历史记录见 ConfigRepository
+type ConfigHistoryRepository interface {
+	// ConfigIDs 有历史记录的配置ID，包括已删除的配置
+	ConfigIDs(ctx context.Context) ([]string, error)
+	// ListRecords 配置的全部历史记录摘要，按修改时间倒序
+	ListRecords(ctx context.Context, configID string) ([]*models.ConfigHistoryRecord, error)
+	// Delete 删除历史记录
+	Delete(ctx context.Context, record *models.ConfigHistoryRecord) error
+}
+
+// configHistoryRepository 配置历史的清理仓库实现
+type configHistoryRepository struct {
+	esClient elasticsearch.ClientInterface
+	logger   *logrus.Logger
+}
+
+// NewConfigHistoryRepository 创建配置历史的清理仓库
+func NewConfigHistoryRepository(esClient elasticsearch.ClientInterface, logger *logrus.Logger) ConfigHistoryRepository {
+	return &configHistoryRepository{
+		esClient: esClient,
+		logger:   logger,
+	}
+}
+
+// ConfigIDs 按config_id聚合获取有历史记录的配置
+func (r *configHistoryRepository) ConfigIDs(ctx context.Context) ([]string, error) {
+	query := map[string]interface{}{
+		"size": 0,
+		"aggs": map[string]interface{}{
+			"configs": map[string]interface{}{
+				"terms": map[string]interface{}{
+					"field": "config_id",
+					"size":  maxHistoryConfigs,
+				},
+			},
+		},
+	}
+
+	var result struct {
+		Aggregations struct {
+			Configs struct {
+				Buckets []struct {
+					Key string `json:"key"`
+				} `json:"buckets"`
+			} `json:"configs"`
+		} `json:"aggregations"`
+	}
+
+	if err := r.esClient.Search(ctx, configHistoryIndex, query, &result); err != nil {
+		return nil, fmt.Errorf("聚合配置历史失败: %w", err)
+	}
+
+	ids := make([]string, 0, len(result.Aggregations.Configs.Buckets))
+	for _, bucket := range result.Aggregations.Configs.Buckets {
+		ids = append(ids, bucket.Key)
+	}
+	return ids, nil
+}
+
+// ListRecords 获取配置的历史记录摘要，记录所在的具体索引用于删除
+func (r *configHistoryRepository) ListRecords(ctx context.Context, configID string) ([]*models.ConfigHistoryRecord, error) {
+	query := map[string]interface{}{
+		"query": map[string]interface{}{
+			"term": map[string]interface{}{"config_id": configID},
+		},
+		"_source": []string{"id", "config_id", "version", "change_type", "modified_at"},
+		"sort": []map[string]interface{}{
+			{"modified_at": map[string]string{"order": "desc"}},
+		},
+		"size": maxHistoryConfigs,
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Index  string                     `json:"_index"`
+				ID     string                     `json:"_id"`
+				Source models.ConfigHistoryRecord `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+
+	if err := r.esClient.Search(ctx, configHistoryIndex, query, &result); err != nil {
+		return nil, fmt.Errorf("搜索配置历史失败: %w", err)
+	}
+
+	records := make([]*models.ConfigHistoryRecord, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		record := hit.Source
+		record.ID = hit.ID
+		record.Index = hit.Index
+		records = append(records, &record)
+	}
+	return records, nil
+}
+
+// Delete 从记录所在的索引删除，滚动出的索引不能经别名删除
+func (r *configHistoryRepository) Delete(ctx context.Context, record *models.ConfigHistoryRecord) error {
+	index := record.Index
+	if index == "" {
+		index = configHistoryIndex
+	}
+	if err := r.esClient.Delete(ctx, index, record.ID); err != nil {
+		return fmt.Errorf("删除配置历史 %s 失败: %w", record.ID, err)
+	}
+	return nil
+}
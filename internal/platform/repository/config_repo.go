@@ -2,7 +2,9 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,11 +17,17 @@ import (
 type ConfigRepository interface {
 	Create(ctx context.Context, config *models.Config) error
 	Update(ctx context.Context, config *models.Config) error
+	// UpdateTestStatus 记录样本测试结果，配置已不是测试时的版本时不更新并返回false
+	UpdateTestStatus(ctx context.Context, id string, version int, status models.TestStatus, testedAt time.Time) (bool, error)
 	Delete(ctx context.Context, id string) error
 	GetByID(ctx context.Context, id string) (*models.Config, error)
 	List(ctx context.Context, req *models.ConfigListRequest) (*models.ConfigListResponse, error)
+	Search(ctx context.Context, req *models.ConfigSearchRequest) (*models.ConfigSearchResponse, error)
+	// Stats 按类型、标签、测试状态和启用状态统计配置
+	Stats(ctx context.Context, req *models.ConfigStatsRequest) (*models.ConfigStats, error)
 	SaveHistory(ctx context.Context, history *models.ConfigHistory) error
 	GetHistory(ctx context.Context, configID string) ([]*models.ConfigHistory, error)
+	GetHistoryVersion(ctx context.Context, configID string, version int) (*models.ConfigHistory, error)
 }
 
 // configRepository 配置仓库实现
@@ -38,9 +46,11 @@ func NewConfigRepository(esClient elasticsearch.ClientInterface, logger *logrus.
 
 // Create 创建配置
 func (r *configRepository) Create(ctx context.Context, config *models.Config) error {
-	// 生成ID
+	// 生成ID，调用方指定的ID须能安全地用作Agent上的文件名
 	if config.ID == "" {
 		config.ID = uuid.New().String()
+	} else if err := models.ValidateConfigID(config.ID); err != nil {
+		return err
 	}
 
 	// 设置时间戳
@@ -67,6 +77,7 @@ func (r *configRepository) Create(ctx context.Context, config *models.Config) er
 		ModifiedBy: config.CreatedBy,
 		ModifiedAt: now,
 	}
+	snapshotMetadata(history, config)
 
 	if err := r.SaveHistory(ctx, history); err != nil {
 		r.logger.Errorf("保存配置历史失败: %v", err)
@@ -92,9 +103,11 @@ func (r *configRepository) Update(ctx context.Context, config *models.Config) er
 	// 如果内容变更，重置测试状态
 	if config.Content != existing.Content {
 		config.TestStatus = models.TestStatusUntested
+		config.TestedAt = nil
 	} else {
 		// 保留原有测试状态
 		config.TestStatus = existing.TestStatus
+		config.TestedAt = existing.TestedAt
 	}
 
 	// 更新文档
@@ -113,6 +126,7 @@ func (r *configRepository) Update(ctx context.Context, config *models.Config) er
 		ModifiedBy: config.UpdatedBy,
 		ModifiedAt: config.UpdatedAt,
 	}
+	snapshotMetadata(history, config)
 
 	if err := r.SaveHistory(ctx, history); err != nil {
 		r.logger.Errorf("保存配置历史失败: %v", err)
@@ -121,6 +135,29 @@ func (r *configRepository) Update(ctx context.Context, config *models.Config) er
 	return nil
 }
 
+// UpdateTestStatus 记录样本测试结果，不产生新版本
+// 按文档版本条件写入，避免覆盖测试期间的配置更新
+func (r *configRepository) UpdateTestStatus(ctx context.Context, id string, version int, status models.TestStatus, testedAt time.Time) (bool, error) {
+	var config models.Config
+	docVersion, err := r.esClient.GetVersioned(ctx, "logstash_configs", id, &config)
+	if err != nil {
+		return false, fmt.Errorf("获取配置失败: %w", err)
+	}
+	if config.Version != version {
+		return false, nil
+	}
+
+	config.TestStatus = status
+	config.TestedAt = &testedAt
+	if err := r.esClient.IndexIfVersion(ctx, "logstash_configs", id, &config, docVersion); err != nil {
+		if errors.Is(err, elasticsearch.ErrVersionConflict) {
+			return false, nil
+		}
+		return false, fmt.Errorf("更新配置测试状态失败: %w", err)
+	}
+	return true, nil
+}
+
 // Delete 删除配置
 func (r *configRepository) Delete(ctx context.Context, id string) error {
 	// 获取配置信息用于历史记录
@@ -145,6 +182,7 @@ func (r *configRepository) Delete(ctx context.Context, id string) error {
 		ModifiedBy: config.UpdatedBy,
 		ModifiedAt: time.Now(),
 	}
+	snapshotMetadata(history, config)
 
 	if err := r.SaveHistory(ctx, history); err != nil {
 		r.logger.Errorf("保存配置历史失败: %v", err)
@@ -164,37 +202,25 @@ func (r *configRepository) GetByID(ctx context.Context, id string) (*models.Conf
 
 // List 获取配置列表
 func (r *configRepository) List(ctx context.Context, req *models.ConfigListRequest) (*models.ConfigListResponse, error) {
-	// 构建查询
+	// 构建查询，按配置ID决出同值记录的先后，保证游标翻页不重复不遗漏
+	order := models.SortOrder(req.Sort, req.Order)
 	query := map[string]interface{}{
-		"from": (req.Page - 1) * req.PageSize,
 		"size": req.PageSize,
 		"sort": []map[string]interface{}{
-			{"updated_at": map[string]string{"order": "desc"}},
+			configSortField(req.Sort, order),
+			{"id": map[string]string{"order": order}},
 		},
 	}
-
-	// 构建过滤条件
-	var must []map[string]interface{}
-
-	if req.Type != "" {
-		must = append(must, map[string]interface{}{
-			"term": map[string]interface{}{"type": req.Type},
-		})
-	}
-
-	if req.Enabled != nil {
-		must = append(must, map[string]interface{}{
-			"term": map[string]interface{}{"enabled": *req.Enabled},
-		})
+	if len(req.After) > 0 {
+		query["search_after"] = req.After
+	} else {
+		query["from"] = (req.Page - 1) * req.PageSize
 	}
-
-	if len(req.Tags) > 0 {
-		must = append(must, map[string]interface{}{
-			"terms": map[string]interface{}{"tags": req.Tags},
-		})
+	if req.OmitContent {
+		query["_source"] = map[string]interface{}{"excludes": []string{"content"}}
 	}
 
-	if len(must) > 0 {
+	if must := configFilters(req); len(must) > 0 {
 		query["query"] = map[string]interface{}{
 			"bool": map[string]interface{}{
 				"must": must,
@@ -210,6 +236,7 @@ func (r *configRepository) List(ctx context.Context, req *models.ConfigListReque
 			} `json:"total"`
 			Hits []struct {
 				Source models.Config `json:"_source"`
+				Sort   []interface{} `json:"sort"`
 			} `json:"hits"`
 		} `json:"hits"`
 	}
@@ -230,10 +257,247 @@ func (r *configRepository) List(ctx context.Context, req *models.ConfigListReque
 		config := hit.Source
 		response.Items = append(response.Items, &config)
 	}
+	if hits := result.Hits.Hits; len(hits) > 0 && len(hits) == req.PageSize {
+		response.NextCursor = models.EncodeCursor(hits[len(hits)-1].Sort)
+	}
+
+	return response, nil
+}
+
+// configSortField 排序字段对应的ES排序条件
+// 名称使用 keyword 子字段，旧索引没有该子字段时按缺失处理而不是报错
+func configSortField(field, order string) map[string]interface{} {
+	switch field {
+	case models.SortName:
+		return map[string]interface{}{"name.keyword": map[string]string{"order": order, "unmapped_type": "keyword"}}
+	case models.SortVersion:
+		return map[string]interface{}{"version": map[string]string{"order": order}}
+	default:
+		return map[string]interface{}{"updated_at": map[string]string{"order": order}}
+	}
+}
+
+// configSearchFields 全文搜索的字段及权重，名称和标签的匹配比内容更相关
+var configSearchFields = []string{"name^3", "tags.text^2", "description^2", "content"}
+
+// Search 全文搜索配置，按相关度排序并返回匹配片段
+// 拼写相近的词按编辑距离模糊匹配，输入的最后一个词按前缀匹配以支持边输入边搜索
+func (r *configRepository) Search(ctx context.Context, req *models.ConfigSearchRequest) (*models.ConfigSearchResponse, error) {
+	query := map[string]interface{}{
+		"from": (req.Page - 1) * req.PageSize,
+		"size": req.PageSize,
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"should": []map[string]interface{}{
+					{"multi_match": map[string]interface{}{
+						"query":     req.Query,
+						"fields":    configSearchFields,
+						"fuzziness": "AUTO",
+					}},
+					{"multi_match": map[string]interface{}{
+						"query":  req.Query,
+						"fields": configSearchFields,
+						"type":   "phrase_prefix",
+					}},
+				},
+				"minimum_should_match": 1,
+				"filter":               configFilters(&req.ConfigListRequest),
+			},
+		},
+		// 配置内容可能很大，结果只返回匹配片段
+		"_source": map[string]interface{}{"excludes": []string{"content"}},
+		"highlight": map[string]interface{}{
+			"pre_tags":  []string{"<em>"},
+			"post_tags": []string{"</em>"},
+			"fields": map[string]interface{}{
+				"name":        map[string]interface{}{"number_of_fragments": 0},
+				"description": map[string]interface{}{},
+				"tags.text":   map[string]interface{}{"number_of_fragments": 0},
+				"content":     map[string]interface{}{"fragment_size": 150, "number_of_fragments": 3},
+			},
+		},
+	}
+
+	var result struct {
+		Hits struct {
+			Total struct {
+				Value int64 `json:"value"`
+			} `json:"total"`
+			Hits []struct {
+				Score     float64             `json:"_score"`
+				Source    models.Config       `json:"_source"`
+				Highlight map[string][]string `json:"highlight"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+
+	if err := r.esClient.Search(ctx, "logstash_configs", query, &result); err != nil {
+		return nil, fmt.Errorf("搜索配置失败: %w", err)
+	}
+
+	response := &models.ConfigSearchResponse{
+		Total: result.Hits.Total.Value,
+		Page:  req.Page,
+		Size:  req.PageSize,
+		Items: make([]*models.ConfigSearchHit, 0, len(result.Hits.Hits)),
+	}
+
+	for _, hit := range result.Hits.Hits {
+		config := hit.Source
+		item := &models.ConfigSearchHit{Config: &config, Score: hit.Score}
+		if len(hit.Highlight) > 0 {
+			item.Highlights = make(map[string][]string, len(hit.Highlight))
+			for field, fragments := range hit.Highlight {
+				// 子字段的片段按原字段名返回
+				item.Highlights[strings.TrimSuffix(field, ".text")] = fragments
+			}
+		}
+		response.Items = append(response.Items, item)
+	}
 
 	return response, nil
 }
 
+// configFilters 配置列表和搜索共用的过滤条件
+func configFilters(req *models.ConfigListRequest) []map[string]interface{} {
+	must := []map[string]interface{}{}
+
+	if req.Type != "" {
+		must = append(must, map[string]interface{}{
+			"term": map[string]interface{}{"type": req.Type},
+		})
+	}
+
+	if req.Enabled != nil {
+		must = append(must, map[string]interface{}{
+			"term": map[string]interface{}{"enabled": *req.Enabled},
+		})
+	}
+
+	if len(req.Tags) > 0 {
+		must = append(must, map[string]interface{}{
+			"terms": map[string]interface{}{"tags": req.Tags},
+		})
+	}
+
+	if req.ScheduledTest {
+		must = append(must, map[string]interface{}{
+			"term": map[string]interface{}{"scheduled_test": true},
+		})
+	}
+
+	if req.Project != "" {
+		must = append(must, projectFilter(req.Project))
+	}
+
+	// 只返回未设置访问控制或允许这些主体读取的配置，读取列表已包含编辑和部署主体
+	if len(req.Principals) > 0 {
+		must = append(must, map[string]interface{}{
+			"bool": map[string]interface{}{
+				"should": []map[string]interface{}{
+					{"bool": map[string]interface{}{
+						"must_not": map[string]interface{}{"exists": map[string]interface{}{"field": "acl.readers"}},
+					}},
+					{"terms": map[string]interface{}{"acl.readers": req.Principals}},
+				},
+				"minimum_should_match": 1,
+			},
+		})
+	}
+
+	return must
+}
+
+// Stats 以terms聚合按类型、标签、测试状态和启用状态统计配置，不返回文档
+// 项目和访问控制限定统计范围；类型、标签和启用状态的过滤条件只作用于其他维度的分桶
+func (r *configRepository) Stats(ctx context.Context, req *models.ConfigStatsRequest) (*models.ConfigStats, error) {
+	dimensions := []struct {
+		field   string
+		filters []map[string]interface{}
+	}{
+		{field: "type", filters: configFilters(&models.ConfigListRequest{Type: req.Type})},
+		{field: "tags", filters: configFilters(&models.ConfigListRequest{Tags: req.Tags})},
+		{field: "enabled", filters: configFilters(&models.ConfigListRequest{Enabled: req.Enabled})},
+	}
+	// except 除field外其余维度的过滤条件，field为空时为全部过滤条件
+	except := func(field string) map[string]interface{} {
+		filter := []map[string]interface{}{}
+		for _, d := range dimensions {
+			if d.field != field {
+				filter = append(filter, d.filters...)
+			}
+		}
+		return map[string]interface{}{"bool": map[string]interface{}{"filter": filter}}
+	}
+	facet := func(field string, terms map[string]interface{}) map[string]interface{} {
+		terms["field"] = field
+		return map[string]interface{}{
+			"filter": except(field),
+			"aggs":   map[string]interface{}{"buckets": map[string]interface{}{"terms": terms}},
+		}
+	}
+
+	query := map[string]interface{}{
+		"size": 0,
+		"aggs": map[string]interface{}{
+			"matched":    map[string]interface{}{"filter": except("")},
+			"by_type":    facet("type", map[string]interface{}{"size": overviewTermsSize}),
+			"by_tag":     facet("tags", map[string]interface{}{"size": req.TagSize}),
+			"by_enabled": facet("enabled", map[string]interface{}{"size": 2}),
+			"by_test_status": facet("test_status", map[string]interface{}{
+				"size":    overviewTermsSize,
+				"missing": models.TestStatusUntested,
+			}),
+		},
+	}
+	if scope := configFilters(&models.ConfigListRequest{Project: req.Project, Principals: req.Principals}); len(scope) > 0 {
+		query["query"] = map[string]interface{}{
+			"bool": map[string]interface{}{"filter": scope},
+		}
+	}
+
+	type facetResult struct {
+		Buckets termsBuckets `json:"buckets"`
+	}
+	var result struct {
+		Aggregations struct {
+			Matched struct {
+				DocCount int64 `json:"doc_count"`
+			} `json:"matched"`
+			ByType       facetResult `json:"by_type"`
+			ByTag        facetResult `json:"by_tag"`
+			ByTestStatus facetResult `json:"by_test_status"`
+			// 布尔字段的分桶键为1和0，key_as_string为true和false
+			ByEnabled struct {
+				Buckets struct {
+					Buckets []struct {
+						KeyAsString string `json:"key_as_string"`
+						DocCount    int64  `json:"doc_count"`
+					} `json:"buckets"`
+				} `json:"buckets"`
+			} `json:"by_enabled"`
+		} `json:"aggregations"`
+	}
+
+	if err := r.esClient.Search(ctx, "logstash_configs", query, &result); err != nil {
+		return nil, fmt.Errorf("统计配置失败: %w", err)
+	}
+
+	aggs := result.Aggregations
+	stats := &models.ConfigStats{
+		Total:        aggs.Matched.DocCount,
+		ByType:       aggs.ByType.Buckets.counts(),
+		ByTag:        aggs.ByTag.Buckets.counts(),
+		OtherTags:    aggs.ByTag.Buckets.SumOtherDocCount,
+		ByTestStatus: aggs.ByTestStatus.Buckets.counts(),
+		ByEnabled:    make(map[string]int64, 2),
+	}
+	for _, bucket := range aggs.ByEnabled.Buckets.Buckets {
+		stats.ByEnabled[bucket.KeyAsString] = bucket.DocCount
+	}
+	return stats, nil
+}
+
 // SaveHistory 保存历史记录
 func (r *configRepository) SaveHistory(ctx context.Context, history *models.ConfigHistory) error {
 	if history.ID == "" {
@@ -243,6 +507,54 @@ func (r *configRepository) SaveHistory(ctx context.Context, history *models.Conf
 	return r.esClient.Index(ctx, "logstash_config_history", history.ID, history)
 }
 
+// GetHistoryVersion 获取指定版本的配置历史，删除记录不计入
+func (r *configRepository) GetHistoryVersion(ctx context.Context, configID string, version int) (*models.ConfigHistory, error) {
+	query := map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"filter": []map[string]interface{}{
+					{"term": map[string]interface{}{"config_id": configID}},
+					{"term": map[string]interface{}{"version": version}},
+				},
+				"must_not": []map[string]interface{}{
+					{"term": map[string]interface{}{"change_type": "delete"}},
+				},
+			},
+		},
+		"sort": []map[string]interface{}{
+			{"modified_at": map[string]string{"order": "desc"}},
+		},
+		"size": 1,
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Source models.ConfigHistory `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+
+	if err := r.esClient.Search(ctx, "logstash_config_history", query, &result); err != nil {
+		return nil, fmt.Errorf("搜索配置历史失败: %w", err)
+	}
+	if len(result.Hits.Hits) == 0 {
+		return nil, elasticsearch.ErrNotFound
+	}
+
+	history := result.Hits.Hits[0].Source
+	return &history, nil
+}
+
+// snapshotMetadata 在历史记录中保存该版本的元数据，用于版本间比较
+func snapshotMetadata(history *models.ConfigHistory, config *models.Config) {
+	enabled := config.Enabled
+	history.Name = config.Name
+	history.Description = config.Description
+	history.Tags = config.Tags
+	history.Enabled = &enabled
+}
+
 // GetHistory 获取配置历史
 func (r *configRepository) GetHistory(ctx context.Context, configID string) ([]*models.ConfigHistory, error) {
 	query := map[string]interface{}{
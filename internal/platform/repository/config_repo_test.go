@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"testing"
 	"time"
@@ -9,6 +10,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"logstash-platform/internal/platform/models"
 	"logstash-platform/tests/mocks"
 )
@@ -474,6 +476,7 @@ func TestConfigRepository_List(t *testing.T) {
 						} `json:"total"`
 						Hits []struct {
 							Source models.Config `json:"_source"`
+							Sort   []interface{} `json:"sort"`
 						} `json:"hits"`
 					} `json:"hits"`
 				}{
@@ -483,6 +486,7 @@ func TestConfigRepository_List(t *testing.T) {
 						} `json:"total"`
 						Hits []struct {
 							Source models.Config `json:"_source"`
+							Sort   []interface{} `json:"sort"`
 						} `json:"hits"`
 					}{
 						Total: struct {
@@ -490,6 +494,7 @@ func TestConfigRepository_List(t *testing.T) {
 						}{Value: 2},
 						Hits: []struct {
 							Source models.Config `json:"_source"`
+							Sort   []interface{} `json:"sort"`
 						}{
 							{
 								Source: models.Config{
@@ -523,6 +528,7 @@ func TestConfigRepository_List(t *testing.T) {
 								} `json:"total"`
 								Hits []struct {
 									Source models.Config `json:"_source"`
+									Sort   []interface{} `json:"sort"`
 								} `json:"hits"`
 							} `json:"hits"`
 						})
@@ -553,6 +559,7 @@ func TestConfigRepository_List(t *testing.T) {
 						} `json:"total"`
 						Hits []struct {
 							Source models.Config `json:"_source"`
+							Sort   []interface{} `json:"sort"`
 						} `json:"hits"`
 					} `json:"hits"`
 				}{}
@@ -567,6 +574,7 @@ func TestConfigRepository_List(t *testing.T) {
 								} `json:"total"`
 								Hits []struct {
 									Source models.Config `json:"_source"`
+									Sort   []interface{} `json:"sort"`
 								} `json:"hits"`
 							} `json:"hits"`
 						})
@@ -837,4 +845,156 @@ func TestConfigRepository_GetHistory(t *testing.T) {
 			mockES.AssertExpectations(t)
 		})
 	}
-}
\ No newline at end of file
+}
+func TestConfigRepository_Search(t *testing.T) {
+	ctx := context.Background()
+	esClient := new(mocks.MockElasticsearchClient)
+
+	var query map[string]interface{}
+	esClient.On("Search", ctx, "logstash_configs", mock.Anything, mock.Anything).
+		Return(nil).
+		Run(func(args mock.Arguments) {
+			query = args.Get(2).(map[string]interface{})
+			data, _ := json.Marshal(map[string]interface{}{
+				"hits": map[string]interface{}{
+					"total": map[string]interface{}{"value": 1},
+					"hits": []map[string]interface{}{{
+						"_score":  2.5,
+						"_source": map[string]interface{}{"id": "config-1", "name": "nginx access"},
+						"highlight": map[string]interface{}{
+							"content":   []string{`grok { match => { "message" => "%{<em>NGINXACCESS</em>}" } }`},
+							"tags.text": []string{"<em>nginx</em>"},
+						},
+					}},
+				},
+			})
+			require.NoError(t, json.Unmarshal(data, args.Get(3)))
+		})
+
+	repo := NewConfigRepository(esClient, logrus.New())
+	resp, err := repo.Search(ctx, &models.ConfigSearchRequest{
+		Query:             "nginxacess",
+		ConfigListRequest: models.ConfigListRequest{Page: 2, PageSize: 5, Type: models.ConfigTypeFilter},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 5, query["from"])
+	boolQuery := query["query"].(map[string]interface{})["bool"].(map[string]interface{})
+	fuzzy := boolQuery["should"].([]map[string]interface{})[0]["multi_match"].(map[string]interface{})
+	assert.Equal(t, "AUTO", fuzzy["fuzziness"])
+	assert.Equal(t, []map[string]interface{}{{"term": map[string]interface{}{"type": models.ConfigTypeFilter}}}, boolQuery["filter"])
+
+	assert.EqualValues(t, 1, resp.Total)
+	require.Len(t, resp.Items, 1)
+	assert.Equal(t, "config-1", resp.Items[0].ID)
+	assert.Equal(t, 2.5, resp.Items[0].Score)
+	assert.Equal(t, []string{"<em>nginx</em>"}, resp.Items[0].Highlights["tags"])
+	assert.Contains(t, resp.Items[0].Highlights["content"][0], "<em>NGINXACCESS</em>")
+}
+
+func TestConfigRepository_ListCursor(t *testing.T) {
+	ctx := context.Background()
+	esClient := new(mocks.MockElasticsearchClient)
+
+	var query map[string]interface{}
+	esClient.On("Search", ctx, "logstash_configs", mock.Anything, mock.Anything).
+		Return(nil).
+		Run(func(args mock.Arguments) {
+			query = args.Get(2).(map[string]interface{})
+			data, _ := json.Marshal(map[string]interface{}{
+				"hits": map[string]interface{}{
+					"total": map[string]interface{}{"value": 10},
+					"hits": []map[string]interface{}{
+						{"_source": map[string]interface{}{"id": "config-3", "name": "c"}, "sort": []interface{}{"c", "config-3"}},
+						{"_source": map[string]interface{}{"id": "config-4", "name": "d"}, "sort": []interface{}{"d", "config-4"}},
+					},
+				},
+			})
+			require.NoError(t, json.Unmarshal(data, args.Get(3)))
+		})
+
+	repo := NewConfigRepository(esClient, logrus.New())
+	resp, err := repo.List(ctx, &models.ConfigListRequest{
+		Page:     3,
+		PageSize: 2,
+		Sort:     models.SortName,
+		After:    []interface{}{"b", "config-2"},
+	})
+	require.NoError(t, err)
+
+	// 游标翻页使用 search_after，不再使用 from
+	assert.Equal(t, []interface{}{"b", "config-2"}, query["search_after"])
+	assert.NotContains(t, query, "from")
+	assert.NotContains(t, query, "_source")
+	assert.Equal(t, []map[string]interface{}{
+		{"name.keyword": map[string]string{"order": "asc", "unmapped_type": "keyword"}},
+		{"id": map[string]string{"order": "asc"}},
+	}, query["sort"])
+
+	require.Len(t, resp.Items, 2)
+	after, err := models.DecodeCursor(resp.NextCursor)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"d", "config-4"}, after)
+
+	_, err = repo.List(ctx, &models.ConfigListRequest{Page: 1, PageSize: 2, OmitContent: true})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"excludes": []string{"content"}}, query["_source"], "导出目录时不读取配置内容")
+}
+
+func TestConfigRepository_Stats(t *testing.T) {
+	ctx := context.Background()
+	esClient := new(mocks.MockElasticsearchClient)
+
+	var query map[string]interface{}
+	esClient.On("Search", ctx, "logstash_configs", mock.Anything, mock.Anything).Return(nil).
+		Run(respondWith(t, &query, map[string]interface{}{
+			"aggregations": map[string]interface{}{
+				"matched": map[string]interface{}{"doc_count": 3},
+				"by_type": map[string]interface{}{"buckets": map[string]interface{}{"buckets": []map[string]interface{}{
+					{"key": "filter", "doc_count": 3},
+					{"key": "output", "doc_count": 2},
+				}}},
+				"by_tag": map[string]interface{}{"buckets": map[string]interface{}{"sum_other_doc_count": 4, "buckets": []map[string]interface{}{
+					{"key": "nginx", "doc_count": 3},
+				}}},
+				"by_test_status": map[string]interface{}{"buckets": map[string]interface{}{"buckets": []map[string]interface{}{
+					{"key": "untested", "doc_count": 2},
+					{"key": "passed", "doc_count": 1},
+				}}},
+				"by_enabled": map[string]interface{}{"buckets": map[string]interface{}{"buckets": []map[string]interface{}{
+					{"key": 1, "key_as_string": "true", "doc_count": 3},
+					{"key": 0, "key_as_string": "false", "doc_count": 1},
+				}}},
+			},
+		}))
+
+	repo := NewConfigRepository(esClient, logrus.New())
+	stats, err := repo.Stats(ctx, &models.ConfigStatsRequest{Type: models.ConfigTypeFilter, Tags: []string{"nginx"}, TagSize: 10, Project: "payments"})
+	require.NoError(t, err)
+
+	assert.Equal(t, &models.ConfigStats{
+		Total:        3,
+		ByType:       map[string]int64{"filter": 3, "output": 2},
+		ByTag:        map[string]int64{"nginx": 3},
+		OtherTags:    4,
+		ByTestStatus: map[string]int64{"untested": 2, "passed": 1},
+		ByEnabled:    map[string]int64{"true": 3, "false": 1},
+	}, stats)
+
+	// 只查询聚合，项目限定统计范围
+	assert.Equal(t, 0, query["size"])
+	scope := query["query"].(map[string]interface{})["bool"].(map[string]interface{})["filter"].([]map[string]interface{})
+	assert.Equal(t, []map[string]interface{}{projectFilter("payments")}, scope)
+
+	// 类型的分桶不应用类型过滤，其他维度应用
+	facetFilter := func(name string) []map[string]interface{} {
+		agg := query["aggs"].(map[string]interface{})[name].(map[string]interface{})
+		return agg["filter"].(map[string]interface{})["bool"].(map[string]interface{})["filter"].([]map[string]interface{})
+	}
+	typeFilter := map[string]interface{}{"term": map[string]interface{}{"type": models.ConfigTypeFilter}}
+	tagFilter := map[string]interface{}{"terms": map[string]interface{}{"tags": []string{"nginx"}}}
+	assert.Equal(t, []map[string]interface{}{tagFilter}, facetFilter("by_type"))
+	assert.Equal(t, []map[string]interface{}{typeFilter}, facetFilter("by_tag"))
+	assert.Equal(t, []map[string]interface{}{typeFilter, tagFilter}, facetFilter("by_test_status"))
+	assert.Equal(t, []map[string]interface{}{typeFilter, tagFilter}, facetFilter("matched"))
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// ConfigRevalidationRepository 配置重新校验结果仓库接口
+type ConfigRevalidationRepository interface {
+	Save(ctx context.Context, result *models.ConfigRevalidation) error
+	GetByID(ctx context.Context, configID string) (*models.ConfigRevalidation, error)
+	List(ctx context.Context, req *models.ConfigRevalidationListRequest) ([]*models.ConfigRevalidation, int64, error)
+}
+
+// configRevalidationRepository 配置重新校验结果仓库实现
+type configRevalidationRepository struct {
+	esClient elasticsearch.ClientInterface
+	logger   *logrus.Logger
+}
+
+// NewConfigRevalidationRepository 创建配置重新校验结果仓库
+func NewConfigRevalidationRepository(esClient elasticsearch.ClientInterface, logger *logrus.Logger) ConfigRevalidationRepository {
+	return &configRevalidationRepository{
+		esClient: esClient,
+		logger:   logger,
+	}
+}
+
+// Save 保存校验结果，每个配置只保留最近一次
+func (r *configRevalidationRepository) Save(ctx context.Context, result *models.ConfigRevalidation) error {
+	if err := r.esClient.Index(ctx, "logstash_config_revalidations", result.ConfigID, result); err != nil {
+		return fmt.Errorf("保存重新校验结果失败: %w", err)
+	}
+	return nil
+}
+
+// GetByID 获取配置最近一次校验结果
+func (r *configRevalidationRepository) GetByID(ctx context.Context, configID string) (*models.ConfigRevalidation, error) {
+	var result models.ConfigRevalidation
+	if err := r.esClient.Get(ctx, "logstash_config_revalidations", configID, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// List 获取校验结果列表，按校验时间倒序
+func (r *configRevalidationRepository) List(ctx context.Context, req *models.ConfigRevalidationListRequest) ([]*models.ConfigRevalidation, int64, error) {
+	query := map[string]interface{}{
+		"from": (req.Page - 1) * req.PageSize,
+		"size": req.PageSize,
+		"sort": []map[string]interface{}{
+			{"checked_at": map[string]string{"order": "desc"}},
+		},
+	}
+
+	if req.Failing {
+		query["query"] = map[string]interface{}{
+			"term": map[string]interface{}{"valid": false},
+		}
+	}
+
+	var result struct {
+		Hits struct {
+			Total struct {
+				Value int64 `json:"value"`
+			} `json:"total"`
+			Hits []struct {
+				Source models.ConfigRevalidation `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+
+	if err := r.esClient.Search(ctx, "logstash_config_revalidations", query, &result); err != nil {
+		return nil, 0, fmt.Errorf("搜索重新校验结果失败: %w", err)
+	}
+
+	items := make([]*models.ConfigRevalidation, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		item := hit.Source
+		items = append(items, &item)
+	}
+
+	return items, result.Hits.Total.Value, nil
+}
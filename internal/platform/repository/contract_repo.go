@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// ContractRepository 字段契约仓库接口
+type ContractRepository interface {
+	Create(ctx context.Context, contract *models.FieldContract) error
+	Update(ctx context.Context, contract *models.FieldContract) error
+	Delete(ctx context.Context, name string) error
+	GetByName(ctx context.Context, name string) (*models.FieldContract, error)
+	List(ctx context.Context) ([]*models.FieldContract, error)
+}
+
+// contractRepository 字段契约仓库实现
+type contractRepository struct {
+	esClient elasticsearch.ClientInterface
+	logger   *logrus.Logger
+}
+
+// NewContractRepository 创建字段契约仓库
+func NewContractRepository(esClient elasticsearch.ClientInterface, logger *logrus.Logger) ContractRepository {
+	return &contractRepository{
+		esClient: esClient,
+		logger:   logger,
+	}
+}
+
+// Create 创建字段契约
+func (r *contractRepository) Create(ctx context.Context, contract *models.FieldContract) error {
+	now := time.Now()
+	contract.CreatedAt = now
+	contract.UpdatedAt = now
+
+	if err := r.esClient.Index(ctx, "logstash_field_contracts", contract.Name, contract); err != nil {
+		return fmt.Errorf("创建字段契约失败: %w", err)
+	}
+
+	return nil
+}
+
+// Update 更新字段契约
+func (r *contractRepository) Update(ctx context.Context, contract *models.FieldContract) error {
+	existing, err := r.GetByName(ctx, contract.Name)
+	if err != nil {
+		return fmt.Errorf("获取现有字段契约失败: %w", err)
+	}
+
+	contract.CreatedAt = existing.CreatedAt
+	contract.CreatedBy = existing.CreatedBy
+	contract.UpdatedAt = time.Now()
+
+	if err := r.esClient.Index(ctx, "logstash_field_contracts", contract.Name, contract); err != nil {
+		return fmt.Errorf("更新字段契约失败: %w", err)
+	}
+
+	return nil
+}
+
+// Delete 删除字段契约
+func (r *contractRepository) Delete(ctx context.Context, name string) error {
+	if err := r.esClient.Delete(ctx, "logstash_field_contracts", name); err != nil {
+		return fmt.Errorf("删除字段契约失败: %w", err)
+	}
+	return nil
+}
+
+// GetByName 根据名称获取字段契约
+func (r *contractRepository) GetByName(ctx context.Context, name string) (*models.FieldContract, error) {
+	var contract models.FieldContract
+	if err := r.esClient.Get(ctx, "logstash_field_contracts", name, &contract); err != nil {
+		return nil, err
+	}
+	return &contract, nil
+}
+
+// List 获取全部字段契约
+func (r *contractRepository) List(ctx context.Context) ([]*models.FieldContract, error) {
+	query := map[string]interface{}{
+		"sort": []map[string]interface{}{
+			{"name": map[string]string{"order": "asc"}},
+		},
+		"size": 1000, // 契约数量有限，一次性返回
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Source models.FieldContract `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+
+	if err := r.esClient.Search(ctx, "logstash_field_contracts", query, &result); err != nil {
+		return nil, fmt.Errorf("搜索字段契约失败: %w", err)
+	}
+
+	contracts := make([]*models.FieldContract, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		contract := hit.Source
+		contracts = append(contracts, &contract)
+	}
+
+	return contracts, nil
+}
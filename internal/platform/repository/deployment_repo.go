@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// DeploymentRepository 部署记录仓库接口
+type DeploymentRepository interface {
+	Create(ctx context.Context, deployment *models.Deployment) error
+	Update(ctx context.Context, deployment *models.Deployment) error
+	GetByID(ctx context.Context, id string) (*models.Deployment, error)
+	List(ctx context.Context, req *models.DeploymentListRequest) ([]*models.Deployment, int64, error)
+}
+
+// deploymentRepository 部署记录仓库实现
+type deploymentRepository struct {
+	esClient elasticsearch.ClientInterface
+	logger   *logrus.Logger
+}
+
+// NewDeploymentRepository 创建部署记录仓库
+func NewDeploymentRepository(esClient elasticsearch.ClientInterface, logger *logrus.Logger) DeploymentRepository {
+	return &deploymentRepository{
+		esClient: esClient,
+		logger:   logger,
+	}
+}
+
+// Create 创建部署记录
+func (r *deploymentRepository) Create(ctx context.Context, deployment *models.Deployment) error {
+	if deployment.ID == "" {
+		deployment.ID = uuid.New().String()
+	}
+	deployment.CreatedAt = time.Now()
+
+	if err := r.esClient.Index(ctx, "logstash_deployments", deployment.ID, deployment); err != nil {
+		return fmt.Errorf("创建部署记录失败: %w", err)
+	}
+
+	return nil
+}
+
+// Update 更新部署记录
+func (r *deploymentRepository) Update(ctx context.Context, deployment *models.Deployment) error {
+	if err := r.esClient.Index(ctx, "logstash_deployments", deployment.ID, deployment); err != nil {
+		return fmt.Errorf("更新部署记录失败: %w", err)
+	}
+	return nil
+}
+
+// GetByID 根据ID获取部署记录
+func (r *deploymentRepository) GetByID(ctx context.Context, id string) (*models.Deployment, error) {
+	var deployment models.Deployment
+	if err := r.esClient.Get(ctx, "logstash_deployments", id, &deployment); err != nil {
+		return nil, err
+	}
+	return &deployment, nil
+}
+
+// List 获取部署记录列表，按创建时间倒序
+func (r *deploymentRepository) List(ctx context.Context, req *models.DeploymentListRequest) ([]*models.Deployment, int64, error) {
+	query := map[string]interface{}{
+		"from": (req.Page - 1) * req.PageSize,
+		"size": req.PageSize,
+		"sort": []map[string]interface{}{
+			{"created_at": map[string]string{"order": "desc"}},
+		},
+	}
+
+	var must []map[string]interface{}
+	if req.ConfigID != "" {
+		must = append(must, map[string]interface{}{
+			"term": map[string]interface{}{"config_id": req.ConfigID},
+		})
+	}
+	if req.AgentID != "" {
+		must = append(must, map[string]interface{}{
+			"term": map[string]interface{}{"agent_ids": req.AgentID},
+		})
+	}
+	if req.Status != "" {
+		must = append(must, map[string]interface{}{
+			"term": map[string]interface{}{"status": req.Status},
+		})
+	}
+	if req.Team != "" {
+		must = append(must, map[string]interface{}{
+			"term": map[string]interface{}{"team": req.Team},
+		})
+	}
+	if req.Project != "" {
+		must = append(must, projectFilter(req.Project))
+	}
+	if !req.Since.IsZero() || !req.Until.IsZero() {
+		createdAt := map[string]interface{}{}
+		if !req.Since.IsZero() {
+			createdAt["gte"] = req.Since
+		}
+		if !req.Until.IsZero() {
+			createdAt["lt"] = req.Until
+		}
+		must = append(must, map[string]interface{}{
+			"range": map[string]interface{}{"created_at": createdAt},
+		})
+	}
+	if len(must) > 0 {
+		query["query"] = map[string]interface{}{
+			"bool": map[string]interface{}{"must": must},
+		}
+	}
+
+	var result struct {
+		Hits struct {
+			Total struct {
+				Value int64 `json:"value"`
+			} `json:"total"`
+			Hits []struct {
+				Source models.Deployment `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+
+	if err := r.esClient.Search(ctx, "logstash_deployments", query, &result); err != nil {
+		return nil, 0, fmt.Errorf("搜索部署记录失败: %w", err)
+	}
+
+	deployments := make([]*models.Deployment, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		deployment := hit.Source
+		deployments = append(deployments, &deployment)
+	}
+
+	return deployments, result.Hits.Total.Value, nil
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// DestinationRepository 下游集群仓库接口
+type DestinationRepository interface {
+	Create(ctx context.Context, destination *models.Destination) error
+	Update(ctx context.Context, destination *models.Destination) error
+	Delete(ctx context.Context, name string) error
+	GetByName(ctx context.Context, name string) (*models.Destination, error)
+	List(ctx context.Context) ([]*models.Destination, error)
+}
+
+// destinationRepository 下游集群仓库实现
+type destinationRepository struct {
+	esClient elasticsearch.ClientInterface
+	logger   *logrus.Logger
+}
+
+// NewDestinationRepository 创建下游集群仓库
+func NewDestinationRepository(esClient elasticsearch.ClientInterface, logger *logrus.Logger) DestinationRepository {
+	return &destinationRepository{
+		esClient: esClient,
+		logger:   logger,
+	}
+}
+
+// Create 创建下游集群
+func (r *destinationRepository) Create(ctx context.Context, destination *models.Destination) error {
+	now := time.Now()
+	destination.CreatedAt = now
+	destination.UpdatedAt = now
+
+	if err := r.esClient.Index(ctx, "logstash_destinations", destination.Name, destination); err != nil {
+		return fmt.Errorf("创建下游集群失败: %w", err)
+	}
+
+	return nil
+}
+
+// Update 更新下游集群
+func (r *destinationRepository) Update(ctx context.Context, destination *models.Destination) error {
+	existing, err := r.GetByName(ctx, destination.Name)
+	if err != nil {
+		return fmt.Errorf("获取现有下游集群失败: %w", err)
+	}
+
+	destination.CreatedAt = existing.CreatedAt
+	destination.CreatedBy = existing.CreatedBy
+	destination.UpdatedAt = time.Now()
+
+	if err := r.esClient.Index(ctx, "logstash_destinations", destination.Name, destination); err != nil {
+		return fmt.Errorf("更新下游集群失败: %w", err)
+	}
+
+	return nil
+}
+
+// Delete 删除下游集群
+func (r *destinationRepository) Delete(ctx context.Context, name string) error {
+	if err := r.esClient.Delete(ctx, "logstash_destinations", name); err != nil {
+		return fmt.Errorf("删除下游集群失败: %w", err)
+	}
+	return nil
+}
+
+// GetByName 根据名称获取下游集群
+func (r *destinationRepository) GetByName(ctx context.Context, name string) (*models.Destination, error) {
+	var destination models.Destination
+	if err := r.esClient.Get(ctx, "logstash_destinations", name, &destination); err != nil {
+		return nil, err
+	}
+	return &destination, nil
+}
+
+// List 获取全部下游集群
+func (r *destinationRepository) List(ctx context.Context) ([]*models.Destination, error) {
+	query := map[string]interface{}{
+		"sort": []map[string]interface{}{
+			{"name": map[string]string{"order": "asc"}},
+		},
+		"size": 1000, // 下游集群数量有限，一次性返回
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Source models.Destination `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+
+	if err := r.esClient.Search(ctx, "logstash_destinations", query, &result); err != nil {
+		return nil, fmt.Errorf("搜索下游集群失败: %w", err)
+	}
+
+	destinations := make([]*models.Destination, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		destination := hit.Source
+		destinations = append(destinations, &destination)
+	}
+
+	return destinations, nil
+}
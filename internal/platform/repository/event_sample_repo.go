@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// EventSampleRepository 从平台Elasticsearch的业务索引中抽取文档，作为测试样本
+type EventSampleRepository interface {
+	// Sample 抽取index中匹配query的最多size个文档的_source，query为nil时匹配全部文档
+	// random为true时随机抽取，否则按@timestamp倒序抽取
+	Sample(ctx context.Context, index string, query map[string]interface{}, size int, random bool) ([]json.RawMessage, error)
+}
+
+// eventSampleRepository 文档抽样仓库实现
+type eventSampleRepository struct {
+	esClient elasticsearch.ClientInterface
+	logger   *logrus.Logger
+}
+
+// NewEventSampleRepository 创建文档抽样仓库
+func NewEventSampleRepository(esClient elasticsearch.ClientInterface, logger *logrus.Logger) EventSampleRepository {
+	return &eventSampleRepository{
+		esClient: esClient,
+		logger:   logger,
+	}
+}
+
+// Sample 抽取文档
+func (r *eventSampleRepository) Sample(ctx context.Context, index string, query map[string]interface{}, size int, random bool) ([]json.RawMessage, error) {
+	if query == nil {
+		query = map[string]interface{}{"match_all": map[string]interface{}{}}
+	}
+	search := map[string]interface{}{
+		"size":             size,
+		"track_total_hits": false,
+	}
+	if random {
+		search["query"] = map[string]interface{}{
+			"function_score": map[string]interface{}{
+				"query":        query,
+				"random_score": map[string]interface{}{},
+				"boost_mode":   "replace",
+			},
+		}
+	} else {
+		search["query"] = query
+		search["sort"] = []map[string]interface{}{
+			{"@timestamp": map[string]interface{}{"order": "desc", "unmapped_type": "date"}},
+		}
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Source json.RawMessage `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := r.esClient.Search(ctx, index, search, &result); err != nil {
+		return nil, fmt.Errorf("抽取索引 %s 的文档失败: %w", index, err)
+	}
+
+	docs := make([]json.RawMessage, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		docs = append(docs, hit.Source)
+	}
+	return docs, nil
+}
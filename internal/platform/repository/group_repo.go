@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// GroupRepository Agent分组仓库接口
+type GroupRepository interface {
+	Create(ctx context.Context, group *models.AgentGroup) error
+	Update(ctx context.Context, group *models.AgentGroup) error
+	Delete(ctx context.Context, name string) error
+	GetByName(ctx context.Context, name string) (*models.AgentGroup, error)
+	List(ctx context.Context) ([]*models.AgentGroup, error)
+}
+
+// groupRepository Agent分组仓库实现
+type groupRepository struct {
+	esClient elasticsearch.ClientInterface
+	logger   *logrus.Logger
+}
+
+// NewGroupRepository 创建Agent分组仓库
+func NewGroupRepository(esClient elasticsearch.ClientInterface, logger *logrus.Logger) GroupRepository {
+	return &groupRepository{
+		esClient: esClient,
+		logger:   logger,
+	}
+}
+
+// Create 创建分组
+func (r *groupRepository) Create(ctx context.Context, group *models.AgentGroup) error {
+	now := time.Now()
+	group.CreatedAt = now
+	group.UpdatedAt = now
+
+	if err := r.esClient.Index(ctx, "logstash_agent_groups", group.Name, group); err != nil {
+		return fmt.Errorf("创建分组失败: %w", err)
+	}
+
+	return nil
+}
+
+// Update 更新分组
+func (r *groupRepository) Update(ctx context.Context, group *models.AgentGroup) error {
+	existing, err := r.GetByName(ctx, group.Name)
+	if err != nil {
+		return fmt.Errorf("获取现有分组失败: %w", err)
+	}
+
+	group.CreatedAt = existing.CreatedAt
+	group.CreatedBy = existing.CreatedBy
+	group.UpdatedAt = time.Now()
+
+	if err := r.esClient.Index(ctx, "logstash_agent_groups", group.Name, group); err != nil {
+		return fmt.Errorf("更新分组失败: %w", err)
+	}
+
+	return nil
+}
+
+// Delete 删除分组
+func (r *groupRepository) Delete(ctx context.Context, name string) error {
+	if err := r.esClient.Delete(ctx, "logstash_agent_groups", name); err != nil {
+		return fmt.Errorf("删除分组失败: %w", err)
+	}
+	return nil
+}
+
+// GetByName 根据名称获取分组
+func (r *groupRepository) GetByName(ctx context.Context, name string) (*models.AgentGroup, error) {
+	var group models.AgentGroup
+	if err := r.esClient.Get(ctx, "logstash_agent_groups", name, &group); err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// List 获取全部分组
+func (r *groupRepository) List(ctx context.Context) ([]*models.AgentGroup, error) {
+	query := map[string]interface{}{
+		"sort": []map[string]interface{}{
+			{"name": map[string]string{"order": "asc"}},
+		},
+		"size": 1000, // 分组数量有限，一次性返回
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Source models.AgentGroup `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+
+	if err := r.esClient.Search(ctx, "logstash_agent_groups", query, &result); err != nil {
+		return nil, fmt.Errorf("搜索分组失败: %w", err)
+	}
+
+	groups := make([]*models.AgentGroup, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		group := hit.Source
+		groups = append(groups, &group)
+	}
+
+	return groups, nil
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// IncidentRepository 事件仓库接口
+type IncidentRepository interface {
+	Save(ctx context.Context, incident *models.Incident) error
+	GetByID(ctx context.Context, id string) (*models.Incident, error)
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context, req *models.IncidentListRequest) ([]*models.Incident, int64, error)
+}
+
+// incidentRepository 事件仓库实现
+type incidentRepository struct {
+	esClient elasticsearch.ClientInterface
+	logger   *logrus.Logger
+}
+
+// NewIncidentRepository 创建事件仓库
+func NewIncidentRepository(esClient elasticsearch.ClientInterface, logger *logrus.Logger) IncidentRepository {
+	return &incidentRepository{
+		esClient: esClient,
+		logger:   logger,
+	}
+}
+
+// Save 保存事件（不存在则创建）
+func (r *incidentRepository) Save(ctx context.Context, incident *models.Incident) error {
+	if err := r.esClient.Index(ctx, "logstash_incidents", incident.ID, incident); err != nil {
+		return fmt.Errorf("保存事件失败: %w", err)
+	}
+	return nil
+}
+
+// GetByID 根据ID获取事件
+func (r *incidentRepository) GetByID(ctx context.Context, id string) (*models.Incident, error) {
+	var incident models.Incident
+	if err := r.esClient.Get(ctx, "logstash_incidents", id, &incident); err != nil {
+		return nil, err
+	}
+	return &incident, nil
+}
+
+// Delete 删除事件
+func (r *incidentRepository) Delete(ctx context.Context, id string) error {
+	if err := r.esClient.Delete(ctx, "logstash_incidents", id); err != nil {
+		return fmt.Errorf("删除事件失败: %w", err)
+	}
+	return nil
+}
+
+// List 获取事件列表，按最近出现时间倒序
+func (r *incidentRepository) List(ctx context.Context, req *models.IncidentListRequest) ([]*models.Incident, int64, error) {
+	query := map[string]interface{}{
+		"from": (req.Page - 1) * req.PageSize,
+		"size": req.PageSize,
+		"sort": []map[string]interface{}{
+			{"last_seen": map[string]string{"order": "desc"}},
+		},
+	}
+
+	if req.Status != "" {
+		query["query"] = map[string]interface{}{
+			"term": map[string]interface{}{"status": req.Status},
+		}
+	}
+
+	return r.search(ctx, query)
+}
+
+// search 执行事件搜索
+func (r *incidentRepository) search(ctx context.Context, query map[string]interface{}) ([]*models.Incident, int64, error) {
+	var result struct {
+		Hits struct {
+			Total struct {
+				Value int64 `json:"value"`
+			} `json:"total"`
+			Hits []struct {
+				Source models.Incident `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+
+	if err := r.esClient.Search(ctx, "logstash_incidents", query, &result); err != nil {
+		return nil, 0, fmt.Errorf("搜索事件失败: %w", err)
+	}
+
+	incidents := make([]*models.Incident, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		incident := hit.Source
+		incidents = append(incidents, &incident)
+	}
+
+	return incidents, result.Hits.Total.Value, nil
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// LeaseRepository 领导者租约仓库接口
+type LeaseRepository interface {
+	// Get 获取租约及其文档版本，租约不存在时均返回nil
+	Get(ctx context.Context, name string) (*models.Lease, *elasticsearch.DocVersion, error)
+	// Put 仅当租约仍为读取时的版本时写入，version为nil时仅在租约不存在时创建
+	// 其他副本先写入时返回 elasticsearch.ErrVersionConflict
+	Put(ctx context.Context, lease *models.Lease, version *elasticsearch.DocVersion) error
+}
+
+// leaseRepository 领导者租约仓库实现
+type leaseRepository struct {
+	esClient elasticsearch.ClientInterface
+	logger   *logrus.Logger
+}
+
+// NewLeaseRepository 创建领导者租约仓库
+func NewLeaseRepository(esClient elasticsearch.ClientInterface, logger *logrus.Logger) LeaseRepository {
+	return &leaseRepository{
+		esClient: esClient,
+		logger:   logger,
+	}
+}
+
+// Get 获取租约及其文档版本
+func (r *leaseRepository) Get(ctx context.Context, name string) (*models.Lease, *elasticsearch.DocVersion, error) {
+	var lease models.Lease
+	version, err := r.esClient.GetVersioned(ctx, "logstash_leases", name, &lease)
+	if err != nil {
+		if err.Error() == "文档不存在" {
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("获取租约失败: %w", err)
+	}
+	return &lease, version, nil
+}
+
+// Put 按版本条件写入租约
+func (r *leaseRepository) Put(ctx context.Context, lease *models.Lease, version *elasticsearch.DocVersion) error {
+	if err := r.esClient.IndexIfVersion(ctx, "logstash_leases", lease.Name, lease, version); err != nil {
+		return fmt.Errorf("写入租约失败: %w", err)
+	}
+	return nil
+}
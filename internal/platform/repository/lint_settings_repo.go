@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// LintSettingsRepository 项目静态检查规则开关仓库接口
+type LintSettingsRepository interface {
+	// Get 获取项目的规则开关，项目未设置过时返回nil
+	Get(ctx context.Context, project string) (*models.LintSettings, error)
+	Save(ctx context.Context, settings *models.LintSettings) error
+}
+
+// lintSettingsRepository 项目静态检查规则开关仓库实现
+type lintSettingsRepository struct {
+	esClient elasticsearch.ClientInterface
+	logger   *logrus.Logger
+}
+
+// NewLintSettingsRepository 创建项目静态检查规则开关仓库
+func NewLintSettingsRepository(esClient elasticsearch.ClientInterface, logger *logrus.Logger) LintSettingsRepository {
+	return &lintSettingsRepository{
+		esClient: esClient,
+		logger:   logger,
+	}
+}
+
+// Get 以项目名称为文档ID获取规则开关
+func (r *lintSettingsRepository) Get(ctx context.Context, project string) (*models.LintSettings, error) {
+	var settings models.LintSettings
+	if err := r.esClient.Get(ctx, "logstash_lint_settings", project, &settings); err != nil {
+		if errors.Is(err, elasticsearch.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("获取静态检查规则设置失败: %w", err)
+	}
+	return &settings, nil
+}
+
+// Save 保存项目的规则开关
+func (r *lintSettingsRepository) Save(ctx context.Context, settings *models.LintSettings) error {
+	if err := r.esClient.Index(ctx, "logstash_lint_settings", settings.Project, settings); err != nil {
+		return fmt.Errorf("保存静态检查规则设置失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,265 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// 指标时序索引按天滚动，查询时匹配全部日期；
+// 启用索引生命周期管理时写入滚动别名，滚动出的索引同样以该前缀命名
+const (
+	metricsIndexPrefix  = "logstash_metrics-"
+	metricsIndexPattern = metricsIndexPrefix + "*"
+)
+
+// MetricsRepository Agent指标时序仓库接口
+type MetricsRepository interface {
+	Save(ctx context.Context, sample *models.MetricsSample) error
+	// Histogram 按固定间隔聚合Agent在[from, to)内的指标均值
+	Histogram(ctx context.Context, agentID string, from, to time.Time, interval time.Duration) ([]models.MetricsPoint, error)
+	// Averages 按Agent计算指标在[from, to)内的均值，没有上报的Agent不出现在结果中
+	Averages(ctx context.Context, agentIDs []string, metric string, from, to time.Time) (map[string]float64, error)
+}
+
+// metricsRepository Agent指标时序仓库实现
+type metricsRepository struct {
+	esClient elasticsearch.ClientInterface
+	logger   *logrus.Logger
+
+	mu       sync.Mutex
+	indices  map[string]bool // 已确认存在的日期索引
+	rollover *bool           // 是否存在滚动别名，首次写入时检查
+}
+
+// NewMetricsRepository 创建Agent指标时序仓库
+func NewMetricsRepository(esClient elasticsearch.ClientInterface, logger *logrus.Logger) MetricsRepository {
+	return &metricsRepository{
+		esClient: esClient,
+		logger:   logger,
+		indices:  make(map[string]bool),
+	}
+}
+
+// MetricsIndex 指标采集时间所在的日期索引，按UTC日期划分
+func MetricsIndex(t time.Time) string {
+	return metricsIndexPrefix + t.UTC().Format("2006.01.02")
+}
+
+// Save 写入一条指标，当天的索引不存在时先按映射创建
+func (r *metricsRepository) Save(ctx context.Context, sample *models.MetricsSample) error {
+	index, err := r.writeIndex(ctx, sample.Timestamp)
+	if err != nil {
+		return err
+	}
+
+	// 同一Agent同一时刻的重复上报覆盖为一条
+	id := fmt.Sprintf("%s-%d", sample.AgentID, sample.Timestamp.UnixMilli())
+	if err := r.esClient.Index(ctx, index, id, sample); err != nil {
+		return fmt.Errorf("保存Agent指标失败: %w", err)
+	}
+	return nil
+}
+
+// writeIndex 指标写入的索引：存在滚动别名时写入别名，由ILM负责滚动和删除；否则写入采集日期的索引
+func (r *metricsRepository) writeIndex(ctx context.Context, t time.Time) (string, error) {
+	r.mu.Lock()
+	if r.rollover == nil {
+		exists, err := r.esClient.IndexExists(ctx, elasticsearch.MetricsAlias)
+		if err != nil {
+			r.mu.Unlock()
+			return "", fmt.Errorf("检查索引 %s 是否存在失败: %w", elasticsearch.MetricsAlias, err)
+		}
+		r.rollover = &exists
+	}
+	rollover := *r.rollover
+	r.mu.Unlock()
+
+	if rollover {
+		return elasticsearch.MetricsAlias, nil
+	}
+	index := MetricsIndex(t)
+	return index, r.ensureIndex(ctx, index)
+}
+
+// ensureIndex 创建日期索引，其他平台实例同时创建时以索引已存在为准
+func (r *metricsRepository) ensureIndex(ctx context.Context, index string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.indices[index] {
+		return nil
+	}
+
+	exists, err := r.esClient.IndexExists(ctx, index)
+	if err != nil {
+		return fmt.Errorf("检查索引 %s 是否存在失败: %w", index, err)
+	}
+	if !exists {
+		if err := r.esClient.CreateIndex(ctx, index, elasticsearch.MetricsIndexMapping); err != nil {
+			if exists, _ = r.esClient.IndexExists(ctx, index); !exists {
+				return fmt.Errorf("创建索引 %s 失败: %w", index, err)
+			}
+		} else {
+			r.logger.Infof("创建索引: %s", index)
+		}
+	}
+
+	r.indices[index] = true
+	return nil
+}
+
+// metricsAverages 每个时间桶内求均值的字段，键为聚合名，与告警规则可用的指标名一致
+var metricsAverages = map[string]string{
+	"cpu_usage":             "cpu_usage",
+	"memory_usage":          "memory_usage",
+	"disk_usage":            "disk_usage",
+	"events_in_rate":        "logstash.events_in_rate",
+	"events_out_rate":       "logstash.events_out_rate",
+	"jvm_heap_used_percent": "logstash.jvm_heap_used_percent",
+}
+
+// Histogram 按固定间隔聚合指标，范围内没有上报的时间桶也会返回
+func (r *metricsRepository) Histogram(ctx context.Context, agentID string, from, to time.Time, interval time.Duration) ([]models.MetricsPoint, error) {
+	averages := make(map[string]interface{}, len(metricsAverages))
+	for name, field := range metricsAverages {
+		averages[name] = map[string]interface{}{"avg": map[string]interface{}{"field": field}}
+	}
+
+	query := map[string]interface{}{
+		"size": 0,
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"filter": []map[string]interface{}{
+					{"term": map[string]interface{}{"agent_id": agentID}},
+					{"range": map[string]interface{}{"timestamp": map[string]interface{}{
+						"gte": from.UTC().Format(time.RFC3339),
+						"lt":  to.UTC().Format(time.RFC3339),
+					}}},
+				},
+			},
+		},
+		"aggs": map[string]interface{}{
+			"series": map[string]interface{}{
+				"date_histogram": map[string]interface{}{
+					"field":          "timestamp",
+					"fixed_interval": fmt.Sprintf("%ds", int64(interval/time.Second)),
+					"min_doc_count":  0,
+					"extended_bounds": map[string]interface{}{
+						"min": from.UnixMilli(),
+						"max": to.Add(-time.Millisecond).UnixMilli(),
+					},
+				},
+				"aggs": averages,
+			},
+		},
+	}
+
+	var result struct {
+		Aggregations struct {
+			Series struct {
+				Buckets []map[string]interface{} `json:"buckets"`
+			} `json:"series"`
+		} `json:"aggregations"`
+	}
+
+	if err := r.esClient.Search(ctx, metricsIndexPattern, query, &result); err != nil {
+		return nil, fmt.Errorf("查询Agent指标失败: %w", err)
+	}
+
+	points := make([]models.MetricsPoint, 0, len(result.Aggregations.Series.Buckets))
+	for _, bucket := range result.Aggregations.Series.Buckets {
+		key, _ := bucket["key"].(float64)
+		count, _ := bucket["doc_count"].(float64)
+		point := models.MetricsPoint{
+			Timestamp: time.UnixMilli(int64(key)).UTC(),
+			Samples:   int64(count),
+		}
+		point.CPUUsage = bucketAverage(bucket, "cpu_usage")
+		point.MemoryUsage = bucketAverage(bucket, "memory_usage")
+		point.DiskUsage = bucketAverage(bucket, "disk_usage")
+		point.EventsInRate = bucketAverage(bucket, "events_in_rate")
+		point.EventsOutRate = bucketAverage(bucket, "events_out_rate")
+		point.JVMHeapUsedPercent = bucketAverage(bucket, "jvm_heap_used_percent")
+		points = append(points, point)
+	}
+
+	return points, nil
+}
+
+// bucketAverage 读取时间桶内的均值聚合，桶内没有数据时ES返回null
+func bucketAverage(bucket map[string]interface{}, name string) *float64 {
+	agg, ok := bucket[name].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	value, ok := agg["value"].(float64)
+	if !ok {
+		return nil
+	}
+	return &value
+}
+
+// Averages 按Agent分组计算指标均值
+func (r *metricsRepository) Averages(ctx context.Context, agentIDs []string, metric string, from, to time.Time) (map[string]float64, error) {
+	field, ok := metricsAverages[metric]
+	if !ok {
+		return nil, fmt.Errorf("不支持的指标: %s", metric)
+	}
+	averages := make(map[string]float64)
+	if len(agentIDs) == 0 {
+		return averages, nil
+	}
+
+	query := map[string]interface{}{
+		"size": 0,
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"filter": []map[string]interface{}{
+					{"terms": map[string]interface{}{"agent_id": agentIDs}},
+					{"range": map[string]interface{}{"timestamp": map[string]interface{}{
+						"gte": from.UTC().Format(time.RFC3339),
+						"lt":  to.UTC().Format(time.RFC3339),
+					}}},
+				},
+			},
+		},
+		"aggs": map[string]interface{}{
+			"agents": map[string]interface{}{
+				"terms": map[string]interface{}{"field": "agent_id", "size": len(agentIDs)},
+				"aggs": map[string]interface{}{
+					"value": map[string]interface{}{"avg": map[string]interface{}{"field": field}},
+				},
+			},
+		},
+	}
+
+	var result struct {
+		Aggregations struct {
+			Agents struct {
+				Buckets []struct {
+					Key   string `json:"key"`
+					Value struct {
+						Value *float64 `json:"value"`
+					} `json:"value"`
+				} `json:"buckets"`
+			} `json:"agents"`
+		} `json:"aggregations"`
+	}
+
+	if err := r.esClient.Search(ctx, metricsIndexPattern, query, &result); err != nil {
+		return nil, fmt.Errorf("查询Agent指标均值失败: %w", err)
+	}
+
+	for _, bucket := range result.Aggregations.Agents.Buckets {
+		// 指标缺失（如Logstash监控API不可用）时均值为null
+		if bucket.Value.Value != nil {
+			averages[bucket.Key] = *bucket.Value.Value
+		}
+	}
+	return averages, nil
+}
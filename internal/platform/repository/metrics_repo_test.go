@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/elasticsearch"
+	"logstash-platform/tests/mocks"
+)
+
+func TestMetricsRepository_SaveCreatesDailyIndex(t *testing.T) {
+	ctx := context.Background()
+	esClient := new(mocks.MockElasticsearchClient)
+	esClient.On("IndexExists", ctx, elasticsearch.MetricsAlias).Return(false, nil).Once()
+	esClient.On("IndexExists", ctx, "logstash_metrics-2026.03.01").Return(false, nil).Once()
+	esClient.On("CreateIndex", ctx, "logstash_metrics-2026.03.01", elasticsearch.MetricsIndexMapping).Return(nil).Once()
+	esClient.On("Index", ctx, "logstash_metrics-2026.03.01", mock.Anything, mock.Anything).Return(nil)
+
+	repo := NewMetricsRepository(esClient, logrus.New())
+	at := time.Date(2026, 3, 1, 23, 59, 0, 0, time.UTC)
+	for i := 0; i < 2; i++ {
+		sample := &models.MetricsSample{AgentID: "agent-1", AgentMetrics: models.AgentMetrics{Timestamp: at.Add(time.Duration(i) * time.Second), CPUUsage: 12}}
+		require.NoError(t, repo.Save(ctx, sample))
+	}
+
+	// 滚动别名和当天的索引都只检查一次
+	esClient.AssertNumberOfCalls(t, "IndexExists", 2)
+	esClient.AssertNumberOfCalls(t, "Index", 2)
+	esClient.AssertCalled(t, "Index", ctx, "logstash_metrics-2026.03.01", "agent-1-1772409540000", mock.Anything)
+}
+
+func TestMetricsRepository_SaveUsesRolloverAlias(t *testing.T) {
+	ctx := context.Background()
+	esClient := new(mocks.MockElasticsearchClient)
+	esClient.On("IndexExists", ctx, elasticsearch.MetricsAlias).Return(true, nil).Once()
+	esClient.On("Index", ctx, elasticsearch.MetricsAlias, mock.Anything, mock.Anything).Return(nil)
+
+	repo := NewMetricsRepository(esClient, logrus.New())
+	sample := &models.MetricsSample{AgentID: "agent-1", AgentMetrics: models.AgentMetrics{Timestamp: time.Now(), CPUUsage: 12}}
+	require.NoError(t, repo.Save(ctx, sample))
+	require.NoError(t, repo.Save(ctx, sample))
+
+	// 存在滚动别名时不再创建日期索引
+	esClient.AssertNumberOfCalls(t, "IndexExists", 1)
+	esClient.AssertNotCalled(t, "CreateIndex", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestMetricsRepository_Histogram(t *testing.T) {
+	ctx := context.Background()
+	esClient := new(mocks.MockElasticsearchClient)
+	from := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	to := from.Add(2 * time.Minute)
+
+	var query map[string]interface{}
+	esClient.On("Search", ctx, "logstash_metrics-*", mock.Anything, mock.Anything).
+		Return(nil).
+		Run(func(args mock.Arguments) {
+			query = args.Get(2).(map[string]interface{})
+			data, _ := json.Marshal(map[string]interface{}{
+				"aggregations": map[string]interface{}{
+					"series": map[string]interface{}{
+						"buckets": []map[string]interface{}{
+							{"key": from.UnixMilli(), "doc_count": 2, "cpu_usage": map[string]interface{}{"value": 40.5}, "events_out_rate": map[string]interface{}{"value": 120}},
+							{"key": from.Add(time.Minute).UnixMilli(), "doc_count": 0, "cpu_usage": map[string]interface{}{"value": nil}},
+						},
+					},
+				},
+			})
+			require.NoError(t, json.Unmarshal(data, args.Get(3)))
+		})
+
+	repo := NewMetricsRepository(esClient, logrus.New())
+	points, err := repo.Histogram(ctx, "agent-1", from, to, time.Minute)
+	require.NoError(t, err)
+
+	histogram := query["aggs"].(map[string]interface{})["series"].(map[string]interface{})["date_histogram"].(map[string]interface{})
+	assert.Equal(t, "60s", histogram["fixed_interval"])
+
+	require.Len(t, points, 2)
+	assert.Equal(t, from, points[0].Timestamp)
+	assert.EqualValues(t, 2, points[0].Samples)
+	require.NotNil(t, points[0].CPUUsage)
+	assert.Equal(t, 40.5, *points[0].CPUUsage)
+	require.NotNil(t, points[0].EventsOutRate)
+	assert.Equal(t, 120.0, *points[0].EventsOutRate)
+	assert.Nil(t, points[0].JVMHeapUsedPercent)
+	// 没有上报的时间桶各值为空
+	assert.Nil(t, points[1].CPUUsage)
+}
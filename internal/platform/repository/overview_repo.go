@@ -0,0 +1,252 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// overviewTermsSize 状态、类型等枚举字段的分桶数上限
+const overviewTermsSize = 50
+
+// OverviewRepository 仪表盘总览仓库接口，每类资源只查询一次，数量由ES聚合计算
+type OverviewRepository interface {
+	// Agents 按状态统计Agent，并返回最近一次上报CPU使用率最高的top个未离线Agent
+	Agents(ctx context.Context, project string, top int) (*models.AgentOverview, []models.AgentCPUUsage, error)
+	// Configs 按类型和测试状态统计配置
+	Configs(ctx context.Context, project string) (*models.ConfigOverview, error)
+	// Deployments 按状态统计since之后创建的部署，并返回最近的limit个失败或已回滚的部署
+	Deployments(ctx context.Context, project string, since time.Time, limit int) (*models.DeploymentOverview, []models.DeploymentFailure, error)
+}
+
+// overviewRepository 仪表盘总览仓库实现
+type overviewRepository struct {
+	esClient elasticsearch.ClientInterface
+	logger   *logrus.Logger
+}
+
+// NewOverviewRepository 创建仪表盘总览仓库
+func NewOverviewRepository(esClient elasticsearch.ClientInterface, logger *logrus.Logger) OverviewRepository {
+	return &overviewRepository{
+		esClient: esClient,
+		logger:   logger,
+	}
+}
+
+// termsBuckets terms聚合的分桶结果
+type termsBuckets struct {
+	Buckets []struct {
+		Key      string `json:"key"`
+		DocCount int64  `json:"doc_count"`
+	} `json:"buckets"`
+	SumOtherDocCount int64 `json:"sum_other_doc_count"` // 未返回的分桶的文档数之和
+}
+
+// counts 分桶结果转换为键到文档数的映射
+func (t termsBuckets) counts() map[string]int64 {
+	counts := make(map[string]int64, len(t.Buckets))
+	for _, bucket := range t.Buckets {
+		counts[bucket.Key] = bucket.DocCount
+	}
+	return counts
+}
+
+// overviewQuery 统计查询，project为空时不按项目过滤
+func overviewQuery(project string, aggs map[string]interface{}) map[string]interface{} {
+	query := map[string]interface{}{
+		"size":             0,
+		"track_total_hits": true,
+		"aggs":             aggs,
+	}
+	if project != "" {
+		query["query"] = map[string]interface{}{
+			"bool": map[string]interface{}{
+				"filter": []map[string]interface{}{projectFilter(project)},
+			},
+		}
+	}
+	return query
+}
+
+// Agents 按状态统计Agent，CPU使用率取Agent文档中最近一次上报的指标
+func (r *overviewRepository) Agents(ctx context.Context, project string, top int) (*models.AgentOverview, []models.AgentCPUUsage, error) {
+	query := overviewQuery(project, map[string]interface{}{
+		"by_status": map[string]interface{}{
+			"terms": map[string]interface{}{"field": "status", "size": overviewTermsSize},
+		},
+		// 离线Agent的指标已过时，不参与排序
+		"reporting": map[string]interface{}{
+			"filter": map[string]interface{}{
+				"bool": map[string]interface{}{
+					"filter":   []map[string]interface{}{{"exists": map[string]interface{}{"field": "metrics.cpu_usage"}}},
+					"must_not": []map[string]interface{}{{"term": map[string]interface{}{"status": models.AgentStatusOffline}}},
+				},
+			},
+			"aggs": map[string]interface{}{
+				"top_cpu": map[string]interface{}{
+					"top_hits": map[string]interface{}{
+						"size":    top,
+						"sort":    []map[string]interface{}{{"metrics.cpu_usage": map[string]interface{}{"order": "desc", "unmapped_type": "float"}}},
+						"_source": []string{"agent_id", "hostname", "status", "metrics.cpu_usage", "metrics.timestamp"},
+					},
+				},
+			},
+		},
+	})
+
+	var result struct {
+		Hits struct {
+			Total struct {
+				Value int64 `json:"value"`
+			} `json:"total"`
+		} `json:"hits"`
+		Aggregations struct {
+			ByStatus  termsBuckets `json:"by_status"`
+			Reporting struct {
+				TopCPU struct {
+					Hits struct {
+						Hits []struct {
+							Source struct {
+								AgentID  string `json:"agent_id"`
+								Hostname string `json:"hostname"`
+								Status   string `json:"status"`
+								Metrics  struct {
+									CPUUsage  float64   `json:"cpu_usage"`
+									Timestamp time.Time `json:"timestamp"`
+								} `json:"metrics"`
+							} `json:"_source"`
+						} `json:"hits"`
+					} `json:"hits"`
+				} `json:"top_cpu"`
+			} `json:"reporting"`
+		} `json:"aggregations"`
+	}
+
+	if err := r.esClient.Search(ctx, "logstash_agents", query, &result); err != nil {
+		return nil, nil, fmt.Errorf("统计Agent失败: %w", err)
+	}
+
+	overview := &models.AgentOverview{
+		Total:    result.Hits.Total.Value,
+		ByStatus: result.Aggregations.ByStatus.counts(),
+	}
+	usages := make([]models.AgentCPUUsage, 0, len(result.Aggregations.Reporting.TopCPU.Hits.Hits))
+	for _, hit := range result.Aggregations.Reporting.TopCPU.Hits.Hits {
+		usages = append(usages, models.AgentCPUUsage{
+			AgentID:    hit.Source.AgentID,
+			Hostname:   hit.Source.Hostname,
+			Status:     hit.Source.Status,
+			CPUUsage:   hit.Source.Metrics.CPUUsage,
+			ReportedAt: hit.Source.Metrics.Timestamp,
+		})
+	}
+	return overview, usages, nil
+}
+
+// Configs 按类型和测试状态统计配置
+func (r *overviewRepository) Configs(ctx context.Context, project string) (*models.ConfigOverview, error) {
+	query := overviewQuery(project, map[string]interface{}{
+		"by_type": map[string]interface{}{
+			"terms": map[string]interface{}{"field": "type", "size": overviewTermsSize},
+		},
+		"by_test_status": map[string]interface{}{
+			"terms": map[string]interface{}{"field": "test_status", "size": overviewTermsSize},
+		},
+		"enabled": map[string]interface{}{
+			"filter": map[string]interface{}{"term": map[string]interface{}{"enabled": true}},
+		},
+	})
+
+	var result struct {
+		Hits struct {
+			Total struct {
+				Value int64 `json:"value"`
+			} `json:"total"`
+		} `json:"hits"`
+		Aggregations struct {
+			ByType       termsBuckets `json:"by_type"`
+			ByTestStatus termsBuckets `json:"by_test_status"`
+			Enabled      struct {
+				DocCount int64 `json:"doc_count"`
+			} `json:"enabled"`
+		} `json:"aggregations"`
+	}
+
+	if err := r.esClient.Search(ctx, "logstash_configs", query, &result); err != nil {
+		return nil, fmt.Errorf("统计配置失败: %w", err)
+	}
+
+	return &models.ConfigOverview{
+		Total:        result.Hits.Total.Value,
+		Enabled:      result.Aggregations.Enabled.DocCount,
+		ByType:       result.Aggregations.ByType.counts(),
+		ByTestStatus: result.Aggregations.ByTestStatus.counts(),
+	}, nil
+}
+
+// Deployments 按状态统计统计窗口内的部署，最近的失败不限于统计窗口
+func (r *overviewRepository) Deployments(ctx context.Context, project string, since time.Time, limit int) (*models.DeploymentOverview, []models.DeploymentFailure, error) {
+	query := overviewQuery(project, map[string]interface{}{
+		"window": map[string]interface{}{
+			"filter": map[string]interface{}{
+				"range": map[string]interface{}{"created_at": map[string]interface{}{"gte": since.UTC().Format(time.RFC3339)}},
+			},
+			"aggs": map[string]interface{}{
+				"by_status": map[string]interface{}{
+					"terms": map[string]interface{}{"field": "status", "size": overviewTermsSize},
+				},
+			},
+		},
+		"failures": map[string]interface{}{
+			"filter": map[string]interface{}{
+				"terms": map[string]interface{}{"status": []models.DeploymentStatus{models.DeploymentStatusFailed, models.DeploymentStatusRolledBack}},
+			},
+			"aggs": map[string]interface{}{
+				"recent": map[string]interface{}{
+					"top_hits": map[string]interface{}{
+						"size":    limit,
+						"sort":    []map[string]interface{}{{"created_at": map[string]interface{}{"order": "desc"}}},
+						"_source": []string{"id", "config_id", "config_name", "config_version", "status", "created_by", "created_at", "completed_at"},
+					},
+				},
+			},
+		},
+	})
+
+	var result struct {
+		Aggregations struct {
+			Window struct {
+				DocCount int64        `json:"doc_count"`
+				ByStatus termsBuckets `json:"by_status"`
+			} `json:"window"`
+			Failures struct {
+				Recent struct {
+					Hits struct {
+						Hits []struct {
+							Source models.DeploymentFailure `json:"_source"`
+						} `json:"hits"`
+					} `json:"hits"`
+				} `json:"recent"`
+			} `json:"failures"`
+		} `json:"aggregations"`
+	}
+
+	if err := r.esClient.Search(ctx, "logstash_deployments", query, &result); err != nil {
+		return nil, nil, fmt.Errorf("统计部署记录失败: %w", err)
+	}
+
+	overview := &models.DeploymentOverview{
+		Since:    since,
+		Total:    result.Aggregations.Window.DocCount,
+		ByStatus: result.Aggregations.Window.ByStatus.counts(),
+	}
+	failures := make([]models.DeploymentFailure, 0, len(result.Aggregations.Failures.Recent.Hits.Hits))
+	for _, hit := range result.Aggregations.Failures.Recent.Hits.Hits {
+		failures = append(failures, hit.Source)
+	}
+	return overview, failures, nil
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/tests/mocks"
+)
+
+// respondWith 将响应按JSON写入Search的结果参数，并记录查询
+func respondWith(t *testing.T, query *map[string]interface{}, response map[string]interface{}) func(mock.Arguments) {
+	return func(args mock.Arguments) {
+		*query = args.Get(2).(map[string]interface{})
+		data, err := json.Marshal(response)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(data, args.Get(3)))
+	}
+}
+
+func TestOverviewRepository_Agents(t *testing.T) {
+	ctx := context.Background()
+	reportedAt := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	esClient := new(mocks.MockElasticsearchClient)
+
+	var query map[string]interface{}
+	esClient.On("Search", ctx, "logstash_agents", mock.Anything, mock.Anything).Return(nil).
+		Run(respondWith(t, &query, map[string]interface{}{
+			"hits": map[string]interface{}{"total": map[string]interface{}{"value": 3}},
+			"aggregations": map[string]interface{}{
+				"by_status": map[string]interface{}{"buckets": []map[string]interface{}{
+					{"key": "online", "doc_count": 2},
+					{"key": "offline", "doc_count": 1},
+				}},
+				"reporting": map[string]interface{}{
+					"top_cpu": map[string]interface{}{"hits": map[string]interface{}{"hits": []map[string]interface{}{
+						{"_source": map[string]interface{}{"agent_id": "agent-2", "hostname": "host-2", "status": "online",
+							"metrics": map[string]interface{}{"cpu_usage": 87.5, "timestamp": reportedAt}}},
+					}}},
+				},
+			},
+		}))
+
+	repo := NewOverviewRepository(esClient, logrus.New())
+	overview, top, err := repo.Agents(ctx, "payments", 5)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(3), overview.Total)
+	assert.Equal(t, map[string]int64{"online": 2, "offline": 1}, overview.ByStatus)
+	require.Len(t, top, 1)
+	assert.Equal(t, models.AgentCPUUsage{AgentID: "agent-2", Hostname: "host-2", Status: "online", CPUUsage: 87.5, ReportedAt: reportedAt}, top[0])
+
+	// 按项目过滤，只查询聚合不返回文档
+	assert.Equal(t, 0, query["size"])
+	filters := query["query"].(map[string]interface{})["bool"].(map[string]interface{})["filter"].([]map[string]interface{})
+	assert.Equal(t, []map[string]interface{}{projectFilter("payments")}, filters)
+}
+
+func TestOverviewRepository_Deployments(t *testing.T) {
+	ctx := context.Background()
+	since := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	esClient := new(mocks.MockElasticsearchClient)
+
+	var query map[string]interface{}
+	esClient.On("Search", ctx, "logstash_deployments", mock.Anything, mock.Anything).Return(nil).
+		Run(respondWith(t, &query, map[string]interface{}{
+			"aggregations": map[string]interface{}{
+				"window": map[string]interface{}{
+					"doc_count": 4,
+					"by_status": map[string]interface{}{"buckets": []map[string]interface{}{
+						{"key": "completed", "doc_count": 3},
+						{"key": "failed", "doc_count": 1},
+					}},
+				},
+				"failures": map[string]interface{}{
+					"recent": map[string]interface{}{"hits": map[string]interface{}{"hits": []map[string]interface{}{
+						{"_source": map[string]interface{}{"id": "d9", "config_id": "cfg-1", "config_name": "nginx", "config_version": 3,
+							"status": "failed", "created_by": "alice", "created_at": since}},
+					}}},
+				},
+			},
+		}))
+
+	repo := NewOverviewRepository(esClient, logrus.New())
+	overview, failures, err := repo.Deployments(ctx, "", since, 10)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(4), overview.Total)
+	assert.Equal(t, map[string]int64{"completed": 3, "failed": 1}, overview.ByStatus)
+	require.Len(t, failures, 1)
+	assert.Equal(t, "d9", failures[0].ID)
+	assert.Equal(t, models.DeploymentStatusFailed, failures[0].Status)
+
+	// 未指定项目时不过滤
+	assert.NotContains(t, query, "query")
+}
@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// PipelineRepository 流水线仓库接口
+type PipelineRepository interface {
+	Create(ctx context.Context, pipeline *models.Pipeline) error
+	Update(ctx context.Context, pipeline *models.Pipeline) error
+	Delete(ctx context.Context, id string) error
+	GetByID(ctx context.Context, id string) (*models.Pipeline, error)
+	GetByName(ctx context.Context, name string) (*models.Pipeline, error)
+	List(ctx context.Context) ([]*models.Pipeline, error)
+	GetVersions(ctx context.Context, pipelineID string) ([]*models.PipelineVersion, error)
+}
+
+// pipelineRepository 流水线仓库实现
+type pipelineRepository struct {
+	esClient elasticsearch.ClientInterface
+	logger   *logrus.Logger
+}
+
+// NewPipelineRepository 创建流水线仓库
+func NewPipelineRepository(esClient elasticsearch.ClientInterface, logger *logrus.Logger) PipelineRepository {
+	return &pipelineRepository{
+		esClient: esClient,
+		logger:   logger,
+	}
+}
+
+// Create 创建流水线，同时保存第一个版本
+func (r *pipelineRepository) Create(ctx context.Context, pipeline *models.Pipeline) error {
+	if pipeline.ID == "" {
+		pipeline.ID = uuid.New().String()
+	}
+
+	now := time.Now()
+	pipeline.CreatedAt = now
+	pipeline.UpdatedAt = now
+	pipeline.Version = 1
+
+	if err := r.esClient.Index(ctx, "logstash_pipelines", pipeline.ID, pipeline); err != nil {
+		return fmt.Errorf("创建流水线失败: %w", err)
+	}
+
+	r.saveVersion(ctx, pipeline, pipeline.CreatedBy)
+	return nil
+}
+
+// Update 更新流水线，版本号加一并保存新版本
+func (r *pipelineRepository) Update(ctx context.Context, pipeline *models.Pipeline) error {
+	existing, err := r.GetByID(ctx, pipeline.ID)
+	if err != nil {
+		return fmt.Errorf("获取现有流水线失败: %w", err)
+	}
+
+	pipeline.Version = existing.Version + 1
+	pipeline.UpdatedAt = time.Now()
+	pipeline.CreatedAt = existing.CreatedAt
+	pipeline.CreatedBy = existing.CreatedBy
+
+	if err := r.esClient.Index(ctx, "logstash_pipelines", pipeline.ID, pipeline); err != nil {
+		return fmt.Errorf("更新流水线失败: %w", err)
+	}
+
+	r.saveVersion(ctx, pipeline, pipeline.UpdatedBy)
+	return nil
+}
+
+// saveVersion 保存流水线版本，失败只记录日志
+func (r *pipelineRepository) saveVersion(ctx context.Context, pipeline *models.Pipeline, user string) {
+	version := &models.PipelineVersion{
+		PipelineID: pipeline.ID,
+		Version:    pipeline.Version,
+		ConfigIDs:  pipeline.ConfigIDs,
+		Components: pipeline.Components,
+		Content:    pipeline.Content,
+		ModifiedBy: user,
+		ModifiedAt: pipeline.UpdatedAt,
+	}
+
+	docID := fmt.Sprintf("%s-v%d", pipeline.ID, pipeline.Version)
+	if err := r.esClient.Index(ctx, "logstash_pipeline_versions", docID, version); err != nil {
+		r.logger.Errorf("保存流水线版本失败: %v", err)
+	}
+}
+
+// Delete 删除流水线，历史版本保留用于审计
+func (r *pipelineRepository) Delete(ctx context.Context, id string) error {
+	if err := r.esClient.Delete(ctx, "logstash_pipelines", id); err != nil {
+		return fmt.Errorf("删除流水线失败: %w", err)
+	}
+	return nil
+}
+
+// GetByID 根据ID获取流水线
+func (r *pipelineRepository) GetByID(ctx context.Context, id string) (*models.Pipeline, error) {
+	var pipeline models.Pipeline
+	if err := r.esClient.Get(ctx, "logstash_pipelines", id, &pipeline); err != nil {
+		return nil, err
+	}
+	return &pipeline, nil
+}
+
+// GetByName 根据名称获取流水线
+func (r *pipelineRepository) GetByName(ctx context.Context, name string) (*models.Pipeline, error) {
+	pipelines, err := r.search(ctx, map[string]interface{}{
+		"query": map[string]interface{}{
+			"term": map[string]interface{}{"name": name},
+		},
+		"size": 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(pipelines) == 0 {
+		return nil, elasticsearch.ErrNotFound
+	}
+	return pipelines[0], nil
+}
+
+// List 获取全部流水线
+func (r *pipelineRepository) List(ctx context.Context) ([]*models.Pipeline, error) {
+	return r.search(ctx, map[string]interface{}{
+		"sort": []map[string]interface{}{
+			{"name": map[string]string{"order": "asc"}},
+		},
+		"size": 1000, // 流水线数量有限，一次性返回
+	})
+}
+
+// search 搜索流水线
+func (r *pipelineRepository) search(ctx context.Context, query map[string]interface{}) ([]*models.Pipeline, error) {
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Source models.Pipeline `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+
+	if err := r.esClient.Search(ctx, "logstash_pipelines", query, &result); err != nil {
+		return nil, fmt.Errorf("搜索流水线失败: %w", err)
+	}
+
+	pipelines := make([]*models.Pipeline, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		pipeline := hit.Source
+		pipelines = append(pipelines, &pipeline)
+	}
+
+	return pipelines, nil
+}
+
+// GetVersions 获取流水线的全部版本，按版本号倒序
+func (r *pipelineRepository) GetVersions(ctx context.Context, pipelineID string) ([]*models.PipelineVersion, error) {
+	query := map[string]interface{}{
+		"query": map[string]interface{}{
+			"term": map[string]interface{}{"pipeline_id": pipelineID},
+		},
+		"sort": []map[string]interface{}{
+			{"version": map[string]string{"order": "desc"}},
+		},
+		"size": 100,
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Source models.PipelineVersion `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+
+	if err := r.esClient.Search(ctx, "logstash_pipeline_versions", query, &result); err != nil {
+		return nil, fmt.Errorf("搜索流水线版本失败: %w", err)
+	}
+
+	versions := make([]*models.PipelineVersion, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		version := hit.Source
+		versions = append(versions, &version)
+	}
+
+	return versions, nil
+}
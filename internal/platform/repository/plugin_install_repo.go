@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// PluginInstallRepository 插件安装记录仓库接口
+type PluginInstallRepository interface {
+	Save(ctx context.Context, install *models.PluginInstall) error
+	// GetByID 获取安装记录，不存在时返回nil
+	GetByID(ctx context.Context, id string) (*models.PluginInstall, error)
+	// ListByAgent 获取Agent的安装记录，按创建时间倒序
+	ListByAgent(ctx context.Context, agentID string, size int) ([]*models.PluginInstall, error)
+	// ListUnfinished 获取尚未结束的安装记录，平台重启后据此恢复超时判定
+	ListUnfinished(ctx context.Context) ([]*models.PluginInstall, error)
+}
+
+// pluginInstallRepository 插件安装记录仓库实现
+type pluginInstallRepository struct {
+	esClient elasticsearch.ClientInterface
+	logger   *logrus.Logger
+}
+
+// NewPluginInstallRepository 创建插件安装记录仓库
+func NewPluginInstallRepository(esClient elasticsearch.ClientInterface, logger *logrus.Logger) PluginInstallRepository {
+	return &pluginInstallRepository{
+		esClient: esClient,
+		logger:   logger,
+	}
+}
+
+// Save 保存安装记录
+func (r *pluginInstallRepository) Save(ctx context.Context, install *models.PluginInstall) error {
+	if err := r.esClient.Index(ctx, "logstash_plugin_installs", install.ID, install); err != nil {
+		return fmt.Errorf("保存插件安装记录失败: %w", err)
+	}
+	return nil
+}
+
+// GetByID 获取安装记录
+func (r *pluginInstallRepository) GetByID(ctx context.Context, id string) (*models.PluginInstall, error) {
+	var install models.PluginInstall
+	if err := r.esClient.Get(ctx, "logstash_plugin_installs", id, &install); err != nil {
+		if errors.Is(err, elasticsearch.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("获取插件安装记录失败: %w", err)
+	}
+	return &install, nil
+}
+
+// ListByAgent 获取Agent最近的安装记录
+func (r *pluginInstallRepository) ListByAgent(ctx context.Context, agentID string, size int) ([]*models.PluginInstall, error) {
+	if size <= 0 {
+		size = 50
+	}
+	return r.search(ctx, map[string]interface{}{
+		"query": map[string]interface{}{
+			"term": map[string]interface{}{"agent_id": agentID},
+		},
+		"sort": []map[string]interface{}{
+			{"created_at": map[string]string{"order": "desc"}},
+		},
+		"size": size,
+	})
+}
+
+// ListUnfinished 获取状态为pending或running的安装记录
+func (r *pluginInstallRepository) ListUnfinished(ctx context.Context) ([]*models.PluginInstall, error) {
+	return r.search(ctx, map[string]interface{}{
+		"query": map[string]interface{}{
+			"terms": map[string]interface{}{
+				"status": []string{models.PluginInstallPending, models.PluginInstallRunning},
+			},
+		},
+		"size": 1000,
+	})
+}
+
+// search 执行查询并返回安装记录
+func (r *pluginInstallRepository) search(ctx context.Context, query map[string]interface{}) ([]*models.PluginInstall, error) {
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Source models.PluginInstall `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+
+	if err := r.esClient.Search(ctx, "logstash_plugin_installs", query, &result); err != nil {
+		return nil, fmt.Errorf("搜索插件安装记录失败: %w", err)
+	}
+
+	installs := make([]*models.PluginInstall, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		install := hit.Source
+		installs = append(installs, &install)
+	}
+	return installs, nil
+}
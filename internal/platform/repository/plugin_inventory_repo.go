@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// PluginInventoryRepository Agent插件清单仓库接口
+type PluginInventoryRepository interface {
+	// Get 获取Agent的插件清单，Agent未上报过时返回nil
+	Get(ctx context.Context, agentID string) (*models.PluginInventory, error)
+	Save(ctx context.Context, inventory *models.PluginInventory) error
+}
+
+// pluginInventoryRepository Agent插件清单仓库实现
+type pluginInventoryRepository struct {
+	esClient elasticsearch.ClientInterface
+	logger   *logrus.Logger
+}
+
+// NewPluginInventoryRepository 创建Agent插件清单仓库
+func NewPluginInventoryRepository(esClient elasticsearch.ClientInterface, logger *logrus.Logger) PluginInventoryRepository {
+	return &pluginInventoryRepository{
+		esClient: esClient,
+		logger:   logger,
+	}
+}
+
+// Get 以Agent ID为文档ID获取插件清单
+func (r *pluginInventoryRepository) Get(ctx context.Context, agentID string) (*models.PluginInventory, error) {
+	var inventory models.PluginInventory
+	if err := r.esClient.Get(ctx, "logstash_agent_plugins", agentID, &inventory); err != nil {
+		if errors.Is(err, elasticsearch.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("获取Agent插件清单失败: %w", err)
+	}
+	return &inventory, nil
+}
+
+// Save 覆盖保存Agent的插件清单
+func (r *pluginInventoryRepository) Save(ctx context.Context, inventory *models.PluginInventory) error {
+	if err := r.esClient.Index(ctx, "logstash_agent_plugins", inventory.AgentID, inventory); err != nil {
+		return fmt.Errorf("保存Agent插件清单失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package repository
+
+import "logstash-platform/internal/platform/models"
+
+// projectFilter 按项目过滤的查询条件
+// 引入项目之前写入的文档没有project字段，查询默认项目时一并匹配
+func projectFilter(project string) map[string]interface{} {
+	if project != models.DefaultProject {
+		return map[string]interface{}{
+			"term": map[string]interface{}{"project": project},
+		}
+	}
+	return map[string]interface{}{
+		"bool": map[string]interface{}{
+			"should": []map[string]interface{}{
+				{"term": map[string]interface{}{"project": project}},
+				{"bool": map[string]interface{}{
+					"must_not": map[string]interface{}{"exists": map[string]interface{}{"field": "project"}},
+				}},
+			},
+			"minimum_should_match": 1,
+		},
+	}
+}
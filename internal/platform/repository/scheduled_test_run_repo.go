@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// ScheduledTestRunRepository 定期测试记录仓库接口
+type ScheduledTestRunRepository interface {
+	Save(ctx context.Context, run *models.ScheduledTestRun) error
+	Latest(ctx context.Context, configID string) (*models.ScheduledTestRun, error)
+	List(ctx context.Context, configID string, req *models.ScheduledTestListRequest) ([]*models.ScheduledTestRun, int64, error)
+}
+
+// scheduledTestRunRepository 定期测试记录仓库实现
+type scheduledTestRunRepository struct {
+	esClient elasticsearch.ClientInterface
+	logger   *logrus.Logger
+}
+
+// NewScheduledTestRunRepository 创建定期测试记录仓库
+func NewScheduledTestRunRepository(esClient elasticsearch.ClientInterface, logger *logrus.Logger) ScheduledTestRunRepository {
+	return &scheduledTestRunRepository{
+		esClient: esClient,
+		logger:   logger,
+	}
+}
+
+// Save 保存一次定期测试记录
+func (r *scheduledTestRunRepository) Save(ctx context.Context, run *models.ScheduledTestRun) error {
+	if err := r.esClient.Index(ctx, "logstash_scheduled_test_runs", run.ID, run); err != nil {
+		return fmt.Errorf("保存定期测试记录失败: %w", err)
+	}
+	return nil
+}
+
+// Latest 获取配置最近一次定期测试记录，没有记录时返回nil
+func (r *scheduledTestRunRepository) Latest(ctx context.Context, configID string) (*models.ScheduledTestRun, error) {
+	items, _, err := r.List(ctx, configID, &models.ScheduledTestListRequest{Page: 1, PageSize: 1})
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+	return items[0], nil
+}
+
+// List 获取配置的定期测试记录，按开始时间倒序
+func (r *scheduledTestRunRepository) List(ctx context.Context, configID string, req *models.ScheduledTestListRequest) ([]*models.ScheduledTestRun, int64, error) {
+	query := map[string]interface{}{
+		"query": map[string]interface{}{
+			"term": map[string]interface{}{"config_id": configID},
+		},
+		"from": (req.Page - 1) * req.PageSize,
+		"size": req.PageSize,
+		"sort": []map[string]interface{}{
+			{"started_at": map[string]string{"order": "desc"}},
+		},
+	}
+
+	var result struct {
+		Hits struct {
+			Total struct {
+				Value int64 `json:"value"`
+			} `json:"total"`
+			Hits []struct {
+				Source models.ScheduledTestRun `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+
+	if err := r.esClient.Search(ctx, "logstash_scheduled_test_runs", query, &result); err != nil {
+		return nil, 0, fmt.Errorf("搜索定期测试记录失败: %w", err)
+	}
+
+	items := make([]*models.ScheduledTestRun, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		item := hit.Source
+		items = append(items, &item)
+	}
+
+	return items, result.Hits.Total.Value, nil
+}
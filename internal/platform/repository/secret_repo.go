@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// SecretRepository 密钥仓库接口，存储的值均为密文
+type SecretRepository interface {
+	Create(ctx context.Context, secret *models.EncryptedSecret) error
+	Update(ctx context.Context, secret *models.EncryptedSecret) error
+	Delete(ctx context.Context, name string) error
+	GetByName(ctx context.Context, name string) (*models.EncryptedSecret, error)
+	List(ctx context.Context) ([]*models.Secret, error)
+}
+
+// secretRepository 密钥仓库实现
+type secretRepository struct {
+	esClient elasticsearch.ClientInterface
+	logger   *logrus.Logger
+}
+
+// NewSecretRepository 创建密钥仓库
+func NewSecretRepository(esClient elasticsearch.ClientInterface, logger *logrus.Logger) SecretRepository {
+	return &secretRepository{
+		esClient: esClient,
+		logger:   logger,
+	}
+}
+
+// Create 创建密钥
+func (r *secretRepository) Create(ctx context.Context, secret *models.EncryptedSecret) error {
+	now := time.Now()
+	secret.CreatedAt = now
+	secret.UpdatedAt = now
+
+	if err := r.esClient.Index(ctx, "logstash_secrets", secret.Name, secret); err != nil {
+		return fmt.Errorf("创建密钥失败: %w", err)
+	}
+
+	return nil
+}
+
+// Update 更新密钥
+func (r *secretRepository) Update(ctx context.Context, secret *models.EncryptedSecret) error {
+	secret.UpdatedAt = time.Now()
+
+	if err := r.esClient.Index(ctx, "logstash_secrets", secret.Name, secret); err != nil {
+		return fmt.Errorf("更新密钥失败: %w", err)
+	}
+
+	return nil
+}
+
+// Delete 删除密钥
+func (r *secretRepository) Delete(ctx context.Context, name string) error {
+	if err := r.esClient.Delete(ctx, "logstash_secrets", name); err != nil {
+		return fmt.Errorf("删除密钥失败: %w", err)
+	}
+	return nil
+}
+
+// GetByName 根据名称获取密钥
+func (r *secretRepository) GetByName(ctx context.Context, name string) (*models.EncryptedSecret, error) {
+	var secret models.EncryptedSecret
+	if err := r.esClient.Get(ctx, "logstash_secrets", name, &secret); err != nil {
+		return nil, err
+	}
+	return &secret, nil
+}
+
+// List 获取全部密钥的元数据，不读取密文
+func (r *secretRepository) List(ctx context.Context) ([]*models.Secret, error) {
+	query := map[string]interface{}{
+		"_source": map[string]interface{}{"excludes": []string{"ciphertext"}},
+		"sort": []map[string]interface{}{
+			{"name": map[string]string{"order": "asc"}},
+		},
+		"size": 1000, // 密钥数量有限，一次性返回
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Source models.Secret `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+
+	if err := r.esClient.Search(ctx, "logstash_secrets", query, &result); err != nil {
+		return nil, fmt.Errorf("搜索密钥失败: %w", err)
+	}
+
+	secrets := make([]*models.Secret, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		secret := hit.Source
+		secrets = append(secrets, &secret)
+	}
+
+	return secrets, nil
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// TestDatasetRepository 测试数据集仓库接口
+type TestDatasetRepository interface {
+	Create(ctx context.Context, dataset *models.TestDataset) error
+	Update(ctx context.Context, dataset *models.TestDataset) error
+	Delete(ctx context.Context, id string) error
+	GetByID(ctx context.Context, id string) (*models.TestDataset, error)
+	ListByConfig(ctx context.Context, configID string) ([]*models.TestDataset, error)
+}
+
+// testDatasetRepository 测试数据集仓库实现
+type testDatasetRepository struct {
+	esClient elasticsearch.ClientInterface
+	logger   *logrus.Logger
+}
+
+// NewTestDatasetRepository 创建测试数据集仓库
+func NewTestDatasetRepository(esClient elasticsearch.ClientInterface, logger *logrus.Logger) TestDatasetRepository {
+	return &testDatasetRepository{
+		esClient: esClient,
+		logger:   logger,
+	}
+}
+
+// Create 创建测试数据集
+func (r *testDatasetRepository) Create(ctx context.Context, dataset *models.TestDataset) error {
+	now := time.Now()
+	dataset.CreatedAt = now
+	dataset.UpdatedAt = now
+
+	if err := r.esClient.Index(ctx, "logstash_test_datasets", dataset.ID, dataset); err != nil {
+		return fmt.Errorf("创建测试数据集失败: %w", err)
+	}
+
+	return nil
+}
+
+// Update 更新测试数据集，创建信息保持不变
+func (r *testDatasetRepository) Update(ctx context.Context, dataset *models.TestDataset) error {
+	existing, err := r.GetByID(ctx, dataset.ID)
+	if err != nil {
+		return fmt.Errorf("获取现有测试数据集失败: %w", err)
+	}
+
+	dataset.CreatedAt = existing.CreatedAt
+	dataset.CreatedBy = existing.CreatedBy
+	dataset.UpdatedAt = time.Now()
+
+	if err := r.esClient.Index(ctx, "logstash_test_datasets", dataset.ID, dataset); err != nil {
+		return fmt.Errorf("更新测试数据集失败: %w", err)
+	}
+
+	return nil
+}
+
+// Delete 删除测试数据集
+func (r *testDatasetRepository) Delete(ctx context.Context, id string) error {
+	if err := r.esClient.Delete(ctx, "logstash_test_datasets", id); err != nil {
+		return fmt.Errorf("删除测试数据集失败: %w", err)
+	}
+	return nil
+}
+
+// GetByID 根据ID获取测试数据集
+func (r *testDatasetRepository) GetByID(ctx context.Context, id string) (*models.TestDataset, error) {
+	var dataset models.TestDataset
+	if err := r.esClient.Get(ctx, "logstash_test_datasets", id, &dataset); err != nil {
+		return nil, err
+	}
+	return &dataset, nil
+}
+
+// ListByConfig 获取配置上的全部测试数据集，按名称排序
+func (r *testDatasetRepository) ListByConfig(ctx context.Context, configID string) ([]*models.TestDataset, error) {
+	query := map[string]interface{}{
+		"query": map[string]interface{}{
+			"term": map[string]interface{}{"config_id": configID},
+		},
+		"sort": []map[string]interface{}{
+			{"name": map[string]string{"order": "asc"}},
+		},
+		"size": 1000,
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Source models.TestDataset `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+
+	if err := r.esClient.Search(ctx, "logstash_test_datasets", query, &result); err != nil {
+		return nil, fmt.Errorf("搜索测试数据集失败: %w", err)
+	}
+
+	datasets := make([]*models.TestDataset, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		dataset := hit.Source
+		datasets = append(datasets, &dataset)
+	}
+
+	return datasets, nil
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// UserRepository 用户仓库接口
+type UserRepository interface {
+	Save(ctx context.Context, user *models.User) error
+	GetByUsername(ctx context.Context, username string) (*models.User, error)
+	Delete(ctx context.Context, username string) error
+	List(ctx context.Context) ([]*models.User, error)
+}
+
+// userRepository 用户仓库实现
+type userRepository struct {
+	esClient elasticsearch.ClientInterface
+	logger   *logrus.Logger
+}
+
+// NewUserRepository 创建用户仓库
+func NewUserRepository(esClient elasticsearch.ClientInterface, logger *logrus.Logger) UserRepository {
+	return &userRepository{
+		esClient: esClient,
+		logger:   logger,
+	}
+}
+
+// Save 保存用户（不存在则创建）
+func (r *userRepository) Save(ctx context.Context, user *models.User) error {
+	if err := r.esClient.Index(ctx, "logstash_users", user.Username, user); err != nil {
+		return fmt.Errorf("保存用户失败: %w", err)
+	}
+	return nil
+}
+
+// GetByUsername 根据用户名获取用户
+func (r *userRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	var user models.User
+	if err := r.esClient.Get(ctx, "logstash_users", username, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Delete 删除用户
+func (r *userRepository) Delete(ctx context.Context, username string) error {
+	if err := r.esClient.Delete(ctx, "logstash_users", username); err != nil {
+		return fmt.Errorf("删除用户失败: %w", err)
+	}
+	return nil
+}
+
+// List 获取全部用户
+func (r *userRepository) List(ctx context.Context) ([]*models.User, error) {
+	query := map[string]interface{}{
+		"sort": []map[string]interface{}{
+			{"username": map[string]string{"order": "asc"}},
+		},
+		"size": 1000, // 用户数量有限，一次性返回
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Source models.User `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+
+	if err := r.esClient.Search(ctx, "logstash_users", query, &result); err != nil {
+		return nil, fmt.Errorf("搜索用户失败: %w", err)
+	}
+
+	users := make([]*models.User, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		user := hit.Source
+		users = append(users, &user)
+	}
+
+	return users, nil
+}
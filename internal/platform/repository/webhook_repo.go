@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// WebhookRepository 出站事件订阅仓库接口
+type WebhookRepository interface {
+	Create(ctx context.Context, webhook *models.StoredWebhook) error
+	Update(ctx context.Context, webhook *models.StoredWebhook) error
+	Delete(ctx context.Context, id string) error
+	GetByID(ctx context.Context, id string) (*models.StoredWebhook, error)
+	List(ctx context.Context) ([]*models.StoredWebhook, error)
+}
+
+// webhookRepository 出站事件订阅仓库实现
+type webhookRepository struct {
+	esClient elasticsearch.ClientInterface
+	logger   *logrus.Logger
+}
+
+// NewWebhookRepository 创建出站事件订阅仓库
+func NewWebhookRepository(esClient elasticsearch.ClientInterface, logger *logrus.Logger) WebhookRepository {
+	return &webhookRepository{
+		esClient: esClient,
+		logger:   logger,
+	}
+}
+
+// Create 创建订阅
+func (r *webhookRepository) Create(ctx context.Context, webhook *models.StoredWebhook) error {
+	if webhook.ID == "" {
+		webhook.ID = uuid.New().String()
+	}
+
+	now := time.Now()
+	webhook.CreatedAt = now
+	webhook.UpdatedAt = now
+
+	if err := r.esClient.Index(ctx, "logstash_webhooks", webhook.ID, webhook); err != nil {
+		return fmt.Errorf("创建webhook失败: %w", err)
+	}
+
+	return nil
+}
+
+// Update 更新订阅
+func (r *webhookRepository) Update(ctx context.Context, webhook *models.StoredWebhook) error {
+	existing, err := r.GetByID(ctx, webhook.ID)
+	if err != nil {
+		return fmt.Errorf("获取现有webhook失败: %w", err)
+	}
+
+	webhook.CreatedAt = existing.CreatedAt
+	webhook.CreatedBy = existing.CreatedBy
+	webhook.UpdatedAt = time.Now()
+
+	if err := r.esClient.Index(ctx, "logstash_webhooks", webhook.ID, webhook); err != nil {
+		return fmt.Errorf("更新webhook失败: %w", err)
+	}
+
+	return nil
+}
+
+// Delete 删除订阅
+func (r *webhookRepository) Delete(ctx context.Context, id string) error {
+	if err := r.esClient.Delete(ctx, "logstash_webhooks", id); err != nil {
+		return fmt.Errorf("删除webhook失败: %w", err)
+	}
+	return nil
+}
+
+// GetByID 根据ID获取订阅
+func (r *webhookRepository) GetByID(ctx context.Context, id string) (*models.StoredWebhook, error) {
+	var webhook models.StoredWebhook
+	if err := r.esClient.Get(ctx, "logstash_webhooks", id, &webhook); err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// List 获取全部订阅
+func (r *webhookRepository) List(ctx context.Context) ([]*models.StoredWebhook, error) {
+	query := map[string]interface{}{
+		"sort": []map[string]interface{}{
+			{"name": map[string]string{"order": "asc"}},
+		},
+		"size": 1000, // 订阅数量有限，一次性返回
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Source models.StoredWebhook `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+
+	if err := r.esClient.Search(ctx, "logstash_webhooks", query, &result); err != nil {
+		return nil, fmt.Errorf("搜索webhook失败: %w", err)
+	}
+
+	webhooks := make([]*models.StoredWebhook, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		webhook := hit.Source
+		webhooks = append(webhooks, &webhook)
+	}
+
+	return webhooks, nil
+}
+
+// WebhookDeliveryRepository 事件推送记录仓库接口
+type WebhookDeliveryRepository interface {
+	Save(ctx context.Context, delivery *models.WebhookDelivery) error
+	// List 获取订阅的推送记录，按创建时间倒序
+	List(ctx context.Context, webhookID string, req *models.WebhookDeliveryListRequest) ([]*models.WebhookDelivery, error)
+}
+
+// webhookDeliveryRepository 事件推送记录仓库实现
+type webhookDeliveryRepository struct {
+	esClient elasticsearch.ClientInterface
+	logger   *logrus.Logger
+}
+
+// NewWebhookDeliveryRepository 创建事件推送记录仓库
+func NewWebhookDeliveryRepository(esClient elasticsearch.ClientInterface, logger *logrus.Logger) WebhookDeliveryRepository {
+	return &webhookDeliveryRepository{
+		esClient: esClient,
+		logger:   logger,
+	}
+}
+
+// Save 保存推送记录，每次发送后覆盖
+func (r *webhookDeliveryRepository) Save(ctx context.Context, delivery *models.WebhookDelivery) error {
+	if err := r.esClient.Index(ctx, "logstash_webhook_deliveries", delivery.ID, delivery); err != nil {
+		return fmt.Errorf("保存webhook推送记录失败: %w", err)
+	}
+	return nil
+}
+
+// List 按条件获取订阅的推送记录
+func (r *webhookDeliveryRepository) List(ctx context.Context, webhookID string, req *models.WebhookDeliveryListRequest) ([]*models.WebhookDelivery, error) {
+	size := req.Size
+	if size <= 0 {
+		size = 50
+	}
+
+	filter := []map[string]interface{}{
+		{"term": map[string]interface{}{"webhook_id": webhookID}},
+	}
+	if req.Status != "" {
+		filter = append(filter, map[string]interface{}{
+			"term": map[string]interface{}{"status": req.Status},
+		})
+	}
+	if req.Event != "" {
+		filter = append(filter, map[string]interface{}{
+			"term": map[string]interface{}{"event": req.Event},
+		})
+	}
+
+	query := map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{"filter": filter},
+		},
+		"sort": []map[string]interface{}{
+			{"created_at": map[string]string{"order": "desc"}},
+		},
+		"size": size,
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Source models.WebhookDelivery `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+
+	if err := r.esClient.Search(ctx, "logstash_webhook_deliveries", query, &result); err != nil {
+		return nil, fmt.Errorf("搜索webhook推送记录失败: %w", err)
+	}
+
+	deliveries := make([]*models.WebhookDelivery, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		delivery := hit.Source
+		deliveries = append(deliveries, &delivery)
+	}
+
+	return deliveries, nil
+}
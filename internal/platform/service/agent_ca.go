@@ -0,0 +1,244 @@
+package service
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+)
+
+// ErrInvalidCSR 证书签名请求无效
+var ErrInvalidCSR = errors.New("证书签名请求无效")
+
+// 生成平台CA时使用的默认值
+const (
+	defaultCACommonName = "Logstash Platform Agent CA"
+	defaultCATTL        = 10 * 365 * 24 * time.Hour
+)
+
+// certificateClockSkew 签发证书的生效时间提前量，容忍Agent与平台的时钟偏差
+const certificateClockSkew = time.Minute
+
+// CertificateAuthority 平台CA，为Agent签发客户端证书并作为TLS监听的客户端证书信任根
+type CertificateAuthority struct {
+	cert    *x509.Certificate
+	certPEM []byte
+	key     crypto.Signer
+	pool    *x509.CertPool
+}
+
+// NewCertificateAuthority 从PEM编码的CA证书和私钥创建平台CA
+// 私钥支持PKCS#8、PKCS#1（RSA）和SEC 1（ECDSA）格式
+func NewCertificateAuthority(certPEM, keyPEM []byte) (*CertificateAuthority, error) {
+	certBlock, _ := pem.Decode(certPEM)
+	if certBlock == nil || certBlock.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("CA证书不是有效的PEM证书")
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("解析CA证书失败: %w", err)
+	}
+	if !cert.IsCA {
+		return nil, fmt.Errorf("证书 %s 不是CA证书", cert.Subject.CommonName)
+	}
+
+	keyBlock, _ := pem.Decode(keyPEM)
+	if keyBlock == nil {
+		return nil, fmt.Errorf("CA私钥不是有效的PEM")
+	}
+	key, err := parsePrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	pub, ok := key.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !pub.Equal(cert.PublicKey) {
+		return nil, fmt.Errorf("CA私钥与证书不匹配")
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return &CertificateAuthority{
+		cert:    cert,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}),
+		key:     key,
+		pool:    pool,
+	}, nil
+}
+
+// LoadCertificateAuthority 从文件加载平台CA
+// 两个文件都不存在时生成新的CA并写入文件；多副本部署须预先生成并让各副本使用同一份CA，否则各副本签发的证书互不认可
+func LoadCertificateAuthority(certFile, keyFile string) (ca *CertificateAuthority, generated bool, err error) {
+	certPEM, certErr := os.ReadFile(certFile)
+	keyPEM, keyErr := os.ReadFile(keyFile)
+	if errors.Is(certErr, os.ErrNotExist) && errors.Is(keyErr, os.ErrNotExist) {
+		certPEM, keyPEM, err = GenerateCertificateAuthority(defaultCACommonName, defaultCATTL)
+		if err != nil {
+			return nil, false, err
+		}
+		if err := os.WriteFile(keyFile, keyPEM, 0600); err != nil {
+			return nil, false, fmt.Errorf("写入CA私钥失败: %w", err)
+		}
+		if err := os.WriteFile(certFile, certPEM, 0644); err != nil {
+			return nil, false, fmt.Errorf("写入CA证书失败: %w", err)
+		}
+		generated = true
+	} else if certErr != nil {
+		return nil, false, fmt.Errorf("读取CA证书失败: %w", certErr)
+	} else if keyErr != nil {
+		return nil, false, fmt.Errorf("读取CA私钥失败: %w", keyErr)
+	}
+
+	ca, err = NewCertificateAuthority(certPEM, keyPEM)
+	return ca, generated, err
+}
+
+// GenerateCertificateAuthority 生成自签名的CA证书和ECDSA P-256私钥，返回PEM编码
+func GenerateCertificateAuthority(commonName string, ttl time.Duration) (certPEM, keyPEM []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("生成CA私钥失败: %w", err)
+	}
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             now.Add(-certificateClockSkew),
+		NotAfter:              now.Add(ttl),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("生成CA证书失败: %w", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("编码CA私钥失败: %w", err)
+	}
+
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM, nil
+}
+
+// CertificatePEM PEM编码的CA证书，随签发的客户端证书一起返回给Agent
+func (ca *CertificateAuthority) CertificatePEM() string {
+	return string(ca.certPEM)
+}
+
+// TLSConfig 要求客户端证书由平台CA签发的TLS配置
+// 使用VerifyClientCertIfGiven：Web界面和用户API不需要证书，Agent接口由 middleware.RequireClientCertificate 检查
+func (ca *CertificateAuthority) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ClientAuth: tls.VerifyClientCertIfGiven,
+		ClientCAs:  ca.pool,
+	}
+}
+
+// Sign 为Agent签发客户端证书，证书CN为Agent ID，有效期不超过CA证书
+// CSR中的CN须为空或与Agent ID一致，其余主题信息和扩展均被忽略
+func (ca *CertificateAuthority) Sign(csrPEM, agentID string, ttl time.Duration, now time.Time) (*x509.Certificate, []byte, error) {
+	csr, err := parseCSR(csrPEM, agentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, nil, err
+	}
+	notAfter := now.Add(ttl)
+	if notAfter.After(ca.cert.NotAfter) {
+		notAfter = ca.cert.NotAfter
+	}
+	keyUsage := x509.KeyUsageDigitalSignature
+	if _, isRSA := csr.PublicKey.(*rsa.PublicKey); isRSA {
+		keyUsage |= x509.KeyUsageKeyEncipherment
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: agentID},
+		NotBefore:    now.Add(-certificateClockSkew),
+		NotAfter:     notAfter,
+		KeyUsage:     keyUsage,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, csr.PublicKey, ca.key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("签发客户端证书失败: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, fmt.Errorf("解析签发的证书失败: %w", err)
+	}
+	return cert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), nil
+}
+
+// CertificateSerial 证书序列号的十六进制表示，即证书记录的文档ID
+func CertificateSerial(cert *x509.Certificate) string {
+	return cert.SerialNumber.Text(16)
+}
+
+// parseCSR 解析并校验PEM编码的CSR，CN须为空或与Agent ID一致
+func parseCSR(csrPEM, agentID string) (*x509.CertificateRequest, error) {
+	block, _ := pem.Decode([]byte(csrPEM))
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, fmt.Errorf("%w: 不是PEM编码的CSR", ErrInvalidCSR)
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCSR, err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("%w: 签名校验失败: %w", ErrInvalidCSR, err)
+	}
+	if cn := csr.Subject.CommonName; cn != "" && cn != agentID {
+		return nil, fmt.Errorf("%w: CN %q 与Agent ID不一致", ErrInvalidCSR, cn)
+	}
+	return csr, nil
+}
+
+// parsePrivateKey 解析DER编码的私钥
+func parsePrivateKey(der []byte) (crypto.Signer, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("不支持的CA私钥类型 %T", key)
+		}
+		return signer, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("解析CA私钥失败：不是PKCS#8、SEC 1或PKCS#1格式")
+}
+
+// randomSerial 生成128位随机证书序列号
+func randomSerial() (*big.Int, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("生成证书序列号失败: %w", err)
+	}
+	return serial, nil
+}
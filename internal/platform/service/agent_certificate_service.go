@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// Agent客户端证书错误
+var (
+	ErrCertificatesDisabled = errors.New("平台未启用Agent客户端证书")
+	ErrCertificateUnknown   = errors.New("客户端证书不是平台签发的")
+	ErrCertificateRevoked   = errors.New("客户端证书已吊销")
+	ErrCertificateRetired   = errors.New("客户端证书已过期或已轮换")
+)
+
+// defaultAgentCertTTL 客户端证书的默认有效期
+const defaultAgentCertTTL = 90 * 24 * time.Hour
+
+// AgentCertificateService Agent客户端证书服务接口
+type AgentCertificateService interface {
+	// CheckCSR 检查CSR能否签发，注册时在签发令牌之前调用
+	CheckCSR(agentID, csr string) error
+	// Issue 使用平台CA按Agent提交的CSR签发客户端证书
+	Issue(ctx context.Context, agentID, csr, operator string) (*models.IssuedCertificate, error)
+	// Rotate 签发新证书，旧证书在宽限期后失效
+	Rotate(ctx context.Context, agentID, csr, operator string) (*models.IssuedCertificate, error)
+	// Revoke 立即吊销Agent的全部证书
+	Revoke(ctx context.Context, agentID, operator string) (*models.RevokeAgentCertificatesResponse, error)
+	ListCertificates(ctx context.Context, agentID string) ([]*models.AgentCertificate, error)
+	// VerifyPeer 检查TLS层已校验过证书链的客户端证书是否仍然可用，返回证书绑定的Agent ID
+	VerifyPeer(ctx context.Context, cert *x509.Certificate) (string, error)
+}
+
+// agentCertificateService Agent客户端证书服务实现
+type agentCertificateService struct {
+	ca       *CertificateAuthority
+	certRepo repository.AgentCertificateRepository
+	ttl      time.Duration
+	grace    time.Duration
+	logger   *logrus.Logger
+	now      func() time.Time
+}
+
+// NewAgentCertificateService 创建Agent客户端证书服务，ttl为证书有效期，grace为轮换后旧证书的宽限期
+// ca为nil时签发和校验返回 ErrCertificatesDisabled，已有的证书记录仍可查询和吊销
+func NewAgentCertificateService(ca *CertificateAuthority, certRepo repository.AgentCertificateRepository, ttl, grace time.Duration, logger *logrus.Logger) AgentCertificateService {
+	if ttl <= 0 {
+		ttl = defaultAgentCertTTL
+	}
+	if grace <= 0 {
+		grace = defaultRotationGrace
+	}
+	return &agentCertificateService{
+		ca:       ca,
+		certRepo: certRepo,
+		ttl:      ttl,
+		grace:    grace,
+		logger:   logger,
+		now:      time.Now,
+	}
+}
+
+// CheckCSR 检查是否启用客户端证书以及CSR是否有效
+func (s *agentCertificateService) CheckCSR(agentID, csr string) error {
+	if s.ca == nil {
+		return ErrCertificatesDisabled
+	}
+	_, err := parseCSR(csr, agentID)
+	return err
+}
+
+// Issue 签发客户端证书，不影响Agent已有的证书
+func (s *agentCertificateService) Issue(ctx context.Context, agentID, csr, operator string) (*models.IssuedCertificate, error) {
+	issued, _, err := s.issue(ctx, agentID, csr, operator)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"agent_id": agentID,
+		"serial":   issued.Serial,
+		"operator": operator,
+	}).Info("签发Agent客户端证书")
+	return issued, nil
+}
+
+// Rotate 签发新证书并为仍可用的旧证书设置宽限截止时间
+// 证书接口要求客户端证书时只有持有可用证书的Agent能调用，因此不要求Agent已有证书
+func (s *agentCertificateService) Rotate(ctx context.Context, agentID, csr, operator string) (*models.IssuedCertificate, error) {
+	if s.ca == nil {
+		return nil, ErrCertificatesDisabled
+	}
+	certs, err := s.certRepo.ListByAgent(elasticsearch.WithPrimaryRead(ctx), agentID)
+	if err != nil {
+		return nil, err
+	}
+
+	issued, now, err := s.issue(ctx, agentID, csr, operator)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := now.Add(s.grace)
+	retiring := 0
+	for _, c := range certs {
+		if !c.Usable(now) {
+			continue
+		}
+		if c.RetiresAt == nil || c.RetiresAt.After(deadline) {
+			c.RetiresAt = &deadline
+		}
+		c.RotatedTo = issued.Serial
+		if err := s.certRepo.Save(ctx, c); err != nil {
+			return nil, err
+		}
+		retiring++
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"agent_id": agentID,
+		"serial":   issued.Serial,
+		"retiring": retiring,
+		"operator": operator,
+	}).Info("轮换Agent客户端证书")
+	return issued, nil
+}
+
+// Revoke 吊销Agent的全部证书
+func (s *agentCertificateService) Revoke(ctx context.Context, agentID, operator string) (*models.RevokeAgentCertificatesResponse, error) {
+	certs, err := s.certRepo.ListByAgent(elasticsearch.WithPrimaryRead(ctx), agentID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	revoked := 0
+	for _, c := range certs {
+		if c.Status == models.AgentCertificateRevoked {
+			continue
+		}
+		c.Status = models.AgentCertificateRevoked
+		c.RevokedAt = &now
+		c.RevokedBy = operator
+		if err := s.certRepo.Save(ctx, c); err != nil {
+			return nil, err
+		}
+		revoked++
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"agent_id": agentID,
+		"revoked":  revoked,
+		"operator": operator,
+	}).Warn("吊销Agent客户端证书")
+	return &models.RevokeAgentCertificatesResponse{AgentID: agentID, Revoked: revoked}, nil
+}
+
+// ListCertificates 获取Agent的证书记录
+func (s *agentCertificateService) ListCertificates(ctx context.Context, agentID string) ([]*models.AgentCertificate, error) {
+	return s.certRepo.ListByAgent(ctx, agentID)
+}
+
+// VerifyPeer 按序列号查找证书记录，检查吊销和轮换状态
+func (s *agentCertificateService) VerifyPeer(ctx context.Context, cert *x509.Certificate) (string, error) {
+	if s.ca == nil {
+		return "", ErrCertificatesDisabled
+	}
+	record, err := s.certRepo.GetBySerial(ctx, CertificateSerial(cert))
+	if err != nil {
+		if err.Error() == "文档不存在" {
+			return "", ErrCertificateUnknown
+		}
+		return "", fmt.Errorf("获取Agent证书失败: %w", err)
+	}
+	if record.AgentID != cert.Subject.CommonName || record.Fingerprint != certificateFingerprint(cert) {
+		return "", ErrCertificateUnknown
+	}
+	if record.Status == models.AgentCertificateRevoked {
+		return "", ErrCertificateRevoked
+	}
+	if !record.Usable(s.now()) {
+		return "", ErrCertificateRetired
+	}
+	return record.AgentID, nil
+}
+
+// issue 签发证书并保存记录，返回签发时间
+func (s *agentCertificateService) issue(ctx context.Context, agentID, csr, operator string) (*models.IssuedCertificate, time.Time, error) {
+	now := s.now()
+	if s.ca == nil {
+		return nil, now, ErrCertificatesDisabled
+	}
+	cert, certPEM, err := s.ca.Sign(csr, agentID, s.ttl, now)
+	if err != nil {
+		return nil, now, err
+	}
+
+	record := &models.AgentCertificate{
+		Serial:      CertificateSerial(cert),
+		AgentID:     agentID,
+		Status:      models.AgentCertificateActive,
+		Fingerprint: certificateFingerprint(cert),
+		NotBefore:   cert.NotBefore,
+		NotAfter:    cert.NotAfter,
+		CreatedAt:   now,
+		CreatedBy:   operator,
+	}
+	if err := s.certRepo.Save(ctx, record); err != nil {
+		return nil, now, err
+	}
+
+	return &models.IssuedCertificate{
+		Serial:        record.Serial,
+		Certificate:   string(certPEM),
+		CACertificate: s.ca.CertificatePEM(),
+		NotAfter:      cert.NotAfter,
+	}, now, nil
+}
+
+// certificateFingerprint 证书DER的SHA-256
+func certificateFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+)
+
+// memAgentCertificateRepository 内存中的Agent证书仓库
+type memAgentCertificateRepository struct {
+	mu    sync.Mutex
+	certs map[string]*models.AgentCertificate
+}
+
+func (r *memAgentCertificateRepository) Save(ctx context.Context, cert *models.AgentCertificate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cp := *cert
+	r.certs[cert.Serial] = &cp
+	return nil
+}
+
+func (r *memAgentCertificateRepository) GetBySerial(ctx context.Context, serial string) (*models.AgentCertificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cert, ok := r.certs[serial]
+	if !ok {
+		return nil, errors.New("文档不存在")
+	}
+	cp := *cert
+	return &cp, nil
+}
+
+func (r *memAgentCertificateRepository) ListByAgent(ctx context.Context, agentID string) ([]*models.AgentCertificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var certs []*models.AgentCertificate
+	for _, c := range r.certs {
+		if c.AgentID == agentID {
+			cp := *c
+			certs = append(certs, &cp)
+		}
+	}
+	return certs, nil
+}
+
+func newTestCertificateAuthority(t *testing.T) *CertificateAuthority {
+	t.Helper()
+	certPEM, keyPEM, err := GenerateCertificateAuthority("test-ca", time.Hour*24)
+	require.NoError(t, err)
+	ca, err := NewCertificateAuthority(certPEM, keyPEM)
+	require.NoError(t, err)
+	return ca
+}
+
+func newTestAgentCertificateService(t *testing.T) (*agentCertificateService, *memAgentCertificateRepository) {
+	repo := &memAgentCertificateRepository{certs: make(map[string]*models.AgentCertificate)}
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	return NewAgentCertificateService(newTestCertificateAuthority(t), repo, time.Hour, time.Minute, logger).(*agentCertificateService), repo
+}
+
+// testCSR 生成指定CN的CSR
+func testCSR(t *testing.T, commonName string) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: pkix.Name{CommonName: commonName}}, key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}))
+}
+
+// parseIssued 解析签发的证书并按平台CA校验证书链
+func parseIssued(t *testing.T, issued *models.IssuedCertificate) *x509.Certificate {
+	t.Helper()
+	block, _ := pem.Decode([]byte(issued.Certificate))
+	require.NotNil(t, block)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM([]byte(issued.CACertificate)))
+	_, err = cert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}})
+	require.NoError(t, err)
+	return cert
+}
+
+func TestAgentCertificateService_Issue(t *testing.T) {
+	svc, repo := newTestAgentCertificateService(t)
+	ctx := context.Background()
+
+	issued, err := svc.Issue(ctx, "agent-1", testCSR(t, ""), "admin")
+	require.NoError(t, err)
+	cert := parseIssued(t, issued)
+	assert.Equal(t, "agent-1", cert.Subject.CommonName)
+	assert.Equal(t, issued.Serial, CertificateSerial(cert))
+	assert.WithinDuration(t, time.Now().Add(time.Hour), cert.NotAfter, time.Minute)
+
+	record, err := repo.GetBySerial(ctx, issued.Serial)
+	require.NoError(t, err)
+	assert.Equal(t, models.AgentCertificateActive, record.Status)
+
+	agentID, err := svc.VerifyPeer(ctx, cert)
+	require.NoError(t, err)
+	assert.Equal(t, "agent-1", agentID)
+
+	t.Run("common name mismatch", func(t *testing.T) {
+		_, err := svc.Issue(ctx, "agent-1", testCSR(t, "agent-2"), "admin")
+		assert.ErrorIs(t, err, ErrInvalidCSR)
+		assert.ErrorIs(t, svc.CheckCSR("agent-1", "not a csr"), ErrInvalidCSR)
+	})
+
+	t.Run("unknown certificate", func(t *testing.T) {
+		other, _ := newTestAgentCertificateService(t)
+		foreign, err := other.Issue(ctx, "agent-1", testCSR(t, ""), "admin")
+		require.NoError(t, err)
+		_, err = svc.VerifyPeer(ctx, parseIssued(t, foreign))
+		assert.ErrorIs(t, err, ErrCertificateUnknown)
+	})
+}
+
+func TestAgentCertificateService_RotateAndRevoke(t *testing.T) {
+	svc, _ := newTestAgentCertificateService(t)
+	ctx := context.Background()
+	now := time.Now()
+	svc.now = func() time.Time { return now }
+
+	first, err := svc.Issue(ctx, "agent-1", testCSR(t, ""), "admin")
+	require.NoError(t, err)
+	second, err := svc.Rotate(ctx, "agent-1", testCSR(t, "agent-1"), "agent-1")
+	require.NoError(t, err)
+
+	// 宽限期内新旧证书都可用
+	_, err = svc.VerifyPeer(ctx, parseIssued(t, first))
+	require.NoError(t, err)
+	_, err = svc.VerifyPeer(ctx, parseIssued(t, second))
+	require.NoError(t, err)
+
+	now = now.Add(2 * time.Minute)
+	_, err = svc.VerifyPeer(ctx, parseIssued(t, first))
+	assert.ErrorIs(t, err, ErrCertificateRetired)
+	_, err = svc.VerifyPeer(ctx, parseIssued(t, second))
+	require.NoError(t, err)
+
+	resp, err := svc.Revoke(ctx, "agent-1", "admin")
+	require.NoError(t, err)
+	assert.Equal(t, 2, resp.Revoked)
+	_, err = svc.VerifyPeer(ctx, parseIssued(t, second))
+	assert.ErrorIs(t, err, ErrCertificateRevoked)
+
+	certs, err := svc.ListCertificates(ctx, "agent-1")
+	require.NoError(t, err)
+	assert.Len(t, certs, 2)
+}
+
+func TestAgentCertificateService_Disabled(t *testing.T) {
+	repo := &memAgentCertificateRepository{certs: make(map[string]*models.AgentCertificate)}
+	svc := NewAgentCertificateService(nil, repo, 0, 0, logrus.New())
+
+	_, err := svc.Issue(context.Background(), "agent-1", testCSR(t, ""), "admin")
+	assert.ErrorIs(t, err, ErrCertificatesDisabled)
+	assert.ErrorIs(t, svc.CheckCSR("agent-1", testCSR(t, "")), ErrCertificatesDisabled)
+}
+
+func TestLoadCertificateAuthority(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "ca.crt")
+	keyFile := filepath.Join(dir, "ca.key")
+
+	ca, generated, err := LoadCertificateAuthority(certFile, keyFile)
+	require.NoError(t, err)
+	assert.True(t, generated)
+
+	reloaded, generated, err := LoadCertificateAuthority(certFile, keyFile)
+	require.NoError(t, err)
+	assert.False(t, generated)
+	assert.Equal(t, ca.CertificatePEM(), reloaded.CertificatePEM())
+
+	_, _, err = LoadCertificateAuthority(certFile, filepath.Join(dir, "missing.key"))
+	assert.Error(t, err)
+}
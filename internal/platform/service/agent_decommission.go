@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// ErrAgentNotFound Agent不存在或不在请求的项目内，处理器通过 errors.Is 映射为404
+var ErrAgentNotFound = errors.New("Agent不存在")
+
+// AgentDisconnector 关闭Agent的WebSocket连接并丢弃未确认的消息，由WebSocket连接管理实现
+type AgentDisconnector interface {
+	Disconnect(agentID, reason string) bool
+}
+
+// AgentDecommissioner Agent注销服务
+// 注销的Agent写入归档后从Agent列表删除，关闭连接、停止心跳巡检并丢弃待领取的命令，
+// 不再以永久离线的状态留在列表中；事件时间线保留在事件索引中
+type AgentDecommissioner struct {
+	agentRepo    repository.AgentRepository
+	archiveRepo  repository.AgentArchiveRepository
+	commands     *CommandQueue
+	liveness     *LivenessMonitor
+	events       AgentEventService // 未设置时不记录注销事件
+	disconnector AgentDisconnector // 未设置时不关闭连接
+	logger       *logrus.Logger
+	now          func() time.Time
+}
+
+// NewAgentDecommissioner 创建Agent注销服务
+func NewAgentDecommissioner(agentRepo repository.AgentRepository, archiveRepo repository.AgentArchiveRepository, commands *CommandQueue, liveness *LivenessMonitor, logger *logrus.Logger) *AgentDecommissioner {
+	return &AgentDecommissioner{
+		agentRepo:   agentRepo,
+		archiveRepo: archiveRepo,
+		commands:    commands,
+		liveness:    liveness,
+		logger:      logger,
+		now:         time.Now,
+	}
+}
+
+// SetEventService 设置Agent事件时间线，注销时记录注销事件
+func (d *AgentDecommissioner) SetEventService(events AgentEventService) {
+	d.events = events
+}
+
+// SetDisconnector 设置WebSocket连接管理，注销时关闭Agent的连接
+func (d *AgentDecommissioner) SetDisconnector(disconnector AgentDisconnector) {
+	d.disconnector = disconnector
+}
+
+// Decommission 注销Agent，initiator为 operator 或 agent，返回写入的归档记录
+// 先写归档再删除，删除失败时归档记录保留，重试注销会产生新的归档记录
+func (d *AgentDecommissioner) Decommission(ctx context.Context, agentID, initiator, operator, reason string) (*models.ArchivedAgent, error) {
+	agent, err := d.agentRepo.GetByID(elasticsearch.WithPrimaryRead(ctx), agentID)
+	if err != nil {
+		if errors.Is(err, elasticsearch.ErrNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrAgentNotFound, agentID)
+		}
+		return nil, fmt.Errorf("获取Agent失败: %w", err)
+	}
+	if !models.InProject(ctx, agent.Project) {
+		return nil, fmt.Errorf("%w: %s", ErrAgentNotFound, agentID)
+	}
+
+	now := d.now()
+	previous := agent.Status
+	archived := &models.ArchivedAgent{
+		ArchiveID:        uuid.New().String(),
+		Agent:            *agent,
+		Initiator:        initiator,
+		DecommissionedBy: operator,
+		DecommissionedAt: now,
+		Reason:           reason,
+	}
+	archived.Status = models.AgentStatusDecommissioned
+
+	if err := d.archiveRepo.Save(ctx, archived); err != nil {
+		return nil, err
+	}
+	if err := d.agentRepo.Delete(ctx, agentID); err != nil {
+		return nil, err
+	}
+
+	if d.disconnector != nil {
+		d.disconnector.Disconnect(agentID, "Agent已注销")
+	}
+	if d.liveness != nil {
+		d.liveness.Forget(agentID)
+	}
+	d.commands.Drop(agentID)
+
+	if d.events != nil {
+		if err := d.events.Record(ctx, &models.AgentEvent{
+			AgentID:       agentID,
+			Type:          models.AgentEventDecommissioned,
+			From:          previous,
+			To:            models.AgentStatusDecommissioned,
+			Reason:        reason,
+			LastHeartbeat: agent.LastHeartbeat,
+			CreatedAt:     now,
+		}); err != nil {
+			d.logger.WithError(err).WithField("agent_id", agentID).Warn("写入Agent事件失败")
+		}
+	}
+
+	d.logger.WithFields(logrus.Fields{
+		"agent_id":  agentID,
+		"hostname":  agent.Hostname,
+		"initiator": initiator,
+		"operator":  operator,
+		"reason":    reason,
+	}).Info("Agent已注销并归档")
+	return archived, nil
+}
+
+// ListArchived 获取请求所属项目的已注销Agent
+func (d *AgentDecommissioner) ListArchived(ctx context.Context, req *models.ArchivedAgentListRequest) (*models.ArchivedAgentListResponse, error) {
+	req.Project = models.ProjectFrom(ctx)
+	return d.archiveRepo.List(ctx, req)
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+)
+
+type memAgentArchiveRepository struct {
+	mu       sync.Mutex
+	archived []*models.ArchivedAgent
+}
+
+func (r *memAgentArchiveRepository) Save(ctx context.Context, archived *models.ArchivedAgent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.archived = append(r.archived, archived)
+	return nil
+}
+
+func (r *memAgentArchiveRepository) List(ctx context.Context, req *models.ArchivedAgentListRequest) (*models.ArchivedAgentListResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	resp := &models.ArchivedAgentListResponse{Page: req.Page, Size: req.PageSize}
+	for _, a := range r.archived {
+		if req.Project != "" && a.Project != req.Project {
+			continue
+		}
+		resp.Items = append(resp.Items, a)
+	}
+	resp.Total = int64(len(resp.Items))
+	return resp, nil
+}
+
+type recordingDisconnector struct {
+	agents []string
+}
+
+func (d *recordingDisconnector) Disconnect(agentID, reason string) bool {
+	d.agents = append(d.agents, agentID)
+	return true
+}
+
+func TestAgentDecommissioner(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	agents := &memAgentRepository{agents: map[string]*models.Agent{
+		"agent-1": {AgentID: "agent-1", Hostname: "web-1", Status: models.AgentStatusOnline, Project: "payments"},
+		"agent-2": {AgentID: "agent-2", Hostname: "web-2", Status: models.AgentStatusOffline},
+	}}
+	archive := &memAgentArchiveRepository{}
+	events := &memAgentEventRepository{}
+	commands := NewCommandQueue(0)
+	disconnector := &recordingDisconnector{}
+
+	d := NewAgentDecommissioner(agents, archive, commands,
+		NewLivenessMonitor(LivenessConfig{OfflineAfter: time.Minute}, agents, events, logger), logger)
+	d.SetEventService(NewAgentEventService(events, agents, logger))
+	d.SetDisconnector(disconnector)
+	d.now = func() time.Time { return now }
+
+	t.Run("其他项目的Agent视为不存在", func(t *testing.T) {
+		_, err := d.Decommission(models.WithProject(context.Background(), "search"), "agent-1", models.DecommissionByOperator, "admin", "")
+		assert.ErrorIs(t, err, ErrAgentNotFound)
+		assert.Contains(t, agents.agents, "agent-1")
+	})
+
+	t.Run("管理员注销：归档、删除并关闭连接", func(t *testing.T) {
+		require.NoError(t, commands.Publish("agent-1", "restart", nil))
+
+		archived, err := d.Decommission(context.Background(), "agent-1", models.DecommissionByOperator, "admin", "主机下线")
+		require.NoError(t, err)
+		assert.NotEmpty(t, archived.ArchiveID)
+		assert.Equal(t, models.AgentStatusDecommissioned, archived.Status)
+		assert.Equal(t, "web-1", archived.Hostname)
+		assert.Equal(t, "admin", archived.DecommissionedBy)
+		assert.Equal(t, now, archived.DecommissionedAt)
+
+		assert.NotContains(t, agents.agents, "agent-1")
+		assert.Equal(t, []string{"agent-1"}, disconnector.agents)
+		assert.Zero(t, commands.Pending("agent-1"))
+
+		recorded, _ := events.ListByAgent(context.Background(), "agent-1", 0)
+		require.Len(t, recorded, 1)
+		assert.Equal(t, models.AgentEventDecommissioned, recorded[0].Type)
+		assert.Equal(t, models.AgentStatusOnline, recorded[0].From)
+		assert.Equal(t, "主机下线", recorded[0].Reason)
+	})
+
+	t.Run("已注销的Agent返回不存在", func(t *testing.T) {
+		_, err := d.Decommission(context.Background(), "agent-1", models.DecommissionByAgent, "agent-1", "")
+		assert.ErrorIs(t, err, ErrAgentNotFound)
+	})
+
+	t.Run("归档列表按请求的项目过滤", func(t *testing.T) {
+		_, err := d.Decommission(context.Background(), "agent-2", models.DecommissionByAgent, "agent-2", "Agent关闭")
+		require.NoError(t, err)
+
+		resp, err := d.ListArchived(models.WithProject(context.Background(), "payments"), &models.ArchivedAgentListRequest{Page: 1, PageSize: 20})
+		require.NoError(t, err)
+		require.Len(t, resp.Items, 1)
+		assert.Equal(t, "agent-1", resp.Items[0].AgentID)
+
+		resp, err = d.ListArchived(context.Background(), &models.ArchivedAgentListRequest{Page: 1, PageSize: 20})
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, resp.Total)
+	})
+}
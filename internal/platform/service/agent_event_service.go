@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+)
+
+// AgentEventService Agent事件时间线服务接口
+// 状态变更由在线状态监测写入，注册、配置应用及Agent自身上报的事件经由本服务写入
+type AgentEventService interface {
+	// Record 写入一条事件，未设置ID和时间时自动补全
+	Record(ctx context.Context, event *models.AgentEvent) error
+	// Report 记录Agent自身上报的事件
+	Report(ctx context.Context, agentID string, report *models.AgentEventReport) error
+	// ListEvents 获取范围内最近的事件，按时间先后排列
+	ListEvents(ctx context.Context, agentID string, req *models.AgentEventListRequest) ([]*models.AgentEvent, error)
+}
+
+// agentEventService Agent事件时间线服务实现
+type agentEventService struct {
+	eventRepo repository.AgentEventRepository
+	agentRepo repository.AgentRepository
+	logger    *logrus.Logger
+}
+
+// NewAgentEventService 创建Agent事件时间线服务
+func NewAgentEventService(eventRepo repository.AgentEventRepository, agentRepo repository.AgentRepository, logger *logrus.Logger) AgentEventService {
+	return &agentEventService{
+		eventRepo: eventRepo,
+		agentRepo: agentRepo,
+		logger:    logger,
+	}
+}
+
+// Record 写入事件，未设置最近心跳时取Agent当前的心跳时间
+func (s *agentEventService) Record(ctx context.Context, event *models.AgentEvent) error {
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	if event.LastHeartbeat.IsZero() {
+		if agent, err := s.agentRepo.GetByID(ctx, event.AgentID); err == nil {
+			event.LastHeartbeat = agent.LastHeartbeat
+		}
+	}
+	if err := s.eventRepo.Save(ctx, event); err != nil {
+		return err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"agent_id": event.AgentID,
+		"type":     event.Type,
+	}).Debug("记录Agent事件")
+	return nil
+}
+
+// Report 记录Agent上报的重载失败、Logstash崩溃和重启事件
+// 资源超过阈值和恢复事件同时更新Agent的warning状态
+func (s *agentEventService) Report(ctx context.Context, agentID string, report *models.AgentEventReport) error {
+	agent, err := s.agentRepo.GetByID(ctx, agentID)
+	if err != nil {
+		return fmt.Errorf("Agent不存在: %w", err)
+	}
+
+	if applyResourcePressure(agent, report) {
+		if err := s.agentRepo.Save(ctx, agent); err != nil {
+			return err
+		}
+	}
+
+	return s.Record(ctx, &models.AgentEvent{
+		AgentID:       agentID,
+		Type:          report.Type,
+		Reason:        report.Reason,
+		ConfigID:      report.ConfigID,
+		LastHeartbeat: agent.LastHeartbeat,
+		CreatedAt:     report.Timestamp,
+	})
+}
+
+// applyResourcePressure 按资源事件设置或清除Agent的资源告警，返回Agent是否需要保存
+// 离线、降级等状态优先，仅在online与warning之间切换
+func applyResourcePressure(agent *models.Agent, report *models.AgentEventReport) bool {
+	switch report.Type {
+	case models.AgentEventResourcePressure:
+		since := report.Timestamp
+		if since.IsZero() {
+			since = time.Now()
+		}
+		agent.ResourcePressure = &models.ResourcePressure{Reasons: strings.Split(report.Reason, "；"), Since: since}
+		if agent.Status == models.AgentStatusOnline {
+			agent.Status = models.AgentStatusWarning
+		}
+	case models.AgentEventResourceRecovered:
+		agent.ResourcePressure = nil
+		if agent.Status == models.AgentStatusWarning {
+			agent.Status = models.AgentStatusOnline
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// ListEvents 获取Agent的事件时间线
+func (s *agentEventService) ListEvents(ctx context.Context, agentID string, req *models.AgentEventListRequest) ([]*models.AgentEvent, error) {
+	if _, err := s.agentRepo.GetByID(ctx, agentID); err != nil {
+		return nil, fmt.Errorf("Agent不存在: %w", err)
+	}
+
+	events, err := s.eventRepo.List(ctx, agentID, req)
+	if err != nil {
+		return nil, err
+	}
+	// 仓库按时间倒序返回最近的事件，时间线按先后排列
+	slices.Reverse(events)
+	return events, nil
+}
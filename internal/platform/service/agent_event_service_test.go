@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+)
+
+func TestAgentEventService(t *testing.T) {
+	ctx := context.Background()
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	heartbeat := time.Now().Add(-5 * time.Second)
+	agents := &memAgentRepository{agents: map[string]*models.Agent{
+		"agent-1": {AgentID: "agent-1", Status: models.AgentStatusOnline, LastHeartbeat: heartbeat},
+	}}
+	events := &memAgentEventRepository{}
+	svc := NewAgentEventService(events, agents, logger)
+
+	require.NoError(t, svc.Record(ctx, &models.AgentEvent{AgentID: "agent-1", Type: models.AgentEventRegistered}))
+	require.NoError(t, svc.Report(ctx, "agent-1", &models.AgentEventReport{
+		Type: models.AgentEventLogstashCrashed, Reason: "exit status 137",
+	}))
+
+	t.Run("补全ID、时间和最近心跳", func(t *testing.T) {
+		recorded, _ := events.ListByAgent(ctx, "agent-1", 0)
+		require.Len(t, recorded, 2)
+		for _, e := range recorded {
+			assert.NotEmpty(t, e.ID)
+			assert.False(t, e.CreatedAt.IsZero())
+			assert.True(t, heartbeat.Equal(e.LastHeartbeat))
+		}
+		assert.Equal(t, "exit status 137", recorded[1].Reason)
+	})
+
+	t.Run("按类型过滤", func(t *testing.T) {
+		list, err := svc.ListEvents(ctx, "agent-1", &models.AgentEventListRequest{Type: models.AgentEventLogstashCrashed, Size: 10})
+		require.NoError(t, err)
+		require.Len(t, list, 1)
+		assert.Equal(t, models.AgentEventLogstashCrashed, list[0].Type)
+	})
+
+	t.Run("资源告警切换warning状态", func(t *testing.T) {
+		require.NoError(t, svc.Report(ctx, "agent-1", &models.AgentEventReport{
+			Type: models.AgentEventResourcePressure, Reason: "/data 所在磁盘使用率 93.5% 超过阈值 90%；主机内存使用率 96.0% 超过阈值 95%",
+		}))
+		agent, _ := agents.GetByID(ctx, "agent-1")
+		assert.Equal(t, models.AgentStatusWarning, agent.Status)
+		require.NotNil(t, agent.ResourcePressure)
+		assert.Len(t, agent.ResourcePressure.Reasons, 2)
+
+		require.NoError(t, svc.Report(ctx, "agent-1", &models.AgentEventReport{Type: models.AgentEventResourceRecovered}))
+		agent, _ = agents.GetByID(ctx, "agent-1")
+		assert.Equal(t, models.AgentStatusOnline, agent.Status)
+		assert.Nil(t, agent.ResourcePressure)
+	})
+
+	t.Run("未知Agent", func(t *testing.T) {
+		err := svc.Report(ctx, "missing", &models.AgentEventReport{Type: models.AgentEventReloadFailed})
+		assert.ErrorContains(t, err, "Agent不存在")
+		_, err = svc.ListEvents(ctx, "missing", &models.AgentEventListRequest{Size: 10})
+		assert.ErrorContains(t, err, "Agent不存在")
+	})
+}
+
+func TestDeploymentEngine_RecordsAppliedEvents(t *testing.T) {
+	ctx := context.Background()
+	engine, _, _ := newTestEngine(t, time.Second)
+
+	events := &memAgentEventRepository{}
+	agents := &memAgentRepository{agents: map[string]*models.Agent{"agent-1": {AgentID: "agent-1"}}}
+	engine.SetEventService(NewAgentEventService(events, agents, logrus.New()))
+
+	for _, report := range []*models.ConfigAppliedReport{
+		{ConfigID: "cfg-1", Version: 4, Status: "success"},
+		{ConfigID: "cfg-1", Version: 5, Status: "reload_queued"},
+		{ConfigID: "cfg-1", Version: 5, Status: "failed", Error: "plugin missing"},
+		{ConfigID: "cfg-1", Version: 6, Status: "apply_failed", Error: "Expected one of #"},
+	} {
+		require.NoError(t, engine.RecordResult(ctx, "agent-1", report))
+	}
+
+	// 重载排队的结果不记录，重载执行后的再次上报才记录
+	recorded, _ := events.ListByAgent(ctx, "agent-1", 0)
+	require.Len(t, recorded, 3)
+	assert.Equal(t, models.AgentEventConfigApplied, recorded[0].Type)
+	assert.Equal(t, 4, recorded[0].ConfigVersion)
+	assert.Equal(t, models.AgentEventConfigApplyFailed, recorded[1].Type)
+	assert.Equal(t, "plugin missing", recorded[1].Reason)
+	assert.Equal(t, models.AgentEventConfigApplyFailed, recorded[2].Type, "验证失败并已恢复的上报同样记为应用失败")
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"logstash-platform/internal/platform/models"
+)
+
+// defaultAgentPageSize 指定 page 或 cursor 而未指定 size 时每页的Agent数
+const defaultAgentPageSize = 100
+
+// agentListBatchSize 未分页时逐页读取全部Agent的每页大小
+const agentListBatchSize = 1000
+
+// PageAgents 按过滤条件、排序和分页获取请求所属项目的Agent，未指定 page、size 和 cursor 时返回全部Agent
+// 过滤、排序和分页由Agent仓库在ES中完成；返回的状态按最近心跳推导，与过滤使用的持久化状态最多相差一个巡检周期
+func (m *LivenessMonitor) PageAgents(ctx context.Context, req *models.AgentListRequest) (*models.AgentListResponse, error) {
+	query := *req
+	query.Project = models.ProjectFrom(ctx)
+	if query.Cursor != "" {
+		after, err := decodeAgentCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		query.After = after
+		query.Page = 0
+	}
+
+	var resp *models.AgentListResponse
+	if query.PageSize == 0 && query.Page == 0 && query.Cursor == "" {
+		all, err := m.listAllAgents(ctx, &query)
+		if err != nil {
+			return nil, err
+		}
+		resp = all
+	} else {
+		if query.PageSize == 0 {
+			query.PageSize = defaultAgentPageSize
+		}
+		page, err := m.agentRepo.List(ctx, &query)
+		if err != nil {
+			return nil, err
+		}
+		resp = page
+	}
+
+	now := m.now()
+	for _, agent := range resp.Items {
+		agent.Status = m.Status(agent, now)
+	}
+	return resp, nil
+}
+
+// listAllAgents 按游标逐页读取匹配的全部Agent
+func (m *LivenessMonitor) listAllAgents(ctx context.Context, query *models.AgentListRequest) (*models.AgentListResponse, error) {
+	query.PageSize = agentListBatchSize
+	resp := &models.AgentListResponse{Items: make([]*models.Agent, 0)}
+	for {
+		page, err := m.agentRepo.List(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		resp.Items = append(resp.Items, page.Items...)
+		resp.Total = page.Total
+		if page.NextCursor == "" {
+			return resp, nil
+		}
+		if query.After, err = decodeAgentCursor(page.NextCursor); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// decodeAgentCursor 解析Agent列表游标：排序字段值和Agent ID
+func decodeAgentCursor(cursor string) ([]interface{}, error) {
+	values, err := models.DecodeCursor(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+	if len(values) != 2 {
+		return nil, fmt.Errorf("%w: 排序值个数不正确", ErrInvalidCursor)
+	}
+	return values, nil
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/tests/mocks"
+)
+
+func TestLivenessMonitor_PageAgents(t *testing.T) {
+	now := time.Now()
+
+	t.Run("未分页时逐页读取全部，状态按最近心跳推导", func(t *testing.T) {
+		repo := &memAgentRepository{agents: make(map[string]*models.Agent)}
+		for i := 0; i < agentListBatchSize+5; i++ {
+			id := fmt.Sprintf("agent-%05d", i)
+			repo.agents[id] = &models.Agent{AgentID: id, Status: models.AgentStatusOnline, LastHeartbeat: now}
+		}
+		repo.agents["agent-00000"].LastHeartbeat = now.Add(-time.Hour)
+		monitor := NewLivenessMonitor(LivenessConfig{OfflineAfter: time.Minute}, repo, nil, logrus.New())
+
+		resp, err := monitor.PageAgents(context.Background(), &models.AgentListRequest{})
+		require.NoError(t, err)
+		assert.Len(t, resp.Items, agentListBatchSize+5)
+		assert.Equal(t, agentListBatchSize+5, resp.Total)
+		assert.Empty(t, resp.NextCursor)
+		assert.Equal(t, models.AgentStatusOffline, resp.Items[0].Status)
+		assert.Equal(t, models.AgentStatusOnline, resp.Items[1].Status)
+	})
+
+	t.Run("过滤条件、项目和游标传给仓库", func(t *testing.T) {
+		repo := new(mocks.MockAgentRepository)
+		cursor := models.EncodeCursor([]interface{}{"web-1", "a2"})
+		repo.On("List", mock.Anything, mock.MatchedBy(func(req *models.AgentListRequest) bool {
+			return req.Status == models.AgentStatusOnline && req.Query == "web" && req.Project == "payments" &&
+				req.PageSize == defaultAgentPageSize && assert.ObjectsAreEqual([]interface{}{"web-1", "a2"}, req.After)
+		})).Return(&models.AgentListResponse{Items: []*models.Agent{{AgentID: "a3", LastHeartbeat: now}}, Total: 3}, nil)
+		monitor := NewLivenessMonitor(LivenessConfig{OfflineAfter: time.Minute}, repo, nil, logrus.New())
+
+		ctx := models.WithProject(context.Background(), "payments")
+		resp, err := monitor.PageAgents(ctx, &models.AgentListRequest{Status: models.AgentStatusOnline, Query: "web", Cursor: cursor})
+		require.NoError(t, err)
+		assert.Equal(t, 3, resp.Total)
+		require.Len(t, resp.Items, 1)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("无效游标", func(t *testing.T) {
+		monitor := NewLivenessMonitor(LivenessConfig{OfflineAfter: time.Minute}, new(mocks.MockAgentRepository), nil, logrus.New())
+		_, err := monitor.PageAgents(context.Background(), &models.AgentListRequest{Cursor: "not-a-cursor"})
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+}
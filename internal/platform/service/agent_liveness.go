@@ -0,0 +1,325 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// missedHeartbeats 平台协商了心跳间隔下限时，至少允许错过的心跳次数
+const missedHeartbeats = 3
+
+// LivenessConfig Agent存活检测参数
+type LivenessConfig struct {
+	OfflineAfter  time.Duration // 心跳超过该时间未更新时判定离线
+	DegradedAfter time.Duration // 心跳超过该时间未更新时判定降级，0表示不启用
+	Interval      time.Duration // 扫描间隔
+}
+
+// LivenessMonitor 按最近心跳时间推导Agent状态
+// 定期扫描全部Agent，将心跳过期的Agent标记为离线（或降级）并写入Agent事件日志；
+// 心跳恢复时由心跳处理将状态改回online，扫描发现后同样记录事件
+type LivenessMonitor struct {
+	cfg       LivenessConfig
+	agentRepo repository.AgentRepository
+	eventRepo repository.AgentEventRepository
+	telemetry *TelemetryPolicy
+	emitter   EventEmitter // 未设置时不发布Agent离线事件
+	logger    *logrus.Logger
+	now       func() time.Time
+
+	mu           sync.Mutex
+	seeded       bool
+	observed     map[string]string    // 上一次扫描时各Agent的状态
+	disconnected map[string]time.Time // 经WebSocket发送心跳的Agent断开连接的时间，之后收到心跳前判定为离线
+
+	tracker loopTracker
+}
+
+// NewLivenessMonitor 创建Agent存活检测
+func NewLivenessMonitor(cfg LivenessConfig, agentRepo repository.AgentRepository, eventRepo repository.AgentEventRepository, logger *logrus.Logger) *LivenessMonitor {
+	if cfg.OfflineAfter <= 0 {
+		cfg.OfflineAfter = 90 * time.Second
+	}
+	if cfg.DegradedAfter >= cfg.OfflineAfter {
+		cfg.DegradedAfter = 0
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 30 * time.Second
+	}
+	return &LivenessMonitor{
+		cfg:       cfg,
+		agentRepo: agentRepo,
+		eventRepo: eventRepo,
+		logger:    logger,
+		now:       time.Now,
+		observed:  make(map[string]string),
+		tracker:   loopTracker{interval: cfg.Interval},
+
+		disconnected: make(map[string]time.Time),
+	}
+}
+
+// SetEventEmitter 设置平台事件的发布方，之后Agent被判定离线时发布 agent.offline
+func (m *LivenessMonitor) SetEventEmitter(emitter EventEmitter) {
+	m.emitter = emitter
+}
+
+// SetTelemetryPolicy 设置间隔协商策略，平台要求Agent拉长心跳间隔时相应放宽判定阈值
+func (m *LivenessMonitor) SetTelemetryPolicy(policy *TelemetryPolicy) {
+	m.telemetry = policy
+}
+
+// Start 按间隔执行扫描，直到ctx取消
+func (m *LivenessMonitor) Start(ctx context.Context) {
+	defer m.tracker.start(m.now())()
+	ticker := time.NewTicker(m.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		done := m.tracker.begin(m.now())
+		_, err := m.Scan(ctx)
+		done(err)
+		if err != nil && ctx.Err() == nil {
+			m.logger.Errorf("扫描Agent心跳失败: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// WorkerStatus 报告心跳扫描的运行状态
+func (m *LivenessMonitor) WorkerStatus(now time.Time) models.WorkerStatus {
+	return m.tracker.status("liveness_monitor", now)
+}
+
+// Disconnected 经WebSocket发送心跳的Agent断开连接时立即判定为离线并写入Agent事件日志，
+// 不必等待心跳过期；之后收到心跳（降级到HTTP或重新连接）时恢复
+func (m *LivenessMonitor) Disconnected(ctx context.Context, agentID string) error {
+	now := m.now()
+	m.mu.Lock()
+	m.disconnected[agentID] = now
+	m.mu.Unlock()
+
+	agent, err := m.agentRepo.GetByID(ctx, agentID)
+	if err != nil {
+		return err
+	}
+	from := agent.Status
+	if from == models.AgentStatusOffline {
+		return nil
+	}
+	changed, err := m.transition(ctx, agentID, models.AgentStatusOffline, now)
+	if err != nil || changed == nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.observed[agentID] = models.AgentStatusOffline
+	m.mu.Unlock()
+	m.record(ctx, changed, from, models.AgentStatusOffline, now)
+	return nil
+}
+
+// Forget 移除已注销Agent的巡检记录，之后的扫描不再包含该Agent
+func (m *LivenessMonitor) Forget(agentID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.observed, agentID)
+	delete(m.disconnected, agentID)
+}
+
+// Status 根据最近心跳时间推导Agent当前状态
+// 心跳未过期时保留存储的状态（如error），降级/离线状态以心跳为准
+func (m *LivenessMonitor) Status(agent *models.Agent, now time.Time) string {
+	offlineAfter, degradedAfter := m.thresholds()
+	age := now.Sub(agent.LastHeartbeat)
+
+	switch {
+	case age > offlineAfter || m.disconnectedSince(agent):
+		return models.AgentStatusOffline
+	case degradedAfter > 0 && age > degradedAfter:
+		return models.AgentStatusDegraded
+	case agent.Status == models.AgentStatusOffline || agent.Status == models.AgentStatusDegraded:
+		return models.AgentStatusOnline
+	default:
+		return agent.Status
+	}
+}
+
+// disconnectedSince Agent在最近一次心跳之后断开了WebSocket连接
+func (m *LivenessMonitor) disconnectedSince(agent *models.Agent) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	at, ok := m.disconnected[agent.AgentID]
+	return ok && !agent.LastHeartbeat.After(at)
+}
+
+// ListAgents 获取请求所属项目的全部Agent，状态为按心跳推导的当前状态
+func (m *LivenessMonitor) ListAgents(ctx context.Context) ([]*models.Agent, error) {
+	agents, err := m.agentRepo.ListByLabels(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	now := m.now()
+	scoped := agents[:0]
+	for _, agent := range agents {
+		if !models.InProject(ctx, agent.Project) {
+			continue
+		}
+		agent.Status = m.Status(agent, now)
+		scoped = append(scoped, agent)
+	}
+	return scoped, nil
+}
+
+// Scan 扫描全部Agent并持久化心跳过期引起的状态变化，返回记录的事件数
+func (m *LivenessMonitor) Scan(ctx context.Context) (int, error) {
+	agents, err := m.agentRepo.ListByLabels(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	now := m.now()
+	m.mu.Lock()
+	seeded := m.seeded
+	previous := m.observed
+	m.mu.Unlock()
+
+	observed := make(map[string]string, len(agents))
+	events := 0
+	for _, agent := range agents {
+		if status := m.Status(agent, now); status != agent.Status &&
+			(status == models.AgentStatusOffline || status == models.AgentStatusDegraded) {
+			changed, err := m.transition(ctx, agent.AgentID, status, now)
+			if err != nil {
+				m.logger.WithError(err).WithField("agent_id", agent.AgentID).Warn("更新Agent状态失败")
+			} else if changed != nil {
+				observed[agent.AgentID] = status
+				if m.record(ctx, changed, agent.Status, status, now) {
+					events++
+				}
+				continue
+			}
+		}
+
+		// 心跳处理恢复的状态没有经过扫描，与上一次扫描的结果比较得出
+		observed[agent.AgentID] = agent.Status
+		if last, ok := previous[agent.AgentID]; seeded && ok && last != agent.Status && m.record(ctx, agent, last, agent.Status, now) {
+			events++
+		}
+	}
+
+	m.mu.Lock()
+	m.seeded = true
+	m.observed = observed
+	m.pruneDisconnected(agents)
+	m.mu.Unlock()
+
+	if events > 0 {
+		m.logger.WithField("events", events).Info("Agent状态变化")
+	}
+	return events, nil
+}
+
+// pruneDisconnected 清理断开后已收到心跳或已按心跳过期判定离线的记录，调用方持有m.mu
+func (m *LivenessMonitor) pruneDisconnected(agents []*models.Agent) {
+	offlineAfter, _ := m.thresholds()
+	for _, agent := range agents {
+		if at, ok := m.disconnected[agent.AgentID]; ok &&
+			(agent.LastHeartbeat.After(at) || m.now().Sub(agent.LastHeartbeat) > offlineAfter) {
+			delete(m.disconnected, agent.AgentID)
+		}
+	}
+}
+
+// transition 以主分片上的最新数据重新判定并保存状态，心跳已恢复时返回nil
+func (m *LivenessMonitor) transition(ctx context.Context, agentID, status string, now time.Time) (*models.Agent, error) {
+	agent, err := m.agentRepo.GetByID(elasticsearch.WithPrimaryRead(ctx), agentID)
+	if err != nil {
+		return nil, err
+	}
+	if m.Status(agent, now) != status {
+		return nil, nil
+	}
+
+	agent.Status = status
+	if err := m.agentRepo.Save(ctx, agent); err != nil {
+		return nil, err
+	}
+	return agent, nil
+}
+
+// record 写入状态变更事件，失败时只记录日志
+func (m *LivenessMonitor) record(ctx context.Context, agent *models.Agent, from, to string, now time.Time) bool {
+	event := &models.AgentEvent{
+		ID:            uuid.New().String(),
+		AgentID:       agent.AgentID,
+		Type:          models.AgentEventStatusChanged,
+		From:          from,
+		To:            to,
+		Reason:        m.reason(agent, from, to, now),
+		LastHeartbeat: agent.LastHeartbeat,
+		CreatedAt:     now,
+	}
+	if err := m.eventRepo.Save(ctx, event); err != nil {
+		m.logger.WithError(err).WithField("agent_id", agent.AgentID).Warn("写入Agent事件失败")
+		return false
+	}
+
+	m.logger.WithFields(logrus.Fields{
+		"agent_id": agent.AgentID,
+		"from":     from,
+		"to":       to,
+	}).Info("Agent状态变更")
+	if to == models.AgentStatusOffline && m.emitter != nil {
+		m.emitter.Emit(ctx, models.WebhookEventAgentOffline, map[string]interface{}{
+			"agent": agent,
+			"event": event,
+		})
+	}
+	return true
+}
+
+// reason 状态变更原因
+func (m *LivenessMonitor) reason(agent *models.Agent, from, to string, now time.Time) string {
+	switch {
+	case to == models.AgentStatusOffline && m.disconnectedSince(agent):
+		return "WebSocket连接已断开"
+	case to == models.AgentStatusOffline || to == models.AgentStatusDegraded:
+		return fmt.Sprintf("心跳已%s未更新", now.Sub(agent.LastHeartbeat).Round(time.Second))
+	case from == models.AgentStatusOffline || from == models.AgentStatusDegraded:
+		return "心跳恢复"
+	default:
+		return ""
+	}
+}
+
+// thresholds 判定阈值，平台协商的心跳间隔下限较大时放宽到至少错过missedHeartbeats次心跳
+func (m *LivenessMonitor) thresholds() (offlineAfter, degradedAfter time.Duration) {
+	offlineAfter, degradedAfter = m.cfg.OfflineAfter, m.cfg.DegradedAfter
+	if m.telemetry == nil {
+		return offlineAfter, degradedAfter
+	}
+
+	floor := m.telemetry.HeartbeatFloor()
+	if min := missedHeartbeats * floor; min > offlineAfter {
+		if degradedAfter > 0 {
+			degradedAfter += min - offlineAfter
+		}
+		offlineAfter = min
+	}
+	return offlineAfter, degradedAfter
+}
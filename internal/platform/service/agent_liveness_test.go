@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+)
+
+// memAgentEventRepository 内存中的Agent事件日志
+type memAgentEventRepository struct {
+	mu     sync.Mutex
+	events []*models.AgentEvent
+}
+
+func (r *memAgentEventRepository) Save(ctx context.Context, event *models.AgentEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cp := *event
+	r.events = append(r.events, &cp)
+	return nil
+}
+
+func (r *memAgentEventRepository) ListByAgent(ctx context.Context, agentID string, size int) ([]*models.AgentEvent, error) {
+	return r.List(ctx, agentID, &models.AgentEventListRequest{Size: size})
+}
+
+func (r *memAgentEventRepository) List(ctx context.Context, agentID string, req *models.AgentEventListRequest) ([]*models.AgentEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var events []*models.AgentEvent
+	for _, e := range r.events {
+		if e.AgentID == agentID && (req.Type == "" || e.Type == req.Type) {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func TestLivenessMonitor_Scan(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	ctx := context.Background()
+
+	now := time.Now()
+	repo := &memAgentRepository{agents: map[string]*models.Agent{
+		"fresh":    {AgentID: "fresh", Status: "online", LastHeartbeat: now.Add(-10 * time.Second)},
+		"late":     {AgentID: "late", Status: "online", LastHeartbeat: now.Add(-45 * time.Second)},
+		"stale":    {AgentID: "stale", Status: "online", LastHeartbeat: now.Add(-2 * time.Minute)},
+		"gone":     {AgentID: "gone", Status: "offline", LastHeartbeat: now.Add(-time.Hour)},
+		"erroring": {AgentID: "erroring", Status: "error", LastHeartbeat: now},
+	}}
+	events := &memAgentEventRepository{}
+	monitor := NewLivenessMonitor(LivenessConfig{OfflineAfter: time.Minute, DegradedAfter: 30 * time.Second}, repo, events, logger)
+	monitor.now = func() time.Time { return now }
+
+	count, err := monitor.Scan(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	assert.Equal(t, models.AgentStatusOnline, repo.agents["fresh"].Status)
+	assert.Equal(t, models.AgentStatusDegraded, repo.agents["late"].Status)
+	assert.Equal(t, models.AgentStatusOffline, repo.agents["stale"].Status)
+	assert.Equal(t, "error", repo.agents["erroring"].Status)
+
+	stale, _ := events.ListByAgent(ctx, "stale", 0)
+	require.Len(t, stale, 1)
+	assert.Equal(t, models.AgentEventStatusChanged, stale[0].Type)
+	assert.Equal(t, "online", stale[0].From)
+	assert.Equal(t, models.AgentStatusOffline, stale[0].To)
+	assert.Contains(t, stale[0].Reason, "2m0s")
+
+	t.Run("状态未变化时不重复记录", func(t *testing.T) {
+		count, err := monitor.Scan(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, count)
+	})
+
+	t.Run("心跳恢复后记录事件", func(t *testing.T) {
+		repo.agents["stale"].Status = models.AgentStatusOnline
+		repo.agents["stale"].LastHeartbeat = now
+
+		count, err := monitor.Scan(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+
+		stale, _ := events.ListByAgent(ctx, "stale", 0)
+		require.Len(t, stale, 2)
+		assert.Equal(t, models.AgentStatusOffline, stale[1].From)
+		assert.Equal(t, models.AgentStatusOnline, stale[1].To)
+		assert.Equal(t, "心跳恢复", stale[1].Reason)
+	})
+}
+
+func TestLivenessMonitor_Status(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	now := time.Now()
+
+	monitor := NewLivenessMonitor(LivenessConfig{OfflineAfter: time.Minute}, nil, nil, logger)
+	agent := &models.Agent{Status: "offline", LastHeartbeat: now.Add(-30 * time.Second)}
+	assert.Equal(t, models.AgentStatusOnline, monitor.Status(agent, now))
+	agent.LastHeartbeat = now.Add(-2 * time.Minute)
+	assert.Equal(t, models.AgentStatusOffline, monitor.Status(agent, now))
+
+	// 平台要求Agent拉长心跳间隔时，阈值放宽到至少错过3次心跳
+	repo := newFleet(1000)
+	policy := NewTelemetryPolicy(TelemetryPolicyConfig{HeartbeatRate: 10}, repo, NewCommandQueue(0), logger)
+	_, err := policy.Evaluate(context.Background())
+	require.NoError(t, err)
+	monitor.SetTelemetryPolicy(policy)
+	assert.Equal(t, models.AgentStatusOnline, monitor.Status(agent, now))
+	agent.LastHeartbeat = now.Add(-6 * time.Minute)
+	assert.Equal(t, models.AgentStatusOffline, monitor.Status(agent, now))
+}
+
+func TestLivenessMonitor_Disconnected(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	ctx := context.Background()
+
+	now := time.Now()
+	repo := &memAgentRepository{agents: map[string]*models.Agent{
+		"agent-1": {AgentID: "agent-1", Status: "online", LastHeartbeat: now.Add(-5 * time.Second)},
+	}}
+	events := &memAgentEventRepository{}
+	monitor := NewLivenessMonitor(LivenessConfig{OfflineAfter: time.Minute}, repo, events, logger)
+	monitor.now = func() time.Time { return now }
+
+	// 断开连接时不等待心跳过期，立即判定离线
+	require.NoError(t, monitor.Disconnected(ctx, "agent-1"))
+	assert.Equal(t, models.AgentStatusOffline, repo.agents["agent-1"].Status)
+	assert.Equal(t, models.AgentStatusOffline, monitor.Status(repo.agents["agent-1"], now))
+
+	recorded, _ := events.ListByAgent(ctx, "agent-1", 0)
+	require.Len(t, recorded, 1)
+	assert.Equal(t, "online", recorded[0].From)
+	assert.Equal(t, models.AgentStatusOffline, recorded[0].To)
+	assert.Equal(t, "WebSocket连接已断开", recorded[0].Reason)
+
+	count, err := monitor.Scan(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, count, "扫描不重复记录")
+
+	// 降级到HTTP的心跳恢复在线
+	now = now.Add(10 * time.Second)
+	repo.agents["agent-1"].Status = models.AgentStatusOnline
+	repo.agents["agent-1"].LastHeartbeat = now
+	assert.Equal(t, models.AgentStatusOnline, monitor.Status(repo.agents["agent-1"], now))
+
+	count, err = monitor.Scan(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	recorded, _ = events.ListByAgent(ctx, "agent-1", 0)
+	require.Len(t, recorded, 2)
+	assert.Equal(t, "心跳恢复", recorded[1].Reason)
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+)
+
+// AgentService Agent服务接口
+type AgentService interface {
+	Register(ctx context.Context, agent *models.Agent) error
+	GetAgent(ctx context.Context, agentID string) (*models.Agent, error)
+	Heartbeat(ctx context.Context, agentID string, acked []string) (*models.HeartbeatResponse, error)
+	EnqueueCommand(ctx context.Context, agentID string, req *models.EnqueueCommandRequest) error
+	SelectAgents(ctx context.Context, selector string) ([]*models.Agent, error)
+	RecordApplied(ctx context.Context, agentID string, applied models.AppliedConfig) error
+	RemoveApplied(ctx context.Context, agentID, configID string) error
+	RecordMetrics(ctx context.Context, agentID string, metrics *models.AgentMetrics) error
+}
+
+// agentService Agent服务实现
+type agentService struct {
+	agentRepo  repository.AgentRepository
+	configRepo repository.ConfigRepository
+	commands   *CommandQueue
+	logger     *logrus.Logger
+}
+
+// NewAgentService 创建Agent服务
+func NewAgentService(agentRepo repository.AgentRepository, configRepo repository.ConfigRepository, commands *CommandQueue, logger *logrus.Logger) AgentService {
+	return &agentService{
+		agentRepo:  agentRepo,
+		configRepo: configRepo,
+		commands:   commands,
+		logger:     logger,
+	}
+}
+
+// Register 注册Agent
+// 重复注册时保留平台侧维护的分组、设置覆盖和CMDB元数据
+func (s *agentService) Register(ctx context.Context, agent *models.Agent) error {
+	if agent.AgentID == "" {
+		return fmt.Errorf("Agent ID不能为空")
+	}
+
+	if existing, err := s.agentRepo.GetByID(ctx, agent.AgentID); err == nil {
+		if agent.Group == "" {
+			agent.Group = existing.Group
+		}
+		agent.Settings = existing.Settings
+		agent.Metadata = existing.Metadata
+		agent.MetadataSyncedAt = existing.MetadataSyncedAt
+		agent.Metrics = existing.Metrics
+	}
+
+	agent.Status = "online"
+	agent.LastHeartbeat = time.Now()
+
+	if err := s.agentRepo.Save(ctx, agent); err != nil {
+		return err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"agent_id": agent.AgentID,
+		"hostname": agent.Hostname,
+		"group":    agent.Group,
+	}).Info("Agent注册成功")
+
+	return nil
+}
+
+// GetAgent 获取Agent
+func (s *agentService) GetAgent(ctx context.Context, agentID string) (*models.Agent, error) {
+	return s.agentRepo.GetByID(ctx, agentID)
+}
+
+// Heartbeat 处理Agent心跳，移除Agent已确认的命令并捎带返回其余待处理命令
+func (s *agentService) Heartbeat(ctx context.Context, agentID string, acked []string) (*models.HeartbeatResponse, error) {
+	agent, err := s.agentRepo.GetByID(ctx, agentID)
+	if err != nil {
+		return nil, fmt.Errorf("Agent不存在: %w", err)
+	}
+
+	agent.LastHeartbeat = time.Now()
+	if agent.Status == models.AgentStatusOffline || agent.Status == models.AgentStatusDegraded {
+		agent.Status = models.AgentStatusOnline
+	}
+	if err := s.agentRepo.Save(ctx, agent); err != nil {
+		return nil, err
+	}
+
+	// 先移除Agent确认的命令，未确认的命令再次下发
+	s.commands.Ack(agentID, acked)
+	resp := &models.HeartbeatResponse{
+		ServerTime: time.Now(),
+		Commands:   s.commands.Deliver(agentID),
+	}
+
+	if len(resp.Commands) > 0 {
+		s.logger.WithFields(logrus.Fields{
+			"agent_id": agentID,
+			"count":    len(resp.Commands),
+		}).Info("通过心跳下发命令")
+	}
+
+	return resp, nil
+}
+
+// EnqueueCommand 为Agent排入一条心跳命令
+func (s *agentService) EnqueueCommand(ctx context.Context, agentID string, req *models.EnqueueCommandRequest) error {
+	if _, err := s.agentRepo.GetByID(ctx, agentID); err != nil {
+		return fmt.Errorf("Agent不存在: %w", err)
+	}
+
+	return s.commands.Publish(agentID, req.Type, req.Payload)
+}
+
+// SelectAgents 获取匹配标签选择器的Agent
+// 等值条件交给ES预过滤，其余条件在内存中判断
+func (s *agentService) SelectAgents(ctx context.Context, selector string) ([]*models.Agent, error) {
+	sel, err := models.ParseLabelSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := s.agentRepo.ListByLabels(ctx, sel.EqualityTerms())
+	if err != nil {
+		return nil, err
+	}
+
+	agents := make([]*models.Agent, 0, len(candidates))
+	for _, agent := range candidates {
+		if sel.Matches(agent.SelectorLabels()) {
+			agents = append(agents, agent)
+		}
+	}
+
+	return agents, nil
+}
+
+// RecordApplied 记录Agent已应用的配置版本
+func (s *agentService) RecordApplied(ctx context.Context, agentID string, applied models.AppliedConfig) error {
+	agent, err := s.agentRepo.GetByID(ctx, agentID)
+	if err != nil {
+		return fmt.Errorf("Agent不存在: %w", err)
+	}
+
+	if applied.AppliedAt.IsZero() {
+		applied.AppliedAt = time.Now()
+	}
+
+	found := false
+	for i, ac := range agent.AppliedConfigs {
+		if ac.ConfigID == applied.ConfigID {
+			agent.AppliedConfigs[i] = applied
+			found = true
+			break
+		}
+	}
+	if !found {
+		agent.AppliedConfigs = append(agent.AppliedConfigs, applied)
+	}
+
+	return s.agentRepo.Save(ctx, agent)
+}
+
+// RemoveApplied 移除Agent已应用的配置，Agent确认删除配置后调用
+func (s *agentService) RemoveApplied(ctx context.Context, agentID, configID string) error {
+	agent, err := s.agentRepo.GetByID(ctx, agentID)
+	if err != nil {
+		return fmt.Errorf("Agent不存在: %w", err)
+	}
+
+	remaining := make([]models.AppliedConfig, 0, len(agent.AppliedConfigs))
+	for _, ac := range agent.AppliedConfigs {
+		if ac.ConfigID != configID {
+			remaining = append(remaining, ac)
+		}
+	}
+	if len(remaining) == len(agent.AppliedConfigs) {
+		return nil
+	}
+	agent.AppliedConfigs = remaining
+
+	return s.agentRepo.Save(ctx, agent)
+}
+
+// RecordMetrics 记录Agent最近一次上报的指标，部署前资源估算据此检查余量
+func (s *agentService) RecordMetrics(ctx context.Context, agentID string, metrics *models.AgentMetrics) error {
+	agent, err := s.agentRepo.GetByID(ctx, agentID)
+	if err != nil {
+		return fmt.Errorf("Agent不存在: %w", err)
+	}
+
+	if metrics.Timestamp.IsZero() {
+		metrics.Timestamp = time.Now()
+	}
+	agent.Metrics = metrics
+
+	return s.agentRepo.Save(ctx, agent)
+}
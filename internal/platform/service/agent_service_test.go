@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/tests/mocks"
+)
+
+func TestCommandQueue_DeliverAndAck(t *testing.T) {
+	queue := NewCommandQueue(2)
+
+	assert.NoError(t, queue.Publish("agent-1", models.MsgTypeLogLevel, map[string]string{"level": "debug"}))
+	assert.NoError(t, queue.Publish("agent-1", models.MsgTypeMaintenance, map[string]bool{"enabled": true}))
+	assert.NoError(t, queue.Publish("agent-1", models.MsgTypeSyncHint, nil))
+
+	// 超出上限时丢弃最早的命令
+	assert.Equal(t, 2, queue.Pending("agent-1"))
+	commands := queue.Deliver("agent-1")
+	assert.Len(t, commands, 2)
+	assert.Equal(t, models.MsgTypeMaintenance, commands[0].Type)
+	assert.JSONEq(t, `{"enabled":true}`, string(commands[0].Payload))
+	assert.Equal(t, models.MsgTypeSyncHint, commands[1].Type)
+	assert.NotEmpty(t, commands[0].ID)
+
+	// 未确认的命令再次下发
+	assert.Len(t, queue.Deliver("agent-1"), 2)
+
+	queue.Ack("agent-1", []string{commands[0].ID})
+	redelivered := queue.Deliver("agent-1")
+	assert.Len(t, redelivered, 1)
+	assert.Equal(t, commands[1].ID, redelivered[0].ID)
+
+	queue.Ack("agent-1", []string{commands[1].ID})
+	assert.Empty(t, queue.Deliver("agent-1"))
+}
+
+func TestCommandQueue_MaxDeliveries(t *testing.T) {
+	queue := NewCommandQueue(0)
+	assert.NoError(t, queue.Publish("agent-1", models.MsgTypeSyncHint, nil))
+
+	// 不回传确认的Agent最多收到maxCommandDeliveries次
+	for i := 0; i < maxCommandDeliveries; i++ {
+		assert.Len(t, queue.Deliver("agent-1"), 1)
+	}
+	assert.Empty(t, queue.Deliver("agent-1"))
+}
+
+func TestAgentService_Heartbeat(t *testing.T) {
+	logger := logrus.New()
+	ctx := context.Background()
+
+	t.Run("returns pending commands", func(t *testing.T) {
+		agentRepo := new(mocks.MockAgentRepository)
+		queue := NewCommandQueue(0)
+		queue.Publish("agent-1", models.MsgTypeLogLevel, map[string]string{"level": "warn"})
+
+		agentRepo.On("GetByID", ctx, "agent-1").Return(&models.Agent{AgentID: "agent-1", Status: "offline"}, nil)
+		agentRepo.On("Save", ctx, mock.MatchedBy(func(a *models.Agent) bool {
+			return a.Status == "online" && !a.LastHeartbeat.IsZero()
+		})).Return(nil)
+
+		svc := NewAgentService(agentRepo, nil, queue, logger)
+		resp, err := svc.Heartbeat(ctx, "agent-1", nil)
+
+		assert.NoError(t, err)
+		assert.Len(t, resp.Commands, 1)
+		// 命令在Agent确认前保留
+		assert.Equal(t, 1, queue.Pending("agent-1"))
+
+		resp, err = svc.Heartbeat(ctx, "agent-1", []string{resp.Commands[0].ID})
+		assert.NoError(t, err)
+		assert.Empty(t, resp.Commands)
+		assert.Equal(t, 0, queue.Pending("agent-1"))
+		agentRepo.AssertExpectations(t)
+	})
+
+	t.Run("unknown agent", func(t *testing.T) {
+		agentRepo := new(mocks.MockAgentRepository)
+		agentRepo.On("GetByID", ctx, "agent-x").Return(nil, errors.New("文档不存在"))
+
+		svc := NewAgentService(agentRepo, nil, NewCommandQueue(0), logger)
+		_, err := svc.Heartbeat(ctx, "agent-x", nil)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "Agent不存在")
+	})
+}
+
+func TestAgentService_SelectAgents(t *testing.T) {
+	logger := logrus.New()
+	ctx := context.Background()
+
+	agentRepo := new(mocks.MockAgentRepository)
+	agentRepo.On("ListByLabels", ctx, map[string]string{"env": "prod"}).Return([]*models.Agent{
+		{AgentID: "agent-east", Labels: map[string]string{"env": "prod", "dc": "east"}},
+		{AgentID: "agent-west", Labels: map[string]string{"env": "prod", "dc": "west"}},
+		{AgentID: "agent-override", Labels: map[string]string{"env": "prod"}, Settings: &models.AgentSettings{Labels: map[string]string{"dc": "east"}}},
+	}, nil)
+
+	svc := NewAgentService(agentRepo, nil, NewCommandQueue(0), logger)
+	agents, err := svc.SelectAgents(ctx, "env=prod,dc!=west")
+	assert.NoError(t, err)
+
+	ids := make([]string, 0, len(agents))
+	for _, agent := range agents {
+		ids = append(ids, agent.AgentID)
+	}
+	// 平台侧设置覆盖的标签参与匹配
+	assert.Equal(t, []string{"agent-east", "agent-override"}, ids)
+
+	_, err = svc.SelectAgents(ctx, "tier in web")
+	assert.ErrorIs(t, err, models.ErrInvalidSelector)
+}
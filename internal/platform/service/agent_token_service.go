@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+	"logstash-platform/pkg/agentauth"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// Agent注册令牌错误
+var (
+	ErrAgentTokenRevoked    = errors.New("令牌已吊销")
+	ErrAgentAlreadyEnrolled = errors.New("Agent已签发过注册令牌")
+	ErrAgentNotEnrolled     = errors.New("Agent没有可用的注册令牌")
+)
+
+// defaultRotationGrace 轮换后旧令牌的默认宽限期，便于Agent在途请求完成并切换到新令牌
+const defaultRotationGrace = 5 * time.Minute
+
+// AgentTokenService Agent注册令牌服务接口
+type AgentTokenService interface {
+	// Enroll 为Agent签发注册令牌；privileged为false（使用共享引导令牌）时只允许从未签发过令牌的Agent
+	Enroll(ctx context.Context, agentID, operator string, privileged bool) (*models.AgentTokenResponse, error)
+	// Rotate 签发新令牌，旧令牌在宽限期后失效
+	Rotate(ctx context.Context, agentID, operator string) (*models.AgentTokenResponse, error)
+	// Revoke 立即吊销Agent的全部令牌
+	Revoke(ctx context.Context, agentID, operator string) (*models.RevokeAgentTokensResponse, error)
+	ListTokens(ctx context.Context, agentID string) ([]*models.AgentToken, error)
+	Verify(ctx context.Context, token string) (*models.TokenClaims, error)
+}
+
+// agentTokenService Agent注册令牌服务实现
+type agentTokenService struct {
+	tokenRepo repository.AgentTokenRepository
+	grace     time.Duration
+	logger    *logrus.Logger
+	now       func() time.Time
+}
+
+// NewAgentTokenService 创建Agent注册令牌服务，grace为轮换后旧令牌的宽限期
+func NewAgentTokenService(tokenRepo repository.AgentTokenRepository, grace time.Duration, logger *logrus.Logger) AgentTokenService {
+	if grace <= 0 {
+		grace = defaultRotationGrace
+	}
+	return &agentTokenService{
+		tokenRepo: tokenRepo,
+		grace:     grace,
+		logger:    logger,
+		now:       time.Now,
+	}
+}
+
+// Enroll 为Agent签发注册令牌
+// 已吊销的Agent不能凭共享引导令牌重新注册，需由管理员重新签发
+func (s *agentTokenService) Enroll(ctx context.Context, agentID, operator string, privileged bool) (*models.AgentTokenResponse, error) {
+	tokens, err := s.tokenRepo.ListByAgent(elasticsearch.WithPrimaryRead(ctx), agentID)
+	if err != nil {
+		return nil, err
+	}
+	if !privileged && len(tokens) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrAgentAlreadyEnrolled, agentID)
+	}
+
+	resp, err := s.issue(ctx, agentID, operator)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"agent_id": agentID,
+		"token_id": resp.TokenID,
+		"operator": operator,
+	}).Info("签发Agent注册令牌")
+	return resp, nil
+}
+
+// Rotate 签发新令牌并为仍可用的旧令牌设置宽限截止时间
+func (s *agentTokenService) Rotate(ctx context.Context, agentID, operator string) (*models.AgentTokenResponse, error) {
+	tokens, err := s.tokenRepo.ListByAgent(elasticsearch.WithPrimaryRead(ctx), agentID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	var usable []*models.AgentToken
+	for _, t := range tokens {
+		if t.Usable(now) {
+			usable = append(usable, t)
+		}
+	}
+	if len(usable) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrAgentNotEnrolled, agentID)
+	}
+
+	resp, err := s.issue(ctx, agentID, operator)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := now.Add(s.grace)
+	for _, t := range usable {
+		if t.ExpiresAt == nil || t.ExpiresAt.After(deadline) {
+			t.ExpiresAt = &deadline
+		}
+		t.RotatedTo = resp.TokenID
+		if err := s.tokenRepo.Save(ctx, t); err != nil {
+			return nil, err
+		}
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"agent_id": agentID,
+		"token_id": resp.TokenID,
+		"retiring": len(usable),
+		"operator": operator,
+	}).Info("轮换Agent注册令牌")
+	return resp, nil
+}
+
+// Revoke 吊销Agent的全部令牌
+func (s *agentTokenService) Revoke(ctx context.Context, agentID, operator string) (*models.RevokeAgentTokensResponse, error) {
+	tokens, err := s.tokenRepo.ListByAgent(elasticsearch.WithPrimaryRead(ctx), agentID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	revoked := 0
+	for _, t := range tokens {
+		if t.Status == models.AgentTokenRevoked {
+			continue
+		}
+		t.Status = models.AgentTokenRevoked
+		t.RevokedAt = &now
+		t.RevokedBy = operator
+		if err := s.tokenRepo.Save(ctx, t); err != nil {
+			return nil, err
+		}
+		revoked++
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"agent_id": agentID,
+		"revoked":  revoked,
+		"operator": operator,
+	}).Warn("吊销Agent注册令牌")
+	return &models.RevokeAgentTokensResponse{AgentID: agentID, Revoked: revoked}, nil
+}
+
+// ListTokens 获取Agent的令牌记录
+func (s *agentTokenService) ListTokens(ctx context.Context, agentID string) ([]*models.AgentToken, error) {
+	tokens, err := s.tokenRepo.ListByAgent(ctx, agentID)
+	if err != nil {
+		return nil, err
+	}
+	for i, t := range tokens {
+		tokens[i] = t.Sanitized()
+	}
+	return tokens, nil
+}
+
+// Verify 校验注册令牌，返回绑定到该Agent的身份
+func (s *agentTokenService) Verify(ctx context.Context, token string) (*models.TokenClaims, error) {
+	id, secret, ok := agentauth.Parse(token)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	record, err := s.tokenRepo.GetByID(ctx, id)
+	if err != nil {
+		if err.Error() == "文档不存在" {
+			return nil, ErrInvalidToken
+		}
+		return nil, fmt.Errorf("获取Agent令牌失败: %w", err)
+	}
+	if subtle.ConstantTimeCompare([]byte(agentauth.HashSecret(secret)), []byte(record.SecretHash)) != 1 {
+		return nil, ErrInvalidToken
+	}
+	if record.Status == models.AgentTokenRevoked {
+		return nil, ErrAgentTokenRevoked
+	}
+	if !record.Usable(s.now()) {
+		return nil, ErrTokenExpired
+	}
+
+	key, _ := hex.DecodeString(record.SecretHash)
+	return &models.TokenClaims{
+		Subject:  record.AgentID,
+		Role:     models.RoleAgent,
+		AgentID:  record.AgentID,
+		ProofKey: key,
+	}, nil
+}
+
+// issue 生成并保存新令牌
+func (s *agentTokenService) issue(ctx context.Context, agentID, operator string) (*models.AgentTokenResponse, error) {
+	id := uuid.New().String()
+	token, hash, err := agentauth.Generate(id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	record := &models.AgentToken{
+		ID:         id,
+		AgentID:    agentID,
+		SecretHash: hash,
+		Status:     models.AgentTokenActive,
+		CreatedAt:  now,
+		CreatedBy:  operator,
+	}
+	if err := s.tokenRepo.Save(ctx, record); err != nil {
+		return nil, err
+	}
+
+	return &models.AgentTokenResponse{
+		AgentID:  agentID,
+		TokenID:  id,
+		Token:    token,
+		IssuedAt: now,
+	}, nil
+}
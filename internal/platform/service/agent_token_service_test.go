@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/agentauth"
+)
+
+// memAgentTokenRepository 内存中的Agent令牌仓库
+type memAgentTokenRepository struct {
+	mu     sync.Mutex
+	tokens map[string]*models.AgentToken
+}
+
+func (r *memAgentTokenRepository) Save(ctx context.Context, token *models.AgentToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cp := *token
+	r.tokens[token.ID] = &cp
+	return nil
+}
+
+func (r *memAgentTokenRepository) GetByID(ctx context.Context, id string) (*models.AgentToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token, ok := r.tokens[id]
+	if !ok {
+		return nil, errors.New("文档不存在")
+	}
+	cp := *token
+	return &cp, nil
+}
+
+func (r *memAgentTokenRepository) ListByAgent(ctx context.Context, agentID string) ([]*models.AgentToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var tokens []*models.AgentToken
+	for _, t := range r.tokens {
+		if t.AgentID == agentID {
+			cp := *t
+			tokens = append(tokens, &cp)
+		}
+	}
+	return tokens, nil
+}
+
+func newTestAgentTokenService() (*agentTokenService, *memAgentTokenRepository) {
+	repo := &memAgentTokenRepository{tokens: make(map[string]*models.AgentToken)}
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	return NewAgentTokenService(repo, time.Minute, logger).(*agentTokenService), repo
+}
+
+func TestAgentTokenService_EnrollAndVerify(t *testing.T) {
+	svc, repo := newTestAgentTokenService()
+	ctx := context.Background()
+
+	resp, err := svc.Enroll(ctx, "agent-1", "agent", false)
+	require.NoError(t, err)
+	assert.True(t, agentauth.IsEnrollmentToken(resp.Token))
+	assert.NotContains(t, repo.tokens[resp.TokenID].SecretHash, resp.Token, "只保存密钥哈希")
+
+	claims, err := svc.Verify(ctx, resp.Token)
+	require.NoError(t, err)
+	assert.Equal(t, "agent-1", claims.AgentID)
+	assert.Equal(t, models.RoleAgent, claims.Role)
+
+	// 平台身份证明使用的密钥与Agent侧由令牌计算的一致
+	key, _ := agentauth.ProofKey(resp.Token)
+	assert.Equal(t, key, claims.ProofKey)
+
+	t.Run("伪造的密钥", func(t *testing.T) {
+		_, err := svc.Verify(ctx, agentauth.Prefix+resp.TokenID+".forged")
+		assert.ErrorIs(t, err, ErrInvalidToken)
+		_, err = svc.Verify(ctx, agentauth.Prefix+"unknown.secret")
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("引导令牌不能重复注册", func(t *testing.T) {
+		_, err := svc.Enroll(ctx, "agent-1", "agent", false)
+		assert.ErrorIs(t, err, ErrAgentAlreadyEnrolled)
+	})
+}
+
+func TestAgentTokenService_Revoke(t *testing.T) {
+	svc, _ := newTestAgentTokenService()
+	ctx := context.Background()
+
+	resp, err := svc.Enroll(ctx, "agent-1", "agent", false)
+	require.NoError(t, err)
+
+	revoked, err := svc.Revoke(ctx, "agent-1", "alice")
+	require.NoError(t, err)
+	assert.Equal(t, 1, revoked.Revoked)
+
+	_, err = svc.Verify(ctx, resp.Token)
+	assert.ErrorIs(t, err, ErrAgentTokenRevoked)
+
+	// 吊销后引导令牌不能重新注册，管理员可以重新签发
+	_, err = svc.Enroll(ctx, "agent-1", "agent", false)
+	assert.ErrorIs(t, err, ErrAgentAlreadyEnrolled)
+	reissued, err := svc.Enroll(ctx, "agent-1", "alice", true)
+	require.NoError(t, err)
+	_, err = svc.Verify(ctx, reissued.Token)
+	assert.NoError(t, err)
+
+	tokens, err := svc.ListTokens(ctx, "agent-1")
+	require.NoError(t, err)
+	assert.Len(t, tokens, 2)
+	for _, tok := range tokens {
+		assert.Empty(t, tok.SecretHash)
+	}
+}
+
+func TestAgentTokenService_Rotate(t *testing.T) {
+	svc, _ := newTestAgentTokenService()
+	ctx := context.Background()
+
+	_, err := svc.Rotate(ctx, "agent-1", "agent-1")
+	assert.ErrorIs(t, err, ErrAgentNotEnrolled)
+
+	old, err := svc.Enroll(ctx, "agent-1", "agent", false)
+	require.NoError(t, err)
+	rotated, err := svc.Rotate(ctx, "agent-1", "agent-1")
+	require.NoError(t, err)
+	assert.NotEqual(t, old.TokenID, rotated.TokenID)
+
+	// 宽限期内新旧令牌都可用
+	_, err = svc.Verify(ctx, old.Token)
+	assert.NoError(t, err)
+	_, err = svc.Verify(ctx, rotated.Token)
+	assert.NoError(t, err)
+
+	svc.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	_, err = svc.Verify(ctx, old.Token)
+	assert.ErrorIs(t, err, ErrTokenExpired)
+	_, err = svc.Verify(ctx, rotated.Token)
+	assert.NoError(t, err)
+}
+
+func TestAuthService_VerifyEnrollmentToken(t *testing.T) {
+	tokens, _ := newTestAgentTokenService()
+	resp, err := tokens.Enroll(context.Background(), "agent-1", "agent", false)
+	require.NoError(t, err)
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.WarnLevel)
+	repo := &memUserRepository{users: make(map[string]*models.User)}
+
+	svc := NewAuthService(repo, "test-secret", time.Hour, []string{"agent-token"}, tokens, logger)
+	claims, err := svc.VerifyToken(context.Background(), resp.Token)
+	require.NoError(t, err)
+	assert.Equal(t, "agent-1", claims.AgentID)
+
+	// 未启用注册令牌时一律拒绝
+	svc = NewAuthService(repo, "test-secret", time.Hour, []string{"agent-token"}, nil, logger)
+	_, err = svc.VerifyToken(context.Background(), resp.Token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
@@ -0,0 +1,415 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+)
+
+// applyFailedLookback 应用失败规则额外回看的部署创建时间，覆盖创建后较久才失败的部署（如金丝雀观察期）
+const applyFailedLookback = time.Hour
+
+// AlertingConfig 告警评估参数
+type AlertingConfig struct {
+	Interval     time.Duration // 评估间隔
+	MetricWindow time.Duration // 阈值规则每次评估取指标均值的时间窗口
+}
+
+// alertState 单个告警的评估状态
+type alertState struct {
+	alert        models.Alert
+	pendingSince time.Time // 条件首次满足的时间，阈值规则持续满足for_seconds后才触发
+	firing       bool
+	notified     bool // 已发送触发通知，恢复时需要发送恢复通知
+}
+
+// alertObservation 规则在单个Agent上满足条件时的观测结果
+type alertObservation struct {
+	value   *float64
+	message string
+}
+
+// alertDelivery 待发送的通知
+type alertDelivery struct {
+	channels []string
+	alert    models.Alert
+}
+
+// AlertEngine 定期按规则评估Agent注册表、指标索引和部署结果，触发和恢复时发送通知
+// 告警状态只保存在内存中，平台重启后重新评估，仍满足条件的告警会再次通知
+type AlertEngine struct {
+	cfg         AlertingConfig
+	ruleRepo    repository.AlertRuleRepository
+	silenceRepo repository.AlertSilenceRepository
+	agentRepo   repository.AgentRepository
+	metricsRepo repository.MetricsRepository
+	deployRepo  repository.DeploymentRepository
+	notifiers   map[string]AlertNotifier
+	logger      *logrus.Logger
+	now         func() time.Time
+
+	mu     sync.Mutex
+	states map[string]*alertState // 告警ID -> 状态
+
+	notifyFailures atomic.Int64
+	tracker        loopTracker
+}
+
+// NewAlertEngine 创建告警评估引擎，notifiers按渠道名称索引
+func NewAlertEngine(cfg AlertingConfig, ruleRepo repository.AlertRuleRepository, silenceRepo repository.AlertSilenceRepository,
+	agentRepo repository.AgentRepository, metricsRepo repository.MetricsRepository, deployRepo repository.DeploymentRepository,
+	notifiers map[string]AlertNotifier, logger *logrus.Logger) *AlertEngine {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Minute
+	}
+	if cfg.MetricWindow <= 0 {
+		cfg.MetricWindow = 5 * time.Minute
+	}
+	return &AlertEngine{
+		cfg:         cfg,
+		ruleRepo:    ruleRepo,
+		silenceRepo: silenceRepo,
+		agentRepo:   agentRepo,
+		metricsRepo: metricsRepo,
+		deployRepo:  deployRepo,
+		notifiers:   notifiers,
+		logger:      logger,
+		now:         time.Now,
+		states:      make(map[string]*alertState),
+		tracker:     loopTracker{interval: cfg.Interval},
+	}
+}
+
+// Start 按间隔评估全部启用的规则，直到ctx取消
+func (e *AlertEngine) Start(ctx context.Context) {
+	defer e.tracker.start(e.now())()
+	ticker := time.NewTicker(e.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		done := e.tracker.begin(e.now())
+		err := e.Evaluate(ctx)
+		done(err)
+		if err != nil && ctx.Err() == nil {
+			e.logger.Errorf("评估告警规则失败: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// WorkerStatus 报告告警评估的运行状态
+func (e *AlertEngine) WorkerStatus(now time.Time) models.WorkerStatus {
+	status := e.tracker.status("alert_engine", now)
+	status.Gauges = map[string]float64{
+		"firing":                float64(len(e.Active())),
+		"notification_failures": float64(e.notifyFailures.Load()),
+	}
+	return status
+}
+
+// HasChannel 是否配置了指定名称的通知渠道
+func (e *AlertEngine) HasChannel(name string) bool {
+	_, ok := e.notifiers[name]
+	return ok
+}
+
+// Active 当前触发中的告警，按开始时间排序
+func (e *AlertEngine) Active() []*models.Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	alerts := make([]*models.Alert, 0, len(e.states))
+	for _, state := range e.states {
+		if state.firing {
+			alert := state.alert
+			alerts = append(alerts, &alert)
+		}
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		if !alerts[i].StartsAt.Equal(alerts[j].StartsAt) {
+			return alerts[i].StartsAt.Before(alerts[j].StartsAt)
+		}
+		return alerts[i].ID < alerts[j].ID
+	})
+	return alerts
+}
+
+// Evaluate 评估一次全部启用的规则，单条规则评估失败时保留其告警状态并继续评估其他规则
+func (e *AlertEngine) Evaluate(ctx context.Context) error {
+	now := e.now()
+	rules, err := e.ruleRepo.List(ctx)
+	if err != nil {
+		return fmt.Errorf("获取告警规则失败: %w", err)
+	}
+
+	silences, err := e.silenceRepo.ListActive(ctx, now)
+	if err != nil {
+		// 静默读取失败时宁可多发通知，不阻塞评估
+		e.logger.WithError(err).Warn("获取告警静默失败")
+	}
+
+	var errs []error
+	var deliveries []alertDelivery
+	evaluated := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		evaluated[rule.ID] = true
+
+		observations, err := e.evaluateRule(ctx, rule, now)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("规则 %s: %w", rule.Name, err))
+			continue
+		}
+		deliveries = append(deliveries, e.apply(rule, observations, silences, now)...)
+	}
+
+	// 规则被删除或停用时直接丢弃其告警，不发送恢复通知
+	e.mu.Lock()
+	for id, state := range e.states {
+		if !evaluated[state.alert.RuleID] {
+			delete(e.states, id)
+		}
+	}
+	e.mu.Unlock()
+
+	for _, delivery := range deliveries {
+		e.notify(ctx, delivery)
+	}
+	return errors.Join(errs...)
+}
+
+// evaluateRule 找出规则选中的Agent中满足条件的Agent
+func (e *AlertEngine) evaluateRule(ctx context.Context, rule *models.AlertRule, now time.Time) (map[string]alertObservation, error) {
+	agents, err := e.selectAgents(ctx, rule.Selector)
+	if err != nil {
+		return nil, err
+	}
+
+	observations := make(map[string]alertObservation)
+	switch rule.Type {
+	case models.AlertRuleAgentOffline:
+		for _, agent := range agents {
+			if age := now.Sub(agent.LastHeartbeat); age >= rule.For() {
+				observations[agent.AgentID] = alertObservation{
+					message: fmt.Sprintf("Agent已 %s 未上报心跳（最近心跳 %s）", age.Truncate(time.Second), agent.LastHeartbeat.Format(time.RFC3339)),
+				}
+			}
+		}
+
+	case models.AlertRuleConfigApplyFailed:
+		selected := make(map[string]bool, len(agents))
+		for _, agent := range agents {
+			selected[agent.AgentID] = true
+		}
+		since := now.Add(-rule.For())
+		deployments, err := e.listDeployments(ctx, since.Add(-applyFailedLookback))
+		if err != nil {
+			return nil, err
+		}
+		latest := make(map[string]time.Time)
+		for _, d := range deployments {
+			for _, result := range d.Results {
+				if result.Status != models.DeploymentResultFailed || !selected[result.AgentID] ||
+					result.FinishedAt == nil || result.FinishedAt.Before(since) || result.FinishedAt.Before(latest[result.AgentID]) {
+					continue
+				}
+				latest[result.AgentID] = *result.FinishedAt
+				observations[result.AgentID] = alertObservation{
+					message: fmt.Sprintf("配置 %s 版本 %d 应用失败: %s", d.ConfigName, d.ConfigVersion, result.Message),
+				}
+			}
+		}
+
+	case models.AlertRuleMetricThreshold:
+		ids := make([]string, 0, len(agents))
+		for _, agent := range agents {
+			ids = append(ids, agent.AgentID)
+		}
+		averages, err := e.metricsRepo.Averages(ctx, ids, rule.Metric, now.Add(-e.cfg.MetricWindow), now)
+		if err != nil {
+			return nil, err
+		}
+		for agentID, value := range averages {
+			breached := value > rule.Threshold
+			if rule.Operator == models.AlertOperatorBelow {
+				breached = value < rule.Threshold
+			}
+			if breached {
+				value := value
+				observations[agentID] = alertObservation{
+					value:   &value,
+					message: fmt.Sprintf("%s 近%s均值 %.2f %s 阈值 %.2f", rule.Metric, e.cfg.MetricWindow, value, rule.Operator, rule.Threshold),
+				}
+			}
+		}
+
+	default:
+		return nil, fmt.Errorf("不支持的规则类型: %s", rule.Type)
+	}
+
+	return observations, nil
+}
+
+// selectAgents 获取规则选中的Agent，选择器为空时为全部Agent
+func (e *AlertEngine) selectAgents(ctx context.Context, selector string) ([]*models.Agent, error) {
+	if selector == "" {
+		return e.agentRepo.ListByLabels(ctx, nil)
+	}
+
+	sel, err := models.ParseLabelSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := e.agentRepo.ListByLabels(ctx, sel.EqualityTerms())
+	if err != nil {
+		return nil, err
+	}
+
+	agents := make([]*models.Agent, 0, len(candidates))
+	for _, agent := range candidates {
+		if sel.Matches(agent.SelectorLabels()) {
+			agents = append(agents, agent)
+		}
+	}
+	return agents, nil
+}
+
+// apply 根据本次观测更新规则的告警状态，返回需要发送的通知
+func (e *AlertEngine) apply(rule *models.AlertRule, observations map[string]alertObservation, silences []*models.AlertSilence, now time.Time) []alertDelivery {
+	// 离线时长和回看窗口已在条件中体现，只有阈值规则需要持续满足
+	var pending time.Duration
+	if rule.Type == models.AlertRuleMetricThreshold {
+		pending = rule.For()
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var deliveries []alertDelivery
+	for agentID, observation := range observations {
+		id := rule.ID + "/" + agentID
+		state, ok := e.states[id]
+		if !ok {
+			state = &alertState{
+				alert:        models.Alert{ID: id, RuleID: rule.ID, Type: rule.Type, AgentID: agentID},
+				pendingSince: now,
+			}
+			e.states[id] = state
+		}
+		state.alert.RuleName = rule.Name
+		state.alert.Severity = rule.Severity
+		state.alert.Value = observation.value
+		state.alert.Message = observation.message
+		state.alert.LastEvaluatedAt = now
+
+		if !state.firing && now.Sub(state.pendingSince) >= pending {
+			state.firing = true
+			state.alert.State = models.AlertStateFiring
+			state.alert.StartsAt = now
+		}
+		if !state.firing {
+			continue
+		}
+
+		// 触发时处于静默中的告警在静默结束后仍在触发则补发通知
+		state.alert.Silenced = silenced(silences, &state.alert, now)
+		if !state.notified && !state.alert.Silenced {
+			state.notified = true
+			deliveries = append(deliveries, alertDelivery{channels: rule.Channels, alert: state.alert})
+		}
+	}
+
+	for id, state := range e.states {
+		if state.alert.RuleID != rule.ID {
+			continue
+		}
+		if _, ok := observations[state.alert.AgentID]; ok {
+			continue
+		}
+		delete(e.states, id)
+		if !state.notified {
+			continue
+		}
+
+		resolved := state.alert
+		resolved.State = models.AlertStateResolved
+		resolved.ResolvedAt = &now
+		resolved.Value = nil
+		if resolved.Silenced = silenced(silences, &resolved, now); !resolved.Silenced {
+			deliveries = append(deliveries, alertDelivery{channels: rule.Channels, alert: resolved})
+		}
+	}
+
+	return deliveries
+}
+
+// silenced 告警是否被生效中的静默覆盖
+func silenced(silences []*models.AlertSilence, alert *models.Alert, now time.Time) bool {
+	for _, silence := range silences {
+		if silence.Active(now) && silence.Matches(alert) {
+			return true
+		}
+	}
+	return false
+}
+
+// notify 向规则的全部通知渠道发送告警，失败只记录日志
+func (e *AlertEngine) notify(ctx context.Context, delivery alertDelivery) {
+	for _, name := range delivery.channels {
+		notifier, ok := e.notifiers[name]
+		if !ok {
+			e.logger.WithField("channel", name).Warn("告警规则引用的通知渠道未配置")
+			continue
+		}
+
+		notifyCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+		err := notifier.Notify(notifyCtx, &delivery.alert)
+		cancel()
+
+		fields := logrus.Fields{
+			"channel":  name,
+			"alert_id": delivery.alert.ID,
+			"state":    delivery.alert.State,
+		}
+		if err != nil {
+			e.notifyFailures.Add(1)
+			e.logger.WithError(err).WithFields(fields).Error("发送告警通知失败")
+			continue
+		}
+		e.logger.WithFields(fields).Info("已发送告警通知")
+	}
+}
+
+// listDeployments 分页获取指定时间之后创建的部署记录
+func (e *AlertEngine) listDeployments(ctx context.Context, since time.Time) ([]*models.Deployment, error) {
+	var deployments []*models.Deployment
+	for page := 1; ; page++ {
+		items, total, err := e.deployRepo.List(ctx, &models.DeploymentListRequest{
+			Since:    since,
+			Page:     page,
+			PageSize: 100,
+		})
+		if err != nil {
+			return nil, err
+		}
+		deployments = append(deployments, items...)
+		if len(items) == 0 || int64(len(deployments)) >= total {
+			return deployments, nil
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+)
+
+type memAlertRuleRepository struct {
+	rules map[string]*models.AlertRule
+}
+
+func (r *memAlertRuleRepository) Create(ctx context.Context, rule *models.AlertRule) error {
+	if rule.ID == "" {
+		rule.ID = fmt.Sprintf("rule-%d", len(r.rules)+1)
+	}
+	cp := *rule
+	r.rules[rule.ID] = &cp
+	return nil
+}
+
+func (r *memAlertRuleRepository) Update(ctx context.Context, rule *models.AlertRule) error {
+	cp := *rule
+	r.rules[rule.ID] = &cp
+	return nil
+}
+
+func (r *memAlertRuleRepository) Delete(ctx context.Context, id string) error {
+	delete(r.rules, id)
+	return nil
+}
+
+func (r *memAlertRuleRepository) GetByID(ctx context.Context, id string) (*models.AlertRule, error) {
+	rule, ok := r.rules[id]
+	if !ok {
+		return nil, fmt.Errorf("文档不存在")
+	}
+	cp := *rule
+	return &cp, nil
+}
+
+func (r *memAlertRuleRepository) List(ctx context.Context) ([]*models.AlertRule, error) {
+	var rules []*models.AlertRule
+	for _, rule := range r.rules {
+		cp := *rule
+		rules = append(rules, &cp)
+	}
+	return rules, nil
+}
+
+type memAlertSilenceRepository struct {
+	silences []*models.AlertSilence
+}
+
+func (r *memAlertSilenceRepository) Create(ctx context.Context, silence *models.AlertSilence) error {
+	silence.ID = fmt.Sprintf("silence-%d", len(r.silences)+1)
+	r.silences = append(r.silences, silence)
+	return nil
+}
+
+func (r *memAlertSilenceRepository) Delete(ctx context.Context, id string) error {
+	for i, silence := range r.silences {
+		if silence.ID == id {
+			r.silences = append(r.silences[:i], r.silences[i+1:]...)
+		}
+	}
+	return nil
+}
+
+func (r *memAlertSilenceRepository) GetByID(ctx context.Context, id string) (*models.AlertSilence, error) {
+	for _, silence := range r.silences {
+		if silence.ID == id {
+			return silence, nil
+		}
+	}
+	return nil, fmt.Errorf("文档不存在")
+}
+
+func (r *memAlertSilenceRepository) ListActive(ctx context.Context, now time.Time) ([]*models.AlertSilence, error) {
+	var active []*models.AlertSilence
+	for _, silence := range r.silences {
+		if silence.EndsAt.After(now) {
+			active = append(active, silence)
+		}
+	}
+	return active, nil
+}
+
+// recordingNotifier 记录收到的通知
+type recordingNotifier struct {
+	mu     sync.Mutex
+	alerts []models.Alert
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, alert *models.Alert) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.alerts = append(n.alerts, *alert)
+	return nil
+}
+
+func (n *recordingNotifier) states() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	var states []string
+	for _, alert := range n.alerts {
+		states = append(states, alert.AgentID+":"+alert.State)
+	}
+	return states
+}
+
+type alertEngineFixture struct {
+	engine   *AlertEngine
+	agents   *memAgentRepository
+	rules    *memAlertRuleRepository
+	silences *memAlertSilenceRepository
+	metrics  *memMetricsRepository
+	deploys  *memDeploymentRepository
+	notifier *recordingNotifier
+	now      time.Time
+}
+
+func newAlertEngineFixture(rules ...*models.AlertRule) *alertEngineFixture {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	f := &alertEngineFixture{
+		agents:   &memAgentRepository{agents: map[string]*models.Agent{}},
+		rules:    &memAlertRuleRepository{rules: map[string]*models.AlertRule{}},
+		silences: &memAlertSilenceRepository{},
+		metrics:  &memMetricsRepository{},
+		deploys:  &memDeploymentRepository{deployments: map[string]models.Deployment{}},
+		notifier: &recordingNotifier{},
+		now:      time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+	for _, rule := range rules {
+		f.rules.rules[rule.ID] = rule
+	}
+	f.engine = NewAlertEngine(AlertingConfig{}, f.rules, f.silences, f.agents, f.metrics, f.deploys,
+		map[string]AlertNotifier{"ops": f.notifier}, logger)
+	f.engine.now = func() time.Time { return f.now }
+	return f
+}
+
+func TestAlertEngine_AgentOffline(t *testing.T) {
+	ctx := context.Background()
+	f := newAlertEngineFixture(&models.AlertRule{
+		ID: "offline", Name: "Agent离线", Type: models.AlertRuleAgentOffline,
+		ForSeconds: 300, Severity: models.AlertSeverityCritical, Channels: []string{"ops"}, Enabled: true,
+	})
+	f.agents.agents["a1"] = &models.Agent{AgentID: "a1", LastHeartbeat: f.now.Add(-10 * time.Minute)}
+	f.agents.agents["a2"] = &models.Agent{AgentID: "a2", LastHeartbeat: f.now.Add(-time.Minute)}
+
+	require.NoError(t, f.engine.Evaluate(ctx))
+	active := f.engine.Active()
+	require.Len(t, active, 1)
+	assert.Equal(t, "offline/a1", active[0].ID)
+	assert.Equal(t, models.AlertSeverityCritical, active[0].Severity)
+	assert.Equal(t, []string{"a1:firing"}, f.notifier.states())
+
+	// 持续离线不重复通知
+	f.now = f.now.Add(time.Minute)
+	require.NoError(t, f.engine.Evaluate(ctx))
+	assert.Len(t, f.notifier.states(), 1)
+
+	// 恢复心跳后发送恢复通知
+	f.agents.agents["a1"].LastHeartbeat = f.now
+	require.NoError(t, f.engine.Evaluate(ctx))
+	assert.Empty(t, f.engine.Active())
+	assert.Equal(t, []string{"a1:firing", "a1:resolved"}, f.notifier.states())
+	assert.NotNil(t, f.notifier.alerts[1].ResolvedAt)
+}
+
+func TestAlertEngine_Silence(t *testing.T) {
+	ctx := context.Background()
+	f := newAlertEngineFixture(&models.AlertRule{
+		ID: "offline", Name: "Agent离线", Type: models.AlertRuleAgentOffline,
+		ForSeconds: 300, Channels: []string{"ops"}, Enabled: true,
+	})
+	f.agents.agents["a1"] = &models.Agent{AgentID: "a1", LastHeartbeat: f.now.Add(-10 * time.Minute)}
+	f.silences.silences = []*models.AlertSilence{
+		{ID: "s1", AgentID: "a1", StartsAt: f.now.Add(-time.Minute), EndsAt: f.now.Add(30 * time.Minute)},
+	}
+
+	require.NoError(t, f.engine.Evaluate(ctx))
+	active := f.engine.Active()
+	require.Len(t, active, 1)
+	assert.True(t, active[0].Silenced)
+	assert.Empty(t, f.notifier.states())
+
+	// 静默结束后仍在触发则补发通知
+	f.now = f.now.Add(time.Hour)
+	require.NoError(t, f.engine.Evaluate(ctx))
+	assert.Equal(t, []string{"a1:firing"}, f.notifier.states())
+}
+
+func TestAlertEngine_MetricThresholdPending(t *testing.T) {
+	ctx := context.Background()
+	f := newAlertEngineFixture(&models.AlertRule{
+		ID: "cpu", Name: "CPU过高", Type: models.AlertRuleMetricThreshold,
+		Metric: models.AlertMetricCPUUsage, Operator: models.AlertOperatorAbove, Threshold: 90,
+		ForSeconds: 600, Channels: []string{"ops"}, Enabled: true,
+	})
+	f.agents.agents["a1"] = &models.Agent{AgentID: "a1", LastHeartbeat: f.now}
+	f.metrics.averages = map[string]float64{"a1": 95}
+
+	require.NoError(t, f.engine.Evaluate(ctx))
+	assert.Empty(t, f.engine.Active(), "未持续满足for_seconds前不触发")
+
+	f.now = f.now.Add(10 * time.Minute)
+	require.NoError(t, f.engine.Evaluate(ctx))
+	active := f.engine.Active()
+	require.Len(t, active, 1)
+	require.NotNil(t, active[0].Value)
+	assert.Equal(t, 95.0, *active[0].Value)
+	assert.Equal(t, []string{"a1:firing"}, f.notifier.states())
+
+	// 中途恢复后重新计时
+	f.metrics.averages = map[string]float64{"a1": 50}
+	f.now = f.now.Add(time.Minute)
+	require.NoError(t, f.engine.Evaluate(ctx))
+	f.metrics.averages = map[string]float64{"a1": 95}
+	f.now = f.now.Add(time.Minute)
+	require.NoError(t, f.engine.Evaluate(ctx))
+	assert.Empty(t, f.engine.Active())
+	assert.Equal(t, []string{"a1:firing", "a1:resolved"}, f.notifier.states())
+}
+
+func TestAlertEngine_ConfigApplyFailed(t *testing.T) {
+	ctx := context.Background()
+	f := newAlertEngineFixture(&models.AlertRule{
+		ID: "apply", Name: "配置应用失败", Type: models.AlertRuleConfigApplyFailed,
+		ForSeconds: 900, Channels: []string{"ops"}, Enabled: true,
+	})
+	f.agents.agents["a1"] = &models.Agent{AgentID: "a1", LastHeartbeat: f.now}
+	f.agents.agents["a2"] = &models.Agent{AgentID: "a2", LastHeartbeat: f.now}
+	recent := f.now.Add(-5 * time.Minute)
+	old := f.now.Add(-time.Hour)
+	f.deploys.deployments["d1"] = models.Deployment{
+		ID: "d1", ConfigName: "nginx", ConfigVersion: 3, CreatedAt: old,
+		Results: []models.DeploymentResult{
+			{AgentID: "a1", Status: models.DeploymentResultFailed, Message: "语法错误", FinishedAt: &recent},
+			{AgentID: "a2", Status: models.DeploymentResultFailed, FinishedAt: &old},
+		},
+	}
+
+	require.NoError(t, f.engine.Evaluate(ctx))
+	active := f.engine.Active()
+	require.Len(t, active, 1)
+	assert.Equal(t, "a1", active[0].AgentID)
+	assert.Contains(t, active[0].Message, "语法错误")
+
+	// 规则停用后丢弃告警
+	f.rules.rules["apply"].Enabled = false
+	require.NoError(t, f.engine.Evaluate(ctx))
+	assert.Empty(t, f.engine.Active())
+	assert.Equal(t, []string{"a1:firing"}, f.notifier.states())
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"logstash-platform/internal/platform/models"
+)
+
+// AlertChannelConfig 通知渠道配置，对应平台配置 alerting.channels 的一项
+type AlertChannelConfig struct {
+	Name string `mapstructure:"name"`
+	Type string `mapstructure:"type"` // webhook, email, dingtalk, wecom
+	// webhook、钉钉和企业微信群机器人的地址
+	URL string `mapstructure:"url"`
+	// 钉钉机器人的加签密钥，为空时不签名
+	Secret string `mapstructure:"secret"`
+	// 邮件
+	SMTPHost string   `mapstructure:"smtp_host"`
+	SMTPPort int      `mapstructure:"smtp_port"`
+	Username string   `mapstructure:"username"`
+	Password string   `mapstructure:"password"`
+	From     string   `mapstructure:"from"`
+	To       []string `mapstructure:"to"`
+}
+
+// AlertNotifier 告警通知渠道
+type AlertNotifier interface {
+	Notify(ctx context.Context, alert *models.Alert) error
+}
+
+// NewAlertNotifier 按渠道类型创建通知渠道
+func NewAlertNotifier(cfg AlertChannelConfig) (AlertNotifier, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	switch cfg.Type {
+	case models.AlertChannelWebhook:
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("通知渠道 %s 缺少url", cfg.Name)
+		}
+		return &webhookNotifier{url: cfg.URL, client: client}, nil
+	case models.AlertChannelDingTalk, models.AlertChannelWeCom:
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("通知渠道 %s 缺少url", cfg.Name)
+		}
+		return &robotNotifier{url: cfg.URL, secret: cfg.Secret, client: client}, nil
+	case models.AlertChannelEmail:
+		if cfg.SMTPHost == "" || cfg.From == "" || len(cfg.To) == 0 {
+			return nil, fmt.Errorf("通知渠道 %s 缺少smtp_host、from或to", cfg.Name)
+		}
+		if cfg.SMTPPort == 0 {
+			cfg.SMTPPort = 25
+		}
+		return &emailNotifier{cfg: cfg}, nil
+	default:
+		return nil, fmt.Errorf("通知渠道 %s 的类型 %q 不支持", cfg.Name, cfg.Type)
+	}
+}
+
+// alertTitle 通知标题
+func alertTitle(alert *models.Alert) string {
+	if alert.State == models.AlertStateResolved {
+		return fmt.Sprintf("[已恢复] %s", alert.RuleName)
+	}
+	return fmt.Sprintf("[%s] %s", strings.ToUpper(alert.Severity), alert.RuleName)
+}
+
+// alertText 通知正文
+func alertText(alert *models.Alert) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\nAgent: %s\n%s\n开始时间: %s", alertTitle(alert), alert.AgentID, alert.Message, alert.StartsAt.Format(time.RFC3339))
+	if alert.ResolvedAt != nil {
+		fmt.Fprintf(&b, "\n恢复时间: %s", alert.ResolvedAt.Format(time.RFC3339))
+	}
+	return b.String()
+}
+
+// postJSON 发送JSON请求，非2xx响应视为失败
+func postJSON(ctx context.Context, client *http.Client, target string, body interface{}) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("序列化通知失败: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("创建通知请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("发送通知失败: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("通知接收方返回状态码 %d", resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// webhookNotifier 以JSON推送告警到通用webhook
+type webhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// Notify 推送告警，请求体为 {"status": 告警状态, "alert": 告警}
+func (n *webhookNotifier) Notify(ctx context.Context, alert *models.Alert) error {
+	resp, err := postJSON(ctx, n.client, n.url, map[string]interface{}{
+		"status": alert.State,
+		"alert":  alert,
+	})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// robotNotifier 钉钉和企业微信群机器人，两者的文本消息格式相同
+type robotNotifier struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// Notify 发送文本消息，机器人以errcode表示处理结果
+func (n *robotNotifier) Notify(ctx context.Context, alert *models.Alert) error {
+	target := n.url
+	if n.secret != "" {
+		signed, err := dingTalkSign(target, n.secret, time.Now())
+		if err != nil {
+			return err
+		}
+		target = signed
+	}
+
+	resp, err := postJSON(ctx, n.client, target, map[string]interface{}{
+		"msgtype": "text",
+		"text":    map[string]string{"content": alertText(alert)},
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("解析机器人响应失败: %w", err)
+	}
+	if result.ErrCode != 0 {
+		return fmt.Errorf("机器人返回错误 %d: %s", result.ErrCode, result.ErrMsg)
+	}
+	return nil
+}
+
+// dingTalkSign 为钉钉机器人地址追加加签参数：HmacSHA256("时间戳\n密钥")的Base64
+func dingTalkSign(target, secret string, now time.Time) (string, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", fmt.Errorf("机器人地址无效: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(now.UnixMilli(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + secret))
+
+	query := u.Query()
+	query.Set("timestamp", timestamp)
+	query.Set("sign", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// emailNotifier 经SMTP发送告警邮件
+type emailNotifier struct {
+	cfg AlertChannelConfig
+}
+
+// Notify 发送纯文本邮件，配置了用户名时使用PLAIN认证
+func (n *emailNotifier) Notify(ctx context.Context, alert *models.Alert) error {
+	addr := net.JoinHostPort(n.cfg.SMTPHost, strconv.Itoa(n.cfg.SMTPPort))
+	var auth smtp.Auth
+	if n.cfg.Username != "" {
+		auth = smtp.PlainAuth("", n.cfg.Username, n.cfg.Password, n.cfg.SMTPHost)
+	}
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", n.cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(n.cfg.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", alertTitle(alert)))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(alertText(alert), "\n", "\r\n"))
+
+	// net/smtp 不支持ctx，在后台发送并在ctx结束时放弃等待
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, n.cfg.From, n.cfg.To, []byte(msg.String()))
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("发送告警邮件失败: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("发送告警邮件超时: %w", ctx.Err())
+	}
+}
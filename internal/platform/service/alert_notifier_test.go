@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+)
+
+func testAlert() *models.Alert {
+	return &models.Alert{
+		ID:       "cpu/a1",
+		RuleID:   "cpu",
+		RuleName: "CPU过高",
+		Severity: models.AlertSeverityCritical,
+		AgentID:  "a1",
+		State:    models.AlertStateFiring,
+		Message:  "cpu_usage 近5m0s均值 95.00 > 阈值 90.00",
+		StartsAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+	}))
+	defer server.Close()
+
+	notifier, err := NewAlertNotifier(AlertChannelConfig{Name: "hook", Type: models.AlertChannelWebhook, URL: server.URL})
+	require.NoError(t, err)
+	require.NoError(t, notifier.Notify(context.Background(), testAlert()))
+
+	assert.Equal(t, models.AlertStateFiring, body["status"])
+	alert, ok := body["alert"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "cpu/a1", alert["id"])
+}
+
+func TestRobotNotifier(t *testing.T) {
+	var query url.Values
+	var content string
+	errcode := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		var msg struct {
+			MsgType string `json:"msgtype"`
+			Text    struct {
+				Content string `json:"content"`
+			} `json:"text"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		assert.Equal(t, "text", msg.MsgType)
+		content = msg.Text.Content
+		json.NewEncoder(w).Encode(map[string]interface{}{"errcode": errcode, "errmsg": "invalid token"})
+	}))
+	defer server.Close()
+
+	notifier, err := NewAlertNotifier(AlertChannelConfig{Name: "ding", Type: models.AlertChannelDingTalk, URL: server.URL + "?access_token=abc", Secret: "SEC123"})
+	require.NoError(t, err)
+	require.NoError(t, notifier.Notify(context.Background(), testAlert()))
+
+	assert.Equal(t, "abc", query.Get("access_token"))
+	assert.NotEmpty(t, query.Get("timestamp"))
+	assert.NotEmpty(t, query.Get("sign"))
+	assert.Contains(t, content, "[CRITICAL] CPU过高")
+	assert.Contains(t, content, "Agent: a1")
+
+	errcode = 310000
+	err = notifier.Notify(context.Background(), testAlert())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid token")
+}
+
+func TestDingTalkSign(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+	signed, err := dingTalkSign("https://oapi.dingtalk.com/robot/send?access_token=abc", "secret", now)
+	require.NoError(t, err)
+
+	u, err := url.Parse(signed)
+	require.NoError(t, err)
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("1700000000000\nsecret"))
+	assert.Equal(t, "1700000000000", u.Query().Get("timestamp"))
+	assert.Equal(t, base64.StdEncoding.EncodeToString(mac.Sum(nil)), u.Query().Get("sign"))
+	assert.Equal(t, "abc", u.Query().Get("access_token"))
+}
+
+func TestNewAlertNotifier_Invalid(t *testing.T) {
+	for _, cfg := range []AlertChannelConfig{
+		{Name: "hook", Type: models.AlertChannelWebhook},
+		{Name: "mail", Type: models.AlertChannelEmail, SMTPHost: "smtp.example.com"},
+		{Name: "sms", Type: "sms"},
+	} {
+		_, err := NewAlertNotifier(cfg)
+		assert.Error(t, err, cfg.Name)
+	}
+}
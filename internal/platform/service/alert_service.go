@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+)
+
+// 告警相关错误，处理器通过 errors.Is 映射为HTTP状态码
+var (
+	ErrAlertRuleNotFound    = errors.New("告警规则不存在")
+	ErrAlertRuleInvalid     = errors.New("告警规则无效")
+	ErrAlertSilenceNotFound = errors.New("告警静默不存在")
+	ErrAlertSilenceInvalid  = errors.New("告警静默无效")
+)
+
+// 未指定持续时间时各类规则的默认值
+var defaultAlertFor = map[string]int{
+	models.AlertRuleAgentOffline:      300,
+	models.AlertRuleConfigApplyFailed: 900,
+	models.AlertRuleMetricThreshold:   600,
+}
+
+// alertMetrics 阈值规则可用的指标
+var alertMetrics = map[string]bool{
+	models.AlertMetricCPUUsage:      true,
+	models.AlertMetricMemoryUsage:   true,
+	models.AlertMetricDiskUsage:     true,
+	models.AlertMetricJVMHeapUsed:   true,
+	models.AlertMetricEventsInRate:  true,
+	models.AlertMetricEventsOutRate: true,
+}
+
+// AlertService 告警规则、静默和当前告警的管理服务接口
+type AlertService interface {
+	CreateRule(ctx context.Context, req *models.AlertRuleRequest, userID string) (*models.AlertRule, error)
+	UpdateRule(ctx context.Context, id string, req *models.AlertRuleRequest, userID string) (*models.AlertRule, error)
+	DeleteRule(ctx context.Context, id string) error
+	GetRule(ctx context.Context, id string) (*models.AlertRule, error)
+	ListRules(ctx context.Context) ([]*models.AlertRule, error)
+	CreateSilence(ctx context.Context, req *models.CreateAlertSilenceRequest, userID string) (*models.AlertSilence, error)
+	DeleteSilence(ctx context.Context, id string) error
+	// ListSilences 获取尚未结束的静默
+	ListSilences(ctx context.Context) ([]*models.AlertSilence, error)
+	// ListAlerts 获取当前触发中的告警
+	ListAlerts(ctx context.Context) ([]*models.Alert, error)
+}
+
+// alertService 告警管理服务实现
+type alertService struct {
+	ruleRepo    repository.AlertRuleRepository
+	silenceRepo repository.AlertSilenceRepository
+	engine      *AlertEngine
+	logger      *logrus.Logger
+	now         func() time.Time
+}
+
+// NewAlertService 创建告警管理服务，通知渠道和当前告警取自评估引擎
+func NewAlertService(ruleRepo repository.AlertRuleRepository, silenceRepo repository.AlertSilenceRepository,
+	engine *AlertEngine, logger *logrus.Logger) AlertService {
+	return &alertService{
+		ruleRepo:    ruleRepo,
+		silenceRepo: silenceRepo,
+		engine:      engine,
+		logger:      logger,
+		now:         time.Now,
+	}
+}
+
+// CreateRule 创建告警规则
+func (s *alertService) CreateRule(ctx context.Context, req *models.AlertRuleRequest, userID string) (*models.AlertRule, error) {
+	rule := &models.AlertRule{CreatedBy: userID}
+	if err := s.applyRule(rule, req, userID); err != nil {
+		return nil, err
+	}
+	if err := s.ruleRepo.Create(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"rule_id": rule.ID,
+		"type":    rule.Type,
+		"user_id": userID,
+	}).Info("创建告警规则成功")
+
+	return rule, nil
+}
+
+// UpdateRule 整体替换告警规则的定义
+func (s *alertService) UpdateRule(ctx context.Context, id string, req *models.AlertRuleRequest, userID string) (*models.AlertRule, error) {
+	rule, err := s.ruleRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrAlertRuleNotFound, err)
+	}
+	if err := s.applyRule(rule, req, userID); err != nil {
+		return nil, err
+	}
+	if err := s.ruleRepo.Update(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"rule_id": rule.ID,
+		"user_id": userID,
+	}).Info("更新告警规则成功")
+
+	return rule, nil
+}
+
+// applyRule 校验请求并写入规则，补全默认级别和持续时间
+func (s *alertService) applyRule(rule *models.AlertRule, req *models.AlertRuleRequest, userID string) error {
+	if req.Selector != "" {
+		if _, err := models.ParseLabelSelector(req.Selector); err != nil {
+			return fmt.Errorf("%w: %w", ErrAlertRuleInvalid, err)
+		}
+	}
+
+	if req.Type == models.AlertRuleMetricThreshold {
+		if !alertMetrics[req.Metric] {
+			return fmt.Errorf("%w: 不支持的指标 %q", ErrAlertRuleInvalid, req.Metric)
+		}
+		if req.Operator != models.AlertOperatorAbove && req.Operator != models.AlertOperatorBelow {
+			return fmt.Errorf("%w: 比较方式必须为 > 或 <", ErrAlertRuleInvalid)
+		}
+	}
+
+	if len(req.Channels) == 0 {
+		return fmt.Errorf("%w: 至少指定一个通知渠道", ErrAlertRuleInvalid)
+	}
+	for _, channel := range req.Channels {
+		if !s.engine.HasChannel(channel) {
+			return fmt.Errorf("%w: 通知渠道 %s 未配置", ErrAlertRuleInvalid, channel)
+		}
+	}
+
+	rule.Name = req.Name
+	rule.Description = req.Description
+	rule.Type = req.Type
+	rule.Selector = req.Selector
+	rule.Metric = ""
+	rule.Operator = ""
+	rule.Threshold = 0
+	if req.Type == models.AlertRuleMetricThreshold {
+		rule.Metric = req.Metric
+		rule.Operator = req.Operator
+		rule.Threshold = req.Threshold
+	}
+	rule.ForSeconds = req.ForSeconds
+	if rule.ForSeconds == 0 {
+		rule.ForSeconds = defaultAlertFor[req.Type]
+	}
+	rule.Severity = req.Severity
+	if rule.Severity == "" {
+		rule.Severity = models.AlertSeverityWarning
+	}
+	rule.Channels = req.Channels
+	rule.Enabled = req.Enabled == nil || *req.Enabled
+	rule.UpdatedBy = userID
+	return nil
+}
+
+// DeleteRule 删除告警规则，其触发中的告警在下一次评估时丢弃
+func (s *alertService) DeleteRule(ctx context.Context, id string) error {
+	if _, err := s.ruleRepo.GetByID(ctx, id); err != nil {
+		return fmt.Errorf("%w: %w", ErrAlertRuleNotFound, err)
+	}
+	if err := s.ruleRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.logger.WithField("rule_id", id).Info("删除告警规则成功")
+	return nil
+}
+
+// GetRule 获取告警规则
+func (s *alertService) GetRule(ctx context.Context, id string) (*models.AlertRule, error) {
+	rule, err := s.ruleRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrAlertRuleNotFound, err)
+	}
+	return rule, nil
+}
+
+// ListRules 获取全部告警规则
+func (s *alertService) ListRules(ctx context.Context) ([]*models.AlertRule, error) {
+	return s.ruleRepo.List(ctx)
+}
+
+// CreateSilence 创建告警静默
+func (s *alertService) CreateSilence(ctx context.Context, req *models.CreateAlertSilenceRequest, userID string) (*models.AlertSilence, error) {
+	if req.RuleID == "" && req.AgentID == "" {
+		return nil, fmt.Errorf("%w: 规则ID和Agent ID至少指定一个", ErrAlertSilenceInvalid)
+	}
+	if req.RuleID != "" {
+		if _, err := s.ruleRepo.GetByID(ctx, req.RuleID); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrAlertRuleNotFound, err)
+		}
+	}
+
+	startsAt := req.StartsAt
+	if startsAt.IsZero() {
+		startsAt = s.now()
+	}
+	if !req.EndsAt.After(startsAt) {
+		return nil, fmt.Errorf("%w: 结束时间必须晚于开始时间", ErrAlertSilenceInvalid)
+	}
+
+	silence := &models.AlertSilence{
+		RuleID:    req.RuleID,
+		AgentID:   req.AgentID,
+		StartsAt:  startsAt,
+		EndsAt:    req.EndsAt,
+		Comment:   req.Comment,
+		CreatedBy: userID,
+	}
+	if err := s.silenceRepo.Create(ctx, silence); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"silence_id": silence.ID,
+		"rule_id":    silence.RuleID,
+		"agent_id":   silence.AgentID,
+		"ends_at":    silence.EndsAt,
+		"user_id":    userID,
+	}).Info("创建告警静默成功")
+
+	return silence, nil
+}
+
+// DeleteSilence 删除告警静默，提前结束静默
+func (s *alertService) DeleteSilence(ctx context.Context, id string) error {
+	if _, err := s.silenceRepo.GetByID(ctx, id); err != nil {
+		return fmt.Errorf("%w: %w", ErrAlertSilenceNotFound, err)
+	}
+	if err := s.silenceRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.logger.WithField("silence_id", id).Info("删除告警静默成功")
+	return nil
+}
+
+// ListSilences 获取尚未结束的静默
+func (s *alertService) ListSilences(ctx context.Context) ([]*models.AlertSilence, error) {
+	return s.silenceRepo.ListActive(ctx, s.now())
+}
+
+// ListAlerts 获取当前触发中的告警
+func (s *alertService) ListAlerts(ctx context.Context) ([]*models.Alert, error) {
+	return s.engine.Active(), nil
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+)
+
+// 审批相关错误，处理器通过 errors.Is 映射为HTTP状态码
+var (
+	ErrConfigNotApproved  = errors.New("配置当前版本未通过审批，无法部署")
+	ErrReviewerNotAllowed = errors.New("当前用户不能审批该配置版本")
+)
+
+// ApprovalService 配置审批服务接口
+type ApprovalService interface {
+	Submit(ctx context.Context, configID string, req *models.ApproveConfigRequest, reviewer string) (*models.ConfigApproval, error)
+	Status(ctx context.Context, configID string, version int) (*models.ConfigApprovalStatus, error)
+	CheckDeployable(ctx context.Context, config *models.Config) error
+}
+
+// approvalService 配置审批服务实现
+// 审批针对配置的具体版本，任何修改都会产生新版本并需要重新审批；
+// 版本的提交者不能审批自己的修改，指派了审批人时只有指派的审批人可以审批
+type approvalService struct {
+	required     int
+	approvalRepo repository.ApprovalRepository
+	configRepo   repository.ConfigRepository
+	logger       *logrus.Logger
+}
+
+// NewApprovalService 创建配置审批服务，required<=0时不要求审批即可部署
+func NewApprovalService(required int, approvalRepo repository.ApprovalRepository, configRepo repository.ConfigRepository, logger *logrus.Logger) ApprovalService {
+	if required < 0 {
+		required = 0
+	}
+	return &approvalService{
+		required:     required,
+		approvalRepo: approvalRepo,
+		configRepo:   configRepo,
+		logger:       logger,
+	}
+}
+
+// Submit 审批配置的当前版本
+func (s *approvalService) Submit(ctx context.Context, configID string, req *models.ApproveConfigRequest, reviewer string) (*models.ConfigApproval, error) {
+	config, err := s.configRepo.GetByID(ctx, configID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConfigNotFound, err)
+	}
+	if err := authorizeConfig(ctx, config, models.PermissionRead); err != nil {
+		return nil, err
+	}
+
+	if reviewer == "" || reviewer == config.UpdatedBy {
+		return nil, fmt.Errorf("%w: 不能审批自己提交的版本", ErrReviewerNotAllowed)
+	}
+	if len(config.Reviewers) > 0 && !slices.Contains(config.Reviewers, reviewer) {
+		return nil, fmt.Errorf("%w: 不是该版本指派的审批人", ErrReviewerNotAllowed)
+	}
+
+	approval := &models.ConfigApproval{
+		ConfigID:      config.ID,
+		ConfigVersion: config.Version,
+		Reviewer:      reviewer,
+		Decision:      req.Decision,
+		Comment:       req.Comment,
+		CreatedAt:     time.Now(),
+	}
+	if err := s.approvalRepo.Save(ctx, approval); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"config_id": config.ID,
+		"version":   config.Version,
+		"reviewer":  reviewer,
+		"decision":  req.Decision,
+	}).Info("提交配置审批")
+
+	return approval, nil
+}
+
+// Status 获取配置指定版本的审批进度，version<=0表示当前版本
+func (s *approvalService) Status(ctx context.Context, configID string, version int) (*models.ConfigApprovalStatus, error) {
+	config, err := s.configRepo.GetByID(ctx, configID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConfigNotFound, err)
+	}
+	if err := authorizeConfig(ctx, config, models.PermissionRead); err != nil {
+		return nil, err
+	}
+
+	// 指派的审批人只对当前版本有意义
+	var reviewers []string
+	if version <= 0 || version == config.Version {
+		version = config.Version
+		reviewers = config.Reviewers
+	}
+	return s.status(ctx, config.ID, version, reviewers)
+}
+
+// CheckDeployable 检查配置当前版本是否已满足审批要求
+func (s *approvalService) CheckDeployable(ctx context.Context, config *models.Config) error {
+	if s.required == 0 {
+		return nil
+	}
+
+	status, err := s.status(ctx, config.ID, config.Version, config.Reviewers)
+	if err != nil {
+		return err
+	}
+	if !status.Approved {
+		if status.Rejected {
+			return fmt.Errorf("%w: 版本 %d 已被驳回", ErrConfigNotApproved, config.Version)
+		}
+		return fmt.Errorf("%w: 版本 %d 已有 %d/%d 个通过", ErrConfigNotApproved, config.Version, status.Approvals, status.Required)
+	}
+	return nil
+}
+
+// status 汇总审批记录
+func (s *approvalService) status(ctx context.Context, configID string, version int, reviewers []string) (*models.ConfigApprovalStatus, error) {
+	records, err := s.approvalRepo.ListByVersion(ctx, configID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &models.ConfigApprovalStatus{
+		ConfigID:      configID,
+		ConfigVersion: version,
+		Required:      s.required,
+		Reviewers:     nonNilStrings(reviewers),
+		Pending:       []string{},
+		Records:       records,
+	}
+
+	decided := make(map[string]bool, len(records))
+	for _, record := range records {
+		// 指派审批人后只统计指派的审批人
+		if len(reviewers) > 0 && !slices.Contains(reviewers, record.Reviewer) {
+			continue
+		}
+		decided[record.Reviewer] = true
+		switch record.Decision {
+		case models.ApprovalApproved:
+			status.Approvals++
+		case models.ApprovalRejected:
+			status.Rejected = true
+		}
+	}
+	for _, reviewer := range reviewers {
+		if !decided[reviewer] {
+			status.Pending = append(status.Pending, reviewer)
+		}
+	}
+
+	status.Approved = !status.Rejected && status.Approvals >= s.required
+	return status, nil
+}
+
+// normalizeReviewers 去重排序指派的审批人，提交者不能审批自己的修改，不计入审批人
+func normalizeReviewers(reviewers []string, author string) []string {
+	var normalized []string
+	for _, reviewer := range reviewers {
+		if reviewer != "" && reviewer != author && !slices.Contains(normalized, reviewer) {
+			normalized = append(normalized, reviewer)
+		}
+	}
+	slices.Sort(normalized)
+	return normalized
+}
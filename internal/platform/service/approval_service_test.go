@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/tests/mocks"
+)
+
+// memApprovalRepository 内存审批记录仓库
+type memApprovalRepository struct {
+	mu        sync.Mutex
+	approvals map[string]*models.ConfigApproval
+}
+
+func (r *memApprovalRepository) Save(ctx context.Context, approval *models.ConfigApproval) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	approval.ID = fmt.Sprintf("%s-v%d-%s", approval.ConfigID, approval.ConfigVersion, approval.Reviewer)
+	copied := *approval
+	r.approvals[approval.ID] = &copied
+	return nil
+}
+
+func (r *memApprovalRepository) ListByVersion(ctx context.Context, configID string, version int) ([]*models.ConfigApproval, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var approvals []*models.ConfigApproval
+	for _, a := range r.approvals {
+		if a.ConfigID == configID && a.ConfigVersion == version {
+			copied := *a
+			approvals = append(approvals, &copied)
+		}
+	}
+	return approvals, nil
+}
+
+func TestApprovalService(t *testing.T) {
+	ctx := context.Background()
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	config := &models.Config{ID: "cfg-1", Version: 3, UpdatedBy: "alice", Enabled: true}
+	assigned := &models.Config{ID: "cfg-2", Version: 1, UpdatedBy: "alice", Reviewers: []string{"bob", "carol"}, Enabled: true}
+	configRepo := new(mocks.MockConfigRepository)
+	configRepo.On("GetByID", mock.Anything, "cfg-1").Return(config, nil)
+	configRepo.On("GetByID", mock.Anything, "cfg-2").Return(assigned, nil)
+	configRepo.On("GetByID", mock.Anything, "missing").Return(nil, fmt.Errorf("文档不存在"))
+
+	repo := &memApprovalRepository{approvals: make(map[string]*models.ConfigApproval)}
+	svc := NewApprovalService(2, repo, configRepo, logger)
+	approve := &models.ApproveConfigRequest{Decision: models.ApprovalApproved}
+
+	t.Run("达到通过数后才能部署", func(t *testing.T) {
+		assert.ErrorIs(t, svc.CheckDeployable(ctx, config), ErrConfigNotApproved)
+
+		_, err := svc.Submit(ctx, "cfg-1", approve, "alice")
+		assert.ErrorIs(t, err, ErrReviewerNotAllowed, "提交者不能审批自己的版本")
+
+		_, err = svc.Submit(ctx, "cfg-1", approve, "bob")
+		require.NoError(t, err)
+		assert.ErrorContains(t, svc.CheckDeployable(ctx, config), "1/2")
+
+		_, err = svc.Submit(ctx, "cfg-1", approve, "dave")
+		require.NoError(t, err)
+		assert.NoError(t, svc.CheckDeployable(ctx, config))
+
+		status, err := svc.Status(ctx, "cfg-1", 0)
+		require.NoError(t, err)
+		assert.Equal(t, 3, status.ConfigVersion)
+		assert.Equal(t, 2, status.Approvals)
+		assert.True(t, status.Approved)
+		assert.Len(t, status.Records, 2)
+
+		// 新版本需要重新审批
+		assert.ErrorIs(t, svc.CheckDeployable(ctx, &models.Config{ID: "cfg-1", Version: 4}), ErrConfigNotApproved)
+	})
+
+	t.Run("驳回阻止部署直到改为通过", func(t *testing.T) {
+		_, err := svc.Submit(ctx, "cfg-1", &models.ApproveConfigRequest{Decision: models.ApprovalRejected, Comment: "缺少索引"}, "erin")
+		require.NoError(t, err)
+		assert.ErrorContains(t, svc.CheckDeployable(ctx, config), "已被驳回")
+
+		_, err = svc.Submit(ctx, "cfg-1", approve, "erin")
+		require.NoError(t, err)
+		assert.NoError(t, svc.CheckDeployable(ctx, config))
+	})
+
+	t.Run("只有指派的审批人可以审批", func(t *testing.T) {
+		_, err := svc.Submit(ctx, "cfg-2", approve, "dave")
+		assert.ErrorIs(t, err, ErrReviewerNotAllowed)
+
+		_, err = svc.Submit(ctx, "cfg-2", approve, "bob")
+		require.NoError(t, err)
+
+		status, err := svc.Status(ctx, "cfg-2", 0)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"carol"}, status.Pending)
+		assert.False(t, status.Approved)
+	})
+
+	t.Run("配置不存在", func(t *testing.T) {
+		_, err := svc.Submit(ctx, "missing", approve, "bob")
+		assert.ErrorIs(t, err, ErrConfigNotFound)
+	})
+
+	t.Run("不要求审批", func(t *testing.T) {
+		assert.NoError(t, NewApprovalService(0, repo, configRepo, logger).CheckDeployable(ctx, &models.Config{ID: "cfg-9"}))
+	})
+}
+
+func TestNormalizeReviewers(t *testing.T) {
+	assert.Equal(t, []string{"bob", "carol"}, normalizeReviewers([]string{"carol", "", "alice", "bob", "carol"}, "alice"))
+	assert.Nil(t, normalizeReviewers(nil, "alice"))
+}
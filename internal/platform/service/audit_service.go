@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+)
+
+// AuditService 审计记录服务接口
+type AuditService interface {
+	// Record 保存一条审计记录，由审计中间件在变更类请求处理完成后调用
+	Record(ctx context.Context, entry *models.AuditEntry) error
+	List(ctx context.Context, req *models.AuditListRequest) ([]*models.AuditEntry, int64, error)
+}
+
+// auditService 审计记录服务实现
+type auditService struct {
+	auditRepo repository.AuditRepository
+	logger    *logrus.Logger
+}
+
+// NewAuditService 创建审计记录服务
+func NewAuditService(auditRepo repository.AuditRepository, logger *logrus.Logger) AuditService {
+	return &auditService{
+		auditRepo: auditRepo,
+		logger:    logger,
+	}
+}
+
+// Record 保存审计记录
+func (s *auditService) Record(ctx context.Context, entry *models.AuditEntry) error {
+	return s.auditRepo.Create(ctx, entry)
+}
+
+// List 按操作人、资源类型和时间范围查询审计记录
+func (s *auditService) List(ctx context.Context, req *models.AuditListRequest) ([]*models.AuditEntry, int64, error) {
+	return s.auditRepo.List(ctx, req)
+}
@@ -0,0 +1,398 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+	"logstash-platform/pkg/agentauth"
+)
+
+// 认证错误
+var (
+	ErrInvalidCredentials = errors.New("用户名或密码错误")
+	ErrInvalidToken       = errors.New("令牌无效")
+	ErrTokenExpired       = errors.New("令牌已过期")
+	ErrUserExists         = errors.New("用户已存在")
+	ErrUserNotFound       = errors.New("用户不存在")
+	ErrInvalidRole        = errors.New("角色无效")
+	ErrWeakJWTSecret      = errors.New("JWT签名密钥无效")
+)
+
+const (
+	// defaultTokenTTL 令牌默认有效期
+	defaultTokenTTL = 24 * time.Hour
+	// passwordIterations PBKDF2迭代次数
+	passwordIterations = 210000
+	// passwordKeyLength 派生密钥长度
+	passwordKeyLength = 32
+	// MinJWTSecretLength 启用认证时JWT签名密钥的最小字节数
+	MinJWTSecretLength = 32
+	// placeholderJWTSecret 早期示例配置中的占位密钥，已公开，不能用于签名
+	placeholderJWTSecret = "your-secret-key-here"
+)
+
+// jwtHeader 固定的HS256令牌头
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// AuthService 认证与用户管理服务接口
+type AuthService interface {
+	Login(ctx context.Context, req *models.LoginRequest) (*models.LoginResponse, error)
+	VerifyToken(ctx context.Context, token string) (*models.TokenClaims, error)
+	CreateUser(ctx context.Context, req *models.CreateUserRequest, operator string) (*models.User, error)
+	UpdateUser(ctx context.Context, username string, req *models.UpdateUserRequest) (*models.User, error)
+	DeleteUser(ctx context.Context, username string) error
+	GetUser(ctx context.Context, username string) (*models.User, error)
+	ListUsers(ctx context.Context) ([]*models.User, error)
+	EnsureBootstrapAdmin(ctx context.Context, username, password string) error
+}
+
+// authService 认证与用户管理服务实现
+type authService struct {
+	userRepo    repository.UserRepository
+	secret      []byte
+	ttl         time.Duration
+	agentTokens []string          // Agent共享令牌，持有者以RoleAgent身份访问Agent接口
+	enrollment  AgentTokenService // Agent注册令牌，为nil时不接受注册令牌
+	logger      *logrus.Logger
+	now         func() time.Time
+}
+
+// NewAuthService 创建认证服务
+func NewAuthService(userRepo repository.UserRepository, secret string, ttl time.Duration, agentTokens []string,
+	enrollment AgentTokenService, logger *logrus.Logger) AuthService {
+	if ttl <= 0 {
+		ttl = defaultTokenTTL
+	}
+	return &authService{
+		userRepo:    userRepo,
+		secret:      []byte(secret),
+		ttl:         ttl,
+		agentTokens: agentTokens,
+		enrollment:  enrollment,
+		logger:      logger,
+		now:         time.Now,
+	}
+}
+
+// ValidateJWTSecret 检查启用认证时使用的JWT签名密钥：不能为空、不能是示例配置中的占位值，且不少于32字节
+func ValidateJWTSecret(secret string) error {
+	switch {
+	case secret == "":
+		return fmt.Errorf("%w: 未设置 security.jwt_secret", ErrWeakJWTSecret)
+	case secret == placeholderJWTSecret:
+		return fmt.Errorf("%w: security.jwt_secret 仍是示例配置中的占位值", ErrWeakJWTSecret)
+	case len(secret) < MinJWTSecretLength:
+		return fmt.Errorf("%w: security.jwt_secret 长度不足%d字节", ErrWeakJWTSecret, MinJWTSecretLength)
+	}
+	return nil
+}
+
+// Login 校验用户名密码并签发访问令牌
+func (s *authService) Login(ctx context.Context, req *models.LoginRequest) (*models.LoginResponse, error) {
+	user, err := s.userRepo.GetByUsername(ctx, req.Username)
+	if err != nil {
+		if err.Error() == "文档不存在" {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	if user.Disabled || !verifyPassword(user.PasswordHash, req.Password) {
+		return nil, ErrInvalidCredentials
+	}
+
+	now := s.now()
+	claims := &models.TokenClaims{
+		Subject:      user.Username,
+		Role:         user.Role,
+		Teams:        user.Teams,
+		ProjectRoles: user.ProjectRoles,
+		IssuedAt:     now.Unix(),
+		ExpiresAt:    now.Add(s.ttl).Unix(),
+	}
+	token, err := s.signToken(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	user.LastLoginAt = &now
+	if err := s.userRepo.Save(ctx, user); err != nil {
+		s.logger.WithError(err).WithField("username", user.Username).Warn("更新最近登录时间失败")
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"username": user.Username,
+		"role":     user.Role,
+	}).Info("用户登录成功")
+
+	return &models.LoginResponse{
+		Token:     token,
+		ExpiresAt: time.Unix(claims.ExpiresAt, 0),
+		User:      user.Sanitized(),
+	}, nil
+}
+
+// VerifyToken 校验令牌签名与有效期，Agent共享令牌直接映射为Agent身份，注册令牌映射为对应Agent的身份；
+// 用户令牌按当前的用户记录返回角色、团队和项目角色，用户被禁用或删除时令牌随即失效
+func (s *authService) VerifyToken(ctx context.Context, token string) (*models.TokenClaims, error) {
+	if agentauth.IsEnrollmentToken(token) {
+		if s.enrollment == nil {
+			return nil, ErrInvalidToken
+		}
+		return s.enrollment.Verify(ctx, token)
+	}
+
+	for _, agentToken := range s.agentTokens {
+		if agentToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(agentToken)) == 1 {
+			return &models.TokenClaims{Subject: "agent", Role: models.RoleAgent}, nil
+		}
+	}
+
+	parts := strings.Split(token, ".")
+	if len(s.secret) == 0 || len(parts) != 3 || parts[0] != jwtHeader {
+		return nil, ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, s.sign(parts[0]+"."+parts[1])) {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var claims models.TokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Subject == "" {
+		return nil, ErrInvalidToken
+	}
+	if s.now().Unix() >= claims.ExpiresAt {
+		return nil, ErrTokenExpired
+	}
+
+	user, err := s.userRepo.GetByUsername(ctx, claims.Subject)
+	if err != nil {
+		if err.Error() == "文档不存在" {
+			return nil, fmt.Errorf("%w: 用户已删除", ErrInvalidToken)
+		}
+		return nil, fmt.Errorf("获取用户失败: %w", err)
+	}
+	if user.Disabled {
+		return nil, fmt.Errorf("%w: 用户已禁用", ErrInvalidToken)
+	}
+	// 令牌中的角色是签发时的快照，以当前记录为准
+	claims.Role = user.Role
+	claims.Teams = user.Teams
+	claims.ProjectRoles = user.ProjectRoles
+
+	return &claims, nil
+}
+
+// CreateUser 创建用户
+func (s *authService) CreateUser(ctx context.Context, req *models.CreateUserRequest, operator string) (*models.User, error) {
+	if !models.ValidRole(req.Role) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidRole, req.Role)
+	}
+	if err := models.ValidateProjectRoles(req.ProjectRoles); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRole, err)
+	}
+	if _, err := s.userRepo.GetByUsername(ctx, req.Username); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUserExists, req.Username)
+	}
+
+	hash, err := hashPassword(req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	user := &models.User{
+		Username:     req.Username,
+		PasswordHash: hash,
+		Role:         req.Role,
+		Teams:        req.Teams,
+		ProjectRoles: req.ProjectRoles,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		CreatedBy:    operator,
+	}
+	if err := s.userRepo.Save(ctx, user); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"username": user.Username,
+		"role":     user.Role,
+		"operator": operator,
+	}).Info("创建用户成功")
+
+	return user.Sanitized(), nil
+}
+
+// UpdateUser 更新用户角色、团队、项目角色、密码或禁用状态
+// 角色、团队、项目角色和禁用状态的变更对已签发的令牌立即生效
+func (s *authService) UpdateUser(ctx context.Context, username string, req *models.UpdateUserRequest) (*models.User, error) {
+	user, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, s.lookupError(username, err)
+	}
+
+	if req.Role != nil {
+		if !models.ValidRole(*req.Role) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidRole, *req.Role)
+		}
+		user.Role = *req.Role
+	}
+	if req.Password != nil {
+		hash, err := hashPassword(*req.Password)
+		if err != nil {
+			return nil, err
+		}
+		user.PasswordHash = hash
+	}
+	if req.Disabled != nil {
+		user.Disabled = *req.Disabled
+	}
+	if req.Teams != nil {
+		user.Teams = *req.Teams
+	}
+	if req.ProjectRoles != nil {
+		if err := models.ValidateProjectRoles(*req.ProjectRoles); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidRole, err)
+		}
+		user.ProjectRoles = *req.ProjectRoles
+	}
+	user.UpdatedAt = s.now()
+
+	if err := s.userRepo.Save(ctx, user); err != nil {
+		return nil, err
+	}
+	return user.Sanitized(), nil
+}
+
+// DeleteUser 删除用户
+func (s *authService) DeleteUser(ctx context.Context, username string) error {
+	if _, err := s.userRepo.GetByUsername(ctx, username); err != nil {
+		return s.lookupError(username, err)
+	}
+	return s.userRepo.Delete(ctx, username)
+}
+
+// GetUser 获取用户
+func (s *authService) GetUser(ctx context.Context, username string) (*models.User, error) {
+	user, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, s.lookupError(username, err)
+	}
+	return user.Sanitized(), nil
+}
+
+// ListUsers 获取全部用户
+func (s *authService) ListUsers(ctx context.Context) ([]*models.User, error) {
+	users, err := s.userRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i, u := range users {
+		users[i] = u.Sanitized()
+	}
+	return users, nil
+}
+
+// EnsureBootstrapAdmin 平台中还没有任何用户时创建初始管理员
+func (s *authService) EnsureBootstrapAdmin(ctx context.Context, username, password string) error {
+	if username == "" || password == "" {
+		return nil
+	}
+	users, err := s.userRepo.List(ctx)
+	if err != nil {
+		return err
+	}
+	if len(users) > 0 {
+		return nil
+	}
+
+	_, err = s.CreateUser(ctx, &models.CreateUserRequest{
+		Username: username,
+		Password: password,
+		Role:     models.RoleAdmin,
+	}, "system")
+	return err
+}
+
+// lookupError 将仓库的文档不存在错误转换为ErrUserNotFound
+func (s *authService) lookupError(username string, err error) error {
+	if err.Error() == "文档不存在" {
+		return fmt.Errorf("%w: %s", ErrUserNotFound, username)
+	}
+	return fmt.Errorf("获取用户失败: %w", err)
+}
+
+// signToken 签发HS256令牌
+func (s *authService) signToken(claims *models.TokenClaims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(s.sign(unsigned)), nil
+}
+
+// sign 计算HMAC-SHA256签名
+func (s *authService) sign(data string) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// hashPassword 使用PBKDF2-SHA256派生口令哈希，格式为 pbkdf2-sha256$迭代次数$盐$哈希
+func hashPassword(password string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("生成盐值失败: %w", err)
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, passwordIterations, passwordKeyLength)
+	if err != nil {
+		return "", fmt.Errorf("计算口令哈希失败: %w", err)
+	}
+	return fmt.Sprintf("pbkdf2-sha256$%d$%s$%s", passwordIterations,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// verifyPassword 校验口令
+func verifyPassword(encoded, password string) bool {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 4 || parts[0] != "pbkdf2-sha256" {
+		return false
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	expected, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(expected))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(key, expected) == 1
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+)
+
+// memUserRepository 内存中的用户仓库
+type memUserRepository struct {
+	mu    sync.Mutex
+	users map[string]*models.User
+}
+
+func (r *memUserRepository) Save(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cp := *user
+	r.users[user.Username] = &cp
+	return nil
+}
+
+func (r *memUserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[username]
+	if !ok {
+		return nil, errors.New("文档不存在")
+	}
+	cp := *user
+	return &cp, nil
+}
+
+func (r *memUserRepository) Delete(ctx context.Context, username string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.users, username)
+	return nil
+}
+
+func (r *memUserRepository) List(ctx context.Context) ([]*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	users := make([]*models.User, 0, len(r.users))
+	for _, u := range r.users {
+		cp := *u
+		users = append(users, &cp)
+	}
+	return users, nil
+}
+
+func newTestAuthService() (*authService, *memUserRepository) {
+	repo := &memUserRepository{users: make(map[string]*models.User)}
+	logger := logrus.New()
+	logger.SetLevel(logrus.WarnLevel)
+	svc := NewAuthService(repo, "test-secret", time.Hour, []string{"agent-token"}, nil, logger).(*authService)
+	return svc, repo
+}
+
+func TestAuthService_LoginAndVerify(t *testing.T) {
+	svc, repo := newTestAuthService()
+	ctx := context.Background()
+
+	user, err := svc.CreateUser(ctx, &models.CreateUserRequest{Username: "alice", Password: "s3cret-pass", Role: models.RoleEditor}, "admin")
+	require.NoError(t, err)
+	assert.Empty(t, user.PasswordHash)
+	assert.NotEmpty(t, repo.users["alice"].PasswordHash)
+
+	_, err = svc.CreateUser(ctx, &models.CreateUserRequest{Username: "alice", Password: "another-pass", Role: models.RoleViewer}, "admin")
+	assert.ErrorIs(t, err, ErrUserExists)
+
+	t.Run("错误的密码", func(t *testing.T) {
+		_, err := svc.Login(ctx, &models.LoginRequest{Username: "alice", Password: "wrong"})
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+		_, err = svc.Login(ctx, &models.LoginRequest{Username: "nobody", Password: "wrong"})
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	})
+
+	t.Run("登录签发的令牌可以校验", func(t *testing.T) {
+		resp, err := svc.Login(ctx, &models.LoginRequest{Username: "alice", Password: "s3cret-pass"})
+		require.NoError(t, err)
+		assert.Empty(t, resp.User.PasswordHash)
+
+		claims, err := svc.VerifyToken(ctx, resp.Token)
+		require.NoError(t, err)
+		assert.Equal(t, "alice", claims.Subject)
+		assert.Equal(t, models.RoleEditor, claims.Role)
+
+		// 篡改载荷后签名校验失败
+		tampered := resp.Token[:len(resp.Token)-2] + "xx"
+		_, err = svc.VerifyToken(ctx, tampered)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("过期令牌", func(t *testing.T) {
+		resp, err := svc.Login(ctx, &models.LoginRequest{Username: "alice", Password: "s3cret-pass"})
+		require.NoError(t, err)
+
+		svc.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+		defer func() { svc.now = time.Now }()
+
+		_, err = svc.VerifyToken(ctx, resp.Token)
+		assert.ErrorIs(t, err, ErrTokenExpired)
+	})
+
+	t.Run("角色变更对已签发的令牌立即生效", func(t *testing.T) {
+		resp, err := svc.Login(ctx, &models.LoginRequest{Username: "alice", Password: "s3cret-pass"})
+		require.NoError(t, err)
+
+		viewer := models.RoleViewer
+		teams := []string{"payments"}
+		projectRoles := map[string]string{"payments": models.RoleEditor}
+		_, err = svc.UpdateUser(ctx, "alice", &models.UpdateUserRequest{Role: &viewer, Teams: &teams, ProjectRoles: &projectRoles})
+		require.NoError(t, err)
+
+		claims, err := svc.VerifyToken(ctx, resp.Token)
+		require.NoError(t, err)
+		assert.Equal(t, models.RoleViewer, claims.Role)
+		assert.Equal(t, teams, claims.Teams)
+		assert.Equal(t, projectRoles, claims.ProjectRoles)
+	})
+
+	t.Run("禁用用户无法登录", func(t *testing.T) {
+		resp, err := svc.Login(ctx, &models.LoginRequest{Username: "alice", Password: "s3cret-pass"})
+		require.NoError(t, err)
+
+		disabled := true
+		_, err = svc.UpdateUser(ctx, "alice", &models.UpdateUserRequest{Disabled: &disabled})
+		require.NoError(t, err)
+
+		_, err = svc.Login(ctx, &models.LoginRequest{Username: "alice", Password: "s3cret-pass"})
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+		// 禁用前签发的令牌随即失效
+		_, err = svc.VerifyToken(ctx, resp.Token)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("删除用户后令牌失效", func(t *testing.T) {
+		_, err := svc.CreateUser(ctx, &models.CreateUserRequest{Username: "bob", Password: "s3cret-pass", Role: models.RoleViewer}, "admin")
+		require.NoError(t, err)
+		resp, err := svc.Login(ctx, &models.LoginRequest{Username: "bob", Password: "s3cret-pass"})
+		require.NoError(t, err)
+
+		require.NoError(t, svc.DeleteUser(ctx, "bob"))
+		_, err = svc.VerifyToken(ctx, resp.Token)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+}
+
+func TestAuthService_JWTSecret(t *testing.T) {
+	assert.ErrorIs(t, ValidateJWTSecret(""), ErrWeakJWTSecret)
+	assert.ErrorIs(t, ValidateJWTSecret("your-secret-key-here"), ErrWeakJWTSecret)
+	assert.ErrorIs(t, ValidateJWTSecret("too-short"), ErrWeakJWTSecret)
+	assert.NoError(t, ValidateJWTSecret("0123456789abcdef0123456789abcdef"))
+
+	// 未设置签名密钥时不接受任何用户令牌，防止以空密钥伪造
+	repo := &memUserRepository{users: map[string]*models.User{"root": {Username: "root", Role: models.RoleAdmin}}}
+	svc := NewAuthService(repo, "", time.Hour, nil, nil, logrus.New()).(*authService)
+	forged, err := svc.signToken(&models.TokenClaims{Subject: "root", Role: models.RoleAdmin, ExpiresAt: time.Now().Add(time.Hour).Unix()})
+	require.NoError(t, err)
+	_, err = svc.VerifyToken(context.Background(), forged)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestAuthService_AgentTokenAndBootstrap(t *testing.T) {
+	svc, repo := newTestAuthService()
+	ctx := context.Background()
+
+	claims, err := svc.VerifyToken(context.Background(), "agent-token")
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleAgent, claims.Role)
+
+	require.NoError(t, svc.EnsureBootstrapAdmin(ctx, "root", "bootstrap-pass"))
+	assert.Equal(t, models.RoleAdmin, repo.users["root"].Role)
+
+	// 已有用户时不再创建
+	require.NoError(t, svc.EnsureBootstrapAdmin(ctx, "other", "bootstrap-pass"))
+	assert.NotContains(t, repo.users, "other")
+
+	_, err = svc.GetUser(ctx, "missing")
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
@@ -0,0 +1,383 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+)
+
+// ErrCMDBHostNotFound CMDB中不存在对应主机
+var ErrCMDBHostNotFound = errors.New("CMDB中不存在该主机")
+
+// defaultCMDBSyncInterval CMDB默认同步周期
+const defaultCMDBSyncInterval = 10 * time.Minute
+
+// CMDBConnector 外部CMDB连接器
+type CMDBConnector interface {
+	// Lookup 查询Agent所在主机的业务元数据，主机不存在时返回ErrCMDBHostNotFound
+	Lookup(ctx context.Context, agent *models.Agent) (map[string]string, error)
+	// PushEvent 将Agent生命周期事件回写到CMDB
+	PushEvent(ctx context.Context, event *models.AgentLifecycleEvent) error
+}
+
+// RESTConnectorConfig 通用REST连接器配置
+type RESTConnectorConfig struct {
+	BaseURL      string            // CMDB服务地址
+	Token        string            // 以Bearer方式携带的访问令牌
+	LookupPath   string            // 主机查询路径，支持 {hostname}、{ip}、{agent_id} 占位符
+	ResultPath   string            // 响应中主机记录的位置，如 data.0；为空表示响应本身
+	FieldMapping map[string]string // 元数据字段 -> 主机记录中的字段路径
+	EventsPath   string            // 生命周期事件回写路径，为空表示不回写
+	Timeout      time.Duration
+}
+
+// restConnector 通用REST连接器实现
+type restConnector struct {
+	cfg    RESTConnectorConfig
+	client *http.Client
+}
+
+// NewRESTConnector 创建通用REST连接器
+func NewRESTConnector(cfg RESTConnectorConfig) CMDBConnector {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	return &restConnector{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// Lookup 查询主机记录并按字段映射提取元数据
+func (c *restConnector) Lookup(ctx context.Context, agent *models.Agent) (map[string]string, error) {
+	path := strings.NewReplacer(
+		"{hostname}", url.QueryEscape(agent.Hostname),
+		"{ip}", url.QueryEscape(agent.IP),
+		"{agent_id}", url.QueryEscape(agent.AgentID),
+	).Replace(c.cfg.LookupPath)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(c.cfg.BaseURL, "/")+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	c.authorize(req)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("查询CMDB失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrCMDBHostNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("查询CMDB失败: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var doc interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("解析CMDB响应失败: %w", err)
+	}
+
+	record, ok := lookupJSONPath(doc, c.cfg.ResultPath)
+	if !ok || record == nil {
+		return nil, ErrCMDBHostNotFound
+	}
+
+	metadata := make(map[string]string, len(c.cfg.FieldMapping))
+	for field, path := range c.cfg.FieldMapping {
+		value, ok := lookupJSONPath(record, path)
+		if !ok || value == nil {
+			continue
+		}
+		metadata[field] = stringifyJSONValue(value)
+	}
+
+	return metadata, nil
+}
+
+// PushEvent 回写生命周期事件
+func (c *restConnector) PushEvent(ctx context.Context, event *models.AgentLifecycleEvent) error {
+	if c.cfg.EventsPath == "" {
+		return nil
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(c.cfg.BaseURL, "/")+c.cfg.EventsPath, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	c.authorize(req)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("推送生命周期事件失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("推送生命周期事件失败: HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// authorize 设置认证头
+func (c *restConnector) authorize(req *http.Request) {
+	if c.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.cfg.Token)
+	}
+}
+
+// lookupJSONPath 按点号路径取值，数字段表示数组下标
+func lookupJSONPath(doc interface{}, path string) (interface{}, bool) {
+	if path == "" {
+		return doc, true
+	}
+	current := doc
+	for _, part := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			next, ok := node[part]
+			if !ok {
+				return nil, false
+			}
+			current = next
+		case []interface{}:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			current = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// stringifyJSONValue 将JSON取值转换为字符串
+func stringifyJSONValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// CMDBSync CMDB同步任务
+// 周期性地用CMDB中的业务元数据补全Agent，并将期间观察到的Agent生命周期变化回写到CMDB
+type CMDBSync struct {
+	agentRepo repository.AgentRepository
+	connector CMDBConnector
+	interval  time.Duration
+	logger    *logrus.Logger
+
+	mu       sync.Mutex
+	seeded   bool
+	statuses map[string]*models.Agent // 上一轮同步看到的Agent
+
+	tracker loopTracker
+}
+
+// NewCMDBSync 创建CMDB同步任务
+func NewCMDBSync(agentRepo repository.AgentRepository, connector CMDBConnector, interval time.Duration, logger *logrus.Logger) *CMDBSync {
+	if interval <= 0 {
+		interval = defaultCMDBSyncInterval
+	}
+	return &CMDBSync{
+		agentRepo: agentRepo,
+		connector: connector,
+		interval:  interval,
+		logger:    logger,
+		statuses:  make(map[string]*models.Agent),
+		tracker:   loopTracker{interval: interval},
+	}
+}
+
+// Start 启动周期同步，直到ctx取消
+func (s *CMDBSync) Start(ctx context.Context) {
+	defer s.tracker.start(time.Now())()
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		done := s.tracker.begin(time.Now())
+		_, err := s.SyncOnce(ctx)
+		done(err)
+		if err != nil && ctx.Err() == nil {
+			s.logger.Errorf("CMDB同步失败: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// WorkerStatus 报告周期同步的运行状态
+func (s *CMDBSync) WorkerStatus(now time.Time) models.WorkerStatus {
+	return s.tracker.status("cmdb_sync", now)
+}
+
+// SyncOnce 执行一次同步
+// 首轮同步只记录现有Agent，不推送事件，避免平台重启时向CMDB重复推送全部Agent
+func (s *CMDBSync) SyncOnce(ctx context.Context) (*models.CMDBSyncResult, error) {
+	agents, err := s.agentRepo.ListByLabels(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := &models.CMDBSyncResult{Agents: len(agents), SyncedAt: time.Now()}
+	seen := make(map[string]bool, len(agents))
+
+	for _, agent := range agents {
+		seen[agent.AgentID] = true
+
+		switch enriched, err := s.enrich(ctx, agent, result.SyncedAt); {
+		case errors.Is(err, ErrCMDBHostNotFound):
+			result.Missing++
+		case err != nil:
+			result.Failed++
+			s.logger.WithField("agent_id", agent.AgentID).Warnf("同步CMDB元数据失败: %v", err)
+		case enriched:
+			result.Enriched++
+		}
+
+		if s.seeded {
+			if event := s.diff(agent, result.SyncedAt); event != nil && s.push(ctx, event) {
+				result.Events++
+			}
+		}
+		s.statuses[agent.AgentID] = agent
+	}
+
+	for id, previous := range s.statuses {
+		if seen[id] {
+			continue
+		}
+		delete(s.statuses, id)
+		event := lifecycleEvent(models.AgentEventRemoved, previous, result.SyncedAt)
+		if s.seeded && s.push(ctx, event) {
+			result.Events++
+		}
+	}
+	s.seeded = true
+
+	s.logger.WithFields(logrus.Fields{
+		"agents":   result.Agents,
+		"enriched": result.Enriched,
+		"missing":  result.Missing,
+		"failed":   result.Failed,
+		"events":   result.Events,
+	}).Info("CMDB同步完成")
+
+	return result, nil
+}
+
+// enrich 查询并更新Agent的业务元数据，返回元数据是否发生变化
+func (s *CMDBSync) enrich(ctx context.Context, agent *models.Agent, now time.Time) (bool, error) {
+	metadata, err := s.connector.Lookup(ctx, agent)
+	if err != nil {
+		return false, err
+	}
+	if metadataEqual(agent.Metadata, metadata) {
+		return false, nil
+	}
+
+	// 重新读取最新文档，避免覆盖同步期间的心跳更新
+	latest, err := s.agentRepo.GetByID(ctx, agent.AgentID)
+	if err != nil {
+		return false, err
+	}
+	latest.Metadata = metadata
+	latest.MetadataSyncedAt = &now
+	if err := s.agentRepo.Save(ctx, latest); err != nil {
+		return false, err
+	}
+
+	agent.Metadata = metadata
+	return true, nil
+}
+
+// diff 与上一轮同步比较，生成生命周期事件
+func (s *CMDBSync) diff(agent *models.Agent, now time.Time) *models.AgentLifecycleEvent {
+	previous, ok := s.statuses[agent.AgentID]
+	if !ok {
+		return lifecycleEvent(models.AgentEventRegistered, agent, now)
+	}
+	if previous.Status != agent.Status {
+		event := lifecycleEvent(models.AgentEventStatusChanged, agent, now)
+		event.PreviousStatus = previous.Status
+		return event
+	}
+	return nil
+}
+
+// push 推送事件，失败只记录日志
+func (s *CMDBSync) push(ctx context.Context, event *models.AgentLifecycleEvent) bool {
+	if err := s.connector.PushEvent(ctx, event); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"agent_id": event.AgentID,
+			"event":    event.Type,
+		}).Warnf("推送Agent生命周期事件失败: %v", err)
+		return false
+	}
+	return true
+}
+
+// lifecycleEvent 由Agent构造生命周期事件
+func lifecycleEvent(eventType string, agent *models.Agent, now time.Time) *models.AgentLifecycleEvent {
+	return &models.AgentLifecycleEvent{
+		Type:      eventType,
+		AgentID:   agent.AgentID,
+		Hostname:  agent.Hostname,
+		IP:        agent.IP,
+		Status:    agent.Status,
+		Group:     agent.Group,
+		Metadata:  agent.Metadata,
+		Timestamp: now,
+	}
+}
+
+// metadataEqual 比较两份元数据
+func metadataEqual(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// memAgentRepository 内存中的Agent仓库
+type memAgentRepository struct {
+	mu     sync.Mutex
+	agents map[string]*models.Agent
+}
+
+func (r *memAgentRepository) Save(ctx context.Context, agent *models.Agent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cp := *agent
+	r.agents[agent.AgentID] = &cp
+	return nil
+}
+
+func (r *memAgentRepository) GetByID(ctx context.Context, id string) (*models.Agent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	agent, ok := r.agents[id]
+	if !ok {
+		return nil, elasticsearch.ErrNotFound
+	}
+	cp := *agent
+	return &cp, nil
+}
+
+func (r *memAgentRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.agents[id]; !ok {
+		return elasticsearch.ErrNotFound
+	}
+	delete(r.agents, id)
+	return nil
+}
+
+func (r *memAgentRepository) ListByGroup(ctx context.Context, group string) ([]*models.Agent, error) {
+	return nil, nil
+}
+
+func (r *memAgentRepository) ListByAppliedConfig(ctx context.Context, configID string) ([]*models.Agent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var agents []*models.Agent
+	for _, agent := range r.agents {
+		for _, applied := range agent.AppliedConfigs {
+			if applied.ConfigID == configID {
+				cp := *agent
+				agents = append(agents, &cp)
+				break
+			}
+		}
+	}
+	return agents, nil
+}
+
+func (r *memAgentRepository) ListByLabels(ctx context.Context, labels map[string]string) ([]*models.Agent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	agents := make([]*models.Agent, 0, len(r.agents))
+	for _, agent := range r.agents {
+		cp := *agent
+		agents = append(agents, &cp)
+	}
+	return agents, nil
+}
+
+// List 按Agent ID升序分页，游标为最后一个Agent的主机名和ID；只支持按项目过滤
+func (r *memAgentRepository) List(ctx context.Context, req *models.AgentListRequest) (*models.AgentListResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var agents []*models.Agent
+	total := 0
+	for _, agent := range r.agents {
+		if req.Project != "" && models.ProjectOf(agent.Project) != req.Project {
+			continue
+		}
+		total++
+		if len(req.After) == 2 && agent.AgentID <= req.After[1].(string) {
+			continue
+		}
+		cp := *agent
+		agents = append(agents, &cp)
+	}
+	sort.Slice(agents, func(i, j int) bool { return agents[i].AgentID < agents[j].AgentID })
+
+	resp := &models.AgentListResponse{Total: total, Items: agents}
+	if len(agents) > req.PageSize {
+		resp.Items = agents[:req.PageSize]
+		last := resp.Items[len(resp.Items)-1]
+		resp.NextCursor = models.EncodeCursor([]interface{}{last.Hostname, last.AgentID})
+	}
+	return resp, nil
+}
+
+// fakeCMDB 模拟CMDB的REST接口
+func fakeCMDB(t *testing.T) (*httptest.Server, chan models.AgentLifecycleEvent) {
+	events := make(chan models.AgentLifecycleEvent, 10)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/hosts", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		if r.URL.Query().Get("hostname") != "web-1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"data":[{"business":{"service":"checkout"},"owner":{"name":"team-pay"},"finance":{"cost_center":1024}}]}`))
+	})
+	mux.HandleFunc("/api/agent-events", func(w http.ResponseWriter, r *http.Request) {
+		var event models.AgentLifecycleEvent
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		events <- event
+		w.WriteHeader(http.StatusAccepted)
+	})
+	return httptest.NewServer(mux), events
+}
+
+func TestCMDBSync_EnrichAndPushEvents(t *testing.T) {
+	server, events := fakeCMDB(t)
+	defer server.Close()
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.WarnLevel)
+	ctx := context.Background()
+
+	repo := &memAgentRepository{agents: map[string]*models.Agent{
+		"agent-1": {AgentID: "agent-1", Hostname: "web-1", Status: "online"},
+		"agent-2": {AgentID: "agent-2", Hostname: "db-9", Status: "online"},
+	}}
+	connector := NewRESTConnector(RESTConnectorConfig{
+		BaseURL:    server.URL,
+		Token:      "secret",
+		LookupPath: "/api/hosts?hostname={hostname}",
+		ResultPath: "data.0",
+		FieldMapping: map[string]string{
+			"service":     "business.service",
+			"owner":       "owner.name",
+			"cost_center": "finance.cost_center",
+		},
+		EventsPath: "/api/agent-events",
+	})
+	sync := NewCMDBSync(repo, connector, time.Minute, logger)
+
+	t.Run("首轮同步补全元数据且不推送事件", func(t *testing.T) {
+		result, err := sync.SyncOnce(ctx)
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, result.Agents)
+		assert.Equal(t, 1, result.Enriched)
+		assert.Equal(t, 1, result.Missing)
+		assert.Equal(t, 0, result.Events)
+
+		agent, _ := repo.GetByID(ctx, "agent-1")
+		assert.Equal(t, map[string]string{"service": "checkout", "owner": "team-pay", "cost_center": "1024"}, agent.Metadata)
+		assert.NotNil(t, agent.MetadataSyncedAt)
+	})
+
+	t.Run("后续同步推送生命周期变化", func(t *testing.T) {
+		repo.agents["agent-1"].Status = "offline"
+		delete(repo.agents, "agent-2")
+		repo.agents["agent-3"] = &models.Agent{AgentID: "agent-3", Hostname: "new-1", Status: "online"}
+
+		result, err := sync.SyncOnce(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 0, result.Enriched)
+		assert.Equal(t, 3, result.Events)
+
+		got := make(map[string]models.AgentLifecycleEvent)
+		for i := 0; i < 3; i++ {
+			event := <-events
+			got[event.AgentID] = event
+		}
+		assert.Equal(t, models.AgentEventStatusChanged, got["agent-1"].Type)
+		assert.Equal(t, "online", got["agent-1"].PreviousStatus)
+		assert.Equal(t, "checkout", got["agent-1"].Metadata["service"])
+		assert.Equal(t, models.AgentEventRemoved, got["agent-2"].Type)
+		assert.Equal(t, models.AgentEventRegistered, got["agent-3"].Type)
+	})
+}
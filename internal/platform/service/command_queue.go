@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/tracing"
+)
+
+// defaultMaxPendingCommands 每个Agent默认最多缓存的待领取命令数
+const defaultMaxPendingCommands = 32
+
+// maxCommandDeliveries 未确认命令的最大下发次数，兼容不回传确认的旧版本Agent
+const maxCommandDeliveries = 5
+
+// CommandQueue Agent待领取命令队列
+// 命令保存在内存中，随Agent心跳响应下发，Agent在下一次心跳中回传已处理的命令ID后才移除（至少一次）；
+// 队列满时丢弃最早的命令，避免长期离线的Agent占用内存
+type CommandQueue struct {
+	mu          sync.Mutex
+	pending     map[string][]models.PendingCommand
+	maxPerAgent int
+}
+
+// NewCommandQueue 创建命令队列，maxPerAgent<=0时使用默认值
+func NewCommandQueue(maxPerAgent int) *CommandQueue {
+	if maxPerAgent <= 0 {
+		maxPerAgent = defaultMaxPendingCommands
+	}
+	return &CommandQueue{
+		pending:     make(map[string][]models.PendingCommand),
+		maxPerAgent: maxPerAgent,
+	}
+}
+
+// Publish 实现MessagePublisher接口，将消息排入Agent的待领取队列
+func (q *CommandQueue) Publish(agentID, msgType string, payload interface{}) error {
+	return q.PublishContext(context.Background(), agentID, msgType, payload)
+}
+
+// PublishContext 实现ContextPublisher接口，命令的metadata携带ctx中的链路上下文
+func (q *CommandQueue) PublishContext(ctx context.Context, agentID, msgType string, payload interface{}) error {
+	var raw json.RawMessage
+	switch v := payload.(type) {
+	case nil:
+	case json.RawMessage:
+		raw = v
+	default:
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("序列化命令失败: %w", err)
+		}
+		raw = data
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	commands := append(q.pending[agentID], models.PendingCommand{
+		ID:        uuid.New().String(),
+		Type:      msgType,
+		Payload:   raw,
+		CreatedAt: time.Now(),
+		Metadata:  tracing.Inject(ctx),
+	})
+	if len(commands) > q.maxPerAgent {
+		commands = commands[len(commands)-q.maxPerAgent:]
+	}
+	q.pending[agentID] = commands
+
+	return nil
+}
+
+// Drop 丢弃Agent全部待领取的命令，用于Agent注销
+func (q *CommandQueue) Drop(agentID string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.pending, agentID)
+}
+
+// Deliver 返回Agent全部未确认的命令，命令保留到Ack或达到最大下发次数
+func (q *CommandQueue) Deliver(agentID string) []models.PendingCommand {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	pending := q.pending[agentID]
+	if len(pending) == 0 {
+		return nil
+	}
+
+	delivered := make([]models.PendingCommand, 0, len(pending))
+	remaining := pending[:0]
+	for _, cmd := range pending {
+		cmd.Deliveries++
+		delivered = append(delivered, cmd)
+		if cmd.Deliveries < maxCommandDeliveries {
+			remaining = append(remaining, cmd)
+		}
+	}
+
+	if len(remaining) == 0 {
+		delete(q.pending, agentID)
+	} else {
+		q.pending[agentID] = remaining
+	}
+	return delivered
+}
+
+// Ack 移除Agent已确认处理的命令
+func (q *CommandQueue) Ack(agentID string, ids []string) {
+	if len(ids) == 0 {
+		return
+	}
+
+	acked := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		acked[id] = true
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	remaining := q.pending[agentID][:0]
+	for _, cmd := range q.pending[agentID] {
+		if !acked[cmd.ID] {
+			remaining = append(remaining, cmd)
+		}
+	}
+	if len(remaining) == 0 {
+		delete(q.pending, agentID)
+	} else {
+		q.pending[agentID] = remaining
+	}
+}
+
+// Pending 返回Agent当前待领取的命令数
+func (q *CommandQueue) Pending(agentID string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending[agentID])
+}
+
+// WorkerStatus 报告命令队列的积压：待领取命令总数、涉及的Agent数、已下发未确认（将重发）的命令数
+func (q *CommandQueue) WorkerStatus(now time.Time) models.WorkerStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var pending, unacked int
+	var oldest time.Time
+	for _, commands := range q.pending {
+		pending += len(commands)
+		for _, cmd := range commands {
+			if cmd.Deliveries > 0 {
+				unacked++
+			}
+			if oldest.IsZero() || cmd.CreatedAt.Before(oldest) {
+				oldest = cmd.CreatedAt
+			}
+		}
+	}
+
+	gauges := map[string]float64{
+		"pending":                float64(pending),
+		"agents":                 float64(len(q.pending)),
+		"unacked":                float64(unacked),
+		"oldest_pending_seconds": 0,
+	}
+	if !oldest.IsZero() {
+		gauges["oldest_pending_seconds"] = now.Sub(oldest).Seconds()
+	}
+	return models.WorkerStatus{Name: "command_queue", Kind: models.WorkerKindQueue, Running: true, Gauges: gauges}
+}
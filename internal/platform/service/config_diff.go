@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"logstash-platform/internal/platform/models"
+)
+
+// diffContextLines 版本差异中每段变更保留的上下文行数
+const diffContextLines = 3
+
+// ConfigDiff 配置两个版本之间的差异
+type ConfigDiff struct {
+	ConfigID         string           `json:"config_id"`
+	From             int              `json:"from"`
+	To               int              `json:"to"`
+	Additions        int              `json:"additions"`
+	Deletions        int              `json:"deletions"`
+	Unified          string           `json:"unified"` // 统一格式差异，内容相同时为空
+	Hunks            []DiffHunk       `json:"hunks"`
+	Metadata         []MetadataChange `json:"metadata"`
+	MetadataComplete bool             `json:"metadata_complete"` // 任一版本缺少元数据快照时为false，元数据差异不完整
+}
+
+// MetadataChange 元数据字段的变更
+type MetadataChange struct {
+	Field string      `json:"field"` // name, description, tags, enabled
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// DiffVersions 比较配置的两个历史版本，to为0时与当前版本比较
+func (s *configService) DiffVersions(ctx context.Context, configID string, from, to int) (*ConfigDiff, error) {
+	config, err := s.configRepo.GetByID(ctx, configID)
+	if err != nil {
+		return nil, fmt.Errorf("配置不存在: %w", err)
+	}
+	if err := authorizeConfig(ctx, config, models.PermissionRead); err != nil {
+		return nil, err
+	}
+	if to == 0 {
+		to = config.Version
+	}
+	if from < 1 || to < 1 {
+		return nil, fmt.Errorf("版本号必须大于0")
+	}
+
+	oldVersion, err := s.loadVersion(ctx, config, from)
+	if err != nil {
+		return nil, err
+	}
+	newVersion, err := s.loadVersion(ctx, config, to)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := DiffLines(oldVersion.Content, newVersion.Content)
+	hunks := DiffHunks(lines, diffContextLines)
+	result := &ConfigDiff{
+		ConfigID: configID,
+		From:     from,
+		To:       to,
+		Unified:  UnifiedDiff(fmt.Sprintf("%s@v%d", configID, from), fmt.Sprintf("%s@v%d", configID, to), hunks),
+		Hunks:    hunks,
+	}
+	if result.Hunks == nil {
+		result.Hunks = []DiffHunk{}
+	}
+	result.Additions, result.Deletions = countDiffLines(lines)
+	result.Metadata, result.MetadataComplete = diffMetadata(oldVersion, newVersion)
+
+	return result, nil
+}
+
+// loadVersion 获取指定版本的历史记录
+// 当前版本的历史记录缺少元数据快照时以当前配置补齐
+func (s *configService) loadVersion(ctx context.Context, config *models.Config, version int) (*models.ConfigHistory, error) {
+	history, err := s.configRepo.GetHistoryVersion(ctx, config.ID, version)
+	if err != nil {
+		if err.Error() == "文档不存在" {
+			return nil, fmt.Errorf("未找到版本 %d 的历史记录", version)
+		}
+		return nil, err
+	}
+
+	if history.Enabled == nil && version == config.Version {
+		snapshotted := *history
+		enabled := config.Enabled
+		snapshotted.Name = config.Name
+		snapshotted.Description = config.Description
+		snapshotted.Tags = config.Tags
+		snapshotted.Enabled = &enabled
+		history = &snapshotted
+	}
+	return history, nil
+}
+
+// diffMetadata 比较两个版本的元数据快照
+func diffMetadata(oldVersion, newVersion *models.ConfigHistory) ([]MetadataChange, bool) {
+	changes := []MetadataChange{}
+	if oldVersion.Enabled == nil || newVersion.Enabled == nil {
+		return changes, false
+	}
+
+	if oldVersion.Name != newVersion.Name {
+		changes = append(changes, MetadataChange{Field: "name", From: oldVersion.Name, To: newVersion.Name})
+	}
+	if oldVersion.Description != newVersion.Description {
+		changes = append(changes, MetadataChange{Field: "description", From: oldVersion.Description, To: newVersion.Description})
+	}
+	if !sameTags(oldVersion.Tags, newVersion.Tags) {
+		changes = append(changes, MetadataChange{Field: "tags", From: nonNilStrings(oldVersion.Tags), To: nonNilStrings(newVersion.Tags)})
+	}
+	if *oldVersion.Enabled != *newVersion.Enabled {
+		changes = append(changes, MetadataChange{Field: "enabled", From: *oldVersion.Enabled, To: *newVersion.Enabled})
+	}
+	return changes, true
+}
+
+// sameTags 标签集合是否相同，不考虑顺序
+func sameTags(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
+
+// nonNilStrings 空切片序列化为[]而不是null
+func nonNilStrings(items []string) []string {
+	if items == nil {
+		return []string{}
+	}
+	return items
+}
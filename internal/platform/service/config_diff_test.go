@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/tests/mocks"
+)
+
+func TestDiffHunks(t *testing.T) {
+	var oldLines, newLines []string
+	for i := 1; i <= 20; i++ {
+		oldLines = append(oldLines, fmt.Sprintf("line %d", i))
+	}
+	newLines = append(newLines, oldLines...)
+	newLines[1] = "line 2 changed"         // 第2行修改
+	newLines = append(newLines, "line 21") // 末尾新增
+
+	hunks := DiffHunks(DiffLines(strings.Join(oldLines, "\n"), strings.Join(newLines, "\n")), 3)
+	require.Len(t, hunks, 2)
+
+	assert.Equal(t, "@@ -1,5 +1,5 @@", hunks[0].Header())
+	assert.Equal(t, DiffLine{Op: DiffOpDelete, Text: "line 2"}, hunks[0].Lines[1])
+	assert.Equal(t, DiffLine{Op: DiffOpAdd, Text: "line 2 changed"}, hunks[0].Lines[2])
+	assert.Equal(t, "@@ -18,3 +18,4 @@", hunks[1].Header())
+
+	// 间隔不超过2倍上下文的变更合并为一段
+	newLines[8] = "line 9 changed"
+	hunks = DiffHunks(DiffLines(strings.Join(oldLines, "\n"), strings.Join(newLines, "\n")), 3)
+	require.Len(t, hunks, 2)
+	assert.Equal(t, "@@ -1,12 +1,12 @@", hunks[0].Header())
+
+	// 空文本一侧的起始行号为0
+	hunks = DiffHunks(DiffLines("", "a\nb"), 3)
+	require.Len(t, hunks, 1)
+	assert.Equal(t, "@@ -0,0 +1,2 @@", hunks[0].Header())
+
+	assert.Empty(t, DiffHunks(DiffLines("a\nb", "a\nb"), 3))
+}
+
+func TestConfigService_DiffVersions(t *testing.T) {
+	logger := logrus.New()
+	ctx := context.Background()
+	enabled := true
+
+	repo := new(mocks.MockConfigRepository)
+	repo.On("GetByID", ctx, "config-1").Return(&models.Config{
+		ID: "config-1", Name: "nginx", Version: 3, Enabled: false, Tags: []string{"web", "prod"},
+	}, nil)
+	repo.On("GetByID", ctx, "missing").Return(nil, fmt.Errorf("文档不存在"))
+	repo.On("GetHistoryVersion", ctx, "config-1", 1).Return(&models.ConfigHistory{
+		ConfigID: "config-1", Version: 1, Content: "filter {\n  mutate {}\n}\n",
+	}, nil)
+	repo.On("GetHistoryVersion", ctx, "config-1", 2).Return(&models.ConfigHistory{
+		ConfigID: "config-1", Version: 2, Content: "filter {\n  mutate {}\n}\n",
+		Name: "nginx", Description: "旧描述", Tags: []string{"web"}, Enabled: &enabled,
+	}, nil)
+	// 当前版本的历史记录没有元数据快照，使用当前配置补齐
+	repo.On("GetHistoryVersion", ctx, "config-1", 3).Return(&models.ConfigHistory{
+		ConfigID: "config-1", Version: 3, Content: "filter {\n  mutate {}\n  drop {}\n}\n",
+	}, nil)
+	repo.On("GetHistoryVersion", ctx, "config-1", 9).Return(nil, fmt.Errorf("文档不存在"))
+
+	svc := NewConfigService(repo, logger)
+
+	t.Run("与当前版本比较", func(t *testing.T) {
+		diff, err := svc.DiffVersions(ctx, "config-1", 2, 0)
+		require.NoError(t, err)
+
+		assert.Equal(t, 3, diff.To)
+		assert.Equal(t, 1, diff.Additions)
+		assert.Equal(t, 0, diff.Deletions)
+		assert.Equal(t, "--- config-1@v2\n+++ config-1@v3\n@@ -1,3 +1,4 @@\n filter {\n   mutate {}\n+  drop {}\n }\n", diff.Unified)
+		require.Len(t, diff.Hunks, 1)
+
+		assert.True(t, diff.MetadataComplete)
+		assert.Equal(t, []MetadataChange{
+			{Field: "description", From: "旧描述", To: ""},
+			{Field: "tags", From: []string{"web"}, To: []string{"web", "prod"}},
+			{Field: "enabled", From: true, To: false},
+		}, diff.Metadata)
+	})
+
+	t.Run("早期版本缺少元数据快照", func(t *testing.T) {
+		diff, err := svc.DiffVersions(ctx, "config-1", 1, 2)
+		require.NoError(t, err)
+		assert.Empty(t, diff.Unified)
+		assert.Empty(t, diff.Hunks)
+		assert.False(t, diff.MetadataComplete)
+		assert.Empty(t, diff.Metadata)
+	})
+
+	t.Run("版本或配置不存在", func(t *testing.T) {
+		_, err := svc.DiffVersions(ctx, "config-1", 9, 3)
+		assert.EqualError(t, err, "未找到版本 9 的历史记录")
+
+		_, err = svc.DiffVersions(ctx, "missing", 1, 2)
+		assert.ErrorContains(t, err, "配置不存在")
+	})
+}
@@ -0,0 +1,314 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+)
+
+var (
+	// ErrHistoryPruneRunning 已有配置历史清理正在进行
+	ErrHistoryPruneRunning = errors.New("配置历史清理正在进行中")
+	// ErrRetentionPolicyEmpty 保留策略未设置保留的版本数或天数
+	ErrRetentionPolicyEmpty = errors.New("未设置配置历史保留策略")
+)
+
+// HistoryRetentionConfig 配置历史定期清理参数
+type HistoryRetentionConfig struct {
+	Policy models.HistoryRetentionPolicy
+	RunAt  string // 每天执行的本地时间，格式 HH:MM，默认 03:00
+}
+
+// ConfigHistoryPruner 按保留策略定期清理配置历史
+// 每个配置的最新版本和未结束部署引用的版本总是保留；被清理的版本不能再查看、比较或回滚到
+type ConfigHistoryPruner struct {
+	policy      models.HistoryRetentionPolicy
+	runAt       time.Duration // 距当天零点的偏移
+	historyRepo repository.ConfigHistoryRepository
+	deployRepo  repository.DeploymentRepository
+	logger      *logrus.Logger
+	now         func() time.Time
+
+	running sync.Mutex
+	mu      sync.Mutex
+	lastRun *models.HistoryPruneRun
+
+	inProgress atomic.Bool
+	tracker    loopTracker
+}
+
+// NewConfigHistoryPruner 创建配置历史定期清理任务
+func NewConfigHistoryPruner(cfg HistoryRetentionConfig, historyRepo repository.ConfigHistoryRepository,
+	deployRepo repository.DeploymentRepository, logger *logrus.Logger) *ConfigHistoryPruner {
+	runAt := 3 * time.Hour
+	if cfg.RunAt != "" {
+		if t, err := time.Parse("15:04", cfg.RunAt); err == nil {
+			runAt = time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+		} else {
+			logger.Warnf("配置历史清理执行时间 %q 无效，使用默认值 03:00", cfg.RunAt)
+		}
+	}
+	p := &ConfigHistoryPruner{
+		policy:      cfg.Policy,
+		runAt:       runAt,
+		historyRepo: historyRepo,
+		deployRepo:  deployRepo,
+		logger:      logger,
+		now:         time.Now,
+	}
+	p.tracker = loopTracker{interval: 24 * time.Hour, schedule: p.nextRun}
+	return p
+}
+
+// Start 每天在设定时间按配置的保留策略清理一次，直到ctx取消
+func (p *ConfigHistoryPruner) Start(ctx context.Context) {
+	defer p.tracker.start(p.now())()
+	for {
+		timer := time.NewTimer(p.nextRun(p.now()).Sub(p.now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if _, err := p.Run(ctx); err != nil && ctx.Err() == nil {
+			p.logger.Errorf("定期清理配置历史失败: %v", err)
+		}
+	}
+}
+
+// nextRun 下一次执行时间
+func (p *ConfigHistoryPruner) nextRun(now time.Time) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).Add(p.runAt)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// WorkerStatus 报告配置历史清理的运行状态
+func (p *ConfigHistoryPruner) WorkerStatus(now time.Time) models.WorkerStatus {
+	status := p.tracker.status("config_history_retention", now)
+	inProgress := 0.0
+	if p.inProgress.Load() {
+		inProgress = 1
+	}
+	status.Gauges = map[string]float64{"in_progress": inProgress}
+	return status
+}
+
+// Policy 配置的保留策略
+func (p *ConfigHistoryPruner) Policy() models.HistoryRetentionPolicy {
+	return p.policy
+}
+
+// LastRun 最近一次完成的清理汇总，尚未执行过时返回nil
+func (p *ConfigHistoryPruner) LastRun() *models.HistoryPruneRun {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.lastRun
+}
+
+// Preview 按保留策略预览将被清理的历史，不删除记录
+func (p *ConfigHistoryPruner) Preview(ctx context.Context, policy models.HistoryRetentionPolicy) (*models.HistoryPruneRun, error) {
+	return p.prune(ctx, policy, true)
+}
+
+// Run 按配置的保留策略清理历史，已有清理正在进行时返回 ErrHistoryPruneRunning
+func (p *ConfigHistoryPruner) Run(ctx context.Context) (*models.HistoryPruneRun, error) {
+	if !p.running.TryLock() {
+		return nil, ErrHistoryPruneRunning
+	}
+	defer p.running.Unlock()
+	return p.run(ctx)
+}
+
+// Trigger 在后台立即按配置的保留策略清理一次，已有清理正在进行时返回 ErrHistoryPruneRunning
+func (p *ConfigHistoryPruner) Trigger() error {
+	if !p.policy.Enabled() {
+		return ErrRetentionPolicyEmpty
+	}
+	if !p.running.TryLock() {
+		return ErrHistoryPruneRunning
+	}
+	go func() {
+		defer p.running.Unlock()
+		if _, err := p.run(context.Background()); err != nil {
+			p.logger.Errorf("清理配置历史失败: %v", err)
+		}
+	}()
+	return nil
+}
+
+// run 执行清理并记录汇总，调用方持有running锁
+func (p *ConfigHistoryPruner) run(ctx context.Context) (run *models.HistoryPruneRun, err error) {
+	p.inProgress.Store(true)
+	done := p.tracker.begin(p.now())
+	defer func() {
+		done(err)
+		p.inProgress.Store(false)
+	}()
+
+	run, err = p.prune(ctx, p.policy, false)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.lastRun = run
+	p.mu.Unlock()
+
+	p.logger.WithFields(logrus.Fields{
+		"configs": run.Configs,
+		"kept":    run.Kept,
+		"pruned":  run.Pruned,
+		"errored": run.Errored,
+	}).Info("配置历史清理完成")
+
+	return run, nil
+}
+
+// prune 逐个配置计算需清理的记录，dryRun为false时删除之
+// 单个配置读取或删除失败时记入Errored并继续处理其余配置
+func (p *ConfigHistoryPruner) prune(ctx context.Context, policy models.HistoryRetentionPolicy, dryRun bool) (*models.HistoryPruneRun, error) {
+	if !policy.Enabled() {
+		return nil, ErrRetentionPolicyEmpty
+	}
+	now := p.now()
+	referenced, err := p.referencedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	configIDs, err := p.historyRepo.ConfigIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	run := &models.HistoryPruneRun{
+		DryRun:    dryRun,
+		Policy:    policy,
+		StartedAt: now,
+		Items:     []models.HistoryPruneItem{},
+	}
+	for _, configID := range configIDs {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		run.Configs++
+
+		records, err := p.historyRepo.ListRecords(ctx, configID)
+		if err != nil {
+			run.Errored++
+			p.logger.WithError(err).WithField("config_id", configID).Warn("读取配置历史失败")
+			continue
+		}
+		pruned := planHistoryPrune(records, policy, referenced[configID], now)
+		run.Kept += len(records) - len(pruned)
+		if len(pruned) == 0 {
+			continue
+		}
+
+		item := models.HistoryPruneItem{ConfigID: configID}
+		for _, record := range pruned {
+			if !dryRun {
+				if err := p.historyRepo.Delete(ctx, record); err != nil {
+					p.logger.WithError(err).WithField("config_id", configID).Warn("删除配置历史失败")
+					run.Kept++
+					continue
+				}
+			}
+			item.Records++
+			if !slices.Contains(item.Versions, record.Version) {
+				item.Versions = append(item.Versions, record.Version)
+			}
+		}
+		if item.Records < len(pruned) {
+			run.Errored++
+		}
+		if item.Records == 0 {
+			continue
+		}
+		slices.Sort(item.Versions)
+		run.Pruned += item.Records
+		run.Items = append(run.Items, item)
+	}
+	run.FinishedAt = p.now()
+	return run, nil
+}
+
+// referencedVersions 未结束的部署引用的配置版本，包括部署的版本和各Agent部署前的版本
+func (p *ConfigHistoryPruner) referencedVersions(ctx context.Context) (map[string]map[int]bool, error) {
+	referenced := make(map[string]map[int]bool)
+	add := func(configID string, version int) {
+		if version <= 0 {
+			return
+		}
+		if referenced[configID] == nil {
+			referenced[configID] = make(map[int]bool)
+		}
+		referenced[configID][version] = true
+	}
+
+	for _, status := range []models.DeploymentStatus{models.DeploymentStatusPending, models.DeploymentStatusRunning} {
+		deployments, _, err := p.deployRepo.List(ctx, &models.DeploymentListRequest{Status: status, Page: 1, PageSize: 1000})
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range deployments {
+			add(d.ConfigID, d.ConfigVersion)
+			add(d.ConfigID, d.PreviousVersion)
+			for _, r := range d.Results {
+				add(d.ConfigID, r.Version)
+				add(d.ConfigID, r.PreviousVersion)
+			}
+		}
+	}
+	return referenced, nil
+}
+
+// planHistoryPrune 按保留策略选出需清理的历史记录，records按修改时间倒序
+// 同一版本的记录一起保留或清理；版本号最大的版本总是保留
+func planHistoryPrune(records []*models.ConfigHistoryRecord, policy models.HistoryRetentionPolicy, referenced map[int]bool, now time.Time) []*models.ConfigHistoryRecord {
+	// 各版本最近一次修改的时间
+	modified := make(map[int]time.Time)
+	for _, record := range records {
+		if record.ModifiedAt.After(modified[record.Version]) {
+			modified[record.Version] = record.ModifiedAt
+		}
+	}
+	versions := make([]int, 0, len(modified))
+	for version := range modified {
+		versions = append(versions, version)
+	}
+	slices.Sort(versions)
+	slices.Reverse(versions)
+
+	cutoff := now.AddDate(0, 0, -policy.KeepDays)
+	keep := make(map[int]bool, len(versions))
+	for i, version := range versions {
+		switch {
+		case i == 0, referenced[version]:
+		case policy.KeepVersions > 0 && i < policy.KeepVersions:
+		case policy.KeepDays > 0 && modified[version].After(cutoff):
+		default:
+			continue
+		}
+		keep[version] = true
+	}
+
+	var pruned []*models.ConfigHistoryRecord
+	for _, record := range records {
+		if !keep[record.Version] {
+			pruned = append(pruned, record)
+		}
+	}
+	return pruned
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+)
+
+// memConfigHistoryRepository 内存中的配置历史
+type memConfigHistoryRepository struct {
+	mu      sync.Mutex
+	records []*models.ConfigHistoryRecord
+}
+
+func (r *memConfigHistoryRepository) ConfigIDs(ctx context.Context) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	seen := map[string]bool{}
+	var ids []string
+	for _, record := range r.records {
+		if !seen[record.ConfigID] {
+			seen[record.ConfigID] = true
+			ids = append(ids, record.ConfigID)
+		}
+	}
+	return ids, nil
+}
+
+func (r *memConfigHistoryRepository) ListRecords(ctx context.Context, configID string) ([]*models.ConfigHistoryRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var records []*models.ConfigHistoryRecord
+	for _, record := range r.records {
+		if record.ConfigID == configID {
+			records = append(records, record)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].ModifiedAt.After(records[j].ModifiedAt) })
+	return records, nil
+}
+
+func (r *memConfigHistoryRepository) Delete(ctx context.Context, record *models.ConfigHistoryRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.records {
+		if existing.ID == record.ID {
+			r.records = append(r.records[:i], r.records[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// versions 配置剩余的历史版本
+func (r *memConfigHistoryRepository) versions(configID string) []int {
+	records, _ := r.ListRecords(context.Background(), configID)
+	var versions []int
+	for _, record := range records {
+		versions = append(versions, record.Version)
+	}
+	sort.Ints(versions)
+	return versions
+}
+
+func newTestPruner(t *testing.T, policy models.HistoryRetentionPolicy) (*ConfigHistoryPruner, *memConfigHistoryRepository, *memDeploymentRepository, time.Time) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	history := &memConfigHistoryRepository{}
+	// cfg-1 每10天一个版本，v6为当前版本
+	for v := 1; v <= 6; v++ {
+		history.records = append(history.records, &models.ConfigHistoryRecord{
+			ID: fmt.Sprintf("cfg-1-%d", v), ConfigID: "cfg-1", Version: v, ChangeType: "update",
+			ModifiedAt: now.AddDate(0, 0, -10*(6-v)),
+		})
+	}
+	// cfg-2 已删除，删除记录与最后一个版本同号
+	history.records = append(history.records,
+		&models.ConfigHistoryRecord{ID: "cfg-2-1", ConfigID: "cfg-2", Version: 1, ChangeType: "create", ModifiedAt: now.AddDate(0, 0, -100)},
+		&models.ConfigHistoryRecord{ID: "cfg-2-2", ConfigID: "cfg-2", Version: 2, ChangeType: "update", ModifiedAt: now.AddDate(0, 0, -90)},
+		&models.ConfigHistoryRecord{ID: "cfg-2-d", ConfigID: "cfg-2", Version: 2, ChangeType: "delete", ModifiedAt: now.AddDate(0, 0, -80)},
+	)
+
+	deployRepo := &memDeploymentRepository{deployments: make(map[string]models.Deployment)}
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	pruner := NewConfigHistoryPruner(HistoryRetentionConfig{Policy: policy}, history, deployRepo, logger)
+	pruner.now = func() time.Time { return now }
+	return pruner, history, deployRepo, now
+}
+
+func TestConfigHistoryPruner_KeepVersions(t *testing.T) {
+	ctx := context.Background()
+	pruner, history, deployRepo, _ := newTestPruner(t, models.HistoryRetentionPolicy{KeepVersions: 2})
+	// 进行中的部署引用 cfg-1 的版本2（部署前的版本）
+	require.NoError(t, deployRepo.Create(ctx, &models.Deployment{
+		ConfigID: "cfg-1", ConfigVersion: 6, PreviousVersion: 2, Status: models.DeploymentStatusRunning,
+	}))
+	// 已结束的部署不阻止清理
+	require.NoError(t, deployRepo.Create(ctx, &models.Deployment{
+		ConfigID: "cfg-1", ConfigVersion: 3, Status: models.DeploymentStatusCompleted,
+	}))
+
+	preview, err := pruner.Preview(ctx, pruner.Policy())
+	require.NoError(t, err)
+	assert.True(t, preview.DryRun)
+	assert.Equal(t, 2, preview.Configs)
+	assert.Equal(t, 3, preview.Pruned)
+	assert.Equal(t, 6, preview.Kept)
+	assert.Equal(t, []models.HistoryPruneItem{{ConfigID: "cfg-1", Versions: []int{1, 3, 4}, Records: 3}}, preview.Items)
+	// 预览不删除记录
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6}, history.versions("cfg-1"))
+
+	run, err := pruner.Run(ctx)
+	require.NoError(t, err)
+	assert.False(t, run.DryRun)
+	assert.Equal(t, 3, run.Pruned)
+	assert.Equal(t, []int{2, 5, 6}, history.versions("cfg-1"))
+	assert.Equal(t, []int{1, 2, 2}, history.versions("cfg-2"))
+	assert.Equal(t, run, pruner.LastRun())
+}
+
+func TestConfigHistoryPruner_KeepDays(t *testing.T) {
+	ctx := context.Background()
+	pruner, history, _, _ := newTestPruner(t, models.HistoryRetentionPolicy{KeepDays: 25})
+
+	_, err := pruner.Run(ctx)
+	require.NoError(t, err)
+	// 25天内修改的 v4~v6 保留；cfg-2 的所有版本都已过期，但最新版本总是保留
+	assert.Equal(t, []int{4, 5, 6}, history.versions("cfg-1"))
+	assert.Equal(t, []int{2, 2}, history.versions("cfg-2"))
+}
+
+func TestConfigHistoryPruner_EitherCondition(t *testing.T) {
+	ctx := context.Background()
+	pruner, history, _, _ := newTestPruner(t, models.HistoryRetentionPolicy{KeepVersions: 1, KeepDays: 15})
+
+	// 预览参数覆盖配置的策略
+	preview, err := pruner.Preview(ctx, models.HistoryRetentionPolicy{KeepVersions: 4})
+	require.NoError(t, err)
+	assert.Equal(t, 2, preview.Pruned)
+
+	_, err = pruner.Run(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int{5, 6}, history.versions("cfg-1"))
+}
+
+func TestConfigHistoryPruner_EmptyPolicy(t *testing.T) {
+	pruner, _, _, _ := newTestPruner(t, models.HistoryRetentionPolicy{})
+
+	_, err := pruner.Run(context.Background())
+	assert.ErrorIs(t, err, ErrRetentionPolicyEmpty)
+	assert.ErrorIs(t, pruner.Trigger(), ErrRetentionPolicyEmpty)
+}
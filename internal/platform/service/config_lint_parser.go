@@ -0,0 +1,352 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+)
+
+// lintValue 插件选项的值
+type lintValue struct {
+	line  int
+	str   string      // 字符串、数字或裸词
+	items []lintValue // 数组
+	pairs []lintPair  // 哈希，或带选项的codec的选项
+	kind  byte        // 's' 字符串（带引号）、'w' 裸词或数字、'a' 数组、'h' 哈希
+}
+
+// lintPair 哈希中的一项
+type lintPair struct {
+	key   string
+	value lintValue
+}
+
+// lintPlugin 配置中的一个插件及其选项
+type lintPlugin struct {
+	section  string // input、filter、output
+	name     string
+	line     int
+	settings []lintPair
+}
+
+// setting 获取插件的选项，未设置时返回nil
+func (p *lintPlugin) setting(name string) *lintValue {
+	for i := range p.settings {
+		if p.settings[i].key == name {
+			return &p.settings[i].value
+		}
+	}
+	return nil
+}
+
+// flatten 值中的全部字符串，数组展开，哈希取值
+func (v *lintValue) flatten() []lintValue {
+	switch v.kind {
+	case 'a':
+		var out []lintValue
+		for i := range v.items {
+			out = append(out, v.items[i].flatten()...)
+		}
+		return out
+	case 'h':
+		var out []lintValue
+		for i := range v.pairs {
+			out = append(out, v.pairs[i].value.flatten()...)
+		}
+		return out
+	default:
+		return []lintValue{*v}
+	}
+}
+
+// lintSyntaxError 配置解析失败的位置和原因
+type lintSyntaxError struct {
+	line    int
+	message string
+}
+
+func (e *lintSyntaxError) Error() string {
+	return fmt.Sprintf("第%d行: %s", e.line, e.message)
+}
+
+// lintParser Logstash配置的简化解析器，只提取各区段中的插件及其选项供静态检查使用
+// 条件语句中的表达式被跳过，分支中的插件照常提取
+type lintParser struct {
+	src  string
+	pos  int
+	line int
+}
+
+// parseLintPlugins 解析配置内容中的全部插件
+func parseLintPlugins(content string) ([]*lintPlugin, error) {
+	p := &lintParser{src: content, line: 1}
+	var plugins []*lintPlugin
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return plugins, nil
+		}
+		section := p.word()
+		if section != "input" && section != "filter" && section != "output" {
+			return nil, p.errorf("应为 input、filter 或 output，实际为 %q", p.near(section))
+		}
+		if err := p.expect('{'); err != nil {
+			return nil, err
+		}
+		found, err := p.block(section)
+		if err != nil {
+			return nil, err
+		}
+		plugins = append(plugins, found...)
+	}
+}
+
+// block 解析区段或条件分支中的插件，直到对应的 }
+func (p *lintParser) block(section string) ([]*lintPlugin, error) {
+	var plugins []*lintPlugin
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return nil, p.errorf("缺少 }")
+		}
+		if p.peek() == '}' {
+			p.advance()
+			return plugins, nil
+		}
+
+		line := p.line
+		name := p.word()
+		switch name {
+		case "":
+			return nil, p.errorf("应为插件名称，实际为 %q", p.near(""))
+		case "if", "else":
+			if name == "else" {
+				p.skipSpace()
+				if strings.HasPrefix(p.src[p.pos:], "if") {
+					p.word()
+				}
+			}
+			if err := p.skipCondition(); err != nil {
+				return nil, err
+			}
+			nested, err := p.block(section)
+			if err != nil {
+				return nil, err
+			}
+			plugins = append(plugins, nested...)
+		default:
+			if err := p.expect('{'); err != nil {
+				return nil, err
+			}
+			settings, err := p.pairs()
+			if err != nil {
+				return nil, err
+			}
+			plugins = append(plugins, &lintPlugin{section: section, name: name, line: line, settings: settings})
+		}
+	}
+}
+
+// skipCondition 跳过条件表达式，直到分支开始的 {，表达式中的字符串和正则不参与匹配
+func (p *lintParser) skipCondition() error {
+	for !p.eof() {
+		switch c := p.peek(); c {
+		case '{':
+			p.advance()
+			return nil
+		case '"', '\'', '/':
+			if _, err := p.quoted(c); err != nil {
+				return err
+			}
+		default:
+			p.advance()
+		}
+	}
+	return p.errorf("条件语句缺少 {")
+}
+
+// pairs 解析插件选项或哈希的各项，直到 }
+func (p *lintParser) pairs() ([]lintPair, error) {
+	var pairs []lintPair
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return nil, p.errorf("缺少 }")
+		}
+		switch p.peek() {
+		case '}':
+			p.advance()
+			return pairs, nil
+		case ',':
+			p.advance()
+			continue
+		}
+
+		key, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		if key.kind != 's' && key.kind != 'w' {
+			return nil, p.errorf("选项名称无效")
+		}
+		p.skipSpace()
+		if !strings.HasPrefix(p.src[p.pos:], "=>") {
+			return nil, p.errorf("选项 %s 之后应为 =>", key.str)
+		}
+		p.pos += 2
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, lintPair{key: key.str, value: value})
+	}
+}
+
+// value 解析一个值
+func (p *lintParser) value() (lintValue, error) {
+	p.skipSpace()
+	if p.eof() {
+		return lintValue{}, p.errorf("缺少值")
+	}
+	line := p.line
+	switch c := p.peek(); c {
+	case '"', '\'':
+		s, err := p.quoted(c)
+		return lintValue{kind: 's', str: s, line: line}, err
+	case '[':
+		p.advance()
+		v := lintValue{kind: 'a', line: line}
+		for {
+			p.skipSpace()
+			if p.eof() {
+				return v, p.errorf("缺少 ]")
+			}
+			switch p.peek() {
+			case ']':
+				p.advance()
+				return v, nil
+			case ',':
+				p.advance()
+				continue
+			}
+			item, err := p.value()
+			if err != nil {
+				return v, err
+			}
+			v.items = append(v.items, item)
+		}
+	case '{':
+		p.advance()
+		pairs, err := p.pairs()
+		return lintValue{kind: 'h', pairs: pairs, line: line}, err
+	default:
+		start := p.pos
+		for !p.eof() && !strings.ContainsRune(" \t\r\n,]}#", rune(p.peek())) && !strings.HasPrefix(p.src[p.pos:], "=>") {
+			p.advance()
+		}
+		if p.pos == start {
+			return lintValue{}, p.errorf("无法识别的字符 %q", string(p.peek()))
+		}
+		v := lintValue{kind: 'w', str: p.src[start:p.pos], line: line}
+		// 带选项的codec，如 codec => json { charset => "UTF-8" }
+		p.skipSpace()
+		if !p.eof() && p.peek() == '{' {
+			p.advance()
+			pairs, err := p.pairs()
+			v.pairs = pairs
+			return v, err
+		}
+		return v, nil
+	}
+}
+
+// quoted 读取以quote包围的字符串，反斜杠转义的引号不结束字符串
+func (p *lintParser) quoted(quote byte) (string, error) {
+	line := p.line
+	p.advance()
+	var b strings.Builder
+	for !p.eof() {
+		c := p.peek()
+		p.advance()
+		switch {
+		case c == '\\' && !p.eof():
+			next := p.peek()
+			p.advance()
+			if next != quote {
+				b.WriteByte(c)
+			}
+			b.WriteByte(next)
+		case c == quote:
+			return b.String(), nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", &lintSyntaxError{line: line, message: "字符串缺少结束的引号"}
+}
+
+// word 读取名称，不是名称时返回空
+func (p *lintParser) word() string {
+	start := p.pos
+	for !p.eof() {
+		c := p.peek()
+		if c != '_' && c != '-' && (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			break
+		}
+		p.advance()
+	}
+	return p.src[start:p.pos]
+}
+
+func (p *lintParser) expect(c byte) error {
+	p.skipSpace()
+	if p.eof() || p.peek() != c {
+		return p.errorf("应为 %q", string(c))
+	}
+	p.advance()
+	return nil
+}
+
+// skipSpace 跳过空白和注释
+func (p *lintParser) skipSpace() {
+	for !p.eof() {
+		switch c := p.peek(); {
+		case c == '#':
+			for !p.eof() && p.peek() != '\n' {
+				p.advance()
+			}
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			p.advance()
+		default:
+			return
+		}
+	}
+}
+
+func (p *lintParser) eof() bool  { return p.pos >= len(p.src) }
+func (p *lintParser) peek() byte { return p.src[p.pos] }
+
+func (p *lintParser) advance() {
+	if p.src[p.pos] == '\n' {
+		p.line++
+	}
+	p.pos++
+}
+
+// near 出错位置附近的内容
+func (p *lintParser) near(word string) string {
+	if word != "" {
+		return word
+	}
+	end := p.pos + 20
+	if end > len(p.src) {
+		end = len(p.src)
+	}
+	if i := strings.IndexByte(p.src[p.pos:end], '\n'); i >= 0 {
+		end = p.pos + i
+	}
+	return p.src[p.pos:end]
+}
+
+func (p *lintParser) errorf(format string, args ...interface{}) error {
+	return &lintSyntaxError{line: p.line, message: fmt.Sprintf(format, args...)}
+}
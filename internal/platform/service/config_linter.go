@@ -0,0 +1,495 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+)
+
+// ErrUnknownLintRule 规则开关中包含不存在的规则
+var ErrUnknownLintRule = errors.New("未知的静态检查规则")
+
+// defaultProductionEnvironments 未配置时视为生产环境的环境名称和配置标签
+var defaultProductionEnvironments = []string{"prod", "production"}
+
+// lintRule 静态检查规则，check返回插件中的问题，Rule和Severity由调用方填写
+type lintRule struct {
+	id             string
+	severity       string
+	description    string
+	defaultEnabled bool
+	check          func(plugin *lintPlugin, env lintEnv) []models.LintIssue
+}
+
+// lintEnv 检查规则使用的环境
+type lintEnv struct {
+	production bool                  // 配置用于生产环境
+	catalog    *PluginCatalogService // 插件目录，为nil时不检查未知选项
+}
+
+// lintRules 全部静态检查规则，语法错误单独处理
+var lintRules = []lintRule{
+	{
+		id: models.LintRuleSyntax, severity: models.LintSeverityError, defaultEnabled: true,
+		description: "配置内容无法解析，其余规则不会执行",
+	},
+	{
+		id: models.LintRuleStdoutInProduction, severity: models.LintSeverityError, defaultEnabled: true,
+		description: "生产环境的配置保留了调试用的stdout输出", check: checkStdoutInProduction,
+	},
+	{
+		id: models.LintRuleGrokUnanchored, severity: models.LintSeverityWarning, defaultEnabled: true,
+		description: "grok模式没有以^锚定开头，匹配失败时会在每个位置重试", check: checkGrokUnanchored,
+	},
+	{
+		id: models.LintRuleDateMissingTimezone, severity: models.LintSeverityWarning, defaultEnabled: true,
+		description: "date过滤器未设置timezone且时间格式不含时区，按Logstash所在主机的时区解析", check: checkDateTimezone,
+	},
+	{
+		id: models.LintRuleDeprecatedOption, severity: models.LintSeverityWarning, defaultEnabled: true,
+		description: "使用了已弃用或在新版本中移除的插件选项", check: checkDeprecatedOptions,
+	},
+	{
+		id: models.LintRuleUnknownOption, severity: models.LintSeverityWarning, defaultEnabled: true,
+		description: "使用了插件目录中该插件没有的选项，通常是拼写错误或插件版本不支持，目录中没有的插件不检查", check: checkUnknownOptions,
+	},
+	{
+		id: models.LintRuleMissingPluginID, severity: models.LintSeverityInfo, defaultEnabled: false,
+		description: "插件未设置id，管道统计和日志中只能看到自动生成的ID", check: checkPluginID,
+	},
+}
+
+// deprecatedOptions 各插件已弃用的选项及替代选项，键为 区段/插件名
+var deprecatedOptions = map[string]map[string]string{
+	"output/elasticsearch": {
+		"document_type":                "",
+		"ssl":                          "ssl_enabled",
+		"cacert":                       "ssl_certificate_authorities",
+		"ssl_certificate_verification": "ssl_verification_mode",
+		"keystore":                     "ssl_keystore_path",
+		"keystore_password":            "ssl_keystore_password",
+		"truststore":                   "ssl_truststore_path",
+		"truststore_password":          "ssl_truststore_password",
+	},
+	"input/elasticsearch": {
+		"ssl":     "ssl_enabled",
+		"ca_file": "ssl_certificate_authorities",
+	},
+	"input/beats": {
+		"ssl":                              "ssl_enabled",
+		"ssl_verify_mode":                  "ssl_client_authentication",
+		"ssl_peer_metadata":                "enrich",
+		"tls_min_version":                  "ssl_supported_protocols",
+		"tls_max_version":                  "ssl_supported_protocols",
+		"cipher_suites":                    "ssl_cipher_suites",
+		"ssl_certificate_authorities_path": "ssl_certificate_authorities",
+	},
+	"input/http": {
+		"ssl":             "ssl_enabled",
+		"ssl_verify_mode": "ssl_client_authentication",
+		"keystore":        "ssl_keystore_path",
+	},
+	"output/http": {
+		"cacert":      "ssl_certificate_authorities",
+		"client_cert": "ssl_certificate",
+		"client_key":  "ssl_key",
+		"keystore":    "ssl_keystore_path",
+		"truststore":  "ssl_truststore_path",
+	},
+}
+
+// zonedDateFormats date过滤器中自带时区的格式
+var zonedDateFormats = map[string]bool{"ISO8601": true, "UNIX": true, "UNIX_MS": true, "TAI64N": true}
+
+// ConfigLinter 配置内容静态检查服务接口
+type ConfigLinter interface {
+	// Lint 检查配置的当前内容
+	Lint(ctx context.Context, configID string, req *models.LintConfigRequest) (*models.LintResult, error)
+	// LintConfig 按配置所在项目启用的规则检查内容，environment为空时按配置标签判断是否为生产环境
+	LintConfig(ctx context.Context, config *models.Config, environment string) (*models.LintResult, error)
+	// Rules 当前项目的规则及是否启用
+	Rules(ctx context.Context) ([]models.LintRule, error)
+	// UpdateRules 设置当前项目的规则开关，未列出的规则保持原值
+	UpdateRules(ctx context.Context, rules map[string]bool, userID string) ([]models.LintRule, error)
+}
+
+// configLinter 配置内容静态检查服务实现
+type configLinter struct {
+	configService ConfigService
+	settingsRepo  repository.LintSettingsRepository
+	catalog       *PluginCatalogService
+	production    map[string]bool
+	logger        *logrus.Logger
+}
+
+// NewConfigLinter 创建配置静态检查服务，production为视为生产环境的环境名称和配置标签，为空时使用 prod、production
+// catalog为nil时不检查未知的插件选项
+func NewConfigLinter(configService ConfigService, settingsRepo repository.LintSettingsRepository, catalog *PluginCatalogService, production []string, logger *logrus.Logger) ConfigLinter {
+	if len(production) == 0 {
+		production = defaultProductionEnvironments
+	}
+	names := make(map[string]bool, len(production))
+	for _, name := range production {
+		names[strings.ToLower(name)] = true
+	}
+	return &configLinter{
+		configService: configService,
+		settingsRepo:  settingsRepo,
+		catalog:       catalog,
+		production:    names,
+		logger:        logger,
+	}
+}
+
+// Lint 获取配置后检查，配置的访问控制由配置服务校验
+func (l *configLinter) Lint(ctx context.Context, configID string, req *models.LintConfigRequest) (*models.LintResult, error) {
+	config, err := l.configService.GetConfig(ctx, configID)
+	if err != nil {
+		return nil, err
+	}
+	return l.LintConfig(ctx, config, req.Environment)
+}
+
+// LintConfig 按项目启用的规则检查配置内容
+func (l *configLinter) LintConfig(ctx context.Context, config *models.Config, environment string) (*models.LintResult, error) {
+	enabled, err := l.enabledRules(ctx, models.ProjectOf(config.Project))
+	if err != nil {
+		return nil, err
+	}
+	env := lintEnv{production: l.isProduction(config, environment), catalog: l.catalog}
+	return lintContent(config.Content, enabled, env), nil
+}
+
+// isProduction 指定环境时按环境名称判断，否则按配置标签判断
+func (l *configLinter) isProduction(config *models.Config, environment string) bool {
+	if environment != "" {
+		return l.production[strings.ToLower(environment)]
+	}
+	for _, tag := range config.Tags {
+		if l.production[strings.ToLower(tag)] {
+			return true
+		}
+	}
+	return false
+}
+
+// Rules 当前项目的规则及是否启用
+func (l *configLinter) Rules(ctx context.Context) ([]models.LintRule, error) {
+	enabled, err := l.enabledRules(ctx, models.ProjectOf(models.ProjectFrom(ctx)))
+	if err != nil {
+		return nil, err
+	}
+	return describeLintRules(enabled), nil
+}
+
+// UpdateRules 合并保存当前项目的规则开关
+func (l *configLinter) UpdateRules(ctx context.Context, rules map[string]bool, userID string) ([]models.LintRule, error) {
+	for id := range rules {
+		if findLintRule(id) == nil {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownLintRule, id)
+		}
+	}
+
+	project := models.ProjectOf(models.ProjectFrom(ctx))
+	settings, err := l.settingsRepo.Get(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		settings = &models.LintSettings{Project: project, Rules: make(map[string]bool)}
+	}
+	if settings.Rules == nil {
+		settings.Rules = make(map[string]bool)
+	}
+	for id, on := range rules {
+		settings.Rules[id] = on
+	}
+	settings.UpdatedAt = time.Now()
+	settings.UpdatedBy = userID
+	if err := l.settingsRepo.Save(ctx, settings); err != nil {
+		return nil, err
+	}
+
+	l.logger.WithFields(logrus.Fields{
+		"project": project,
+		"rules":   rules,
+		"user":    userID,
+	}).Info("更新静态检查规则开关")
+	return describeLintRules(effectiveLintRules(settings)), nil
+}
+
+// enabledRules 项目启用的规则
+func (l *configLinter) enabledRules(ctx context.Context, project string) (map[string]bool, error) {
+	settings, err := l.settingsRepo.Get(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	return effectiveLintRules(settings), nil
+}
+
+// effectiveLintRules 规则默认值叠加项目的开关，settings为nil时全部使用默认值
+func effectiveLintRules(settings *models.LintSettings) map[string]bool {
+	enabled := make(map[string]bool, len(lintRules))
+	for _, rule := range lintRules {
+		enabled[rule.id] = rule.defaultEnabled
+		if settings != nil {
+			if on, ok := settings.Rules[rule.id]; ok {
+				enabled[rule.id] = on
+			}
+		}
+	}
+	return enabled
+}
+
+func describeLintRules(enabled map[string]bool) []models.LintRule {
+	rules := make([]models.LintRule, 0, len(lintRules))
+	for _, rule := range lintRules {
+		rules = append(rules, models.LintRule{
+			ID:          rule.id,
+			Severity:    rule.severity,
+			Description: rule.description,
+			Enabled:     enabled[rule.id],
+		})
+	}
+	return rules
+}
+
+func findLintRule(id string) *lintRule {
+	for i := range lintRules {
+		if lintRules[i].id == id {
+			return &lintRules[i]
+		}
+	}
+	return nil
+}
+
+// lintContent 解析配置内容并执行启用的规则，问题按行号排列
+func lintContent(content string, enabled map[string]bool, env lintEnv) *models.LintResult {
+	result := &models.LintResult{Production: env.production, Issues: []models.LintIssue{}}
+
+	plugins, err := parseLintPlugins(content)
+	if err != nil {
+		if enabled[models.LintRuleSyntax] {
+			issue := models.LintIssue{Message: err.Error()}
+			var syntaxErr *lintSyntaxError
+			if errors.As(err, &syntaxErr) {
+				issue.Line, issue.Message = syntaxErr.line, syntaxErr.message
+			}
+			addLintIssue(result, models.LintRuleSyntax, models.LintSeverityError, issue)
+		}
+		return result
+	}
+
+	for _, rule := range lintRules {
+		if rule.check == nil || !enabled[rule.id] {
+			continue
+		}
+		for _, plugin := range plugins {
+			for _, issue := range rule.check(plugin, env) {
+				if issue.Line == 0 {
+					issue.Line = plugin.line
+				}
+				issue.Plugin = plugin.section + "/" + plugin.name
+				addLintIssue(result, rule.id, rule.severity, issue)
+			}
+		}
+	}
+	sort.SliceStable(result.Issues, func(i, j int) bool { return result.Issues[i].Line < result.Issues[j].Line })
+	return result
+}
+
+// addLintIssue 记录问题并按严重级别计数
+func addLintIssue(result *models.LintResult, rule, severity string, issue models.LintIssue) {
+	issue.Rule = rule
+	issue.Severity = severity
+	result.Issues = append(result.Issues, issue)
+	switch severity {
+	case models.LintSeverityError:
+		result.Errors++
+	case models.LintSeverityWarning:
+		result.Warnings++
+	default:
+		result.Infos++
+	}
+}
+
+// checkStdoutInProduction 生产环境的配置不应保留stdout输出
+func checkStdoutInProduction(plugin *lintPlugin, env lintEnv) []models.LintIssue {
+	if !env.production || plugin.section != "output" || plugin.name != "stdout" {
+		return nil
+	}
+	return []models.LintIssue{{Message: "生产环境的配置包含stdout输出，每个事件都会写入Logstash日志，部署前应移除"}}
+}
+
+// checkGrokUnanchored grok的match中的模式应以^开头
+// match可以是 {"字段" => 模式或模式数组}，也可以是旧式的 ["字段", 模式, ...]
+func checkGrokUnanchored(plugin *lintPlugin, env lintEnv) []models.LintIssue {
+	if plugin.section != "filter" || plugin.name != "grok" {
+		return nil
+	}
+	match := plugin.setting("match")
+	if match == nil {
+		return nil
+	}
+
+	var patterns []lintValue
+	switch match.kind {
+	case 'h':
+		patterns = match.flatten()
+	case 'a':
+		if len(match.items) > 1 {
+			patterns = (&lintValue{kind: 'a', items: match.items[1:]}).flatten()
+		}
+	}
+
+	var issues []models.LintIssue
+	for _, pattern := range patterns {
+		trimmed := strings.TrimSpace(pattern.str)
+		if trimmed == "" || strings.HasPrefix(trimmed, "^") || strings.HasPrefix(trimmed, `\A`) {
+			continue
+		}
+		issues = append(issues, models.LintIssue{
+			Line:    pattern.line,
+			Message: fmt.Sprintf("grok模式 %q 没有以^锚定开头，不匹配的事件会在每个字符位置重试", truncateLint(trimmed)),
+		})
+	}
+	return issues
+}
+
+// checkDateTimezone date过滤器的格式不含时区时应设置timezone
+func checkDateTimezone(plugin *lintPlugin, env lintEnv) []models.LintIssue {
+	if plugin.section != "filter" || plugin.name != "date" || plugin.setting("timezone") != nil {
+		return nil
+	}
+	match := plugin.setting("match")
+	if match == nil || match.kind != 'a' || len(match.items) < 2 {
+		return nil
+	}
+	// 第一项为字段，其余为依次尝试的格式
+	for _, format := range match.items[1:] {
+		if !dateFormatHasZone(format.str) {
+			return []models.LintIssue{{
+				Line:    format.line,
+				Message: fmt.Sprintf("时间格式 %q 不含时区且未设置timezone，将按Logstash所在主机的时区解析", format.str),
+			}}
+		}
+	}
+	return nil
+}
+
+// dateFormatHasZone 格式是否自带时区，单引号中的字面量不计
+func dateFormatHasZone(format string) bool {
+	if zonedDateFormats[format] {
+		return true
+	}
+	quoted := false
+	for _, c := range format {
+		switch {
+		case c == '\'':
+			quoted = !quoted
+		case !quoted && (c == 'Z' || c == 'z' || c == 'X'):
+			return true
+		}
+	}
+	return false
+}
+
+// checkDeprecatedOptions 插件使用了已弃用的选项
+func checkDeprecatedOptions(plugin *lintPlugin, env lintEnv) []models.LintIssue {
+	options := deprecatedOptions[plugin.section+"/"+plugin.name]
+	if options == nil {
+		return nil
+	}
+	var issues []models.LintIssue
+	for _, setting := range plugin.settings {
+		replacement, ok := options[setting.key]
+		if !ok {
+			continue
+		}
+		message := fmt.Sprintf("选项 %s 已弃用，将在新版本的插件中移除", setting.key)
+		if replacement != "" {
+			message = fmt.Sprintf("选项 %s 已弃用，请改用 %s", setting.key, replacement)
+		}
+		issues = append(issues, models.LintIssue{Line: setting.value.line, Message: message})
+	}
+	return issues
+}
+
+// checkPluginID 插件应设置id，便于在管道统计中识别
+func checkPluginID(plugin *lintPlugin, env lintEnv) []models.LintIssue {
+	if plugin.setting("id") != nil {
+		return nil
+	}
+	return []models.LintIssue{{Message: fmt.Sprintf("%s插件 %s 未设置id", plugin.section, plugin.name)}}
+}
+
+// checkUnknownOptions 插件使用了目录中没有的选项，目录中没有的插件不检查
+func checkUnknownOptions(plugin *lintPlugin, env lintEnv) []models.LintIssue {
+	if env.catalog == nil {
+		return nil
+	}
+	known, ok := env.catalog.knownOptions(plugin.section, plugin.name)
+	if !ok {
+		return nil
+	}
+	var issues []models.LintIssue
+	for _, setting := range plugin.settings {
+		if known[setting.key] {
+			continue
+		}
+		message := fmt.Sprintf("%s插件 %s 没有选项 %s", plugin.section, plugin.name, setting.key)
+		if suggestion := closestOption(setting.key, known); suggestion != "" {
+			message += fmt.Sprintf("，是否为 %s", suggestion)
+		}
+		issues = append(issues, models.LintIssue{Line: setting.value.line, Message: message})
+	}
+	return issues
+}
+
+// closestOption 编辑距离不超过2的最接近的选项，用于提示拼写错误，没有时返回空
+func closestOption(name string, known map[string]bool) string {
+	best, bestDistance := "", 3
+	for option := range known {
+		if d := editDistance(name, option); d < bestDistance || (d == bestDistance && option < best) {
+			best, bestDistance = option, d
+		}
+	}
+	return best
+}
+
+// editDistance 两个字符串的编辑距离
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// truncateLint 截断问题描述中过长的内容
+func truncateLint(s string) string {
+	const max = 60
+	if len([]rune(s)) <= max {
+		return s
+	}
+	return string([]rune(s)[:max]) + "..."
+}
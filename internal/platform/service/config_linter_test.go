@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+)
+
+// memoryLintSettingsRepo 内存中的规则开关存储
+type memoryLintSettingsRepo struct {
+	settings map[string]*models.LintSettings
+}
+
+func (r *memoryLintSettingsRepo) Get(ctx context.Context, project string) (*models.LintSettings, error) {
+	return r.settings[project], nil
+}
+
+func (r *memoryLintSettingsRepo) Save(ctx context.Context, settings *models.LintSettings) error {
+	r.settings[settings.Project] = settings
+	return nil
+}
+
+const lintSample = `input {
+  beats { port => 5044 ssl => true }
+}
+filter {
+  if [type] == "nginx" {
+    grok {
+      match => { "message" => "%{IP:client} %{WORD:method}" }
+    }
+  } else {
+    grok { match => ["message", "^%{GREEDYDATA:msg}"] }
+  }
+  date { match => ["ts", "yyyy-MM-dd HH:mm:ss"] }
+  date { match => ["ts", "ISO8601"] }
+  date { match => ["ts", "yyyy-MM-dd'T'HH:mm:ssZ"] }
+}
+output {
+  stdout { codec => rubydebug }
+  elasticsearch { hosts => ["http://es:9200"] document_type => "_doc" id => "es" }
+}
+`
+
+func TestLintContent(t *testing.T) {
+	defaults := effectiveLintRules(nil)
+
+	result := lintContent(lintSample, defaults, lintEnv{production: true})
+	rules := make([]string, 0, len(result.Issues))
+	for _, issue := range result.Issues {
+		rules = append(rules, issue.Rule+"@"+issue.Plugin)
+	}
+	assert.Equal(t, []string{
+		"deprecated_option@input/beats",
+		"grok_unanchored@filter/grok",
+		"date_missing_timezone@filter/date",
+		"stdout_in_production@output/stdout",
+		"deprecated_option@output/elasticsearch",
+	}, rules)
+	assert.Equal(t, 1, result.Errors)
+	assert.Equal(t, 4, result.Warnings)
+	assert.True(t, result.Production)
+	assert.Equal(t, 2, result.Issues[0].Line)
+	assert.Contains(t, result.Issues[0].Message, "ssl_enabled")
+	assert.Equal(t, 7, result.Issues[1].Line)
+	assert.Equal(t, 12, result.Issues[2].Line)
+
+	// 非生产环境不检查stdout
+	result = lintContent(lintSample, defaults, lintEnv{})
+	assert.Equal(t, 0, result.Errors)
+
+	// 默认关闭的规则启用后生效
+	enabled := effectiveLintRules(&models.LintSettings{Rules: map[string]bool{
+		models.LintRuleMissingPluginID: true,
+		models.LintRuleGrokUnanchored:  false,
+	}})
+	result = lintContent(lintSample, enabled, lintEnv{})
+	assert.Equal(t, 7, result.Infos, "只有elasticsearch输出设置了id")
+	for _, issue := range result.Issues {
+		assert.NotEqual(t, models.LintRuleGrokUnanchored, issue.Rule)
+	}
+}
+
+func TestLintContent_SyntaxError(t *testing.T) {
+	result := lintContent("filter {\n  grok { match => { \"message\" => \"%{IP}\" }\n", effectiveLintRules(nil), lintEnv{})
+	require.Len(t, result.Issues, 1)
+	assert.Equal(t, models.LintRuleSyntax, result.Issues[0].Rule)
+	assert.Equal(t, models.LintSeverityError, result.Issues[0].Severity)
+	assert.Equal(t, 3, result.Issues[0].Line)
+	assert.Equal(t, 1, result.Errors)
+
+	result = lintContent("fliter { }", effectiveLintRules(nil), lintEnv{})
+	require.Len(t, result.Issues, 1)
+	assert.Contains(t, result.Issues[0].Message, "fliter")
+}
+
+func TestConfigLinter(t *testing.T) {
+	configs := &fakeConfigService{configs: map[string]*models.Config{
+		"cfg-1": {ID: "cfg-1", Project: "payments", Tags: []string{"Prod"}, Content: lintSample},
+	}}
+	repo := &memoryLintSettingsRepo{settings: map[string]*models.LintSettings{}}
+	linter := NewConfigLinter(configs, repo, nil, nil, logrus.New())
+	ctx := models.WithProject(context.Background(), "payments")
+
+	// 未指定环境时按配置标签判断
+	result, err := linter.Lint(ctx, "cfg-1", &models.LintConfigRequest{})
+	require.NoError(t, err)
+	assert.True(t, result.Production)
+	assert.Equal(t, 1, result.Errors)
+
+	result, err = linter.Lint(ctx, "cfg-1", &models.LintConfigRequest{Environment: "staging"})
+	require.NoError(t, err)
+	assert.False(t, result.Production)
+
+	_, err = linter.Lint(ctx, "missing", &models.LintConfigRequest{})
+	assert.EqualError(t, err, "文档不存在")
+
+	// 规则开关按项目保存，未列出的规则保持原值
+	_, err = linter.UpdateRules(ctx, map[string]bool{"no_such_rule": true}, "admin")
+	assert.True(t, errors.Is(err, ErrUnknownLintRule))
+
+	rules, err := linter.UpdateRules(ctx, map[string]bool{models.LintRuleStdoutInProduction: false}, "admin")
+	require.NoError(t, err)
+	require.Len(t, rules, len(lintRules))
+	for _, rule := range rules {
+		defaultRule := findLintRule(rule.ID)
+		if rule.ID == models.LintRuleStdoutInProduction {
+			assert.False(t, rule.Enabled)
+		} else {
+			assert.Equal(t, defaultRule.defaultEnabled, rule.Enabled, rule.ID)
+		}
+	}
+	assert.Equal(t, "admin", repo.settings["payments"].UpdatedBy)
+
+	result, err = linter.Lint(ctx, "cfg-1", &models.LintConfigRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Errors)
+
+	// 其他项目仍使用默认规则
+	rules, err = linter.Rules(models.WithProject(context.Background(), "search"))
+	require.NoError(t, err)
+	for _, rule := range rules {
+		if rule.ID == models.LintRuleStdoutInProduction {
+			assert.True(t, rule.Enabled)
+		}
+	}
+}
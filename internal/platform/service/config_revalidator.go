@@ -0,0 +1,315 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+)
+
+// ErrRevalidationRunning 已有重新校验正在进行
+var ErrRevalidationRunning = errors.New("重新校验正在进行中")
+
+// revalidationReporter 重新校验写入事件收件箱时使用的上报方标识
+const revalidationReporter = "platform:revalidation"
+
+// RevalidationConfig 定期重新校验参数
+type RevalidationConfig struct {
+	RunAt string // 每天执行的本地时间，格式 HH:MM，默认 02:00
+}
+
+// ConfigRevalidator 定期按当前的校验器和内容规则重新校验全部启用的配置
+// 规则更新或Logstash升级后，已保存的配置可能在下次部署时才暴露问题；
+// 新出现失败的配置在事件收件箱中创建事件，恢复通过时自动解决该事件
+type ConfigRevalidator struct {
+	runAt      time.Duration // 距当天零点的偏移
+	configRepo repository.ConfigRepository
+	resultRepo repository.ConfigRevalidationRepository
+	validator  ConfigValidator
+	incidents  IncidentService
+	logger     *logrus.Logger
+	now        func() time.Time
+
+	running sync.Mutex
+	mu      sync.Mutex
+	lastRun *models.RevalidationRun
+
+	inProgress atomic.Bool
+	tracker    loopTracker
+}
+
+// NewConfigRevalidator 创建定期重新校验任务
+func NewConfigRevalidator(cfg RevalidationConfig, configRepo repository.ConfigRepository, resultRepo repository.ConfigRevalidationRepository,
+	validator ConfigValidator, incidents IncidentService, logger *logrus.Logger) *ConfigRevalidator {
+	runAt := 2 * time.Hour
+	if cfg.RunAt != "" {
+		if t, err := time.Parse("15:04", cfg.RunAt); err == nil {
+			runAt = time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+		} else {
+			logger.Warnf("重新校验执行时间 %q 无效，使用默认值 02:00", cfg.RunAt)
+		}
+	}
+	r := &ConfigRevalidator{
+		runAt:      runAt,
+		configRepo: configRepo,
+		resultRepo: resultRepo,
+		validator:  validator,
+		incidents:  incidents,
+		logger:     logger,
+		now:        time.Now,
+	}
+	r.tracker = loopTracker{interval: 24 * time.Hour, schedule: r.nextRun}
+	return r
+}
+
+// Start 每天在设定时间执行一次重新校验，直到ctx取消
+func (r *ConfigRevalidator) Start(ctx context.Context) {
+	defer r.tracker.start(r.now())()
+	for {
+		timer := time.NewTimer(r.nextRun(r.now()).Sub(r.now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if _, err := r.Run(ctx); err != nil && ctx.Err() == nil {
+			r.logger.Errorf("定期重新校验配置失败: %v", err)
+		}
+	}
+}
+
+// nextRun 下一次执行时间
+func (r *ConfigRevalidator) nextRun(now time.Time) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).Add(r.runAt)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// WorkerStatus 报告定期重新校验的运行状态
+func (r *ConfigRevalidator) WorkerStatus(now time.Time) models.WorkerStatus {
+	status := r.tracker.status("config_revalidation", now)
+	inProgress := 0.0
+	if r.inProgress.Load() {
+		inProgress = 1
+	}
+	status.Gauges = map[string]float64{"in_progress": inProgress}
+	return status
+}
+
+// LastRun 最近一次完成的重新校验汇总，尚未执行过时返回nil
+func (r *ConfigRevalidator) LastRun() *models.RevalidationRun {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lastRun
+}
+
+// ListResults 获取各配置最近一次的校验结果
+func (r *ConfigRevalidator) ListResults(ctx context.Context, req *models.ConfigRevalidationListRequest) ([]*models.ConfigRevalidation, int64, error) {
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 10
+	}
+	return r.resultRepo.List(ctx, req)
+}
+
+// Run 重新校验全部启用的配置，已有校验正在进行时返回 ErrRevalidationRunning
+func (r *ConfigRevalidator) Run(ctx context.Context) (*models.RevalidationRun, error) {
+	if !r.running.TryLock() {
+		return nil, ErrRevalidationRunning
+	}
+	defer r.running.Unlock()
+	return r.run(ctx)
+}
+
+// Trigger 在后台立即执行一次重新校验，已有校验正在进行时返回 ErrRevalidationRunning
+func (r *ConfigRevalidator) Trigger() error {
+	if !r.running.TryLock() {
+		return ErrRevalidationRunning
+	}
+	go func() {
+		defer r.running.Unlock()
+		if _, err := r.run(context.Background()); err != nil {
+			r.logger.Errorf("重新校验配置失败: %v", err)
+		}
+	}()
+	return nil
+}
+
+// run 执行重新校验，调用方持有running锁
+func (r *ConfigRevalidator) run(ctx context.Context) (run *models.RevalidationRun, err error) {
+	r.inProgress.Store(true)
+	done := r.tracker.begin(r.now())
+	defer func() {
+		done(err)
+		r.inProgress.Store(false)
+	}()
+
+	configs, err := r.enabledConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	run = &models.RevalidationRun{
+		StartedAt:   r.now(),
+		NewlyFailed: []string{},
+		Recovered:   []string{},
+	}
+	for _, config := range configs {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		r.revalidate(ctx, config, run)
+	}
+	run.FinishedAt = r.now()
+
+	r.mu.Lock()
+	r.lastRun = run
+	r.mu.Unlock()
+
+	r.logger.WithFields(logrus.Fields{
+		"checked":      run.Checked,
+		"failed":       run.Failed,
+		"errored":      run.Errored,
+		"newly_failed": len(run.NewlyFailed),
+		"recovered":    len(run.Recovered),
+	}).Info("配置重新校验完成")
+
+	return run, nil
+}
+
+// revalidate 校验单个配置，与上一次结果比较后保存并处理状态变化
+func (r *ConfigRevalidator) revalidate(ctx context.Context, config *models.Config, run *models.RevalidationRun) {
+	result, err := r.check(ctx, config)
+	if err != nil {
+		// 校验本身失败时不下结论，保留上一次的结果
+		run.Errored++
+		r.logger.WithError(err).WithField("config_id", config.ID).Warn("重新校验配置出错")
+		return
+	}
+	run.Checked++
+
+	previous, err := r.resultRepo.GetByID(ctx, config.ID)
+	if err != nil {
+		if err.Error() != "文档不存在" {
+			r.logger.WithError(err).WithField("config_id", config.ID).Warn("读取上一次重新校验结果失败")
+		}
+		previous = nil
+	}
+
+	if !result.Valid {
+		run.Failed++
+		if previous != nil && !previous.Valid {
+			result.FailingSince = previous.FailingSince
+			result.IncidentID = previous.IncidentID
+		}
+		if result.FailingSince == nil {
+			at := result.CheckedAt
+			result.FailingSince = &at
+		}
+		// 上一次通过（或从未校验过）时视为新出现的失败；之前未能写入收件箱的失败在本次补写
+		if result.IncidentID == "" {
+			run.NewlyFailed = append(run.NewlyFailed, config.ID)
+			result.IncidentID = r.report(ctx, config, result)
+		}
+	} else if previous != nil && !previous.Valid {
+		run.Recovered = append(run.Recovered, config.ID)
+		r.resolve(ctx, config, previous)
+	}
+
+	if err := r.resultRepo.Save(ctx, result); err != nil {
+		r.logger.WithError(err).WithField("config_id", config.ID).Error("保存重新校验结果失败")
+	}
+}
+
+// check 按内容规则和Logstash语法校验配置，平台没有可用的Logstash时只检查内容规则
+func (r *ConfigRevalidator) check(ctx context.Context, config *models.Config) (*models.ConfigRevalidation, error) {
+	result := &models.ConfigRevalidation{
+		ConfigID:      config.ID,
+		ConfigName:    config.Name,
+		ConfigVersion: config.Version,
+		Errors:        []models.ConfigSyntaxError{},
+		CheckedAt:     r.now(),
+	}
+
+	if err := validateConfigContent(config.Type, config.Content); err != nil {
+		result.Errors = append(result.Errors, models.ConfigSyntaxError{Message: err.Error()})
+	}
+
+	syntax, err := r.validator.Validate(ctx, &models.ValidateConfigRequest{Type: config.Type, Content: config.Content})
+	switch {
+	case errors.Is(err, ErrValidatorUnavailable):
+	case err != nil:
+		return nil, err
+	default:
+		result.SyntaxChecked = true
+		result.Errors = append(result.Errors, syntax.Errors...)
+	}
+
+	result.Valid = len(result.Errors) == 0
+	return result, nil
+}
+
+// report 在事件收件箱中记录新出现的失败，返回事件ID，写入失败时返回空字符串以便下次重试
+func (r *ConfigRevalidator) report(ctx context.Context, config *models.Config, result *models.ConfigRevalidation) string {
+	incident, err := r.incidents.ReportError(ctx, revalidationReporter, &models.AgentErrorReport{
+		ErrorType: models.ErrorTypeRevalidationFailed,
+		ConfigID:  config.ID,
+		Message:   fmt.Sprintf("配置 %s 重新校验未通过: %s", config.Name, result.Errors[0].Message),
+		Timestamp: result.CheckedAt,
+	})
+	if err != nil {
+		r.logger.WithError(err).WithField("config_id", config.ID).Error("记录重新校验失败事件失败")
+		return ""
+	}
+
+	r.logger.WithFields(logrus.Fields{
+		"config_id":   config.ID,
+		"version":     config.Version,
+		"incident_id": incident.ID,
+	}).Warn("配置重新校验新出现失败")
+	return incident.ID
+}
+
+// resolve 配置恢复通过时解决此前创建的事件，事件已被人工处理时忽略
+func (r *ConfigRevalidator) resolve(ctx context.Context, config *models.Config, previous *models.ConfigRevalidation) {
+	r.logger.WithField("config_id", config.ID).Info("配置重新校验恢复通过")
+	if previous.IncidentID == "" {
+		return
+	}
+
+	_, err := r.incidents.ResolveIncident(ctx, previous.IncidentID, &models.ResolveIncidentRequest{
+		Resolution: fmt.Sprintf("版本 %d 重新校验已通过", config.Version),
+	}, revalidationReporter)
+	if err != nil {
+		r.logger.WithError(err).WithField("incident_id", previous.IncidentID).Debug("自动解决重新校验事件失败")
+	}
+}
+
+// enabledConfigs 分页获取全部启用的配置
+func (r *ConfigRevalidator) enabledConfigs(ctx context.Context) ([]*models.Config, error) {
+	enabled := true
+	var configs []*models.Config
+	for page := 1; ; page++ {
+		resp, err := r.configRepo.List(ctx, &models.ConfigListRequest{Enabled: &enabled, Page: page, PageSize: 100})
+		if err != nil {
+			return nil, fmt.Errorf("获取配置列表失败: %w", err)
+		}
+		configs = append(configs, resp.Items...)
+		if len(resp.Items) == 0 || int64(page*resp.Size) >= resp.Total {
+			break
+		}
+	}
+	return configs, nil
+}
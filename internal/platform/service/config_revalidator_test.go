@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/tests/mocks"
+)
+
+// memRevalidationRepository 内存重新校验结果仓库
+type memRevalidationRepository struct {
+	mu      sync.Mutex
+	results map[string]*models.ConfigRevalidation
+}
+
+func (r *memRevalidationRepository) Save(ctx context.Context, result *models.ConfigRevalidation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *result
+	r.results[result.ConfigID] = &copied
+	return nil
+}
+
+func (r *memRevalidationRepository) GetByID(ctx context.Context, configID string) (*models.ConfigRevalidation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result, ok := r.results[configID]
+	if !ok {
+		return nil, fmt.Errorf("文档不存在")
+	}
+	copied := *result
+	return &copied, nil
+}
+
+func (r *memRevalidationRepository) List(ctx context.Context, req *models.ConfigRevalidationListRequest) ([]*models.ConfigRevalidation, int64, error) {
+	return nil, 0, nil
+}
+
+// stubValidator 按配置内容返回预设的校验结果
+type stubValidator struct {
+	mu      sync.Mutex
+	results map[string]error // 内容 -> 语法错误，nil表示通过
+	err     error            // 校验器本身的错误
+}
+
+func (v *stubValidator) Validate(ctx context.Context, req *models.ValidateConfigRequest) (*models.ConfigValidationResult, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.err != nil {
+		return nil, v.err
+	}
+	result := &models.ConfigValidationResult{Valid: true, Errors: []models.ConfigSyntaxError{}}
+	if err := v.results[req.Content]; err != nil {
+		result.Valid = false
+		result.Errors = append(result.Errors, models.ConfigSyntaxError{Line: 1, Message: err.Error()})
+	}
+	return result, nil
+}
+
+func TestConfigRevalidator_Run(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	ctx := context.Background()
+
+	configs := []*models.Config{
+		{ID: "cfg-a", Name: "nginx", Type: models.ConfigTypeFilter, Content: "filter { mutate {} }", Version: 1, Enabled: true},
+		{ID: "cfg-b", Name: "legacy", Type: models.ConfigTypeFilter, Content: "filter { legacy {} }", Version: 4, Enabled: true},
+	}
+	configRepo := new(mocks.MockConfigRepository)
+	configRepo.On("List", ctx, mock.MatchedBy(func(req *models.ConfigListRequest) bool {
+		return req.Enabled != nil && *req.Enabled
+	})).Return(&models.ConfigListResponse{Total: 2, Page: 1, Size: 100, Items: configs}, nil)
+
+	results := &memRevalidationRepository{results: make(map[string]*models.ConfigRevalidation)}
+	incidentRepo := &memIncidentRepository{incidents: make(map[string]*models.Incident)}
+	incidents := NewIncidentService(incidentRepo, logger)
+	// Logstash升级后legacy插件不再可用
+	validator := &stubValidator{results: map[string]error{"filter { legacy {} }": errors.New("Couldn't find any filter plugin named 'legacy'")}}
+
+	r := NewConfigRevalidator(RevalidationConfig{}, configRepo, results, validator, incidents, logger)
+
+	t.Run("新出现的失败写入事件收件箱", func(t *testing.T) {
+		run, err := r.Run(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, run.Checked)
+		assert.Equal(t, 1, run.Failed)
+		assert.Equal(t, []string{"cfg-b"}, run.NewlyFailed)
+		assert.Same(t, run, r.LastRun())
+
+		result, err := results.GetByID(ctx, "cfg-b")
+		require.NoError(t, err)
+		assert.False(t, result.Valid)
+		assert.True(t, result.SyntaxChecked)
+		assert.Equal(t, 4, result.ConfigVersion)
+		require.NotEmpty(t, result.IncidentID)
+
+		incident, err := incidents.GetIncident(ctx, result.IncidentID)
+		require.NoError(t, err)
+		assert.Equal(t, models.ErrorTypeRevalidationFailed, incident.ErrorType)
+		assert.Equal(t, "cfg-b", incident.ConfigID)
+		assert.Contains(t, incident.Message, "legacy")
+	})
+
+	t.Run("持续失败不重复上报", func(t *testing.T) {
+		first, _ := results.GetByID(ctx, "cfg-b")
+
+		run, err := r.Run(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, run.NewlyFailed)
+		assert.Equal(t, 1, run.Failed)
+
+		result, _ := results.GetByID(ctx, "cfg-b")
+		assert.Equal(t, first.IncidentID, result.IncidentID)
+		assert.Equal(t, first.FailingSince, result.FailingSince)
+
+		incident, err := incidents.GetIncident(ctx, result.IncidentID)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, incident.Occurrences)
+	})
+
+	t.Run("校验器出错时保留上一次结果", func(t *testing.T) {
+		validator.err = errors.New("配置校验超时（1m0s）")
+		defer func() { validator.err = nil }()
+
+		run, err := r.Run(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, run.Errored)
+		assert.Equal(t, 0, run.Checked)
+
+		result, _ := results.GetByID(ctx, "cfg-b")
+		assert.False(t, result.Valid)
+	})
+
+	t.Run("恢复通过时自动解决事件", func(t *testing.T) {
+		previous, _ := results.GetByID(ctx, "cfg-b")
+		validator.results = nil
+
+		run, err := r.Run(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 0, run.Failed)
+		assert.Equal(t, []string{"cfg-b"}, run.Recovered)
+
+		result, _ := results.GetByID(ctx, "cfg-b")
+		assert.True(t, result.Valid)
+		assert.Empty(t, result.IncidentID)
+		assert.Nil(t, result.FailingSince)
+
+		_, err = incidents.GetIncident(ctx, previous.IncidentID)
+		assert.EqualError(t, err, "文档不存在")
+	})
+
+	t.Run("没有Logstash时只检查内容规则", func(t *testing.T) {
+		validator.err = ErrValidatorUnavailable
+		defer func() { validator.err = nil }()
+
+		run, err := r.Run(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, run.Checked)
+
+		result, _ := results.GetByID(ctx, "cfg-a")
+		assert.True(t, result.Valid)
+		assert.False(t, result.SyntaxChecked)
+	})
+}
+
+func TestConfigRevalidator_NextRun(t *testing.T) {
+	r := NewConfigRevalidator(RevalidationConfig{RunAt: "03:30"}, nil, nil, nil, nil, logrus.New())
+
+	now := time.Date(2026, 3, 1, 1, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2026, 3, 1, 3, 30, 0, 0, time.UTC), r.nextRun(now))
+
+	now = time.Date(2026, 3, 1, 3, 30, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2026, 3, 2, 3, 30, 0, 0, time.UTC), r.nextRun(now))
+}
@@ -2,28 +2,90 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/apperror"
 	"logstash-platform/internal/platform/models"
 	"logstash-platform/internal/platform/repository"
+	"logstash-platform/pkg/tabular"
 )
 
+var (
+	// ErrConfigForbidden 配置级访问控制不允许当前身份执行该操作
+	ErrConfigForbidden = errors.New("无权访问该配置")
+	// ErrConfigInUse 配置仍在Agent上运行，不能直接删除
+	ErrConfigInUse = errors.New("配置仍在Agent上运行")
+	// ErrConfigRemoveFailed 强制删除时未能从全部Agent上移除配置
+	ErrConfigRemoveFailed = errors.New("从Agent移除配置失败")
+	// ErrInvalidCursor 列表游标无法解析
+	ErrInvalidCursor = errors.New("游标无效")
+	// ErrConfigTooLarge 配置内容超过大小上限
+	ErrConfigTooLarge = errors.New("配置内容超过大小上限")
+)
+
+// ConfigInUseError 配置仍在Agent上运行，列出运行该配置的Agent
+type ConfigInUseError struct {
+	ConfigID string
+	AgentIDs []string
+}
+
+func (e *ConfigInUseError) Error() string {
+	return fmt.Sprintf("%s: %s 仍在 %d 个Agent上运行", ErrConfigInUse, e.ConfigID, len(e.AgentIDs))
+}
+
+// Unwrap 使 errors.Is(err, ErrConfigInUse) 成立
+func (e *ConfigInUseError) Unwrap() error {
+	return ErrConfigInUse
+}
+
+// ConfigRemover 从Agent上移除配置，等待Agent确认后返回
+type ConfigRemover interface {
+	RemoveConfig(ctx context.Context, configID string, agentIDs []string) error
+}
+
 // ConfigService 配置服务接口
 type ConfigService interface {
 	CreateConfig(ctx context.Context, req *models.CreateConfigRequest, userID string) (*models.Config, error)
 	UpdateConfig(ctx context.Context, id string, req *models.UpdateConfigRequest, userID string) (*models.Config, error)
-	DeleteConfig(ctx context.Context, id string) error
+	// DeleteConfig 删除配置，配置仍在Agent上运行时返回 *ConfigInUseError；
+	// force为true时先从这些Agent上移除配置并等待确认
+	DeleteConfig(ctx context.Context, id string, force bool) error
 	GetConfig(ctx context.Context, id string) (*models.Config, error)
 	ListConfigs(ctx context.Context, req *models.ConfigListRequest) (*models.ConfigListResponse, error)
+	SearchConfigs(ctx context.Context, req *models.ConfigSearchRequest) (*models.ConfigSearchResponse, error)
+	// ConfigStats 按类型、标签、测试状态和启用状态统计配置，可见范围与配置列表相同
+	ConfigStats(ctx context.Context, req *models.ConfigStatsRequest) (*models.ConfigStats, error)
+	// ExportConfigs 按列表的过滤条件逐页读取配置目录并写出选定的列，可见范围与配置列表相同；
+	// 列名无效时在调用open之前返回 ErrInvalidExportColumn
+	ExportConfigs(ctx context.Context, req *models.ConfigExportRequest, open func() (tabular.Writer, error)) error
 	GetConfigHistory(ctx context.Context, configID string) ([]*models.ConfigHistory, error)
 	RollbackConfig(ctx context.Context, configID string, version int, userID string) (*models.Config, error)
+	DiffVersions(ctx context.Context, configID string, from, to int) (*ConfigDiff, error)
+	SetConfigACL(ctx context.Context, configID string, acl *models.ConfigACL, userID string) (*models.Config, error)
+	// SetScheduledTest 开启或关闭配置的定期测试
+	SetScheduledTest(ctx context.Context, configID string, enabled bool, userID string) (*models.Config, error)
+	// RecordTestResult 记录配置某个版本的样本测试结果，配置已更新到其他版本时忽略
+	RecordTestResult(ctx context.Context, configID string, version int, status models.TestStatus) error
+	// SetDeleteGuard 设置删除前的引用检查，未设置时删除不检查配置是否仍在运行
+	SetDeleteGuard(agentRepo repository.AgentRepository, remover ConfigRemover)
+	// SetEventEmitter 设置平台事件的发布方，创建配置时发布 config.created
+	SetEventEmitter(events EventEmitter)
+	// SetMaxContentSize 设置配置内容的大小上限（字节），创建和更新超过上限的配置时返回 ErrConfigTooLarge，<=0表示不限制
+	SetMaxContentSize(limit int)
 }
 
 // configService 配置服务实现
 type configService struct {
 	configRepo repository.ConfigRepository
+	agentRepo  repository.AgentRepository // 未设置时删除不检查引用
+	remover    ConfigRemover
+	events     EventEmitter // 未设置时不发布事件
+	maxContent int          // 配置内容的大小上限（字节），0表示不限制
 	logger     *logrus.Logger
 }
 
@@ -37,18 +99,33 @@ func NewConfigService(configRepo repository.ConfigRepository, logger *logrus.Log
 
 // CreateConfig 创建配置
 func (s *configService) CreateConfig(ctx context.Context, req *models.CreateConfigRequest, userID string) (*models.Config, error) {
+	if err := s.checkContentSize(req.Content); err != nil {
+		return nil, err
+	}
+
 	// 验证配置内容
-	if err := s.validateConfigContent(req.Type, req.Content); err != nil {
+	if err := validateConfigContent(req.Type, req.Content); err != nil {
 		return nil, fmt.Errorf("配置内容验证失败: %w", err)
 	}
 
+	acl, err := s.prepareACL(ctx, req.ACL)
+	if err != nil {
+		return nil, err
+	}
+
 	// 创建配置对象
 	config := &models.Config{
 		Name:        req.Name,
 		Description: req.Description,
 		Type:        req.Type,
 		Content:     req.Content,
+		Project:     models.ProjectOf(models.ProjectFrom(ctx)),
 		Tags:        req.Tags,
+		Destinations: resolveDestinations(req.Destinations, req.Content),
+		Team:        req.Team,
+		ACL:         acl,
+		Reviewers:   normalizeReviewers(req.Reviewers, userID),
+		PipelineSettings: req.PipelineSettings,
 		CreatedBy:   userID,
 		UpdatedBy:   userID,
 	}
@@ -65,6 +142,10 @@ func (s *configService) CreateConfig(ctx context.Context, req *models.CreateConf
 		"user_id":   userID,
 	}).Info("创建配置成功")
 
+	if s.events != nil {
+		s.events.Emit(ctx, models.WebhookEventConfigCreated, config)
+	}
+
 	return config, nil
 }
 
@@ -75,9 +156,15 @@ func (s *configService) UpdateConfig(ctx context.Context, id string, req *models
 	if err != nil {
 		return nil, fmt.Errorf("配置不存在: %w", err)
 	}
+	if err := authorizeConfig(ctx, config, models.PermissionEdit); err != nil {
+		return nil, err
+	}
+	if err := s.checkContentSize(req.Content); err != nil {
+		return nil, err
+	}
 
 	// 验证配置内容
-	if err := s.validateConfigContent(req.Type, req.Content); err != nil {
+	if err := validateConfigContent(req.Type, req.Content); err != nil {
 		return nil, fmt.Errorf("配置内容验证失败: %w", err)
 	}
 
@@ -87,6 +174,10 @@ func (s *configService) UpdateConfig(ctx context.Context, id string, req *models
 	config.Type = req.Type
 	config.Content = req.Content
 	config.Tags = req.Tags
+	config.Destinations = resolveDestinations(req.Destinations, req.Content)
+	config.Team = req.Team
+	config.Reviewers = normalizeReviewers(req.Reviewers, userID)
+	config.PipelineSettings = req.PipelineSettings
 	config.UpdatedBy = userID
 
 	if req.Enabled != nil {
@@ -108,8 +199,73 @@ func (s *configService) UpdateConfig(ctx context.Context, id string, req *models
 	return config, nil
 }
 
+// SetDeleteGuard 设置删除前的引用检查
+func (s *configService) SetDeleteGuard(agentRepo repository.AgentRepository, remover ConfigRemover) {
+	s.agentRepo = agentRepo
+	s.remover = remover
+}
+
+// SetMaxContentSize 设置配置内容的大小上限
+func (s *configService) SetMaxContentSize(limit int) {
+	s.maxContent = limit
+}
+
+// checkContentSize 检查配置内容是否超过大小上限
+func (s *configService) checkContentSize(content string) error {
+	if s.maxContent <= 0 || len(content) <= s.maxContent {
+		return nil
+	}
+	return &apperror.Error{
+		Code:    apperror.ConfigTooLarge,
+		Message: fmt.Sprintf("配置内容为 %d 字节，超过上限 %d 字节", len(content), s.maxContent),
+		Cause:   ErrConfigTooLarge,
+	}
+}
+
+// SetEventEmitter 设置平台事件的发布方
+func (s *configService) SetEventEmitter(events EventEmitter) {
+	s.events = events
+}
+
 // DeleteConfig 删除配置
-func (s *configService) DeleteConfig(ctx context.Context, id string) error {
+// 配置仍在Agent上运行时拒绝删除，避免Agent上的流水线失去平台侧的配置来源；
+// force为true时先向这些Agent下发config_delete，全部确认移除后再删除
+func (s *configService) DeleteConfig(ctx context.Context, id string, force bool) error {
+	config, err := s.configRepo.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("配置不存在: %w", err)
+	}
+	if err := authorizeConfig(ctx, config, models.PermissionEdit); err != nil {
+		return err
+	}
+
+	if s.agentRepo != nil {
+		agents, err := s.agentRepo.ListByAppliedConfig(ctx, id)
+		if err != nil {
+			return fmt.Errorf("查询配置使用情况失败: %w", err)
+		}
+		if len(agents) > 0 {
+			agentIDs := make([]string, 0, len(agents))
+			for _, agent := range agents {
+				agentIDs = append(agentIDs, agent.AgentID)
+			}
+			sort.Strings(agentIDs)
+			if !force {
+				return &ConfigInUseError{ConfigID: id, AgentIDs: agentIDs}
+			}
+			if s.remover == nil {
+				return fmt.Errorf("%w: 未配置消息推送通道", ErrConfigRemoveFailed)
+			}
+			if err := s.remover.RemoveConfig(ctx, id, agentIDs); err != nil {
+				return fmt.Errorf("%w: %w", ErrConfigRemoveFailed, err)
+			}
+			s.logger.WithFields(logrus.Fields{
+				"config_id": id,
+				"agents":    len(agentIDs),
+			}).Info("已从Agent移除配置")
+		}
+	}
+
 	if err := s.configRepo.Delete(ctx, id); err != nil {
 		return err
 	}
@@ -120,11 +276,75 @@ func (s *configService) DeleteConfig(ctx context.Context, id string) error {
 
 // GetConfig 获取配置
 func (s *configService) GetConfig(ctx context.Context, id string) (*models.Config, error) {
-	return s.configRepo.GetByID(ctx, id)
+	config, err := s.configRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeConfig(ctx, config, models.PermissionRead); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// RecordTestResult 记录配置某个版本的样本测试结果
+func (s *configService) RecordTestResult(ctx context.Context, configID string, version int, status models.TestStatus) error {
+	updated, err := s.configRepo.UpdateTestStatus(ctx, configID, version, status, time.Now())
+	if err != nil {
+		return err
+	}
+	if !updated {
+		s.logger.WithFields(logrus.Fields{
+			"config_id": configID,
+			"version":   version,
+		}).Info("配置已在测试期间更新，不记录测试结果")
+		return nil
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"config_id":   configID,
+		"version":     version,
+		"test_status": status,
+	}).Info("记录配置测试结果")
+	return nil
 }
 
 // ListConfigs 获取配置列表
 func (s *configService) ListConfigs(ctx context.Context, req *models.ConfigListRequest) (*models.ConfigListResponse, error) {
+	scopeListRequest(ctx, req)
+
+	req.After = nil
+	if req.Cursor != "" {
+		after, err := models.DecodeCursor(req.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+		}
+		req.After = after
+	}
+
+	return s.configRepo.List(ctx, req)
+}
+
+// SearchConfigs 全文搜索配置，过滤条件和可见范围与配置列表相同
+func (s *configService) SearchConfigs(ctx context.Context, req *models.ConfigSearchRequest) (*models.ConfigSearchResponse, error) {
+	req.Query = strings.TrimSpace(req.Query)
+	if req.Query == "" {
+		return nil, fmt.Errorf("搜索关键词不能为空")
+	}
+	scopeListRequest(ctx, &req.ConfigListRequest)
+	return s.configRepo.Search(ctx, req)
+}
+
+// ConfigStats 统计配置，分面数量与列表一样只包含当前身份可读取的配置
+func (s *configService) ConfigStats(ctx context.Context, req *models.ConfigStatsRequest) (*models.ConfigStats, error) {
+	if req.TagSize < 1 || req.TagSize > 100 {
+		req.TagSize = 20
+	}
+	req.Project, req.Principals = visibleScope(ctx)
+	return s.configRepo.Stats(ctx, req)
+}
+
+// scopeListRequest 校正分页参数，并按请求的项目和身份限定可见的配置
+func scopeListRequest(ctx context.Context, req *models.ConfigListRequest) {
 	// 参数验证
 	if req.Page < 1 {
 		req.Page = 1
@@ -133,15 +353,28 @@ func (s *configService) ListConfigs(ctx context.Context, req *models.ConfigListR
 		req.PageSize = 10
 	}
 
-	return s.configRepo.List(ctx, req)
+	req.Project, req.Principals = visibleScope(ctx)
+}
+
+// visibleScope 请求的项目，以及受访问控制的配置中当前身份可读取的主体，不受限制时主体为空
+// 在查询中过滤以保证分页和总数正确
+func visibleScope(ctx context.Context) (string, []string) {
+	if p := models.PrincipalFrom(ctx); !p.Unrestricted() {
+		return models.ProjectFrom(ctx), p.Principals()
+	}
+	return models.ProjectFrom(ctx), nil
 }
 
 // GetConfigHistory 获取配置历史
 func (s *configService) GetConfigHistory(ctx context.Context, configID string) ([]*models.ConfigHistory, error) {
 	// 验证配置是否存在
-	if _, err := s.configRepo.GetByID(ctx, configID); err != nil {
+	config, err := s.configRepo.GetByID(ctx, configID)
+	if err != nil {
 		return nil, fmt.Errorf("配置不存在: %w", err)
 	}
+	if err := authorizeConfig(ctx, config, models.PermissionRead); err != nil {
+		return nil, err
+	}
 
 	return s.configRepo.GetHistory(ctx, configID)
 }
@@ -172,6 +405,9 @@ func (s *configService) RollbackConfig(ctx context.Context, configID string, ver
 	if err != nil {
 		return nil, err
 	}
+	if err := authorizeConfig(ctx, config, models.PermissionEdit); err != nil {
+		return nil, err
+	}
 
 	// 更新配置内容
 	config.Content = targetHistory.Content
@@ -192,8 +428,91 @@ func (s *configService) RollbackConfig(ctx context.Context, configID string, ver
 	return config, nil
 }
 
+// SetConfigACL 设置配置的访问控制，acl为空时取消限制
+// 需要编辑权限，且不允许非管理员移除自己的编辑权限
+func (s *configService) SetConfigACL(ctx context.Context, configID string, acl *models.ConfigACL, userID string) (*models.Config, error) {
+	config, err := s.configRepo.GetByID(ctx, configID)
+	if err != nil {
+		return nil, fmt.Errorf("配置不存在: %w", err)
+	}
+	if err := authorizeConfig(ctx, config, models.PermissionEdit); err != nil {
+		return nil, err
+	}
+
+	normalized, err := s.prepareACL(ctx, acl)
+	if err != nil {
+		return nil, err
+	}
+	config.ACL = normalized
+	config.UpdatedBy = userID
+
+	if err := s.configRepo.Update(ctx, config); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"config_id": config.ID,
+		"acl":       config.ACL,
+		"user_id":   userID,
+	}).Info("更新配置访问控制成功")
+
+	return config, nil
+}
+
+// SetScheduledTest 开启或关闭配置的定期测试，需要编辑权限
+func (s *configService) SetScheduledTest(ctx context.Context, configID string, enabled bool, userID string) (*models.Config, error) {
+	config, err := s.configRepo.GetByID(ctx, configID)
+	if err != nil {
+		return nil, fmt.Errorf("配置不存在: %w", err)
+	}
+	if err := authorizeConfig(ctx, config, models.PermissionEdit); err != nil {
+		return nil, err
+	}
+
+	config.ScheduledTest = enabled
+	config.UpdatedBy = userID
+	if err := s.configRepo.Update(ctx, config); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"config_id":      config.ID,
+		"scheduled_test": enabled,
+		"user_id":        userID,
+	}).Info("设置配置定期测试成功")
+
+	return config, nil
+}
+
+// prepareACL 规范化访问控制列表，为空时返回nil
+func (s *configService) prepareACL(ctx context.Context, acl *models.ConfigACL) (*models.ConfigACL, error) {
+	if acl.Empty() {
+		return nil, nil
+	}
+
+	normalized := *acl
+	if err := normalized.Normalize(); err != nil {
+		return nil, fmt.Errorf("访问控制无效: %w", err)
+	}
+	if !normalized.Allows(models.PrincipalFrom(ctx), models.PermissionEdit) {
+		return nil, fmt.Errorf("访问控制无效: 编辑者中必须包含当前用户或其所在团队")
+	}
+	return &normalized, nil
+}
+
+// authorizeConfig 按请求上下文中的项目和身份检查配置级权限
+func authorizeConfig(ctx context.Context, config *models.Config, permission string) error {
+	if !models.InProject(ctx, config.Project) {
+		return fmt.Errorf("%w: %s 不属于项目 %s", ErrConfigForbidden, config.ID, models.ProjectFrom(ctx))
+	}
+	if !config.ACL.Allows(models.PrincipalFrom(ctx), permission) {
+		return fmt.Errorf("%w: %s", ErrConfigForbidden, config.ID)
+	}
+	return nil
+}
+
 // validateConfigContent 验证配置内容
-func (s *configService) validateConfigContent(configType models.ConfigType, content string) error {
+func validateConfigContent(configType models.ConfigType, content string) error {
 	// TODO: 实现配置内容验证逻辑
 	// 1. 检查语法是否正确
 	// 2. 验证必要的字段
@@ -222,6 +541,14 @@ func (s *configService) validateConfigContent(configType models.ConfigType, cont
 	return nil
 }
 
+// resolveDestinations 优先使用显式标注的下游集群，未标注时从内容识别
+func resolveDestinations(tagged []string, content string) []string {
+	if len(tagged) > 0 {
+		return tagged
+	}
+	return ExtractDestinations(content)
+}
+
 // containsKeyword 检查内容是否包含关键字
 func containsKeyword(content, keyword string) bool {
 	// 简单的关键字检查，实际应该使用更复杂的解析
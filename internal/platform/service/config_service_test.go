@@ -8,6 +8,8 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/apperror"
 	"logstash-platform/internal/platform/models"
 	"logstash-platform/tests/mocks"
 )
@@ -257,6 +259,7 @@ func TestConfigService_DeleteConfig(t *testing.T) {
 			name: "successful deletion",
 			id:   "config-123",
 			setup: func(m *mocks.MockConfigRepository) {
+				m.On("GetByID", ctx, "config-123").Return(&models.Config{ID: "config-123"}, nil)
 				m.On("Delete", ctx, "config-123").Return(nil)
 			},
 			wantErr: false,
@@ -265,10 +268,19 @@ func TestConfigService_DeleteConfig(t *testing.T) {
 			name: "repository error",
 			id:   "config-123",
 			setup: func(m *mocks.MockConfigRepository) {
+				m.On("GetByID", ctx, "config-123").Return(&models.Config{ID: "config-123"}, nil)
 				m.On("Delete", ctx, "config-123").Return(assert.AnError)
 			},
 			wantErr: true,
 		},
+		{
+			name: "config not found",
+			id:   "non-existent",
+			setup: func(m *mocks.MockConfigRepository) {
+				m.On("GetByID", ctx, "non-existent").Return(nil, assert.AnError)
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -277,7 +289,7 @@ func TestConfigService_DeleteConfig(t *testing.T) {
 			tt.setup(mockRepo)
 
 			service := NewConfigService(mockRepo, logger)
-			err := service.DeleteConfig(ctx, tt.id)
+			err := service.DeleteConfig(ctx, tt.id, false)
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -622,10 +634,7 @@ func TestValidateConfigContent(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			logger := logrus.New()
-			service := &configService{logger: logger}
-			
-			err := service.validateConfigContent(tt.configType, tt.content)
+			err := validateConfigContent(tt.configType, tt.content)
 			
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -637,4 +646,229 @@ func TestValidateConfigContent(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestConfigService_ConfigACL(t *testing.T) {
+	logger := logrus.New()
+	alice := models.WithPrincipal(context.Background(), &models.Principal{User: "alice", Role: models.RoleEditor, Teams: []string{"security"}})
+	bob := models.WithPrincipal(context.Background(), &models.Principal{User: "bob", Role: models.RoleEditor})
+	admin := models.WithPrincipal(context.Background(), &models.Principal{User: "root", Role: models.RoleAdmin})
+
+	restricted := &models.Config{
+		ID:      "config-sec",
+		Content: "filter { }",
+		ACL:     &models.ConfigACL{Readers: []string{"team:security"}, Editors: []string{"team:security"}},
+	}
+
+	t.Run("按身份检查读取和编辑权限", func(t *testing.T) {
+		mockRepo := new(mocks.MockConfigRepository)
+		mockRepo.On("GetByID", mock.Anything, "config-sec").Return(restricted, nil)
+		mockRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+		svc := NewConfigService(mockRepo, logger)
+
+		_, err := svc.GetConfig(bob, "config-sec")
+		assert.ErrorIs(t, err, ErrConfigForbidden)
+		_, err = svc.GetConfig(alice, "config-sec")
+		assert.NoError(t, err)
+		_, err = svc.GetConfig(admin, "config-sec")
+		assert.NoError(t, err)
+		// 后台任务没有身份，不受限制
+		_, err = svc.GetConfig(context.Background(), "config-sec")
+		assert.NoError(t, err)
+
+		req := &models.UpdateConfigRequest{Name: "sec", Type: models.ConfigTypeFilter, Content: "filter { }"}
+		_, err = svc.UpdateConfig(bob, "config-sec", req, "bob")
+		assert.ErrorIs(t, err, ErrConfigForbidden)
+		assert.ErrorIs(t, svc.DeleteConfig(bob, "config-sec", false), ErrConfigForbidden)
+		_, err = svc.UpdateConfig(alice, "config-sec", req, "alice")
+		assert.NoError(t, err)
+	})
+
+	t.Run("列表按主体过滤", func(t *testing.T) {
+		mockRepo := new(mocks.MockConfigRepository)
+		mockRepo.On("List", mock.Anything, mock.MatchedBy(func(req *models.ConfigListRequest) bool {
+			return assert.ObjectsAreEqual([]string{"user:alice", "team:security"}, req.Principals)
+		})).Return(&models.ConfigListResponse{}, nil).Once()
+		mockRepo.On("List", mock.Anything, mock.MatchedBy(func(req *models.ConfigListRequest) bool {
+			return req.Principals == nil
+		})).Return(&models.ConfigListResponse{}, nil).Once()
+		svc := NewConfigService(mockRepo, logger)
+
+		_, err := svc.ListConfigs(alice, &models.ConfigListRequest{})
+		assert.NoError(t, err)
+		_, err = svc.ListConfigs(admin, &models.ConfigListRequest{Principals: []string{"user:forged"}})
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("设置访问控制", func(t *testing.T) {
+		mockRepo := new(mocks.MockConfigRepository)
+		// 每次返回新对象，避免前一次设置的访问控制影响后续用例
+		for i := 0; i < 4; i++ {
+			mockRepo.On("GetByID", mock.Anything, "config-1").Return(&models.Config{ID: "config-1"}, nil).Once()
+		}
+		mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*models.Config")).Return(nil)
+		svc := NewConfigService(mockRepo, logger)
+
+		config, err := svc.SetConfigACL(alice, "config-1", &models.ConfigACL{
+			Editors:   []string{"team:security", "user:alice", "team:security"},
+			Deployers: []string{"user:deployer"},
+		}, "alice")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"team:security", "user:alice"}, config.ACL.Editors)
+		assert.Equal(t, []string{"team:security", "user:alice", "user:deployer"}, config.ACL.Readers)
+
+		// 不能移除自己的编辑权限
+		_, err = svc.SetConfigACL(bob, "config-1", &models.ConfigACL{Editors: []string{"user:alice"}}, "bob")
+		assert.ErrorContains(t, err, "访问控制无效")
+
+		_, err = svc.SetConfigACL(admin, "config-1", &models.ConfigACL{Readers: []string{"security"}}, "root")
+		assert.ErrorContains(t, err, "无效的访问控制主体")
+
+		// 列表均为空时取消限制
+		config, err = svc.SetConfigACL(bob, "config-1", &models.ConfigACL{}, "bob")
+		assert.NoError(t, err)
+		assert.Nil(t, config.ACL)
+	})
+}
+
+func TestConfigService_Projects(t *testing.T) {
+	logger := logrus.New()
+	payments := models.WithProject(context.Background(), "payments")
+	defaultProject := models.WithProject(context.Background(), models.DefaultProject)
+
+	t.Run("创建时记录请求所属的项目", func(t *testing.T) {
+		mockRepo := new(mocks.MockConfigRepository)
+		mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.Config")).Return(nil)
+		svc := NewConfigService(mockRepo, logger)
+
+		req := &models.CreateConfigRequest{Name: "pay", Type: models.ConfigTypeFilter, Content: "filter { }"}
+		config, err := svc.CreateConfig(payments, req, "alice")
+		assert.NoError(t, err)
+		assert.Equal(t, "payments", config.Project)
+
+		config, err = svc.CreateConfig(context.Background(), req, "alice")
+		assert.NoError(t, err)
+		assert.Equal(t, models.DefaultProject, config.Project)
+	})
+
+	t.Run("不能访问其他项目的配置", func(t *testing.T) {
+		mockRepo := new(mocks.MockConfigRepository)
+		mockRepo.On("GetByID", mock.Anything, "config-pay").Return(&models.Config{ID: "config-pay", Project: "payments"}, nil)
+		mockRepo.On("GetByID", mock.Anything, "config-legacy").Return(&models.Config{ID: "config-legacy"}, nil)
+		svc := NewConfigService(mockRepo, logger)
+
+		_, err := svc.GetConfig(payments, "config-pay")
+		assert.NoError(t, err)
+		_, err = svc.GetConfig(defaultProject, "config-pay")
+		assert.ErrorIs(t, err, ErrConfigForbidden)
+		// 没有项目字段的配置属于默认项目
+		_, err = svc.GetConfig(defaultProject, "config-legacy")
+		assert.NoError(t, err)
+		_, err = svc.GetConfig(payments, "config-legacy")
+		assert.ErrorIs(t, err, ErrConfigForbidden)
+		// 后台任务不按项目限制
+		_, err = svc.GetConfig(context.Background(), "config-pay")
+		assert.NoError(t, err)
+	})
+
+	t.Run("列表按项目过滤", func(t *testing.T) {
+		mockRepo := new(mocks.MockConfigRepository)
+		mockRepo.On("List", mock.Anything, mock.MatchedBy(func(req *models.ConfigListRequest) bool {
+			return req.Project == "payments"
+		})).Return(&models.ConfigListResponse{}, nil).Once()
+		svc := NewConfigService(mockRepo, logger)
+
+		_, err := svc.ListConfigs(payments, &models.ConfigListRequest{})
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestConfigService_SafeDelete(t *testing.T) {
+	ctx := context.Background()
+	logger := logrus.New()
+
+	setup := func(ackTimeout time.Duration) (ConfigService, *mocks.MockConfigRepository, *memAgentRepository, *DeploymentEngine, *chanPublisher) {
+		configRepo := new(mocks.MockConfigRepository)
+		configRepo.On("GetByID", mock.Anything, "cfg-1").Return(&models.Config{ID: "cfg-1", Version: 2}, nil)
+		configRepo.On("Delete", mock.Anything, "cfg-1").Return(nil)
+		agentRepo := &memAgentRepository{agents: map[string]*models.Agent{
+			"agent-2": {AgentID: "agent-2", AppliedConfigs: []models.AppliedConfig{{ConfigID: "cfg-1", Version: 2}}},
+			"agent-1": {AgentID: "agent-1", AppliedConfigs: []models.AppliedConfig{{ConfigID: "cfg-1", Version: 1}}},
+			"agent-3": {AgentID: "agent-3"},
+		}}
+		agents := NewAgentService(agentRepo, configRepo, NewCommandQueue(0), logger)
+		publisher := &chanPublisher{sent: make(chan string, 10)}
+		engine := NewDeploymentEngine(&memDeploymentRepository{deployments: make(map[string]models.Deployment)},
+			configRepo, agents, publisher, nil, ackTimeout, logger)
+
+		svc := NewConfigService(configRepo, logger)
+		svc.SetDeleteGuard(agentRepo, engine)
+		return svc, configRepo, agentRepo, engine, publisher
+	}
+
+	t.Run("仍在运行的配置拒绝删除并列出Agent", func(t *testing.T) {
+		svc, configRepo, _, _, _ := setup(time.Second)
+
+		err := svc.DeleteConfig(ctx, "cfg-1", false)
+		var inUse *ConfigInUseError
+		require.ErrorAs(t, err, &inUse)
+		assert.ErrorIs(t, err, ErrConfigInUse)
+		assert.Equal(t, []string{"agent-1", "agent-2"}, inUse.AgentIDs)
+		configRepo.AssertNotCalled(t, "Delete", mock.Anything, "cfg-1")
+	})
+
+	t.Run("强制删除等待Agent确认移除后删除", func(t *testing.T) {
+		svc, configRepo, agentRepo, engine, publisher := setup(time.Second)
+
+		go func() {
+			for i := 0; i < 2; i++ {
+				agentID := <-publisher.sent
+				assert.NoError(t, engine.RecordResult(ctx, agentID, &models.ConfigAppliedReport{ConfigID: "cfg-1", Status: "removed"}))
+			}
+		}()
+
+		require.NoError(t, svc.DeleteConfig(ctx, "cfg-1", true))
+		configRepo.AssertCalled(t, "Delete", mock.Anything, "cfg-1")
+		remaining, err := agentRepo.ListByAppliedConfig(ctx, "cfg-1")
+		require.NoError(t, err)
+		assert.Empty(t, remaining)
+	})
+
+	t.Run("Agent未确认时不删除", func(t *testing.T) {
+		svc, configRepo, _, engine, publisher := setup(50 * time.Millisecond)
+
+		go func() {
+			agentID := <-publisher.sent
+			assert.NoError(t, engine.RecordResult(ctx, agentID, &models.ConfigAppliedReport{ConfigID: "cfg-1", Status: "removed"}))
+		}()
+
+		err := svc.DeleteConfig(ctx, "cfg-1", true)
+		assert.ErrorIs(t, err, ErrConfigRemoveFailed)
+		assert.Contains(t, err.Error(), "agent-2")
+		configRepo.AssertNotCalled(t, "Delete", mock.Anything, "cfg-1")
+	})
+}
+
+func TestConfigService_MaxContentSize(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(mocks.MockConfigRepository)
+	service := NewConfigService(mockRepo, logrus.New())
+	service.SetMaxContentSize(32)
+
+	large := "filter { mutate { add_tag => [\"oversized\"] } }"
+	_, err := service.CreateConfig(ctx, &models.CreateConfigRequest{Name: "large", Type: models.ConfigTypeFilter, Content: large}, "user123")
+	require.ErrorIs(t, err, ErrConfigTooLarge)
+	assert.Equal(t, apperror.ConfigTooLarge, apperror.From(err).Code)
+	assert.Contains(t, err.Error(), "超过上限 32 字节")
+
+	mockRepo.On("GetByID", ctx, "cfg-1").Return(&models.Config{ID: "cfg-1", Type: models.ConfigTypeFilter, Content: "filter { }"}, nil)
+	_, err = service.UpdateConfig(ctx, "cfg-1", &models.UpdateConfigRequest{Name: "large", Type: models.ConfigTypeFilter, Content: large}, "user123")
+	assert.ErrorIs(t, err, ErrConfigTooLarge)
+
+	// 未超过上限时正常保存
+	mockRepo.On("Create", ctx, mock.Anything).Return(nil)
+	_, err = service.CreateConfig(ctx, &models.CreateConfigRequest{Name: "small", Type: models.ConfigTypeFilter, Content: "filter { }"}, "user123")
+	assert.NoError(t, err)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+)
+
+// ConfigUsageService 配置使用情况服务接口
+// 按Agent上报的配置应用结果维护Agent与配置版本的映射，回答“哪些Agent运行哪个版本”
+type ConfigUsageService interface {
+	// Record 记录Agent应用的配置版本
+	Record(ctx context.Context, agentID string, applied models.AppliedConfig) error
+	// Remove 删除Agent已移除的配置的映射
+	Remove(ctx context.Context, agentID, configID string) error
+	// ConfigAgents 获取运行配置的Agent及其版本
+	ConfigAgents(ctx context.Context, configID string) (*models.ConfigUsage, error)
+	// AgentConfigs 获取Agent运行的配置及其版本
+	AgentConfigs(ctx context.Context, agentID string) ([]*models.AgentConfigUsage, error)
+}
+
+// configUsageService 配置使用情况服务实现
+type configUsageService struct {
+	usageRepo  repository.AppliedConfigRepository
+	configRepo repository.ConfigRepository
+	logger     *logrus.Logger
+}
+
+// NewConfigUsageService 创建配置使用情况服务
+func NewConfigUsageService(usageRepo repository.AppliedConfigRepository, configRepo repository.ConfigRepository, logger *logrus.Logger) ConfigUsageService {
+	return &configUsageService{
+		usageRepo:  usageRepo,
+		configRepo: configRepo,
+		logger:     logger,
+	}
+}
+
+// Record 记录Agent应用的配置版本
+func (s *configUsageService) Record(ctx context.Context, agentID string, applied models.AppliedConfig) error {
+	if applied.AppliedAt.IsZero() {
+		applied.AppliedAt = time.Now()
+	}
+	return s.usageRepo.Save(ctx, &models.AppliedConfigMapping{
+		AgentID:       agentID,
+		ConfigID:      applied.ConfigID,
+		Version:       applied.Version,
+		Hash:          applied.Hash,
+		DeploymentID:  applied.DeploymentID,
+		ReloadPending: applied.ReloadPending,
+		AppliedAt:     applied.AppliedAt,
+	})
+}
+
+// Remove 删除Agent已移除的配置的映射
+func (s *configUsageService) Remove(ctx context.Context, agentID, configID string) error {
+	return s.usageRepo.Delete(ctx, agentID, configID)
+}
+
+// ConfigAgents 获取运行配置的Agent，标出版本落后于当前版本的Agent
+func (s *configUsageService) ConfigAgents(ctx context.Context, configID string) (*models.ConfigUsage, error) {
+	config, err := s.configRepo.GetByID(ctx, configID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConfigNotFound, err)
+	}
+	if err := authorizeConfig(ctx, config, models.PermissionRead); err != nil {
+		return nil, err
+	}
+
+	mappings, err := s.usageRepo.ListByConfig(ctx, configID)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &models.ConfigUsage{
+		ConfigID:       config.ID,
+		CurrentVersion: config.Version,
+		Total:          len(mappings),
+		Versions:       []models.ConfigVersionCount{},
+		Agents:         make([]models.ConfigUsageAgent, 0, len(mappings)),
+	}
+	counts := make(map[int]int)
+	for _, m := range mappings {
+		outdated := m.Version < config.Version
+		if outdated {
+			usage.Outdated++
+		}
+		counts[m.Version]++
+		usage.Agents = append(usage.Agents, models.ConfigUsageAgent{
+			AgentID:       m.AgentID,
+			Version:       m.Version,
+			Outdated:      outdated,
+			ReloadPending: m.ReloadPending,
+			DeploymentID:  m.DeploymentID,
+			AppliedAt:     m.AppliedAt,
+		})
+	}
+	for version, agents := range counts {
+		usage.Versions = append(usage.Versions, models.ConfigVersionCount{Version: version, Agents: agents})
+	}
+	sort.Slice(usage.Versions, func(i, j int) bool { return usage.Versions[i].Version > usage.Versions[j].Version })
+
+	return usage, nil
+}
+
+// AgentConfigs 获取Agent运行的配置
+// 平台上已删除的配置仍然列出并标记，便于发现Agent上残留的配置；无权读取的配置不返回名称
+func (s *configUsageService) AgentConfigs(ctx context.Context, agentID string) ([]*models.AgentConfigUsage, error) {
+	mappings, err := s.usageRepo.ListByAgent(ctx, agentID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*models.AgentConfigUsage, 0, len(mappings))
+	for _, m := range mappings {
+		item := &models.AgentConfigUsage{
+			ConfigID:      m.ConfigID,
+			Version:       m.Version,
+			ReloadPending: m.ReloadPending,
+			DeploymentID:  m.DeploymentID,
+			AppliedAt:     m.AppliedAt,
+		}
+
+		config, err := s.configRepo.GetByID(ctx, m.ConfigID)
+		switch {
+		case err != nil && err.Error() == "文档不存在":
+			item.Deleted = true
+		case err != nil:
+			return nil, fmt.Errorf("获取配置 %s 失败: %w", m.ConfigID, err)
+		default:
+			item.CurrentVersion = config.Version
+			item.Outdated = m.Version < config.Version
+			if authorizeConfig(ctx, config, models.PermissionRead) == nil {
+				item.ConfigName = config.Name
+			}
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/tests/mocks"
+)
+
+// memAppliedConfigRepository 内存中的已应用配置映射仓库
+type memAppliedConfigRepository struct {
+	mu       sync.Mutex
+	mappings map[string]models.AppliedConfigMapping
+}
+
+func (r *memAppliedConfigRepository) Save(ctx context.Context, mapping *models.AppliedConfigMapping) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.mappings == nil {
+		r.mappings = make(map[string]models.AppliedConfigMapping)
+	}
+	r.mappings[mapping.AgentID+":"+mapping.ConfigID] = *mapping
+	return nil
+}
+
+func (r *memAppliedConfigRepository) Delete(ctx context.Context, agentID, configID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.mappings, agentID+":"+configID)
+	return nil
+}
+
+func (r *memAppliedConfigRepository) ListByConfig(ctx context.Context, configID string) ([]*models.AppliedConfigMapping, error) {
+	return r.list(func(m models.AppliedConfigMapping) bool { return m.ConfigID == configID }), nil
+}
+
+func (r *memAppliedConfigRepository) ListByAgent(ctx context.Context, agentID string) ([]*models.AppliedConfigMapping, error) {
+	return r.list(func(m models.AppliedConfigMapping) bool { return m.AgentID == agentID }), nil
+}
+
+func (r *memAppliedConfigRepository) list(match func(models.AppliedConfigMapping) bool) []*models.AppliedConfigMapping {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var mappings []*models.AppliedConfigMapping
+	for _, m := range r.mappings {
+		if match(m) {
+			cp := m
+			mappings = append(mappings, &cp)
+		}
+	}
+	sort.Slice(mappings, func(i, j int) bool {
+		return mappings[i].AgentID+mappings[i].ConfigID < mappings[j].AgentID+mappings[j].ConfigID
+	})
+	return mappings
+}
+
+func TestConfigUsageService(t *testing.T) {
+	ctx := context.Background()
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	configRepo := new(mocks.MockConfigRepository)
+	configRepo.On("GetByID", mock.Anything, "cfg-1").Return(&models.Config{ID: "cfg-1", Name: "nginx", Version: 3}, nil)
+	configRepo.On("GetByID", mock.Anything, "cfg-gone").Return(nil, fmt.Errorf("文档不存在"))
+	usageRepo := &memAppliedConfigRepository{}
+	svc := NewConfigUsageService(usageRepo, configRepo, logger)
+
+	require.NoError(t, svc.Record(ctx, "agent-1", models.AppliedConfig{ConfigID: "cfg-1", Version: 2}))
+	require.NoError(t, svc.Record(ctx, "agent-1", models.AppliedConfig{ConfigID: "cfg-1", Version: 3, DeploymentID: "dep-1"}))
+	require.NoError(t, svc.Record(ctx, "agent-2", models.AppliedConfig{ConfigID: "cfg-1", Version: 2}))
+	require.NoError(t, svc.Record(ctx, "agent-1", models.AppliedConfig{ConfigID: "cfg-gone", Version: 1}))
+
+	t.Run("配置的Agent及版本", func(t *testing.T) {
+		usage, err := svc.ConfigAgents(ctx, "cfg-1")
+		require.NoError(t, err)
+		assert.Equal(t, 3, usage.CurrentVersion)
+		assert.Equal(t, 2, usage.Total)
+		assert.Equal(t, 1, usage.Outdated)
+		assert.Equal(t, []models.ConfigVersionCount{{Version: 3, Agents: 1}, {Version: 2, Agents: 1}}, usage.Versions)
+		require.Len(t, usage.Agents, 2)
+		assert.Equal(t, "agent-1", usage.Agents[0].AgentID)
+		assert.Equal(t, "dep-1", usage.Agents[0].DeploymentID)
+		assert.False(t, usage.Agents[0].Outdated)
+		assert.True(t, usage.Agents[1].Outdated)
+		assert.False(t, usage.Agents[1].AppliedAt.IsZero())
+	})
+
+	t.Run("Agent运行的配置", func(t *testing.T) {
+		items, err := svc.AgentConfigs(ctx, "agent-1")
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		assert.Equal(t, "nginx", items[0].ConfigName)
+		assert.Equal(t, 3, items[0].CurrentVersion)
+		assert.False(t, items[0].Outdated)
+		assert.True(t, items[1].Deleted)
+	})
+
+	t.Run("配置不存在", func(t *testing.T) {
+		_, err := svc.ConfigAgents(ctx, "cfg-gone")
+		assert.ErrorIs(t, err, ErrConfigNotFound)
+	})
+}
+
+func TestDeploymentEngine_RecordsConfigUsage(t *testing.T) {
+	ctx := context.Background()
+	engine, _, _ := newTestEngine(t, time.Second)
+	usageRepo := &memAppliedConfigRepository{}
+	engine.SetConfigUsageService(NewConfigUsageService(usageRepo, new(mocks.MockConfigRepository), logrus.New()))
+
+	require.NoError(t, engine.RecordResult(ctx, "agent-1", &models.ConfigAppliedReport{ConfigID: "cfg-1", Version: 4, Status: "success"}))
+	require.NoError(t, engine.RecordResult(ctx, "agent-2", &models.ConfigAppliedReport{ConfigID: "cfg-1", Version: 5, Status: "failed"}))
+
+	mappings, _ := usageRepo.ListByConfig(ctx, "cfg-1")
+	require.Len(t, mappings, 1)
+	assert.Equal(t, "agent-1", mappings[0].AgentID)
+	assert.Equal(t, 4, mappings[0].Version)
+}
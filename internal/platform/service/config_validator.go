@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+)
+
+// ErrValidatorUnavailable 本地没有可用的Logstash
+var ErrValidatorUnavailable = errors.New("Logstash校验器不可用")
+
+// defaultValidateTimeout 单次校验的默认超时
+const defaultValidateTimeout = 60 * time.Second
+
+var (
+	// logstashReasonPattern 提取 "Reason: ..." 之后的错误描述
+	logstashReasonPattern = regexp.MustCompile(`(?s)Reason:\s*(.+?)(?:\n\[|\z)`)
+	// logstashPositionPattern 提取错误位置
+	logstashPositionPattern = regexp.MustCompile(`at line (\d+), column (\d+)`)
+)
+
+// commandRunner 执行外部命令并返回合并后的输出
+type commandRunner func(ctx context.Context, name string, args ...string) ([]byte, error)
+
+// runCommand 默认的命令执行方式
+func runCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, name, args...).CombinedOutput()
+}
+
+// ConfigValidator 配置语法校验接口
+type ConfigValidator interface {
+	Validate(ctx context.Context, req *models.ValidateConfigRequest) (*models.ConfigValidationResult, error)
+}
+
+// logstashValidator 通过本地Logstash的 --config.test_and_exit 校验配置
+type logstashValidator struct {
+	bin     string
+	tempDir string
+	timeout time.Duration
+	slots   chan struct{} // 限制同时运行的Logstash进程数
+	run     commandRunner
+	logger  *logrus.Logger
+}
+
+// NewLogstashValidator 创建Logstash配置校验器
+// maxConcurrent小于1时不限制并发
+func NewLogstashValidator(bin, tempDir string, timeout time.Duration, maxConcurrent int, logger *logrus.Logger) ConfigValidator {
+	if timeout <= 0 {
+		timeout = defaultValidateTimeout
+	}
+	if tempDir == "" {
+		tempDir = os.TempDir()
+	}
+	v := &logstashValidator{
+		bin:     bin,
+		tempDir: tempDir,
+		timeout: timeout,
+		run:     runCommand,
+		logger:  logger,
+	}
+	if maxConcurrent > 0 {
+		v.slots = make(chan struct{}, maxConcurrent)
+	}
+	return v
+}
+
+// Validate 校验配置语法
+func (v *logstashValidator) Validate(ctx context.Context, req *models.ValidateConfigRequest) (*models.ConfigValidationResult, error) {
+	if v.bin == "" {
+		return nil, ErrValidatorUnavailable
+	}
+	if _, err := os.Stat(v.bin); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidatorUnavailable, err)
+	}
+
+	if v.slots != nil {
+		select {
+		case v.slots <- struct{}{}:
+			defer func() { <-v.slots }()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	if err := os.MkdirAll(v.tempDir, 0755); err != nil {
+		return nil, fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	workDir, err := os.MkdirTemp(v.tempDir, "validate-")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	configFile := filepath.Join(workDir, "pipeline.conf")
+	if err := os.WriteFile(configFile, []byte(req.Content), 0644); err != nil {
+		return nil, fmt.Errorf("写入临时配置失败: %w", err)
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, v.timeout)
+	defer cancel()
+
+	started := time.Now()
+	// 每次校验使用独立的path.data，避免与正在运行的Logstash争用数据目录锁
+	output, runErr := v.run(runCtx, v.bin,
+		"--config.test_and_exit",
+		"--path.config", configFile,
+		"--path.data", filepath.Join(workDir, "data"),
+		"--log.level", "error",
+	)
+	elapsed := time.Since(started)
+
+	if runCtx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("配置校验超时（%s）", v.timeout)
+	}
+
+	result := parseLogstashValidation(string(output), runErr)
+	result.DurationMs = elapsed.Milliseconds()
+
+	v.logger.WithFields(logrus.Fields{
+		"valid":    result.Valid,
+		"errors":   len(result.Errors),
+		"duration": elapsed,
+	}).Debug("Logstash配置校验完成")
+
+	return result, nil
+}
+
+// parseLogstashValidation 解析 --config.test_and_exit 的输出
+func parseLogstashValidation(output string, runErr error) *models.ConfigValidationResult {
+	result := &models.ConfigValidationResult{
+		Errors: []models.ConfigSyntaxError{},
+		Output: strings.TrimSpace(output),
+	}
+
+	if runErr == nil && strings.Contains(output, "Configuration OK") {
+		result.Valid = true
+		return result
+	}
+
+	for _, m := range logstashReasonPattern.FindAllStringSubmatch(output, -1) {
+		syntaxErr := models.ConfigSyntaxError{Message: strings.TrimSpace(m[1])}
+		if pos := logstashPositionPattern.FindStringSubmatch(m[1]); pos != nil {
+			syntaxErr.Line, _ = strconv.Atoi(pos[1])
+			syntaxErr.Column, _ = strconv.Atoi(pos[2])
+		}
+		result.Errors = append(result.Errors, syntaxErr)
+	}
+
+	if len(result.Errors) == 0 {
+		message := "Logstash配置校验未通过"
+		if runErr != nil {
+			message = fmt.Sprintf("%s: %v", message, runErr)
+		}
+		result.Errors = append(result.Errors, models.ConfigSyntaxError{Message: message})
+	}
+
+	return result
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+)
+
+func newTestValidator(t *testing.T, run commandRunner) ConfigValidator {
+	bin := filepath.Join(t.TempDir(), "logstash")
+	require.NoError(t, os.WriteFile(bin, []byte("#!/bin/sh\n"), 0755))
+
+	v := NewLogstashValidator(bin, t.TempDir(), time.Second, 1, logrus.New()).(*logstashValidator)
+	v.run = run
+	return v
+}
+
+func TestLogstashValidator_Validate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("配置正确", func(t *testing.T) {
+		v := newTestValidator(t, func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			assert.Contains(t, args, "--config.test_and_exit")
+			return []byte("Using bundled JDK\nConfiguration OK\n"), nil
+		})
+
+		result, err := v.Validate(ctx, &models.ValidateConfigRequest{Content: "filter { }"})
+		require.NoError(t, err)
+		assert.True(t, result.Valid)
+		assert.Empty(t, result.Errors)
+	})
+
+	t.Run("语法错误带行列号", func(t *testing.T) {
+		v := newTestValidator(t, func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			return []byte(`[2024-01-01T00:00:00,000][FATAL][logstash.runner] The given configuration is invalid. Reason: Expected one of [ \t\r\n], "#", "=>" at line 3, column 9 (byte 30) after filter {
+  mutate {
+    add_field
+[2024-01-01T00:00:00,001][FATAL][org.logstash.Logstash] Logstash stopped processing because of an error`), errors.New("exit status 1")
+		})
+
+		result, err := v.Validate(ctx, &models.ValidateConfigRequest{Content: "filter {\n  mutate {\n    add_field\n  }\n}"})
+		require.NoError(t, err)
+		assert.False(t, result.Valid)
+		require.Len(t, result.Errors, 1)
+		assert.Equal(t, 3, result.Errors[0].Line)
+		assert.Equal(t, 9, result.Errors[0].Column)
+		assert.Contains(t, result.Errors[0].Message, "Expected one of")
+	})
+
+	t.Run("Logstash不存在", func(t *testing.T) {
+		v := NewLogstashValidator(filepath.Join(t.TempDir(), "missing"), "", 0, 0, logrus.New())
+
+		_, err := v.Validate(ctx, &models.ValidateConfigRequest{Content: "filter { }"})
+		assert.ErrorIs(t, err, ErrValidatorUnavailable)
+	})
+}
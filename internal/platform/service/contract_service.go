@@ -0,0 +1,303 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+var (
+	// elasticsearch/opensearch 输出的 index 参数
+	esIndexPattern = regexp.MustCompile(`(?s)(elasticsearch|opensearch)\s*\{[^}]*?\bindex\s*=>\s*("[^"]*"|'[^']*')`)
+	// kafka 输出的 topic_id 参数
+	kafkaTopicPattern = regexp.MustCompile(`(?s)kafka\s*\{[^}]*?\btopic_id\s*=>\s*("[^"]*"|'[^']*')`)
+	// sprintf 引用，如 %{+YYYY.MM.dd}、%{[service]}
+	sprintfPattern = regexp.MustCompile(`%\{[^}]*\}`)
+)
+
+// ContractService 字段契约服务接口
+type ContractService interface {
+	CreateContract(ctx context.Context, req *models.CreateFieldContractRequest, userID string) (*models.FieldContract, error)
+	UpdateContract(ctx context.Context, name string, req *models.UpdateFieldContractRequest, userID string) (*models.FieldContract, error)
+	DeleteContract(ctx context.Context, name string) error
+	GetContract(ctx context.Context, name string) (*models.FieldContract, error)
+	ListContracts(ctx context.Context) ([]*models.FieldContract, error)
+	// Verify 用管道输出事件验证写入目标上登记的契约，targets为空时从配置的输出插件识别
+	Verify(ctx context.Context, config *models.Config, targets []string, outputs []map[string]interface{}) (*models.ContractReport, error)
+}
+
+// contractService 字段契约服务实现
+type contractService struct {
+	contractRepo repository.ContractRepository
+	logger       *logrus.Logger
+}
+
+// NewContractService 创建字段契约服务
+func NewContractService(contractRepo repository.ContractRepository, logger *logrus.Logger) ContractService {
+	return &contractService{
+		contractRepo: contractRepo,
+		logger:       logger,
+	}
+}
+
+// CreateContract 登记字段契约
+func (s *contractService) CreateContract(ctx context.Context, req *models.CreateFieldContractRequest, userID string) (*models.FieldContract, error) {
+	if err := validateContract(req.Target, req.Fields); err != nil {
+		return nil, fmt.Errorf("字段契约验证失败: %w", err)
+	}
+
+	if _, err := s.contractRepo.GetByName(ctx, req.Name); err == nil {
+		return nil, fmt.Errorf("字段契约已存在: %s", req.Name)
+	}
+
+	contract := &models.FieldContract{
+		Name:        req.Name,
+		Consumer:    req.Consumer,
+		Description: req.Description,
+		Target:      req.Target,
+		Fields:      req.Fields,
+		CreatedBy:   userID,
+		UpdatedBy:   userID,
+	}
+
+	if err := s.contractRepo.Create(ctx, contract); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"contract": contract.Name,
+		"target":   contract.Target,
+		"user_id":  userID,
+	}).Info("登记字段契约成功")
+
+	return contract, nil
+}
+
+// UpdateContract 更新字段契约
+func (s *contractService) UpdateContract(ctx context.Context, name string, req *models.UpdateFieldContractRequest, userID string) (*models.FieldContract, error) {
+	contract, err := s.contractRepo.GetByName(elasticsearch.WithPrimaryRead(ctx), name)
+	if err != nil {
+		return nil, fmt.Errorf("字段契约不存在: %w", err)
+	}
+
+	if err := validateContract(req.Target, req.Fields); err != nil {
+		return nil, fmt.Errorf("字段契约验证失败: %w", err)
+	}
+
+	contract.Consumer = req.Consumer
+	contract.Description = req.Description
+	contract.Target = req.Target
+	contract.Fields = req.Fields
+	contract.UpdatedBy = userID
+
+	if err := s.contractRepo.Update(ctx, contract); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"contract": contract.Name,
+		"user_id":  userID,
+	}).Info("更新字段契约成功")
+
+	return contract, nil
+}
+
+// DeleteContract 删除字段契约
+func (s *contractService) DeleteContract(ctx context.Context, name string) error {
+	if _, err := s.contractRepo.GetByName(ctx, name); err != nil {
+		return fmt.Errorf("字段契约不存在: %w", err)
+	}
+
+	if err := s.contractRepo.Delete(ctx, name); err != nil {
+		return err
+	}
+
+	s.logger.WithField("contract", name).Info("删除字段契约成功")
+	return nil
+}
+
+// GetContract 获取字段契约
+func (s *contractService) GetContract(ctx context.Context, name string) (*models.FieldContract, error) {
+	return s.contractRepo.GetByName(ctx, name)
+}
+
+// ListContracts 获取全部字段契约
+func (s *contractService) ListContracts(ctx context.Context) ([]*models.FieldContract, error) {
+	return s.contractRepo.List(ctx)
+}
+
+// Verify 用管道输出事件验证写入目标上登记的契约
+// 契约要求的字段必须出现在每个输出事件中，且类型与契约一致
+func (s *contractService) Verify(ctx context.Context, config *models.Config, targets []string, outputs []map[string]interface{}) (*models.ContractReport, error) {
+	if len(targets) == 0 {
+		targets = ExtractOutputTargets(config.Content)
+	}
+
+	report := &models.ContractReport{
+		Targets:    nonNilStrings(targets),
+		Contracts:  []string{},
+		Violations: []models.ContractViolation{},
+	}
+	if len(targets) == 0 || len(outputs) == 0 {
+		return report, nil
+	}
+
+	contracts, err := s.contractRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, contract := range contracts {
+		if !contractApplies(contract.Target, targets) {
+			continue
+		}
+		report.Contracts = append(report.Contracts, contract.Name)
+		report.Violations = append(report.Violations, checkContract(contract, outputs)...)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"config_id":  config.ID,
+		"targets":    targets,
+		"contracts":  len(report.Contracts),
+		"violations": len(report.Violations),
+	}).Debug("完成字段契约验证")
+
+	return report, nil
+}
+
+// checkContract 逐个输出事件检查契约字段
+func checkContract(contract *models.FieldContract, outputs []map[string]interface{}) []models.ContractViolation {
+	var violations []models.ContractViolation
+	for _, field := range contract.Fields {
+		missing := models.ContractViolation{
+			Contract: contract.Name, Consumer: contract.Consumer, Field: field.Path, Reason: models.ContractViolationMissing,
+		}
+		mismatch := models.ContractViolation{
+			Contract: contract.Name, Consumer: contract.Consumer, Field: field.Path, Reason: models.ContractViolationTypeMismatch, Expected: field.Type,
+		}
+
+		for _, output := range outputs {
+			value, ok := lookupField(output, field.Path)
+			if !ok {
+				missing.Samples++
+				continue
+			}
+			if actual, ok := matchesFieldType(field.Type, value); !ok {
+				if mismatch.Samples == 0 {
+					mismatch.Actual = actual
+				}
+				mismatch.Samples++
+			}
+		}
+
+		if missing.Samples > 0 {
+			violations = append(violations, missing)
+		}
+		if mismatch.Samples > 0 {
+			violations = append(violations, mismatch)
+		}
+	}
+	return violations
+}
+
+// lookupField 按点号路径读取字段，路径不存在时再按扁平的字段名查找
+func lookupField(event map[string]interface{}, fieldPath string) (interface{}, bool) {
+	var current interface{} = event
+	for _, part := range strings.Split(fieldPath, ".") {
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			current = nil
+			break
+		}
+		if current, ok = obj[part]; !ok {
+			current = nil
+			break
+		}
+	}
+	if current == nil {
+		value, ok := event[fieldPath]
+		return value, ok && value != nil
+	}
+	return current, true
+}
+
+// matchesFieldType 判断取值是否满足契约类型，返回推断出的实际类型
+// 字符串类字段（keyword/text）接受任意字符串，double接受整数，date接受RFC3339字符串和毫秒时间戳
+func matchesFieldType(expected string, value interface{}) (string, bool) {
+	actual := inferValueType(value)
+	if _, ok := value.(map[string]interface{}); ok {
+		actual = models.FieldTypeObject
+	}
+	if expected == "" {
+		return actual, true
+	}
+
+	switch expected {
+	case models.FieldTypeKeyword, models.FieldTypeText:
+		return actual, actual == models.FieldTypeKeyword || actual == models.FieldTypeDate || actual == models.FieldTypeIP
+	case models.FieldTypeDouble:
+		return actual, actual == models.FieldTypeDouble || actual == models.FieldTypeLong
+	case models.FieldTypeDate:
+		return actual, actual == models.FieldTypeDate || actual == models.FieldTypeLong
+	default:
+		return actual, actual == expected
+	}
+}
+
+// contractApplies 判断契约的目标是否匹配管道写入的任一目标
+// 目标中的sprintf引用（如按日期滚动的索引）视为任意内容
+func contractApplies(pattern string, targets []string) bool {
+	for _, target := range targets {
+		concrete := sprintfPattern.ReplaceAllString(target, "x")
+		if pattern == target {
+			return true
+		}
+		if ok, err := path.Match(pattern, concrete); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// validateContract 校验契约目标和字段
+func validateContract(target string, fields []models.ContractField) error {
+	if _, err := path.Match(target, ""); err != nil {
+		return fmt.Errorf("目标模式无效: %s", target)
+	}
+	seen := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if strings.HasPrefix(field.Path, ".") || strings.HasSuffix(field.Path, ".") || strings.Contains(field.Path, "..") {
+			return fmt.Errorf("字段路径无效: %s", field.Path)
+		}
+		if seen[field.Path] {
+			return fmt.Errorf("字段重复: %s", field.Path)
+		}
+		seen[field.Path] = true
+	}
+	return nil
+}
+
+// ExtractOutputTargets 从配置内容中识别输出写入的索引和Topic，按名称排序去重
+func ExtractOutputTargets(content string) []string {
+	seen := make(map[string]bool)
+	var targets []string
+	for _, pattern := range []*regexp.Regexp{esIndexPattern, kafkaTopicPattern} {
+		for _, m := range pattern.FindAllStringSubmatch(content, -1) {
+			target := strings.Trim(m[len(m)-1], `"'`)
+			if target != "" && !seen[target] {
+				seen[target] = true
+				targets = append(targets, target)
+			}
+		}
+	}
+	sort.Strings(targets)
+	return targets
+}
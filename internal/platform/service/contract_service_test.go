@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+)
+
+// memContractRepository 内存字段契约仓库
+type memContractRepository struct {
+	mu        sync.Mutex
+	contracts map[string]*models.FieldContract
+}
+
+func (r *memContractRepository) Create(ctx context.Context, contract *models.FieldContract) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *contract
+	r.contracts[contract.Name] = &copied
+	return nil
+}
+
+func (r *memContractRepository) Update(ctx context.Context, contract *models.FieldContract) error {
+	return r.Create(ctx, contract)
+}
+
+func (r *memContractRepository) Delete(ctx context.Context, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.contracts, name)
+	return nil
+}
+
+func (r *memContractRepository) GetByName(ctx context.Context, name string) (*models.FieldContract, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	contract, ok := r.contracts[name]
+	if !ok {
+		return nil, fmt.Errorf("文档不存在")
+	}
+	copied := *contract
+	return &copied, nil
+}
+
+func (r *memContractRepository) List(ctx context.Context) ([]*models.FieldContract, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	contracts := make([]*models.FieldContract, 0, len(r.contracts))
+	for _, contract := range r.contracts {
+		copied := *contract
+		contracts = append(contracts, &copied)
+	}
+	sort.Slice(contracts, func(i, j int) bool { return contracts[i].Name < contracts[j].Name })
+	return contracts, nil
+}
+
+func TestContractService_Verify(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	ctx := context.Background()
+
+	repo := &memContractRepository{contracts: make(map[string]*models.FieldContract)}
+	s := NewContractService(repo, logger)
+
+	_, err := s.CreateContract(ctx, &models.CreateFieldContractRequest{
+		Name:     "nginx-dashboard",
+		Consumer: "Nginx访问量仪表盘",
+		Target:   "logs-nginx-*",
+		Fields: []models.ContractField{
+			{Path: "status", Type: models.FieldTypeLong},
+			{Path: "response_time", Type: models.FieldTypeDouble},
+			{Path: "client.ip", Type: models.FieldTypeIP},
+		},
+	}, "alice")
+	require.NoError(t, err)
+	_, err = s.CreateContract(ctx, &models.CreateFieldContractRequest{
+		Name:     "audit-alert",
+		Consumer: "审计告警",
+		Target:   "audit",
+		Fields:   []models.ContractField{{Path: "user"}},
+	}, "alice")
+	require.NoError(t, err)
+
+	config := &models.Config{
+		ID:      "cfg-1",
+		Type:    models.ConfigTypeOutput,
+		Content: `output { elasticsearch { hosts => ["es:9200"] index => "logs-nginx-%{+YYYY.MM.dd}" } }`,
+	}
+
+	t.Run("满足契约", func(t *testing.T) {
+		report, err := s.Verify(ctx, config, nil, []map[string]interface{}{
+			{"status": float64(200), "response_time": float64(12), "client": map[string]interface{}{"ip": "10.0.0.1"}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"logs-nginx-%{+YYYY.MM.dd}"}, report.Targets)
+		assert.Equal(t, []string{"nginx-dashboard"}, report.Contracts)
+		assert.Empty(t, report.Violations)
+	})
+
+	t.Run("缺少字段和类型不一致", func(t *testing.T) {
+		report, err := s.Verify(ctx, config, nil, []map[string]interface{}{
+			{"status": "OK", "response_time": 0.5, "client.ip": "10.0.0.1"},
+			{"status": float64(500), "response_time": 0.7},
+		})
+		require.NoError(t, err)
+		require.Len(t, report.Violations, 2)
+
+		assert.Equal(t, models.ContractViolation{
+			Contract: "nginx-dashboard", Consumer: "Nginx访问量仪表盘", Field: "status",
+			Reason: models.ContractViolationTypeMismatch, Expected: models.FieldTypeLong, Actual: models.FieldTypeKeyword, Samples: 1,
+		}, report.Violations[0])
+		assert.Equal(t, models.ContractViolation{
+			Contract: "nginx-dashboard", Consumer: "Nginx访问量仪表盘", Field: "client.ip",
+			Reason: models.ContractViolationMissing, Samples: 1,
+		}, report.Violations[1])
+	})
+
+	t.Run("显式指定目标", func(t *testing.T) {
+		report, err := s.Verify(ctx, config, []string{"audit"}, []map[string]interface{}{{"message": "login"}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"audit-alert"}, report.Contracts)
+		require.Len(t, report.Violations, 1)
+		assert.Equal(t, "user", report.Violations[0].Field)
+	})
+
+	t.Run("没有匹配的契约", func(t *testing.T) {
+		report, err := s.Verify(ctx, config, []string{"metrics"}, []map[string]interface{}{{"message": "login"}})
+		require.NoError(t, err)
+		assert.Empty(t, report.Contracts)
+		assert.Empty(t, report.Violations)
+	})
+}
+
+func TestContractService_CreateContract(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	ctx := context.Background()
+
+	repo := &memContractRepository{contracts: make(map[string]*models.FieldContract)}
+	s := NewContractService(repo, logger)
+
+	req := &models.CreateFieldContractRequest{
+		Name: "billing", Consumer: "计费报表", Target: "billing-*",
+		Fields: []models.ContractField{{Path: "amount", Type: models.FieldTypeDouble}},
+	}
+	_, err := s.CreateContract(ctx, req, "alice")
+	require.NoError(t, err)
+
+	_, err = s.CreateContract(ctx, req, "alice")
+	assert.EqualError(t, err, "字段契约已存在: billing")
+
+	_, err = s.CreateContract(ctx, &models.CreateFieldContractRequest{
+		Name: "broken", Consumer: "计费报表", Target: "billing-*",
+		Fields: []models.ContractField{{Path: "amount"}, {Path: "amount"}},
+	}, "alice")
+	assert.EqualError(t, err, "字段契约验证失败: 字段重复: amount")
+
+	_, err = s.CreateContract(ctx, &models.CreateFieldContractRequest{
+		Name: "broken", Consumer: "计费报表", Target: "billing-[",
+		Fields: []models.ContractField{{Path: "amount"}},
+	}, "alice")
+	assert.EqualError(t, err, "字段契约验证失败: 目标模式无效: billing-[")
+}
+
+func TestExtractOutputTargets(t *testing.T) {
+	content := `output {
+  if [type] == "audit" {
+    kafka { bootstrap_servers => "kafka:9092" topic_id => 'audit' }
+  }
+  elasticsearch { hosts => ["es:9200"] index => "logs-app-%{+YYYY.MM.dd}" }
+  opensearch { index => "logs-app-%{+YYYY.MM.dd}" }
+}`
+	assert.Equal(t, []string{"audit", "logs-app-%{+YYYY.MM.dd}"}, ExtractOutputTargets(content))
+	assert.Empty(t, ExtractOutputTargets(`output { stdout {} }`))
+}
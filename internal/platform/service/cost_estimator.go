@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+)
+
+// defaultAvgEventBytes 基准测试未给出事件大小时使用的平均事件大小
+const defaultAvgEventBytes = 1024
+
+// CostEstimateConfig 部署前资源估算参数
+type CostEstimateConfig struct {
+	UtilizationTarget float64       // 检查余量时允许的资源利用率上限，默认0.8
+	MetricsMaxAge     time.Duration // 超过该时间的指标视为过期，不用于检查余量
+	QueueBuffer       time.Duration // 持久化队列默认需缓冲的下游不可用时长
+}
+
+// CostEstimator 部署前资源估算服务接口
+type CostEstimator interface {
+	Estimate(ctx context.Context, configID string, req *models.CostEstimateRequest) (*models.CostEstimate, error)
+}
+
+// costEstimator 部署前资源估算服务实现
+// 按基准测试结果线性外推每个Agent所需的CPU、堆内存和队列磁盘，并与目标Agent近期上报的指标比较
+type costEstimator struct {
+	cfg        CostEstimateConfig
+	configRepo repository.ConfigRepository
+	agentRepo  repository.AgentRepository
+	agents     AgentService
+	logger     *logrus.Logger
+	now        func() time.Time
+}
+
+// NewCostEstimator 创建部署前资源估算服务
+func NewCostEstimator(cfg CostEstimateConfig, configRepo repository.ConfigRepository, agentRepo repository.AgentRepository, agents AgentService, logger *logrus.Logger) CostEstimator {
+	if cfg.UtilizationTarget <= 0 || cfg.UtilizationTarget > 1 {
+		cfg.UtilizationTarget = 0.8
+	}
+	if cfg.MetricsMaxAge <= 0 {
+		cfg.MetricsMaxAge = 15 * time.Minute
+	}
+	if cfg.QueueBuffer <= 0 {
+		cfg.QueueBuffer = 5 * time.Minute
+	}
+	return &costEstimator{
+		cfg:        cfg,
+		configRepo: configRepo,
+		agentRepo:  agentRepo,
+		agents:     agents,
+		logger:     logger,
+		now:        time.Now,
+	}
+}
+
+// Estimate 估算配置在目标Agent上运行所需的资源
+func (e *costEstimator) Estimate(ctx context.Context, configID string, req *models.CostEstimateRequest) (*models.CostEstimate, error) {
+	config, err := e.configRepo.GetByID(ctx, configID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConfigNotFound, err)
+	}
+
+	targets, err := e.resolveTargets(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	estimate := &models.CostEstimate{
+		ConfigID:          config.ID,
+		ConfigVersion:     config.Version,
+		UtilizationTarget: e.cfg.UtilizationTarget,
+		PerAgent:          e.project(req, len(targets)),
+		Agents:            make([]models.AgentHeadroom, 0, len(targets)),
+	}
+
+	now := e.now()
+	for _, agent := range targets {
+		headroom := e.headroom(agent, estimate.PerAgent, now)
+		for _, w := range headroom.Warnings {
+			estimate.Warnings = append(estimate.Warnings, fmt.Sprintf("%s: %s", agent.AgentID, w))
+		}
+		estimate.Agents = append(estimate.Agents, headroom)
+	}
+
+	e.logger.WithFields(logrus.Fields{
+		"config_id": config.ID,
+		"agents":    len(targets),
+		"warnings":  len(estimate.Warnings),
+	}).Debug("完成部署前资源估算")
+
+	return estimate, nil
+}
+
+// project 外推单个Agent所需的资源
+// CPU按速率线性缩放；堆内存主要由批大小和worker数决定，低于压测速率时不缩小；
+// 队列磁盘按下游不可用期间需要缓冲的事件量计算
+func (e *costEstimator) project(req *models.CostEstimateRequest, agents int) models.ResourceProjection {
+	bench := req.Benchmark
+	rate := req.ExpectedRate
+	if agents > 1 {
+		rate /= float64(agents)
+	}
+	ratio := rate / bench.EventsPerSecond
+
+	eventBytes := bench.AvgEventBytes
+	if eventBytes <= 0 {
+		eventBytes = defaultAvgEventBytes
+	}
+	buffer := e.cfg.QueueBuffer
+	if req.QueueBufferSeconds > 0 {
+		buffer = time.Duration(req.QueueBufferSeconds) * time.Second
+	}
+
+	return models.ResourceProjection{
+		EventsPerSecond: round2(rate),
+		CPUCores:        round2(bench.CPUCores * ratio),
+		HeapMB:          round2(bench.HeapMB * math.Max(1, ratio)),
+		QueueDiskMB:     round2(rate * float64(eventBytes) * buffer.Seconds() / (1 << 20)),
+	}
+}
+
+// headroom 按Agent近期指标检查可用资源是否足够
+// 可用资源为利用率上限与当前利用率之差，当前利用率已包含Agent上正在运行的管道
+func (e *costEstimator) headroom(agent *models.Agent, need models.ResourceProjection, now time.Time) models.AgentHeadroom {
+	result := models.AgentHeadroom{AgentID: agent.AgentID, Hostname: agent.Hostname}
+
+	metrics := agent.Metrics
+	if metrics == nil {
+		result.Warnings = []string{"Agent尚未上报指标，无法检查余量"}
+		return result
+	}
+	at := metrics.Timestamp
+	result.MetricsAt = &at
+	if now.Sub(at) > e.cfg.MetricsMaxAge {
+		result.Warnings = []string{fmt.Sprintf("最近的指标已过期（%s前上报），无法检查余量", now.Sub(at).Round(time.Second))}
+		return result
+	}
+	if metrics.CPUCores <= 0 || metrics.MemoryTotalMB <= 0 || metrics.DiskTotalMB <= 0 {
+		result.Warnings = []string{"Agent上报的指标缺少主机容量，请升级Agent"}
+		return result
+	}
+
+	target := e.cfg.UtilizationTarget
+	result.Checked = true
+	result.AvailableCPUCores = round2(available(float64(metrics.CPUCores), metrics.CPUUsage, target))
+	result.AvailableMemoryMB = round2(available(metrics.MemoryTotalMB, metrics.MemoryUsage, target))
+	result.AvailableDiskMB = round2(available(metrics.DiskTotalMB, metrics.DiskUsage, target))
+
+	if need.CPUCores > result.AvailableCPUCores {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("CPU余量不足：需要%.2f核，可用%.2f核", need.CPUCores, result.AvailableCPUCores))
+	}
+	if need.HeapMB > result.AvailableMemoryMB {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("内存余量不足：需要%.0fMB，可用%.0fMB", need.HeapMB, result.AvailableMemoryMB))
+	}
+	if need.QueueDiskMB > result.AvailableDiskMB {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("磁盘余量不足：队列需要%.0fMB，可用%.0fMB", need.QueueDiskMB, result.AvailableDiskMB))
+	}
+	result.Sufficient = len(result.Warnings) == 0
+	return result
+}
+
+// resolveTargets 合并显式指定的Agent和选择器匹配的Agent，不存在的Agent ID直接报错
+func (e *costEstimator) resolveTargets(ctx context.Context, req *models.CostEstimateRequest) ([]*models.Agent, error) {
+	targets := make([]*models.Agent, 0, len(req.AgentIDs))
+	seen := make(map[string]bool)
+	for _, id := range req.AgentIDs {
+		if id == "" || seen[id] {
+			continue
+		}
+		agent, err := e.agentRepo.GetByID(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("Agent不存在: %s", id)
+		}
+		seen[id] = true
+		targets = append(targets, agent)
+	}
+
+	if req.Selector != "" {
+		agents, err := e.agents.SelectAgents(ctx, req.Selector)
+		if err != nil {
+			return nil, err
+		}
+		for _, agent := range agents {
+			if !seen[agent.AgentID] {
+				seen[agent.AgentID] = true
+				targets = append(targets, agent)
+			}
+		}
+	}
+
+	return targets, nil
+}
+
+// available 在利用率上限内剩余的容量
+func available(capacity, usedPercent, target float64) float64 {
+	return math.Max(0, capacity*(target-usedPercent/100))
+}
+
+// round2 保留两位小数
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/tests/mocks"
+)
+
+func newTestCostEstimator(t *testing.T, now time.Time) (*costEstimator, *mocks.MockConfigRepository, *mocks.MockAgentRepository) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	configRepo := new(mocks.MockConfigRepository)
+	agentRepo := new(mocks.MockAgentRepository)
+	agents := NewAgentService(agentRepo, configRepo, NewCommandQueue(0), logger)
+
+	e := NewCostEstimator(CostEstimateConfig{}, configRepo, agentRepo, agents, logger).(*costEstimator)
+	e.now = func() time.Time { return now }
+	return e, configRepo, agentRepo
+}
+
+func TestCostEstimator_Estimate(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	e, configRepo, agentRepo := newTestCostEstimator(t, now)
+
+	configRepo.On("GetByID", ctx, "cfg-1").Return(&models.Config{ID: "cfg-1", Version: 3}, nil)
+	configRepo.On("GetByID", ctx, "missing").Return(nil, fmt.Errorf("文档不存在"))
+
+	// 8核16G主机，CPU已用50%：利用率上限80%时剩余2.4核
+	roomy := &models.Agent{AgentID: "agent-1", Hostname: "host-1", Metrics: &models.AgentMetrics{
+		Timestamp: now.Add(-time.Minute), CPUCores: 8, CPUUsage: 50,
+		MemoryTotalMB: 16384, MemoryUsage: 40, DiskTotalMB: 102400, DiskUsage: 30,
+	}}
+	// CPU已用75%：剩余0.4核
+	busy := &models.Agent{AgentID: "agent-2", Metrics: &models.AgentMetrics{
+		Timestamp: now.Add(-time.Minute), CPUCores: 8, CPUUsage: 75,
+		MemoryTotalMB: 16384, MemoryUsage: 40, DiskTotalMB: 102400, DiskUsage: 30,
+	}}
+	stale := &models.Agent{AgentID: "agent-3", Metrics: &models.AgentMetrics{Timestamp: now.Add(-time.Hour), CPUCores: 8}}
+	agentRepo.On("GetByID", ctx, "agent-1").Return(roomy, nil)
+	agentRepo.On("GetByID", ctx, "agent-2").Return(busy, nil)
+	agentRepo.On("GetByID", ctx, "agent-3").Return(stale, nil)
+	agentRepo.On("GetByID", ctx, "agent-4").Return(&models.Agent{AgentID: "agent-4"}, nil)
+	agentRepo.On("GetByID", ctx, "unknown").Return(nil, fmt.Errorf("文档不存在"))
+
+	// 压测：单Agent 10000条/秒消耗2核、1024MB堆
+	bench := models.PipelineBenchmark{EventsPerSecond: 10000, CPUCores: 2, HeapMB: 1024, AvgEventBytes: 512}
+
+	t.Run("单个Agent", func(t *testing.T) {
+		estimate, err := e.Estimate(ctx, "cfg-1", &models.CostEstimateRequest{
+			Benchmark: bench, ExpectedRate: 5000, AgentIDs: []string{"agent-1"}, QueueBufferSeconds: 60,
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, 3, estimate.ConfigVersion)
+		assert.Equal(t, 5000.0, estimate.PerAgent.EventsPerSecond)
+		assert.Equal(t, 1.0, estimate.PerAgent.CPUCores)
+		assert.Equal(t, 1024.0, estimate.PerAgent.HeapMB) // 低于压测速率时堆内存不缩小
+		assert.Equal(t, 146.48, estimate.PerAgent.QueueDiskMB)
+
+		require.Len(t, estimate.Agents, 1)
+		headroom := estimate.Agents[0]
+		assert.True(t, headroom.Checked)
+		assert.True(t, headroom.Sufficient)
+		assert.Equal(t, 2.4, headroom.AvailableCPUCores)
+		assert.Empty(t, estimate.Warnings)
+	})
+
+	t.Run("速率分摊到多个Agent", func(t *testing.T) {
+		estimate, err := e.Estimate(ctx, "cfg-1", &models.CostEstimateRequest{
+			Benchmark: bench, ExpectedRate: 30000, AgentIDs: []string{"agent-1", "agent-2", "agent-1"},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, 15000.0, estimate.PerAgent.EventsPerSecond)
+		assert.Equal(t, 3.0, estimate.PerAgent.CPUCores)
+		assert.Equal(t, 1536.0, estimate.PerAgent.HeapMB)
+
+		require.Len(t, estimate.Agents, 2)
+		assert.False(t, estimate.Agents[0].Sufficient)
+		assert.False(t, estimate.Agents[1].Sufficient)
+		assert.Equal(t, []string{
+			"agent-1: CPU余量不足：需要3.00核，可用2.40核",
+			"agent-2: CPU余量不足：需要3.00核，可用0.40核",
+		}, estimate.Warnings)
+	})
+
+	t.Run("缺少近期指标", func(t *testing.T) {
+		estimate, err := e.Estimate(ctx, "cfg-1", &models.CostEstimateRequest{
+			Benchmark: bench, ExpectedRate: 1000, AgentIDs: []string{"agent-3", "agent-4"},
+		})
+		require.NoError(t, err)
+
+		require.Len(t, estimate.Agents, 2)
+		assert.False(t, estimate.Agents[0].Checked)
+		assert.Contains(t, estimate.Agents[0].Warnings[0], "已过期")
+		assert.False(t, estimate.Agents[1].Checked)
+		assert.Equal(t, []string{"Agent尚未上报指标，无法检查余量"}, estimate.Agents[1].Warnings)
+	})
+
+	t.Run("不指定目标Agent", func(t *testing.T) {
+		estimate, err := e.Estimate(ctx, "cfg-1", &models.CostEstimateRequest{Benchmark: bench, ExpectedRate: 20000})
+		require.NoError(t, err)
+		assert.Equal(t, 4.0, estimate.PerAgent.CPUCores)
+		assert.Empty(t, estimate.Agents)
+	})
+
+	t.Run("配置或Agent不存在", func(t *testing.T) {
+		_, err := e.Estimate(ctx, "missing", &models.CostEstimateRequest{Benchmark: bench, ExpectedRate: 1000})
+		assert.ErrorIs(t, err, ErrConfigNotFound)
+
+		_, err = e.Estimate(ctx, "cfg-1", &models.CostEstimateRequest{Benchmark: bench, ExpectedRate: 1000, AgentIDs: []string{"unknown"}})
+		assert.ErrorContains(t, err, "Agent不存在")
+	})
+
+	agentRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+)
+
+// defaultReportWindow 报表默认统计窗口
+const defaultReportWindow = 30 * 24 * time.Hour
+
+// unassignedTeam 未标注团队的配置归入的分组
+const unassignedTeam = "unassigned"
+
+// DeliveryMetricsService 变更交付指标服务接口
+type DeliveryMetricsService interface {
+	Report(ctx context.Context, req *models.DeliveryReportRequest) (*models.DeliveryReport, error)
+}
+
+// deliveryMetricsService 变更交付指标服务实现
+// 平台上的每次部署都视为一次生产变更
+type deliveryMetricsService struct {
+	deployRepo repository.DeploymentRepository
+	configRepo repository.ConfigRepository
+	logger     *logrus.Logger
+}
+
+// NewDeliveryMetricsService 创建变更交付指标服务
+func NewDeliveryMetricsService(deployRepo repository.DeploymentRepository, configRepo repository.ConfigRepository, logger *logrus.Logger) DeliveryMetricsService {
+	return &deliveryMetricsService{
+		deployRepo: deployRepo,
+		configRepo: configRepo,
+		logger:     logger,
+	}
+}
+
+// configInfo 统计过程中缓存的配置信息
+type configInfo struct {
+	team         string
+	versionTimes map[int]time.Time // 版本号 -> 该版本产生时间
+}
+
+// Report 生成变更交付指标报表
+func (s *deliveryMetricsService) Report(ctx context.Context, req *models.DeliveryReportRequest) (*models.DeliveryReport, error) {
+	until := req.Until
+	if until.IsZero() {
+		until = time.Now()
+	}
+	since := req.Since
+	if since.IsZero() || !since.Before(until) {
+		since = until.Add(-defaultReportWindow)
+	}
+
+	deployments, err := s.listDeployments(ctx, since, until)
+	if err != nil {
+		return nil, err
+	}
+
+	configs := make(map[string]*configInfo)
+	byTeam := make(map[string][]*models.Deployment)
+	var all []*models.Deployment
+	for _, d := range deployments {
+		if !d.IsFinished() {
+			continue
+		}
+		team := d.Team
+		if team == "" {
+			team = s.lookupConfig(ctx, configs, d.ConfigID).team
+		}
+		if req.Team != "" && team != req.Team {
+			continue
+		}
+		byTeam[team] = append(byTeam[team], d)
+		all = append(all, d)
+	}
+
+	days := until.Sub(since).Hours() / 24
+	report := &models.DeliveryReport{
+		Since:   since,
+		Until:   until,
+		Overall: s.compute(ctx, configs, all, days),
+		Teams:   make([]models.DeliveryMetrics, 0, len(byTeam)),
+	}
+	for _, team := range sortedKeys(byTeam) {
+		metrics := s.compute(ctx, configs, byTeam[team], days)
+		metrics.Team = team
+		report.Teams = append(report.Teams, metrics)
+	}
+
+	return report, nil
+}
+
+// compute 计算一组已结束部署的指标
+func (s *deliveryMetricsService) compute(ctx context.Context, configs map[string]*configInfo, deployments []*models.Deployment, days float64) models.DeliveryMetrics {
+	metrics := models.DeliveryMetrics{Deployments: len(deployments)}
+	if len(deployments) == 0 {
+		return metrics
+	}
+
+	sorted := make([]*models.Deployment, len(deployments))
+	copy(sorted, deployments)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].CreatedAt.Before(sorted[j].CreatedAt) })
+
+	var leadTimes []float64
+	var recoveryTotal float64
+	for i, d := range sorted {
+		if d.Status == models.DeploymentStatusCompleted {
+			metrics.SuccessfulDeployments++
+			info := s.lookupConfig(ctx, configs, d.ConfigID)
+			if changedAt, ok := info.versionTimes[d.ConfigVersion]; ok && d.CompletedAt != nil && d.CompletedAt.After(changedAt) {
+				leadTimes = append(leadTimes, d.CompletedAt.Sub(changedAt).Seconds())
+			}
+			continue
+		}
+
+		// 失败或回滚：寻找同一配置之后的首次成功部署作为恢复时间点
+		metrics.FailedDeployments++
+		failedAt := finishedAt(d)
+		for _, next := range sorted[i+1:] {
+			if next.ConfigID == d.ConfigID && next.Status == models.DeploymentStatusCompleted {
+				recoveryTotal += finishedAt(next).Sub(failedAt).Seconds()
+				metrics.Recoveries++
+				break
+			}
+		}
+	}
+
+	if days > 0 {
+		metrics.DeploymentsPerDay = float64(metrics.SuccessfulDeployments) / days
+	}
+	metrics.LeadTimeSeconds = median(leadTimes)
+	metrics.ChangeFailureRate = float64(metrics.FailedDeployments) / float64(metrics.Deployments)
+	if metrics.Recoveries > 0 {
+		metrics.MTTRSeconds = recoveryTotal / float64(metrics.Recoveries)
+	}
+
+	return metrics
+}
+
+// listDeployments 分页获取时间窗口内请求项目的全部部署记录
+func (s *deliveryMetricsService) listDeployments(ctx context.Context, since, until time.Time) ([]*models.Deployment, error) {
+	var deployments []*models.Deployment
+	for page := 1; ; page++ {
+		items, total, err := s.deployRepo.List(ctx, &models.DeploymentListRequest{
+			Project:  models.ProjectFrom(ctx),
+			Since:    since,
+			Until:    until,
+			Page:     page,
+			PageSize: 100,
+		})
+		if err != nil {
+			return nil, err
+		}
+		deployments = append(deployments, items...)
+		if len(items) == 0 || int64(len(deployments)) >= total {
+			return deployments, nil
+		}
+	}
+}
+
+// lookupConfig 获取配置的团队及各版本产生时间，配置已删除时归入未分配团队
+func (s *deliveryMetricsService) lookupConfig(ctx context.Context, cache map[string]*configInfo, configID string) *configInfo {
+	if info, ok := cache[configID]; ok {
+		return info
+	}
+
+	info := &configInfo{team: unassignedTeam, versionTimes: make(map[int]time.Time)}
+	cache[configID] = info
+
+	config, err := s.configRepo.GetByID(ctx, configID)
+	if err != nil {
+		s.logger.WithError(err).WithField("config_id", configID).Debug("统计指标时获取配置失败")
+		return info
+	}
+	if config.Team != "" {
+		info.team = config.Team
+	}
+	info.versionTimes[1] = config.CreatedAt
+
+	history, err := s.configRepo.GetHistory(ctx, configID)
+	if err != nil {
+		s.logger.WithError(err).WithField("config_id", configID).Debug("统计指标时获取配置历史失败")
+		return info
+	}
+	for _, h := range history {
+		info.versionTimes[h.Version] = h.ModifiedAt
+	}
+
+	return info
+}
+
+// finishedAt 部署结束时间，缺失时使用创建时间
+func finishedAt(d *models.Deployment) time.Time {
+	if d.CompletedAt != nil {
+		return *d.CompletedAt
+	}
+	return d.CreatedAt
+}
+
+// median 计算中位数
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/tests/mocks"
+)
+
+func TestDeliveryMetricsService_Report(t *testing.T) {
+	ctx := context.Background()
+	until := time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)
+	since := until.Add(-10 * 24 * time.Hour)
+	at := func(hours int) *time.Time {
+		ts := since.Add(time.Duration(hours) * time.Hour)
+		return &ts
+	}
+
+	deployments := []*models.Deployment{
+		// payments：版本2在第1小时产生，第3小时部署完成
+		{ID: "d1", ConfigID: "cfg-pay", ConfigVersion: 2, Team: "payments", Status: models.DeploymentStatusCompleted, CreatedAt: *at(2), CompletedAt: at(3)},
+		// payments：第10小时回滚，第14小时同一配置恢复
+		{ID: "d2", ConfigID: "cfg-pay", ConfigVersion: 3, Team: "payments", Status: models.DeploymentStatusRolledBack, CreatedAt: *at(9), CompletedAt: at(10)},
+		{ID: "d3", ConfigID: "cfg-pay", ConfigVersion: 4, Team: "payments", Status: models.DeploymentStatusCompleted, CreatedAt: *at(13), CompletedAt: at(14)},
+		// 未记录团队的部署从配置读取
+		{ID: "d4", ConfigID: "cfg-search", ConfigVersion: 1, Status: models.DeploymentStatusCompleted, CreatedAt: *at(20), CompletedAt: at(25)},
+		// 未结束的部署不计入
+		{ID: "d5", ConfigID: "cfg-search", ConfigVersion: 2, Status: models.DeploymentStatusRunning, CreatedAt: *at(30)},
+	}
+
+	deployRepo := new(mocks.MockDeploymentRepository)
+	deployRepo.On("List", ctx, mock.MatchedBy(func(req *models.DeploymentListRequest) bool {
+		return req.Page == 1 && req.Since.Equal(since) && req.Until.Equal(until)
+	})).Return(deployments, int64(len(deployments)), nil)
+
+	configRepo := new(mocks.MockConfigRepository)
+	configRepo.On("GetByID", ctx, "cfg-pay").Return(&models.Config{ID: "cfg-pay", Team: "payments", CreatedAt: *at(0)}, nil)
+	configRepo.On("GetHistory", ctx, "cfg-pay").Return([]*models.ConfigHistory{
+		{Version: 2, ModifiedAt: *at(1)},
+		{Version: 4, ModifiedAt: *at(12)},
+	}, nil)
+	configRepo.On("GetByID", ctx, "cfg-search").Return(&models.Config{ID: "cfg-search", Team: "search", CreatedAt: *at(19)}, nil)
+	configRepo.On("GetHistory", ctx, "cfg-search").Return(nil, errors.New("文档不存在"))
+
+	svc := NewDeliveryMetricsService(deployRepo, configRepo, logrus.New())
+	report, err := svc.Report(ctx, &models.DeliveryReportRequest{Since: since, Until: until})
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, report.Overall.Deployments)
+	assert.Equal(t, 1, report.Overall.FailedDeployments)
+	assert.InDelta(t, 0.25, report.Overall.ChangeFailureRate, 1e-9)
+	assert.InDelta(t, 0.3, report.Overall.DeploymentsPerDay, 1e-9)
+	// 前置时间：2h、2h、6h，中位数2h
+	assert.Equal(t, (2 * time.Hour).Seconds(), report.Overall.LeadTimeSeconds)
+	assert.Equal(t, (4 * time.Hour).Seconds(), report.Overall.MTTRSeconds)
+
+	require.Len(t, report.Teams, 2)
+	assert.Equal(t, "payments", report.Teams[0].Team)
+	assert.Equal(t, 3, report.Teams[0].Deployments)
+	assert.Equal(t, 1, report.Teams[0].Recoveries)
+	assert.Equal(t, "search", report.Teams[1].Team)
+	assert.Equal(t, 0.0, report.Teams[1].ChangeFailureRate)
+
+	filtered, err := svc.Report(ctx, &models.DeliveryReportRequest{Team: "search", Since: since, Until: until})
+	require.NoError(t, err)
+	assert.Equal(t, 1, filtered.Overall.Deployments)
+	assert.Len(t, filtered.Teams, 1)
+}
+
+func TestDeliveryMetricsService_ReportInProject(t *testing.T) {
+	ctx := models.WithProject(context.Background(), "payments")
+	deployRepo := new(mocks.MockDeploymentRepository)
+	// 只统计请求项目的部署记录
+	deployRepo.On("List", ctx, mock.MatchedBy(func(req *models.DeploymentListRequest) bool {
+		return req.Project == "payments"
+	})).Return([]*models.Deployment{}, int64(0), nil)
+
+	svc := NewDeliveryMetricsService(deployRepo, new(mocks.MockConfigRepository), logrus.New())
+	report, err := svc.Report(ctx, &models.DeliveryReportRequest{})
+	require.NoError(t, err)
+	assert.Zero(t, report.Overall.Deployments)
+	deployRepo.AssertExpectations(t)
+}
@@ -0,0 +1,1645 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+	"logstash-platform/internal/platform/tracing"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// defaultAckTimeout Agent上报部署结果的默认超时时间
+const defaultAckTimeout = 5 * time.Minute
+
+// defaultAckRetries Agent重新连接后重新下发部署的默认次数上限
+const defaultAckRetries = 3
+
+// defaultThrottleHold 单个Agent占用下游集群并发名额的默认上限
+const defaultThrottleHold = time.Minute
+
+// recoverPageSize 启动恢复时分页读取未结束部署的页大小
+const recoverPageSize = 100
+
+// 金丝雀部署的默认参数
+const (
+	defaultCanarySoak         = 10 * time.Minute
+	defaultCanaryMaxErrorRate = 0.01
+)
+
+// 分批下发等待Agent恢复健康的默认参数
+const (
+	defaultRolloutHealthTimeout = 5 * time.Minute
+	defaultHealthCheckInterval  = 5 * time.Second
+)
+
+// reloadQueuedMessage Agent重载排队期间部署结果上的说明
+const reloadQueuedMessage = "配置已落盘，Agent重载预算耗尽，重载排队中"
+
+// drainCheckpointMessage 平台关闭时仍未上报结果的Agent在部署结果上的说明
+const drainCheckpointMessage = "平台关闭时仍在等待Agent上报结果，由恢复流程继续跟踪"
+
+// 部署引擎返回的错误，处理器通过 errors.Is 映射为HTTP状态码
+var (
+	ErrConfigNotFound      = errors.New("配置不存在")
+	ErrConfigDisabled      = errors.New("配置已禁用，无法部署")
+	ErrNoTargets           = errors.New("没有匹配的Agent")
+	ErrDeploymentNotFound  = errors.New("部署不存在")
+	ErrNotDeploymentTarget = errors.New("Agent不在部署目标中")
+	ErrInvalidStrategy     = errors.New("部署策略无效")
+	ErrProjectMismatch     = errors.New("Agent与配置不属于同一项目")
+	ErrRollbackNotAllowed  = errors.New("部署无法回滚")
+)
+
+// DeploymentEngine 部署执行引擎
+// 向目标Agent扇出config_deploy消息，按Agent上报的结果跟踪部署进度；
+// canary策略先下发金丝雀Agent，观察期结束后按其健康状态推广到其余Agent或回滚金丝雀；
+// 指定分批参数时逐批下发，失败的Agent超过上限时中止其余批次
+type DeploymentEngine struct {
+	deployRepo repository.DeploymentRepository
+	configRepo repository.ConfigRepository
+	agents     AgentService
+	publisher  MessagePublisher
+	throttle   *DestinationThrottle
+	ackTimeout time.Duration
+	ackRetries int // Agent重新连接后重新下发的次数上限，0表示不重试
+	// throttleHold 下发后最多占用并发名额的时间，Agent上报结果或超过该时间即释放名额
+	throttleHold time.Duration
+	// healthPoll 分批下发等待Agent恢复健康时的检查间隔
+	healthPoll   time.Duration
+	approvals    ApprovalService        // 未设置时不检查审批
+	testGate     *TestGate              // 未设置时不检查配置的测试状态
+	events       AgentEventService      // 未设置时不写入Agent事件时间线
+	usage        ConfigUsageService     // 未设置时不维护已应用配置映射
+	plugins      PluginInventoryService // 未设置时不检查目标Agent的插件
+	emitter      EventEmitter           // 未设置时不发布部署结束事件
+	destinations DestinationService     // 未设置时预览不渲染下游集群引用
+	secrets      SecretService          // 未设置时预览不检查引用的密钥是否存在
+	logger       *logrus.Logger
+
+	mu       sync.Mutex
+	active   map[string]*deploymentTracker
+	removals map[string]chan struct{} // Agent ID:配置ID -> 移除确认通知
+	draining bool                     // 平台正在关闭，不再创建新部署
+	runs     sync.WaitGroup           // 后台执行中的部署
+}
+
+// deploymentTracker 进行中部署的内存状态
+type deploymentTracker struct {
+	mu         sync.Mutex
+	deployment *models.Deployment
+	waiters    map[string]chan struct{} // Agent ID -> 结果到达通知
+	reconnects map[string]chan struct{} // Agent ID -> Agent重新连接通知，缓冲为1
+}
+
+// NewDeploymentEngine 创建部署执行引擎，ackTimeout<=0时使用默认值
+func NewDeploymentEngine(deployRepo repository.DeploymentRepository, configRepo repository.ConfigRepository, agents AgentService,
+	publisher MessagePublisher, throttle *DestinationThrottle, ackTimeout time.Duration, logger *logrus.Logger) *DeploymentEngine {
+	if ackTimeout <= 0 {
+		ackTimeout = defaultAckTimeout
+	}
+	if throttle == nil {
+		throttle = NewDestinationThrottle(0, nil)
+	}
+	return &DeploymentEngine{
+		deployRepo:   deployRepo,
+		configRepo:   configRepo,
+		agents:       agents,
+		publisher:    publisher,
+		throttle:     throttle,
+		ackTimeout:   ackTimeout,
+		ackRetries:   defaultAckRetries,
+		throttleHold: defaultThrottleHold,
+		healthPoll:   defaultHealthCheckInterval,
+		logger:       logger,
+		active:       make(map[string]*deploymentTracker),
+		removals:     make(map[string]chan struct{}),
+	}
+}
+
+// SetThrottleHold 设置单个Agent占用下游集群并发名额的上限，d<=0时使用默认值
+func (e *DeploymentEngine) SetThrottleHold(d time.Duration) {
+	if d <= 0 {
+		d = defaultThrottleHold
+	}
+	e.throttleHold = d
+}
+
+// SetAckRetries 设置Agent重新连接后重新下发部署的次数上限，n<0时使用默认值，0表示不重试
+func (e *DeploymentEngine) SetAckRetries(n int) {
+	if n < 0 {
+		n = defaultAckRetries
+	}
+	e.ackRetries = n
+}
+
+// AgentConnected Agent建立WebSocket连接时调用，通知仍在等待该Agent上报结果的部署重新下发
+// 断线期间推送的消息可能已丢失（例如Agent崩溃重启），重新下发同一版本对Agent是幂等的
+func (e *DeploymentEngine) AgentConnected(agentID string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, tracker := range e.active {
+		if ch, ok := tracker.reconnects[agentID]; ok {
+			select {
+			case ch <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// SetApprovalService 设置审批服务，之后只允许部署已通过审批的配置版本
+func (e *DeploymentEngine) SetApprovalService(approvals ApprovalService) {
+	e.approvals = approvals
+}
+
+// SetTestGate 设置测试门禁，之后只允许部署在有效期内通过测试的配置版本
+func (e *DeploymentEngine) SetTestGate(gate *TestGate) {
+	e.testGate = gate
+}
+
+// SetEventService 设置Agent事件时间线，之后Agent上报的配置应用结果同时写入时间线
+func (e *DeploymentEngine) SetEventService(events AgentEventService) {
+	e.events = events
+}
+
+// SetEventEmitter 设置平台事件的发布方，之后部署结束（含回滚）时发布 config.deployed
+func (e *DeploymentEngine) SetEventEmitter(emitter EventEmitter) {
+	e.emitter = emitter
+}
+
+// SetConfigUsageService 设置配置使用情况服务，之后Agent上报的配置应用结果同时更新已应用配置映射
+func (e *DeploymentEngine) SetConfigUsageService(usage ConfigUsageService) {
+	e.usage = usage
+}
+
+// SetPluginInventory 设置Agent插件清单服务，之后部署前检查目标Agent是否安装了配置引用的全部插件
+func (e *DeploymentEngine) SetPluginInventory(plugins PluginInventoryService) {
+	e.plugins = plugins
+}
+
+// SetDestinationService 设置下游集群服务，之后部署预览按各Agent所在的环境渲染配置
+func (e *DeploymentEngine) SetDestinationService(destinations DestinationService) {
+	e.destinations = destinations
+}
+
+// SetSecretService 设置密钥服务，之后部署预览检查配置引用的密钥是否存在
+func (e *DeploymentEngine) SetSecretService(secrets SecretService) {
+	e.secrets = secrets
+}
+
+// Recover 处理上次运行遗留的未结束部署
+// 内存中的跟踪状态在平台重启后丢失，已超过等待时间的部署直接判定未上报的Agent失败，
+// 其余部署在剩余等待时间后再检查，期间到达的上报由RecordResult直接写入存储
+func (e *DeploymentEngine) Recover(ctx context.Context) error {
+	var stale []*models.Deployment
+	for _, status := range []models.DeploymentStatus{models.DeploymentStatusPending, models.DeploymentStatusRunning} {
+		for page := 1; ; page++ {
+			deployments, total, err := e.deployRepo.List(ctx, &models.DeploymentListRequest{
+				Status:   status,
+				Page:     page,
+				PageSize: recoverPageSize,
+			})
+			if err != nil {
+				return fmt.Errorf("查询未结束部署失败: %w", err)
+			}
+			stale = append(stale, deployments...)
+			if len(deployments) < recoverPageSize || int64(page*recoverPageSize) >= total {
+				break
+			}
+		}
+	}
+
+	for _, deployment := range stale {
+		e.mu.Lock()
+		_, tracked := e.active[deployment.ID]
+		e.mu.Unlock()
+		if tracked {
+			continue
+		}
+
+		// 金丝雀尚未通过观察时无法继续观察，保守起见直接回滚
+		if canary := deployment.Canary; canary != nil && canary.Phase != models.CanaryPhasePromoting {
+			e.rollback(ctx, deployment, "平台重启中断了金丝雀观察")
+			continue
+		}
+
+		since := deployment.CreatedAt
+		if deployment.StartedAt != nil {
+			since = *deployment.StartedAt
+		}
+		remaining := time.Until(since.Add(e.ackTimeout))
+		if remaining <= 0 {
+			e.expire(ctx, deployment.ID)
+			continue
+		}
+
+		deploymentID := deployment.ID
+		time.AfterFunc(remaining, func() {
+			e.expire(context.Background(), deploymentID)
+		})
+	}
+
+	if len(stale) > 0 {
+		e.logger.WithField("count", len(stale)).Info("恢复未结束的部署")
+	}
+	return nil
+}
+
+// expire 将存储中未结束部署的未上报Agent判定为超时并结束部署
+func (e *DeploymentEngine) expire(ctx context.Context, deploymentID string) {
+	deployment, err := e.deployRepo.GetByID(elasticsearch.WithPrimaryRead(ctx), deploymentID)
+	if err != nil {
+		e.logger.WithError(err).WithField("deployment_id", deploymentID).Warn("读取待过期部署失败")
+		return
+	}
+	if deployment.IsFinished() {
+		return
+	}
+
+	for _, r := range deployment.Results {
+		if r.Status != models.DeploymentResultPending {
+			continue
+		}
+		// 分批下发中尚未下发的批次不再继续
+		if deployment.Rollout != nil && r.StartedAt == nil {
+			setResult(deployment, r.AgentID, models.DeploymentResultSkipped, "平台重启中断了分批下发，未下发")
+			deployment.Rollout.Aborted = true
+			deployment.Rollout.Reason = "平台重启中断了分批下发"
+			continue
+		}
+		setResult(deployment, r.AgentID, models.DeploymentResultFailed, "等待Agent上报结果超时（平台重启）")
+	}
+	e.complete(deployment)
+	e.save(ctx, deployment)
+	e.emitFinished(ctx, deployment)
+}
+
+// Start 创建部署记录并在后台开始下发，立即返回部署记录
+func (e *DeploymentEngine) Start(ctx context.Context, req *models.CreateDeploymentRequest, userID string) (*models.Deployment, error) {
+	if len(req.AgentIDs) == 0 && req.Selector == "" {
+		return nil, fmt.Errorf("必须指定Agent ID列表或标签选择器")
+	}
+
+	e.mu.Lock()
+	if e.draining {
+		e.mu.Unlock()
+		return nil, ErrShuttingDown
+	}
+	e.runs.Add(1)
+	e.mu.Unlock()
+	started := false
+	defer func() {
+		if !started {
+			e.runs.Done()
+		}
+	}()
+
+	config, err := e.configRepo.GetByID(ctx, req.ConfigID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConfigNotFound, err)
+	}
+	if err := authorizeConfig(ctx, config, models.PermissionDeploy); err != nil {
+		return nil, err
+	}
+	// 配置ID在Agent上用作文件名，早于ID校验写入的配置不能部署
+	if err := models.ValidateConfigID(config.ID); err != nil {
+		return nil, err
+	}
+	if !config.Enabled {
+		return nil, fmt.Errorf("%w: %s", ErrConfigDisabled, req.ConfigID)
+	}
+	if e.approvals != nil {
+		if err := e.approvals.CheckDeployable(ctx, config); err != nil {
+			return nil, err
+		}
+	}
+	if e.testGate != nil {
+		if err := e.testGate.Check(ctx, config, req.SkipTestGate); err != nil {
+			return nil, err
+		}
+	}
+
+	targets, err := e.resolveTargets(ctx, req, models.ProjectOf(config.Project))
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) == 0 {
+		return nil, ErrNoTargets
+	}
+	if e.plugins != nil {
+		if err := e.plugins.CheckDeployable(ctx, config, targets); err != nil {
+			return nil, err
+		}
+	}
+	canary, err := planCanary(req, targets)
+	if err != nil {
+		return nil, err
+	}
+	rollout, err := planRollout(req, len(rolloutAgents(targets, canary)))
+	if err != nil {
+		return nil, err
+	}
+	if rollout != nil && rollout.Options.WaitForHealthy && e.agents == nil {
+		return nil, fmt.Errorf("%w: 未配置Agent服务，无法等待Agent恢复健康", ErrInvalidStrategy)
+	}
+
+	deployment := &models.Deployment{
+		ConfigID:      config.ID,
+		ConfigName:    config.Name,
+		ConfigVersion: config.Version,
+		Team:          config.Team,
+		Project:       models.ProjectOf(config.Project),
+		AgentIDs:      targets,
+		Strategy:      models.DeploymentStrategyAll,
+		Status:        models.DeploymentStatusPending,
+		Results:       make([]models.DeploymentResult, 0, len(targets)),
+		CreatedBy:     userID,
+	}
+	if e.testGate != nil && req.SkipTestGate {
+		deployment.TestGateSkipped = true
+	}
+	if canary != nil {
+		deployment.Strategy = models.DeploymentStrategyCanary
+		deployment.Canary = canary
+	}
+	deployment.Rollout = rollout
+	for _, agentID := range targets {
+		result := models.DeploymentResult{
+			AgentID: agentID,
+			Status:  models.DeploymentResultPending,
+		}
+		// 记录金丝雀部署前的版本，回滚时恢复
+		if canary != nil && slices.Contains(canary.AgentIDs, agentID) {
+			if result.PreviousVersion, err = e.appliedVersion(ctx, agentID, config.ID); err != nil {
+				return nil, err
+			}
+		}
+		deployment.Results = append(deployment.Results, result)
+	}
+
+	if err := e.deployRepo.Create(ctx, deployment); err != nil {
+		return nil, err
+	}
+
+	tracker := &deploymentTracker{
+		deployment: deployment,
+		waiters:    make(map[string]chan struct{}, len(targets)),
+		reconnects: make(map[string]chan struct{}, len(targets)),
+	}
+	for _, agentID := range targets {
+		tracker.waiters[agentID] = make(chan struct{})
+		tracker.reconnects[agentID] = make(chan struct{}, 1)
+	}
+
+	e.mu.Lock()
+	e.active[deployment.ID] = tracker
+	e.mu.Unlock()
+
+	e.logger.WithFields(logrus.Fields{
+		"deployment_id": deployment.ID,
+		"config_id":     config.ID,
+		"version":       config.Version,
+		"targets":       len(targets),
+		"strategy":      deployment.Strategy,
+		"user_id":       userID,
+	}).Info("创建部署")
+
+	snapshot := *deployment
+	snapshot.Results = append([]models.DeploymentResult(nil), deployment.Results...)
+	if canary != nil {
+		copied := *canary
+		snapshot.Canary = &copied
+	}
+	if rollout != nil {
+		copied := *rollout
+		snapshot.Rollout = &copied
+	}
+	started = true
+	go e.run(tracing.Detach(ctx), tracker, config.Destinations)
+
+	return &snapshot, nil
+}
+
+// Rollback 回滚已结束的部署，创建回滚部署并在后台下发，立即返回回滚部署记录
+// 各Agent恢复该部署前应用的版本：金丝雀Agent取部署时记录的版本，其余Agent取事件时间线中该部署之前最近一次应用的版本，
+// 部署前没有该配置的Agent删除该配置。只回滚仍在运行该部署版本的Agent，之后又部署了其他版本的Agent跳过。
+// 回滚部署像普通部署一样跟踪各Agent的上报结果，原部署标记为rolled_back
+func (e *DeploymentEngine) Rollback(ctx context.Context, deploymentID, userID string) (*models.Deployment, error) {
+	e.mu.Lock()
+	if e.draining {
+		e.mu.Unlock()
+		return nil, ErrShuttingDown
+	}
+	e.runs.Add(1)
+	e.mu.Unlock()
+	started := false
+	defer func() {
+		if !started {
+			e.runs.Done()
+		}
+	}()
+
+	original, err := e.deployRepo.GetByID(elasticsearch.WithPrimaryRead(ctx), deploymentID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDeploymentNotFound, err)
+	}
+	if !models.InProject(ctx, original.Project) {
+		return nil, fmt.Errorf("%w: %s", ErrDeploymentNotFound, deploymentID)
+	}
+	switch {
+	case !original.IsFinished():
+		return nil, fmt.Errorf("%w: 部署尚未结束", ErrRollbackNotAllowed)
+	case original.RollbackOf != "":
+		return nil, fmt.Errorf("%w: 不能回滚回滚部署", ErrRollbackNotAllowed)
+	case original.RolledBackBy != "":
+		return nil, fmt.Errorf("%w: 已由部署 %s 回滚", ErrRollbackNotAllowed, original.RolledBackBy)
+	case original.Status == models.DeploymentStatusRolledBack:
+		return nil, fmt.Errorf("%w: 金丝雀部署已自动回滚", ErrRollbackNotAllowed)
+	}
+
+	config, err := e.configRepo.GetByID(ctx, original.ConfigID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConfigNotFound, err)
+	}
+	if err := authorizeConfig(ctx, config, models.PermissionDeploy); err != nil {
+		return nil, err
+	}
+
+	deployment := &models.Deployment{
+		ConfigID:        config.ID,
+		ConfigName:      config.Name,
+		Team:            config.Team,
+		Project:         original.Project,
+		PreviousVersion: original.ConfigVersion,
+		Strategy:        models.DeploymentStrategyRollback,
+		RollbackOf:      original.ID,
+		Status:          models.DeploymentStatusPending,
+		CreatedBy:       userID,
+	}
+	versions := make(map[int]bool)
+	for _, r := range original.Results {
+		// 上报失败的Agent已自行恢复原配置，未下发的Agent没有变化
+		if r.Status != models.DeploymentResultApplied {
+			continue
+		}
+		result := models.DeploymentResult{AgentID: r.AgentID, Status: models.DeploymentResultPending}
+		current, err := e.appliedVersion(ctx, r.AgentID, original.ConfigID)
+		if err != nil {
+			return nil, err
+		}
+		if current != original.ConfigVersion {
+			result.Status = models.DeploymentResultSkipped
+			result.Message = fmt.Sprintf("Agent当前运行版本 %d，不是原部署的版本，未回滚", current)
+			deployment.Results = append(deployment.Results, result)
+			continue
+		}
+		if result.Version, err = e.previousVersion(ctx, original, r); err != nil {
+			return nil, err
+		}
+		versions[result.Version] = true
+		deployment.AgentIDs = append(deployment.AgentIDs, r.AgentID)
+		deployment.Results = append(deployment.Results, result)
+	}
+	if len(deployment.AgentIDs) == 0 {
+		return nil, fmt.Errorf("%w: 没有仍在运行原部署版本的Agent", ErrNoTargets)
+	}
+	// 各Agent恢复的版本相同时记为部署版本，否则为0，以各结果的version为准
+	if len(versions) == 1 {
+		for version := range versions {
+			deployment.ConfigVersion = version
+		}
+	}
+
+	if err := e.deployRepo.Create(ctx, deployment); err != nil {
+		return nil, err
+	}
+	original.RolledBackBy = deployment.ID
+	original.Status = models.DeploymentStatusRolledBack
+	if err := e.deployRepo.Update(ctx, original); err != nil {
+		return nil, err
+	}
+
+	tracker := &deploymentTracker{
+		deployment: deployment,
+		waiters:    make(map[string]chan struct{}, len(deployment.AgentIDs)),
+		reconnects: make(map[string]chan struct{}, len(deployment.AgentIDs)),
+	}
+	for _, agentID := range deployment.AgentIDs {
+		tracker.waiters[agentID] = make(chan struct{})
+		tracker.reconnects[agentID] = make(chan struct{}, 1)
+	}
+
+	e.mu.Lock()
+	e.active[deployment.ID] = tracker
+	e.mu.Unlock()
+
+	e.logger.WithFields(logrus.Fields{
+		"deployment_id": deployment.ID,
+		"rollback_of":   original.ID,
+		"config_id":     config.ID,
+		"targets":       len(deployment.AgentIDs),
+		"user_id":       userID,
+	}).Info("创建回滚部署")
+
+	snapshot := *deployment
+	snapshot.Results = slices.Clone(deployment.Results)
+	started = true
+	go e.run(tracing.Detach(ctx), tracker, config.Destinations)
+
+	return &snapshot, nil
+}
+
+// previousVersion Agent在原部署之前应用的版本，没有应用记录时返回0
+func (e *DeploymentEngine) previousVersion(ctx context.Context, original *models.Deployment, result models.DeploymentResult) (int, error) {
+	if original.Canary != nil && slices.Contains(original.Canary.AgentIDs, result.AgentID) {
+		return result.PreviousVersion, nil
+	}
+	if e.events == nil {
+		return 0, fmt.Errorf("%w: 未启用Agent事件时间线，无法确定部署前的版本", ErrRollbackNotAllowed)
+	}
+	events, err := e.events.ListEvents(ctx, result.AgentID, &models.AgentEventListRequest{
+		Type:  models.AgentEventConfigApplied,
+		Until: original.CreatedAt,
+		Size:  1000,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("获取Agent %s 的配置应用记录失败: %w", result.AgentID, err)
+	}
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].ConfigID == original.ConfigID && events[i].DeploymentID != original.ID {
+			return events[i].ConfigVersion, nil
+		}
+	}
+	return 0, nil
+}
+
+// recordAppliedEvent 将配置应用结果写入Agent事件时间线，重载排队的结果在重载执行后再次上报时记录
+func (e *DeploymentEngine) recordAppliedEvent(ctx context.Context, agentID string, report *models.ConfigAppliedReport) {
+	if e.events == nil || report.Status == "reload_queued" {
+		return
+	}
+
+	event := &models.AgentEvent{
+		AgentID:       agentID,
+		Type:          models.AgentEventConfigApplied,
+		ConfigID:      report.ConfigID,
+		ConfigVersion: report.Version,
+		DeploymentID:  report.DeploymentID,
+		CreatedAt:     report.AppliedAt,
+	}
+	if report.Failed() {
+		event.Type = models.AgentEventConfigApplyFailed
+		event.Reason = report.Error
+	}
+	if err := e.events.Record(ctx, event); err != nil {
+		e.logger.WithError(err).WithField("agent_id", agentID).Warn("写入Agent事件失败")
+	}
+}
+
+// WorkerStatus 报告进行中的部署数及仍在等待Agent上报结果的目标数
+func (e *DeploymentEngine) WorkerStatus(now time.Time) models.WorkerStatus {
+	e.mu.Lock()
+	trackers := make([]*deploymentTracker, 0, len(e.active))
+	for _, tracker := range e.active {
+		trackers = append(trackers, tracker)
+	}
+	e.mu.Unlock()
+
+	awaiting := 0
+	for _, tracker := range trackers {
+		tracker.mu.Lock()
+		for _, r := range tracker.deployment.Results {
+			if r.Status == models.DeploymentResultPending {
+				awaiting++
+			}
+		}
+		tracker.mu.Unlock()
+	}
+
+	return models.WorkerStatus{
+		Name:    "deployment_engine",
+		Kind:    models.WorkerKindPool,
+		Running: true,
+		Gauges: map[string]float64{
+			"active_deployments": float64(len(trackers)),
+			"awaiting_results":   float64(awaiting),
+		},
+	}
+}
+
+// RecordResult 记录Agent上报的配置应用结果
+func (e *DeploymentEngine) RecordResult(ctx context.Context, agentID string, report *models.ConfigAppliedReport) error {
+	if report.Status == "removed" {
+		return e.recordRemoved(ctx, agentID, report.ConfigID)
+	}
+	if !report.Failed() {
+		applied := models.AppliedConfig{
+			ConfigID:      report.ConfigID,
+			Version:       report.Version,
+			AppliedAt:     report.AppliedAt,
+			DeploymentID:  report.DeploymentID,
+			ReloadPending: report.Status == "reload_queued",
+			Hash:          report.Hash,
+		}
+		if e.agents != nil {
+			if err := e.agents.RecordApplied(ctx, agentID, applied); err != nil {
+				e.logger.WithError(err).WithField("agent_id", agentID).Warn("更新Agent已应用配置失败")
+			}
+		}
+		if e.usage != nil {
+			if err := e.usage.Record(ctx, agentID, applied); err != nil {
+				e.logger.WithError(err).WithField("agent_id", agentID).Warn("更新已应用配置映射失败")
+			}
+		}
+	}
+	e.recordAppliedEvent(ctx, agentID, report)
+
+	if report.DeploymentID == "" {
+		return nil
+	}
+	if report.Status == "reload_queued" {
+		return e.recordReloadQueued(ctx, agentID, report.DeploymentID)
+	}
+
+	status := models.DeploymentResultApplied
+	if report.Failed() {
+		status = models.DeploymentResultFailed
+	}
+
+	e.mu.Lock()
+	tracker, ok := e.active[report.DeploymentID]
+	e.mu.Unlock()
+
+	if ok {
+		return e.updateResult(ctx, tracker, agentID, status, report.Error)
+	}
+
+	// 部署不在本实例内存中（例如平台重启），直接更新存储中的记录
+	deployment, err := e.deployRepo.GetByID(elasticsearch.WithPrimaryRead(ctx), report.DeploymentID)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrDeploymentNotFound, err)
+	}
+	if deployment.IsFinished() {
+		return nil
+	}
+	if !setResult(deployment, agentID, status, report.Error) {
+		return fmt.Errorf("%w: %s", ErrNotDeploymentTarget, agentID)
+	}
+	// 全部目标都已上报时结束部署，没有后台任务会再处理该记录
+	if !hasPendingResults(deployment) {
+		e.complete(deployment)
+	}
+	if err := e.deployRepo.Update(ctx, deployment); err != nil {
+		return err
+	}
+	if deployment.IsFinished() {
+		e.emitFinished(ctx, deployment)
+	}
+	return nil
+}
+
+// RemoveConfig 向Agent下发config_delete并等待全部Agent确认移除，超过ackTimeout仍未确认时返回错误
+func (e *DeploymentEngine) RemoveConfig(ctx context.Context, configID string, agentIDs []string) error {
+	if e.publisher == nil {
+		return fmt.Errorf("未配置消息推送通道")
+	}
+
+	waiters := make(map[string]chan struct{}, len(agentIDs))
+	e.mu.Lock()
+	for _, agentID := range agentIDs {
+		key := agentID + ":" + configID
+		waiter, ok := e.removals[key]
+		if !ok {
+			waiter = make(chan struct{})
+			e.removals[key] = waiter
+		}
+		waiters[agentID] = waiter
+	}
+	e.mu.Unlock()
+	defer func() {
+		e.mu.Lock()
+		for agentID, waiter := range waiters {
+			key := agentID + ":" + configID
+			if e.removals[key] == waiter {
+				delete(e.removals, key)
+			}
+		}
+		e.mu.Unlock()
+	}()
+
+	for _, agentID := range agentIDs {
+		if err := PublishWithContext(ctx, e.publisher, agentID, models.MsgTypeConfigDelete, models.ConfigDeletePayload{ConfigID: configID}); err != nil {
+			return fmt.Errorf("向Agent %s 下发配置删除失败: %w", agentID, err)
+		}
+	}
+
+	timer := time.NewTimer(e.ackTimeout)
+	defer timer.Stop()
+	expired := false
+	var unconfirmed []string
+	for _, agentID := range agentIDs {
+		if expired {
+			// 超时后只收集已确认的结果，不再等待
+			select {
+			case <-waiters[agentID]:
+			default:
+				unconfirmed = append(unconfirmed, agentID)
+			}
+			continue
+		}
+		select {
+		case <-waiters[agentID]:
+		case <-timer.C:
+			expired = true
+			unconfirmed = append(unconfirmed, agentID)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if len(unconfirmed) > 0 {
+		return fmt.Errorf("Agent %s 未在 %s 内确认移除配置", strings.Join(unconfirmed, ", "), e.ackTimeout)
+	}
+	return nil
+}
+
+// recordRemoved Agent确认已移除配置：更新已应用配置并通知等待中的强制删除
+func (e *DeploymentEngine) recordRemoved(ctx context.Context, agentID, configID string) error {
+	if e.agents != nil {
+		if err := e.agents.RemoveApplied(ctx, agentID, configID); err != nil {
+			e.logger.WithError(err).WithField("agent_id", agentID).Warn("更新Agent已应用配置失败")
+		}
+	}
+	if e.usage != nil {
+		if err := e.usage.Remove(ctx, agentID, configID); err != nil {
+			e.logger.WithError(err).WithField("agent_id", agentID).Warn("更新已应用配置映射失败")
+		}
+	}
+
+	e.mu.Lock()
+	key := agentID + ":" + configID
+	if waiter, ok := e.removals[key]; ok {
+		close(waiter)
+		delete(e.removals, key)
+	}
+	e.mu.Unlock()
+	return nil
+}
+
+// recordReloadQueued Agent已落盘配置但重载因预算排队，结果保持pending并标注原因，等待重载后的最终上报
+func (e *DeploymentEngine) recordReloadQueued(ctx context.Context, agentID, deploymentID string) error {
+	e.mu.Lock()
+	tracker, ok := e.active[deploymentID]
+	e.mu.Unlock()
+
+	if ok {
+		tracker.mu.Lock()
+		defer tracker.mu.Unlock()
+		if !setPendingMessage(tracker.deployment, agentID, reloadQueuedMessage) {
+			return fmt.Errorf("%w: %s", ErrNotDeploymentTarget, agentID)
+		}
+		e.save(ctx, tracker.deployment)
+		return nil
+	}
+
+	deployment, err := e.deployRepo.GetByID(elasticsearch.WithPrimaryRead(ctx), deploymentID)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrDeploymentNotFound, err)
+	}
+	if deployment.IsFinished() {
+		return nil
+	}
+	if !setPendingMessage(deployment, agentID, reloadQueuedMessage) {
+		return fmt.Errorf("%w: %s", ErrNotDeploymentTarget, agentID)
+	}
+	return e.deployRepo.Update(ctx, deployment)
+}
+
+// RecordApproval 记录部署审批，同一审批人重复提交时以最新结论为准
+// 进行中的部署在内存记录上追加，避免后续进度保存覆盖审批记录
+func (e *DeploymentEngine) RecordApproval(ctx context.Context, deploymentID, approver string, req *models.ApproveDeploymentRequest) (*models.Deployment, error) {
+	approval := models.DeploymentApproval{
+		Approver:  approver,
+		Decision:  req.Decision,
+		Comment:   req.Comment,
+		DecidedAt: time.Now(),
+	}
+
+	e.mu.Lock()
+	tracker, ok := e.active[deploymentID]
+	e.mu.Unlock()
+
+	if ok {
+		tracker.mu.Lock()
+		defer tracker.mu.Unlock()
+		if !models.InProject(ctx, tracker.deployment.Project) {
+			return nil, fmt.Errorf("%w: %s", ErrDeploymentNotFound, deploymentID)
+		}
+		setApproval(tracker.deployment, approval)
+		if err := e.deployRepo.Update(ctx, tracker.deployment); err != nil {
+			return nil, err
+		}
+		snapshot := *tracker.deployment
+		snapshot.Results = append([]models.DeploymentResult(nil), tracker.deployment.Results...)
+		snapshot.Approvals = append([]models.DeploymentApproval(nil), tracker.deployment.Approvals...)
+		return &snapshot, nil
+	}
+
+	deployment, err := e.deployRepo.GetByID(elasticsearch.WithPrimaryRead(ctx), deploymentID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDeploymentNotFound, err)
+	}
+	if !models.InProject(ctx, deployment.Project) {
+		return nil, fmt.Errorf("%w: %s", ErrDeploymentNotFound, deploymentID)
+	}
+	setApproval(deployment, approval)
+	if err := e.deployRepo.Update(ctx, deployment); err != nil {
+		return nil, err
+	}
+	return deployment, nil
+}
+
+// run 后台执行部署，ctx只携带创建部署请求的链路上下文，下发给Agent的消息接续该链路
+func (e *DeploymentEngine) run(ctx context.Context, tracker *deploymentTracker, destinations []string) {
+	defer e.runs.Done()
+	ctx, span := tracing.Tracer().Start(ctx, "deployment.run", trace.WithAttributes(
+		attribute.String("deployment.id", tracker.deployment.ID),
+		attribute.String("config.id", tracker.deployment.ConfigID),
+	))
+	defer span.End()
+
+	tracker.mu.Lock()
+	now := time.Now()
+	tracker.deployment.Status = models.DeploymentStatusRunning
+	tracker.deployment.StartedAt = &now
+	e.save(ctx, tracker.deployment)
+	deploymentID := tracker.deployment.ID
+	payload := models.ConfigDeployPayload{
+		ConfigID:     tracker.deployment.ConfigID,
+		Version:      tracker.deployment.ConfigVersion,
+		DeploymentID: deploymentID,
+	}
+	targets := append([]string(nil), tracker.deployment.AgentIDs...)
+	canary := tracker.deployment.Canary != nil
+	rollback := tracker.deployment.RollbackOf != ""
+	tracker.mu.Unlock()
+
+	switch {
+	case canary:
+		e.runCanary(ctx, tracker, targets, destinations, payload)
+	case rollback:
+		e.runRollback(ctx, tracker, destinations, payload)
+		e.finish(ctx, tracker)
+	default:
+		e.dispatchRollout(ctx, tracker, targets, destinations, payload)
+		e.finish(ctx, tracker)
+	}
+
+	e.mu.Lock()
+	delete(e.active, deploymentID)
+	e.mu.Unlock()
+}
+
+// Drain 停止创建新部署并等待进行中的部署结束
+// ctx到期时保存仍在进行的部署的当前进度，部署保持未结束状态，由下次启动或接管的副本通过Recover继续跟踪
+func (e *DeploymentEngine) Drain(ctx context.Context) error {
+	e.mu.Lock()
+	e.draining = true
+	e.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		e.runs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	e.mu.Lock()
+	trackers := make([]*deploymentTracker, 0, len(e.active))
+	for _, tracker := range e.active {
+		trackers = append(trackers, tracker)
+	}
+	e.mu.Unlock()
+
+	saveCtx := context.WithoutCancel(ctx)
+	for _, tracker := range trackers {
+		tracker.mu.Lock()
+		for _, r := range tracker.deployment.Results {
+			if r.Status == models.DeploymentResultPending && r.Message != reloadQueuedMessage {
+				setPendingMessage(tracker.deployment, r.AgentID, drainCheckpointMessage)
+			}
+		}
+		e.save(saveCtx, tracker.deployment)
+		tracker.mu.Unlock()
+	}
+	return fmt.Errorf("%d个部署未在关闭前结束，已保存进度", len(trackers))
+}
+
+// dispatchAll 并发向一组Agent下发部署并等待全部结果
+func (e *DeploymentEngine) dispatchAll(ctx context.Context, tracker *deploymentTracker, agentIDs, destinations []string, payload models.ConfigDeployPayload) {
+	var wg sync.WaitGroup
+	for _, agentID := range agentIDs {
+		wg.Add(1)
+		go func(agentID string) {
+			defer wg.Done()
+			e.dispatch(ctx, tracker, agentID, destinations, payload)
+		}(agentID)
+	}
+	wg.Wait()
+}
+
+// runRollback 并发向各Agent下发其恢复的版本并等待全部结果
+// 恢复为“没有该配置”的Agent下发config_delete，Agent确认删除的上报不携带部署ID，下发成功即视为完成
+func (e *DeploymentEngine) runRollback(ctx context.Context, tracker *deploymentTracker, destinations []string, payload models.ConfigDeployPayload) {
+	tracker.mu.Lock()
+	versions := make(map[string]int, len(tracker.deployment.AgentIDs))
+	for _, r := range tracker.deployment.Results {
+		if r.Status == models.DeploymentResultPending {
+			versions[r.AgentID] = r.Version
+		}
+	}
+	tracker.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for agentID, version := range versions {
+		wg.Add(1)
+		go func(agentID string, version int) {
+			defer wg.Done()
+			if version > 0 {
+				agentPayload := payload
+				agentPayload.Version = version
+				e.dispatch(ctx, tracker, agentID, destinations, agentPayload)
+				return
+			}
+
+			status, message := models.DeploymentResultApplied, "已删除部署前不存在的配置"
+			if e.publisher == nil {
+				status, message = models.DeploymentResultFailed, "未配置消息推送通道"
+			} else if err := PublishWithContext(ctx, e.publisher, agentID, models.MsgTypeConfigDelete,
+				models.ConfigDeletePayload{ConfigID: payload.ConfigID}); err != nil {
+				status, message = models.DeploymentResultFailed, fmt.Sprintf("下发失败: %v", err)
+			}
+			e.updateResult(ctx, tracker, agentID, status, message)
+		}(agentID, version)
+	}
+	wg.Wait()
+}
+
+// runCanary 按金丝雀策略执行部署
+// 金丝雀全部应用成功后进入观察期，观察期结束时检查金丝雀的健康状态：
+// 全部健康则推广到其余Agent，任一金丝雀下发失败或不健康则回滚金丝雀并结束部署
+func (e *DeploymentEngine) runCanary(ctx context.Context, tracker *deploymentTracker, targets, destinations []string, payload models.ConfigDeployPayload) {
+	tracker.mu.Lock()
+	canary := tracker.deployment.Canary
+	canaries := append([]string(nil), canary.AgentIDs...)
+	soak := time.Duration(canary.Options.SoakSeconds) * time.Second
+	maxErrorRate := canary.Options.MaxErrorRate
+	tracker.mu.Unlock()
+
+	e.dispatchAll(ctx, tracker, canaries, destinations, payload)
+
+	tracker.mu.Lock()
+	for _, r := range tracker.deployment.Results {
+		if slices.Contains(canaries, r.AgentID) && r.Status != models.DeploymentResultApplied {
+			e.rollback(ctx, tracker.deployment, fmt.Sprintf("金丝雀Agent %s 部署失败: %s", r.AgentID, r.Message))
+			tracker.mu.Unlock()
+			return
+		}
+	}
+	tracker.mu.Unlock()
+
+	// 以金丝雀应用后的指标为基线，只统计观察期内的事件
+	baselines := make(map[string]*models.AgentMetrics, len(canaries))
+	for _, agentID := range canaries {
+		if agent, err := e.agents.GetAgent(ctx, agentID); err == nil {
+			baselines[agentID] = agent.Metrics
+		}
+	}
+
+	soakStart := time.Now()
+	soakUntil := soakStart.Add(soak)
+	tracker.mu.Lock()
+	canary.Phase = models.CanaryPhaseSoaking
+	canary.SoakUntil = &soakUntil
+	e.save(ctx, tracker.deployment)
+	tracker.mu.Unlock()
+
+	time.Sleep(soak)
+
+	checks := make([]models.CanaryCheck, 0, len(canaries))
+	var unhealthy *models.CanaryCheck
+	for _, agentID := range canaries {
+		check := models.CanaryCheck{AgentID: agentID, Reason: "获取Agent状态失败"}
+		if agent, err := e.agents.GetAgent(ctx, agentID); err == nil {
+			check = checkCanary(agent, baselines[agentID], soakStart, maxErrorRate)
+		}
+		checks = append(checks, check)
+	}
+	for i := range checks {
+		if !checks[i].Healthy {
+			unhealthy = &checks[i]
+			break
+		}
+	}
+
+	tracker.mu.Lock()
+	canary.Checks = checks
+	if unhealthy != nil {
+		e.rollback(ctx, tracker.deployment, fmt.Sprintf("金丝雀Agent %s 不健康: %s", unhealthy.AgentID, unhealthy.Reason))
+		tracker.mu.Unlock()
+		return
+	}
+	canary.Phase = models.CanaryPhasePromoting
+	canary.Reason = "观察期内金丝雀Agent均健康"
+	e.save(ctx, tracker.deployment)
+	tracker.mu.Unlock()
+
+	e.logger.WithFields(logrus.Fields{
+		"deployment_id": payload.DeploymentID,
+		"canaries":      len(canaries),
+	}).Info("金丝雀观察通过，推广到其余Agent")
+
+	if !e.dispatchRollout(ctx, tracker, rolloutAgents(targets, canary), destinations, payload) {
+		tracker.mu.Lock()
+		canary.Phase = models.CanaryPhasePromoted
+		tracker.mu.Unlock()
+	}
+	e.finish(ctx, tracker)
+}
+
+// dispatchRollout 向一组Agent下发部署，指定了分批参数时按批下发，返回是否中止了其余批次
+// 一批全部上报结果（等待健康时还需其Agent恢复健康）后再下发下一批；
+// 累计失败的Agent占比超过上限时中止，其余批次的Agent标记为skipped
+func (e *DeploymentEngine) dispatchRollout(ctx context.Context, tracker *deploymentTracker, agentIDs, destinations []string, payload models.ConfigDeployPayload) bool {
+	tracker.mu.Lock()
+	rollout := tracker.deployment.Rollout
+	tracker.mu.Unlock()
+	if rollout == nil {
+		e.dispatchAll(ctx, tracker, agentIDs, destinations, payload)
+		return false
+	}
+
+	options := rollout.Options
+	batches := rolloutBatches(agentIDs, options.BatchSize)
+	for i, batch := range batches {
+		tracker.mu.Lock()
+		rollout.Current = i + 1
+		e.save(ctx, tracker.deployment)
+		tracker.mu.Unlock()
+
+		e.dispatchAll(ctx, tracker, batch, destinations, payload)
+		appliedAt := time.Now()
+
+		tracker.mu.Lock()
+		var applied []string
+		failed := 0
+		for _, r := range tracker.deployment.Results {
+			if !slices.Contains(batch, r.AgentID) {
+				continue
+			}
+			if r.Status == models.DeploymentResultApplied {
+				applied = append(applied, r.AgentID)
+			} else {
+				failed++
+			}
+		}
+		tracker.mu.Unlock()
+		if options.WaitForHealthy && len(applied) > 0 {
+			failed += e.waitHealthy(ctx, tracker, applied, appliedAt, time.Duration(options.HealthTimeoutSeconds)*time.Second)
+		}
+
+		tracker.mu.Lock()
+		rollout.Failed += failed
+		if i == len(batches)-1 || rollout.Failed*100 <= options.MaxFailurePercent*len(agentIDs) {
+			e.save(ctx, tracker.deployment)
+			tracker.mu.Unlock()
+			continue
+		}
+
+		rollout.Aborted = true
+		rollout.Reason = fmt.Sprintf("第 %d/%d 批后累计 %d 个Agent失败，超过分批下发的 %d 个Agent的 %d%%",
+			i+1, len(batches), rollout.Failed, len(agentIDs), options.MaxFailurePercent)
+		for j := range tracker.deployment.Results {
+			r := &tracker.deployment.Results[j]
+			if r.Status == models.DeploymentResultPending && slices.Contains(agentIDs, r.AgentID) {
+				r.Status = models.DeploymentResultSkipped
+				r.Message = "分批下发已中止，未下发"
+			}
+		}
+		e.save(ctx, tracker.deployment)
+		tracker.mu.Unlock()
+
+		e.logger.WithFields(logrus.Fields{
+			"deployment_id": payload.DeploymentID,
+			"reason":        rollout.Reason,
+		}).Warn("分批下发已中止")
+		return true
+	}
+	return false
+}
+
+// waitHealthy 等待一批已应用新版本的Agent恢复健康，返回超过timeout仍不健康的Agent数
+// 健康的要求与金丝雀观察相同（保持在线且应用后上报过指标），但不检查失败事件占比；仍不健康的Agent在结果中记录原因
+func (e *DeploymentEngine) waitHealthy(ctx context.Context, tracker *deploymentTracker, agentIDs []string, since time.Time, timeout time.Duration) int {
+	deadline := time.Now().Add(timeout)
+	reasons := make(map[string]string, len(agentIDs))
+	pending := agentIDs
+	for {
+		var unhealthy []string
+		for _, agentID := range pending {
+			check := models.CanaryCheck{AgentID: agentID, Reason: "获取Agent状态失败"}
+			if agent, err := e.agents.GetAgent(ctx, agentID); err == nil {
+				check = checkCanary(agent, nil, since, 1)
+			}
+			if !check.Healthy {
+				unhealthy = append(unhealthy, agentID)
+				reasons[agentID] = check.Reason
+			}
+		}
+		pending = unhealthy
+		if len(pending) == 0 || !time.Now().Before(deadline) || ctx.Err() != nil {
+			break
+		}
+		select {
+		case <-time.After(min(e.healthPoll, time.Until(deadline))):
+		case <-ctx.Done():
+		}
+	}
+
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	for i := range tracker.deployment.Results {
+		r := &tracker.deployment.Results[i]
+		if slices.Contains(pending, r.AgentID) {
+			r.Message = fmt.Sprintf("已应用，%s内未恢复健康: %s", timeout, reasons[r.AgentID])
+		}
+	}
+	return len(pending)
+}
+
+// rollback 回滚已应用新版本的金丝雀Agent并结束部署，其余目标不再下发
+// 本身上报失败的金丝雀由Agent自行恢复原配置，不再处理；调用方持有部署记录的锁
+func (e *DeploymentEngine) rollback(ctx context.Context, deployment *models.Deployment, reason string) {
+	canary := deployment.Canary
+	for i := range deployment.Results {
+		r := &deployment.Results[i]
+		if !slices.Contains(canary.AgentIDs, r.AgentID) {
+			if r.Status == models.DeploymentResultPending {
+				r.Status = models.DeploymentResultSkipped
+				r.Message = "金丝雀未通过，未下发"
+			}
+			continue
+		}
+		if r.Status != models.DeploymentResultApplied {
+			continue
+		}
+
+		message, err := e.revert(ctx, deployment.ConfigID, r)
+		now := time.Now()
+		r.FinishedAt = &now
+		if err != nil {
+			r.Status = models.DeploymentResultFailed
+			r.Message = fmt.Sprintf("回滚下发失败: %v", err)
+			continue
+		}
+		r.Status = models.DeploymentResultRolledBack
+		r.Message = message
+	}
+
+	now := time.Now()
+	canary.Phase = models.CanaryPhaseRolledBack
+	canary.Reason = reason
+	deployment.Status = models.DeploymentStatusRolledBack
+	deployment.CompletedAt = &now
+	e.save(ctx, deployment)
+	e.emitFinished(ctx, deployment)
+
+	e.logger.WithFields(logrus.Fields{
+		"deployment_id": deployment.ID,
+		"config_id":     deployment.ConfigID,
+		"reason":        reason,
+	}).Warn("金丝雀部署已回滚")
+}
+
+// revert 向金丝雀Agent下发部署前的版本，部署前没有该配置时删除该配置
+// 回滚消息不携带部署ID，Agent的上报只更新其已应用配置
+func (e *DeploymentEngine) revert(ctx context.Context, configID string, result *models.DeploymentResult) (string, error) {
+	if e.publisher == nil {
+		return "", fmt.Errorf("未配置消息推送通道")
+	}
+	if result.PreviousVersion == 0 {
+		err := PublishWithContext(ctx, e.publisher, result.AgentID, models.MsgTypeConfigDelete, models.ConfigDeletePayload{ConfigID: configID})
+		return "已删除部署前不存在的配置", err
+	}
+	err := PublishWithContext(ctx, e.publisher, result.AgentID, models.MsgTypeConfigDeploy, models.ConfigDeployPayload{
+		ConfigID: configID,
+		Version:  result.PreviousVersion,
+	})
+	return fmt.Sprintf("已恢复版本 %d", result.PreviousVersion), err
+}
+
+// appliedVersion Agent上该配置当前应用的版本，未应用过时返回0
+func (e *DeploymentEngine) appliedVersion(ctx context.Context, agentID, configID string) (int, error) {
+	if e.agents == nil {
+		return 0, fmt.Errorf("%w: 未配置Agent服务", ErrInvalidStrategy)
+	}
+	agent, err := e.agents.GetAgent(ctx, agentID)
+	if err != nil {
+		return 0, fmt.Errorf("获取Agent %s 失败: %w", agentID, err)
+	}
+	for _, applied := range agent.AppliedConfigs {
+		if applied.ConfigID == configID {
+			return applied.Version, nil
+		}
+	}
+	return 0, nil
+}
+
+// dispatch 向单个Agent下发部署并等待结果
+// 下游集群并发名额在Agent上报结果（重载完成）或占用超过throttleHold后释放，
+// 避免上报延迟（例如经由下一次心跳）长时间阻塞同一集群上的其他部署。
+// 等待期间Agent重新连接时重新下发并重新计时，最多重试ackRetries次；超时仍未上报时判定失败
+func (e *DeploymentEngine) dispatch(ctx context.Context, tracker *deploymentTracker, agentID string, destinations []string, payload models.ConfigDeployPayload) {
+	ctx, span := tracing.Tracer().Start(ctx, "deployment.dispatch", trace.WithAttributes(
+		attribute.String("deployment.id", payload.DeploymentID),
+		attribute.String("agent.id", agentID),
+	))
+	defer span.End()
+
+	release, err := e.throttle.Acquire(ctx, destinations)
+	if err != nil {
+		e.updateResult(ctx, tracker, agentID, models.DeploymentResultFailed, err.Error())
+		return
+	}
+	defer release()
+
+	tracker.mu.Lock()
+	for i := range tracker.deployment.Results {
+		if tracker.deployment.Results[i].AgentID == agentID {
+			now := time.Now()
+			tracker.deployment.Results[i].StartedAt = &now
+		}
+	}
+	tracker.mu.Unlock()
+
+	if e.publisher == nil {
+		e.updateResult(ctx, tracker, agentID, models.DeploymentResultFailed, "未配置消息推送通道")
+		return
+	}
+	attempts := 1
+	e.recordAttempt(ctx, tracker, agentID, attempts, "")
+	if err := PublishWithContext(ctx, e.publisher, agentID, models.MsgTypeConfigDeploy, payload); err != nil {
+		if e.ackRetries == 0 {
+			e.updateResult(ctx, tracker, agentID, models.DeploymentResultFailed, fmt.Sprintf("下发失败: %v", err))
+			return
+		}
+		// Agent不在线时等待其重新连接后重试，仍受ackTimeout限制
+		e.recordAttempt(ctx, tracker, agentID, attempts, fmt.Sprintf("下发失败，等待Agent重新连接: %v", err))
+	}
+
+	timer := time.NewTimer(e.ackTimeout)
+	defer timer.Stop()
+	hold := time.NewTimer(e.throttleHold)
+	defer hold.Stop()
+
+	holdC := hold.C
+	for {
+		select {
+		case <-tracker.waiters[agentID]:
+			return
+		case <-holdC:
+			release()
+			holdC = nil
+		case <-tracker.reconnects[agentID]:
+			if attempts > e.ackRetries || e.reloadQueued(tracker, agentID) {
+				continue
+			}
+			attempts++
+			message := fmt.Sprintf("Agent重新连接，第 %d 次下发", attempts)
+			if err := PublishWithContext(ctx, e.publisher, agentID, models.MsgTypeConfigDeploy, payload); err != nil {
+				message = fmt.Sprintf("第 %d 次下发失败: %v", attempts, err)
+			}
+			e.recordAttempt(ctx, tracker, agentID, attempts, message)
+			timer.Reset(e.ackTimeout)
+			e.logger.WithFields(logrus.Fields{
+				"deployment_id": payload.DeploymentID,
+				"agent_id":      agentID,
+				"attempts":      attempts,
+			}).Info("Agent重新连接，重新下发部署")
+		case <-timer.C:
+			e.updateResult(ctx, tracker, agentID, models.DeploymentResultFailed,
+				fmt.Sprintf("等待Agent上报结果超时（共下发 %d 次）", attempts))
+			return
+		}
+	}
+}
+
+// reloadQueued Agent是否已收到配置并在排队等待重载，此时无需重新下发
+func (e *DeploymentEngine) reloadQueued(tracker *deploymentTracker, agentID string) bool {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	for _, r := range tracker.deployment.Results {
+		if r.AgentID == agentID {
+			return r.Message == reloadQueuedMessage
+		}
+	}
+	return false
+}
+
+// recordAttempt 记录对Agent的下发次数和进度说明，已有结果时不再修改
+func (e *DeploymentEngine) recordAttempt(ctx context.Context, tracker *deploymentTracker, agentID string, attempts int, message string) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	for i := range tracker.deployment.Results {
+		r := &tracker.deployment.Results[i]
+		if r.AgentID == agentID && r.Status == models.DeploymentResultPending {
+			r.Attempts = attempts
+			r.Message = message
+		}
+	}
+	if attempts > 1 || message != "" {
+		e.save(ctx, tracker.deployment)
+	}
+}
+
+// updateResult 更新单个Agent的结果，只有首次结果生效
+func (e *DeploymentEngine) updateResult(ctx context.Context, tracker *deploymentTracker, agentID, status, message string) error {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	waiter, ok := tracker.waiters[agentID]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotDeploymentTarget, agentID)
+	}
+
+	select {
+	case <-waiter:
+		return nil // 已有结果
+	default:
+	}
+
+	setResult(tracker.deployment, agentID, status, message)
+	close(waiter)
+	e.save(ctx, tracker.deployment)
+	return nil
+}
+
+// finish 根据各Agent结果确定部署最终状态
+func (e *DeploymentEngine) finish(ctx context.Context, tracker *deploymentTracker) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	deployment := tracker.deployment
+	failed := e.complete(deployment)
+	e.save(ctx, deployment)
+	e.emitFinished(ctx, deployment)
+
+	e.logger.WithFields(logrus.Fields{
+		"deployment_id": deployment.ID,
+		"status":        deployment.Status,
+		"failed":        failed,
+		"total":         len(deployment.Results),
+	}).Info("部署结束")
+}
+
+// complete 根据各Agent结果设置部署最终状态，返回未成功的Agent数
+func (e *DeploymentEngine) complete(deployment *models.Deployment) int {
+	failed := 0
+	for _, r := range deployment.Results {
+		// 回滚时跳过的Agent未受原部署影响，不计为失败
+		if r.Status != models.DeploymentResultApplied && r.Status != models.DeploymentResultSkipped {
+			failed++
+		}
+	}
+
+	now := time.Now()
+	deployment.CompletedAt = &now
+	deployment.Status = models.DeploymentStatusCompleted
+	// 分批下发中止时等待健康超时的Agent结果仍为applied，以中止判定失败
+	if failed > 0 || (deployment.Rollout != nil && deployment.Rollout.Aborted) {
+		deployment.Status = models.DeploymentStatusFailed
+	}
+	return failed
+}
+
+// emitFinished 发布部署结束事件，事件内容为部署记录的副本，避免异步序列化时与后续修改竞争
+func (e *DeploymentEngine) emitFinished(ctx context.Context, deployment *models.Deployment) {
+	if e.emitter == nil {
+		return
+	}
+	snapshot := *deployment
+	snapshot.Results = slices.Clone(deployment.Results)
+	e.emitter.Emit(ctx, models.WebhookEventConfigDeployed, &snapshot)
+}
+
+// save 持久化部署记录，失败只记录日志，下次更新时会覆盖
+func (e *DeploymentEngine) save(ctx context.Context, deployment *models.Deployment) {
+	if err := e.deployRepo.Update(ctx, deployment); err != nil {
+		e.logger.WithError(err).WithField("deployment_id", deployment.ID).Error("保存部署进度失败")
+	}
+}
+
+// resolveTargets 合并显式指定的Agent和选择器匹配的Agent
+// 配置只能部署到同一项目的Agent：显式指定其他项目的Agent时拒绝部署，选择器只匹配同一项目的Agent
+func (e *DeploymentEngine) resolveTargets(ctx context.Context, req *models.CreateDeploymentRequest, project string) ([]string, error) {
+	targets := make([]string, 0, len(req.AgentIDs))
+	seen := make(map[string]bool)
+	for _, id := range req.AgentIDs {
+		if id != "" && !seen[id] {
+			// 尚未注册的Agent无法判断项目，保持原有行为，等待其上线后下发
+			if e.agents != nil {
+				if agent, err := e.agents.GetAgent(ctx, id); err == nil && models.ProjectOf(agent.Project) != project {
+					return nil, fmt.Errorf("%w: Agent %s 属于项目 %s", ErrProjectMismatch, id, models.ProjectOf(agent.Project))
+				}
+			}
+			seen[id] = true
+			targets = append(targets, id)
+		}
+	}
+
+	if req.Selector != "" {
+		agents, err := e.agents.SelectAgents(ctx, req.Selector)
+		if err != nil {
+			return nil, err
+		}
+		for _, agent := range agents {
+			if !seen[agent.AgentID] && models.ProjectOf(agent.Project) == project {
+				seen[agent.AgentID] = true
+				targets = append(targets, agent.AgentID)
+			}
+		}
+	}
+
+	return targets, nil
+}
+
+// planCanary 按金丝雀参数从目标列表的前部选取金丝雀Agent，非canary策略返回nil
+func planCanary(req *models.CreateDeploymentRequest, targets []string) (*models.CanaryStatus, error) {
+	if req.Strategy != models.DeploymentStrategyCanary {
+		if req.Canary != nil {
+			return nil, fmt.Errorf("%w: 金丝雀参数仅用于canary策略", ErrInvalidStrategy)
+		}
+		return nil, nil
+	}
+
+	var options models.CanaryOptions
+	if req.Canary != nil {
+		options = *req.Canary
+	}
+	count := options.Count
+	if count == 0 && options.Percent > 0 {
+		count = (len(targets)*options.Percent + 99) / 100
+	}
+	if count < 1 {
+		count = 1
+	}
+	if count >= len(targets) {
+		return nil, fmt.Errorf("%w: 金丝雀数 %d 必须少于目标Agent数 %d", ErrInvalidStrategy, count, len(targets))
+	}
+	options.Count = count
+	if options.SoakSeconds <= 0 {
+		options.SoakSeconds = int(defaultCanarySoak / time.Second)
+	}
+	if options.MaxErrorRate <= 0 {
+		options.MaxErrorRate = defaultCanaryMaxErrorRate
+	}
+
+	return &models.CanaryStatus{
+		Options:  options,
+		AgentIDs: append([]string(nil), targets[:count]...),
+		Phase:    models.CanaryPhaseCanary,
+	}, nil
+}
+
+// planRollout 按分批参数确定每批Agent数，n为分批下发的Agent数，未指定分批参数时返回nil
+func planRollout(req *models.CreateDeploymentRequest, n int) (*models.RolloutStatus, error) {
+	if req.Rollout == nil {
+		return nil, nil
+	}
+
+	options := *req.Rollout
+	size := options.BatchSize
+	if size == 0 && options.BatchPercent > 0 {
+		size = (n*options.BatchPercent + 99) / 100
+	}
+	if size < 1 {
+		return nil, fmt.Errorf("%w: 分批参数必须指定batch_size或batch_percent", ErrInvalidStrategy)
+	}
+	options.BatchSize = size
+	if options.WaitForHealthy && options.HealthTimeoutSeconds <= 0 {
+		options.HealthTimeoutSeconds = int(defaultRolloutHealthTimeout / time.Second)
+	}
+
+	return &models.RolloutStatus{
+		Options: options,
+		Batches: (n + size - 1) / size,
+	}, nil
+}
+
+// rolloutAgents 分批下发的Agent，canary策略为金丝雀以外的目标，其余策略为全部目标
+func rolloutAgents(targets []string, canary *models.CanaryStatus) []string {
+	if canary == nil {
+		return targets
+	}
+	return slices.DeleteFunc(slices.Clone(targets), func(agentID string) bool {
+		return slices.Contains(canary.AgentIDs, agentID)
+	})
+}
+
+// rolloutBatches 按目标顺序将Agent分为每批size个
+func rolloutBatches(agentIDs []string, size int) [][]string {
+	return slices.Collect(slices.Chunk(agentIDs, size))
+}
+
+// checkCanary 检查金丝雀Agent在观察期内的健康状态
+// Agent需保持在线（组件卡住时上报degraded，心跳中断时判定为offline），观察期内上报过指标，且失败事件占比不超过上限；
+// Agent重启导致计数归零时以当前计数为观察期内的事件数
+func checkCanary(agent *models.Agent, baseline *models.AgentMetrics, since time.Time, maxErrorRate float64) models.CanaryCheck {
+	check := models.CanaryCheck{AgentID: agent.AgentID, Status: agent.Status}
+	if agent.Status != "online" {
+		check.Reason = fmt.Sprintf("Agent状态为 %s", agent.Status)
+		return check
+	}
+	metrics := agent.Metrics
+	if metrics == nil || metrics.Timestamp.Before(since) {
+		check.Reason = "观察期内未上报指标"
+		return check
+	}
+
+	sent, failed := metrics.EventsSent, metrics.EventsFailed
+	if baseline != nil && baseline.EventsSent <= sent && baseline.EventsFailed <= failed {
+		sent -= baseline.EventsSent
+		failed -= baseline.EventsFailed
+	}
+	if total := sent + failed; total > 0 {
+		check.ErrorRate = float64(failed) / float64(total)
+	}
+	if check.ErrorRate > maxErrorRate {
+		check.Reason = fmt.Sprintf("失败事件占比 %.2f%% 超过上限 %.2f%%", check.ErrorRate*100, maxErrorRate*100)
+		return check
+	}
+
+	check.Healthy = true
+	return check
+}
+
+// hasPendingResults 部署中是否还有未上报结果的Agent
+func hasPendingResults(deployment *models.Deployment) bool {
+	for _, r := range deployment.Results {
+		if r.Status == models.DeploymentResultPending {
+			return true
+		}
+	}
+	return false
+}
+
+// setPendingMessage 为仍在等待结果的Agent记录进度说明，Agent不在目标中时返回false
+func setPendingMessage(deployment *models.Deployment, agentID, message string) bool {
+	for i := range deployment.Results {
+		if deployment.Results[i].AgentID == agentID {
+			if deployment.Results[i].Status == models.DeploymentResultPending {
+				deployment.Results[i].Message = message
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// setApproval 追加审批记录，同一审批人只保留最新结论
+func setApproval(deployment *models.Deployment, approval models.DeploymentApproval) {
+	for i := range deployment.Approvals {
+		if deployment.Approvals[i].Approver == approval.Approver {
+			deployment.Approvals[i] = approval
+			return
+		}
+	}
+	deployment.Approvals = append(deployment.Approvals, approval)
+}
+
+// setResult 设置部署中指定Agent的结果，Agent不在目标中时返回false
+func setResult(deployment *models.Deployment, agentID, status, message string) bool {
+	for i := range deployment.Results {
+		if deployment.Results[i].AgentID == agentID {
+			now := time.Now()
+			deployment.Results[i].Status = status
+			deployment.Results[i].Message = message
+			deployment.Results[i].FinishedAt = &now
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,890 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/tests/mocks"
+)
+
+// memDeploymentRepository 并发安全的内存部署仓库
+type memDeploymentRepository struct {
+	mu          sync.Mutex
+	deployments map[string]models.Deployment
+}
+
+func (r *memDeploymentRepository) Create(ctx context.Context, d *models.Deployment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d.ID = fmt.Sprintf("dep-%d", len(r.deployments)+1)
+	d.CreatedAt = time.Now()
+	r.deployments[d.ID] = copyDeployment(d)
+	return nil
+}
+
+func (r *memDeploymentRepository) Update(ctx context.Context, d *models.Deployment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deployments[d.ID] = copyDeployment(d)
+	return nil
+}
+
+func (r *memDeploymentRepository) GetByID(ctx context.Context, id string) (*models.Deployment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d, ok := r.deployments[id]
+	if !ok {
+		return nil, fmt.Errorf("文档不存在")
+	}
+	copied := copyDeployment(&d)
+	return &copied, nil
+}
+
+func (r *memDeploymentRepository) List(ctx context.Context, req *models.DeploymentListRequest) ([]*models.Deployment, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []*models.Deployment
+	for _, d := range r.deployments {
+		if req.Status != "" && d.Status != req.Status {
+			continue
+		}
+		copied := copyDeployment(&d)
+		result = append(result, &copied)
+	}
+	return result, int64(len(result)), nil
+}
+
+func copyDeployment(d *models.Deployment) models.Deployment {
+	copied := *d
+	copied.Results = append([]models.DeploymentResult(nil), d.Results...)
+	if d.Canary != nil {
+		canary := *d.Canary
+		canary.Checks = append([]models.CanaryCheck(nil), d.Canary.Checks...)
+		copied.Canary = &canary
+	}
+	if d.Rollout != nil {
+		rollout := *d.Rollout
+		copied.Rollout = &rollout
+	}
+	return copied
+}
+
+// chanPublisher 将下发的消息写入通道
+type chanPublisher struct {
+	sent chan string
+}
+
+func (p *chanPublisher) Publish(agentID, msgType string, payload interface{}) error {
+	p.sent <- agentID
+	return nil
+}
+
+// waitFinished 等待部署结束
+func waitFinished(t *testing.T, repo *memDeploymentRepository, id string) *models.Deployment {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		d, err := repo.GetByID(context.Background(), id)
+		return err == nil && d.IsFinished()
+	}, 2*time.Second, 10*time.Millisecond)
+	d, _ := repo.GetByID(context.Background(), id)
+	return d
+}
+
+func newTestEngine(t *testing.T, ackTimeout time.Duration) (*DeploymentEngine, *memDeploymentRepository, *chanPublisher) {
+	ctx := context.Background()
+	deployRepo := &memDeploymentRepository{deployments: make(map[string]models.Deployment)}
+
+	configRepo := new(mocks.MockConfigRepository)
+	configRepo.On("GetByID", ctx, "cfg-1").Return(&models.Config{ID: "cfg-1", Name: "nginx", Version: 4, Team: "web", Enabled: true}, nil)
+
+	agentRepo := new(mocks.MockAgentRepository)
+	agentRepo.On("ListByLabels", ctx, map[string]string{"env": "prod"}).Return([]*models.Agent{
+		{AgentID: "agent-2", Labels: map[string]string{"env": "prod"}},
+	}, nil)
+	agentRepo.On("GetByID", mock.Anything, mock.Anything).Return(&models.Agent{AgentID: "agent-1"}, nil)
+	agentRepo.On("Save", mock.Anything, mock.Anything).Return(nil)
+
+	publisher := &chanPublisher{sent: make(chan string, 10)}
+	agents := NewAgentService(agentRepo, configRepo, NewCommandQueue(0), logrus.New())
+	engine := NewDeploymentEngine(deployRepo, configRepo, agents, publisher, NewDestinationThrottle(1, nil), ackTimeout, logrus.New())
+	return engine, deployRepo, publisher
+}
+
+func TestDeploymentEngine_TracksPerAgentResults(t *testing.T) {
+	ctx := context.Background()
+	engine, repo, publisher := newTestEngine(t, time.Second)
+
+	deployment, err := engine.Start(ctx, &models.CreateDeploymentRequest{
+		ConfigID: "cfg-1",
+		AgentIDs: []string{"agent-1"},
+		Selector: "env=prod",
+	}, "admin")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"agent-1", "agent-2"}, deployment.AgentIDs)
+	assert.Equal(t, "web", deployment.Team)
+	assert.Equal(t, models.DeploymentResultPending, deployment.Results[0].Status)
+
+	sent := map[string]bool{<-publisher.sent: true, <-publisher.sent: true}
+	assert.Equal(t, map[string]bool{"agent-1": true, "agent-2": true}, sent)
+
+	require.NoError(t, engine.RecordResult(ctx, "agent-1", &models.ConfigAppliedReport{
+		ConfigID: "cfg-1", Version: 4, Status: "success", DeploymentID: deployment.ID,
+	}))
+	require.NoError(t, engine.RecordResult(ctx, "agent-2", &models.ConfigAppliedReport{
+		ConfigID: "cfg-1", Version: 4, Status: "failed", DeploymentID: deployment.ID, Error: "plugin missing",
+	}))
+
+	finished := waitFinished(t, repo, deployment.ID)
+	assert.Equal(t, models.DeploymentStatusFailed, finished.Status)
+	assert.Equal(t, models.DeploymentResultApplied, finished.Results[0].Status)
+	assert.Equal(t, models.DeploymentResultFailed, finished.Results[1].Status)
+	assert.Equal(t, "plugin missing", finished.Results[1].Message)
+	assert.NotNil(t, finished.CompletedAt)
+
+	// 未知部署的上报返回错误
+	err = engine.RecordResult(ctx, "agent-x", &models.ConfigAppliedReport{ConfigID: "cfg-1", DeploymentID: "missing"})
+	assert.Error(t, err)
+}
+
+func TestDeploymentEngine_AckTimeout(t *testing.T) {
+	ctx := context.Background()
+	engine, repo, publisher := newTestEngine(t, 50*time.Millisecond)
+
+	deployment, err := engine.Start(ctx, &models.CreateDeploymentRequest{ConfigID: "cfg-1", AgentIDs: []string{"agent-1"}}, "admin")
+	require.NoError(t, err)
+	<-publisher.sent
+
+	finished := waitFinished(t, repo, deployment.ID)
+	assert.Equal(t, models.DeploymentStatusFailed, finished.Status)
+	assert.Contains(t, finished.Results[0].Message, "超时")
+}
+
+func TestDeploymentEngine_RetryOnReconnect(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("重新连接后重新下发，上报成功后结束", func(t *testing.T) {
+		engine, repo, publisher := newTestEngine(t, time.Second)
+		deployment, err := engine.Start(ctx, &models.CreateDeploymentRequest{ConfigID: "cfg-1", AgentIDs: []string{"agent-1"}}, "admin")
+		require.NoError(t, err)
+		<-publisher.sent
+
+		engine.AgentConnected("agent-2") // 不在部署目标中的Agent不影响部署
+		engine.AgentConnected("agent-1")
+		assert.Equal(t, "agent-1", <-publisher.sent)
+
+		require.NoError(t, engine.RecordResult(ctx, "agent-1", &models.ConfigAppliedReport{
+			ConfigID: "cfg-1", Version: 4, Status: "success", DeploymentID: deployment.ID,
+		}))
+		finished := waitFinished(t, repo, deployment.ID)
+		assert.Equal(t, models.DeploymentStatusCompleted, finished.Status)
+		assert.Equal(t, 2, finished.Results[0].Attempts)
+	})
+
+	t.Run("重试次数用尽后超时失败", func(t *testing.T) {
+		engine, repo, publisher := newTestEngine(t, 200*time.Millisecond)
+		engine.SetAckRetries(1)
+		deployment, err := engine.Start(ctx, &models.CreateDeploymentRequest{ConfigID: "cfg-1", AgentIDs: []string{"agent-1"}}, "admin")
+		require.NoError(t, err)
+		<-publisher.sent
+
+		engine.AgentConnected("agent-1")
+		<-publisher.sent
+		require.Eventually(t, func() bool {
+			d, _ := repo.GetByID(ctx, deployment.ID)
+			return d.Results[0].Attempts == 2
+		}, time.Second, 5*time.Millisecond)
+		engine.AgentConnected("agent-1")
+
+		finished := waitFinished(t, repo, deployment.ID)
+		assert.Equal(t, models.DeploymentStatusFailed, finished.Status)
+		assert.Equal(t, 2, finished.Results[0].Attempts)
+		assert.Contains(t, finished.Results[0].Message, "共下发 2 次")
+		assert.Empty(t, publisher.sent)
+	})
+}
+
+func TestDeploymentEngine_NoTargets(t *testing.T) {
+	engine, _, _ := newTestEngine(t, time.Second)
+
+	_, err := engine.Start(context.Background(), &models.CreateDeploymentRequest{ConfigID: "cfg-1"}, "admin")
+	assert.Error(t, err)
+}
+
+func TestDeploymentEngine_Drain(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("等待进行中的部署结束，之后拒绝新部署", func(t *testing.T) {
+		engine, repo, publisher := newTestEngine(t, time.Second)
+		deployment, err := engine.Start(ctx, &models.CreateDeploymentRequest{ConfigID: "cfg-1", AgentIDs: []string{"agent-1"}}, "admin")
+		require.NoError(t, err)
+		<-publisher.sent
+
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			engine.RecordResult(ctx, "agent-1", &models.ConfigAppliedReport{
+				ConfigID: "cfg-1", Version: 4, Status: "success", DeploymentID: deployment.ID,
+			})
+		}()
+		drainCtx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		require.NoError(t, engine.Drain(drainCtx))
+
+		stored, _ := repo.GetByID(ctx, deployment.ID)
+		assert.Equal(t, models.DeploymentStatusCompleted, stored.Status)
+
+		_, err = engine.Start(ctx, &models.CreateDeploymentRequest{ConfigID: "cfg-1", AgentIDs: []string{"agent-1"}}, "admin")
+		assert.ErrorIs(t, err, ErrShuttingDown)
+	})
+
+	t.Run("超时后保存进度，部署留给恢复流程", func(t *testing.T) {
+		engine, repo, publisher := newTestEngine(t, time.Minute)
+		deployment, err := engine.Start(ctx, &models.CreateDeploymentRequest{ConfigID: "cfg-1", AgentIDs: []string{"agent-1"}}, "admin")
+		require.NoError(t, err)
+		<-publisher.sent
+
+		drainCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		assert.Error(t, engine.Drain(drainCtx))
+
+		stored, _ := repo.GetByID(ctx, deployment.ID)
+		assert.False(t, stored.IsFinished())
+		assert.Equal(t, models.DeploymentResultPending, stored.Results[0].Status)
+		assert.Equal(t, drainCheckpointMessage, stored.Results[0].Message)
+		assert.NotNil(t, stored.Results[0].StartedAt)
+	})
+}
+
+func TestDeploymentEngine_Recover(t *testing.T) {
+	ctx := context.Background()
+	engine, repo, _ := newTestEngine(t, 50*time.Millisecond)
+
+	// 模拟平台重启前遗留的部署：一个已超时，一个仍在等待
+	old := time.Now().Add(-time.Hour)
+	recent := time.Now()
+	repo.deployments["dep-old"] = models.Deployment{
+		ID: "dep-old", Status: models.DeploymentStatusRunning, StartedAt: &old,
+		Results: []models.DeploymentResult{
+			{AgentID: "agent-1", Status: models.DeploymentResultApplied},
+			{AgentID: "agent-2", Status: models.DeploymentResultPending},
+		},
+	}
+	repo.deployments["dep-recent"] = models.Deployment{
+		ID: "dep-recent", Status: models.DeploymentStatusRunning, StartedAt: &recent,
+		Results: []models.DeploymentResult{{AgentID: "agent-1", Status: models.DeploymentResultPending}},
+	}
+
+	require.NoError(t, engine.Recover(ctx))
+
+	expired, _ := repo.GetByID(ctx, "dep-old")
+	assert.Equal(t, models.DeploymentStatusFailed, expired.Status)
+	assert.Equal(t, models.DeploymentResultFailed, expired.Results[1].Status)
+	assert.NotNil(t, expired.CompletedAt)
+
+	// 剩余等待时间结束后过期
+	finished := waitFinished(t, repo, "dep-recent")
+	assert.Equal(t, models.DeploymentStatusFailed, finished.Status)
+}
+
+func TestDeploymentEngine_RecordResultCompletesStoredDeployment(t *testing.T) {
+	ctx := context.Background()
+	engine, repo, _ := newTestEngine(t, time.Second)
+
+	now := time.Now()
+	repo.deployments["dep-1"] = models.Deployment{
+		ID: "dep-1", Status: models.DeploymentStatusRunning, StartedAt: &now,
+		Results: []models.DeploymentResult{{AgentID: "agent-1", Status: models.DeploymentResultPending}},
+	}
+
+	// 部署不在内存中时，最后一个结果到达即结束部署
+	require.NoError(t, engine.RecordResult(ctx, "agent-1", &models.ConfigAppliedReport{
+		ConfigID: "cfg-1", Version: 4, Status: "success", DeploymentID: "dep-1",
+	}))
+	finished, _ := repo.GetByID(ctx, "dep-1")
+	assert.Equal(t, models.DeploymentStatusCompleted, finished.Status)
+	assert.NotNil(t, finished.CompletedAt)
+}
+
+func TestDeploymentEngine_ThrottleHold(t *testing.T) {
+	ctx := context.Background()
+	engine, _, publisher := newTestEngine(t, time.Second)
+	engine.SetThrottleHold(20 * time.Millisecond)
+
+	dest := "elasticsearch:es1:9200"
+	engine.configRepo.(*mocks.MockConfigRepository).On("GetByID", ctx, "cfg-es").Return(&models.Config{
+		ID: "cfg-es", Version: 1, Enabled: true, Destinations: []string{dest},
+	}, nil)
+
+	_, err := engine.Start(ctx, &models.CreateDeploymentRequest{ConfigID: "cfg-es", AgentIDs: []string{"agent-1"}}, "admin")
+	require.NoError(t, err)
+	<-publisher.sent
+	assert.Equal(t, 1, engine.throttle.Stats()[dest].InFlight)
+
+	// Agent尚未上报，名额在占用上限后释放
+	require.Eventually(t, func() bool {
+		return engine.throttle.Stats()[dest].InFlight == 0
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestDeploymentEngine_RecordApproval(t *testing.T) {
+	ctx := context.Background()
+	engine, repo, publisher := newTestEngine(t, time.Second)
+
+	deployment, err := engine.Start(ctx, &models.CreateDeploymentRequest{ConfigID: "cfg-1", AgentIDs: []string{"agent-1"}}, "alice")
+	require.NoError(t, err)
+	<-publisher.sent
+
+	// 进行中的部署：审批记录不会被后续进度保存覆盖
+	_, err = engine.RecordApproval(ctx, deployment.ID, "bob", &models.ApproveDeploymentRequest{Decision: models.ApprovalRejected})
+	require.NoError(t, err)
+	approved, err := engine.RecordApproval(ctx, deployment.ID, "bob", &models.ApproveDeploymentRequest{Decision: models.ApprovalApproved, Comment: "LGTM"})
+	require.NoError(t, err)
+	require.Len(t, approved.Approvals, 1)
+	assert.Equal(t, models.ApprovalApproved, approved.Approvals[0].Decision)
+
+	require.NoError(t, engine.RecordResult(ctx, "agent-1", &models.ConfigAppliedReport{
+		ConfigID: "cfg-1", Version: 4, Status: "success", DeploymentID: deployment.ID,
+	}))
+	finished := waitFinished(t, repo, deployment.ID)
+	require.Len(t, finished.Approvals, 1)
+	assert.Equal(t, "bob", finished.Approvals[0].Approver)
+	assert.Equal(t, "LGTM", finished.Approvals[0].Comment)
+
+	// 已结束的部署仍可补充审批
+	_, err = engine.RecordApproval(ctx, deployment.ID, "carol", &models.ApproveDeploymentRequest{Decision: models.ApprovalApproved})
+	require.NoError(t, err)
+	stored, _ := repo.GetByID(ctx, deployment.ID)
+	assert.Len(t, stored.Approvals, 2)
+
+	_, err = engine.RecordApproval(ctx, "missing", "bob", &models.ApproveDeploymentRequest{Decision: models.ApprovalApproved})
+	assert.ErrorIs(t, err, ErrDeploymentNotFound)
+}
+
+func TestDeploymentEngine_RequiresApproval(t *testing.T) {
+	ctx := context.Background()
+	engine, _, publisher := newTestEngine(t, time.Second)
+
+	repo := &memApprovalRepository{approvals: make(map[string]*models.ConfigApproval)}
+	configRepo := new(mocks.MockConfigRepository)
+	configRepo.On("GetByID", mock.Anything, "cfg-1").Return(&models.Config{ID: "cfg-1", Version: 4, UpdatedBy: "alice"}, nil)
+	approvals := NewApprovalService(1, repo, configRepo, logrus.New())
+	engine.SetApprovalService(approvals)
+
+	req := &models.CreateDeploymentRequest{ConfigID: "cfg-1", AgentIDs: []string{"agent-1"}}
+	_, err := engine.Start(ctx, req, "alice")
+	assert.ErrorIs(t, err, ErrConfigNotApproved)
+
+	_, err = approvals.Submit(ctx, "cfg-1", &models.ApproveConfigRequest{Decision: models.ApprovalApproved}, "bob")
+	require.NoError(t, err)
+	_, err = engine.Start(ctx, req, "alice")
+	require.NoError(t, err)
+	<-publisher.sent
+}
+
+func TestDeploymentEngine_TestGate(t *testing.T) {
+	ctx := context.Background()
+	engine, _, publisher := newTestEngine(t, time.Second)
+	engine.SetTestGate(NewTestGate(0, "", logrus.New()))
+
+	req := &models.CreateDeploymentRequest{ConfigID: "cfg-1", AgentIDs: []string{"agent-1"}}
+	_, err := engine.Start(ctx, req, "alice")
+	assert.ErrorIs(t, err, ErrTestGateFailed)
+
+	req.SkipTestGate = true
+	deployment, err := engine.Start(ctx, req, "alice")
+	require.NoError(t, err)
+	assert.True(t, deployment.TestGateSkipped, "跳过门禁记录在部署上")
+	<-publisher.sent
+}
+
+// fixedPluginInventory 部署前的插件检查固定返回err
+type fixedPluginInventory struct {
+	PluginInventoryService
+	err error
+}
+
+func (f fixedPluginInventory) CheckDeployable(ctx context.Context, config *models.Config, agentIDs []string) error {
+	return f.err
+}
+
+func TestDeploymentEngine_PluginCheck(t *testing.T) {
+	ctx := context.Background()
+	engine, repo, _ := newTestEngine(t, time.Second)
+	engine.SetPluginInventory(fixedPluginInventory{err: &PluginCompatibilityError{ConfigID: "cfg-1", Checks: []models.AgentPluginCheck{
+		{AgentID: "agent-1", Missing: []string{"logstash-filter-translate"}},
+	}}})
+
+	_, err := engine.Start(ctx, &models.CreateDeploymentRequest{ConfigID: "cfg-1", AgentIDs: []string{"agent-1"}}, "alice")
+	assert.ErrorIs(t, err, ErrPluginsMissing)
+	assert.Contains(t, err.Error(), "agent-1 缺少 logstash-filter-translate")
+	assert.Empty(t, repo.deployments, "检查未通过时不创建部署")
+}
+
+// publishedMessage 下发给Agent的消息
+type publishedMessage struct {
+	agentID string
+	msgType string
+	payload interface{}
+}
+
+// msgPublisher 将下发的消息连同类型和内容写入通道
+type msgPublisher struct {
+	sent chan publishedMessage
+}
+
+func (p *msgPublisher) Publish(agentID, msgType string, payload interface{}) error {
+	p.sent <- publishedMessage{agentID: agentID, msgType: msgType, payload: payload}
+	return nil
+}
+
+func newCanaryEngine(t *testing.T, agents map[string]*models.Agent) (*DeploymentEngine, *memDeploymentRepository, *msgPublisher, AgentService) {
+	deployRepo := &memDeploymentRepository{deployments: make(map[string]models.Deployment)}
+	configRepo := new(mocks.MockConfigRepository)
+	configRepo.On("GetByID", mock.Anything, "cfg-1").Return(&models.Config{ID: "cfg-1", Name: "nginx", Version: 4, Enabled: true}, nil)
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	publisher := &msgPublisher{sent: make(chan publishedMessage, 10)}
+	agentService := NewAgentService(&memAgentRepository{agents: agents}, configRepo, NewCommandQueue(0), logger)
+	engine := NewDeploymentEngine(deployRepo, configRepo, agentService, publisher, nil, time.Second, logger)
+	return engine, deployRepo, publisher, agentService
+}
+
+// waitCanaryPhase 等待金丝雀部署进入指定阶段
+func waitCanaryPhase(t *testing.T, repo *memDeploymentRepository, id string, phase models.CanaryPhase) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		d, err := repo.GetByID(context.Background(), id)
+		return err == nil && d.Canary != nil && d.Canary.Phase == phase
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestDeploymentEngine_CanaryPromotes(t *testing.T) {
+	ctx := context.Background()
+	engine, repo, publisher, agents := newCanaryEngine(t, map[string]*models.Agent{
+		"agent-1": {AgentID: "agent-1", Status: "online"},
+		"agent-2": {AgentID: "agent-2", Status: "online"},
+		"agent-3": {AgentID: "agent-3", Status: "online"},
+	})
+
+	deployment, err := engine.Start(ctx, &models.CreateDeploymentRequest{
+		ConfigID: "cfg-1",
+		AgentIDs: []string{"agent-1", "agent-2", "agent-3"},
+		Strategy: models.DeploymentStrategyCanary,
+		Canary:   &models.CanaryOptions{Count: 1, SoakSeconds: 1},
+	}, "admin")
+	require.NoError(t, err)
+	assert.Equal(t, models.DeploymentStrategyCanary, deployment.Strategy)
+	assert.Equal(t, []string{"agent-1"}, deployment.Canary.AgentIDs)
+	assert.Equal(t, defaultCanaryMaxErrorRate, deployment.Canary.Options.MaxErrorRate)
+
+	// 只先下发金丝雀
+	msg := <-publisher.sent
+	assert.Equal(t, "agent-1", msg.agentID)
+	require.NoError(t, engine.RecordResult(ctx, "agent-1", &models.ConfigAppliedReport{
+		ConfigID: "cfg-1", Version: 4, Status: "success", DeploymentID: deployment.ID,
+	}))
+
+	// 观察期内金丝雀上报健康的指标
+	waitCanaryPhase(t, repo, deployment.ID, models.CanaryPhaseSoaking)
+	assert.Empty(t, publisher.sent)
+	require.NoError(t, agents.RecordMetrics(ctx, "agent-1", &models.AgentMetrics{Timestamp: time.Now(), EventsSent: 1000, EventsFailed: 5}))
+
+	sent := map[string]bool{}
+	for range 2 {
+		select {
+		case msg := <-publisher.sent:
+			sent[msg.agentID] = true
+		case <-time.After(3 * time.Second):
+			t.Fatal("观察期结束后未推广到其余Agent")
+		}
+	}
+	assert.Equal(t, map[string]bool{"agent-2": true, "agent-3": true}, sent)
+	for _, agentID := range []string{"agent-2", "agent-3"} {
+		require.NoError(t, engine.RecordResult(ctx, agentID, &models.ConfigAppliedReport{
+			ConfigID: "cfg-1", Version: 4, Status: "success", DeploymentID: deployment.ID,
+		}))
+	}
+
+	finished := waitFinished(t, repo, deployment.ID)
+	assert.Equal(t, models.DeploymentStatusCompleted, finished.Status)
+	assert.Equal(t, models.CanaryPhasePromoted, finished.Canary.Phase)
+	require.Len(t, finished.Canary.Checks, 1)
+	assert.True(t, finished.Canary.Checks[0].Healthy)
+	assert.InDelta(t, 0.005, finished.Canary.Checks[0].ErrorRate, 0.0001)
+}
+
+func TestDeploymentEngine_CanaryRollsBackUnhealthy(t *testing.T) {
+	ctx := context.Background()
+	stale := time.Now().Add(-time.Hour)
+	engine, repo, publisher, agents := newCanaryEngine(t, map[string]*models.Agent{
+		"agent-1": {AgentID: "agent-1", Status: "online",
+			AppliedConfigs: []models.AppliedConfig{{ConfigID: "cfg-1", Version: 3}},
+			Metrics:        &models.AgentMetrics{Timestamp: stale, EventsSent: 500}},
+		"agent-2": {AgentID: "agent-2", Status: "online"},
+		"agent-3": {AgentID: "agent-3", Status: "online"},
+	})
+
+	deployment, err := engine.Start(ctx, &models.CreateDeploymentRequest{
+		ConfigID: "cfg-1",
+		AgentIDs: []string{"agent-1", "agent-2", "agent-3"},
+		Strategy: models.DeploymentStrategyCanary,
+		Canary:   &models.CanaryOptions{Percent: 50, SoakSeconds: 1},
+	}, "admin")
+	require.NoError(t, err)
+	require.Equal(t, []string{"agent-1", "agent-2"}, deployment.Canary.AgentIDs)
+	assert.Equal(t, 3, deployment.Results[0].PreviousVersion)
+
+	for range 2 {
+		msg := <-publisher.sent
+		require.NoError(t, engine.RecordResult(ctx, msg.agentID, &models.ConfigAppliedReport{
+			ConfigID: "cfg-1", Version: 4, Status: "success", DeploymentID: deployment.ID,
+		}))
+	}
+
+	// agent-1 应用后失败事件激增，agent-2 正常
+	waitCanaryPhase(t, repo, deployment.ID, models.CanaryPhaseSoaking)
+	require.NoError(t, agents.RecordMetrics(ctx, "agent-1", &models.AgentMetrics{Timestamp: time.Now(), EventsSent: 600, EventsFailed: 100}))
+	require.NoError(t, agents.RecordMetrics(ctx, "agent-2", &models.AgentMetrics{Timestamp: time.Now(), EventsSent: 100}))
+
+	finished := waitFinished(t, repo, deployment.ID)
+	assert.Equal(t, models.DeploymentStatusRolledBack, finished.Status)
+	assert.Equal(t, models.CanaryPhaseRolledBack, finished.Canary.Phase)
+	assert.Contains(t, finished.Canary.Reason, "agent-1")
+	assert.Contains(t, finished.Canary.Reason, "失败事件占比")
+	assert.Equal(t, models.DeploymentResultRolledBack, finished.Results[0].Status)
+	assert.Equal(t, models.DeploymentResultRolledBack, finished.Results[1].Status)
+	assert.Equal(t, models.DeploymentResultSkipped, finished.Results[2].Status)
+
+	// agent-1 恢复部署前的版本，agent-2 部署前没有该配置，删除之
+	reverts := map[string]publishedMessage{}
+	for range 2 {
+		msg := <-publisher.sent
+		reverts[msg.agentID] = msg
+	}
+	assert.Equal(t, models.MsgTypeConfigDeploy, reverts["agent-1"].msgType)
+	assert.Equal(t, models.ConfigDeployPayload{ConfigID: "cfg-1", Version: 3}, reverts["agent-1"].payload)
+	assert.Equal(t, models.MsgTypeConfigDelete, reverts["agent-2"].msgType)
+	assert.Empty(t, publisher.sent)
+}
+
+func TestDeploymentEngine_CanaryDeployFailure(t *testing.T) {
+	ctx := context.Background()
+	engine, repo, publisher, _ := newCanaryEngine(t, map[string]*models.Agent{
+		"agent-1": {AgentID: "agent-1", Status: "online"},
+		"agent-2": {AgentID: "agent-2", Status: "online"},
+	})
+
+	deployment, err := engine.Start(ctx, &models.CreateDeploymentRequest{
+		ConfigID: "cfg-1",
+		AgentIDs: []string{"agent-1", "agent-2"},
+		Strategy: models.DeploymentStrategyCanary,
+	}, "admin")
+	require.NoError(t, err)
+	assert.Equal(t, 600, deployment.Canary.Options.SoakSeconds)
+
+	<-publisher.sent
+	require.NoError(t, engine.RecordResult(ctx, "agent-1", &models.ConfigAppliedReport{
+		ConfigID: "cfg-1", Version: 4, Status: "failed", DeploymentID: deployment.ID, Error: "plugin missing",
+	}))
+
+	// 金丝雀下发失败时不进入观察期，Agent自行恢复原配置，无需下发回滚
+	finished := waitFinished(t, repo, deployment.ID)
+	assert.Equal(t, models.DeploymentStatusRolledBack, finished.Status)
+	assert.Contains(t, finished.Canary.Reason, "plugin missing")
+	assert.Equal(t, models.DeploymentResultFailed, finished.Results[0].Status)
+	assert.Equal(t, models.DeploymentResultSkipped, finished.Results[1].Status)
+	assert.Empty(t, publisher.sent)
+}
+
+func TestPlanCanary(t *testing.T) {
+	targets := []string{"a", "b", "c", "d", "e"}
+	canary := func(options *models.CanaryOptions) *models.CreateDeploymentRequest {
+		return &models.CreateDeploymentRequest{Strategy: models.DeploymentStrategyCanary, Canary: options}
+	}
+
+	tests := []struct {
+		name    string
+		req     *models.CreateDeploymentRequest
+		want    []string
+		wantErr bool
+	}{
+		{name: "非金丝雀策略", req: &models.CreateDeploymentRequest{}},
+		{name: "默认取1个", req: canary(nil), want: []string{"a"}},
+		{name: "按百分比向上取整", req: canary(&models.CanaryOptions{Percent: 30}), want: []string{"a", "b"}},
+		{name: "指定数量优先", req: canary(&models.CanaryOptions{Count: 3, Percent: 10}), want: []string{"a", "b", "c"}},
+		{name: "金丝雀覆盖全部目标", req: canary(&models.CanaryOptions{Percent: 100}), wantErr: true},
+		{name: "未指定策略时设置参数", req: &models.CreateDeploymentRequest{Canary: &models.CanaryOptions{Count: 1}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := planCanary(tt.req, targets)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidStrategy)
+				return
+			}
+			require.NoError(t, err)
+			if tt.want == nil {
+				assert.Nil(t, got)
+				return
+			}
+			assert.Equal(t, tt.want, got.AgentIDs)
+			assert.Equal(t, models.CanaryPhaseCanary, got.Phase)
+		})
+	}
+}
+
+// reportApplied 模拟Agent上报部署结果
+func reportApplied(t *testing.T, engine *DeploymentEngine, deploymentID, agentID, status string) {
+	t.Helper()
+	require.NoError(t, engine.RecordResult(context.Background(), agentID, &models.ConfigAppliedReport{
+		ConfigID: "cfg-1", Version: 4, Status: status, DeploymentID: deploymentID,
+	}))
+}
+
+func TestDeploymentEngine_RolloutBatches(t *testing.T) {
+	ctx := context.Background()
+	engine, repo, publisher, _ := newCanaryEngine(t, map[string]*models.Agent{})
+
+	deployment, err := engine.Start(ctx, &models.CreateDeploymentRequest{
+		ConfigID: "cfg-1",
+		AgentIDs: []string{"agent-1", "agent-2", "agent-3"},
+		Rollout:  &models.RolloutOptions{BatchSize: 2},
+	}, "admin")
+	require.NoError(t, err)
+	require.NotNil(t, deployment.Rollout)
+	assert.Equal(t, 2, deployment.Rollout.Batches)
+
+	// 第一批全部上报结果后才下发第二批
+	first := map[string]bool{(<-publisher.sent).agentID: true, (<-publisher.sent).agentID: true}
+	assert.Equal(t, map[string]bool{"agent-1": true, "agent-2": true}, first)
+	reportApplied(t, engine, deployment.ID, "agent-1", "success")
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, publisher.sent)
+	reportApplied(t, engine, deployment.ID, "agent-2", "success")
+
+	msg := <-publisher.sent
+	assert.Equal(t, "agent-3", msg.agentID)
+	reportApplied(t, engine, deployment.ID, "agent-3", "success")
+
+	finished := waitFinished(t, repo, deployment.ID)
+	assert.Equal(t, models.DeploymentStatusCompleted, finished.Status)
+	assert.Equal(t, 2, finished.Rollout.Current)
+	assert.False(t, finished.Rollout.Aborted)
+}
+
+func TestDeploymentEngine_RolloutAbortsOnFailures(t *testing.T) {
+	ctx := context.Background()
+	engine, repo, publisher, _ := newCanaryEngine(t, map[string]*models.Agent{})
+
+	deployment, err := engine.Start(ctx, &models.CreateDeploymentRequest{
+		ConfigID: "cfg-1",
+		AgentIDs: []string{"agent-1", "agent-2", "agent-3", "agent-4"},
+		Rollout:  &models.RolloutOptions{BatchSize: 1, MaxFailurePercent: 25},
+	}, "admin")
+	require.NoError(t, err)
+
+	// 1/4 未超过25%，继续下发；第二个失败后中止
+	assert.Equal(t, "agent-1", (<-publisher.sent).agentID)
+	reportApplied(t, engine, deployment.ID, "agent-1", "failed")
+	assert.Equal(t, "agent-2", (<-publisher.sent).agentID)
+	reportApplied(t, engine, deployment.ID, "agent-2", "apply_failed")
+
+	finished := waitFinished(t, repo, deployment.ID)
+	assert.Equal(t, models.DeploymentStatusFailed, finished.Status)
+	assert.True(t, finished.Rollout.Aborted)
+	assert.Equal(t, 2, finished.Rollout.Failed)
+	assert.Contains(t, finished.Rollout.Reason, "第 2/4 批")
+	assert.Equal(t, models.DeploymentResultSkipped, finished.Results[2].Status)
+	assert.Equal(t, models.DeploymentResultSkipped, finished.Results[3].Status)
+	assert.Empty(t, publisher.sent)
+}
+
+func TestDeploymentEngine_RolloutWaitsForHealthy(t *testing.T) {
+	ctx := context.Background()
+	engine, repo, publisher, agents := newCanaryEngine(t, map[string]*models.Agent{
+		"agent-1": {AgentID: "agent-1", Status: "online"},
+		"agent-2": {AgentID: "agent-2", Status: "online"},
+		"agent-3": {AgentID: "agent-3", Status: "online"},
+	})
+	engine.healthPoll = 10 * time.Millisecond
+
+	deployment, err := engine.Start(ctx, &models.CreateDeploymentRequest{
+		ConfigID: "cfg-1",
+		AgentIDs: []string{"agent-1", "agent-2", "agent-3"},
+		Rollout:  &models.RolloutOptions{BatchSize: 1, WaitForHealthy: true, HealthTimeoutSeconds: 1},
+	}, "admin")
+	require.NoError(t, err)
+	assert.Equal(t, 1, deployment.Rollout.Options.HealthTimeoutSeconds)
+
+	// agent-1 应用后上报指标才下发下一批
+	assert.Equal(t, "agent-1", (<-publisher.sent).agentID)
+	reportApplied(t, engine, deployment.ID, "agent-1", "success")
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, publisher.sent)
+	require.NoError(t, agents.RecordMetrics(ctx, "agent-1", &models.AgentMetrics{Timestamp: time.Now()}))
+
+	// agent-2 应用后一直未上报指标，等待超时计为失败，默认任一Agent失败即中止
+	assert.Equal(t, "agent-2", (<-publisher.sent).agentID)
+	reportApplied(t, engine, deployment.ID, "agent-2", "success")
+
+	finished := waitFinished(t, repo, deployment.ID)
+	assert.Equal(t, models.DeploymentStatusFailed, finished.Status)
+	assert.True(t, finished.Rollout.Aborted)
+	assert.Equal(t, models.DeploymentResultApplied, finished.Results[1].Status)
+	assert.Contains(t, finished.Results[1].Message, "未恢复健康")
+	assert.Equal(t, models.DeploymentResultSkipped, finished.Results[2].Status)
+}
+
+func TestPlanRollout(t *testing.T) {
+	rollout := func(options *models.RolloutOptions) *models.CreateDeploymentRequest {
+		return &models.CreateDeploymentRequest{Rollout: options}
+	}
+
+	tests := []struct {
+		name        string
+		req         *models.CreateDeploymentRequest
+		wantSize    int
+		wantBatches int
+		wantErr     bool
+	}{
+		{name: "未指定分批参数", req: &models.CreateDeploymentRequest{}},
+		{name: "按数量", req: rollout(&models.RolloutOptions{BatchSize: 3}), wantSize: 3, wantBatches: 4},
+		{name: "按百分比向上取整", req: rollout(&models.RolloutOptions{BatchPercent: 25}), wantSize: 3, wantBatches: 4},
+		{name: "指定数量优先", req: rollout(&models.RolloutOptions{BatchSize: 5, BatchPercent: 10}), wantSize: 5, wantBatches: 2},
+		{name: "一批覆盖全部", req: rollout(&models.RolloutOptions{BatchSize: 20}), wantSize: 20, wantBatches: 1},
+		{name: "未指定每批数量", req: rollout(&models.RolloutOptions{WaitForHealthy: true}), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := planRollout(tt.req, 10)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidStrategy)
+				return
+			}
+			require.NoError(t, err)
+			if tt.wantSize == 0 {
+				assert.Nil(t, got)
+				return
+			}
+			assert.Equal(t, tt.wantSize, got.Options.BatchSize)
+			assert.Equal(t, tt.wantBatches, got.Batches)
+		})
+	}
+}
+
+func TestDeploymentEngine_ProjectScope(t *testing.T) {
+	ctx := context.Background()
+	deployRepo := &memDeploymentRepository{deployments: make(map[string]models.Deployment)}
+	configRepo := new(mocks.MockConfigRepository)
+	configRepo.On("GetByID", mock.Anything, "cfg-pay").Return(&models.Config{ID: "cfg-pay", Version: 1, Project: "payments", Enabled: true}, nil)
+	agentRepo := &memAgentRepository{agents: map[string]*models.Agent{
+		"pay-1":    {AgentID: "pay-1", Project: "payments", Labels: map[string]string{"env": "prod"}},
+		"pay-2":    {AgentID: "pay-2", Project: "payments", Labels: map[string]string{"env": "prod"}},
+		"legacy-1": {AgentID: "legacy-1", Labels: map[string]string{"env": "prod"}},
+	}}
+	agents := NewAgentService(agentRepo, configRepo, NewCommandQueue(0), logrus.New())
+	publisher := &chanPublisher{sent: make(chan string, 10)}
+	engine := NewDeploymentEngine(deployRepo, configRepo, agents, publisher, NewDestinationThrottle(1, nil), time.Second, logrus.New())
+
+	// 选择器只匹配同一项目的Agent
+	deployment, err := engine.Start(ctx, &models.CreateDeploymentRequest{ConfigID: "cfg-pay", Selector: "env=prod"}, "admin")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"pay-1", "pay-2"}, deployment.AgentIDs)
+	assert.Equal(t, "payments", deployment.Project)
+
+	// 显式指定其他项目的Agent时拒绝部署
+	_, err = engine.Start(ctx, &models.CreateDeploymentRequest{ConfigID: "cfg-pay", AgentIDs: []string{"pay-1", "legacy-1"}}, "admin")
+	assert.ErrorIs(t, err, ErrProjectMismatch)
+
+	// 其他项目的请求看不到该部署
+	_, err = engine.RecordApproval(models.WithProject(ctx, models.DefaultProject), deployment.ID, "bob", &models.ApproveDeploymentRequest{Decision: "approved"})
+	assert.ErrorIs(t, err, ErrDeploymentNotFound)
+}
+
+func TestDeploymentEngine_Rollback(t *testing.T) {
+	ctx := context.Background()
+	engine, repo, publisher, _ := newCanaryEngine(t, map[string]*models.Agent{
+		"agent-1": {AgentID: "agent-1", Status: "online", AppliedConfigs: []models.AppliedConfig{{ConfigID: "cfg-1", Version: 4}}},
+		"agent-2": {AgentID: "agent-2", Status: "online", AppliedConfigs: []models.AppliedConfig{{ConfigID: "cfg-1", Version: 4}}},
+		"agent-3": {AgentID: "agent-3", Status: "online", AppliedConfigs: []models.AppliedConfig{{ConfigID: "cfg-1", Version: 5}}},
+	})
+	events := &memAgentEventRepository{}
+	engine.SetEventService(NewAgentEventService(events, &memAgentRepository{agents: map[string]*models.Agent{
+		"agent-1": {AgentID: "agent-1"}, "agent-2": {AgentID: "agent-2"}, "agent-3": {AgentID: "agent-3"},
+	}}, logrus.New()))
+
+	original := &models.Deployment{
+		ConfigID:      "cfg-1",
+		ConfigVersion: 4,
+		AgentIDs:      []string{"agent-1", "agent-2", "agent-3", "agent-4"},
+		Status:        models.DeploymentStatusFailed,
+		Results: []models.DeploymentResult{
+			{AgentID: "agent-1", Status: models.DeploymentResultApplied},
+			{AgentID: "agent-2", Status: models.DeploymentResultApplied},
+			{AgentID: "agent-3", Status: models.DeploymentResultApplied},
+			{AgentID: "agent-4", Status: models.DeploymentResultFailed},
+		},
+	}
+	require.NoError(t, repo.Create(ctx, original))
+	// agent-1 部署前应用过版本3，agent-2 部署前没有该配置
+	require.NoError(t, events.Save(ctx, &models.AgentEvent{AgentID: "agent-1", Type: models.AgentEventConfigApplied, ConfigID: "cfg-1", ConfigVersion: 3}))
+	require.NoError(t, events.Save(ctx, &models.AgentEvent{AgentID: "agent-1", Type: models.AgentEventConfigApplied, ConfigID: "cfg-1", ConfigVersion: 4, DeploymentID: original.ID}))
+
+	rollback, err := engine.Rollback(ctx, original.ID, "admin")
+	require.NoError(t, err)
+	assert.Equal(t, models.DeploymentStrategyRollback, rollback.Strategy)
+	assert.Equal(t, original.ID, rollback.RollbackOf)
+	assert.Equal(t, 4, rollback.PreviousVersion)
+	assert.Equal(t, []string{"agent-1", "agent-2"}, rollback.AgentIDs)
+	require.Len(t, rollback.Results, 3)
+	assert.Equal(t, models.DeploymentResultSkipped, rollback.Results[2].Status)
+
+	sent := map[string]publishedMessage{}
+	for range 2 {
+		msg := <-publisher.sent
+		sent[msg.agentID] = msg
+	}
+	assert.Equal(t, models.MsgTypeConfigDeploy, sent["agent-1"].msgType)
+	assert.Equal(t, models.ConfigDeployPayload{ConfigID: "cfg-1", Version: 3, DeploymentID: rollback.ID}, sent["agent-1"].payload)
+	assert.Equal(t, models.MsgTypeConfigDelete, sent["agent-2"].msgType)
+	require.NoError(t, engine.RecordResult(ctx, "agent-1", &models.ConfigAppliedReport{
+		ConfigID: "cfg-1", Version: 3, Status: "success", DeploymentID: rollback.ID,
+	}))
+
+	finished := waitFinished(t, repo, rollback.ID)
+	assert.Equal(t, models.DeploymentStatusCompleted, finished.Status)
+	assert.Equal(t, models.DeploymentResultApplied, finished.Results[0].Status)
+	assert.Equal(t, models.DeploymentResultApplied, finished.Results[1].Status)
+
+	stored, err := repo.GetByID(ctx, original.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.DeploymentStatusRolledBack, stored.Status)
+	assert.Equal(t, rollback.ID, stored.RolledBackBy)
+
+	// 已回滚的部署和回滚部署本身都不能再回滚
+	_, err = engine.Rollback(ctx, original.ID, "admin")
+	assert.ErrorIs(t, err, ErrRollbackNotAllowed)
+	_, err = engine.Rollback(ctx, rollback.ID, "admin")
+	assert.ErrorIs(t, err, ErrRollbackNotAllowed)
+}
+
+func TestDeploymentEngine_RollbackUnfinished(t *testing.T) {
+	ctx := context.Background()
+	engine, repo, _, _ := newCanaryEngine(t, map[string]*models.Agent{})
+
+	running := &models.Deployment{ConfigID: "cfg-1", ConfigVersion: 4, Status: models.DeploymentStatusRunning}
+	require.NoError(t, repo.Create(ctx, running))
+	_, err := engine.Rollback(ctx, running.ID, "admin")
+	assert.ErrorIs(t, err, ErrRollbackNotAllowed)
+
+	_, err = engine.Rollback(ctx, "missing", "admin")
+	assert.ErrorIs(t, err, ErrDeploymentNotFound)
+}
@@ -0,0 +1,305 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// previewRender 按版本和环境缓存的渲染结果，同一环境的Agent共用
+type previewRender struct {
+	content string
+	err     error
+}
+
+// Preview 预览部署，按创建部署的规则确定目标，返回各Agent渲染后的配置内容、与当前运行版本的差异和警告，
+// 不创建部署也不下发任何消息。创建部署时会被拒绝的情况作为阻止性警告返回；
+// 配置不存在、无权部署、目标解析失败等错误与创建部署相同
+func (e *DeploymentEngine) Preview(ctx context.Context, req *models.CreateDeploymentRequest) (*models.DeploymentPreview, error) {
+	if len(req.AgentIDs) == 0 && req.Selector == "" {
+		return nil, fmt.Errorf("必须指定Agent ID列表或标签选择器")
+	}
+
+	config, err := e.configRepo.GetByID(ctx, req.ConfigID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConfigNotFound, err)
+	}
+	if err := authorizeConfig(ctx, config, models.PermissionDeploy); err != nil {
+		return nil, err
+	}
+
+	targets, err := e.resolveTargets(ctx, req, models.ProjectOf(config.Project))
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) == 0 {
+		return nil, ErrNoTargets
+	}
+	canary, err := planCanary(req, targets)
+	if err != nil {
+		return nil, err
+	}
+	rollout, err := planRollout(req, len(rolloutAgents(targets, canary)))
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &models.DeploymentPreview{
+		ConfigID:      config.ID,
+		ConfigName:    config.Name,
+		ConfigVersion: config.Version,
+		Strategy:      models.DeploymentStrategyAll,
+		Agents:        make([]models.AgentDeploymentPreview, 0, len(targets)),
+		Warnings:      []models.DeploymentPreviewWarning{},
+	}
+	if canary != nil {
+		preview.Strategy = models.DeploymentStrategyCanary
+		preview.CanaryAgentIDs = canary.AgentIDs
+	}
+	if rollout != nil {
+		preview.Batches = rolloutBatches(rolloutAgents(targets, canary), rollout.Options.BatchSize)
+	}
+
+	warnings, err := e.previewConfigWarnings(ctx, config, req.SkipTestGate)
+	if err != nil {
+		return nil, err
+	}
+	preview.Warnings = append(preview.Warnings, warnings...)
+
+	if e.plugins != nil {
+		checks, err := e.plugins.Check(ctx, config, targets)
+		if err != nil {
+			return nil, fmt.Errorf("检查插件兼容性失败: %w", err)
+		}
+		for _, check := range checks {
+			if len(check.Missing) > 0 {
+				preview.Warnings = append(preview.Warnings, models.DeploymentPreviewWarning{
+					Type:     models.PreviewWarningMissingPlugins,
+					AgentID:  check.AgentID,
+					Message:  fmt.Sprintf("缺少插件 %s", strings.Join(check.Missing, "、")),
+					Blocking: true,
+				})
+			}
+		}
+	}
+
+	renders := make(map[string]previewRender)
+	rollbacks := make(map[string]bool) // 部署ID -> 是否为回滚部署
+	for _, agentID := range targets {
+		item, warnings, err := e.previewAgent(ctx, config, agentID, renders, rollbacks)
+		if err != nil {
+			return nil, err
+		}
+		preview.Agents = append(preview.Agents, item)
+		preview.Warnings = append(preview.Warnings, warnings...)
+	}
+
+	preview.Deployable = true
+	for _, w := range preview.Warnings {
+		if w.Blocking {
+			preview.Deployable = false
+		}
+	}
+	return preview, nil
+}
+
+// previewConfigWarnings 针对整个部署的警告：禁用、审批、测试门禁和缺失的密钥
+func (e *DeploymentEngine) previewConfigWarnings(ctx context.Context, config *models.Config, skipTestGate bool) ([]models.DeploymentPreviewWarning, error) {
+	var warnings []models.DeploymentPreviewWarning
+	if !config.Enabled {
+		warnings = append(warnings, models.DeploymentPreviewWarning{
+			Type: models.PreviewWarningDisabled, Message: ErrConfigDisabled.Error(), Blocking: true,
+		})
+	}
+
+	if e.approvals != nil {
+		if err := e.approvals.CheckDeployable(ctx, config); err != nil {
+			if !errors.Is(err, ErrConfigNotApproved) {
+				return nil, err
+			}
+			warnings = append(warnings, models.DeploymentPreviewWarning{
+				Type: models.PreviewWarningNotApproved, Message: err.Error(), Blocking: true,
+			})
+		}
+	}
+
+	// 未启用测试门禁时未通过测试不阻止部署，仍提示
+	switch {
+	case e.testGate != nil:
+		if err := e.testGate.checkTested(config); err != nil {
+			warning := models.DeploymentPreviewWarning{Type: models.PreviewWarningUntested, Message: err.Error(), Blocking: true}
+			if skipTestGate {
+				if err := e.testGate.checkOverride(ctx); err != nil {
+					warning.Message = err.Error()
+				} else {
+					warning.Blocking = false
+				}
+			}
+			warnings = append(warnings, warning)
+		}
+	case config.TestStatus != models.TestStatusPassed:
+		warnings = append(warnings, models.DeploymentPreviewWarning{
+			Type:    models.PreviewWarningUntested,
+			Message: fmt.Sprintf("版本 %d 的测试状态为 %s", config.Version, config.TestStatus),
+		})
+	}
+
+	if e.secrets != nil {
+		var missing []string
+		for _, name := range models.SecretReferences(config.Content) {
+			if _, err := e.secrets.GetSecret(ctx, name); err != nil {
+				if !errors.Is(err, elasticsearch.ErrNotFound) {
+					return nil, err
+				}
+				missing = append(missing, name)
+			}
+		}
+		if len(missing) > 0 {
+			warnings = append(warnings, models.DeploymentPreviewWarning{
+				Type:    models.PreviewWarningMissingSecrets,
+				Message: fmt.Sprintf("配置引用的密钥 %s 不存在，Agent获取密钥时部署失败", strings.Join(missing, "、")),
+			})
+		}
+	}
+	return warnings, nil
+}
+
+// previewAgent 单个Agent的预览：按其所在环境渲染新版本和当前运行的版本并比较
+// 当前运行版本的历史记录已被清理时不计算差异，只标记为有变更
+func (e *DeploymentEngine) previewAgent(ctx context.Context, config *models.Config, agentID string,
+	renders map[string]previewRender, rollbacks map[string]bool) (models.AgentDeploymentPreview, []models.DeploymentPreviewWarning, error) {
+	item := models.AgentDeploymentPreview{AgentID: agentID, Environment: models.DefaultEnvironment}
+	var warnings []models.DeploymentPreviewWarning
+
+	var applied *models.AppliedConfig
+	agent, err := e.previewTarget(ctx, agentID)
+	if err != nil {
+		return item, nil, err
+	}
+	if agent == nil {
+		warnings = append(warnings, models.DeploymentPreviewWarning{
+			Type: models.PreviewWarningUnregistered, AgentID: agentID, Message: "Agent尚未注册，上线后才会下发",
+		})
+	} else {
+		item.Hostname = agent.Hostname
+		if agent.Environment != "" {
+			item.Environment = agent.Environment
+		}
+		for i := range agent.AppliedConfigs {
+			if agent.AppliedConfigs[i].ConfigID == config.ID {
+				applied = &agent.AppliedConfigs[i]
+			}
+		}
+	}
+
+	if applied != nil {
+		item.CurrentVersion = applied.Version
+		if e.rolledBack(ctx, applied.DeploymentID, rollbacks) && applied.Version != config.Version {
+			warnings = append(warnings, models.DeploymentPreviewWarning{
+				Type:    models.PreviewWarningPinned,
+				AgentID: agentID,
+				Message: fmt.Sprintf("运行的版本 %d 由回滚部署 %s 恢复，部署将覆盖回滚", applied.Version, applied.DeploymentID),
+			})
+		}
+	}
+
+	content, err := e.renderPreview(ctx, config, item.Environment, renders)
+	if err != nil {
+		warnings = append(warnings, models.DeploymentPreviewWarning{
+			Type: models.PreviewWarningRenderFailed, AgentID: agentID, Message: err.Error(),
+		})
+		item.Changed = item.CurrentVersion != config.Version
+		return item, warnings, nil
+	}
+	item.Content = content
+
+	oldName, oldContent := "/dev/null", ""
+	if item.CurrentVersion > 0 {
+		history, err := e.configRepo.GetHistoryVersion(ctx, config.ID, item.CurrentVersion)
+		if err != nil && !errors.Is(err, elasticsearch.ErrNotFound) {
+			return item, nil, fmt.Errorf("获取版本 %d 的历史记录失败: %w", item.CurrentVersion, err)
+		}
+		if err != nil {
+			item.Changed = item.CurrentVersion != config.Version
+			return item, warnings, nil
+		}
+		current := *config
+		current.Version = history.Version
+		current.Content = history.Content
+		if oldContent, err = e.renderPreview(ctx, &current, item.Environment, renders); err != nil {
+			// 旧版本引用的下游集群已删除时与未渲染的内容比较
+			oldContent = history.Content
+		}
+		oldName = fmt.Sprintf("%s@v%d", config.ID, item.CurrentVersion)
+	}
+
+	lines := DiffLines(oldContent, content)
+	hunks := DiffHunks(lines, diffContextLines)
+	item.Unified = UnifiedDiff(oldName, fmt.Sprintf("%s@v%d", config.ID, config.Version), hunks)
+	item.Additions, item.Deletions = countDiffLines(lines)
+	item.Changed = item.Unified != ""
+	return item, warnings, nil
+}
+
+// previewTarget 获取目标Agent，尚未注册时返回nil
+func (e *DeploymentEngine) previewTarget(ctx context.Context, agentID string) (*models.Agent, error) {
+	if e.agents == nil {
+		return nil, nil
+	}
+	agent, err := e.agents.GetAgent(ctx, agentID)
+	if err != nil {
+		if errors.Is(err, elasticsearch.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("获取Agent %s 失败: %w", agentID, err)
+	}
+	return agent, nil
+}
+
+// rolledBack Agent当前的版本是否由回滚部署下发，结果按部署ID缓存
+func (e *DeploymentEngine) rolledBack(ctx context.Context, deploymentID string, cache map[string]bool) bool {
+	if deploymentID == "" {
+		return false
+	}
+	if rollback, ok := cache[deploymentID]; ok {
+		return rollback
+	}
+	deployment, err := e.deployRepo.GetByID(ctx, deploymentID)
+	rollback := err == nil && deployment.RollbackOf != ""
+	if err != nil {
+		e.logger.WithError(err).WithField("deployment_id", deploymentID).Debug("获取部署记录失败，不检查回滚")
+	}
+	cache[deploymentID] = rollback
+	return rollback
+}
+
+// renderPreview 按Agent拉取配置时的方式渲染下游集群引用，密钥引用替换为keystore引用，不解密密钥
+func (e *DeploymentEngine) renderPreview(ctx context.Context, config *models.Config, environment string, cache map[string]previewRender) (string, error) {
+	key := fmt.Sprintf("%d/%s", config.Version, environment)
+	if cached, ok := cache[key]; ok {
+		return cached.content, cached.err
+	}
+
+	content := config.Content
+	var err error
+	if e.destinations != nil {
+		var rendered *models.RenderedConfig
+		if rendered, err = e.destinations.RenderConfig(ctx, config, environment); err == nil {
+			content = rendered.Content
+		}
+	}
+	if err == nil {
+		content = models.ReplaceSecretReferences(content, func(name string) string {
+			return "${" + name + "}"
+		})
+	} else {
+		content = ""
+	}
+
+	cache[key] = previewRender{content: content, err: err}
+	return content, err
+}
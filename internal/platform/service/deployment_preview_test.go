@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/elasticsearch"
+	"logstash-platform/tests/mocks"
+)
+
+// envDestinations 将配置中的 {{env}} 替换为渲染所用的环境
+type envDestinations struct {
+	DestinationService
+}
+
+func (envDestinations) RenderConfig(ctx context.Context, config *models.Config, environment string) (*models.RenderedConfig, error) {
+	return &models.RenderedConfig{ConfigID: config.ID, Version: config.Version, Environment: environment,
+		Content: strings.ReplaceAll(config.Content, "{{env}}", environment)}, nil
+}
+
+// missingSecrets 所有密钥都不存在
+type missingSecrets struct {
+	SecretService
+}
+
+func (missingSecrets) GetSecret(ctx context.Context, name string) (*models.Secret, error) {
+	return nil, fmt.Errorf("%w: %w", ErrSecretNotFound, elasticsearch.ErrNotFound)
+}
+
+// missingPlugins 固定返回插件检查结果
+type missingPlugins struct {
+	PluginInventoryService
+	checks []models.AgentPluginCheck
+}
+
+func (m missingPlugins) Check(ctx context.Context, config *models.Config, agentIDs []string) ([]models.AgentPluginCheck, error) {
+	return m.checks, nil
+}
+
+func TestDeploymentEngine_Preview(t *testing.T) {
+	ctx := context.Background()
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	current := "output {\n  elasticsearch {\n    hosts => \"es-{{env}}:9200\"\n    password => \"${secret:es_pass}\"\n  }\n}\n"
+	configRepo := new(mocks.MockConfigRepository)
+	configRepo.On("GetByID", mock.Anything, "cfg-1").Return(&models.Config{ID: "cfg-1", Name: "nginx", Version: 4, Enabled: true,
+		Content: strings.Replace(current, "9200", "9201", 1), TestStatus: models.TestStatusUntested}, nil)
+	configRepo.On("GetHistoryVersion", mock.Anything, "cfg-1", 3).Return(&models.ConfigHistory{ConfigID: "cfg-1", Version: 3, Content: current}, nil)
+	configRepo.On("GetHistoryVersion", mock.Anything, "cfg-1", 4).Return(&models.ConfigHistory{ConfigID: "cfg-1", Version: 4,
+		Content: strings.Replace(current, "9200", "9201", 1)}, nil)
+
+	deployRepo := &memDeploymentRepository{deployments: make(map[string]models.Deployment)}
+	rollback := &models.Deployment{ConfigID: "cfg-1", ConfigVersion: 3, Strategy: models.DeploymentStrategyRollback, RollbackOf: "dep-0"}
+	require.NoError(t, deployRepo.Create(ctx, rollback))
+
+	agents := NewAgentService(&memAgentRepository{agents: map[string]*models.Agent{
+		"agent-1": {AgentID: "agent-1", Hostname: "web-1", Environment: "prod",
+			AppliedConfigs: []models.AppliedConfig{{ConfigID: "cfg-1", Version: 3, DeploymentID: rollback.ID}}},
+		"agent-2": {AgentID: "agent-2", AppliedConfigs: []models.AppliedConfig{{ConfigID: "cfg-1", Version: 4}}},
+	}}, configRepo, NewCommandQueue(0), logger)
+	publisher := &chanPublisher{sent: make(chan string, 10)}
+	engine := NewDeploymentEngine(deployRepo, configRepo, agents, publisher, nil, 0, logger)
+	engine.SetDestinationService(envDestinations{})
+	engine.SetSecretService(missingSecrets{})
+	engine.SetTestGate(NewTestGate(0, "", logger))
+	engine.SetPluginInventory(missingPlugins{checks: []models.AgentPluginCheck{
+		{AgentID: "agent-2", Missing: []string{"logstash-filter-translate"}},
+		{AgentID: "agent-3", Unknown: true},
+	}})
+
+	req := &models.CreateDeploymentRequest{ConfigID: "cfg-1", AgentIDs: []string{"agent-1", "agent-2", "agent-3"}}
+	preview, err := engine.Preview(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, preview.Agents, 3)
+	assert.Len(t, deployRepo.deployments, 1, "预览不创建部署")
+	assert.Empty(t, publisher.sent, "预览不下发消息")
+
+	t.Run("按Agent所在环境渲染，密钥显示为keystore引用", func(t *testing.T) {
+		prod := preview.Agents[0]
+		assert.Equal(t, "web-1", prod.Hostname)
+		assert.Equal(t, "prod", prod.Environment)
+		assert.Contains(t, prod.Content, `hosts => "es-prod:9201"`)
+		assert.Contains(t, prod.Content, `password => "${es_pass}"`)
+		assert.Equal(t, models.DefaultEnvironment, preview.Agents[1].Environment)
+	})
+
+	t.Run("与当前运行的版本比较", func(t *testing.T) {
+		prod := preview.Agents[0]
+		assert.Equal(t, 3, prod.CurrentVersion)
+		assert.True(t, prod.Changed)
+		assert.Equal(t, 1, prod.Additions)
+		assert.Equal(t, 1, prod.Deletions)
+		assert.Contains(t, prod.Unified, "--- cfg-1@v3\n+++ cfg-1@v4\n")
+		assert.Contains(t, prod.Unified, "-    hosts => \"es-prod:9200\"\n+    hosts => \"es-prod:9201\"\n")
+
+		assert.False(t, preview.Agents[1].Changed, "已运行当前版本")
+		assert.Empty(t, preview.Agents[1].Unified)
+
+		unregistered := preview.Agents[2]
+		assert.Zero(t, unregistered.CurrentVersion)
+		assert.True(t, unregistered.Changed)
+		assert.Contains(t, unregistered.Unified, "--- /dev/null\n")
+	})
+
+	t.Run("警告", func(t *testing.T) {
+		byType := make(map[string]models.DeploymentPreviewWarning)
+		for _, w := range preview.Warnings {
+			byType[w.Type] = w
+		}
+		assert.True(t, byType[models.PreviewWarningUntested].Blocking)
+		assert.Equal(t, "agent-2", byType[models.PreviewWarningMissingPlugins].AgentID)
+		assert.True(t, byType[models.PreviewWarningMissingPlugins].Blocking)
+		assert.Contains(t, byType[models.PreviewWarningMissingSecrets].Message, "es_pass")
+		assert.Equal(t, "agent-1", byType[models.PreviewWarningPinned].AgentID)
+		assert.False(t, byType[models.PreviewWarningPinned].Blocking)
+		assert.Equal(t, "agent-3", byType[models.PreviewWarningUnregistered].AgentID)
+		assert.Len(t, preview.Warnings, 5)
+		assert.False(t, preview.Deployable)
+	})
+
+	t.Run("跳过测试门禁时未通过测试不阻止部署", func(t *testing.T) {
+		engine.SetPluginInventory(missingPlugins{})
+		req := &models.CreateDeploymentRequest{ConfigID: "cfg-1", AgentIDs: []string{"agent-2"}, SkipTestGate: true}
+		preview, err := engine.Preview(ctx, req)
+		require.NoError(t, err)
+		for _, w := range preview.Warnings {
+			assert.False(t, w.Blocking, w.Type)
+		}
+		assert.True(t, preview.Deployable)
+	})
+
+	t.Run("金丝雀以外的Agent分批", func(t *testing.T) {
+		req := &models.CreateDeploymentRequest{ConfigID: "cfg-1", AgentIDs: []string{"agent-1", "agent-2", "agent-3"},
+			Strategy: models.DeploymentStrategyCanary, Rollout: &models.RolloutOptions{BatchSize: 1}, SkipTestGate: true}
+		preview, err := engine.Preview(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"agent-1"}, preview.CanaryAgentIDs)
+		assert.Equal(t, [][]string{{"agent-2"}, {"agent-3"}}, preview.Batches)
+	})
+
+	t.Run("没有匹配的Agent", func(t *testing.T) {
+		_, err := engine.Preview(ctx, &models.CreateDeploymentRequest{ConfigID: "cfg-1", Selector: "env=none"})
+		assert.ErrorIs(t, err, ErrNoTargets)
+	})
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+)
+
+// ErrDeploymentInProgress 部署尚未结束，不能生成报告
+var ErrDeploymentInProgress = errors.New("部署尚未完成")
+
+// DeploymentService 部署服务接口
+type DeploymentService interface {
+	GetDeployment(ctx context.Context, id string) (*models.Deployment, error)
+	ListDeployments(ctx context.Context, req *models.DeploymentListRequest) ([]*models.Deployment, int64, error)
+	RenderReport(ctx context.Context, id string) ([]byte, error)
+	// DesiredConfigs 按部署记录推导Agent应运行的配置及版本，Agent启动或重新连接后据此自愈
+	DesiredConfigs(ctx context.Context, agentID string) (*models.AgentDesiredConfigs, error)
+}
+
+// deploymentService 部署服务实现
+type deploymentService struct {
+	deployRepo repository.DeploymentRepository
+	configRepo repository.ConfigRepository
+	logger     *logrus.Logger
+}
+
+// NewDeploymentService 创建部署服务
+func NewDeploymentService(deployRepo repository.DeploymentRepository, configRepo repository.ConfigRepository, logger *logrus.Logger) DeploymentService {
+	return &deploymentService{
+		deployRepo: deployRepo,
+		configRepo: configRepo,
+		logger:     logger,
+	}
+}
+
+// GetDeployment 获取部署记录，其他项目的部署记录按不存在处理
+func (s *deploymentService) GetDeployment(ctx context.Context, id string) (*models.Deployment, error) {
+	deployment, err := s.deployRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !models.InProject(ctx, deployment.Project) {
+		return nil, fmt.Errorf("文档不存在")
+	}
+	return deployment, nil
+}
+
+// ListDeployments 获取部署记录列表
+func (s *deploymentService) ListDeployments(ctx context.Context, req *models.DeploymentListRequest) ([]*models.Deployment, int64, error) {
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 10
+	}
+	req.Project = models.ProjectFrom(ctx)
+	return s.deployRepo.List(ctx, req)
+}
+
+// deploymentReport 报告模板数据
+type deploymentReport struct {
+	Deployment    *models.Deployment
+	Content       string
+	ContentSHA256 string
+	Diff          []DiffLine
+	GeneratedAt   time.Time
+}
+
+// RenderReport 将已结束的部署渲染为独立的HTML审计报告
+// 报告不依赖外部资源，可直接作为变更审批的附件，或通过浏览器打印为PDF
+func (s *deploymentService) RenderReport(ctx context.Context, id string) ([]byte, error) {
+	deployment, err := s.GetDeployment(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDeploymentNotFound, err)
+	}
+
+	if !deployment.IsFinished() {
+		return nil, fmt.Errorf("%w: %s", ErrDeploymentInProgress, deployment.Status)
+	}
+
+	content, previous, err := s.versionContents(ctx, deployment)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := sha256.Sum256([]byte(content))
+	report := deploymentReport{
+		Deployment:    deployment,
+		Content:       content,
+		ContentSHA256: hex.EncodeToString(digest[:]),
+		Diff:          DiffLines(previous, content),
+		GeneratedAt:   time.Now(),
+	}
+
+	var buf bytes.Buffer
+	if err := reportTemplate.Execute(&buf, report); err != nil {
+		return nil, fmt.Errorf("渲染部署报告失败: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// versionContents 从配置历史中取出部署版本及前一版本的内容
+func (s *deploymentService) versionContents(ctx context.Context, deployment *models.Deployment) (string, string, error) {
+	histories, err := s.configRepo.GetHistory(ctx, deployment.ConfigID)
+	if err != nil {
+		return "", "", fmt.Errorf("获取配置历史失败: %w", err)
+	}
+
+	var content, previous string
+	found := false
+	for _, h := range histories {
+		switch h.Version {
+		case deployment.ConfigVersion:
+			content = h.Content
+			found = true
+		case deployment.PreviousVersion:
+			previous = h.Content
+		}
+	}
+
+	if !found {
+		return "", "", fmt.Errorf("配置版本不存在: %d", deployment.ConfigVersion)
+	}
+
+	return content, previous, nil
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"fmtTime": func(t interface{}) string {
+		switch v := t.(type) {
+		case time.Time:
+			return v.Format(time.RFC3339)
+		case *time.Time:
+			if v != nil {
+				return v.Format(time.RFC3339)
+			}
+		}
+		return "-"
+	},
+	"diffPrefix": func(op DiffOp) string {
+		switch op {
+		case DiffOpAdd:
+			return "+"
+		case DiffOpDelete:
+			return "-"
+		}
+		return " "
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<title>部署报告 {{.Deployment.ID}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 1.5em; }
+th, td { border: 1px solid #999; padding: 4px 8px; text-align: left; font-size: 13px; }
+th { background: #eee; }
+pre { background: #f7f7f7; border: 1px solid #ccc; padding: 8px; font-size: 12px; white-space: pre-wrap; }
+.add { background: #e6ffed; }
+.delete { background: #ffeef0; }
+@media print { body { margin: 0; } tr, pre span { page-break-inside: avoid; } }
+</style>
+</head>
+<body>
+<h1>部署报告</h1>
+<table>
+<tr><th>部署ID</th><td>{{.Deployment.ID}}</td></tr>
+<tr><th>配置</th><td>{{.Deployment.ConfigName}} ({{.Deployment.ConfigID}})</td></tr>
+<tr><th>版本</th><td>{{.Deployment.PreviousVersion}} → {{.Deployment.ConfigVersion}}</td></tr>
+<tr><th>状态</th><td>{{.Deployment.Status}}</td></tr>
+<tr><th>发起人</th><td>{{.Deployment.CreatedBy}}</td></tr>
+<tr><th>创建时间</th><td>{{fmtTime .Deployment.CreatedAt}}</td></tr>
+<tr><th>开始时间</th><td>{{fmtTime .Deployment.StartedAt}}</td></tr>
+<tr><th>完成时间</th><td>{{fmtTime .Deployment.CompletedAt}}</td></tr>
+<tr><th>配置内容SHA-256</th><td><code>{{.ContentSHA256}}</code></td></tr>
+</table>
+
+<h2>审批记录</h2>
+{{if .Deployment.Approvals}}
+<table>
+<tr><th>审批人</th><th>结论</th><th>意见</th><th>时间</th></tr>
+{{range .Deployment.Approvals}}<tr><td>{{.Approver}}</td><td>{{.Decision}}</td><td>{{.Comment}}</td><td>{{fmtTime .DecidedAt}}</td></tr>
+{{end}}</table>
+{{else}}<p>无审批记录</p>{{end}}
+
+<h2>Agent执行结果</h2>
+<table>
+<tr><th>Agent ID</th><th>结果</th><th>信息</th><th>开始时间</th><th>结束时间</th></tr>
+{{range .Deployment.Results}}<tr><td>{{.AgentID}}</td><td>{{.Status}}</td><td>{{.Message}}</td><td>{{fmtTime .StartedAt}}</td><td>{{fmtTime .FinishedAt}}</td></tr>
+{{end}}</table>
+
+<h2>配置变更</h2>
+<pre>{{range .Diff}}<span class="{{.Op}}">{{diffPrefix .Op}} {{.Text}}</span>
+{{end}}</pre>
+
+<p>报告生成时间：{{fmtTime .GeneratedAt}}</p>
+</body>
+</html>
+`))
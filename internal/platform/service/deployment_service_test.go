@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/elasticsearch"
+	"logstash-platform/tests/mocks"
+)
+
+func TestDiffLines(t *testing.T) {
+	diff := DiffLines("a\nb\nc\n", "a\nc\nd\n")
+
+	assert.Equal(t, []DiffLine{
+		{Op: DiffOpEqual, Text: "a"},
+		{Op: DiffOpDelete, Text: "b"},
+		{Op: DiffOpEqual, Text: "c"},
+		{Op: DiffOpAdd, Text: "d"},
+	}, diff)
+	assert.Empty(t, DiffLines("", ""))
+}
+
+func TestDeploymentService_RenderReport(t *testing.T) {
+	logger := logrus.New()
+	ctx := context.Background()
+	finished := time.Now()
+
+	t.Run("completed deployment", func(t *testing.T) {
+		deployRepo := new(mocks.MockDeploymentRepository)
+		configRepo := new(mocks.MockConfigRepository)
+
+		deployRepo.On("GetByID", ctx, "dep-1").Return(&models.Deployment{
+			ID:              "dep-1",
+			ConfigID:        "cfg-1",
+			ConfigName:      "nginx-filter",
+			ConfigVersion:   2,
+			PreviousVersion: 1,
+			Status:          models.DeploymentStatusCompleted,
+			CreatedBy:       "alice",
+			Results: []models.DeploymentResult{
+				{AgentID: "agent-1", Status: "success", FinishedAt: &finished},
+			},
+			Approvals: []models.DeploymentApproval{
+				{Approver: "bob", Decision: "approved", DecidedAt: finished},
+			},
+		}, nil)
+		configRepo.On("GetHistory", ctx, "cfg-1").Return([]*models.ConfigHistory{
+			{Version: 2, Content: "filter {\n  mutate {}\n}"},
+			{Version: 1, Content: "filter {\n}"},
+		}, nil)
+
+		svc := NewDeploymentService(deployRepo, configRepo, logger)
+		report, err := svc.RenderReport(ctx, "dep-1")
+
+		assert.NoError(t, err)
+		html := string(report)
+		assert.Contains(t, html, "nginx-filter")
+		assert.Contains(t, html, "agent-1")
+		assert.Contains(t, html, "bob")
+		assert.Contains(t, html, `<span class="add">&#43;   mutate {}</span>`)
+	})
+
+	t.Run("deployment in progress", func(t *testing.T) {
+		deployRepo := new(mocks.MockDeploymentRepository)
+		configRepo := new(mocks.MockConfigRepository)
+		deployRepo.On("GetByID", ctx, "dep-2").Return(&models.Deployment{
+			ID:     "dep-2",
+			Status: models.DeploymentStatusRunning,
+		}, nil)
+
+		svc := NewDeploymentService(deployRepo, configRepo, logger)
+		_, err := svc.RenderReport(ctx, "dep-2")
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "部署尚未完成")
+		configRepo.AssertNotCalled(t, "GetHistory")
+	})
+}
+
+func TestDeploymentService_DesiredConfigs(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	at := func(minutes int) time.Time { return now.Add(time.Duration(minutes) * time.Minute) }
+	result := func(status string) []models.DeploymentResult {
+		return []models.DeploymentResult{{AgentID: "agent-1", Status: status, StartedAt: &now}}
+	}
+
+	deployRepo := new(mocks.MockDeploymentRepository)
+	configRepo := new(mocks.MockConfigRepository)
+	deployRepo.On("List", ctx, mock.MatchedBy(func(req *models.DeploymentListRequest) bool {
+		return req.AgentID == "agent-1"
+	})).Return([]*models.Deployment{
+		// nginx：v3失败，Agent上仍是v2
+		{ID: "dep-1", ConfigID: "nginx", ConfigVersion: 2, Status: models.DeploymentStatusCompleted, CreatedAt: at(1), Results: result(models.DeploymentResultApplied)},
+		{ID: "dep-2", ConfigID: "nginx", ConfigVersion: 3, Status: models.DeploymentStatusFailed, CreatedAt: at(2), Results: result(models.DeploymentResultFailed)},
+		// kafka：已下发、Agent尚未上报
+		{ID: "dep-3", ConfigID: "kafka", ConfigVersion: 5, Status: models.DeploymentStatusRunning, CreatedAt: at(3), Results: result(models.DeploymentResultPending)},
+		// beats：回滚部署删除了原部署前不存在的配置
+		{ID: "dep-4", ConfigID: "beats", ConfigVersion: 1, Status: models.DeploymentStatusCompleted, CreatedAt: at(4), Results: result(models.DeploymentResultApplied)},
+		{ID: "dep-5", ConfigID: "beats", ConfigVersion: 1, Strategy: models.DeploymentStrategyRollback, Status: models.DeploymentStatusCompleted, CreatedAt: at(5), Results: result(models.DeploymentResultApplied)},
+		// syslog：等待审批，尚未下发
+		{ID: "dep-6", ConfigID: "syslog", ConfigVersion: 1, Status: models.DeploymentStatusPending, CreatedAt: at(6), Results: result(models.DeploymentResultPending)},
+		// removed：配置已从平台删除
+		{ID: "dep-7", ConfigID: "removed", ConfigVersion: 1, Status: models.DeploymentStatusCompleted, CreatedAt: at(7), Results: result(models.DeploymentResultApplied)},
+	}, int64(7), nil)
+	configRepo.On("GetByID", ctx, "nginx").Return(&models.Config{ID: "nginx"}, nil)
+	configRepo.On("GetByID", ctx, "kafka").Return(&models.Config{ID: "kafka"}, nil)
+	configRepo.On("GetByID", ctx, "removed").Return(nil, elasticsearch.ErrNotFound)
+
+	svc := NewDeploymentService(deployRepo, configRepo, logrus.New())
+	desired, err := svc.DesiredConfigs(ctx, "agent-1")
+	require.NoError(t, err)
+
+	assert.Equal(t, "agent-1", desired.AgentID)
+	assert.Equal(t, []models.AgentDesiredConfig{
+		{ConfigID: "kafka", Version: 5, DeploymentID: "dep-3"},
+		{ConfigID: "nginx", Version: 2, DeploymentID: "dep-1"},
+	}, desired.Configs)
+}
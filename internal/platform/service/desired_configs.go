@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// desiredConfigScanLimit 推导期望配置时最多查看的部署记录数，按创建时间倒序
+const desiredConfigScanLimit = 1000
+
+// DesiredConfigs 按向该Agent下发过的部署记录推导其应运行的配置
+// 每个配置取最近一次对该Agent生效的部署：已应用或已下发尚未上报时为部署的版本，
+// 回滚部署为其恢复的版本，恢复为部署前不存在（版本0）时该配置不应存在；
+// 失败、跳过和金丝雀已回滚的结果没有改变Agent上的配置，继续查看更早的部署。
+// 等待审批的部署尚未下发，平台上已删除的配置不再返回
+func (s *deploymentService) DesiredConfigs(ctx context.Context, agentID string) (*models.AgentDesiredConfigs, error) {
+	deployments, _, err := s.deployRepo.List(ctx, &models.DeploymentListRequest{
+		AgentID:  agentID,
+		Page:     1,
+		PageSize: desiredConfigScanLimit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("查询Agent的部署记录失败: %w", err)
+	}
+	sort.SliceStable(deployments, func(i, j int) bool { return deployments[i].CreatedAt.After(deployments[j].CreatedAt) })
+
+	decided := make(map[string]bool)
+	var configs []models.AgentDesiredConfig
+	for _, deployment := range deployments {
+		if decided[deployment.ConfigID] || deployment.Status == models.DeploymentStatusPending {
+			continue
+		}
+		version, ok := desiredVersion(deployment, agentID)
+		if !ok {
+			continue
+		}
+		decided[deployment.ConfigID] = true
+		if version == 0 {
+			continue
+		}
+
+		if _, err := s.configRepo.GetByID(ctx, deployment.ConfigID); err != nil {
+			if errors.Is(err, elasticsearch.ErrNotFound) {
+				continue
+			}
+			return nil, fmt.Errorf("获取配置 %s 失败: %w", deployment.ConfigID, err)
+		}
+		configs = append(configs, models.AgentDesiredConfig{
+			ConfigID:     deployment.ConfigID,
+			Version:      version,
+			DeploymentID: deployment.ID,
+		})
+	}
+
+	sort.Slice(configs, func(i, j int) bool { return configs[i].ConfigID < configs[j].ConfigID })
+	if configs == nil {
+		configs = []models.AgentDesiredConfig{}
+	}
+	return &models.AgentDesiredConfigs{AgentID: agentID, Configs: configs, GeneratedAt: time.Now()}, nil
+}
+
+// desiredVersion 部署对该Agent生效时返回Agent上应有的版本，0表示配置应被删除；
+// 未对该Agent生效（失败、跳过、尚未下发）时返回false
+func desiredVersion(deployment *models.Deployment, agentID string) (int, bool) {
+	for _, result := range deployment.Results {
+		if result.AgentID != agentID {
+			continue
+		}
+		switch result.Status {
+		case models.DeploymentResultApplied:
+		case models.DeploymentResultPending:
+			// 金丝雀部署中尚未推广到的Agent还没有收到配置
+			if result.StartedAt == nil {
+				return 0, false
+			}
+		default:
+			return 0, false
+		}
+		if deployment.Strategy == models.DeploymentStrategyRollback {
+			return result.Version, true
+		}
+		return deployment.ConfigVersion, true
+	}
+	return 0, false
+}
@@ -0,0 +1,375 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+)
+
+// DesiredStateService 声明式期望状态服务接口
+type DesiredStateService interface {
+	Plan(ctx context.Context, state *models.DesiredState) (*models.ApplyPlan, error)
+	Apply(ctx context.Context, state *models.DesiredState, userID string) (*models.ApplyPlan, error)
+}
+
+// desiredStateService 声明式期望状态服务实现
+// 基于已有的配置和分组服务计算差异并执行，复用其校验和历史记录逻辑
+type desiredStateService struct {
+	configService ConfigService
+	groupService  GroupService
+	agentRepo     repository.AgentRepository
+	logger        *logrus.Logger
+}
+
+// NewDesiredStateService 创建声明式期望状态服务
+func NewDesiredStateService(configService ConfigService, groupService GroupService, agentRepo repository.AgentRepository, logger *logrus.Logger) DesiredStateService {
+	return &desiredStateService{
+		configService: configService,
+		groupService:  groupService,
+		agentRepo:     agentRepo,
+		logger:        logger,
+	}
+}
+
+// currentState 当前平台状态
+type currentState struct {
+	configs map[string]*models.Config
+	groups  map[string]*models.AgentGroup
+}
+
+// Plan 计算期望状态与当前状态的差异
+func (s *desiredStateService) Plan(ctx context.Context, state *models.DesiredState) (*models.ApplyPlan, error) {
+	plan, _, err := s.plan(ctx, state)
+	return plan, err
+}
+
+// plan 加载当前状态并计算差异，返回计划及其所基于的当前状态
+func (s *desiredStateService) plan(ctx context.Context, state *models.DesiredState) (*models.ApplyPlan, *currentState, error) {
+	if err := validateDesiredState(state); err != nil {
+		return nil, nil, err
+	}
+
+	current, err := s.loadCurrent(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	plan := &models.ApplyPlan{Summary: make(map[string]int)}
+	declaredConfigs := make(map[string]bool)
+	for _, dc := range state.Configs {
+		declaredConfigs[dc.Name] = true
+		action := models.PlanAction{Kind: "config", Name: dc.Name, Action: models.PlanActionCreate}
+		if existing, ok := current.configs[dc.Name]; ok {
+			action.Changes = configChanges(existing, &dc)
+			action.Action = models.PlanActionUpdate
+			if len(action.Changes) == 0 {
+				action.Action = models.PlanActionNoop
+			}
+		}
+		plan.Actions = append(plan.Actions, action)
+	}
+
+	// 分组是平台级资源，只凭项目内角色不能变更，清理时也不删除分组
+	manageGroups := models.PrincipalFrom(ctx).PlatformAllows(models.RoleEditor)
+	declaredGroups := make(map[string]bool)
+	for _, dg := range state.Groups {
+		declaredGroups[dg.Name] = true
+		action := models.PlanAction{Kind: "group", Name: dg.Name, Action: models.PlanActionCreate}
+		if existing, ok := current.groups[dg.Name]; ok {
+			action.Changes = groupChanges(existing, &dg)
+			action.Action = models.PlanActionUpdate
+			if len(action.Changes) == 0 {
+				action.Action = models.PlanActionNoop
+			}
+		}
+		if action.Action != models.PlanActionNoop && !manageGroups {
+			action.Error = "分组是平台级资源，变更需要全局editor角色"
+		}
+		plan.Actions = append(plan.Actions, action)
+	}
+
+	if state.Prune {
+		for _, name := range sortedKeys(current.configs) {
+			if declaredConfigs[name] {
+				continue
+			}
+			action := models.PlanAction{Kind: "config", Name: name, Action: models.PlanActionDelete}
+			// 仍被Agent应用的配置默认拒绝删除，避免Agent上的流水线失去平台侧的配置来源
+			if !state.Force {
+				agents, err := s.agentRepo.ListByAppliedConfig(ctx, current.configs[name].ID)
+				if err != nil {
+					return nil, nil, fmt.Errorf("查询配置使用情况失败: %w", err)
+				}
+				if len(agents) > 0 {
+					action.Error = fmt.Sprintf("仍有 %d 个Agent应用该配置，使用 --force 强制删除", len(agents))
+				}
+			}
+			plan.Actions = append(plan.Actions, action)
+		}
+		for _, name := range sortedKeys(current.groups) {
+			if manageGroups && !declaredGroups[name] {
+				plan.Actions = append(plan.Actions, models.PlanAction{Kind: "group", Name: name, Action: models.PlanActionDelete})
+			}
+		}
+	}
+
+	for _, a := range plan.Actions {
+		plan.Summary[a.Action]++
+	}
+
+	return plan, current, nil
+}
+
+// Apply 计算差异并执行，单个资源失败不影响其余资源
+// 执行使用计划所基于的同一份状态快照，保证执行的变更与计划一致
+func (s *desiredStateService) Apply(ctx context.Context, state *models.DesiredState, userID string) (*models.ApplyPlan, error) {
+	plan, current, err := s.plan(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+
+	configs := make(map[string]models.DesiredConfig, len(state.Configs))
+	for _, dc := range state.Configs {
+		configs[dc.Name] = dc
+	}
+	groups := make(map[string]models.DesiredGroup, len(state.Groups))
+	for _, dg := range state.Groups {
+		groups[dg.Name] = dg
+	}
+
+	failed := 0
+	for i := range plan.Actions {
+		action := &plan.Actions[i]
+		if action.Action == models.PlanActionNoop {
+			continue
+		}
+		// 计划阶段已拒绝的变更不执行
+		if action.Error != "" {
+			failed++
+			continue
+		}
+
+		var err error
+		switch action.Kind {
+		case "config":
+			err = s.applyConfig(ctx, action, configs[action.Name], current.configs[action.Name], state.Force, userID)
+		case "group":
+			err = s.applyGroup(ctx, action, groups[action.Name], userID)
+		}
+		if err != nil {
+			action.Error = err.Error()
+			failed++
+		}
+	}
+
+	plan.Applied = true
+	plan.Summary["failed"] = failed
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id": userID,
+		"create":  plan.Summary[models.PlanActionCreate],
+		"update":  plan.Summary[models.PlanActionUpdate],
+		"delete":  plan.Summary[models.PlanActionDelete],
+		"failed":  failed,
+	}).Info("应用期望状态完成")
+
+	return plan, nil
+}
+
+// applyConfig 执行单个配置的变更，force时删除仍在Agent上运行的配置前先从Agent上移除
+func (s *desiredStateService) applyConfig(ctx context.Context, action *models.PlanAction, dc models.DesiredConfig, existing *models.Config, force bool, userID string) error {
+	switch action.Action {
+	case models.PlanActionCreate:
+		created, err := s.configService.CreateConfig(ctx, &models.CreateConfigRequest{
+			Name:        dc.Name,
+			Description: dc.Description,
+			Type:        dc.Type,
+			Content:     dc.Content,
+			Tags:        dc.Tags,
+			Team:        dc.Team,
+		}, userID)
+		if err != nil {
+			return err
+		}
+		// 新建配置默认启用，声明为禁用时再更新一次
+		if dc.Enabled != nil && !*dc.Enabled {
+			_, err = s.configService.UpdateConfig(ctx, created.ID, updateRequestFor(dc), userID)
+		}
+		return err
+	case models.PlanActionUpdate:
+		_, err := s.configService.UpdateConfig(ctx, existing.ID, updateRequestFor(dc), userID)
+		return err
+	case models.PlanActionDelete:
+		return s.configService.DeleteConfig(ctx, existing.ID, force)
+	}
+	return nil
+}
+
+// applyGroup 执行单个分组的变更
+func (s *desiredStateService) applyGroup(ctx context.Context, action *models.PlanAction, dg models.DesiredGroup, userID string) error {
+	switch action.Action {
+	case models.PlanActionCreate:
+		_, err := s.groupService.CreateGroup(ctx, &models.CreateGroupRequest{
+			Name:        dg.Name,
+			Description: dg.Description,
+			Settings:    dg.Settings,
+		}, userID)
+		return err
+	case models.PlanActionUpdate:
+		_, err := s.groupService.UpdateGroup(ctx, dg.Name, &models.UpdateGroupRequest{
+			Description: dg.Description,
+			Settings:    dg.Settings,
+		}, userID)
+		return err
+	case models.PlanActionDelete:
+		return s.groupService.DeleteGroup(ctx, action.Name)
+	}
+	return nil
+}
+
+// loadCurrent 加载当前的全部配置和分组
+func (s *desiredStateService) loadCurrent(ctx context.Context) (*currentState, error) {
+	current := &currentState{
+		configs: make(map[string]*models.Config),
+		groups:  make(map[string]*models.AgentGroup),
+	}
+
+	// 计划需要看到全部配置，否则受访问控制的同名配置会被当作不存在而重复创建；
+	// 对这些配置的修改仍按请求身份检查权限
+	listCtx := models.WithPrincipal(ctx, nil)
+	for page := 1; ; page++ {
+		resp, err := s.configService.ListConfigs(listCtx, &models.ConfigListRequest{Page: page, PageSize: 100})
+		if err != nil {
+			return nil, fmt.Errorf("获取配置列表失败: %w", err)
+		}
+		for _, cfg := range resp.Items {
+			// 平台中存在同名配置时无法确定声明对应哪一个，拒绝计划
+			if existing, ok := current.configs[cfg.Name]; ok && existing.ID != cfg.ID {
+				return nil, fmt.Errorf("期望状态无效: 平台中存在多个名为 %s 的配置 (%s, %s)", cfg.Name, existing.ID, cfg.ID)
+			}
+			current.configs[cfg.Name] = cfg
+		}
+		if len(resp.Items) == 0 || int64(page*resp.Size) >= resp.Total {
+			break
+		}
+	}
+
+	groups, err := s.groupService.ListGroups(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取分组列表失败: %w", err)
+	}
+	for _, g := range groups {
+		current.groups[g.Name] = g
+	}
+
+	return current, nil
+}
+
+// validateDesiredState 校验期望状态文件
+func validateDesiredState(state *models.DesiredState) error {
+	seen := make(map[string]bool)
+	for _, dc := range state.Configs {
+		if dc.Name == "" {
+			return fmt.Errorf("期望状态无效: 配置名称不能为空")
+		}
+		if seen["config/"+dc.Name] {
+			return fmt.Errorf("期望状态无效: 配置重复声明: %s", dc.Name)
+		}
+		seen["config/"+dc.Name] = true
+		switch dc.Type {
+		case models.ConfigTypeInput, models.ConfigTypeFilter, models.ConfigTypeOutput:
+		default:
+			return fmt.Errorf("期望状态无效: 配置 %s 的类型无效: %s", dc.Name, dc.Type)
+		}
+	}
+	for _, dg := range state.Groups {
+		if dg.Name == "" {
+			return fmt.Errorf("期望状态无效: 分组名称不能为空")
+		}
+		if seen["group/"+dg.Name] {
+			return fmt.Errorf("期望状态无效: 分组重复声明: %s", dg.Name)
+		}
+		seen["group/"+dg.Name] = true
+	}
+	return nil
+}
+
+// configChanges 对比配置字段差异
+func configChanges(existing *models.Config, dc *models.DesiredConfig) []string {
+	var changes []string
+	if existing.Description != dc.Description {
+		changes = append(changes, "description")
+	}
+	if existing.Type != dc.Type {
+		changes = append(changes, "type")
+	}
+	if strings.TrimSpace(existing.Content) != strings.TrimSpace(dc.Content) {
+		changes = append(changes, "content")
+	}
+	if strings.Join(existing.Tags, ",") != strings.Join(dc.Tags, ",") {
+		changes = append(changes, "tags")
+	}
+	if existing.Team != dc.Team {
+		changes = append(changes, "team")
+	}
+	if dc.Enabled != nil && existing.Enabled != *dc.Enabled {
+		changes = append(changes, "enabled")
+	}
+	return changes
+}
+
+// groupChanges 对比分组字段差异
+func groupChanges(existing *models.AgentGroup, dg *models.DesiredGroup) []string {
+	var changes []string
+	if existing.Description != dg.Description {
+		changes = append(changes, "description")
+	}
+	if !settingsEqual(existing.Settings, dg.Settings) {
+		changes = append(changes, "settings")
+	}
+	return changes
+}
+
+// settingsEqual 比较两组Agent设置
+func settingsEqual(a, b models.AgentSettings) bool {
+	intEq := func(x, y *int) bool { return (x == nil && y == nil) || (x != nil && y != nil && *x == *y) }
+	boolEq := func(x, y *bool) bool { return (x == nil && y == nil) || (x != nil && y != nil && *x == *y) }
+	if !intEq(a.HeartbeatInterval, b.HeartbeatInterval) || !intEq(a.MetricsInterval, b.MetricsInterval) ||
+		!boolEq(a.EnableAutoReload, b.EnableAutoReload) || len(a.Labels) != len(b.Labels) {
+		return false
+	}
+	for k, v := range a.Labels {
+		if b.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// updateRequestFor 由期望配置构造更新请求
+func updateRequestFor(dc models.DesiredConfig) *models.UpdateConfigRequest {
+	return &models.UpdateConfigRequest{
+		Name:        dc.Name,
+		Description: dc.Description,
+		Type:        dc.Type,
+		Content:     dc.Content,
+		Tags:        dc.Tags,
+		Team:        dc.Team,
+		Enabled:     dc.Enabled,
+	}
+}
+
+// sortedKeys 返回排序后的map键
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
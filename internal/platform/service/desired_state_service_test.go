@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+)
+
+// fakeConfigService 内存中的配置服务，仅实现期望状态服务用到的方法
+type fakeConfigService struct {
+	ConfigService
+	configs map[string]*models.Config
+	updated []string
+	lists   int
+}
+
+func (f *fakeConfigService) ListConfigs(ctx context.Context, req *models.ConfigListRequest) (*models.ConfigListResponse, error) {
+	f.lists++
+	items := make([]*models.Config, 0, len(f.configs))
+	for _, c := range f.configs {
+		items = append(items, c)
+	}
+	return &models.ConfigListResponse{Total: int64(len(items)), Page: 1, Size: 100, Items: items}, nil
+}
+
+func (f *fakeConfigService) CreateConfig(ctx context.Context, req *models.CreateConfigRequest, userID string) (*models.Config, error) {
+	cfg := &models.Config{ID: "id-" + req.Name, Name: req.Name, Type: req.Type, Content: req.Content, Enabled: true}
+	f.configs[cfg.ID] = cfg
+	return cfg, nil
+}
+
+func (f *fakeConfigService) UpdateConfig(ctx context.Context, id string, req *models.UpdateConfigRequest, userID string) (*models.Config, error) {
+	f.updated = append(f.updated, id)
+	return f.configs[id], nil
+}
+
+func (f *fakeConfigService) GetConfig(ctx context.Context, id string) (*models.Config, error) {
+	cfg, ok := f.configs[id]
+	if !ok {
+		return nil, fmt.Errorf("文档不存在")
+	}
+	return cfg, nil
+}
+
+func (f *fakeConfigService) DeleteConfig(ctx context.Context, id string, force bool) error {
+	delete(f.configs, id)
+	return nil
+}
+
+// fakeGroupService 内存中的分组服务
+type fakeGroupService struct {
+	GroupService
+	groups []*models.AgentGroup
+}
+
+func (f *fakeGroupService) ListGroups(ctx context.Context) ([]*models.AgentGroup, error) {
+	return f.groups, nil
+}
+
+func (f *fakeGroupService) CreateGroup(ctx context.Context, req *models.CreateGroupRequest, userID string) (*models.AgentGroup, error) {
+	group := &models.AgentGroup{Name: req.Name}
+	f.groups = append(f.groups, group)
+	return group, nil
+}
+
+func TestDesiredStateService_PlanAndApply(t *testing.T) {
+	ctx := context.Background()
+	logger := logrus.New()
+
+	configs := &fakeConfigService{configs: map[string]*models.Config{
+		"id-unchanged": {ID: "id-unchanged", Name: "unchanged", Type: models.ConfigTypeFilter, Content: "filter {}", Enabled: true},
+		"id-changed":   {ID: "id-changed", Name: "changed", Type: models.ConfigTypeFilter, Content: "filter {}", Enabled: true},
+		"id-orphan":    {ID: "id-orphan", Name: "orphan", Type: models.ConfigTypeInput, Content: "input {}"},
+	}}
+	groups := &fakeGroupService{}
+
+	state := &models.DesiredState{
+		Configs: []models.DesiredConfig{
+			{Name: "unchanged", Type: models.ConfigTypeFilter, Content: "filter {}\n"},
+			{Name: "changed", Type: models.ConfigTypeFilter, Content: "filter { mutate {} }", Tags: []string{"prod"}},
+			{Name: "new", Type: models.ConfigTypeOutput, Content: "output {}"},
+		},
+		Groups: []models.DesiredGroup{{Name: "prod"}},
+		Prune:  true,
+	}
+
+	agents := &memAgentRepository{agents: map[string]*models.Agent{}}
+	svc := NewDesiredStateService(configs, groups, agents, logger)
+
+	plan, err := svc.Plan(ctx, state)
+	require.NoError(t, err)
+	assert.False(t, plan.Applied)
+	assert.Equal(t, map[string]int{"noop": 1, "update": 1, "create": 2, "delete": 1}, plan.Summary)
+	assert.Equal(t, []string{"content", "tags"}, plan.Actions[1].Changes)
+
+	// 仅计划不产生任何变更
+	assert.Len(t, configs.configs, 3)
+
+	configs.lists = 0
+	plan, err = svc.Apply(ctx, state, "admin")
+	require.NoError(t, err)
+	assert.Equal(t, 1, configs.lists, "计划与执行应基于同一份状态快照")
+	assert.True(t, plan.Applied)
+	assert.Equal(t, 0, plan.Summary["failed"])
+	assert.Contains(t, configs.configs, "id-new")
+	assert.NotContains(t, configs.configs, "id-orphan")
+	assert.Equal(t, []string{"id-changed"}, configs.updated)
+	assert.Len(t, groups.groups, 1)
+}
+
+func TestDesiredStateService_InvalidState(t *testing.T) {
+	svc := NewDesiredStateService(&fakeConfigService{}, &fakeGroupService{}, &memAgentRepository{}, logrus.New())
+
+	_, err := svc.Plan(context.Background(), &models.DesiredState{
+		Configs: []models.DesiredConfig{{Name: "a", Type: "bogus"}},
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "期望状态无效")
+}
+
+func TestDesiredStateService_PruneRefusesAppliedConfigs(t *testing.T) {
+	ctx := context.Background()
+	configs := &fakeConfigService{configs: map[string]*models.Config{
+		"id-live": {ID: "id-live", Name: "live", Type: models.ConfigTypeInput, Content: "input {}"},
+	}}
+	agents := &memAgentRepository{agents: map[string]*models.Agent{
+		"agent-1": {AgentID: "agent-1", AppliedConfigs: []models.AppliedConfig{{ConfigID: "id-live"}}},
+	}}
+	svc := NewDesiredStateService(configs, &fakeGroupService{}, agents, logrus.New())
+
+	state := &models.DesiredState{Prune: true}
+	plan, err := svc.Apply(ctx, state, "admin")
+	require.NoError(t, err)
+	require.Len(t, plan.Actions, 1)
+	assert.Contains(t, plan.Actions[0].Error, "--force")
+	assert.Equal(t, 1, plan.Summary["failed"])
+	assert.Contains(t, configs.configs, "id-live")
+
+	// 显式强制时删除
+	state.Force = true
+	plan, err = svc.Apply(ctx, state, "admin")
+	require.NoError(t, err)
+	assert.Equal(t, 0, plan.Summary["failed"])
+	assert.NotContains(t, configs.configs, "id-live")
+}
+
+func TestDesiredStateService_DuplicateConfigNamesInPlatform(t *testing.T) {
+	configs := &fakeConfigService{configs: map[string]*models.Config{
+		"id-a": {ID: "id-a", Name: "shared", Type: models.ConfigTypeFilter},
+		"id-b": {ID: "id-b", Name: "shared", Type: models.ConfigTypeFilter},
+	}}
+	svc := NewDesiredStateService(configs, &fakeGroupService{}, &memAgentRepository{}, logrus.New())
+
+	_, err := svc.Plan(context.Background(), &models.DesiredState{
+		Configs: []models.DesiredConfig{{Name: "shared", Type: models.ConfigTypeFilter}},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "期望状态无效")
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// elasticsearch/opensearch 插件的 hosts 参数，支持数组和单个字符串
+	esHostsPattern = regexp.MustCompile(`(?s)(elasticsearch|opensearch)\s*\{[^}]*?hosts\s*=>\s*(\[[^\]]*\]|"[^"]*"|'[^']*')`)
+	// kafka 插件的 bootstrap_servers 参数
+	kafkaServersPattern = regexp.MustCompile(`(?s)kafka\s*\{[^}]*?bootstrap_servers\s*=>\s*("[^"]*"|'[^']*')`)
+	quotedPattern       = regexp.MustCompile(`"([^"]*)"|'([^']*)'`)
+)
+
+// ExtractDestinations 从配置内容中识别下游集群（ES集群、Kafka集群）
+// 同一集群以排序后的地址列表作为标识，例如 "elasticsearch:es1:9200,es2:9200"
+// 通过注册表引用的集群以名称作为标识，例如 "destination:logs-main"
+func ExtractDestinations(content string) []string {
+	seen := make(map[string]bool)
+	var destinations []string
+
+	add := func(kind string, addrs []string) {
+		if len(addrs) == 0 {
+			return
+		}
+		sort.Strings(addrs)
+		key := kind + ":" + strings.Join(addrs, ",")
+		if !seen[key] {
+			seen[key] = true
+			destinations = append(destinations, key)
+		}
+	}
+
+	for _, m := range esHostsPattern.FindAllStringSubmatch(content, -1) {
+		add("elasticsearch", quotedValues(m[2]))
+	}
+	for _, m := range kafkaServersPattern.FindAllStringSubmatch(content, -1) {
+		var addrs []string
+		for _, v := range quotedValues(m[1]) {
+			addrs = append(addrs, strings.Split(v, ",")...)
+		}
+		add("kafka", normalizeAddrs(addrs))
+	}
+
+	for _, name := range ReferencedDestinations(content) {
+		add("destination", []string{name})
+	}
+
+	sort.Strings(destinations)
+	return destinations
+}
+
+// quotedValues 取出引号内的全部值
+func quotedValues(s string) []string {
+	var values []string
+	for _, m := range quotedPattern.FindAllStringSubmatch(s, -1) {
+		v := m[1]
+		if v == "" {
+			v = m[2]
+		}
+		values = append(values, v)
+	}
+	return normalizeAddrs(values)
+}
+
+// normalizeAddrs 去除空白、协议前缀和结尾斜杠
+func normalizeAddrs(addrs []string) []string {
+	result := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		addr = strings.TrimSpace(addr)
+		addr = strings.TrimPrefix(addr, "http://")
+		addr = strings.TrimPrefix(addr, "https://")
+		addr = strings.TrimSuffix(addr, "/")
+		if addr != "" {
+			result = append(result, addr)
+		}
+	}
+	return result
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+)
+
+// DestinationProber 检查下游集群一个环境的连通性
+type DestinationProber interface {
+	Probe(ctx context.Context, destType string, ep *models.DestinationEndpoint, password string) *models.DestinationCheck
+}
+
+// SecretResolver 在平台侧解析密钥，用于连通性检查
+type SecretResolver interface {
+	Resolve(name string) (string, bool)
+}
+
+// EnvSecretResolver 从平台进程的环境变量读取密钥，与Agent侧Logstash的keystore使用相同的名称
+type EnvSecretResolver struct{}
+
+// Resolve 读取同名环境变量
+func (EnvSecretResolver) Resolve(name string) (string, bool) {
+	return os.LookupEnv(name)
+}
+
+// networkProber 逐个地址探测：ES请求根路径，Kafka建立TCP连接
+type networkProber struct {
+	timeout    time.Duration
+	httpClient *http.Client
+}
+
+// NewNetworkProber 创建下游集群网络探测器，timeout<=0时默认5秒
+func NewNetworkProber(timeout time.Duration) DestinationProber {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &networkProber{
+		timeout: timeout,
+		httpClient: &http.Client{
+			Timeout: timeout,
+			// 只检查连通性和认证，证书由Logstash侧校验
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+		},
+	}
+}
+
+// Probe 全部地址可连通时视为健康
+func (p *networkProber) Probe(ctx context.Context, destType string, ep *models.DestinationEndpoint, password string) *models.DestinationCheck {
+	start := time.Now()
+	check := &models.DestinationCheck{Total: len(ep.Hosts)}
+
+	var errs []string
+	for _, host := range ep.Hosts {
+		var err error
+		if destType == models.DestinationTypeKafka {
+			err = p.dial(ctx, host)
+		} else {
+			err = p.request(ctx, host, ep, password)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", host, err))
+			continue
+		}
+		check.Reachable++
+	}
+
+	check.Healthy = check.Total > 0 && check.Reachable == check.Total
+	check.Error = strings.Join(errs, "; ")
+	check.LatencyMs = time.Since(start).Milliseconds()
+	check.CheckedAt = time.Now()
+	return check
+}
+
+// dial 建立TCP连接
+func (p *networkProber) dial(ctx context.Context, host string) error {
+	dialer := net.Dialer{Timeout: p.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// request 请求ES节点根路径，认证失败同样视为不可用
+func (p *networkProber) request(ctx context.Context, host string, ep *models.DestinationEndpoint, password string) error {
+	url := host
+	if !strings.Contains(host, "://") {
+		scheme := "http://"
+		if ep.SSL {
+			scheme = "https://"
+		}
+		url = scheme + host
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if ep.Username != "" {
+		req.SetBasicAuth(ep.Username, password)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// DestinationMonitor 定期检查全部下游集群的连通性
+type DestinationMonitor struct {
+	service  DestinationService
+	interval time.Duration
+	logger   *logrus.Logger
+	tracker  loopTracker
+}
+
+// NewDestinationMonitor 创建下游集群连通性巡检
+func NewDestinationMonitor(service DestinationService, interval time.Duration, logger *logrus.Logger) *DestinationMonitor {
+	return &DestinationMonitor{
+		service:  service,
+		interval: interval,
+		logger:   logger,
+		tracker:  loopTracker{interval: interval},
+	}
+}
+
+// Start 按间隔执行检查，直到ctx取消
+func (m *DestinationMonitor) Start(ctx context.Context) {
+	defer m.tracker.start(time.Now())()
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		done := m.tracker.begin(time.Now())
+		err := m.service.CheckAll(ctx)
+		done(err)
+		if err != nil && ctx.Err() == nil {
+			m.logger.Errorf("下游集群连通性检查失败: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// WorkerStatus 报告连通性巡检的运行状态
+func (m *DestinationMonitor) WorkerStatus(now time.Time) models.WorkerStatus {
+	return m.tracker.status("destination_monitor", now)
+}
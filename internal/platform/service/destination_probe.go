@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrQueueDepthUnsupported 该类型的下游集群不支持积压探测
+var ErrQueueDepthUnsupported = errors.New("不支持探测该下游集群的积压")
+
+// ESQueueProbeConfig Elasticsearch积压探测配置
+type ESQueueProbeConfig struct {
+	Scheme   string // http或https，默认http
+	Username string
+	Password string
+	Timeout  time.Duration
+}
+
+// esQueueProbe 通过 _cat/thread_pool/write 读取ES集群写线程池的排队任务数
+type esQueueProbe struct {
+	cfg        ESQueueProbeConfig
+	httpClient *http.Client
+}
+
+// NewESQueueProbe 创建Elasticsearch下游积压探测器，Kafka等其他集群返回 ErrQueueDepthUnsupported
+func NewESQueueProbe(cfg ESQueueProbeConfig) QueueDepthProbe {
+	if cfg.Scheme == "" {
+		cfg.Scheme = "http"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	return &esQueueProbe{
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// QueueDepth 依次尝试集群中的节点，返回全部节点写队列长度之和
+func (p *esQueueProbe) QueueDepth(ctx context.Context, destination string) (int, error) {
+	kind, addrs, ok := strings.Cut(destination, ":")
+	if !ok || (kind != "elasticsearch" && kind != "opensearch") {
+		return 0, ErrQueueDepthUnsupported
+	}
+
+	var lastErr error
+	for _, addr := range strings.Split(addrs, ",") {
+		depth, err := p.queryNode(ctx, addr)
+		if err == nil {
+			return depth, nil
+		}
+		lastErr = err
+	}
+	return 0, lastErr
+}
+
+// queryNode 通过单个节点查询集群写线程池排队数
+func (p *esQueueProbe) queryNode(ctx context.Context, addr string) (int, error) {
+	url := fmt.Sprintf("%s://%s/_cat/thread_pool/write?format=json&h=queue", p.cfg.Scheme, addr)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	if p.cfg.Username != "" {
+		req.SetBasicAuth(p.cfg.Username, p.cfg.Password)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("查询下游集群积压失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("查询下游集群积压失败: HTTP %d", resp.StatusCode)
+	}
+
+	var rows []struct {
+		Queue string `json:"queue"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&rows); err != nil {
+		return 0, fmt.Errorf("解析下游集群积压失败: %w", err)
+	}
+
+	total := 0
+	for _, row := range rows {
+		n, err := strconv.Atoi(row.Queue)
+		if err != nil {
+			continue
+		}
+		total += n
+	}
+	return total, nil
+}
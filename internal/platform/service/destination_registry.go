@@ -0,0 +1,362 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// 下游集群渲染错误
+var (
+	ErrUnknownDestination     = errors.New("引用了不存在的下游集群")
+	ErrDestinationEnvironment = errors.New("下游集群未配置该环境")
+)
+
+var (
+	// destination => "名称"，独占一行，渲染时整行替换为连接参数
+	destinationRefPattern = regexp.MustCompile(`(?m)^([ \t]*)destination\s*=>\s*(?:"([^"]*)"|'([^']*)')[ \t]*$`)
+	// Logstash keystore 的键名
+	secretNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+)
+
+// DestinationService 下游集群注册表服务接口
+type DestinationService interface {
+	CreateDestination(ctx context.Context, req *models.CreateDestinationRequest, userID string) (*models.Destination, error)
+	UpdateDestination(ctx context.Context, name string, req *models.UpdateDestinationRequest, userID string) (*models.Destination, error)
+	DeleteDestination(ctx context.Context, name string) error
+	GetDestination(ctx context.Context, name string) (*models.Destination, error)
+	ListDestinations(ctx context.Context) ([]*models.Destination, error)
+	// RenderConfig 将配置中引用的下游集群替换为指定环境的连接参数
+	RenderConfig(ctx context.Context, config *models.Config, environment string) (*models.RenderedConfig, error)
+	// CheckDestination 检查下游集群在各环境的连通性并保存结果
+	CheckDestination(ctx context.Context, name string) (*models.Destination, error)
+	// CheckAll 检查全部下游集群，单个集群失败不影响其他集群
+	CheckAll(ctx context.Context) error
+}
+
+// destinationService 下游集群注册表服务实现
+type destinationService struct {
+	destRepo repository.DestinationRepository
+	prober   DestinationProber
+	secrets  SecretResolver
+	logger   *logrus.Logger
+}
+
+// NewDestinationService 创建下游集群注册表服务
+// prober或secrets为nil时使用默认的网络探测和环境变量密钥
+func NewDestinationService(destRepo repository.DestinationRepository, prober DestinationProber, secrets SecretResolver, logger *logrus.Logger) DestinationService {
+	if prober == nil {
+		prober = NewNetworkProber(0)
+	}
+	if secrets == nil {
+		secrets = EnvSecretResolver{}
+	}
+	return &destinationService{
+		destRepo: destRepo,
+		prober:   prober,
+		secrets:  secrets,
+		logger:   logger,
+	}
+}
+
+// CreateDestination 创建下游集群
+func (s *destinationService) CreateDestination(ctx context.Context, req *models.CreateDestinationRequest, userID string) (*models.Destination, error) {
+	if err := validateEnvironments(req.Environments); err != nil {
+		return nil, fmt.Errorf("下游集群验证失败: %w", err)
+	}
+
+	if _, err := s.destRepo.GetByName(ctx, req.Name); err == nil {
+		return nil, fmt.Errorf("下游集群已存在: %s", req.Name)
+	}
+
+	dest := &models.Destination{
+		Name:         req.Name,
+		Type:         req.Type,
+		Description:  req.Description,
+		Tags:         req.Tags,
+		Environments: req.Environments,
+		CreatedBy:    userID,
+		UpdatedBy:    userID,
+	}
+
+	if err := s.destRepo.Create(ctx, dest); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"destination": dest.Name,
+		"type":        dest.Type,
+		"user_id":     userID,
+	}).Info("创建下游集群成功")
+
+	return dest, nil
+}
+
+// UpdateDestination 更新下游集群，连接信息变更后清除旧的检查结果
+func (s *destinationService) UpdateDestination(ctx context.Context, name string, req *models.UpdateDestinationRequest, userID string) (*models.Destination, error) {
+	dest, err := s.destRepo.GetByName(elasticsearch.WithPrimaryRead(ctx), name)
+	if err != nil {
+		return nil, fmt.Errorf("下游集群不存在: %w", err)
+	}
+
+	if err := validateEnvironments(req.Environments); err != nil {
+		return nil, fmt.Errorf("下游集群验证失败: %w", err)
+	}
+
+	dest.Description = req.Description
+	dest.Tags = req.Tags
+	dest.Environments = req.Environments
+	dest.Checks = nil
+	dest.UpdatedBy = userID
+
+	if err := s.destRepo.Update(ctx, dest); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"destination": dest.Name,
+		"user_id":     userID,
+	}).Info("更新下游集群成功")
+
+	return dest, nil
+}
+
+// DeleteDestination 删除下游集群
+func (s *destinationService) DeleteDestination(ctx context.Context, name string) error {
+	if _, err := s.destRepo.GetByName(ctx, name); err != nil {
+		return fmt.Errorf("下游集群不存在: %w", err)
+	}
+
+	if err := s.destRepo.Delete(ctx, name); err != nil {
+		return err
+	}
+
+	s.logger.WithField("destination", name).Info("删除下游集群成功")
+	return nil
+}
+
+// GetDestination 获取下游集群
+func (s *destinationService) GetDestination(ctx context.Context, name string) (*models.Destination, error) {
+	return s.destRepo.GetByName(ctx, name)
+}
+
+// ListDestinations 获取下游集群列表
+func (s *destinationService) ListDestinations(ctx context.Context) ([]*models.Destination, error) {
+	return s.destRepo.List(ctx)
+}
+
+// RenderConfig 渲染配置，未引用下游集群的配置原样返回
+func (s *destinationService) RenderConfig(ctx context.Context, config *models.Config, environment string) (*models.RenderedConfig, error) {
+	names := ReferencedDestinations(config.Content)
+
+	resolved := make(map[string]*models.Destination, len(names))
+	for _, name := range names {
+		dest, err := s.destRepo.GetByName(ctx, name)
+		if err != nil {
+			if err.Error() == "文档不存在" {
+				return nil, fmt.Errorf("%w: %s", ErrUnknownDestination, name)
+			}
+			return nil, fmt.Errorf("获取下游集群失败: %w", err)
+		}
+		resolved[name] = dest
+	}
+
+	content, err := RenderDestinations(config.Content, resolved, environment)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.RenderedConfig{
+		ConfigID:     config.ID,
+		Version:      config.Version,
+		Environment:  environment,
+		Content:      content,
+		Destinations: names,
+	}, nil
+}
+
+// CheckDestination 检查单个下游集群
+func (s *destinationService) CheckDestination(ctx context.Context, name string) (*models.Destination, error) {
+	dest, err := s.destRepo.GetByName(elasticsearch.WithPrimaryRead(ctx), name)
+	if err != nil {
+		return nil, fmt.Errorf("下游集群不存在: %w", err)
+	}
+
+	dest.Checks = make(map[string]*models.DestinationCheck, len(dest.Environments))
+	for env, ep := range dest.Environments {
+		check := s.checkEndpoint(ctx, dest.Type, ep)
+		dest.Checks[env] = check
+		if !check.Healthy {
+			s.logger.WithFields(logrus.Fields{
+				"destination": dest.Name,
+				"environment": env,
+				"error":       check.Error,
+			}).Warn("下游集群连通性检查失败")
+		}
+	}
+
+	if err := s.destRepo.Update(ctx, dest); err != nil {
+		return nil, err
+	}
+	return dest, nil
+}
+
+// CheckAll 检查全部下游集群
+func (s *destinationService) CheckAll(ctx context.Context) error {
+	dests, err := s.destRepo.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, dest := range dests {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if _, err := s.CheckDestination(ctx, dest.Name); err != nil {
+			s.logger.WithError(err).WithField("destination", dest.Name).Error("检查下游集群失败")
+		}
+	}
+	return nil
+}
+
+// checkEndpoint 使用平台侧的密钥检查一个环境的连接信息
+func (s *destinationService) checkEndpoint(ctx context.Context, destType string, ep *models.DestinationEndpoint) *models.DestinationCheck {
+	password := ""
+	if ep.PasswordSecret != "" {
+		value, ok := s.secrets.Resolve(ep.PasswordSecret)
+		if !ok {
+			return &models.DestinationCheck{
+				Total:     len(ep.Hosts),
+				Error:     fmt.Sprintf("平台未配置密钥 %s，无法检查", ep.PasswordSecret),
+				CheckedAt: time.Now(),
+			}
+		}
+		password = value
+	}
+	return s.prober.Probe(ctx, destType, ep, password)
+}
+
+// validateEnvironments 验证各环境的连接信息
+func validateEnvironments(envs map[string]*models.DestinationEndpoint) error {
+	if len(envs) == 0 {
+		return fmt.Errorf("至少需要配置一个环境")
+	}
+	for env, ep := range envs {
+		if env == "" {
+			return fmt.Errorf("环境名称不能为空")
+		}
+		if ep == nil || len(ep.Hosts) == 0 {
+			return fmt.Errorf("环境 %s 未配置地址", env)
+		}
+		for _, host := range ep.Hosts {
+			if strings.TrimSpace(host) == "" {
+				return fmt.Errorf("环境 %s 存在空地址", env)
+			}
+		}
+		if ep.PasswordSecret != "" && !secretNamePattern.MatchString(ep.PasswordSecret) {
+			return fmt.Errorf("环境 %s 的密钥名称无效: %s", env, ep.PasswordSecret)
+		}
+	}
+	return nil
+}
+
+// ReferencedDestinations 获取配置通过 destination => "名称" 引用的下游集群，按名称排序去重
+func ReferencedDestinations(content string) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, m := range destinationRefPattern.FindAllStringSubmatch(content, -1) {
+		name := m[2] + m[3]
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RenderDestinations 将 destination => "名称" 替换为下游集群在指定环境的连接参数
+// 密码渲染为 "${密钥名称}"，由Agent侧的Logstash从keystore或环境变量读取
+func RenderDestinations(content string, destinations map[string]*models.Destination, environment string) (string, error) {
+	var renderErr error
+	rendered := destinationRefPattern.ReplaceAllStringFunc(content, func(line string) string {
+		m := destinationRefPattern.FindStringSubmatch(line)
+		indent, name := m[1], m[2]+m[3]
+
+		dest, ok := destinations[name]
+		if !ok {
+			if renderErr == nil {
+				renderErr = fmt.Errorf("%w: %s", ErrUnknownDestination, name)
+			}
+			return line
+		}
+		ep, ok := dest.Endpoint(environment)
+		if !ok {
+			if renderErr == nil {
+				renderErr = fmt.Errorf("%w: %s/%s", ErrDestinationEnvironment, name, environment)
+			}
+			return line
+		}
+
+		return indent + strings.Join(endpointSettings(dest.Type, ep), "\n"+indent)
+	})
+	if renderErr != nil {
+		return "", renderErr
+	}
+	return rendered, nil
+}
+
+// endpointSettings 生成插件的连接参数
+func endpointSettings(destType string, ep *models.DestinationEndpoint) []string {
+	password := ""
+	if ep.PasswordSecret != "" {
+		password = "${" + ep.PasswordSecret + "}"
+	}
+
+	if destType == models.DestinationTypeKafka {
+		settings := []string{fmt.Sprintf("bootstrap_servers => %q", strings.Join(ep.Hosts, ","))}
+		switch {
+		case ep.Username != "":
+			protocol := "SASL_PLAINTEXT"
+			if ep.SSL {
+				protocol = "SASL_SSL"
+			}
+			settings = append(settings,
+				fmt.Sprintf("security_protocol => %q", protocol),
+				`sasl_mechanism => "PLAIN"`,
+				fmt.Sprintf(`sasl_jaas_config => "org.apache.kafka.common.security.plain.PlainLoginModule required username='%s' password='%s';"`, ep.Username, password))
+		case ep.SSL:
+			settings = append(settings, `security_protocol => "SSL"`)
+		}
+		return settings
+	}
+
+	scheme := "http://"
+	if ep.SSL {
+		scheme = "https://"
+	}
+	hosts := make([]string, 0, len(ep.Hosts))
+	for _, host := range ep.Hosts {
+		if !strings.Contains(host, "://") {
+			host = scheme + host
+		}
+		hosts = append(hosts, fmt.Sprintf("%q", host))
+	}
+	settings := []string{"hosts => [" + strings.Join(hosts, ", ") + "]"}
+	if ep.Username != "" {
+		settings = append(settings, fmt.Sprintf("user => %q", ep.Username))
+	}
+	if password != "" {
+		settings = append(settings, fmt.Sprintf("password => %q", password))
+	}
+	return settings
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+)
+
+// memDestinationRepository 内存中的下游集群仓库
+type memDestinationRepository struct {
+	mu    sync.Mutex
+	items map[string]*models.Destination
+}
+
+func newMemDestinationRepository() *memDestinationRepository {
+	return &memDestinationRepository{items: make(map[string]*models.Destination)}
+}
+
+func (r *memDestinationRepository) Create(ctx context.Context, d *models.Destination) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cp := *d
+	r.items[d.Name] = &cp
+	return nil
+}
+
+func (r *memDestinationRepository) Update(ctx context.Context, d *models.Destination) error {
+	return r.Create(ctx, d)
+}
+
+func (r *memDestinationRepository) Delete(ctx context.Context, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.items, name)
+	return nil
+}
+
+func (r *memDestinationRepository) GetByName(ctx context.Context, name string) (*models.Destination, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d, ok := r.items[name]
+	if !ok {
+		return nil, errors.New("文档不存在")
+	}
+	cp := *d
+	return &cp, nil
+}
+
+func (r *memDestinationRepository) List(ctx context.Context) ([]*models.Destination, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var items []*models.Destination
+	for _, d := range r.items {
+		cp := *d
+		items = append(items, &cp)
+	}
+	return items, nil
+}
+
+// staticSecrets 固定的密钥集合
+type staticSecrets map[string]string
+
+func (s staticSecrets) Resolve(name string) (string, bool) {
+	v, ok := s[name]
+	return v, ok
+}
+
+func newTestDestinationService(secrets SecretResolver) DestinationService {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	return NewDestinationService(newMemDestinationRepository(), NewNetworkProber(time.Second), secrets, logger)
+}
+
+func TestDestinationService_RenderConfig(t *testing.T) {
+	svc := newTestDestinationService(nil)
+	ctx := context.Background()
+
+	_, err := svc.CreateDestination(ctx, &models.CreateDestinationRequest{
+		Name: "logs-main",
+		Type: models.DestinationTypeElasticsearch,
+		Environments: map[string]*models.DestinationEndpoint{
+			"prod":                    {Hosts: []string{"es-prod-1:9200", "es-prod-2:9200"}, SSL: true, Username: "logstash", PasswordSecret: "ES_PROD_PASSWORD"},
+			models.DefaultEnvironment: {Hosts: []string{"es-dev:9200"}},
+		},
+	}, "alice")
+	require.NoError(t, err)
+	_, err = svc.CreateDestination(ctx, &models.CreateDestinationRequest{
+		Name: "events",
+		Type: models.DestinationTypeKafka,
+		Environments: map[string]*models.DestinationEndpoint{
+			"prod": {Hosts: []string{"k1:9093", "k2:9093"}, SSL: true, Username: "lp", PasswordSecret: "KAFKA_PASSWORD"},
+		},
+	}, "alice")
+	require.NoError(t, err)
+
+	config := &models.Config{ID: "c1", Version: 2, Content: `output {
+  elasticsearch {
+    destination => "logs-main"
+    index => "logs-%{+YYYY.MM.dd}"
+  }
+  kafka {
+    destination => 'events'
+    topic_id => "events"
+  }
+}`}
+
+	rendered, err := svc.RenderConfig(ctx, config, "prod")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"events", "logs-main"}, rendered.Destinations)
+	assert.Equal(t, `output {
+  elasticsearch {
+    hosts => ["https://es-prod-1:9200", "https://es-prod-2:9200"]
+    user => "logstash"
+    password => "${ES_PROD_PASSWORD}"
+    index => "logs-%{+YYYY.MM.dd}"
+  }
+  kafka {
+    bootstrap_servers => "k1:9093,k2:9093"
+    security_protocol => "SASL_SSL"
+    sasl_mechanism => "PLAIN"
+    sasl_jaas_config => "org.apache.kafka.common.security.plain.PlainLoginModule required username='lp' password='${KAFKA_PASSWORD}';"
+    topic_id => "events"
+  }
+}`, rendered.Content)
+
+	t.Run("环境回退到default", func(t *testing.T) {
+		content := "output {\n  elasticsearch {\n    destination => \"logs-main\"\n  }\n}"
+		rendered, err := svc.RenderConfig(ctx, &models.Config{Content: content}, "staging")
+		require.NoError(t, err)
+		assert.Contains(t, rendered.Content, `hosts => ["http://es-dev:9200"]`)
+		assert.NotContains(t, rendered.Content, "password")
+	})
+
+	t.Run("未配置的环境", func(t *testing.T) {
+		_, err := svc.RenderConfig(ctx, config, "staging")
+		assert.ErrorIs(t, err, ErrDestinationEnvironment)
+	})
+
+	t.Run("不存在的下游集群", func(t *testing.T) {
+		_, err := svc.RenderConfig(ctx, &models.Config{Content: `destination => "missing"`}, "prod")
+		assert.ErrorIs(t, err, ErrUnknownDestination)
+	})
+
+	t.Run("未引用下游集群的配置原样返回", func(t *testing.T) {
+		rendered, err := svc.RenderConfig(ctx, &models.Config{Content: "filter { mutate {} }"}, "prod")
+		require.NoError(t, err)
+		assert.Equal(t, "filter { mutate {} }", rendered.Content)
+	})
+}
+
+func TestDestinationService_Validate(t *testing.T) {
+	svc := newTestDestinationService(nil)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		envs map[string]*models.DestinationEndpoint
+	}{
+		{name: "no environments"},
+		{name: "no hosts", envs: map[string]*models.DestinationEndpoint{"prod": {}}},
+		{name: "bad secret name", envs: map[string]*models.DestinationEndpoint{"prod": {Hosts: []string{"es:9200"}, PasswordSecret: "${X}"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.CreateDestination(ctx, &models.CreateDestinationRequest{Name: "d", Type: models.DestinationTypeElasticsearch, Environments: tt.envs}, "alice")
+			assert.Error(t, err)
+		})
+	}
+
+	req := &models.CreateDestinationRequest{Name: "d", Type: models.DestinationTypeElasticsearch,
+		Environments: map[string]*models.DestinationEndpoint{"prod": {Hosts: []string{"es:9200"}}}}
+	_, err := svc.CreateDestination(ctx, req, "alice")
+	require.NoError(t, err)
+	_, err = svc.CreateDestination(ctx, req, "alice")
+	assert.ErrorContains(t, err, "下游集群已存在")
+}
+
+func TestDestinationService_Check(t *testing.T) {
+	es := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "logstash" || pass != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer es.Close()
+	addr := strings.TrimPrefix(es.URL, "http://")
+
+	svc := newTestDestinationService(staticSecrets{"ES_PASSWORD": "s3cret"})
+	ctx := context.Background()
+
+	_, err := svc.CreateDestination(ctx, &models.CreateDestinationRequest{
+		Name: "logs-main",
+		Type: models.DestinationTypeElasticsearch,
+		Environments: map[string]*models.DestinationEndpoint{
+			"prod":    {Hosts: []string{addr}, Username: "logstash", PasswordSecret: "ES_PASSWORD"},
+			"staging": {Hosts: []string{addr, "127.0.0.1:1"}, Username: "logstash", PasswordSecret: "ES_PASSWORD"},
+			"dev":     {Hosts: []string{addr}, Username: "logstash", PasswordSecret: "MISSING_PASSWORD"},
+		},
+	}, "alice")
+	require.NoError(t, err)
+	_, err = svc.CreateDestination(ctx, &models.CreateDestinationRequest{
+		Name:         "events",
+		Type:         models.DestinationTypeKafka,
+		Environments: map[string]*models.DestinationEndpoint{"prod": {Hosts: []string{addr}}},
+	}, "alice")
+	require.NoError(t, err)
+
+	require.NoError(t, svc.CheckAll(ctx))
+
+	dest, err := svc.GetDestination(ctx, "logs-main")
+	require.NoError(t, err)
+	assert.True(t, dest.Checks["prod"].Healthy)
+	assert.False(t, dest.Checks["staging"].Healthy)
+	assert.Equal(t, 1, dest.Checks["staging"].Reachable)
+	assert.Equal(t, 2, dest.Checks["staging"].Total)
+	assert.False(t, dest.Checks["dev"].Healthy)
+	assert.Contains(t, dest.Checks["dev"].Error, "MISSING_PASSWORD")
+
+	events, err := svc.GetDestination(ctx, "events")
+	require.NoError(t, err)
+	assert.True(t, events.Checks["prod"].Healthy)
+
+	// 更新连接信息后清除旧的检查结果
+	updated, err := svc.UpdateDestination(ctx, "events", &models.UpdateDestinationRequest{
+		Environments: map[string]*models.DestinationEndpoint{"prod": {Hosts: []string{"127.0.0.1:1"}}},
+	}, "alice")
+	require.NoError(t, err)
+	assert.Nil(t, updated.Checks)
+}
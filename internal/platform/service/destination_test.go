@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractDestinations(t *testing.T) {
+	content := `output {
+  elasticsearch {
+    hosts => ["http://es2:9200", "http://es1:9200/"]
+    index => "logs-%{+YYYY.MM.dd}"
+  }
+  kafka {
+    bootstrap_servers => "kafka2:9092,kafka1:9092"
+    topic_id => "events"
+  }
+}`
+
+	assert.Equal(t, []string{
+		"elasticsearch:es1:9200,es2:9200",
+		"kafka:kafka1:9092,kafka2:9092",
+	}, ExtractDestinations(content))
+
+	assert.Empty(t, ExtractDestinations("filter { mutate {} }"))
+
+	// 通过注册表引用的下游集群以名称标识
+	assert.Equal(t, []string{"destination:logs-main"}, ExtractDestinations("output {\n  elasticsearch {\n    destination => \"logs-main\"\n  }\n}"))
+}
+
+func TestDestinationThrottle(t *testing.T) {
+	throttle := NewDestinationThrottle(1, map[string]int{"kafka:k1": 0})
+	ctx := context.Background()
+
+	release, err := throttle.Acquire(ctx, []string{"elasticsearch:es1", "kafka:k1"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, throttle.Stats()["elasticsearch:es1"].InFlight)
+	// 限制为0的集群不节流
+	_, tracked := throttle.Stats()["kafka:k1"]
+	assert.False(t, tracked)
+
+	// 同一集群的第二个部署需等待
+	timeoutCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	_, err = throttle.Acquire(timeoutCtx, []string{"elasticsearch:es1"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 0, throttle.Stats()["elasticsearch:es1"].Waiting)
+
+	release()
+	release2, err := throttle.Acquire(ctx, []string{"elasticsearch:es1"})
+	require.NoError(t, err)
+	release2()
+	assert.Equal(t, 0, throttle.Stats()["elasticsearch:es1"].InFlight)
+}
+
+func TestDestinationThrottle_ReleaseIdempotent(t *testing.T) {
+	throttle := NewDestinationThrottle(2, nil)
+	ctx := context.Background()
+
+	release1, err := throttle.Acquire(ctx, []string{"elasticsearch:es1"})
+	require.NoError(t, err)
+	release2, err := throttle.Acquire(ctx, []string{"elasticsearch:es1"})
+	require.NoError(t, err)
+
+	// 重复释放不能归还其他部署占用的名额
+	release1()
+	release1()
+	assert.Equal(t, 1, throttle.Stats()["elasticsearch:es1"].InFlight)
+
+	release2()
+	assert.Equal(t, 0, throttle.Stats()["elasticsearch:es1"].InFlight)
+}
+
+// queueDepthFunc 按调用次数返回下游积压
+type queueDepthFunc func(dest string) (int, error)
+
+func (f queueDepthFunc) QueueDepth(ctx context.Context, dest string) (int, error) {
+	return f(dest)
+}
+
+func TestDestinationThrottle_QueueDepth(t *testing.T) {
+	throttle := NewDestinationThrottle(1, nil)
+	calls := 0
+	throttle.SetQueueDepthProbe(queueDepthFunc(func(dest string) (int, error) {
+		calls++
+		if calls < 3 {
+			return 500, nil
+		}
+		return 10, nil
+	}), 100, time.Millisecond)
+
+	// 积压回落后才获得名额
+	release, err := throttle.Acquire(context.Background(), []string{"elasticsearch:es1"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, 10, throttle.Stats()["elasticsearch:es1"].QueueDepth)
+	release()
+
+	// 积压持续过高时随ctx超时放弃并归还名额
+	calls = 0
+	throttle.SetQueueDepthProbe(queueDepthFunc(func(dest string) (int, error) {
+		return 500, nil
+	}), 100, time.Millisecond)
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = throttle.Acquire(timeoutCtx, []string{"elasticsearch:es1"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 0, throttle.Stats()["elasticsearch:es1"].InFlight)
+
+	// 探测失败时不阻塞部署
+	throttle.SetQueueDepthProbe(queueDepthFunc(func(dest string) (int, error) {
+		return 0, ErrQueueDepthUnsupported
+	}), 100, time.Millisecond)
+	release, err = throttle.Acquire(context.Background(), []string{"kafka:k1"})
+	require.NoError(t, err)
+	release()
+}
+
+func TestESQueueProbe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/_cat/thread_pool/write", r.URL.Path)
+		w.Write([]byte(`[{"queue":"12"},{"queue":"30"}]`))
+	}))
+	defer server.Close()
+
+	probe := NewESQueueProbe(ESQueueProbeConfig{})
+	addr := strings.TrimPrefix(server.URL, "http://")
+
+	// 第一个节点不可达时尝试下一个节点
+	depth, err := probe.QueueDepth(context.Background(), "elasticsearch:127.0.0.1:1,"+addr)
+	require.NoError(t, err)
+	assert.Equal(t, 42, depth)
+
+	_, err = probe.QueueDepth(context.Background(), "kafka:k1:9092")
+	assert.ErrorIs(t, err, ErrQueueDepthUnsupported)
+}
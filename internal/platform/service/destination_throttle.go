@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"logstash-platform/internal/platform/models"
+)
+
+// DestinationStats 单个下游集群的节流状态
+type DestinationStats struct {
+	Limit      int `json:"limit"`
+	InFlight   int `json:"in_flight"`
+	Waiting    int `json:"waiting"`
+	QueueDepth int `json:"queue_depth"` // 最近一次探测到的下游积压，未探测时为0
+}
+
+// QueueDepthProbe 探测下游集群当前的写入积压深度
+// 不支持的集群类型返回 ErrQueueDepthUnsupported
+type QueueDepthProbe interface {
+	QueueDepth(ctx context.Context, destination string) (int, error)
+}
+
+// DestinationThrottle 按下游集群限制同时重载的Pipeline数量
+// 全量发布时避免大量Pipeline同时重连同一个ES/Kafka集群
+type DestinationThrottle struct {
+	mu           sync.Mutex
+	defaultLimit int
+	limits       map[string]int
+	slots        map[string]chan struct{}
+	waiting      map[string]int
+
+	// 下游积压感知：积压超过maxQueueDepth时暂缓在该集群上发起新的重载
+	probe         QueueDepthProbe
+	maxQueueDepth int
+	pollInterval  time.Duration
+	queueDepths   map[string]int
+}
+
+// NewDestinationThrottle 创建节流器，defaultLimit<=0时不限制
+// limits 可按集群标识覆盖默认并发数
+func NewDestinationThrottle(defaultLimit int, limits map[string]int) *DestinationThrottle {
+	if limits == nil {
+		limits = make(map[string]int)
+	}
+	return &DestinationThrottle{
+		defaultLimit: defaultLimit,
+		limits:       limits,
+		slots:        make(map[string]chan struct{}),
+		waiting:      make(map[string]int),
+		queueDepths:  make(map[string]int),
+	}
+}
+
+// SetQueueDepthProbe 启用下游积压感知，maxDepth<=0时不启用
+// 获得并发名额后若下游积压超过maxDepth，按pollInterval轮询直到积压回落
+// 探测失败时不阻塞部署，避免监控不可达导致发布停滞
+func (t *DestinationThrottle) SetQueueDepthProbe(probe QueueDepthProbe, maxDepth int, pollInterval time.Duration) {
+	if pollInterval <= 0 {
+		pollInterval = 5 * time.Second
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.probe = probe
+	t.maxQueueDepth = maxDepth
+	t.pollInterval = pollInterval
+}
+
+// Acquire 占用配置涉及的全部下游集群的并发名额，返回释放函数
+// 按集群标识排序后依次获取，避免不同部署相互等待导致死锁
+// 释放函数可重复调用，只有第一次生效
+func (t *DestinationThrottle) Acquire(ctx context.Context, destinations []string) (func(), error) {
+	sorted := append([]string(nil), destinations...)
+	sort.Strings(sorted)
+
+	acquired := make([]chan struct{}, 0, len(sorted))
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			for i := len(acquired) - 1; i >= 0; i-- {
+				<-acquired[i]
+			}
+		})
+	}
+
+	for i, dest := range sorted {
+		if i > 0 && dest == sorted[i-1] {
+			continue
+		}
+		slot := t.slot(dest)
+		if slot == nil {
+			continue
+		}
+
+		t.adjustWaiting(dest, 1)
+		select {
+		case slot <- struct{}{}:
+			t.adjustWaiting(dest, -1)
+			acquired = append(acquired, slot)
+		case <-ctx.Done():
+			t.adjustWaiting(dest, -1)
+			release()
+			return nil, ctx.Err()
+		}
+
+		if err := t.waitQueueDrain(ctx, dest); err != nil {
+			release()
+			return nil, err
+		}
+	}
+
+	return release, nil
+}
+
+// waitQueueDrain 等待下游积压回落到阈值以下
+func (t *DestinationThrottle) waitQueueDrain(ctx context.Context, dest string) error {
+	t.mu.Lock()
+	probe, maxDepth, interval := t.probe, t.maxQueueDepth, t.pollInterval
+	t.mu.Unlock()
+	if probe == nil || maxDepth <= 0 {
+		return nil
+	}
+
+	for {
+		depth, err := probe.QueueDepth(ctx, dest)
+		if err != nil {
+			return nil
+		}
+
+		t.mu.Lock()
+		t.queueDepths[dest] = depth
+		t.mu.Unlock()
+		if depth <= maxDepth {
+			return nil
+		}
+
+		t.adjustWaiting(dest, 1)
+		select {
+		case <-time.After(interval):
+			t.adjustWaiting(dest, -1)
+		case <-ctx.Done():
+			t.adjustWaiting(dest, -1)
+			return ctx.Err()
+		}
+	}
+}
+
+// Stats 返回各下游集群当前的并发与排队情况
+func (t *DestinationThrottle) Stats() map[string]DestinationStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := make(map[string]DestinationStats, len(t.slots))
+	for dest, slot := range t.slots {
+		stats[dest] = DestinationStats{
+			Limit:      cap(slot),
+			InFlight:   len(slot),
+			Waiting:    t.waiting[dest],
+			QueueDepth: t.queueDepths[dest],
+		}
+	}
+	return stats
+}
+
+// slot 获取集群的并发名额通道，不限制时返回nil
+func (t *DestinationThrottle) slot(dest string) chan struct{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if slot, ok := t.slots[dest]; ok {
+		return slot
+	}
+
+	limit := t.defaultLimit
+	if l, ok := t.limits[dest]; ok {
+		limit = l
+	}
+	if limit <= 0 {
+		return nil
+	}
+
+	slot := make(chan struct{}, limit)
+	t.slots[dest] = slot
+	return slot
+}
+
+// adjustWaiting 更新排队计数
+func (t *DestinationThrottle) adjustWaiting(dest string, delta int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.waiting[dest] += delta
+}
+
+// WorkerStatus 报告下游集群节流的汇总：占用的并发名额、排队等待的重载和最大的下游积压
+func (t *DestinationThrottle) WorkerStatus(now time.Time) models.WorkerStatus {
+	var inFlight, waiting, maxDepth int
+	stats := t.Stats()
+	for _, s := range stats {
+		inFlight += s.InFlight
+		waiting += s.Waiting
+		maxDepth = max(maxDepth, s.QueueDepth)
+	}
+	return models.WorkerStatus{
+		Name:    "destination_throttle",
+		Kind:    models.WorkerKindPool,
+		Running: true,
+		Gauges: map[string]float64{
+			"destinations":    float64(len(stats)),
+			"in_flight":       float64(inFlight),
+			"waiting":         float64(waiting),
+			"max_queue_depth": float64(maxDepth),
+		},
+	}
+}
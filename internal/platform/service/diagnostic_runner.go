@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+)
+
+// ErrDiagnosticTimeout 等待Agent回复诊断结果超时
+var ErrDiagnosticTimeout = errors.New("等待Agent回复诊断结果超时")
+
+// defaultDiagnosticTimeout 默认等待诊断结果的时长，logstash --version需要启动JVM
+const defaultDiagnosticTimeout = 60 * time.Second
+
+// pendingDiagnostic 等待回复的诊断请求
+type pendingDiagnostic struct {
+	agentID string
+	result  chan *models.DiagnosticResult
+}
+
+// DiagnosticRunner 向Agent下发白名单内的诊断命令并等待结果
+// 请求只经WebSocket下发，Agent未连接时直接失败；Agent只执行固定的诊断操作，不接受任意命令
+type DiagnosticRunner struct {
+	channel LiveChannel
+	timeout time.Duration
+	logger  *logrus.Logger
+
+	mu      sync.Mutex
+	pending map[string]*pendingDiagnostic
+}
+
+// NewDiagnosticRunner 创建远程诊断，timeout为0时使用默认值
+func NewDiagnosticRunner(channel LiveChannel, timeout time.Duration, logger *logrus.Logger) *DiagnosticRunner {
+	if timeout <= 0 {
+		timeout = defaultDiagnosticTimeout
+	}
+	return &DiagnosticRunner{
+		channel: channel,
+		timeout: timeout,
+		logger:  logger,
+		pending: make(map[string]*pendingDiagnostic),
+	}
+}
+
+// Run 下发诊断请求并等待Agent回复，ctx取消或超时时返回错误
+func (r *DiagnosticRunner) Run(ctx context.Context, agentID string, req *models.DiagnosticRequest) (*models.DiagnosticResult, error) {
+	if !r.channel.IsConnected(agentID) {
+		return nil, fmt.Errorf("%w: %s", ErrAgentOffline, agentID)
+	}
+
+	payload := models.DiagnosticPayload{RequestID: uuid.New().String(), DiagnosticRequest: *req}
+	wait := &pendingDiagnostic{agentID: agentID, result: make(chan *models.DiagnosticResult, 1)}
+	r.mu.Lock()
+	r.pending[payload.RequestID] = wait
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		delete(r.pending, payload.RequestID)
+		r.mu.Unlock()
+	}()
+
+	if err := r.channel.Publish(agentID, models.MsgTypeDiagnosticRequest, payload); err != nil {
+		return nil, fmt.Errorf("下发诊断请求失败: %w", err)
+	}
+	r.logger.WithFields(logrus.Fields{
+		"agent_id":   agentID,
+		"request_id": payload.RequestID,
+		"command":    req.Command,
+	}).Info("下发远程诊断")
+
+	timer := time.NewTimer(r.timeout)
+	defer timer.Stop()
+	select {
+	case result := <-wait.result:
+		return result, nil
+	case <-timer.C:
+		return nil, ErrDiagnosticTimeout
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Deliver 处理Agent回复的诊断结果，请求已超时或不属于该Agent时忽略
+func (r *DiagnosticRunner) Deliver(agentID string, result *models.DiagnosticResult) {
+	r.mu.Lock()
+	wait := r.pending[result.RequestID]
+	r.mu.Unlock()
+
+	if wait == nil || wait.agentID != agentID {
+		r.logger.WithFields(logrus.Fields{
+			"agent_id":   agentID,
+			"request_id": result.RequestID,
+		}).Debug("忽略无人等待的诊断结果")
+		return
+	}
+	select {
+	case wait.result <- result:
+	default:
+	}
+}
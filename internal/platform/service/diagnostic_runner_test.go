@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+)
+
+func TestDiagnosticRunner_Run(t *testing.T) {
+	channel := &liveChannel{}
+	runner := NewDiagnosticRunner(channel, 50*time.Millisecond, logrus.New())
+	req := &models.DiagnosticRequest{Command: models.DiagnosticListConfigs}
+
+	_, err := runner.Run(context.Background(), "agent-1", req)
+	assert.True(t, errors.Is(err, ErrAgentOffline))
+	assert.Empty(t, channel.sent, "Agent未连接时不下发")
+
+	// Agent未回复时超时
+	channel.connected = true
+	_, err = runner.Run(context.Background(), "agent-1", req)
+	assert.Equal(t, ErrDiagnosticTimeout, err)
+	require.Len(t, channel.sent, 1)
+	assert.Equal(t, models.MsgTypeDiagnosticRequest, channel.sent[0].msgType)
+
+	// 其他Agent回复同一request_id时忽略
+	runner.timeout = time.Second
+	done := make(chan *models.DiagnosticResult)
+	go func() {
+		result, err := runner.Run(context.Background(), "agent-1", req)
+		assert.NoError(t, err)
+		done <- result
+	}()
+	require.Eventually(t, func() bool {
+		runner.mu.Lock()
+		defer runner.mu.Unlock()
+		return len(runner.pending) == 1
+	}, time.Second, 5*time.Millisecond)
+	runner.mu.Lock()
+	var requestID string
+	for id := range runner.pending {
+		requestID = id
+	}
+	runner.mu.Unlock()
+
+	runner.Deliver("agent-2", &models.DiagnosticResult{RequestID: requestID, Output: "spoofed"})
+	runner.Deliver("agent-1", &models.DiagnosticResult{RequestID: requestID, Command: models.DiagnosticListConfigs, Output: "nginx.conf"})
+	result := <-done
+	assert.Equal(t, "nginx.conf", result.Output)
+	assert.Empty(t, runner.pending)
+}
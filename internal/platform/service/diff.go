@@ -0,0 +1,192 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DiffOp 差异操作类型
+type DiffOp string
+
+const (
+	DiffOpEqual  DiffOp = "equal"
+	DiffOpAdd    DiffOp = "add"
+	DiffOpDelete DiffOp = "delete"
+)
+
+// DiffLine 行级差异
+type DiffLine struct {
+	Op   DiffOp `json:"op"`
+	Text string `json:"text"`
+}
+
+// DiffLines 基于最长公共子序列计算两段文本的行级差异
+// 配置文件通常只有几百行，O(n*m) 的开销可以接受
+func DiffLines(oldText, newText string) []DiffLine {
+	oldLines := splitLines(oldText)
+	newLines := splitLines(newText)
+	n, m := len(oldLines), len(newLines)
+
+	// lcs[i][j] 表示 oldLines[i:] 与 newLines[j:] 的最长公共子序列长度
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	diff := make([]DiffLine, 0, n+m)
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case oldLines[i] == newLines[j]:
+			diff = append(diff, DiffLine{Op: DiffOpEqual, Text: oldLines[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, DiffLine{Op: DiffOpDelete, Text: oldLines[i]})
+			i++
+		default:
+			diff = append(diff, DiffLine{Op: DiffOpAdd, Text: newLines[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		diff = append(diff, DiffLine{Op: DiffOpDelete, Text: oldLines[i]})
+	}
+	for ; j < m; j++ {
+		diff = append(diff, DiffLine{Op: DiffOpAdd, Text: newLines[j]})
+	}
+
+	return diff
+}
+
+// countDiffLines 统计新增和删除的行数
+func countDiffLines(lines []DiffLine) (additions, deletions int) {
+	for _, line := range lines {
+		switch line.Op {
+		case DiffOpAdd:
+			additions++
+		case DiffOpDelete:
+			deletions++
+		}
+	}
+	return additions, deletions
+}
+
+// DiffHunk 一段连续的变更及其上下文，行号从1开始
+type DiffHunk struct {
+	OldStart int        `json:"old_start"`
+	OldLines int        `json:"old_lines"`
+	NewStart int        `json:"new_start"`
+	NewLines int        `json:"new_lines"`
+	Lines    []DiffLine `json:"lines"`
+}
+
+// Header 统一格式的段头
+func (h DiffHunk) Header() string {
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+}
+
+// DiffHunks 将行级差异划分为变更段，每段前后保留contextLines行未变更的上下文
+// 间隔不超过2*contextLines行的变更合并为同一段
+func DiffHunks(diff []DiffLine, contextLines int) []DiffHunk {
+	// oldPos[i]、newPos[i] 为diff[i]之前旧、新文本已经过的行数
+	oldPos := make([]int, len(diff)+1)
+	newPos := make([]int, len(diff)+1)
+	for i, line := range diff {
+		oldPos[i+1], newPos[i+1] = oldPos[i], newPos[i]
+		if line.Op != DiffOpAdd {
+			oldPos[i+1]++
+		}
+		if line.Op != DiffOpDelete {
+			newPos[i+1]++
+		}
+	}
+
+	var hunks []DiffHunk
+	start, end := -1, -1
+	flush := func() {
+		if start < 0 {
+			return
+		}
+		from := max(0, start-contextLines)
+		to := min(len(diff), end+contextLines+1)
+		hunk := DiffHunk{
+			OldStart: oldPos[from] + 1,
+			OldLines: oldPos[to] - oldPos[from],
+			NewStart: newPos[from] + 1,
+			NewLines: newPos[to] - newPos[from],
+			Lines:    diff[from:to],
+		}
+		// 与diff工具一致，没有行的一侧起始行号取前一行
+		if hunk.OldLines == 0 {
+			hunk.OldStart--
+		}
+		if hunk.NewLines == 0 {
+			hunk.NewStart--
+		}
+		hunks = append(hunks, hunk)
+	}
+
+	for i, line := range diff {
+		if line.Op == DiffOpEqual {
+			continue
+		}
+		if start >= 0 && i-end-1 > 2*contextLines {
+			flush()
+			start = -1
+		}
+		if start < 0 {
+			start = i
+		}
+		end = i
+	}
+	flush()
+
+	return hunks
+}
+
+// UnifiedDiff 生成统一格式的差异文本，没有变更时返回空字符串
+func UnifiedDiff(oldName, newName string, hunks []DiffHunk) string {
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+	for _, hunk := range hunks {
+		b.WriteString(hunk.Header())
+		b.WriteByte('\n')
+		for _, line := range hunk.Lines {
+			switch line.Op {
+			case DiffOpAdd:
+				b.WriteByte('+')
+			case DiffOpDelete:
+				b.WriteByte('-')
+			default:
+				b.WriteByte(' ')
+			}
+			b.WriteString(line.Text)
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// splitLines 按行拆分文本，空文本返回空切片
+func splitLines(text string) []string {
+	if text == "" {
+		return []string{}
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
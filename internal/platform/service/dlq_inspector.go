@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+)
+
+// ErrDLQTimeout 等待Agent回复死信队列超时
+var ErrDLQTimeout = errors.New("等待Agent回复死信队列超时")
+
+// defaultDLQTimeout 默认等待死信队列结果的时长
+const defaultDLQTimeout = 30 * time.Second
+
+// pendingDLQ 等待回复的死信队列请求
+type pendingDLQ struct {
+	agentID string
+	result  chan *models.DLQResult
+}
+
+// DLQInspector 经Agent分页读取管道死信队列中的事件，用于远程排查写入失败的事件
+// 请求只经WebSocket下发，Agent未连接时直接失败
+type DLQInspector struct {
+	channel LiveChannel
+	timeout time.Duration
+	logger  *logrus.Logger
+
+	mu      sync.Mutex
+	pending map[string]*pendingDLQ
+}
+
+// NewDLQInspector 创建死信队列查看，timeout为0时使用默认值
+func NewDLQInspector(channel LiveChannel, timeout time.Duration, logger *logrus.Logger) *DLQInspector {
+	if timeout <= 0 {
+		timeout = defaultDLQTimeout
+	}
+	return &DLQInspector{
+		channel: channel,
+		timeout: timeout,
+		logger:  logger,
+		pending: make(map[string]*pendingDLQ),
+	}
+}
+
+// Read 下发死信队列请求并等待Agent回复，ctx取消或超时时返回错误
+func (i *DLQInspector) Read(ctx context.Context, agentID string, req *models.DLQRequest) (*models.DLQResult, error) {
+	if !i.channel.IsConnected(agentID) {
+		return nil, fmt.Errorf("%w: %s", ErrAgentOffline, agentID)
+	}
+
+	payload := models.DLQPayload{RequestID: uuid.New().String(), DLQRequest: *req}
+	wait := &pendingDLQ{agentID: agentID, result: make(chan *models.DLQResult, 1)}
+	i.mu.Lock()
+	i.pending[payload.RequestID] = wait
+	i.mu.Unlock()
+	defer func() {
+		i.mu.Lock()
+		delete(i.pending, payload.RequestID)
+		i.mu.Unlock()
+	}()
+
+	if err := i.channel.Publish(agentID, models.MsgTypeDLQRequest, payload); err != nil {
+		return nil, fmt.Errorf("下发死信队列请求失败: %w", err)
+	}
+	i.logger.WithFields(logrus.Fields{
+		"agent_id":   agentID,
+		"request_id": payload.RequestID,
+		"pipeline":   req.Pipeline,
+		"page":       req.Page,
+	}).Debug("下发死信队列请求")
+
+	timer := time.NewTimer(i.timeout)
+	defer timer.Stop()
+	select {
+	case result := <-wait.result:
+		return result, nil
+	case <-timer.C:
+		return nil, ErrDLQTimeout
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Deliver 处理Agent回复的死信队列结果，请求已超时或不属于该Agent时忽略
+func (i *DLQInspector) Deliver(agentID string, result *models.DLQResult) {
+	i.mu.Lock()
+	wait := i.pending[result.RequestID]
+	i.mu.Unlock()
+
+	if wait == nil || wait.agentID != agentID {
+		i.logger.WithFields(logrus.Fields{
+			"agent_id":   agentID,
+			"request_id": result.RequestID,
+		}).Debug("忽略无人等待的死信队列结果")
+		return
+	}
+	select {
+	case wait.result <- result:
+	default:
+	}
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+)
+
+func TestDLQInspector_Read(t *testing.T) {
+	channel := &liveChannel{}
+	inspector := NewDLQInspector(channel, 50*time.Millisecond, logrus.New())
+	req := &models.DLQRequest{Pipeline: "main", Page: 2, PageSize: 10}
+
+	_, err := inspector.Read(context.Background(), "agent-1", req)
+	assert.True(t, errors.Is(err, ErrAgentOffline))
+	assert.Empty(t, channel.sent, "Agent未连接时不下发")
+
+	// Agent未回复时超时
+	channel.connected = true
+	_, err = inspector.Read(context.Background(), "agent-1", req)
+	assert.Equal(t, ErrDLQTimeout, err)
+	require.Len(t, channel.sent, 1)
+	assert.Equal(t, models.MsgTypeDLQRequest, channel.sent[0].msgType)
+	payload := channel.sent[0].payload.(models.DLQPayload)
+	assert.Equal(t, *req, payload.DLQRequest)
+
+	// 其他Agent回复同一request_id时忽略
+	inspector.timeout = time.Second
+	done := make(chan *models.DLQResult)
+	go func() {
+		result, err := inspector.Read(context.Background(), "agent-1", req)
+		assert.NoError(t, err)
+		done <- result
+	}()
+	require.Eventually(t, func() bool {
+		inspector.mu.Lock()
+		defer inspector.mu.Unlock()
+		return len(inspector.pending) == 1
+	}, time.Second, 5*time.Millisecond)
+	inspector.mu.Lock()
+	var requestID string
+	for id := range inspector.pending {
+		requestID = id
+	}
+	inspector.mu.Unlock()
+
+	inspector.Deliver("agent-2", &models.DLQResult{RequestID: requestID, Error: "spoofed"})
+	inspector.Deliver("agent-1", &models.DLQResult{RequestID: requestID, Pipeline: "main", Entries: []models.DLQEntry{{Reason: "mapper_parsing_exception"}}})
+	result := <-done
+	assert.Empty(t, result.Error)
+	require.Len(t, result.Entries, 1)
+	assert.Equal(t, "mapper_parsing_exception", result.Entries[0].Reason)
+	assert.Empty(t, inspector.pending)
+}
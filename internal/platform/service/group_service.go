@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+)
+
+// GroupService Agent分组服务接口
+type GroupService interface {
+	CreateGroup(ctx context.Context, req *models.CreateGroupRequest, userID string) (*models.AgentGroup, error)
+	UpdateGroup(ctx context.Context, name string, req *models.UpdateGroupRequest, userID string) (*models.AgentGroup, error)
+	DeleteGroup(ctx context.Context, name string) error
+	GetGroup(ctx context.Context, name string) (*models.AgentGroup, error)
+	ListGroups(ctx context.Context) ([]*models.AgentGroup, error)
+	GetEffectiveSettings(ctx context.Context, agentID string) (*models.EffectiveSettings, error)
+	UpdateAgentSettings(ctx context.Context, agentID string, req *models.UpdateAgentSettingsRequest) (*models.EffectiveSettings, error)
+}
+
+// groupService Agent分组服务实现
+type groupService struct {
+	groupRepo repository.GroupRepository
+	agentRepo repository.AgentRepository
+	publisher MessagePublisher
+	logger    *logrus.Logger
+}
+
+// NewGroupService 创建Agent分组服务
+// publisher可以为nil，此时设置变更只保存不推送，Agent在下次注册时获取
+func NewGroupService(groupRepo repository.GroupRepository, agentRepo repository.AgentRepository, publisher MessagePublisher, logger *logrus.Logger) GroupService {
+	return &groupService{
+		groupRepo: groupRepo,
+		agentRepo: agentRepo,
+		publisher: publisher,
+		logger:    logger,
+	}
+}
+
+// CreateGroup 创建分组
+func (s *groupService) CreateGroup(ctx context.Context, req *models.CreateGroupRequest, userID string) (*models.AgentGroup, error) {
+	if err := validateSettings(&req.Settings); err != nil {
+		return nil, fmt.Errorf("分组设置验证失败: %w", err)
+	}
+
+	if _, err := s.groupRepo.GetByName(ctx, req.Name); err == nil {
+		return nil, fmt.Errorf("分组已存在: %s", req.Name)
+	}
+
+	group := &models.AgentGroup{
+		Name:        req.Name,
+		Description: req.Description,
+		Settings:    req.Settings,
+		CreatedBy:   userID,
+		UpdatedBy:   userID,
+	}
+
+	if err := s.groupRepo.Create(ctx, group); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"group":   group.Name,
+		"user_id": userID,
+	}).Info("创建分组成功")
+
+	return group, nil
+}
+
+// UpdateGroup 更新分组，并向组内Agent推送新的生效设置
+func (s *groupService) UpdateGroup(ctx context.Context, name string, req *models.UpdateGroupRequest, userID string) (*models.AgentGroup, error) {
+	group, err := s.groupRepo.GetByName(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("分组不存在: %w", err)
+	}
+
+	if err := validateSettings(&req.Settings); err != nil {
+		return nil, fmt.Errorf("分组设置验证失败: %w", err)
+	}
+
+	group.Description = req.Description
+	group.Settings = req.Settings
+	group.UpdatedBy = userID
+
+	if err := s.groupRepo.Update(ctx, group); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"group":   group.Name,
+		"user_id": userID,
+	}).Info("更新分组成功")
+
+	// 向组内Agent下发设置
+	agents, err := s.agentRepo.ListByGroup(ctx, name)
+	if err != nil {
+		s.logger.WithError(err).WithField("group", name).Warn("获取分组Agent失败，跳过设置下发")
+		return group, nil
+	}
+	for _, agent := range agents {
+		s.pushSettings(agent.AgentID, group.Settings.Merge(agent.Settings))
+	}
+
+	return group, nil
+}
+
+// DeleteGroup 删除分组，仍有Agent引用的分组不允许删除
+func (s *groupService) DeleteGroup(ctx context.Context, name string) error {
+	if _, err := s.groupRepo.GetByName(ctx, name); err != nil {
+		return fmt.Errorf("分组不存在: %w", err)
+	}
+
+	agents, err := s.agentRepo.ListByGroup(ctx, name)
+	if err != nil {
+		return err
+	}
+	if len(agents) > 0 {
+		return fmt.Errorf("分组仍包含 %d 个Agent，无法删除", len(agents))
+	}
+
+	if err := s.groupRepo.Delete(ctx, name); err != nil {
+		return err
+	}
+
+	s.logger.WithField("group", name).Info("删除分组成功")
+	return nil
+}
+
+// GetGroup 获取分组
+func (s *groupService) GetGroup(ctx context.Context, name string) (*models.AgentGroup, error) {
+	return s.groupRepo.GetByName(ctx, name)
+}
+
+// ListGroups 获取分组列表
+func (s *groupService) ListGroups(ctx context.Context) ([]*models.AgentGroup, error) {
+	return s.groupRepo.List(ctx)
+}
+
+// GetEffectiveSettings 计算Agent的最终生效设置（分组默认值 + Agent覆盖值）
+func (s *groupService) GetEffectiveSettings(ctx context.Context, agentID string) (*models.EffectiveSettings, error) {
+	agent, err := s.agentRepo.GetByID(ctx, agentID)
+	if err != nil {
+		return nil, fmt.Errorf("Agent不存在: %w", err)
+	}
+
+	return s.resolveSettings(ctx, agent), nil
+}
+
+// UpdateAgentSettings 更新Agent的分组归属和设置覆盖，并推送生效设置
+func (s *groupService) UpdateAgentSettings(ctx context.Context, agentID string, req *models.UpdateAgentSettingsRequest) (*models.EffectiveSettings, error) {
+	agent, err := s.agentRepo.GetByID(ctx, agentID)
+	if err != nil {
+		return nil, fmt.Errorf("Agent不存在: %w", err)
+	}
+
+	if req.Group != nil && *req.Group != "" {
+		if _, err := s.groupRepo.GetByName(ctx, *req.Group); err != nil {
+			return nil, fmt.Errorf("分组不存在: %s", *req.Group)
+		}
+	}
+
+	if req.Settings != nil {
+		if err := validateSettings(req.Settings); err != nil {
+			return nil, fmt.Errorf("Agent设置验证失败: %w", err)
+		}
+		agent.Settings = req.Settings
+	}
+	if req.Group != nil {
+		agent.Group = *req.Group
+	}
+
+	if err := s.agentRepo.Save(ctx, agent); err != nil {
+		return nil, err
+	}
+
+	effective := s.resolveSettings(ctx, agent)
+	s.pushSettings(agentID, effective.Settings)
+
+	return effective, nil
+}
+
+// resolveSettings 合并分组默认值和Agent覆盖值
+func (s *groupService) resolveSettings(ctx context.Context, agent *models.Agent) *models.EffectiveSettings {
+	effective := &models.EffectiveSettings{
+		AgentID:  agent.AgentID,
+		Group:    agent.Group,
+		Override: agent.Settings,
+	}
+
+	var defaults models.AgentSettings
+	if agent.Group != "" {
+		if group, err := s.groupRepo.GetByName(ctx, agent.Group); err == nil {
+			defaults = group.Settings
+			effective.Defaults = &group.Settings
+		} else {
+			s.logger.WithError(err).WithField("group", agent.Group).Warn("获取Agent所属分组失败，忽略分组默认值")
+		}
+	}
+
+	effective.Settings = defaults.Merge(agent.Settings)
+	return effective
+}
+
+// pushSettings 向Agent推送设置
+func (s *groupService) pushSettings(agentID string, settings models.AgentSettings) {
+	if s.publisher == nil {
+		return
+	}
+	if err := s.publisher.Publish(agentID, models.MsgTypeSettingsUpdate, settings); err != nil {
+		s.logger.WithError(err).WithField("agent_id", agentID).Warn("下发Agent设置失败")
+	}
+}
+
+// Agent设置的取值范围（秒）
+// 心跳间隔上限需远小于平台判定Agent离线的时间，指标间隔过长会使看板和告警失去参考价值
+const (
+	minHeartbeatInterval = 10
+	maxHeartbeatInterval = 300
+	minMetricsInterval   = 30
+	maxMetricsInterval   = 3600
+)
+
+// validateSettings 验证设置取值范围，与Agent端的最小间隔保持一致
+func validateSettings(settings *models.AgentSettings) error {
+	if v := settings.HeartbeatInterval; v != nil && (*v < minHeartbeatInterval || *v > maxHeartbeatInterval) {
+		return fmt.Errorf("heartbeat_interval 必须在%d到%d秒之间", minHeartbeatInterval, maxHeartbeatInterval)
+	}
+	if v := settings.MetricsInterval; v != nil && (*v < minMetricsInterval || *v > maxMetricsInterval) {
+		return fmt.Errorf("metrics_interval 必须在%d到%d秒之间", minMetricsInterval, maxMetricsInterval)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/tests/mocks"
+)
+
+func intPtr(v int) *int    { return &v }
+func boolPtr(v bool) *bool { return &v }
+
+func TestAgentSettings_Merge(t *testing.T) {
+	defaults := models.AgentSettings{
+		HeartbeatInterval: intPtr(30),
+		MetricsInterval:   intPtr(60),
+		EnableAutoReload:  boolPtr(true),
+		Labels:            map[string]string{"env": "prod", "dc": "east"},
+	}
+	override := &models.AgentSettings{
+		HeartbeatInterval: intPtr(15),
+		Labels:            map[string]string{"dc": "west"},
+	}
+
+	merged := defaults.Merge(override)
+
+	assert.Equal(t, 15, *merged.HeartbeatInterval)
+	assert.Equal(t, 60, *merged.MetricsInterval)
+	assert.True(t, *merged.EnableAutoReload)
+	assert.Equal(t, map[string]string{"env": "prod", "dc": "west"}, merged.Labels)
+	// 合并不应修改原始默认值
+	assert.Equal(t, "east", defaults.Labels["dc"])
+
+	assert.Equal(t, 30, *defaults.Merge(nil).HeartbeatInterval)
+}
+
+func TestGroupService_CreateGroup(t *testing.T) {
+	logger := logrus.New()
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		req     *models.CreateGroupRequest
+		setup   func(*mocks.MockGroupRepository)
+		wantErr string
+	}{
+		{
+			name: "successful creation",
+			req:  &models.CreateGroupRequest{Name: "prod", Settings: models.AgentSettings{HeartbeatInterval: intPtr(20)}},
+			setup: func(m *mocks.MockGroupRepository) {
+				m.On("GetByName", ctx, "prod").Return(nil, errors.New("文档不存在"))
+				m.On("Create", ctx, mock.MatchedBy(func(g *models.AgentGroup) bool {
+					return g.Name == "prod" && g.CreatedBy == "user1"
+				})).Return(nil)
+			},
+		},
+		{
+			name: "duplicate group",
+			req:  &models.CreateGroupRequest{Name: "prod"},
+			setup: func(m *mocks.MockGroupRepository) {
+				m.On("GetByName", ctx, "prod").Return(&models.AgentGroup{Name: "prod"}, nil)
+			},
+			wantErr: "分组已存在",
+		},
+		{
+			name:    "heartbeat interval too short",
+			req:     &models.CreateGroupRequest{Name: "prod", Settings: models.AgentSettings{HeartbeatInterval: intPtr(5)}},
+			setup:   func(m *mocks.MockGroupRepository) {},
+			wantErr: "heartbeat_interval",
+		},
+		{
+			name:    "heartbeat interval too long",
+			req:     &models.CreateGroupRequest{Name: "prod", Settings: models.AgentSettings{HeartbeatInterval: intPtr(86400)}},
+			setup:   func(m *mocks.MockGroupRepository) {},
+			wantErr: "heartbeat_interval",
+		},
+		{
+			name:    "metrics interval too long",
+			req:     &models.CreateGroupRequest{Name: "prod", Settings: models.AgentSettings{MetricsInterval: intPtr(7200)}},
+			setup:   func(m *mocks.MockGroupRepository) {},
+			wantErr: "metrics_interval",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groupRepo := new(mocks.MockGroupRepository)
+			agentRepo := new(mocks.MockAgentRepository)
+			tt.setup(groupRepo)
+
+			svc := NewGroupService(groupRepo, agentRepo, nil, logger)
+			group, err := svc.CreateGroup(ctx, tt.req, "user1")
+
+			if tt.wantErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "prod", group.Name)
+			}
+			groupRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGroupService_UpdateGroup_PushesSettings(t *testing.T) {
+	logger := logrus.New()
+	ctx := context.Background()
+
+	groupRepo := new(mocks.MockGroupRepository)
+	agentRepo := new(mocks.MockAgentRepository)
+	publisher := new(mocks.MockMessagePublisher)
+
+	groupRepo.On("GetByName", ctx, "prod").Return(&models.AgentGroup{Name: "prod"}, nil)
+	groupRepo.On("Update", ctx, mock.Anything).Return(nil)
+	agentRepo.On("ListByGroup", ctx, "prod").Return([]*models.Agent{
+		{AgentID: "agent-1", Group: "prod"},
+		{AgentID: "agent-2", Group: "prod", Settings: &models.AgentSettings{HeartbeatInterval: intPtr(60)}},
+	}, nil)
+	publisher.On("Publish", "agent-1", models.MsgTypeSettingsUpdate, mock.MatchedBy(func(s models.AgentSettings) bool {
+		return *s.HeartbeatInterval == 20
+	})).Return(nil)
+	publisher.On("Publish", "agent-2", models.MsgTypeSettingsUpdate, mock.MatchedBy(func(s models.AgentSettings) bool {
+		return *s.HeartbeatInterval == 60
+	})).Return(nil)
+
+	svc := NewGroupService(groupRepo, agentRepo, publisher, logger)
+	_, err := svc.UpdateGroup(ctx, "prod", &models.UpdateGroupRequest{
+		Settings: models.AgentSettings{HeartbeatInterval: intPtr(20)},
+	}, "user1")
+
+	assert.NoError(t, err)
+	publisher.AssertExpectations(t)
+}
+
+func TestGroupService_DeleteGroup_InUse(t *testing.T) {
+	logger := logrus.New()
+	ctx := context.Background()
+
+	groupRepo := new(mocks.MockGroupRepository)
+	agentRepo := new(mocks.MockAgentRepository)
+	groupRepo.On("GetByName", ctx, "prod").Return(&models.AgentGroup{Name: "prod"}, nil)
+	agentRepo.On("ListByGroup", ctx, "prod").Return([]*models.Agent{{AgentID: "agent-1"}}, nil)
+
+	svc := NewGroupService(groupRepo, agentRepo, nil, logger)
+	err := svc.DeleteGroup(ctx, "prod")
+
+	assert.Error(t, err)
+	groupRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+func TestGroupService_GetEffectiveSettings(t *testing.T) {
+	logger := logrus.New()
+	ctx := context.Background()
+
+	groupRepo := new(mocks.MockGroupRepository)
+	agentRepo := new(mocks.MockAgentRepository)
+	agentRepo.On("GetByID", ctx, "agent-1").Return(&models.Agent{
+		AgentID:  "agent-1",
+		Group:    "prod",
+		Settings: &models.AgentSettings{EnableAutoReload: boolPtr(false)},
+	}, nil)
+	groupRepo.On("GetByName", ctx, "prod").Return(&models.AgentGroup{
+		Name:     "prod",
+		Settings: models.AgentSettings{HeartbeatInterval: intPtr(20), EnableAutoReload: boolPtr(true)},
+	}, nil)
+
+	svc := NewGroupService(groupRepo, agentRepo, nil, logger)
+	effective, err := svc.GetEffectiveSettings(ctx, "agent-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "prod", effective.Group)
+	assert.Equal(t, 20, *effective.Settings.HeartbeatInterval)
+	assert.False(t, *effective.Settings.EnableAutoReload)
+}
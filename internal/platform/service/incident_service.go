@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// maxIncidentTimeline 每个事件保留的时间线条目数
+const maxIncidentTimeline = 100
+
+// IncidentService 错误事件服务接口
+type IncidentService interface {
+	ReportError(ctx context.Context, agentID string, report *models.AgentErrorReport) (*models.Incident, error)
+	GetIncident(ctx context.Context, id string) (*models.Incident, error)
+	ListIncidents(ctx context.Context, req *models.IncidentListRequest) ([]*models.Incident, int64, error)
+	ResolveIncident(ctx context.Context, id string, req *models.ResolveIncidentRequest, userID string) (*models.Incident, error)
+}
+
+// incidentService 错误事件服务实现
+// 未解决事件的文档ID由指纹确定，按ID读取不受ES刷新延迟影响，多个平台实例也会写入同一文档
+type incidentService struct {
+	incidentRepo repository.IncidentRepository
+	logger       *logrus.Logger
+
+	mu    sync.Mutex
+	locks map[string]*fingerprintLock // 指纹 -> 锁，不同指纹的上报互不阻塞
+}
+
+// fingerprintLock 单个指纹的锁及等待者计数，计数归零时回收
+type fingerprintLock struct {
+	sync.Mutex
+	refs int
+}
+
+// NewIncidentService 创建错误事件服务
+func NewIncidentService(incidentRepo repository.IncidentRepository, logger *logrus.Logger) IncidentService {
+	return &incidentService{
+		incidentRepo: incidentRepo,
+		logger:       logger,
+		locks:        make(map[string]*fingerprintLock),
+	}
+}
+
+// ReportError 记录Agent错误，按指纹归并到未解决事件
+func (s *incidentService) ReportError(ctx context.Context, agentID string, report *models.AgentErrorReport) (*models.Incident, error) {
+	if agentID == "" {
+		return nil, fmt.Errorf("Agent ID不能为空")
+	}
+
+	now := report.Timestamp
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	fingerprint := errorFingerprint(report)
+
+	unlock := s.lock(fingerprint)
+	defer unlock()
+
+	incident, err := s.findOpen(ctx, fingerprint)
+	if err != nil {
+		return nil, err
+	}
+
+	if incident == nil {
+		incident = &models.Incident{
+			ID:          fingerprint,
+			Fingerprint: fingerprint,
+			ErrorType:   report.ErrorType,
+			ConfigID:    report.ConfigID,
+			Message:     report.Message,
+			Status:      models.IncidentStatusOpen,
+			FirstSeen:   now,
+		}
+		s.logger.WithFields(logrus.Fields{
+			"incident_id": incident.ID,
+			"error_type":  report.ErrorType,
+			"agent_id":    agentID,
+		}).Warn("新建错误事件")
+	}
+
+	if !containsString(incident.AffectedAgents, agentID) {
+		incident.AffectedAgents = append(incident.AffectedAgents, agentID)
+	}
+	incident.AffectedCount = len(incident.AffectedAgents)
+	incident.Occurrences++
+	if now.After(incident.LastSeen) {
+		incident.LastSeen = now
+	}
+
+	incident.Timeline = append(incident.Timeline, models.IncidentEvent{
+		Timestamp: now,
+		AgentID:   agentID,
+		Message:   report.Message,
+	})
+	if len(incident.Timeline) > maxIncidentTimeline {
+		incident.Timeline = incident.Timeline[len(incident.Timeline)-maxIncidentTimeline:]
+	}
+
+	if err := s.incidentRepo.Save(ctx, incident); err != nil {
+		return nil, err
+	}
+
+	return incident, nil
+}
+
+// findOpen 按指纹确定的ID读取未解决事件，不存在时返回nil
+func (s *incidentService) findOpen(ctx context.Context, fingerprint string) (*models.Incident, error) {
+	// 查到的事件会被立即更新，必须读主，避免副本延迟导致覆盖新数据
+	incident, err := s.incidentRepo.GetByID(elasticsearch.WithPrimaryRead(ctx), fingerprint)
+	if err != nil {
+		if err.Error() == "文档不存在" {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查找事件失败: %w", err)
+	}
+	return incident, nil
+}
+
+// lock 锁定指纹，返回解锁函数
+func (s *incidentService) lock(fingerprint string) func() {
+	s.mu.Lock()
+	l, ok := s.locks[fingerprint]
+	if !ok {
+		l = &fingerprintLock{}
+		s.locks[fingerprint] = l
+	}
+	l.refs++
+	s.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		s.mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(s.locks, fingerprint)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// GetIncident 获取事件
+func (s *incidentService) GetIncident(ctx context.Context, id string) (*models.Incident, error) {
+	return s.incidentRepo.GetByID(ctx, id)
+}
+
+// ListIncidents 获取事件列表
+func (s *incidentService) ListIncidents(ctx context.Context, req *models.IncidentListRequest) ([]*models.Incident, int64, error) {
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 10
+	}
+	return s.incidentRepo.List(ctx, req)
+}
+
+// ResolveIncident 解决事件，之后同类错误再次出现时会新建事件
+// 已解决的事件归档到新ID，把指纹对应的ID让给后续的新事件
+func (s *incidentService) ResolveIncident(ctx context.Context, id string, req *models.ResolveIncidentRequest, userID string) (*models.Incident, error) {
+	// 未解决事件的ID即指纹
+	unlock := s.lock(id)
+	defer unlock()
+
+	incident, err := s.incidentRepo.GetByID(elasticsearch.WithPrimaryRead(ctx), id)
+	if err != nil {
+		return nil, err
+	}
+
+	if incident.Status == models.IncidentStatusResolved {
+		return nil, fmt.Errorf("事件已解决: %s", id)
+	}
+
+	now := time.Now()
+	incident.Status = models.IncidentStatusResolved
+	incident.ResolvedAt = &now
+	incident.ResolvedBy = userID
+	incident.Resolution = req.Resolution
+
+	incident.ID = fmt.Sprintf("%s-%d", incident.Fingerprint, now.UnixNano())
+	if err := s.incidentRepo.Save(ctx, incident); err != nil {
+		return nil, err
+	}
+	if err := s.incidentRepo.Delete(ctx, id); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"incident_id":    id,
+		"affected_count": incident.AffectedCount,
+		"user_id":        userID,
+	}).Info("错误事件已解决")
+
+	return incident, nil
+}
+
+var (
+	uuidPattern   = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+	ipPattern     = regexp.MustCompile(`\b\d{1,3}(\.\d{1,3}){3}(:\d+)?\b`)
+	numberPattern = regexp.MustCompile(`\b(0x[0-9a-f]+|\d+)\b`) // 消息已转为小写，只替换十进制数和0x前缀的十六进制数
+)
+
+// errorFingerprint 计算错误指纹
+// 去掉ID、地址、数字等随Agent变化的部分，使不同Agent上的同类错误得到相同指纹
+func errorFingerprint(report *models.AgentErrorReport) string {
+	msg := strings.ToLower(strings.TrimSpace(report.Message))
+	msg = uuidPattern.ReplaceAllString(msg, "<id>")
+	msg = ipPattern.ReplaceAllString(msg, "<addr>")
+	msg = numberPattern.ReplaceAllString(msg, "<n>")
+	msg = strings.Join(strings.Fields(msg), " ")
+
+	sum := sha1.Sum([]byte(report.ErrorType + "|" + report.ConfigID + "|" + msg))
+	return hex.EncodeToString(sum[:8])
+}
+
+// containsString 判断切片是否包含指定字符串
+func containsString(items []string, s string) bool {
+	for _, item := range items {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
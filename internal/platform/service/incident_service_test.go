@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+)
+
+// memIncidentRepository 内存事件仓库
+type memIncidentRepository struct {
+	mu        sync.Mutex
+	incidents map[string]*models.Incident
+	saves     int
+}
+
+func (r *memIncidentRepository) Save(ctx context.Context, incident *models.Incident) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *incident
+	r.incidents[incident.ID] = &copied
+	r.saves++
+	return nil
+}
+
+func (r *memIncidentRepository) GetByID(ctx context.Context, id string) (*models.Incident, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	incident, ok := r.incidents[id]
+	if !ok {
+		return nil, fmt.Errorf("文档不存在")
+	}
+	copied := *incident
+	return &copied, nil
+}
+
+func (r *memIncidentRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.incidents, id)
+	return nil
+}
+
+func (r *memIncidentRepository) List(ctx context.Context, req *models.IncidentListRequest) ([]*models.Incident, int64, error) {
+	return nil, 0, nil
+}
+
+func TestIncidentService_DedupAcrossAgents(t *testing.T) {
+	ctx := context.Background()
+	repo := &memIncidentRepository{incidents: make(map[string]*models.Incident)}
+	svc := NewIncidentService(repo, logrus.New())
+
+	var incident *models.Incident
+	for i := 0; i < 200; i++ {
+		var err error
+		incident, err = svc.ReportError(ctx, fmt.Sprintf("agent-%03d", i%150), &models.AgentErrorReport{
+			ErrorType: "plugin_missing",
+			Message:   fmt.Sprintf("Couldn't find any output plugin named 'kafka' (pipeline main, worker %d, host 10.0.0.%d)", i, i%250),
+		})
+		require.NoError(t, err)
+	}
+
+	assert.Len(t, repo.incidents, 1)
+	assert.Equal(t, 150, incident.AffectedCount)
+	assert.Equal(t, int64(200), incident.Occurrences)
+	assert.Len(t, incident.Timeline, maxIncidentTimeline)
+
+	// 不同类型的错误独立成事件
+	_, err := svc.ReportError(ctx, "agent-001", &models.AgentErrorReport{ErrorType: "config_invalid", Message: "syntax error"})
+	require.NoError(t, err)
+	assert.Len(t, repo.incidents, 2)
+}
+
+func TestIncidentService_ResolveStartsNewIncident(t *testing.T) {
+	ctx := context.Background()
+	repo := &memIncidentRepository{incidents: make(map[string]*models.Incident)}
+	svc := NewIncidentService(repo, logrus.New())
+
+	report := &models.AgentErrorReport{ErrorType: "pipeline_crash", Message: "pipeline worker died"}
+	first, err := svc.ReportError(ctx, "agent-1", report)
+	require.NoError(t, err)
+
+	resolved, err := svc.ResolveIncident(ctx, first.ID, &models.ResolveIncidentRequest{Resolution: "回滚配置"}, "admin")
+	require.NoError(t, err)
+	assert.Equal(t, models.IncidentStatusResolved, resolved.Status)
+	assert.Equal(t, "admin", resolved.ResolvedBy)
+
+	_, err = svc.ResolveIncident(ctx, first.ID, &models.ResolveIncidentRequest{}, "admin")
+	assert.Error(t, err)
+
+	second, err := svc.ReportError(ctx, "agent-1", report)
+	require.NoError(t, err)
+	assert.NotEqual(t, resolved.ID, second.ID)
+	assert.Equal(t, 1, second.AffectedCount)
+
+	// 已解决的事件保留在归档ID下
+	archived, err := repo.GetByID(ctx, resolved.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.IncidentStatusResolved, archived.Status)
+	assert.Equal(t, models.IncidentStatusOpen, second.Status)
+}
+
+func TestIncidentService_ConcurrentReportsShareIncident(t *testing.T) {
+	ctx := context.Background()
+	repo := &memIncidentRepository{incidents: make(map[string]*models.Incident)}
+	svc := NewIncidentService(repo, logrus.New())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := svc.ReportError(ctx, fmt.Sprintf("agent-%d", i), &models.AgentErrorReport{
+				ErrorType: "plugin_missing",
+				Message:   fmt.Sprintf("plugin missing on worker %d", i),
+			})
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	require.Len(t, repo.incidents, 1)
+	for _, incident := range repo.incidents {
+		assert.Equal(t, incident.Fingerprint, incident.ID)
+		assert.Equal(t, 50, incident.AffectedCount)
+	}
+}
+
+func TestErrorFingerprint_KeepsHexLikeWords(t *testing.T) {
+	fp := func(msg string) string {
+		return errorFingerprint(&models.AgentErrorReport{ErrorType: "pipeline_crash", Message: msg})
+	}
+
+	// 十进制数和0x前缀的十六进制数被归一
+	assert.Equal(t, fp("worker 3 crashed at 0x1f"), fp("worker 12 crashed at 0xdeadbeef"))
+	// 像十六进制的普通单词保留，不同插件的错误不会被合并
+	assert.NotEqual(t, fp("plugin cafe failed"), fp("plugin beef failed"))
+	assert.NotEqual(t, fp("output add failed"), fp("output dead failed"))
+}
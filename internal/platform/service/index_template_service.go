@@ -0,0 +1,312 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+)
+
+// validFieldTypes 允许在覆盖中使用的字段类型
+var validFieldTypes = map[string]bool{
+	models.FieldTypeKeyword: true,
+	models.FieldTypeText:    true,
+	models.FieldTypeLong:    true,
+	models.FieldTypeDouble:  true,
+	models.FieldTypeBoolean: true,
+	models.FieldTypeDate:    true,
+	models.FieldTypeIP:      true,
+	models.FieldTypeObject:  true,
+}
+
+var (
+	// grokCapturePattern 匹配 %{SYNTAX:field} 或 %{SYNTAX:field:type}
+	grokCapturePattern = regexp.MustCompile(`%\{(\w+):([\w@.\[\]-]+)(?::(\w+))?\}`)
+	// convertBlockPattern 匹配 mutate 中的 convert => { ... }
+	convertBlockPattern = regexp.MustCompile(`convert\s*=>\s*\{([^}]*)\}`)
+	// convertEntryPattern 匹配 "field" => "type"
+	convertEntryPattern = regexp.MustCompile(`"([^"]+)"\s*=>\s*"(\w+)"`)
+	// ipValuePattern 粗略判断IPv4取值
+	ipValuePattern = regexp.MustCompile(`^\d{1,3}(\.\d{1,3}){3}$`)
+)
+
+// grokSyntaxTypes grok模式名到字段类型的映射，未列出的模式按keyword处理
+var grokSyntaxTypes = map[string]string{
+	"INT":               models.FieldTypeLong,
+	"POSINT":            models.FieldTypeLong,
+	"NONNEGINT":         models.FieldTypeLong,
+	"NUMBER":            models.FieldTypeDouble,
+	"BASE10NUM":         models.FieldTypeDouble,
+	"IP":                models.FieldTypeIP,
+	"IPV4":              models.FieldTypeIP,
+	"IPV6":              models.FieldTypeIP,
+	"IPORHOST":          models.FieldTypeKeyword,
+	"TIMESTAMP_ISO8601": models.FieldTypeDate,
+	"HTTPDATE":          models.FieldTypeDate,
+	"GREEDYDATA":        models.FieldTypeText,
+	"DATA":              models.FieldTypeKeyword,
+}
+
+// logstashConvertTypes grok/mutate转换类型到字段类型的映射
+var logstashConvertTypes = map[string]string{
+	"int":     models.FieldTypeLong,
+	"integer": models.FieldTypeLong,
+	"float":   models.FieldTypeDouble,
+	"boolean": models.FieldTypeBoolean,
+	"string":  models.FieldTypeKeyword,
+}
+
+// IndexTemplateService 索引模板生成服务接口
+type IndexTemplateService interface {
+	Generate(ctx context.Context, configID string, req *models.GenerateIndexTemplateRequest) (*models.IndexTemplateResult, error)
+}
+
+// indexTemplateService 索引模板生成服务实现
+type indexTemplateService struct {
+	configService ConfigService
+	logger        *logrus.Logger
+}
+
+// NewIndexTemplateService 创建索引模板生成服务
+func NewIndexTemplateService(configService ConfigService, logger *logrus.Logger) IndexTemplateService {
+	return &indexTemplateService{
+		configService: configService,
+		logger:        logger,
+	}
+}
+
+// Generate 根据配置推断的输出字段生成索引模板
+// 字段类型优先级：显式覆盖 > 配置内容 > 样本事件
+func (s *indexTemplateService) Generate(ctx context.Context, configID string, req *models.GenerateIndexTemplateRequest) (*models.IndexTemplateResult, error) {
+	for field, typ := range req.Overrides {
+		if !validFieldTypes[typ] {
+			return nil, fmt.Errorf("字段类型无效: %s => %s", field, typ)
+		}
+	}
+
+	config, err := s.configService.GetConfig(ctx, configID)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := InferSampleFields(req.Samples)
+	for name, field := range InferConfigFields(config.Content) {
+		fields[name] = field
+	}
+	for name, typ := range req.Overrides {
+		fields[name] = models.InferredField{Name: name, Type: typ, Source: models.FieldSourceOverride}
+	}
+
+	name := req.Name
+	if name == "" {
+		name = strings.ToLower(strings.ReplaceAll(config.Name, " ", "-"))
+	}
+
+	result := &models.IndexTemplateResult{
+		Name:     name,
+		ConfigID: config.ID,
+		Fields:   sortedFields(fields),
+		Template: buildIndexTemplate(req, config, fields),
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"config_id": configID,
+		"template":  name,
+		"fields":    len(result.Fields),
+	}).Info("生成索引模板")
+
+	return result, nil
+}
+
+// InferConfigFields 从配置内容中推断字段类型（grok捕获与mutate convert）
+func InferConfigFields(content string) map[string]models.InferredField {
+	fields := make(map[string]models.InferredField)
+
+	for _, m := range grokCapturePattern.FindAllStringSubmatch(content, -1) {
+		name := normalizeFieldPath(m[2])
+		typ, ok := logstashConvertTypes[m[3]]
+		if !ok {
+			typ, ok = grokSyntaxTypes[m[1]]
+		}
+		if !ok {
+			typ = models.FieldTypeKeyword
+		}
+		fields[name] = models.InferredField{Name: name, Type: typ, Source: models.FieldSourceConfig}
+	}
+
+	for _, block := range convertBlockPattern.FindAllStringSubmatch(content, -1) {
+		for _, m := range convertEntryPattern.FindAllStringSubmatch(block[1], -1) {
+			if typ, ok := logstashConvertTypes[m[2]]; ok {
+				name := normalizeFieldPath(m[1])
+				fields[name] = models.InferredField{Name: name, Type: typ, Source: models.FieldSourceConfig}
+			}
+		}
+	}
+
+	return fields
+}
+
+// InferSampleFields 从样本事件中推断字段类型
+// 同一字段在不同样本中类型不一致时按 double > long、其余冲突退化为keyword 处理
+func InferSampleFields(samples []map[string]interface{}) map[string]models.InferredField {
+	fields := make(map[string]models.InferredField)
+	for _, sample := range samples {
+		walkSample("", sample, fields)
+	}
+	return fields
+}
+
+// walkSample 递归遍历样本对象
+func walkSample(prefix string, obj map[string]interface{}, fields map[string]models.InferredField) {
+	for key, value := range obj {
+		name := key
+		if prefix != "" {
+			name = prefix + "." + key
+		}
+
+		if nested, ok := value.(map[string]interface{}); ok {
+			walkSample(name, nested, fields)
+			continue
+		}
+
+		typ := inferValueType(value)
+		if typ == "" {
+			continue
+		}
+		if existing, ok := fields[name]; ok && existing.Type != typ {
+			typ = mergeFieldTypes(existing.Type, typ)
+		}
+		fields[name] = models.InferredField{Name: name, Type: typ, Source: models.FieldSourceSample}
+	}
+}
+
+// inferValueType 推断单个取值的字段类型，无法推断时返回空字符串
+func inferValueType(value interface{}) string {
+	switch v := value.(type) {
+	case bool:
+		return models.FieldTypeBoolean
+	case float64:
+		if v == float64(int64(v)) {
+			return models.FieldTypeLong
+		}
+		return models.FieldTypeDouble
+	case int, int64:
+		return models.FieldTypeLong
+	case string:
+		if _, err := time.Parse(time.RFC3339, v); err == nil {
+			return models.FieldTypeDate
+		}
+		if ipValuePattern.MatchString(v) {
+			return models.FieldTypeIP
+		}
+		return models.FieldTypeKeyword
+	case []interface{}:
+		// ES没有数组类型，取首个元素的类型
+		if len(v) > 0 {
+			return inferValueType(v[0])
+		}
+	}
+	return ""
+}
+
+// mergeFieldTypes 合并同一字段的冲突类型
+func mergeFieldTypes(a, b string) string {
+	if (a == models.FieldTypeLong && b == models.FieldTypeDouble) || (a == models.FieldTypeDouble && b == models.FieldTypeLong) {
+		return models.FieldTypeDouble
+	}
+	return models.FieldTypeKeyword
+}
+
+// normalizeFieldPath 将Logstash的 [a][b] 字段引用转换为 a.b
+func normalizeFieldPath(field string) string {
+	if !strings.HasPrefix(field, "[") {
+		return field
+	}
+	parts := strings.FieldsFunc(field, func(r rune) bool { return r == '[' || r == ']' })
+	return strings.Join(parts, ".")
+}
+
+// sortedFields 按字段路径排序
+func sortedFields(fields map[string]models.InferredField) []models.InferredField {
+	list := make([]models.InferredField, 0, len(fields))
+	for _, f := range fields {
+		list = append(list, f)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// buildIndexTemplate 构建可组合索引模板的请求体
+func buildIndexTemplate(req *models.GenerateIndexTemplateRequest, config *models.Config, fields map[string]models.InferredField) map[string]interface{} {
+	properties := make(map[string]interface{})
+	for _, f := range sortedFields(fields) {
+		insertMappingField(properties, strings.Split(f.Name, "."), f.Type)
+	}
+
+	settings := map[string]interface{}{}
+	if req.Shards > 0 {
+		settings["number_of_shards"] = req.Shards
+	}
+	if req.Replicas != nil {
+		settings["number_of_replicas"] = *req.Replicas
+	}
+
+	template := map[string]interface{}{
+		"mappings": map[string]interface{}{
+			"properties": properties,
+		},
+	}
+	if len(settings) > 0 {
+		template["settings"] = settings
+	}
+
+	return map[string]interface{}{
+		"index_patterns": req.IndexPatterns,
+		"priority":       req.Priority,
+		"template":       template,
+		"_meta": map[string]interface{}{
+			"generated_by":   "logstash-platform",
+			"config_id":      config.ID,
+			"config_version": config.Version,
+		},
+	}
+}
+
+// insertMappingField 按路径插入字段映射，中间层级生成object属性
+func insertMappingField(properties map[string]interface{}, path []string, typ string) {
+	if len(path) == 1 {
+		// 已作为父级对象存在的字段不再覆盖
+		if _, exists := properties[path[0]]; !exists {
+			properties[path[0]] = fieldMapping(typ)
+		}
+		return
+	}
+
+	parent, ok := properties[path[0]].(map[string]interface{})
+	if !ok || parent["properties"] == nil {
+		parent = map[string]interface{}{"properties": map[string]interface{}{}}
+		properties[path[0]] = parent
+	}
+	insertMappingField(parent["properties"].(map[string]interface{}), path[1:], typ)
+}
+
+// fieldMapping 单个字段的映射定义
+func fieldMapping(typ string) map[string]interface{} {
+	switch typ {
+	case models.FieldTypeText:
+		return map[string]interface{}{
+			"type": typ,
+			"fields": map[string]interface{}{
+				"keyword": map[string]interface{}{"type": "keyword", "ignore_above": 256},
+			},
+		}
+	case models.FieldTypeObject:
+		return map[string]interface{}{"type": typ, "dynamic": true}
+	}
+	return map[string]interface{}{"type": typ}
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+)
+
+func TestInferConfigFields(t *testing.T) {
+	content := `filter {
+  grok { match => { "message" => "%{IP:client} %{WORD:method} %{NUMBER:bytes:int} %{NUMBER:duration} %{GREEDYDATA:msg}" } }
+  mutate { convert => { "[http][status]" => "integer" "ok" => "boolean" } }
+}`
+
+	fields := InferConfigFields(content)
+
+	assert.Equal(t, models.FieldTypeIP, fields["client"].Type)
+	assert.Equal(t, models.FieldTypeKeyword, fields["method"].Type)
+	assert.Equal(t, models.FieldTypeLong, fields["bytes"].Type)
+	assert.Equal(t, models.FieldTypeDouble, fields["duration"].Type)
+	assert.Equal(t, models.FieldTypeText, fields["msg"].Type)
+	assert.Equal(t, models.FieldTypeLong, fields["http.status"].Type)
+	assert.Equal(t, models.FieldTypeBoolean, fields["ok"].Type)
+}
+
+func TestInferSampleFields(t *testing.T) {
+	samples := []map[string]interface{}{
+		{"@timestamp": "2024-01-01T00:00:00Z", "latency": float64(3), "host": map[string]interface{}{"name": "web-1"}},
+		{"latency": 3.5, "tags": []interface{}{"a"}},
+	}
+
+	fields := InferSampleFields(samples)
+
+	assert.Equal(t, models.FieldTypeDate, fields["@timestamp"].Type)
+	assert.Equal(t, models.FieldTypeDouble, fields["latency"].Type)
+	assert.Equal(t, models.FieldTypeKeyword, fields["host.name"].Type)
+	assert.Equal(t, models.FieldTypeKeyword, fields["tags"].Type)
+}
+
+func TestIndexTemplateService_Generate(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.WarnLevel)
+	configs := &fakeConfigService{configs: map[string]*models.Config{
+		"cfg-1": {ID: "cfg-1", Name: "Nginx Access", Version: 3, Content: `grok { match => { "message" => "%{NUMBER:bytes:int}" } }`},
+	}}
+	svc := NewIndexTemplateService(configs, logger)
+
+	t.Run("覆盖优先并生成嵌套映射", func(t *testing.T) {
+		replicas := 1
+		result, err := svc.Generate(context.Background(), "cfg-1", &models.GenerateIndexTemplateRequest{
+			IndexPatterns: []string{"nginx-*"},
+			Samples:       []map[string]interface{}{{"bytes": "12", "url": map[string]interface{}{"path": "/"}}},
+			Overrides:     map[string]string{"url.path": models.FieldTypeText},
+			Replicas:      &replicas,
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, "nginx-access", result.Name)
+		assert.Len(t, result.Fields, 2)
+
+		template := result.Template["template"].(map[string]interface{})
+		props := template["mappings"].(map[string]interface{})["properties"].(map[string]interface{})
+		assert.Equal(t, models.FieldTypeLong, props["bytes"].(map[string]interface{})["type"])
+		urlProps := props["url"].(map[string]interface{})["properties"].(map[string]interface{})
+		assert.Equal(t, models.FieldTypeText, urlProps["path"].(map[string]interface{})["type"])
+		assert.Equal(t, 1, template["settings"].(map[string]interface{})["number_of_replicas"])
+	})
+
+	t.Run("无效的覆盖类型", func(t *testing.T) {
+		_, err := svc.Generate(context.Background(), "cfg-1", &models.GenerateIndexTemplateRequest{
+			IndexPatterns: []string{"nginx-*"},
+			Overrides:     map[string]string{"bytes": "varchar"},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "字段类型无效")
+	})
+}
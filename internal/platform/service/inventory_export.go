@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/tabular"
+)
+
+// ErrInvalidExportColumn 导出请求包含未定义的列
+var ErrInvalidExportColumn = errors.New("导出列无效")
+
+// configExportBatchSize 导出配置目录时每页读取的配置数
+const configExportBatchSize = 500
+
+// exportColumn 导出表格的一列
+type exportColumn[T any] struct {
+	name  string
+	value func(T) string
+}
+
+// agentExportColumns Agent清单的列，未指定列时按此顺序全部导出
+var agentExportColumns = []exportColumn[*models.Agent]{
+	{"agent_id", func(a *models.Agent) string { return a.AgentID }},
+	{"hostname", func(a *models.Agent) string { return a.Hostname }},
+	{"ip", func(a *models.Agent) string { return a.IP }},
+	{"status", func(a *models.Agent) string { return a.Status }},
+	{"logstash_version", func(a *models.Agent) string { return a.LogstashVersion }},
+	{"group", func(a *models.Agent) string { return a.Group }},
+	{"project", func(a *models.Agent) string { return models.ProjectOf(a.Project) }},
+	{"environment", func(a *models.Agent) string { return a.Environment }},
+	{"labels", func(a *models.Agent) string { return formatPairs(a.Labels) }},
+	{"metadata", func(a *models.Agent) string { return formatPairs(a.Metadata) }},
+	{"last_heartbeat", func(a *models.Agent) string { return formatExportTime(a.LastHeartbeat) }},
+	{"applied_configs", func(a *models.Agent) string {
+		applied := make([]string, 0, len(a.AppliedConfigs))
+		for _, ac := range a.AppliedConfigs {
+			applied = append(applied, fmt.Sprintf("%s@v%d", ac.ConfigID, ac.Version))
+		}
+		return strings.Join(applied, ";")
+	}},
+}
+
+// configExportColumns 配置目录的列，不包含配置内容；未指定列时按此顺序全部导出
+var configExportColumns = []exportColumn[*models.Config]{
+	{"id", func(c *models.Config) string { return c.ID }},
+	{"name", func(c *models.Config) string { return c.Name }},
+	{"type", func(c *models.Config) string { return string(c.Type) }},
+	{"description", func(c *models.Config) string { return c.Description }},
+	{"version", func(c *models.Config) string { return strconv.Itoa(c.Version) }},
+	{"enabled", func(c *models.Config) string { return strconv.FormatBool(c.Enabled) }},
+	{"test_status", func(c *models.Config) string { return string(c.TestStatus) }},
+	{"tested_at", func(c *models.Config) string {
+		if c.TestedAt == nil {
+			return ""
+		}
+		return formatExportTime(*c.TestedAt)
+	}},
+	{"tags", func(c *models.Config) string { return strings.Join(c.Tags, ";") }},
+	{"team", func(c *models.Config) string { return c.Team }},
+	{"project", func(c *models.Config) string { return models.ProjectOf(c.Project) }},
+	{"destinations", func(c *models.Config) string { return strings.Join(c.Destinations, ";") }},
+	{"pipeline_id", func(c *models.Config) string { return c.PipelineID }},
+	{"created_by", func(c *models.Config) string { return c.CreatedBy }},
+	{"created_at", func(c *models.Config) string { return formatExportTime(c.CreatedAt) }},
+	{"updated_by", func(c *models.Config) string { return c.UpdatedBy }},
+	{"updated_at", func(c *models.Config) string { return formatExportTime(c.UpdatedAt) }},
+}
+
+// ExportAgents 按Agent列表的过滤和排序条件逐页读取请求所属项目的Agent并写出选定的列，忽略分页参数
+// 列名无效时在调用open之前返回 ErrInvalidExportColumn；状态与列表一样按最近心跳推导
+func (m *LivenessMonitor) ExportAgents(ctx context.Context, req *models.AgentExportRequest, open func() (tabular.Writer, error)) error {
+	query := req.AgentListRequest
+	query.Project = models.ProjectFrom(ctx)
+	query.Page, query.Cursor, query.After = 0, "", nil
+	query.PageSize = agentListBatchSize
+
+	done := false
+	return exportRows(agentExportColumns, req.Columns, open, func() ([]*models.Agent, error) {
+		if done {
+			return nil, nil
+		}
+		page, err := m.agentRepo.List(ctx, &query)
+		if err != nil {
+			return nil, err
+		}
+		if page.NextCursor == "" {
+			done = true
+		} else if query.After, err = decodeAgentCursor(page.NextCursor); err != nil {
+			return nil, err
+		}
+		now := m.now()
+		for _, agent := range page.Items {
+			agent.Status = m.Status(agent, now)
+		}
+		return page.Items, nil
+	})
+}
+
+// ExportConfigs 按配置列表的过滤和排序条件逐页读取可见的配置并写出选定的列，忽略分页参数
+func (s *configService) ExportConfigs(ctx context.Context, req *models.ConfigExportRequest, open func() (tabular.Writer, error)) error {
+	query := req.ConfigListRequest
+	scopeListRequest(ctx, &query)
+	query.Page, query.Cursor, query.After = 1, "", nil
+	query.PageSize = configExportBatchSize
+	query.OmitContent = true
+
+	done := false
+	return exportRows(configExportColumns, req.Columns, open, func() ([]*models.Config, error) {
+		if done {
+			return nil, nil
+		}
+		page, err := s.configRepo.List(ctx, &query)
+		if err != nil {
+			return nil, err
+		}
+		if page.NextCursor == "" {
+			done = true
+		} else if query.After, err = models.DecodeCursor(page.NextCursor); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+		}
+		return page.Items, nil
+	})
+}
+
+// exportRows 校验列名后打开写出器，写出表头并逐页写出记录，next返回空页时结束
+// 写出中途出错时不写结尾就返回，被截断的CSV缺少行、XLSX无法打开，不会被误当作完整的导出
+func exportRows[T any](columns []exportColumn[T], names string, open func() (tabular.Writer, error), next func() ([]T, error)) error {
+	selected, err := selectExportColumns(columns, names)
+	if err != nil {
+		return err
+	}
+	w, err := open()
+	if err != nil {
+		return err
+	}
+
+	row := make([]string, len(selected))
+	for i, col := range selected {
+		row[i] = col.name
+	}
+	if err := w.WriteRow(row); err != nil {
+		return err
+	}
+	for {
+		items, err := next()
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return w.Close()
+		}
+		for _, item := range items {
+			for i, col := range selected {
+				row[i] = col.value(item)
+			}
+			if err := w.WriteRow(row); err != nil {
+				return err
+			}
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+}
+
+// selectExportColumns 按逗号分隔的列名依次选取列，为空时返回全部列
+func selectExportColumns[T any](columns []exportColumn[T], names string) ([]exportColumn[T], error) {
+	if strings.TrimSpace(names) == "" {
+		return columns, nil
+	}
+	var selected []exportColumn[T]
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		i := slices.IndexFunc(columns, func(col exportColumn[T]) bool { return col.name == name })
+		if i < 0 {
+			valid := make([]string, len(columns))
+			for j, col := range columns {
+				valid[j] = col.name
+			}
+			return nil, fmt.Errorf("%w: %q，可选的列: %s", ErrInvalidExportColumn, name, strings.Join(valid, ", "))
+		}
+		selected = append(selected, columns[i])
+	}
+	return selected, nil
+}
+
+// formatPairs 按键排序格式化为 k=v;k=v
+func formatPairs(pairs map[string]string) string {
+	keys := make([]string, 0, len(pairs))
+	for k := range pairs {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for i, k := range keys {
+		keys[i] = k + "=" + pairs[k]
+	}
+	return strings.Join(keys, ";")
+}
+
+// formatExportTime 以UTC的RFC3339格式输出时间，零值为空
+func formatExportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
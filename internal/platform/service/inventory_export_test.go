@@ -0,0 +1,130 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/tabular"
+	"logstash-platform/tests/mocks"
+)
+
+// csvOpener 返回写入buf的CSV写出器创建函数，opened记录是否被调用
+func csvOpener(buf *bytes.Buffer, opened *bool) func() (tabular.Writer, error) {
+	return func() (tabular.Writer, error) {
+		*opened = true
+		return tabular.NewWriter(tabular.FormatCSV, buf, "")
+	}
+}
+
+func readCSV(t *testing.T, buf *bytes.Buffer) [][]string {
+	rows, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(buf.String(), "\ufeff"))).ReadAll()
+	require.NoError(t, err)
+	return rows
+}
+
+func TestLivenessMonitor_ExportAgents(t *testing.T) {
+	now := time.Date(2026, 10, 14, 8, 0, 0, 0, time.UTC)
+
+	t.Run("逐页导出项目内全部Agent", func(t *testing.T) {
+		repo := &memAgentRepository{agents: make(map[string]*models.Agent)}
+		for i := 0; i < agentListBatchSize+5; i++ {
+			id := fmt.Sprintf("agent-%05d", i)
+			repo.agents[id] = &models.Agent{AgentID: id, Hostname: "web-" + id, Project: "payments", Status: models.AgentStatusOffline, LastHeartbeat: now}
+		}
+		repo.agents["agent-00000"].Labels = map[string]string{"zone": "a", "env": "prod"}
+		repo.agents["agent-00000"].AppliedConfigs = []models.AppliedConfig{{ConfigID: "c1", Version: 3}, {ConfigID: "c2", Version: 1}}
+		repo.agents["other"] = &models.Agent{AgentID: "other", LastHeartbeat: now}
+		monitor := NewLivenessMonitor(LivenessConfig{OfflineAfter: time.Minute}, repo, nil, logrus.New())
+		monitor.now = func() time.Time { return now }
+
+		var buf bytes.Buffer
+		var opened bool
+		ctx := models.WithProject(context.Background(), "payments")
+		req := &models.AgentExportRequest{
+			AgentListRequest: models.AgentListRequest{Page: 3, PageSize: 10},
+			ExportOptions:    models.ExportOptions{Columns: "agent_id, status,labels,applied_configs,last_heartbeat"},
+		}
+		require.NoError(t, monitor.ExportAgents(ctx, req, csvOpener(&buf, &opened)))
+
+		rows := readCSV(t, &buf)
+		require.Len(t, rows, agentListBatchSize+6, "表头加全部Agent，忽略分页参数")
+		assert.Equal(t, []string{"agent_id", "status", "labels", "applied_configs", "last_heartbeat"}, rows[0])
+		assert.Equal(t, []string{"agent-00000", models.AgentStatusOnline, "env=prod;zone=a", "c1@v3;c2@v1", "2026-10-14T08:00:00Z"}, rows[1])
+		assert.Equal(t, "agent-01004", rows[len(rows)-1][0])
+	})
+
+	t.Run("无效的列不打开写出器", func(t *testing.T) {
+		monitor := NewLivenessMonitor(LivenessConfig{OfflineAfter: time.Minute}, new(mocks.MockAgentRepository), nil, logrus.New())
+		var buf bytes.Buffer
+		var opened bool
+		err := monitor.ExportAgents(context.Background(), &models.AgentExportRequest{
+			ExportOptions: models.ExportOptions{Columns: "agent_id,password"},
+		}, csvOpener(&buf, &opened))
+		assert.ErrorIs(t, err, ErrInvalidExportColumn)
+		assert.Contains(t, err.Error(), "hostname", "列出可选的列")
+		assert.False(t, opened)
+	})
+}
+
+func TestConfigService_ExportConfigs(t *testing.T) {
+	created := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("按游标逐页导出，不读取配置内容", func(t *testing.T) {
+		repo := new(mocks.MockConfigRepository)
+		repo.On("List", mock.Anything, mock.MatchedBy(func(req *models.ConfigListRequest) bool {
+			return req.After == nil && req.PageSize == configExportBatchSize && req.OmitContent &&
+				req.Type == models.ConfigTypeFilter && req.Project == "payments"
+		})).Return(&models.ConfigListResponse{
+			Items: []*models.Config{{
+				ID: "c1", Name: "nginx", Type: models.ConfigTypeFilter, Version: 2, Enabled: true,
+				Tags: []string{"web", "prod"}, CreatedAt: created,
+			}},
+			NextCursor: models.EncodeCursor([]interface{}{"nginx", "c1"}),
+		}, nil).Once()
+		repo.On("List", mock.Anything, mock.MatchedBy(func(req *models.ConfigListRequest) bool {
+			return assert.ObjectsAreEqual([]interface{}{"nginx", "c1"}, req.After)
+		})).Return(&models.ConfigListResponse{
+			Items: []*models.Config{{ID: "c2", Name: "=cmd", Type: models.ConfigTypeFilter}},
+		}, nil).Once()
+		svc := NewConfigService(repo, logrus.New())
+
+		var buf bytes.Buffer
+		var opened bool
+		ctx := models.WithProject(context.Background(), "payments")
+		req := &models.ConfigExportRequest{
+			ConfigListRequest: models.ConfigListRequest{Type: models.ConfigTypeFilter, Cursor: "ignored"},
+			ExportOptions:     models.ExportOptions{Columns: "id,name,version,enabled,tags,created_at,tested_at"},
+		}
+		require.NoError(t, svc.ExportConfigs(ctx, req, csvOpener(&buf, &opened)))
+
+		assert.Equal(t, [][]string{
+			{"id", "name", "version", "enabled", "tags", "created_at", "tested_at"},
+			{"c1", "nginx", "2", "true", "web;prod", "2026-10-01T00:00:00Z", ""},
+			{"c2", "'=cmd", "0", "false", "", "", ""},
+		}, readCSV(t, &buf))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("读取失败返回错误", func(t *testing.T) {
+		repo := new(mocks.MockConfigRepository)
+		repo.On("List", mock.Anything, mock.Anything).Return(nil, errors.New("es down"))
+		svc := NewConfigService(repo, logrus.New())
+
+		var buf bytes.Buffer
+		var opened bool
+		err := svc.ExportConfigs(context.Background(), &models.ConfigExportRequest{}, csvOpener(&buf, &opened))
+		assert.Error(t, err)
+		assert.True(t, opened)
+	})
+}
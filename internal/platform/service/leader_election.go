@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// LeaderElectionConfig 后台任务领导者选举参数
+type LeaderElectionConfig struct {
+	LeaseName     string        // 租约名称，同一组平台副本使用相同名称
+	Identity      string        // 当前副本标识，为空时使用主机名加随机后缀
+	TTL           time.Duration // 租约有效期，领导者停止续约后其他副本最迟在该时间后接管
+	RenewInterval time.Duration // 续约和竞选的间隔，应明显小于TTL
+}
+
+// LeaderElector 基于ES租约文档的领导者选举
+// 多个平台副本竞争同一租约，只有持有租约的副本运行后台任务；租约通过文档版本条件写入，
+// 同一时刻只有一个副本能续约或接管成功。判定租约是否过期使用本地时钟，副本间时钟偏差应远小于TTL
+type LeaderElector struct {
+	cfg    LeaderElectionConfig
+	repo   repository.LeaseRepository
+	logger *logrus.Logger
+	now    func() time.Time
+
+	mu        sync.Mutex
+	leader    bool
+	renewedAt time.Time // 本副本最近一次成功续约的时间
+
+	tracker loopTracker
+}
+
+// NewLeaderElector 创建领导者选举
+func NewLeaderElector(cfg LeaderElectionConfig, repo repository.LeaseRepository, logger *logrus.Logger) *LeaderElector {
+	if cfg.LeaseName == "" {
+		cfg.LeaseName = "background-jobs"
+	}
+	if cfg.Identity == "" {
+		hostname, _ := os.Hostname()
+		cfg.Identity = hostname + "-" + uuid.New().String()[:8]
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = 15 * time.Second
+	}
+	if cfg.RenewInterval <= 0 || cfg.RenewInterval >= cfg.TTL {
+		cfg.RenewInterval = cfg.TTL / 3
+	}
+	return &LeaderElector{
+		cfg:     cfg,
+		repo:    repo,
+		logger:  logger,
+		now:     time.Now,
+		tracker: loopTracker{interval: cfg.RenewInterval},
+	}
+}
+
+// Identity 当前副本标识
+func (e *LeaderElector) Identity() string {
+	return e.cfg.Identity
+}
+
+// IsLeader 当前副本是否持有租约
+func (e *LeaderElector) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.leader
+}
+
+// Run 按间隔竞选和续约，直到ctx取消
+// 成为领导者时以新的上下文调用lead，失去租约时取消该上下文并等待lead返回后才会再次竞选；
+// lead应阻塞运行后台任务直到其上下文取消。ctx取消时停止lead并释放租约，其他副本无需等待过期即可接管
+func (e *LeaderElector) Run(ctx context.Context, lead func(ctx context.Context)) {
+	defer e.tracker.start(e.now())()
+	ticker := time.NewTicker(e.cfg.RenewInterval)
+	defer ticker.Stop()
+
+	var (
+		cancel context.CancelFunc
+		done   chan struct{}
+	)
+	stepDown := func() {
+		cancel()
+		<-done
+		cancel = nil
+		e.setLeader(false)
+	}
+	defer func() {
+		if cancel != nil {
+			stepDown()
+			e.release()
+		}
+	}()
+
+	for {
+		finish := e.tracker.begin(e.now())
+		held, err := e.tryAcquireOrRenew(ctx)
+		finish(err)
+		if err != nil && ctx.Err() == nil {
+			e.logger.WithError(err).Warn("领导者租约续约失败")
+		}
+
+		switch {
+		case held && cancel == nil:
+			e.logger.Infof("当前副本 %s 成为领导者，启动后台任务", e.cfg.Identity)
+			e.setLeader(true)
+			leadCtx, leadCancel := context.WithCancel(ctx)
+			cancel = leadCancel
+			done = make(chan struct{})
+			go func() {
+				defer close(done)
+				lead(leadCtx)
+			}()
+		case !held && cancel != nil && (err == nil || e.expiring()):
+			// 租约被接管，或续约持续失败且租约即将到期，停止后台任务以免与新领导者同时运行
+			e.logger.Warnf("当前副本 %s 失去领导者租约，停止后台任务", e.cfg.Identity)
+			stepDown()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// WorkerStatus 报告领导者选举的运行状态
+func (e *LeaderElector) WorkerStatus(now time.Time) models.WorkerStatus {
+	status := e.tracker.status("leader_election", now)
+	leader := 0.0
+	if e.IsLeader() {
+		leader = 1
+	}
+	status.Gauges = map[string]float64{"leader": leader}
+	return status
+}
+
+// tryAcquireOrRenew 租约由本副本持有时续约，不存在或已过期时接管，返回是否持有租约
+func (e *LeaderElector) tryAcquireOrRenew(ctx context.Context) (bool, error) {
+	lease, version, err := e.repo.Get(ctx, e.cfg.LeaseName)
+	if err != nil {
+		return false, err
+	}
+
+	now := e.now()
+	next := &models.Lease{
+		Name:       e.cfg.LeaseName,
+		Holder:     e.cfg.Identity,
+		AcquiredAt: now,
+		RenewedAt:  now,
+		ExpiresAt:  now.Add(e.cfg.TTL),
+	}
+	if lease != nil {
+		if lease.Holder != e.cfg.Identity && now.Before(lease.ExpiresAt) {
+			return false, nil
+		}
+		if lease.Holder == e.cfg.Identity {
+			next.AcquiredAt = lease.AcquiredAt
+		}
+	}
+
+	if err := e.repo.Put(ctx, next, version); err != nil {
+		if errors.Is(err, elasticsearch.ErrVersionConflict) {
+			// 其他副本在读取后先完成了写入
+			return false, nil
+		}
+		return false, err
+	}
+
+	e.mu.Lock()
+	e.renewedAt = now
+	e.mu.Unlock()
+	return true, nil
+}
+
+// expiring 下一次续约前租约是否会到期
+func (e *LeaderElector) expiring() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.now().Add(e.cfg.RenewInterval).After(e.renewedAt.Add(e.cfg.TTL))
+}
+
+// release 退出时将本副本持有的租约置为已过期
+func (e *LeaderElector) release() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	lease, version, err := e.repo.Get(ctx, e.cfg.LeaseName)
+	if err != nil || lease == nil || lease.Holder != e.cfg.Identity {
+		return
+	}
+	lease.ExpiresAt = e.now()
+	if err := e.repo.Put(ctx, lease, version); err != nil {
+		e.logger.WithError(err).Warn("释放领导者租约失败，其他副本将在租约到期后接管")
+		return
+	}
+	e.logger.Infof("当前副本 %s 已释放领导者租约", e.cfg.Identity)
+}
+
+func (e *LeaderElector) setLeader(leader bool) {
+	e.mu.Lock()
+	e.leader = leader
+	e.mu.Unlock()
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// memLeaseRepository 按版本号条件写入的内存租约仓库
+type memLeaseRepository struct {
+	mu     sync.Mutex
+	leases map[string]models.Lease
+	seqNos map[string]int64
+}
+
+func newMemLeaseRepository() *memLeaseRepository {
+	return &memLeaseRepository{leases: map[string]models.Lease{}, seqNos: map[string]int64{}}
+}
+
+func (r *memLeaseRepository) Get(ctx context.Context, name string) (*models.Lease, *elasticsearch.DocVersion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	lease, ok := r.leases[name]
+	if !ok {
+		return nil, nil, nil
+	}
+	return &lease, &elasticsearch.DocVersion{SeqNo: r.seqNos[name], PrimaryTerm: 1}, nil
+}
+
+func (r *memLeaseRepository) Put(ctx context.Context, lease *models.Lease, version *elasticsearch.DocVersion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	seqNo, exists := r.seqNos[lease.Name]
+	if (version == nil && exists) || (version != nil && (!exists || version.SeqNo != seqNo)) {
+		return elasticsearch.ErrVersionConflict
+	}
+	r.leases[lease.Name] = *lease
+	r.seqNos[lease.Name] = seqNo + 1
+	return nil
+}
+
+func (r *memLeaseRepository) holder(name string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.leases[name].Holder
+}
+
+// partitionedLeaseRepository 模拟与ES失联的副本
+type partitionedLeaseRepository struct {
+	*memLeaseRepository
+	down atomic.Bool
+}
+
+func (r *partitionedLeaseRepository) Get(ctx context.Context, name string) (*models.Lease, *elasticsearch.DocVersion, error) {
+	if r.down.Load() {
+		return nil, nil, errors.New("连接ES超时")
+	}
+	return r.memLeaseRepository.Get(ctx, name)
+}
+
+func TestLeaderElector_Failover(t *testing.T) {
+	cfg := LeaderElectionConfig{LeaseName: "jobs", TTL: 300 * time.Millisecond, RenewInterval: 50 * time.Millisecond}
+
+	// running 记录各副本后台任务是否在运行，同一时刻最多一个
+	var (
+		mu      sync.Mutex
+		running = map[string]bool{}
+		overlap atomic.Bool
+	)
+	lead := func(identity string) func(ctx context.Context) {
+		return func(ctx context.Context) {
+			mu.Lock()
+			for other, active := range running {
+				if active && other != identity {
+					overlap.Store(true)
+				}
+			}
+			running[identity] = true
+			mu.Unlock()
+
+			<-ctx.Done()
+			mu.Lock()
+			running[identity] = false
+			mu.Unlock()
+		}
+	}
+	isRunning := func(identity string) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return running[identity]
+	}
+	start := func(elector *LeaderElector) (context.CancelFunc, chan struct{}) {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			elector.Run(ctx, lead(elector.Identity()))
+		}()
+		return cancel, done
+	}
+
+	t.Run("领导者退出时释放租约，其他副本立即接管", func(t *testing.T) {
+		repo := newMemLeaseRepository()
+		first := NewLeaderElector(withIdentity(cfg, "a"), repo, logrus.New())
+		second := NewLeaderElector(withIdentity(cfg, "b"), repo, logrus.New())
+
+		stopFirst, firstDone := start(first)
+		require.Eventually(t, first.IsLeader, time.Second, 10*time.Millisecond)
+		stopSecond, secondDone := start(second)
+		defer func() { stopSecond(); <-secondDone }()
+
+		// 租约有效期内其他副本不会接管
+		time.Sleep(2 * cfg.TTL)
+		assert.False(t, second.IsLeader())
+		assert.Equal(t, "a", repo.holder("jobs"))
+
+		stopFirst()
+		<-firstDone
+		assert.False(t, isRunning("a"), "退出前应等待后台任务停止")
+		require.Eventually(t, second.IsLeader, cfg.TTL, 10*time.Millisecond)
+		require.Eventually(t, func() bool { return isRunning("b") }, time.Second, 10*time.Millisecond)
+		assert.Equal(t, "b", repo.holder("jobs"))
+		assert.False(t, overlap.Load())
+	})
+
+	t.Run("领导者失联时停止任务，租约过期后被接管", func(t *testing.T) {
+		repo := newMemLeaseRepository()
+		partitioned := &partitionedLeaseRepository{memLeaseRepository: repo}
+		first := NewLeaderElector(withIdentity(cfg, "c"), partitioned, logrus.New())
+		second := NewLeaderElector(withIdentity(cfg, "d"), repo, logrus.New())
+
+		stopFirst, firstDone := start(first)
+		defer func() { stopFirst(); <-firstDone }()
+		require.Eventually(t, first.IsLeader, time.Second, 10*time.Millisecond)
+		stopSecond, secondDone := start(second)
+		defer func() { stopSecond(); <-secondDone }()
+
+		partitioned.down.Store(true)
+		require.Eventually(t, func() bool { return !first.IsLeader() }, cfg.TTL, 10*time.Millisecond)
+		assert.False(t, isRunning("c"))
+		require.Eventually(t, second.IsLeader, 2*cfg.TTL, 10*time.Millisecond)
+		assert.False(t, overlap.Load())
+
+		// 恢复连接后原领导者不会抢回未过期的租约
+		partitioned.down.Store(false)
+		time.Sleep(2 * cfg.RenewInterval)
+		assert.False(t, first.IsLeader())
+		assert.Equal(t, "d", repo.holder("jobs"))
+	})
+}
+
+func withIdentity(cfg LeaderElectionConfig, identity string) LeaderElectionConfig {
+	cfg.Identity = identity
+	return cfg
+}
+
+func TestLeaderElector_WorkerStatus(t *testing.T) {
+	elector := NewLeaderElector(LeaderElectionConfig{TTL: 30 * time.Second}, newMemLeaseRepository(), logrus.New())
+	held, err := elector.tryAcquireOrRenew(context.Background())
+	require.NoError(t, err)
+	assert.True(t, held)
+	assert.Equal(t, 10*time.Second, elector.cfg.RenewInterval)
+	assert.NotEmpty(t, elector.Identity())
+
+	status := elector.WorkerStatus(time.Now())
+	assert.Equal(t, "leader_election", status.Name)
+	assert.Equal(t, 0.0, status.Gauges["leader"], "只有Run启动后台任务后才算领导者")
+}
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+)
+
+// ErrAgentOffline Agent没有WebSocket连接，实时日志和远程诊断只经WebSocket下发
+var ErrAgentOffline = errors.New("Agent未建立WebSocket连接")
+
+// ErrTooManyLogStreams 同时打开的实时日志流已达上限
+var ErrTooManyLogStreams = errors.New("实时日志流数量已达上限")
+
+// LiveChannel 只经由实时连接推送的通道，日志流不能交给心跳命令队列延后下发
+type LiveChannel interface {
+	MessagePublisher
+	IsConnected(agentID string) bool
+}
+
+// LogStreamConfig 实时日志流参数
+type LogStreamConfig struct {
+	MaxRate    int // 每个流每秒最多转发的行数，请求的速率超过时取该值
+	MaxStreams int // 平台同时打开的流数上限
+	Buffer     int // 每个流等待写给浏览器的批次数，写入过慢时新的批次丢弃并计入dropped
+}
+
+// withDefaults 补全未设置的参数
+func (c LogStreamConfig) withDefaults() LogStreamConfig {
+	if c.MaxRate <= 0 {
+		c.MaxRate = 200
+	}
+	if c.MaxStreams <= 0 {
+		c.MaxStreams = 50
+	}
+	if c.Buffer <= 0 {
+		c.Buffer = 32
+	}
+	return c
+}
+
+// LogStream 一个浏览器订阅的实时日志流
+type LogStream struct {
+	ID      string
+	AgentID string
+	Request models.LogTailStartPayload
+
+	batches   chan *models.LogLinesMessage
+	done      chan struct{}
+	closeOnce sync.Once
+
+	mu          sync.Mutex
+	windowStart time.Time // 当前一秒速率窗口的起点
+	sent        int       // 当前窗口已转发的行数
+	dropped     int       // 尚未告知浏览器的丢弃行数
+}
+
+// Batches 等待写给浏览器的日志批次，收到Closed为true的批次后不会再有新的批次
+func (s *LogStream) Batches() <-chan *models.LogLinesMessage {
+	return s.batches
+}
+
+// Done 流被关闭时关闭
+func (s *LogStream) Done() <-chan struct{} {
+	return s.done
+}
+
+// LogStreamRelay 把Agent经WebSocket推送的日志行转发给订阅的浏览器
+// 浏览器打开流时向Agent下发log_tail_start，关闭时下发log_tail_stop；Agent按请求的级别和速率过滤，
+// 平台转发前再次按级别过滤并执行速率上限，避免异常的Agent压垮浏览器连接
+type LogStreamRelay struct {
+	cfg     LogStreamConfig
+	channel LiveChannel
+	logger  *logrus.Logger
+	now     func() time.Time
+
+	mu      sync.Mutex
+	streams map[string]*LogStream
+}
+
+// NewLogStreamRelay 创建实时日志转发
+func NewLogStreamRelay(cfg LogStreamConfig, channel LiveChannel, logger *logrus.Logger) *LogStreamRelay {
+	return &LogStreamRelay{
+		cfg:     cfg.withDefaults(),
+		channel: channel,
+		logger:  logger,
+		now:     time.Now,
+		streams: make(map[string]*LogStream),
+	}
+}
+
+// MaxRate 每个流每秒最多转发的行数
+func (r *LogStreamRelay) MaxRate() int {
+	return r.cfg.MaxRate
+}
+
+// Open 要求Agent开始跟踪日志，返回的流需在浏览器断开后调用Close
+// 请求的速率为0或超过上限时取上限，StreamID由平台生成
+func (r *LogStreamRelay) Open(agentID string, req models.LogTailStartPayload) (*LogStream, error) {
+	if !r.channel.IsConnected(agentID) {
+		return nil, fmt.Errorf("%w: %s", ErrAgentOffline, agentID)
+	}
+	if req.MaxRate <= 0 || req.MaxRate > r.cfg.MaxRate {
+		req.MaxRate = r.cfg.MaxRate
+	}
+	req.StreamID = uuid.New().String()
+
+	stream := &LogStream{
+		ID:      req.StreamID,
+		AgentID: agentID,
+		Request: req,
+		batches: make(chan *models.LogLinesMessage, r.cfg.Buffer),
+		done:    make(chan struct{}),
+	}
+
+	r.mu.Lock()
+	if len(r.streams) >= r.cfg.MaxStreams {
+		r.mu.Unlock()
+		return nil, ErrTooManyLogStreams
+	}
+	r.streams[stream.ID] = stream
+	r.mu.Unlock()
+
+	if err := r.channel.Publish(agentID, models.MsgTypeLogTailStart, req); err != nil {
+		r.remove(stream)
+		return nil, fmt.Errorf("下发日志跟踪请求失败: %w", err)
+	}
+
+	r.logger.WithFields(logrus.Fields{
+		"agent_id":  agentID,
+		"stream_id": stream.ID,
+		"source":    req.Source,
+		"pipeline":  req.Pipeline,
+	}).Info("打开实时日志流")
+	return stream, nil
+}
+
+// Close 关闭流并通知Agent停止跟踪，可重复调用
+func (r *LogStreamRelay) Close(stream *LogStream) {
+	if !r.remove(stream) {
+		return
+	}
+	if err := r.channel.Publish(stream.AgentID, models.MsgTypeLogTailStop, models.LogTailStopPayload{StreamID: stream.ID}); err != nil {
+		// Agent已断开时其跟踪随连接结束
+		r.logger.WithError(err).WithField("stream_id", stream.ID).Debug("下发停止日志跟踪失败")
+	}
+	r.logger.WithFields(logrus.Fields{
+		"agent_id":  stream.AgentID,
+		"stream_id": stream.ID,
+	}).Info("关闭实时日志流")
+}
+
+// Deliver 转发Agent推送的一批日志行，流不存在（浏览器已断开）时忽略
+func (r *LogStreamRelay) Deliver(agentID string, msg *models.LogLinesMessage) error {
+	r.mu.Lock()
+	stream := r.streams[msg.StreamID]
+	r.mu.Unlock()
+
+	if stream == nil {
+		return nil
+	}
+	if stream.AgentID != agentID {
+		return fmt.Errorf("日志流 %s 不属于Agent %s", msg.StreamID, agentID)
+	}
+
+	batch := r.limit(stream, msg)
+	if len(batch.Lines) == 0 && batch.Dropped == 0 && !batch.Closed {
+		return nil
+	}
+	select {
+	case stream.batches <- batch:
+	default:
+		if batch.Closed {
+			// 缓冲已满时仍需结束流，浏览器连接随之关闭
+			r.remove(stream)
+			return nil
+		}
+		stream.mu.Lock()
+		stream.dropped += len(batch.Lines) + batch.Dropped
+		stream.mu.Unlock()
+	}
+	if batch.Closed {
+		r.remove(stream)
+	}
+	return nil
+}
+
+// limit 按最低级别过滤并执行每秒行数上限，返回待转发的批次
+func (r *LogStreamRelay) limit(stream *LogStream, msg *models.LogLinesMessage) *models.LogLinesMessage {
+	minRank := models.LogLevelRank(stream.Request.MinLevel)
+	now := r.now()
+
+	stream.mu.Lock()
+	defer stream.mu.Unlock()
+
+	if now.Sub(stream.windowStart) >= time.Second {
+		stream.windowStart = now
+		stream.sent = 0
+	}
+	batch := &models.LogLinesMessage{
+		StreamID: msg.StreamID,
+		Dropped:  stream.dropped + msg.Dropped,
+		Closed:   msg.Closed,
+		Error:    msg.Error,
+	}
+	for _, line := range msg.Lines {
+		if rank := models.LogLevelRank(line.Level); rank >= 0 && rank < minRank {
+			continue
+		}
+		if stream.sent >= stream.Request.MaxRate {
+			batch.Dropped++
+			continue
+		}
+		stream.sent++
+		batch.Lines = append(batch.Lines, line)
+	}
+	stream.dropped = 0
+	return batch
+}
+
+// remove 移除流并关闭其Done通道，返回流此前是否仍打开
+func (r *LogStreamRelay) remove(stream *LogStream) bool {
+	r.mu.Lock()
+	_, ok := r.streams[stream.ID]
+	delete(r.streams, stream.ID)
+	r.mu.Unlock()
+
+	stream.closeOnce.Do(func() { close(stream.done) })
+	return ok
+}
+
+// WorkerStatus 报告当前打开的实时日志流数
+func (r *LogStreamRelay) WorkerStatus(now time.Time) models.WorkerStatus {
+	r.mu.Lock()
+	open := len(r.streams)
+	r.mu.Unlock()
+	return models.WorkerStatus{
+		Name:    "log_streams",
+		Kind:    models.WorkerKindConnections,
+		Running: true,
+		Gauges:  map[string]float64{"open": float64(open)},
+	}
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+)
+
+// liveChannel 记录下发的消息，connected为false时模拟Agent未连接
+type liveChannel struct {
+	connected bool
+	sent      []publishedMessage
+}
+
+func (l *liveChannel) Publish(agentID, msgType string, payload interface{}) error {
+	l.sent = append(l.sent, publishedMessage{agentID: agentID, msgType: msgType, payload: payload})
+	return nil
+}
+
+func (l *liveChannel) IsConnected(agentID string) bool {
+	return l.connected
+}
+
+func TestLogStreamRelay_OpenAndClose(t *testing.T) {
+	channel := &liveChannel{}
+	relay := NewLogStreamRelay(LogStreamConfig{MaxRate: 50, MaxStreams: 1}, channel, logrus.New())
+
+	_, err := relay.Open("agent-1", models.LogTailStartPayload{Source: models.LogSourceLogstash})
+	assert.True(t, errors.Is(err, ErrAgentOffline))
+	assert.Empty(t, channel.sent, "Agent未连接时不下发")
+
+	channel.connected = true
+	stream, err := relay.Open("agent-1", models.LogTailStartPayload{Source: models.LogSourceLogstash, MaxRate: 500})
+	require.NoError(t, err)
+	assert.NotEmpty(t, stream.ID)
+	assert.Equal(t, 50, stream.Request.MaxRate, "请求的速率不能超过平台上限")
+	require.Len(t, channel.sent, 1)
+	assert.Equal(t, models.MsgTypeLogTailStart, channel.sent[0].msgType)
+
+	_, err = relay.Open("agent-2", models.LogTailStartPayload{Source: models.LogSourceLogstash})
+	assert.Equal(t, ErrTooManyLogStreams, err)
+
+	relay.Close(stream)
+	relay.Close(stream)
+	require.Len(t, channel.sent, 2, "重复关闭只下发一次停止")
+	assert.Equal(t, models.MsgTypeLogTailStop, channel.sent[1].msgType)
+	assert.Equal(t, models.LogTailStopPayload{StreamID: stream.ID}, channel.sent[1].payload)
+	<-stream.Done()
+
+	// 浏览器断开后到达的日志行忽略
+	assert.NoError(t, relay.Deliver("agent-1", &models.LogLinesMessage{StreamID: stream.ID, Lines: []models.LogLine{{Message: "late"}}}))
+}
+
+func TestLogStreamRelay_DeliverFiltersAndLimits(t *testing.T) {
+	channel := &liveChannel{connected: true}
+	relay := NewLogStreamRelay(LogStreamConfig{MaxRate: 100}, channel, logrus.New())
+	now := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	relay.now = func() time.Time { return now }
+
+	stream, err := relay.Open("agent-1", models.LogTailStartPayload{Source: models.LogSourceLogstash, MinLevel: "warn", MaxRate: 2})
+	require.NoError(t, err)
+
+	assert.Error(t, relay.Deliver("agent-2", &models.LogLinesMessage{StreamID: stream.ID}), "其他Agent不能向该流推送")
+
+	require.NoError(t, relay.Deliver("agent-1", &models.LogLinesMessage{StreamID: stream.ID, Dropped: 1, Lines: []models.LogLine{
+		{Level: "INFO", Message: "filtered"},
+		{Level: "WARN", Message: "w1"},
+		{Message: "  at org.logstash.Stack"}, // 无法识别级别的行不过滤
+		{Level: "ERROR", Message: "over limit"},
+	}}))
+	batch := <-stream.Batches()
+	require.Len(t, batch.Lines, 2)
+	assert.Equal(t, "w1", batch.Lines[0].Message)
+	assert.Equal(t, 2, batch.Dropped, "超过速率的行和Agent端丢弃的行一并计入")
+
+	// 下一秒窗口重新计数
+	now = now.Add(time.Second)
+	require.NoError(t, relay.Deliver("agent-1", &models.LogLinesMessage{StreamID: stream.ID, Lines: []models.LogLine{{Level: "ERROR", Message: "e2"}}}))
+	batch = <-stream.Batches()
+	require.Len(t, batch.Lines, 1)
+	assert.Zero(t, batch.Dropped)
+
+	// Agent结束跟踪后流关闭
+	require.NoError(t, relay.Deliver("agent-1", &models.LogLinesMessage{StreamID: stream.ID, Closed: true, Error: "日志文件不存在"}))
+	batch = <-stream.Batches()
+	assert.True(t, batch.Closed)
+	<-stream.Done()
+	assert.Equal(t, float64(0), relay.WorkerStatus(now).Gauges["open"])
+}
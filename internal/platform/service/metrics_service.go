@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+)
+
+// ErrInvalidMetricsQuery 指标查询的时间范围或聚合间隔无效
+var ErrInvalidMetricsQuery = errors.New("指标查询参数无效")
+
+const (
+	// defaultMetricsWindow 未指定起始时间时查询最近1小时
+	defaultMetricsWindow = time.Hour
+	// minMetricsBucketInterval 聚合间隔下限
+	minMetricsBucketInterval = 10 * time.Second
+	// maxMetricsBuckets 单次查询最多返回的时间桶数
+	maxMetricsBuckets = 1000
+	// targetMetricsBuckets 自动选择间隔时期望的时间桶数
+	targetMetricsBuckets = 120
+)
+
+// metricsIntervals 自动选择聚合间隔时的候选值
+var metricsIntervals = []time.Duration{
+	10 * time.Second, 30 * time.Second, time.Minute, 5 * time.Minute, 10 * time.Minute,
+	30 * time.Minute, time.Hour, 3 * time.Hour, 6 * time.Hour, 12 * time.Hour, 24 * time.Hour,
+}
+
+// MetricsService Agent指标时序服务接口
+type MetricsService interface {
+	Record(ctx context.Context, agentID string, metrics *models.AgentMetrics) error
+	Query(ctx context.Context, agentID string, req *models.MetricsQueryRequest) (*models.MetricsSeries, error)
+	// LatestPipelines 获取Agent最近一次上报的各管道统计
+	LatestPipelines(ctx context.Context, agentID string) (*models.AgentPipelineStats, error)
+}
+
+// metricsService Agent指标时序服务实现
+type metricsService struct {
+	metricsRepo repository.MetricsRepository
+	agentRepo   repository.AgentRepository
+	logger      *logrus.Logger
+}
+
+// NewMetricsService 创建Agent指标时序服务
+func NewMetricsService(metricsRepo repository.MetricsRepository, agentRepo repository.AgentRepository, logger *logrus.Logger) MetricsService {
+	return &metricsService{
+		metricsRepo: metricsRepo,
+		agentRepo:   agentRepo,
+		logger:      logger,
+	}
+}
+
+// Record 将Agent上报的指标写入时序索引
+func (s *metricsService) Record(ctx context.Context, agentID string, metrics *models.AgentMetrics) error {
+	sample := &models.MetricsSample{AgentID: agentID, AgentMetrics: *metrics}
+	if sample.Timestamp.IsZero() {
+		sample.Timestamp = time.Now()
+	}
+	return s.metricsRepo.Save(ctx, sample)
+}
+
+// Query 按时间范围和聚合间隔查询Agent的指标时序
+func (s *metricsService) Query(ctx context.Context, agentID string, req *models.MetricsQueryRequest) (*models.MetricsSeries, error) {
+	to := req.To
+	if to.IsZero() {
+		to = time.Now()
+	}
+	from := req.From
+	if from.IsZero() {
+		from = to.Add(-defaultMetricsWindow)
+	}
+	if !from.Before(to) {
+		return nil, fmt.Errorf("%w: 起始时间必须早于结束时间", ErrInvalidMetricsQuery)
+	}
+
+	interval, err := metricsInterval(req.Interval, to.Sub(from))
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.agentRepo.GetByID(ctx, agentID); err != nil {
+		return nil, fmt.Errorf("Agent不存在: %w", err)
+	}
+
+	points, err := s.metricsRepo.Histogram(ctx, agentID, from, to, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.MetricsSeries{
+		AgentID:  agentID,
+		From:     from,
+		To:       to,
+		Interval: interval.String(),
+		Points:   points,
+	}, nil
+}
+
+// LatestPipelines 获取Agent最近一次上报的各管道统计，取自Agent文档中保存的最新指标而非时序索引
+func (s *metricsService) LatestPipelines(ctx context.Context, agentID string) (*models.AgentPipelineStats, error) {
+	agent, err := s.agentRepo.GetByID(ctx, agentID)
+	if err != nil {
+		return nil, fmt.Errorf("Agent不存在: %w", err)
+	}
+
+	result := &models.AgentPipelineStats{AgentID: agentID, Pipelines: []models.PipelineStats{}}
+	if agent.Metrics != nil && agent.Metrics.Logstash != nil {
+		reportedAt := agent.Metrics.Timestamp
+		result.ReportedAt = &reportedAt
+		result.Pipelines = append(result.Pipelines, agent.Metrics.Logstash.Pipelines...)
+	}
+	return result, nil
+}
+
+// metricsInterval 解析聚合间隔，为空时按时间范围选择使时间桶数接近targetMetricsBuckets的候选值
+func metricsInterval(value string, window time.Duration) (time.Duration, error) {
+	if value == "" {
+		for _, interval := range metricsIntervals {
+			if window/interval <= targetMetricsBuckets {
+				return interval, nil
+			}
+		}
+		interval := metricsIntervals[len(metricsIntervals)-1]
+		if window/interval > maxMetricsBuckets {
+			return 0, fmt.Errorf("%w: 时间范围过大", ErrInvalidMetricsQuery)
+		}
+		return interval, nil
+	}
+
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: 聚合间隔格式应为 30s、5m、1h 等", ErrInvalidMetricsQuery)
+	}
+	if interval < minMetricsBucketInterval || interval%time.Second != 0 {
+		return 0, fmt.Errorf("%w: 聚合间隔不能小于 %s 且必须为整秒", ErrInvalidMetricsQuery, minMetricsBucketInterval)
+	}
+	if window/interval > maxMetricsBuckets {
+		return 0, fmt.Errorf("%w: 时间桶数超过 %d，请增大聚合间隔", ErrInvalidMetricsQuery, maxMetricsBuckets)
+	}
+	return interval, nil
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+)
+
+// memMetricsRepository 记录写入的指标和查询参数
+type memMetricsRepository struct {
+	samples  []*models.MetricsSample
+	interval time.Duration
+	averages map[string]float64 // Agent ID -> 指标均值
+}
+
+func (r *memMetricsRepository) Save(ctx context.Context, sample *models.MetricsSample) error {
+	r.samples = append(r.samples, sample)
+	return nil
+}
+
+func (r *memMetricsRepository) Histogram(ctx context.Context, agentID string, from, to time.Time, interval time.Duration) ([]models.MetricsPoint, error) {
+	r.interval = interval
+	return []models.MetricsPoint{}, nil
+}
+
+func (r *memMetricsRepository) Averages(ctx context.Context, agentIDs []string, metric string, from, to time.Time) (map[string]float64, error) {
+	averages := make(map[string]float64)
+	for _, agentID := range agentIDs {
+		if value, ok := r.averages[agentID]; ok {
+			averages[agentID] = value
+		}
+	}
+	return averages, nil
+}
+
+func TestMetricsService_Query(t *testing.T) {
+	ctx := context.Background()
+	repo := &memMetricsRepository{}
+	agents := &memAgentRepository{agents: map[string]*models.Agent{"agent-1": {AgentID: "agent-1"}}}
+	svc := NewMetricsService(repo, agents, logrus.New())
+
+	to := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("默认查询最近1小时并自动选择间隔", func(t *testing.T) {
+		series, err := svc.Query(ctx, "agent-1", &models.MetricsQueryRequest{To: to})
+		require.NoError(t, err)
+		assert.Equal(t, to.Add(-time.Hour), series.From)
+		assert.Equal(t, "30s", series.Interval)
+		assert.Equal(t, 30*time.Second, repo.interval)
+	})
+
+	t.Run("一周范围自动放大间隔", func(t *testing.T) {
+		series, err := svc.Query(ctx, "agent-1", &models.MetricsQueryRequest{From: to.AddDate(0, 0, -7), To: to})
+		require.NoError(t, err)
+		assert.Equal(t, "3h0m0s", series.Interval)
+	})
+
+	t.Run("指定间隔", func(t *testing.T) {
+		_, err := svc.Query(ctx, "agent-1", &models.MetricsQueryRequest{To: to, Interval: "5m"})
+		require.NoError(t, err)
+		assert.Equal(t, 5*time.Minute, repo.interval)
+	})
+
+	t.Run("无效参数", func(t *testing.T) {
+		for _, req := range []*models.MetricsQueryRequest{
+			{From: to, To: to},
+			{To: to, Interval: "abc"},
+			{To: to, Interval: "1s"},
+			{From: to.AddDate(0, 0, -30), To: to, Interval: "10s"},
+		} {
+			_, err := svc.Query(ctx, "agent-1", req)
+			assert.ErrorIs(t, err, ErrInvalidMetricsQuery)
+		}
+	})
+
+	t.Run("Agent不存在", func(t *testing.T) {
+		_, err := svc.Query(ctx, "agent-x", &models.MetricsQueryRequest{To: to})
+		assert.ErrorContains(t, err, "Agent不存在")
+	})
+}
+
+func TestMetricsService_Record(t *testing.T) {
+	repo := &memMetricsRepository{}
+	svc := NewMetricsService(repo, &memAgentRepository{agents: map[string]*models.Agent{}}, logrus.New())
+
+	require.NoError(t, svc.Record(context.Background(), "agent-1", &models.AgentMetrics{CPUUsage: 10}))
+	require.Len(t, repo.samples, 1)
+	assert.Equal(t, "agent-1", repo.samples[0].AgentID)
+	assert.False(t, repo.samples[0].Timestamp.IsZero())
+}
+
+func TestMetricsService_LatestPipelines(t *testing.T) {
+	ctx := context.Background()
+	reportedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	agents := &memAgentRepository{agents: map[string]*models.Agent{
+		"agent-1": {AgentID: "agent-1", Metrics: &models.AgentMetrics{
+			Timestamp: reportedAt,
+			Logstash: &models.LogstashStats{Pipelines: []models.PipelineStats{
+				{ID: "main", EventsIn: 100, QueuePushed: 100, DeadLetter: &models.DeadLetterStats{Events: 3}},
+			}},
+		}},
+		"agent-2": {AgentID: "agent-2"},
+	}}
+	svc := NewMetricsService(&memMetricsRepository{}, agents, logrus.New())
+
+	stats, err := svc.LatestPipelines(ctx, "agent-1")
+	require.NoError(t, err)
+	require.NotNil(t, stats.ReportedAt)
+	assert.Equal(t, reportedAt, *stats.ReportedAt)
+	require.Len(t, stats.Pipelines, 1)
+	assert.EqualValues(t, 3, stats.Pipelines[0].DeadLetter.Events)
+
+	// 尚未上报Logstash统计
+	stats, err = svc.LatestPipelines(ctx, "agent-2")
+	require.NoError(t, err)
+	assert.Nil(t, stats.ReportedAt)
+	assert.Empty(t, stats.Pipelines)
+
+	_, err = svc.LatestPipelines(ctx, "missing")
+	assert.Error(t, err)
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+)
+
+// 仪表盘总览的统计窗口和列表长度
+const (
+	overviewDeploymentWindow = 24 * time.Hour
+	overviewTopCPUAgents     = 5
+	overviewRecentFailures   = 10
+)
+
+// OverviewService 仪表盘总览服务接口
+type OverviewService interface {
+	// Overview 汇总当前项目内的Agent、配置和最近24小时的部署
+	Overview(ctx context.Context) (*models.FleetOverview, error)
+}
+
+// overviewService 仪表盘总览服务实现
+type overviewService struct {
+	repo   repository.OverviewRepository
+	logger *logrus.Logger
+	now    func() time.Time
+}
+
+// NewOverviewService 创建仪表盘总览服务
+func NewOverviewService(repo repository.OverviewRepository, logger *logrus.Logger) OverviewService {
+	return &overviewService{
+		repo:   repo,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// Overview 汇总仪表盘数据，Agent状态以心跳巡检持久化的状态为准
+func (s *overviewService) Overview(ctx context.Context) (*models.FleetOverview, error) {
+	project := models.ProjectFrom(ctx)
+	now := s.now()
+
+	agents, topCPU, err := s.repo.Agents(ctx, project, overviewTopCPUAgents)
+	if err != nil {
+		return nil, err
+	}
+	configs, err := s.repo.Configs(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	deployments, failures, err := s.repo.Deployments(ctx, project, now.Add(-overviewDeploymentWindow), overviewRecentFailures)
+	if err != nil {
+		return nil, err
+	}
+	deployments.SuccessRate = deploymentSuccessRate(deployments.ByStatus)
+
+	return &models.FleetOverview{
+		Agents:         *agents,
+		Configs:        *configs,
+		Deployments:    *deployments,
+		TopCPUAgents:   topCPU,
+		RecentFailures: failures,
+		GeneratedAt:    now,
+	}, nil
+}
+
+// deploymentSuccessRate 已结束的部署中成功的比例，进行中和等待审批的部署不计入
+func deploymentSuccessRate(byStatus map[string]int64) *float64 {
+	completed := byStatus[string(models.DeploymentStatusCompleted)]
+	finished := completed +
+		byStatus[string(models.DeploymentStatusFailed)] +
+		byStatus[string(models.DeploymentStatusRolledBack)]
+	if finished == 0 {
+		return nil
+	}
+	rate := float64(completed) / float64(finished)
+	return &rate
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+)
+
+// fakeOverviewRepository 返回固定统计结果并记录查询参数的总览仓库
+type fakeOverviewRepository struct {
+	project  string
+	since    time.Time
+	byStatus map[string]int64
+}
+
+func (r *fakeOverviewRepository) Agents(ctx context.Context, project string, top int) (*models.AgentOverview, []models.AgentCPUUsage, error) {
+	r.project = project
+	return &models.AgentOverview{Total: 1, ByStatus: map[string]int64{"online": 1}},
+		[]models.AgentCPUUsage{{AgentID: "agent-1", CPUUsage: 50}}, nil
+}
+
+func (r *fakeOverviewRepository) Configs(ctx context.Context, project string) (*models.ConfigOverview, error) {
+	return &models.ConfigOverview{Total: 2, Enabled: 1}, nil
+}
+
+func (r *fakeOverviewRepository) Deployments(ctx context.Context, project string, since time.Time, limit int) (*models.DeploymentOverview, []models.DeploymentFailure, error) {
+	r.since = since
+	return &models.DeploymentOverview{Since: since, ByStatus: r.byStatus}, nil, nil
+}
+
+func TestOverviewService_Overview(t *testing.T) {
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	repo := &fakeOverviewRepository{byStatus: map[string]int64{
+		"completed":   6,
+		"failed":      1,
+		"rolled_back": 1,
+		"running":     2, // 未结束的部署不计入成功率
+	}}
+	svc := NewOverviewService(repo, logrus.New()).(*overviewService)
+	svc.now = func() time.Time { return now }
+
+	overview, err := svc.Overview(models.WithProject(context.Background(), "payments"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "payments", repo.project)
+	assert.Equal(t, now.Add(-24*time.Hour), repo.since)
+	assert.Equal(t, now, overview.GeneratedAt)
+	assert.Equal(t, int64(1), overview.Agents.Total)
+	assert.Len(t, overview.TopCPUAgents, 1)
+	require.NotNil(t, overview.Deployments.SuccessRate)
+	assert.InDelta(t, 0.75, *overview.Deployments.SuccessRate, 1e-9)
+
+	// 窗口内没有已结束的部署时成功率为空
+	repo.byStatus = map[string]int64{"pending": 1}
+	overview, err = svc.Overview(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, overview.Deployments.SuccessRate)
+}
@@ -0,0 +1,397 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// 流水线相关错误，处理器通过 errors.Is 映射为HTTP状态码
+var (
+	ErrPipelineNotFound = errors.New("流水线不存在")
+	ErrPipelineExists   = errors.New("流水线已存在")
+	ErrPipelineInvalid  = errors.New("流水线验证失败")
+)
+
+// pipelineConfigTag 流水线生成的配置上的标签
+const pipelineConfigTag = "pipeline"
+
+// configTypeOrder 流水线中各类配置的排列顺序
+var configTypeOrder = map[models.ConfigType]int{
+	models.ConfigTypeInput:  0,
+	models.ConfigTypeFilter: 1,
+	models.ConfigTypeOutput: 2,
+}
+
+// PipelineService 流水线服务接口
+type PipelineService interface {
+	CreatePipeline(ctx context.Context, req *models.CreatePipelineRequest, userID string) (*models.Pipeline, error)
+	UpdatePipeline(ctx context.Context, id string, req *models.UpdatePipelineRequest, userID string) (*models.Pipeline, error)
+	DeletePipeline(ctx context.Context, id string) error
+	GetPipeline(ctx context.Context, id string) (*models.Pipeline, error)
+	ListPipelines(ctx context.Context) ([]*models.Pipeline, error)
+	GetPipelineVersions(ctx context.Context, id string) ([]*models.PipelineVersion, error)
+	// RenderPipeline 按引用配置的最新内容拼装并校验，不保存
+	RenderPipeline(ctx context.Context, id string) (*models.PipelineRender, error)
+	// DeployPipeline 拼装最新内容并部署到Agent，内容有变化时先保存为新版本
+	DeployPipeline(ctx context.Context, id string, req *models.DeployPipelineRequest, userID string) (*models.Deployment, error)
+}
+
+// pipelineService 流水线服务实现
+// 部署时拼装结果写入与流水线同ID的配置（类型为pipeline），复用配置的部署、审批和Agent拉取流程
+type pipelineService struct {
+	pipelineRepo repository.PipelineRepository
+	configRepo   repository.ConfigRepository
+	validator    ConfigValidator
+	engine       *DeploymentEngine
+	logger       *logrus.Logger
+}
+
+// NewPipelineService 创建流水线服务
+func NewPipelineService(pipelineRepo repository.PipelineRepository, configRepo repository.ConfigRepository,
+	validator ConfigValidator, engine *DeploymentEngine, logger *logrus.Logger) PipelineService {
+	return &pipelineService{
+		pipelineRepo: pipelineRepo,
+		configRepo:   configRepo,
+		validator:    validator,
+		engine:       engine,
+		logger:       logger,
+	}
+}
+
+// CreatePipeline 创建流水线
+func (s *pipelineService) CreatePipeline(ctx context.Context, req *models.CreatePipelineRequest, userID string) (*models.Pipeline, error) {
+	if _, err := s.pipelineRepo.GetByName(ctx, req.Name); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrPipelineExists, req.Name)
+	}
+
+	configs, err := s.assemble(ctx, req.ConfigIDs, models.PermissionRead)
+	if err != nil {
+		return nil, err
+	}
+	content, components := renderPipeline(req.Name, configs)
+	if _, err := s.validate(ctx, content); err != nil {
+		return nil, err
+	}
+
+	pipeline := &models.Pipeline{
+		Name:        req.Name,
+		Description: req.Description,
+		ConfigIDs:   req.ConfigIDs,
+		Components:  components,
+		Content:     content,
+		CreatedBy:   userID,
+		UpdatedBy:   userID,
+	}
+	if err := s.pipelineRepo.Create(ctx, pipeline); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"pipeline_id": pipeline.ID,
+		"name":        pipeline.Name,
+		"components":  len(components),
+		"user_id":     userID,
+	}).Info("创建流水线成功")
+
+	return pipeline, nil
+}
+
+// UpdatePipeline 更新流水线的组成，产生新版本
+func (s *pipelineService) UpdatePipeline(ctx context.Context, id string, req *models.UpdatePipelineRequest, userID string) (*models.Pipeline, error) {
+	pipeline, err := s.pipelineRepo.GetByID(elasticsearch.WithPrimaryRead(ctx), id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrPipelineNotFound, err)
+	}
+
+	configs, err := s.assemble(ctx, req.ConfigIDs, models.PermissionRead)
+	if err != nil {
+		return nil, err
+	}
+	content, components := renderPipeline(pipeline.Name, configs)
+	if _, err := s.validate(ctx, content); err != nil {
+		return nil, err
+	}
+
+	pipeline.Description = req.Description
+	pipeline.ConfigIDs = req.ConfigIDs
+	pipeline.Components = components
+	pipeline.Content = content
+	pipeline.UpdatedBy = userID
+	if err := s.pipelineRepo.Update(ctx, pipeline); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"pipeline_id": pipeline.ID,
+		"version":     pipeline.Version,
+		"user_id":     userID,
+	}).Info("更新流水线成功")
+
+	return pipeline, nil
+}
+
+// DeletePipeline 删除流水线，已部署到Agent的配置保留，需要时单独删除
+func (s *pipelineService) DeletePipeline(ctx context.Context, id string) error {
+	if _, err := s.pipelineRepo.GetByID(ctx, id); err != nil {
+		return fmt.Errorf("%w: %w", ErrPipelineNotFound, err)
+	}
+
+	if err := s.pipelineRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.logger.WithField("pipeline_id", id).Info("删除流水线成功")
+	return nil
+}
+
+// GetPipeline 获取流水线
+func (s *pipelineService) GetPipeline(ctx context.Context, id string) (*models.Pipeline, error) {
+	pipeline, err := s.pipelineRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrPipelineNotFound, err)
+	}
+	return pipeline, nil
+}
+
+// ListPipelines 获取全部流水线
+func (s *pipelineService) ListPipelines(ctx context.Context) ([]*models.Pipeline, error) {
+	return s.pipelineRepo.List(ctx)
+}
+
+// GetPipelineVersions 获取流水线的历史版本
+func (s *pipelineService) GetPipelineVersions(ctx context.Context, id string) ([]*models.PipelineVersion, error) {
+	if _, err := s.pipelineRepo.GetByID(ctx, id); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrPipelineNotFound, err)
+	}
+	return s.pipelineRepo.GetVersions(ctx, id)
+}
+
+// RenderPipeline 按引用配置的最新内容拼装并校验
+func (s *pipelineService) RenderPipeline(ctx context.Context, id string) (*models.PipelineRender, error) {
+	pipeline, err := s.pipelineRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrPipelineNotFound, err)
+	}
+
+	configs, err := s.assemble(ctx, pipeline.ConfigIDs, models.PermissionRead)
+	if err != nil {
+		return nil, err
+	}
+	content, components := renderPipeline(pipeline.Name, configs)
+	validation, err := s.validate(ctx, content)
+	if err != nil && !errors.Is(err, ErrPipelineInvalid) {
+		return nil, err
+	}
+
+	return &models.PipelineRender{
+		PipelineID: pipeline.ID,
+		Version:    pipeline.Version,
+		Changed:    content != pipeline.Content,
+		Components: components,
+		Content:    content,
+		Validation: validation,
+	}, nil
+}
+
+// DeployPipeline 拼装最新内容并部署到Agent
+// 部署要求对每个引用的配置都有部署权限；拼装结果写入流水线的配置后按普通配置部署，
+// 因此同样需要满足配置审批要求
+func (s *pipelineService) DeployPipeline(ctx context.Context, id string, req *models.DeployPipelineRequest, userID string) (*models.Deployment, error) {
+	if len(req.AgentIDs) == 0 && req.Selector == "" {
+		return nil, fmt.Errorf("必须指定Agent ID列表或标签选择器")
+	}
+
+	pipeline, err := s.pipelineRepo.GetByID(elasticsearch.WithPrimaryRead(ctx), id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrPipelineNotFound, err)
+	}
+
+	configs, err := s.assemble(ctx, pipeline.ConfigIDs, models.PermissionDeploy)
+	if err != nil {
+		return nil, err
+	}
+	content, components := renderPipeline(pipeline.Name, configs)
+	if _, err := s.validate(ctx, content); err != nil {
+		return nil, err
+	}
+
+	// 引用的配置在上次保存后有修改，先保存为新版本
+	if content != pipeline.Content {
+		pipeline.Components = components
+		pipeline.Content = content
+		pipeline.UpdatedBy = userID
+		if err := s.pipelineRepo.Update(ctx, pipeline); err != nil {
+			return nil, err
+		}
+	}
+
+	config, err := s.publish(ctx, pipeline, configs, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	deployment, err := s.engine.Start(ctx, &models.CreateDeploymentRequest{
+		ConfigID: config.ID,
+		AgentIDs: req.AgentIDs,
+		Selector: req.Selector,
+	}, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"pipeline_id":   pipeline.ID,
+		"version":       pipeline.Version,
+		"deployment_id": deployment.ID,
+		"user_id":       userID,
+	}).Info("部署流水线")
+
+	return deployment, nil
+}
+
+// publish 将拼装结果写入流水线的配置，内容未变化时不产生新的配置版本
+// 新建的配置属于请求所在的项目，已有的配置须在请求的项目内且有部署权限才能覆盖
+func (s *pipelineService) publish(ctx context.Context, pipeline *models.Pipeline, configs []*models.Config, userID string) (*models.Config, error) {
+	var destinations []string
+	for _, c := range configs {
+		for _, dest := range resolveDestinations(c.Destinations, c.Content) {
+			if !slices.Contains(destinations, dest) {
+				destinations = append(destinations, dest)
+			}
+		}
+	}
+
+	config, err := s.configRepo.GetByID(elasticsearch.WithPrimaryRead(ctx), pipeline.ID)
+	if err != nil {
+		if err.Error() != "文档不存在" {
+			return nil, fmt.Errorf("获取流水线配置失败: %w", err)
+		}
+		config = &models.Config{
+			ID:           pipeline.ID,
+			Name:         pipeline.Name,
+			Description:  fmt.Sprintf("由流水线 %s 拼装生成", pipeline.Name),
+			Type:         models.ConfigTypePipeline,
+			Content:      pipeline.Content,
+			Tags:         []string{pipelineConfigTag},
+			Destinations: destinations,
+			PipelineID:   pipeline.ID,
+			Project:      models.ProjectOf(models.ProjectFrom(ctx)),
+			CreatedBy:    userID,
+			UpdatedBy:    userID,
+		}
+		if err := s.configRepo.Create(ctx, config); err != nil {
+			return nil, err
+		}
+		return config, nil
+	}
+	if err := authorizeConfig(ctx, config, models.PermissionDeploy); err != nil {
+		return nil, err
+	}
+
+	if config.Content == pipeline.Content && slices.Equal(config.Destinations, destinations) {
+		return config, nil
+	}
+	config.Content = pipeline.Content
+	config.Destinations = destinations
+	config.UpdatedBy = userID
+	if err := s.configRepo.Update(ctx, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// assemble 按顺序获取流水线引用的配置并检查组成
+// 配置必须按输入、过滤、输出排列，且至少包含一个输入和一个输出
+func (s *pipelineService) assemble(ctx context.Context, configIDs []string, permission string) ([]*models.Config, error) {
+	configs := make([]*models.Config, 0, len(configIDs))
+	seen := make(map[string]bool, len(configIDs))
+	last := -1
+	for _, id := range configIDs {
+		if seen[id] {
+			return nil, fmt.Errorf("%w: 配置重复引用: %s", ErrPipelineInvalid, id)
+		}
+		seen[id] = true
+
+		config, err := s.configRepo.GetByID(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("%w: 配置不存在: %s", ErrPipelineInvalid, id)
+		}
+		if err := authorizeConfig(ctx, config, permission); err != nil {
+			return nil, err
+		}
+
+		order, ok := configTypeOrder[config.Type]
+		if !ok {
+			return nil, fmt.Errorf("%w: 配置 %s 的类型 %s 不能用于拼装", ErrPipelineInvalid, config.Name, config.Type)
+		}
+		if order < last {
+			return nil, fmt.Errorf("%w: %s 配置 %s 不能排在 %s 配置之后", ErrPipelineInvalid, config.Type, config.Name, configs[len(configs)-1].Type)
+		}
+		last = order
+		configs = append(configs, config)
+	}
+
+	if len(configs) == 0 || configs[0].Type != models.ConfigTypeInput {
+		return nil, fmt.Errorf("%w: 至少需要一个输入配置", ErrPipelineInvalid)
+	}
+	if configs[len(configs)-1].Type != models.ConfigTypeOutput {
+		return nil, fmt.Errorf("%w: 至少需要一个输出配置", ErrPipelineInvalid)
+	}
+	return configs, nil
+}
+
+// validate 用Logstash校验拼装结果，平台没有可用的Logstash时跳过并返回nil
+func (s *pipelineService) validate(ctx context.Context, content string) (*models.ConfigValidationResult, error) {
+	if s.validator == nil {
+		return nil, nil
+	}
+
+	result, err := s.validator.Validate(ctx, &models.ValidateConfigRequest{Type: models.ConfigTypePipeline, Content: content})
+	if errors.Is(err, ErrValidatorUnavailable) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("校验流水线失败: %w", err)
+	}
+	if !result.Valid {
+		message := "Logstash校验未通过"
+		if len(result.Errors) > 0 {
+			message = result.Errors[0].Message
+			if result.Errors[0].Line > 0 {
+				message = fmt.Sprintf("第%d行: %s", result.Errors[0].Line, message)
+			}
+		}
+		return result, fmt.Errorf("%w: %s", ErrPipelineInvalid, message)
+	}
+	return result, nil
+}
+
+// renderPipeline 按顺序拼接配置内容，每段前标注来源配置及版本
+// Logstash会合并同一文件中的多个input/filter/output块，过滤器按出现顺序执行
+func renderPipeline(name string, configs []*models.Config) (string, []models.PipelineComponent) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# 流水线 %s，由平台拼装生成，请勿直接修改\n", name)
+
+	components := make([]models.PipelineComponent, 0, len(configs))
+	for _, c := range configs {
+		fmt.Fprintf(&b, "\n# ---- %s (%s, 版本 %d) ----\n", c.Name, c.Type, c.Version)
+		b.WriteString(strings.TrimSpace(c.Content))
+		b.WriteString("\n")
+
+		components = append(components, models.PipelineComponent{
+			ConfigID: c.ID,
+			Name:     c.Name,
+			Type:     c.Type,
+			Version:  c.Version,
+		})
+	}
+	return b.String(), components
+}
@@ -0,0 +1,322 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+)
+
+// memPipelineRepository 内存流水线仓库
+type memPipelineRepository struct {
+	mu        sync.Mutex
+	pipelines map[string]*models.Pipeline
+	versions  []*models.PipelineVersion
+}
+
+func (r *memPipelineRepository) Create(ctx context.Context, p *models.Pipeline) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p.ID = fmt.Sprintf("pl-%d", len(r.pipelines)+1)
+	p.Version = 1
+	r.save(p)
+	return nil
+}
+
+func (r *memPipelineRepository) Update(ctx context.Context, p *models.Pipeline) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p.Version = r.pipelines[p.ID].Version + 1
+	r.save(p)
+	return nil
+}
+
+func (r *memPipelineRepository) save(p *models.Pipeline) {
+	copied := *p
+	r.pipelines[p.ID] = &copied
+	r.versions = append(r.versions, &models.PipelineVersion{PipelineID: p.ID, Version: p.Version, ConfigIDs: p.ConfigIDs, Content: p.Content})
+}
+
+func (r *memPipelineRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.pipelines, id)
+	return nil
+}
+
+func (r *memPipelineRepository) GetByID(ctx context.Context, id string) (*models.Pipeline, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.pipelines[id]
+	if !ok {
+		return nil, fmt.Errorf("文档不存在")
+	}
+	copied := *p
+	return &copied, nil
+}
+
+func (r *memPipelineRepository) GetByName(ctx context.Context, name string) (*models.Pipeline, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, p := range r.pipelines {
+		if p.Name == name {
+			copied := *p
+			return &copied, nil
+		}
+	}
+	return nil, fmt.Errorf("文档不存在")
+}
+
+func (r *memPipelineRepository) List(ctx context.Context) ([]*models.Pipeline, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pipelines := make([]*models.Pipeline, 0, len(r.pipelines))
+	for _, p := range r.pipelines {
+		copied := *p
+		pipelines = append(pipelines, &copied)
+	}
+	sort.Slice(pipelines, func(i, j int) bool { return pipelines[i].Name < pipelines[j].Name })
+	return pipelines, nil
+}
+
+func (r *memPipelineRepository) GetVersions(ctx context.Context, pipelineID string) ([]*models.PipelineVersion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var versions []*models.PipelineVersion
+	for i := len(r.versions) - 1; i >= 0; i-- {
+		if r.versions[i].PipelineID == pipelineID {
+			versions = append(versions, r.versions[i])
+		}
+	}
+	return versions, nil
+}
+
+// memConfigRepository 内存配置仓库，更新时版本号加一
+type memConfigRepository struct {
+	mu      sync.Mutex
+	configs map[string]*models.Config
+}
+
+func (r *memConfigRepository) Create(ctx context.Context, c *models.Config) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c.ID == "" {
+		c.ID = fmt.Sprintf("cfg-%d", len(r.configs)+1)
+	}
+	c.Version = 1
+	c.Enabled = true
+	copied := *c
+	r.configs[c.ID] = &copied
+	return nil
+}
+
+func (r *memConfigRepository) Update(ctx context.Context, c *models.Config) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c.Version = r.configs[c.ID].Version + 1
+	copied := *c
+	r.configs[c.ID] = &copied
+	return nil
+}
+
+func (r *memConfigRepository) UpdateTestStatus(ctx context.Context, id string, version int, status models.TestStatus, testedAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.configs[id]
+	if !ok || c.Version != version {
+		return false, nil
+	}
+	c.TestStatus = status
+	c.TestedAt = &testedAt
+	return true, nil
+}
+
+func (r *memConfigRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.configs, id)
+	return nil
+}
+
+func (r *memConfigRepository) GetByID(ctx context.Context, id string) (*models.Config, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.configs[id]
+	if !ok {
+		return nil, fmt.Errorf("文档不存在")
+	}
+	copied := *c
+	return &copied, nil
+}
+
+func (r *memConfigRepository) List(ctx context.Context, req *models.ConfigListRequest) (*models.ConfigListResponse, error) {
+	return &models.ConfigListResponse{Items: []*models.Config{}}, nil
+}
+
+func (r *memConfigRepository) Search(ctx context.Context, req *models.ConfigSearchRequest) (*models.ConfigSearchResponse, error) {
+	return &models.ConfigSearchResponse{Items: []*models.ConfigSearchHit{}}, nil
+}
+
+func (r *memConfigRepository) Stats(ctx context.Context, req *models.ConfigStatsRequest) (*models.ConfigStats, error) {
+	return &models.ConfigStats{}, nil
+}
+
+func (r *memConfigRepository) SaveHistory(ctx context.Context, history *models.ConfigHistory) error {
+	return nil
+}
+
+func (r *memConfigRepository) GetHistory(ctx context.Context, configID string) ([]*models.ConfigHistory, error) {
+	return nil, nil
+}
+
+func (r *memConfigRepository) GetHistoryVersion(ctx context.Context, configID string, version int) (*models.ConfigHistory, error) {
+	return nil, fmt.Errorf("文档不存在")
+}
+
+func newTestPipelineService(t *testing.T) (PipelineService, *memConfigRepository, *stubValidator, *memDeploymentRepository) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	configRepo := &memConfigRepository{configs: map[string]*models.Config{
+		"in":     {ID: "in", Name: "beats", Type: models.ConfigTypeInput, Content: "input { beats { port => 5044 } }", Version: 2, Enabled: true},
+		"grok":   {ID: "grok", Name: "nginx-grok", Type: models.ConfigTypeFilter, Content: "filter { grok {} }\n", Version: 5, Enabled: true},
+		"out":    {ID: "out", Name: "es", Type: models.ConfigTypeOutput, Content: `output { elasticsearch { hosts => ["http://es-logs:9200"] } }`, Version: 1, Enabled: true},
+		"locked": {ID: "locked", Name: "secret", Type: models.ConfigTypeFilter, Content: "filter {}", Version: 1, Enabled: true, ACL: &models.ConfigACL{Readers: []string{"user:bob"}, Editors: []string{"user:bob"}}},
+	}}
+	validator := &stubValidator{}
+	deployRepo := &memDeploymentRepository{deployments: make(map[string]models.Deployment)}
+	engine := NewDeploymentEngine(deployRepo, configRepo, nil, &chanPublisher{sent: make(chan string, 10)}, nil, 50*time.Millisecond, logger)
+
+	pipelineRepo := &memPipelineRepository{pipelines: make(map[string]*models.Pipeline)}
+	return NewPipelineService(pipelineRepo, configRepo, validator, engine, logger), configRepo, validator, deployRepo
+}
+
+func TestPipelineService_CreatePipeline(t *testing.T) {
+	ctx := context.Background()
+	s, _, validator, _ := newTestPipelineService(t)
+
+	pipeline, err := s.CreatePipeline(ctx, &models.CreatePipelineRequest{Name: "nginx", ConfigIDs: []string{"in", "grok", "out"}}, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, 1, pipeline.Version)
+	assert.Equal(t, []models.PipelineComponent{
+		{ConfigID: "in", Name: "beats", Type: models.ConfigTypeInput, Version: 2},
+		{ConfigID: "grok", Name: "nginx-grok", Type: models.ConfigTypeFilter, Version: 5},
+		{ConfigID: "out", Name: "es", Type: models.ConfigTypeOutput, Version: 1},
+	}, pipeline.Components)
+
+	// 各段按引用顺序出现，并标注来源版本
+	in := strings.Index(pipeline.Content, "input { beats")
+	grok := strings.Index(pipeline.Content, "filter { grok {} }\n\n# ---- es (output, 版本 1) ----")
+	out := strings.Index(pipeline.Content, "output { elasticsearch")
+	assert.True(t, in > 0 && grok > in && out > grok, pipeline.Content)
+	assert.Contains(t, pipeline.Content, "# ---- nginx-grok (filter, 版本 5) ----")
+
+	t.Run("名称重复", func(t *testing.T) {
+		_, err := s.CreatePipeline(ctx, &models.CreatePipelineRequest{Name: "nginx", ConfigIDs: []string{"in", "out"}}, "alice")
+		assert.ErrorIs(t, err, ErrPipelineExists)
+	})
+
+	t.Run("组成无效", func(t *testing.T) {
+		cases := map[string][]string{
+			"缺少输出":  {"in", "grok"},
+			"缺少输入":  {"grok", "out"},
+			"顺序错误":  {"in", "out", "grok"},
+			"重复引用":  {"in", "grok", "grok", "out"},
+			"配置不存在": {"in", "missing", "out"},
+		}
+		for name, ids := range cases {
+			_, err := s.CreatePipeline(ctx, &models.CreatePipelineRequest{Name: "bad", ConfigIDs: ids}, "alice")
+			assert.ErrorIs(t, err, ErrPipelineInvalid, name)
+		}
+	})
+
+	t.Run("拼装结果未通过Logstash校验", func(t *testing.T) {
+		content, _ := renderPipeline("broken", []*models.Config{
+			{Name: "beats", Type: models.ConfigTypeInput, Content: "input { beats { port => 5044 } }", Version: 2},
+			{Name: "es", Type: models.ConfigTypeOutput, Content: `output { elasticsearch { hosts => ["http://es-logs:9200"] } }`, Version: 1},
+		})
+		validator.results = map[string]error{content: errors.New("Expected one of [ \\t\\r\\n]")}
+		defer func() { validator.results = nil }()
+
+		_, err := s.CreatePipeline(ctx, &models.CreatePipelineRequest{Name: "broken", ConfigIDs: []string{"in", "out"}}, "alice")
+		assert.ErrorIs(t, err, ErrPipelineInvalid)
+		assert.Contains(t, err.Error(), "第1行")
+	})
+
+	t.Run("引用无权读取的配置", func(t *testing.T) {
+		ctx := models.WithPrincipal(ctx, &models.Principal{User: "alice", Role: models.RoleEditor})
+		_, err := s.CreatePipeline(ctx, &models.CreatePipelineRequest{Name: "locked", ConfigIDs: []string{"in", "locked", "out"}}, "alice")
+		assert.ErrorIs(t, err, ErrConfigForbidden)
+	})
+}
+
+func TestPipelineService_DeployPipeline(t *testing.T) {
+	ctx := context.Background()
+	s, configRepo, _, deployRepo := newTestPipelineService(t)
+
+	pipeline, err := s.CreatePipeline(ctx, &models.CreatePipelineRequest{Name: "nginx", ConfigIDs: []string{"in", "grok", "out"}}, "alice")
+	require.NoError(t, err)
+
+	deployment, err := s.DeployPipeline(ctx, pipeline.ID, &models.DeployPipelineRequest{AgentIDs: []string{"agent-1"}}, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, pipeline.ID, deployment.ConfigID)
+	assert.Equal(t, 1, deployment.ConfigVersion)
+	waitFinished(t, deployRepo, deployment.ID)
+
+	// 拼装结果写入与流水线同ID的配置
+	config, err := configRepo.GetByID(ctx, pipeline.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ConfigTypePipeline, config.Type)
+	assert.Equal(t, pipeline.ID, config.PipelineID)
+	assert.Equal(t, pipeline.Content, config.Content)
+	assert.Equal(t, []string{"elasticsearch:es-logs:9200"}, config.Destinations)
+
+	t.Run("内容未变化时不产生新版本", func(t *testing.T) {
+		deployment, err := s.DeployPipeline(ctx, pipeline.ID, &models.DeployPipelineRequest{AgentIDs: []string{"agent-1"}}, "alice")
+		require.NoError(t, err)
+		assert.Equal(t, 1, deployment.ConfigVersion)
+		waitFinished(t, deployRepo, deployment.ID)
+
+		current, _ := s.GetPipeline(ctx, pipeline.ID)
+		assert.Equal(t, 1, current.Version)
+	})
+
+	t.Run("引用的配置修改后部署新版本", func(t *testing.T) {
+		grok, _ := configRepo.GetByID(ctx, "grok")
+		grok.Content = "filter { grok { match => {} } }"
+		require.NoError(t, configRepo.Update(ctx, grok))
+
+		render, err := s.RenderPipeline(ctx, pipeline.ID)
+		require.NoError(t, err)
+		assert.True(t, render.Changed)
+		assert.Equal(t, 6, render.Components[1].Version)
+
+		deployment, err := s.DeployPipeline(ctx, pipeline.ID, &models.DeployPipelineRequest{AgentIDs: []string{"agent-1"}}, "bob")
+		require.NoError(t, err)
+		assert.Equal(t, 2, deployment.ConfigVersion)
+		waitFinished(t, deployRepo, deployment.ID)
+
+		current, _ := s.GetPipeline(ctx, pipeline.ID)
+		assert.Equal(t, 2, current.Version)
+		assert.Contains(t, current.Content, "grok { match => {} }")
+
+		versions, err := s.GetPipelineVersions(ctx, pipeline.ID)
+		require.NoError(t, err)
+		require.Len(t, versions, 2)
+		assert.Equal(t, 2, versions[0].Version)
+	})
+
+	t.Run("流水线不存在", func(t *testing.T) {
+		_, err := s.DeployPipeline(ctx, "missing", &models.DeployPipelineRequest{AgentIDs: []string{"agent-1"}}, "alice")
+		assert.ErrorIs(t, err, ErrPipelineNotFound)
+	})
+}
@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+)
+
+// builtinPluginCatalog 平台内置的插件目录，整理自常用插件的文档
+//
+//go:embed plugin_catalog.json
+var builtinPluginCatalog []byte
+
+// ErrInvalidPluginCatalog 插件目录文件无法解析或内容不完整
+var ErrInvalidPluginCatalog = errors.New("插件目录无效")
+
+// 插件目录的默认参数
+const (
+	defaultPluginCatalogReloadInterval = 5 * time.Minute
+	builtinPluginCatalogSource         = "builtin"
+)
+
+// pluginSections 插件目录中的区段
+var pluginSections = map[string]bool{"input": true, "filter": true, "output": true, "codec": true}
+
+// PluginCatalogConfig 插件目录参数
+type PluginCatalogConfig struct {
+	File           string        // 替代内置目录的文件，为空时使用内置目录
+	ReloadInterval time.Duration // 检查文件是否更新的间隔，默认5分钟
+}
+
+// PluginCatalogService 提供插件及其选项的目录，供配置编辑器自动补全和静态检查未知选项
+// 配置了目录文件时以文件替代内置目录，文件更新后按间隔自动重新加载，也可以手动刷新
+type PluginCatalogService struct {
+	file     string
+	interval time.Duration
+	logger   *logrus.Logger
+	now      func() time.Time
+	tracker  loopTracker
+
+	mu      sync.RWMutex
+	catalog *models.PluginCatalog
+	options map[string]map[string]bool // 区段/插件名 到已知选项（包括区段共有的选项）
+	modTime time.Time                  // 已加载的目录文件的修改时间
+}
+
+// NewPluginCatalogService 创建插件目录服务，目录文件无法加载时记录错误并使用内置目录
+func NewPluginCatalogService(cfg PluginCatalogConfig, logger *logrus.Logger) *PluginCatalogService {
+	if cfg.ReloadInterval <= 0 {
+		cfg.ReloadInterval = defaultPluginCatalogReloadInterval
+	}
+	s := &PluginCatalogService{
+		file:     cfg.File,
+		interval: cfg.ReloadInterval,
+		logger:   logger,
+		now:      time.Now,
+		tracker:  loopTracker{interval: cfg.ReloadInterval},
+	}
+	if err := s.loadBuiltin(); err != nil {
+		// 内置目录由测试保证有效，这里只记录错误并以空目录运行
+		logger.Errorf("加载内置插件目录失败: %v", err)
+		s.catalog = &models.PluginCatalog{Source: builtinPluginCatalogSource, Common: map[string][]models.PluginOption{}, Plugins: []models.PluginSchema{}}
+	}
+	if s.file != "" {
+		if _, err := s.Refresh(context.Background()); err != nil {
+			logger.Errorf("加载插件目录文件失败，使用内置目录: %v", err)
+		}
+	}
+	return s
+}
+
+// Catalog 按区段和插件名筛选目录，插件的选项与服务共享，调用方不能修改
+func (s *PluginCatalogService) Catalog(req *models.PluginCatalogRequest) *models.PluginCatalog {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	catalog := *s.catalog
+	catalog.Common = make(map[string][]models.PluginOption, len(s.catalog.Common))
+	for section, options := range s.catalog.Common {
+		if req.Section == "" || req.Section == section {
+			catalog.Common[section] = options
+		}
+	}
+	catalog.Plugins = make([]models.PluginSchema, 0, len(s.catalog.Plugins))
+	for _, plugin := range s.catalog.Plugins {
+		if (req.Section == "" || req.Section == plugin.Section) && (req.Name == "" || req.Name == plugin.Name) {
+			catalog.Plugins = append(catalog.Plugins, plugin)
+		}
+	}
+	return &catalog
+}
+
+// Refresh 重新加载目录文件，未配置文件时重新加载内置目录；加载失败时保留当前目录
+func (s *PluginCatalogService) Refresh(ctx context.Context) (*models.PluginCatalog, error) {
+	if s.file == "" {
+		if err := s.loadBuiltin(); err != nil {
+			return nil, err
+		}
+		return s.Catalog(&models.PluginCatalogRequest{}), nil
+	}
+
+	info, err := os.Stat(s.file)
+	if err != nil {
+		return nil, fmt.Errorf("读取插件目录文件失败: %w", err)
+	}
+	data, err := os.ReadFile(s.file)
+	if err != nil {
+		return nil, fmt.Errorf("读取插件目录文件失败: %w", err)
+	}
+	catalog, options, err := parsePluginCatalog(data, s.file, s.now())
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.catalog, s.options, s.modTime = catalog, options, info.ModTime()
+	s.mu.Unlock()
+
+	s.logger.WithFields(logrus.Fields{
+		"file":    s.file,
+		"version": catalog.Version,
+		"plugins": len(catalog.Plugins),
+	}).Info("加载插件目录")
+	return s.Catalog(&models.PluginCatalogRequest{}), nil
+}
+
+// Start 按间隔检查目录文件，文件更新后重新加载，直到ctx取消
+// 每个副本的目录各自加载，都需要运行；未配置目录文件时立即返回
+func (s *PluginCatalogService) Start(ctx context.Context) {
+	if s.file == "" {
+		return
+	}
+	defer s.tracker.start(s.now())()
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		done := s.tracker.begin(s.now())
+		err := s.reloadIfChanged(ctx)
+		done(err)
+		if err != nil {
+			s.logger.Errorf("重新加载插件目录失败，继续使用当前目录: %v", err)
+		}
+	}
+}
+
+// WorkerStatus 报告插件目录重新加载的运行状态
+func (s *PluginCatalogService) WorkerStatus(now time.Time) models.WorkerStatus {
+	return s.tracker.status("plugin_catalog_reload", now)
+}
+
+// reloadIfChanged 目录文件的修改时间变化时重新加载
+func (s *PluginCatalogService) reloadIfChanged(ctx context.Context) error {
+	info, err := os.Stat(s.file)
+	if err != nil {
+		return fmt.Errorf("读取插件目录文件失败: %w", err)
+	}
+	s.mu.RLock()
+	unchanged := info.ModTime().Equal(s.modTime)
+	s.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+	_, err = s.Refresh(ctx)
+	return err
+}
+
+// knownOptions 插件的已知选项，目录中没有该插件时返回false
+func (s *PluginCatalogService) knownOptions(section, name string) (map[string]bool, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	options, ok := s.options[section+"/"+name]
+	return options, ok
+}
+
+// loadBuiltin 加载内置目录
+func (s *PluginCatalogService) loadBuiltin() error {
+	catalog, options, err := parsePluginCatalog(builtinPluginCatalog, builtinPluginCatalogSource, s.now())
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.catalog, s.options = catalog, options
+	s.mu.Unlock()
+	return nil
+}
+
+// parsePluginCatalog 解析并校验目录，插件按区段和名称排序
+func parsePluginCatalog(data []byte, source string, now time.Time) (*models.PluginCatalog, map[string]map[string]bool, error) {
+	var catalog models.PluginCatalog
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidPluginCatalog, err)
+	}
+	if catalog.Version == "" {
+		return nil, nil, fmt.Errorf("%w: 缺少version", ErrInvalidPluginCatalog)
+	}
+	if catalog.Common == nil {
+		catalog.Common = map[string][]models.PluginOption{}
+	}
+	for section, options := range catalog.Common {
+		if !pluginSections[section] {
+			return nil, nil, fmt.Errorf("%w: 共有选项的区段 %q 无效", ErrInvalidPluginCatalog, section)
+		}
+		if err := checkPluginOptions(options); err != nil {
+			return nil, nil, fmt.Errorf("%w: %s区段的共有选项%v", ErrInvalidPluginCatalog, section, err)
+		}
+	}
+
+	known := make(map[string]map[string]bool, len(catalog.Plugins))
+	for _, plugin := range catalog.Plugins {
+		key := plugin.Section + "/" + plugin.Name
+		if !pluginSections[plugin.Section] || plugin.Name == "" {
+			return nil, nil, fmt.Errorf("%w: 插件 %q 的区段或名称无效", ErrInvalidPluginCatalog, key)
+		}
+		if known[key] != nil {
+			return nil, nil, fmt.Errorf("%w: 插件 %s 重复", ErrInvalidPluginCatalog, key)
+		}
+		if err := checkPluginOptions(plugin.Options); err != nil {
+			return nil, nil, fmt.Errorf("%w: 插件 %s 的选项%v", ErrInvalidPluginCatalog, key, err)
+		}
+		options := make(map[string]bool, len(plugin.Options)+len(catalog.Common[plugin.Section]))
+		for _, option := range catalog.Common[plugin.Section] {
+			options[option.Name] = true
+		}
+		for _, option := range plugin.Options {
+			options[option.Name] = true
+		}
+		known[key] = options
+	}
+
+	sort.SliceStable(catalog.Plugins, func(i, j int) bool {
+		if catalog.Plugins[i].Section != catalog.Plugins[j].Section {
+			return catalog.Plugins[i].Section < catalog.Plugins[j].Section
+		}
+		return catalog.Plugins[i].Name < catalog.Plugins[j].Name
+	})
+	catalog.Source = source
+	catalog.LoadedAt = now
+	return &catalog, known, nil
+}
+
+// checkPluginOptions 选项名不能为空或重复
+func checkPluginOptions(options []models.PluginOption) error {
+	seen := make(map[string]bool, len(options))
+	for _, option := range options {
+		if option.Name == "" {
+			return errors.New("名称为空")
+		}
+		if seen[option.Name] {
+			return fmt.Errorf(" %s 重复", option.Name)
+		}
+		seen[option.Name] = true
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+)
+
+func TestPluginCatalogService_Builtin(t *testing.T) {
+	_, known, err := parsePluginCatalog(builtinPluginCatalog, builtinPluginCatalogSource, time.Now())
+	require.NoError(t, err, "内置目录必须有效")
+
+	// 已弃用的选项由deprecated_option规则提示，不应再被当作未知选项
+	for plugin, options := range deprecatedOptions {
+		if !assert.NotNil(t, known[plugin], "内置目录缺少插件 %s", plugin) {
+			continue
+		}
+		for option, replacement := range options {
+			assert.True(t, known[plugin][option], "%s 缺少已弃用的选项 %s", plugin, option)
+			if replacement != "" {
+				assert.True(t, known[plugin][replacement], "%s 缺少替代选项 %s", plugin, replacement)
+			}
+		}
+	}
+
+	s := NewPluginCatalogService(PluginCatalogConfig{}, logrus.New())
+	catalog := s.Catalog(&models.PluginCatalogRequest{Section: "filter", Name: "grok"})
+	assert.Equal(t, builtinPluginCatalogSource, catalog.Source)
+	require.Len(t, catalog.Plugins, 1)
+	assert.Equal(t, "grok", catalog.Plugins[0].Name)
+	assert.Equal(t, []string{"filter"}, keysOf(catalog.Common))
+
+	all := s.Catalog(&models.PluginCatalogRequest{})
+	assert.Greater(t, len(all.Plugins), 20)
+	assert.Len(t, all.Common, 4)
+}
+
+func TestPluginCatalogService_Refresh(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel)
+	file := filepath.Join(t.TempDir(), "plugins.json")
+
+	// 文件不存在时使用内置目录
+	s := NewPluginCatalogService(PluginCatalogConfig{File: file}, logger)
+	assert.Equal(t, builtinPluginCatalogSource, s.Catalog(&models.PluginCatalogRequest{}).Source)
+
+	require.NoError(t, os.WriteFile(file, []byte(`{"version": "custom-1", "common": {"filter": [{"name": "id", "type": "string"}]},
+		"plugins": [{"section": "filter", "name": "acme", "options": [{"name": "level", "type": "string"}]}]}`), 0600))
+	catalog, err := s.Refresh(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "custom-1", catalog.Version)
+	assert.Equal(t, file, catalog.Source)
+	known, ok := s.knownOptions("filter", "acme")
+	require.True(t, ok)
+	assert.Equal(t, map[string]bool{"id": true, "level": true}, known)
+	_, ok = s.knownOptions("filter", "grok")
+	assert.False(t, ok, "文件替代内置目录")
+
+	// 文件无效时保留当前目录
+	require.NoError(t, os.WriteFile(file, []byte(`{"version": "custom-2", "plugins": [{"section": "parser", "name": "x"}]}`), 0600))
+	_, err = s.Refresh(context.Background())
+	assert.ErrorIs(t, err, ErrInvalidPluginCatalog)
+	assert.Equal(t, "custom-1", s.Catalog(&models.PluginCatalogRequest{}).Version)
+
+	// 文件更新后自动重新加载
+	require.NoError(t, os.WriteFile(file, []byte(`{"version": "custom-3", "plugins": []}`), 0600))
+	require.NoError(t, os.Chtimes(file, time.Now(), time.Now().Add(time.Minute)))
+	require.NoError(t, s.reloadIfChanged(context.Background()))
+	assert.Equal(t, "custom-3", s.Catalog(&models.PluginCatalogRequest{}).Version)
+}
+
+func TestLintContent_UnknownOption(t *testing.T) {
+	catalog := NewPluginCatalogService(PluginCatalogConfig{}, logrus.New())
+	content := `filter {
+  grok { mach => { "message" => "^%{IP:client}" } id => "grok" }
+  acme { anything => true }
+}
+output {
+  elasticsearch { hosts => ["http://es:9200"] document_type => "_doc" }
+}
+`
+	result := lintContent(content, effectiveLintRules(nil), lintEnv{catalog: catalog})
+	var unknown []models.LintIssue
+	for _, issue := range result.Issues {
+		if issue.Rule == models.LintRuleUnknownOption {
+			unknown = append(unknown, issue)
+		}
+	}
+	require.Len(t, unknown, 1, "目录中没有的插件和已弃用的选项不提示")
+	assert.Equal(t, "filter/grok", unknown[0].Plugin)
+	assert.Equal(t, 2, unknown[0].Line)
+	assert.Equal(t, "filter插件 grok 没有选项 mach，是否为 match", unknown[0].Message)
+
+	result = lintContent(content, effectiveLintRules(nil), lintEnv{})
+	for _, issue := range result.Issues {
+		assert.NotEqual(t, models.LintRuleUnknownOption, issue.Rule, "没有目录时不检查")
+	}
+	result = lintContent(lintSample, effectiveLintRules(nil), lintEnv{catalog: catalog})
+	for _, issue := range result.Issues {
+		assert.NotEqual(t, models.LintRuleUnknownOption, issue.Rule, issue.Message)
+	}
+}
+
+func keysOf(m map[string][]models.PluginOption) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
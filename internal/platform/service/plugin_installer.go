@@ -0,0 +1,308 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// 插件安装相关错误，处理器通过 errors.Is 映射为HTTP状态码
+var (
+	ErrPluginInstallNotFound = errors.New("插件安装记录不存在")
+	ErrPluginInstallRunning  = errors.New("Agent上有尚未结束的插件安装")
+	ErrInvalidPluginInstall  = errors.New("插件安装请求无效")
+)
+
+// defaultPluginInstallTimeout 默认等待Agent完成安装的时长，在线安装需要解析依赖并下载gem
+const defaultPluginInstallTimeout = 15 * time.Minute
+
+// PluginInstaller 经Agent安装或更新Logstash插件
+// 请求只经WebSocket下发，Agent报告的进度和结果写入安装记录，安装成功后以Agent重新采集的清单更新插件清单；
+// 同一Agent同时只允许一个安装，Agent在超时前未报告结果时判定安装失败
+type PluginInstaller struct {
+	repo      repository.PluginInstallRepository
+	channel   LiveChannel
+	inventory PluginInventoryService
+	timeout   time.Duration
+	logger    *logrus.Logger
+
+	// 本副本下发、尚未结束的安装，按Agent ID
+	mu      sync.Mutex
+	running map[string]string
+}
+
+// NewPluginInstaller 创建插件安装，timeout为0时使用默认值
+func NewPluginInstaller(repo repository.PluginInstallRepository, channel LiveChannel, inventory PluginInventoryService, timeout time.Duration, logger *logrus.Logger) *PluginInstaller {
+	if timeout <= 0 {
+		timeout = defaultPluginInstallTimeout
+	}
+	return &PluginInstaller{
+		repo:      repo,
+		channel:   channel,
+		inventory: inventory,
+		timeout:   timeout,
+		logger:    logger,
+		running:   make(map[string]string),
+	}
+}
+
+// Start 记录并下发安装请求，立即返回pending状态的安装记录，之后以Get查询进度
+func (p *PluginInstaller) Start(ctx context.Context, agentID string, req *models.PluginInstallRequest, requestedBy string) (*models.PluginInstall, error) {
+	if err := validatePluginInstall(req); err != nil {
+		return nil, err
+	}
+	if !p.channel.IsConnected(agentID) {
+		return nil, fmt.Errorf("%w: %s", ErrAgentOffline, agentID)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if id, ok := p.running[agentID]; ok {
+		// 结果可能由Agent连接的其他副本记录
+		running, err := p.repo.GetByID(elasticsearch.WithPrimaryRead(ctx), id)
+		if err != nil {
+			return nil, err
+		}
+		if running != nil && !running.IsFinished() {
+			return nil, fmt.Errorf("%w: %s", ErrPluginInstallRunning, id)
+		}
+		delete(p.running, agentID)
+	}
+	// 其他副本下发的安装只能从存储中得知
+	recent, err := p.repo.ListByAgent(ctx, agentID, 10)
+	if err != nil {
+		return nil, err
+	}
+	for _, install := range recent {
+		if !install.IsFinished() {
+			return nil, fmt.Errorf("%w: %s", ErrPluginInstallRunning, install.ID)
+		}
+	}
+
+	now := time.Now()
+	install := &models.PluginInstall{
+		ID:          uuid.New().String(),
+		AgentID:     agentID,
+		Name:        req.Name,
+		Version:     req.Version,
+		BundleURL:   req.BundleURL,
+		Restart:     req.Restart,
+		Status:      models.PluginInstallPending,
+		RequestedBy: requestedBy,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := p.repo.Save(ctx, install); err != nil {
+		return nil, err
+	}
+
+	payload := models.PluginInstallPayload{InstallID: install.ID, PluginInstallRequest: *req}
+	if err := p.channel.Publish(agentID, models.MsgTypePluginInstall, payload); err != nil {
+		if saveErr := p.finish(ctx, install, models.PluginInstallFailed, "下发插件安装请求失败: "+err.Error()); saveErr != nil {
+			p.logger.WithError(saveErr).WithField("install_id", install.ID).Warn("保存下发失败的插件安装失败")
+		}
+		return nil, fmt.Errorf("下发插件安装请求失败: %w", err)
+	}
+
+	p.running[agentID] = install.ID
+	installID := install.ID
+	time.AfterFunc(p.timeout, func() {
+		p.expire(context.Background(), installID)
+	})
+
+	p.logger.WithFields(logrus.Fields{
+		"agent_id":   agentID,
+		"install_id": install.ID,
+		"plugin":     req.Name,
+		"version":    req.Version,
+		"offline":    req.BundleURL != "",
+	}).Info("下发插件安装请求")
+	return install, nil
+}
+
+// Get 获取Agent的安装记录
+func (p *PluginInstaller) Get(ctx context.Context, agentID, installID string) (*models.PluginInstall, error) {
+	install, err := p.repo.GetByID(ctx, installID)
+	if err != nil {
+		return nil, err
+	}
+	if install == nil || install.AgentID != agentID {
+		return nil, fmt.Errorf("%w: %s", ErrPluginInstallNotFound, installID)
+	}
+	return install, nil
+}
+
+// List 获取Agent最近的安装记录
+func (p *PluginInstaller) List(ctx context.Context, agentID string, size int) ([]*models.PluginInstall, error) {
+	return p.repo.ListByAgent(ctx, agentID, size)
+}
+
+// Progress 记录Agent报告的安装阶段，安装已结束或不属于该Agent时忽略
+func (p *PluginInstaller) Progress(ctx context.Context, agentID string, progress *models.PluginInstallProgress) error {
+	install, err := p.load(ctx, agentID, progress.InstallID)
+	if err != nil || install == nil {
+		return err
+	}
+	install.Status = models.PluginInstallRunning
+	install.Phase = progress.Phase
+	install.UpdatedAt = time.Now()
+	if err := p.repo.Save(ctx, install); err != nil {
+		return err
+	}
+	p.logger.WithFields(logrus.Fields{
+		"agent_id":   agentID,
+		"install_id": install.ID,
+		"phase":      progress.Phase,
+	}).Debug("插件安装进度")
+	return nil
+}
+
+// Complete 记录Agent报告的安装结果，结果带有Agent重新采集的插件清单时更新Agent的插件清单
+func (p *PluginInstaller) Complete(ctx context.Context, agentID string, result *models.PluginInstallResult) error {
+	install, err := p.load(ctx, agentID, result.InstallID)
+	if err != nil || install == nil {
+		return err
+	}
+
+	install.Output = result.Output
+	install.Truncated = result.Truncated
+	install.InstalledVersion = result.InstalledVersion
+	status, reason := models.PluginInstallSucceeded, ""
+	if !result.Success {
+		status, reason = models.PluginInstallFailed, result.Error
+		if reason == "" {
+			reason = "Agent未说明失败原因"
+		}
+	}
+	p.release(install)
+	if err := p.finish(ctx, install, status, reason); err != nil {
+		return err
+	}
+
+	// 安装后重启失败时插件已安装，同样以Agent重新采集的清单为准
+	if len(result.Plugins) > 0 {
+		report := &models.PluginInventoryReport{Plugins: result.Plugins, CollectedAt: install.UpdatedAt}
+		if err := p.inventory.Report(ctx, agentID, report); err != nil {
+			p.logger.WithError(err).WithField("agent_id", agentID).Warn("安装插件后更新插件清单失败")
+		}
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"agent_id":          agentID,
+		"install_id":        install.ID,
+		"plugin":            install.Name,
+		"status":            status,
+		"installed_version": result.InstalledVersion,
+		"duration_ms":       result.DurationMs,
+	}).Info("插件安装结束")
+	return nil
+}
+
+// Recover 平台重启或接管后处理遗留的未结束安装，剩余等待时间后仍未结束的判定失败
+func (p *PluginInstaller) Recover(ctx context.Context) error {
+	stale, err := p.repo.ListUnfinished(ctx)
+	if err != nil {
+		return fmt.Errorf("查询未结束的插件安装失败: %w", err)
+	}
+	for _, install := range stale {
+		remaining := time.Until(install.CreatedAt.Add(p.timeout))
+		if remaining <= 0 {
+			p.expire(ctx, install.ID)
+			continue
+		}
+		installID := install.ID
+		time.AfterFunc(remaining, func() {
+			p.expire(context.Background(), installID)
+		})
+	}
+	return nil
+}
+
+// load 读取属于该Agent且尚未结束的安装记录，不满足时返回nil
+func (p *PluginInstaller) load(ctx context.Context, agentID, installID string) (*models.PluginInstall, error) {
+	install, err := p.repo.GetByID(elasticsearch.WithPrimaryRead(ctx), installID)
+	if err != nil {
+		return nil, err
+	}
+	if install == nil || install.AgentID != agentID || install.IsFinished() {
+		p.logger.WithFields(logrus.Fields{
+			"agent_id":   agentID,
+			"install_id": installID,
+		}).Debug("忽略不属于进行中安装的插件安装上报")
+		return nil, nil
+	}
+	return install, nil
+}
+
+// expire 等待超时后仍未结束的安装判定失败
+func (p *PluginInstaller) expire(ctx context.Context, installID string) {
+	install, err := p.repo.GetByID(elasticsearch.WithPrimaryRead(ctx), installID)
+	if err != nil {
+		p.logger.WithError(err).WithField("install_id", installID).Warn("读取待过期的插件安装失败")
+		return
+	}
+	p.release(install)
+	if install == nil || install.IsFinished() {
+		return
+	}
+	if err := p.finish(ctx, install, models.PluginInstallFailed, "等待Agent报告安装结果超时"); err != nil {
+		p.logger.WithError(err).WithField("install_id", installID).Warn("保存超时的插件安装失败")
+	}
+}
+
+// finish 结束安装并保存
+func (p *PluginInstaller) finish(ctx context.Context, install *models.PluginInstall, status, reason string) error {
+	now := time.Now()
+	install.Status = status
+	install.Error = reason
+	install.UpdatedAt = now
+	install.FinishedAt = &now
+	return p.repo.Save(ctx, install)
+}
+
+// release 释放本副本记录的安装名额
+func (p *PluginInstaller) release(install *models.PluginInstall) {
+	if install == nil {
+		return
+	}
+	p.mu.Lock()
+	if p.running[install.AgentID] == install.ID {
+		delete(p.running, install.AgentID)
+	}
+	p.mu.Unlock()
+}
+
+// validatePluginInstall 校验插件名、版本和离线包地址，这些参数会成为Agent上logstash-plugin的命令行参数
+func validatePluginInstall(req *models.PluginInstallRequest) error {
+	if !models.ValidPluginName(req.Name) {
+		return fmt.Errorf("%w: 插件名应为 logstash-<input|filter|output|codec|integration>-<名称>", ErrInvalidPluginInstall)
+	}
+	if !models.ValidPluginVersion(req.Version) {
+		return fmt.Errorf("%w: 版本号格式无效", ErrInvalidPluginInstall)
+	}
+	if req.BundleURL != "" {
+		if req.Version != "" {
+			return fmt.Errorf("%w: 从离线包安装时不能指定版本，版本由离线包决定", ErrInvalidPluginInstall)
+		}
+		u, err := url.Parse(req.BundleURL)
+		if err != nil {
+			return fmt.Errorf("%w: 离线包地址无效", ErrInvalidPluginInstall)
+		}
+		switch strings.ToLower(u.Scheme) {
+		case "http", "https", "file":
+		default:
+			return fmt.Errorf("%w: 离线包地址只支持http、https和file", ErrInvalidPluginInstall)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+)
+
+// memoryPluginInstallRepo 内存中的插件安装记录存储，超时回调在其他goroutine中读写
+type memoryPluginInstallRepo struct {
+	mu       sync.Mutex
+	installs map[string]models.PluginInstall
+}
+
+func (r *memoryPluginInstallRepo) Save(ctx context.Context, install *models.PluginInstall) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.installs[install.ID] = *install
+	return nil
+}
+
+func (r *memoryPluginInstallRepo) GetByID(ctx context.Context, id string) (*models.PluginInstall, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	install, ok := r.installs[id]
+	if !ok {
+		return nil, nil
+	}
+	return &install, nil
+}
+
+func (r *memoryPluginInstallRepo) ListByAgent(ctx context.Context, agentID string, size int) ([]*models.PluginInstall, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var installs []*models.PluginInstall
+	for _, install := range r.installs {
+		if install.AgentID == agentID {
+			install := install
+			installs = append(installs, &install)
+		}
+	}
+	return installs, nil
+}
+
+func (r *memoryPluginInstallRepo) ListUnfinished(ctx context.Context) ([]*models.PluginInstall, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var installs []*models.PluginInstall
+	for _, install := range r.installs {
+		if !install.IsFinished() {
+			install := install
+			installs = append(installs, &install)
+		}
+	}
+	return installs, nil
+}
+
+func newTestPluginInstaller(timeout time.Duration) (*PluginInstaller, *liveChannel, *memoryPluginInstallRepo, *memoryPluginInventoryRepo) {
+	channel := &liveChannel{connected: true}
+	repo := &memoryPluginInstallRepo{installs: make(map[string]models.PluginInstall)}
+	inventoryRepo := &memoryPluginInventoryRepo{inventories: make(map[string]*models.PluginInventory)}
+	inventory := NewPluginInventoryService(inventoryRepo, logrus.New())
+	return NewPluginInstaller(repo, channel, inventory, timeout, logrus.New()), channel, repo, inventoryRepo
+}
+
+func TestPluginInstaller_Lifecycle(t *testing.T) {
+	installer, channel, _, inventoryRepo := newTestPluginInstaller(time.Minute)
+	ctx := context.Background()
+	req := &models.PluginInstallRequest{Name: "logstash-filter-translate", Version: "3.4.2", Restart: true}
+
+	install, err := installer.Start(ctx, "agent-1", req, "admin")
+	require.NoError(t, err)
+	assert.Equal(t, models.PluginInstallPending, install.Status)
+	require.Len(t, channel.sent, 1)
+	assert.Equal(t, models.MsgTypePluginInstall, channel.sent[0].msgType)
+	payload := channel.sent[0].payload.(models.PluginInstallPayload)
+	assert.Equal(t, install.ID, payload.InstallID)
+	assert.Equal(t, *req, payload.PluginInstallRequest)
+
+	// 同一Agent同时只允许一个安装
+	_, err = installer.Start(ctx, "agent-1", req, "admin")
+	assert.True(t, errors.Is(err, ErrPluginInstallRunning))
+
+	// 其他Agent的上报忽略
+	require.NoError(t, installer.Progress(ctx, "agent-2", &models.PluginInstallProgress{InstallID: install.ID, Phase: models.PluginInstallPhaseInstalling}))
+	got, err := installer.Get(ctx, "agent-1", install.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.PluginInstallPending, got.Status)
+
+	require.NoError(t, installer.Progress(ctx, "agent-1", &models.PluginInstallProgress{InstallID: install.ID, Phase: models.PluginInstallPhaseInstalling}))
+	got, _ = installer.Get(ctx, "agent-1", install.ID)
+	assert.Equal(t, models.PluginInstallRunning, got.Status)
+	assert.Equal(t, models.PluginInstallPhaseInstalling, got.Phase)
+
+	plugins := []models.LogstashPlugin{{Name: "logstash-filter-grok", Version: "4.4.3"}, {Name: "logstash-filter-translate", Version: "3.4.2"}}
+	require.NoError(t, installer.Complete(ctx, "agent-1", &models.PluginInstallResult{
+		InstallID:        install.ID,
+		Success:          true,
+		Output:           "Installation successful",
+		InstalledVersion: "3.4.2",
+		Plugins:          plugins,
+	}))
+	got, _ = installer.Get(ctx, "agent-1", install.ID)
+	assert.Equal(t, models.PluginInstallSucceeded, got.Status)
+	assert.Equal(t, "3.4.2", got.InstalledVersion)
+	assert.Equal(t, "Installation successful", got.Output)
+	require.NotNil(t, got.FinishedAt)
+	require.NotNil(t, inventoryRepo.inventories["agent-1"], "安装成功后更新插件清单")
+	assert.True(t, inventoryRepo.inventories["agent-1"].Has("logstash-filter-translate"))
+
+	// 结束后的重复上报忽略，可以开始下一个安装
+	require.NoError(t, installer.Complete(ctx, "agent-1", &models.PluginInstallResult{InstallID: install.ID, Error: "late"}))
+	got, _ = installer.Get(ctx, "agent-1", install.ID)
+	assert.Equal(t, models.PluginInstallSucceeded, got.Status)
+	_, err = installer.Start(ctx, "agent-1", &models.PluginInstallRequest{Name: "logstash-output-s3"}, "admin")
+	assert.NoError(t, err)
+
+	_, err = installer.Get(ctx, "agent-2", install.ID)
+	assert.True(t, errors.Is(err, ErrPluginInstallNotFound), "不属于该Agent的安装记录")
+}
+
+func TestPluginInstaller_StartRejects(t *testing.T) {
+	installer, channel, _, _ := newTestPluginInstaller(time.Minute)
+	ctx := context.Background()
+
+	for _, req := range []*models.PluginInstallRequest{
+		{Name: "grok"},
+		{Name: "logstash-filter-grok; rm -rf /"},
+		{Name: "logstash-filter-grok", Version: "--local"},
+		{Name: "logstash-filter-grok", BundleURL: "ftp://repo/bundle.zip"},
+		{Name: "logstash-filter-grok", Version: "4.4.3", BundleURL: "https://repo/bundle.zip"},
+	} {
+		_, err := installer.Start(ctx, "agent-1", req, "admin")
+		assert.True(t, errors.Is(err, ErrInvalidPluginInstall), req)
+	}
+
+	channel.connected = false
+	_, err := installer.Start(ctx, "agent-1", &models.PluginInstallRequest{Name: "logstash-filter-grok"}, "admin")
+	assert.True(t, errors.Is(err, ErrAgentOffline))
+	assert.Empty(t, channel.sent)
+}
+
+func TestPluginInstaller_Timeout(t *testing.T) {
+	installer, _, _, _ := newTestPluginInstaller(30 * time.Millisecond)
+	ctx := context.Background()
+
+	install, err := installer.Start(ctx, "agent-1", &models.PluginInstallRequest{Name: "logstash-filter-grok", BundleURL: "file:///opt/bundle.zip"}, "admin")
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		got, _ := installer.Get(ctx, "agent-1", install.ID)
+		return got.Status == models.PluginInstallFailed
+	}, time.Second, 5*time.Millisecond)
+	got, _ := installer.Get(ctx, "agent-1", install.ID)
+	assert.Contains(t, got.Error, "超时")
+
+	_, err = installer.Start(ctx, "agent-1", &models.PluginInstallRequest{Name: "logstash-filter-grok"}, "admin")
+	assert.NoError(t, err, "超时后释放安装名额")
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+)
+
+// 插件清单相关错误，处理器通过 errors.Is 映射为HTTP状态码
+var (
+	ErrPluginInventoryNotFound = errors.New("Agent尚未上报插件清单")
+	ErrPluginsMissing          = errors.New("目标Agent缺少配置引用的插件")
+)
+
+// corePlugins Logstash核心自带、不以插件gem安装的插件，logstash-plugin list 不会列出
+var corePlugins = map[string]bool{
+	"logstash-input-pipeline":       true,
+	"logstash-output-pipeline":      true,
+	"logstash-input-java_generator": true,
+	"logstash-input-java_stdin":     true,
+	"logstash-output-java_stdout":   true,
+	"logstash-output-sink":          true,
+	"logstash-filter-java_uuid":     true,
+	"logstash-codec-java_line":      true,
+	"logstash-codec-java_plain":     true,
+}
+
+// PluginCompatibilityError 部署前检查发现目标Agent缺少插件，Checks只包含缺少插件的Agent
+type PluginCompatibilityError struct {
+	ConfigID string
+	Checks   []models.AgentPluginCheck
+}
+
+// Error 逐个Agent列出缺少的插件
+func (e *PluginCompatibilityError) Error() string {
+	parts := make([]string, 0, len(e.Checks))
+	for _, check := range e.Checks {
+		parts = append(parts, fmt.Sprintf("%s 缺少 %s", check.AgentID, strings.Join(check.Missing, "、")))
+	}
+	return fmt.Sprintf("%s: %s", ErrPluginsMissing, strings.Join(parts, "；"))
+}
+
+// Unwrap 便于调用方以 errors.Is(err, ErrPluginsMissing) 判断
+func (e *PluginCompatibilityError) Unwrap() error {
+	return ErrPluginsMissing
+}
+
+// PluginInventoryService Agent插件清单服务接口
+type PluginInventoryService interface {
+	// Report 保存Agent上报的插件清单，覆盖之前的清单
+	Report(ctx context.Context, agentID string, report *models.PluginInventoryReport) error
+	// Get 获取Agent的插件清单，未上报过时返回ErrPluginInventoryNotFound
+	Get(ctx context.Context, agentID string) (*models.PluginInventory, error)
+	// Check 检查各Agent是否安装了配置引用的全部插件，未上报清单的Agent标记为Unknown
+	Check(ctx context.Context, config *models.Config, agentIDs []string) ([]models.AgentPluginCheck, error)
+	// CheckDeployable 部署前检查，有Agent缺少插件时返回*PluginCompatibilityError
+	CheckDeployable(ctx context.Context, config *models.Config, agentIDs []string) error
+}
+
+// pluginInventoryService Agent插件清单服务实现
+type pluginInventoryService struct {
+	repo   repository.PluginInventoryRepository
+	logger *logrus.Logger
+}
+
+// NewPluginInventoryService 创建Agent插件清单服务
+func NewPluginInventoryService(repo repository.PluginInventoryRepository, logger *logrus.Logger) PluginInventoryService {
+	return &pluginInventoryService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Report 保存Agent上报的插件清单
+func (s *pluginInventoryService) Report(ctx context.Context, agentID string, report *models.PluginInventoryReport) error {
+	inventory := &models.PluginInventory{
+		AgentID:     agentID,
+		Plugins:     append([]models.LogstashPlugin(nil), report.Plugins...),
+		CollectedAt: report.CollectedAt,
+		ReportedAt:  time.Now(),
+	}
+	if inventory.CollectedAt.IsZero() {
+		inventory.CollectedAt = inventory.ReportedAt
+	}
+	sort.Slice(inventory.Plugins, func(i, j int) bool { return inventory.Plugins[i].Name < inventory.Plugins[j].Name })
+
+	if err := s.repo.Save(ctx, inventory); err != nil {
+		return err
+	}
+	s.logger.WithFields(logrus.Fields{
+		"agent_id": agentID,
+		"plugins":  len(inventory.Plugins),
+	}).Info("更新Agent插件清单")
+	return nil
+}
+
+// Get 获取Agent的插件清单
+func (s *pluginInventoryService) Get(ctx context.Context, agentID string) (*models.PluginInventory, error) {
+	inventory, err := s.repo.Get(ctx, agentID)
+	if err != nil {
+		return nil, err
+	}
+	if inventory == nil {
+		return nil, fmt.Errorf("%w: %s", ErrPluginInventoryNotFound, agentID)
+	}
+	return inventory, nil
+}
+
+// Check 对照各Agent的插件清单检查配置引用的插件
+// 配置内容无法解析时不检查，语法问题由配置校验和静态检查报告
+func (s *pluginInventoryService) Check(ctx context.Context, config *models.Config, agentIDs []string) ([]models.AgentPluginCheck, error) {
+	required, err := ConfigPlugins(config.Content)
+	if err != nil {
+		s.logger.WithError(err).WithField("config_id", config.ID).Warn("解析配置引用的插件失败，跳过插件兼容性检查")
+		return nil, nil
+	}
+
+	checks := make([]models.AgentPluginCheck, 0, len(agentIDs))
+	for _, agentID := range agentIDs {
+		check := models.AgentPluginCheck{AgentID: agentID}
+		inventory, err := s.repo.Get(ctx, agentID)
+		if err != nil {
+			return nil, err
+		}
+		if inventory == nil {
+			check.Unknown = true
+		} else {
+			for _, name := range required {
+				if !inventory.Has(name) {
+					check.Missing = append(check.Missing, name)
+				}
+			}
+		}
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
+
+// CheckDeployable 部署前检查，尚未上报清单的Agent（如旧版本Agent）不阻止部署
+func (s *pluginInventoryService) CheckDeployable(ctx context.Context, config *models.Config, agentIDs []string) error {
+	checks, err := s.Check(ctx, config, agentIDs)
+	if err != nil {
+		return fmt.Errorf("检查插件兼容性失败: %w", err)
+	}
+
+	var missing []models.AgentPluginCheck
+	var unknown []string
+	for _, check := range checks {
+		switch {
+		case len(check.Missing) > 0:
+			missing = append(missing, check)
+		case check.Unknown:
+			unknown = append(unknown, check.AgentID)
+		}
+	}
+	if len(unknown) > 0 {
+		s.logger.WithFields(logrus.Fields{
+			"config_id": config.ID,
+			"agents":    unknown,
+		}).Debug("Agent尚未上报插件清单，跳过插件兼容性检查")
+	}
+	if len(missing) > 0 {
+		return &PluginCompatibilityError{ConfigID: config.ID, Checks: missing}
+	}
+	return nil
+}
+
+// ConfigPlugins 配置引用的插件gem名（含codec），按名称排序，Logstash核心自带的插件不计入
+func ConfigPlugins(content string) ([]string, error) {
+	plugins, err := parseLintPlugins(content)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if !corePlugins[name] {
+			seen[name] = true
+		}
+	}
+	for _, plugin := range plugins {
+		add(models.PluginName(plugin.section, plugin.name))
+		if codec := plugin.setting("codec"); codec != nil && (codec.kind == 'w' || codec.kind == 's') {
+			add(models.PluginName("codec", codec.str))
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+)
+
+// memoryPluginInventoryRepo 内存中的插件清单存储
+type memoryPluginInventoryRepo struct {
+	inventories map[string]*models.PluginInventory
+}
+
+func (r *memoryPluginInventoryRepo) Get(ctx context.Context, agentID string) (*models.PluginInventory, error) {
+	return r.inventories[agentID], nil
+}
+
+func (r *memoryPluginInventoryRepo) Save(ctx context.Context, inventory *models.PluginInventory) error {
+	r.inventories[inventory.AgentID] = inventory
+	return nil
+}
+
+const pluginSample = `input {
+  kafka { topics => ["logs"] codec => json { charset => "UTF-8" } }
+  pipeline { address => "upstream" }
+}
+filter {
+  if [type] == "geo" {
+    geoip { source => "client" }
+  }
+}
+output {
+  elasticsearch { hosts => ["http://es:9200"] codec => "json_lines" }
+}
+`
+
+func TestConfigPlugins(t *testing.T) {
+	names, err := ConfigPlugins(pluginSample)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"logstash-codec-json",
+		"logstash-codec-json_lines",
+		"logstash-filter-geoip",
+		"logstash-input-kafka",
+		"logstash-output-elasticsearch",
+	}, names, "pipeline为Logstash核心自带的插件")
+
+	_, err = ConfigPlugins("input { kafka {")
+	assert.Error(t, err)
+}
+
+func TestPluginInventoryService(t *testing.T) {
+	ctx := context.Background()
+	repo := &memoryPluginInventoryRepo{inventories: map[string]*models.PluginInventory{}}
+	svc := NewPluginInventoryService(repo, logrus.New())
+	config := &models.Config{ID: "cfg-1", Content: pluginSample}
+
+	_, err := svc.Get(ctx, "agent-1")
+	assert.True(t, errors.Is(err, ErrPluginInventoryNotFound))
+
+	complete := []models.LogstashPlugin{
+		{Name: "logstash-output-elasticsearch", Version: "11.22.2"},
+		{Name: "logstash-codec-json", Version: "3.1.1"},
+		{Name: "logstash-codec-json_lines", Version: "3.1.0"},
+		{Name: "logstash-filter-geoip", Version: "7.2.13"},
+		{Name: "logstash-integration-kafka", Version: "11.3.1"},
+		{Name: "logstash-input-kafka", Version: "11.3.1", Integration: "logstash-integration-kafka"},
+	}
+	require.NoError(t, svc.Report(ctx, "agent-1", &models.PluginInventoryReport{Plugins: complete}))
+	require.NoError(t, svc.Report(ctx, "agent-2", &models.PluginInventoryReport{Plugins: complete[:3]}))
+
+	inventory, err := svc.Get(ctx, "agent-1")
+	require.NoError(t, err)
+	assert.Equal(t, "logstash-codec-json", inventory.Plugins[0].Name, "按名称排序")
+	assert.False(t, inventory.CollectedAt.IsZero())
+
+	checks, err := svc.Check(ctx, config, []string{"agent-1", "agent-2", "agent-3"})
+	require.NoError(t, err)
+	assert.Equal(t, []models.AgentPluginCheck{
+		{AgentID: "agent-1"},
+		{AgentID: "agent-2", Missing: []string{"logstash-filter-geoip", "logstash-input-kafka"}},
+		{AgentID: "agent-3", Unknown: true},
+	}, checks)
+
+	// 只有缺少插件的Agent阻止部署，未上报清单的Agent不检查
+	err = svc.CheckDeployable(ctx, config, []string{"agent-1", "agent-2", "agent-3"})
+	var compat *PluginCompatibilityError
+	require.True(t, errors.As(err, &compat))
+	assert.True(t, errors.Is(err, ErrPluginsMissing))
+	require.Len(t, compat.Checks, 1)
+	assert.Contains(t, err.Error(), "agent-2 缺少 logstash-filter-geoip、logstash-input-kafka")
+
+	assert.NoError(t, svc.CheckDeployable(ctx, config, []string{"agent-1", "agent-3"}))
+	assert.NoError(t, svc.CheckDeployable(ctx, &models.Config{ID: "cfg-2", Content: "input {"}, []string{"agent-2"}),
+		"无法解析的配置不检查")
+}
@@ -0,0 +1,22 @@
+package service
+
+import "context"
+
+// MessagePublisher 向Agent推送消息的接口
+// 由平台的WebSocket连接管理实现；未配置时推送操作被跳过
+type MessagePublisher interface {
+	Publish(agentID, msgType string, payload interface{}) error
+}
+
+// ContextPublisher 可随消息传递链路上下文的推送方，Agent处理消息时发出的请求接续同一链路
+type ContextPublisher interface {
+	PublishContext(ctx context.Context, agentID, msgType string, payload interface{}) error
+}
+
+// PublishWithContext 推送方支持时携带ctx中的链路上下文推送消息，否则按普通消息推送
+func PublishWithContext(ctx context.Context, publisher MessagePublisher, agentID, msgType string, payload interface{}) error {
+	if cp, ok := publisher.(ContextPublisher); ok {
+		return cp.PublishContext(ctx, agentID, msgType, payload)
+	}
+	return publisher.Publish(agentID, msgType, payload)
+}
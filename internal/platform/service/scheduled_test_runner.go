@@ -0,0 +1,354 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+)
+
+// ErrScheduledTestsRunning 已有定期测试正在进行
+var ErrScheduledTestsRunning = errors.New("定期测试正在进行中")
+
+// ErrScheduledTestsUnavailable 平台没有可用的样本处理器，无法运行测试数据集
+var ErrScheduledTestsUnavailable = errors.New("未设置样本处理器，无法运行定期测试")
+
+// scheduledTestReporter 定期测试写入事件收件箱时使用的上报方标识
+const scheduledTestReporter = "platform:scheduled-tests"
+
+// ScheduledTestConfig 定期测试参数
+type ScheduledTestConfig struct {
+	RunAt string // 每天执行的本地时间，格式 HH:MM，默认 03:00
+}
+
+// ScheduledTestRunner 每天用标记为定期测试的配置的当前版本运行其上保存的测试数据集
+// 测试结果写入配置的测试状态并保存为历史记录；此前通过的配置开始失败时在事件收件箱中创建事件
+// 并发布 test.regressed，恢复通过时自动解决该事件
+type ScheduledTestRunner struct {
+	runAt      time.Duration // 距当天零点的偏移
+	configRepo repository.ConfigRepository
+	runRepo    repository.ScheduledTestRunRepository
+	datasets   TestDatasetService
+	incidents  IncidentService
+	emitter    EventEmitter
+	process    SampleProcessor
+	logger     *logrus.Logger
+	now        func() time.Time
+
+	running sync.Mutex
+	mu      sync.Mutex
+	lastRun *models.ScheduledTestSweep
+
+	inProgress atomic.Bool
+	tracker    loopTracker
+}
+
+// NewScheduledTestRunner 创建定期测试任务
+func NewScheduledTestRunner(cfg ScheduledTestConfig, configRepo repository.ConfigRepository, runRepo repository.ScheduledTestRunRepository,
+	datasets TestDatasetService, incidents IncidentService, logger *logrus.Logger) *ScheduledTestRunner {
+	runAt := 3 * time.Hour
+	if cfg.RunAt != "" {
+		if t, err := time.Parse("15:04", cfg.RunAt); err == nil {
+			runAt = time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+		} else {
+			logger.Warnf("定期测试执行时间 %q 无效，使用默认值 03:00", cfg.RunAt)
+		}
+	}
+	r := &ScheduledTestRunner{
+		runAt:      runAt,
+		configRepo: configRepo,
+		runRepo:    runRepo,
+		datasets:   datasets,
+		incidents:  incidents,
+		logger:     logger,
+		now:        time.Now,
+	}
+	r.tracker = loopTracker{interval: 24 * time.Hour, schedule: r.nextRun}
+	return r
+}
+
+// SetProcessor 设置运行测试数据集时处理样本的方式，未设置时定期测试不执行
+func (r *ScheduledTestRunner) SetProcessor(process SampleProcessor) {
+	r.process = process
+}
+
+// SetEventEmitter 设置平台事件的发布方，之后配置的定期测试由通过变为失败时发布 test.regressed
+func (r *ScheduledTestRunner) SetEventEmitter(emitter EventEmitter) {
+	r.emitter = emitter
+}
+
+// Start 每天在设定时间执行一次定期测试，直到ctx取消
+func (r *ScheduledTestRunner) Start(ctx context.Context) {
+	defer r.tracker.start(r.now())()
+	for {
+		timer := time.NewTimer(r.nextRun(r.now()).Sub(r.now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if _, err := r.Run(ctx); err != nil && ctx.Err() == nil {
+			r.logger.Errorf("执行定期测试失败: %v", err)
+		}
+	}
+}
+
+// nextRun 下一次执行时间
+func (r *ScheduledTestRunner) nextRun(now time.Time) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).Add(r.runAt)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// WorkerStatus 报告定期测试的运行状态
+func (r *ScheduledTestRunner) WorkerStatus(now time.Time) models.WorkerStatus {
+	status := r.tracker.status("scheduled_tests", now)
+	inProgress := 0.0
+	if r.inProgress.Load() {
+		inProgress = 1
+	}
+	status.Gauges = map[string]float64{"in_progress": inProgress}
+	return status
+}
+
+// LastRun 最近一次完成的定期测试汇总，尚未执行过时返回nil
+func (r *ScheduledTestRunner) LastRun() *models.ScheduledTestSweep {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lastRun
+}
+
+// ListRuns 获取配置的定期测试记录，按开始时间倒序，需要配置的读权限
+func (r *ScheduledTestRunner) ListRuns(ctx context.Context, configID string, req *models.ScheduledTestListRequest) ([]*models.ScheduledTestRun, int64, error) {
+	config, err := r.configRepo.GetByID(ctx, configID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %w", ErrConfigNotFound, err)
+	}
+	if err := authorizeConfig(ctx, config, models.PermissionRead); err != nil {
+		return nil, 0, err
+	}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 10
+	}
+	return r.runRepo.List(ctx, configID, req)
+}
+
+// Run 测试全部标记为定期测试的启用配置，已有测试正在进行时返回 ErrScheduledTestsRunning
+func (r *ScheduledTestRunner) Run(ctx context.Context) (*models.ScheduledTestSweep, error) {
+	if !r.running.TryLock() {
+		return nil, ErrScheduledTestsRunning
+	}
+	defer r.running.Unlock()
+	return r.run(ctx)
+}
+
+// Trigger 在后台立即执行一次定期测试，已有测试正在进行时返回 ErrScheduledTestsRunning
+func (r *ScheduledTestRunner) Trigger() error {
+	if r.process == nil {
+		return ErrScheduledTestsUnavailable
+	}
+	if !r.running.TryLock() {
+		return ErrScheduledTestsRunning
+	}
+	go func() {
+		defer r.running.Unlock()
+		if _, err := r.run(context.Background()); err != nil {
+			r.logger.Errorf("执行定期测试失败: %v", err)
+		}
+	}()
+	return nil
+}
+
+// run 执行定期测试，调用方持有running锁
+func (r *ScheduledTestRunner) run(ctx context.Context) (sweep *models.ScheduledTestSweep, err error) {
+	if r.process == nil {
+		return nil, ErrScheduledTestsUnavailable
+	}
+	r.inProgress.Store(true)
+	done := r.tracker.begin(r.now())
+	defer func() {
+		done(err)
+		r.inProgress.Store(false)
+	}()
+
+	configs, err := r.scheduledConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sweep = &models.ScheduledTestSweep{
+		StartedAt: r.now(),
+		Regressed: []string{},
+		Recovered: []string{},
+	}
+	for _, config := range configs {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		r.test(ctx, config, sweep)
+	}
+	sweep.FinishedAt = r.now()
+
+	r.mu.Lock()
+	r.lastRun = sweep
+	r.mu.Unlock()
+
+	r.logger.WithFields(logrus.Fields{
+		"tested":    sweep.Tested,
+		"failed":    sweep.Failed,
+		"skipped":   sweep.Skipped,
+		"errored":   sweep.Errored,
+		"regressed": len(sweep.Regressed),
+		"recovered": len(sweep.Recovered),
+	}).Info("定期测试完成")
+
+	return sweep, nil
+}
+
+// test 运行单个配置的测试数据集，与上一次结果比较后更新测试状态、保存记录并处理状态变化
+func (r *ScheduledTestRunner) test(ctx context.Context, config *models.Config, sweep *models.ScheduledTestSweep) {
+	datasets, err := r.datasets.ListDatasets(ctx, config.ID)
+	if err != nil {
+		// 读取数据集失败时不下结论，保留原有的测试状态
+		sweep.Errored++
+		r.logger.WithError(err).WithField("config_id", config.ID).Warn("读取定期测试数据集失败")
+		return
+	}
+	if len(datasets) == 0 {
+		sweep.Skipped++
+		return
+	}
+
+	previous, err := r.runRepo.Latest(ctx, config.ID)
+	if err != nil {
+		sweep.Errored++
+		r.logger.WithError(err).WithField("config_id", config.ID).Warn("读取上一次定期测试记录失败")
+		return
+	}
+
+	started := r.now()
+	result := RunDatasets(ctx, r.datasets, config, datasets, r.process, r.logger)
+	sweep.Tested++
+
+	run := &models.ScheduledTestRun{
+		ID:            uuid.New().String(),
+		ConfigID:      config.ID,
+		ConfigName:    config.Name,
+		ConfigVersion: config.Version,
+		Status:        result.Status,
+		Datasets:      result.Runs,
+		StartedAt:     started,
+		DurationMs:    r.now().Sub(started).Milliseconds(),
+	}
+	// 首次定期测试以配置原有的测试状态作为比较基准
+	if previous != nil {
+		run.PreviousStatus = previous.Status
+	} else if config.TestStatus == models.TestStatusPassed || config.TestStatus == models.TestStatusFailed {
+		run.PreviousStatus = string(config.TestStatus)
+	}
+
+	status := models.TestStatusPassed
+	if run.Status == models.DatasetRunFailed {
+		status = models.TestStatusFailed
+		sweep.Failed++
+		if previous != nil && previous.Status == models.DatasetRunFailed {
+			run.FailingSince = previous.FailingSince
+			run.IncidentID = previous.IncidentID
+		}
+		if run.FailingSince == nil {
+			at := run.StartedAt
+			run.FailingSince = &at
+		}
+		if run.PreviousStatus == models.DatasetRunPassed {
+			run.Regressed = true
+			sweep.Regressed = append(sweep.Regressed, config.ID)
+			run.IncidentID = r.report(ctx, config, run)
+			if r.emitter != nil {
+				r.emitter.Emit(ctx, models.WebhookEventTestRegressed, run)
+			}
+		}
+	} else if previous != nil && previous.Status == models.DatasetRunFailed {
+		sweep.Recovered = append(sweep.Recovered, config.ID)
+		r.resolve(ctx, config, previous)
+	}
+
+	if _, err := r.configRepo.UpdateTestStatus(ctx, config.ID, config.Version, status, run.StartedAt); err != nil {
+		r.logger.WithError(err).WithField("config_id", config.ID).Warn("记录定期测试状态失败")
+	}
+	if err := r.runRepo.Save(ctx, run); err != nil {
+		r.logger.WithError(err).WithField("config_id", config.ID).Error("保存定期测试记录失败")
+	}
+}
+
+// report 在事件收件箱中记录由通过变为失败的配置，返回事件ID，写入失败时返回空字符串
+func (r *ScheduledTestRunner) report(ctx context.Context, config *models.Config, run *models.ScheduledTestRun) string {
+	failed := 0
+	for _, dataset := range run.Datasets {
+		if dataset.Status == models.DatasetRunFailed {
+			failed++
+		}
+	}
+	incident, err := r.incidents.ReportError(ctx, scheduledTestReporter, &models.AgentErrorReport{
+		ErrorType: models.ErrorTypeScheduledTestRegressed,
+		ConfigID:  config.ID,
+		Message:   fmt.Sprintf("配置 %s 版本 %d 定期测试未通过: %d/%d 个测试数据集失败", config.Name, config.Version, failed, len(run.Datasets)),
+		Timestamp: run.StartedAt,
+	})
+	if err != nil {
+		r.logger.WithError(err).WithField("config_id", config.ID).Error("记录定期测试失败事件失败")
+		return ""
+	}
+
+	r.logger.WithFields(logrus.Fields{
+		"config_id":   config.ID,
+		"version":     config.Version,
+		"incident_id": incident.ID,
+	}).Warn("配置定期测试由通过变为失败")
+	return incident.ID
+}
+
+// resolve 配置恢复通过时解决此前创建的事件，事件已被人工处理时忽略
+func (r *ScheduledTestRunner) resolve(ctx context.Context, config *models.Config, previous *models.ScheduledTestRun) {
+	r.logger.WithField("config_id", config.ID).Info("配置定期测试恢复通过")
+	if previous.IncidentID == "" {
+		return
+	}
+
+	_, err := r.incidents.ResolveIncident(ctx, previous.IncidentID, &models.ResolveIncidentRequest{
+		Resolution: fmt.Sprintf("版本 %d 定期测试已通过", config.Version),
+	}, scheduledTestReporter)
+	if err != nil {
+		r.logger.WithError(err).WithField("incident_id", previous.IncidentID).Debug("自动解决定期测试事件失败")
+	}
+}
+
+// scheduledConfigs 分页获取全部标记为定期测试的启用配置
+func (r *ScheduledTestRunner) scheduledConfigs(ctx context.Context) ([]*models.Config, error) {
+	enabled := true
+	var configs []*models.Config
+	for page := 1; ; page++ {
+		resp, err := r.configRepo.List(ctx, &models.ConfigListRequest{Enabled: &enabled, ScheduledTest: true, Page: page, PageSize: 100})
+		if err != nil {
+			return nil, fmt.Errorf("获取配置列表失败: %w", err)
+		}
+		configs = append(configs, resp.Items...)
+		if len(resp.Items) == 0 || int64(page*resp.Size) >= resp.Total {
+			break
+		}
+	}
+	return configs, nil
+}
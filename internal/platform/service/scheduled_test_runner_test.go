@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/tests/mocks"
+)
+
+// memScheduledTestRunRepository 内存定期测试记录仓库，按保存顺序返回最近一次记录
+type memScheduledTestRunRepository struct {
+	mu   sync.Mutex
+	runs []*models.ScheduledTestRun
+}
+
+func (r *memScheduledTestRunRepository) Save(ctx context.Context, run *models.ScheduledTestRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *run
+	r.runs = append(r.runs, &copied)
+	return nil
+}
+
+func (r *memScheduledTestRunRepository) Latest(ctx context.Context, configID string) (*models.ScheduledTestRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := len(r.runs) - 1; i >= 0; i-- {
+		if r.runs[i].ConfigID == configID {
+			copied := *r.runs[i]
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memScheduledTestRunRepository) List(ctx context.Context, configID string, req *models.ScheduledTestListRequest) ([]*models.ScheduledTestRun, int64, error) {
+	return nil, 0, nil
+}
+
+// recordingEmitter 记录发布的平台事件
+type recordingEmitter struct {
+	mu     sync.Mutex
+	events []string
+	data   []interface{}
+}
+
+func (e *recordingEmitter) Emit(ctx context.Context, event string, data interface{}) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.events = append(e.events, event)
+	e.data = append(e.data, data)
+}
+
+func TestScheduledTestRunner_Run(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	ctx := context.Background()
+
+	configs := []*models.Config{
+		{ID: "cfg-a", Name: "nginx", Version: 3, Enabled: true, ScheduledTest: true, TestStatus: models.TestStatusPassed},
+		{ID: "cfg-b", Name: "empty", Version: 1, Enabled: true, ScheduledTest: true},
+	}
+	configRepo := new(mocks.MockConfigRepository)
+	configRepo.On("List", ctx, mock.MatchedBy(func(req *models.ConfigListRequest) bool {
+		return req.Enabled != nil && *req.Enabled && req.ScheduledTest
+	})).Return(&models.ConfigListResponse{Total: 2, Page: 1, Size: 100, Items: configs}, nil)
+	configRepo.On("GetByID", mock.Anything, mock.Anything).Return(&models.Config{ID: "cfg-a"}, nil)
+	configRepo.On("UpdateTestStatus", ctx, "cfg-a", 3, mock.Anything, mock.Anything).Return(true, nil)
+
+	datasetRepo := &memDatasetRepository{datasets: map[string]*models.TestDataset{
+		"ds-1": {ID: "ds-1", ConfigID: "cfg-a", Name: "access", Samples: []models.DatasetSample{
+			{Input: "GET /", Expected: map[string]interface{}{"method": "GET"}},
+		}},
+	}}
+	datasets := NewTestDatasetService(datasetRepo, configRepo, logger)
+	runs := &memScheduledTestRunRepository{}
+	incidents := NewIncidentService(&memIncidentRepository{incidents: make(map[string]*models.Incident)}, logger)
+	emitter := &recordingEmitter{}
+
+	// broken 模拟Logstash升级后插件行为变化，输出中不再有method字段
+	broken := false
+	process := func(config *models.Config, samples []string) []models.TestOutput {
+		outputs := make([]models.TestOutput, len(samples))
+		for i, sample := range samples {
+			outputs[i] = models.TestOutput{Input: sample, Output: map[string]interface{}{"method": "GET"}}
+			if broken {
+				outputs[i].Output = map[string]interface{}{}
+			}
+		}
+		return outputs
+	}
+
+	r := NewScheduledTestRunner(ScheduledTestConfig{}, configRepo, runs, datasets, incidents, logger)
+	r.SetEventEmitter(emitter)
+
+	t.Run("未设置样本处理器时不执行", func(t *testing.T) {
+		_, err := r.Run(ctx)
+		assert.ErrorIs(t, err, ErrScheduledTestsUnavailable)
+		assert.ErrorIs(t, r.Trigger(), ErrScheduledTestsUnavailable)
+	})
+	r.SetProcessor(process)
+
+	t.Run("通过时记录测试状态和历史", func(t *testing.T) {
+		sweep, err := r.Run(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, sweep.Tested)
+		assert.Equal(t, 1, sweep.Skipped, "没有数据集的配置跳过")
+		assert.Empty(t, sweep.Regressed)
+		assert.Same(t, sweep, r.LastRun())
+
+		run, _ := runs.Latest(ctx, "cfg-a")
+		require.NotNil(t, run)
+		assert.Equal(t, models.DatasetRunPassed, run.Status)
+		assert.Equal(t, models.DatasetRunPassed, run.PreviousStatus, "首次运行以配置原有的测试状态为基准")
+		assert.Equal(t, 3, run.ConfigVersion)
+		require.Len(t, run.Datasets, 1)
+		configRepo.AssertCalled(t, "UpdateTestStatus", ctx, "cfg-a", 3, models.TestStatusPassed, mock.Anything)
+
+		dataset, _ := datasetRepo.GetByID(ctx, "ds-1")
+		require.NotNil(t, dataset.LastRun)
+		assert.Equal(t, models.DatasetRunPassed, dataset.LastRun.Status)
+	})
+
+	t.Run("由通过变为失败时创建事件并发布test.regressed", func(t *testing.T) {
+		broken = true
+		sweep, err := r.Run(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, sweep.Failed)
+		assert.Equal(t, []string{"cfg-a"}, sweep.Regressed)
+
+		run, _ := runs.Latest(ctx, "cfg-a")
+		assert.True(t, run.Regressed)
+		assert.Equal(t, models.DatasetRunPassed, run.PreviousStatus)
+		require.NotNil(t, run.FailingSince)
+		require.NotEmpty(t, run.IncidentID)
+		configRepo.AssertCalled(t, "UpdateTestStatus", ctx, "cfg-a", 3, models.TestStatusFailed, mock.Anything)
+
+		incident, err := incidents.GetIncident(ctx, run.IncidentID)
+		require.NoError(t, err)
+		assert.Equal(t, models.ErrorTypeScheduledTestRegressed, incident.ErrorType)
+		assert.Equal(t, "cfg-a", incident.ConfigID)
+		assert.Contains(t, incident.Message, "1/1")
+
+		require.Equal(t, []string{models.WebhookEventTestRegressed}, emitter.events)
+		assert.Equal(t, "cfg-a", emitter.data[0].(*models.ScheduledTestRun).ConfigID)
+	})
+
+	t.Run("持续失败不重复上报", func(t *testing.T) {
+		first, _ := runs.Latest(ctx, "cfg-a")
+
+		sweep, err := r.Run(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, sweep.Regressed)
+
+		run, _ := runs.Latest(ctx, "cfg-a")
+		assert.False(t, run.Regressed)
+		assert.Equal(t, first.IncidentID, run.IncidentID)
+		assert.Equal(t, first.FailingSince, run.FailingSince)
+		assert.Len(t, emitter.events, 1)
+	})
+
+	t.Run("恢复通过时自动解决事件", func(t *testing.T) {
+		previous, _ := runs.Latest(ctx, "cfg-a")
+		broken = false
+
+		sweep, err := r.Run(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"cfg-a"}, sweep.Recovered)
+
+		run, _ := runs.Latest(ctx, "cfg-a")
+		assert.Equal(t, models.DatasetRunPassed, run.Status)
+		assert.Empty(t, run.IncidentID)
+		assert.Nil(t, run.FailingSince)
+
+		_, err = incidents.GetIncident(ctx, previous.IncidentID)
+		assert.EqualError(t, err, "文档不存在")
+	})
+}
+
+func TestScheduledTestRunner_NextRun(t *testing.T) {
+	r := NewScheduledTestRunner(ScheduledTestConfig{}, nil, nil, nil, nil, logrus.New())
+
+	now := time.Date(2026, 3, 1, 1, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2026, 3, 1, 3, 0, 0, 0, time.UTC), r.nextRun(now))
+
+	now = time.Date(2026, 3, 1, 3, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2026, 3, 2, 3, 0, 0, 0, time.UTC), r.nextRun(now))
+}
@@ -0,0 +1,319 @@
+package service
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// 密钥相关错误，处理器通过 errors.Is 映射为HTTP状态码
+var (
+	ErrSecretsDisabled     = errors.New("平台未配置密钥主密钥")
+	ErrSecretNotFound      = errors.New("密钥不存在")
+	ErrSecretExists        = errors.New("密钥已存在")
+	ErrSecretInvalid       = errors.New("密钥无效")
+	ErrSecretNotReferenced = errors.New("配置未引用该密钥")
+	ErrConfigNotAssigned   = errors.New("配置未下发到该Agent")
+)
+
+// SecretCipher 使用平台主密钥加解密密钥值（AES-256-GCM）
+// 密钥名称作为附加认证数据，密文无法挪用到其他名称下
+type SecretCipher struct {
+	aead cipher.AEAD
+}
+
+// NewSecretCipher 创建密钥加解密器，masterKey为base64编码的32字节主密钥
+func NewSecretCipher(masterKey string) (*SecretCipher, error) {
+	key, err := base64.StdEncoding.DecodeString(masterKey)
+	if err != nil {
+		return nil, fmt.Errorf("主密钥不是有效的base64: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("主密钥长度必须为32字节，实际为%d字节", len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &SecretCipher{aead: aead}, nil
+}
+
+// Encrypt 加密密钥值，返回base64编码的随机数和密文
+func (c *SecretCipher) Encrypt(name, value string) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("生成随机数失败: %w", err)
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(value), []byte(name))
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt 解密密钥值
+func (c *SecretCipher) Decrypt(name, ciphertext string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("密文格式无效: %w", err)
+	}
+	if len(data) < c.aead.NonceSize() {
+		return "", fmt.Errorf("密文长度无效")
+	}
+	nonce, sealed := data[:c.aead.NonceSize()], data[c.aead.NonceSize():]
+	plain, err := c.aead.Open(nil, nonce, sealed, []byte(name))
+	if err != nil {
+		return "", fmt.Errorf("解密失败，主密钥可能已变更: %w", err)
+	}
+	return string(plain), nil
+}
+
+// SecretService 密钥管理服务接口
+// 管理接口只返回元数据，明文值只在Agent获取所部署配置引用的密钥时返回
+type SecretService interface {
+	CreateSecret(ctx context.Context, req *models.CreateSecretRequest, userID string) (*models.Secret, error)
+	UpdateSecret(ctx context.Context, name string, req *models.UpdateSecretRequest, userID string) (*models.Secret, error)
+	DeleteSecret(ctx context.Context, name string) error
+	GetSecret(ctx context.Context, name string) (*models.Secret, error)
+	ListSecrets(ctx context.Context) ([]*models.Secret, error)
+	// ResolveForConfig 解密Agent已应用或正在部署的配置（当前或任一历史版本）引用的密钥
+	ResolveForConfig(ctx context.Context, agentID string, req *models.ResolveSecretsRequest) (*models.ResolvedSecrets, error)
+}
+
+// secretService 密钥管理服务实现
+type secretService struct {
+	secretRepo  repository.SecretRepository
+	configRepo  repository.ConfigRepository
+	deployRepo  repository.DeploymentRepository
+	appliedRepo repository.AppliedConfigRepository
+	cipher      *SecretCipher
+	logger      *logrus.Logger
+}
+
+// NewSecretService 创建密钥管理服务，cipher为nil时所有操作返回 ErrSecretsDisabled
+// deployRepo和appliedRepo用于确认请求密钥的Agent确实被下发或已应用了该配置
+func NewSecretService(secretRepo repository.SecretRepository, configRepo repository.ConfigRepository,
+	deployRepo repository.DeploymentRepository, appliedRepo repository.AppliedConfigRepository,
+	cipher *SecretCipher, logger *logrus.Logger) SecretService {
+	return &secretService{
+		secretRepo:  secretRepo,
+		configRepo:  configRepo,
+		deployRepo:  deployRepo,
+		appliedRepo: appliedRepo,
+		cipher:      cipher,
+		logger:      logger,
+	}
+}
+
+// CreateSecret 创建密钥
+func (s *secretService) CreateSecret(ctx context.Context, req *models.CreateSecretRequest, userID string) (*models.Secret, error) {
+	if s.cipher == nil {
+		return nil, ErrSecretsDisabled
+	}
+	if !models.SecretNamePattern.MatchString(req.Name) {
+		return nil, fmt.Errorf("%w: 名称只能包含字母、数字、下划线和点，且不能以数字开头", ErrSecretInvalid)
+	}
+	if _, err := s.secretRepo.GetByName(elasticsearch.WithPrimaryRead(ctx), req.Name); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrSecretExists, req.Name)
+	}
+
+	ciphertext, err := s.cipher.Encrypt(req.Name, req.Value)
+	if err != nil {
+		return nil, err
+	}
+	secret := &models.EncryptedSecret{
+		Secret: models.Secret{
+			Name:        req.Name,
+			Description: req.Description,
+			Version:     1,
+			CreatedBy:   userID,
+			UpdatedBy:   userID,
+		},
+		Ciphertext: ciphertext,
+	}
+	if err := s.secretRepo.Create(ctx, secret); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"secret":  secret.Name,
+		"user_id": userID,
+	}).Info("创建密钥成功")
+
+	return &secret.Secret, nil
+}
+
+// UpdateSecret 替换密钥值，使用旧值的Agent在下次部署引用它的配置时获取新值
+func (s *secretService) UpdateSecret(ctx context.Context, name string, req *models.UpdateSecretRequest, userID string) (*models.Secret, error) {
+	if s.cipher == nil {
+		return nil, ErrSecretsDisabled
+	}
+	secret, err := s.secretRepo.GetByName(elasticsearch.WithPrimaryRead(ctx), name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSecretNotFound, err)
+	}
+
+	ciphertext, err := s.cipher.Encrypt(name, req.Value)
+	if err != nil {
+		return nil, err
+	}
+	secret.Ciphertext = ciphertext
+	secret.Description = req.Description
+	secret.Version++
+	secret.UpdatedBy = userID
+	if err := s.secretRepo.Update(ctx, secret); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"secret":  name,
+		"version": secret.Version,
+		"user_id": userID,
+	}).Info("更新密钥成功")
+
+	return &secret.Secret, nil
+}
+
+// DeleteSecret 删除密钥
+func (s *secretService) DeleteSecret(ctx context.Context, name string) error {
+	if _, err := s.secretRepo.GetByName(ctx, name); err != nil {
+		return fmt.Errorf("%w: %w", ErrSecretNotFound, err)
+	}
+	if err := s.secretRepo.Delete(ctx, name); err != nil {
+		return err
+	}
+
+	s.logger.WithField("secret", name).Info("删除密钥成功")
+	return nil
+}
+
+// GetSecret 获取密钥元数据
+func (s *secretService) GetSecret(ctx context.Context, name string) (*models.Secret, error) {
+	secret, err := s.secretRepo.GetByName(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSecretNotFound, err)
+	}
+	return &secret.Secret, nil
+}
+
+// ListSecrets 获取全部密钥元数据
+func (s *secretService) ListSecrets(ctx context.Context) ([]*models.Secret, error) {
+	return s.secretRepo.List(ctx)
+}
+
+// ResolveForConfig 解密Agent部署的配置引用的密钥
+// 配置须已应用在该Agent上或由仍对该Agent生效的部署下发，否则返回 ErrConfigNotAssigned；
+// 只返回配置内容中引用的密钥，回滚到旧版本时可能引用当前版本已不再使用的密钥，因此历史版本的引用同样有效
+func (s *secretService) ResolveForConfig(ctx context.Context, agentID string, req *models.ResolveSecretsRequest) (*models.ResolvedSecrets, error) {
+	if s.cipher == nil {
+		return nil, ErrSecretsDisabled
+	}
+
+	assigned, err := s.configAssigned(ctx, agentID, req.ConfigID)
+	if err != nil {
+		return nil, err
+	}
+	if !assigned {
+		s.logger.WithFields(logrus.Fields{
+			"agent_id":  agentID,
+			"config_id": req.ConfigID,
+		}).Warn("Agent请求未下发给它的配置引用的密钥")
+		return nil, fmt.Errorf("%w: %s", ErrConfigNotAssigned, req.ConfigID)
+	}
+
+	config, err := s.configRepo.GetByID(ctx, req.ConfigID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConfigNotFound, err)
+	}
+	referenced := models.SecretReferences(config.Content)
+	if missing := unreferencedSecrets(req.Names, referenced); len(missing) > 0 {
+		history, err := s.configRepo.GetHistory(ctx, req.ConfigID)
+		if err != nil {
+			return nil, fmt.Errorf("获取配置历史失败: %w", err)
+		}
+		for _, h := range history {
+			referenced = append(referenced, models.SecretReferences(h.Content)...)
+		}
+		if missing := unreferencedSecrets(req.Names, referenced); len(missing) > 0 {
+			return nil, fmt.Errorf("%w: %v", ErrSecretNotReferenced, missing)
+		}
+	}
+
+	resolved := &models.ResolvedSecrets{Secrets: make(map[string]string, len(req.Names))}
+	for _, name := range req.Names {
+		secret, err := s.secretRepo.GetByName(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+		}
+		value, err := s.cipher.Decrypt(name, secret.Ciphertext)
+		if err != nil {
+			return nil, fmt.Errorf("解密密钥 %s 失败: %w", name, err)
+		}
+		resolved.Secrets[name] = value
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"agent_id":  agentID,
+		"config_id": req.ConfigID,
+		"secrets":   req.Names,
+	}).Info("Agent获取配置引用的密钥")
+
+	return resolved, nil
+}
+
+// configAssigned 配置已应用在该Agent上，或最近一次对该Agent生效的部署下发了该配置
+func (s *secretService) configAssigned(ctx context.Context, agentID, configID string) (bool, error) {
+	applied, err := s.appliedRepo.ListByAgent(ctx, agentID)
+	if err != nil {
+		return false, fmt.Errorf("查询Agent已应用的配置失败: %w", err)
+	}
+	for _, mapping := range applied {
+		if mapping.ConfigID == configID {
+			return true, nil
+		}
+	}
+
+	deployments, _, err := s.deployRepo.List(ctx, &models.DeploymentListRequest{
+		ConfigID: configID,
+		AgentID:  agentID,
+		Page:     1,
+		PageSize: desiredConfigScanLimit,
+	})
+	if err != nil {
+		return false, fmt.Errorf("查询Agent的部署记录失败: %w", err)
+	}
+	sort.SliceStable(deployments, func(i, j int) bool { return deployments[i].CreatedAt.After(deployments[j].CreatedAt) })
+	for _, deployment := range deployments {
+		if deployment.ConfigID != configID || deployment.Status == models.DeploymentStatusPending {
+			continue
+		}
+		if version, ok := desiredVersion(deployment, agentID); ok {
+			return version > 0, nil
+		}
+	}
+	return false, nil
+}
+
+// unreferencedSecrets 返回names中不在referenced内的名称
+func unreferencedSecrets(names, referenced []string) []string {
+	var missing []string
+	for _, name := range names {
+		if !slices.Contains(referenced, name) {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
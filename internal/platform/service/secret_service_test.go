@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/tests/mocks"
+)
+
+// memSecretRepository 内存中的密钥仓库
+type memSecretRepository struct {
+	secrets map[string]models.EncryptedSecret
+}
+
+func (r *memSecretRepository) Create(ctx context.Context, secret *models.EncryptedSecret) error {
+	r.secrets[secret.Name] = *secret
+	return nil
+}
+
+func (r *memSecretRepository) Update(ctx context.Context, secret *models.EncryptedSecret) error {
+	r.secrets[secret.Name] = *secret
+	return nil
+}
+
+func (r *memSecretRepository) Delete(ctx context.Context, name string) error {
+	delete(r.secrets, name)
+	return nil
+}
+
+func (r *memSecretRepository) GetByName(ctx context.Context, name string) (*models.EncryptedSecret, error) {
+	secret, ok := r.secrets[name]
+	if !ok {
+		return nil, errors.New("文档不存在")
+	}
+	return &secret, nil
+}
+
+func (r *memSecretRepository) List(ctx context.Context) ([]*models.Secret, error) {
+	var secrets []*models.Secret
+	for _, s := range r.secrets {
+		secret := s.Secret
+		secrets = append(secrets, &secret)
+	}
+	return secrets, nil
+}
+
+func testSecretCipher(t *testing.T) *SecretCipher {
+	cipher, err := NewSecretCipher(base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32))))
+	require.NoError(t, err)
+	return cipher
+}
+
+func TestSecretCipher(t *testing.T) {
+	cipher := testSecretCipher(t)
+
+	ciphertext, err := cipher.Encrypt("es_password", "changeme")
+	require.NoError(t, err)
+	assert.NotContains(t, ciphertext, "changeme")
+
+	value, err := cipher.Decrypt("es_password", ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, "changeme", value)
+
+	// 密文与名称绑定，不能挪用到其他名称
+	_, err = cipher.Decrypt("kafka_password", ciphertext)
+	assert.Error(t, err)
+
+	_, err = NewSecretCipher(base64.StdEncoding.EncodeToString([]byte("short")))
+	assert.Error(t, err)
+}
+
+func TestSecretService(t *testing.T) {
+	ctx := context.Background()
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	secretRepo := &memSecretRepository{secrets: make(map[string]models.EncryptedSecret)}
+	configRepo := new(mocks.MockConfigRepository)
+	configRepo.On("GetByID", mock.Anything, "cfg-1").Return(&models.Config{
+		ID: "cfg-1", Content: `output { elasticsearch { password => "${secret:es_password}" } }`,
+	}, nil)
+	configRepo.On("GetByID", mock.Anything, "missing").Return(nil, errors.New("文档不存在"))
+	configRepo.On("GetHistory", mock.Anything, "cfg-1").Return([]*models.ConfigHistory{
+		{ConfigID: "cfg-1", Version: 1, Content: `output { kafka { sasl_jaas_config => "${secret:kafka_jaas}" } }`},
+	}, nil)
+
+	// agent-1已应用cfg-1，agent-3正在接收cfg-1的部署，agent-2从未被下发cfg-1
+	appliedRepo := &memAppliedConfigRepository{}
+	require.NoError(t, appliedRepo.Save(ctx, &models.AppliedConfigMapping{AgentID: "agent-1", ConfigID: "cfg-1", Version: 2}))
+	started := time.Now()
+	deployRepo := new(mocks.MockDeploymentRepository)
+	deployRepo.On("List", mock.Anything, mock.MatchedBy(func(req *models.DeploymentListRequest) bool {
+		return req.AgentID == "agent-3" && req.ConfigID == "cfg-1"
+	})).Return([]*models.Deployment{{
+		ID: "dep-1", ConfigID: "cfg-1", ConfigVersion: 2, Status: models.DeploymentStatusRunning,
+		Results: []models.DeploymentResult{{AgentID: "agent-3", Status: models.DeploymentResultPending, StartedAt: &started}},
+	}}, int64(1), nil)
+	deployRepo.On("List", mock.Anything, mock.Anything).Return([]*models.Deployment{}, int64(0), nil)
+
+	svc := NewSecretService(secretRepo, configRepo, deployRepo, appliedRepo, testSecretCipher(t), logger)
+	for name, value := range map[string]string{"es_password": "changeme", "kafka_jaas": "jaas", "unrelated": "x"} {
+		_, err := svc.CreateSecret(ctx, &models.CreateSecretRequest{Name: name, Value: value}, "admin")
+		require.NoError(t, err)
+	}
+
+	t.Run("值加密保存", func(t *testing.T) {
+		stored := secretRepo.secrets["es_password"]
+		assert.NotEmpty(t, stored.Ciphertext)
+		assert.NotContains(t, stored.Ciphertext, "changeme")
+
+		_, err := svc.CreateSecret(ctx, &models.CreateSecretRequest{Name: "es_password", Value: "again"}, "admin")
+		assert.ErrorIs(t, err, ErrSecretExists)
+		_, err = svc.CreateSecret(ctx, &models.CreateSecretRequest{Name: "1bad-name", Value: "x"}, "admin")
+		assert.ErrorIs(t, err, ErrSecretInvalid)
+	})
+
+	t.Run("更新递增版本", func(t *testing.T) {
+		secret, err := svc.UpdateSecret(ctx, "es_password", &models.UpdateSecretRequest{Value: "rotated"}, "admin")
+		require.NoError(t, err)
+		assert.Equal(t, 2, secret.Version)
+
+		_, err = svc.UpdateSecret(ctx, "missing", &models.UpdateSecretRequest{Value: "x"}, "admin")
+		assert.ErrorIs(t, err, ErrSecretNotFound)
+	})
+
+	t.Run("只解析配置引用的密钥", func(t *testing.T) {
+		resolved, err := svc.ResolveForConfig(ctx, "agent-1", &models.ResolveSecretsRequest{
+			ConfigID: "cfg-1", Names: []string{"es_password", "kafka_jaas"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"es_password": "rotated", "kafka_jaas": "jaas"}, resolved.Secrets)
+
+		_, err = svc.ResolveForConfig(ctx, "agent-1", &models.ResolveSecretsRequest{ConfigID: "cfg-1", Names: []string{"unrelated"}})
+		assert.ErrorIs(t, err, ErrSecretNotReferenced)
+		require.NoError(t, appliedRepo.Save(ctx, &models.AppliedConfigMapping{AgentID: "agent-1", ConfigID: "missing", Version: 1}))
+		_, err = svc.ResolveForConfig(ctx, "agent-1", &models.ResolveSecretsRequest{ConfigID: "missing", Names: []string{"es_password"}})
+		assert.ErrorIs(t, err, ErrConfigNotFound)
+	})
+
+	t.Run("只解析下发给该Agent的配置", func(t *testing.T) {
+		// 正在部署中的Agent可以获取，用于应用前替换引用
+		resolved, err := svc.ResolveForConfig(ctx, "agent-3", &models.ResolveSecretsRequest{ConfigID: "cfg-1", Names: []string{"es_password"}})
+		require.NoError(t, err)
+		assert.Equal(t, "rotated", resolved.Secrets["es_password"])
+
+		// agent-2不能凭cfg-1的ID获取agent-1所部署配置引用的密钥
+		_, err = svc.ResolveForConfig(ctx, "agent-2", &models.ResolveSecretsRequest{ConfigID: "cfg-1", Names: []string{"es_password"}})
+		assert.ErrorIs(t, err, ErrConfigNotAssigned)
+	})
+
+	t.Run("未配置主密钥", func(t *testing.T) {
+		disabled := NewSecretService(secretRepo, configRepo, deployRepo, appliedRepo, nil, logger)
+		_, err := disabled.CreateSecret(ctx, &models.CreateSecretRequest{Name: "x", Value: "y"}, "admin")
+		assert.ErrorIs(t, err, ErrSecretsDisabled)
+		_, err = disabled.ResolveForConfig(ctx, "agent-1", &models.ResolveSecretsRequest{ConfigID: "cfg-1", Names: []string{"es_password"}})
+		assert.ErrorIs(t, err, ErrSecretsDisabled)
+	})
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"logstash-platform/internal/platform/apperror"
+)
+
+// ErrShuttingDown 平台正在关闭，不再接受新的部署和测试
+var ErrShuttingDown = apperror.New(apperror.ShuttingDown, "平台正在关闭，不再接受新任务")
+
+// Drainer 平台关闭时需要等待进行中任务的子系统
+// Drain 先停止接受新任务，再等待进行中的任务结束；ctx到期时将未结束任务的进度写入存储后返回错误
+type Drainer interface {
+	Drain(ctx context.Context) error
+}
+
+// ShutdownCoordinator 协调平台关闭：停止接受新任务，在限定时间内等待各子系统的进行中任务结束
+type ShutdownCoordinator struct {
+	mu       sync.Mutex
+	drainers map[string]Drainer
+	logger   *logrus.Logger
+}
+
+// NewShutdownCoordinator 创建关闭协调器
+func NewShutdownCoordinator(logger *logrus.Logger) *ShutdownCoordinator {
+	return &ShutdownCoordinator{
+		drainers: make(map[string]Drainer),
+		logger:   logger,
+	}
+}
+
+// Register 登记子系统，name用于日志
+func (s *ShutdownCoordinator) Register(name string, drainer Drainer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.drainers[name] = drainer
+}
+
+// Drain 并行排空全部子系统，直到全部结束或ctx到期，返回各子系统未能排空的原因
+func (s *ShutdownCoordinator) Drain(ctx context.Context) error {
+	s.mu.Lock()
+	drainers := make(map[string]Drainer, len(s.drainers))
+	for name, drainer := range s.drainers {
+		drainers[name] = drainer
+	}
+	s.mu.Unlock()
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for name, drainer := range drainers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := drainer.Drain(ctx); err != nil {
+				s.logger.WithError(err).WithField("worker", name).Warn("关闭前未能等到进行中的任务结束")
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+				return
+			}
+			s.logger.WithField("worker", name).Info("进行中的任务已结束")
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
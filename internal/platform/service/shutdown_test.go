@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeDrainer struct {
+	delay time.Duration
+	err   error
+}
+
+func (d *fakeDrainer) Drain(ctx context.Context) error {
+	select {
+	case <-time.After(d.delay):
+		return d.err
+	case <-ctx.Done():
+		return errors.New("未排空")
+	}
+}
+
+func TestShutdownCoordinator_Drain(t *testing.T) {
+	coordinator := NewShutdownCoordinator(logrus.New())
+	coordinator.Register("fast", &fakeDrainer{})
+	coordinator.Register("slow", &fakeDrainer{delay: 50 * time.Millisecond})
+
+	started := time.Now()
+	assert.NoError(t, coordinator.Drain(context.Background()))
+	assert.Less(t, time.Since(started), 500*time.Millisecond, "各子系统并行排空")
+
+	coordinator.Register("stuck", &fakeDrainer{delay: time.Minute})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.EqualError(t, coordinator.Drain(ctx), "未排空")
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+)
+
+// latencySmoothing 心跳处理耗时的指数平滑系数
+const latencySmoothing = 0.2
+
+// TelemetryPolicyConfig 心跳/指标间隔协商参数
+type TelemetryPolicyConfig struct {
+	HeartbeatRate float64       // 平台期望每秒处理的心跳数上限
+	MetricsRate   float64       // 平台期望每秒处理的指标上报数上限
+	TargetLatency time.Duration // 心跳处理耗时超过该值时视为平台有压力，按比例拉长间隔
+	MaxPressure   float64       // 压力系数上限
+	Interval      time.Duration // 重新计算的间隔
+}
+
+// TelemetryPolicy 根据在线Agent数量和平台负载计算Agent心跳/指标间隔的下限
+// 平台压力大时通过心跳命令要求Agent拉长间隔，压力消失后恢复，无需发布Agent配置
+type TelemetryPolicy struct {
+	cfg       TelemetryPolicyConfig
+	agentRepo repository.AgentRepository
+	publisher MessagePublisher
+	logger    *logrus.Logger
+
+	mu        sync.RWMutex
+	latency   float64 // 平滑后的心跳处理耗时（秒）
+	current   *models.TelemetryIntervals
+	published map[string]models.TelemetryIntervals // 已下发给各Agent的间隔
+
+	tracker loopTracker
+}
+
+// NewTelemetryPolicy 创建间隔协商策略
+func NewTelemetryPolicy(cfg TelemetryPolicyConfig, agentRepo repository.AgentRepository, publisher MessagePublisher, logger *logrus.Logger) *TelemetryPolicy {
+	if cfg.MaxPressure < 1 {
+		cfg.MaxPressure = 4
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Minute
+	}
+	return &TelemetryPolicy{
+		cfg:       cfg,
+		agentRepo: agentRepo,
+		publisher: publisher,
+		logger:    logger,
+		published: make(map[string]models.TelemetryIntervals),
+		tracker:   loopTracker{interval: cfg.Interval},
+	}
+}
+
+// ObserveHeartbeat 记录一次心跳的处理耗时
+func (p *TelemetryPolicy) ObserveHeartbeat(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.latency == 0 {
+		p.latency = d.Seconds()
+		return
+	}
+	p.latency += latencySmoothing * (d.Seconds() - p.latency)
+}
+
+// Negotiate 获取Agent注册时应使用的间隔，并记为已下发，尚未计算时返回nil
+func (p *TelemetryPolicy) Negotiate(agentID string) *models.TelemetryIntervals {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.current == nil {
+		return nil
+	}
+	p.published[agentID] = *p.current
+	current := *p.current
+	return &current
+}
+
+// HeartbeatFloor 当前心跳间隔下限，尚未计算或不限制时返回0
+func (p *TelemetryPolicy) HeartbeatFloor() time.Duration {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.current == nil {
+		return 0
+	}
+	return time.Duration(p.current.HeartbeatInterval) * time.Second
+}
+
+// Start 按间隔重新计算并下发，直到ctx取消
+func (p *TelemetryPolicy) Start(ctx context.Context) {
+	defer p.tracker.start(time.Now())()
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		done := p.tracker.begin(time.Now())
+		_, err := p.Evaluate(ctx)
+		done(err)
+		if err != nil && ctx.Err() == nil {
+			p.logger.Errorf("计算Agent上报间隔失败: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// WorkerStatus 报告间隔协商的运行状态，附带平滑后的心跳处理耗时
+func (p *TelemetryPolicy) WorkerStatus(now time.Time) models.WorkerStatus {
+	status := p.tracker.status("telemetry_policy", now)
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	status.Gauges = map[string]float64{"heartbeat_latency_seconds": p.latency}
+	return status
+}
+
+// Evaluate 根据当前在线Agent数和平台压力计算间隔，并向间隔变化明显的在线Agent下发
+func (p *TelemetryPolicy) Evaluate(ctx context.Context) (*models.TelemetryIntervals, error) {
+	agents, err := p.agentRepo.ListByLabels(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var online []string
+	for _, agent := range agents {
+		if agent.Status != "offline" {
+			online = append(online, agent.AgentID)
+		}
+	}
+
+	intervals := p.compute(len(online))
+
+	p.mu.Lock()
+	p.current = &intervals
+	var targets []string
+	seen := make(map[string]bool, len(online))
+	for _, agentID := range online {
+		seen[agentID] = true
+		last, ok := p.published[agentID]
+		if !ok {
+			// 首次计算时Agent使用本地间隔，没有下限就无需下发
+			last = models.TelemetryIntervals{}
+		}
+		if significantChange(last, intervals) {
+			p.published[agentID] = intervals
+			targets = append(targets, agentID)
+		} else if !ok {
+			p.published[agentID] = intervals
+		}
+	}
+	// 离线的Agent重新上线时通过注册响应获取最新间隔
+	for agentID := range p.published {
+		if !seen[agentID] {
+			delete(p.published, agentID)
+		}
+	}
+	p.mu.Unlock()
+
+	for _, agentID := range targets {
+		if err := p.publisher.Publish(agentID, models.MsgTypeTelemetryIntervals, intervals); err != nil {
+			p.logger.WithError(err).WithField("agent_id", agentID).Warn("下发上报间隔失败")
+		}
+	}
+
+	if len(targets) > 0 {
+		p.logger.WithFields(logrus.Fields{
+			"fleet_size":         intervals.FleetSize,
+			"pressure":           intervals.Pressure,
+			"heartbeat_interval": intervals.HeartbeatInterval,
+			"metrics_interval":   intervals.MetricsInterval,
+			"agents":             len(targets),
+		}).Info("下发Agent上报间隔")
+	}
+
+	return &intervals, nil
+}
+
+// compute 间隔下限 = max(在线Agent数/期望处理速率, 最小间隔) × 压力系数，不超过分组设置允许的最大值
+func (p *TelemetryPolicy) compute(fleetSize int) models.TelemetryIntervals {
+	p.mu.RLock()
+	latency := p.latency
+	p.mu.RUnlock()
+
+	pressure := 1.0
+	if target := p.cfg.TargetLatency.Seconds(); target > 0 && latency > target {
+		pressure = math.Min(latency/target, p.cfg.MaxPressure)
+	}
+	pressure = math.Round(pressure*100) / 100
+
+	return models.TelemetryIntervals{
+		HeartbeatInterval: intervalFloor(fleetSize, p.cfg.HeartbeatRate, pressure, minHeartbeatInterval, maxHeartbeatInterval),
+		MetricsInterval:   intervalFloor(fleetSize, p.cfg.MetricsRate, pressure, minMetricsInterval, maxMetricsInterval),
+		FleetSize:         fleetSize,
+		Pressure:          pressure,
+		ComputedAt:        time.Now(),
+	}
+}
+
+// intervalFloor 计算单项间隔下限（秒），没有压力且Agent数量不足以超过速率时返回0，表示不限制
+func intervalFloor(fleetSize int, rate, pressure float64, min, max int) int {
+	if rate <= 0 {
+		return 0
+	}
+	base := float64(fleetSize) / rate
+	if base <= float64(min) {
+		if pressure <= 1 {
+			return 0
+		}
+		base = float64(min)
+	}
+	seconds := int(math.Ceil(base * pressure))
+	if seconds > max {
+		return max
+	}
+	return seconds
+}
+
+// significantChange 任一间隔变化超过10%时才重新下发，避免Agent数量小幅波动引起的命令风暴
+func significantChange(old, new models.TelemetryIntervals) bool {
+	changed := func(a, b int) bool {
+		diff := a - b
+		if diff < 0 {
+			diff = -diff
+		}
+		threshold := a / 10
+		if threshold < 1 {
+			threshold = 1
+		}
+		return diff >= threshold
+	}
+	return changed(old.HeartbeatInterval, new.HeartbeatInterval) || changed(old.MetricsInterval, new.MetricsInterval)
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+)
+
+func newFleet(n int) *memAgentRepository {
+	repo := &memAgentRepository{agents: make(map[string]*models.Agent)}
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("agent-%d", i)
+		repo.agents[id] = &models.Agent{AgentID: id, Status: "online"}
+	}
+	return repo
+}
+
+func TestTelemetryPolicy_Evaluate(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	ctx := context.Background()
+
+	repo := newFleet(3)
+	queue := NewCommandQueue(0)
+	policy := NewTelemetryPolicy(TelemetryPolicyConfig{HeartbeatRate: 0.1, MetricsRate: 0.05, TargetLatency: 100 * time.Millisecond}, repo, queue, logger)
+
+	// 尚未计算时注册响应不携带间隔
+	assert.Nil(t, policy.Negotiate("agent-0"))
+
+	// 3个Agent / 每秒0.1次心跳 = 30秒
+	intervals, err := policy.Evaluate(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 30, intervals.HeartbeatInterval)
+	assert.Equal(t, 60, intervals.MetricsInterval)
+	assert.Equal(t, 3, intervals.FleetSize)
+	assert.Equal(t, 1.0, intervals.Pressure)
+
+	commands := queue.Deliver("agent-0")
+	require.Len(t, commands, 1)
+	assert.Equal(t, models.MsgTypeTelemetryIntervals, commands[0].Type)
+	var payload models.TelemetryIntervals
+	require.NoError(t, json.Unmarshal(commands[0].Payload, &payload))
+	assert.Equal(t, 30, payload.HeartbeatInterval)
+
+	t.Run("间隔未明显变化时不重复下发", func(t *testing.T) {
+		queue.Ack("agent-0", []string{commands[0].ID})
+		_, err := policy.Evaluate(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, queue.Deliver("agent-0"))
+	})
+
+	t.Run("平台压力按比例拉长间隔并封顶", func(t *testing.T) {
+		for i := 0; i < 50; i++ {
+			policy.ObserveHeartbeat(time.Second)
+		}
+		intervals, err := policy.Evaluate(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 4.0, intervals.Pressure)
+		assert.Equal(t, 120, intervals.HeartbeatInterval)
+		assert.Equal(t, 240, intervals.MetricsInterval)
+		assert.Len(t, queue.Deliver("agent-1"), 2)
+	})
+
+	t.Run("注册时返回当前间隔", func(t *testing.T) {
+		negotiated := policy.Negotiate("agent-new")
+		require.NotNil(t, negotiated)
+		assert.Equal(t, 120, negotiated.HeartbeatInterval)
+	})
+}
+
+func TestIntervalFloor(t *testing.T) {
+	// 没有压力且Agent不多时不限制
+	assert.Equal(t, 0, intervalFloor(100, 200, 1, minHeartbeatInterval, maxHeartbeatInterval))
+	// 压力存在时至少为最小间隔乘以压力系数
+	assert.Equal(t, 20, intervalFloor(100, 200, 2, minHeartbeatInterval, maxHeartbeatInterval))
+	// 超大规模时封顶
+	assert.Equal(t, maxHeartbeatInterval, intervalFloor(1000000, 200, 1, minHeartbeatInterval, maxHeartbeatInterval))
+	// 未配置速率时不限制
+	assert.Equal(t, 0, intervalFloor(1000000, 0, 4, minHeartbeatInterval, maxHeartbeatInterval))
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"logstash-platform/internal/platform/models"
+)
+
+// ErrInvalidAssertion 测试断言缺少必要参数或正则表达式无法编译
+var ErrInvalidAssertion = errors.New("测试断言无效")
+
+// AssertionSet 编译后的样本测试断言
+type AssertionSet struct {
+	assertions []models.TestAssertion
+	patterns   []*regexp.Regexp // 与assertions按下标对应，非regexp断言为nil
+}
+
+// NewAssertionSet 校验并编译断言，samples为样本数，用于检查断言指定的样本下标
+func NewAssertionSet(assertions []models.TestAssertion, samples int) (*AssertionSet, error) {
+	set := &AssertionSet{
+		assertions: assertions,
+		patterns:   make([]*regexp.Regexp, len(assertions)),
+	}
+	for i, assertion := range assertions {
+		if assertion.Sample != nil && (*assertion.Sample < 0 || *assertion.Sample >= samples) {
+			return nil, fmt.Errorf("%w: 第%d个断言的样本下标超出范围: %d", ErrInvalidAssertion, i+1, *assertion.Sample)
+		}
+		switch assertion.Type {
+		case models.AssertTag:
+			if assertion.Tag == "" {
+				return nil, fmt.Errorf("%w: 第%d个断言缺少tag", ErrInvalidAssertion, i+1)
+			}
+			continue
+		case models.AssertRegexp:
+			pattern, err := regexp.Compile(assertion.Pattern)
+			if err != nil || assertion.Pattern == "" {
+				return nil, fmt.Errorf("%w: 第%d个断言的正则表达式无效: %s", ErrInvalidAssertion, i+1, assertion.Pattern)
+			}
+			set.patterns[i] = pattern
+		}
+		if assertion.Field == "" {
+			return nil, fmt.Errorf("%w: 第%d个断言缺少field", ErrInvalidAssertion, i+1)
+		}
+	}
+	return set, nil
+}
+
+// Empty 是否没有任何断言
+func (s *AssertionSet) Empty() bool {
+	return s == nil || len(s.assertions) == 0
+}
+
+// Check 检查作用于第index条样本的断言，样本处理失败时全部断言不通过
+func (s *AssertionSet) Check(index int, output models.TestOutput) (bool, []models.AssertionOutcome) {
+	if s.Empty() {
+		return true, nil
+	}
+
+	passed := output.Error == ""
+	var outcomes []models.AssertionOutcome
+	for i, assertion := range s.assertions {
+		if assertion.Sample != nil && *assertion.Sample != index {
+			continue
+		}
+		outcome := models.AssertionOutcome{Assertion: assertion}
+		if output.Error != "" {
+			outcome.Message = "样本处理失败: " + output.Error
+		} else {
+			s.check(i, output.Output, &outcome)
+		}
+		if !outcome.Passed {
+			passed = false
+		}
+		outcomes = append(outcomes, outcome)
+	}
+	return passed, outcomes
+}
+
+// check 对一个输出事件检查第i个断言
+func (s *AssertionSet) check(i int, event map[string]interface{}, outcome *models.AssertionOutcome) {
+	assertion := s.assertions[i]
+	if assertion.Type == models.AssertTag {
+		outcome.Passed = containsTag(event["tags"], assertion.Tag)
+		if !outcome.Passed {
+			outcome.Actual = event["tags"]
+			outcome.Message = "tags中没有该标签"
+		}
+		return
+	}
+
+	value, ok := lookupField(event, assertion.Field)
+	if !ok {
+		outcome.Message = "输出中没有该字段"
+		return
+	}
+	outcome.Actual = value
+
+	switch assertion.Type {
+	case models.AssertExists:
+		outcome.Passed = true
+	case models.AssertEquals:
+		outcome.Passed = jsonEqual(assertion.Value, value)
+		if !outcome.Passed {
+			outcome.Message = "字段值与期望不一致"
+		}
+	case models.AssertRegexp:
+		str, ok := value.(string)
+		outcome.Passed = ok && s.patterns[i].MatchString(str)
+		if !outcome.Passed {
+			outcome.Message = "字段值不匹配正则表达式"
+		}
+	}
+}
+
+// containsTag tags字段中是否有给定标签，兼容单个字符串的写法
+func containsTag(tags interface{}, tag string) bool {
+	switch tags := tags.(type) {
+	case string:
+		return tags == tag
+	case []string:
+		for _, t := range tags {
+			if t == tag {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, t := range tags {
+			if t == tag {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+)
+
+func TestNewAssertionSet_Validates(t *testing.T) {
+	second := 1
+	outOfRange := 3
+	_, err := NewAssertionSet([]models.TestAssertion{
+		{Type: models.AssertEquals, Field: "status", Value: 200},
+		{Type: models.AssertTag, Tag: "nginx", Sample: &second},
+	}, 2)
+	assert.NoError(t, err)
+
+	for name, assertion := range map[string]models.TestAssertion{
+		"缺少字段":   {Type: models.AssertExists},
+		"缺少标签":   {Type: models.AssertTag},
+		"正则无效":   {Type: models.AssertRegexp, Field: "message", Pattern: "("},
+		"正则为空":   {Type: models.AssertRegexp, Field: "message"},
+		"样本下标越界": {Type: models.AssertExists, Field: "message", Sample: &outOfRange},
+	} {
+		_, err := NewAssertionSet([]models.TestAssertion{assertion}, 2)
+		assert.ErrorIs(t, err, ErrInvalidAssertion, name)
+	}
+}
+
+func TestAssertionSet_Check(t *testing.T) {
+	first := 0
+	set, err := NewAssertionSet([]models.TestAssertion{
+		{Type: models.AssertEquals, Field: "http.status", Value: float64(200)},
+		{Type: models.AssertExists, Field: "client.ip"},
+		{Type: models.AssertTag, Tag: "nginx"},
+		{Type: models.AssertRegexp, Field: "message", Pattern: `^GET /`, Sample: &first},
+	}, 3)
+	require.NoError(t, err)
+
+	passed, outcomes := set.Check(0, models.TestOutput{Output: map[string]interface{}{
+		"message": "GET /index.html",
+		"http":    map[string]interface{}{"status": 200},
+		"client":  map[string]interface{}{"ip": "10.0.0.1"},
+		"tags":    []interface{}{"nginx", "access"},
+	}})
+	assert.True(t, passed)
+	require.Len(t, outcomes, 4)
+	for _, outcome := range outcomes {
+		assert.True(t, outcome.Passed, outcome.Assertion.Type)
+	}
+
+	passed, outcomes = set.Check(1, models.TestOutput{Output: map[string]interface{}{
+		"message": "POST /login",
+		"http":    map[string]interface{}{"status": 500},
+		"tags":    "_grokparsefailure",
+	}})
+	assert.False(t, passed)
+	require.Len(t, outcomes, 3, "只作用于第一条样本的断言不检查")
+	assert.False(t, outcomes[0].Passed)
+	assert.Equal(t, 500, outcomes[0].Actual)
+	assert.Equal(t, "输出中没有该字段", outcomes[1].Message)
+	assert.False(t, outcomes[2].Passed)
+
+	passed, outcomes = set.Check(2, models.TestOutput{Error: "解析失败"})
+	assert.False(t, passed)
+	for _, outcome := range outcomes {
+		assert.Contains(t, outcome.Message, "解析失败")
+	}
+
+	var empty *AssertionSet
+	passed, outcomes = empty.Check(0, models.TestOutput{Error: "解析失败"})
+	assert.True(t, passed, "没有断言时不判定样本")
+	assert.Nil(t, outcomes)
+}
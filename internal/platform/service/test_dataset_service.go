@@ -0,0 +1,316 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// 测试数据集相关错误，处理器通过 errors.Is 映射为HTTP状态码
+var (
+	ErrDatasetNotFound = errors.New("测试数据集不存在")
+	ErrDatasetExists   = errors.New("测试数据集已存在")
+	ErrDatasetInvalid  = errors.New("测试数据集验证失败")
+)
+
+// TestDatasetService 测试数据集服务接口
+// 数据集挂在配置上，查看和运行需要配置的读权限，增删改需要编辑权限
+type TestDatasetService interface {
+	CreateDataset(ctx context.Context, configID string, req *models.CreateTestDatasetRequest, userID string) (*models.TestDataset, error)
+	UpdateDataset(ctx context.Context, configID, datasetID string, req *models.UpdateTestDatasetRequest, userID string) (*models.TestDataset, error)
+	DeleteDataset(ctx context.Context, configID, datasetID string) error
+	GetDataset(ctx context.Context, configID, datasetID string) (*models.TestDataset, error)
+	ListDatasets(ctx context.Context, configID string) ([]*models.TestDataset, error)
+	// RecordRun 保存数据集最近一次的运行结果
+	RecordRun(ctx context.Context, dataset *models.TestDataset, run *models.DatasetRun) error
+}
+
+// testDatasetService 测试数据集服务实现
+type testDatasetService struct {
+	datasetRepo repository.TestDatasetRepository
+	configRepo  repository.ConfigRepository
+	logger      *logrus.Logger
+}
+
+// NewTestDatasetService 创建测试数据集服务
+func NewTestDatasetService(datasetRepo repository.TestDatasetRepository, configRepo repository.ConfigRepository, logger *logrus.Logger) TestDatasetService {
+	return &testDatasetService{
+		datasetRepo: datasetRepo,
+		configRepo:  configRepo,
+		logger:      logger,
+	}
+}
+
+// CreateDataset 在配置上保存测试数据集，同一配置上名称唯一
+func (s *testDatasetService) CreateDataset(ctx context.Context, configID string, req *models.CreateTestDatasetRequest, userID string) (*models.TestDataset, error) {
+	if err := s.authorize(ctx, configID, models.PermissionEdit); err != nil {
+		return nil, err
+	}
+	if err := validateDatasetSamples(req.Samples); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.datasetRepo.ListByConfig(elasticsearch.WithPrimaryRead(ctx), configID)
+	if err != nil {
+		return nil, err
+	}
+	for _, dataset := range existing {
+		if dataset.Name == req.Name {
+			return nil, fmt.Errorf("%w: %s", ErrDatasetExists, req.Name)
+		}
+	}
+
+	dataset := &models.TestDataset{
+		ID:          uuid.New().String(),
+		ConfigID:    configID,
+		Name:        req.Name,
+		Description: req.Description,
+		Samples:     req.Samples,
+		CreatedBy:   userID,
+		UpdatedBy:   userID,
+	}
+	if err := s.datasetRepo.Create(ctx, dataset); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"config_id": configID,
+		"dataset":   dataset.Name,
+		"samples":   len(dataset.Samples),
+		"user_id":   userID,
+	}).Info("保存测试数据集成功")
+
+	return dataset, nil
+}
+
+// UpdateDataset 更新数据集的描述和样本，样本变化后之前的运行结果不再有效
+func (s *testDatasetService) UpdateDataset(ctx context.Context, configID, datasetID string, req *models.UpdateTestDatasetRequest, userID string) (*models.TestDataset, error) {
+	if err := s.authorize(ctx, configID, models.PermissionEdit); err != nil {
+		return nil, err
+	}
+	dataset, err := s.dataset(elasticsearch.WithPrimaryRead(ctx), configID, datasetID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateDatasetSamples(req.Samples); err != nil {
+		return nil, err
+	}
+
+	dataset.Description = req.Description
+	dataset.Samples = req.Samples
+	dataset.LastRun = nil
+	dataset.UpdatedBy = userID
+	if err := s.datasetRepo.Update(ctx, dataset); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"config_id": configID,
+		"dataset":   dataset.Name,
+		"user_id":   userID,
+	}).Info("更新测试数据集成功")
+
+	return dataset, nil
+}
+
+// DeleteDataset 删除测试数据集
+func (s *testDatasetService) DeleteDataset(ctx context.Context, configID, datasetID string) error {
+	if err := s.authorize(ctx, configID, models.PermissionEdit); err != nil {
+		return err
+	}
+	dataset, err := s.dataset(ctx, configID, datasetID)
+	if err != nil {
+		return err
+	}
+	if err := s.datasetRepo.Delete(ctx, dataset.ID); err != nil {
+		return err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"config_id": configID,
+		"dataset":   dataset.Name,
+	}).Info("删除测试数据集成功")
+	return nil
+}
+
+// GetDataset 获取配置上的测试数据集
+func (s *testDatasetService) GetDataset(ctx context.Context, configID, datasetID string) (*models.TestDataset, error) {
+	if err := s.authorize(ctx, configID, models.PermissionRead); err != nil {
+		return nil, err
+	}
+	return s.dataset(ctx, configID, datasetID)
+}
+
+// ListDatasets 获取配置上的全部测试数据集
+func (s *testDatasetService) ListDatasets(ctx context.Context, configID string) ([]*models.TestDataset, error) {
+	if err := s.authorize(ctx, configID, models.PermissionRead); err != nil {
+		return nil, err
+	}
+	return s.datasetRepo.ListByConfig(ctx, configID)
+}
+
+// RecordRun 保存数据集最近一次的运行结果
+func (s *testDatasetService) RecordRun(ctx context.Context, dataset *models.TestDataset, run *models.DatasetRun) error {
+	dataset.LastRun = run
+	if err := s.datasetRepo.Update(ctx, dataset); err != nil {
+		return fmt.Errorf("保存测试数据集运行结果失败: %w", err)
+	}
+	return nil
+}
+
+// authorize 检查配置存在且当前身份具有指定权限
+func (s *testDatasetService) authorize(ctx context.Context, configID, permission string) error {
+	config, err := s.configRepo.GetByID(ctx, configID)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrConfigNotFound, err)
+	}
+	return authorizeConfig(ctx, config, permission)
+}
+
+// dataset 获取数据集并确认属于该配置
+func (s *testDatasetService) dataset(ctx context.Context, configID, datasetID string) (*models.TestDataset, error) {
+	dataset, err := s.datasetRepo.GetByID(ctx, datasetID)
+	if err != nil {
+		if err.Error() == "文档不存在" {
+			return nil, fmt.Errorf("%w: %s", ErrDatasetNotFound, datasetID)
+		}
+		return nil, err
+	}
+	if dataset.ConfigID != configID {
+		return nil, fmt.Errorf("%w: %s", ErrDatasetNotFound, datasetID)
+	}
+	return dataset, nil
+}
+
+// validateDatasetSamples 校验期望输出的字段路径
+func validateDatasetSamples(samples []models.DatasetSample) error {
+	for i, sample := range samples {
+		for field := range sample.Expected {
+			if field == "" || strings.HasPrefix(field, ".") || strings.HasSuffix(field, ".") || strings.Contains(field, "..") {
+				return fmt.Errorf("%w: 第%d条样本的字段路径无效: %s", ErrDatasetInvalid, i+1, field)
+			}
+		}
+	}
+	return nil
+}
+
+// SampleProcessor 用配置处理一组样本，返回与样本按下标对应的处理结果
+type SampleProcessor func(config *models.Config, samples []string) []models.TestOutput
+
+// RunDatasets 用配置的当前版本运行给定的测试数据集，逐个断言并保存每个数据集的运行结果
+// 全部数据集的样本合并后一次处理，再按数据集切分；任一数据集失败时整体状态为failed
+func RunDatasets(ctx context.Context, datasets TestDatasetService, config *models.Config, list []*models.TestDataset, process SampleProcessor, logger *logrus.Logger) *models.RunTestDatasetsResult {
+	var samples []string
+	for _, dataset := range list {
+		for _, sample := range dataset.Samples {
+			samples = append(samples, sample.Input)
+		}
+	}
+	started := time.Now()
+	var outputs []models.TestOutput
+	if len(samples) > 0 {
+		outputs = process(config, samples)
+	}
+
+	result := &models.RunTestDatasetsResult{
+		ConfigID:      config.ID,
+		ConfigVersion: config.Version,
+		Status:        models.DatasetRunPassed,
+		Runs:          make([]models.DatasetRun, 0, len(list)),
+	}
+	offset := 0
+	for _, dataset := range list {
+		run := EvaluateDataset(dataset, config.Version, outputs[offset:offset+len(dataset.Samples)], started)
+		offset += len(dataset.Samples)
+		if run.Status == models.DatasetRunFailed {
+			result.Status = models.DatasetRunFailed
+		}
+		if err := datasets.RecordRun(ctx, dataset, run); err != nil {
+			logger.WithError(err).WithField("dataset", dataset.Name).Warn("保存测试数据集运行结果失败")
+		}
+		result.Runs = append(result.Runs, *run)
+	}
+	return result
+}
+
+// EvaluateDataset 用样本的处理结果检查数据集的断言，outputs与样本按下标对应
+// 期望输出中的每个字段是一个断言，取值按JSON语义比较（整数与等值的浮点数相等）；
+// 没有期望输出的样本只断言处理成功
+func EvaluateDataset(dataset *models.TestDataset, configVersion int, outputs []models.TestOutput, started time.Time) *models.DatasetRun {
+	run := &models.DatasetRun{
+		DatasetID:     dataset.ID,
+		DatasetName:   dataset.Name,
+		ConfigVersion: configVersion,
+		Assertions:    []models.AssertionResult{},
+		StartedAt:     started,
+	}
+
+	for i, sample := range dataset.Samples {
+		output := outputs[i]
+		if len(sample.Expected) == 0 {
+			run.Assertions = append(run.Assertions, models.AssertionResult{Sample: i, Passed: output.Error == "", Error: output.Error})
+			continue
+		}
+
+		fields := make([]string, 0, len(sample.Expected))
+		for field := range sample.Expected {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+
+		for _, field := range fields {
+			assertion := models.AssertionResult{Sample: i, Field: field, Expected: sample.Expected[field]}
+			switch actual, ok := lookupField(output.Output, field); {
+			case output.Error != "":
+				assertion.Error = output.Error
+			case !ok:
+				assertion.Error = "输出中没有该字段"
+			default:
+				assertion.Actual = actual
+				assertion.Passed = jsonEqual(assertion.Expected, actual)
+			}
+			run.Assertions = append(run.Assertions, assertion)
+		}
+	}
+
+	for _, assertion := range run.Assertions {
+		if assertion.Passed {
+			run.Passed++
+		} else {
+			run.Failed++
+		}
+	}
+	run.Status = models.DatasetRunPassed
+	if run.Failed > 0 {
+		run.Status = models.DatasetRunFailed
+	}
+	run.DurationMs = time.Since(started).Milliseconds()
+	return run
+}
+
+// jsonEqual 按JSON编码后的取值比较，消除int与float64等表示上的差异
+func jsonEqual(a, b interface{}) bool {
+	normalize := func(v interface{}) interface{} {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return v
+		}
+		var out interface{}
+		if err := json.Unmarshal(data, &out); err != nil {
+			return v
+		}
+		return out
+	}
+	return reflect.DeepEqual(normalize(a), normalize(b))
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/tests/mocks"
+)
+
+// memDatasetRepository 内存测试数据集仓库
+type memDatasetRepository struct {
+	mu       sync.Mutex
+	datasets map[string]*models.TestDataset
+}
+
+func (r *memDatasetRepository) Create(ctx context.Context, dataset *models.TestDataset) error {
+	return r.Update(ctx, dataset)
+}
+
+func (r *memDatasetRepository) Update(ctx context.Context, dataset *models.TestDataset) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *dataset
+	r.datasets[dataset.ID] = &copied
+	return nil
+}
+
+func (r *memDatasetRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.datasets, id)
+	return nil
+}
+
+func (r *memDatasetRepository) GetByID(ctx context.Context, id string) (*models.TestDataset, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	dataset, ok := r.datasets[id]
+	if !ok {
+		return nil, fmt.Errorf("文档不存在")
+	}
+	copied := *dataset
+	return &copied, nil
+}
+
+func (r *memDatasetRepository) ListByConfig(ctx context.Context, configID string) ([]*models.TestDataset, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var datasets []*models.TestDataset
+	for _, dataset := range r.datasets {
+		if dataset.ConfigID == configID {
+			copied := *dataset
+			datasets = append(datasets, &copied)
+		}
+	}
+	sort.Slice(datasets, func(i, j int) bool { return datasets[i].Name < datasets[j].Name })
+	return datasets, nil
+}
+
+func TestTestDatasetService(t *testing.T) {
+	ctx := context.Background()
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	configRepo := new(mocks.MockConfigRepository)
+	configRepo.On("GetByID", mock.Anything, "cfg-1").Return(&models.Config{ID: "cfg-1", Version: 2}, nil)
+	configRepo.On("GetByID", mock.Anything, "cfg-2").Return(&models.Config{ID: "cfg-2", Version: 1}, nil)
+	configRepo.On("GetByID", mock.Anything, "missing").Return(nil, fmt.Errorf("文档不存在"))
+
+	repo := &memDatasetRepository{datasets: make(map[string]*models.TestDataset)}
+	svc := NewTestDatasetService(repo, configRepo, logger)
+
+	req := &models.CreateTestDatasetRequest{
+		Name:    "nginx-access",
+		Samples: []models.DatasetSample{{Input: "GET /", Expected: map[string]interface{}{"http.method": "GET"}}},
+	}
+	dataset, err := svc.CreateDataset(ctx, "cfg-1", req, "alice")
+	require.NoError(t, err)
+	assert.NotEmpty(t, dataset.ID)
+	assert.Equal(t, "cfg-1", dataset.ConfigID)
+
+	_, err = svc.CreateDataset(ctx, "cfg-1", req, "alice")
+	assert.ErrorIs(t, err, ErrDatasetExists, "同一配置上名称唯一")
+	_, err = svc.CreateDataset(ctx, "cfg-2", req, "alice")
+	assert.NoError(t, err, "不同配置可以使用相同名称")
+	_, err = svc.CreateDataset(ctx, "missing", req, "alice")
+	assert.ErrorIs(t, err, ErrConfigNotFound)
+	_, err = svc.CreateDataset(ctx, "cfg-1", &models.CreateTestDatasetRequest{
+		Name:    "bad",
+		Samples: []models.DatasetSample{{Input: "x", Expected: map[string]interface{}{"a..b": 1}}},
+	}, "alice")
+	assert.ErrorIs(t, err, ErrDatasetInvalid)
+
+	_, err = svc.GetDataset(ctx, "cfg-2", dataset.ID)
+	assert.ErrorIs(t, err, ErrDatasetNotFound, "不能通过其他配置访问数据集")
+
+	require.NoError(t, svc.RecordRun(ctx, dataset, &models.DatasetRun{DatasetID: dataset.ID, Status: models.DatasetRunPassed}))
+	got, err := svc.GetDataset(ctx, "cfg-1", dataset.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got.LastRun)
+
+	updated, err := svc.UpdateDataset(ctx, "cfg-1", dataset.ID, &models.UpdateTestDatasetRequest{
+		Samples: []models.DatasetSample{{Input: "POST /"}},
+	}, "bob")
+	require.NoError(t, err)
+	assert.Nil(t, updated.LastRun, "样本变化后清除旧的运行结果")
+	assert.Equal(t, "bob", updated.UpdatedBy)
+
+	list, err := svc.ListDatasets(ctx, "cfg-1")
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+
+	require.NoError(t, svc.DeleteDataset(ctx, "cfg-1", dataset.ID))
+	assert.ErrorIs(t, svc.DeleteDataset(ctx, "cfg-1", dataset.ID), ErrDatasetNotFound)
+}
+
+func TestEvaluateDataset(t *testing.T) {
+	dataset := &models.TestDataset{
+		ID:   "ds-1",
+		Name: "nginx-access",
+		Samples: []models.DatasetSample{
+			{Input: "GET / 200", Expected: map[string]interface{}{"http.method": "GET", "http.status": float64(200), "user": "bob"}},
+			{Input: "plain"},
+			{Input: "broken", Expected: map[string]interface{}{"http.method": "GET"}},
+		},
+	}
+	outputs := []models.TestOutput{
+		{Output: map[string]interface{}{
+			"http": map[string]interface{}{"method": "GET", "status": 200},
+			"user": "alice",
+		}},
+		{Output: map[string]interface{}{"message": "plain"}},
+		{Error: "_grokparsefailure"},
+	}
+
+	run := EvaluateDataset(dataset, 3, outputs, time.Now())
+	assert.Equal(t, "nginx-access", run.DatasetName)
+	assert.Equal(t, 3, run.ConfigVersion)
+	assert.Equal(t, models.DatasetRunFailed, run.Status)
+	assert.Equal(t, 3, run.Passed)
+	assert.Equal(t, 2, run.Failed)
+	require.Len(t, run.Assertions, 5)
+
+	assert.Equal(t, "http.method", run.Assertions[0].Field, "断言按字段排序")
+	assert.True(t, run.Assertions[0].Passed)
+	assert.True(t, run.Assertions[1].Passed, "整数与等值的浮点数相等")
+	assert.False(t, run.Assertions[2].Passed)
+	assert.Equal(t, "alice", run.Assertions[2].Actual)
+	assert.Equal(t, models.AssertionResult{Sample: 1, Passed: true}, run.Assertions[3], "没有期望输出时只断言处理成功")
+	assert.Equal(t, "_grokparsefailure", run.Assertions[4].Error)
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/filestore"
+)
+
+// 测试文件相关错误，处理器通过 errors.Is 映射为HTTP状态码
+var (
+	ErrTestFileNotFound = errors.New("测试文件不存在")
+	ErrTestFileTooLarge = errors.New("测试文件过大")
+	ErrTestFileInvalid  = errors.New("测试文件无法读取")
+)
+
+// 测试文件的默认参数
+const (
+	defaultTestFileCleanupInterval = 10 * time.Minute
+	maxTestFileLineBytes           = 1 << 20 // 单行的字节数上限
+)
+
+// TestFileConfig 测试文件参数
+type TestFileConfig struct {
+	CleanupInterval time.Duration // 清理过期文件的间隔，默认10分钟
+}
+
+// TestFileService 管理上传的测试文件并把文件内容读取为测试样本
+// 文件属于上传时所在的项目，其他项目的请求视为文件不存在
+type TestFileService struct {
+	store    filestore.Store
+	interval time.Duration
+	logger   *logrus.Logger
+	now      func() time.Time
+	tracker  loopTracker
+}
+
+// NewTestFileService 创建测试文件服务
+func NewTestFileService(cfg TestFileConfig, store filestore.Store, logger *logrus.Logger) *TestFileService {
+	if cfg.CleanupInterval <= 0 {
+		cfg.CleanupInterval = defaultTestFileCleanupInterval
+	}
+	return &TestFileService{
+		store:    store,
+		interval: cfg.CleanupInterval,
+		logger:   logger,
+		now:      time.Now,
+		tracker:  loopTracker{interval: cfg.CleanupInterval},
+	}
+}
+
+// Upload 保存上传的测试文件，超过大小上限时返回 ErrTestFileTooLarge
+func (s *TestFileService) Upload(ctx context.Context, name string, r io.Reader) (*models.TestFile, error) {
+	file, err := s.store.Put(ctx, filestore.File{Name: name, Scope: models.ProjectOf(models.ProjectFrom(ctx))}, r)
+	if err != nil {
+		if errors.Is(err, filestore.ErrTooLarge) {
+			return nil, fmt.Errorf("%w: %w", ErrTestFileTooLarge, err)
+		}
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"file_id": file.ID,
+		"name":    file.Name,
+		"size":    file.Size,
+	}).Info("上传测试文件")
+	return testFileOf(file), nil
+}
+
+// Get 获取测试文件信息
+func (s *TestFileService) Get(ctx context.Context, id string) (*models.TestFile, error) {
+	file, err := s.stat(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return testFileOf(file), nil
+}
+
+// Delete 删除测试文件
+func (s *TestFileService) Delete(ctx context.Context, id string) error {
+	if _, err := s.stat(ctx, id); err != nil {
+		return err
+	}
+	if err := s.store.Delete(ctx, id); err != nil {
+		if errors.Is(err, filestore.ErrNotFound) {
+			return fmt.Errorf("%w: %s", ErrTestFileNotFound, id)
+		}
+		return err
+	}
+	return nil
+}
+
+// ReadSamples 按行读取文件作为样本，跳过空行，最多读取maxLines行，gzip压缩的文件自动解压
+// 读取的样本总字节数超过maxBytes或单行超过1MB时返回 ErrTestFileTooLarge
+func (s *TestFileService) ReadSamples(ctx context.Context, id string, maxLines int, maxBytes int64) ([]string, error) {
+	if _, err := s.stat(ctx, id); err != nil {
+		return nil, err
+	}
+	rc, _, err := s.store.Open(ctx, id)
+	if err != nil {
+		if errors.Is(err, filestore.ErrNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrTestFileNotFound, id)
+		}
+		return nil, err
+	}
+	defer rc.Close()
+
+	var r io.Reader = bufio.NewReader(rc)
+	if magic, _ := r.(*bufio.Reader).Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrTestFileInvalid, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxTestFileLineBytes)
+	var samples []string
+	var size int64
+	for line := 1; len(samples) < maxLines && scanner.Scan(); line++ {
+		sample := string(bytes.TrimSuffix(scanner.Bytes(), []byte("\r")))
+		if sample == "" {
+			continue
+		}
+		if size += int64(len(sample)); size > maxBytes {
+			return nil, fmt.Errorf("%w: 读取到第 %d 行时样本超过 %d 字节", ErrTestFileTooLarge, line, maxBytes)
+		}
+		samples = append(samples, sample)
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, fmt.Errorf("%w: 单行超过 %d 字节", ErrTestFileTooLarge, maxTestFileLineBytes)
+		}
+		return nil, fmt.Errorf("%w: %w", ErrTestFileInvalid, err)
+	}
+	return samples, nil
+}
+
+// Start 按间隔清理过期的测试文件，直到ctx取消
+// 文件保存在接收上传的副本上，每个副本都需要运行
+func (s *TestFileService) Start(ctx context.Context) {
+	defer s.tracker.start(s.now())()
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		done := s.tracker.begin(s.now())
+		purged, err := s.store.Purge(ctx, s.now())
+		done(err)
+		if err != nil && ctx.Err() == nil {
+			s.logger.Errorf("清理过期测试文件失败: %v", err)
+		} else if purged > 0 {
+			s.logger.WithField("purged", purged).Info("清理过期测试文件")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// WorkerStatus 报告过期测试文件清理的运行状态
+func (s *TestFileService) WorkerStatus(now time.Time) models.WorkerStatus {
+	return s.tracker.status("test_file_cleanup", now)
+}
+
+// stat 获取文件信息并确认属于请求所在的项目
+func (s *TestFileService) stat(ctx context.Context, id string) (*filestore.File, error) {
+	file, err := s.store.Stat(ctx, id)
+	if err != nil {
+		if errors.Is(err, filestore.ErrNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrTestFileNotFound, id)
+		}
+		return nil, err
+	}
+	if !models.InProject(ctx, file.Scope) {
+		return nil, fmt.Errorf("%w: %s", ErrTestFileNotFound, id)
+	}
+	return file, nil
+}
+
+func testFileOf(file *filestore.File) *models.TestFile {
+	return &models.TestFile{
+		ID:        file.ID,
+		Name:      file.Name,
+		Size:      file.Size,
+		CreatedAt: file.CreatedAt,
+		ExpiresAt: file.ExpiresAt,
+	}
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/filestore"
+)
+
+func TestTestFileService_ReadSamples(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	store, err := filestore.NewLocal(t.TempDir(), 1<<20, time.Hour)
+	require.NoError(t, err)
+	s := NewTestFileService(TestFileConfig{}, store, logger)
+	ctx := models.WithProject(context.Background(), "payments")
+
+	t.Run("按行读取并跳过空行", func(t *testing.T) {
+		file, err := s.Upload(ctx, "access.log", strings.NewReader("line1\r\n\nline2\nline3\n"))
+		require.NoError(t, err)
+		samples, err := s.ReadSamples(ctx, file.ID, 10, 1024)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"line1", "line2", "line3"}, samples)
+
+		samples, err = s.ReadSamples(ctx, file.ID, 2, 1024)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"line1", "line2"}, samples, "最多读取maxLines行")
+	})
+
+	t.Run("自动解压gzip文件", func(t *testing.T) {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		gz.Write([]byte("a\nb\n"))
+		require.NoError(t, gz.Close())
+
+		file, err := s.Upload(ctx, "access.log.gz", &buf)
+		require.NoError(t, err)
+		samples, err := s.ReadSamples(ctx, file.ID, 10, 1024)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "b"}, samples)
+	})
+
+	t.Run("样本超过字节数上限", func(t *testing.T) {
+		file, err := s.Upload(ctx, "big.log", strings.NewReader("12345\n67890\n"))
+		require.NoError(t, err)
+		_, err = s.ReadSamples(ctx, file.ID, 10, 8)
+		assert.ErrorIs(t, err, ErrTestFileTooLarge)
+		assert.Contains(t, err.Error(), "第 2 行")
+	})
+
+	t.Run("其他项目的文件视为不存在", func(t *testing.T) {
+		file, err := s.Upload(ctx, "access.log", strings.NewReader("x\n"))
+		require.NoError(t, err)
+
+		other := models.WithProject(context.Background(), "search")
+		_, err = s.Get(other, file.ID)
+		assert.ErrorIs(t, err, ErrTestFileNotFound)
+		_, err = s.ReadSamples(other, file.ID, 10, 1024)
+		assert.ErrorIs(t, err, ErrTestFileNotFound)
+		assert.ErrorIs(t, s.Delete(other, file.ID), ErrTestFileNotFound)
+
+		require.NoError(t, s.Delete(ctx, file.ID))
+		_, err = s.Get(ctx, file.ID)
+		assert.ErrorIs(t, err, ErrTestFileNotFound)
+	})
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+)
+
+// 测试门禁相关错误，处理器通过 errors.Is 映射为HTTP状态码
+var (
+	ErrTestGateFailed   = errors.New("配置未在有效期内通过测试，无法部署")
+	ErrTestGateOverride = errors.New("当前角色不能跳过测试门禁")
+)
+
+// defaultTestGateMaxAge 测试结果的默认有效期
+const defaultTestGateMaxAge = 7 * 24 * time.Hour
+
+// TestGate 部署前的测试门禁：配置当前版本的测试状态必须为passed，且测试在有效期内完成
+// 具备overrideRole角色的用户可以在部署请求中跳过门禁，跳过记录在部署上
+type TestGate struct {
+	maxAge       time.Duration
+	overrideRole string
+	logger       *logrus.Logger
+	now          func() time.Time
+}
+
+// NewTestGate 创建测试门禁，maxAge<=0时使用默认有效期，overrideRole为空时只有管理员可以跳过
+func NewTestGate(maxAge time.Duration, overrideRole string, logger *logrus.Logger) *TestGate {
+	if maxAge <= 0 {
+		maxAge = defaultTestGateMaxAge
+	}
+	if !models.ValidRole(overrideRole) {
+		overrideRole = models.RoleAdmin
+	}
+	return &TestGate{
+		maxAge:       maxAge,
+		overrideRole: overrideRole,
+		logger:       logger,
+		now:          time.Now,
+	}
+}
+
+// Check 检查配置是否可以部署，skip为true时校验当前身份能否跳过门禁
+// 未启用认证（上下文中没有身份）时允许跳过
+func (g *TestGate) Check(ctx context.Context, config *models.Config, skip bool) error {
+	if skip {
+		if err := g.checkOverride(ctx); err != nil {
+			return err
+		}
+		principal := models.PrincipalFrom(ctx)
+		fields := logrus.Fields{"config_id": config.ID, "version": config.Version, "test_status": config.TestStatus}
+		if principal != nil {
+			fields["user"] = principal.User
+		}
+		g.logger.WithFields(fields).Warn("跳过测试门禁部署配置")
+		return nil
+	}
+
+	return g.checkTested(config)
+}
+
+// checkOverride 检查当前身份能否跳过门禁
+func (g *TestGate) checkOverride(ctx context.Context) error {
+	principal := models.PrincipalFrom(ctx)
+	if principal != nil && !models.RoleAllows(principal.Role, g.overrideRole) {
+		return fmt.Errorf("%w: 需要 %s 角色", ErrTestGateOverride, g.overrideRole)
+	}
+	return nil
+}
+
+// checkTested 检查配置当前版本是否在有效期内通过测试
+func (g *TestGate) checkTested(config *models.Config) error {
+	if config.TestStatus != models.TestStatusPassed {
+		return fmt.Errorf("%w: 版本 %d 的测试状态为 %s，需要先通过样本测试", ErrTestGateFailed, config.Version, config.TestStatus)
+	}
+	if config.TestedAt == nil || g.now().Sub(*config.TestedAt) > g.maxAge {
+		tested := "未知"
+		if config.TestedAt != nil {
+			tested = config.TestedAt.Format(time.RFC3339)
+		}
+		return fmt.Errorf("%w: 版本 %d 最近一次通过测试的时间为 %s，超过有效期 %d 天，请重新测试",
+			ErrTestGateFailed, config.Version, tested, int(g.maxAge.Hours()/24))
+	}
+	return nil
+}
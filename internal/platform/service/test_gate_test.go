@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"logstash-platform/internal/platform/models"
+)
+
+func TestTestGate_Check(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	gate := NewTestGate(3*24*time.Hour, models.RoleAdmin, logrus.New())
+	gate.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	recent := now.Add(-48 * time.Hour)
+	stale := now.Add(-96 * time.Hour)
+
+	assert.NoError(t, gate.Check(ctx, &models.Config{ID: "cfg", TestStatus: models.TestStatusPassed, TestedAt: &recent}, false))
+
+	err := gate.Check(ctx, &models.Config{ID: "cfg", Version: 2, TestStatus: models.TestStatusPassed, TestedAt: &stale}, false)
+	assert.ErrorIs(t, err, ErrTestGateFailed)
+	assert.Contains(t, err.Error(), "超过有效期 3 天")
+
+	err = gate.Check(ctx, &models.Config{ID: "cfg", Version: 2, TestStatus: models.TestStatusFailed, TestedAt: &recent}, false)
+	assert.ErrorIs(t, err, ErrTestGateFailed)
+	assert.Contains(t, err.Error(), "failed")
+
+	assert.ErrorIs(t, gate.Check(ctx, &models.Config{ID: "cfg", TestStatus: models.TestStatusPassed}, false), ErrTestGateFailed, "没有测试时间视为过期")
+
+	untested := &models.Config{ID: "cfg", TestStatus: models.TestStatusUntested}
+	editor := models.WithPrincipal(ctx, &models.Principal{User: "alice", Role: models.RoleEditor})
+	admin := models.WithPrincipal(ctx, &models.Principal{User: "root", Role: models.RoleAdmin})
+	assert.ErrorIs(t, gate.Check(editor, untested, true), ErrTestGateOverride)
+	assert.NoError(t, gate.Check(admin, untested, true))
+	assert.NoError(t, gate.Check(ctx, untested, true), "未启用认证时允许跳过")
+}
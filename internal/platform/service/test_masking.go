@@ -0,0 +1,164 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"logstash-platform/internal/platform/models"
+)
+
+// ErrInvalidMaskRule 脱敏规则缺少字段和正则表达式，或正则表达式无法编译
+var ErrInvalidMaskRule = errors.New("脱敏规则无效")
+
+// defaultMaskReplacement 未设置替换内容时使用的占位
+const defaultMaskReplacement = "[REDACTED]"
+
+// TestMasker 编译后的测试结果脱敏规则，在保存测试结果前对输出进行脱敏
+// 断言和字段契约仍使用脱敏前的输出检查
+type TestMasker struct {
+	rules []maskRule
+}
+
+type maskRule struct {
+	field       string
+	pattern     *regexp.Regexp // 为nil时替换field的整个值
+	replacement string
+}
+
+// NewTestMasker 校验并编译脱敏规则
+func NewTestMasker(rules []models.TestMaskRule) (*TestMasker, error) {
+	m := &TestMasker{rules: make([]maskRule, 0, len(rules))}
+	for i, rule := range rules {
+		if rule.Field == "" && rule.Pattern == "" {
+			return nil, fmt.Errorf("%w: 第%d个规则需要field或pattern", ErrInvalidMaskRule, i+1)
+		}
+		compiled := maskRule{field: rule.Field, replacement: rule.Replacement}
+		if compiled.replacement == "" {
+			compiled.replacement = defaultMaskReplacement
+		}
+		if rule.Pattern != "" {
+			pattern, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("%w: 第%d个规则的正则表达式无法编译: %v", ErrInvalidMaskRule, i+1, err)
+			}
+			compiled.pattern = pattern
+		}
+		m.rules = append(m.rules, compiled)
+	}
+	return m, nil
+}
+
+// Empty 没有脱敏规则
+func (m *TestMasker) Empty() bool {
+	return m == nil || len(m.rules) == 0
+}
+
+// Mask 返回脱敏后的样本输出，不修改原输出
+func (m *TestMasker) Mask(output models.TestOutput) models.TestOutput {
+	if m.Empty() {
+		return output
+	}
+	output.Input = m.maskInput(output.Input)
+	output.Error = m.maskString(output.Error, "")
+	if output.Output != nil {
+		output.Output = m.maskValue(output.Output, "").(map[string]interface{})
+	}
+	if len(output.Assertions) > 0 {
+		outcomes := make([]models.AssertionOutcome, len(output.Assertions))
+		for i, outcome := range output.Assertions {
+			// 实际值和未通过的原因来自断言的字段，按该字段脱敏
+			outcome.Actual = m.maskValue(outcome.Actual, outcome.Assertion.Field)
+			outcome.Message = m.maskValue(outcome.Message, outcome.Assertion.Field).(string)
+			outcomes[i] = outcome
+		}
+		output.Assertions = outcomes
+	}
+	return output
+}
+
+// maskInput 对样本输入脱敏：每行都是JSON对象时按字段规则逐行脱敏，
+// 否则无法定位字段，只要配置了按字段的规则就整体替换，避免原始样本绕过字段规则被保存
+func (m *TestMasker) maskInput(input string) string {
+	if input == "" {
+		return input
+	}
+	lines := strings.Split(input, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		var event map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			if m.hasFieldRules() {
+				return defaultMaskReplacement
+			}
+			return m.maskString(input, "")
+		}
+		data, err := json.Marshal(m.maskValue(event, ""))
+		if err != nil {
+			return defaultMaskReplacement
+		}
+		lines[i] = string(data)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// hasFieldRules 是否有只作用于指定字段的规则
+func (m *TestMasker) hasFieldRules() bool {
+	for _, rule := range m.rules {
+		if rule.field != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// maskValue 对path处的值脱敏，对象和数组逐层复制
+func (m *TestMasker) maskValue(value interface{}, path string) interface{} {
+	for _, rule := range m.rules {
+		if rule.pattern == nil && coversField(rule.field, path) {
+			return rule.replacement
+		}
+	}
+	switch v := value.(type) {
+	case string:
+		return m.maskString(v, path)
+	case map[string]interface{}:
+		masked := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			child := key
+			if path != "" {
+				child = path + "." + key
+			}
+			masked[key] = m.maskValue(item, child)
+		}
+		return masked
+	case []interface{}:
+		masked := make([]interface{}, len(v))
+		for i, item := range v {
+			masked[i] = m.maskValue(item, path)
+		}
+		return masked
+	default:
+		return value
+	}
+}
+
+// maskString 对path处的字符串应用正则规则，path为空表示输入或错误信息等不属于字段的内容
+func (m *TestMasker) maskString(s, path string) string {
+	for _, rule := range m.rules {
+		if rule.pattern == nil || (rule.field != "" && !coversField(rule.field, path)) {
+			continue
+		}
+		s = rule.pattern.ReplaceAllString(s, rule.replacement)
+	}
+	return s
+}
+
+// coversField 判断path是否为field或其子字段
+func coversField(field, path string) bool {
+	return field != "" && (path == field || strings.HasPrefix(path, field+"."))
+}
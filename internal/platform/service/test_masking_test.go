@@ -0,0 +1,80 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+)
+
+func TestTestMasker_Mask(t *testing.T) {
+	masker, err := NewTestMasker([]models.TestMaskRule{
+		{Pattern: `\d{3}-\d{4}-\d{4}`, Replacement: "***"},
+		{Field: "user.email"},
+		{Field: "client", Pattern: `(\d+)\.\d+\.\d+\.\d+`, Replacement: "$1.x.x.x"},
+	})
+	require.NoError(t, err)
+
+	passed := false
+	output := models.TestOutput{
+		Input: "tel=138-1234-5678 email=a@example.com ip=10.1.2.3",
+		Output: map[string]interface{}{
+			"tel":    "138-1234-5678",
+			"user":   map[string]interface{}{"email": "a@example.com", "name": "a"},
+			"client": map[string]interface{}{"ip": "10.1.2.3"},
+			"tags":   []interface{}{"call 138-1234-5678"},
+			"status": float64(200),
+		},
+		Passed: &passed,
+		Assertions: []models.AssertionOutcome{
+			{Assertion: models.TestAssertion{Type: "equals", Field: "user.email"}, Actual: "a@example.com", Message: "实际值为 a@example.com"},
+		},
+	}
+	masked := masker.Mask(output)
+
+	assert.NotContains(t, masked.Input, "a@example.com", "非JSON输入无法按字段脱敏，整体替换")
+	assert.NotContains(t, masked.Input, "138-1234-5678")
+	assert.NotContains(t, masked.Input, "10.1.2.3")
+	assert.Equal(t, "***", masked.Output["tel"])
+	assert.Equal(t, map[string]interface{}{"email": "[REDACTED]", "name": "a"}, masked.Output["user"])
+	assert.Equal(t, map[string]interface{}{"ip": "10.x.x.x"}, masked.Output["client"])
+	assert.Equal(t, []interface{}{"call ***"}, masked.Output["tags"])
+	assert.Equal(t, float64(200), masked.Output["status"])
+	assert.Equal(t, "[REDACTED]", masked.Assertions[0].Actual)
+	assert.Equal(t, "[REDACTED]", masked.Assertions[0].Message)
+
+	assert.Equal(t, "a@example.com", output.Output["user"].(map[string]interface{})["email"], "不修改原输出")
+	assert.Equal(t, "a@example.com", output.Assertions[0].Actual)
+}
+
+func TestTestMasker_MaskInput(t *testing.T) {
+	masker, err := NewTestMasker([]models.TestMaskRule{
+		{Pattern: `\d{3}-\d{4}-\d{4}`, Replacement: "***"},
+		{Field: "user.email"},
+	})
+	require.NoError(t, err)
+
+	// JSON输入与输出一样按字段脱敏，多行时逐行处理
+	masked := masker.Mask(models.TestOutput{
+		Input: `{"tel":"138-1234-5678","user":{"email":"a@example.com","name":"a"}}` + "\n" + `{"user":{"email":"b@example.com"}}`,
+	})
+	assert.Equal(t, `{"tel":"***","user":{"email":"[REDACTED]","name":"a"}}`+"\n"+`{"user":{"email":"[REDACTED]"}}`, masked.Input)
+
+	// 只有正则规则时非JSON输入按正则脱敏
+	patternOnly, err := NewTestMasker([]models.TestMaskRule{{Pattern: `\d{3}-\d{4}-\d{4}`, Replacement: "***"}})
+	require.NoError(t, err)
+	assert.Equal(t, "tel=*** email=a@example.com", patternOnly.Mask(models.TestOutput{Input: "tel=138-1234-5678 email=a@example.com"}).Input)
+}
+
+func TestNewTestMasker(t *testing.T) {
+	var masker *TestMasker
+	assert.True(t, masker.Empty())
+	output := models.TestOutput{Input: "x"}
+	assert.Equal(t, output, masker.Mask(output))
+
+	_, err := NewTestMasker([]models.TestMaskRule{{Replacement: "x"}})
+	assert.ErrorIs(t, err, ErrInvalidMaskRule)
+	_, err = NewTestMasker([]models.TestMaskRule{{Pattern: "("}})
+	assert.ErrorIs(t, err, ErrInvalidMaskRule)
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"logstash-platform/internal/platform/models"
+)
+
+// harDocument HAR文档中回放需要的部分
+type harDocument struct {
+	Log struct {
+		Entries []struct {
+			Request struct {
+				Method   string `json:"method"`
+				URL      string `json:"url"`
+				PostData *struct {
+					Text string `json:"text"`
+				} `json:"postData"`
+			} `json:"request"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+// ReplaySamples 把HTTP请求的记录解析为样本，顺序与记录一致
+// HAR以每个请求的请求体作为样本，没有请求体的请求跳过；JSON数组的每个元素为HTTP输入收到的一个事件
+func ReplaySamples(source *models.TestReplaySource) ([]string, error) {
+	switch source.Format {
+	case "har":
+		var har harDocument
+		if err := json.Unmarshal(source.Content, &har); err != nil {
+			return nil, fmt.Errorf("%w: 解析HAR失败: %v", ErrTestSourceInvalid, err)
+		}
+		samples := make([]string, 0, len(har.Log.Entries))
+		for _, entry := range har.Log.Entries {
+			if entry.Request.PostData != nil && entry.Request.PostData.Text != "" {
+				samples = append(samples, entry.Request.PostData.Text)
+			}
+		}
+		return samples, nil
+	case "json":
+		var events []json.RawMessage
+		if err := json.Unmarshal(source.Content, &events); err != nil {
+			return nil, fmt.Errorf("%w: content必须为JSON数组: %v", ErrTestSourceInvalid, err)
+		}
+		samples := make([]string, 0, len(events))
+		for _, event := range events {
+			if sample, ok := replaySample(event, source.Field); ok {
+				samples = append(samples, sample)
+			}
+		}
+		return samples, nil
+	default:
+		return nil, fmt.Errorf("%w: 不支持的回放格式 %s", ErrTestSourceInvalid, source.Format)
+	}
+}
+
+// replaySample 取JSON数组中一个元素的样本
+func replaySample(event json.RawMessage, field string) (string, bool) {
+	var value interface{}
+	if err := json.Unmarshal(event, &value); err != nil {
+		return "", false
+	}
+	if field != "" {
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		return fieldSample(fields, field)
+	}
+	switch v := value.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, v != ""
+	default:
+		return string(event), true
+	}
+}
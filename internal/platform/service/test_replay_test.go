@@ -0,0 +1,41 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+)
+
+func TestReplaySamples(t *testing.T) {
+	t.Run("HAR以请求体作为样本", func(t *testing.T) {
+		har := `{"log": {"version": "1.2", "entries": [
+			{"request": {"method": "POST", "url": "http://logs/", "postData": {"mimeType": "application/json", "text": "{\"a\":1}"}}},
+			{"request": {"method": "GET", "url": "http://logs/health"}},
+			{"request": {"method": "POST", "url": "http://logs/", "postData": {"text": "line 2"}}}
+		]}}`
+		samples, err := ReplaySamples(&models.TestReplaySource{Format: "har", Content: json.RawMessage(har)})
+		require.NoError(t, err)
+		assert.Equal(t, []string{`{"a":1}`, "line 2"}, samples, "没有请求体的请求跳过")
+	})
+
+	t.Run("JSON事件数组", func(t *testing.T) {
+		events := json.RawMessage(`["raw line", {"message": "GET /", "http": {"method": "GET"}}, {"http": {}}, null]`)
+		samples, err := ReplaySamples(&models.TestReplaySource{Format: "json", Content: events})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"raw line", `{"message": "GET /", "http": {"method": "GET"}}`, `{"http": {}}`}, samples)
+
+		samples, err = ReplaySamples(&models.TestReplaySource{Format: "json", Content: events, Field: "message"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"GET /"}, samples)
+	})
+
+	t.Run("内容无效", func(t *testing.T) {
+		_, err := ReplaySamples(&models.TestReplaySource{Format: "json", Content: json.RawMessage(`{"a": 1}`)})
+		assert.ErrorIs(t, err, ErrTestSourceInvalid)
+		_, err = ReplaySamples(&models.TestReplaySource{Format: "har", Content: json.RawMessage(`[`)})
+		assert.ErrorIs(t, err, ErrTestSourceInvalid)
+	})
+}
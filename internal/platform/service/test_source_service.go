@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// 测试数据源相关错误，处理器通过 errors.Is 映射为HTTP状态码
+var (
+	ErrTestSourceForbidden = errors.New("不允许读取的测试数据源")
+	ErrTestSourceInvalid   = errors.New("测试数据源无效")
+)
+
+// platformIndexPrefix 平台自身索引的前缀，无论如何配置都不能作为测试数据源
+const platformIndexPrefix = "logstash_"
+
+// ESTestSourceConfig Elasticsearch测试数据源参数
+type ESTestSourceConfig struct {
+	AllowedIndices []string // 允许抽取文档的索引模式，例如 logs-*，为空时不允许任何索引
+}
+
+// ESTestSource 从平台Elasticsearch的业务索引中抽取文档作为测试样本
+// 只能读取允许范围内的索引，平台自身的索引不能读取
+type ESTestSource struct {
+	repo    repository.EventSampleRepository
+	allowed []string
+	logger  *logrus.Logger
+}
+
+// NewESTestSource 创建Elasticsearch测试数据源
+func NewESTestSource(cfg ESTestSourceConfig, repo repository.EventSampleRepository, logger *logrus.Logger) *ESTestSource {
+	return &ESTestSource{
+		repo:    repo,
+		allowed: cfg.AllowedIndices,
+		logger:  logger,
+	}
+}
+
+// Sample 抽取最多maxSamples个文档作为样本，source.Size更小时以其为准
+// 设置了source.Field时以该字段的值作为样本，缺少该字段的文档跳过；否则以整个文档的JSON作为样本
+func (s *ESTestSource) Sample(ctx context.Context, source *models.TestESSource, maxSamples int) ([]string, error) {
+	if err := s.checkIndex(source.Index); err != nil {
+		return nil, err
+	}
+	size := maxSamples
+	if source.Size > 0 && source.Size < size {
+		size = source.Size
+	}
+
+	docs, err := s.repo.Sample(ctx, source.Index, source.Query, size, source.Random)
+	if err != nil {
+		if errors.Is(err, elasticsearch.ErrUnavailable) || ctx.Err() != nil {
+			return nil, err
+		}
+		// 其余错误来自索引不存在或查询无效，属于请求的问题
+		return nil, fmt.Errorf("%w: %w", ErrTestSourceInvalid, err)
+	}
+
+	samples := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		if source.Field == "" {
+			samples = append(samples, string(doc))
+			continue
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal(doc, &fields); err != nil {
+			continue
+		}
+		if sample, ok := fieldSample(fields, source.Field); ok {
+			samples = append(samples, sample)
+		}
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"index":   source.Index,
+		"docs":    len(docs),
+		"samples": len(samples),
+	}).Debug("从索引抽取测试样本")
+	return samples, nil
+}
+
+// checkIndex 检查索引是否允许读取，请求的索引模式必须完全落在某个允许的模式内
+func (s *ESTestSource) checkIndex(index string) error {
+	if index == "" || strings.ContainsAny(index, ",:") || strings.HasPrefix(index, "-") {
+		return fmt.Errorf("%w: 索引 %q 无效，只能指定单个索引或索引模式", ErrTestSourceInvalid, index)
+	}
+	if strings.HasPrefix(index, ".") || strings.HasPrefix(index, platformIndexPrefix) || strings.HasPrefix(index, "*") {
+		return fmt.Errorf("%w: 不能读取系统或平台索引 %s", ErrTestSourceForbidden, index)
+	}
+	for _, pattern := range s.allowed {
+		if ok, _ := path.Match(pattern, index); ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: 索引 %s 不在允许的范围内", ErrTestSourceForbidden, index)
+}
+
+// fieldSample 按点号分隔的路径取文档字段，字段名本身包含点号时直接取该字段
+// 字符串直接作为样本，其他类型编码为JSON
+func fieldSample(fields map[string]interface{}, field string) (string, bool) {
+	value, ok := fields[field]
+	if !ok {
+		value = fields
+		for _, key := range strings.Split(field, ".") {
+			object, isObject := value.(map[string]interface{})
+			if !isObject {
+				return "", false
+			}
+			if value, ok = object[key]; !ok {
+				return "", false
+			}
+		}
+	}
+	switch v := value.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, v != ""
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", false
+		}
+		return string(data), true
+	}
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// fakeEventSampleRepository 返回固定文档并记录抽样参数
+type fakeEventSampleRepository struct {
+	docs   []json.RawMessage
+	err    error
+	index  string
+	size   int
+	random bool
+}
+
+func (r *fakeEventSampleRepository) Sample(ctx context.Context, index string, query map[string]interface{}, size int, random bool) ([]json.RawMessage, error) {
+	r.index, r.size, r.random = index, size, random
+	if r.err != nil {
+		return nil, r.err
+	}
+	if len(r.docs) > size {
+		return r.docs[:size], nil
+	}
+	return r.docs, nil
+}
+
+func TestESTestSource_Sample(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	ctx := context.Background()
+	repo := &fakeEventSampleRepository{docs: []json.RawMessage{
+		json.RawMessage(`{"message":"GET /a","event":{"original":"raw a"},"status":200}`),
+		json.RawMessage(`{"event":{"original":"raw b"}}`),
+		json.RawMessage(`{"event.original":"raw c","message":""}`),
+	}}
+	s := NewESTestSource(ESTestSourceConfig{AllowedIndices: []string{"logs-*"}}, repo, logger)
+
+	t.Run("以整个文档作为样本", func(t *testing.T) {
+		samples, err := s.Sample(ctx, &models.TestESSource{Index: "logs-nginx-*", Size: 2, Random: true}, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{`{"message":"GET /a","event":{"original":"raw a"},"status":200}`, `{"event":{"original":"raw b"}}`}, samples)
+		assert.Equal(t, "logs-nginx-*", repo.index)
+		assert.Equal(t, 2, repo.size)
+		assert.True(t, repo.random)
+	})
+
+	t.Run("以字段作为样本", func(t *testing.T) {
+		samples, err := s.Sample(ctx, &models.TestESSource{Index: "logs-nginx", Field: "event.original"}, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"raw a", "raw b", "raw c"}, samples, "支持嵌套路径和包含点号的字段名")
+		assert.Equal(t, 10, repo.size, "默认抽取样本条数上限个文档")
+
+		samples, err = s.Sample(ctx, &models.TestESSource{Index: "logs-nginx", Field: "message"}, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"GET /a"}, samples, "缺少字段或字段为空的文档跳过")
+	})
+
+	t.Run("不允许读取的索引", func(t *testing.T) {
+		for _, index := range []string{"metrics-*", "logstash_configs", ".security", "*", "logs-a,logstash_users", "remote:logs-a"} {
+			_, err := s.Sample(ctx, &models.TestESSource{Index: index}, 10)
+			assert.Error(t, err, index)
+		}
+		_, err := s.Sample(ctx, &models.TestESSource{Index: "logstash_configs"}, 10)
+		assert.ErrorIs(t, err, ErrTestSourceForbidden)
+		_, err = s.Sample(ctx, &models.TestESSource{Index: "logs-a,logstash_users"}, 10)
+		assert.ErrorIs(t, err, ErrTestSourceInvalid)
+	})
+
+	t.Run("查询失败", func(t *testing.T) {
+		repo.err = fmt.Errorf("搜索响应错误: [400] parsing_exception")
+		_, err := s.Sample(ctx, &models.TestESSource{Index: "logs-a"}, 10)
+		assert.ErrorIs(t, err, ErrTestSourceInvalid)
+
+		repo.err = fmt.Errorf("搜索失败: %w", elasticsearch.ErrUnavailable)
+		_, err = s.Sample(ctx, &models.TestESSource{Index: "logs-a"}, 10)
+		assert.ErrorIs(t, err, elasticsearch.ErrUnavailable)
+		assert.NotErrorIs(t, err, ErrTestSourceInvalid)
+	})
+}
@@ -0,0 +1,325 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+	"logstash-platform/internal/platform/tracing"
+)
+
+// 推送请求头，接收方用 X-Webhook-Signature 校验请求来自平台
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature" // sha256=<hex(HMAC-SHA256(secret, timestamp + "." + body))>
+	WebhookTimestampHeader = "X-Webhook-Timestamp" // 签名时的Unix秒，接收方可据此拒绝过旧的请求
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// webhook推送的默认参数
+const (
+	defaultWebhookMaxAttempts    = 5
+	defaultWebhookInitialBackoff = 10 * time.Second
+	defaultWebhookMaxBackoff     = 10 * time.Minute
+	defaultWebhookTimeout        = 10 * time.Second
+	defaultWebhookWorkers        = 4
+	defaultWebhookQueueSize      = 1000
+)
+
+// maxWebhookErrorBody 记录接收方错误响应的最大字节数
+const maxWebhookErrorBody = 512
+
+// EventEmitter 平台事件的发布方，配置、部署、Agent状态和测试在事件发生时调用
+type EventEmitter interface {
+	// Emit 发布事件，不阻塞调用方；data为事件内容，序列化后作为推送请求体的data字段
+	Emit(ctx context.Context, event string, data interface{})
+}
+
+// SignWebhookPayload 计算推送请求的签名，接收方以同样方式计算后比较 X-Webhook-Signature
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookConfig 出站事件推送配置
+type WebhookConfig struct {
+	MaxAttempts    int           // 每个订阅的最大发送次数，包含首次发送
+	InitialBackoff time.Duration // 首次重试的等待时间，之后每次翻倍
+	MaxBackoff     time.Duration // 重试等待时间上限
+	Timeout        time.Duration // 单次发送的超时时间
+	Workers        int           // 同时发送的请求数上限
+	QueueSize      int           // 等待分发的事件数上限，队列满时丢弃新事件
+}
+
+// queuedEvent 等待分发的事件
+type queuedEvent struct {
+	ctx   context.Context
+	event models.WebhookEvent
+}
+
+// WebhookDispatcher 将平台事件推送给订阅了该事件的webhook
+// 事件先进入内存队列，由后台分发给每个启用的订阅；每个订阅的推送独立重试（指数退避），
+// 每次发送后更新推送记录，重试用尽后记录为失败。事件只在当前副本内存中排队，副本重启时未完成的推送会丢失
+type WebhookDispatcher struct {
+	cfg          WebhookConfig
+	webhookRepo  repository.WebhookRepository
+	deliveryRepo repository.WebhookDeliveryRepository
+	client       *http.Client
+	logger       *logrus.Logger
+	now          func() time.Time
+
+	queue    chan queuedEvent
+	slots    chan struct{} // 限制同时发送的请求数
+	wg       sync.WaitGroup
+	inFlight atomic.Int64 // 尚未结束（含等待重试）的推送数
+	dropped  atomic.Int64
+	failed   atomic.Int64
+}
+
+// NewWebhookDispatcher 创建事件推送器，未设置的参数使用默认值
+func NewWebhookDispatcher(cfg WebhookConfig, webhookRepo repository.WebhookRepository, deliveryRepo repository.WebhookDeliveryRepository, logger *logrus.Logger) *WebhookDispatcher {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaultWebhookMaxAttempts
+	}
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = defaultWebhookInitialBackoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = defaultWebhookMaxBackoff
+	}
+	if cfg.MaxBackoff < cfg.InitialBackoff {
+		cfg.MaxBackoff = cfg.InitialBackoff
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultWebhookTimeout
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = defaultWebhookWorkers
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultWebhookQueueSize
+	}
+	return &WebhookDispatcher{
+		cfg:          cfg,
+		webhookRepo:  webhookRepo,
+		deliveryRepo: deliveryRepo,
+		client:       &http.Client{Timeout: cfg.Timeout},
+		logger:       logger,
+		now:          time.Now,
+		queue:        make(chan queuedEvent, cfg.QueueSize),
+		slots:        make(chan struct{}, cfg.Workers),
+	}
+}
+
+// Emit 实现EventEmitter接口，事件排入队列后立即返回；队列满时丢弃事件并记录日志
+func (d *WebhookDispatcher) Emit(ctx context.Context, event string, data interface{}) {
+	queued := queuedEvent{
+		ctx: tracing.Detach(ctx),
+		event: models.WebhookEvent{
+			ID:        uuid.New().String(),
+			Type:      event,
+			Timestamp: d.now(),
+			Data:      data,
+		},
+	}
+	select {
+	case d.queue <- queued:
+	default:
+		d.dropped.Add(1)
+		d.logger.WithField("event", event).Warn("webhook事件队列已满，丢弃事件")
+	}
+}
+
+// Start 分发队列中的事件直到ctx取消，返回前等待进行中的推送结束
+// ctx取消后不再重试，仍在等待重试的推送记录为失败
+func (d *WebhookDispatcher) Start(ctx context.Context) {
+	defer d.wg.Wait()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case queued := <-d.queue:
+			d.dispatch(ctx, queued)
+		}
+	}
+}
+
+// dispatch 为订阅了该事件的每个启用的webhook启动一次推送
+func (d *WebhookDispatcher) dispatch(ctx context.Context, queued queuedEvent) {
+	webhooks, err := d.webhookRepo.List(ctx)
+	if err != nil {
+		d.logger.WithError(err).WithField("event", queued.event.Type).Error("获取webhook列表失败，丢弃事件")
+		return
+	}
+
+	var payload []byte
+	for _, webhook := range webhooks {
+		if !webhook.Enabled || !webhook.Subscribes(queued.event.Type) {
+			continue
+		}
+		if payload == nil {
+			if payload, err = json.Marshal(queued.event); err != nil {
+				d.logger.WithError(err).WithField("event", queued.event.Type).Error("序列化webhook事件失败")
+				return
+			}
+		}
+
+		now := d.now()
+		delivery := &models.WebhookDelivery{
+			ID:        uuid.New().String(),
+			WebhookID: webhook.ID,
+			EventID:   queued.event.ID,
+			Event:     queued.event.Type,
+			Status:    models.WebhookDeliveryPending,
+			Payload:   payload,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		d.inFlight.Add(1)
+		d.wg.Add(1)
+		go func(webhook *models.StoredWebhook) {
+			defer d.wg.Done()
+			defer d.inFlight.Add(-1)
+			d.deliver(ctx, queued.ctx, webhook, delivery)
+		}(webhook)
+	}
+}
+
+// deliver 发送推送直到成功、重试用尽或ctx取消，每次发送后保存推送记录
+func (d *WebhookDispatcher) deliver(ctx, traceCtx context.Context, webhook *models.StoredWebhook, delivery *models.WebhookDelivery) {
+	logger := d.logger.WithFields(logrus.Fields{
+		"webhook_id":  webhook.ID,
+		"delivery_id": delivery.ID,
+		"event":       delivery.Event,
+	})
+	backoff := d.cfg.InitialBackoff
+	for {
+		select {
+		case d.slots <- struct{}{}:
+		case <-ctx.Done():
+			d.abandon(delivery, logger)
+			return
+		}
+		code, err := d.send(ctx, traceCtx, webhook, delivery)
+		<-d.slots
+
+		now := d.now()
+		delivery.Attempts++
+		delivery.ResponseCode = code
+		delivery.UpdatedAt = now
+		delivery.NextRetryAt = nil
+		if err == nil {
+			delivery.Status = models.WebhookDeliverySucceeded
+			delivery.Error = ""
+			delivery.DeliveredAt = &now
+			d.save(delivery, logger)
+			return
+		}
+
+		delivery.Error = err.Error()
+		if delivery.Attempts >= d.cfg.MaxAttempts {
+			delivery.Status = models.WebhookDeliveryFailed
+			d.failed.Add(1)
+			d.save(delivery, logger)
+			logger.WithError(err).Warn("webhook推送失败，重试次数已用尽")
+			return
+		}
+		next := now.Add(backoff)
+		delivery.NextRetryAt = &next
+		d.save(delivery, logger)
+		logger.WithError(err).WithField("attempts", delivery.Attempts).Debug("webhook推送失败，等待重试")
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			d.abandon(delivery, logger)
+			return
+		}
+		backoff *= 2
+		if backoff > d.cfg.MaxBackoff {
+			backoff = d.cfg.MaxBackoff
+		}
+	}
+}
+
+// send 发送一次推送，返回接收方的状态码；非2xx响应视为失败
+func (d *WebhookDispatcher) send(ctx, traceCtx context.Context, webhook *models.StoredWebhook, delivery *models.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("创建推送请求失败: %w", err)
+	}
+	timestamp := d.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, delivery.Event)
+	req.Header.Set(WebhookDeliveryHeader, delivery.ID)
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(webhook.Secret, timestamp, delivery.Payload))
+	otel.GetTextMapPropagator().Inject(traceCtx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("发送推送失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxWebhookErrorBody))
+		return resp.StatusCode, fmt.Errorf("接收方返回状态码 %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	return resp.StatusCode, nil
+}
+
+// abandon 平台关闭时结束尚未成功的推送
+func (d *WebhookDispatcher) abandon(delivery *models.WebhookDelivery, logger *logrus.Entry) {
+	delivery.Status = models.WebhookDeliveryFailed
+	delivery.Error = "平台关闭，未完成重试"
+	delivery.NextRetryAt = nil
+	delivery.UpdatedAt = d.now()
+	d.failed.Add(1)
+	d.save(delivery, logger)
+}
+
+// save 保存推送记录，ctx可能已取消，使用独立的超时
+func (d *WebhookDispatcher) save(delivery *models.WebhookDelivery, logger *logrus.Entry) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := d.deliveryRepo.Save(ctx, delivery); err != nil {
+		logger.WithError(err).Error("保存webhook推送记录失败")
+	}
+}
+
+// WorkerStatus 实现WorkerReporter接口
+func (d *WebhookDispatcher) WorkerStatus(now time.Time) models.WorkerStatus {
+	return models.WorkerStatus{
+		Name:    "webhook_dispatcher",
+		Kind:    models.WorkerKindQueue,
+		Running: true,
+		Gauges: map[string]float64{
+			"queued":    float64(len(d.queue)),
+			"in_flight": float64(d.inFlight.Load()),
+			"sending":   float64(len(d.slots)),
+			"dropped":   float64(d.dropped.Load()),
+			"failed":    float64(d.failed.Load()),
+		},
+	}
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+)
+
+// waitDeliveries 等待推送记录全部结束
+func waitDeliveries(t *testing.T, repo *memWebhookDeliveryRepository, webhookID string, n int) []*models.WebhookDelivery {
+	t.Helper()
+	var deliveries []*models.WebhookDelivery
+	require.Eventually(t, func() bool {
+		deliveries, _ = repo.List(context.Background(), webhookID, &models.WebhookDeliveryListRequest{})
+		if len(deliveries) != n {
+			return false
+		}
+		for _, delivery := range deliveries {
+			if delivery.Status == models.WebhookDeliveryPending {
+				return false
+			}
+		}
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+	return deliveries
+}
+
+func TestWebhookDispatcher_SignedDelivery(t *testing.T) {
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	requests := make(chan received, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- received{header: r.Header.Clone(), body: body}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	webhooks := newMemWebhookRepository()
+	deliveries := newMemWebhookDeliveryRepository()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, webhooks.Create(ctx, &models.StoredWebhook{
+		Webhook: models.Webhook{ID: "deploys", Name: "deploys", URL: server.URL, Events: []string{models.WebhookEventConfigDeployed}, Enabled: true},
+		Secret:  "0123456789abcdef",
+	}))
+	require.NoError(t, webhooks.Create(ctx, &models.StoredWebhook{
+		Webhook: models.Webhook{ID: "disabled", Name: "disabled", URL: server.URL, Events: []string{models.WebhookEventConfigDeployed}},
+		Secret:  "0123456789abcdef",
+	}))
+
+	dispatcher := NewWebhookDispatcher(WebhookConfig{}, webhooks, deliveries, logrus.New())
+	go dispatcher.Start(ctx)
+	dispatcher.Emit(ctx, models.WebhookEventConfigCreated, map[string]string{"id": "ignored"})
+	dispatcher.Emit(ctx, models.WebhookEventConfigDeployed, &models.Deployment{ID: "deploy-1", ConfigID: "config-1"})
+
+	var req received
+	select {
+	case req = <-requests:
+	case <-time.After(5 * time.Second):
+		t.Fatal("未收到推送")
+	}
+	assert.Equal(t, models.WebhookEventConfigDeployed, req.header.Get(WebhookEventHeader))
+	timestamp, err := strconv.ParseInt(req.header.Get(WebhookTimestampHeader), 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, SignWebhookPayload("0123456789abcdef", timestamp, req.body), req.header.Get(WebhookSignatureHeader))
+
+	var event struct {
+		ID   string            `json:"id"`
+		Type string            `json:"type"`
+		Data models.Deployment `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(req.body, &event))
+	assert.Equal(t, models.WebhookEventConfigDeployed, event.Type)
+	assert.Equal(t, "deploy-1", event.Data.ID)
+
+	recorded := waitDeliveries(t, deliveries, "deploys", 1)
+	assert.Equal(t, models.WebhookDeliverySucceeded, recorded[0].Status)
+	assert.Equal(t, 1, recorded[0].Attempts)
+	assert.Equal(t, http.StatusNoContent, recorded[0].ResponseCode)
+	assert.Equal(t, event.ID, recorded[0].EventID)
+	assert.Equal(t, req.header.Get(WebhookDeliveryHeader), recorded[0].ID)
+
+	none, _ := deliveries.List(ctx, "disabled", &models.WebhookDeliveryListRequest{})
+	assert.Empty(t, none)
+}
+
+func TestWebhookDispatcher_RetriesUntilSuccess(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	webhooks := newMemWebhookRepository()
+	deliveries := newMemWebhookDeliveryRepository()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, webhooks.Create(ctx, &models.StoredWebhook{
+		Webhook: models.Webhook{ID: "offline", Name: "offline", URL: server.URL, Events: []string{models.WebhookEventAgentOffline}, Enabled: true},
+		Secret:  "0123456789abcdef",
+	}))
+
+	dispatcher := NewWebhookDispatcher(WebhookConfig{InitialBackoff: 10 * time.Millisecond}, webhooks, deliveries, logrus.New())
+	go dispatcher.Start(ctx)
+	dispatcher.Emit(ctx, models.WebhookEventAgentOffline, map[string]string{"agent_id": "agent-1"})
+
+	recorded := waitDeliveries(t, deliveries, "offline", 1)
+	assert.Equal(t, models.WebhookDeliverySucceeded, recorded[0].Status)
+	assert.Equal(t, 3, recorded[0].Attempts)
+	assert.Empty(t, recorded[0].Error)
+	assert.Nil(t, recorded[0].NextRetryAt)
+	assert.NotNil(t, recorded[0].DeliveredAt)
+}
+
+func TestWebhookDispatcher_GivesUp(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad request", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	webhooks := newMemWebhookRepository()
+	deliveries := newMemWebhookDeliveryRepository()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, webhooks.Create(ctx, &models.StoredWebhook{
+		Webhook: models.Webhook{ID: "tests", Name: "tests", URL: server.URL, Events: []string{models.WebhookEventTestCompleted}, Enabled: true},
+		Secret:  "0123456789abcdef",
+	}))
+
+	dispatcher := NewWebhookDispatcher(WebhookConfig{MaxAttempts: 2, InitialBackoff: 10 * time.Millisecond}, webhooks, deliveries, logrus.New())
+	go dispatcher.Start(ctx)
+	dispatcher.Emit(ctx, models.WebhookEventTestCompleted, map[string]string{"test_id": "test-1"})
+
+	recorded := waitDeliveries(t, deliveries, "tests", 1)
+	assert.Equal(t, models.WebhookDeliveryFailed, recorded[0].Status)
+	assert.Equal(t, 2, recorded[0].Attempts)
+	assert.Equal(t, http.StatusBadRequest, recorded[0].ResponseCode)
+	assert.Contains(t, recorded[0].Error, "bad request")
+	assert.Equal(t, float64(1), dispatcher.WorkerStatus(time.Now()).Gauges["failed"])
+}
+
+func TestWebhookDispatcher_QueueFull(t *testing.T) {
+	dispatcher := NewWebhookDispatcher(WebhookConfig{QueueSize: 1}, newMemWebhookRepository(), newMemWebhookDeliveryRepository(), logrus.New())
+	dispatcher.Emit(context.Background(), models.WebhookEventConfigCreated, nil)
+	dispatcher.Emit(context.Background(), models.WebhookEventConfigCreated, nil)
+
+	gauges := dispatcher.WorkerStatus(time.Now()).Gauges
+	assert.Equal(t, float64(1), gauges["queued"])
+	assert.Equal(t, float64(1), gauges["dropped"])
+}
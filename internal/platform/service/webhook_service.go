@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/repository"
+)
+
+// webhook相关错误，处理器通过 errors.Is 映射为HTTP状态码
+var (
+	ErrWebhookNotFound = errors.New("webhook不存在")
+	ErrWebhookInvalid  = errors.New("webhook无效")
+)
+
+// webhookSecretBytes 平台生成的签名密钥长度
+const webhookSecretBytes = 32
+
+// WebhookService 出站事件订阅的管理服务接口
+type WebhookService interface {
+	// CreateWebhook 创建订阅，返回的签名密钥只在此时可见
+	CreateWebhook(ctx context.Context, req *models.CreateWebhookRequest, userID string) (*models.CreatedWebhook, error)
+	UpdateWebhook(ctx context.Context, id string, req *models.UpdateWebhookRequest, userID string) (*models.Webhook, error)
+	DeleteWebhook(ctx context.Context, id string) error
+	GetWebhook(ctx context.Context, id string) (*models.Webhook, error)
+	ListWebhooks(ctx context.Context) ([]*models.Webhook, error)
+	// ListDeliveries 获取订阅最近的推送记录
+	ListDeliveries(ctx context.Context, id string, req *models.WebhookDeliveryListRequest) ([]*models.WebhookDelivery, error)
+}
+
+// webhookService 出站事件订阅管理服务实现
+type webhookService struct {
+	webhookRepo  repository.WebhookRepository
+	deliveryRepo repository.WebhookDeliveryRepository
+	logger       *logrus.Logger
+}
+
+// NewWebhookService 创建出站事件订阅管理服务
+func NewWebhookService(webhookRepo repository.WebhookRepository, deliveryRepo repository.WebhookDeliveryRepository, logger *logrus.Logger) WebhookService {
+	return &webhookService{
+		webhookRepo:  webhookRepo,
+		deliveryRepo: deliveryRepo,
+		logger:       logger,
+	}
+}
+
+// CreateWebhook 创建订阅，未指定签名密钥时随机生成
+func (s *webhookService) CreateWebhook(ctx context.Context, req *models.CreateWebhookRequest, userID string) (*models.CreatedWebhook, error) {
+	if err := validateWebhookURL(req.URL); err != nil {
+		return nil, err
+	}
+	secret := req.Secret
+	if secret == "" {
+		generated, err := generateWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+		secret = generated
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+	webhook := &models.StoredWebhook{
+		Webhook: models.Webhook{
+			Name:        req.Name,
+			URL:         req.URL,
+			Events:      uniqueEvents(req.Events),
+			Enabled:     enabled,
+			Description: req.Description,
+			CreatedBy:   userID,
+			UpdatedBy:   userID,
+		},
+		Secret: secret,
+	}
+	if err := s.webhookRepo.Create(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"webhook_id": webhook.ID,
+		"events":     webhook.Events,
+		"user_id":    userID,
+	}).Info("创建webhook成功")
+
+	return &models.CreatedWebhook{Webhook: webhook.Webhook, Secret: secret}, nil
+}
+
+// UpdateWebhook 整体替换订阅的地址和事件，未指定签名密钥时保留原密钥
+func (s *webhookService) UpdateWebhook(ctx context.Context, id string, req *models.UpdateWebhookRequest, userID string) (*models.Webhook, error) {
+	if err := validateWebhookURL(req.URL); err != nil {
+		return nil, err
+	}
+	webhook, err := s.webhookRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrWebhookNotFound, err)
+	}
+
+	webhook.Name = req.Name
+	webhook.URL = req.URL
+	webhook.Events = uniqueEvents(req.Events)
+	webhook.Enabled = req.Enabled
+	webhook.Description = req.Description
+	webhook.UpdatedBy = userID
+	if req.Secret != "" {
+		webhook.Secret = req.Secret
+	}
+	if err := s.webhookRepo.Update(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"webhook_id": webhook.ID,
+		"user_id":    userID,
+	}).Info("更新webhook成功")
+
+	return &webhook.Webhook, nil
+}
+
+// DeleteWebhook 删除订阅，已有的推送记录保留
+func (s *webhookService) DeleteWebhook(ctx context.Context, id string) error {
+	if _, err := s.webhookRepo.GetByID(ctx, id); err != nil {
+		return fmt.Errorf("%w: %w", ErrWebhookNotFound, err)
+	}
+	return s.webhookRepo.Delete(ctx, id)
+}
+
+// GetWebhook 获取单个订阅，不含签名密钥
+func (s *webhookService) GetWebhook(ctx context.Context, id string) (*models.Webhook, error) {
+	webhook, err := s.webhookRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrWebhookNotFound, err)
+	}
+	return &webhook.Webhook, nil
+}
+
+// ListWebhooks 获取全部订阅，不含签名密钥
+func (s *webhookService) ListWebhooks(ctx context.Context) ([]*models.Webhook, error) {
+	stored, err := s.webhookRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	webhooks := make([]*models.Webhook, 0, len(stored))
+	for _, webhook := range stored {
+		webhooks = append(webhooks, &webhook.Webhook)
+	}
+	return webhooks, nil
+}
+
+// ListDeliveries 获取订阅最近的推送记录
+func (s *webhookService) ListDeliveries(ctx context.Context, id string, req *models.WebhookDeliveryListRequest) ([]*models.WebhookDelivery, error) {
+	if _, err := s.webhookRepo.GetByID(ctx, id); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrWebhookNotFound, err)
+	}
+	return s.deliveryRepo.List(ctx, id, req)
+}
+
+// validateWebhookURL 推送地址须为http或https的绝对地址
+func validateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("%w: 地址无效: %w", ErrWebhookInvalid, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: 地址须为http或https的绝对地址", ErrWebhookInvalid)
+	}
+	return nil
+}
+
+// generateWebhookSecret 生成随机的签名密钥
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, webhookSecretBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成签名密钥失败: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// uniqueEvents 去掉重复的事件，保持原有顺序
+func uniqueEvents(events []string) []string {
+	seen := make(map[string]bool, len(events))
+	unique := make([]string, 0, len(events))
+	for _, event := range events {
+		if !seen[event] {
+			seen[event] = true
+			unique = append(unique, event)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+)
+
+type memWebhookRepository struct {
+	mu       sync.Mutex
+	webhooks map[string]*models.StoredWebhook
+}
+
+func newMemWebhookRepository() *memWebhookRepository {
+	return &memWebhookRepository{webhooks: make(map[string]*models.StoredWebhook)}
+}
+
+func (r *memWebhookRepository) Create(ctx context.Context, webhook *models.StoredWebhook) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if webhook.ID == "" {
+		webhook.ID = fmt.Sprintf("webhook-%d", len(r.webhooks)+1)
+	}
+	cp := *webhook
+	r.webhooks[webhook.ID] = &cp
+	return nil
+}
+
+func (r *memWebhookRepository) Update(ctx context.Context, webhook *models.StoredWebhook) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cp := *webhook
+	r.webhooks[webhook.ID] = &cp
+	return nil
+}
+
+func (r *memWebhookRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.webhooks, id)
+	return nil
+}
+
+func (r *memWebhookRepository) GetByID(ctx context.Context, id string) (*models.StoredWebhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	webhook, ok := r.webhooks[id]
+	if !ok {
+		return nil, fmt.Errorf("文档不存在")
+	}
+	cp := *webhook
+	return &cp, nil
+}
+
+func (r *memWebhookRepository) List(ctx context.Context) ([]*models.StoredWebhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var webhooks []*models.StoredWebhook
+	for _, webhook := range r.webhooks {
+		cp := *webhook
+		webhooks = append(webhooks, &cp)
+	}
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].Name < webhooks[j].Name })
+	return webhooks, nil
+}
+
+type memWebhookDeliveryRepository struct {
+	mu         sync.Mutex
+	deliveries map[string]*models.WebhookDelivery
+	saves      int
+}
+
+func newMemWebhookDeliveryRepository() *memWebhookDeliveryRepository {
+	return &memWebhookDeliveryRepository{deliveries: make(map[string]*models.WebhookDelivery)}
+}
+
+func (r *memWebhookDeliveryRepository) Save(ctx context.Context, delivery *models.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cp := *delivery
+	r.deliveries[delivery.ID] = &cp
+	r.saves++
+	return nil
+}
+
+func (r *memWebhookDeliveryRepository) List(ctx context.Context, webhookID string, req *models.WebhookDeliveryListRequest) ([]*models.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var deliveries []*models.WebhookDelivery
+	for _, delivery := range r.deliveries {
+		if delivery.WebhookID != webhookID {
+			continue
+		}
+		if req.Status != "" && delivery.Status != req.Status {
+			continue
+		}
+		if req.Event != "" && delivery.Event != req.Event {
+			continue
+		}
+		cp := *delivery
+		deliveries = append(deliveries, &cp)
+	}
+	return deliveries, nil
+}
+
+func TestWebhookService_CreateGeneratesSecret(t *testing.T) {
+	repo := newMemWebhookRepository()
+	svc := NewWebhookService(repo, newMemWebhookDeliveryRepository(), logrus.New())
+	ctx := context.Background()
+
+	created, err := svc.CreateWebhook(ctx, &models.CreateWebhookRequest{
+		Name:   "ci",
+		URL:    "https://hooks.example.com/logstash",
+		Events: []string{models.WebhookEventConfigDeployed, models.WebhookEventConfigDeployed, models.WebhookEventAgentOffline},
+	}, "admin")
+	require.NoError(t, err)
+	assert.Len(t, created.Secret, 2*webhookSecretBytes)
+	assert.True(t, created.Enabled)
+	assert.Equal(t, []string{models.WebhookEventConfigDeployed, models.WebhookEventAgentOffline}, created.Events)
+
+	stored, err := repo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, created.Secret, stored.Secret)
+
+	// 之后的读取不返回密钥
+	webhook, err := svc.GetWebhook(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "ci", webhook.Name)
+}
+
+func TestWebhookService_UpdateKeepsSecret(t *testing.T) {
+	repo := newMemWebhookRepository()
+	svc := NewWebhookService(repo, newMemWebhookDeliveryRepository(), logrus.New())
+	ctx := context.Background()
+
+	created, err := svc.CreateWebhook(ctx, &models.CreateWebhookRequest{
+		Name:   "ci",
+		URL:    "https://hooks.example.com/logstash",
+		Events: []string{models.WebhookEventConfigCreated},
+		Secret: "0123456789abcdef",
+	}, "admin")
+	require.NoError(t, err)
+
+	_, err = svc.UpdateWebhook(ctx, created.ID, &models.UpdateWebhookRequest{
+		Name:   "ci",
+		URL:    "https://hooks.example.com/v2",
+		Events: []string{models.WebhookEventTestCompleted},
+	}, "admin")
+	require.NoError(t, err)
+
+	stored, err := repo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789abcdef", stored.Secret)
+	assert.Equal(t, "https://hooks.example.com/v2", stored.URL)
+	assert.False(t, stored.Enabled)
+}
+
+func TestWebhookService_Errors(t *testing.T) {
+	svc := NewWebhookService(newMemWebhookRepository(), newMemWebhookDeliveryRepository(), logrus.New())
+	ctx := context.Background()
+
+	_, err := svc.CreateWebhook(ctx, &models.CreateWebhookRequest{
+		Name:   "ftp",
+		URL:    "ftp://hooks.example.com",
+		Events: []string{models.WebhookEventConfigCreated},
+	}, "admin")
+	assert.ErrorIs(t, err, ErrWebhookInvalid)
+
+	_, err = svc.GetWebhook(ctx, "missing")
+	assert.ErrorIs(t, err, ErrWebhookNotFound)
+	assert.ErrorIs(t, svc.DeleteWebhook(ctx, "missing"), ErrWebhookNotFound)
+	_, err = svc.ListDeliveries(ctx, "missing", &models.WebhookDeliveryListRequest{})
+	assert.ErrorIs(t, err, ErrWebhookNotFound)
+}
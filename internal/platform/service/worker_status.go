@@ -0,0 +1,144 @@
+package service
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"logstash-platform/internal/platform/models"
+)
+
+// WorkerReporter 可以报告运行状态的后台子系统
+type WorkerReporter interface {
+	WorkerStatus(now time.Time) models.WorkerStatus
+}
+
+// WorkerRegistry 汇总平台各后台子系统的运行状态，用于发现控制面自身处理不过来的情况
+type WorkerRegistry struct {
+	mu        sync.Mutex
+	reporters []WorkerReporter
+	now       func() time.Time
+}
+
+// NewWorkerRegistry 创建后台子系统状态汇总
+func NewWorkerRegistry() *WorkerRegistry {
+	return &WorkerRegistry{now: time.Now}
+}
+
+// Register 登记后台子系统
+func (r *WorkerRegistry) Register(reporters ...WorkerReporter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reporters = append(r.reporters, reporters...)
+}
+
+// Snapshot 采集全部后台子系统的当前状态，按名称排序
+func (r *WorkerRegistry) Snapshot() *models.WorkersSnapshot {
+	r.mu.Lock()
+	reporters := append([]WorkerReporter(nil), r.reporters...)
+	r.mu.Unlock()
+
+	now := r.now()
+	snapshot := &models.WorkersSnapshot{
+		CollectedAt: now,
+		Workers:     make([]models.WorkerStatus, 0, len(reporters)),
+		Lagging:     []string{},
+	}
+	for _, reporter := range reporters {
+		snapshot.Workers = append(snapshot.Workers, reporter.WorkerStatus(now))
+	}
+	sort.Slice(snapshot.Workers, func(i, j int) bool { return snapshot.Workers[i].Name < snapshot.Workers[j].Name })
+
+	for _, w := range snapshot.Workers {
+		if w.Kind == models.WorkerKindLoop && w.IntervalSeconds > 0 && w.LagSeconds > w.IntervalSeconds {
+			snapshot.Lagging = append(snapshot.Lagging, w.Name)
+		}
+	}
+	return snapshot
+}
+
+// loopTracker 周期任务的运行记录
+// 零值可用，interval为预期的执行间隔，schedule不为空时按其计算下一次预期执行时间
+type loopTracker struct {
+	mu       sync.Mutex
+	interval time.Duration
+	schedule func(last time.Time) time.Time
+
+	running      bool
+	startedAt    time.Time // 任务循环启动时间
+	lastRunAt    time.Time
+	lastDuration time.Duration
+	lastErr      string
+	runs         int64
+	failures     int64
+}
+
+// start 记录任务循环启动，返回的函数在循环退出时调用
+func (t *loopTracker) start(now time.Time) func() {
+	t.mu.Lock()
+	t.running = true
+	t.startedAt = now
+	t.mu.Unlock()
+
+	return func() {
+		t.mu.Lock()
+		t.running = false
+		t.mu.Unlock()
+	}
+}
+
+// begin 记录一次执行开始，返回的函数在执行结束时传入结果调用
+func (t *loopTracker) begin(now time.Time) func(err error) {
+	t.mu.Lock()
+	t.lastRunAt = now
+	t.mu.Unlock()
+
+	return func(err error) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.runs++
+		t.lastDuration = time.Since(now)
+		t.lastErr = ""
+		if err != nil {
+			t.failures++
+			t.lastErr = err.Error()
+		}
+	}
+}
+
+// status 生成任务状态
+// 落后时长为当前时间超过下一次预期执行时间的部分；单次执行耗时超过间隔或任务卡住时持续增长
+func (t *loopTracker) status(name string, now time.Time) models.WorkerStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	status := models.WorkerStatus{
+		Name:            name,
+		Kind:            models.WorkerKindLoop,
+		Running:         t.running,
+		IntervalSeconds: t.interval.Seconds(),
+		LastDuration:    t.lastDuration.Seconds(),
+		LastError:       t.lastErr,
+		Runs:            t.runs,
+		Failures:        t.failures,
+	}
+	if !t.lastRunAt.IsZero() {
+		last := t.lastRunAt
+		status.LastRunAt = &last
+	}
+
+	if t.running {
+		last := t.lastRunAt
+		if last.IsZero() {
+			last = t.startedAt
+		}
+		next := last.Add(t.interval)
+		if t.schedule != nil {
+			next = t.schedule(last)
+		}
+		if now.After(next) {
+			status.LagSeconds = now.Sub(next).Seconds()
+		}
+	}
+	return status
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+)
+
+// fixedReporter 返回固定状态的后台子系统
+type fixedReporter models.WorkerStatus
+
+func (r fixedReporter) WorkerStatus(now time.Time) models.WorkerStatus {
+	return models.WorkerStatus(r)
+}
+
+func TestLoopTracker_Status(t *testing.T) {
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	tracker := &loopTracker{interval: time.Minute}
+
+	t.Run("未启动时不计算落后", func(t *testing.T) {
+		status := tracker.status("job", base.Add(time.Hour))
+		assert.False(t, status.Running)
+		assert.Zero(t, status.LagSeconds)
+	})
+
+	stop := tracker.start(base)
+
+	t.Run("按时执行", func(t *testing.T) {
+		done := tracker.begin(base)
+		done(nil)
+		done = tracker.begin(base.Add(time.Minute))
+		done(errors.New("ES不可用"))
+
+		status := tracker.status("job", base.Add(90*time.Second))
+		assert.True(t, status.Running)
+		assert.Equal(t, 60.0, status.IntervalSeconds)
+		assert.EqualValues(t, 2, status.Runs)
+		assert.EqualValues(t, 1, status.Failures)
+		assert.Equal(t, "ES不可用", status.LastError)
+		require.NotNil(t, status.LastRunAt)
+		assert.Equal(t, base.Add(time.Minute), *status.LastRunAt)
+		assert.Zero(t, status.LagSeconds)
+	})
+
+	t.Run("执行卡住时落后时长持续增长", func(t *testing.T) {
+		tracker.begin(base.Add(2 * time.Minute))
+		status := tracker.status("job", base.Add(5*time.Minute))
+		assert.Equal(t, 120.0, status.LagSeconds)
+	})
+
+	stop()
+	assert.False(t, tracker.status("job", base).Running)
+}
+
+func TestLoopTracker_Schedule(t *testing.T) {
+	r := NewConfigRevalidator(RevalidationConfig{RunAt: "02:00"}, nil, nil, nil, nil, nil)
+	started := time.Date(2026, 3, 1, 12, 0, 0, 0, time.Local)
+	defer r.tracker.start(started)()
+
+	// 下一次预期在次日02:00执行
+	assert.Zero(t, r.WorkerStatus(started.Add(13*time.Hour)).LagSeconds)
+	assert.Equal(t, 3600.0, r.WorkerStatus(started.Add(15*time.Hour)).LagSeconds)
+}
+
+func TestWorkerRegistry_Snapshot(t *testing.T) {
+	registry := NewWorkerRegistry()
+	registry.Register(
+		fixedReporter{Name: "liveness_monitor", Kind: models.WorkerKindLoop, Running: true, IntervalSeconds: 30, LagSeconds: 45},
+		fixedReporter{Name: "cmdb_sync", Kind: models.WorkerKindLoop, Running: true, IntervalSeconds: 300, LagSeconds: 10},
+		fixedReporter{Name: "command_queue", Kind: models.WorkerKindQueue, Running: true},
+	)
+
+	snapshot := registry.Snapshot()
+	require.Len(t, snapshot.Workers, 3)
+	assert.Equal(t, "cmdb_sync", snapshot.Workers[0].Name)
+	assert.Equal(t, "command_queue", snapshot.Workers[1].Name)
+	assert.Equal(t, []string{"liveness_monitor"}, snapshot.Lagging)
+}
+
+func TestCommandQueue_WorkerStatus(t *testing.T) {
+	q := NewCommandQueue(0)
+	require.NoError(t, q.Publish("agent-1", models.MsgTypeConfigDeploy, nil))
+	require.NoError(t, q.Publish("agent-1", models.MsgTypeConfigDeploy, nil))
+	require.NoError(t, q.Publish("agent-2", models.MsgTypeConfigDeploy, nil))
+	q.Deliver("agent-2")
+
+	status := q.WorkerStatus(time.Now())
+	assert.Equal(t, models.WorkerKindQueue, status.Kind)
+	assert.Equal(t, 3.0, status.Gauges["pending"])
+	assert.Equal(t, 2.0, status.Gauges["agents"])
+	assert.Equal(t, 1.0, status.Gauges["unacked"])
+}
@@ -0,0 +1,117 @@
+// Package tracing 平台的OpenTelemetry链路追踪
+//
+// 链路上下文按W3C Trace Context传播：HTTP请求经 traceparent/tracestate 请求头，
+// 发给Agent的WebSocket消息和心跳捎带命令经消息的 metadata 字段，Agent处理消息时发出的请求携带同一链路上下文，
+// 一次部署可以从创建部署的API请求一直追踪到Agent应用配置后的上报。
+// 未启用导出时只传播链路上下文，不记录span。
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName 平台创建span使用的tracer名称
+const instrumentationName = "logstash-platform"
+
+// defaultServiceName 未配置服务名时上报的service.name
+const defaultServiceName = "logstash-platform"
+
+// Config 链路追踪配置
+type Config struct {
+	Enabled     bool              // 是否导出span，未启用时只传播链路上下文
+	Endpoint    string            // OTLP/HTTP接收端地址，如 otel-collector:4318，为空时使用OTEL_EXPORTER_OTLP_ENDPOINT或默认地址
+	URLPath     string            // 接收端路径，默认 /v1/traces
+	Insecure    bool              // 使用HTTP而不是HTTPS连接接收端
+	Headers     map[string]string // 发往接收端的额外请求头，如认证令牌
+	ServiceName string            // 上报的service.name
+	SampleRatio float64           // 根span的采样比例，(0,1]，0按1处理；有上游链路时沿用上游的采样决定
+}
+
+// Setup 设置全局的链路上下文传播方式，启用时创建OTLP导出的TracerProvider
+// 返回的函数在平台关闭时调用，导出尚未发送的span
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if !cfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	opts := []otlptracehttp.Option{}
+	if cfg.Endpoint != "" {
+		opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+	}
+	if cfg.URLPath != "" {
+		opts = append(opts, otlptracehttp.WithURLPath(cfg.URLPath))
+	}
+	if cfg.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	if len(cfg.Headers) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(cfg.Headers))
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("创建OTLP导出器失败: %w", err)
+	}
+
+	serviceName := cfg.ServiceName
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+	ratio := cfg.SampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(serviceName))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Tracer 平台创建span使用的tracer
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Inject 将ctx中的链路上下文写入消息元数据，没有链路上下文时返回nil
+func Inject(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return nil
+	}
+	return carrier
+}
+
+// Extract 从消息元数据中恢复链路上下文
+func Extract(ctx context.Context, metadata map[string]string) context.Context {
+	if len(metadata) == 0 {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(metadata))
+}
+
+// Detach 返回不随ctx取消、但保留其链路上下文的新上下文，用于请求结束后继续执行的后台任务
+func Detach(ctx context.Context) context.Context {
+	return trace.ContextWithSpanContext(context.Background(), trace.SpanContextFromContext(ctx))
+}
+
+// TraceID ctx中当前span的trace ID，没有有效的链路上下文时为空
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
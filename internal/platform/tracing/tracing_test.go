@@ -0,0 +1,48 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// remoteContext 带有上游链路上下文的ctx
+func remoteContext(t *testing.T) context.Context {
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	require.NoError(t, err)
+	spanID, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+	require.NoError(t, err)
+	return trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	}))
+}
+
+func TestInjectExtract(t *testing.T) {
+	shutdown, err := Setup(context.Background(), Config{})
+	require.NoError(t, err)
+	defer shutdown(context.Background())
+
+	assert.Nil(t, Inject(context.Background()), "没有链路上下文时不写入元数据")
+
+	metadata := Inject(remoteContext(t))
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", metadata["traceparent"])
+
+	ctx := Extract(context.Background(), metadata)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", TraceID(ctx))
+	assert.Empty(t, TraceID(Extract(context.Background(), nil)))
+}
+
+func TestDetach(t *testing.T) {
+	ctx, cancel := context.WithCancel(remoteContext(t))
+	detached := Detach(ctx)
+	cancel()
+
+	assert.NoError(t, detached.Err(), "请求结束后后台任务不随之取消")
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", TraceID(detached))
+}
@@ -0,0 +1,170 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/wschunk"
+)
+
+// conn 单个Agent的WebSocket连接
+// 写操作全部在writeLoop中完成，读操作全部在readLoop中完成，满足gorilla/websocket的并发约束
+type conn struct {
+	hub       *Hub
+	agentID   string
+	ws        *websocket.Conn
+	assembler *wschunk.Assembler
+
+	send      chan [][]byte // 每个元素为一条消息的全部帧
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// newConn 创建连接
+func newConn(h *Hub, agentID string, ws *websocket.Conn) *conn {
+	return &conn{
+		hub:       h,
+		agentID:   agentID,
+		ws:        ws,
+		assembler: wschunk.NewAssembler(h.cfg.ChunkTimeout, int(h.cfg.MaxMessageSize)),
+		send:      make(chan [][]byte, h.cfg.SendBuffer),
+		done:      make(chan struct{}),
+	}
+}
+
+// enqueue 排入待写出的消息，不阻塞调用方
+func (c *conn) enqueue(frames [][]byte) error {
+	select {
+	case <-c.done:
+		return ErrAgentNotConnected
+	default:
+	}
+
+	select {
+	case c.send <- frames:
+		return nil
+	case <-c.done:
+		return ErrAgentNotConnected
+	default:
+		return ErrSendBufferFull
+	}
+}
+
+// close 关闭连接，可重复调用
+func (c *conn) close() {
+	c.closeWith(websocket.CloseNormalClosure, "")
+}
+
+func (c *conn) agent() string {
+	return c.agentID
+}
+
+func (c *conn) transport() string {
+	return models.TransportWebSocket
+}
+
+func (c *conn) remote() string {
+	return c.ws.RemoteAddr().String()
+}
+
+func (c *conn) queued() (int, int) {
+	return len(c.send), cap(c.send)
+}
+
+// closeWith 以指定的关闭码关闭连接，只有首次调用生效
+func (c *conn) closeWith(code int, reason string) {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		deadline := time.Now().Add(c.hub.cfg.WriteTimeout)
+		c.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), deadline)
+		c.ws.Close()
+	})
+}
+
+// writeLoop 写出排队的消息并定期发送Ping
+func (c *conn) writeLoop() {
+	ticker := time.NewTicker(c.hub.cfg.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case frames := <-c.send:
+			for _, frame := range frames {
+				c.ws.SetWriteDeadline(time.Now().Add(c.hub.cfg.WriteTimeout))
+				if err := c.ws.WriteMessage(websocket.TextMessage, frame); err != nil {
+					c.hub.logger.WithError(err).WithField("agent_id", c.agentID).Warn("写入WebSocket消息失败，断开连接")
+					c.close()
+					return
+				}
+			}
+		case <-ticker.C:
+			deadline := time.Now().Add(c.hub.cfg.WriteTimeout)
+			if err := c.ws.WriteControl(websocket.PingMessage, nil, deadline); err != nil {
+				c.hub.logger.WithError(err).WithField("agent_id", c.agentID).Warn("发送Ping失败，断开连接")
+				c.close()
+				return
+			}
+		}
+	}
+}
+
+// readLoop 读取Agent上报的消息，超过PongTimeout没有收到Pong或任何消息时断开
+func (c *conn) readLoop(ctx context.Context) {
+	if c.hub.cfg.MaxMessageSize > 0 {
+		c.ws.SetReadLimit(c.hub.cfg.MaxMessageSize)
+	}
+	c.ws.SetReadDeadline(time.Now().Add(c.hub.cfg.PongTimeout))
+	c.ws.SetPongHandler(func(string) error {
+		return c.ws.SetReadDeadline(time.Now().Add(c.hub.cfg.PongTimeout))
+	})
+
+	for {
+		_, data, err := c.ws.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				c.hub.logger.WithError(err).WithField("agent_id", c.agentID).Debug("读取WebSocket消息失败")
+			}
+			return
+		}
+		c.ws.SetReadDeadline(time.Now().Add(c.hub.cfg.PongTimeout))
+		c.handle(ctx, data)
+	}
+}
+
+// handle 解析消息并交给处理器，分片消息重组完整后再处理
+func (c *conn) handle(ctx context.Context, data []byte) {
+	logger := c.hub.logger.WithField("agent_id", c.agentID)
+
+	var msg models.WebSocketMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		logger.WithError(err).Warn("解析WebSocket消息失败")
+		return
+	}
+
+	if msg.Type == models.MsgTypeChunk {
+		var envelope wschunk.Envelope
+		if err := json.Unmarshal(msg.Payload, &envelope); err != nil {
+			logger.WithError(err).Warn("解析分片消息失败")
+			return
+		}
+		msgType, payload, complete, err := c.assembler.Add(&envelope)
+		if err != nil {
+			logger.WithError(err).Warn("重组分片消息失败")
+			return
+		}
+		if !complete {
+			return
+		}
+		msg.Type = msgType
+		msg.Payload = payload
+	}
+
+	logger.WithField("type", msg.Type).Debug("收到Agent WebSocket消息")
+	c.hub.dispatch(ctx, c.agentID, &msg)
+}
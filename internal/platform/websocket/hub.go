@@ -0,0 +1,450 @@
+// Package websocket 管理平台与Agent之间的WebSocket连接
+//
+// Hub 按Agent ID维护连接，向在线Agent推送消息，并把Agent上报的消息交给 MessageHandler 处理。
+// config_deploy、config_delete 携带消息ID，Agent处理后以 ack 确认，未确认的消息在Agent重新连接时重发。
+// 连接的认证在升级前由HTTP中间件完成（令牌校验及令牌与 agent_id 的绑定），Hub 只接受已认证的请求。
+// 代理不允许WebSocket时Agent改用HTTP长轮询，长轮询会话与WebSocket连接一样登记在 Hub 中，推送、重发和断开通知相同。
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/service"
+	"logstash-platform/internal/platform/tracing"
+	"logstash-platform/pkg/wschunk"
+)
+
+// ErrAgentNotConnected Agent当前没有WebSocket连接
+var ErrAgentNotConnected = errors.New("Agent未建立WebSocket连接")
+
+// ErrSendBufferFull 连接的发送缓冲已满，通常意味着Agent读取过慢或连接已失效
+var ErrSendBufferFull = errors.New("WebSocket发送缓冲已满")
+
+// Config 连接参数
+type Config struct {
+	PingInterval    time.Duration // 平台发送Ping的间隔
+	PongTimeout     time.Duration // 超过该时间未收到Pong或任何消息时断开连接
+	WriteTimeout    time.Duration // 单帧写入超时
+	ReadBufferSize  int
+	WriteBufferSize int
+	MaxFrameSize    int           // 单帧消息大小上限，超过时分片发送，0表示不分片
+	ChunkTimeout    time.Duration // 分片消息重组超时
+	MaxMessageSize  int64         // 单条消息（重组后）大小上限，0表示不限制
+	SendBuffer      int           // 每个连接等待写出的消息数
+	ResendTTL       time.Duration // 未确认的config_deploy、config_delete保留待重发的时长
+	ResendLimit     int           // 每个Agent保留的未确认消息数上限，超出时丢弃最早的
+	PollMaxWait     time.Duration // 长轮询没有消息时的最长等待时间，Agent请求的等待时间超过时按该值
+}
+
+// withDefaults 补全未设置的参数
+func (c Config) withDefaults() Config {
+	if c.PingInterval <= 0 {
+		c.PingInterval = 30 * time.Second
+	}
+	if c.PongTimeout <= c.PingInterval {
+		c.PongTimeout = 2 * c.PingInterval
+	}
+	if c.WriteTimeout <= 0 {
+		c.WriteTimeout = 10 * time.Second
+	}
+	if c.ChunkTimeout <= 0 {
+		c.ChunkTimeout = 30 * time.Second
+	}
+	if c.SendBuffer <= 0 {
+		c.SendBuffer = 64
+	}
+	if c.ResendTTL <= 0 {
+		c.ResendTTL = 10 * time.Minute
+	}
+	if c.ResendLimit <= 0 {
+		c.ResendLimit = 100
+	}
+	if c.PollMaxWait <= 0 {
+		c.PollMaxWait = 60 * time.Second
+	}
+	return c
+}
+
+// MessageHandler 处理Agent上报的消息，同一连接的消息按接收顺序串行处理
+type MessageHandler interface {
+	HandleMessage(ctx context.Context, agentID string, msg *models.WebSocketMessage) error
+}
+
+// ConnectListener 接收Agent建立连接的通知，例如重新下发断线期间未确认的部署
+type ConnectListener interface {
+	AgentConnected(agentID string)
+}
+
+// DisconnectListener 接收Agent连接断开的通知，连接被同一Agent的新连接替换时不通知
+type DisconnectListener interface {
+	AgentDisconnected(agentID string)
+}
+
+// agentConn Agent的消息通道：WebSocket连接或长轮询会话，Hub对两者的推送、重发和断开处理相同
+type agentConn interface {
+	agent() string
+	transport() string // models.TransportWebSocket 或 models.TransportLongPoll
+	remote() string
+	// enqueue 排入待发送的消息，不阻塞调用方
+	enqueue(frames [][]byte) error
+	// closeWith 关闭通道，可重复调用；长轮询会话忽略关闭码
+	closeWith(code int, reason string)
+	// queued 等待发送的消息数及缓冲容量
+	queued() (int, int)
+}
+
+// Hub WebSocket连接管理
+type Hub struct {
+	cfg      Config
+	upgrader websocket.Upgrader
+	logger   *logrus.Logger
+
+	handler  MessageHandler
+	fallback service.MessagePublisher
+	listener ConnectListener
+	onClose  DisconnectListener
+
+	mu    sync.RWMutex
+	conns map[string]agentConn
+
+	// 已写入连接、尚未收到Agent确认的消息，Agent重新连接时重发
+	resend *resendQueue
+}
+
+// NewHub 创建连接管理
+func NewHub(cfg Config, logger *logrus.Logger) *Hub {
+	cfg = cfg.withDefaults()
+	return &Hub{
+		cfg: cfg,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  cfg.ReadBufferSize,
+			WriteBufferSize: cfg.WriteBufferSize,
+			// Agent不是浏览器，认证由令牌完成，不校验Origin
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+		logger: logger,
+		conns:  make(map[string]agentConn),
+		resend: newResendQueue(cfg.ResendTTL, cfg.ResendLimit),
+	}
+}
+
+// SetHandler 设置Agent上报消息的处理器，未设置时消息只记录日志
+func (h *Hub) SetHandler(handler MessageHandler) {
+	h.handler = handler
+}
+
+// SetFallback 设置Agent未连接时的推送通道，例如心跳命令队列
+func (h *Hub) SetFallback(publisher service.MessagePublisher) {
+	h.fallback = publisher
+}
+
+// SetConnectListener 设置Agent建立连接时的通知对象
+func (h *Hub) SetConnectListener(listener ConnectListener) {
+	h.listener = listener
+}
+
+// SetDisconnectListener 设置Agent连接断开时的通知对象
+func (h *Hub) SetDisconnectListener(listener DisconnectListener) {
+	h.onClose = listener
+}
+
+// ServeAgent 将已认证的HTTP请求升级为Agent的WebSocket连接，并阻塞到连接关闭
+// 同一Agent重复连接时关闭旧连接，以最新的连接为准
+func (h *Hub) ServeAgent(w http.ResponseWriter, r *http.Request, agentID string) error {
+	if agentID == "" {
+		return fmt.Errorf("Agent ID不能为空")
+	}
+
+	ws, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return fmt.Errorf("升级WebSocket连接失败: %w", err)
+	}
+
+	c := newConn(h, agentID, ws)
+	h.register(c)
+	defer h.unregister(c)
+
+	go c.writeLoop()
+	c.readLoop(r.Context())
+	return nil
+}
+
+// register 登记连接，替换同一Agent的旧连接（包括另一种传输方式的通道），并重发该Agent未确认的消息
+// 旧连接发送缓冲中未写出的消息、Agent断线前未处理完的消息均在此重发，Agent按消息ID去重
+func (h *Hub) register(c agentConn) {
+	h.mu.Lock()
+	old := h.conns[c.agent()]
+	h.conns[c.agent()] = c
+	h.mu.Unlock()
+
+	if old != nil {
+		h.logger.WithField("agent_id", c.agent()).Info("Agent重新建立连接，关闭旧连接")
+		old.closeWith(websocket.CloseNormalClosure, "")
+	}
+	h.logger.WithFields(logrus.Fields{
+		"agent_id":  c.agent(),
+		"remote":    c.remote(),
+		"transport": c.transport(),
+	}).Info("Agent已连接")
+	h.replay(c)
+
+	if h.listener != nil {
+		h.listener.AgentConnected(c.agent())
+	}
+}
+
+// unregister 移除连接，连接已被新连接替换时保留新连接
+func (h *Hub) unregister(c agentConn) {
+	h.mu.Lock()
+	current := h.conns[c.agent()] == c
+	if current {
+		delete(h.conns, c.agent())
+	}
+	h.mu.Unlock()
+
+	c.closeWith(websocket.CloseNormalClosure, "")
+	h.logger.WithFields(logrus.Fields{
+		"agent_id":  c.agent(),
+		"transport": c.transport(),
+	}).Info("Agent已断开")
+
+	if current && h.onClose != nil {
+		h.onClose.AgentDisconnected(c.agent())
+	}
+}
+
+// Publish 实现MessagePublisher接口，向Agent推送消息
+// Agent未连接时交给备用通道，没有备用通道时返回ErrAgentNotConnected
+func (h *Hub) Publish(agentID, msgType string, payload interface{}) error {
+	return h.PublishContext(context.Background(), agentID, msgType, payload)
+}
+
+// PublishContext 实现ContextPublisher接口，消息的metadata携带ctx中的链路上下文
+// 需要确认的消息写入连接后保留在重发队列中，交给备用通道的消息由备用通道自行确认
+func (h *Hub) PublishContext(ctx context.Context, agentID, msgType string, payload interface{}) error {
+	h.mu.RLock()
+	c := h.conns[agentID]
+	h.mu.RUnlock()
+
+	if c == nil {
+		if h.fallback != nil {
+			return service.PublishWithContext(ctx, h.fallback, agentID, msgType, payload)
+		}
+		return fmt.Errorf("%w: %s", ErrAgentNotConnected, agentID)
+	}
+
+	var id string
+	if acknowledgedTypes[msgType] {
+		id = uuid.New().String()
+	}
+	frames, raw, err := h.buildFrames(id, msgType, payload, tracing.Inject(ctx))
+	if err != nil {
+		return err
+	}
+	// 先写入重发队列再排入连接：连接恰好被替换时，新连接注册后的重发会带上这条消息
+	if id != "" {
+		h.resend.add(agentID, &unackedMessage{id: id, key: messageKey(raw), frames: frames, queuedAt: time.Now()})
+	}
+	if err := c.enqueue(frames); err != nil {
+		if id != "" {
+			h.resend.ack(agentID, []string{id})
+		}
+		return fmt.Errorf("%w: %s", err, agentID)
+	}
+	return nil
+}
+
+// replay 向新连接按原顺序重发未确认的消息
+func (h *Hub) replay(c agentConn) {
+	pending := h.resend.pending(c.agent(), time.Now())
+	for _, msg := range pending {
+		if err := c.enqueue(msg.frames); err != nil {
+			h.logger.WithError(err).WithField("agent_id", c.agent()).Warn("重发未确认的消息失败")
+			return
+		}
+	}
+	if len(pending) > 0 {
+		h.logger.WithFields(logrus.Fields{
+			"agent_id": c.agent(),
+			"count":    len(pending),
+		}).Info("Agent重新连接，重发未确认的消息")
+	}
+}
+
+// dispatch 处理Agent上报的完整消息，ack由Hub处理，其余交给处理器
+func (h *Hub) dispatch(ctx context.Context, agentID string, msg *models.WebSocketMessage) {
+	if msg.Type == models.MsgTypeAck {
+		h.acknowledge(agentID, msg.Payload)
+		return
+	}
+
+	if h.handler == nil {
+		return
+	}
+	if err := h.handler.HandleMessage(ctx, agentID, msg); err != nil {
+		h.logger.WithError(err).WithFields(logrus.Fields{"agent_id": agentID, "type": msg.Type}).Warn("处理Agent上报的消息失败")
+	}
+}
+
+// acknowledge 处理Agent的ack消息，从重发队列中移除已确认的消息
+func (h *Hub) acknowledge(agentID string, payload json.RawMessage) {
+	var ack models.MessageAck
+	if err := json.Unmarshal(payload, &ack); err != nil {
+		h.logger.WithError(err).WithField("agent_id", agentID).Warn("解析消息确认失败")
+		return
+	}
+	removed := h.resend.ack(agentID, ack.IDs)
+	h.logger.WithFields(logrus.Fields{
+		"agent_id": agentID,
+		"acked":    removed,
+	}).Debug("Agent确认消息")
+}
+
+// IsConnected Agent当前是否有WebSocket连接或长轮询会话
+func (h *Hub) IsConnected(agentID string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	_, ok := h.conns[agentID]
+	return ok
+}
+
+// ConnectedAgents 获取当前已连接的Agent ID，按ID排序
+func (h *Hub) ConnectedAgents() []string {
+	h.mu.RLock()
+	ids := make([]string, 0, len(h.conns))
+	for id := range h.conns {
+		ids = append(ids, id)
+	}
+	h.mu.RUnlock()
+
+	sort.Strings(ids)
+	return ids
+}
+
+// WorkerStatus 报告当前连接数、长轮询会话数、等待写出的消息数和发送缓冲将满的连接数
+func (h *Hub) WorkerStatus(now time.Time) models.WorkerStatus {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	queued, saturated, polling := 0, 0, 0
+	for _, c := range h.conns {
+		n, capacity := c.queued()
+		queued += n
+		if n*4 >= capacity*3 {
+			saturated++
+		}
+		if c.transport() == models.TransportLongPoll {
+			polling++
+		}
+	}
+	return models.WorkerStatus{
+		Name:    "websocket_hub",
+		Kind:    models.WorkerKindConnections,
+		Running: true,
+		Gauges: map[string]float64{
+			"connections":           float64(len(h.conns)),
+			"long_poll_sessions":    float64(polling),
+			"queued_messages":       float64(queued),
+			"saturated_connections": float64(saturated),
+			"unacked_messages":      float64(h.resend.size()),
+		},
+	}
+}
+
+// Disconnect 关闭Agent的连接并丢弃其未确认的消息，用于Agent注销；Agent没有连接时返回false
+// 与平台关闭相同不通知断开监听方，已注销的Agent不再产生离线事件
+func (h *Hub) Disconnect(agentID, reason string) bool {
+	h.mu.Lock()
+	c := h.conns[agentID]
+	delete(h.conns, agentID)
+	h.mu.Unlock()
+
+	h.resend.drop(agentID)
+	if c == nil {
+		return false
+	}
+	h.logger.WithField("agent_id", agentID).Info("关闭已注销Agent的连接")
+	c.closeWith(websocket.CloseNormalClosure, reason)
+	return true
+}
+
+// Close 平台关闭时关闭全部连接，发送going-away关闭帧，Agent不将其记录为异常断开，随后重新连接
+// 关闭的连接不通知断开监听方，Agent不会因平台关闭被判定离线
+func (h *Hub) Close() {
+	h.mu.Lock()
+	conns := h.conns
+	h.conns = make(map[string]agentConn)
+	h.mu.Unlock()
+
+	for _, c := range conns {
+		c.closeWith(websocket.CloseGoingAway, "平台正在关闭")
+	}
+}
+
+// buildFrames 序列化消息，超过帧大小上限时拆分为分片消息，每个分片都携带消息ID和链路上下文，同时返回序列化的消息内容
+// 分片内容经base64编码后约膨胀1/3，按帧上限的一半切分以留出封包余量
+func (h *Hub) buildFrames(id, msgType string, payload interface{}, metadata map[string]string) ([][]byte, json.RawMessage, error) {
+	msg := models.WebSocketMessage{
+		ID:        id,
+		Type:      msgType,
+		Timestamp: time.Now(),
+		Metadata:  metadata,
+	}
+	switch v := payload.(type) {
+	case nil:
+	case json.RawMessage:
+		msg.Payload = v
+	default:
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, nil, fmt.Errorf("序列化消息失败: %w", err)
+		}
+		msg.Payload = data
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("序列化消息失败: %w", err)
+	}
+
+	limit := h.cfg.MaxFrameSize
+	if limit <= 0 || len(data) <= limit {
+		return [][]byte{data}, msg.Payload, nil
+	}
+
+	chunkSize := limit / 2
+	if chunkSize < 1 {
+		chunkSize = 1
+	}
+	chunks := wschunk.Split(msgType, msg.Payload, chunkSize)
+	frames := make([][]byte, 0, len(chunks))
+	for i := range chunks {
+		envelope, err := json.Marshal(&chunks[i])
+		if err != nil {
+			return nil, nil, fmt.Errorf("序列化分片失败: %w", err)
+		}
+		frame, err := json.Marshal(models.WebSocketMessage{
+			ID:        id,
+			Type:      models.MsgTypeChunk,
+			Timestamp: msg.Timestamp,
+			Payload:   envelope,
+			Metadata:  metadata,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("序列化分片失败: %w", err)
+		}
+		frames = append(frames, frame)
+	}
+	return frames, msg.Payload, nil
+}
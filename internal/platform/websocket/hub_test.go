@@ -0,0 +1,487 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/internal/platform/tracing"
+	"logstash-platform/pkg/wschunk"
+)
+
+// recordingHandler 记录收到的Agent消息
+type recordingHandler struct {
+	mu       sync.Mutex
+	messages []*models.WebSocketMessage
+}
+
+func (h *recordingHandler) HandleMessage(ctx context.Context, agentID string, msg *models.WebSocketMessage) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.messages = append(h.messages, msg)
+	return nil
+}
+
+func (h *recordingHandler) received() []*models.WebSocketMessage {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]*models.WebSocketMessage(nil), h.messages...)
+}
+
+// recordingPublisher 记录经备用通道推送的消息类型
+type recordingPublisher struct {
+	types []string
+}
+
+func (p *recordingPublisher) Publish(agentID, msgType string, payload interface{}) error {
+	p.types = append(p.types, agentID+":"+msgType)
+	return nil
+}
+
+func newTestHub(t *testing.T, cfg Config) (*Hub, *httptest.Server) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	hub := NewHub(cfg, logger)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hub.ServeAgent(w, r, r.URL.Query().Get("agent_id"))
+	}))
+	t.Cleanup(func() {
+		hub.Close()
+		server.Close()
+	})
+	return hub, server
+}
+
+func dial(t *testing.T, hub *Hub, server *httptest.Server, agentID string) *websocket.Conn {
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws?agent_id=" + agentID
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	require.Eventually(t, func() bool { return hub.IsConnected(agentID) }, time.Second, 10*time.Millisecond)
+	return conn
+}
+
+func readMessage(t *testing.T, conn *websocket.Conn) models.WebSocketMessage {
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, data, err := conn.ReadMessage()
+	require.NoError(t, err)
+	var msg models.WebSocketMessage
+	require.NoError(t, json.Unmarshal(data, &msg))
+	return msg
+}
+
+func TestHub_Publish(t *testing.T) {
+	hub, server := newTestHub(t, Config{})
+
+	err := hub.Publish("agent-1", models.MsgTypeConfigDeploy, nil)
+	assert.ErrorIs(t, err, ErrAgentNotConnected)
+
+	conn := dial(t, hub, server, "agent-1")
+	assert.Equal(t, []string{"agent-1"}, hub.ConnectedAgents())
+
+	require.NoError(t, hub.Publish("agent-1", models.MsgTypeConfigDeploy, models.ConfigDeployPayload{ConfigID: "config-1", Version: 2}))
+	msg := readMessage(t, conn)
+	assert.Equal(t, models.MsgTypeConfigDeploy, msg.Type)
+	var payload models.ConfigDeployPayload
+	require.NoError(t, json.Unmarshal(msg.Payload, &payload))
+	assert.Equal(t, "config-1", payload.ConfigID)
+	assert.Equal(t, 2, payload.Version)
+
+	t.Run("未连接的Agent交给备用通道", func(t *testing.T) {
+		fallback := &recordingPublisher{}
+		hub.SetFallback(fallback)
+		defer hub.SetFallback(nil)
+
+		require.NoError(t, hub.Publish("agent-2", models.MsgTypeSettingsUpdate, nil))
+		require.NoError(t, hub.Publish("agent-1", models.MsgTypeReloadRequest, nil))
+		assert.Equal(t, []string{"agent-2:" + models.MsgTypeSettingsUpdate}, fallback.types)
+		assert.Equal(t, models.MsgTypeReloadRequest, readMessage(t, conn).Type)
+	})
+}
+
+func TestHub_PublishContext(t *testing.T) {
+	shutdown, err := tracing.Setup(context.Background(), tracing.Config{})
+	require.NoError(t, err)
+	defer shutdown(context.Background())
+
+	hub, server := newTestHub(t, Config{MaxFrameSize: 256})
+	conn := dial(t, hub, server, "agent-1")
+
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	ctx := tracing.Extract(context.Background(), map[string]string{"traceparent": traceparent})
+
+	require.NoError(t, hub.PublishContext(ctx, "agent-1", models.MsgTypeConfigDeploy, models.ConfigDeployPayload{ConfigID: "config-1"}))
+	assert.Equal(t, traceparent, readMessage(t, conn).Metadata["traceparent"])
+
+	// 分片消息的每个分片都携带链路上下文
+	require.NoError(t, hub.PublishContext(ctx, "agent-1", models.MsgTypeSettingsUpdate, map[string]string{"data": strings.Repeat("x", 1024)}))
+	first := readMessage(t, conn)
+	assert.Equal(t, models.MsgTypeChunk, first.Type)
+	assert.Equal(t, traceparent, first.Metadata["traceparent"])
+
+	require.NoError(t, hub.Publish("agent-1", models.MsgTypeReloadRequest, nil))
+	for {
+		msg := readMessage(t, conn)
+		if msg.Type == models.MsgTypeChunk {
+			continue
+		}
+		assert.Equal(t, models.MsgTypeReloadRequest, msg.Type)
+		assert.Empty(t, msg.Metadata)
+		break
+	}
+}
+
+func TestHub_PublishChunked(t *testing.T) {
+	hub, server := newTestHub(t, Config{MaxFrameSize: 512})
+	conn := dial(t, hub, server, "agent-1")
+
+	content := strings.Repeat("filter { mutate { add_field => { \"k\" => \"v\" } } }\n", 100)
+	require.NoError(t, hub.Publish("agent-1", models.MsgTypeSettingsUpdate, map[string]string{"content": content}))
+
+	assembler := wschunk.NewAssembler(time.Second, 0)
+	for {
+		msg := readMessage(t, conn)
+		require.Equal(t, models.MsgTypeChunk, msg.Type)
+		var env wschunk.Envelope
+		require.NoError(t, json.Unmarshal(msg.Payload, &env))
+		msgType, payload, complete, err := assembler.Add(&env)
+		require.NoError(t, err)
+		if !complete {
+			continue
+		}
+		assert.Equal(t, models.MsgTypeSettingsUpdate, msgType)
+		var decoded map[string]string
+		require.NoError(t, json.Unmarshal(payload, &decoded))
+		assert.Equal(t, content, decoded["content"])
+		break
+	}
+}
+
+func TestHub_InboundMessages(t *testing.T) {
+	hub, server := newTestHub(t, Config{})
+	handler := &recordingHandler{}
+	hub.SetHandler(handler)
+	conn := dial(t, hub, server, "agent-1")
+
+	require.NoError(t, conn.WriteJSON(models.WebSocketMessage{Type: models.MsgTypeHeartbeat, Timestamp: time.Now()}))
+
+	// 分片上报的消息重组后再交给处理器
+	payload, _ := json.Marshal(map[string]string{"config_id": "config-1"})
+	for _, env := range wschunk.Split(models.MsgTypeConfigApplied, payload, 8) {
+		data, _ := json.Marshal(env)
+		require.NoError(t, conn.WriteJSON(models.WebSocketMessage{Type: models.MsgTypeChunk, Payload: data}))
+	}
+
+	require.Eventually(t, func() bool { return len(handler.received()) == 2 }, time.Second, 10*time.Millisecond)
+	received := handler.received()
+	assert.Equal(t, models.MsgTypeHeartbeat, received[0].Type)
+	assert.Equal(t, models.MsgTypeConfigApplied, received[1].Type)
+	assert.JSONEq(t, string(payload), string(received[1].Payload))
+}
+
+func TestHub_ReconnectReplacesConnection(t *testing.T) {
+	hub, server := newTestHub(t, Config{})
+	old := dial(t, hub, server, "agent-1")
+	current := dial(t, hub, server, "agent-1")
+
+	// 旧连接被平台关闭
+	old.SetReadDeadline(time.Now().Add(time.Second))
+	_, _, err := old.ReadMessage()
+	assert.Error(t, err)
+
+	require.NoError(t, hub.Publish("agent-1", models.MsgTypeStatusRequest, nil))
+	assert.Equal(t, models.MsgTypeStatusRequest, readMessage(t, current).Type)
+	assert.Equal(t, []string{"agent-1"}, hub.ConnectedAgents())
+}
+
+// connectRecorder 记录建立连接的Agent
+type connectRecorder struct {
+	connected chan string
+}
+
+func (r *connectRecorder) AgentConnected(agentID string) {
+	r.connected <- agentID
+}
+
+func TestHub_ConnectListener(t *testing.T) {
+	hub, server := newTestHub(t, Config{})
+	recorder := &connectRecorder{connected: make(chan string, 2)}
+	hub.SetConnectListener(recorder)
+
+	dial(t, hub, server, "agent-1")
+	dial(t, hub, server, "agent-1")
+	assert.Equal(t, "agent-1", <-recorder.connected)
+	assert.Equal(t, "agent-1", <-recorder.connected, "重新连接同样通知")
+}
+
+// disconnectRecorder 记录断开连接的Agent
+type disconnectRecorder struct {
+	disconnected chan string
+}
+
+func (r *disconnectRecorder) AgentDisconnected(agentID string) {
+	r.disconnected <- agentID
+}
+
+func TestHub_DisconnectListener(t *testing.T) {
+	hub, server := newTestHub(t, Config{})
+	recorder := &disconnectRecorder{disconnected: make(chan string, 2)}
+	hub.SetDisconnectListener(recorder)
+
+	dial(t, hub, server, "agent-1")
+	current := dial(t, hub, server, "agent-1")
+	select {
+	case agentID := <-recorder.disconnected:
+		t.Fatalf("连接被替换时不应通知断开: %s", agentID)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	current.Close()
+	select {
+	case agentID := <-recorder.disconnected:
+		assert.Equal(t, "agent-1", agentID)
+	case <-time.After(time.Second):
+		t.Fatal("连接断开时未通知")
+	}
+}
+
+func TestHub_CloseGoingAway(t *testing.T) {
+	hub, server := newTestHub(t, Config{})
+	recorder := &disconnectRecorder{disconnected: make(chan string, 1)}
+	hub.SetDisconnectListener(recorder)
+	conn := dial(t, hub, server, "agent-1")
+
+	hub.Close()
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, _, err := conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	require.ErrorAs(t, err, &closeErr)
+	assert.Equal(t, websocket.CloseGoingAway, closeErr.Code)
+	assert.False(t, hub.IsConnected("agent-1"))
+
+	select {
+	case agentID := <-recorder.disconnected:
+		t.Fatalf("平台关闭时不应通知断开: %s", agentID)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestHub_DisconnectDecommissioned(t *testing.T) {
+	hub, server := newTestHub(t, Config{})
+	recorder := &disconnectRecorder{disconnected: make(chan string, 1)}
+	hub.SetDisconnectListener(recorder)
+	conn := dial(t, hub, server, "agent-1")
+
+	assert.True(t, hub.Disconnect("agent-1", "Agent已注销"))
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, _, err := conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	require.ErrorAs(t, err, &closeErr)
+	assert.Equal(t, websocket.CloseNormalClosure, closeErr.Code)
+	assert.False(t, hub.IsConnected("agent-1"))
+	assert.False(t, hub.Disconnect("agent-1", "Agent已注销"))
+
+	select {
+	case agentID := <-recorder.disconnected:
+		t.Fatalf("注销关闭的连接不应通知断开: %s", agentID)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestHub_PongTimeout(t *testing.T) {
+	hub, server := newTestHub(t, Config{PingInterval: 20 * time.Millisecond, PongTimeout: 60 * time.Millisecond})
+	alive := dial(t, hub, server, "agent-1")
+	dial(t, hub, server, "agent-2")
+
+	// 持续读取的客户端自动回复Pong，连接保持
+	go func() {
+		for {
+			if _, _, err := alive.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	// 不读取的客户端不会回复Pong，平台断开连接
+	assert.Eventually(t, func() bool { return !hub.IsConnected("agent-2") }, 2*time.Second, 10*time.Millisecond)
+	assert.True(t, hub.IsConnected("agent-1"))
+}
+
+func TestHub_ResendUnacked(t *testing.T) {
+	hub, server := newTestHub(t, Config{MaxFrameSize: 512})
+	conn := dial(t, hub, server, "agent-1")
+
+	require.NoError(t, hub.Publish("agent-1", models.MsgTypeConfigDeploy, models.ConfigDeployPayload{ConfigID: "config-1", Version: 1}))
+	require.NoError(t, hub.Publish("agent-1", models.MsgTypeConfigDelete, models.ConfigDeletePayload{ConfigID: "config-2"}))
+	require.NoError(t, hub.Publish("agent-1", models.MsgTypeConfigDeploy, models.ConfigDeployPayload{ConfigID: "config-1", Version: 2}))
+	require.NoError(t, hub.Publish("agent-1", models.MsgTypeReloadRequest, nil))
+
+	deploy1, deleted, deploy2, reload := readMessage(t, conn), readMessage(t, conn), readMessage(t, conn), readMessage(t, conn)
+	assert.NotEmpty(t, deploy1.ID)
+	assert.NotEmpty(t, deleted.ID)
+	assert.NotEqual(t, deploy1.ID, deploy2.ID)
+	assert.Empty(t, reload.ID, "其余消息不需要确认")
+	// 同一配置的后续部署取代之前未确认的部署
+	assert.Equal(t, 2, hub.resend.size())
+
+	ack, _ := json.Marshal(models.MessageAck{IDs: []string{deleted.ID}})
+	require.NoError(t, conn.WriteJSON(models.WebSocketMessage{Type: models.MsgTypeAck, Payload: ack}))
+	require.Eventually(t, func() bool { return hub.resend.size() == 1 }, time.Second, 10*time.Millisecond)
+
+	// 重新连接后按原ID重发未确认的消息
+	conn.Close()
+	require.Eventually(t, func() bool { return !hub.IsConnected("agent-1") }, time.Second, 10*time.Millisecond)
+	conn = dial(t, hub, server, "agent-1")
+	replayed := readMessage(t, conn)
+	assert.Equal(t, deploy2.ID, replayed.ID)
+	assert.Equal(t, models.MsgTypeConfigDeploy, replayed.Type)
+	assert.JSONEq(t, string(deploy2.Payload), string(replayed.Payload))
+
+	// 分片消息的每个分片都携带消息ID
+	content := strings.Repeat("x", 2048)
+	require.NoError(t, hub.Publish("agent-1", models.MsgTypeConfigDeploy, map[string]string{"config_id": "config-3", "content": content}))
+	first := readMessage(t, conn)
+	assert.Equal(t, models.MsgTypeChunk, first.Type)
+	assert.NotEmpty(t, first.ID)
+	assert.Equal(t, first.ID, readMessage(t, conn).ID)
+	assert.Equal(t, 2, hub.resend.size())
+}
+
+// replacedConn 排入消息前Agent已经重新连接，模拟发布与连接替换交错
+type replacedConn struct {
+	agentConn
+	hub         *Hub
+	replacement *recordingConn
+}
+
+func (c *replacedConn) enqueue(frames [][]byte) error {
+	c.hub.register(c.replacement)
+	return nil
+}
+
+// recordingConn 记录排入的消息
+type recordingConn struct {
+	id     string
+	frames [][]byte
+}
+
+func (c *recordingConn) agent() string         { return c.id }
+func (c *recordingConn) transport() string     { return models.TransportWebSocket }
+func (c *recordingConn) remote() string        { return "test" }
+func (c *recordingConn) closeWith(int, string) {}
+func (c *recordingConn) queued() (int, int)    { return 0, 0 }
+func (c *recordingConn) enqueue(frames [][]byte) error {
+	c.frames = append(c.frames, frames...)
+	return nil
+}
+
+func TestHub_PublishDuringReconnect(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	hub := NewHub(Config{}, logger)
+
+	replacement := &recordingConn{id: "agent-1"}
+	old := &replacedConn{agentConn: &recordingConn{id: "agent-1"}, hub: hub, replacement: replacement}
+	hub.mu.Lock()
+	hub.conns["agent-1"] = old
+	hub.mu.Unlock()
+
+	// 旧连接已关闭，需要确认的消息由新连接注册时的重发送达
+	require.NoError(t, hub.Publish("agent-1", models.MsgTypeConfigDeploy, models.ConfigDeployPayload{ConfigID: "config-1", Version: 1}))
+	require.Len(t, replacement.frames, 1)
+	var msg models.WebSocketMessage
+	require.NoError(t, json.Unmarshal(replacement.frames[0], &msg))
+	assert.Equal(t, models.MsgTypeConfigDeploy, msg.Type)
+	assert.Equal(t, 1, hub.resend.size())
+}
+
+func TestResendQueue_Expiry(t *testing.T) {
+	queue := newResendQueue(time.Minute, 2)
+	now := time.Now()
+
+	queue.add("agent-1", &unackedMessage{id: "m1", key: "config-1", queuedAt: now.Add(-2 * time.Minute)})
+	queue.add("agent-2", &unackedMessage{id: "m2", key: "config-1", queuedAt: now.Add(-2 * time.Minute)})
+	queue.add("agent-1", &unackedMessage{id: "m3", key: "config-2", queuedAt: now})
+	assert.Equal(t, 1, queue.size(), "超过保留时长的消息丢弃，包括其他Agent的")
+
+	queue.add("agent-1", &unackedMessage{id: "m4", key: "config-3", queuedAt: now})
+	queue.add("agent-1", &unackedMessage{id: "m5", key: "config-4", queuedAt: now})
+	pending := queue.pending("agent-1", now)
+	require.Len(t, pending, 2, "超出条数上限时丢弃最早的")
+	assert.Equal(t, "m4", pending[0].id)
+	assert.Equal(t, "m5", pending[1].id)
+
+	assert.Equal(t, 1, queue.ack("agent-1", []string{"m4", "unknown"}))
+	assert.Equal(t, 0, queue.ack("agent-2", []string{"m5"}), "只移除该Agent的消息")
+	assert.Len(t, queue.pending("agent-1", now), 1)
+}
+
+func TestHub_LongPoll(t *testing.T) {
+	hub, _ := newTestHub(t, Config{PingInterval: 50 * time.Millisecond, PongTimeout: 200 * time.Millisecond, PollMaxWait: time.Second})
+	handler := &recordingHandler{}
+	hub.SetHandler(handler)
+	recorder := &disconnectRecorder{disconnected: make(chan string, 1)}
+	hub.SetDisconnectListener(recorder)
+	ctx := context.Background()
+
+	// 第一次轮询建立会话，没有消息时等待超时后返回空的一批
+	first, err := hub.ServePoll(ctx, "agent-1", "10.0.0.1", &models.PollRequest{Wait: 20 * time.Millisecond})
+	require.NoError(t, err)
+	assert.Empty(t, first.Messages)
+	assert.True(t, hub.IsConnected("agent-1"))
+
+	// 等待中的轮询收到推送的消息后立即返回
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		hub.Publish("agent-1", models.MsgTypeConfigDeploy, models.ConfigDeployPayload{ConfigID: "config-1", Version: 1})
+		hub.Publish("agent-1", models.MsgTypeReloadRequest, nil)
+	}()
+	resp, err := hub.ServePoll(ctx, "agent-1", "10.0.0.1", &models.PollRequest{Wait: time.Second, Received: first.Batch})
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.Messages)
+	var deploy models.WebSocketMessage
+	require.NoError(t, json.Unmarshal(resp.Messages[0], &deploy))
+	assert.Equal(t, models.MsgTypeConfigDeploy, deploy.Type)
+	assert.NotEmpty(t, deploy.ID)
+	assert.NotEqual(t, first.Batch, resp.Batch)
+
+	t.Run("未确认收到的一批消息重发", func(t *testing.T) {
+		again, err := hub.ServePoll(ctx, "agent-1", "10.0.0.1", &models.PollRequest{Wait: time.Second, Received: first.Batch})
+		require.NoError(t, err)
+		assert.Equal(t, resp.Batch, again.Batch)
+		assert.Equal(t, resp.Messages, again.Messages)
+	})
+
+	t.Run("经上报通道确认的消息不再重发，其他消息交给处理器", func(t *testing.T) {
+		ack, _ := json.Marshal(models.MessageAck{IDs: []string{deploy.ID}})
+		hub.HandlePolled(ctx, "agent-1", []models.WebSocketMessage{
+			{Type: models.MsgTypeAck, Payload: ack},
+			{Type: models.MsgTypeHeartbeat},
+		})
+		assert.Zero(t, hub.resend.size())
+		require.Len(t, handler.received(), 1)
+		assert.Equal(t, models.MsgTypeHeartbeat, handler.received()[0].Type)
+	})
+
+	t.Run("超过pong_timeout未再次轮询视为断开", func(t *testing.T) {
+		select {
+		case agentID := <-recorder.disconnected:
+			assert.Equal(t, "agent-1", agentID)
+		case <-time.After(time.Second):
+			t.Fatal("会话过期时未通知断开")
+		}
+		assert.False(t, hub.IsConnected("agent-1"))
+	})
+}
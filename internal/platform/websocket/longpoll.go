@@ -0,0 +1,199 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"logstash-platform/internal/platform/models"
+)
+
+// pollSession 经HTTP长轮询接收消息的Agent会话，供代理不允许WebSocket的环境使用
+// 推送给会话的消息排队等待Agent的下一次轮询；一次轮询结束后超过PongTimeout没有新的轮询时会话过期，等同于连接断开
+type pollSession struct {
+	hub        *Hub
+	agentID    string
+	remoteAddr string
+
+	send      chan [][]byte
+	done      chan struct{}
+	closeOnce sync.Once
+
+	mu       sync.Mutex
+	waiting  chan struct{} // 等待中的轮询，同一Agent的新轮询到达时关闭以结束旧的轮询
+	expiry   *time.Timer
+	batch    uint64            // 最后一批消息的序号
+	inflight []json.RawMessage // 最后一批消息，Agent以下一次轮询的received确认收到
+}
+
+// newPollSession 创建会话，会话在第一次轮询结束后开始计算过期
+func newPollSession(h *Hub, agentID, remoteAddr string) *pollSession {
+	s := &pollSession{
+		hub:        h,
+		agentID:    agentID,
+		remoteAddr: remoteAddr,
+		send:       make(chan [][]byte, h.cfg.SendBuffer),
+		done:       make(chan struct{}),
+		// 序号从会话创建时间开始，避免与Agent记录的上一个会话的序号重合
+		batch: uint64(time.Now().UnixNano()),
+	}
+	s.expiry = time.AfterFunc(h.cfg.PongTimeout, func() {
+		h.logger.WithField("agent_id", agentID).Info("Agent长轮询会话过期")
+		h.unregister(s)
+	})
+	return s
+}
+
+func (s *pollSession) agent() string {
+	return s.agentID
+}
+
+func (s *pollSession) transport() string {
+	return models.TransportLongPoll
+}
+
+func (s *pollSession) remote() string {
+	return s.remoteAddr
+}
+
+func (s *pollSession) queued() (int, int) {
+	return len(s.send), cap(s.send)
+}
+
+// enqueue 排入待Agent取走的消息，不阻塞调用方
+func (s *pollSession) enqueue(frames [][]byte) error {
+	select {
+	case <-s.done:
+		return ErrAgentNotConnected
+	default:
+	}
+
+	select {
+	case s.send <- frames:
+		return nil
+	case <-s.done:
+		return ErrAgentNotConnected
+	default:
+		return ErrSendBufferFull
+	}
+}
+
+// closeWith 关闭会话，等待中的轮询立即返回；长轮询没有关闭帧，忽略关闭码
+func (s *pollSession) closeWith(code int, reason string) {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.expiry.Stop()
+	})
+}
+
+// closed 会话是否已关闭
+func (s *pollSession) closed() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// poll 返回已排队的消息，没有消息时等待最多wait时间
+// received与最后一批消息的序号不符时说明Agent没有收到该批消息（例如响应在途中丢失），立即重发
+func (s *pollSession) poll(ctx context.Context, wait time.Duration, received uint64) *models.PollResponse {
+	s.mu.Lock()
+	if s.waiting != nil {
+		close(s.waiting)
+	}
+	waiting := make(chan struct{})
+	s.waiting = waiting
+	s.expiry.Stop()
+	if s.inflight != nil && received != s.batch {
+		resp := &models.PollResponse{Batch: s.batch, Messages: s.inflight}
+		s.mu.Unlock()
+		s.finish(waiting)
+		return resp
+	}
+	s.inflight = nil
+	s.mu.Unlock()
+	defer s.finish(waiting)
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	var messages []json.RawMessage
+	select {
+	case frames := <-s.send:
+		messages = appendFrames(messages, frames)
+	case <-timer.C:
+	case <-ctx.Done():
+	case <-waiting:
+	case <-s.done:
+	}
+	// 一次取走已排队的全部消息
+	for drained := false; len(messages) > 0 && !drained; {
+		select {
+		case frames := <-s.send:
+			messages = appendFrames(messages, frames)
+		default:
+			drained = true
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(messages) == 0 {
+		return &models.PollResponse{Batch: s.batch, Messages: []json.RawMessage{}}
+	}
+	s.batch++
+	s.inflight = messages
+	return &models.PollResponse{Batch: s.batch, Messages: messages}
+}
+
+// finish 轮询结束，仍是最新的轮询时开始计算会话过期
+func (s *pollSession) finish(waiting chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.waiting == waiting {
+		s.waiting = nil
+		if !s.closed() {
+			s.expiry.Reset(s.hub.cfg.PongTimeout)
+		}
+	}
+}
+
+// appendFrames 追加一条消息的全部帧
+func appendFrames(messages []json.RawMessage, frames [][]byte) []json.RawMessage {
+	for _, frame := range frames {
+		messages = append(messages, frame)
+	}
+	return messages
+}
+
+// ServePoll 处理已认证的Agent长轮询请求，没有会话时创建会话并视为Agent建立连接
+// 同一Agent已有WebSocket连接时以长轮询会话替换，与重复连接的处理相同
+func (h *Hub) ServePoll(ctx context.Context, agentID, remoteAddr string, req *models.PollRequest) (*models.PollResponse, error) {
+	if agentID == "" {
+		return nil, fmt.Errorf("Agent ID不能为空")
+	}
+	wait := req.Wait
+	if wait <= 0 || wait > h.cfg.PollMaxWait {
+		wait = h.cfg.PollMaxWait
+	}
+
+	h.mu.RLock()
+	s, ok := h.conns[agentID].(*pollSession)
+	h.mu.RUnlock()
+	if !ok || s.closed() {
+		s = newPollSession(h, agentID, remoteAddr)
+		h.register(s)
+	}
+	return s.poll(ctx, wait, req.Received), nil
+}
+
+// HandlePolled 处理Agent经长轮询通道上报的消息，与经WebSocket上报的消息处理相同
+func (h *Hub) HandlePolled(ctx context.Context, agentID string, messages []models.WebSocketMessage) {
+	for i := range messages {
+		h.dispatch(ctx, agentID, &messages[i])
+	}
+}
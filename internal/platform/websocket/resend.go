@@ -0,0 +1,144 @@
+package websocket
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"logstash-platform/internal/platform/models"
+)
+
+// acknowledgedTypes 需要Agent确认的消息类型，未确认的消息在Agent重新连接时重发
+// 其余消息（如实时日志、诊断）过时后重发没有意义，仍为发后即忘
+var acknowledgedTypes = map[string]bool{
+	models.MsgTypeConfigDeploy: true,
+	models.MsgTypeConfigDelete: true,
+}
+
+// unackedMessage 已写入连接、尚未收到Agent确认的消息
+type unackedMessage struct {
+	id       string
+	key      string // 同一配置的后续消息取代之前未确认的消息，重发时只保留最新的部署或删除
+	frames   [][]byte
+	queuedAt time.Time
+}
+
+// resendQueue 按Agent记录未确认的消息
+// 只保存在本副本内存中，Agent重新连接到其他副本时由部署引擎的重新下发兜底
+type resendQueue struct {
+	ttl   time.Duration
+	limit int
+
+	mu       sync.Mutex
+	messages map[string][]*unackedMessage
+}
+
+// newResendQueue 创建重发队列，超过ttl或超出每个Agent的条数上限的消息丢弃
+func newResendQueue(ttl time.Duration, limit int) *resendQueue {
+	return &resendQueue{
+		ttl:      ttl,
+		limit:    limit,
+		messages: make(map[string][]*unackedMessage),
+	}
+}
+
+// add 记录已写入连接的消息，取代同一配置之前未确认的消息
+func (q *resendQueue) add(agentID string, msg *unackedMessage) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	// 顺带清理长时间未重新连接的Agent的过期消息
+	for id := range q.messages {
+		q.live(id, msg.queuedAt)
+	}
+	pending := q.messages[agentID]
+	kept := pending[:0]
+	for _, m := range pending {
+		if msg.key == "" || m.key != msg.key {
+			kept = append(kept, m)
+		}
+	}
+	kept = append(kept, msg)
+	if len(kept) > q.limit {
+		kept = kept[len(kept)-q.limit:]
+	}
+	q.messages[agentID] = kept
+}
+
+// ack 移除Agent确认的消息，返回移除的条数
+func (q *resendQueue) ack(agentID string, ids []string) int {
+	acked := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		acked[id] = true
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	pending := q.messages[agentID]
+	kept := pending[:0]
+	for _, m := range pending {
+		if !acked[m.id] {
+			kept = append(kept, m)
+		}
+	}
+	removed := len(pending) - len(kept)
+	q.store(agentID, kept)
+	return removed
+}
+
+// drop 丢弃Agent的全部未确认消息
+func (q *resendQueue) drop(agentID string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.messages, agentID)
+}
+
+// pending 获取Agent仍在有效期内的未确认消息，按写入先后排列
+func (q *resendQueue) pending(agentID string, now time.Time) []*unackedMessage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]*unackedMessage(nil), q.live(agentID, now)...)
+}
+
+// size 全部Agent未确认的消息数
+func (q *resendQueue) size() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	n := 0
+	for _, pending := range q.messages {
+		n += len(pending)
+	}
+	return n
+}
+
+// live 丢弃已过期的消息并返回其余消息，调用方持有mu
+func (q *resendQueue) live(agentID string, now time.Time) []*unackedMessage {
+	pending := q.messages[agentID]
+	i := 0
+	for i < len(pending) && now.Sub(pending[i].queuedAt) > q.ttl {
+		i++
+	}
+	pending = pending[i:]
+	q.store(agentID, pending)
+	return pending
+}
+
+// store 保存Agent的未确认消息，为空时删除该Agent，调用方持有mu
+func (q *resendQueue) store(agentID string, pending []*unackedMessage) {
+	if len(pending) == 0 {
+		delete(q.messages, agentID)
+		return
+	}
+	q.messages[agentID] = pending
+}
+
+// messageKey 取消息内容中的config_id，同一配置的部署和删除互相取代
+func messageKey(payload json.RawMessage) string {
+	var ref struct {
+		ConfigID string `json:"config_id"`
+	}
+	if err := json.Unmarshal(payload, &ref); err != nil {
+		return ""
+	}
+	return ref.ConfigID
+}
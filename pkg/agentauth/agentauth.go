@@ -0,0 +1,95 @@
+// Package agentauth 实现Agent注册令牌的格式和平台身份证明
+//
+// 注册令牌格式为 lpa_<令牌ID>.<密钥>，平台只保存密钥的SHA-256。Agent请求时在
+// X-Agent-Nonce 中携带随机数，平台校验令牌后以密钥哈希为HMAC密钥计算 X-Platform-Proof，
+// Agent据此确认对端持有自己的令牌记录，而不是冒充的平台。平台和Agent两端共用同一套实现。
+package agentauth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Prefix 注册令牌前缀
+const Prefix = "lpa_"
+
+// 平台身份证明使用的请求头和响应头
+const (
+	NonceHeader = "X-Agent-Nonce"
+	ProofHeader = "X-Platform-Proof"
+)
+
+// Generate 生成新的注册令牌，返回令牌明文和密钥哈希
+func Generate(id string) (string, string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", fmt.Errorf("生成令牌密钥失败: %w", err)
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(secret)
+	return Prefix + id + "." + encoded, HashSecret(encoded), nil
+}
+
+// Parse 拆分注册令牌，返回令牌ID和密钥
+func Parse(token string) (string, string, bool) {
+	rest, ok := strings.CutPrefix(token, Prefix)
+	if !ok {
+		return "", "", false
+	}
+	id, secret, ok := strings.Cut(rest, ".")
+	if !ok || id == "" || secret == "" {
+		return "", "", false
+	}
+	return id, secret, true
+}
+
+// IsEnrollmentToken 判断是否为注册令牌
+func IsEnrollmentToken(token string) bool {
+	_, _, ok := Parse(token)
+	return ok
+}
+
+// HashSecret 计算密钥的SHA-256（十六进制）
+func HashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// ProofKey 由注册令牌计算平台身份证明的HMAC密钥
+func ProofKey(token string) ([]byte, bool) {
+	_, secret, ok := Parse(token)
+	if !ok {
+		return nil, false
+	}
+	sum := sha256.Sum256([]byte(secret))
+	return sum[:], true
+}
+
+// NewNonce 生成请求随机数
+func NewNonce() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Proof 计算平台对随机数的身份证明
+func Proof(key []byte, nonce string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(nonce))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyProof 校验平台返回的身份证明
+func VerifyProof(key []byte, nonce, proof string) bool {
+	expected, err := hex.DecodeString(proof)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(nonce))
+	return hmac.Equal(expected, mac.Sum(nil))
+}
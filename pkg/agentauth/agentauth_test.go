@@ -0,0 +1,41 @@
+package agentauth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateAndParse(t *testing.T) {
+	token, hash, err := Generate("tok-1")
+	require.NoError(t, err)
+
+	id, secret, ok := Parse(token)
+	require.True(t, ok)
+	assert.Equal(t, "tok-1", id)
+	assert.Equal(t, hash, HashSecret(secret))
+	assert.True(t, IsEnrollmentToken(token))
+
+	for _, bad := range []string{"shared-token", "lpa_", "lpa_tok-1", "lpa_.secret", "lpa_tok-1."} {
+		assert.False(t, IsEnrollmentToken(bad), bad)
+	}
+}
+
+func TestProof(t *testing.T) {
+	token, _, err := Generate("tok-1")
+	require.NoError(t, err)
+	other, _, err := Generate("tok-2")
+	require.NoError(t, err)
+
+	key, ok := ProofKey(token)
+	require.True(t, ok)
+	otherKey, _ := ProofKey(other)
+
+	nonce := NewNonce()
+	proof := Proof(key, nonce)
+	assert.True(t, VerifyProof(key, nonce, proof))
+	assert.False(t, VerifyProof(key, NewNonce(), proof), "随机数不同")
+	assert.False(t, VerifyProof(otherKey, nonce, proof), "不持有令牌记录的平台")
+	assert.False(t, VerifyProof(key, nonce, "not-hex"))
+}
@@ -3,7 +3,9 @@ package elasticsearch
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
@@ -11,12 +13,36 @@ import (
 	"github.com/elastic/go-elasticsearch/v8/esapi"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel"
 )
 
+// ErrNotFound 文档不存在
+var ErrNotFound = errors.New("文档不存在")
+
+// ErrUnavailable ES不可达或暂时无法处理请求
+var ErrUnavailable = errors.New("Elasticsearch不可用")
+
+// unavailable 将请求发送失败标记为ErrUnavailable，调用方取消或超时除外
+func unavailable(err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrUnavailable, err)
+}
+
+// responseError ES返回的错误响应，503标记为ErrUnavailable
+func responseError(action string, res *esapi.Response) error {
+	if res.StatusCode == http.StatusServiceUnavailable {
+		return fmt.Errorf("%s: %w: %s", action, ErrUnavailable, res.String())
+	}
+	return fmt.Errorf("%s: %s", action, res.String())
+}
+
 // Client ES客户端封装
 // 实现 ClientInterface 接口
 type Client struct {
 	es     *elasticsearch.Client
+	readers map[string]*elasticsearch.Client // 各索引组的只读节点客户端，按组名索引
 	logger *logrus.Logger
 	config *Config
 }
@@ -36,6 +62,8 @@ type Config struct {
 		ConfigHistory string
 		Agents        string
 	}
+	ReadRouting ReadRoutingConfig
+	Lifecycle   LifecycleConfig
 }
 
 // NewClient 创建新的ES客户端
@@ -51,6 +79,12 @@ func NewClient(logger *logrus.Logger) (ClientInterface, error) {
 	config.Indices.Configs = viper.GetString("elasticsearch.indices.configs")
 	config.Indices.ConfigHistory = viper.GetString("elasticsearch.indices.config_history")
 	config.Indices.Agents = viper.GetString("elasticsearch.indices.agents")
+	if err := viper.UnmarshalKey("elasticsearch.read.groups", &config.ReadRouting.Groups); err != nil {
+		return nil, fmt.Errorf("解析ES读路由配置失败: %w", err)
+	}
+	if err := viper.UnmarshalKey("elasticsearch.lifecycle", &config.Lifecycle); err != nil {
+		return nil, fmt.Errorf("解析ES索引生命周期配置失败: %w", err)
+	}
 
 	// 创建ES客户端配置
 	esCfg := elasticsearch.Config{
@@ -62,6 +96,10 @@ func NewClient(logger *logrus.Logger) (ClientInterface, error) {
 		esCfg.Username = config.Username
 		esCfg.Password = config.Password
 	}
+	// 启用链路追踪时每个ES请求记录为所在请求链路下的span，不记录查询内容
+	if viper.GetBool("tracing.enabled") {
+		esCfg.Instrumentation = elasticsearch.NewOpenTelemetryInstrumentation(otel.GetTracerProvider(), false)
+	}
 
 	// 创建ES客户端
 	es, err := elasticsearch.NewClient(esCfg)
@@ -82,19 +120,43 @@ func NewClient(logger *logrus.Logger) (ClientInterface, error) {
 
 	logger.Info("成功连接到Elasticsearch")
 
-	return &Client{
+	client := &Client{
 		es:     es,
 		logger: logger,
 		config: config,
-	}, nil
+	}
+
+	// 为每个配置了只读地址的索引组创建客户端，读密集的索引组由其承担
+	client.readers = make(map[string]*elasticsearch.Client)
+	for _, group := range config.ReadRouting.Groups {
+		if len(group.Addresses) == 0 {
+			continue
+		}
+		readCfg := esCfg
+		readCfg.Addresses = group.Addresses
+		reader, err := elasticsearch.NewClient(readCfg)
+		if err != nil {
+			return nil, fmt.Errorf("创建ES只读客户端失败(%s): %w", group.Name, err)
+		}
+		client.readers[group.Name] = reader
+		logger.WithFields(logrus.Fields{
+			"group":     group.Name,
+			"addresses": group.Addresses,
+		}).Info("已启用ES读请求路由")
+	}
+
+	return client, nil
+}
+
+// indexDefinition 平台使用的索引及其最新的映射
+type indexDefinition struct {
+	name    string
+	mapping string
 }
 
-// InitializeIndices 初始化索引
-func (c *Client) InitializeIndices(ctx context.Context) error {
-	indices := []struct {
-		name    string
-		mapping string
-	}{
+// indexDefinitions 平台使用的全部索引，由第一个迁移创建
+func (c *Client) indexDefinitions() []indexDefinition {
+	return []indexDefinition{
 		{
 			name:    c.config.Indices.Configs,
 			mapping: configIndexMapping,
@@ -107,25 +169,115 @@ func (c *Client) InitializeIndices(ctx context.Context) error {
 			name:    c.config.Indices.Agents,
 			mapping: agentIndexMapping,
 		},
+		{
+			name:    "logstash_agent_groups",
+			mapping: agentGroupIndexMapping,
+		},
+		{
+			name:    "logstash_deployments",
+			mapping: deploymentIndexMapping,
+		},
+		{
+			name:    "logstash_incidents",
+			mapping: incidentsMapping,
+		},
+		{
+			name:    "logstash_users",
+			mapping: usersMapping,
+		},
+		{
+			name:    "logstash_agent_tokens",
+			mapping: agentTokensMapping,
+		},
+		{
+			name:    "logstash_agent_certificates",
+			mapping: agentCertificatesMapping,
+		},
+		{
+			name:    "logstash_destinations",
+			mapping: destinationsMapping,
+		},
+		{
+			name:    "logstash_agent_events",
+			mapping: agentEventsMapping,
+		},
+		{
+			name:    "logstash_config_revalidations",
+			mapping: configRevalidationsMapping,
+		},
+		{
+			name:    "logstash_config_approvals",
+			mapping: configApprovalsMapping,
+		},
+		{
+			name:    "logstash_field_contracts",
+			mapping: fieldContractsMapping,
+		},
+		{
+			name:    "logstash_test_datasets",
+			mapping: testDatasetsMapping,
+		},
+		{
+			name:    "logstash_pipelines",
+			mapping: pipelinesMapping,
+		},
+		{
+			name:    "logstash_pipeline_versions",
+			mapping: pipelineVersionsMapping,
+		},
+		{
+			name:    "logstash_alert_rules",
+			mapping: alertRulesMapping,
+		},
+		{
+			name:    "logstash_alert_silences",
+			mapping: alertSilencesMapping,
+		},
+		{
+			name:    "logstash_audit_log",
+			mapping: auditLogMapping,
+		},
+		{
+			name:    "logstash_secrets",
+			mapping: secretsMapping,
+		},
+		{
+			name:    "logstash_applied_configs",
+			mapping: appliedConfigsMapping,
+		},
+		{
+			name:    "logstash_leases",
+			mapping: leasesMapping,
+		},
+		{
+			name:    "logstash_webhooks",
+			mapping: webhooksMapping,
+		},
+		{
+			name:    "logstash_webhook_deliveries",
+			mapping: webhookDeliveriesMapping,
+		},
+		{
+			name:    "logstash_lint_settings",
+			mapping: lintSettingsMapping,
+		},
+		{
+			name:    "logstash_agent_plugins",
+			mapping: agentPluginsMapping,
+		},
+		{
+			name:    "logstash_plugin_installs",
+			mapping: pluginInstallsMapping,
+		},
+		{
+			name:    "logstash_agents_archive",
+			mapping: agentArchiveMapping,
+		},
+		{
+			name:    "logstash_scheduled_test_runs",
+			mapping: scheduledTestRunsMapping,
+		},
 	}
-
-	for _, index := range indices {
-		exists, err := c.IndexExists(ctx, index.name)
-		if err != nil {
-			return fmt.Errorf("检查索引 %s 是否存在失败: %w", index.name, err)
-		}
-
-		if !exists {
-			if err := c.CreateIndex(ctx, index.name, index.mapping); err != nil {
-				return fmt.Errorf("创建索引 %s 失败: %w", index.name, err)
-			}
-			c.logger.Infof("创建索引: %s", index.name)
-		} else {
-			c.logger.Infof("索引已存在: %s", index.name)
-		}
-	}
-
-	return nil
 }
 
 // IndexExists 检查索引是否存在
@@ -175,12 +327,12 @@ func (c *Client) Index(ctx context.Context, index, id string, doc interface{}) e
 
 	res, err := req.Do(ctx, c.es)
 	if err != nil {
-		return fmt.Errorf("索引文档失败: %w", err)
+		return fmt.Errorf("索引文档失败: %w", unavailable(err))
 	}
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return fmt.Errorf("索引文档响应错误: %s", res.String())
+		return responseError("索引文档响应错误", res)
 	}
 
 	return nil
@@ -188,22 +340,24 @@ func (c *Client) Index(ctx context.Context, index, id string, doc interface{}) e
 
 // Get 获取文档
 func (c *Client) Get(ctx context.Context, index, id string, result interface{}) error {
+	es, preference := c.readTarget(ctx, index)
 	req := esapi.GetRequest{
 		Index:      index,
 		DocumentID: id,
+		Preference: preference,
 	}
 
-	res, err := req.Do(ctx, c.es)
+	res, err := req.Do(ctx, es)
 	if err != nil {
-		return fmt.Errorf("获取文档失败: %w", err)
+		return fmt.Errorf("获取文档失败: %w", unavailable(err))
 	}
 	defer res.Body.Close()
 
 	if res.IsError() {
 		if res.StatusCode == 404 {
-			return fmt.Errorf("文档不存在")
+			return ErrNotFound
 		}
-		return fmt.Errorf("获取文档响应错误: %s", res.String())
+		return responseError("获取文档响应错误", res)
 	}
 
 	var response struct {
@@ -216,7 +370,7 @@ func (c *Client) Get(ctx context.Context, index, id string, result interface{})
 	}
 
 	if !response.Found {
-		return fmt.Errorf("文档不存在")
+		return ErrNotFound
 	}
 
 	if err := json.Unmarshal(response.Source, result); err != nil {
@@ -233,19 +387,21 @@ func (c *Client) Search(ctx context.Context, index string, query map[string]inte
 		return fmt.Errorf("序列化查询失败: %w", err)
 	}
 
+	es, preference := c.readTarget(ctx, index)
 	req := esapi.SearchRequest{
-		Index: []string{index},
-		Body:  strings.NewReader(string(data)),
+		Index:      []string{index},
+		Body:       strings.NewReader(string(data)),
+		Preference: preference,
 	}
 
-	res, err := req.Do(ctx, c.es)
+	res, err := req.Do(ctx, es)
 	if err != nil {
-		return fmt.Errorf("搜索失败: %w", err)
+		return fmt.Errorf("搜索失败: %w", unavailable(err))
 	}
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return fmt.Errorf("搜索响应错误: %s", res.String())
+		return responseError("搜索响应错误", res)
 	}
 
 	if err := json.NewDecoder(res.Body).Decode(results); err != nil {
@@ -265,12 +421,12 @@ func (c *Client) Delete(ctx context.Context, index, id string) error {
 
 	res, err := req.Do(ctx, c.es)
 	if err != nil {
-		return fmt.Errorf("删除文档失败: %w", err)
+		return fmt.Errorf("删除文档失败: %w", unavailable(err))
 	}
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return fmt.Errorf("删除文档响应错误: %s", res.String())
+		return responseError("删除文档响应错误", res)
 	}
 
 	return nil
@@ -278,18 +434,43 @@ func (c *Client) Delete(ctx context.Context, index, id string) error {
 
 // 索引映射定义
 const (
+	// content 按非字母数字切分并转小写，使插件名、字段名（例如 log.level 中的 level）都能单独检索
 	configIndexMapping = `{
+		"settings": {
+			"analysis": {
+				"tokenizer": {
+					"config_content": { "type": "pattern", "pattern": "[^\\p{L}\\p{N}_]+" }
+				},
+				"analyzer": {
+					"config_content": { "type": "custom", "tokenizer": "config_content", "filter": ["lowercase"] }
+				}
+			}
+		},
 		"mappings": {
 			"properties": {
 				"id": { "type": "keyword" },
-				"name": { "type": "text" },
+				"name": { "type": "text", "fields": { "keyword": { "type": "keyword" } } },
 				"description": { "type": "text" },
 				"type": { "type": "keyword" },
-				"content": { "type": "text" },
-				"tags": { "type": "keyword" },
+				"content": { "type": "text", "analyzer": "config_content" },
+				"tags": { "type": "keyword", "fields": { "text": { "type": "text" } } },
+				"destinations": { "type": "keyword" },
+				"team": { "type": "keyword" },
+				"project": { "type": "keyword" },
+				"acl": {
+					"properties": {
+						"readers": { "type": "keyword" },
+						"editors": { "type": "keyword" },
+						"deployers": { "type": "keyword" }
+					}
+				},
+				"reviewers": { "type": "keyword" },
+				"pipeline_settings": { "type": "object", "enabled": false },
 				"version": { "type": "integer" },
 				"enabled": { "type": "boolean" },
 				"test_status": { "type": "keyword" },
+				"tested_at": { "type": "date" },
+				"scheduled_test": { "type": "boolean" },
 				"created_at": { "type": "date" },
 				"updated_at": { "type": "date" },
 				"created_by": { "type": "keyword" },
@@ -307,7 +488,11 @@ const (
 				"change_type": { "type": "keyword" },
 				"change_log": { "type": "text" },
 				"modified_by": { "type": "keyword" },
-				"modified_at": { "type": "date" }
+				"modified_at": { "type": "date" },
+				"name": { "type": "keyword" },
+				"description": { "type": "text" },
+				"tags": { "type": "keyword" },
+				"enabled": { "type": "boolean" }
 			}
 		}
 	}`
@@ -328,6 +513,549 @@ const (
 						"version": { "type": "integer" },
 						"applied_at": { "type": "date" }
 					}
+				},
+				"group": { "type": "keyword" },
+				"project": { "type": "keyword" },
+				"environment": { "type": "keyword" },
+				"labels": { "type": "flattened" },
+				"settings": { "type": "object", "dynamic": false, "properties": { "labels": { "type": "flattened" } } },
+				"metadata": { "type": "flattened" },
+				"metadata_synced_at": { "type": "date" },
+				"metrics": {
+					"properties": {
+						"timestamp": { "type": "date" },
+						"logstash": {
+							"properties": {
+								"jvm_heap_used_percent": { "type": "float" },
+								"events_in_rate": { "type": "float" },
+								"events_out_rate": { "type": "float" },
+								"pipelines": { "type": "object", "enabled": false }
+							}
+						}
+					}
+				}
+			}
+		}
+	}`
+
+	agentGroupIndexMapping = `{
+		"mappings": {
+			"properties": {
+				"name": { "type": "keyword" },
+				"description": { "type": "text" },
+				"settings": { "type": "object", "enabled": false },
+				"created_at": { "type": "date" },
+				"updated_at": { "type": "date" },
+				"created_by": { "type": "keyword" },
+				"updated_by": { "type": "keyword" }
+			}
+		}
+	}`
+
+	deploymentIndexMapping = `{
+		"mappings": {
+			"properties": {
+				"id": { "type": "keyword" },
+				"config_id": { "type": "keyword" },
+				"config_name": { "type": "keyword" },
+				"config_version": { "type": "integer" },
+				"team": { "type": "keyword" },
+				"project": { "type": "keyword" },
+				"previous_version": { "type": "integer" },
+				"agent_ids": { "type": "keyword" },
+				"strategy": { "type": "keyword" },
+				"canary": { "type": "object", "enabled": false },
+				"rollout": { "type": "object", "enabled": false },
+				"status": { "type": "keyword" },
+				"results": { "type": "object", "enabled": false },
+				"approvals": { "type": "object", "enabled": false },
+				"rollback_of": { "type": "keyword" },
+				"rolled_back_by": { "type": "keyword" },
+				"created_by": { "type": "keyword" },
+				"created_at": { "type": "date" },
+				"started_at": { "type": "date" },
+				"completed_at": { "type": "date" }
+			}
+		}
+	}`
+
+	incidentsMapping = `{
+		"mappings": {
+			"properties": {
+				"id": {"type": "keyword"},
+				"fingerprint": {"type": "keyword"},
+				"error_type": {"type": "keyword"},
+				"config_id": {"type": "keyword"},
+				"message": {"type": "text"},
+				"status": {"type": "keyword"},
+				"affected_agents": {"type": "keyword"},
+				"affected_count": {"type": "integer"},
+				"occurrences": {"type": "long"},
+				"timeline": {"type": "object", "enabled": false},
+				"first_seen": {"type": "date"},
+				"last_seen": {"type": "date"},
+				"resolved_at": {"type": "date"},
+				"resolved_by": {"type": "keyword"},
+				"resolution": {"type": "text"}
+			}
+		}
+	}`
+
+	usersMapping = `{
+		"mappings": {
+			"properties": {
+				"username": { "type": "keyword" },
+				"password_hash": { "type": "keyword", "index": false },
+				"role": { "type": "keyword" },
+				"teams": { "type": "keyword" },
+				"project_roles": { "type": "flattened" },
+				"disabled": { "type": "boolean" },
+				"last_login_at": { "type": "date" },
+				"created_at": { "type": "date" },
+				"updated_at": { "type": "date" },
+				"created_by": { "type": "keyword" }
+			}
+		}
+	}`
+
+	agentTokensMapping = `{
+		"mappings": {
+			"properties": {
+				"id": { "type": "keyword" },
+				"agent_id": { "type": "keyword" },
+				"secret_hash": { "type": "keyword", "index": false },
+				"status": { "type": "keyword" },
+				"created_at": { "type": "date" },
+				"created_by": { "type": "keyword" },
+				"expires_at": { "type": "date" },
+				"revoked_at": { "type": "date" },
+				"revoked_by": { "type": "keyword" },
+				"rotated_to": { "type": "keyword" }
+			}
+		}
+	}`
+
+	agentCertificatesMapping = `{
+		"mappings": {
+			"properties": {
+				"serial": { "type": "keyword" },
+				"agent_id": { "type": "keyword" },
+				"status": { "type": "keyword" },
+				"fingerprint": { "type": "keyword" },
+				"not_before": { "type": "date" },
+				"not_after": { "type": "date" },
+				"created_at": { "type": "date" },
+				"created_by": { "type": "keyword" },
+				"retires_at": { "type": "date" },
+				"revoked_at": { "type": "date" },
+				"revoked_by": { "type": "keyword" },
+				"rotated_to": { "type": "keyword" }
+			}
+		}
+	}`
+
+	destinationsMapping = `{
+		"mappings": {
+			"properties": {
+				"name": { "type": "keyword" },
+				"type": { "type": "keyword" },
+				"description": { "type": "text" },
+				"tags": { "type": "keyword" },
+				"environments": { "type": "object", "enabled": false },
+				"checks": { "type": "object", "enabled": false },
+				"created_at": { "type": "date" },
+				"updated_at": { "type": "date" },
+				"created_by": { "type": "keyword" },
+				"updated_by": { "type": "keyword" }
+			}
+		}
+	}`
+
+	agentEventsMapping = `{
+		"mappings": {
+			"properties": {
+				"id": { "type": "keyword" },
+				"agent_id": { "type": "keyword" },
+				"type": { "type": "keyword" },
+				"from": { "type": "keyword" },
+				"to": { "type": "keyword" },
+				"reason": { "type": "text" },
+				"config_id": { "type": "keyword" },
+				"config_version": { "type": "integer" },
+				"deployment_id": { "type": "keyword" },
+				"last_heartbeat": { "type": "date" },
+				"created_at": { "type": "date" }
+			}
+		}
+	}`
+
+	configRevalidationsMapping = `{
+		"mappings": {
+			"properties": {
+				"config_id": { "type": "keyword" },
+				"config_name": { "type": "keyword" },
+				"config_version": { "type": "integer" },
+				"valid": { "type": "boolean" },
+				"syntax_checked": { "type": "boolean" },
+				"errors": { "type": "object", "enabled": false },
+				"failing_since": { "type": "date" },
+				"incident_id": { "type": "keyword" },
+				"checked_at": { "type": "date" }
+			}
+		}
+	}`
+
+	scheduledTestRunsMapping = `{
+		"mappings": {
+			"properties": {
+				"id": { "type": "keyword" },
+				"config_id": { "type": "keyword" },
+				"config_name": { "type": "keyword" },
+				"config_version": { "type": "integer" },
+				"status": { "type": "keyword" },
+				"previous_status": { "type": "keyword" },
+				"regressed": { "type": "boolean" },
+				"datasets": { "type": "object", "enabled": false },
+				"failing_since": { "type": "date" },
+				"incident_id": { "type": "keyword" },
+				"started_at": { "type": "date" },
+				"duration_ms": { "type": "long" }
+			}
+		}
+	}`
+
+	configApprovalsMapping = `{
+		"mappings": {
+			"properties": {
+				"id": { "type": "keyword" },
+				"config_id": { "type": "keyword" },
+				"config_version": { "type": "integer" },
+				"reviewer": { "type": "keyword" },
+				"decision": { "type": "keyword" },
+				"comment": { "type": "text" },
+				"created_at": { "type": "date" }
+			}
+		}
+	}`
+
+	fieldContractsMapping = `{
+		"mappings": {
+			"properties": {
+				"name": { "type": "keyword" },
+				"consumer": { "type": "keyword" },
+				"description": { "type": "text" },
+				"target": { "type": "keyword" },
+				"fields": {
+					"properties": {
+						"path": { "type": "keyword" },
+						"type": { "type": "keyword" }
+					}
+				},
+				"created_at": { "type": "date" },
+				"updated_at": { "type": "date" },
+				"created_by": { "type": "keyword" },
+				"updated_by": { "type": "keyword" }
+			}
+		}
+	}`
+
+	testDatasetsMapping = `{
+		"mappings": {
+			"properties": {
+				"id": { "type": "keyword" },
+				"config_id": { "type": "keyword" },
+				"name": { "type": "keyword" },
+				"description": { "type": "text" },
+				"samples": { "type": "object", "enabled": false },
+				"last_run": { "type": "object", "enabled": false },
+				"created_at": { "type": "date" },
+				"updated_at": { "type": "date" },
+				"created_by": { "type": "keyword" },
+				"updated_by": { "type": "keyword" }
+			}
+		}
+	}`
+
+	pipelinesMapping = `{
+		"mappings": {
+			"properties": {
+				"id": { "type": "keyword" },
+				"name": { "type": "keyword" },
+				"description": { "type": "text" },
+				"config_ids": { "type": "keyword" },
+				"components": {
+					"properties": {
+						"config_id": { "type": "keyword" },
+						"name": { "type": "keyword" },
+						"type": { "type": "keyword" },
+						"version": { "type": "integer" }
+					}
+				},
+				"content": { "type": "text", "index": false },
+				"version": { "type": "integer" },
+				"created_at": { "type": "date" },
+				"updated_at": { "type": "date" },
+				"created_by": { "type": "keyword" },
+				"updated_by": { "type": "keyword" }
+			}
+		}
+	}`
+
+	pipelineVersionsMapping = `{
+		"mappings": {
+			"properties": {
+				"pipeline_id": { "type": "keyword" },
+				"version": { "type": "integer" },
+				"config_ids": { "type": "keyword" },
+				"components": {
+					"properties": {
+						"config_id": { "type": "keyword" },
+						"name": { "type": "keyword" },
+						"type": { "type": "keyword" },
+						"version": { "type": "integer" }
+					}
+				},
+				"content": { "type": "text", "index": false },
+				"modified_by": { "type": "keyword" },
+				"modified_at": { "type": "date" }
+			}
+		}
+	}`
+
+	alertRulesMapping = `{
+		"mappings": {
+			"properties": {
+				"id": { "type": "keyword" },
+				"name": { "type": "keyword" },
+				"description": { "type": "text" },
+				"type": { "type": "keyword" },
+				"selector": { "type": "keyword" },
+				"metric": { "type": "keyword" },
+				"operator": { "type": "keyword" },
+				"threshold": { "type": "double" },
+				"for_seconds": { "type": "integer" },
+				"severity": { "type": "keyword" },
+				"channels": { "type": "keyword" },
+				"enabled": { "type": "boolean" },
+				"created_by": { "type": "keyword" },
+				"updated_by": { "type": "keyword" },
+				"created_at": { "type": "date" },
+				"updated_at": { "type": "date" }
+			}
+		}
+	}`
+
+	alertSilencesMapping = `{
+		"mappings": {
+			"properties": {
+				"id": { "type": "keyword" },
+				"rule_id": { "type": "keyword" },
+				"agent_id": { "type": "keyword" },
+				"starts_at": { "type": "date" },
+				"ends_at": { "type": "date" },
+				"comment": { "type": "text" },
+				"created_by": { "type": "keyword" },
+				"created_at": { "type": "date" }
+			}
+		}
+	}`
+
+	auditLogMapping = `{
+		"mappings": {
+			"properties": {
+				"id": { "type": "keyword" },
+				"timestamp": { "type": "date" },
+				"actor": { "type": "keyword" },
+				"role": { "type": "keyword" },
+				"action": { "type": "keyword" },
+				"method": { "type": "keyword" },
+				"route": { "type": "keyword" },
+				"path": { "type": "keyword" },
+				"resource_type": { "type": "keyword" },
+				"resource_id": { "type": "keyword" },
+				"body_digest": { "type": "keyword" },
+				"detail": { "type": "keyword" },
+				"status": { "type": "integer" },
+				"outcome": { "type": "keyword" },
+				"error": { "type": "text" },
+				"client_ip": { "type": "keyword" },
+				"latency_ms": { "type": "long" }
+			}
+		}
+	}`
+
+	secretsMapping = `{
+		"mappings": {
+			"properties": {
+				"name": { "type": "keyword" },
+				"description": { "type": "text" },
+				"version": { "type": "integer" },
+				"ciphertext": { "type": "keyword", "index": false, "doc_values": false },
+				"created_at": { "type": "date" },
+				"updated_at": { "type": "date" },
+				"created_by": { "type": "keyword" },
+				"updated_by": { "type": "keyword" }
+			}
+		}
+	}`
+
+	appliedConfigsMapping = `{
+		"mappings": {
+			"properties": {
+				"agent_id": { "type": "keyword" },
+				"config_id": { "type": "keyword" },
+				"version": { "type": "integer" },
+				"hash": { "type": "keyword", "index": false },
+				"deployment_id": { "type": "keyword" },
+				"reload_pending": { "type": "boolean" },
+				"applied_at": { "type": "date" },
+				"updated_at": { "type": "date" }
+			}
+		}
+	}`
+
+	leasesMapping = `{
+		"mappings": {
+			"properties": {
+				"name": { "type": "keyword" },
+				"holder": { "type": "keyword" },
+				"acquired_at": { "type": "date" },
+				"renewed_at": { "type": "date" },
+				"expires_at": { "type": "date" }
+			}
+		}
+	}`
+
+	webhooksMapping = `{
+		"mappings": {
+			"properties": {
+				"id": { "type": "keyword" },
+				"name": { "type": "keyword" },
+				"url": { "type": "keyword", "index": false },
+				"events": { "type": "keyword" },
+				"enabled": { "type": "boolean" },
+				"description": { "type": "text" },
+				"secret": { "type": "keyword", "index": false },
+				"created_by": { "type": "keyword" },
+				"updated_by": { "type": "keyword" },
+				"created_at": { "type": "date" },
+				"updated_at": { "type": "date" }
+			}
+		}
+	}`
+
+	webhookDeliveriesMapping = `{
+		"mappings": {
+			"properties": {
+				"id": { "type": "keyword" },
+				"webhook_id": { "type": "keyword" },
+				"event_id": { "type": "keyword" },
+				"event": { "type": "keyword" },
+				"status": { "type": "keyword" },
+				"attempts": { "type": "integer" },
+				"response_code": { "type": "integer" },
+				"error": { "type": "text" },
+				"payload": { "type": "object", "enabled": false },
+				"created_at": { "type": "date" },
+				"updated_at": { "type": "date" },
+				"next_retry_at": { "type": "date" },
+				"delivered_at": { "type": "date" }
+			}
+		}
+	}`
+
+	lintSettingsMapping = `{
+		"mappings": {
+			"properties": {
+				"project": { "type": "keyword" },
+				"rules": { "type": "object", "enabled": false },
+				"updated_at": { "type": "date" },
+				"updated_by": { "type": "keyword" }
+			}
+		}
+	}`
+
+	agentPluginsMapping = `{
+		"mappings": {
+			"properties": {
+				"agent_id": { "type": "keyword" },
+				"plugins": {
+					"properties": {
+						"name": { "type": "keyword" },
+						"version": { "type": "keyword" },
+						"integration": { "type": "keyword" }
+					}
+				},
+				"collected_at": { "type": "date" },
+				"reported_at": { "type": "date" }
+			}
+		}
+	}`
+
+	// 归档记录保留注销时Agent的全部字段，只为列表查询需要的字段建立映射
+	agentArchiveMapping = `{
+		"mappings": {
+			"dynamic": false,
+			"properties": {
+				"archive_id": { "type": "keyword" },
+				"agent_id": { "type": "keyword" },
+				"hostname": { "type": "keyword" },
+				"ip": { "type": "keyword" },
+				"logstash_version": { "type": "keyword" },
+				"group": { "type": "keyword" },
+				"project": { "type": "keyword" },
+				"last_heartbeat": { "type": "date" },
+				"initiator": { "type": "keyword" },
+				"decommissioned_by": { "type": "keyword" },
+				"decommissioned_at": { "type": "date" },
+				"reason": { "type": "text" }
+			}
+		}
+	}`
+
+	pluginInstallsMapping = `{
+		"mappings": {
+			"properties": {
+				"id": { "type": "keyword" },
+				"agent_id": { "type": "keyword" },
+				"name": { "type": "keyword" },
+				"version": { "type": "keyword" },
+				"bundle_url": { "type": "keyword", "index": false },
+				"restart": { "type": "boolean" },
+				"status": { "type": "keyword" },
+				"phase": { "type": "keyword" },
+				"error": { "type": "text" },
+				"output": { "type": "text", "index": false },
+				"truncated": { "type": "boolean" },
+				"installed_version": { "type": "keyword" },
+				"requested_by": { "type": "keyword" },
+				"created_at": { "type": "date" },
+				"updated_at": { "type": "date" },
+				"finished_at": { "type": "date" }
+			}
+		}
+	}`
+
+	// MetricsIndexMapping Agent指标时序索引映射，按天滚动的索引由指标仓库在首次写入当天数据时创建
+	MetricsIndexMapping = `{
+		"mappings": {
+			"dynamic": false,
+			"properties": {
+				"agent_id": { "type": "keyword" },
+				"timestamp": { "type": "date" },
+				"cpu_usage": { "type": "float" },
+				"memory_usage": { "type": "float" },
+				"disk_usage": { "type": "float" },
+				"events_received": { "type": "long" },
+				"events_sent": { "type": "long" },
+				"events_failed": { "type": "long" },
+				"uptime": { "type": "long" },
+				"logstash": {
+					"properties": {
+						"jvm_heap_used_percent": { "type": "float" },
+						"events_in_rate": { "type": "float" },
+						"events_out_rate": { "type": "float" }
+					}
 				}
 			}
 		}
@@ -5,8 +5,11 @@ import "context"
 // ClientInterface 定义 Elasticsearch 客户端接口
 // 这个接口抽象了所有 Elasticsearch 操作，便于测试时使用 mock
 type ClientInterface interface {
-	// InitializeIndices 初始化所需的索引
-	InitializeIndices(ctx context.Context) error
+	// Migrate 执行尚未执行的索引迁移，创建或升级所需的索引
+	Migrate(ctx context.Context) (*MigrationStatus, error)
+
+	// MigrationStatus 获取索引迁移的执行状态
+	MigrationStatus(ctx context.Context) (*MigrationStatus, error)
 
 	// IndexExists 检查索引是否存在
 	IndexExists(ctx context.Context, index string) (bool, error)
@@ -25,4 +28,10 @@ type ClientInterface interface {
 
 	// Delete 删除文档
 	Delete(ctx context.Context, index, id string) error
+
+	// GetVersioned 获取文档及其版本
+	GetVersioned(ctx context.Context, index, id string, result interface{}) (*DocVersion, error)
+
+	// IndexIfVersion 按版本条件索引文档
+	IndexIfVersion(ctx context.Context, index, id string, doc interface{}, version *DocVersion) error
 }
\ No newline at end of file
@@ -0,0 +1,202 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+// LifecycleConfig 时序类索引的生命周期配置
+// 启用后配置历史、指标、审计日志和Agent事件改为按日期滚动的索引，写入和查询使用同名别名，
+// 滚动出的索引超过保留时长后由ILM自动删除
+type LifecycleConfig struct {
+	Enabled         bool              `mapstructure:"enabled"`
+	RolloverMaxAge  string            `mapstructure:"rollover_max_age"`  // 写入索引滚动的最长时间，例如 1d
+	RolloverMaxSize string            `mapstructure:"rollover_max_size"` // 写入索引单个主分片的大小上限，例如 50gb
+	Retention       map[string]string `mapstructure:"retention"`         // 索引类别 -> 保留时长，为空时不删除
+}
+
+// 生命周期管理的索引类别，对应 retention 中的键
+const (
+	LifecycleConfigHistory = "config_history"
+	LifecycleMetrics       = "metrics"
+	LifecycleAudit         = "audit"
+	LifecycleAgentEvents   = "agent_events"
+)
+
+// MetricsAlias 启用生命周期管理后指标写入的别名
+const MetricsAlias = "logstash_metrics"
+
+// managedIndex 生命周期管理的索引
+type managedIndex struct {
+	kind    string // 索引类别
+	alias   string // 写入和查询使用的别名
+	mapping string
+}
+
+// managedIndices 生命周期管理的索引列表
+func (c *Client) managedIndices() []managedIndex {
+	return []managedIndex{
+		{kind: LifecycleConfigHistory, alias: c.config.Indices.ConfigHistory, mapping: configHistoryIndexMapping},
+		{kind: LifecycleMetrics, alias: MetricsAlias, mapping: MetricsIndexMapping},
+		{kind: LifecycleAudit, alias: "logstash_audit_log", mapping: auditLogMapping},
+		{kind: LifecycleAgentEvents, alias: "logstash_agent_events", mapping: agentEventsMapping},
+	}
+}
+
+// isManaged 索引是否由生命周期管理创建
+func (c *Client) isManaged(name string) bool {
+	if !c.config.Lifecycle.Enabled {
+		return false
+	}
+	for _, index := range c.managedIndices() {
+		if index.alias == name {
+			return true
+		}
+	}
+	return false
+}
+
+// initializeLifecycle 创建或更新ILM策略和索引模板，别名不存在时创建第一个滚动索引
+// 策略和模板每次启动都会覆盖，修改保留时长后重启即生效；已存在的滚动索引按新策略继续执行
+func (c *Client) initializeLifecycle(ctx context.Context) error {
+	for _, index := range c.managedIndices() {
+		policy := index.alias + "-policy"
+		body, err := json.Marshal(lifecyclePolicy(c.config.Lifecycle, index.kind))
+		if err != nil {
+			return fmt.Errorf("序列化ILM策略 %s 失败: %w", policy, err)
+		}
+		if err := c.do(ctx, esapi.ILMPutLifecycleRequest{Policy: policy, Body: strings.NewReader(string(body))}); err != nil {
+			return fmt.Errorf("创建ILM策略 %s 失败: %w", policy, err)
+		}
+
+		template, err := rolloverTemplate(index, policy)
+		if err != nil {
+			return err
+		}
+		if err := c.do(ctx, esapi.IndicesPutIndexTemplateRequest{Name: index.alias, Body: strings.NewReader(template)}); err != nil {
+			return fmt.Errorf("创建索引模板 %s 失败: %w", index.alias, err)
+		}
+
+		if err := c.bootstrapAlias(ctx, index.alias); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bootstrapAlias 别名不存在时创建第一个按日期命名的滚动索引并设为写入索引
+// 同名的静态索引（启用前创建）无法改为别名，保持原样并提示迁移
+func (c *Client) bootstrapAlias(ctx context.Context, alias string) error {
+	res, err := c.es.Indices.ExistsAlias([]string{alias}, c.es.Indices.ExistsAlias.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("检查别名 %s 是否存在失败: %w", alias, err)
+	}
+	res.Body.Close()
+	if res.StatusCode == 200 {
+		c.logger.Infof("滚动别名已存在: %s", alias)
+		return nil
+	}
+
+	exists, err := c.IndexExists(ctx, alias)
+	if err != nil {
+		return fmt.Errorf("检查索引 %s 是否存在失败: %w", alias, err)
+	}
+	if exists {
+		c.logger.Warnf("索引 %s 为启用生命周期管理前创建的静态索引，未启用滚动和自动删除；迁移数据并删除该索引后重启即可启用", alias)
+		return nil
+	}
+
+	body := fmt.Sprintf(`{"aliases": {%q: {"is_write_index": true}}}`, alias)
+	req := esapi.IndicesCreateRequest{
+		Index: url.PathEscape(rolloverIndexName(alias)),
+		Body:  strings.NewReader(body),
+	}
+	if err := c.do(ctx, req); err != nil {
+		return fmt.Errorf("创建滚动索引 %s 失败: %w", alias, err)
+	}
+	c.logger.Infof("创建滚动索引: %s", alias)
+	return nil
+}
+
+// rolloverIndexName 第一个滚动索引的日期数学名称，例如 logstash_audit_log-2024.01.02-000001
+// 滚动时ES按当天日期生成后续索引名
+func rolloverIndexName(alias string) string {
+	return "<" + alias + "-{now/d}-000001>"
+}
+
+// lifecyclePolicy 构造ILM策略：hot阶段按时间和大小滚动，delete阶段在滚动后超过保留时长时删除
+func lifecyclePolicy(cfg LifecycleConfig, kind string) map[string]interface{} {
+	rollover := map[string]interface{}{}
+	if cfg.RolloverMaxAge != "" {
+		rollover["max_age"] = cfg.RolloverMaxAge
+	}
+	if cfg.RolloverMaxSize != "" {
+		rollover["max_primary_shard_size"] = cfg.RolloverMaxSize
+	}
+	if len(rollover) == 0 {
+		rollover["max_age"] = "1d"
+	}
+
+	phases := map[string]interface{}{
+		"hot": map[string]interface{}{
+			"actions": map[string]interface{}{"rollover": rollover},
+		},
+	}
+	if retention := cfg.Retention[kind]; retention != "" {
+		phases["delete"] = map[string]interface{}{
+			"min_age": retention,
+			"actions": map[string]interface{}{"delete": map[string]interface{}{}},
+		}
+	}
+
+	return map[string]interface{}{
+		"policy": map[string]interface{}{"phases": phases},
+	}
+}
+
+// rolloverTemplate 构造滚动索引的索引模板，映射沿用静态索引的定义
+func rolloverTemplate(index managedIndex, policy string) (string, error) {
+	var mapping map[string]interface{}
+	if err := json.Unmarshal([]byte(index.mapping), &mapping); err != nil {
+		return "", fmt.Errorf("解析索引 %s 的映射失败: %w", index.alias, err)
+	}
+
+	settings, _ := mapping["settings"].(map[string]interface{})
+	if settings == nil {
+		settings = map[string]interface{}{}
+	}
+	settings["index.lifecycle.name"] = policy
+	settings["index.lifecycle.rollover_alias"] = index.alias
+
+	template := map[string]interface{}{
+		"index_patterns": []string{index.alias + "-*"},
+		"template": map[string]interface{}{
+			"settings": settings,
+			"mappings": mapping["mappings"],
+		},
+	}
+	data, err := json.Marshal(template)
+	if err != nil {
+		return "", fmt.Errorf("序列化索引模板 %s 失败: %w", index.alias, err)
+	}
+	return string(data), nil
+}
+
+// do 执行请求并检查响应状态
+func (c *Client) do(ctx context.Context, req esapi.Request) error {
+	res, err := req.Do(ctx, c.es)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("响应错误: %s", res.String())
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLifecyclePolicy(t *testing.T) {
+	cfg := LifecycleConfig{
+		RolloverMaxAge:  "1d",
+		RolloverMaxSize: "50gb",
+		Retention:       map[string]string{LifecycleAudit: "180d"},
+	}
+
+	policy := lifecyclePolicy(cfg, LifecycleAudit)["policy"].(map[string]interface{})
+	phases := policy["phases"].(map[string]interface{})
+	rollover := phases["hot"].(map[string]interface{})["actions"].(map[string]interface{})["rollover"]
+	assert.Equal(t, map[string]interface{}{"max_age": "1d", "max_primary_shard_size": "50gb"}, rollover)
+	assert.Equal(t, "180d", phases["delete"].(map[string]interface{})["min_age"])
+
+	// 未配置保留时长时只滚动不删除
+	phases = lifecyclePolicy(cfg, LifecycleConfigHistory)["policy"].(map[string]interface{})["phases"].(map[string]interface{})
+	assert.NotContains(t, phases, "delete")
+}
+
+func TestRolloverTemplate(t *testing.T) {
+	data, err := rolloverTemplate(managedIndex{kind: LifecycleAudit, alias: "logstash_audit_log", mapping: auditLogMapping}, "logstash_audit_log-policy")
+	require.NoError(t, err)
+
+	var template map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(data), &template))
+	assert.Equal(t, []interface{}{"logstash_audit_log-*"}, template["index_patterns"])
+	settings := template["template"].(map[string]interface{})["settings"].(map[string]interface{})
+	assert.Equal(t, "logstash_audit_log-policy", settings["index.lifecycle.name"])
+	assert.Equal(t, "logstash_audit_log", settings["index.lifecycle.rollover_alias"])
+	assert.Contains(t, template["template"].(map[string]interface{})["mappings"], "properties")
+}
+
+func TestClient_InitializeLifecycle(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.EscapedPath())
+		mu.Unlock()
+
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		switch {
+		case r.Method == http.MethodHead && r.URL.Path == "/_alias/logstash_metrics":
+			// 指标别名已创建过
+			w.WriteHeader(http.StatusOK)
+		case r.Method == http.MethodHead && r.URL.Path == "/logstash_audit_log":
+			// 启用前创建的静态审计索引
+			w.WriteHeader(http.StatusOK)
+		case r.Method == http.MethodHead:
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.Write([]byte(`{"acknowledged": true}`))
+		}
+	}))
+	defer server.Close()
+
+	es, err := elasticsearch.NewClient(elasticsearch.Config{Addresses: []string{server.URL}})
+	require.NoError(t, err)
+	config := &Config{Lifecycle: LifecycleConfig{Enabled: true, RolloverMaxAge: "1d"}}
+	config.Indices.ConfigHistory = "logstash_config_history"
+	client := &Client{es: es, logger: logrus.New(), config: config}
+
+	require.NoError(t, client.initializeLifecycle(context.Background()))
+
+	assert.Contains(t, requests, "PUT /_ilm/policy/logstash_agent_events-policy")
+	assert.Contains(t, requests, "PUT /_index_template/logstash_agent_events")
+	assert.Contains(t, requests, "PUT /%3Clogstash_agent_events-%7Bnow%2Fd%7D-000001%3E")
+	assert.Contains(t, requests, "PUT /%3Clogstash_config_history-%7Bnow%2Fd%7D-000001%3E")
+	// 别名已存在或同名静态索引存在时不创建滚动索引
+	assert.NotContains(t, requests, "PUT /%3Clogstash_metrics-%7Bnow%2Fd%7D-000001%3E")
+	assert.NotContains(t, requests, "PUT /%3Clogstash_audit_log-%7Bnow%2Fd%7D-000001%3E")
+
+	assert.True(t, client.isManaged("logstash_audit_log"))
+	assert.False(t, client.isManaged("logstash_configs"))
+}
@@ -0,0 +1,470 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+// MigrationsIndex 记录索引迁移执行情况的索引
+const MigrationsIndex = "logstash_migrations"
+
+const (
+	// migrationLockID 迁移锁文档，同一时间只有一个平台实例执行迁移
+	migrationLockID = "lock"
+	// migrationLockTTL 迁移锁的有效期，持有锁的实例异常退出后其他实例在过期后接管
+	migrationLockTTL = 30 * time.Minute
+)
+
+// ErrMigrationLocked 其他平台实例正在执行迁移
+var ErrMigrationLocked = errors.New("其他平台实例正在执行索引迁移")
+
+// 迁移的执行状态
+const (
+	MigrationApplied = "applied"
+	MigrationFailed  = "failed"
+	MigrationPending = "pending"
+)
+
+// Migration 编号的索引迁移，按版本号顺序执行，成功执行后不再执行
+// 失败的迁移在下次执行时从第一步重新开始，因此每一步都需要可以重复执行
+type Migration struct {
+	Version     int
+	Description string
+	Steps       []MigrationStep
+}
+
+// MigrationStep 迁移中的一步，例如修改映射、重建索引或切换别名
+type MigrationStep func(ctx context.Context, c *Client) error
+
+// MigrationRecord 迁移的执行记录
+type MigrationRecord struct {
+	Version     int        `json:"version"`
+	Description string     `json:"description"`
+	Status      string     `json:"status"`
+	AppliedBy   string     `json:"applied_by,omitempty"` // 执行迁移的平台实例
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+// MigrationStatus 索引迁移的状态
+type MigrationStatus struct {
+	Current    int               `json:"current"` // 从第一个迁移起连续执行成功的最高版本
+	Latest     int               `json:"latest"`  // 当前平台版本定义的最高版本
+	Migrations []MigrationRecord `json:"migrations"`
+}
+
+// Pending 是否有尚未执行成功的迁移
+func (s *MigrationStatus) Pending() bool {
+	return s.Current < s.Latest
+}
+
+// migrationLock 迁移锁文档
+type migrationLock struct {
+	Owner     string    `json:"owner"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+const migrationsMapping = `{
+	"mappings": {
+		"dynamic": false,
+		"properties": {
+			"version": { "type": "integer" },
+			"description": { "type": "text" },
+			"status": { "type": "keyword" },
+			"applied_by": { "type": "keyword" },
+			"started_at": { "type": "date" },
+			"finished_at": { "type": "date" },
+			"owner": { "type": "keyword" },
+			"expires_at": { "type": "date" }
+		}
+	}
+}`
+
+// migrations 平台的全部索引迁移，新的映射变更追加在末尾，已发布的迁移不再修改
+// 索引映射常量始终描述最新的映射：全新集群由第一个迁移按最新映射创建索引，之后的迁移对其重复执行不产生影响
+func (c *Client) migrations() []Migration {
+	return []Migration{
+		{
+			Version:     1,
+			Description: "创建平台索引",
+			Steps:       []MigrationStep{createMissingIndices(c.indexDefinitions())},
+		},
+		{
+			Version:     2,
+			Description: "Agent增加所在环境字段",
+			Steps: []MigrationStep{
+				putMapping(c.config.Indices.Agents, `{"properties": {"environment": {"type": "keyword"}}}`),
+			},
+		},
+		{
+			Version:     3,
+			Description: "部署增加分批下发进度字段",
+			Steps: []MigrationStep{
+				putMapping("logstash_deployments", `{"properties": {"rollout": {"type": "object", "enabled": false}}}`),
+			},
+		},
+		{
+			Version:     4,
+			Description: "配置增加定期测试标记并创建定期测试记录索引",
+			Steps: []MigrationStep{
+				putMapping(c.config.Indices.Configs, `{"properties": {"scheduled_test": {"type": "boolean"}}}`),
+				createMissingIndices([]indexDefinition{{name: "logstash_scheduled_test_runs", mapping: scheduledTestRunsMapping}}),
+			},
+		},
+	}
+}
+
+// createMissingIndices 创建不存在的索引，已存在的索引保持不变
+// 启用生命周期管理时由滚动别名代替静态索引
+func createMissingIndices(indices []indexDefinition) MigrationStep {
+	return func(ctx context.Context, c *Client) error {
+		for _, index := range indices {
+			if c.isManaged(index.name) {
+				continue
+			}
+			exists, err := c.IndexExists(ctx, index.name)
+			if err != nil {
+				return fmt.Errorf("检查索引 %s 是否存在失败: %w", index.name, err)
+			}
+			if exists {
+				continue
+			}
+			if err := c.CreateIndex(ctx, index.name, index.mapping); err != nil {
+				return fmt.Errorf("创建索引 %s 失败: %w", index.name, err)
+			}
+			c.logger.Infof("创建索引: %s", index.name)
+		}
+		return nil
+	}
+}
+
+// putMapping 为已有索引增加字段，index为生命周期管理的别名时作用于其全部滚动索引
+// 只能增加字段或修改可更新的参数，修改已有字段的类型需要重建索引
+func putMapping(index, mapping string) MigrationStep {
+	return func(ctx context.Context, c *Client) error {
+		req := esapi.IndicesPutMappingRequest{
+			Index: []string{index},
+			Body:  strings.NewReader(mapping),
+		}
+		if err := c.do(ctx, req); err != nil {
+			return fmt.Errorf("更新索引 %s 的映射失败: %w", index, err)
+		}
+		return nil
+	}
+}
+
+// reindex 按新的映射创建target并将source的文档复制过去，通常随后以swapAlias切换到target
+// 上次执行中途失败留下的target会被删除重建；source已是指向target的别名时说明切换已完成，不再执行
+func reindex(source, target, mapping string) MigrationStep {
+	return func(ctx context.Context, c *Client) error {
+		if c.isManaged(source) {
+			return fmt.Errorf("索引 %s 由生命周期管理，不能重建", source)
+		}
+		current, err := c.aliasIndices(ctx, source)
+		if err != nil {
+			return err
+		}
+		for _, index := range current {
+			if index == target {
+				return nil
+			}
+		}
+
+		exists, err := c.IndexExists(ctx, target)
+		if err != nil {
+			return fmt.Errorf("检查索引 %s 是否存在失败: %w", target, err)
+		}
+		if exists {
+			if err := c.do(ctx, esapi.IndicesDeleteRequest{Index: []string{target}}); err != nil {
+				return fmt.Errorf("删除未完成的索引 %s 失败: %w", target, err)
+			}
+		}
+		if err := c.CreateIndex(ctx, target, mapping); err != nil {
+			return fmt.Errorf("创建索引 %s 失败: %w", target, err)
+		}
+
+		body := fmt.Sprintf(`{"source": {"index": %q}, "dest": {"index": %q}}`, source, target)
+		waitForCompletion, refresh := true, true
+		req := esapi.ReindexRequest{
+			Body:              strings.NewReader(body),
+			WaitForCompletion: &waitForCompletion,
+			Refresh:           &refresh,
+		}
+		if err := c.do(ctx, req); err != nil {
+			return fmt.Errorf("将索引 %s 重建到 %s 失败: %w", source, target, err)
+		}
+		c.logger.Infof("已将索引 %s 重建到 %s", source, target)
+		return nil
+	}
+}
+
+// swapAlias 将alias原子地切换到target并删除其原来指向的索引
+// alias尚是同名的静态索引时删除该索引并创建别名，之后读写都经由别名
+func swapAlias(alias, target string) MigrationStep {
+	return func(ctx context.Context, c *Client) error {
+		if c.isManaged(alias) {
+			return fmt.Errorf("别名 %s 由生命周期管理，不能切换", alias)
+		}
+		current, err := c.aliasIndices(ctx, alias)
+		if err != nil {
+			return err
+		}
+
+		var actions []map[string]interface{}
+		switch {
+		case len(current) > 0:
+			for _, index := range current {
+				if index != target {
+					actions = append(actions, map[string]interface{}{"remove_index": map[string]interface{}{"index": index}})
+				}
+			}
+		default:
+			exists, err := c.IndexExists(ctx, alias)
+			if err != nil {
+				return fmt.Errorf("检查索引 %s 是否存在失败: %w", alias, err)
+			}
+			if exists {
+				actions = append(actions, map[string]interface{}{"remove_index": map[string]interface{}{"index": alias}})
+			}
+		}
+		if len(actions) == 0 && len(current) > 0 {
+			return nil
+		}
+		actions = append([]map[string]interface{}{
+			{"add": map[string]interface{}{"index": target, "alias": alias, "is_write_index": true}},
+		}, actions...)
+
+		data, err := json.Marshal(map[string]interface{}{"actions": actions})
+		if err != nil {
+			return fmt.Errorf("序列化别名操作失败: %w", err)
+		}
+		if err := c.do(ctx, esapi.IndicesUpdateAliasesRequest{Body: strings.NewReader(string(data))}); err != nil {
+			return fmt.Errorf("将别名 %s 切换到 %s 失败: %w", alias, target, err)
+		}
+		c.logger.Infof("已将别名 %s 切换到 %s", alias, target)
+		return nil
+	}
+}
+
+// aliasIndices 别名当前指向的索引，别名不存在时返回空
+func (c *Client) aliasIndices(ctx context.Context, alias string) ([]string, error) {
+	res, err := esapi.IndicesGetAliasRequest{Name: []string{alias}}.Do(ctx, c.es)
+	if err != nil {
+		return nil, fmt.Errorf("获取别名 %s 失败: %w", alias, unavailable(err))
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == 404 {
+		return nil, nil
+	}
+	if res.IsError() {
+		return nil, responseError("获取别名响应错误", res)
+	}
+
+	var response map[string]json.RawMessage
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("解析别名失败: %w", err)
+	}
+	indices := make([]string, 0, len(response))
+	for index := range response {
+		indices = append(indices, index)
+	}
+	sort.Strings(indices)
+	return indices, nil
+}
+
+// MigrationStatus 获取各迁移的执行状态，不执行迁移
+func (c *Client) MigrationStatus(ctx context.Context) (*MigrationStatus, error) {
+	if err := c.ensureMigrationsIndex(ctx); err != nil {
+		return nil, err
+	}
+	records, err := c.migrationRecords(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.migrationStatus(records), nil
+}
+
+// Migrate 按版本顺序执行尚未成功的迁移，遇到失败的迁移时记录错误并停止，返回执行后的状态
+// 启用生命周期管理时先创建或更新滚动别名，迁移可以修改其映射；
+// 同一时间只有一个平台实例执行迁移，其他实例返回 ErrMigrationLocked
+func (c *Client) Migrate(ctx context.Context) (*MigrationStatus, error) {
+	if err := c.ensureMigrationsIndex(ctx); err != nil {
+		return nil, err
+	}
+	if c.config.Lifecycle.Enabled {
+		if err := c.initializeLifecycle(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	owner := migrationOwner()
+	if err := c.acquireMigrationLock(ctx, owner); err != nil {
+		return nil, err
+	}
+	defer func() {
+		// 调用方取消时仍需释放锁，否则其他实例要等锁过期
+		releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+		defer cancel()
+		if err := c.Delete(releaseCtx, MigrationsIndex, migrationLockID); err != nil {
+			c.logger.WithError(err).Warn("释放索引迁移锁失败")
+		}
+	}()
+
+	records, err := c.migrationRecords(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, migration := range c.migrations() {
+		if records[migration.Version].Status == MigrationApplied {
+			continue
+		}
+
+		started := time.Now().UTC()
+		record := MigrationRecord{
+			Version:     migration.Version,
+			Description: migration.Description,
+			Status:      MigrationApplied,
+			AppliedBy:   owner,
+			StartedAt:   &started,
+		}
+		var stepErr error
+		for i, step := range migration.Steps {
+			if stepErr = step(ctx, c); stepErr != nil {
+				stepErr = fmt.Errorf("第 %d 步: %w", i+1, stepErr)
+				break
+			}
+		}
+		finished := time.Now().UTC()
+		record.FinishedAt = &finished
+		if stepErr != nil {
+			record.Status = MigrationFailed
+			record.Error = stepErr.Error()
+		}
+
+		if err := c.Index(ctx, MigrationsIndex, fmt.Sprint(migration.Version), record); err != nil {
+			return nil, fmt.Errorf("记录迁移 %d 的执行结果失败: %w", migration.Version, err)
+		}
+		records[migration.Version] = record
+		if stepErr != nil {
+			return c.migrationStatus(records), fmt.Errorf("执行迁移 %d（%s）失败: %w", migration.Version, migration.Description, stepErr)
+		}
+		c.logger.WithField("version", migration.Version).Infof("已执行索引迁移: %s", migration.Description)
+	}
+
+	return c.migrationStatus(records), nil
+}
+
+// ensureMigrationsIndex 创建迁移记录索引
+func (c *Client) ensureMigrationsIndex(ctx context.Context) error {
+	exists, err := c.IndexExists(ctx, MigrationsIndex)
+	if err != nil {
+		return fmt.Errorf("检查索引 %s 是否存在失败: %w", MigrationsIndex, err)
+	}
+	if exists {
+		return nil
+	}
+	if err := c.CreateIndex(ctx, MigrationsIndex, migrationsMapping); err != nil {
+		// 多个实例同时启动时可能已由其他实例创建
+		if exists, _ := c.IndexExists(ctx, MigrationsIndex); exists {
+			return nil
+		}
+		return fmt.Errorf("创建索引 %s 失败: %w", MigrationsIndex, err)
+	}
+	return nil
+}
+
+// acquireMigrationLock 获取迁移锁，锁已过期时接管
+func (c *Client) acquireMigrationLock(ctx context.Context, owner string) error {
+	lock := migrationLock{Owner: owner, ExpiresAt: time.Now().UTC().Add(migrationLockTTL)}
+	err := c.IndexIfVersion(ctx, MigrationsIndex, migrationLockID, lock, nil)
+	if !errors.Is(err, ErrVersionConflict) {
+		return err
+	}
+
+	var current migrationLock
+	version, err := c.GetVersioned(ctx, MigrationsIndex, migrationLockID, &current)
+	if errors.Is(err, ErrNotFound) {
+		// 持有者刚刚释放
+		return c.IndexIfVersion(ctx, MigrationsIndex, migrationLockID, lock, nil)
+	}
+	if err != nil {
+		return fmt.Errorf("获取索引迁移锁失败: %w", err)
+	}
+	if time.Now().Before(current.ExpiresAt) {
+		return fmt.Errorf("%w（%s）", ErrMigrationLocked, current.Owner)
+	}
+
+	c.logger.WithField("owner", current.Owner).Warn("索引迁移锁已过期，接管执行")
+	if err := c.IndexIfVersion(ctx, MigrationsIndex, migrationLockID, lock, version); err != nil {
+		if errors.Is(err, ErrVersionConflict) {
+			return ErrMigrationLocked
+		}
+		return err
+	}
+	return nil
+}
+
+// migrationRecords 已有的执行记录，按版本索引
+func (c *Client) migrationRecords(ctx context.Context) (map[int]MigrationRecord, error) {
+	query := map[string]interface{}{
+		"query": map[string]interface{}{"exists": map[string]interface{}{"field": "version"}},
+		"size":  1000,
+	}
+	var response struct {
+		Hits struct {
+			Hits []struct {
+				Source MigrationRecord `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := c.Search(WithPrimaryRead(ctx), MigrationsIndex, query, &response); err != nil {
+		return nil, fmt.Errorf("获取迁移记录失败: %w", err)
+	}
+
+	records := make(map[int]MigrationRecord, len(response.Hits.Hits))
+	for _, hit := range response.Hits.Hits {
+		records[hit.Source.Version] = hit.Source
+	}
+	return records, nil
+}
+
+// migrationStatus 按平台定义的迁移汇总执行记录，尚未执行的迁移为pending
+func (c *Client) migrationStatus(records map[int]MigrationRecord) *MigrationStatus {
+	migrations := c.migrations()
+	status := &MigrationStatus{Migrations: make([]MigrationRecord, 0, len(migrations))}
+	contiguous := true
+	for _, migration := range migrations {
+		record, ok := records[migration.Version]
+		if !ok {
+			record = MigrationRecord{Version: migration.Version, Description: migration.Description, Status: MigrationPending}
+		}
+		if contiguous && record.Status == MigrationApplied {
+			status.Current = migration.Version
+		} else {
+			contiguous = false
+		}
+		status.Latest = migration.Version
+		status.Migrations = append(status.Migrations, record)
+	}
+	return status
+}
+
+// migrationOwner 当前平台实例的标识
+func migrationOwner() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return fmt.Sprintf("pid-%d", os.Getpid())
+	}
+	return fmt.Sprintf("%s/%d", hostname, os.Getpid())
+}
@@ -0,0 +1,181 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMigrationES 模拟迁移用到的ES接口，已执行第1个迁移
+type fakeMigrationES struct {
+	mu         sync.Mutex
+	requests   []string
+	bodies     map[string]string
+	lock       string // 迁移锁文档，为空表示未加锁
+	mappingErr bool
+}
+
+func (f *fakeMigrationES) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := r.Method + " " + r.URL.Path
+	f.requests = append(f.requests, key)
+	f.bodies[key] = string(body)
+
+	w.Header().Set("X-Elastic-Product", "Elasticsearch")
+	switch {
+	case r.Method == http.MethodHead && (r.URL.Path == "/"+MigrationsIndex || r.URL.Path == "/logstash_agents"):
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodHead:
+		w.WriteHeader(http.StatusNotFound)
+	case r.URL.Path == "/_alias/logstash_agents":
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error": "alias [logstash_agents] missing", "status": 404}`))
+	case strings.HasSuffix(r.URL.Path, "/"+migrationLockID) && r.Method == http.MethodGet:
+		fmt.Fprintf(w, `{"found": true, "_seq_no": 3, "_primary_term": 1, "_source": %s}`, f.lock)
+	case strings.HasSuffix(r.URL.Path, "/"+migrationLockID) && r.Method == http.MethodDelete:
+		f.lock = ""
+		w.Write([]byte(`{"result": "deleted"}`))
+	case strings.HasSuffix(r.URL.Path, "/"+migrationLockID):
+		if f.lock != "" && r.URL.Query().Get("op_type") == "create" {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"error": "version_conflict_engine_exception", "status": 409}`))
+			return
+		}
+		f.lock = string(body)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"result": "created"}`))
+	case strings.HasSuffix(r.URL.Path, "/_search"):
+		w.Write([]byte(`{"hits": {"hits": [{"_source": {"version": 1, "description": "创建平台索引", "status": "applied"}}]}}`))
+	case strings.HasSuffix(r.URL.Path, "/_mapping") && f.mappingErr:
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": "illegal_argument_exception", "status": 400}`))
+	default:
+		w.Write([]byte(`{"acknowledged": true}`))
+	}
+}
+
+func (f *fakeMigrationES) client(t *testing.T) *Client {
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	es, err := elasticsearch.NewClient(elasticsearch.Config{Addresses: []string{server.URL}})
+	require.NoError(t, err)
+	config := &Config{}
+	config.Indices.Agents = "logstash_agents"
+	config.Indices.Configs = "logstash_configs"
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	return &Client{es: es, logger: logger, config: config}
+}
+
+func (f *fakeMigrationES) record(t *testing.T, version int) MigrationRecord {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var record MigrationRecord
+	require.NoError(t, json.Unmarshal([]byte(f.bodies[fmt.Sprintf("PUT /%s/_doc/%d", MigrationsIndex, version)]), &record))
+	return record
+}
+
+func TestClient_Migrate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("只执行尚未执行的迁移并释放锁", func(t *testing.T) {
+		fake := &fakeMigrationES{bodies: make(map[string]string)}
+		client := fake.client(t)
+
+		status, err := client.Migrate(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 4, status.Current)
+		assert.Equal(t, 4, status.Latest)
+		assert.False(t, status.Pending())
+
+		// 第1个迁移已执行，不再检查其他索引
+		assert.NotContains(t, fake.requests, "HEAD /logstash_configs")
+		assert.Contains(t, fake.requests, "PUT /logstash_agents/_mapping")
+		assert.JSONEq(t, `{"properties": {"environment": {"type": "keyword"}}}`, fake.bodies["PUT /logstash_agents/_mapping"])
+		assert.Contains(t, fake.requests, "PUT /logstash_deployments/_mapping")
+		assert.JSONEq(t, `{"properties": {"scheduled_test": {"type": "boolean"}}}`, fake.bodies["PUT /logstash_configs/_mapping"])
+		assert.Contains(t, fake.requests, "PUT /logstash_scheduled_test_runs")
+
+		record := fake.record(t, 2)
+		assert.Equal(t, MigrationApplied, record.Status)
+		assert.NotEmpty(t, record.AppliedBy)
+		assert.Empty(t, fake.lock, "执行结束后释放锁")
+	})
+
+	t.Run("迁移失败时记录错误并停止", func(t *testing.T) {
+		fake := &fakeMigrationES{bodies: make(map[string]string), mappingErr: true}
+		client := fake.client(t)
+
+		status, err := client.Migrate(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "执行迁移 2")
+		assert.Equal(t, 1, status.Current)
+		assert.True(t, status.Pending())
+
+		record := fake.record(t, 2)
+		assert.Equal(t, MigrationFailed, record.Status)
+		assert.Contains(t, record.Error, "第 1 步")
+		assert.Empty(t, fake.lock)
+	})
+
+	t.Run("其他实例持有锁时不执行", func(t *testing.T) {
+		lock, _ := json.Marshal(migrationLock{Owner: "platform-2/1", ExpiresAt: time.Now().Add(time.Minute)})
+		fake := &fakeMigrationES{bodies: make(map[string]string), lock: string(lock)}
+		client := fake.client(t)
+
+		_, err := client.Migrate(ctx)
+		assert.ErrorIs(t, err, ErrMigrationLocked)
+		assert.Contains(t, err.Error(), "platform-2/1")
+		assert.NotContains(t, fake.requests, "PUT /logstash_agents/_mapping")
+		assert.NotEmpty(t, fake.lock, "不释放其他实例的锁")
+	})
+
+	t.Run("接管过期的锁", func(t *testing.T) {
+		lock, _ := json.Marshal(migrationLock{Owner: "platform-2/1", ExpiresAt: time.Now().Add(-time.Minute)})
+		fake := &fakeMigrationES{bodies: make(map[string]string), lock: string(lock)}
+		client := fake.client(t)
+
+		status, err := client.Migrate(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 4, status.Current)
+	})
+}
+
+func TestClient_MigrationStatus(t *testing.T) {
+	fake := &fakeMigrationES{bodies: make(map[string]string)}
+	client := fake.client(t)
+
+	status, err := client.MigrationStatus(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.Current)
+	require.Len(t, status.Migrations, 4)
+	assert.Equal(t, MigrationApplied, status.Migrations[0].Status)
+	assert.Equal(t, MigrationPending, status.Migrations[1].Status)
+	assert.NotContains(t, fake.requests, "PUT /logstash_agents/_mapping", "只查询不执行")
+}
+
+func TestSwapAlias(t *testing.T) {
+	fake := &fakeMigrationES{bodies: make(map[string]string)}
+	client := fake.client(t)
+
+	// 同名静态索引改为指向新索引的别名
+	require.NoError(t, swapAlias("logstash_agents", "logstash_agents-v2")(context.Background(), client))
+	assert.JSONEq(t, `{"actions": [
+		{"add": {"index": "logstash_agents-v2", "alias": "logstash_agents", "is_write_index": true}},
+		{"remove_index": {"index": "logstash_agents"}}
+	]}`, fake.bodies["POST /_aliases"])
+}
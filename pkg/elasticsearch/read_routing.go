@@ -0,0 +1,78 @@
+package elasticsearch
+
+import (
+	"context"
+	"strings"
+
+	"github.com/elastic/go-elasticsearch/v8"
+)
+
+// ReadRoutingConfig 读请求路由配置
+// 按索引组将指标查询、看板、导出等读密集的请求定向到各自的副本或协调节点，写请求仍走主地址
+type ReadRoutingConfig struct {
+	Groups []ReadGroup
+}
+
+// ReadGroup 一个索引组的读路由
+type ReadGroup struct {
+	Name       string   `mapstructure:"name"`       // 索引组名称，用于日志和区分只读客户端
+	Addresses  []string `mapstructure:"addresses"`  // 只读节点地址，为空时复用主地址
+	Preference string   `mapstructure:"preference"` // 搜索偏好，例如 _replica、_prefer_nodes:node1
+	Indices    []string `mapstructure:"indices"`    // 属于该组的索引，支持结尾的 * 前缀匹配
+}
+
+// primaryReadKey 上下文键，标记请求必须读取主地址
+type primaryReadKey struct{}
+
+// WithPrimaryRead 返回强制读主的上下文，用于写后立即读取的场景
+func WithPrimaryRead(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryReadKey{}, true)
+}
+
+// isPrimaryRead 上下文是否要求读主
+func isPrimaryRead(ctx context.Context) bool {
+	v, _ := ctx.Value(primaryReadKey{}).(bool)
+	return v
+}
+
+// group 返回索引所属的第一个索引组，未配置路由时返回nil
+func (r *ReadRoutingConfig) group(index string) *ReadGroup {
+	for i := range r.Groups {
+		if r.Groups[i].matches(index) {
+			return &r.Groups[i]
+		}
+	}
+	return nil
+}
+
+// matches 判断索引是否属于该索引组
+func (g *ReadGroup) matches(index string) bool {
+	for _, pattern := range g.Indices {
+		if strings.HasSuffix(pattern, "*") {
+			if strings.HasPrefix(index, strings.TrimSuffix(pattern, "*")) {
+				return true
+			}
+			continue
+		}
+		if pattern == index {
+			return true
+		}
+	}
+	return false
+}
+
+// readTarget 选择读请求使用的ES客户端及偏好
+func (c *Client) readTarget(ctx context.Context, index string) (*elasticsearch.Client, string) {
+	if isPrimaryRead(ctx) {
+		return c.es, ""
+	}
+	group := c.config.ReadRouting.group(index)
+	if group == nil {
+		return c.es, ""
+	}
+
+	if reader, ok := c.readers[group.Name]; ok {
+		return reader, group.Preference
+	}
+	return c.es, group.Preference
+}
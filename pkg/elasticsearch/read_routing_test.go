@@ -0,0 +1,57 @@
+package elasticsearch
+
+import (
+	"context"
+	"testing"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadRoutingConfig_Group(t *testing.T) {
+	routing := &ReadRoutingConfig{Groups: []ReadGroup{
+		{Name: "metrics", Indices: []string{"logstash_metrics*"}},
+		{Name: "deployments", Indices: []string{"logstash_deployments"}},
+	}}
+
+	assert.Equal(t, "metrics", routing.group("logstash_metrics").Name)
+	assert.Equal(t, "metrics", routing.group("logstash_metrics-2024.01").Name)
+	assert.Equal(t, "deployments", routing.group("logstash_deployments").Name)
+	assert.Nil(t, routing.group("logstash_configs"))
+	assert.Nil(t, (&ReadRoutingConfig{}).group("logstash_configs"))
+}
+
+func TestClient_ReadTarget(t *testing.T) {
+	primary, _ := elasticsearch.NewClient(elasticsearch.Config{Addresses: []string{"http://primary:9200"}})
+	reader, _ := elasticsearch.NewClient(elasticsearch.Config{Addresses: []string{"http://replica:9200"}})
+
+	client := &Client{
+		es:      primary,
+		readers: map[string]*elasticsearch.Client{"metrics": reader},
+		config: &Config{
+			ReadRouting: ReadRoutingConfig{Groups: []ReadGroup{
+				{Name: "metrics", Preference: "_replica", Indices: []string{"logstash_metrics*"}},
+				{Name: "deployments", Preference: "_local", Indices: []string{"logstash_deployments"}},
+			}},
+		},
+	}
+	ctx := context.Background()
+
+	es, pref := client.readTarget(ctx, "logstash_metrics")
+	assert.Same(t, reader, es)
+	assert.Equal(t, "_replica", pref)
+
+	// 没有只读地址的索引组复用主地址，仅应用偏好
+	es, pref = client.readTarget(ctx, "logstash_deployments")
+	assert.Same(t, primary, es)
+	assert.Equal(t, "_local", pref)
+
+	es, pref = client.readTarget(ctx, "logstash_configs")
+	assert.Same(t, primary, es)
+	assert.Empty(t, pref)
+
+	// 强制读主时忽略只读路由
+	es, pref = client.readTarget(WithPrimaryRead(ctx), "logstash_metrics")
+	assert.Same(t, primary, es)
+	assert.Empty(t, pref)
+}
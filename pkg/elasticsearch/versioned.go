@@ -0,0 +1,98 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+// ErrVersionConflict 条件写入时文档已被其他写入修改（或已存在）
+var ErrVersionConflict = errors.New("文档版本冲突")
+
+// DocVersion 文档的序列号和主分片任期，用于乐观并发控制
+type DocVersion struct {
+	SeqNo       int64
+	PrimaryTerm int64
+}
+
+// GetVersioned 获取文档及其版本，文档不存在时返回 ErrNotFound
+func (c *Client) GetVersioned(ctx context.Context, index, id string, result interface{}) (*DocVersion, error) {
+	req := esapi.GetRequest{
+		Index:      index,
+		DocumentID: id,
+	}
+
+	res, err := req.Do(ctx, c.es)
+	if err != nil {
+		return nil, fmt.Errorf("获取文档失败: %w", unavailable(err))
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		if res.StatusCode == http.StatusNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, responseError("获取文档响应错误", res)
+	}
+
+	var response struct {
+		Source      json.RawMessage `json:"_source"`
+		Found       bool            `json:"found"`
+		SeqNo       int64           `json:"_seq_no"`
+		PrimaryTerm int64           `json:"_primary_term"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+	if !response.Found {
+		return nil, ErrNotFound
+	}
+	if err := json.Unmarshal(response.Source, result); err != nil {
+		return nil, fmt.Errorf("解析文档失败: %w", err)
+	}
+
+	return &DocVersion{SeqNo: response.SeqNo, PrimaryTerm: response.PrimaryTerm}, nil
+}
+
+// IndexIfVersion 仅当文档仍为指定版本时写入，version为nil时仅在文档不存在时创建
+// 条件不满足时返回 ErrVersionConflict
+func (c *Client) IndexIfVersion(ctx context.Context, index, id string, doc interface{}, version *DocVersion) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("序列化文档失败: %w", err)
+	}
+
+	req := esapi.IndexRequest{
+		Index:      index,
+		DocumentID: id,
+		Body:       strings.NewReader(string(data)),
+		Refresh:    "true",
+	}
+	if version == nil {
+		req.OpType = "create"
+	} else {
+		seqNo, primaryTerm := int(version.SeqNo), int(version.PrimaryTerm)
+		req.IfSeqNo = &seqNo
+		req.IfPrimaryTerm = &primaryTerm
+	}
+
+	res, err := req.Do(ctx, c.es)
+	if err != nil {
+		return fmt.Errorf("索引文档失败: %w", unavailable(err))
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusConflict {
+		return ErrVersionConflict
+	}
+	if res.IsError() {
+		return responseError("索引文档响应错误", res)
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package elasticsearch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_VersionedWrites(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/logstash_leases/_doc/jobs":
+			w.Write([]byte(`{"found": true, "_seq_no": 7, "_primary_term": 2, "_source": {"holder": "a"}}`))
+		case r.Method == http.MethodGet:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"found": false}`))
+		default:
+			query = r.URL.RawQuery
+			if r.URL.Query().Get("if_seq_no") == "6" {
+				w.WriteHeader(http.StatusConflict)
+				w.Write([]byte(`{"error": {"type": "version_conflict_engine_exception"}}`))
+				return
+			}
+			w.Write([]byte(`{"result": "updated"}`))
+		}
+	}))
+	defer server.Close()
+
+	es, err := elasticsearch.NewClient(elasticsearch.Config{Addresses: []string{server.URL}})
+	require.NoError(t, err)
+	client := &Client{es: es, logger: logrus.New(), config: &Config{}}
+	ctx := context.Background()
+
+	var lease struct {
+		Holder string `json:"holder"`
+	}
+	version, err := client.GetVersioned(ctx, "logstash_leases", "jobs", &lease)
+	require.NoError(t, err)
+	assert.Equal(t, &DocVersion{SeqNo: 7, PrimaryTerm: 2}, version)
+	assert.Equal(t, "a", lease.Holder)
+
+	_, err = client.GetVersioned(ctx, "logstash_leases", "missing", &lease)
+	assert.EqualError(t, err, "文档不存在")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, client.IndexIfVersion(ctx, "logstash_leases", "jobs", lease, version))
+	assert.Contains(t, query, "if_seq_no=7")
+	assert.Contains(t, query, "if_primary_term=2")
+
+	require.NoError(t, client.IndexIfVersion(ctx, "logstash_leases", "jobs", lease, nil))
+	assert.Contains(t, query, "op_type=create")
+
+	err = client.IndexIfVersion(ctx, "logstash_leases", "jobs", lease, &DocVersion{SeqNo: 6, PrimaryTerm: 2})
+	assert.ErrorIs(t, err, ErrVersionConflict)
+}
+
+func TestClient_Unavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"error": {"type": "cluster_block_exception"}}`))
+	}))
+	defer server.Close()
+
+	es, err := elasticsearch.NewClient(elasticsearch.Config{Addresses: []string{server.URL}, DisableRetry: true})
+	require.NoError(t, err)
+	client := &Client{es: es, logger: logrus.New(), config: &Config{}}
+	ctx := context.Background()
+
+	var doc map[string]interface{}
+	err = client.Get(ctx, "logstash_configs", "c1", &doc)
+	assert.ErrorIs(t, err, ErrUnavailable)
+
+	_, err = client.GetVersioned(ctx, "logstash_leases", "jobs", &doc)
+	assert.ErrorIs(t, err, ErrUnavailable)
+
+	// 连接失败同样视为不可用
+	server.Close()
+	err = client.Index(ctx, "logstash_configs", "c1", doc)
+	assert.ErrorIs(t, err, ErrUnavailable)
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	err = client.Index(canceled, "logstash_configs", "c1", doc)
+	assert.NotErrorIs(t, err, ErrUnavailable, "调用方取消不属于ES不可用")
+}
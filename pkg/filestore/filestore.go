@@ -0,0 +1,246 @@
+// Package filestore 保存上传的临时文件，文件有大小上限并在有效期过后被清理
+package filestore
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 文件存储的错误
+var (
+	ErrNotFound = errors.New("文件不存在")
+	ErrTooLarge = errors.New("文件超过大小上限")
+)
+
+// File 保存的文件
+type File struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`            // 上传时的文件名
+	Scope     string    `json:"scope,omitempty"` // 文件所属的范围，由调用方解释，例如项目
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Store 临时文件存储
+type Store interface {
+	// Put 保存r的全部内容，超过大小上限时返回 ErrTooLarge 且不保留任何内容
+	Put(ctx context.Context, file File, r io.Reader) (*File, error)
+	// Open 打开文件，文件不存在或已过期时返回 ErrNotFound
+	Open(ctx context.Context, id string) (io.ReadCloser, *File, error)
+	// Stat 获取文件信息，文件不存在或已过期时返回 ErrNotFound
+	Stat(ctx context.Context, id string) (*File, error)
+	Delete(ctx context.Context, id string) error
+	// Purge 删除在now之前过期的文件，返回删除的文件数
+	Purge(ctx context.Context, now time.Time) (int, error)
+}
+
+// Local 保存在本地目录的文件存储，每个文件对应内容文件 <id>.data 和信息文件 <id>.json
+// 多副本部署时文件只存在于接收上传的副本上
+type Local struct {
+	dir     string
+	maxSize int64
+	ttl     time.Duration
+	now     func() time.Time
+	mu      sync.Mutex // 保护Purge与Put之间对同一文件的并发操作
+}
+
+// NewLocal 创建本地文件存储，目录不存在时创建
+// maxSize为单个文件的字节数上限，<=0表示不限制；ttl为文件的有效期
+func NewLocal(dir string, maxSize int64, ttl time.Duration) (*Local, error) {
+	if ttl <= 0 {
+		return nil, fmt.Errorf("文件有效期必须大于0")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("创建文件目录失败: %w", err)
+	}
+	return &Local{dir: dir, maxSize: maxSize, ttl: ttl, now: time.Now}, nil
+}
+
+// Put 保存文件，先写入临时文件，完整写入后再改名，读取方不会看到写了一半的文件
+func (s *Local) Put(ctx context.Context, file File, r io.Reader) (*File, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	tmp, err := os.CreateTemp(s.dir, ".upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("创建文件失败: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	src := r
+	if s.maxSize > 0 {
+		src = io.LimitReader(r, s.maxSize+1)
+	}
+	size, err := io.Copy(tmp, src)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("写入文件失败: %w", err)
+	}
+	if s.maxSize > 0 && size > s.maxSize {
+		return nil, fmt.Errorf("%w: 上限 %d 字节", ErrTooLarge, s.maxSize)
+	}
+
+	now := s.now()
+	file.ID = id
+	file.Name = filepath.Base(file.Name)
+	file.Size = size
+	file.CreatedAt = now
+	file.ExpiresAt = now.Add(s.ttl)
+	meta, err := json.Marshal(&file)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Rename(tmp.Name(), s.path(id, ".data")); err != nil {
+		return nil, fmt.Errorf("保存文件失败: %w", err)
+	}
+	if err := os.WriteFile(s.path(id, ".json"), meta, 0600); err != nil {
+		os.Remove(s.path(id, ".data"))
+		return nil, fmt.Errorf("保存文件信息失败: %w", err)
+	}
+	return &file, nil
+}
+
+// Open 打开文件
+func (s *Local) Open(ctx context.Context, id string) (io.ReadCloser, *File, error) {
+	file, err := s.Stat(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.Open(s.path(id, ".data"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil, ErrNotFound
+		}
+		return nil, nil, fmt.Errorf("打开文件失败: %w", err)
+	}
+	return f, file, nil
+}
+
+// Stat 获取文件信息
+func (s *Local) Stat(ctx context.Context, id string) (*File, error) {
+	if !validID(id) {
+		return nil, ErrNotFound
+	}
+	file, err := s.readMeta(id)
+	if err != nil {
+		return nil, err
+	}
+	if !s.now().Before(file.ExpiresAt) {
+		return nil, ErrNotFound
+	}
+	return file, nil
+}
+
+// Delete 删除文件，文件不存在时返回 ErrNotFound
+func (s *Local) Delete(ctx context.Context, id string) error {
+	if !validID(id) {
+		return ErrNotFound
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	os.Remove(s.path(id, ".data"))
+	if err := os.Remove(s.path(id, ".json")); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("删除文件失败: %w", err)
+	}
+	return nil
+}
+
+// Purge 删除过期的文件，同时删除缺少信息文件的内容文件和上传中断留下的临时文件
+func (s *Local) Purge(ctx context.Context, now time.Time) (int, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return 0, fmt.Errorf("读取文件目录失败: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	purged := 0
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return purged, ctx.Err()
+		}
+		name := entry.Name()
+		switch {
+		case strings.HasPrefix(name, ".upload-"):
+			// 上传中的临时文件在Put结束时删除，超过有效期仍存在的是进程异常退出留下的
+			if info, err := entry.Info(); err == nil && now.Sub(info.ModTime()) > s.ttl {
+				os.Remove(filepath.Join(s.dir, name))
+			}
+		case strings.HasSuffix(name, ".json"):
+			id := strings.TrimSuffix(name, ".json")
+			file, err := s.readMeta(id)
+			if err != nil && !errors.Is(err, ErrNotFound) {
+				continue
+			}
+			if file == nil || !now.Before(file.ExpiresAt) {
+				os.Remove(s.path(id, ".data"))
+				os.Remove(s.path(id, ".json"))
+				purged++
+			}
+		case strings.HasSuffix(name, ".data"):
+			id := strings.TrimSuffix(name, ".data")
+			if _, err := os.Stat(s.path(id, ".json")); errors.Is(err, os.ErrNotExist) {
+				os.Remove(filepath.Join(s.dir, name))
+			}
+		}
+	}
+	return purged, nil
+}
+
+// readMeta 读取文件信息
+func (s *Local) readMeta(id string) (*File, error) {
+	data, err := os.ReadFile(s.path(id, ".json"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("读取文件信息失败: %w", err)
+	}
+	var file File
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("解析文件信息失败: %w", err)
+	}
+	return &file, nil
+}
+
+func (s *Local) path(id, ext string) string {
+	return filepath.Join(s.dir, id+ext)
+}
+
+// newID 生成随机的文件ID
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成文件ID失败: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// validID 文件ID只能是newID生成的格式，防止通过ID访问目录外的文件
+func validID(id string) bool {
+	if len(id) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
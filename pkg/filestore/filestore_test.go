@@ -0,0 +1,71 @@
+package filestore
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocal(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := NewLocal(dir, 16, time.Hour)
+	require.NoError(t, err)
+	now := time.Date(2026, 10, 14, 8, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	t.Run("保存后可读取", func(t *testing.T) {
+		file, err := store.Put(ctx, File{Name: "../../access.log", Scope: "payments"}, strings.NewReader("line1\nline2\n"))
+		require.NoError(t, err)
+		assert.Len(t, file.ID, 32)
+		assert.Equal(t, "access.log", file.Name, "只保留文件名")
+		assert.EqualValues(t, 12, file.Size)
+		assert.Equal(t, now.Add(time.Hour), file.ExpiresAt)
+
+		r, opened, err := store.Open(ctx, file.ID)
+		require.NoError(t, err)
+		defer r.Close()
+		data, _ := io.ReadAll(r)
+		assert.Equal(t, "line1\nline2\n", string(data))
+		assert.Equal(t, "payments", opened.Scope)
+	})
+
+	t.Run("超过大小上限时不保留", func(t *testing.T) {
+		_, err := store.Put(ctx, File{Name: "big.log"}, strings.NewReader(strings.Repeat("x", 17)))
+		assert.ErrorIs(t, err, ErrTooLarge)
+		entries, _ := os.ReadDir(dir)
+		for _, entry := range entries {
+			assert.False(t, strings.HasPrefix(entry.Name(), ".upload-"), "临时文件已删除")
+		}
+	})
+
+	t.Run("无效的ID", func(t *testing.T) {
+		_, _, err := store.Open(ctx, "../etc/passwd")
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.ErrorIs(t, store.Delete(ctx, strings.Repeat("0", 32)), ErrNotFound)
+	})
+
+	t.Run("过期后不可读取并被清理", func(t *testing.T) {
+		file, err := store.Put(ctx, File{Name: "old.log"}, strings.NewReader("x"))
+		require.NoError(t, err)
+		orphan := filepath.Join(dir, strings.Repeat("a", 32)+".data")
+		require.NoError(t, os.WriteFile(orphan, []byte("x"), 0600))
+
+		now = now.Add(time.Hour)
+		_, err = store.Stat(ctx, file.ID)
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		purged, err := store.Purge(ctx, now)
+		require.NoError(t, err)
+		assert.Equal(t, 2, purged, "两个上传的文件均已过期")
+		entries, _ := os.ReadDir(dir)
+		assert.Empty(t, entries, "缺少信息文件的内容文件一并删除")
+	})
+}
@@ -0,0 +1,113 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/sirupsen/logrus"
+)
+
+// journaldSocket systemd-journald原生协议的套接字
+const journaldSocket = "/run/systemd/journal/socket"
+
+// journaldPriorities 日志级别到syslog严重程度的映射
+var journaldPriorities = map[logrus.Level]int{
+	logrus.PanicLevel: 0,
+	logrus.FatalLevel: 2,
+	logrus.ErrorLevel: 3,
+	logrus.WarnLevel:  4,
+	logrus.InfoLevel:  6,
+	logrus.DebugLevel: 7,
+	logrus.TraceLevel: 7,
+}
+
+// journaldHook 以systemd-journald原生协议写入日志，日志字段转为大写的journal字段，可用 journalctl AGENT_ID=xxx 过滤
+type journaldHook struct {
+	conn       *net.UnixConn
+	addr       *net.UnixAddr
+	identifier string
+}
+
+// newJournaldHook 连接本机journald
+func newJournaldHook(identifier string) (*journaldHook, error) {
+	// 先检查套接字是否存在，避免在没有journald的环境中静默丢弃全部日志
+	if _, err := os.Stat(journaldSocket); err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	if identifier == "" {
+		identifier = programName()
+	}
+	return &journaldHook{
+		conn:       conn,
+		addr:       &net.UnixAddr{Name: journaldSocket, Net: "unixgram"},
+		identifier: identifier,
+	}, nil
+}
+
+// Levels 处理全部级别
+func (h *journaldHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire 写入一条日志
+func (h *journaldHook) Fire(entry *logrus.Entry) error {
+	var buf bytes.Buffer
+	writeJournaldField(&buf, "MESSAGE", entry.Message)
+	writeJournaldField(&buf, "PRIORITY", fmt.Sprint(journaldPriorities[entry.Level]))
+	writeJournaldField(&buf, "SYSLOG_IDENTIFIER", h.identifier)
+	for key, value := range entry.Data {
+		name := journaldFieldName(key)
+		if name == "" {
+			continue
+		}
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		writeJournaldField(&buf, name, fmt.Sprint(value))
+	}
+	_, err := h.conn.WriteToUnix(buf.Bytes(), h.addr)
+	return err
+}
+
+// writeJournaldField 按原生协议编码字段，值含换行时使用长度前缀格式
+func writeJournaldField(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(name)
+	if !strings.Contains(value, "\n") {
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+	buf.WriteByte('\n')
+	_ = binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// journaldFieldName 将日志字段名转为journal字段名：大写字母、数字和下划线，不能以下划线或数字开头
+func journaldFieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return unicode.ToUpper(r)
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+	name = strings.TrimLeft(name, "_0123456789")
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
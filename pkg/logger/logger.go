@@ -1,66 +1,279 @@
 package logger
 
 import (
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
-// New 创建新的日志实例
-func New() *logrus.Logger {
+// 日志输出目标
+const (
+	OutputStdout   = "stdout"
+	OutputStderr   = "stderr"
+	OutputFile     = "file"     // 按大小和保留天数轮转的文件
+	OutputSyslog   = "syslog"   // 本机或远程syslog
+	OutputJournald = "journald" // systemd-journald原生协议，日志字段写为journal字段
+)
+
+// 日志格式
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// timestampFormat 日志时间格式
+const timestampFormat = "2006-01-02 15:04:05"
+
+// Config 日志配置，平台读取 config.yaml 的 logging 段，Agent读取 agent.yaml 的 logging 段
+type Config struct {
+	Level   string            `yaml:"level" mapstructure:"level"`     // debug, info, warn, error
+	Format  string            `yaml:"format" mapstructure:"format"`   // json, text；journald输出不使用
+	Output  string            `yaml:"output" mapstructure:"output"`   // stdout, stderr, file, syslog, journald
+	File    FileConfig        `yaml:"file" mapstructure:"file"`       // output为file时使用
+	Syslog  SyslogConfig      `yaml:"syslog" mapstructure:"syslog"`   // output为syslog或journald时使用
+	Modules map[string]string `yaml:"modules" mapstructure:"modules"` // 模块名到日志级别，覆盖level
+}
+
+// FileConfig 文件输出配置
+type FileConfig struct {
+	Path       string `yaml:"path" mapstructure:"path"`
+	MaxSize    int    `yaml:"max_size" mapstructure:"max_size"`       // 单个文件的大小上限（MB），超过后轮转
+	MaxBackups int    `yaml:"max_backups" mapstructure:"max_backups"` // 保留的轮转文件数，0表示不限
+	MaxAge     int    `yaml:"max_age" mapstructure:"max_age"`         // 轮转文件的保留天数，0表示不限
+	Compress   bool   `yaml:"compress" mapstructure:"compress"`       // 是否gzip压缩轮转后的文件
+}
+
+// SyslogConfig syslog/journald输出配置
+type SyslogConfig struct {
+	Network  string `yaml:"network" mapstructure:"network"`   // udp、tcp，为空时使用本机syslog
+	Address  string `yaml:"address" mapstructure:"address"`   // 远程syslog地址，如 log.example.com:514
+	Facility string `yaml:"facility" mapstructure:"facility"` // daemon、local0~local7等，默认daemon
+	Tag      string `yaml:"tag" mapstructure:"tag"`           // 日志标识（SYSLOG_IDENTIFIER），默认为程序名
+}
+
+// Validate 检查日志配置
+func (c *Config) Validate() error {
+	if c.Level != "" {
+		if _, err := logrus.ParseLevel(c.Level); err != nil {
+			return fmt.Errorf("日志级别 %q 无效", c.Level)
+		}
+	}
+	switch c.Format {
+	case "", FormatText, FormatJSON:
+	default:
+		return fmt.Errorf("日志格式 %q 无效，可选 text、json", c.Format)
+	}
+	switch c.Output {
+	case "", OutputStdout, OutputStderr, OutputFile, OutputJournald:
+	case OutputSyslog:
+		if _, err := syslogFacility(c.Syslog.Facility); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("日志输出 %q 无效，可选 stdout、stderr、file、syslog、journald", c.Output)
+	}
+	for module, level := range c.Modules {
+		if _, err := logrus.ParseLevel(level); err != nil {
+			return fmt.Errorf("模块 %s 的日志级别 %q 无效", module, level)
+		}
+	}
+	return nil
+}
+
+// Setup 按配置创建日志实例
+// 配置了 modules 时，可经 Module 获取各模块独立级别的日志实例，各实例共享输出和格式
+func Setup(cfg Config) (*logrus.Logger, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
 	logger := logrus.New()
-	
-	// 设置日志级别
-	level, err := logrus.ParseLevel(viper.GetString("logging.level"))
-	if err != nil {
-		level = logrus.InfoLevel
+	level := logrus.InfoLevel
+	if cfg.Level != "" {
+		level, _ = logrus.ParseLevel(cfg.Level)
 	}
 	logger.SetLevel(level)
 
-	// 设置日志格式
-	if viper.GetString("logging.format") == "json" {
+	if cfg.Format == FormatJSON {
 		logger.SetFormatter(&logrus.JSONFormatter{
-			TimestampFormat: "2006-01-02 15:04:05",
+			TimestampFormat: timestampFormat,
 		})
 	} else {
 		logger.SetFormatter(&logrus.TextFormatter{
-			TimestampFormat: "2006-01-02 15:04:05",
+			TimestampFormat: timestampFormat,
 			FullTimestamp:   true,
 		})
 	}
 
-	// 设置输出
-	if viper.GetString("logging.output") == "file" {
-		logFile := viper.GetString("logging.file.path")
-		if logFile == "" {
-			logFile = "./logs/platform.log"
+	var out io.Writer = os.Stdout
+	switch cfg.Output {
+	case OutputStderr:
+		out = os.Stderr
+	case OutputFile:
+		writer, err := fileWriter(cfg.File)
+		if err != nil {
+			return nil, err
 		}
+		out = writer
+	case OutputSyslog:
+		hook, err := newSyslogHook(cfg.Syslog)
+		if err != nil {
+			return nil, fmt.Errorf("连接syslog失败: %w", err)
+		}
+		logger.AddHook(hook)
+		out = io.Discard
+	case OutputJournald:
+		hook, err := newJournaldHook(cfg.Syslog.Tag)
+		if err != nil {
+			return nil, fmt.Errorf("连接journald失败: %w", err)
+		}
+		logger.AddHook(hook)
+		out = io.Discard
+	}
 
-		// 确保日志目录存在
-		logDir := filepath.Dir(logFile)
-		if err := os.MkdirAll(logDir, 0755); err != nil {
-			logger.Errorf("创建日志目录失败: %v", err)
+	if len(cfg.Modules) > 0 {
+		// 模块日志实例与根实例并发写入同一输出，需要共用一把锁
+		out = &lockedWriter{w: out}
+		levels := make(map[string]logrus.Level, len(cfg.Modules))
+		for module, l := range cfg.Modules {
+			levels[module], _ = logrus.ParseLevel(l)
 		}
+		modules.Store(logger, &moduleLoggers{levels: levels, loggers: make(map[string]*logrus.Logger)})
+	}
+	logger.SetOutput(out)
 
-		// 使用lumberjack进行日志轮转
-		logger.SetOutput(&lumberjack.Logger{
-			Filename:   logFile,
-			MaxSize:    viper.GetInt("logging.file.max_size"),    // MB
-			MaxBackups: viper.GetInt("logging.file.max_backups"),
-			MaxAge:     viper.GetInt("logging.file.max_age"),     // days
-			Compress:   true,
-		})
-	} else {
-		logger.SetOutput(os.Stdout)
+	return logger, nil
+}
+
+// New 按viper中的 logging.* 配置创建日志实例，配置无效时输出到标准输出并记录原因
+func New() *logrus.Logger {
+	cfg := ConfigFromViper("logging")
+	if cfg.Output == OutputFile && cfg.File.Path == "" {
+		cfg.File.Path = "./logs/platform.log"
 	}
 
+	logger, err := Setup(cfg)
+	if err != nil {
+		fallback := Config{Format: cfg.Format}
+		if fallback.Validate() != nil {
+			fallback.Format = FormatText
+		}
+		logger, _ = Setup(fallback)
+		logger.Errorf("日志配置无效，输出到标准输出: %v", err)
+	}
+	return logger
+}
+
+// ConfigFromViper 读取viper中指定前缀下的日志配置，未设置 file.compress 时压缩轮转后的文件
+func ConfigFromViper(prefix string) Config {
+	compress := true
+	if viper.IsSet(prefix + ".file.compress") {
+		compress = viper.GetBool(prefix + ".file.compress")
+	}
+	return Config{
+		Level:  viper.GetString(prefix + ".level"),
+		Format: viper.GetString(prefix + ".format"),
+		Output: viper.GetString(prefix + ".output"),
+		File: FileConfig{
+			Path:       viper.GetString(prefix + ".file.path"),
+			MaxSize:    viper.GetInt(prefix + ".file.max_size"),
+			MaxBackups: viper.GetInt(prefix + ".file.max_backups"),
+			MaxAge:     viper.GetInt(prefix + ".file.max_age"),
+			Compress:   compress,
+		},
+		Syslog: SyslogConfig{
+			Network:  viper.GetString(prefix + ".syslog.network"),
+			Address:  viper.GetString(prefix + ".syslog.address"),
+			Facility: viper.GetString(prefix + ".syslog.facility"),
+			Tag:      viper.GetString(prefix + ".syslog.tag"),
+		},
+		Modules: viper.GetStringMapString(prefix + ".modules"),
+	}
+}
+
+// modules 由 Setup 创建的根日志实例到其模块日志实例的映射
+var modules sync.Map // *logrus.Logger -> *moduleLoggers
+
+// moduleLoggers 根日志实例下各模块的日志级别及已创建的模块日志实例
+type moduleLoggers struct {
+	levels  map[string]logrus.Level
+	mu      sync.Mutex
+	loggers map[string]*logrus.Logger
+}
+
+// Module 获取模块的日志实例
+// base由 Setup 创建且配置中为该模块指定了级别时，返回使用该级别、与base共享输出、格式和钩子的日志实例，否则返回base
+func Module(base *logrus.Logger, name string) *logrus.Logger {
+	v, ok := modules.Load(base)
+	if !ok {
+		return base
+	}
+	set := v.(*moduleLoggers)
+	level, ok := set.levels[name]
+	if !ok {
+		return base
+	}
+
+	set.mu.Lock()
+	defer set.mu.Unlock()
+	if logger, ok := set.loggers[name]; ok {
+		return logger
+	}
+	logger := &logrus.Logger{
+		Out:          base.Out,
+		Hooks:        base.Hooks,
+		Formatter:    base.Formatter,
+		ReportCaller: base.ReportCaller,
+		Level:        level,
+		ExitFunc:     base.ExitFunc,
+	}
+	set.loggers[name] = logger
 	return logger
 }
 
 // WithFields 创建带字段的日志条目
 func WithFields(logger *logrus.Logger, fields map[string]interface{}) *logrus.Entry {
 	return logger.WithFields(fields)
-}
\ No newline at end of file
+}
+
+// fileWriter 创建按大小轮转的日志文件
+func fileWriter(cfg FileConfig) (io.Writer, error) {
+	if strings.TrimSpace(cfg.Path) == "" {
+		return nil, fmt.Errorf("日志输出为file时 file.path 不能为空")
+	}
+	// 确保日志目录存在
+	if err := os.MkdirAll(filepath.Dir(cfg.Path), 0755); err != nil {
+		return nil, fmt.Errorf("创建日志目录失败: %w", err)
+	}
+	return &lumberjack.Logger{
+		Filename:   cfg.Path,
+		MaxSize:    cfg.MaxSize, // MB，0时使用lumberjack默认的100MB
+		MaxBackups: cfg.MaxBackups,
+		MaxAge:     cfg.MaxAge, // days
+		Compress:   cfg.Compress,
+	}, nil
+}
+
+// lockedWriter 串行化多个日志实例对同一输出的写入
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
+
+// programName 默认的日志标识
+func programName() string {
+	return filepath.Base(os.Args[0])
+}
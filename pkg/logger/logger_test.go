@@ -337,4 +337,70 @@ func TestLoggerIntegration(t *testing.T) {
 		assert.Contains(t, logContent, "component\":\"test")
 		assert.Contains(t, logContent, "action\":\"integration")
 	})
-}
\ No newline at end of file
+}
+func TestSetup(t *testing.T) {
+	t.Run("invalid configuration", func(t *testing.T) {
+		for _, cfg := range []Config{
+			{Level: "verbose"},
+			{Format: "xml"},
+			{Output: "kafka"},
+			{Output: OutputFile},
+			{Output: OutputSyslog, Syslog: SyslogConfig{Facility: "mail2"}},
+			{Modules: map[string]string{"client": "loud"}},
+		} {
+			_, err := Setup(cfg)
+			assert.Error(t, err, "%+v", cfg)
+		}
+	})
+
+	t.Run("stderr output", func(t *testing.T) {
+		logger, err := Setup(Config{Level: "warn", Output: OutputStderr})
+		require.NoError(t, err)
+		assert.Equal(t, logrus.WarnLevel, logger.Level)
+		assert.Equal(t, os.Stderr, logger.Out)
+	})
+
+	t.Run("rotating file output", func(t *testing.T) {
+		logPath := filepath.Join(t.TempDir(), "nested", "agent.log")
+		logger, err := Setup(Config{Format: FormatJSON, Output: OutputFile, File: FileConfig{Path: logPath, MaxSize: 1}})
+		require.NoError(t, err)
+		logger.WithField("agent_id", "agent-1").Info("started")
+
+		content, err := os.ReadFile(logPath)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), `"agent_id":"agent-1"`)
+	})
+}
+
+func TestModule(t *testing.T) {
+	logger, err := Setup(Config{Level: "info", Modules: map[string]string{"client": "debug", "logstash": "error"}})
+	require.NoError(t, err)
+
+	client := Module(logger, "client")
+	assert.Same(t, client, Module(logger, "client"))
+	assert.Same(t, logger, Module(logger, "heartbeat"))
+	assert.Equal(t, logger.Out, client.Out)
+	assert.Equal(t, logger.Formatter, client.Formatter)
+
+	assert.True(t, client.IsLevelEnabled(logrus.DebugLevel))
+	assert.False(t, logger.IsLevelEnabled(logrus.DebugLevel))
+	assert.False(t, Module(logger, "logstash").IsLevelEnabled(logrus.WarnLevel))
+
+	plain := logrus.New()
+	assert.Same(t, plain, Module(plain, "client"))
+}
+
+func TestJournaldEncoding(t *testing.T) {
+	assert.Equal(t, "AGENT_ID", journaldFieldName("agent_id"))
+	assert.Equal(t, "TRACE_ID", journaldFieldName("trace.id"))
+	assert.Equal(t, "X", journaldFieldName("_1x"))
+	assert.Empty(t, journaldFieldName("_"))
+
+	var buf bytes.Buffer
+	writeJournaldField(&buf, "MESSAGE", "single line")
+	assert.Equal(t, "MESSAGE=single line\n", buf.String())
+
+	buf.Reset()
+	writeJournaldField(&buf, "MESSAGE", "a\nb")
+	assert.Equal(t, []byte("MESSAGE\n\x03\x00\x00\x00\x00\x00\x00\x00a\nb\n"), buf.Bytes())
+}
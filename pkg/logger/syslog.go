@@ -0,0 +1,52 @@
+//go:build !windows && !plan9
+
+package logger
+
+import (
+	"fmt"
+	"log/syslog"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	lsyslog "github.com/sirupsen/logrus/hooks/syslog"
+)
+
+// syslogFacilities 可配置的syslog facility
+var syslogFacilities = map[string]syslog.Priority{
+	"kern":   syslog.LOG_KERN,
+	"user":   syslog.LOG_USER,
+	"daemon": syslog.LOG_DAEMON,
+	"local0": syslog.LOG_LOCAL0,
+	"local1": syslog.LOG_LOCAL1,
+	"local2": syslog.LOG_LOCAL2,
+	"local3": syslog.LOG_LOCAL3,
+	"local4": syslog.LOG_LOCAL4,
+	"local5": syslog.LOG_LOCAL5,
+	"local6": syslog.LOG_LOCAL6,
+	"local7": syslog.LOG_LOCAL7,
+}
+
+// syslogFacility 解析facility，为空时使用daemon
+func syslogFacility(name string) (syslog.Priority, error) {
+	if name == "" {
+		return syslog.LOG_DAEMON, nil
+	}
+	facility, ok := syslogFacilities[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("syslog facility %q 无效", name)
+	}
+	return facility, nil
+}
+
+// newSyslogHook 连接本机或远程syslog，日志按级别映射为syslog的严重程度
+func newSyslogHook(cfg SyslogConfig) (logrus.Hook, error) {
+	facility, err := syslogFacility(cfg.Facility)
+	if err != nil {
+		return nil, err
+	}
+	tag := cfg.Tag
+	if tag == "" {
+		tag = programName()
+	}
+	return lsyslog.NewSyslogHook(cfg.Network, cfg.Address, facility|syslog.LOG_INFO, tag)
+}
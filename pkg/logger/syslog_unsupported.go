@@ -0,0 +1,19 @@
+//go:build windows || plan9
+
+package logger
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// syslogFacility 当前平台不支持syslog
+func syslogFacility(name string) (int, error) {
+	return 0, fmt.Errorf("当前平台不支持syslog输出")
+}
+
+// newSyslogHook 当前平台不支持syslog
+func newSyslogHook(cfg SyslogConfig) (logrus.Hook, error) {
+	return nil, fmt.Errorf("当前平台不支持syslog输出")
+}
@@ -0,0 +1,72 @@
+package tabular
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(FormatCSV, &buf, "")
+	require.NoError(t, err)
+	require.NoError(t, w.WriteRow([]string{"agent_id", "hostname"}))
+	require.NoError(t, w.WriteRow([]string{"agent-1", "web,01"}))
+	require.NoError(t, w.WriteRow([]string{"=HYPERLINK(\"x\")", "-1"}))
+	require.NoError(t, w.Close())
+
+	out := buf.String()
+	require.True(t, strings.HasPrefix(out, "\ufeff"), "以BOM开头")
+	rows, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(out, "\ufeff"))).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"agent_id", "hostname"},
+		{"agent-1", "web,01"},
+		{"'=HYPERLINK(\"x\")", "'-1"},
+	}, rows)
+}
+
+func TestXLSXWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(FormatXLSX, &buf, "agents")
+	require.NoError(t, err)
+	require.NoError(t, w.WriteRow([]string{"agent_id", "labels"}))
+	require.NoError(t, w.WriteRow([]string{"agent-1", "env=prod;team=<web> & \"ops\"\x01"}))
+	require.NoError(t, w.Close())
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		files[f.Name] = string(content)
+	}
+	assert.Contains(t, files, "[Content_Types].xml")
+	assert.Contains(t, files["xl/workbook.xml"], `name="agents"`)
+
+	var sheet struct {
+		Rows []struct {
+			Cells []string `xml:"c>is>t"`
+		} `xml:"sheetData>row"`
+	}
+	require.NoError(t, xml.Unmarshal([]byte(files["xl/worksheets/sheet1.xml"]), &sheet))
+	require.Len(t, sheet.Rows, 2)
+	assert.Equal(t, []string{"agent_id", "labels"}, sheet.Rows[0].Cells)
+	assert.Equal(t, []string{"agent-1", "env=prod;team=<web> & \"ops\"\ufffd"}, sheet.Rows[1].Cells, "转义特殊字符，替换控制字符")
+}
+
+func TestNewWriter_UnsupportedFormat(t *testing.T) {
+	_, err := NewWriter("pdf", io.Discard, "")
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
@@ -0,0 +1,174 @@
+// Package wschunk 实现WebSocket大消息的分片传输
+//
+// 超过帧大小上限的消息被拆分为多个 chunk 消息发送，接收端按消息ID重组并校验SHA-256，
+// 平台和Agent两端共用同一套封包格式。
+package wschunk
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MsgType 分片消息类型
+const MsgType = "chunk"
+
+// Envelope 分片封包
+type Envelope struct {
+	MessageID string `json:"message_id"` // 同一原始消息的分片共享该ID
+	Type      string `json:"type"`       // 原始消息类型
+	Seq       int    `json:"seq"`        // 分片序号，从0开始
+	Total     int    `json:"total"`      // 分片总数
+	Checksum  string `json:"checksum"`   // 原始payload的SHA-256
+	Data      []byte `json:"data"`       // 分片内容（JSON中为base64）
+}
+
+// Split 将payload按maxChunk字节拆分为分片
+func Split(msgType string, payload []byte, maxChunk int) []Envelope {
+	if maxChunk <= 0 {
+		maxChunk = len(payload)
+	}
+
+	total := (len(payload) + maxChunk - 1) / maxChunk
+	if total == 0 {
+		total = 1
+	}
+
+	id := uuid.New().String()
+	checksum := Checksum(payload)
+	chunks := make([]Envelope, 0, total)
+	for seq := 0; seq < total; seq++ {
+		start := seq * maxChunk
+		end := start + maxChunk
+		if end > len(payload) {
+			end = len(payload)
+		}
+		chunks = append(chunks, Envelope{
+			MessageID: id,
+			Type:      msgType,
+			Seq:       seq,
+			Total:     total,
+			Checksum:  checksum,
+			Data:      payload[start:end],
+		})
+	}
+	return chunks
+}
+
+// Checksum 计算payload的SHA-256
+func Checksum(payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// Assembler 分片重组器，并发安全
+type Assembler struct {
+	mu       sync.Mutex
+	timeout  time.Duration
+	maxBytes int
+	pending  map[string]*partial
+	now      func() time.Time
+}
+
+// partial 重组中的消息
+type partial struct {
+	msgType   string
+	total     int
+	checksum  string
+	chunks    [][]byte
+	received  int
+	size      int
+	startedAt time.Time
+}
+
+// NewAssembler 创建重组器
+// timeout 为单条消息从首个分片到最后一个分片的最长等待时间，maxBytes 为重组后消息的大小上限，<=0表示不限制
+func NewAssembler(timeout time.Duration, maxBytes int) *Assembler {
+	return &Assembler{
+		timeout:  timeout,
+		maxBytes: maxBytes,
+		pending:  make(map[string]*partial),
+		now:      time.Now,
+	}
+}
+
+// Add 加入一个分片，消息完整时返回原始类型和payload
+func (a *Assembler) Add(env *Envelope) (string, []byte, bool, error) {
+	if env.MessageID == "" || env.Total <= 0 || env.Seq < 0 || env.Seq >= env.Total {
+		return "", nil, false, fmt.Errorf("分片封包无效: id=%s seq=%d total=%d", env.MessageID, env.Seq, env.Total)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.expireLocked()
+
+	p, ok := a.pending[env.MessageID]
+	if !ok {
+		p = &partial{
+			msgType:   env.Type,
+			total:     env.Total,
+			checksum:  env.Checksum,
+			chunks:    make([][]byte, env.Total),
+			startedAt: a.now(),
+		}
+		a.pending[env.MessageID] = p
+	}
+
+	if p.total != env.Total || p.checksum != env.Checksum {
+		delete(a.pending, env.MessageID)
+		return "", nil, false, fmt.Errorf("分片元数据不一致: %s", env.MessageID)
+	}
+
+	if p.chunks[env.Seq] == nil {
+		p.chunks[env.Seq] = append([]byte{}, env.Data...)
+		p.received++
+		p.size += len(env.Data)
+	}
+
+	if a.maxBytes > 0 && p.size > a.maxBytes {
+		delete(a.pending, env.MessageID)
+		return "", nil, false, fmt.Errorf("分片消息超过大小上限: %d > %d", p.size, a.maxBytes)
+	}
+
+	if p.received < p.total {
+		return "", nil, false, nil
+	}
+
+	delete(a.pending, env.MessageID)
+
+	payload := make([]byte, 0, p.size)
+	for _, chunk := range p.chunks {
+		payload = append(payload, chunk...)
+	}
+	if Checksum(payload) != p.checksum {
+		return "", nil, false, fmt.Errorf("分片消息校验失败: %s", env.MessageID)
+	}
+
+	return p.msgType, payload, true, nil
+}
+
+// Pending 返回重组中的消息数
+func (a *Assembler) Pending() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.expireLocked()
+	return len(a.pending)
+}
+
+// expireLocked 丢弃超时未完成的消息，调用方需持有锁
+func (a *Assembler) expireLocked() {
+	if a.timeout <= 0 {
+		return
+	}
+	now := a.now()
+	for id, p := range a.pending {
+		if now.Sub(p.startedAt) > a.timeout {
+			delete(a.pending, id)
+		}
+	}
+}
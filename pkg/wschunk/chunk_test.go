@@ -0,0 +1,73 @@
+package wschunk
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitAndAssemble(t *testing.T) {
+	payload := bytes.Repeat([]byte("0123456789"), 105)
+	chunks := Split("config_deploy", payload, 100)
+	require.Len(t, chunks, 11)
+	assert.Len(t, chunks[10].Data, 50)
+
+	a := NewAssembler(time.Minute, 0)
+
+	// 乱序且有重复分片
+	order := []int{3, 0, 10, 3, 1, 2, 4, 5, 6, 7, 8}
+	for _, i := range order {
+		_, _, done, err := a.Add(&chunks[i])
+		require.NoError(t, err)
+		assert.False(t, done)
+	}
+
+	msgType, got, done, err := a.Add(&chunks[9])
+	require.NoError(t, err)
+	assert.True(t, done)
+	assert.Equal(t, "config_deploy", msgType)
+	assert.Equal(t, payload, got)
+	assert.Equal(t, 0, a.Pending())
+}
+
+func TestAssembler_Timeout(t *testing.T) {
+	now := time.Now()
+	a := NewAssembler(time.Second, 0)
+	a.now = func() time.Time { return now }
+
+	chunks := Split("log_batch", []byte("abcdef"), 2)
+	_, _, _, err := a.Add(&chunks[0])
+	require.NoError(t, err)
+	assert.Equal(t, 1, a.Pending())
+
+	now = now.Add(2 * time.Second)
+	assert.Equal(t, 0, a.Pending())
+
+	// 超时后剩余分片开始新的重组，不会得到残缺消息
+	_, _, done, err := a.Add(&chunks[1])
+	require.NoError(t, err)
+	assert.False(t, done)
+}
+
+func TestAssembler_Rejects(t *testing.T) {
+	a := NewAssembler(time.Minute, 4)
+
+	chunks := Split("x", []byte("abcdef"), 3)
+	_, _, _, err := a.Add(&chunks[0])
+	require.NoError(t, err)
+	_, _, _, err = a.Add(&chunks[1])
+	assert.Error(t, err, "超过大小上限")
+
+	corrupt := Split("x", []byte("abcd"), 2)
+	corrupt[1].Data = []byte("zz")
+	a = NewAssembler(time.Minute, 0)
+	a.Add(&corrupt[0])
+	_, _, _, err = a.Add(&corrupt[1])
+	assert.Error(t, err, "校验失败")
+
+	_, _, _, err = a.Add(&Envelope{MessageID: "m", Seq: 2, Total: 2})
+	assert.Error(t, err)
+}
@@ -40,6 +40,8 @@ curl -s -X PUT $AUTH "$ES_HOST/logstash_configs" -H 'Content-Type: application/j
       "type": { "type": "keyword" },
       "content": { "type": "text" },
       "tags": { "type": "keyword" },
+      "destinations": { "type": "keyword" },
+      "team": { "type": "keyword" },
       "version": { "type": "integer" },
       "enabled": { "type": "boolean" },
       "test_status": { "type": "keyword" },
@@ -85,7 +87,89 @@ curl -s -X PUT $AUTH "$ES_HOST/logstash_agents" -H 'Content-Type: application/js
           "version": { "type": "integer" },
           "applied_at": { "type": "date" }
         }
-      }
+      },
+      "group": { "type": "keyword" },
+      "labels": { "type": "flattened" },
+      "settings": { "type": "object", "dynamic": false, "properties": { "labels": { "type": "flattened" } } },
+      "metadata": { "type": "flattened" },
+      "metadata_synced_at": { "type": "date" }
+    }
+  }
+}' | python3 -m json.tool
+
+# 创建Agent分组索引
+echo -e "\n${YELLOW}创建logstash_agent_groups索引...${NC}"
+curl -s -X PUT $AUTH "$ES_HOST/logstash_agent_groups" -H 'Content-Type: application/json' -d '{
+  "mappings": {
+    "properties": {
+      "name": { "type": "keyword" },
+      "description": { "type": "text" },
+      "settings": { "type": "object", "enabled": false },
+      "created_at": { "type": "date" },
+      "updated_at": { "type": "date" },
+      "created_by": { "type": "keyword" },
+      "updated_by": { "type": "keyword" }
+    }
+  }
+}' | python3 -m json.tool
+
+echo -e "\n${YELLOW}创建logstash_deployments索引...${NC}"
+curl -s -X PUT $AUTH "$ES_HOST/logstash_deployments" -H 'Content-Type: application/json' -d '{
+  "mappings": {
+    "properties": {
+      "id": { "type": "keyword" },
+      "config_id": { "type": "keyword" },
+      "config_name": { "type": "keyword" },
+      "config_version": { "type": "integer" },
+      "team": { "type": "keyword" },
+      "previous_version": { "type": "integer" },
+      "agent_ids": { "type": "keyword" },
+      "status": { "type": "keyword" },
+      "results": { "type": "object", "enabled": false },
+      "approvals": { "type": "object", "enabled": false },
+      "created_by": { "type": "keyword" },
+      "created_at": { "type": "date" },
+      "started_at": { "type": "date" },
+      "completed_at": { "type": "date" }
+    }
+  }
+}' | python3 -m json.tool
+
+echo -e "\n${YELLOW}创建logstash_incidents索引...${NC}"
+curl -s -X PUT $AUTH "$ES_HOST/logstash_incidents" -H 'Content-Type: application/json' -d '{
+  "mappings": {
+    "properties": {
+      "id": {"type": "keyword"},
+      "fingerprint": {"type": "keyword"},
+      "error_type": {"type": "keyword"},
+      "config_id": {"type": "keyword"},
+      "message": {"type": "text"},
+      "status": {"type": "keyword"},
+      "affected_agents": {"type": "keyword"},
+      "affected_count": {"type": "integer"},
+      "occurrences": {"type": "long"},
+      "timeline": {"type": "object", "enabled": false},
+      "first_seen": {"type": "date"},
+      "last_seen": {"type": "date"},
+      "resolved_at": {"type": "date"},
+      "resolved_by": {"type": "keyword"},
+      "resolution": {"type": "text"}
+    }
+  }
+}' | python3 -m json.tool
+
+echo -e "\n${YELLOW}创建logstash_users索引...${NC}"
+curl -s -X PUT $AUTH "$ES_HOST/logstash_users" -H 'Content-Type: application/json' -d '{
+  "mappings": {
+    "properties": {
+      "username": { "type": "keyword" },
+      "password_hash": { "type": "keyword", "index": false },
+      "role": { "type": "keyword" },
+      "disabled": { "type": "boolean" },
+      "last_login_at": { "type": "date" },
+      "created_at": { "type": "date" },
+      "updated_at": { "type": "date" },
+      "created_by": { "type": "keyword" }
     }
   }
 }' | python3 -m json.tool
@@ -0,0 +1,277 @@
+package fixtures
+
+import (
+	"fmt"
+	"time"
+
+	"logstash-platform/internal/platform/models"
+)
+
+// ConfigOption customizes a Config built by NewConfig
+type ConfigOption func(*models.Config)
+
+// NewConfig builds a valid filter config with sensible defaults
+func NewConfig(opts ...ConfigOption) *models.Config {
+	now := time.Now()
+	cfg := &models.Config{
+		ID:          GenerateTestID("config"),
+		Name:        "fixture-filter",
+		Description: "Fixture filter configuration",
+		Type:        models.ConfigTypeFilter,
+		Content:     "filter {\n  mutate {\n    add_field => { \"fixture\" => \"true\" }\n  }\n}",
+		Tags:        []string{"fixture"},
+		Version:     1,
+		Enabled:     true,
+		TestStatus:  models.TestStatusUntested,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		CreatedBy:   "admin",
+		UpdatedBy:   "admin",
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithConfigID sets the config ID
+func WithConfigID(id string) ConfigOption {
+	return func(c *models.Config) { c.ID = id }
+}
+
+// WithConfigName sets the config name
+func WithConfigName(name string) ConfigOption {
+	return func(c *models.Config) { c.Name = name }
+}
+
+// minimalPlugins maps each config type to a plugin that is valid in that section
+var minimalPlugins = map[models.ConfigType]string{
+	models.ConfigTypeInput:  "stdin {}",
+	models.ConfigTypeFilter: "mutate {}",
+	models.ConfigTypeOutput: "stdout {}",
+}
+
+// WithConfigType sets the config type and a matching minimal content block
+func WithConfigType(configType models.ConfigType) ConfigOption {
+	return func(c *models.Config) {
+		c.Type = configType
+		c.Content = fmt.Sprintf("%s {\n  %s\n}", configType, minimalPlugins[configType])
+	}
+}
+
+// WithConfigContent sets the config content
+func WithConfigContent(content string) ConfigOption {
+	return func(c *models.Config) { c.Content = content }
+}
+
+// WithConfigVersion sets the config version
+func WithConfigVersion(version int) ConfigOption {
+	return func(c *models.Config) { c.Version = version }
+}
+
+// WithConfigTags sets the config tags
+func WithConfigTags(tags ...string) ConfigOption {
+	return func(c *models.Config) { c.Tags = tags }
+}
+
+// WithConfigEnabled sets whether the config is enabled
+func WithConfigEnabled(enabled bool) ConfigOption {
+	return func(c *models.Config) { c.Enabled = enabled }
+}
+
+// WithConfigTestStatus sets the config test status
+func WithConfigTestStatus(status models.TestStatus) ConfigOption {
+	return func(c *models.Config) { c.TestStatus = status }
+}
+
+// AgentOption customizes an Agent built by NewAgent
+type AgentOption func(*models.Agent)
+
+// NewAgent builds an online agent with sensible defaults
+func NewAgent(opts ...AgentOption) *models.Agent {
+	agent := &models.Agent{
+		AgentID:         GenerateTestID("agent"),
+		Hostname:        "logstash-fixture",
+		IP:              "10.0.0.10",
+		LogstashVersion: "8.11.0",
+		Status:          "online",
+		LastHeartbeat:   time.Now(),
+		AppliedConfigs:  []models.AppliedConfig{},
+	}
+	for _, opt := range opts {
+		opt(agent)
+	}
+	return agent
+}
+
+// WithAgentID sets the agent ID
+func WithAgentID(id string) AgentOption {
+	return func(a *models.Agent) { a.AgentID = id }
+}
+
+// WithAgentHostname sets the agent hostname and IP
+func WithAgentHostname(hostname, ip string) AgentOption {
+	return func(a *models.Agent) {
+		a.Hostname = hostname
+		a.IP = ip
+	}
+}
+
+// WithAgentStatus sets the agent status and last heartbeat
+func WithAgentStatus(status string, lastHeartbeat time.Time) AgentOption {
+	return func(a *models.Agent) {
+		a.Status = status
+		a.LastHeartbeat = lastHeartbeat
+	}
+}
+
+// WithAgentGroup sets the agent group
+func WithAgentGroup(group string) AgentOption {
+	return func(a *models.Agent) { a.Group = group }
+}
+
+// WithAgentLabels sets the agent labels
+func WithAgentLabels(labels map[string]string) AgentOption {
+	return func(a *models.Agent) { a.Labels = labels }
+}
+
+// WithAppliedConfig appends an applied config to the agent
+func WithAppliedConfig(configID string, version int) AgentOption {
+	return func(a *models.Agent) {
+		a.AppliedConfigs = append(a.AppliedConfigs, models.AppliedConfig{
+			ConfigID:  configID,
+			Version:   version,
+			AppliedAt: time.Now(),
+		})
+	}
+}
+
+// DeploymentOption customizes a Deployment built by NewDeployment
+type DeploymentOption func(*models.Deployment)
+
+// NewDeployment builds a completed single-agent deployment with sensible defaults
+func NewDeployment(opts ...DeploymentOption) *models.Deployment {
+	started := time.Now().Add(-time.Minute)
+	finished := time.Now()
+	deployment := &models.Deployment{
+		ID:            GenerateTestID("deployment"),
+		ConfigID:      "test-filter-001",
+		ConfigName:    "fixture-filter",
+		ConfigVersion: 1,
+		AgentIDs:      []string{"agent-001"},
+		Status:        models.DeploymentStatusCompleted,
+		Results: []models.DeploymentResult{
+			{AgentID: "agent-001", Status: "success", StartedAt: &started, FinishedAt: &finished},
+		},
+		Approvals:   []models.DeploymentApproval{},
+		CreatedBy:   "admin",
+		CreatedAt:   started,
+		StartedAt:   &started,
+		CompletedAt: &finished,
+	}
+	for _, opt := range opts {
+		opt(deployment)
+	}
+	return deployment
+}
+
+// WithDeploymentConfig sets the deployed config and version transition
+func WithDeploymentConfig(configID string, previousVersion, version int) DeploymentOption {
+	return func(d *models.Deployment) {
+		d.ConfigID = configID
+		d.PreviousVersion = previousVersion
+		d.ConfigVersion = version
+	}
+}
+
+// WithDeploymentStatus sets the deployment status; unfinished statuses clear CompletedAt
+func WithDeploymentStatus(status models.DeploymentStatus) DeploymentOption {
+	return func(d *models.Deployment) {
+		d.Status = status
+		if !d.IsFinished() {
+			d.CompletedAt = nil
+		}
+	}
+}
+
+// WithAgentResults replaces the targets and gives every agent the same outcome
+func WithAgentResults(status string, agentIDs ...string) DeploymentOption {
+	return func(d *models.Deployment) {
+		now := time.Now()
+		d.AgentIDs = agentIDs
+		d.Results = make([]models.DeploymentResult, 0, len(agentIDs))
+		for _, id := range agentIDs {
+			d.Results = append(d.Results, models.DeploymentResult{
+				AgentID:    id,
+				Status:     status,
+				StartedAt:  d.StartedAt,
+				FinishedAt: &now,
+			})
+		}
+	}
+}
+
+// WithApproval appends an approval record
+func WithApproval(approver, decision string) DeploymentOption {
+	return func(d *models.Deployment) {
+		d.Approvals = append(d.Approvals, models.DeploymentApproval{
+			Approver:  approver,
+			Decision:  decision,
+			DecidedAt: d.CreatedAt,
+		})
+	}
+}
+
+// TestResultOption customizes a TestResult built by NewTestResult
+type TestResultOption func(*models.TestResult)
+
+// NewTestResult builds a completed test result with one passing sample
+func NewTestResult(opts ...TestResultOption) *models.TestResult {
+	end := time.Now()
+	result := &models.TestResult{
+		TestID:      GenerateTestID("test"),
+		Status:      "completed",
+		InputCount:  1,
+		OutputCount: 1,
+		Results: []models.TestOutput{
+			{
+				Input:  `{"message": "fixture"}`,
+				Output: map[string]interface{}{"message": "fixture"},
+			},
+		},
+		Errors:    []string{},
+		StartTime: end.Add(-2 * time.Second),
+		EndTime:   &end,
+	}
+	for _, opt := range opts {
+		opt(result)
+	}
+	return result
+}
+
+// WithTestResultStatus sets the test status; running results have no end time
+func WithTestResultStatus(status string) TestResultOption {
+	return func(r *models.TestResult) {
+		r.Status = status
+		if status == "running" {
+			r.EndTime = nil
+		}
+	}
+}
+
+// WithTestOutputs replaces the outputs and keeps the counters consistent
+func WithTestOutputs(outputs ...models.TestOutput) TestResultOption {
+	return func(r *models.TestResult) {
+		r.Results = outputs
+		r.InputCount = len(outputs)
+		r.OutputCount = 0
+		r.Errors = []string{}
+		for _, o := range outputs {
+			if o.Error != "" {
+				r.Errors = append(r.Errors, o.Error)
+				continue
+			}
+			r.OutputCount++
+		}
+	}
+}
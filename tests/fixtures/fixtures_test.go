@@ -1,12 +1,15 @@
 package fixtures
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"logstash-platform/internal/platform/models"
+	"logstash-platform/tests/mocks"
 )
 
 func TestGetValidFilterConfig(t *testing.T) {
@@ -224,4 +227,46 @@ func TestUpdateConfigRequests(t *testing.T) {
 	assert.NotEmpty(t, fullReq.Content)
 	assert.NotEmpty(t, fullReq.Description)
 	assert.NotEmpty(t, fullReq.Tags)
-}
\ No newline at end of file
+}
+func TestBuilders(t *testing.T) {
+	cfg := NewConfig(WithConfigID("cfg-1"), WithConfigType(models.ConfigTypeOutput), WithConfigVersion(3))
+	assert.Equal(t, "cfg-1", cfg.ID)
+	assert.Equal(t, models.ConfigTypeOutput, cfg.Type)
+	assert.Contains(t, cfg.Content, "output {")
+	assert.Contains(t, cfg.Content, "stdout {}")
+	assert.Equal(t, 3, cfg.Version)
+	assert.Contains(t, NewConfig(WithConfigType(models.ConfigTypeInput)).Content, "stdin {}")
+	assert.Contains(t, NewConfig(WithConfigType(models.ConfigTypeFilter)).Content, "mutate {}")
+
+	agent := NewAgent(WithAgentGroup("prod"), WithAppliedConfig("cfg-1", 3))
+	assert.Equal(t, "online", agent.Status)
+	assert.Equal(t, "prod", agent.Group)
+	assert.Len(t, agent.AppliedConfigs, 1)
+
+	deployment := NewDeployment(WithAgentResults("failed", "a1", "a2"), WithDeploymentStatus(models.DeploymentStatusRunning))
+	assert.Equal(t, []string{"a1", "a2"}, deployment.AgentIDs)
+	assert.Len(t, deployment.Results, 2)
+	assert.Nil(t, deployment.CompletedAt)
+
+	result := NewTestResult(WithTestOutputs(
+		models.TestOutput{Input: "ok"},
+		models.TestOutput{Input: "bad", Error: "parse failure"},
+	))
+	assert.Equal(t, 2, result.InputCount)
+	assert.Equal(t, 1, result.OutputCount)
+	assert.Equal(t, []string{"parse failure"}, result.Errors)
+}
+
+func TestSeed(t *testing.T) {
+	es := new(mocks.MockElasticsearchClient)
+	es.On("Index", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	summary, err := Seed(context.Background(), es, SeedOptions{Configs: 3, Agents: 2, Deployments: 2})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, summary.Configs)
+	assert.Equal(t, 6, summary.Histories)
+	assert.Equal(t, 2, summary.Agents)
+	assert.Equal(t, 2, summary.Deployments)
+	es.AssertCalled(t, "Index", mock.Anything, "logstash_agents", "seed-agent-001", mock.Anything)
+}
@@ -0,0 +1,118 @@
+package fixtures
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"logstash-platform/internal/platform/models"
+	"logstash-platform/pkg/elasticsearch"
+)
+
+// SeedOptions controls how much fixture data Seed writes
+type SeedOptions struct {
+	Configs     int
+	Agents      int
+	Deployments int
+}
+
+// SeedSummary reports what Seed wrote
+type SeedSummary struct {
+	Configs     int
+	Histories   int
+	Agents      int
+	Deployments int
+}
+
+// Seed populates a development Elasticsearch with realistic fixture data for UI work.
+// Documents use deterministic IDs so re-running the seed overwrites instead of duplicating.
+func Seed(ctx context.Context, es elasticsearch.ClientInterface, opts SeedOptions) (*SeedSummary, error) {
+	summary := &SeedSummary{}
+	types := []models.ConfigType{models.ConfigTypeInput, models.ConfigTypeFilter, models.ConfigTypeOutput}
+	statuses := []models.TestStatus{models.TestStatusPassed, models.TestStatusUntested, models.TestStatusFailed}
+
+	configs := make([]*models.Config, 0, opts.Configs)
+	for i := 0; i < opts.Configs; i++ {
+		cfg := NewConfig(
+			WithConfigID(fmt.Sprintf("seed-config-%03d", i+1)),
+			WithConfigName(fmt.Sprintf("seed-%s-%03d", types[i%len(types)], i+1)),
+			WithConfigType(types[i%len(types)]),
+			WithConfigVersion(i%3+1),
+			WithConfigTags("seed", string(types[i%len(types)])),
+			WithConfigTestStatus(statuses[i%len(statuses)]),
+		)
+		if err := es.Index(ctx, "logstash_configs", cfg.ID, cfg); err != nil {
+			return summary, fmt.Errorf("写入配置失败: %w", err)
+		}
+		summary.Configs++
+
+		for v := 1; v <= cfg.Version; v++ {
+			history := &models.ConfigHistory{
+				ID:         fmt.Sprintf("%s-v%d", cfg.ID, v),
+				ConfigID:   cfg.ID,
+				Version:    v,
+				Content:    cfg.Content,
+				ChangeType: "update",
+				ModifiedBy: cfg.UpdatedBy,
+				ModifiedAt: cfg.UpdatedAt.Add(time.Duration(v-cfg.Version) * time.Hour),
+			}
+			if v == 1 {
+				history.ChangeType = "create"
+			}
+			if err := es.Index(ctx, "logstash_config_history", history.ID, history); err != nil {
+				return summary, fmt.Errorf("写入配置历史失败: %w", err)
+			}
+			summary.Histories++
+		}
+		configs = append(configs, cfg)
+	}
+
+	agentIDs := make([]string, 0, opts.Agents)
+	for i := 0; i < opts.Agents; i++ {
+		status, lastSeen := "online", time.Now()
+		if i%5 == 4 {
+			status, lastSeen = "offline", time.Now().Add(-time.Hour)
+		}
+		agent := NewAgent(
+			WithAgentID(fmt.Sprintf("seed-agent-%03d", i+1)),
+			WithAgentHostname(fmt.Sprintf("logstash-%03d", i+1), fmt.Sprintf("10.0.%d.%d", i/250, i%250+1)),
+			WithAgentStatus(status, lastSeen),
+			WithAgentLabels(map[string]string{"env": []string{"prod", "staging"}[i%2]}),
+		)
+		for _, cfg := range configs {
+			if len(agent.AppliedConfigs) < 3 {
+				agent.AppliedConfigs = append(agent.AppliedConfigs, models.AppliedConfig{
+					ConfigID: cfg.ID, Version: cfg.Version, AppliedAt: lastSeen,
+				})
+			}
+		}
+		if err := es.Index(ctx, "logstash_agents", agent.AgentID, agent); err != nil {
+			return summary, fmt.Errorf("写入Agent失败: %w", err)
+		}
+		agentIDs = append(agentIDs, agent.AgentID)
+		summary.Agents++
+	}
+
+	for i := 0; i < opts.Deployments && len(configs) > 0 && len(agentIDs) > 0; i++ {
+		cfg := configs[i%len(configs)]
+		outcome := "success"
+		status := models.DeploymentStatusCompleted
+		if i%4 == 3 {
+			outcome, status = "failed", models.DeploymentStatusFailed
+		}
+		deployment := NewDeployment(
+			WithDeploymentConfig(cfg.ID, cfg.Version-1, cfg.Version),
+			WithAgentResults(outcome, agentIDs[:i%len(agentIDs)+1]...),
+			WithDeploymentStatus(status),
+			WithApproval("reviewer", "approved"),
+		)
+		deployment.ID = fmt.Sprintf("seed-deployment-%03d", i+1)
+		deployment.ConfigName = cfg.Name
+		if err := es.Index(ctx, "logstash_deployments", deployment.ID, deployment); err != nil {
+			return summary, fmt.Errorf("写入部署记录失败: %w", err)
+		}
+		summary.Deployments++
+	}
+
+	return summary, nil
+}
@@ -0,0 +1,81 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"logstash-platform/internal/platform/models"
+)
+
+// MockAgentRepository is a mock implementation of AgentRepository
+type MockAgentRepository struct {
+	mock.Mock
+}
+
+// Save mocks the Save method
+func (m *MockAgentRepository) Save(ctx context.Context, agent *models.Agent) error {
+	args := m.Called(ctx, agent)
+	return args.Error(0)
+}
+
+// GetByID mocks the GetByID method
+func (m *MockAgentRepository) GetByID(ctx context.Context, agentID string) (*models.Agent, error) {
+	args := m.Called(ctx, agentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Agent), args.Error(1)
+}
+
+// Delete mocks the Delete method
+func (m *MockAgentRepository) Delete(ctx context.Context, agentID string) error {
+	args := m.Called(ctx, agentID)
+	return args.Error(0)
+}
+
+// ListByGroup mocks the ListByGroup method
+func (m *MockAgentRepository) ListByGroup(ctx context.Context, group string) ([]*models.Agent, error) {
+	args := m.Called(ctx, group)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Agent), args.Error(1)
+}
+
+// ListByLabels mocks the ListByLabels method
+func (m *MockAgentRepository) ListByLabels(ctx context.Context, labels map[string]string) ([]*models.Agent, error) {
+	args := m.Called(ctx, labels)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Agent), args.Error(1)
+}
+
+// ListByAppliedConfig mocks the ListByAppliedConfig method
+func (m *MockAgentRepository) ListByAppliedConfig(ctx context.Context, configID string) ([]*models.Agent, error) {
+	args := m.Called(ctx, configID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Agent), args.Error(1)
+}
+
+// List mocks the List method
+func (m *MockAgentRepository) List(ctx context.Context, req *models.AgentListRequest) (*models.AgentListResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AgentListResponse), args.Error(1)
+}
+
+// MockMessagePublisher is a mock implementation of MessagePublisher
+type MockMessagePublisher struct {
+	mock.Mock
+}
+
+// Publish mocks the Publish method
+func (m *MockMessagePublisher) Publish(agentID, msgType string, payload interface{}) error {
+	args := m.Called(agentID, msgType, payload)
+	return args.Error(0)
+}
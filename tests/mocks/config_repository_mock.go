@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"context"
+	"time"
 	"logstash-platform/internal/platform/models"
 	"github.com/stretchr/testify/mock"
 )
@@ -23,6 +24,12 @@ func (m *MockConfigRepository) Update(ctx context.Context, config *models.Config
 	return args.Error(0)
 }
 
+// UpdateTestStatus mocks the UpdateTestStatus method
+func (m *MockConfigRepository) UpdateTestStatus(ctx context.Context, id string, version int, status models.TestStatus, testedAt time.Time) (bool, error) {
+	args := m.Called(ctx, id, version, status, testedAt)
+	return args.Bool(0), args.Error(1)
+}
+
 // Delete mocks the Delete method
 func (m *MockConfigRepository) Delete(ctx context.Context, id string) error {
 	args := m.Called(ctx, id)
@@ -47,6 +54,24 @@ func (m *MockConfigRepository) List(ctx context.Context, req *models.ConfigListR
 	return args.Get(0).(*models.ConfigListResponse), args.Error(1)
 }
 
+// Search mocks the Search method
+func (m *MockConfigRepository) Search(ctx context.Context, req *models.ConfigSearchRequest) (*models.ConfigSearchResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ConfigSearchResponse), args.Error(1)
+}
+
+// Stats mocks the Stats method
+func (m *MockConfigRepository) Stats(ctx context.Context, req *models.ConfigStatsRequest) (*models.ConfigStats, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ConfigStats), args.Error(1)
+}
+
 // SaveHistory mocks the SaveHistory method
 func (m *MockConfigRepository) SaveHistory(ctx context.Context, history *models.ConfigHistory) error {
 	args := m.Called(ctx, history)
@@ -60,4 +85,12 @@ func (m *MockConfigRepository) GetHistory(ctx context.Context, configID string)
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]*models.ConfigHistory), args.Error(1)
-}
\ No newline at end of file
+}
+// GetHistoryVersion mocks the GetHistoryVersion method
+func (m *MockConfigRepository) GetHistoryVersion(ctx context.Context, configID string, version int) (*models.ConfigHistory, error) {
+	args := m.Called(ctx, configID, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ConfigHistory), args.Error(1)
+}
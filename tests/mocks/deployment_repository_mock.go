@@ -0,0 +1,43 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"logstash-platform/internal/platform/models"
+)
+
+// MockDeploymentRepository is a mock implementation of DeploymentRepository
+type MockDeploymentRepository struct {
+	mock.Mock
+}
+
+// Create mocks the Create method
+func (m *MockDeploymentRepository) Create(ctx context.Context, deployment *models.Deployment) error {
+	args := m.Called(ctx, deployment)
+	return args.Error(0)
+}
+
+// Update mocks the Update method
+func (m *MockDeploymentRepository) Update(ctx context.Context, deployment *models.Deployment) error {
+	args := m.Called(ctx, deployment)
+	return args.Error(0)
+}
+
+// GetByID mocks the GetByID method
+func (m *MockDeploymentRepository) GetByID(ctx context.Context, id string) (*models.Deployment, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Deployment), args.Error(1)
+}
+
+// List mocks the List method
+func (m *MockDeploymentRepository) List(ctx context.Context, req *models.DeploymentListRequest) ([]*models.Deployment, int64, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]*models.Deployment), args.Get(1).(int64), args.Error(2)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/stretchr/testify/mock"
+	"logstash-platform/pkg/elasticsearch"
 )
 
 // MockElasticsearchClient 是 Elasticsearch 客户端的 mock 实现
@@ -11,10 +12,22 @@ type MockElasticsearchClient struct {
 	mock.Mock
 }
 
-// InitializeIndices 初始化所需的索引
-func (m *MockElasticsearchClient) InitializeIndices(ctx context.Context) error {
+// Migrate 执行尚未执行的索引迁移
+func (m *MockElasticsearchClient) Migrate(ctx context.Context) (*elasticsearch.MigrationStatus, error) {
 	args := m.Called(ctx)
-	return args.Error(0)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*elasticsearch.MigrationStatus), args.Error(1)
+}
+
+// MigrationStatus 获取索引迁移的执行状态
+func (m *MockElasticsearchClient) MigrationStatus(ctx context.Context) (*elasticsearch.MigrationStatus, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*elasticsearch.MigrationStatus), args.Error(1)
 }
 
 // IndexExists 检查索引是否存在
@@ -51,4 +64,19 @@ func (m *MockElasticsearchClient) Search(ctx context.Context, index string, quer
 func (m *MockElasticsearchClient) Delete(ctx context.Context, index, id string) error {
 	args := m.Called(ctx, index, id)
 	return args.Error(0)
-}
\ No newline at end of file
+}
+
+// GetVersioned 获取文档及其版本
+func (m *MockElasticsearchClient) GetVersioned(ctx context.Context, index, id string, result interface{}) (*elasticsearch.DocVersion, error) {
+	args := m.Called(ctx, index, id, result)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*elasticsearch.DocVersion), args.Error(1)
+}
+
+// IndexIfVersion 按版本条件索引文档
+func (m *MockElasticsearchClient) IndexIfVersion(ctx context.Context, index, id string, doc interface{}, version *elasticsearch.DocVersion) error {
+	args := m.Called(ctx, index, id, doc, version)
+	return args.Error(0)
+}
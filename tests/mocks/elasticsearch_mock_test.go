@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"logstash-platform/pkg/elasticsearch"
 )
 
 // TestMockElasticsearchClient 测试 mock 实现是否正常工作
@@ -13,12 +14,13 @@ func TestMockElasticsearchClient(t *testing.T) {
 	ctx := context.Background()
 	mockClient := new(MockElasticsearchClient)
 
-	// 测试 InitializeIndices
-	t.Run("InitializeIndices", func(t *testing.T) {
-		mockClient.On("InitializeIndices", ctx).Return(nil).Once()
+	// 测试 Migrate
+	t.Run("Migrate", func(t *testing.T) {
+		mockClient.On("Migrate", ctx).Return(&elasticsearch.MigrationStatus{Current: 2, Latest: 2}, nil).Once()
 		
-		err := mockClient.InitializeIndices(ctx)
+		status, err := mockClient.Migrate(ctx)
 		assert.NoError(t, err)
+		assert.False(t, status.Pending())
 		mockClient.AssertExpectations(t)
 	})
 
@@ -0,0 +1,49 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"logstash-platform/internal/platform/models"
+)
+
+// MockGroupRepository is a mock implementation of GroupRepository
+type MockGroupRepository struct {
+	mock.Mock
+}
+
+// Create mocks the Create method
+func (m *MockGroupRepository) Create(ctx context.Context, group *models.AgentGroup) error {
+	args := m.Called(ctx, group)
+	return args.Error(0)
+}
+
+// Update mocks the Update method
+func (m *MockGroupRepository) Update(ctx context.Context, group *models.AgentGroup) error {
+	args := m.Called(ctx, group)
+	return args.Error(0)
+}
+
+// Delete mocks the Delete method
+func (m *MockGroupRepository) Delete(ctx context.Context, name string) error {
+	args := m.Called(ctx, name)
+	return args.Error(0)
+}
+
+// GetByName mocks the GetByName method
+func (m *MockGroupRepository) GetByName(ctx context.Context, name string) (*models.AgentGroup, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AgentGroup), args.Error(1)
+}
+
+// List mocks the List method
+func (m *MockGroupRepository) List(ctx context.Context) ([]*models.AgentGroup, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.AgentGroup), args.Error(1)
+}